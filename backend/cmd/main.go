@@ -7,6 +7,7 @@ import (
 	"neobase-ai/config"
 	"neobase-ai/internal/apis/routes"
 	"neobase-ai/internal/di"
+	"neobase-ai/internal/eval"
 	"neobase-ai/internal/middleware"
 	"net/http"
 	"os"
@@ -28,6 +29,11 @@ func main() {
 	// Initialize dependencies
 	di.Initialize()
 
+	// The eval mode scores the LLM on a corpus of questions instead of serving the API, e.g. `neobase eval -corpus eval/corpus.example.json`
+	if len(os.Args) > 1 && os.Args[1] == "eval" {
+		os.Exit(eval.RunCLI(os.Args[2:]))
+	}
+
 	// Setup Gin
 	ginApp := gin.New() // Use gin.New() instead of gin.Default()
 
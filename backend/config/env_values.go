@@ -5,6 +5,7 @@ import (
 	"neobase-ai/internal/constants"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -35,6 +36,11 @@ type Environment struct {
 	MongoURI          string
 	MongoDatabaseName string
 
+	// MongoDB query configs, the limits of the documents a find or an aggregate keeps in memory (0 uses the default)
+	MongoDBCursorBatchSize    int
+	MongoDBMaxResultDocuments int
+	MongoDBMaxResultMB        int
+
 	// Redis configs
 	RedisHost     string
 	RedisPort     string
@@ -47,11 +53,130 @@ type Environment struct {
 	OpenAIMaxCompletionTokens int
 	OpenAITemperature         float64
 
+	// Azure OpenAI configs, the model is the name of the deployment
+	AzureOpenAIAPIKey              string
+	AzureOpenAIEndpoint            string
+	AzureOpenAIDeployment          string
+	AzureOpenAIAPIVersion          string
+	AzureOpenAIMaxCompletionTokens int
+	AzureOpenAITemperature         float64
+
 	// Gemini configs
 	GeminiAPIKey              string
 	GeminiModel               string
 	GeminiMaxCompletionTokens int
 	GeminiTemperature         float64
+	GeminiSafetyThreshold     string
+
+	// Ollama configs
+	OllamaBaseURL             string
+	OllamaModel               string
+	OllamaMaxCompletionTokens int
+	OllamaTemperature         float64
+
+	// Claude configs
+	ClaudeAPIKey              string
+	ClaudeModel               string
+	ClaudeMaxCompletionTokens int
+	ClaudeTemperature         float64
+
+	// Bedrock configs, the credentials are the ones of the AWS SDKs
+	AWSRegion                  string
+	AWSAccessKeyID             string
+	AWSSecretAccessKey         string
+	AWSSessionToken            string
+	BedrockModel               string
+	BedrockMaxCompletionTokens int
+	BedrockTemperature         float64
+
+	// OpenAI-compatible configs, for servers like vLLM, LM Studio, Together or Groq
+	OpenAICompatibleBaseURL             string
+	OpenAICompatibleAPIKey              string
+	OpenAICompatibleModel               string
+	OpenAICompatibleMaxCompletionTokens int
+	OpenAICompatibleTemperature         float64
+	OpenAICompatibleResponseFormat      string
+	OpenAICompatibleToolCalling         bool
+
+	// LLM failover configs, the providers are tried in order when one is rate limited, fails or times out
+	LLMFailoverProviders        []string
+	LLMFailoverFailureThreshold int
+	LLMFailoverCooldownSeconds  int
+
+	// Tokens a user can use per month, 0 for no quota
+	LLMMonthlyTokenQuota int
+
+	// Schema retrieval configs, databases with many tables only send the tables relevant to the request, disabled without a provider
+	SchemaEmbeddingProvider string
+	SchemaEmbeddingModel    string
+	SchemaRAGMinTables      int
+	SchemaRAGTopK           int
+
+	// Minutes the LLM responses to the first question of a chat are cached for, shared by the chats with the same schema, 0 to disable
+	LLMResponseCacheTTLMinutes int
+
+	// Rounds of tool calls (schema lookups, explains & read-only queries) the LLM can make before answering, 0 to disable
+	LLMMaxToolRounds int
+
+	// Query job configs, the queries of the jobs run in the background on a pool of workers
+	QueryJobWorkers        int
+	QueryJobQueueSize      int
+	QueryJobTimeoutMinutes int
+
+	// Query timeout configs, the default timeout of the queries executed from a chat & the maximum a connection or a request can set
+	QueryTimeoutSeconds    int
+	MaxQueryTimeoutSeconds int
+
+	// Result row configs, the rows of a result shown in a chat & read per page, and the rows read from a result at most. A
+	// connection or a request can set its own, up to the maximum.
+	ResultDisplayRows int
+	MaxResultRows     int
+	// LIMIT appended to the SELECTs & finds without one, at most the rows read from a result, 0 to disable. A request can
+	// execute a query as a full scan to skip it.
+	AutoLimitRows int
+
+	// Result spill configs, the results above the threshold or beyond the row limit are written whole to a file of the storage
+	// (local, s3 or gcs) & shown with a preview & a signed download link, disabled without a storage
+	ResultSpillStorage         string
+	ResultSpillThresholdMB     int
+	ResultSpillLinkTTLMinutes  int
+	ResultSpillLocalDir        string
+	ResultSpillPublicURL       string // URL of the server the links of the local files point to
+	ResultSpillBucket          string
+	ResultSpillRegion          string
+	ResultSpillEndpoint        string // S3-compatible services, e.g. MinIO, GCS by default for gcs
+	ResultSpillAccessKeyID     string
+	ResultSpillSecretAccessKey string
+
+	// Queries a connection runs at once, the others wait in a queue, a connection can set its own
+	MaxConcurrentQueries int
+
+	// Query retry configs, the executions failing on a deadlock, a serialization failure or a dropped connection are retried
+	// with an exponential backoff
+	QueryRetryMaxAttempts int
+	QueryRetryBaseDelayMs int
+	QueryRetryMaxDelayMs  int
+
+	// Transaction session configs, a transaction kept open across messages is rolled back once unused or open for too long
+	TransactionSessionIdleSeconds int
+	TransactionSessionMaxSeconds  int
+
+	// Rows a generated query is estimated to read above which it is not executed automatically but waits for the user, 0 to disable
+	AutoExecuteMaxEstimatedRows int
+
+	// Scheduled query configs, the due schedules are polled & each run is delayed by a random jitter so the schedules sharing an
+	// expression don't hit a database at once. Runs beyond the limit per connection wait for the next poll.
+	ScheduledQueryPollSeconds          int
+	ScheduledQueryMaxJitterSeconds     int
+	ScheduledQueryMaxRunsPerConnection int
+	ScheduledQueryAllowPrivateWebhooks bool // Webhooks may call loopback & private addresses, e.g. the services of a self-hosted network
+
+	// SMTP server the outcome of scheduled queries is emailed through, STARTTLS is used when the server offers it
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
 }
 
 var Env Environment
@@ -90,6 +215,11 @@ func LoadEnv() error {
 	Env.RedisUsername = getRequiredEnv("NEOBASE_REDIS_USERNAME", "neobase")
 	Env.RedisPassword = getRequiredEnv("NEOBASE_REDIS_PASSWORD", "neobase")
 
+	// MongoDB query configs
+	Env.MongoDBCursorBatchSize = getIntEnvWithDefault("MONGODB_CURSOR_BATCH_SIZE", 0)       // 1000 documents default
+	Env.MongoDBMaxResultDocuments = getIntEnvWithDefault("MONGODB_MAX_RESULT_DOCUMENTS", 0) // 10000 documents default
+	Env.MongoDBMaxResultMB = getIntEnvWithDefault("MONGODB_MAX_RESULT_MB", 0)               // 32 MB default
+
 	// Example DB For Development Environment
 	Env.ExampleDatabaseType = getRequiredEnv("EXAMPLE_DB_TYPE", "postgres")
 	Env.ExampleDatabaseHost = getRequiredEnv("EXAMPLE_DB_HOST", "localhost")
@@ -107,11 +237,124 @@ func LoadEnv() error {
 	Env.OpenAIMaxCompletionTokens = getIntEnvWithDefault("OPENAI_MAX_COMPLETION_TOKENS", constants.OpenAIMaxCompletionTokens)
 	Env.OpenAITemperature = getFloatEnvWithDefault("OPENAI_TEMPERATURE", constants.OpenAITemperature)
 
+	// Azure OpenAI configs
+	Env.AzureOpenAIAPIKey = getRequiredEnv("AZURE_OPENAI_API_KEY", "")
+	Env.AzureOpenAIEndpoint = getEnvWithDefault("AZURE_OPENAI_ENDPOINT", "")
+	Env.AzureOpenAIDeployment = getEnvWithDefault("AZURE_OPENAI_DEPLOYMENT", "")
+	Env.AzureOpenAIAPIVersion = getEnvWithDefault("AZURE_OPENAI_API_VERSION", constants.AzureOpenAIAPIVersion)
+	Env.AzureOpenAIMaxCompletionTokens = getIntEnvWithDefault("AZURE_OPENAI_MAX_COMPLETION_TOKENS", constants.OpenAIMaxCompletionTokens)
+	Env.AzureOpenAITemperature = getFloatEnvWithDefault("AZURE_OPENAI_TEMPERATURE", constants.OpenAITemperature)
+
 	// Gemini configs
 	Env.GeminiAPIKey = getRequiredEnv("GEMINI_API_KEY", "")
 	Env.GeminiModel = getEnvWithDefault("GEMINI_MODEL", constants.GeminiModel)
 	Env.GeminiMaxCompletionTokens = getIntEnvWithDefault("GEMINI_MAX_COMPLETION_TOKENS", constants.GeminiMaxCompletionTokens)
 	Env.GeminiTemperature = getFloatEnvWithDefault("GEMINI_TEMPERATURE", constants.GeminiTemperature)
+	Env.GeminiSafetyThreshold = getEnvWithDefault("GEMINI_SAFETY_THRESHOLD", constants.GeminiSafetyThreshold)
+
+	// Ollama configs
+	Env.OllamaBaseURL = getEnvWithDefault("OLLAMA_BASE_URL", constants.OllamaBaseURL)
+	Env.OllamaModel = getEnvWithDefault("OLLAMA_MODEL", constants.OllamaModel)
+	Env.OllamaMaxCompletionTokens = getIntEnvWithDefault("OLLAMA_MAX_COMPLETION_TOKENS", constants.OllamaMaxCompletionTokens)
+	Env.OllamaTemperature = getFloatEnvWithDefault("OLLAMA_TEMPERATURE", constants.OllamaTemperature)
+
+	// Claude configs
+	Env.ClaudeAPIKey = getRequiredEnv("CLAUDE_API_KEY", "")
+	Env.ClaudeModel = getEnvWithDefault("CLAUDE_MODEL", constants.ClaudeModel)
+	Env.ClaudeMaxCompletionTokens = getIntEnvWithDefault("CLAUDE_MAX_COMPLETION_TOKENS", constants.ClaudeMaxCompletionTokens)
+	Env.ClaudeTemperature = getFloatEnvWithDefault("CLAUDE_TEMPERATURE", constants.ClaudeTemperature)
+
+	// Bedrock configs
+	Env.AWSRegion = getEnvWithDefault("AWS_REGION", constants.BedrockRegion)
+	Env.AWSAccessKeyID = getEnvWithDefault("AWS_ACCESS_KEY_ID", "")
+	Env.AWSSecretAccessKey = getEnvWithDefault("AWS_SECRET_ACCESS_KEY", "")
+	Env.AWSSessionToken = getEnvWithDefault("AWS_SESSION_TOKEN", "")
+	Env.BedrockModel = getEnvWithDefault("BEDROCK_MODEL", constants.BedrockModel)
+	Env.BedrockMaxCompletionTokens = getIntEnvWithDefault("BEDROCK_MAX_COMPLETION_TOKENS", constants.BedrockMaxCompletionTokens)
+	Env.BedrockTemperature = getFloatEnvWithDefault("BEDROCK_TEMPERATURE", constants.BedrockTemperature)
+
+	// OpenAI-compatible configs
+	Env.OpenAICompatibleBaseURL = getEnvWithDefault("OPENAI_COMPATIBLE_BASE_URL", "")
+	Env.OpenAICompatibleAPIKey = getEnvWithDefault("OPENAI_COMPATIBLE_API_KEY", "")
+	Env.OpenAICompatibleModel = getEnvWithDefault("OPENAI_COMPATIBLE_MODEL", "")
+	Env.OpenAICompatibleMaxCompletionTokens = getIntEnvWithDefault("OPENAI_COMPATIBLE_MAX_COMPLETION_TOKENS", constants.OpenAICompatibleMaxCompletionTokens)
+	Env.OpenAICompatibleTemperature = getFloatEnvWithDefault("OPENAI_COMPATIBLE_TEMPERATURE", constants.OpenAICompatibleTemperature)
+	Env.OpenAICompatibleResponseFormat = getEnvWithDefault("OPENAI_COMPATIBLE_RESPONSE_FORMAT", constants.OpenAICompatibleResponseFormat)
+	Env.OpenAICompatibleToolCalling = getEnvWithDefault("OPENAI_COMPATIBLE_TOOL_CALLING", "true") == "true"
+
+	// LLM failover configs
+	Env.LLMFailoverProviders = getListEnv("LLM_FAILOVER_PROVIDERS")
+	Env.LLMFailoverFailureThreshold = getIntEnvWithDefault("LLM_FAILOVER_FAILURE_THRESHOLD", 3)
+	Env.LLMFailoverCooldownSeconds = getIntEnvWithDefault("LLM_FAILOVER_COOLDOWN_SECONDS", 60)
+
+	// LLM usage configs
+	Env.LLMMonthlyTokenQuota = getIntEnvWithDefault("LLM_MONTHLY_TOKEN_QUOTA", 0)
+
+	// Schema retrieval configs
+	Env.SchemaEmbeddingProvider = getEnvWithDefault("SCHEMA_EMBEDDING_PROVIDER", "")
+	Env.SchemaEmbeddingModel = getEnvWithDefault("SCHEMA_EMBEDDING_MODEL", "")
+	Env.SchemaRAGMinTables = getIntEnvWithDefault("SCHEMA_RAG_MIN_TABLES", 50)
+	Env.SchemaRAGTopK = getIntEnvWithDefault("SCHEMA_RAG_TOP_K", 15)
+	Env.LLMResponseCacheTTLMinutes = getIntEnvWithDefault("LLM_RESPONSE_CACHE_TTL_MINUTES", 0)
+	Env.LLMMaxToolRounds = getIntEnvWithDefault("LLM_MAX_TOOL_ROUNDS", 3)
+
+	// Query job configs
+	Env.QueryJobWorkers = getIntEnvWithDefault("QUERY_JOB_WORKERS", 4)
+	Env.QueryJobQueueSize = getIntEnvWithDefault("QUERY_JOB_QUEUE_SIZE", 100)
+	Env.QueryJobTimeoutMinutes = getIntEnvWithDefault("QUERY_JOB_TIMEOUT_MINUTES", 60)
+
+	// Query timeout configs
+	Env.QueryTimeoutSeconds = getIntEnvWithDefault("QUERY_TIMEOUT_SECONDS", 60)
+	Env.MaxQueryTimeoutSeconds = getIntEnvWithDefault("MAX_QUERY_TIMEOUT_SECONDS", 600)
+	Env.AutoExecuteMaxEstimatedRows = getIntEnvWithDefault("AUTO_EXECUTE_MAX_ESTIMATED_ROWS", 1000000)
+
+	// Result row configs
+	Env.ResultDisplayRows = getIntEnvWithDefault("RESULT_DISPLAY_ROWS", 50)
+	Env.MaxResultRows = getIntEnvWithDefault("MAX_RESULT_ROWS", 10000)
+	Env.AutoLimitRows = getIntEnvWithDefault("AUTO_LIMIT_ROWS", 10000)
+
+	// Result spill configs, the S3 credentials & region default to the AWS ones
+	Env.ResultSpillStorage = getEnvWithDefault("RESULT_SPILL_STORAGE", "")
+	Env.ResultSpillThresholdMB = getIntEnvWithDefault("RESULT_SPILL_THRESHOLD_MB", 5)
+	Env.ResultSpillLinkTTLMinutes = getIntEnvWithDefault("RESULT_SPILL_LINK_TTL_MINUTES", 60)
+	Env.ResultSpillLocalDir = getEnvWithDefault("RESULT_SPILL_LOCAL_DIR", "./results")
+	Env.ResultSpillPublicURL = getEnvWithDefault("RESULT_SPILL_PUBLIC_URL", "http://localhost:"+Env.Port)
+	Env.ResultSpillBucket = getEnvWithDefault("RESULT_SPILL_BUCKET", "")
+	Env.ResultSpillEndpoint = getEnvWithDefault("RESULT_SPILL_ENDPOINT", "")
+	if Env.ResultSpillStorage == "s3" {
+		Env.ResultSpillRegion = getEnvWithDefault("RESULT_SPILL_REGION", Env.AWSRegion)
+		Env.ResultSpillAccessKeyID = getEnvWithDefault("RESULT_SPILL_ACCESS_KEY_ID", Env.AWSAccessKeyID)
+		Env.ResultSpillSecretAccessKey = getEnvWithDefault("RESULT_SPILL_SECRET_ACCESS_KEY", Env.AWSSecretAccessKey)
+	} else {
+		Env.ResultSpillRegion = getEnvWithDefault("RESULT_SPILL_REGION", "")
+		Env.ResultSpillAccessKeyID = getEnvWithDefault("RESULT_SPILL_ACCESS_KEY_ID", "")
+		Env.ResultSpillSecretAccessKey = getEnvWithDefault("RESULT_SPILL_SECRET_ACCESS_KEY", "")
+	}
+
+	// Query concurrency configs
+	Env.MaxConcurrentQueries = getIntEnvWithDefault("MAX_CONCURRENT_QUERIES", 5)
+
+	// Query retry configs
+	Env.QueryRetryMaxAttempts = getIntEnvWithDefault("QUERY_RETRY_MAX_ATTEMPTS", 3)
+	Env.QueryRetryBaseDelayMs = getIntEnvWithDefault("QUERY_RETRY_BASE_DELAY_MS", 200)
+	Env.QueryRetryMaxDelayMs = getIntEnvWithDefault("QUERY_RETRY_MAX_DELAY_MS", 2000)
+
+	// Transaction session configs
+	Env.TransactionSessionIdleSeconds = getIntEnvWithDefault("TRANSACTION_SESSION_IDLE_SECONDS", 300)
+	Env.TransactionSessionMaxSeconds = getIntEnvWithDefault("TRANSACTION_SESSION_MAX_SECONDS", 1800)
+
+	// Scheduled query configs
+	Env.ScheduledQueryPollSeconds = getIntEnvWithDefault("SCHEDULED_QUERY_POLL_SECONDS", 30)
+	Env.ScheduledQueryMaxJitterSeconds = getIntEnvWithDefault("SCHEDULED_QUERY_MAX_JITTER_SECONDS", 30)
+	Env.ScheduledQueryMaxRunsPerConnection = getIntEnvWithDefault("SCHEDULED_QUERY_MAX_RUNS_PER_CONNECTION", 2)
+	Env.ScheduledQueryAllowPrivateWebhooks = getEnvWithDefault("SCHEDULED_QUERY_ALLOW_PRIVATE_WEBHOOKS", "false") == "true"
+
+	// SMTP configs
+	Env.SMTPHost = getEnvWithDefault("SMTP_HOST", "")
+	Env.SMTPPort = getEnvWithDefault("SMTP_PORT", "587")
+	Env.SMTPUsername = getEnvWithDefault("SMTP_USERNAME", "")
+	Env.SMTPPassword = getEnvWithDefault("SMTP_PASSWORD", "")
+	Env.SMTPFrom = getEnvWithDefault("SMTP_FROM", Env.SMTPUsername)
 
 	return validateConfig()
 }
@@ -145,6 +388,17 @@ func getIntEnvWithDefault(key string, defaultValue int) int {
 	return value
 }
 
+// getListEnv splits a comma separated value, empty items are ignored
+func getListEnv(key string) []string {
+	values := make([]string, 0)
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 func getFloatEnvWithDefault(key string, defaultValue float64) float64 {
 	strValue := os.Getenv(key)
 	if strValue == "" {
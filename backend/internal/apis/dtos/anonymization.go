@@ -0,0 +1,80 @@
+package dtos
+
+type MaskingRuleRequest struct {
+	Column   string  `json:"column" binding:"required"`
+	Strategy string  `json:"strategy" binding:"required,oneof=keep hash redact null fixed partial fake_email fake_name"`
+	Value    *string `json:"value,omitempty"` // Required for the fixed strategy
+}
+
+type AnonymizationTableRequest struct {
+	Table string               `json:"table" binding:"required"`
+	Rules []MaskingRuleRequest `json:"rules" binding:"omitempty,dive"`
+}
+
+// CreateAnonymizationJobRequest clones tables of the source chat's database into the target chat's database with their columns masked
+type CreateAnonymizationJobRequest struct {
+	SourceChatID string                      `json:"source_chat_id" binding:"required"`
+	TargetChatID string                      `json:"target_chat_id" binding:"required"`
+	Tables       []AnonymizationTableRequest `json:"tables" binding:"required,min=1,dive"`
+	ChunkSize    *int                        `json:"chunk_size,omitempty"` // Rows written per INSERT, defaults to 500
+	ClearTarget  bool                        `json:"clear_target"`         // Delete the rows of the target tables before copying
+}
+
+type MaskingRuleResponse struct {
+	Column   string  `json:"column"`
+	Strategy string  `json:"strategy"`
+	Value    *string `json:"value,omitempty"`
+}
+
+type AnonymizationVerificationResponse struct {
+	SourceRows     int64    `json:"source_rows"`
+	TargetRows     int64    `json:"target_rows"`
+	CheckedValues  int      `json:"checked_values"`
+	LeakedValues   int64    `json:"leaked_values"`
+	LeakedColumns  []string `json:"leaked_columns"`
+	RowCountsMatch bool     `json:"row_counts_match"`
+	Passed         bool     `json:"passed"`
+}
+
+type AnonymizationTableResponse struct {
+	Table        string                             `json:"table"`
+	Rules        []MaskingRuleResponse              `json:"rules"`
+	Status       string                             `json:"status"`
+	TotalRows    int64                              `json:"total_rows"`
+	CopiedRows   int64                              `json:"copied_rows"`
+	Progress     float64                            `json:"progress"` // Percentage of the rows copied
+	Error        *string                            `json:"error,omitempty"`
+	Verification *AnonymizationVerificationResponse `json:"verification,omitempty"`
+	StartedAt    *string                            `json:"started_at,omitempty"`
+	CompletedAt  *string                            `json:"completed_at,omitempty"`
+}
+
+type AnonymizationJobResponse struct {
+	ID           string                       `json:"id"`
+	SourceChatID string                       `json:"source_chat_id"`
+	TargetChatID string                       `json:"target_chat_id"`
+	Status       string                       `json:"status"`
+	CurrentTable int                          `json:"current_table"`
+	ChunkSize    int                          `json:"chunk_size"`
+	ClearTarget  bool                         `json:"clear_target"`
+	Tables       []AnonymizationTableResponse `json:"tables"`
+	Progress     float64                      `json:"progress"` // Percentage of the rows copied across all tables
+	Error        *string                      `json:"error,omitempty"`
+	StartedAt    string                       `json:"started_at"`
+	CompletedAt  *string                      `json:"completed_at,omitempty"`
+}
+
+type AnonymizationJobListResponse struct {
+	Jobs  []AnonymizationJobResponse `json:"jobs"`
+	Total int64                      `json:"total"`
+}
+
+type AnonymizationReportResponse struct {
+	AnonymizationJobResponse
+	TotalRows    int64  `json:"total_rows"`
+	CopiedRows   int64  `json:"copied_rows"`
+	TablesPassed int    `json:"tables_passed"` // Tables whose verification found no leak & matching row counts
+	TablesFailed int    `json:"tables_failed"`
+	Duration     int64  `json:"duration"` // in milliseconds
+	Summary      string `json:"summary"`  // Markdown summary of the job
+}
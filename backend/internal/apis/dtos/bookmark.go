@@ -0,0 +1,35 @@
+package dtos
+
+type CreateBookmarkRequest struct {
+	MessageID      string                 `json:"message_id" binding:"required"`
+	QueryID        string                 `json:"query_id" binding:"required"`
+	Title          string                 `json:"title" binding:"required"`
+	Note           *string                `json:"note,omitempty"`
+	Parameters     map[string]interface{} `json:"parameters,omitempty"`       // Parameters used for the execution (e.g. offset)
+	ExpiresInHours *int                   `json:"expires_in_hours,omitempty"` // Share link expiry, never expires if not provided
+}
+
+type BookmarkResponse struct {
+	ID                string                 `json:"id"`
+	ChatID            string                 `json:"chat_id"`
+	MessageID         string                 `json:"message_id"`
+	QueryID           string                 `json:"query_id"`
+	Title             string                 `json:"title"`
+	Note              *string                `json:"note,omitempty"`
+	Query             string                 `json:"query"`
+	QueryType         *string                `json:"query_type"`
+	Tables            *string                `json:"tables,omitempty"`
+	Parameters        map[string]interface{} `json:"parameters,omitempty"`
+	ResultSnapshot    interface{}            `json:"result_snapshot,omitempty"`
+	TotalRecordsCount *int                   `json:"total_records_count,omitempty"`
+	ExecutionTime     *int                   `json:"execution_time,omitempty"`
+	ShareToken        string                 `json:"share_token"`
+	DeepLink          string                 `json:"deep_link"`
+	ExpiresAt         *string                `json:"expires_at,omitempty"`
+	CreatedAt         string                 `json:"created_at"`
+}
+
+type BookmarkListResponse struct {
+	Bookmarks []BookmarkResponse `json:"bookmarks"`
+	Total     int64              `json:"total"`
+}
@@ -0,0 +1,36 @@
+package dtos
+
+type CreateCommentRequest struct {
+	Content    string  `json:"content" binding:"required"`
+	ParentID   *string `json:"parent_id,omitempty"`   // ID of the comment to reply to
+	RowIndexes []int   `json:"row_indexes,omitempty"` // Indexes of the result rows the comment refers to
+}
+
+type UpdateCommentRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+type CommentResponse struct {
+	ID         string            `json:"id"`
+	ChatID     string            `json:"chat_id"`
+	MessageID  string            `json:"message_id"`
+	QueryID    string            `json:"query_id"`
+	ParentID   *string           `json:"parent_id,omitempty"`
+	RowIndexes []int             `json:"row_indexes,omitempty"`
+	Content    string            `json:"content"`
+	IsEdited   bool              `json:"is_edited"`
+	Author     CommentAuthor     `json:"author"`
+	Replies    []CommentResponse `json:"replies,omitempty"`
+	CreatedAt  string            `json:"created_at"`
+	UpdatedAt  string            `json:"updated_at"`
+}
+
+type CommentAuthor struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+type CommentListResponse struct {
+	Comments []CommentResponse `json:"comments"` // Top level comments with their replies
+	Total    int               `json:"total"`
+}
@@ -0,0 +1,30 @@
+package dtos
+
+// FineTuningRecord is a line of the fine-tuning dataset: a question, the schema it was asked on, the query generated for it & how it went.
+// The values users could have typed (emails, IPs, numbers) are redacted & the IDs are replaced by checksums.
+type FineTuningRecord struct {
+	ConversationID string             `json:"conversation_id"` // same for the records of a chat
+	DBType         string             `json:"db_type"`
+	Question       string             `json:"question"`
+	SchemaExcerpt  string             `json:"schema_excerpt"` // tables involved in the query, without example records
+	Query          string             `json:"query"`
+	QueryType      string             `json:"query_type,omitempty"`
+	Description    string             `json:"description,omitempty"`
+	Outcome        FineTuningOutcome  `json:"outcome"`
+	Feedback       FineTuningFeedback `json:"feedback"`
+	CreatedAt      string             `json:"created_at"`
+}
+
+type FineTuningOutcome struct {
+	Status        string `json:"status"` // not_executed, succeeded, failed or rolled_back
+	ErrorCode     string `json:"error_code,omitempty"`
+	ErrorMessage  string `json:"error_message,omitempty"`
+	ExecutionTime *int   `json:"execution_time,omitempty"` // in milliseconds
+}
+
+// FineTuningFeedback is what users did with the query: fixed it by hand, kept it or discussed it
+type FineTuningFeedback struct {
+	Edited     bool `json:"edited"`
+	Bookmarked bool `json:"bookmarked"`
+	Comments   int  `json:"comments"`
+}
@@ -0,0 +1,47 @@
+package dtos
+
+import "neobase-ai/internal/models"
+
+type LineageEventResponse struct {
+	ID           string                 `json:"id"`
+	MessageID    string                 `json:"message_id,omitempty"`
+	QueryID      string                 `json:"query_id,omitempty"`
+	Operation    string                 `json:"operation"`
+	TargetTable  string                 `json:"target_table"`
+	Columns      []models.LineageColumn `json:"columns"`
+	SourceTables []string               `json:"source_tables"`
+	Statement    string                 `json:"statement"`
+	IsRollback   bool                   `json:"is_rollback"`
+	CreatedAt    string                 `json:"created_at"`
+}
+
+type LineageEventListResponse struct {
+	Events []LineageEventResponse `json:"events"`
+	Total  int64                  `json:"total"`
+}
+
+// LineageNode is a table, or a column of a table when Column is set
+type LineageNode struct {
+	ID     string `json:"id"`
+	Table  string `json:"table"`
+	Column string `json:"column,omitempty"`
+}
+
+// LineageEdge is a data flow from one node to another caused by an executed statement
+type LineageEdge struct {
+	From       string `json:"from"`
+	To         string `json:"to"`
+	Operation  string `json:"operation"`
+	Expression string `json:"expression,omitempty"`
+	EventID    string `json:"event_id"`
+	QueryID    string `json:"query_id,omitempty"`
+	CreatedAt  string `json:"created_at"`
+}
+
+type LineageGraphResponse struct {
+	Table  string        `json:"table"`
+	Column string        `json:"column,omitempty"`
+	Depth  int           `json:"depth"`
+	Nodes  []LineageNode `json:"nodes"`
+	Edges  []LineageEdge `json:"edges"`
+}
@@ -0,0 +1,20 @@
+package dtos
+
+import "neobase-ai/internal/models"
+
+type LLMUsageResponse struct {
+	Calls            int64                    `json:"calls"`
+	PromptTokens     int64                    `json:"prompt_tokens"`
+	CompletionTokens int64                    `json:"completion_tokens"`
+	TotalTokens      int64                    `json:"total_tokens"`
+	EstimatedCost    float64                  `json:"estimated_cost"` // USD
+	Models           []models.LLMUsageByModel `json:"models"`
+}
+
+// UserLLMUsageResponse is the usage of a user over a month, along with the monthly token quota when one is set
+type UserLLMUsageResponse struct {
+	LLMUsageResponse
+	Month             string `json:"month"` // YYYY-MM
+	MonthlyTokenQuota int64  `json:"monthly_token_quota,omitempty"`
+	RemainingTokens   *int64 `json:"remaining_tokens,omitempty"`
+}
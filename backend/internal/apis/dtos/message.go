@@ -11,6 +11,13 @@ type CreateMessageRequest struct {
 	Content  string `json:"content" binding:"required"`
 }
 
+// AnswerClarificationRequest answers the clarification of an assistant message with one of its options (button_id) or a free answer
+type AnswerClarificationRequest struct {
+	StreamID string `json:"stream_id" binding:"required"`
+	ButtonID string `json:"button_id,omitempty"`
+	Answer   string `json:"answer,omitempty"`
+}
+
 type MessageResponse struct {
 	ID            string          `json:"id"`
 	ChatID        string          `json:"chat_id"`
@@ -40,22 +47,37 @@ type Query struct {
 	ExampleExecutionTime   int                    `json:"example_execution_time"`
 	CanRollback            bool                   `json:"can_rollback"`
 	IsCritical             bool                   `json:"is_critical"`
+	IsBlocked              bool                   `json:"is_blocked"`
+	GuardrailReason        *string                `json:"guardrail_reason,omitempty"`      // Why the query was flagged as destructive
+	EstimatedRows          *int64                 `json:"estimated_rows,omitempty"`        // Rows the query was estimated to read before its auto-execution
+	RequiresConfirmation   bool                   `json:"requires_confirmation,omitempty"` // The estimate was above the auto-execution limit, the user executes it
+	AffectedRowsPreview    *AffectedRowsPreview   `json:"affected_rows_preview,omitempty"` // Rows an UPDATE or a DELETE would change, read before it is executed
 	IsExecuted             bool                   `json:"is_executed"`
 	IsRolledBack           bool                   `json:"is_rolled_back"`
 	Error                  *QueryError            `json:"error,omitempty"`
 	ExampleResult          []interface{}          `json:"example_result,omitempty"`
 	ExecutionResult        map[string]interface{} `json:"execution_result,omitempty"`
+	ResultColumns          []ResultColumn         `json:"result_columns,omitempty"` // Columns of the rows of the execution result in their order
+	ResultFile             *ResultFile            `json:"result_file,omitempty"`    // The file holding the whole result when it was too large, without its link
 	QueryType              *string                `json:"query_type,omitempty"`
 	Tables                 *string                `json:"tables,omitempty"`
 	RollbackQuery          *string                `json:"rollback_query,omitempty"`
 	RollbackDependentQuery *string                `json:"rollback_dependent_query,omitempty"`
 	Pagination             *Pagination            `json:"pagination,omitempty"`
+	Params                 map[string]interface{} `json:"params,omitempty"` // Values bound to the named parameters at the last execution
 	IsEdited               bool                   `json:"is_edited"`
 	ActionAt               *string                `json:"action_at,omitempty"` // The timestamp when the action was taken
 }
 
+// AffectedRowsPreview is the count & a sample of the rows a write would change
+type AffectedRowsPreview struct {
+	Count  int64         `json:"count"`
+	Sample []interface{} `json:"sample"`
+}
+
 type Pagination struct {
-	TotalRecordsCount int `json:"total_records_count"` // Total records count of the query
+	TotalRecordsCount int     `json:"total_records_count"`     // Total records count of the query
+	KeysetColumn      *string `json:"keyset_column,omitempty"` // The next page is read after the value of this column in the last row
 	// We do not return the paginatedQuery and countQuery in the response
 }
 
@@ -122,6 +144,7 @@ func ToQueryDto(queries *[]models.Query) *[]Query {
 			}
 			pagination = &Pagination{
 				TotalRecordsCount: totalCount,
+				KeysetColumn:      query.Pagination.KeysetColumn,
 			}
 		}
 		log.Printf("ToQueryDto -> final exampleResult: %v", exampleResult)
@@ -133,16 +156,24 @@ func ToQueryDto(queries *[]models.Query) *[]Query {
 			ExampleExecutionTime:   query.ExampleExecutionTime,
 			CanRollback:            query.CanRollback,
 			IsCritical:             query.IsCritical,
+			IsBlocked:              query.IsBlocked,
+			GuardrailReason:        query.GuardrailReason,
+			EstimatedRows:          query.EstimatedRows,
+			RequiresConfirmation:   query.RequiresConfirmation,
+			AffectedRowsPreview:    ToAffectedRowsPreviewDto(query.AffectedRowsPreview),
 			IsExecuted:             query.IsExecuted,
 			IsRolledBack:           query.IsRolledBack,
 			Error:                  (*QueryError)(query.Error),
 			ExampleResult:          exampleResult,
 			ExecutionResult:        executionResult,
+			ResultColumns:          ToResultColumnsDto(query.ResultColumns),
+			ResultFile:             ToResultFileDto(query.ResultFile),
 			QueryType:              query.QueryType,
 			Tables:                 query.Tables,
 			RollbackQuery:          query.RollbackQuery,
 			RollbackDependentQuery: query.RollbackDependentQuery,
 			Pagination:             pagination,
+			Params:                 query.Params,
 			IsEdited:               query.IsEdited,
 			ActionAt:               query.ActionAt,
 		}
@@ -150,6 +181,52 @@ func ToQueryDto(queries *[]models.Query) *[]Query {
 	return &queriesDto
 }
 
+// ToAffectedRowsPreviewDto converts the model preview of the rows a write would change to its DTO
+func ToAffectedRowsPreviewDto(preview *models.AffectedRowsPreview) *AffectedRowsPreview {
+	if preview == nil {
+		return nil
+	}
+	sample := []interface{}{}
+	if preview.Sample != nil {
+		if err := json.Unmarshal([]byte(*preview.Sample), &sample); err != nil {
+			log.Printf("ToAffectedRowsPreviewDto -> error unmarshalling sample: %v", err)
+		}
+	}
+	return &AffectedRowsPreview{Count: preview.Count, Sample: sample}
+}
+
+// ToResultColumnsDto converts the model columns of an execution result to their DTOs
+func ToResultColumnsDto(columns []models.ResultColumn) []ResultColumn {
+	if columns == nil {
+		return nil
+	}
+	columnsDto := make([]ResultColumn, len(columns))
+	for i, column := range columns {
+		columnsDto[i] = ResultColumn(column)
+	}
+	return columnsDto
+}
+
+// ToResultColumnsModel converts the columns of an execution result to their models, stored with the query
+func ToResultColumnsModel(columns []ResultColumn) []models.ResultColumn {
+	if columns == nil {
+		return nil
+	}
+	columnsModel := make([]models.ResultColumn, len(columns))
+	for i, column := range columns {
+		columnsModel[i] = models.ResultColumn(column)
+	}
+	return columnsModel
+}
+
+// ToResultFileDto converts the model file of a spilled result to its DTO, the link is signed on request
+func ToResultFileDto(file *models.ResultFile) *ResultFile {
+	if file == nil {
+		return nil
+	}
+	return &ResultFile{Format: file.Format, Rows: file.Rows, SizeBytes: file.SizeBytes}
+}
+
 // ToActionButtonDto converts model action buttons to DTO action buttons
 func ToActionButtonDto(actionButtons *[]models.ActionButton) *[]ActionButton {
 	log.Printf("ToActionButtonDto -> input actionButtons: %+v", actionButtons)
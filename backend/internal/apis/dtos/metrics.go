@@ -0,0 +1,28 @@
+package dtos
+
+// MetricsResponse is the activity of the server since it started, for capacity planning
+type MetricsResponse struct {
+	Since         string                 `json:"since"`
+	LLM           []LLMModelMetrics      `json:"llm"`
+	DatabasePools map[string]interface{} `json:"database_pools"`
+}
+
+type LLMModelMetrics struct {
+	Provider         string             `json:"provider"`
+	Model            string             `json:"model"`
+	Requests         int64              `json:"requests"`
+	Failures         int64              `json:"failures"`
+	AvgDurationMs    int64              `json:"avg_duration_ms"`
+	MaxDurationMs    int64              `json:"max_duration_ms"`
+	Latency          []LLMLatencyBucket `json:"latency"`
+	PromptTokens     int64              `json:"prompt_tokens"`
+	CompletionTokens int64              `json:"completion_tokens"`
+	Retries          map[string]int64   `json:"retries"` // by reason: failover, repair or query_plan
+	Errors           map[string]int64   `json:"errors"`  // by class, e.g. rate_limit or timeout
+}
+
+// LLMLatencyBucket counts the requests which took up to UpToMs, without it for the requests slower than the last bucket
+type LLMLatencyBucket struct {
+	UpToMs *int64 `json:"up_to_ms,omitempty"`
+	Count  int64  `json:"count"`
+}
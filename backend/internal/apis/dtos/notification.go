@@ -0,0 +1,28 @@
+package dtos
+
+type NotificationResponse struct {
+	ID        string                 `json:"id"`
+	ChatID    *string                `json:"chat_id,omitempty"`
+	Type      string                 `json:"type"`
+	Title     string                 `json:"title"`
+	Message   string                 `json:"message"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	IsRead    bool                   `json:"is_read"`
+	ReadAt    *string                `json:"read_at,omitempty"`
+	CreatedAt string                 `json:"created_at"`
+}
+
+type NotificationListResponse struct {
+	Notifications []NotificationResponse `json:"notifications"`
+	Total         int64                  `json:"total"`
+	UnreadCount   int64                  `json:"unread_count"`
+}
+
+type MarkNotificationsReadRequest struct {
+	IDs []string `json:"ids" binding:"required,min=1"`
+}
+
+type MarkNotificationsReadResponse struct {
+	Updated     int64 `json:"updated"`
+	UnreadCount int64 `json:"unread_count"`
+}
@@ -0,0 +1,50 @@
+package dtos
+
+type CreateOrganizationRequest struct {
+	Name      string   `json:"name" binding:"required"`
+	Usernames []string `json:"usernames,omitempty"` // Initial members
+}
+
+type UpdateOrganizationRequest struct {
+	Name               *string `json:"name,omitempty"`
+	DefaultLLMProvider *string `json:"default_llm_provider,omitempty" binding:"omitempty,oneof=openai gemini claude"`
+}
+
+type OrganizationMemberRequest struct {
+	Username string `json:"username" binding:"required"`
+}
+
+// SetOrganizationLLMProviderRequest configures a provider, the API key can be omitted to keep the stored one
+type SetOrganizationLLMProviderRequest struct {
+	APIKey        *string  `json:"api_key,omitempty"`
+	AllowedModels []string `json:"allowed_models" binding:"required,min=1"`
+	DefaultModel  string   `json:"default_model,omitempty"` // Defaults to the first allowed model
+}
+
+type OrganizationMemberResponse struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+type OrganizationLLMProviderResponse struct {
+	Provider      string   `json:"provider"`
+	APIKeyHint    string   `json:"api_key_hint"` // Last characters of the key, the key itself is never returned
+	AllowedModels []string `json:"allowed_models"`
+	DefaultModel  string   `json:"default_model"`
+	UpdatedAt     string   `json:"updated_at"`
+}
+
+type OrganizationResponse struct {
+	ID                 string                            `json:"id"`
+	Name               string                            `json:"name"`
+	Members            []OrganizationMemberResponse      `json:"members"`
+	LLMProviders       []OrganizationLLMProviderResponse `json:"llm_providers"`
+	DefaultLLMProvider string                            `json:"default_llm_provider,omitempty"`
+	CreatedAt          string                            `json:"created_at"`
+	UpdatedAt          string                            `json:"updated_at"`
+}
+
+type OrganizationListResponse struct {
+	Organizations []OrganizationResponse `json:"organizations"`
+	Total         int64                  `json:"total"`
+}
@@ -0,0 +1,35 @@
+package dtos
+
+// CreatePromptTemplateRequest creates the next version of the template of a database type
+type CreatePromptTemplateRequest struct {
+	Provider    string `json:"provider,omitempty" binding:"omitempty,oneof=openai azure-openai gemini ollama claude bedrock openai-compatible"` // Empty for every provider
+	Content     string `json:"content" binding:"required"`
+	Description string `json:"description,omitempty"`
+	Activate    *bool  `json:"activate,omitempty"` // Defaults to true
+}
+
+type PromptTemplateResponse struct {
+	ID          string `json:"id"`
+	DBType      string `json:"db_type"`
+	Provider    string `json:"provider,omitempty"`
+	Version     int    `json:"version"`
+	Content     string `json:"content"`
+	Description string `json:"description,omitempty"`
+	IsActive    bool   `json:"is_active"`
+	CreatedBy   string `json:"created_by"`
+	CreatedAt   string `json:"created_at"`
+}
+
+// PromptTemplateListResponse lists the active templates, the database types without one use their built-in prompt
+type PromptTemplateListResponse struct {
+	Templates []PromptTemplateResponse `json:"templates"`
+}
+
+// PromptTemplateVersionsResponse lists the versions of the template of a database type & provider along with the built-in prompt
+type PromptTemplateVersionsResponse struct {
+	DBType        string                   `json:"db_type"`
+	Provider      string                   `json:"provider,omitempty"`
+	ActiveVersion int                      `json:"active_version"` // 0 when the built-in prompt is used
+	BuiltInPrompt string                   `json:"built_in_prompt"`
+	Versions      []PromptTemplateResponse `json:"versions"`
+}
@@ -1,9 +1,22 @@
 package dtos
 
 type ExecuteQueryRequest struct {
-	MessageID string `json:"message_id" binding:"required"`
-	QueryID   string `json:"query_id" binding:"required"`
-	StreamID  string `json:"stream_id" binding:"required"`
+	MessageID  string `json:"message_id" binding:"required"`
+	QueryID    string `json:"query_id" binding:"required"`
+	StreamID   string `json:"stream_id" binding:"required"`
+	UsePrimary bool   `json:"use_primary"` // Run a read-only query on the primary instead of a read replica, e.g. to read rows just written
+	// Timeout of the execution, the connection's or QUERY_TIMEOUT_SECONDS when empty, up to MAX_QUERY_TIMEOUT_SECONDS
+	TimeoutSeconds *int `json:"timeout_seconds,omitempty" binding:"omitempty,min=1"`
+	// Return the plan of the query (EXPLAIN or the driver equivalent) in the execution result instead of running it
+	ExplainOnly bool `json:"explain_only"`
+	// Values of the named parameters of the query (:name or {{name}}), the ones of its last execution when empty
+	Params map[string]interface{} `json:"params,omitempty"`
+	// Rows of the result shown & stored, the page size of its pagination, the connection's or RESULT_DISPLAY_ROWS when empty
+	DisplayRows *int `json:"display_rows,omitempty" binding:"omitempty,min=1"`
+	// Rows read from the result at most, the connection's or MAX_RESULT_ROWS when empty, up to MAX_RESULT_ROWS
+	MaxRows *int `json:"max_rows,omitempty" binding:"omitempty,min=1"`
+	// Run a SELECT or find without a limit as written, AUTO_LIMIT_ROWS is appended to it otherwise
+	FullScan bool `json:"full_scan"`
 }
 
 type RollbackQueryRequest struct {
@@ -24,8 +37,11 @@ type QueryExecutionResponse struct {
 	QueryID           string          `json:"query_id"`
 	IsExecuted        bool            `json:"is_executed"`
 	IsRolledBack      bool            `json:"is_rolled_back"`
+	ExplainOnly       bool            `json:"explain_only,omitempty"` // The execution result is the plan, the query was not run
 	ExecutionTime     *int            `json:"execution_time"`
 	ExecutionResult   interface{}     `json:"execution_result"`
+	Columns           []ResultColumn  `json:"columns,omitempty"`             // Columns of the rows of the result in their order
+	ResultFilePending bool            `json:"result_file_pending,omitempty"` // The whole result is being written to a file, its link comes in a query-result-file event
 	Error             *QueryError     `json:"error,omitempty"`
 	TotalRecordsCount *int            `json:"total_records_count"`
 	ActionButtons     *[]ActionButton `json:"action_buttons,omitempty"`
@@ -37,6 +53,8 @@ type QueryResultsRequest struct {
 	QueryID   string `json:"query_id" binding:"required"`
 	StreamID  string `json:"stream_id" binding:"required"`
 	Offset    int    `json:"offset" binding:"required"`
+	// Keyset column value of the last row of the previous page, the page is read by key rather than by offset when the query has a keyset column
+	After interface{} `json:"after,omitempty"`
 }
 
 type QueryResultsResponse struct {
@@ -44,6 +62,7 @@ type QueryResultsResponse struct {
 	MessageID         string          `json:"message_id"`
 	QueryID           string          `json:"query_id"`
 	ExecutionResult   interface{}     `json:"execution_result"`
+	Columns           []ResultColumn  `json:"columns,omitempty"`
 	Error             *QueryError     `json:"error,omitempty"`
 	TotalRecordsCount *int            `json:"total_records_count"`
 	ActionButtons     *[]ActionButton `json:"action_buttons,omitempty"`
@@ -62,4 +81,137 @@ type EditQueryResponse struct {
 	QueryID   string `json:"query_id"`
 	Query     string `json:"query"`
 	IsEdited  bool   `json:"is_edited"`
+
+	AffectedRowsPreview *AffectedRowsPreview `json:"affected_rows_preview,omitempty"` // Rows the edited UPDATE or DELETE would change
+}
+
+type BenchmarkQueryRequest struct {
+	MessageID  string  `json:"message_id" binding:"required"`
+	QueryID    string  `json:"query_id" binding:"required"`
+	StreamID   string  `json:"stream_id" binding:"required"`                 // Cancels the benchmark with the query cancel route
+	Iterations int     `json:"iterations" binding:"omitempty,min=1,max=100"` // Measured executions, 10 by default
+	WarmUp     *int    `json:"warm_up" binding:"omitempty,min=0,max=10"`     // Executions before the measured ones, 1 by default
+	Query      *string `json:"query,omitempty"`                              // Variant of the query to compare with it, the query of the message by default
+	UsePrimary bool    `json:"use_primary"`                                  // Run on the primary instead of a read replica
+}
+
+type BenchmarkQueryResponse struct {
+	ChatID        string              `json:"chat_id"`
+	MessageID     string              `json:"message_id"`
+	QueryID       string              `json:"query_id"`
+	Query         string              `json:"query"`
+	Iterations    int                 `json:"iterations"`
+	WarmUp        int                 `json:"warm_up"`
+	MinMs         float64             `json:"min_ms"`
+	MedianMs      float64             `json:"median_ms"`
+	P95Ms         float64             `json:"p95_ms"`
+	MaxMs         float64             `json:"max_ms"`
+	MeanMs        float64             `json:"mean_ms"`
+	Rows          int                 `json:"rows"`
+	RowsVary      bool                `json:"rows_vary"` // The runs returned different row counts
+	OnReadReplica bool                `json:"on_read_replica"`
+	Runs          []BenchmarkQueryRun `json:"runs"`
+}
+
+type BenchmarkQueryRun struct {
+	Iteration  int     `json:"iteration"`
+	DurationMs float64 `json:"duration_ms"`
+	Rows       int     `json:"rows"`
+}
+
+type StreamQueryResultsRequest struct {
+	MessageID  string `json:"message_id" binding:"required"`
+	QueryID    string `json:"query_id" binding:"required"`
+	StreamID   string `json:"stream_id" binding:"required"`                  // Stream the query-result-chunk events are sent to, cancels the streaming with the query cancel route
+	ChunkSize  int    `json:"chunk_size" binding:"omitempty,min=1,max=5000"` // Rows of a chunk, 500 by default
+	UsePrimary bool   `json:"use_primary"`                                   // Read from the primary instead of a read replica
+}
+
+type StreamQueryResultsResponse struct {
+	ChatID        string `json:"chat_id"`
+	MessageID     string `json:"message_id"`
+	QueryID       string `json:"query_id"`
+	Rows          int64  `json:"rows"`
+	Chunks        int    `json:"chunks"`
+	OnReadReplica bool   `json:"on_read_replica"`
+}
+
+type ExecuteAllQueriesRequest struct {
+	MessageID string   `json:"message_id" binding:"required"`
+	StreamID  string   `json:"stream_id" binding:"required"` // Stream the batch-execution-finished event is sent to, cancels the batch with the query cancel route
+	QueryIDs  []string `json:"query_ids,omitempty"`          // Queries to execute in this order, the queries of the message not executed yet when empty
+	// Timeout of the whole batch, the connection's or QUERY_TIMEOUT_SECONDS when empty, up to MAX_QUERY_TIMEOUT_SECONDS
+	TimeoutSeconds *int `json:"timeout_seconds,omitempty" binding:"omitempty,min=1"`
+	// Run the SELECTs & finds without a limit as written, AUTO_LIMIT_ROWS is appended to them otherwise
+	FullScan bool `json:"full_scan"`
+}
+
+type ExecuteAllQueriesResponse struct {
+	ChatID        string                `json:"chat_id"`
+	MessageID     string                `json:"message_id"`
+	Success       bool                  `json:"success"` // Every query ran & the batch was committed
+	Queries       []BatchQueryExecution `json:"queries"`
+	ActionButtons *[]ActionButton       `json:"action_buttons,omitempty"`
+}
+
+type BatchQueryExecution struct {
+	QueryID         string         `json:"query_id"`
+	Status          string         `json:"status"` // succeeded, failed, rolled_back or skipped
+	IsExecuted      bool           `json:"is_executed"`
+	ExecutionTime   *int           `json:"execution_time"`
+	ExecutionResult interface{}    `json:"execution_result"`
+	Columns         []ResultColumn `json:"columns,omitempty"`
+	Error           *QueryError    `json:"error,omitempty"`
+	ActionAt        *string        `json:"action_at,omitempty"`
+}
+
+type ChartQueryResultsRequest struct {
+	MessageID    string `json:"message_id" binding:"required"`
+	QueryID      string `json:"query_id" binding:"required"`
+	XColumn      string `json:"x_column" binding:"required"` // Column the points are grouped by
+	YColumn      string `json:"y_column"`                    // Column aggregated, empty to count the rows
+	SeriesColumn string `json:"series_column"`               // Splits the points in a series per value of the column
+	// Aggregate of the y values of a point, count when empty & sum with a y column
+	Aggregate  string `json:"aggregate" binding:"omitempty,oneof=count sum avg min max"`
+	TimeBucket string `json:"time_bucket" binding:"omitempty,oneof=minute hour day week month year"` // Groups the x values by time
+	MaxPoints  int    `json:"max_points" binding:"omitempty,min=1,max=1000"`                         // x values kept when grouped by value, 100 by default
+	UsePrimary bool   `json:"use_primary"`                                                           // Read from the primary instead of a read replica
+}
+
+type ChartQueryResultsResponse struct {
+	ChatID        string        `json:"chat_id"`
+	MessageID     string        `json:"message_id"`
+	QueryID       string        `json:"query_id"`
+	XColumn       string        `json:"x_column"`
+	YColumn       string        `json:"y_column,omitempty"`
+	Aggregate     string        `json:"aggregate"`
+	TimeBucket    string        `json:"time_bucket,omitempty"`
+	Series        []ChartSeries `json:"series"`
+	Rows          int64         `json:"rows"`      // Rows of the result the series were read from
+	Truncated     bool          `json:"truncated"` // The x values of the smallest totals were left out
+	OnReadReplica bool          `json:"on_read_replica"`
+}
+
+type ChartSeries struct {
+	Name   string       `json:"name"`
+	Points []ChartPoint `json:"points"`
+}
+
+type ChartPoint struct {
+	X interface{} `json:"x"` // The value, or the start of the time bucket as RFC 3339, null for NULL
+	Y float64     `json:"y"`
+}
+
+type ResultColumn struct {
+	Name         string `json:"name"`
+	DatabaseType string `json:"database_type"`      // As the driver names it, e.g. NUMERIC or VARCHAR, the BSON type of the values for documents
+	Nullable     *bool  `json:"nullable,omitempty"` // Not set when the driver doesn't tell
+}
+
+type ResultFile struct {
+	URL       string `json:"url,omitempty"`        // Signed link downloading the file, not stored with the query
+	ExpiresAt string `json:"expires_at,omitempty"` // When the link stops working, a new one is returned by the result-file route
+	Format    string `json:"format"`               // jsonl, a JSON object per row
+	Rows      int64  `json:"rows"`
+	SizeBytes int64  `json:"size_bytes"`
 }
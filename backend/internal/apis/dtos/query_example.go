@@ -0,0 +1,29 @@
+package dtos
+
+type CreateQueryExampleRequest struct {
+	Question    string  `json:"question" binding:"required"`
+	Query       string  `json:"query" binding:"required"`
+	Explanation *string `json:"explanation,omitempty"`
+}
+
+type UpdateQueryExampleRequest struct {
+	Question    *string `json:"question,omitempty"`
+	Query       *string `json:"query,omitempty"`
+	Explanation *string `json:"explanation,omitempty"`
+}
+
+type QueryExampleResponse struct {
+	ID           string  `json:"id"`
+	ChatID       string  `json:"chat_id"`
+	ConnectionID *string `json:"connection_id,omitempty"` // Set when the example is shared by the chats of a saved connection
+	Question     string  `json:"question"`
+	Query        string  `json:"query"`
+	Explanation  *string `json:"explanation,omitempty"`
+	CreatedAt    string  `json:"created_at"`
+	UpdatedAt    string  `json:"updated_at"`
+}
+
+type QueryExampleListResponse struct {
+	Examples []QueryExampleResponse `json:"examples"`
+	Total    int                    `json:"total"`
+}
@@ -0,0 +1,64 @@
+package dtos
+
+// QueryHistoryListRequest filters the query history, the empty fields don't filter
+type QueryHistoryListRequest struct {
+	ChatID       string
+	ConnectionID string // Saved connection the chats share
+	DatabaseType string // e.g. postgresql or mongodb
+	Status       string // succeeded or failed
+	IsRollback   *bool
+	From         string // RFC3339 time or YYYY-MM-DD date, inclusive
+	To           string // RFC3339 time, exclusive, or YYYY-MM-DD date, inclusive
+	Search       string // Case-insensitive text the query contains
+	Page         int
+	PageSize     int
+}
+
+type QueryHistoryResponse struct {
+	ID            string      `json:"id"`
+	ChatID        string      `json:"chat_id"`
+	ConnectionID  *string     `json:"connection_id,omitempty"`
+	DatabaseType  string      `json:"database_type"`
+	Database      string      `json:"database"`
+	MessageID     string      `json:"message_id"`
+	QueryID       string      `json:"query_id"`
+	Query         string      `json:"query"`
+	QueryType     *string     `json:"query_type,omitempty"`
+	IsRollback    bool        `json:"is_rollback"`
+	Status        string      `json:"status"`
+	ExecutionTime int         `json:"execution_time"` // in milliseconds
+	Rows          *int        `json:"rows,omitempty"`
+	Error         *QueryError `json:"error,omitempty"`
+	ExecutedAt    string      `json:"executed_at"`
+}
+
+type QueryHistoryListResponse struct {
+	Entries []QueryHistoryResponse `json:"entries"`
+	Total   int64                  `json:"total"`
+}
+
+// QueryHistoryDiffRequest compares the results of two executions of the history, the rows are matched by their KeyColumn
+type QueryHistoryDiffRequest struct {
+	BaseID    string `json:"base_id" binding:"required"`   // Execution before, e.g. of the query before a fix
+	TargetID  string `json:"target_id" binding:"required"` // Execution after
+	KeyColumn string `json:"key_column" binding:"required"`
+}
+
+type QueryHistoryDiffResponse struct {
+	Base        QueryHistoryResponse  `json:"base"`
+	Target      QueryHistoryResponse  `json:"target"`
+	KeyColumn   string                `json:"key_column"`
+	Added       []interface{}         `json:"added"`   // Rows of the target only
+	Removed     []interface{}         `json:"removed"` // Rows of the base only
+	Changed     []QueryHistoryRowDiff `json:"changed"`
+	Unchanged   int                   `json:"unchanged"`
+	IsTruncated bool                  `json:"is_truncated"` // Only the first rows of a result were kept & compared
+}
+
+// QueryHistoryRowDiff is a row of both results whose values differ, a column of one of the rows only is a changed column
+type QueryHistoryRowDiff struct {
+	Key     interface{} `json:"key"`
+	Columns []string    `json:"columns"` // Changed columns, by name
+	Before  interface{} `json:"before"`
+	After   interface{} `json:"after"`
+}
@@ -0,0 +1,32 @@
+package dtos
+
+// CreateQueryJobRequest runs a query of a message in the background, for the queries exceeding the timeout of /queries/execute
+type CreateQueryJobRequest struct {
+	ChatID         string `json:"chat_id" binding:"required"`
+	MessageID      string `json:"message_id" binding:"required"`
+	QueryID        string `json:"query_id" binding:"required"`
+	StreamID       string `json:"stream_id,omitempty"`       // Stream the query-job-finished event is sent to, the job can be polled without one
+	UsePrimary     bool   `json:"use_primary"`               // Run a read-only query on the primary instead of a read replica
+	TimeoutMinutes *int   `json:"timeout_minutes,omitempty"` // Defaults to & is capped at QUERY_JOB_TIMEOUT_MINUTES
+}
+
+type QueryJobResponse struct {
+	ID                string      `json:"id"`
+	ChatID            string      `json:"chat_id"`
+	MessageID         string      `json:"message_id"`
+	QueryID           string      `json:"query_id"`
+	Status            string      `json:"status"` // queued, running, completed, failed or cancelled
+	TimeoutMinutes    int         `json:"timeout_minutes"`
+	ExecutionTime     *int        `json:"execution_time,omitempty"` // in milliseconds
+	ExecutionResult   interface{} `json:"execution_result,omitempty"`
+	TotalRecordsCount *int        `json:"total_records_count,omitempty"`
+	Error             *QueryError `json:"error,omitempty"`
+	QueuedAt          string      `json:"queued_at"`
+	StartedAt         *string     `json:"started_at,omitempty"`
+	CompletedAt       *string     `json:"completed_at,omitempty"`
+}
+
+type QueryJobListResponse struct {
+	Jobs  []QueryJobResponse `json:"jobs"`
+	Total int64              `json:"total"`
+}
@@ -0,0 +1,48 @@
+package dtos
+
+// CreateQueryTemplateRequest saves a query with named parameters (:name or {{name}}) as a template of the chat, either the
+// query text or the message & query to save is required
+type CreateQueryTemplateRequest struct {
+	Name        string                 `json:"name" binding:"required"`
+	Description *string                `json:"description,omitempty"`
+	Query       *string                `json:"query,omitempty"`
+	QueryType   *string                `json:"query_type,omitempty"` // SELECT, INSERT, UPDATE, DELETE...
+	MessageID   *string                `json:"message_id,omitempty"`
+	QueryID     *string                `json:"query_id,omitempty"`
+	Defaults    map[string]interface{} `json:"defaults,omitempty"` // Values of the parameters an execution doesn't send
+}
+
+// UpdateQueryTemplateRequest changes the fields set, the defaults are replaced as a whole
+type UpdateQueryTemplateRequest struct {
+	Name        *string                `json:"name,omitempty"`
+	Description *string                `json:"description,omitempty"`
+	Query       *string                `json:"query,omitempty"`
+	QueryType   *string                `json:"query_type,omitempty"`
+	Defaults    map[string]interface{} `json:"defaults,omitempty"`
+}
+
+// ExecuteQueryTemplateRequest executes a template in a new message of the chat
+type ExecuteQueryTemplateRequest struct {
+	StreamID       string                 `json:"stream_id" binding:"required"`
+	Params         map[string]interface{} `json:"params,omitempty"` // Values of the parameters, merged over the defaults of the template
+	UsePrimary     bool                   `json:"use_primary"`
+	TimeoutSeconds *int                   `json:"timeout_seconds,omitempty" binding:"omitempty,min=1"`
+}
+
+type QueryTemplateResponse struct {
+	ID          string                 `json:"id"`
+	ChatID      string                 `json:"chat_id"`
+	Name        string                 `json:"name"`
+	Description *string                `json:"description,omitempty"`
+	Query       string                 `json:"query"`
+	QueryType   *string                `json:"query_type,omitempty"`
+	Parameters  []string               `json:"parameters"`
+	Defaults    map[string]interface{} `json:"defaults,omitempty"`
+	CreatedAt   string                 `json:"created_at"`
+	UpdatedAt   string                 `json:"updated_at"`
+}
+
+type QueryTemplateListResponse struct {
+	Templates []QueryTemplateResponse `json:"templates"`
+	Total     int64                   `json:"total"`
+}
@@ -1,7 +1,36 @@
 package dtos
 
+import "neobase-ai/internal/apperrors"
+
 type Response struct {
-	Success bool        `json:"success"`
-	Data    interface{} `json:"data,omitempty"`
-	Error   *string     `json:"error,omitempty"`
+	Success     bool         `json:"success"`
+	Data        interface{}  `json:"data,omitempty"`
+	Error       *string      `json:"error,omitempty"`
+	ErrorDetail *ErrorDetail `json:"error_detail,omitempty"` // Machine-readable error, for programmatic handling & localization
+}
+
+// ErrorDetail is the structured error of a failed request, Message is the English fallback of the localized message
+type ErrorDetail struct {
+	Code       string                 `json:"code"`
+	MessageKey string                 `json:"message_key"`
+	Message    string                 `json:"message"`
+	Params     map[string]interface{} `json:"params,omitempty"`
+	Status     int                    `json:"status"`
+}
+
+// NewErrorResponse builds the response of a failed request
+func NewErrorResponse(status int, err error) Response {
+	appErr := apperrors.From(err, status)
+	message := appErr.Error()
+	return Response{
+		Success: false,
+		Error:   &message,
+		ErrorDetail: &ErrorDetail{
+			Code:       appErr.Code,
+			MessageKey: appErr.MessageKey(),
+			Message:    message,
+			Params:     appErr.Params,
+			Status:     status,
+		},
+	}
 }
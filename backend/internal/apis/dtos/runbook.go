@@ -0,0 +1,108 @@
+package dtos
+
+type RunbookStepRequest struct {
+	Name            string  `json:"name" binding:"required"`
+	Type            string  `json:"type" binding:"required,oneof=query pause confirmation health_check"`
+	Query           *string `json:"query,omitempty"`         // Required for query & health_check steps
+	QueryType       *string `json:"query_type,omitempty"`    // SELECT, INSERT, UPDATE, DELETE...
+	PauseSeconds    *int    `json:"pause_seconds,omitempty"` // Required for pause steps
+	Message         *string `json:"message,omitempty"`
+	ExpectEmpty     bool    `json:"expect_empty"`
+	ContinueOnError bool    `json:"continue_on_error"`
+}
+
+type CreateRunbookRequest struct {
+	Name        string               `json:"name" binding:"required"`
+	Description *string              `json:"description,omitempty"`
+	Steps       []RunbookStepRequest `json:"steps" binding:"required,min=1,dive"`
+}
+
+type UpdateRunbookRequest struct {
+	Name        *string              `json:"name,omitempty"`
+	Description *string              `json:"description,omitempty"`
+	Steps       []RunbookStepRequest `json:"steps,omitempty" binding:"omitempty,dive"`
+}
+
+// CreateRunbookFromMessageRequest builds a runbook from the queries the assistant generated in a message
+type CreateRunbookFromMessageRequest struct {
+	MessageID   string  `json:"message_id" binding:"required"`
+	Name        string  `json:"name" binding:"required"`
+	Description *string `json:"description,omitempty"`
+}
+
+type ConfirmRunbookStepRequest struct {
+	Note *string `json:"note,omitempty"`
+}
+
+type ResumeRunbookRunRequest struct {
+	SkipFailedStep bool `json:"skip_failed_step"` // Skip the failed step instead of retrying it
+}
+
+type RunbookStepResponse struct {
+	ID              string  `json:"id"`
+	Name            string  `json:"name"`
+	Type            string  `json:"type"`
+	Query           *string `json:"query,omitempty"`
+	QueryType       *string `json:"query_type,omitempty"`
+	PauseSeconds    *int    `json:"pause_seconds,omitempty"`
+	Message         *string `json:"message,omitempty"`
+	ExpectEmpty     bool    `json:"expect_empty"`
+	ContinueOnError bool    `json:"continue_on_error"`
+}
+
+type RunbookResponse struct {
+	ID          string                `json:"id"`
+	ChatID      string                `json:"chat_id"`
+	Name        string                `json:"name"`
+	Description *string               `json:"description,omitempty"`
+	Steps       []RunbookStepResponse `json:"steps"`
+	CreatedAt   string                `json:"created_at"`
+	UpdatedAt   string                `json:"updated_at"`
+}
+
+type RunbookListResponse struct {
+	Runbooks []RunbookResponse `json:"runbooks"`
+	Total    int64             `json:"total"`
+}
+
+type RunbookStepResultResponse struct {
+	StepID        string      `json:"step_id"`
+	Name          string      `json:"name"`
+	Type          string      `json:"type"`
+	Status        string      `json:"status"`
+	Attempts      int         `json:"attempts"`
+	Result        interface{} `json:"result,omitempty"`
+	Error         *QueryError `json:"error,omitempty"`
+	Note          *string     `json:"note,omitempty"`
+	Message       *string     `json:"message,omitempty"`
+	ExecutionTime *int        `json:"execution_time,omitempty"`
+	StartedAt     *string     `json:"started_at,omitempty"`
+	CompletedAt   *string     `json:"completed_at,omitempty"`
+}
+
+type RunbookRunResponse struct {
+	ID          string                      `json:"id"`
+	RunbookID   string                      `json:"runbook_id"`
+	RunbookName string                      `json:"runbook_name"`
+	Status      string                      `json:"status"`
+	CurrentStep int                         `json:"current_step"`
+	Steps       []RunbookStepResultResponse `json:"steps"`
+	StartedAt   string                      `json:"started_at"`
+	CompletedAt *string                     `json:"completed_at,omitempty"`
+}
+
+type RunbookRunListResponse struct {
+	Runs  []RunbookRunResponse `json:"runs"`
+	Total int64                `json:"total"`
+}
+
+type RunbookReportResponse struct {
+	RunbookRunResponse
+	TotalSteps int    `json:"total_steps"`
+	Succeeded  int    `json:"succeeded"`
+	Failed     int    `json:"failed"`
+	Skipped    int    `json:"skipped"`
+	Pending    int    `json:"pending"`
+	Duration   int64  `json:"duration"` // in milliseconds
+	Summary    string `json:"summary"`  // Markdown summary of the run
+}
@@ -0,0 +1,55 @@
+package dtos
+
+// CreateScheduledQueryRequest schedules a bookmarked query, or a query of a message, to run on a cron expression
+type CreateScheduledQueryRequest struct {
+	ChatID     string   `json:"chat_id" binding:"required"`
+	BookmarkID *string  `json:"bookmark_id,omitempty"` // Either the bookmark or the message & query to schedule
+	MessageID  *string  `json:"message_id,omitempty"`
+	QueryID    *string  `json:"query_id,omitempty"`
+	Name       string   `json:"name" binding:"required"`
+	Cron       string   `json:"cron" binding:"required"` // Standard 5 fields expression or a descriptor, e.g. "0 8 * * 1-5" or "@daily"
+	Timezone   *string  `json:"timezone,omitempty"`      // IANA name the expression is evaluated in, UTC by default
+	UsePrimary bool     `json:"use_primary"`             // Run a read-only query on the primary instead of a read replica
+	WebhookURL *string  `json:"webhook_url,omitempty"`   // Receives the outcome of each run as a JSON POST
+	EmailTo    []string `json:"email_to,omitempty"`      // Addresses the outcome of each run is emailed to, needs SMTP_HOST
+	Enabled    *bool    `json:"enabled,omitempty"`       // Defaults to true
+}
+
+// UpdateScheduledQueryRequest changes the fields set, an empty webhook_url removes the webhook & an empty email_to the emails
+type UpdateScheduledQueryRequest struct {
+	Name       *string   `json:"name,omitempty"`
+	Cron       *string   `json:"cron,omitempty"`
+	Timezone   *string   `json:"timezone,omitempty"`
+	UsePrimary *bool     `json:"use_primary,omitempty"`
+	WebhookURL *string   `json:"webhook_url,omitempty"`
+	EmailTo    *[]string `json:"email_to,omitempty"`
+	Enabled    *bool     `json:"enabled,omitempty"`
+}
+
+type ScheduledQueryResponse struct {
+	ID            string      `json:"id"`
+	ChatID        string      `json:"chat_id"`
+	BookmarkID    *string     `json:"bookmark_id,omitempty"`
+	Name          string      `json:"name"`
+	Query         string      `json:"query"`
+	QueryType     *string     `json:"query_type,omitempty"`
+	Cron          string      `json:"cron"`
+	Timezone      string      `json:"timezone"`
+	UsePrimary    bool        `json:"use_primary"`
+	HasWebhook    bool        `json:"has_webhook"`
+	EmailTo       []string    `json:"email_to,omitempty"`
+	Enabled       bool        `json:"enabled"`
+	NextRunAt     *string     `json:"next_run_at,omitempty"`
+	LastRunAt     *string     `json:"last_run_at,omitempty"`
+	LastStatus    *string     `json:"last_status,omitempty"` // succeeded or failed
+	LastError     *QueryError `json:"last_error,omitempty"`
+	LastMessageID *string     `json:"last_message_id,omitempty"` // Message of the chat holding the result of the last run
+	RunCount      int         `json:"run_count"`
+	CreatedAt     string      `json:"created_at"`
+	UpdatedAt     string      `json:"updated_at"`
+}
+
+type ScheduledQueryListResponse struct {
+	Schedules []ScheduledQueryResponse `json:"schedules"`
+	Total     int64                    `json:"total"`
+}
@@ -0,0 +1,28 @@
+package dtos
+
+import "neobase-ai/internal/models"
+
+type SchemaVersionResponse struct {
+	ID           string                `json:"id"`
+	ChatID       string                `json:"chat_id"`
+	ConnectionID *string               `json:"connection_id,omitempty"`
+	DatabaseType string                `json:"database_type"`
+	Database     string                `json:"database"`
+	Checksum     string                `json:"checksum"`
+	Tables       int                   `json:"tables"`
+	Changes      *models.SchemaChanges `json:"changes,omitempty"` // Changes from the previous version, none for the first one
+	CapturedAt   string                `json:"captured_at"`
+}
+
+type SchemaVersionListResponse struct {
+	Versions []SchemaVersionResponse `json:"versions"`
+	Total    int64                   `json:"total"`
+}
+
+// SchemaVersionDiffResponse is what changed in the schema from a version to another, From may be the later one
+type SchemaVersionDiffResponse struct {
+	From       SchemaVersionResponse `json:"from"`
+	To         SchemaVersionResponse `json:"to"`
+	HasChanges bool                  `json:"has_changes"`
+	Changes    models.SchemaChanges  `json:"changes"`
+}
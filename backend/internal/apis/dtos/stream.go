@@ -1,6 +1,6 @@
 package dtos
 
 type StreamResponse struct {
-	Event string      `json:"event"` // ai-response, ai-response-step, ai-response-error, db-connected, db-disconnected, sse-connected, response-cancelled, query-results, rollback-executed, rollback-query-failed
+	Event string      `json:"event"` // ai-response, ai-response-step, ai-response-error, db-connected, db-disconnected, sse-connected, response-cancelled, query-results, rollback-executed, rollback-query-failed, notification, query-job-finished, query-result-file
 	Data  interface{} `json:"data,omitempty"`
 }
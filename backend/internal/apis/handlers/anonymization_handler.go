@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AnonymizationHandler struct {
+	anonymizationService services.AnonymizationService
+}
+
+func NewAnonymizationHandler(anonymizationService services.AnonymizationService) *AnonymizationHandler {
+	return &AnonymizationHandler{
+		anonymizationService: anonymizationService,
+	}
+}
+
+// @Summary Create an anonymization job
+// @Description Clone tables from a source connection to a target connection with masking rules per column, the copy runs in the background
+// @Accept json
+// @Produce json
+// @Param createAnonymizationJobRequest body dtos.CreateAnonymizationJobRequest true "Create anonymization job request"
+
+func (h *AnonymizationHandler) Create(c *gin.Context) {
+	var req dtos.CreateAnonymizationJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.InvalidRequest(err)))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	response, statusCode, err := h.anonymizationService.Create(userID, &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary List anonymization jobs
+// @Description List the anonymization jobs of the user
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(10)
+
+func (h *AnonymizationHandler) List(c *gin.Context) {
+	userID := c.GetString("userID")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	response, statusCode, err := h.anonymizationService.List(userID, page, pageSize)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Get an anonymization job
+// @Description Get the state & progress of an anonymization job
+// @Accept json
+// @Produce json
+// @Param jobId path string true "Job ID"
+
+func (h *AnonymizationHandler) Get(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	response, statusCode, err := h.anonymizationService.Get(userID, c.Param("jobId"))
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Cancel an anonymization job
+// @Description Cancel an anonymization job, the rows already copied stay in the target
+// @Accept json
+// @Produce json
+// @Param jobId path string true "Job ID"
+
+func (h *AnonymizationHandler) Cancel(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	response, statusCode, err := h.anonymizationService.Cancel(userID, c.Param("jobId"))
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Get an anonymization job report
+// @Description Get the verification report of an anonymization job
+// @Accept json
+// @Produce json
+// @Param jobId path string true "Job ID"
+
+func (h *AnonymizationHandler) GetReport(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	response, statusCode, err := h.anonymizationService.GetReport(userID, c.Param("jobId"))
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
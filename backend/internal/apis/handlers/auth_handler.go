@@ -3,6 +3,7 @@ package handlers
 import (
 	"log"
 	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
 	"neobase-ai/internal/services"
 	"net/http"
 	"strings"
@@ -33,11 +34,7 @@ func NewAuthHandler(authService services.AuthService) *AuthHandler {
 func (h *AuthHandler) Signup(c *gin.Context) {
 	var req dtos.SignupRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errorMsg := err.Error()
-		c.JSON(http.StatusBadRequest, dtos.Response{
-			Success: false,
-			Error:   &errorMsg,
-		})
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.InvalidRequest(err)))
 		return
 	}
 
@@ -46,11 +43,7 @@ func (h *AuthHandler) Signup(c *gin.Context) {
 	}
 	response, statusCode, err := h.authService.Signup(&req)
 	if err != nil {
-		errorMsg := err.Error()
-		c.JSON(int(statusCode), dtos.Response{
-			Success: false,
-			Error:   &errorMsg,
-		})
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
 		return
 	}
 
@@ -69,21 +62,13 @@ func (h *AuthHandler) Signup(c *gin.Context) {
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req dtos.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errorMsg := err.Error()
-		c.JSON(http.StatusBadRequest, dtos.Response{
-			Success: false,
-			Error:   &errorMsg,
-		})
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.InvalidRequest(err)))
 		return
 	}
 
 	response, statusCode, err := h.authService.Login(&req)
 	if err != nil {
-		errorMsg := err.Error()
-		c.JSON(int(statusCode), dtos.Response{
-			Success: false,
-			Error:   &errorMsg,
-		})
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
 		return
 	}
 
@@ -103,21 +88,13 @@ func (h *AuthHandler) Login(c *gin.Context) {
 func (h *AuthHandler) GenerateUserSignupSecret(c *gin.Context) {
 	var req dtos.UserSignupSecretRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errorMsg := err.Error()
-		c.JSON(http.StatusBadRequest, dtos.Response{
-			Success: false,
-			Error:   &errorMsg,
-		})
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.InvalidRequest(err)))
 		return
 	}
 
 	response, statusCode, err := h.authService.GenerateUserSignupSecret(&req)
 	if err != nil {
-		errorMsg := err.Error()
-		c.JSON(int(statusCode), dtos.Response{
-			Success: false,
-			Error:   &errorMsg,
-		})
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
 		return
 	}
 
@@ -138,22 +115,14 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	refreshToken := c.GetHeader("Authorization")
 	parts := strings.Split(refreshToken, " ")
 	if len(parts) != 2 || parts[0] != "Bearer" {
-		errorMsg := "Invalid authorization header"
-		c.JSON(http.StatusBadRequest, dtos.Response{
-			Success: false,
-			Error:   &errorMsg,
-		})
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.New("INVALID_AUTHORIZATION_HEADER", "Invalid authorization header")))
 		return
 	}
 	refreshToken = parts[1]
 
 	response, statusCode, err := h.authService.RefreshToken(refreshToken)
 	if err != nil {
-		errorMsg := err.Error()
-		c.JSON(int(statusCode), dtos.Response{
-			Success: false,
-			Error:   &errorMsg,
-		})
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
 		return
 	}
 
@@ -173,11 +142,7 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 func (h *AuthHandler) Logout(c *gin.Context) {
 	var req dtos.LogoutRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errorMsg := err.Error()
-		c.JSON(http.StatusBadRequest, dtos.Response{
-			Success: false,
-			Error:   &errorMsg,
-		})
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.InvalidRequest(err)))
 		return
 	}
 
@@ -185,22 +150,14 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 	authHeader := c.GetHeader("Authorization")
 	parts := strings.Split(authHeader, " ")
 	if len(parts) != 2 || parts[0] != "Bearer" {
-		errorMsg := "Invalid authorization header"
-		c.JSON(http.StatusBadRequest, dtos.Response{
-			Success: false,
-			Error:   &errorMsg,
-		})
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.New("INVALID_AUTHORIZATION_HEADER", "Invalid authorization header")))
 		return
 	}
 	accessToken := parts[1]
 
 	statusCode, err := h.authService.Logout(req.RefreshToken, accessToken)
 	if err != nil {
-		errorMsg := err.Error()
-		c.JSON(int(statusCode), dtos.Response{
-			Success: false,
-			Error:   &errorMsg,
-		})
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
 		return
 	}
 
@@ -219,11 +176,7 @@ func (h *AuthHandler) GetUser(c *gin.Context) {
 	userID := c.GetString("userID")
 	user, statusCode, err := h.authService.GetUser(userID)
 	if err != nil {
-		errorMsg := err.Error()
-		c.JSON(int(statusCode), dtos.Response{
-			Success: false,
-			Error:   &errorMsg,
-		})
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
 		return
 	}
 
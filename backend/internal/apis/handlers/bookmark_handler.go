@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BookmarkHandler struct {
+	bookmarkService services.BookmarkService
+}
+
+func NewBookmarkHandler(bookmarkService services.BookmarkService) *BookmarkHandler {
+	return &BookmarkHandler{
+		bookmarkService: bookmarkService,
+	}
+}
+
+// @Summary Bookmark a query execution
+// @Description Bookmark a query execution with its result snapshot & generate a shareable deep link
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param createBookmarkRequest body dtos.CreateBookmarkRequest true "Create bookmark request"
+// @Success 201 {object} dtos.Response
+
+func (h *BookmarkHandler) Create(c *gin.Context) {
+	var req dtos.CreateBookmarkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.InvalidRequest(err)))
+		return
+	}
+
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	response, statusCode, err := h.bookmarkService.Create(userID, chatID, &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary List bookmarks
+// @Description List all bookmarks of a chat
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(10)
+
+func (h *BookmarkHandler) List(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	response, statusCode, err := h.bookmarkService.List(userID, chatID, page, pageSize)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Delete a bookmark
+// @Description Delete a bookmark & revoke its deep link
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param bookmarkId path string true "Bookmark ID"
+
+func (h *BookmarkHandler) Delete(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+	bookmarkID := c.Param("bookmarkId")
+
+	statusCode, err := h.bookmarkService.Delete(userID, chatID, bookmarkID)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    "Bookmark deleted successfully",
+	})
+}
+
+// @Summary Open a shared bookmark
+// @Description Open a bookmarked query execution through its deep link token
+// @Accept json
+// @Produce json
+// @Param token path string true "Share token"
+
+func (h *BookmarkHandler) GetByShareToken(c *gin.Context) {
+	userID := c.GetString("userID")
+	token := c.Param("token")
+
+	response, statusCode, err := h.bookmarkService.GetByShareToken(userID, token)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
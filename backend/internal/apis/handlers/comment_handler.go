@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CommentHandler struct {
+	commentService services.CommentService
+}
+
+func NewCommentHandler(commentService services.CommentService) *CommentHandler {
+	return &CommentHandler{
+		commentService: commentService,
+	}
+}
+
+// @Summary Comment on a query
+// @Description Add a comment or a reply to a query, optionally attached to specific result rows
+// @Accept json
+// @Produce json
+// @Param id path string true "Query ID"
+// @Param createCommentRequest body dtos.CreateCommentRequest true "Create comment request"
+// @Success 201 {object} dtos.Response
+
+func (h *CommentHandler) Create(c *gin.Context) {
+	var req dtos.CreateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.InvalidRequest(err)))
+		return
+	}
+
+	userID := c.GetString("userID")
+	queryID := c.Param("id")
+
+	response, statusCode, err := h.commentService.Create(userID, queryID, &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary List query comments
+// @Description List the comment threads of a query
+// @Accept json
+// @Produce json
+// @Param id path string true "Query ID"
+
+func (h *CommentHandler) List(c *gin.Context) {
+	userID := c.GetString("userID")
+	queryID := c.Param("id")
+
+	response, statusCode, err := h.commentService.List(userID, queryID)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Update a comment
+// @Description Edit the content of a comment
+// @Accept json
+// @Produce json
+// @Param id path string true "Query ID"
+// @Param commentId path string true "Comment ID"
+
+func (h *CommentHandler) Update(c *gin.Context) {
+	var req dtos.UpdateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.InvalidRequest(err)))
+		return
+	}
+
+	userID := c.GetString("userID")
+	queryID := c.Param("id")
+	commentID := c.Param("commentId")
+
+	response, statusCode, err := h.commentService.Update(userID, queryID, commentID, &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Delete a comment
+// @Description Delete a comment along with its replies
+// @Accept json
+// @Produce json
+// @Param id path string true "Query ID"
+// @Param commentId path string true "Comment ID"
+
+func (h *CommentHandler) Delete(c *gin.Context) {
+	userID := c.GetString("userID")
+	queryID := c.Param("id")
+	commentID := c.Param("commentId")
+
+	statusCode, err := h.commentService.Delete(userID, queryID, commentID)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    "Comment deleted successfully",
+	})
+}
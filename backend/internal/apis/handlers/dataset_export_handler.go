@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DatasetExportHandler struct {
+	datasetExportService services.DatasetExportService
+}
+
+func NewDatasetExportHandler(datasetExportService services.DatasetExportService) *DatasetExportHandler {
+	return &DatasetExportHandler{
+		datasetExportService: datasetExportService,
+	}
+}
+
+// @Summary Export fine-tuning dataset
+// @Description Export the questions asked in the chats with the schema excerpt, the generated query, its execution outcome & the user feedback as anonymized JSONL records, to fine-tune or evaluate custom models offline
+// @Accept json
+// @Produce application/x-ndjson
+// @Param since query string false "First day of the period as YYYY-MM-DD"
+// @Param until query string false "Last day of the period as YYYY-MM-DD"
+// @Param executed_only query bool false "Only export the queries that were executed"
+
+func (h *DatasetExportHandler) ExportFineTuningDataset(c *gin.Context) {
+	// The records are streamed as they are read, the headers are sent with the first one so that errors before it are still JSON
+	started := false
+	start := func() {
+		started = true
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=fine-tuning-dataset-%s.jsonl", time.Now().Format("20060102")))
+		c.Status(http.StatusOK)
+	}
+	encoder := json.NewEncoder(c.Writer)
+	write := func(record *dtos.FineTuningRecord) error {
+		if !started {
+			start()
+		}
+		return encoder.Encode(record)
+	}
+
+	statusCode, err := h.datasetExportService.ExportFineTuningDataset(c.Request.Context(), c.Query("since"), c.Query("until"), c.Query("executed_only") == "true", write)
+	if err != nil {
+		if !started {
+			c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		}
+		return
+	}
+	if !started {
+		// An empty dataset is still a file
+		start()
+	}
+}
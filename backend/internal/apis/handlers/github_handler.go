@@ -27,11 +27,7 @@ func NewGitHubHandler(githubService services.GitHubService) *GitHubHandler {
 func (h *GitHubHandler) GetGitHubStats(c *gin.Context) {
 	stats, err := h.githubService.GetStats(c.Request.Context())
 	if err != nil {
-		errorMsg := err.Error()
-		c.JSON(http.StatusBadRequest, dtos.Response{
-			Success: false,
-			Error:   &errorMsg,
-		})
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, err))
 		return
 	}
 
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LineageHandler struct {
+	lineageService services.LineageService
+}
+
+func NewLineageHandler(lineageService services.LineageService) *LineageHandler {
+	return &LineageHandler{
+		lineageService: lineageService,
+	}
+}
+
+// @Summary Get lineage graph
+// @Description Get the upstream lineage of a table or column, i.e. where its data came from through the executed queries
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param table query string true "Table name"
+// @Param column query string false "Column name, table level lineage if not provided"
+// @Param depth query int false "Number of hops to walk upstream" default(3)
+
+func (h *LineageHandler) GetGraph(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+	depth, _ := strconv.Atoi(c.DefaultQuery("depth", "3"))
+
+	response, statusCode, err := h.lineageService.GetGraph(userID, chatID, c.Query("table"), c.Query("column"), depth)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary List lineage events
+// @Description List the recorded lineage events of a chat, optionally only the ones writing a table or column
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param table query string false "Table name"
+// @Param column query string false "Column name"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(20)
+
+func (h *LineageHandler) ListEvents(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	response, statusCode, err := h.lineageService.ListEvents(userID, chatID, c.Query("table"), c.Query("column"), page, pageSize)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
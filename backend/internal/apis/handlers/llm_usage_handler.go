@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LLMUsageHandler struct {
+	llmUsageService services.LLMUsageService
+}
+
+func NewLLMUsageHandler(llmUsageService services.LLMUsageService) *LLMUsageHandler {
+	return &LLMUsageHandler{
+		llmUsageService: llmUsageService,
+	}
+}
+
+// @Summary Get user LLM usage
+// @Description Get the tokens & the estimated cost of the LLM calls of the user over a month, with the remaining monthly token quota if one is set
+// @Accept json
+// @Produce json
+// @Param month query string false "Month as YYYY-MM, the current month if not provided"
+
+func (h *LLMUsageHandler) GetUserUsage(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	response, statusCode, err := h.llmUsageService.GetUserUsage(userID, c.Query("month"))
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Get chat LLM usage
+// @Description Get the tokens & the estimated cost of the LLM calls of a chat, per model
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+
+func (h *LLMUsageHandler) GetChatUsage(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	response, statusCode, err := h.llmUsageService.GetChatUsage(userID, chatID)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MetricsHandler struct {
+	metricsService services.MetricsService
+}
+
+func NewMetricsHandler(metricsService services.MetricsService) *MetricsHandler {
+	return &MetricsHandler{
+		metricsService: metricsService,
+	}
+}
+
+// @Summary Get server metrics
+// @Description Get the LLM requests per model since the server started (duration, tokens, retries & errors per class) and the database connection pools, for capacity planning
+// @Accept json
+// @Produce json
+
+func (h *MetricsHandler) GetMetrics(c *gin.Context) {
+	response, statusCode, err := h.metricsService.GetMetrics()
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	notificationService services.NotificationService
+}
+
+func NewNotificationHandler(notificationService services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+	}
+}
+
+// @Summary List notifications
+// @Description List the notifications of the user, newest first
+// @Accept json
+// @Produce json
+// @Param unread_only query bool false "Only unread notifications" default(false)
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(20)
+
+func (h *NotificationHandler) List(c *gin.Context) {
+	userID := c.GetString("userID")
+	unreadOnly := c.Query("unread_only") == "true"
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	response, statusCode, err := h.notificationService.List(userID, unreadOnly, page, pageSize)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Mark notifications as read
+// @Description Mark the given notifications of the user as read
+// @Accept json
+// @Produce json
+// @Param markNotificationsReadRequest body dtos.MarkNotificationsReadRequest true "Mark notifications read request"
+
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	var req dtos.MarkNotificationsReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.InvalidRequest(err)))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	response, statusCode, err := h.notificationService.MarkRead(userID, &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Mark all notifications as read
+// @Description Mark every notification of the user as read
+// @Accept json
+// @Produce json
+
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	response, statusCode, err := h.notificationService.MarkAllRead(userID)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
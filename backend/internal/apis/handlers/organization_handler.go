@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrganizationHandler exposes the admin API managing organizations & their LLM provider credentials
+type OrganizationHandler struct {
+	organizationService services.OrganizationService
+}
+
+func NewOrganizationHandler(organizationService services.OrganizationService) *OrganizationHandler {
+	return &OrganizationHandler{
+		organizationService: organizationService,
+	}
+}
+
+// @Summary Create an organization
+// @Description Create an organization with its initial members
+// @Accept json
+// @Produce json
+// @Param createOrganizationRequest body dtos.CreateOrganizationRequest true "Create organization request"
+
+func (h *OrganizationHandler) Create(c *gin.Context) {
+	var req dtos.CreateOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.InvalidRequest(err)))
+		return
+	}
+
+	response, statusCode, err := h.organizationService.Create(&req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary List organizations
+// @Description List the organizations with their members & LLM providers
+// @Accept json
+// @Produce json
+// @Param page query int false "Page"
+// @Param page_size query int false "Page size"
+
+func (h *OrganizationHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	response, statusCode, err := h.organizationService.List(page, pageSize)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Get an organization
+// @Description Get an organization with its members & LLM providers, API keys are masked
+// @Accept json
+// @Produce json
+// @Param organizationId path string true "Organization ID"
+
+func (h *OrganizationHandler) Get(c *gin.Context) {
+	response, statusCode, err := h.organizationService.Get(c.Param("organizationId"))
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Update an organization
+// @Description Rename an organization or change the LLM provider used by its members
+// @Accept json
+// @Produce json
+// @Param organizationId path string true "Organization ID"
+// @Param updateOrganizationRequest body dtos.UpdateOrganizationRequest true "Update organization request"
+
+func (h *OrganizationHandler) Update(c *gin.Context) {
+	var req dtos.UpdateOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.InvalidRequest(err)))
+		return
+	}
+
+	response, statusCode, err := h.organizationService.Update(c.Param("organizationId"), &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Delete an organization
+// @Description Delete an organization, its members fall back to the default LLM provider
+// @Accept json
+// @Produce json
+// @Param organizationId path string true "Organization ID"
+
+func (h *OrganizationHandler) Delete(c *gin.Context) {
+	statusCode, err := h.organizationService.Delete(c.Param("organizationId"))
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    "Organization deleted successfully",
+	})
+}
+
+// @Summary Add an organization member
+// @Description Add a user to an organization, a user can only be part of one organization
+// @Accept json
+// @Produce json
+// @Param organizationId path string true "Organization ID"
+// @Param organizationMemberRequest body dtos.OrganizationMemberRequest true "Organization member request"
+
+func (h *OrganizationHandler) AddMember(c *gin.Context) {
+	var req dtos.OrganizationMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.InvalidRequest(err)))
+		return
+	}
+
+	response, statusCode, err := h.organizationService.AddMember(c.Param("organizationId"), &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Remove an organization member
+// @Description Remove a user from an organization
+// @Accept json
+// @Produce json
+// @Param organizationId path string true "Organization ID"
+// @Param userId path string true "User ID"
+
+func (h *OrganizationHandler) RemoveMember(c *gin.Context) {
+	response, statusCode, err := h.organizationService.RemoveMember(c.Param("organizationId"), c.Param("userId"))
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Configure an LLM provider
+// @Description Store the API key (encrypted) & the allowed models of an LLM provider for an organization
+// @Accept json
+// @Produce json
+// @Param organizationId path string true "Organization ID"
+// @Param provider path string true "LLM provider (openai, gemini, claude)"
+// @Param setOrganizationLLMProviderRequest body dtos.SetOrganizationLLMProviderRequest true "Set LLM provider request"
+
+func (h *OrganizationHandler) SetLLMProvider(c *gin.Context) {
+	var req dtos.SetOrganizationLLMProviderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.InvalidRequest(err)))
+		return
+	}
+
+	response, statusCode, err := h.organizationService.SetLLMProvider(c.Param("organizationId"), c.Param("provider"), &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Remove an LLM provider
+// @Description Remove the credentials of an LLM provider from an organization
+// @Accept json
+// @Produce json
+// @Param organizationId path string true "Organization ID"
+// @Param provider path string true "LLM provider (openai, gemini, claude)"
+
+func (h *OrganizationHandler) RemoveLLMProvider(c *gin.Context) {
+	response, statusCode, err := h.organizationService.RemoveLLMProvider(c.Param("organizationId"), c.Param("provider"))
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PromptTemplateHandler exposes the admin API editing the system prompts of the database types
+type PromptTemplateHandler struct {
+	promptTemplateService services.PromptTemplateService
+}
+
+func NewPromptTemplateHandler(promptTemplateService services.PromptTemplateService) *PromptTemplateHandler {
+	return &PromptTemplateHandler{
+		promptTemplateService: promptTemplateService,
+	}
+}
+
+// @Summary List active prompt templates
+// @Description List the active prompt templates, the database types without one use their built-in prompt
+// @Accept json
+// @Produce json
+
+func (h *PromptTemplateHandler) ListActive(c *gin.Context) {
+	response, statusCode, err := h.promptTemplateService.ListActive()
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary List prompt template versions
+// @Description List the versions of the prompt template of a database type, with the built-in prompt
+// @Accept json
+// @Produce json
+// @Param dbType path string true "Database type"
+// @Param provider query string false "LLM provider, the template of every provider if not provided"
+
+func (h *PromptTemplateHandler) GetVersions(c *gin.Context) {
+	response, statusCode, err := h.promptTemplateService.GetVersions(c.Param("dbType"), c.Query("provider"))
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Create a prompt template version
+// @Description Create the next version of the prompt template of a database type, it becomes the active one unless activate is false
+// @Accept json
+// @Produce json
+// @Param dbType path string true "Database type"
+// @Param createPromptTemplateRequest body dtos.CreatePromptTemplateRequest true "Create prompt template request"
+
+func (h *PromptTemplateHandler) CreateVersion(c *gin.Context) {
+	var req dtos.CreatePromptTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.InvalidRequest(err)))
+		return
+	}
+
+	response, statusCode, err := h.promptTemplateService.CreateVersion(c.GetString("userID"), c.Param("dbType"), &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Activate a prompt template version
+// @Description Make a version the active prompt template of a database type, e.g. to roll an edit back
+// @Accept json
+// @Produce json
+// @Param dbType path string true "Database type"
+// @Param version path int true "Version"
+// @Param provider query string false "LLM provider, the template of every provider if not provided"
+
+func (h *PromptTemplateHandler) ActivateVersion(c *gin.Context) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.New("INVALID_PROMPT_TEMPLATE_VERSION", "invalid prompt template version")))
+		return
+	}
+
+	response, statusCode, err := h.promptTemplateService.ActivateVersion(c.Param("dbType"), c.Query("provider"), version)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Reset a prompt template
+// @Description Deactivate the prompt template of a database type, the built-in prompt is used again. The versions are kept.
+// @Accept json
+// @Produce json
+// @Param dbType path string true "Database type"
+// @Param provider query string false "LLM provider, the template of every provider if not provided"
+
+func (h *PromptTemplateHandler) ResetToBuiltIn(c *gin.Context) {
+	statusCode, err := h.promptTemplateService.ResetToBuiltIn(c.Param("dbType"), c.Query("provider"))
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    "Prompt template reset to the built-in prompt",
+	})
+}
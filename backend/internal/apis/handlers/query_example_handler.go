@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QueryExampleHandler struct {
+	queryExampleService services.QueryExampleService
+}
+
+func NewQueryExampleHandler(queryExampleService services.QueryExampleService) *QueryExampleHandler {
+	return &QueryExampleHandler{
+		queryExampleService: queryExampleService,
+	}
+}
+
+// @Summary Register a query example
+// @Description Register a question & the query answering it, the most relevant examples are given to the LLM
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param createQueryExampleRequest body dtos.CreateQueryExampleRequest true "Create query example request"
+// @Success 201 {object} dtos.Response
+
+func (h *QueryExampleHandler) Create(c *gin.Context) {
+	var req dtos.CreateQueryExampleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.InvalidRequest(err)))
+		return
+	}
+
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	response, statusCode, err := h.queryExampleService.Create(userID, chatID, &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary List query examples
+// @Description List the query examples of a chat, including the ones shared through its saved connection
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+
+func (h *QueryExampleHandler) List(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	response, statusCode, err := h.queryExampleService.List(userID, chatID)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Update a query example
+// @Description Update the question, query or explanation of a query example
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param exampleId path string true "Query example ID"
+// @Param updateQueryExampleRequest body dtos.UpdateQueryExampleRequest true "Update query example request"
+
+func (h *QueryExampleHandler) Update(c *gin.Context) {
+	var req dtos.UpdateQueryExampleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.InvalidRequest(err)))
+		return
+	}
+
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+	exampleID := c.Param("exampleId")
+
+	response, statusCode, err := h.queryExampleService.Update(userID, chatID, exampleID, &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Delete a query example
+// @Description Delete a query example, it's no longer given to the LLM
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param exampleId path string true "Query example ID"
+
+func (h *QueryExampleHandler) Delete(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+	exampleID := c.Param("exampleId")
+
+	statusCode, err := h.queryExampleService.Delete(userID, chatID, exampleID)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    "Query example deleted successfully",
+	})
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QueryHistoryHandler struct {
+	queryHistoryService services.QueryHistoryService
+}
+
+func NewQueryHistoryHandler(queryHistoryService services.QueryHistoryService) *QueryHistoryHandler {
+	return &QueryHistoryHandler{
+		queryHistoryService: queryHistoryService,
+	}
+}
+
+// @Summary List the query history
+// @Description List the queries the user executed from the chats with their duration, rows & status, most recent first
+// @Accept json
+// @Produce json
+// @Param chat_id query string false "Only the queries of the chat"
+// @Param connection_id query string false "Only the queries of the chats using the saved connection"
+// @Param database_type query string false "Only the queries of the database type, e.g. postgresql"
+// @Param status query string false "succeeded or failed"
+// @Param is_rollback query bool false "Only the rollbacks, or only the executions"
+// @Param from query string false "RFC3339 time or YYYY-MM-DD date the queries were executed from"
+// @Param to query string false "RFC3339 time or YYYY-MM-DD date the queries were executed until"
+// @Param q query string false "Text the query contains"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(20)
+
+func (h *QueryHistoryHandler) List(c *gin.Context) {
+	userID := c.GetString("userID")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	req := dtos.QueryHistoryListRequest{
+		ChatID:       c.Query("chat_id"),
+		ConnectionID: c.Query("connection_id"),
+		DatabaseType: c.Query("database_type"),
+		Status:       c.Query("status"),
+		From:         c.Query("from"),
+		To:           c.Query("to"),
+		Search:       c.Query("q"),
+		Page:         page,
+		PageSize:     pageSize,
+	}
+	if value := c.Query("is_rollback"); value != "" {
+		isRollback, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.InvalidRequest(err)))
+			return
+		}
+		req.IsRollback = &isRollback
+	}
+
+	response, statusCode, err := h.queryHistoryService.List(userID, &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Diff two executions
+// @Description Compare the results of two executions of the history, of the same query or of two queries, e.g. before & after a fix. The rows are matched by the value of key_column and reported as added, removed or changed.
+// @Accept json
+// @Produce json
+// @Param diffRequest body dtos.QueryHistoryDiffRequest true "Executions to compare"
+
+func (h *QueryHistoryHandler) Diff(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	var req dtos.QueryHistoryDiffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.InvalidRequest(err)))
+		return
+	}
+
+	response, statusCode, err := h.queryHistoryService.DiffExecutions(userID, &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QueryJobHandler struct {
+	queryJobService services.QueryJobService
+}
+
+func NewQueryJobHandler(queryJobService services.QueryJobService) *QueryJobHandler {
+	return &QueryJobHandler{
+		queryJobService: queryJobService,
+	}
+}
+
+// @Summary Create a query job
+// @Description Execute a query of a message in the background, for long-running queries. The job is polled or its query-job-finished event is sent to the stream.
+// @Accept json
+// @Produce json
+// @Param createQueryJobRequest body dtos.CreateQueryJobRequest true "Create query job request"
+
+func (h *QueryJobHandler) Create(c *gin.Context) {
+	var req dtos.CreateQueryJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.InvalidRequest(err)))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	response, statusCode, err := h.queryJobService.Create(userID, &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary List query jobs
+// @Description List the query jobs of the user, most recent first
+// @Accept json
+// @Produce json
+// @Param chat_id query string false "Only the jobs of the chat"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(10)
+
+func (h *QueryJobHandler) List(c *gin.Context) {
+	userID := c.GetString("userID")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	response, statusCode, err := h.queryJobService.List(userID, c.Query("chat_id"), page, pageSize)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Get a query job
+// @Description Get the status of a query job, with the result of the query once completed
+// @Accept json
+// @Produce json
+// @Param jobId path string true "Job ID"
+
+func (h *QueryJobHandler) Get(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	response, statusCode, err := h.queryJobService.Get(userID, c.Param("jobId"))
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Cancel a query job
+// @Description Cancel a queued query job or stop its running query
+// @Accept json
+// @Produce json
+// @Param jobId path string true "Job ID"
+
+func (h *QueryJobHandler) Cancel(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	response, statusCode, err := h.queryJobService.Cancel(userID, c.Param("jobId"))
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QueryTemplateHandler struct {
+	queryTemplateService services.QueryTemplateService
+}
+
+func NewQueryTemplateHandler(queryTemplateService services.QueryTemplateService) *QueryTemplateHandler {
+	return &QueryTemplateHandler{
+		queryTemplateService: queryTemplateService,
+	}
+}
+
+// @Summary Create a query template
+// @Description Save a query with named parameters (:name or {{name}}) as a template of the chat, from its text or a query of a message
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param createQueryTemplateRequest body dtos.CreateQueryTemplateRequest true "Create query template request"
+// @Success 201 {object} dtos.Response
+
+func (h *QueryTemplateHandler) Create(c *gin.Context) {
+	var req dtos.CreateQueryTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.InvalidRequest(err)))
+		return
+	}
+
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	response, statusCode, err := h.queryTemplateService.Create(userID, chatID, &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary List query templates
+// @Description List the query templates of a chat by name
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(10)
+
+func (h *QueryTemplateHandler) List(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	response, statusCode, err := h.queryTemplateService.List(userID, chatID, page, pageSize)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Get a query template
+// @Description Get a query template with the names of its parameters
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param templateId path string true "Template ID"
+
+func (h *QueryTemplateHandler) Get(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	response, statusCode, err := h.queryTemplateService.Get(userID, c.Param("id"), c.Param("templateId"))
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Update a query template
+// @Description Change the name, the description, the query or the default parameter values of a query template
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param templateId path string true "Template ID"
+// @Param updateQueryTemplateRequest body dtos.UpdateQueryTemplateRequest true "Update query template request"
+
+func (h *QueryTemplateHandler) Update(c *gin.Context) {
+	var req dtos.UpdateQueryTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.InvalidRequest(err)))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	response, statusCode, err := h.queryTemplateService.Update(userID, c.Param("id"), c.Param("templateId"), &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Delete a query template
+// @Description Delete a query template, the messages of its past executions are kept
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param templateId path string true "Template ID"
+
+func (h *QueryTemplateHandler) Delete(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	statusCode, err := h.queryTemplateService.Delete(userID, c.Param("id"), c.Param("templateId"))
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    "Query template deleted successfully",
+	})
+}
+
+// @Summary Execute a query template
+// @Description Execute a query template in a new message of the chat, the driver binds the parameter values to the query
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param templateId path string true "Template ID"
+// @Param executeQueryTemplateRequest body dtos.ExecuteQueryTemplateRequest true "Execute query template request"
+
+func (h *QueryTemplateHandler) Execute(c *gin.Context) {
+	var req dtos.ExecuteQueryTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.InvalidRequest(err)))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	response, statusCode, err := h.queryTemplateService.Execute(c.Request.Context(), userID, c.Param("id"), c.Param("templateId"), &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
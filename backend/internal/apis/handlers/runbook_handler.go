@@ -0,0 +1,364 @@
+package handlers
+
+import (
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RunbookHandler struct {
+	runbookService services.RunbookService
+}
+
+func NewRunbookHandler(runbookService services.RunbookService) *RunbookHandler {
+	return &RunbookHandler{
+		runbookService: runbookService,
+	}
+}
+
+// @Summary Create a runbook
+// @Description Create a runbook of ordered steps (queries, pauses, manual confirmations, health checks)
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param createRunbookRequest body dtos.CreateRunbookRequest true "Create runbook request"
+
+func (h *RunbookHandler) Create(c *gin.Context) {
+	var req dtos.CreateRunbookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.InvalidRequest(err)))
+		return
+	}
+
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	response, statusCode, err := h.runbookService.Create(userID, chatID, &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Create a runbook from a message
+// @Description Assemble a runbook from the queries the assistant generated in a message
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param createRunbookFromMessageRequest body dtos.CreateRunbookFromMessageRequest true "Create runbook from message request"
+
+func (h *RunbookHandler) CreateFromMessage(c *gin.Context) {
+	var req dtos.CreateRunbookFromMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.InvalidRequest(err)))
+		return
+	}
+
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	response, statusCode, err := h.runbookService.CreateFromMessage(userID, chatID, &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary List runbooks
+// @Description List all runbooks of a chat
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(10)
+
+func (h *RunbookHandler) List(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	response, statusCode, err := h.runbookService.List(userID, chatID, page, pageSize)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Get a runbook
+// @Description Get a runbook with its steps
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param runbookId path string true "Runbook ID"
+
+func (h *RunbookHandler) Get(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	response, statusCode, err := h.runbookService.Get(userID, chatID, c.Param("runbookId"))
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Update a runbook
+// @Description Update the name, description or steps of a runbook
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param runbookId path string true "Runbook ID"
+// @Param updateRunbookRequest body dtos.UpdateRunbookRequest true "Update runbook request"
+
+func (h *RunbookHandler) Update(c *gin.Context) {
+	var req dtos.UpdateRunbookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.InvalidRequest(err)))
+		return
+	}
+
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	response, statusCode, err := h.runbookService.Update(userID, chatID, c.Param("runbookId"), &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Delete a runbook
+// @Description Delete a runbook along with its run history
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param runbookId path string true "Runbook ID"
+
+func (h *RunbookHandler) Delete(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	statusCode, err := h.runbookService.Delete(userID, chatID, c.Param("runbookId"))
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    "Runbook deleted successfully",
+	})
+}
+
+// @Summary Start a runbook run
+// @Description Start executing a runbook, the run continues in the background
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param runbookId path string true "Runbook ID"
+
+func (h *RunbookHandler) Start(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	response, statusCode, err := h.runbookService.Start(userID, chatID, c.Param("runbookId"))
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary List runbook runs
+// @Description List the run history of a runbook
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param runbookId path string true "Runbook ID"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(10)
+
+func (h *RunbookHandler) ListRuns(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	response, statusCode, err := h.runbookService.ListRuns(userID, chatID, c.Param("runbookId"), page, pageSize)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Get a runbook run
+// @Description Get the state of a runbook run with the result of each step
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param runId path string true "Run ID"
+
+func (h *RunbookHandler) GetRun(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	response, statusCode, err := h.runbookService.GetRun(userID, chatID, c.Param("runId"))
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Confirm a runbook step
+// @Description Confirm the manual step a run is waiting on & continue the run
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param runId path string true "Run ID"
+// @Param confirmRunbookStepRequest body dtos.ConfirmRunbookStepRequest true "Confirm runbook step request"
+
+func (h *RunbookHandler) ConfirmStep(c *gin.Context) {
+	var req dtos.ConfirmRunbookStepRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.InvalidRequest(err)))
+			return
+		}
+	}
+
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	response, statusCode, err := h.runbookService.ConfirmStep(userID, chatID, c.Param("runId"), &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Resume a runbook run
+// @Description Resume a failed or interrupted run, retrying or skipping the failed step
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param runId path string true "Run ID"
+// @Param resumeRunbookRunRequest body dtos.ResumeRunbookRunRequest true "Resume runbook run request"
+
+func (h *RunbookHandler) Resume(c *gin.Context) {
+	var req dtos.ResumeRunbookRunRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.InvalidRequest(err)))
+			return
+		}
+	}
+
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	response, statusCode, err := h.runbookService.Resume(userID, chatID, c.Param("runId"), &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Cancel a runbook run
+// @Description Cancel a runbook run, the step being executed is cancelled
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param runId path string true "Run ID"
+
+func (h *RunbookHandler) Cancel(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	response, statusCode, err := h.runbookService.Cancel(userID, chatID, c.Param("runId"))
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Get a runbook run report
+// @Description Get the final report of a runbook run
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param runId path string true "Run ID"
+
+func (h *RunbookHandler) GetReport(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	response, statusCode, err := h.runbookService.GetReport(userID, chatID, c.Param("runId"))
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SavedConnectionHandler struct {
+	savedConnectionService services.SavedConnectionService
+}
+
+func NewSavedConnectionHandler(savedConnectionService services.SavedConnectionService) *SavedConnectionHandler {
+	return &SavedConnectionHandler{
+		savedConnectionService: savedConnectionService,
+	}
+}
+
+// @Summary Save a connection
+// @Description Test & save a connection once so several chats can use it
+// @Accept json
+// @Produce json
+// @Param createSavedConnectionRequest body dtos.CreateSavedConnectionRequest true "Create saved connection request"
+
+func (h *SavedConnectionHandler) Create(c *gin.Context) {
+	var req dtos.CreateSavedConnectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.InvalidRequest(err)))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	response, statusCode, err := h.savedConnectionService.Create(userID, &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Save the connection of a chat
+// @Description Move the connection embedded in a chat to a saved connection other chats can use
+// @Accept json
+// @Produce json
+// @Param saveChatConnectionRequest body dtos.SaveChatConnectionRequest true "Save chat connection request"
+
+func (h *SavedConnectionHandler) CreateFromChat(c *gin.Context) {
+	var req dtos.SaveChatConnectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.InvalidRequest(err)))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	response, statusCode, err := h.savedConnectionService.CreateFromChat(userID, &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary List saved connections
+// @Description List the saved connections of the user with the number of chats using them
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(10)
+
+func (h *SavedConnectionHandler) List(c *gin.Context) {
+	userID := c.GetString("userID")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	response, statusCode, err := h.savedConnectionService.List(userID, page, pageSize)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Get a saved connection
+// @Description Get a saved connection, secrets are not exposed
+// @Accept json
+// @Produce json
+// @Param connectionId path string true "Connection ID"
+
+func (h *SavedConnectionHandler) Get(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	response, statusCode, err := h.savedConnectionService.Get(userID, c.Param("connectionId"))
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Update a saved connection
+// @Description Rename a saved connection or update its credentials for all the chats using it
+// @Accept json
+// @Produce json
+// @Param connectionId path string true "Connection ID"
+// @Param updateSavedConnectionRequest body dtos.UpdateSavedConnectionRequest true "Update saved connection request"
+
+func (h *SavedConnectionHandler) Update(c *gin.Context) {
+	var req dtos.UpdateSavedConnectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.InvalidRequest(err)))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	response, statusCode, err := h.savedConnectionService.Update(userID, c.Param("connectionId"), &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Delete a saved connection
+// @Description Delete a saved connection, the chats using it keep a copy of the connection
+// @Accept json
+// @Produce json
+// @Param connectionId path string true "Connection ID"
+
+func (h *SavedConnectionHandler) Delete(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	statusCode, err := h.savedConnectionService.Delete(userID, c.Param("connectionId"))
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    "Connection deleted successfully",
+	})
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ScheduledQueryHandler struct {
+	scheduledQueryService services.ScheduledQueryService
+}
+
+func NewScheduledQueryHandler(scheduledQueryService services.ScheduledQueryService) *ScheduledQueryHandler {
+	return &ScheduledQueryHandler{
+		scheduledQueryService: scheduledQueryService,
+	}
+}
+
+// @Summary Schedule a query
+// @Description Run a bookmarked query or a query of a message on a cron expression, each run adds a message with the result to the chat & can call a webhook or send an email
+// @Accept json
+// @Produce json
+// @Param createScheduledQueryRequest body dtos.CreateScheduledQueryRequest true "Create scheduled query request"
+
+func (h *ScheduledQueryHandler) Create(c *gin.Context) {
+	var req dtos.CreateScheduledQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.InvalidRequest(err)))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	response, statusCode, err := h.scheduledQueryService.Create(userID, &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary List scheduled queries
+// @Description List the scheduled queries of the user, most recent first
+// @Accept json
+// @Produce json
+// @Param chat_id query string false "Only the scheduled queries of the chat"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(10)
+
+func (h *ScheduledQueryHandler) List(c *gin.Context) {
+	userID := c.GetString("userID")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	response, statusCode, err := h.scheduledQueryService.List(userID, c.Query("chat_id"), page, pageSize)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Get a scheduled query
+// @Description Get a scheduled query with its next run & the outcome of its last run
+// @Accept json
+// @Produce json
+// @Param scheduleId path string true "Schedule ID"
+
+func (h *ScheduledQueryHandler) Get(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	response, statusCode, err := h.scheduledQueryService.Get(userID, c.Param("scheduleId"))
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Update a scheduled query
+// @Description Change the name, the cron expression, the timezone, the webhook or the email recipients of a scheduled query, or pause it
+// @Accept json
+// @Produce json
+// @Param scheduleId path string true "Schedule ID"
+// @Param updateScheduledQueryRequest body dtos.UpdateScheduledQueryRequest true "Update scheduled query request"
+
+func (h *ScheduledQueryHandler) Update(c *gin.Context) {
+	var req dtos.UpdateScheduledQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.NewErrorResponse(http.StatusBadRequest, apperrors.InvalidRequest(err)))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	response, statusCode, err := h.scheduledQueryService.Update(userID, c.Param("scheduleId"), &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Delete a scheduled query
+// @Description Delete a scheduled query, the messages of its past runs are kept
+// @Accept json
+// @Produce json
+// @Param scheduleId path string true "Schedule ID"
+
+func (h *ScheduledQueryHandler) Delete(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	statusCode, err := h.scheduledQueryService.Delete(userID, c.Param("scheduleId"))
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    "Scheduled query deleted successfully",
+	})
+}
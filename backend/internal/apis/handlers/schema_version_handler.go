@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SchemaVersionHandler struct {
+	schemaVersionService services.SchemaVersionService
+}
+
+func NewSchemaVersionHandler(schemaVersionService services.SchemaVersionService) *SchemaVersionHandler {
+	return &SchemaVersionHandler{
+		schemaVersionService: schemaVersionService,
+	}
+}
+
+// @Summary List schema versions
+// @Description List the versions of the schema of a chat's database, a version is recorded each time the schema changes with its checksum & the changes from the previous version
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(20)
+
+func (h *SchemaVersionHandler) List(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	response, statusCode, err := h.schemaVersionService.List(userID, chatID, page, pageSize)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Diff schema versions
+// @Description Get what changed in the schema of a chat's database between two points, each a version ID or an RFC3339 time standing for the version the schema was at then
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param from query string true "Version ID or RFC3339 time"
+// @Param to query string false "Version ID or RFC3339 time, the latest version if not provided"
+
+func (h *SchemaVersionHandler) Diff(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	response, statusCode, err := h.schemaVersionService.Diff(userID, chatID, c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(int(statusCode), dtos.NewErrorResponse(int(statusCode), err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
@@ -0,0 +1,37 @@
+package middlewares
+
+import (
+	"log"
+	"neobase-ai/config"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/di"
+	"neobase-ai/internal/repositories"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+var userRepo repositories.UserRepository
+
+// AdminMiddleware only lets the admin user (ADMIN_USER) through, it must be used after AuthMiddleware
+func AdminMiddleware() gin.HandlerFunc {
+	if userRepo == nil {
+		if err := di.DiContainer.Invoke(func(repo repositories.UserRepository) {
+			userRepo = repo
+		}); err != nil {
+			log.Fatalf("Failed to provide User repository: %v", err)
+		}
+	}
+
+	return func(c *gin.Context) {
+		user, err := userRepo.FindByID(c.GetString("userID"))
+		if err != nil || user == nil || user.Username != config.Env.AdminUser {
+			c.JSON(http.StatusForbidden, dtos.NewErrorResponse(http.StatusForbidden, apperrors.New("ADMIN_REQUIRED", "Admin access is required")))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -3,6 +3,7 @@ package middlewares
 import (
 	"log"
 	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
 	"neobase-ai/internal/di"
 	"neobase-ai/internal/repositories"
 	"neobase-ai/internal/utils"
@@ -34,22 +35,14 @@ func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			errorMsg := "Authorization header is required"
-			c.JSON(http.StatusUnauthorized, dtos.Response{
-				Success: false,
-				Error:   &errorMsg,
-			})
+			c.JSON(http.StatusUnauthorized, dtos.NewErrorResponse(http.StatusUnauthorized, apperrors.New("AUTHORIZATION_HEADER_REQUIRED", "Authorization header is required")))
 			c.Abort()
 			return
 		}
 
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			errorMsg := "Invalid authorization header format"
-			c.JSON(http.StatusUnauthorized, dtos.Response{
-				Success: false,
-				Error:   &errorMsg,
-			})
+			c.JSON(http.StatusUnauthorized, dtos.NewErrorResponse(http.StatusUnauthorized, apperrors.New("INVALID_AUTHORIZATION_HEADER", "Invalid authorization header format")))
 			c.Abort()
 			return
 		}
@@ -58,22 +51,14 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		// Check if token is blacklisted
 		if tokenRepo.IsTokenBlacklisted(token) {
-			errorMsg := "Token has been revoked"
-			c.JSON(http.StatusUnauthorized, dtos.Response{
-				Success: false,
-				Error:   &errorMsg,
-			})
+			c.JSON(http.StatusUnauthorized, dtos.NewErrorResponse(http.StatusUnauthorized, apperrors.New("TOKEN_REVOKED", "Token has been revoked")))
 			c.Abort()
 			return
 		}
 
 		claims, err := (*jwtService).ValidateToken(token)
 		if err != nil {
-			errorMsg := "Invalid or expired token"
-			c.JSON(http.StatusUnauthorized, dtos.Response{
-				Success: false,
-				Error:   &errorMsg,
-			})
+			c.JSON(http.StatusUnauthorized, dtos.NewErrorResponse(http.StatusUnauthorized, apperrors.New("INVALID_TOKEN", "Invalid or expired token")))
 			c.Abort()
 			return
 		}
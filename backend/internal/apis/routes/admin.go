@@ -0,0 +1,69 @@
+package routes
+
+import (
+	"log"
+	"neobase-ai/internal/apis/middlewares"
+	"neobase-ai/internal/di"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupAdminRoutes(router *gin.Engine) {
+	organizationHandler, err := di.GetOrganizationHandler()
+	if err != nil {
+		log.Fatalf("Failed to get organization handler: %v", err)
+	}
+
+	organizations := router.Group("/api/admin/organizations")
+	organizations.Use(middlewares.AuthMiddleware(), middlewares.AdminMiddleware())
+	{
+		organizations.POST("", organizationHandler.Create)
+		organizations.GET("", organizationHandler.List)
+		organizations.GET("/:organizationId", organizationHandler.Get)
+		organizations.PATCH("/:organizationId", organizationHandler.Update)
+		organizations.DELETE("/:organizationId", organizationHandler.Delete)
+		organizations.POST("/:organizationId/members", organizationHandler.AddMember)
+		organizations.DELETE("/:organizationId/members/:userId", organizationHandler.RemoveMember)
+		organizations.PUT("/:organizationId/llm-providers/:provider", organizationHandler.SetLLMProvider)
+		organizations.DELETE("/:organizationId/llm-providers/:provider", organizationHandler.RemoveLLMProvider)
+	}
+
+	promptTemplateHandler, err := di.GetPromptTemplateHandler()
+	if err != nil {
+		log.Fatalf("Failed to get prompt template handler: %v", err)
+	}
+
+	// The provider query param selects the template of a provider, the template of every provider when omitted
+	promptTemplates := router.Group("/api/admin/prompt-templates")
+	promptTemplates.Use(middlewares.AuthMiddleware(), middlewares.AdminMiddleware())
+	{
+		promptTemplates.GET("", promptTemplateHandler.ListActive)
+		promptTemplates.GET("/:dbType", promptTemplateHandler.GetVersions)
+		promptTemplates.POST("/:dbType", promptTemplateHandler.CreateVersion)
+		promptTemplates.POST("/:dbType/versions/:version/activate", promptTemplateHandler.ActivateVersion)
+		promptTemplates.DELETE("/:dbType/active", promptTemplateHandler.ResetToBuiltIn)
+	}
+
+	datasetExportHandler, err := di.GetDatasetExportHandler()
+	if err != nil {
+		log.Fatalf("Failed to get dataset export handler: %v", err)
+	}
+
+	// Anonymized (question, schema excerpt, query, outcome, feedback) records as JSONL, to fine-tune or evaluate custom models
+	datasets := router.Group("/api/admin/datasets")
+	datasets.Use(middlewares.AuthMiddleware(), middlewares.AdminMiddleware())
+	{
+		datasets.GET("/fine-tuning", datasetExportHandler.ExportFineTuningDataset)
+	}
+
+	metricsHandler, err := di.GetMetricsHandler()
+	if err != nil {
+		log.Fatalf("Failed to get metrics handler: %v", err)
+	}
+
+	metrics := router.Group("/api/admin/metrics")
+	metrics.Use(middlewares.AuthMiddleware(), middlewares.AdminMiddleware())
+	{
+		metrics.GET("", metricsHandler.GetMetrics)
+	}
+}
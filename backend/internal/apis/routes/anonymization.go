@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"log"
+	"neobase-ai/internal/apis/middlewares"
+	"neobase-ai/internal/di"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupAnonymizationRoutes(router *gin.Engine) {
+	anonymizationHandler, err := di.GetAnonymizationHandler()
+	if err != nil {
+		log.Fatalf("Failed to get anonymization handler: %v", err)
+	}
+
+	jobs := router.Group("/api/anonymization-jobs")
+	jobs.Use(middlewares.AuthMiddleware())
+	{
+		jobs.POST("", anonymizationHandler.Create)
+		jobs.GET("", anonymizationHandler.List)
+		jobs.GET("/:jobId", anonymizationHandler.Get)
+		jobs.GET("/:jobId/report", anonymizationHandler.GetReport)
+		jobs.POST("/:jobId/cancel", anonymizationHandler.Cancel)
+	}
+}
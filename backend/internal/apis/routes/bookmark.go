@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"log"
+	"neobase-ai/internal/apis/middlewares"
+	"neobase-ai/internal/di"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupBookmarkRoutes(router *gin.Engine) {
+	bookmarkHandler, err := di.GetBookmarkHandler()
+	if err != nil {
+		log.Fatalf("Failed to get bookmark handler: %v", err)
+	}
+
+	chatBookmarks := router.Group("/api/chats/:id/bookmarks")
+	chatBookmarks.Use(middlewares.AuthMiddleware())
+	{
+		chatBookmarks.POST("", bookmarkHandler.Create)
+		chatBookmarks.GET("", bookmarkHandler.List)
+		chatBookmarks.DELETE("/:bookmarkId", bookmarkHandler.Delete)
+	}
+
+	// Deep links, the owner of the chat & the members of their organization can open the bookmark with the token
+	shared := router.Group("/api/bookmarks")
+	shared.Use(middlewares.AuthMiddleware())
+	{
+		shared.GET("/:token", bookmarkHandler.GetByShareToken)
+	}
+}
@@ -19,6 +19,8 @@ func SetupChatRoutes(router *gin.Engine) {
 	{
 		// Chat CRUD
 		protected.POST("", chatHandler.Create)
+		protected.POST("/import", chatHandler.ImportConnections)                      // Bulk create chats from DBeaver/DataGrip exports, .pgpass files or URI lists
+		protected.POST("/parse-connection-string", chatHandler.ParseConnectionString) // Fills the connection form from a pasted URI
 		protected.GET("", chatHandler.List)
 		protected.GET("/:id", chatHandler.GetByID)
 		protected.PATCH("/:id", chatHandler.Update)
@@ -29,6 +31,7 @@ func SetupChatRoutes(router *gin.Engine) {
 		protected.GET("/:id/messages", chatHandler.ListMessages)
 		protected.POST("/:id/messages", chatHandler.CreateMessage)
 		protected.PATCH("/:id/messages/:messageId", chatHandler.UpdateMessage)
+		protected.POST("/:id/messages/:messageId/clarify", chatHandler.AnswerClarification) // Answers the clarification of an assistant message, resuming the generation
 		protected.DELETE("/:id/messages", chatHandler.DeleteMessages)
 
 		// Database connection routes
@@ -37,6 +40,23 @@ func SetupChatRoutes(router *gin.Engine) {
 		protected.GET("/:id/connection-status", chatHandler.GetDBConnectionStatus)
 		protected.POST("/:id/refresh-schema", chatHandler.RefreshSchema)
 		protected.GET("/:id/tables", chatHandler.GetTables)
+		protected.GET("/:id/tables/:table/rows", chatHandler.GetTableRows) // Has query params "page", "page_size", "sort_by", "sort_order", "filter" & "include_total"
+
+		// Audit triggers on the chat's database
+		protected.GET("/:id/audit", chatHandler.GetAuditStatus)
+		protected.POST("/:id/audit/install", chatHandler.InstallAuditTriggers)
+		protected.DELETE("/:id/audit", chatHandler.RemoveAuditTriggers) // Has query param "drop_log"
+
+		// Change stream watches on the chat's MongoDB collections, changes are pushed to the stream as db-change events
+		protected.POST("/:id/watches", chatHandler.StartWatch)
+		protected.GET("/:id/watches", chatHandler.ListWatches)
+		protected.DELETE("/:id/watches/:watchId", chatHandler.StopWatch)
+
+		// Transaction kept open across messages, the chat's queries run in it until it is committed or rolled back
+		protected.POST("/:id/transaction", chatHandler.StartTransaction)
+		protected.GET("/:id/transaction", chatHandler.GetTransaction)
+		protected.POST("/:id/transaction/commit", chatHandler.CommitTransaction)
+		protected.POST("/:id/transaction/rollback", chatHandler.RollbackTransaction)
 
 		// SSE endpoints for streaming
 		protected.GET("/:id/stream", chatHandler.StreamChat)
@@ -44,9 +64,19 @@ func SetupChatRoutes(router *gin.Engine) {
 
 		// Query execution routes
 		protected.POST("/:id/queries/execute", chatHandler.ExecuteQuery)
+		protected.POST("/:id/queries/execute-all", chatHandler.ExecuteAllQueries)
 		protected.POST("/:id/queries/rollback", chatHandler.RollbackQuery)
 		protected.POST("/:id/queries/cancel", chatHandler.CancelQueryExecution)
 		protected.POST("/:id/queries/results", chatHandler.GetQueryResults)
 		protected.PATCH("/:id/queries/edit", chatHandler.EditQuery)
+		protected.POST("/:id/queries/benchmark", chatHandler.BenchmarkQuery)              // Read-only queries only, cancelled with the stream ID like an execution
+		protected.POST("/:id/queries/stream", chatHandler.StreamQueryResults)             // Read-only queries only, the rows are sent to the stream as query-result-chunk events
+		protected.POST("/:id/queries/chart", chatHandler.ChartQueryResults)               // Read-only queries only, the whole result is aggregated into chart series
+		protected.GET("/:id/queries/export", chatHandler.ExportQueryResults)              // Has query params "message_id", "query_id", "format" (csv, xlsx or parquet) & "use_primary", read-only queries only
+		protected.GET("/:id/queries/:queryId/download", chatHandler.DownloadQueryResults) // Has query params "format" (jsonl) & "use_primary"
+		protected.GET("/:id/queries/result-file", chatHandler.GetQueryResultFile)         // Has query params "message_id" & "query_id", a new link to the file of a result too large for the chat
 	}
+
+	// Files of the local result store, the signed link is the credential so that the link can be shared & opened by a browser
+	router.GET("/api/results/*key", chatHandler.DownloadResultFile) // Has query params "expires" & "signature"
 }
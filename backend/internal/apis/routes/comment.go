@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"log"
+	"neobase-ai/internal/apis/middlewares"
+	"neobase-ai/internal/di"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupCommentRoutes(router *gin.Engine) {
+	commentHandler, err := di.GetCommentHandler()
+	if err != nil {
+		log.Fatalf("Failed to get comment handler: %v", err)
+	}
+
+	protected := router.Group("/api/queries/:id/comments")
+	protected.Use(middlewares.AuthMiddleware())
+	{
+		protected.POST("", commentHandler.Create)
+		protected.GET("", commentHandler.List)
+		protected.PATCH("/:commentId", commentHandler.Update)
+		protected.DELETE("/:commentId", commentHandler.Delete)
+	}
+}
@@ -31,4 +31,19 @@ func SetupDefaultRoutes(router *gin.Engine) {
 	// Setup all route groups
 	SetupAuthRoutes(router)
 	SetupChatRoutes(router)
+	SetupSavedConnectionRoutes(router)
+	SetupBookmarkRoutes(router)
+	SetupQueryExampleRoutes(router)
+	SetupCommentRoutes(router)
+	SetupRunbookRoutes(router)
+	SetupAnonymizationRoutes(router)
+	SetupQueryJobRoutes(router)
+	SetupScheduledQueryRoutes(router)
+	SetupQueryTemplateRoutes(router)
+	SetupLineageRoutes(router)
+	SetupNotificationRoutes(router)
+	SetupLLMUsageRoutes(router)
+	SetupQueryHistoryRoutes(router)
+	SetupSchemaVersionRoutes(router)
+	SetupAdminRoutes(router)
 }
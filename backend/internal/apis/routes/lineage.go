@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"log"
+	"neobase-ai/internal/apis/middlewares"
+	"neobase-ai/internal/di"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupLineageRoutes(router *gin.Engine) {
+	lineageHandler, err := di.GetLineageHandler()
+	if err != nil {
+		log.Fatalf("Failed to get lineage handler: %v", err)
+	}
+
+	chatLineage := router.Group("/api/chats/:id/lineage")
+	chatLineage.Use(middlewares.AuthMiddleware())
+	{
+		chatLineage.GET("", lineageHandler.GetGraph)
+		chatLineage.GET("/events", lineageHandler.ListEvents)
+	}
+}
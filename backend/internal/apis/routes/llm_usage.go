@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"log"
+	"neobase-ai/internal/apis/middlewares"
+	"neobase-ai/internal/di"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupLLMUsageRoutes(router *gin.Engine) {
+	llmUsageHandler, err := di.GetLLMUsageHandler()
+	if err != nil {
+		log.Fatalf("Failed to get LLM usage handler: %v", err)
+	}
+
+	usage := router.Group("/api/usage")
+	usage.Use(middlewares.AuthMiddleware())
+	{
+		usage.GET("", llmUsageHandler.GetUserUsage)
+	}
+
+	chatUsage := router.Group("/api/chats/:id/usage")
+	chatUsage.Use(middlewares.AuthMiddleware())
+	{
+		chatUsage.GET("", llmUsageHandler.GetChatUsage)
+	}
+}
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"log"
+	"neobase-ai/internal/apis/middlewares"
+	"neobase-ai/internal/di"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupNotificationRoutes(router *gin.Engine) {
+	notificationHandler, err := di.GetNotificationHandler()
+	if err != nil {
+		log.Fatalf("Failed to get notification handler: %v", err)
+	}
+
+	notifications := router.Group("/api/notifications")
+	notifications.Use(middlewares.AuthMiddleware())
+	{
+		notifications.GET("", notificationHandler.List)
+		notifications.POST("/read", notificationHandler.MarkRead)
+		notifications.POST("/read-all", notificationHandler.MarkAllRead)
+	}
+}
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"log"
+	"neobase-ai/internal/apis/middlewares"
+	"neobase-ai/internal/di"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupQueryExampleRoutes(router *gin.Engine) {
+	queryExampleHandler, err := di.GetQueryExampleHandler()
+	if err != nil {
+		log.Fatalf("Failed to get query example handler: %v", err)
+	}
+
+	chatExamples := router.Group("/api/chats/:id/examples")
+	chatExamples.Use(middlewares.AuthMiddleware())
+	{
+		chatExamples.POST("", queryExampleHandler.Create)
+		chatExamples.GET("", queryExampleHandler.List)
+		chatExamples.PUT("/:exampleId", queryExampleHandler.Update)
+		chatExamples.DELETE("/:exampleId", queryExampleHandler.Delete)
+	}
+}
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"log"
+	"neobase-ai/internal/apis/middlewares"
+	"neobase-ai/internal/di"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupQueryHistoryRoutes(router *gin.Engine) {
+	queryHistoryHandler, err := di.GetQueryHistoryHandler()
+	if err != nil {
+		log.Fatalf("Failed to get query history handler: %v", err)
+	}
+
+	history := router.Group("/api/history")
+	history.Use(middlewares.AuthMiddleware())
+	{
+		// Has query params "chat_id", "connection_id", "database_type", "status", "is_rollback", "from", "to", "q", "page" & "page_size"
+		history.GET("", queryHistoryHandler.List)
+		history.POST("/diff", queryHistoryHandler.Diff) // Compares the results of two executions by a key column
+	}
+}
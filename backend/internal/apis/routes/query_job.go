@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"log"
+	"neobase-ai/internal/apis/middlewares"
+	"neobase-ai/internal/di"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupQueryJobRoutes(router *gin.Engine) {
+	queryJobHandler, err := di.GetQueryJobHandler()
+	if err != nil {
+		log.Fatalf("Failed to get query job handler: %v", err)
+	}
+
+	jobs := router.Group("/api/jobs")
+	jobs.Use(middlewares.AuthMiddleware())
+	{
+		jobs.POST("", queryJobHandler.Create)
+		jobs.GET("", queryJobHandler.List) // Has query params "chat_id", "page" & "page_size"
+		jobs.GET("/:jobId", queryJobHandler.Get)
+		jobs.POST("/:jobId/cancel", queryJobHandler.Cancel)
+	}
+}
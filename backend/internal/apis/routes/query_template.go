@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"log"
+	"neobase-ai/internal/apis/middlewares"
+	"neobase-ai/internal/di"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupQueryTemplateRoutes(router *gin.Engine) {
+	queryTemplateHandler, err := di.GetQueryTemplateHandler()
+	if err != nil {
+		log.Fatalf("Failed to get query template handler: %v", err)
+	}
+
+	templates := router.Group("/api/chats/:id/templates")
+	templates.Use(middlewares.AuthMiddleware())
+	{
+		templates.POST("", queryTemplateHandler.Create)
+		templates.GET("", queryTemplateHandler.List) // Has query params "page" & "page_size"
+		templates.GET("/:templateId", queryTemplateHandler.Get)
+		templates.PATCH("/:templateId", queryTemplateHandler.Update)
+		templates.DELETE("/:templateId", queryTemplateHandler.Delete)
+		templates.POST("/:templateId/execute", queryTemplateHandler.Execute)
+	}
+}
@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"log"
+	"neobase-ai/internal/apis/middlewares"
+	"neobase-ai/internal/di"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupRunbookRoutes(router *gin.Engine) {
+	runbookHandler, err := di.GetRunbookHandler()
+	if err != nil {
+		log.Fatalf("Failed to get runbook handler: %v", err)
+	}
+
+	runbooks := router.Group("/api/chats/:id/runbooks")
+	runbooks.Use(middlewares.AuthMiddleware())
+	{
+		runbooks.POST("", runbookHandler.Create)
+		runbooks.POST("/from-message", runbookHandler.CreateFromMessage)
+		runbooks.GET("", runbookHandler.List)
+		runbooks.GET("/:runbookId", runbookHandler.Get)
+		runbooks.PATCH("/:runbookId", runbookHandler.Update)
+		runbooks.DELETE("/:runbookId", runbookHandler.Delete)
+		runbooks.POST("/:runbookId/runs", runbookHandler.Start)
+		runbooks.GET("/:runbookId/runs", runbookHandler.ListRuns)
+	}
+
+	runs := router.Group("/api/chats/:id/runbook-runs")
+	runs.Use(middlewares.AuthMiddleware())
+	{
+		runs.GET("/:runId", runbookHandler.GetRun)
+		runs.GET("/:runId/report", runbookHandler.GetReport)
+		runs.POST("/:runId/confirm", runbookHandler.ConfirmStep)
+		runs.POST("/:runId/resume", runbookHandler.Resume)
+		runs.POST("/:runId/cancel", runbookHandler.Cancel)
+	}
+}
@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"log"
+	"neobase-ai/internal/apis/middlewares"
+	"neobase-ai/internal/di"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupSavedConnectionRoutes(router *gin.Engine) {
+	savedConnectionHandler, err := di.GetSavedConnectionHandler()
+	if err != nil {
+		log.Fatalf("Failed to get saved connection handler: %v", err)
+	}
+
+	connections := router.Group("/api/connections")
+	connections.Use(middlewares.AuthMiddleware())
+	{
+		connections.POST("", savedConnectionHandler.Create)
+		connections.POST("/from-chat", savedConnectionHandler.CreateFromChat)
+		connections.GET("", savedConnectionHandler.List)
+		connections.GET("/:connectionId", savedConnectionHandler.Get)
+		connections.PATCH("/:connectionId", savedConnectionHandler.Update)
+		connections.DELETE("/:connectionId", savedConnectionHandler.Delete)
+	}
+}
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"log"
+	"neobase-ai/internal/apis/middlewares"
+	"neobase-ai/internal/di"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupScheduledQueryRoutes(router *gin.Engine) {
+	scheduledQueryHandler, err := di.GetScheduledQueryHandler()
+	if err != nil {
+		log.Fatalf("Failed to get scheduled query handler: %v", err)
+	}
+
+	schedules := router.Group("/api/schedules")
+	schedules.Use(middlewares.AuthMiddleware())
+	{
+		schedules.POST("", scheduledQueryHandler.Create)
+		schedules.GET("", scheduledQueryHandler.List) // Has query params "chat_id", "page" & "page_size"
+		schedules.GET("/:scheduleId", scheduledQueryHandler.Get)
+		schedules.PATCH("/:scheduleId", scheduledQueryHandler.Update)
+		schedules.DELETE("/:scheduleId", scheduledQueryHandler.Delete)
+	}
+}
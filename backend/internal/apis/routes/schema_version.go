@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"log"
+	"neobase-ai/internal/apis/middlewares"
+	"neobase-ai/internal/di"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupSchemaVersionRoutes(router *gin.Engine) {
+	schemaVersionHandler, err := di.GetSchemaVersionHandler()
+	if err != nil {
+		log.Fatalf("Failed to get schema version handler: %v", err)
+	}
+
+	schemaVersions := router.Group("/api/chats/:id/schema-versions")
+	schemaVersions.Use(middlewares.AuthMiddleware())
+	{
+		schemaVersions.GET("", schemaVersionHandler.List)
+		schemaVersions.GET("/diff", schemaVersionHandler.Diff) // Has query params "from" & "to", version IDs or RFC3339 times
+	}
+}
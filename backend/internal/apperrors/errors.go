@@ -0,0 +1,93 @@
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Error is an error returned to API clients with a stable code, clients use the message key & params
+// to show a localized message and the code to handle the error programmatically
+type Error struct {
+	Code    string                 // Machine-readable code, e.g. CHAT_NOT_FOUND
+	Message string                 // English message template, params are referenced as {name}
+	Params  map[string]interface{} // Values interpolated in the message
+}
+
+// New creates an error with an English message template, e.g. New("FAILED_TO_FETCH_CHAT", "failed to fetch chat: {error}")
+func New(code, message string) *Error {
+	return &Error{
+		Code:    code,
+		Message: message,
+	}
+}
+
+// With adds a param interpolated in the message, errors are stored with their message so params stay serializable
+func (e *Error) With(key string, value interface{}) *Error {
+	if e.Params == nil {
+		e.Params = make(map[string]interface{})
+	}
+	if err, ok := value.(error); ok {
+		value = err.Error()
+	}
+	e.Params[key] = value
+	return e
+}
+
+// MessageKey returns the key of the localized message, e.g. errors.chat_not_found
+func (e *Error) MessageKey() string {
+	return "errors." + strings.ToLower(e.Code)
+}
+
+// Error returns the English message with its params interpolated
+func (e *Error) Error() string {
+	message := e.Message
+	for key, value := range e.Params {
+		message = strings.ReplaceAll(message, "{"+key+"}", fmt.Sprint(value))
+	}
+	return message
+}
+
+// InvalidRequest wraps an error binding or validating the request body/query
+func InvalidRequest(err error) *Error {
+	return New("INVALID_REQUEST", "{error}").With("error", err)
+}
+
+// From returns the structured error of err, errors created without a code get a generic one derived from the HTTP status
+func From(err error, status int) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return New(CodeForStatus(status), "{error}").With("error", err)
+}
+
+// CodeForStatus returns the generic code of an HTTP status
+func CodeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "BAD_REQUEST"
+	case http.StatusUnauthorized:
+		return "UNAUTHORIZED"
+	case http.StatusForbidden:
+		return "FORBIDDEN"
+	case http.StatusNotFound:
+		return "NOT_FOUND"
+	case http.StatusRequestTimeout:
+		return "REQUEST_TIMEOUT"
+	case http.StatusConflict:
+		return "CONFLICT"
+	case http.StatusGone:
+		return "GONE"
+	case http.StatusTooManyRequests:
+		return "TOO_MANY_REQUESTS"
+	case http.StatusServiceUnavailable:
+		return "SERVICE_UNAVAILABLE"
+	default:
+		if status >= http.StatusInternalServerError {
+			return "INTERNAL_ERROR"
+		}
+		return "REQUEST_FAILED"
+	}
+}
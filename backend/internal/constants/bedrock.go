@@ -0,0 +1,9 @@
+package constants
+
+// Bedrock reuses the OpenAI prompts & JSON response schemas, model IDs may be cross-region inference profiles, e.g. us.anthropic.claude-...
+const (
+	BedrockRegion              = "us-east-1"
+	BedrockModel               = "anthropic.claude-3-5-sonnet-20240620-v1:0"
+	BedrockTemperature         = 1
+	BedrockMaxCompletionTokens = 4096
+)
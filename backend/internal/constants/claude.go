@@ -0,0 +1,9 @@
+package constants
+
+// Claude reuses the OpenAI prompts & JSON response schemas, the schema is the input of the tool Claude answers with
+const (
+	ClaudeModel               = "claude-sonnet-4-20250514"
+	ClaudeTemperature         = 1
+	ClaudeMaxCompletionTokens = 8192
+	ClaudeMaxOutputTokens     = 64000 // Output limit of the largest models, max_tokens above it is rejected
+)
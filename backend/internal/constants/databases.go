@@ -1,12 +1,17 @@
 package constants
 
 const (
-	DatabaseTypePostgreSQL = "postgresql"
-	DatabaseTypeYugabyteDB = "yugabytedb"
-	DatabaseTypeMySQL      = "mysql"
-	DatabaseTypeMongoDB    = "mongodb"
-	DatabaseTypeRedis      = "redis"
-	DatabaseTypeNeo4j      = "neo4j"
-	DatabaseTypeClickhouse = "clickhouse"
-	DatabaseTypeCassandra  = "cassandra"
+	DatabaseTypePostgreSQL  = "postgresql"
+	DatabaseTypeYugabyteDB  = "yugabytedb"
+	DatabaseTypeMySQL       = "mysql"
+	DatabaseTypeMariaDB     = "mariadb"
+	DatabaseTypeSingleStore = "singlestore"
+	DatabaseTypeDB2         = "db2"
+	DatabaseTypeDatabricks  = "databricks"
+	DatabaseTypeFirestore   = "firestore"
+	DatabaseTypeMongoDB     = "mongodb"
+	DatabaseTypeRedis       = "redis"
+	DatabaseTypeNeo4j       = "neo4j"
+	DatabaseTypeClickhouse  = "clickhouse"
+	DatabaseTypeCassandra   = "cassandra"
 )
@@ -0,0 +1,43 @@
+package constants
+
+import "strings"
+
+// DefaultLLMContextLimit is the context window assumed for the models missing from LLMContextLimits
+const DefaultLLMContextLimit = 32000
+
+// LLMContextLimits are the context windows in tokens, matched against the model names as LLMModelPrices
+var LLMContextLimits = map[string]int{
+	// OpenAI & Azure OpenAI
+	"gpt-4o":  128000,
+	"gpt-4.1": 1047576,
+	"o1":      200000,
+	"o3-mini": 200000,
+	// Gemini
+	"gemini-2.0-flash": 1048576,
+	"gemini-1.5-flash": 1048576,
+	"gemini-1.5-pro":   2097152,
+	// Claude & Bedrock
+	"claude":      200000,
+	"titan-text":  8192,
+	"llama3.1":    128000,
+	"llama3.2":    128000,
+	"llama3.3":    128000,
+	"mistral":     32000,
+	"qwen2.5":     32000,
+	"deepseek-r1": 128000,
+}
+
+// GetLLMContextLimit returns the context window of a model, using the longest model name matching
+func GetLLMContextLimit(model string) int {
+	model = strings.ToLower(model)
+	matched := ""
+	for name := range LLMContextLimits {
+		if strings.Contains(model, name) && len(name) > len(matched) {
+			matched = name
+		}
+	}
+	if matched == "" {
+		return DefaultLLMContextLimit
+	}
+	return LLMContextLimits[matched]
+}
@@ -0,0 +1,53 @@
+package constants
+
+import "strings"
+
+// LLMModelPrice is the list price of a model in USD per million tokens
+type LLMModelPrice struct {
+	Prompt     float64
+	Completion float64
+}
+
+// LLMModelPrices are matched against the model names, Bedrock model IDs & dated versions contain them.
+// Self-hosted models (Ollama) have no price, their cost is 0.
+var LLMModelPrices = map[string]LLMModelPrice{
+	// OpenAI & Azure OpenAI
+	"gpt-4o":       {Prompt: 2.5, Completion: 10},
+	"gpt-4o-mini":  {Prompt: 0.15, Completion: 0.6},
+	"gpt-4.1":      {Prompt: 2, Completion: 8},
+	"gpt-4.1-mini": {Prompt: 0.4, Completion: 1.6},
+	"gpt-4.1-nano": {Prompt: 0.1, Completion: 0.4},
+	"o1":           {Prompt: 15, Completion: 60},
+	"o3-mini":      {Prompt: 1.1, Completion: 4.4},
+	// Gemini
+	"gemini-2.0-flash":      {Prompt: 0.1, Completion: 0.4},
+	"gemini-2.0-flash-lite": {Prompt: 0.075, Completion: 0.3},
+	"gemini-1.5-flash":      {Prompt: 0.075, Completion: 0.3},
+	"gemini-1.5-pro":        {Prompt: 1.25, Completion: 5},
+	// Claude & Bedrock
+	"claude-opus-4":      {Prompt: 15, Completion: 75},
+	"claude-sonnet-4":    {Prompt: 3, Completion: 15},
+	"claude-3-7-sonnet":  {Prompt: 3, Completion: 15},
+	"claude-3-5-sonnet":  {Prompt: 3, Completion: 15},
+	"claude-3-5-haiku":   {Prompt: 0.8, Completion: 4},
+	"claude-3-haiku":     {Prompt: 0.25, Completion: 1.25},
+	"titan-text-express": {Prompt: 0.2, Completion: 0.6},
+	"titan-text-lite":    {Prompt: 0.15, Completion: 0.2},
+}
+
+// EstimateLLMCost returns the cost in USD of the tokens of a call, using the price of the longest model name matching
+func EstimateLLMCost(model string, promptTokens, completionTokens int) float64 {
+	model = strings.ToLower(model)
+	matched := ""
+	for name := range LLMModelPrices {
+		if strings.Contains(model, name) && len(name) > len(matched) {
+			matched = name
+		}
+	}
+	if matched == "" {
+		return 0
+	}
+
+	price := LLMModelPrices[matched]
+	return (float64(promptTokens)*price.Prompt + float64(completionTokens)*price.Completion) / 1_000_000
+}
@@ -5,6 +5,13 @@ type LLMResponse struct {
 	Queries          []QueryInfo    `json:"queries,omitempty"`
 	AssistantMessage string         `json:"assistantMessage"`
 	ActionButtons    []ActionButton `json:"actionButtons,omitempty"`
+	Clarification    *Clarification `json:"clarification,omitempty"`
+}
+
+// Clarification is a question asked by the LLM instead of guessing when the request is ambiguous, e.g. which table or column is meant
+type Clarification struct {
+	Question string   `json:"question"`
+	Options  []string `json:"options"` // Answers the user can pick from, rendered as action buttons
 }
 
 // ActionButton represents a UI action button that can be suggested by the LLM
@@ -35,4 +42,6 @@ type Pagination struct {
 	TotalRecordsCount *int    `json:"total_records_count"` // Total number of records that the original query returns, found by running the countQuery
 	PaginatedQuery    *string `json:"paginated_query"`     // (Empty "" if the original query is to find count) A paginated query of the original query with OFFSET placeholder to replace with actual value. For SQL, use OFFSET offset_size LIMIT 50. The query should have a replaceable placeholder such as offset_size. (skip(offset_size) should come before limit(50))
 	CountQuery        *string `json:"count_query"`         // (Only applicable for Fetching, Getting data) A fetch count query to get the total count of the original query, this query will not fetch original query data but only fetch count of the original query from the DB so that we can use the total count for pagination
+	KeysetColumn      *string `json:"keyset_column"`       // (Empty "" when not applicable) Unique & not null column of the result the paginatedQuery is ordered by, the next pages are read with WHERE column > last value seen instead of OFFSET
+	KeysetDescending  bool    `json:"keyset_descending"`   // True when the paginatedQuery orders the keyset column descending
 }
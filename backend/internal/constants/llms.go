@@ -3,22 +3,46 @@ package constants
 const (
 	OpenAI = "openai"
 	Gemini = "gemini"
+	Ollama = "ollama"
+	Claude = "claude"
+	// AzureOpenAI runs the OpenAI models of an Azure deployment
+	AzureOpenAI = "azure-openai"
+	// Bedrock runs the Claude & Titan models in an AWS account
+	Bedrock = "bedrock"
+	// OpenAICompatible runs the models of any server implementing the OpenAI API, e.g. vLLM, LM Studio, Together or Groq
+	OpenAICompatible = "openai-compatible"
 )
 
+// LLMDatabaseTypes are the database types the LLM clients have a prompt & a response schema for
+var LLMDatabaseTypes = []string{
+	DatabaseTypePostgreSQL,
+	DatabaseTypeYugabyteDB,
+	DatabaseTypeMySQL,
+	DatabaseTypeMariaDB,
+	DatabaseTypeSingleStore,
+	DatabaseTypeDB2,
+	DatabaseTypeDatabricks,
+	DatabaseTypeFirestore,
+	DatabaseTypeClickhouse,
+	DatabaseTypeMongoDB,
+}
+
 func GetLLMResponseSchema(provider string, dbType string) interface{} {
 	switch provider {
-	case OpenAI:
+	case OpenAI, AzureOpenAI, Ollama, Claude, Bedrock, OpenAICompatible:
 		switch dbType {
 		case DatabaseTypePostgreSQL:
 			return OpenAIPostgresLLMResponseSchema
 		case DatabaseTypeYugabyteDB:
 			return OpenAIYugabyteDBLLMResponseSchema
-		case DatabaseTypeMySQL:
+		case DatabaseTypeMySQL, DatabaseTypeMariaDB, DatabaseTypeSingleStore, DatabaseTypeDB2, DatabaseTypeDatabricks:
 			return OpenAIMySQLLLMResponseSchema
 		case DatabaseTypeClickhouse:
 			return OpenAIClickhouseLLMResponseSchema
 		case DatabaseTypeMongoDB:
 			return OpenAIMongoDBLLMResponseSchema
+		case DatabaseTypeFirestore:
+			return OpenAIFirestoreLLMResponseSchema
 		default:
 			return OpenAIPostgresLLMResponseSchema
 		}
@@ -28,12 +52,14 @@ func GetLLMResponseSchema(provider string, dbType string) interface{} {
 			return GeminiPostgresLLMResponseSchema
 		case DatabaseTypeYugabyteDB:
 			return GeminiYugabyteDBLLMResponseSchema
-		case DatabaseTypeMySQL:
+		case DatabaseTypeMySQL, DatabaseTypeMariaDB, DatabaseTypeSingleStore, DatabaseTypeDB2, DatabaseTypeDatabricks:
 			return GeminiMySQLLLMResponseSchema
 		case DatabaseTypeClickhouse:
 			return GeminiClickhouseLLMResponseSchema
 		case DatabaseTypeMongoDB:
 			return GeminiMongoDBLLMResponseSchema
+		case DatabaseTypeFirestore:
+			return GeminiFirestoreLLMResponseSchema
 		default:
 			return GeminiPostgresLLMResponseSchema
 		}
@@ -44,12 +70,22 @@ func GetLLMResponseSchema(provider string, dbType string) interface{} {
 // GetSystemPrompt returns the appropriate system prompt based on database type
 func GetSystemPrompt(provider string, dbType string) string {
 	switch provider {
-	case OpenAI:
+	case OpenAI, AzureOpenAI, Ollama, Claude, Bedrock, OpenAICompatible:
 		switch dbType {
 		case DatabaseTypePostgreSQL:
 			return OpenAIPostgreSQLPrompt
 		case DatabaseTypeMySQL:
 			return OpenAIMySQLPrompt
+		case DatabaseTypeMariaDB:
+			return OpenAIMariaDBPrompt
+		case DatabaseTypeSingleStore:
+			return OpenAISingleStorePrompt
+		case DatabaseTypeDB2:
+			return OpenAIDB2Prompt
+		case DatabaseTypeDatabricks:
+			return OpenAIDatabricksPrompt
+		case DatabaseTypeFirestore:
+			return OpenAIFirestorePrompt
 		case DatabaseTypeYugabyteDB:
 			return OpenAIYugabyteDBPrompt
 		case DatabaseTypeClickhouse:
@@ -67,6 +103,16 @@ func GetSystemPrompt(provider string, dbType string) string {
 			return GeminiYugabyteDBPrompt
 		case DatabaseTypeMySQL:
 			return GeminiMySQLPrompt
+		case DatabaseTypeMariaDB:
+			return GeminiMariaDBPrompt
+		case DatabaseTypeSingleStore:
+			return GeminiSingleStorePrompt
+		case DatabaseTypeDB2:
+			return GeminiDB2Prompt
+		case DatabaseTypeDatabricks:
+			return GeminiDatabricksPrompt
+		case DatabaseTypeFirestore:
+			return GeminiFirestorePrompt
 		case DatabaseTypeClickhouse:
 			return GeminiClickhousePrompt
 		case DatabaseTypeMongoDB:
@@ -0,0 +1,10 @@
+package constants
+
+// Ollama runs the models locally, it reuses the OpenAI prompts & JSON response schemas
+const (
+	OllamaBaseURL             = "http://localhost:11434"
+	OllamaModel               = "llama3.1"
+	OllamaTemperature         = 1
+	OllamaMaxCompletionTokens = 30000
+	OllamaEmbeddingModel      = "nomic-embed-text"
+)
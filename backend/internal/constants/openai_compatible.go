@@ -0,0 +1,8 @@
+package constants
+
+// OpenAI-compatible servers reuse the OpenAI prompts & JSON response schemas, the model & the base URL depend on the server
+const (
+	OpenAICompatibleTemperature         = 1
+	OpenAICompatibleMaxCompletionTokens = 8192
+	OpenAICompatibleResponseFormat      = "json_schema"
+)
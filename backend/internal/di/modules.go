@@ -12,6 +12,7 @@ import (
 	"neobase-ai/pkg/llm"
 	"neobase-ai/pkg/mongodb"
 	"neobase-ai/pkg/redis"
+	"neobase-ai/pkg/resultstore"
 	"time"
 
 	"go.uber.org/dig"
@@ -47,7 +48,24 @@ func Initialize() {
 	tokenRepo := repositories.NewTokenRepository(redisRepo)
 
 	chatRepo := repositories.NewChatRepository(mongodbClient)
+	savedConnectionRepo := repositories.NewSavedConnectionRepository(mongodbClient)
 	llmRepo := repositories.NewLLMMessageRepository(mongodbClient)
+	bookmarkRepo := repositories.NewBookmarkRepository(mongodbClient)
+	queryExampleRepo := repositories.NewQueryExampleRepository(mongodbClient)
+	commentRepo := repositories.NewCommentRepository(mongodbClient)
+	runbookRepo := repositories.NewRunbookRepository(mongodbClient)
+	anonymizationJobRepo := repositories.NewAnonymizationJobRepository(mongodbClient)
+	queryJobRepo := repositories.NewQueryJobRepository(mongodbClient)
+	scheduledQueryRepo := repositories.NewScheduledQueryRepository(mongodbClient)
+	queryTemplateRepo := repositories.NewQueryTemplateRepository(mongodbClient)
+	organizationRepo := repositories.NewOrganizationRepository(mongodbClient)
+	lineageRepo := repositories.NewLineageRepository(mongodbClient)
+	tableUsageRepo := repositories.NewTableUsageRepository(mongodbClient)
+	queryHistoryRepo := repositories.NewQueryHistoryRepository(mongodbClient)
+	schemaVersionRepo := repositories.NewSchemaVersionRepository(mongodbClient)
+	notificationRepo := repositories.NewNotificationRepository(mongodbClient)
+	promptTemplateRepo := repositories.NewPromptTemplateRepository(mongodbClient)
+	schemaEmbeddingRepo := repositories.NewSchemaEmbeddingRepository(mongodbClient)
 
 	// Provide all dependencies to the container
 	if err := DiContainer.Provide(func() *mongodb.MongoDBClient { return mongodbClient }); err != nil {
@@ -66,10 +84,78 @@ func Initialize() {
 		log.Fatalf("Failed to provide chat repository: %v", err)
 	}
 
+	if err := DiContainer.Provide(func() repositories.SavedConnectionRepository { return savedConnectionRepo }); err != nil {
+		log.Fatalf("Failed to provide saved connection repository: %v", err)
+	}
+
 	if err := DiContainer.Provide(func() repositories.LLMMessageRepository { return llmRepo }); err != nil {
 		log.Fatalf("Failed to provide LLM message repository: %v", err)
 	}
 
+	if err := DiContainer.Provide(func() repositories.BookmarkRepository { return bookmarkRepo }); err != nil {
+		log.Fatalf("Failed to provide bookmark repository: %v", err)
+	}
+
+	if err := DiContainer.Provide(func() repositories.QueryExampleRepository { return queryExampleRepo }); err != nil {
+		log.Fatalf("Failed to provide query example repository: %v", err)
+	}
+
+	if err := DiContainer.Provide(func() repositories.CommentRepository { return commentRepo }); err != nil {
+		log.Fatalf("Failed to provide comment repository: %v", err)
+	}
+
+	if err := DiContainer.Provide(func() repositories.RunbookRepository { return runbookRepo }); err != nil {
+		log.Fatalf("Failed to provide runbook repository: %v", err)
+	}
+
+	if err := DiContainer.Provide(func() repositories.AnonymizationJobRepository { return anonymizationJobRepo }); err != nil {
+		log.Fatalf("Failed to provide anonymization job repository: %v", err)
+	}
+
+	if err := DiContainer.Provide(func() repositories.QueryJobRepository { return queryJobRepo }); err != nil {
+		log.Fatalf("Failed to provide query job repository: %v", err)
+	}
+
+	if err := DiContainer.Provide(func() repositories.ScheduledQueryRepository { return scheduledQueryRepo }); err != nil {
+		log.Fatalf("Failed to provide scheduled query repository: %v", err)
+	}
+
+	if err := DiContainer.Provide(func() repositories.QueryTemplateRepository { return queryTemplateRepo }); err != nil {
+		log.Fatalf("Failed to provide query template repository: %v", err)
+	}
+
+	if err := DiContainer.Provide(func() repositories.OrganizationRepository { return organizationRepo }); err != nil {
+		log.Fatalf("Failed to provide organization repository: %v", err)
+	}
+
+	if err := DiContainer.Provide(func() repositories.LineageRepository { return lineageRepo }); err != nil {
+		log.Fatalf("Failed to provide lineage repository: %v", err)
+	}
+
+	if err := DiContainer.Provide(func() repositories.TableUsageRepository { return tableUsageRepo }); err != nil {
+		log.Fatalf("Failed to provide table usage repository: %v", err)
+	}
+
+	if err := DiContainer.Provide(func() repositories.QueryHistoryRepository { return queryHistoryRepo }); err != nil {
+		log.Fatalf("Failed to provide query history repository: %v", err)
+	}
+
+	if err := DiContainer.Provide(func() repositories.SchemaVersionRepository { return schemaVersionRepo }); err != nil {
+		log.Fatalf("Failed to provide schema version repository: %v", err)
+	}
+
+	if err := DiContainer.Provide(func() repositories.NotificationRepository { return notificationRepo }); err != nil {
+		log.Fatalf("Failed to provide notification repository: %v", err)
+	}
+
+	if err := DiContainer.Provide(func() repositories.PromptTemplateRepository { return promptTemplateRepo }); err != nil {
+		log.Fatalf("Failed to provide prompt template repository: %v", err)
+	}
+
+	if err := DiContainer.Provide(func() repositories.SchemaEmbeddingRepository { return schemaEmbeddingRepo }); err != nil {
+		log.Fatalf("Failed to provide schema embedding repository: %v", err)
+	}
+
 	// Provide DB Manager
 	if err := DiContainer.Provide(func(redisRepo redis.IRedisRepositories) (*dbmanager.Manager, error) {
 		encryptionKey := config.Env.SchemaEncryptionKey
@@ -81,13 +167,58 @@ func Initialize() {
 		manager.RegisterDriver(constants.DatabaseTypePostgreSQL, dbmanager.NewPostgresDriver())
 		manager.RegisterDriver(constants.DatabaseTypeYugabyteDB, dbmanager.NewPostgresDriver()) // Use same driver for both
 		manager.RegisterDriver(constants.DatabaseTypeMySQL, dbmanager.NewMySQLDriver())
+		manager.RegisterDriver(constants.DatabaseTypeMariaDB, dbmanager.NewMariaDBDriver())
+		manager.RegisterDriver(constants.DatabaseTypeSingleStore, dbmanager.NewSingleStoreDriver())
+		if dbmanager.DB2Supported() {
+			manager.RegisterDriver(constants.DatabaseTypeDB2, dbmanager.NewDB2Driver())
+		}
+		manager.RegisterDriver(constants.DatabaseTypeDatabricks, dbmanager.NewDatabricksDriver())
+		manager.RegisterDriver(constants.DatabaseTypeFirestore, dbmanager.NewFirestoreDriver())
 		manager.RegisterDriver(constants.DatabaseTypeClickhouse, dbmanager.NewClickHouseDriver())
 		manager.RegisterDriver(constants.DatabaseTypeMongoDB, dbmanager.NewMongoDBDriver())
+		dbmanager.SetMongoDBCursorLimits(dbmanager.MongoDBCursorLimits{
+			BatchSize:    int32(config.Env.MongoDBCursorBatchSize),
+			MaxDocuments: config.Env.MongoDBMaxResultDocuments,
+			MaxBytes:     config.Env.MongoDBMaxResultMB * 1024 * 1024,
+		})
+		dbmanager.SetMaxConcurrentQueries(config.Env.MaxConcurrentQueries)
+		dbmanager.SetQueryRetryPolicy(dbmanager.QueryRetryPolicy{
+			MaxAttempts: config.Env.QueryRetryMaxAttempts,
+			BaseDelay:   time.Duration(config.Env.QueryRetryBaseDelayMs) * time.Millisecond,
+			MaxDelay:    time.Duration(config.Env.QueryRetryMaxDelayMs) * time.Millisecond,
+		})
+		dbmanager.SetTransactionSessionLimits(
+			time.Duration(config.Env.TransactionSessionIdleSeconds)*time.Second,
+			time.Duration(config.Env.TransactionSessionMaxSeconds)*time.Second,
+		)
 		return manager, nil
 	}); err != nil {
 		log.Fatalf("Failed to provide DB manager: %v", err)
 	}
 
+	// The store of the large results, nil when no storage is configured & the results are capped as usual
+	if err := DiContainer.Provide(func() resultstore.Store {
+		store, err := resultstore.New(resultstore.Config{
+			Storage:         config.Env.ResultSpillStorage,
+			LocalDir:        config.Env.ResultSpillLocalDir,
+			PublicURL:       config.Env.ResultSpillPublicURL,
+			SigningKey:      config.Env.JWTSecret,
+			Bucket:          config.Env.ResultSpillBucket,
+			Region:          config.Env.ResultSpillRegion,
+			Endpoint:        config.Env.ResultSpillEndpoint,
+			AccessKeyID:     config.Env.ResultSpillAccessKeyID,
+			SecretAccessKey: config.Env.ResultSpillSecretAccessKey,
+			SessionToken:    config.Env.AWSSessionToken,
+		})
+		if err != nil {
+			log.Printf("Warning: Failed to create the result store, large results are capped: %v", err)
+			return nil
+		}
+		return store
+	}); err != nil {
+		log.Fatalf("Failed to provide result store: %v", err)
+	}
+
 	if err := DiContainer.Provide(func(db *mongodb.MongoDBClient) repositories.UserRepository {
 		return repositories.NewUserRepository(db)
 	}); err != nil {
@@ -112,108 +243,115 @@ func Initialize() {
 		switch config.Env.DefaultLLMClient {
 		case constants.OpenAI:
 			// Register default OpenAI client
-			err := manager.RegisterClient(constants.OpenAI, llm.Config{
-				Provider:            constants.OpenAI,
-				Model:               config.Env.OpenAIModel,
-				APIKey:              config.Env.OpenAIAPIKey,
-				MaxCompletionTokens: config.Env.OpenAIMaxCompletionTokens,
-				Temperature:         config.Env.OpenAITemperature,
-				DBConfigs: []llm.LLMDBConfig{
-					{
-						DBType:       constants.DatabaseTypePostgreSQL,
-						Schema:       constants.GetLLMResponseSchema(constants.OpenAI, constants.DatabaseTypePostgreSQL),
-						SystemPrompt: constants.GetSystemPrompt(constants.OpenAI, constants.DatabaseTypePostgreSQL),
-					},
-					{
-						DBType:       constants.DatabaseTypeYugabyteDB,
-						Schema:       constants.GetLLMResponseSchema(constants.OpenAI, constants.DatabaseTypeYugabyteDB),
-						SystemPrompt: constants.GetSystemPrompt(constants.OpenAI, constants.DatabaseTypeYugabyteDB),
-					},
-					{
-						DBType:       constants.DatabaseTypeMySQL,
-						Schema:       constants.GetLLMResponseSchema(constants.OpenAI, constants.DatabaseTypeMySQL),
-						SystemPrompt: constants.GetSystemPrompt(constants.OpenAI, constants.DatabaseTypeMySQL),
-					},
-					{
-						DBType:       constants.DatabaseTypeClickhouse,
-						Schema:       constants.GetLLMResponseSchema(constants.OpenAI, constants.DatabaseTypeClickhouse),
-						SystemPrompt: constants.GetSystemPrompt(constants.OpenAI, constants.DatabaseTypeClickhouse),
-					},
-					{
-						DBType:       constants.DatabaseTypeMongoDB,
-						Schema:       constants.GetLLMResponseSchema(constants.OpenAI, constants.DatabaseTypeMongoDB),
-						SystemPrompt: constants.GetSystemPrompt(constants.OpenAI, constants.DatabaseTypeMongoDB),
-					},
-				},
-			})
+			err := manager.RegisterClient(constants.OpenAI, buildLLMConfig(constants.OpenAI, config.Env.OpenAIModel, config.Env.OpenAIAPIKey))
 			if err != nil {
 				log.Printf("Warning: Failed to register OpenAI client: %v", err)
 			}
+		case constants.AzureOpenAI:
+			// Register default Azure OpenAI client, the model is the name of the deployment
+			err := manager.RegisterClient(constants.AzureOpenAI, buildLLMConfig(constants.AzureOpenAI, config.Env.AzureOpenAIDeployment, config.Env.AzureOpenAIAPIKey))
+			if err != nil {
+				log.Printf("Warning: Failed to register Azure OpenAI client: %v", err)
+			}
 		case constants.Gemini:
 			// Register default Gemini client
-			err := manager.RegisterClient(constants.Gemini, llm.Config{
-				Provider:            constants.Gemini,
-				Model:               config.Env.GeminiModel,
-				APIKey:              config.Env.GeminiAPIKey,
-				MaxCompletionTokens: config.Env.GeminiMaxCompletionTokens,
-				Temperature:         config.Env.GeminiTemperature,
-				DBConfigs: []llm.LLMDBConfig{
-					{
-						DBType:       constants.DatabaseTypePostgreSQL,
-						Schema:       constants.GetLLMResponseSchema(constants.Gemini, constants.DatabaseTypePostgreSQL),
-						SystemPrompt: constants.GetSystemPrompt(constants.Gemini, constants.DatabaseTypePostgreSQL),
-					},
-					{
-						DBType:       constants.DatabaseTypeYugabyteDB,
-						Schema:       constants.GetLLMResponseSchema(constants.Gemini, constants.DatabaseTypeYugabyteDB),
-						SystemPrompt: constants.GetSystemPrompt(constants.Gemini, constants.DatabaseTypeYugabyteDB),
-					},
-					{
-						DBType:       constants.DatabaseTypeMySQL,
-						Schema:       constants.GetLLMResponseSchema(constants.Gemini, constants.DatabaseTypeMySQL),
-						SystemPrompt: constants.GetSystemPrompt(constants.Gemini, constants.DatabaseTypeMySQL),
-					},
-					{
-						DBType:       constants.DatabaseTypeClickhouse,
-						Schema:       constants.GetLLMResponseSchema(constants.Gemini, constants.DatabaseTypeClickhouse),
-						SystemPrompt: constants.GetSystemPrompt(constants.Gemini, constants.DatabaseTypeClickhouse),
-					},
-					{
-						DBType:       constants.DatabaseTypeMongoDB,
-						Schema:       constants.GetLLMResponseSchema(constants.Gemini, constants.DatabaseTypeMongoDB),
-						SystemPrompt: constants.GetSystemPrompt(constants.Gemini, constants.DatabaseTypeMongoDB),
-					},
-				},
-			})
+			err := manager.RegisterClient(constants.Gemini, buildLLMConfig(constants.Gemini, config.Env.GeminiModel, config.Env.GeminiAPIKey))
 			if err != nil {
 				log.Printf("Warning: Failed to register Gemini client: %v", err)
 			}
+		case constants.Ollama:
+			// Register default Ollama client, the server is self-hosted so no API key is needed
+			err := manager.RegisterClient(constants.Ollama, buildLLMConfig(constants.Ollama, config.Env.OllamaModel, ""))
+			if err != nil {
+				log.Printf("Warning: Failed to register Ollama client: %v", err)
+			}
+		case constants.Claude:
+			// Register default Claude client
+			err := manager.RegisterClient(constants.Claude, buildLLMConfig(constants.Claude, config.Env.ClaudeModel, config.Env.ClaudeAPIKey))
+			if err != nil {
+				log.Printf("Warning: Failed to register Claude client: %v", err)
+			}
+		case constants.Bedrock:
+			// Register default Bedrock client, the access key ID is the API key
+			err := manager.RegisterClient(constants.Bedrock, buildLLMConfig(constants.Bedrock, config.Env.BedrockModel, config.Env.AWSAccessKeyID))
+			if err != nil {
+				log.Printf("Warning: Failed to register Bedrock client: %v", err)
+			}
+		case constants.OpenAICompatible:
+			// Register default OpenAI-compatible client, the API key is optional for local servers
+			err := manager.RegisterClient(constants.OpenAICompatible, buildLLMConfig(constants.OpenAICompatible, config.Env.OpenAICompatibleModel, config.Env.OpenAICompatibleAPIKey))
+			if err != nil {
+				log.Printf("Warning: Failed to register OpenAI-compatible client: %v", err)
+			}
+		}
+
+		// With a failover chain, the default client tries the providers in order when one is unavailable
+		if len(config.Env.LLMFailoverProviders) > 0 {
+			providers := make([]llm.FailoverProvider, 0, len(config.Env.LLMFailoverProviders))
+			for _, provider := range config.Env.LLMFailoverProviders {
+				client, err := manager.NewClient(envLLMConfig(provider))
+				if err != nil {
+					log.Printf("Warning: Failed to create %s client for failover: %v", provider, err)
+					continue
+				}
+				providers = append(providers, llm.FailoverProvider{Name: provider, Client: client})
+			}
+
+			failoverClient, err := llm.NewFailoverClient(providers, config.Env.LLMFailoverFailureThreshold, time.Duration(config.Env.LLMFailoverCooldownSeconds)*time.Second)
+			if err != nil {
+				log.Printf("Warning: Failed to create LLM failover client: %v", err)
+			} else {
+				manager.AddClient(config.Env.DefaultLLMClient, failoverClient)
+			}
 		}
 		return manager
 	}); err != nil {
 		log.Fatalf("Failed to provide LLM manager: %v", err)
 	}
 
-	// Update Chat Service provider to include DB manager setup
+	// Organizations resolve the LLM client of their members, the default client is used for users without organization
 	if err := DiContainer.Provide(func(
-		chatRepo repositories.ChatRepository,
-		llmRepo repositories.LLMMessageRepository,
-		dbManager *dbmanager.Manager,
+		organizationRepo repositories.OrganizationRepository,
+		userRepo repositories.UserRepository,
 		llmManager *llm.Manager,
-	) services.ChatService {
+	) services.OrganizationService {
 		// Get default LLM client
 		llmClient, err := llmManager.GetClient(config.Env.DefaultLLMClient)
 		if err != nil {
 			log.Printf("Warning: Failed to get default LLM client: %v", err)
 		}
+		return services.NewOrganizationService(organizationRepo, userRepo, llmManager, llmClient, buildLLMConfig)
+	}); err != nil {
+		log.Fatalf("Failed to provide organization service: %v", err)
+	}
 
-		chatService := services.NewChatService(chatRepo, llmRepo, dbManager, llmClient)
+	// Update Chat Service provider to include DB manager setup
+	if err := DiContainer.Provide(func(
+		chatRepo repositories.ChatRepository,
+		savedConnectionRepo repositories.SavedConnectionRepository,
+		llmRepo repositories.LLMMessageRepository,
+		dbManager *dbmanager.Manager,
+		organizationService services.OrganizationService,
+		lineageService services.LineageService,
+		tableUsageService services.TableUsageService,
+		notificationService services.NotificationService,
+		llmUsageService services.LLMUsageService,
+		promptTemplateService services.PromptTemplateService,
+		schemaRetrievalService services.SchemaRetrievalService,
+		queryExampleService services.QueryExampleService,
+		llmResponseCacheService services.LLMResponseCacheService,
+		queryHistoryService services.QueryHistoryService,
+		schemaVersionService services.SchemaVersionService,
+		resultStore resultstore.Store,
+	) services.ChatService {
+		// The LLM client is resolved per request from the user's organization
+		chatService := services.NewChatService(chatRepo, savedConnectionRepo, llmRepo, dbManager, organizationService, lineageService, tableUsageService, notificationService, llmUsageService, promptTemplateService, schemaRetrievalService, queryExampleService, llmResponseCacheService, queryHistoryService, schemaVersionService, resultStore)
 
 		// Set chat service as stream handler for DB manager
 		dbManager.SetStreamHandler(chatService)
 
 		// Set chat service in auth service
-		err = DiContainer.Invoke(func(authService services.AuthService) {
+		err := DiContainer.Invoke(func(authService services.AuthService) {
 			authService.SetChatService(chatService)
 		})
 		if err != nil {
@@ -230,6 +368,170 @@ func Initialize() {
 		log.Fatalf("Failed to provide github handler: %v", err)
 	}
 
+	if err := DiContainer.Provide(func(savedConnectionRepo repositories.SavedConnectionRepository, chatRepo repositories.ChatRepository, dbManager *dbmanager.Manager) services.SavedConnectionService {
+		return services.NewSavedConnectionService(savedConnectionRepo, chatRepo, dbManager)
+	}); err != nil {
+		log.Fatalf("Failed to provide saved connection service: %v", err)
+	}
+
+	if err := DiContainer.Provide(func(notificationRepo repositories.NotificationRepository) services.NotificationService {
+		return services.NewNotificationService(notificationRepo)
+	}); err != nil {
+		log.Fatalf("Failed to provide notification service: %v", err)
+	}
+
+	if err := DiContainer.Provide(func(llmRepo repositories.LLMMessageRepository, chatRepo repositories.ChatRepository) services.LLMUsageService {
+		return services.NewLLMUsageService(llmRepo, chatRepo)
+	}); err != nil {
+		log.Fatalf("Failed to provide LLM usage service: %v", err)
+	}
+
+	if err := DiContainer.Provide(func(promptTemplateRepo repositories.PromptTemplateRepository) services.PromptTemplateService {
+		return services.NewPromptTemplateService(promptTemplateRepo)
+	}); err != nil {
+		log.Fatalf("Failed to provide prompt template service: %v", err)
+	}
+
+	if err := DiContainer.Provide(func(lineageRepo repositories.LineageRepository, chatRepo repositories.ChatRepository) services.LineageService {
+		return services.NewLineageService(lineageRepo, chatRepo)
+	}); err != nil {
+		log.Fatalf("Failed to provide lineage service: %v", err)
+	}
+
+	if err := DiContainer.Provide(func(tableUsageRepo repositories.TableUsageRepository) services.TableUsageService {
+		return services.NewTableUsageService(tableUsageRepo)
+	}); err != nil {
+		log.Fatalf("Failed to provide table usage service: %v", err)
+	}
+
+	if err := DiContainer.Provide(func(queryHistoryRepo repositories.QueryHistoryRepository) services.QueryHistoryService {
+		return services.NewQueryHistoryService(queryHistoryRepo)
+	}); err != nil {
+		log.Fatalf("Failed to provide query history service: %v", err)
+	}
+
+	if err := DiContainer.Provide(func(schemaVersionRepo repositories.SchemaVersionRepository, chatRepo repositories.ChatRepository, dbManager *dbmanager.Manager) services.SchemaVersionService {
+		return services.NewSchemaVersionService(schemaVersionRepo, chatRepo, dbManager)
+	}); err != nil {
+		log.Fatalf("Failed to provide schema version service: %v", err)
+	}
+
+	// Schema retrieval is disabled without an embedding provider, the whole schema is then sent
+	if err := DiContainer.Provide(func(schemaEmbeddingRepo repositories.SchemaEmbeddingRepository, dbManager *dbmanager.Manager) services.SchemaRetrievalService {
+		var embeddingClient llm.EmbeddingClient
+		if config.Env.SchemaEmbeddingProvider != "" {
+			embeddingConfig := envLLMConfig(config.Env.SchemaEmbeddingProvider)
+			embeddingConfig.Model = config.Env.SchemaEmbeddingModel
+			client, err := llm.NewEmbeddingClient(embeddingConfig)
+			if err != nil {
+				log.Printf("Warning: Failed to create schema embedding client: %v", err)
+			} else {
+				embeddingClient = client
+			}
+		}
+		return services.NewSchemaRetrievalService(schemaEmbeddingRepo, embeddingClient, dbManager, config.Env.SchemaRAGMinTables, config.Env.SchemaRAGTopK)
+	}); err != nil {
+		log.Fatalf("Failed to provide schema retrieval service: %v", err)
+	}
+
+	if err := DiContainer.Provide(func(bookmarkRepo repositories.BookmarkRepository, chatRepo repositories.ChatRepository, organizationRepo repositories.OrganizationRepository) services.BookmarkService {
+		return services.NewBookmarkService(bookmarkRepo, chatRepo, organizationRepo)
+	}); err != nil {
+		log.Fatalf("Failed to provide bookmark service: %v", err)
+	}
+
+	if err := DiContainer.Provide(func(queryExampleRepo repositories.QueryExampleRepository, chatRepo repositories.ChatRepository) services.QueryExampleService {
+		return services.NewQueryExampleService(queryExampleRepo, chatRepo)
+	}); err != nil {
+		log.Fatalf("Failed to provide query example service: %v", err)
+	}
+
+	// The cache is disabled without a TTL
+	if err := DiContainer.Provide(func(redisRepo redis.IRedisRepositories) services.LLMResponseCacheService {
+		return services.NewLLMResponseCacheService(redisRepo, time.Duration(config.Env.LLMResponseCacheTTLMinutes)*time.Minute)
+	}); err != nil {
+		log.Fatalf("Failed to provide LLM response cache service: %v", err)
+	}
+
+	if err := DiContainer.Provide(func(
+		chatRepo repositories.ChatRepository,
+		bookmarkRepo repositories.BookmarkRepository,
+		commentRepo repositories.CommentRepository,
+		dbManager *dbmanager.Manager,
+	) services.DatasetExportService {
+		return services.NewDatasetExportService(chatRepo, bookmarkRepo, commentRepo, dbManager)
+	}); err != nil {
+		log.Fatalf("Failed to provide dataset export service: %v", err)
+	}
+
+	if err := DiContainer.Provide(func(dbManager *dbmanager.Manager) services.MetricsService {
+		return services.NewMetricsService(dbManager)
+	}); err != nil {
+		log.Fatalf("Failed to provide metrics service: %v", err)
+	}
+
+	if err := DiContainer.Provide(func(commentRepo repositories.CommentRepository, chatRepo repositories.ChatRepository, userRepo repositories.UserRepository) services.CommentService {
+		return services.NewCommentService(commentRepo, chatRepo, userRepo)
+	}); err != nil {
+		log.Fatalf("Failed to provide comment service: %v", err)
+	}
+
+	if err := DiContainer.Provide(func(
+		runbookRepo repositories.RunbookRepository,
+		chatRepo repositories.ChatRepository,
+		dbManager *dbmanager.Manager,
+		chatService services.ChatService,
+		notificationService services.NotificationService,
+	) services.RunbookService {
+		return services.NewRunbookService(runbookRepo, chatRepo, dbManager, chatService, notificationService)
+	}); err != nil {
+		log.Fatalf("Failed to provide runbook service: %v", err)
+	}
+
+	if err := DiContainer.Provide(func(
+		jobRepo repositories.AnonymizationJobRepository,
+		chatRepo repositories.ChatRepository,
+		dbManager *dbmanager.Manager,
+		chatService services.ChatService,
+		notificationService services.NotificationService,
+	) services.AnonymizationService {
+		return services.NewAnonymizationService(jobRepo, chatRepo, dbManager, chatService, notificationService)
+	}); err != nil {
+		log.Fatalf("Failed to provide anonymization service: %v", err)
+	}
+
+	if err := DiContainer.Provide(func(
+		jobRepo repositories.QueryJobRepository,
+		chatRepo repositories.ChatRepository,
+		dbManager *dbmanager.Manager,
+		chatService services.ChatService,
+	) services.QueryJobService {
+		return services.NewQueryJobService(jobRepo, chatRepo, dbManager, chatService)
+	}); err != nil {
+		log.Fatalf("Failed to provide query job service: %v", err)
+	}
+
+	if err := DiContainer.Provide(func(
+		scheduleRepo repositories.ScheduledQueryRepository,
+		chatRepo repositories.ChatRepository,
+		bookmarkRepo repositories.BookmarkRepository,
+		chatService services.ChatService,
+	) services.ScheduledQueryService {
+		return services.NewScheduledQueryService(scheduleRepo, chatRepo, bookmarkRepo, chatService)
+	}); err != nil {
+		log.Fatalf("Failed to provide scheduled query service: %v", err)
+	}
+
+	if err := DiContainer.Provide(func(
+		templateRepo repositories.QueryTemplateRepository,
+		chatRepo repositories.ChatRepository,
+		chatService services.ChatService,
+	) services.QueryTemplateService {
+		return services.NewQueryTemplateService(templateRepo, chatRepo, chatService)
+	}); err != nil {
+		log.Fatalf("Failed to provide query template service: %v", err)
+	}
+
 	// Provide handlers
 	if err := DiContainer.Provide(func(authService services.AuthService) *handlers.AuthHandler {
 		return handlers.NewAuthHandler(authService)
@@ -246,13 +548,142 @@ func Initialize() {
 	// Chat Handler
 	if err := DiContainer.Provide(func(
 		chatService services.ChatService,
+		notificationService services.NotificationService,
 	) *handlers.ChatHandler {
 		handler := handlers.NewChatHandler(chatService)
 		chatService.SetStreamHandler(handler)
+		// Notifications are pushed over the chat streams of the user
+		notificationService.SetPusher(handler)
 		return handler
 	}); err != nil {
 		log.Fatalf("Failed to provide chat handler: %v", err)
 	}
+
+	// Saved Connection Handler
+	if err := DiContainer.Provide(func(savedConnectionService services.SavedConnectionService) *handlers.SavedConnectionHandler {
+		return handlers.NewSavedConnectionHandler(savedConnectionService)
+	}); err != nil {
+		log.Fatalf("Failed to provide saved connection handler: %v", err)
+	}
+
+	// Bookmark Handler
+	if err := DiContainer.Provide(func(bookmarkService services.BookmarkService) *handlers.BookmarkHandler {
+		return handlers.NewBookmarkHandler(bookmarkService)
+	}); err != nil {
+		log.Fatalf("Failed to provide bookmark handler: %v", err)
+	}
+
+	// Query Example Handler
+	if err := DiContainer.Provide(func(queryExampleService services.QueryExampleService) *handlers.QueryExampleHandler {
+		return handlers.NewQueryExampleHandler(queryExampleService)
+	}); err != nil {
+		log.Fatalf("Failed to provide query example handler: %v", err)
+	}
+
+	// Comment Handler
+	if err := DiContainer.Provide(func(commentService services.CommentService) *handlers.CommentHandler {
+		return handlers.NewCommentHandler(commentService)
+	}); err != nil {
+		log.Fatalf("Failed to provide comment handler: %v", err)
+	}
+
+	// Runbook Handler
+	if err := DiContainer.Provide(func(runbookService services.RunbookService) *handlers.RunbookHandler {
+		return handlers.NewRunbookHandler(runbookService)
+	}); err != nil {
+		log.Fatalf("Failed to provide runbook handler: %v", err)
+	}
+
+	// Anonymization Handler
+	if err := DiContainer.Provide(func(anonymizationService services.AnonymizationService) *handlers.AnonymizationHandler {
+		return handlers.NewAnonymizationHandler(anonymizationService)
+	}); err != nil {
+		log.Fatalf("Failed to provide anonymization handler: %v", err)
+	}
+
+	// Query Job Handler
+	if err := DiContainer.Provide(func(queryJobService services.QueryJobService) *handlers.QueryJobHandler {
+		return handlers.NewQueryJobHandler(queryJobService)
+	}); err != nil {
+		log.Fatalf("Failed to provide query job handler: %v", err)
+	}
+
+	// Scheduled Query Handler
+	if err := DiContainer.Provide(func(scheduledQueryService services.ScheduledQueryService) *handlers.ScheduledQueryHandler {
+		return handlers.NewScheduledQueryHandler(scheduledQueryService)
+	}); err != nil {
+		log.Fatalf("Failed to provide scheduled query handler: %v", err)
+	}
+
+	// Query Template Handler
+	if err := DiContainer.Provide(func(queryTemplateService services.QueryTemplateService) *handlers.QueryTemplateHandler {
+		return handlers.NewQueryTemplateHandler(queryTemplateService)
+	}); err != nil {
+		log.Fatalf("Failed to provide query template handler: %v", err)
+	}
+
+	// Organization Handler
+	if err := DiContainer.Provide(func(organizationService services.OrganizationService) *handlers.OrganizationHandler {
+		return handlers.NewOrganizationHandler(organizationService)
+	}); err != nil {
+		log.Fatalf("Failed to provide organization handler: %v", err)
+	}
+
+	// Lineage Handler
+	if err := DiContainer.Provide(func(lineageService services.LineageService) *handlers.LineageHandler {
+		return handlers.NewLineageHandler(lineageService)
+	}); err != nil {
+		log.Fatalf("Failed to provide lineage handler: %v", err)
+	}
+
+	// Notification Handler
+	if err := DiContainer.Provide(func(notificationService services.NotificationService) *handlers.NotificationHandler {
+		return handlers.NewNotificationHandler(notificationService)
+	}); err != nil {
+		log.Fatalf("Failed to provide notification handler: %v", err)
+	}
+
+	// LLM Usage Handler
+	if err := DiContainer.Provide(func(llmUsageService services.LLMUsageService) *handlers.LLMUsageHandler {
+		return handlers.NewLLMUsageHandler(llmUsageService)
+	}); err != nil {
+		log.Fatalf("Failed to provide LLM usage handler: %v", err)
+	}
+
+	// Query History Handler
+	if err := DiContainer.Provide(func(queryHistoryService services.QueryHistoryService) *handlers.QueryHistoryHandler {
+		return handlers.NewQueryHistoryHandler(queryHistoryService)
+	}); err != nil {
+		log.Fatalf("Failed to provide query history handler: %v", err)
+	}
+
+	// Schema Version Handler
+	if err := DiContainer.Provide(func(schemaVersionService services.SchemaVersionService) *handlers.SchemaVersionHandler {
+		return handlers.NewSchemaVersionHandler(schemaVersionService)
+	}); err != nil {
+		log.Fatalf("Failed to provide schema version handler: %v", err)
+	}
+
+	// Prompt Template Handler
+	if err := DiContainer.Provide(func(promptTemplateService services.PromptTemplateService) *handlers.PromptTemplateHandler {
+		return handlers.NewPromptTemplateHandler(promptTemplateService)
+	}); err != nil {
+		log.Fatalf("Failed to provide prompt template handler: %v", err)
+	}
+
+	// Dataset Export Handler
+	if err := DiContainer.Provide(func(datasetExportService services.DatasetExportService) *handlers.DatasetExportHandler {
+		return handlers.NewDatasetExportHandler(datasetExportService)
+	}); err != nil {
+		log.Fatalf("Failed to provide dataset export handler: %v", err)
+	}
+
+	// Metrics Handler
+	if err := DiContainer.Provide(func(metricsService services.MetricsService) *handlers.MetricsHandler {
+		return handlers.NewMetricsHandler(metricsService)
+	}); err != nil {
+		log.Fatalf("Failed to provide metrics handler: %v", err)
+	}
 }
 
 // GetAuthHandler retrieves the AuthHandler from the DI container
@@ -290,3 +721,317 @@ func GetGitHubHandler() (*handlers.GitHubHandler, error) {
 	}
 	return handler, nil
 }
+
+// GetSavedConnectionHandler retrieves the SavedConnectionHandler from the DI container
+func GetSavedConnectionHandler() (*handlers.SavedConnectionHandler, error) {
+	var handler *handlers.SavedConnectionHandler
+	err := DiContainer.Invoke(func(h *handlers.SavedConnectionHandler) {
+		handler = h
+	})
+	if err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+// GetBookmarkHandler retrieves the BookmarkHandler from the DI container
+func GetBookmarkHandler() (*handlers.BookmarkHandler, error) {
+	var handler *handlers.BookmarkHandler
+	err := DiContainer.Invoke(func(h *handlers.BookmarkHandler) {
+		handler = h
+	})
+	if err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+// GetQueryExampleHandler retrieves the QueryExampleHandler from the DI container
+func GetQueryExampleHandler() (*handlers.QueryExampleHandler, error) {
+	var handler *handlers.QueryExampleHandler
+	err := DiContainer.Invoke(func(h *handlers.QueryExampleHandler) {
+		handler = h
+	})
+	if err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+// GetCommentHandler retrieves the CommentHandler from the DI container
+func GetCommentHandler() (*handlers.CommentHandler, error) {
+	var handler *handlers.CommentHandler
+	err := DiContainer.Invoke(func(h *handlers.CommentHandler) {
+		handler = h
+	})
+	if err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+// GetRunbookHandler retrieves the RunbookHandler from the DI container
+func GetRunbookHandler() (*handlers.RunbookHandler, error) {
+	var handler *handlers.RunbookHandler
+	err := DiContainer.Invoke(func(h *handlers.RunbookHandler) {
+		handler = h
+	})
+	if err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+// GetQueryJobHandler retrieves the QueryJobHandler from the DI container
+func GetQueryJobHandler() (*handlers.QueryJobHandler, error) {
+	var handler *handlers.QueryJobHandler
+	err := DiContainer.Invoke(func(h *handlers.QueryJobHandler) {
+		handler = h
+	})
+	if err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+// GetScheduledQueryHandler retrieves the ScheduledQueryHandler from the DI container
+func GetScheduledQueryHandler() (*handlers.ScheduledQueryHandler, error) {
+	var handler *handlers.ScheduledQueryHandler
+	err := DiContainer.Invoke(func(h *handlers.ScheduledQueryHandler) {
+		handler = h
+	})
+	if err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+// GetQueryTemplateHandler retrieves the QueryTemplateHandler from the DI container
+func GetQueryTemplateHandler() (*handlers.QueryTemplateHandler, error) {
+	var handler *handlers.QueryTemplateHandler
+	err := DiContainer.Invoke(func(h *handlers.QueryTemplateHandler) {
+		handler = h
+	})
+	if err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+// GetAnonymizationHandler retrieves the AnonymizationHandler from the DI container
+func GetAnonymizationHandler() (*handlers.AnonymizationHandler, error) {
+	var handler *handlers.AnonymizationHandler
+	err := DiContainer.Invoke(func(h *handlers.AnonymizationHandler) {
+		handler = h
+	})
+	if err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+// GetOrganizationHandler retrieves the OrganizationHandler from the DI container
+func GetOrganizationHandler() (*handlers.OrganizationHandler, error) {
+	var handler *handlers.OrganizationHandler
+	err := DiContainer.Invoke(func(h *handlers.OrganizationHandler) {
+		handler = h
+	})
+	if err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+// envLLMConfig builds the config of an LLM client from the model & the credentials of the provider in env
+func envLLMConfig(provider string) llm.Config {
+	switch provider {
+	case constants.OpenAI:
+		return buildLLMConfig(provider, config.Env.OpenAIModel, config.Env.OpenAIAPIKey)
+	case constants.AzureOpenAI:
+		return buildLLMConfig(provider, config.Env.AzureOpenAIDeployment, config.Env.AzureOpenAIAPIKey)
+	case constants.Gemini:
+		return buildLLMConfig(provider, config.Env.GeminiModel, config.Env.GeminiAPIKey)
+	case constants.Ollama:
+		return buildLLMConfig(provider, config.Env.OllamaModel, "")
+	case constants.Claude:
+		return buildLLMConfig(provider, config.Env.ClaudeModel, config.Env.ClaudeAPIKey)
+	case constants.Bedrock:
+		return buildLLMConfig(provider, config.Env.BedrockModel, config.Env.AWSAccessKeyID)
+	case constants.OpenAICompatible:
+		return buildLLMConfig(provider, config.Env.OpenAICompatibleModel, config.Env.OpenAICompatibleAPIKey)
+	}
+	return buildLLMConfig(provider, "", "")
+}
+
+// buildLLMConfig builds the config of an LLM client, token limits & temperature come from env
+func buildLLMConfig(provider, model, apiKey string) llm.Config {
+	llmConfig := llm.Config{
+		Provider:  provider,
+		Model:     model,
+		APIKey:    apiKey,
+		DBConfigs: make([]llm.LLMDBConfig, 0, len(constants.LLMDatabaseTypes)),
+	}
+
+	switch provider {
+	case constants.OpenAI:
+		llmConfig.MaxCompletionTokens = config.Env.OpenAIMaxCompletionTokens
+		llmConfig.Temperature = config.Env.OpenAITemperature
+	case constants.AzureOpenAI:
+		llmConfig.BaseURL = config.Env.AzureOpenAIEndpoint
+		llmConfig.APIVersion = config.Env.AzureOpenAIAPIVersion
+		llmConfig.MaxCompletionTokens = config.Env.AzureOpenAIMaxCompletionTokens
+		llmConfig.Temperature = config.Env.AzureOpenAITemperature
+	case constants.Gemini:
+		llmConfig.MaxCompletionTokens = config.Env.GeminiMaxCompletionTokens
+		llmConfig.Temperature = config.Env.GeminiTemperature
+		llmConfig.SafetyThreshold = config.Env.GeminiSafetyThreshold
+	case constants.Ollama:
+		llmConfig.BaseURL = config.Env.OllamaBaseURL
+		llmConfig.MaxCompletionTokens = config.Env.OllamaMaxCompletionTokens
+		llmConfig.Temperature = config.Env.OllamaTemperature
+	case constants.Claude:
+		llmConfig.MaxCompletionTokens = config.Env.ClaudeMaxCompletionTokens
+		llmConfig.Temperature = config.Env.ClaudeTemperature
+	case constants.Bedrock:
+		llmConfig.SecretKey = config.Env.AWSSecretAccessKey
+		llmConfig.SessionToken = config.Env.AWSSessionToken
+		llmConfig.Region = config.Env.AWSRegion
+		llmConfig.MaxCompletionTokens = config.Env.BedrockMaxCompletionTokens
+		llmConfig.Temperature = config.Env.BedrockTemperature
+	case constants.OpenAICompatible:
+		llmConfig.BaseURL = config.Env.OpenAICompatibleBaseURL
+		llmConfig.MaxCompletionTokens = config.Env.OpenAICompatibleMaxCompletionTokens
+		llmConfig.Temperature = config.Env.OpenAICompatibleTemperature
+		llmConfig.ResponseFormat = config.Env.OpenAICompatibleResponseFormat
+		llmConfig.DisableTools = !config.Env.OpenAICompatibleToolCalling
+	}
+
+	for _, dbType := range constants.LLMDatabaseTypes {
+		llmConfig.DBConfigs = append(llmConfig.DBConfigs, llm.LLMDBConfig{
+			DBType:       dbType,
+			Schema:       constants.GetLLMResponseSchema(provider, dbType),
+			SystemPrompt: constants.GetSystemPrompt(provider, dbType),
+		})
+	}
+	return llmConfig
+}
+
+// GetLineageHandler retrieves the LineageHandler from the DI container
+func GetLineageHandler() (*handlers.LineageHandler, error) {
+	var handler *handlers.LineageHandler
+	err := DiContainer.Invoke(func(h *handlers.LineageHandler) {
+		handler = h
+	})
+	if err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+// GetNotificationHandler retrieves the NotificationHandler from the DI container
+func GetNotificationHandler() (*handlers.NotificationHandler, error) {
+	var handler *handlers.NotificationHandler
+	err := DiContainer.Invoke(func(h *handlers.NotificationHandler) {
+		handler = h
+	})
+	if err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+// GetQueryHistoryHandler retrieves the QueryHistoryHandler from the DI container
+func GetQueryHistoryHandler() (*handlers.QueryHistoryHandler, error) {
+	var handler *handlers.QueryHistoryHandler
+	err := DiContainer.Invoke(func(h *handlers.QueryHistoryHandler) {
+		handler = h
+	})
+	if err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+// GetSchemaVersionHandler retrieves the SchemaVersionHandler from the DI container
+func GetSchemaVersionHandler() (*handlers.SchemaVersionHandler, error) {
+	var handler *handlers.SchemaVersionHandler
+	err := DiContainer.Invoke(func(h *handlers.SchemaVersionHandler) {
+		handler = h
+	})
+	if err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+// GetLLMUsageHandler retrieves the LLMUsageHandler from the DI container
+func GetLLMUsageHandler() (*handlers.LLMUsageHandler, error) {
+	var handler *handlers.LLMUsageHandler
+	err := DiContainer.Invoke(func(h *handlers.LLMUsageHandler) {
+		handler = h
+	})
+	if err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+// GetPromptTemplateHandler retrieves the PromptTemplateHandler from the DI container
+func GetPromptTemplateHandler() (*handlers.PromptTemplateHandler, error) {
+	var handler *handlers.PromptTemplateHandler
+	err := DiContainer.Invoke(func(h *handlers.PromptTemplateHandler) {
+		handler = h
+	})
+	if err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+// GetDatasetExportHandler retrieves the DatasetExportHandler from the DI container
+func GetDatasetExportHandler() (*handlers.DatasetExportHandler, error) {
+	var handler *handlers.DatasetExportHandler
+	err := DiContainer.Invoke(func(h *handlers.DatasetExportHandler) {
+		handler = h
+	})
+	if err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+// GetMetricsHandler retrieves the MetricsHandler from the DI container
+func GetMetricsHandler() (*handlers.MetricsHandler, error) {
+	var handler *handlers.MetricsHandler
+	err := DiContainer.Invoke(func(h *handlers.MetricsHandler) {
+		handler = h
+	})
+	if err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+// GetDBManager retrieves the DB manager from the DI container, used by the eval mode
+func GetDBManager() (*dbmanager.Manager, error) {
+	var manager *dbmanager.Manager
+	err := DiContainer.Invoke(func(m *dbmanager.Manager) {
+		manager = m
+	})
+	if err != nil {
+		return nil, err
+	}
+	return manager, nil
+}
+
+// GetLLMManager retrieves the LLM manager from the DI container, used by the eval mode
+func GetLLMManager() (*llm.Manager, error) {
+	var manager *llm.Manager
+	err := DiContainer.Invoke(func(m *llm.Manager) {
+		manager = m
+	})
+	if err != nil {
+		return nil, err
+	}
+	return manager, nil
+}
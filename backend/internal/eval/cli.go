@@ -0,0 +1,82 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"neobase-ai/config"
+	"neobase-ai/internal/di"
+	"os"
+)
+
+// RunCLI replays a corpus of questions against seeded test databases & scores the generated queries, e.g. to regression-test a prompt change.
+// It returns the exit code: 1 when the score is below -min-score or the run failed.
+func RunCLI(args []string) int {
+	flags := flag.NewFlagSet("eval", flag.ContinueOnError)
+	corpusPath := flags.String("corpus", "eval/corpus.example.json", "Corpus of questions & expected queries")
+	database := flags.String("database", "", "Only run the cases of this database of the corpus")
+	output := flags.String("out", "", "Write the JSON report to this file")
+	minScore := flags.Float64("min-score", 0, "Fail when the share of passed cases is below this score, between 0 & 1")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	corpus, err := LoadCorpus(*corpusPath)
+	if err != nil {
+		log.Printf("Eval -> %v", err)
+		return 1
+	}
+
+	dbManager, err := di.GetDBManager()
+	if err != nil {
+		log.Printf("Eval -> Failed to get DB manager: %v", err)
+		return 1
+	}
+	llmManager, err := di.GetLLMManager()
+	if err != nil {
+		log.Printf("Eval -> Failed to get LLM manager: %v", err)
+		return 1
+	}
+	llmClient, err := llmManager.GetClient(config.Env.DefaultLLMClient)
+	if err != nil {
+		log.Printf("Eval -> Failed to get LLM client: %v", err)
+		return 1
+	}
+
+	report, err := NewRunner(dbManager, llmClient).Run(context.Background(), corpus, *database)
+	if err != nil {
+		log.Printf("Eval -> %v", err)
+		return 1
+	}
+
+	if *output != "" {
+		reportJSON, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			log.Printf("Eval -> Failed to encode report: %v", err)
+			return 1
+		}
+		if err := os.WriteFile(*output, reportJSON, 0644); err != nil {
+			log.Printf("Eval -> Failed to write report: %v", err)
+			return 1
+		}
+	}
+
+	fmt.Printf("\nEval of %s (%s)\n", report.Model, report.Provider)
+	for _, result := range report.Cases {
+		if !result.Passed {
+			fmt.Printf("  ✗ %s [%s] %s\n", result.ID, result.Reason, result.Error)
+		}
+	}
+	for _, score := range report.Databases {
+		fmt.Printf("  %s (%s): %d/%d passed, %.0f%%\n", score.Name, score.Type, score.Passed, score.Total, score.Score*100)
+	}
+	fmt.Printf("Total: %d/%d passed, %.0f%%\n", report.Passed, report.Total, report.Score*100)
+
+	if report.Score < *minScore {
+		fmt.Printf("Score below the minimum of %.0f%%\n", *minScore*100)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,88 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"neobase-ai/pkg/dbmanager"
+	"os"
+	"strings"
+)
+
+// Corpus is a set of questions asked on test databases, with the query answering each of them
+type Corpus struct {
+	Databases []Database `json:"databases"`
+	Cases     []Case     `json:"cases"`
+}
+
+// Database is a test database, seeded before its cases are run.
+// The host, port, username, password & database of the connection can reference environment variables, e.g. ${EVAL_POSTGRES_PASSWORD}.
+type Database struct {
+	Name       string                     `json:"name"`
+	Connection dbmanager.ConnectionConfig `json:"connection"`
+	Seed       []string                   `json:"seed"` // statements run in order before the cases, they should recreate the data from scratch
+}
+
+// Case is a question & the query expected for it. The generated query passes when it returns the rows of the expected one,
+// a write passes when it is the expected query once the case & the spacing are ignored.
+type Case struct {
+	ID            string `json:"id"`
+	Database      string `json:"database"`
+	Question      string `json:"question"`
+	ExpectedQuery string `json:"expected_query"`
+	Ordered       bool   `json:"ordered"` // the rows must be in the same order, e.g. for a top N question
+}
+
+// LoadCorpus reads & validates a corpus file
+func LoadCorpus(path string) (*Corpus, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read corpus: %v", err)
+	}
+
+	var corpus Corpus
+	if err := json.Unmarshal(data, &corpus); err != nil {
+		return nil, fmt.Errorf("invalid corpus: %v", err)
+	}
+
+	databases := make(map[string]bool, len(corpus.Databases))
+	for i := range corpus.Databases {
+		database := &corpus.Databases[i]
+		if database.Name == "" || database.Connection.Type == "" {
+			return nil, fmt.Errorf("database %d: name & connection type are required", i+1)
+		}
+		if databases[database.Name] {
+			return nil, fmt.Errorf("database %s is listed several times", database.Name)
+		}
+		databases[database.Name] = true
+		expandConnectionEnv(&database.Connection)
+	}
+
+	cases := make(map[string]bool, len(corpus.Cases))
+	for i, evalCase := range corpus.Cases {
+		if evalCase.ID == "" {
+			return nil, fmt.Errorf("case %d: id is required", i+1)
+		}
+		if cases[evalCase.ID] {
+			return nil, fmt.Errorf("case %s is listed several times", evalCase.ID)
+		}
+		cases[evalCase.ID] = true
+		if !databases[evalCase.Database] {
+			return nil, fmt.Errorf("case %s: unknown database %s", evalCase.ID, evalCase.Database)
+		}
+		if strings.TrimSpace(evalCase.Question) == "" || strings.TrimSpace(evalCase.ExpectedQuery) == "" {
+			return nil, fmt.Errorf("case %s: question & expected_query are required", evalCase.ID)
+		}
+	}
+	return &corpus, nil
+}
+
+// expandConnectionEnv replaces the environment variables of a connection, the queries are left as is since MongoDB ones use $
+func expandConnectionEnv(connection *dbmanager.ConnectionConfig) {
+	connection.Host = os.ExpandEnv(connection.Host)
+	connection.Database = os.ExpandEnv(connection.Database)
+	for _, value := range []*string{connection.Port, connection.Username, connection.Password} {
+		if value != nil {
+			*value = os.ExpandEnv(*value)
+		}
+	}
+}
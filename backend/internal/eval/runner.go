@@ -0,0 +1,330 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"neobase-ai/internal/constants"
+	"neobase-ai/internal/models"
+	"neobase-ai/pkg/dbmanager"
+	"neobase-ai/pkg/llm"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	evalChatIDPrefix = "eval-" // Prefix of the chat ID the test databases are connected with
+	evalUserID       = "eval"
+)
+
+// Why a case failed
+const (
+	ReasonConnectionError    = "connection_error"     // the test database couldn't be connected or seeded
+	ReasonExpectedQueryError = "expected_query_error" // the expected query of the corpus fails
+	ReasonLLMError           = "llm_error"
+	ReasonInvalidResponse    = "invalid_response" // the response doesn't match the response schema
+	ReasonNoQuery            = "no_query"         // the LLM answered without a query, e.g. with a clarification
+	ReasonQueryError         = "query_error"      // the generated query fails
+	ReasonResultMismatch     = "result_mismatch"
+	ReasonQueryMismatch      = "query_mismatch" // the generated write isn't the expected one
+)
+
+// Report is the outcome of the cases, the score is the share of passed cases
+type Report struct {
+	Provider  string          `json:"provider"`
+	Model     string          `json:"model"`
+	Passed    int             `json:"passed"`
+	Total     int             `json:"total"`
+	Score     float64         `json:"score"`
+	Databases []DatabaseScore `json:"databases"`
+	Cases     []CaseResult    `json:"cases"`
+}
+
+type DatabaseScore struct {
+	Name   string  `json:"name"`
+	Type   string  `json:"type"`
+	Passed int     `json:"passed"`
+	Total  int     `json:"total"`
+	Score  float64 `json:"score"`
+}
+
+type CaseResult struct {
+	ID             string `json:"id"`
+	Database       string `json:"database"`
+	Question       string `json:"question"`
+	Passed         bool   `json:"passed"`
+	Reason         string `json:"reason,omitempty"` // why the case failed, see the Reason constants
+	Error          string `json:"error,omitempty"`
+	GeneratedQuery string `json:"generated_query,omitempty"`
+	DurationMs     int64  `json:"duration_ms"` // time taken by the LLM to answer
+}
+
+// Runner asks the questions of a corpus to the LLM like the first message of a chat & runs the generated queries on the test databases
+type Runner struct {
+	dbManager *dbmanager.Manager
+	llmClient llm.Client
+}
+
+func NewRunner(dbManager *dbmanager.Manager, llmClient llm.Client) *Runner {
+	return &Runner{
+		dbManager: dbManager,
+		llmClient: llmClient,
+	}
+}
+
+// Run runs the cases of every database of the corpus, only the ones of the named database when not empty
+func (r *Runner) Run(ctx context.Context, corpus *Corpus, database string) (*Report, error) {
+	modelInfo := r.llmClient.GetModelInfo()
+	report := &Report{Provider: modelInfo.Provider, Model: modelInfo.Name}
+
+	// Eval runs must be reproducible, the LLM answers deterministically
+	temperature := 0.0
+	ctx = llm.WithSampling(ctx, llm.Sampling{Temperature: &temperature})
+
+	for _, db := range corpus.Databases {
+		if database != "" && db.Name != database {
+			continue
+		}
+		var cases []Case
+		for _, evalCase := range corpus.Cases {
+			if evalCase.Database == db.Name {
+				cases = append(cases, evalCase)
+			}
+		}
+		if len(cases) == 0 {
+			continue
+		}
+
+		log.Printf("Eval -> Run -> Running %d cases on %s (%s)", len(cases), db.Name, db.Connection.Type)
+		results := r.runDatabase(ctx, db, cases)
+
+		score := DatabaseScore{Name: db.Name, Type: db.Connection.Type, Total: len(results)}
+		for _, result := range results {
+			if result.Passed {
+				score.Passed++
+			}
+		}
+		score.Score = ratio(score.Passed, score.Total)
+		report.Databases = append(report.Databases, score)
+		report.Cases = append(report.Cases, results...)
+		report.Passed += score.Passed
+		report.Total += score.Total
+	}
+	if report.Total == 0 {
+		return nil, fmt.Errorf("no case to run")
+	}
+	report.Score = ratio(report.Passed, report.Total)
+	return report, nil
+}
+
+// runDatabase connects & seeds a test database, then runs its cases. The cases fail together when the database can't be prepared.
+func (r *Runner) runDatabase(ctx context.Context, db Database, cases []Case) []CaseResult {
+	chatID := evalChatIDPrefix + db.Name
+	schema, err := r.prepareDatabase(ctx, chatID, db)
+	defer func() {
+		if err := r.dbManager.Disconnect(chatID, evalUserID, true); err != nil {
+			log.Printf("Eval -> runDatabase -> Error disconnecting %s: %v", db.Name, err)
+		}
+	}()
+
+	results := make([]CaseResult, 0, len(cases))
+	for _, evalCase := range cases {
+		result := CaseResult{ID: evalCase.ID, Database: evalCase.Database, Question: evalCase.Question}
+		if err != nil {
+			result.Reason = ReasonConnectionError
+			result.Error = err.Error()
+		} else {
+			r.runCase(ctx, chatID, db.Connection.Type, schema, evalCase, &result)
+		}
+		log.Printf("Eval -> runDatabase -> %s: passed %t %s", evalCase.ID, result.Passed, result.Reason)
+		results = append(results, result)
+	}
+	return results
+}
+
+// prepareDatabase returns the schema sent to the LLM once the database is seeded
+func (r *Runner) prepareDatabase(ctx context.Context, chatID string, db Database) (string, error) {
+	if err := r.dbManager.Connect(chatID, evalUserID, "", db.Connection); err != nil {
+		return "", fmt.Errorf("failed to connect: %v", err)
+	}
+	if queryErr := r.dbManager.ExecuteStatements(ctx, chatID, db.Seed); queryErr != nil {
+		return "", fmt.Errorf("failed to seed: %s %s", queryErr.Message, queryErr.Details)
+	}
+	schema, err := r.dbManager.RefreshSchemaWithExamples(ctx, chatID, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch schema: %v", err)
+	}
+	return schema, nil
+}
+
+func (r *Runner) runCase(ctx context.Context, chatID, dbType, schema string, evalCase Case, result *CaseResult) {
+	readOnly := dbmanager.IsReadOnlyQuery(dbType, evalCase.ExpectedQuery)
+
+	// The expected rows are read first, a failing expected query is a mistake of the corpus rather than of the LLM
+	var expectedRows [][]string
+	if readOnly {
+		expected, queryErr := r.dbManager.ExecuteReadOnlyQuery(ctx, chatID, evalCase.ExpectedQuery)
+		if queryErr != nil {
+			result.Reason = ReasonExpectedQueryError
+			result.Error = queryErr.Message + ": " + queryErr.Details
+			return
+		}
+		expectedRows = resultRows(expected)
+	}
+
+	messages := []*models.LLMMessage{
+		{
+			Role:    string(constants.MessageTypeSystem),
+			Content: map[string]interface{}{"schema_update": schema},
+		},
+		{
+			Role:    string(constants.MessageTypeUser),
+			Content: map[string]interface{}{"user_message": evalCase.Question},
+		},
+	}
+	start := time.Now()
+	response, err := r.llmClient.GenerateResponse(ctx, messages, dbType)
+	result.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Reason = ReasonLLMError
+		var invalidErr *llm.InvalidResponseError
+		if errors.As(err, &invalidErr) {
+			result.Reason = ReasonInvalidResponse
+		}
+		result.Error = err.Error()
+		return
+	}
+
+	var parsed constants.LLMResponse
+	if err := json.Unmarshal([]byte(response), &parsed); err != nil {
+		result.Reason = ReasonInvalidResponse
+		result.Error = err.Error()
+		return
+	}
+	if len(parsed.Queries) == 0 || strings.TrimSpace(parsed.Queries[0].Query) == "" {
+		result.Reason = ReasonNoQuery
+		result.Error = parsed.AssistantMessage
+		return
+	}
+	result.GeneratedQuery = parsed.Queries[0].Query
+
+	if !readOnly {
+		// Writes are not run, they would change the data of the next cases
+		if normalizeQuery(result.GeneratedQuery) != normalizeQuery(evalCase.ExpectedQuery) {
+			result.Reason = ReasonQueryMismatch
+			return
+		}
+		result.Passed = true
+		return
+	}
+
+	generated, queryErr := r.dbManager.ExecuteReadOnlyQuery(ctx, chatID, result.GeneratedQuery)
+	if queryErr != nil {
+		result.Reason = ReasonQueryError
+		result.Error = queryErr.Message + ": " + queryErr.Details
+		return
+	}
+	if !rowsMatch(resultRows(generated), expectedRows, evalCase.Ordered) {
+		result.Reason = ReasonResultMismatch
+		return
+	}
+	result.Passed = true
+}
+
+// resultRows returns the values of each row of a query result, sorted so that the order & the names of the columns don't matter
+func resultRows(resultJSON string) [][]string {
+	var result interface{}
+	if err := json.Unmarshal([]byte(resultJSON), &result); err != nil {
+		return [][]string{{resultJSON}}
+	}
+	if object, ok := result.(map[string]interface{}); ok {
+		if results, hasResults := object["results"]; hasResults {
+			result = results
+		}
+	}
+	rows, ok := result.([]interface{})
+	if !ok {
+		rows = []interface{}{result}
+	}
+
+	values := make([][]string, 0, len(rows))
+	for _, row := range rows {
+		var rowValues []string
+		if columns, isObject := row.(map[string]interface{}); isObject {
+			for _, value := range columns {
+				rowValues = append(rowValues, jsonValue(value))
+			}
+		} else {
+			rowValues = append(rowValues, jsonValue(row))
+		}
+		sort.Strings(rowValues)
+		values = append(values, rowValues)
+	}
+	return values
+}
+
+func jsonValue(value interface{}) string {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(encoded)
+}
+
+// rowsMatch reports whether the generated rows hold the expected ones, a generated row can have more columns (e.g. the _id of MongoDB)
+func rowsMatch(generated, expected [][]string, ordered bool) bool {
+	if len(generated) != len(expected) {
+		return false
+	}
+	if ordered {
+		for i := range expected {
+			if !containsValues(generated[i], expected[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	matched := make([]bool, len(generated))
+	for _, expectedRow := range expected {
+		found := false
+		for i, generatedRow := range generated {
+			if !matched[i] && containsValues(generatedRow, expectedRow) {
+				matched[i] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// containsValues reports whether the sorted values of a row hold the sorted expected values
+func containsValues(row, expected []string) bool {
+	i := 0
+	for _, value := range row {
+		if i < len(expected) && value == expected[i] {
+			i++
+		}
+	}
+	return i == len(expected)
+}
+
+// normalizeQuery ignores the case, the spacing & the trailing semicolon of a query
+func normalizeQuery(query string) string {
+	query = strings.ToLower(strings.Join(strings.Fields(query), " "))
+	return strings.TrimRight(query, "; ")
+}
+
+func ratio(passed, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(passed) / float64(total)
+}
@@ -3,6 +3,8 @@ package middleware
 import (
 	"fmt"
 	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"net/http"
 	"runtime/debug"
 
 	"github.com/gin-gonic/gin"
@@ -17,18 +19,14 @@ func CustomRecoveryMiddleware() gin.HandlerFunc {
 				debugStack := string(debug.Stack())
 				fmt.Printf("Recovery from panic: %v\nStack Trace:\n%s\n", err, debugStack)
 
-				// Create error message
-				errorMsg := "Internal Server Error"
+				// Create error, the panic value is only exposed in debug mode
+				appErr := apperrors.New("INTERNAL_ERROR", "Internal Server Error")
 				if gin.IsDebugging() {
-					errorMsg = fmt.Sprintf("Internal Server Error: %v", err)
+					appErr = apperrors.New("INTERNAL_ERROR", "Internal Server Error: {error}").With("error", fmt.Sprint(err))
 				}
 
 				// Return error response using response DTO
-				c.AbortWithStatusJSON(500, dtos.Response{
-					Success: false,
-					Error:   &errorMsg,
-					Data:    nil,
-				})
+				c.AbortWithStatusJSON(http.StatusInternalServerError, dtos.NewErrorResponse(http.StatusInternalServerError, appErr))
 			}
 		}()
 		c.Next()
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Anonymization job statuses
+const (
+	AnonymizationJobStatusRunning   = "running"
+	AnonymizationJobStatusCompleted = "completed"
+	AnonymizationJobStatusFailed    = "failed"
+	AnonymizationJobStatusCancelled = "cancelled"
+)
+
+// Anonymization table statuses
+const (
+	AnonymizationTableStatusPending   = "pending"
+	AnonymizationTableStatusCopying   = "copying"
+	AnonymizationTableStatusVerifying = "verifying"
+	AnonymizationTableStatusCompleted = "completed"
+	AnonymizationTableStatusFailed    = "failed"
+)
+
+// AnonymizationJob clones tables from the database of a source chat to the database of a target chat with PII masked,
+// e.g. to refresh a staging database from production
+type AnonymizationJob struct {
+	UserID       primitive.ObjectID   `bson:"user_id" json:"user_id"`
+	SourceChatID primitive.ObjectID   `bson:"source_chat_id" json:"source_chat_id"`
+	TargetChatID primitive.ObjectID   `bson:"target_chat_id" json:"target_chat_id"`
+	Tables       []AnonymizationTable `bson:"tables" json:"tables"`
+	ChunkSize    int                  `bson:"chunk_size" json:"chunk_size"`
+	ClearTarget  bool                 `bson:"clear_target" json:"clear_target"` // Deletes the rows of the target tables before copying
+	Salt         string               `bson:"salt" json:"-"`                    // Secret of the hash based strategies, shared by all tables of the job so hashed keys still match
+	Status       string               `bson:"status" json:"status"`
+	CurrentTable int                  `bson:"current_table" json:"current_table"` // Index of the table being copied
+	Error        *string              `bson:"error,omitempty" json:"error,omitempty"`
+	CompletedAt  *time.Time           `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	Base         `bson:",inline"`
+}
+
+// AnonymizationTable is a table of the job with its masking rules & progress
+type AnonymizationTable struct {
+	Table        string                          `bson:"table" json:"table"`
+	Rules        []MaskingRule                   `bson:"rules" json:"rules"`
+	Status       string                          `bson:"status" json:"status"`
+	TotalRows    int64                           `bson:"total_rows" json:"total_rows"`
+	CopiedRows   int64                           `bson:"copied_rows" json:"copied_rows"`
+	Error        *string                         `bson:"error,omitempty" json:"error,omitempty"`
+	Verification *AnonymizationTableVerification `bson:"verification,omitempty" json:"verification,omitempty"`
+	StartedAt    *time.Time                      `bson:"started_at,omitempty" json:"started_at,omitempty"`
+	CompletedAt  *time.Time                      `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+}
+
+// MaskingRule masks a column, the columns without a rule are copied as is
+type MaskingRule struct {
+	Column   string  `bson:"column" json:"column"`
+	Strategy string  `bson:"strategy" json:"strategy"` // keep, hash, redact, null, fixed, partial, fake_email, fake_name
+	Value    *string `bson:"value,omitempty" json:"value,omitempty"`
+}
+
+// AnonymizationTableVerification compares a copied table with its source
+type AnonymizationTableVerification struct {
+	SourceRows     int64    `bson:"source_rows" json:"source_rows"`
+	TargetRows     int64    `bson:"target_rows" json:"target_rows"`
+	CheckedValues  int      `bson:"checked_values" json:"checked_values"`
+	LeakedValues   int64    `bson:"leaked_values" json:"leaked_values"`
+	LeakedColumns  []string `bson:"leaked_columns" json:"leaked_columns"`
+	RowCountsMatch bool     `bson:"row_counts_match" json:"row_counts_match"`
+	Passed         bool     `bson:"passed" json:"passed"`
+}
+
+func NewAnonymizationJob(userID, sourceChatID, targetChatID primitive.ObjectID, tables []AnonymizationTable, chunkSize int, clearTarget bool, salt string) *AnonymizationJob {
+	for i := range tables {
+		tables[i].Status = AnonymizationTableStatusPending
+	}
+
+	return &AnonymizationJob{
+		UserID:       userID,
+		SourceChatID: sourceChatID,
+		TargetChatID: targetChatID,
+		Tables:       tables,
+		ChunkSize:    chunkSize,
+		ClearTarget:  clearTarget,
+		Salt:         salt,
+		Status:       AnonymizationJobStatusRunning,
+		CurrentTable: 0,
+		Base:         NewBase(),
+	}
+}
+
+// IsFinished checks if the job reached a terminal status
+func (j *AnonymizationJob) IsFinished() bool {
+	return j.Status != AnonymizationJobStatusRunning
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// QueryBookmark is a saved snapshot of a query execution that can be opened directly through a share token
+type QueryBookmark struct {
+	UserID            primitive.ObjectID     `bson:"user_id" json:"user_id"`
+	ChatID            primitive.ObjectID     `bson:"chat_id" json:"chat_id"`
+	MessageID         primitive.ObjectID     `bson:"message_id" json:"message_id"`
+	QueryID           primitive.ObjectID     `bson:"query_id" json:"query_id"`
+	Title             string                 `bson:"title" json:"title"`
+	Note              *string                `bson:"note,omitempty" json:"note,omitempty"`
+	Query             string                 `bson:"query" json:"query"`
+	QueryType         *string                `bson:"query_type" json:"query_type"`
+	Tables            *string                `bson:"tables,omitempty" json:"tables,omitempty"`
+	Parameters        map[string]interface{} `bson:"parameters,omitempty" json:"parameters,omitempty"`           // Parameters used for the execution (e.g. offset)
+	ResultSnapshot    *string                `bson:"result_snapshot,omitempty" json:"result_snapshot,omitempty"` // JSON string of the execution result at bookmark time
+	TotalRecordsCount *int                   `bson:"total_records_count,omitempty" json:"total_records_count,omitempty"`
+	ExecutionTime     *int                   `bson:"execution_time,omitempty" json:"execution_time,omitempty"` // in milliseconds
+	ShareToken        string                 `bson:"share_token" json:"share_token"`                           // Token used to open the bookmark through a deep link
+	ExpiresAt         *time.Time             `bson:"expires_at,omitempty" json:"expires_at,omitempty"`         // nil means the share link never expires
+	Base              `bson:",inline"`
+}
+
+func NewQueryBookmark(userID, chatID, messageID, queryID primitive.ObjectID, title string, shareToken string) *QueryBookmark {
+	return &QueryBookmark{
+		UserID:     userID,
+		ChatID:     chatID,
+		MessageID:  messageID,
+		QueryID:    queryID,
+		Title:      title,
+		ShareToken: shareToken,
+		Base:       NewBase(),
+	}
+}
+
+// IsExpired checks if the share link of the bookmark has expired
+func (b *QueryBookmark) IsExpired() bool {
+	return b.ExpiresAt != nil && time.Now().After(*b.ExpiresAt)
+}
@@ -5,8 +5,21 @@ import (
 )
 
 type ChatSettings struct {
-	AutoExecuteQuery bool `bson:"auto_execute_query" json:"auto_execute_query,omitempty"` // default is false, Execute query automatically when LLM response is received
-	ShareDataWithAI  bool `bson:"share_data_with_ai" json:"share_data_with_ai,omitempty"` // default is false, Don't share data with AI
+	AutoExecuteQuery        bool         `bson:"auto_execute_query" json:"auto_execute_query,omitempty"`               // default is false, Execute query automatically when LLM response is received
+	ShareDataWithAI         bool         `bson:"share_data_with_ai" json:"share_data_with_ai,omitempty"`               // default is false, Don't share data with AI
+	AuditChanges            bool         `bson:"audit_changes" json:"audit_changes,omitempty"`                         // default is false, Record changes made through NeoBase with audit triggers
+	AllowDestructiveQueries bool         `bson:"allow_destructive_queries" json:"allow_destructive_queries,omitempty"` // default is false, Block the drops, truncates & unfiltered deletes suggested by the LLM
+	VerifyQueries           bool         `bson:"verify_queries" json:"verify_queries,omitempty"`                       // default is false, Present the queries of the LLM without checking their plan
+	LLMSampling             *LLMSampling `bson:"llm_sampling,omitempty" json:"llm_sampling,omitempty"`                 // default is nil, Use the sampling configured for the LLM
+	LLMModel                string       `bson:"llm_model,omitempty" json:"llm_model,omitempty"`                       // default is "", Use the default model of the organization's provider
+}
+
+// LLMSampling overrides the sampling of the LLM for a chat, e.g. a temperature of 0 for deterministic queries on production databases.
+// Nil fields keep the configuration of the LLM.
+type LLMSampling struct {
+	Temperature *float64 `bson:"temperature,omitempty" json:"temperature,omitempty"`
+	TopP        *float64 `bson:"top_p,omitempty" json:"top_p,omitempty"`
+	MaxTokens   *int     `bson:"max_tokens,omitempty" json:"max_tokens,omitempty"`
 }
 
 type Connection struct {
@@ -17,21 +30,74 @@ type Connection struct {
 	Password    *string `bson:"password" json:"-"` // Hide in JSON
 	Database    string  `bson:"database" json:"database"`
 	AuthDatabase *string `bson:"auth_database" json:"auth_database"` // Database to authenticate against
+	SocketPath   *string `bson:"socket_path,omitempty" json:"socket_path,omitempty"` // Unix socket used instead of host & port
+	ReadReplicas []ReadReplica `bson:"read_replicas,omitempty" json:"read_replicas,omitempty"` // Read-only queries are routed to the replicas
+	ProxyURL     *string `bson:"proxy_url,omitempty" json:"-"` // Hide in JSON, may contain the proxy credentials
+	ReadPreference *string `bson:"read_preference,omitempty" json:"read_preference,omitempty"` // MongoDB read preference, e.g. secondaryPreferred
+	ReadConcern    *string `bson:"read_concern,omitempty" json:"read_concern,omitempty"`       // MongoDB read concern level, e.g. majority
+	WriteConcern   *string `bson:"write_concern,omitempty" json:"write_concern,omitempty"`     // MongoDB write concern, majority or a number of members
+	SchemaSampleSize      *int `bson:"schema_sample_size,omitempty" json:"schema_sample_size,omitempty"`             // MongoDB documents sampled per collection to infer the schema
+	SchemaMaxDepth        *int `bson:"schema_max_depth,omitempty" json:"schema_max_depth,omitempty"`                 // MongoDB nesting levels of embedded documents inferred
+	SchemaArraySampleSize *int `bson:"schema_array_sample_size,omitempty" json:"schema_array_sample_size,omitempty"` // MongoDB array elements sampled per array
+	SchemaNewestSampleSize *int `bson:"schema_newest_sample_size,omitempty" json:"schema_newest_sample_size,omitempty"` // MongoDB newest documents by _id added to the sample
+	QueryTimeoutSeconds *int `bson:"query_timeout_seconds,omitempty" json:"query_timeout_seconds,omitempty"` // Timeout of the queries executed from the chats, the server default when empty
+	ResultDisplayRows *int `bson:"result_display_rows,omitempty" json:"result_display_rows,omitempty"` // Rows of a result shown & stored after an execution, the page size of its pagination
+	MaxResultRows     *int `bson:"max_result_rows,omitempty" json:"max_result_rows,omitempty"`         // Rows read from a result at most, the server default when empty
+	MaxConcurrentQueries *int `bson:"max_concurrent_queries,omitempty" json:"max_concurrent_queries,omitempty"` // Queries run at once, the others wait in a queue, the server default when empty
 	IsExampleDB bool    `bson:"is_example_db" json:"is_example_db"` // default is false, if true, then the database is an example database configs setup from environment variables
 
+	// Authentication mode: password (default), azure_ad, aws_iam or kerberos
+	AuthMode          *string `bson:"auth_mode,omitempty" json:"auth_mode,omitempty"`
+	AzureTenantID     *string `bson:"azure_tenant_id,omitempty" json:"azure_tenant_id,omitempty"`
+	AzureClientID     *string `bson:"azure_client_id,omitempty" json:"azure_client_id,omitempty"`
+	AzureClientSecret *string `bson:"azure_client_secret,omitempty" json:"-"` // Hide in JSON
+
+	// AWS RDS IAM authentication
+	AWSRegion          *string `bson:"aws_region,omitempty" json:"aws_region,omitempty"`
+	AWSAccessKeyID     *string `bson:"aws_access_key_id,omitempty" json:"aws_access_key_id,omitempty"`
+	AWSSecretAccessKey *string `bson:"aws_secret_access_key,omitempty" json:"-"` // Hide in JSON
+
+	// Kerberos (GSSAPI) authentication
+	KerberosRealm       *string `bson:"kerberos_realm,omitempty" json:"kerberos_realm,omitempty"`
+	KerberosKDC         *string `bson:"kerberos_kdc,omitempty" json:"kerberos_kdc,omitempty"`
+	KerberosServiceName *string `bson:"kerberos_service_name,omitempty" json:"kerberos_service_name,omitempty"`
+	KerberosKeytab      *string `bson:"kerberos_keytab,omitempty" json:"-"` // Hide in JSON
+
 	// SSL/TLS Configuration
 	UseSSL         bool    `bson:"use_ssl" json:"use_ssl"`
 	SSLMode        *string `bson:"ssl_mode,omitempty" json:"ssl_mode,omitempty"` // type: disable, require, verify-ca, verify-full
 	SSLCertURL     *string `bson:"ssl_cert_url,omitempty" json:"ssl_cert_url,omitempty"`
 	SSLKeyURL      *string `bson:"ssl_key_url,omitempty" json:"ssl_key_url,omitempty"`
 	SSLRootCertURL *string `bson:"ssl_root_cert_url,omitempty" json:"ssl_root_cert_url,omitempty"`
+	SSLCert        *string `bson:"ssl_cert,omitempty" json:"ssl_cert,omitempty"` // Uploaded PEM content
+	SSLKey         *string `bson:"ssl_key,omitempty" json:"-"`                  // Hide in JSON
+	SSLRootCert    *string `bson:"ssl_root_cert,omitempty" json:"ssl_root_cert,omitempty"`
+
+	// Databricks SQL Warehouse Configuration
+	HTTPPath    *string `bson:"http_path,omitempty" json:"http_path,omitempty"`
+	AccessToken *string `bson:"access_token,omitempty" json:"-"` // Hide in JSON
+
+	// Google Cloud Firestore Configuration
+	CredentialsJSON *string `bson:"credentials_json,omitempty" json:"-"` // Hide in JSON
 
 	Base `bson:",inline"`
 }
 
+// IsZero lets chats referencing a saved connection omit the embedded connection
+func (c Connection) IsZero() bool {
+	return c.Type == ""
+}
+
+// ReadReplica is a read-only copy of the connection's database, reached with the credentials of the connection
+type ReadReplica struct {
+	Host string  `bson:"host" json:"host"`
+	Port *string `bson:"port,omitempty" json:"port,omitempty"`
+}
+
 type Chat struct {
 	UserID              primitive.ObjectID `bson:"user_id" json:"user_id"`
-	Connection          Connection         `bson:"connection" json:"connection"`
+	ConnectionID        *primitive.ObjectID `bson:"connection_id,omitempty" json:"connection_id,omitempty"` // Saved connection shared with other chats, the connection is not embedded then
+	Connection          Connection         `bson:"connection,omitempty" json:"connection"`
 	SelectedCollections string             `bson:"selected_collections" json:"selected_collections"` // "ALL" or comma-separated table names
 	Settings            ChatSettings       `bson:"settings" json:"settings"`
 	Base                `bson:",inline"`
@@ -47,9 +113,19 @@ func NewChat(userID primitive.ObjectID, connection Connection, settings ChatSett
 	}
 }
 
+// NewChatWithSavedConnection creates a chat using a saved connection, the connection is loaded with the chat
+func NewChatWithSavedConnection(userID primitive.ObjectID, saved *SavedConnection, settings ChatSettings) *Chat {
+	chat := NewChat(userID, saved.Connection, settings)
+	chat.ConnectionID = &saved.ID
+	return chat
+}
+
 func DefaultChatSettings() ChatSettings {
 	return ChatSettings{
-		AutoExecuteQuery: true,  // default is true, Execute query automatically when LLM response is received
-		ShareDataWithAI:  false, // default is false, Don't share data with AI
+		AutoExecuteQuery:        true,  // default is true, Execute query automatically when LLM response is received
+		ShareDataWithAI:         false, // default is false, Don't share data with AI
+		AuditChanges:            false, // default is false, Don't install audit triggers
+		AllowDestructiveQueries: false, // default is false, Block destructive queries
+		VerifyQueries:           false, // default is false, Don't explain the queries before presenting them
 	}
 }
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// QueryComment is a threaded comment attached to a query or to specific rows of its result
+type QueryComment struct {
+	UserID     primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	ChatID     primitive.ObjectID  `bson:"chat_id" json:"chat_id"`
+	MessageID  primitive.ObjectID  `bson:"message_id" json:"message_id"`
+	QueryID    primitive.ObjectID  `bson:"query_id" json:"query_id"`
+	ParentID   *primitive.ObjectID `bson:"parent_id,omitempty" json:"parent_id,omitempty"`     // ID of the comment being replied to, nil for top level comments
+	RowIndexes []int               `bson:"row_indexes,omitempty" json:"row_indexes,omitempty"` // Indexes of the result rows the comment refers to, empty if it refers to the whole query
+	Content    string              `bson:"content" json:"content"`
+	IsEdited   bool                `bson:"is_edited" json:"is_edited"`
+	Base       `bson:",inline"`
+}
+
+func NewQueryComment(userID, chatID, messageID, queryID primitive.ObjectID, parentID *primitive.ObjectID, rowIndexes []int, content string) *QueryComment {
+	return &QueryComment{
+		UserID:     userID,
+		ChatID:     chatID,
+		MessageID:  messageID,
+		QueryID:    queryID,
+		ParentID:   parentID,
+		RowIndexes: rowIndexes,
+		Content:    content,
+		IsEdited:   false,
+		Base:       NewBase(),
+	}
+}
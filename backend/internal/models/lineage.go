@@ -0,0 +1,45 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LineageSource is a column read by a lineage column, Column is "*" when the whole table is read
+type LineageSource struct {
+	Table  string `bson:"table" json:"table"`
+	Column string `bson:"column" json:"column"`
+}
+
+// LineageColumn is a column written by a query & the source columns its value was derived from
+type LineageColumn struct {
+	Column     string          `bson:"column" json:"column"`
+	Expression string          `bson:"expression,omitempty" json:"expression,omitempty"`
+	Sources    []LineageSource `bson:"sources,omitempty" json:"sources,omitempty"`
+}
+
+// LineageEvent records the tables & columns an executed statement wrote, and which inputs they were sourced from
+type LineageEvent struct {
+	ChatID       primitive.ObjectID `bson:"chat_id" json:"chat_id"`
+	MessageID    primitive.ObjectID `bson:"message_id" json:"message_id"`
+	QueryID      primitive.ObjectID `bson:"query_id" json:"query_id"`
+	Operation    string             `bson:"operation" json:"operation"` // INSERT, UPDATE, MERGE, DELETE, CREATE, ALTER, RENAME, DROP, TRUNCATE
+	TargetTable  string             `bson:"target_table" json:"target_table"`
+	Columns      []LineageColumn    `bson:"columns,omitempty" json:"columns,omitempty"`
+	SourceTables []string           `bson:"source_tables,omitempty" json:"source_tables,omitempty"`
+	Statement    string             `bson:"statement" json:"statement"`
+	IsRollback   bool               `bson:"is_rollback" json:"is_rollback"`
+	Base         `bson:",inline"`
+}
+
+func NewLineageEvent(chatID, messageID, queryID primitive.ObjectID, operation, targetTable, statement string, isRollback bool) *LineageEvent {
+	return &LineageEvent{
+		ChatID:      chatID,
+		MessageID:   messageID,
+		QueryID:     queryID,
+		Operation:   operation,
+		TargetTable: targetTable,
+		Statement:   statement,
+		IsRollback:  isRollback,
+		Base:        NewBase(),
+	}
+}
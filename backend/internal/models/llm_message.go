@@ -8,7 +8,29 @@ type LLMMessage struct {
 	MessageID primitive.ObjectID     `bson:"message_id" json:"message_id"` // ID of the original message
 	UserID    primitive.ObjectID     `bson:"user_id" json:"user_id"`
 	Role      string                 `bson:"role" json:"role"`
-	Content   map[string]interface{} `bson:"content" json:"content"`     // Can include user_message, assistant_response (with queries and action_buttons), schema_update
-	IsEdited  bool                   `bson:"is_edited" json:"is_edited"` // if the message content has been edited
+	Content   map[string]interface{} `bson:"content" json:"content"`                 // Can include user_message, assistant_response (with queries and action_buttons), schema_update, live_activity, server_features, relevant_tables, conversation_summary, query_examples
+	IsEdited  bool                   `bson:"is_edited" json:"is_edited"`             // if the message content has been edited
+	Usage     *LLMUsage              `bson:"usage,omitempty" json:"usage,omitempty"` // Tokens of the LLM call generating an assistant message
 	Base      `bson:",inline"`
 }
+
+// LLMUsage is the number of tokens & the estimated cost of an LLM call
+type LLMUsage struct {
+	Provider         string  `bson:"provider" json:"provider"`
+	Model            string  `bson:"model" json:"model"`
+	PromptTokens     int     `bson:"prompt_tokens" json:"prompt_tokens"`
+	CompletionTokens int     `bson:"completion_tokens" json:"completion_tokens"`
+	TotalTokens      int     `bson:"total_tokens" json:"total_tokens"`
+	EstimatedCost    float64 `bson:"estimated_cost" json:"estimated_cost"` // USD
+}
+
+// LLMUsageByModel is the usage of the LLM calls of a model, aggregated over a chat or a user
+type LLMUsageByModel struct {
+	Provider         string  `bson:"provider" json:"provider"`
+	Model            string  `bson:"model" json:"model"`
+	Calls            int64   `bson:"calls" json:"calls"`
+	PromptTokens     int64   `bson:"prompt_tokens" json:"prompt_tokens"`
+	CompletionTokens int64   `bson:"completion_tokens" json:"completion_tokens"`
+	TotalTokens      int64   `bson:"total_tokens" json:"total_tokens"`
+	EstimatedCost    float64 `bson:"estimated_cost" json:"estimated_cost"`
+}
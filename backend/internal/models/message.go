@@ -2,6 +2,7 @@ package models
 
 import (
 	"log"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -27,26 +28,51 @@ type ActionButton struct {
 }
 
 type Query struct {
-	ID                     primitive.ObjectID `bson:"id" json:"id"`
-	Query                  string             `bson:"query" json:"query"`
-	QueryType              *string            `bson:"query_type" json:"query_type"` // SELECT, INSERT, UPDATE, DELETE...
-	Pagination             *Pagination        `bson:"pagination,omitempty" json:"pagination,omitempty"`
-	Tables                 *string            `bson:"tables" json:"tables"` // comma separated table names involved in the query
-	Description            string             `bson:"description" json:"description"`
-	RollbackDependentQuery *string            `bson:"rollback_dependent_query,omitempty" json:"rollback_dependent_query,omitempty"` // ID of the query that this query depends on
-	RollbackQuery          *string            `bson:"rollback_query,omitempty" json:"rollback_query,omitempty"`                     // the query to rollback the query
-	ExecutionTime          *int               `bson:"execution_time" json:"execution_time"`                                         // in milliseconds, same for execution & rollback query
-	ExampleExecutionTime   int                `bson:"example_execution_time" json:"example_execution_time"`                         // in milliseconds
-	CanRollback            bool               `bson:"can_rollback" json:"can_rollback"`
-	IsCritical             bool               `bson:"is_critical" json:"is_critical"`
-	IsExecuted             bool               `bson:"is_executed" json:"is_executed"`       // if the query has been executed
-	IsRolledBack           bool               `bson:"is_rolled_back" json:"is_rolled_back"` // if the query has been rolled back
-	Error                  *QueryError        `bson:"error,omitempty" json:"error,omitempty"`
-	ExampleResult          *string            `bson:"example_result,omitempty" json:"example_result,omitempty"`     // JSON string
-	ExecutionResult        *string            `bson:"execution_result,omitempty" json:"execution_result,omitempty"` // JSON string
-	IsEdited               bool               `bson:"is_edited" json:"is_edited"`                                   // if the query has been edited
-	Metadata               *string            `bson:"metadata,omitempty" json:"metadata,omitempty"`                 // JSON string for database-specific metadata (e.g., ClickHouse engine type)
-	ActionAt               *string            `bson:"action_at,omitempty" json:"action_at,omitempty"`               // The timestamp when the action was taken
+	ID                     primitive.ObjectID     `bson:"id" json:"id"`
+	Query                  string                 `bson:"query" json:"query"`
+	QueryType              *string                `bson:"query_type" json:"query_type"` // SELECT, INSERT, UPDATE, DELETE...
+	Pagination             *Pagination            `bson:"pagination,omitempty" json:"pagination,omitempty"`
+	Params                 map[string]interface{} `bson:"params,omitempty" json:"params,omitempty"` // values bound to the named parameters (:name, {{name}}) at the last execution
+	Tables                 *string                `bson:"tables" json:"tables"`                     // comma separated table names involved in the query
+	Description            string                 `bson:"description" json:"description"`
+	RollbackDependentQuery *string                `bson:"rollback_dependent_query,omitempty" json:"rollback_dependent_query,omitempty"` // ID of the query that this query depends on
+	RollbackQuery          *string                `bson:"rollback_query,omitempty" json:"rollback_query,omitempty"`                     // the query to rollback the query
+	PreImage               *string                `bson:"pre_image,omitempty" json:"pre_image,omitempty"`                               // JSON string of the rows the query changed, read before its execution
+	ExecutionTime          *int                   `bson:"execution_time" json:"execution_time"`                                         // in milliseconds, same for execution & rollback query
+	ExampleExecutionTime   int                    `bson:"example_execution_time" json:"example_execution_time"`                         // in milliseconds
+	CanRollback            bool                   `bson:"can_rollback" json:"can_rollback"`
+	IsCritical             bool                   `bson:"is_critical" json:"is_critical"`
+	IsBlocked              bool                   `bson:"is_blocked,omitempty" json:"is_blocked,omitempty"`                       // if the guardrail blocked the destructive query, it can't be executed
+	GuardrailReason        *string                `bson:"guardrail_reason,omitempty" json:"guardrail_reason,omitempty"`           // why the guardrail flagged the query as destructive
+	EstimatedRows          *int64                 `bson:"estimated_rows,omitempty" json:"estimated_rows,omitempty"`               // rows the query was estimated to read before its auto-execution
+	RequiresConfirmation   bool                   `bson:"requires_confirmation,omitempty" json:"requires_confirmation,omitempty"` // if the estimate was above the auto-execution limit, the user executes it
+	AffectedRowsPreview    *AffectedRowsPreview   `bson:"affected_rows_preview,omitempty" json:"affected_rows_preview,omitempty"` // rows an UPDATE or a DELETE would change, read before it is executed
+	IsExecuted             bool                   `bson:"is_executed" json:"is_executed"`                                         // if the query has been executed
+	IsRolledBack           bool                   `bson:"is_rolled_back" json:"is_rolled_back"`                                   // if the query has been rolled back
+	Error                  *QueryError            `bson:"error,omitempty" json:"error,omitempty"`
+	ExampleResult          *string                `bson:"example_result,omitempty" json:"example_result,omitempty"`     // JSON string
+	ExecutionResult        *string                `bson:"execution_result,omitempty" json:"execution_result,omitempty"` // JSON string
+	ResultColumns          []ResultColumn         `bson:"result_columns,omitempty" json:"result_columns,omitempty"`     // columns of the rows of the execution result in their order
+	ResultFile             *ResultFile            `bson:"result_file,omitempty" json:"result_file,omitempty"`           // file the whole result was written to when too large, the execution result is its preview
+	IsEdited               bool                   `bson:"is_edited" json:"is_edited"`                                   // if the query has been edited
+	Metadata               *string                `bson:"metadata,omitempty" json:"metadata,omitempty"`                 // JSON string for database-specific metadata (e.g., ClickHouse engine type)
+	ActionAt               *string                `bson:"action_at,omitempty" json:"action_at,omitempty"`               // The timestamp when the action was taken
+}
+
+// ResultColumn is a column of the rows of an execution result, Nullable is nil when the driver didn't tell
+type ResultColumn struct {
+	Name         string `bson:"name" json:"name"`
+	DatabaseType string `bson:"database_type" json:"database_type"`
+	Nullable     *bool  `bson:"nullable,omitempty" json:"nullable,omitempty"`
+}
+
+// ResultFile is a file of the result store holding a whole result, downloaded with signed links
+type ResultFile struct {
+	Key       string    `bson:"key" json:"key"`
+	Format    string    `bson:"format" json:"format"`
+	Rows      int64     `bson:"rows" json:"rows"`
+	SizeBytes int64     `bson:"size_bytes" json:"size_bytes"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
 }
 
 type QueryError struct {
@@ -55,10 +81,21 @@ type QueryError struct {
 	Details string `bson:"details" json:"details"`
 }
 
+// AffectedRowsPreview is the count & a sample of the rows a write would change, read with a SELECT of the same predicate
+type AffectedRowsPreview struct {
+	Count  int64   `bson:"count" json:"count"`
+	Sample *string `bson:"sample,omitempty" json:"sample,omitempty"` // JSON string
+}
+
 type Pagination struct {
 	TotalRecordsCount *int    `bson:"total_records_count" json:"total_records_count"`
 	PaginatedQuery    *string `bson:"paginated_query" json:"paginated_query"`
 	CountQuery        *string `bson:"count_query" json:"count_query"`
+	KeysetColumn      *string `bson:"keyset_column,omitempty" json:"keyset_column,omitempty"` // unique column the paginated query is ordered by, the pages are read by key after it
+	KeysetDescending  bool    `bson:"keyset_descending,omitempty" json:"keyset_descending,omitempty"`
+	KeysetUnique      *bool   `bson:"keyset_unique,omitempty" json:"keyset_unique,omitempty"` // the keyset column was checked unique & never null, nil until the first page read by key
+	PageSize          int     `bson:"page_size,omitempty" json:"page_size,omitempty"`         // rows the paginated query reads per page, the LIMIT it was generated with when 0
+	MaxRows           int     `bson:"max_rows,omitempty" json:"max_rows,omitempty"`           // rows of the result the pages can read at most, none when 0
 }
 
 func NewMessage(userID, chatID primitive.ObjectID, msgType, content string, queries *[]Query, userMessageId *primitive.ObjectID) *Message {
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Notification types
+const (
+	NotificationTypeQueryFinished     = "query_finished"     // A query execution succeeded or failed
+	NotificationTypeApprovalRequested = "approval_requested" // A runbook run waits for the user to confirm a step
+	NotificationTypeSchemaChanged     = "schema_changed"     // The schema of a chat's database changed
+	NotificationTypeReportReady       = "report_ready"       // A runbook run completed & its report can be opened
+)
+
+// Notification is an event of the in-app notification center of a user
+type Notification struct {
+	UserID  primitive.ObjectID     `bson:"user_id" json:"user_id"`
+	ChatID  *primitive.ObjectID    `bson:"chat_id,omitempty" json:"chat_id,omitempty"`
+	Type    string                 `bson:"type" json:"type"`
+	Title   string                 `bson:"title" json:"title"`
+	Message string                 `bson:"message" json:"message"`
+	Data    map[string]interface{} `bson:"data,omitempty" json:"data,omitempty"` // IDs needed to open the subject of the notification, e.g. message_id & query_id
+	ReadAt  *time.Time             `bson:"read_at,omitempty" json:"read_at,omitempty"`
+	Base    `bson:",inline"`
+}
+
+func NewNotification(userID primitive.ObjectID, chatID *primitive.ObjectID, notificationType, title, message string, data map[string]interface{}) *Notification {
+	return &Notification{
+		UserID:  userID,
+		ChatID:  chatID,
+		Type:    notificationType,
+		Title:   title,
+		Message: message,
+		Data:    data,
+		Base:    NewBase(),
+	}
+}
+
+// IsRead checks if the user has read the notification
+func (n *Notification) IsRead() bool {
+	return n.ReadAt != nil
+}
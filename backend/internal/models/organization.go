@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Organization groups users sharing the same LLM provider credentials
+type Organization struct {
+	Name               string                    `bson:"name" json:"name"`
+	MemberIDs          []primitive.ObjectID      `bson:"member_ids" json:"member_ids"`
+	LLMProviders       []OrganizationLLMProvider `bson:"llm_providers" json:"llm_providers"`
+	DefaultLLMProvider string                    `bson:"default_llm_provider,omitempty" json:"default_llm_provider,omitempty"` // Provider used for the requests of the members, the first configured one when empty
+	Base               `bson:",inline"`
+}
+
+// OrganizationLLMProvider holds the key & the models an organization may use with a provider
+type OrganizationLLMProvider struct {
+	Provider      string    `bson:"provider" json:"provider"`
+	APIKey        string    `bson:"api_key" json:"-"` // Encrypted, never exposed
+	AllowedModels []string  `bson:"allowed_models" json:"allowed_models"`
+	DefaultModel  string    `bson:"default_model" json:"default_model"`
+	UpdatedAt     time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+func NewOrganization(name string, memberIDs []primitive.ObjectID) *Organization {
+	return &Organization{
+		Name:         name,
+		MemberIDs:    memberIDs,
+		LLMProviders: []OrganizationLLMProvider{},
+		Base:         NewBase(),
+	}
+}
+
+// GetLLMProvider returns the settings of a provider, nil if it isn't configured
+func (o *Organization) GetLLMProvider(provider string) *OrganizationLLMProvider {
+	for i := range o.LLMProviders {
+		if o.LLMProviders[i].Provider == provider {
+			return &o.LLMProviders[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// PromptTemplate is a version of the system prompt of a database type, replacing the built-in prompt while it's active.
+// Every edit creates a new version, at most one version of a database type & provider is active.
+type PromptTemplate struct {
+	DBType      string             `bson:"db_type" json:"db_type"`
+	Provider    string             `bson:"provider" json:"provider"` // Empty for every provider, a provider's own template has precedence
+	Version     int                `bson:"version" json:"version"`
+	Content     string             `bson:"content" json:"content"`
+	Description string             `bson:"description,omitempty" json:"description,omitempty"` // What changed in this version
+	IsActive    bool               `bson:"is_active" json:"is_active"`
+	CreatedBy   primitive.ObjectID `bson:"created_by" json:"created_by"`
+	Base        `bson:",inline"`
+}
+
+func NewPromptTemplate(dbType, provider string, version int, content, description string, createdBy primitive.ObjectID) *PromptTemplate {
+	return &PromptTemplate{
+		DBType:      dbType,
+		Provider:    provider,
+		Version:     version,
+		Content:     content,
+		Description: description,
+		CreatedBy:   createdBy,
+		Base:        NewBase(),
+	}
+}
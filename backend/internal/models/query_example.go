@@ -0,0 +1,28 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// QueryExample is a question & the query answering it, the most relevant examples are added to the LLM prompt.
+// Examples of a chat using a saved connection are shared by the chats of the connection.
+type QueryExample struct {
+	UserID       primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	ChatID       primitive.ObjectID  `bson:"chat_id" json:"chat_id"`
+	ConnectionID *primitive.ObjectID `bson:"connection_id,omitempty" json:"connection_id,omitempty"`
+	Question     string              `bson:"question" json:"question"`
+	Query        string              `bson:"query" json:"query"`
+	Explanation  *string             `bson:"explanation,omitempty" json:"explanation,omitempty"`
+	Base         `bson:",inline"`
+}
+
+func NewQueryExample(userID, chatID primitive.ObjectID, connectionID *primitive.ObjectID, question, query string) *QueryExample {
+	return &QueryExample{
+		UserID:       userID,
+		ChatID:       chatID,
+		ConnectionID: connectionID,
+		Question:     question,
+		Query:        query,
+		Base:         NewBase(),
+	}
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Query history statuses
+const (
+	QueryHistoryStatusSucceeded = "succeeded"
+	QueryHistoryStatusFailed    = "failed"
+)
+
+// QueryHistoryEntry is an execution of a query of a chat, kept after the message is edited or the chat deleted
+type QueryHistoryEntry struct {
+	UserID        primitive.ObjectID  `bson:"user_id" json:"user_id"` // User who executed the query
+	ChatID        primitive.ObjectID  `bson:"chat_id" json:"chat_id"`
+	ConnectionID  *primitive.ObjectID `bson:"connection_id,omitempty" json:"connection_id,omitempty"` // Saved connection of the chat, if any
+	DatabaseType  string              `bson:"database_type" json:"database_type"`
+	Database      string              `bson:"database" json:"database"`
+	MessageID     primitive.ObjectID  `bson:"message_id" json:"message_id"`
+	QueryID       primitive.ObjectID  `bson:"query_id" json:"query_id"`
+	Query         string              `bson:"query" json:"query"` // Query of the message, its rollback query for a rollback
+	QueryType     *string             `bson:"query_type,omitempty" json:"query_type,omitempty"`
+	IsRollback    bool                `bson:"is_rollback" json:"is_rollback"`
+	Status        string              `bson:"status" json:"status"`
+	ExecutionTime int                 `bson:"execution_time" json:"execution_time"` // in milliseconds
+	Rows          *int                `bson:"rows,omitempty" json:"rows,omitempty"` // Rows returned, nil when the query failed
+	Error         *QueryError         `bson:"error,omitempty" json:"error,omitempty"`
+	Result        *string             `bson:"result,omitempty" json:"result,omitempty"`             // JSON array of the first rows returned, compared by the diffs of executions
+	IsTruncated   bool                `bson:"is_truncated,omitempty" json:"is_truncated,omitempty"` // Result holds only some of the rows returned
+	ExecutedAt    time.Time           `bson:"executed_at" json:"executed_at"`
+	Base          `bson:",inline"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Query job statuses
+const (
+	QueryJobStatusQueued    = "queued"
+	QueryJobStatusRunning   = "running"
+	QueryJobStatusCompleted = "completed"
+	QueryJobStatusFailed    = "failed"
+	QueryJobStatusCancelled = "cancelled"
+)
+
+// QueryJob executes a query of a message in the background, e.g. a long-running analytical query exceeding the timeout of the requests
+type QueryJob struct {
+	UserID            primitive.ObjectID `bson:"user_id" json:"user_id"`
+	ChatID            primitive.ObjectID `bson:"chat_id" json:"chat_id"`
+	MessageID         primitive.ObjectID `bson:"message_id" json:"message_id"`
+	QueryID           primitive.ObjectID `bson:"query_id" json:"query_id"`
+	StreamID          string             `bson:"stream_id" json:"stream_id"` // Stream the completion event is sent to
+	UsePrimary        bool               `bson:"use_primary" json:"use_primary"`
+	TimeoutMinutes    int                `bson:"timeout_minutes" json:"timeout_minutes"`
+	Status            string             `bson:"status" json:"status"`
+	ExecutionTime     *int               `bson:"execution_time,omitempty" json:"execution_time,omitempty"` // in milliseconds
+	ExecutionResult   *string            `bson:"execution_result,omitempty" json:"execution_result,omitempty"`
+	TotalRecordsCount *int               `bson:"total_records_count,omitempty" json:"total_records_count,omitempty"`
+	Error             *QueryError        `bson:"error,omitempty" json:"error,omitempty"`
+	StartedAt         *time.Time         `bson:"started_at,omitempty" json:"started_at,omitempty"`
+	CompletedAt       *time.Time         `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	Base              `bson:",inline"`
+}
+
+func NewQueryJob(userID, chatID, messageID, queryID primitive.ObjectID, streamID string, usePrimary bool, timeoutMinutes int) *QueryJob {
+	return &QueryJob{
+		UserID:         userID,
+		ChatID:         chatID,
+		MessageID:      messageID,
+		QueryID:        queryID,
+		StreamID:       streamID,
+		UsePrimary:     usePrimary,
+		TimeoutMinutes: timeoutMinutes,
+		Status:         QueryJobStatusQueued,
+		Base:           NewBase(),
+	}
+}
+
+// IsFinished checks if the job reached a terminal status
+func (j *QueryJob) IsFinished() bool {
+	return j.Status != QueryJobStatusQueued && j.Status != QueryJobStatusRunning
+}
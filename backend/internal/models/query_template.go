@@ -0,0 +1,31 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// QueryTemplate is a saved query with named parameters (:name or {{name}}), each execution binds their values & adds a message
+// with the result to the chat
+type QueryTemplate struct {
+	UserID      primitive.ObjectID     `bson:"user_id" json:"user_id"`
+	ChatID      primitive.ObjectID     `bson:"chat_id" json:"chat_id"`
+	Name        string                 `bson:"name" json:"name"`
+	Description *string                `bson:"description,omitempty" json:"description,omitempty"`
+	Query       string                 `bson:"query" json:"query"`
+	QueryType   *string                `bson:"query_type,omitempty" json:"query_type,omitempty"`
+	Tables      *string                `bson:"tables,omitempty" json:"tables,omitempty"`
+	Parameters  []string               `bson:"parameters" json:"parameters"`                 // Names of the parameters in their order in the query
+	Defaults    map[string]interface{} `bson:"defaults,omitempty" json:"defaults,omitempty"` // Values of the parameters an execution doesn't send
+	Base        `bson:",inline"`
+}
+
+func NewQueryTemplate(userID, chatID primitive.ObjectID, name, query string, parameters []string) *QueryTemplate {
+	return &QueryTemplate{
+		UserID:     userID,
+		ChatID:     chatID,
+		Name:       name,
+		Query:      query,
+		Parameters: parameters,
+		Base:       NewBase(),
+	}
+}
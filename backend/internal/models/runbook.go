@@ -0,0 +1,120 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Runbook step types
+const (
+	RunbookStepTypeQuery        = "query"        // Executes a query against the chat's database
+	RunbookStepTypePause        = "pause"        // Waits for PauseSeconds before continuing
+	RunbookStepTypeConfirmation = "confirmation" // Stops until the user confirms the step
+	RunbookStepTypeHealthCheck  = "health_check" // Executes a query & fails the run if the result is not the expected one
+)
+
+// Runbook run statuses
+const (
+	RunbookRunStatusRunning             = "running"
+	RunbookRunStatusWaitingConfirmation = "waiting_confirmation"
+	RunbookRunStatusFailed              = "failed"
+	RunbookRunStatusCompleted           = "completed"
+	RunbookRunStatusCancelled           = "cancelled"
+)
+
+// Runbook step result statuses
+const (
+	RunbookStepStatusPending   = "pending"
+	RunbookStepStatusRunning   = "running"
+	RunbookStepStatusWaiting   = "waiting"
+	RunbookStepStatusSucceeded = "succeeded"
+	RunbookStepStatusFailed    = "failed"
+	RunbookStepStatusSkipped   = "skipped"
+)
+
+// Runbook is an ordered list of steps for a repeatable maintenance procedure on a chat's database
+type Runbook struct {
+	UserID      primitive.ObjectID `bson:"user_id" json:"user_id"`
+	ChatID      primitive.ObjectID `bson:"chat_id" json:"chat_id"`
+	Name        string             `bson:"name" json:"name"`
+	Description *string            `bson:"description,omitempty" json:"description,omitempty"`
+	Steps       []RunbookStep      `bson:"steps" json:"steps"`
+	Base        `bson:",inline"`
+}
+
+type RunbookStep struct {
+	ID              primitive.ObjectID `bson:"id" json:"id"`
+	Name            string             `bson:"name" json:"name"`
+	Type            string             `bson:"type" json:"type"`
+	Query           *string            `bson:"query,omitempty" json:"query,omitempty"`                 // query & health_check steps
+	QueryType       *string            `bson:"query_type,omitempty" json:"query_type,omitempty"`       // SELECT, INSERT, UPDATE, DELETE...
+	PauseSeconds    *int               `bson:"pause_seconds,omitempty" json:"pause_seconds,omitempty"` // pause steps
+	Message         *string            `bson:"message,omitempty" json:"message,omitempty"`             // Instructions shown to the user, e.g. for confirmation steps
+	ExpectEmpty     bool               `bson:"expect_empty" json:"expect_empty"`                       // health_check passes when the query returns no rows instead of at least one row
+	ContinueOnError bool               `bson:"continue_on_error" json:"continue_on_error"`             // A failure of this step doesn't stop the run
+}
+
+// RunbookRun tracks the execution state of a runbook, steps are copied so editing the runbook doesn't affect running executions
+type RunbookRun struct {
+	UserID      primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	ChatID      primitive.ObjectID  `bson:"chat_id" json:"chat_id"`
+	RunbookID   primitive.ObjectID  `bson:"runbook_id" json:"runbook_id"`
+	RunbookName string              `bson:"runbook_name" json:"runbook_name"`
+	Steps       []RunbookStep       `bson:"steps" json:"steps"`
+	StepResults []RunbookStepResult `bson:"step_results" json:"step_results"`
+	Status      string              `bson:"status" json:"status"`
+	CurrentStep int                 `bson:"current_step" json:"current_step"` // Index of the step being executed or waiting
+	CompletedAt *time.Time          `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	Base        `bson:",inline"`
+}
+
+type RunbookStepResult struct {
+	StepID        primitive.ObjectID `bson:"step_id" json:"step_id"`
+	Status        string             `bson:"status" json:"status"`
+	Attempts      int                `bson:"attempts" json:"attempts"`
+	Result        *string            `bson:"result,omitempty" json:"result,omitempty"` // JSON string of the query result
+	Error         *QueryError        `bson:"error,omitempty" json:"error,omitempty"`
+	Note          *string            `bson:"note,omitempty" json:"note,omitempty"`                     // Confirmation note or skip reason
+	ExecutionTime *int               `bson:"execution_time,omitempty" json:"execution_time,omitempty"` // in milliseconds
+	StartedAt     *time.Time         `bson:"started_at,omitempty" json:"started_at,omitempty"`
+	CompletedAt   *time.Time         `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+}
+
+func NewRunbook(userID, chatID primitive.ObjectID, name string, description *string, steps []RunbookStep) *Runbook {
+	return &Runbook{
+		UserID:      userID,
+		ChatID:      chatID,
+		Name:        name,
+		Description: description,
+		Steps:       steps,
+		Base:        NewBase(),
+	}
+}
+
+func NewRunbookRun(runbook *Runbook) *RunbookRun {
+	stepResults := make([]RunbookStepResult, len(runbook.Steps))
+	for i, step := range runbook.Steps {
+		stepResults[i] = RunbookStepResult{
+			StepID: step.ID,
+			Status: RunbookStepStatusPending,
+		}
+	}
+
+	return &RunbookRun{
+		UserID:      runbook.UserID,
+		ChatID:      runbook.ChatID,
+		RunbookID:   runbook.ID,
+		RunbookName: runbook.Name,
+		Steps:       runbook.Steps,
+		StepResults: stepResults,
+		Status:      RunbookRunStatusRunning,
+		CurrentStep: 0,
+		Base:        NewBase(),
+	}
+}
+
+// IsFinished checks if the run reached a terminal status
+func (r *RunbookRun) IsFinished() bool {
+	return r.Status == RunbookRunStatusCompleted || r.Status == RunbookRunStatusCancelled
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SavedConnection is a connection created once & used by several chats, its credentials are updated in one place.
+// Chats reference it with their ConnectionID instead of embedding an encrypted copy.
+type SavedConnection struct {
+	UserID     primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Name       string             `bson:"name" json:"name"`
+	Connection Connection         `bson:"connection" json:"connection"` // Encrypted like the connections embedded in chats
+	Base       `bson:",inline"`
+}
+
+func NewSavedConnection(userID primitive.ObjectID, name string, connection Connection) *SavedConnection {
+	return &SavedConnection{
+		UserID:     userID,
+		Name:       name,
+		Connection: connection,
+		Base:       NewBase(),
+	}
+}
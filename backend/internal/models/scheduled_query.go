@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Scheduled query run statuses
+const (
+	ScheduledQueryStatusSucceeded = "succeeded"
+	ScheduledQueryStatusFailed    = "failed"
+)
+
+// ScheduledQuery executes a saved query on a cron expression, each run adds a message with the result to the chat
+type ScheduledQuery struct {
+	UserID      primitive.ObjectID     `bson:"user_id" json:"user_id"`
+	ChatID      primitive.ObjectID     `bson:"chat_id" json:"chat_id"`
+	BookmarkID  *primitive.ObjectID    `bson:"bookmark_id,omitempty" json:"bookmark_id,omitempty"` // Bookmark the query was saved from, if any
+	Name        string                 `bson:"name" json:"name"`
+	Query       string                 `bson:"query" json:"query"`
+	QueryType   *string                `bson:"query_type,omitempty" json:"query_type,omitempty"`
+	Tables      *string                `bson:"tables,omitempty" json:"tables,omitempty"`
+	Pagination  *Pagination            `bson:"pagination,omitempty" json:"pagination,omitempty"` // Count & paginated queries of the message query, if any
+	Params      map[string]interface{} `bson:"params,omitempty" json:"params,omitempty"`         // Values of the named parameters of the message query, if any
+	Cron        string                 `bson:"cron" json:"cron"`                                 // Standard 5 fields expression or a descriptor, e.g. @daily
+	Timezone    string                 `bson:"timezone" json:"timezone"`                         // IANA name the expression is evaluated in
+	UsePrimary  bool                   `bson:"use_primary" json:"use_primary"`
+	WebhookURL  *string                `bson:"webhook_url,omitempty" json:"-"`               // Hide in JSON, may contain a token. Receives the outcome of each run
+	EmailTo     []string               `bson:"email_to,omitempty" json:"email_to,omitempty"` // Addresses the outcome of each run is emailed to
+	Enabled     bool                   `bson:"enabled" json:"enabled"`
+	NextRunAt   *time.Time             `bson:"next_run_at,omitempty" json:"next_run_at,omitempty"` // nil while disabled
+	LastRunAt   *time.Time             `bson:"last_run_at,omitempty" json:"last_run_at,omitempty"`
+	LastStatus  *string                `bson:"last_status,omitempty" json:"last_status,omitempty"`
+	LastError   *QueryError            `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	LastMessage *primitive.ObjectID    `bson:"last_message_id,omitempty" json:"last_message_id,omitempty"` // Message of the chat holding the last result
+	RunCount    int                    `bson:"run_count" json:"run_count"`
+	Base        `bson:",inline"`
+}
+
+func NewScheduledQuery(userID, chatID primitive.ObjectID, name, query string, cron, timezone string) *ScheduledQuery {
+	return &ScheduledQuery{
+		UserID:   userID,
+		ChatID:   chatID,
+		Name:     name,
+		Query:    query,
+		Cron:     cron,
+		Timezone: timezone,
+		Enabled:  true,
+		Base:     NewBase(),
+	}
+}
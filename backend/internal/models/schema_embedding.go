@@ -0,0 +1,15 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SchemaEmbedding is the vector of the description of a table (collection for MongoDB), used to find the tables relevant to a request
+type SchemaEmbedding struct {
+	ChatID   primitive.ObjectID `bson:"chat_id" json:"chat_id"`
+	Table    string             `bson:"table" json:"table"`
+	Checksum string             `bson:"checksum" json:"checksum"` // Checksum of the embedded description, the table is embedded again when it changes
+	Model    string             `bson:"model" json:"model"`
+	Vector   []float32          `bson:"vector" json:"-"`
+	Base     `bson:",inline"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SchemaTableChange is a table of both schemas whose columns, indexes or foreign keys differ
+type SchemaTableChange struct {
+	Table           string   `bson:"table" json:"table"`
+	AddedColumns    []string `bson:"added_columns,omitempty" json:"added_columns,omitempty"`
+	RemovedColumns  []string `bson:"removed_columns,omitempty" json:"removed_columns,omitempty"`
+	ModifiedColumns []string `bson:"modified_columns,omitempty" json:"modified_columns,omitempty"`
+	AddedIndexes    []string `bson:"added_indexes,omitempty" json:"added_indexes,omitempty"`
+	RemovedIndexes  []string `bson:"removed_indexes,omitempty" json:"removed_indexes,omitempty"`
+	AddedFKs        []string `bson:"added_fks,omitempty" json:"added_fks,omitempty"`
+	RemovedFKs      []string `bson:"removed_fks,omitempty" json:"removed_fks,omitempty"`
+}
+
+// SchemaChanges is what changed from a schema to another, the tables are sorted by name
+type SchemaChanges struct {
+	AddedTables    []string            `bson:"added_tables" json:"added_tables"`
+	RemovedTables  []string            `bson:"removed_tables" json:"removed_tables"`
+	ModifiedTables []SchemaTableChange `bson:"modified_tables" json:"modified_tables"`
+}
+
+// SchemaVersion is a snapshot of the schema of a chat's database, recorded each time the schema changes
+type SchemaVersion struct {
+	UserID       primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	ChatID       primitive.ObjectID  `bson:"chat_id" json:"chat_id"`
+	ConnectionID *primitive.ObjectID `bson:"connection_id,omitempty" json:"connection_id,omitempty"` // Saved connection of the chat, if any
+	DatabaseType string              `bson:"database_type" json:"database_type"`
+	Database     string              `bson:"database" json:"database"`
+	Checksum     string              `bson:"checksum" json:"checksum"`
+	Tables       int                 `bson:"tables" json:"tables"`
+	Changes      *SchemaChanges      `bson:"changes,omitempty" json:"changes,omitempty"` // Changes from the previous version, nil for the first one
+	Schema       string              `bson:"schema,omitempty" json:"-"`                  // JSON of the full schema, compared by the diffs of versions
+	CapturedAt   time.Time           `bson:"captured_at" json:"captured_at"`
+	Base         `bson:",inline"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TableUsage counts the executed queries of a chat that read or wrote a table (collection for MongoDB)
+type TableUsage struct {
+	ChatID     primitive.ObjectID `bson:"chat_id" json:"chat_id"`
+	Table      string             `bson:"table" json:"table"`
+	Count      int64              `bson:"count" json:"count"`
+	LastUsedAt time.Time          `bson:"last_used_at" json:"last_used_at"`
+	Base       `bson:",inline"`
+}
@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"context"
+	"neobase-ai/internal/models"
+	"neobase-ai/pkg/mongodb"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AnonymizationJobRepository interface {
+	Create(job *models.AnonymizationJob) error
+	Update(job *models.AnonymizationJob) error
+	FindByID(id primitive.ObjectID) (*models.AnonymizationJob, error)
+	FindByUserID(userID primitive.ObjectID, page, pageSize int) ([]*models.AnonymizationJob, int64, error)
+}
+
+type anonymizationJobRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAnonymizationJobRepository(mongoClient *mongodb.MongoDBClient) AnonymizationJobRepository {
+	return &anonymizationJobRepository{
+		collection: mongoClient.GetCollectionByName("anonymization_jobs"),
+	}
+}
+
+func (r *anonymizationJobRepository) Create(job *models.AnonymizationJob) error {
+	_, err := r.collection.InsertOne(context.Background(), job)
+	return err
+}
+
+func (r *anonymizationJobRepository) Update(job *models.AnonymizationJob) error {
+	job.UpdatedAt = time.Now()
+	filter := bson.M{"_id": job.ID}
+	update := bson.M{"$set": job}
+	_, err := r.collection.UpdateOne(context.Background(), filter, update)
+	return err
+}
+
+func (r *anonymizationJobRepository) FindByID(id primitive.ObjectID) (*models.AnonymizationJob, error) {
+	var job models.AnonymizationJob
+	err := r.collection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &job, err
+}
+
+func (r *anonymizationJobRepository) FindByUserID(userID primitive.ObjectID, page, pageSize int) ([]*models.AnonymizationJob, int64, error) {
+	var jobs []*models.AnonymizationJob
+	filter := bson.M{"user_id": userID}
+
+	// Get total count
+	total, err := r.collection.CountDocuments(context.Background(), filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Setup pagination
+	skip := int64((page - 1) * pageSize)
+	opts := options.Find().
+		SetSkip(skip).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(context.Background())
+
+	err = cursor.All(context.Background(), &jobs)
+	return jobs, total, err
+}
@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"context"
+	"neobase-ai/internal/models"
+	"neobase-ai/pkg/mongodb"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type BookmarkRepository interface {
+	Create(bookmark *models.QueryBookmark) error
+	Delete(id primitive.ObjectID) error
+	FindByID(id primitive.ObjectID) (*models.QueryBookmark, error)
+	FindByShareToken(token string) (*models.QueryBookmark, error)
+	FindByChatID(chatID primitive.ObjectID, page, pageSize int) ([]*models.QueryBookmark, int64, error)
+	CountByQueryID(queryID primitive.ObjectID) (int64, error)
+}
+
+type bookmarkRepository struct {
+	bookmarkCollection *mongo.Collection
+}
+
+func NewBookmarkRepository(mongoClient *mongodb.MongoDBClient) BookmarkRepository {
+	return &bookmarkRepository{
+		bookmarkCollection: mongoClient.GetCollectionByName("query_bookmarks"),
+	}
+}
+
+func (r *bookmarkRepository) Create(bookmark *models.QueryBookmark) error {
+	_, err := r.bookmarkCollection.InsertOne(context.Background(), bookmark)
+	return err
+}
+
+func (r *bookmarkRepository) Delete(id primitive.ObjectID) error {
+	filter := bson.M{"_id": id}
+	_, err := r.bookmarkCollection.DeleteOne(context.Background(), filter)
+	return err
+}
+
+func (r *bookmarkRepository) FindByID(id primitive.ObjectID) (*models.QueryBookmark, error) {
+	var bookmark models.QueryBookmark
+	err := r.bookmarkCollection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&bookmark)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &bookmark, err
+}
+
+func (r *bookmarkRepository) FindByShareToken(token string) (*models.QueryBookmark, error) {
+	var bookmark models.QueryBookmark
+	err := r.bookmarkCollection.FindOne(context.Background(), bson.M{"share_token": token}).Decode(&bookmark)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &bookmark, err
+}
+
+func (r *bookmarkRepository) FindByChatID(chatID primitive.ObjectID, page, pageSize int) ([]*models.QueryBookmark, int64, error) {
+	var bookmarks []*models.QueryBookmark
+	filter := bson.M{"chat_id": chatID}
+
+	// Get total count
+	total, err := r.bookmarkCollection.CountDocuments(context.Background(), filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Setup pagination
+	skip := int64((page - 1) * pageSize)
+	opts := options.Find().
+		SetSkip(skip).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.bookmarkCollection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(context.Background())
+
+	err = cursor.All(context.Background(), &bookmarks)
+	return bookmarks, total, err
+}
+
+func (r *bookmarkRepository) CountByQueryID(queryID primitive.ObjectID) (int64, error) {
+	return r.bookmarkCollection.CountDocuments(context.Background(), bson.M{"query_id": queryID})
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"neobase-ai/internal/models"
 	"neobase-ai/pkg/mongodb"
@@ -19,36 +20,54 @@ type ChatRepository interface {
 	Delete(id primitive.ObjectID) error
 	FindByID(id primitive.ObjectID) (*models.Chat, error)
 	FindByUserID(userID primitive.ObjectID, page, pageSize int) ([]*models.Chat, int64, error)
+	FindByConnectionID(connectionID primitive.ObjectID) ([]*models.Chat, error)
+	CountByConnectionID(connectionID primitive.ObjectID) (int64, error)
+	DetachConnection(connectionID primitive.ObjectID, connection models.Connection) (int64, error)
 	CreateMessage(message *models.Message) error
 	UpdateMessage(id primitive.ObjectID, message *models.Message) error
 	DeleteMessages(chatID primitive.ObjectID) error
 	FindMessagesByChat(chatID primitive.ObjectID, page, pageSize int) ([]*models.Message, int64, error)
 	FindLatestMessageByChat(chatID primitive.ObjectID, page, pageSize int) ([]*models.Message, int64, error)
 	FindMessageByID(id primitive.ObjectID) (*models.Message, error)
+	FindMessageByQueryID(queryID primitive.ObjectID) (*models.Message, error)
 	FindNextMessageByID(id primitive.ObjectID) (*models.Message, error)
+	// SetQueryResultFile saves the result file of a query while it still holds the execution of actionAt, false when it doesn't
+	SetQueryResultFile(messageID, queryID primitive.ObjectID, actionAt string, file *models.ResultFile) (bool, error)
+	// SetQueryKeysetUnique saves whether the keyset column of a paginated query was checked unique & never null
+	SetQueryKeysetUnique(messageID, queryID primitive.ObjectID, unique bool) error
+	// ForEachQueryMessage calls fn with the assistant messages having queries created in the period, oldest first. Nil bounds are open.
+	ForEachQueryMessage(ctx context.Context, since, until *time.Time, fn func(message *models.Message) error) error
 }
 
+const savedConnectionCollectionName = "connections"
+
 type chatRepository struct {
-	chatCollection    *mongo.Collection
-	messageCollection *mongo.Collection
+	chatCollection       *mongo.Collection
+	messageCollection    *mongo.Collection
+	connectionCollection *mongo.Collection
 }
 
 func NewChatRepository(mongoClient *mongodb.MongoDBClient) ChatRepository {
 	return &chatRepository{
-		chatCollection:    mongoClient.GetCollectionByName("chats"),
-		messageCollection: mongoClient.GetCollectionByName("messages"),
+		chatCollection:       mongoClient.GetCollectionByName("chats"),
+		messageCollection:    mongoClient.GetCollectionByName("messages"),
+		connectionCollection: mongoClient.GetCollectionByName(savedConnectionCollectionName),
 	}
 }
 
 func (r *chatRepository) Create(chat *models.Chat) error {
-	_, err := r.chatCollection.InsertOne(context.Background(), chat)
+	_, err := r.chatCollection.InsertOne(context.Background(), storedChat(chat))
 	return err
 }
 
 func (r *chatRepository) Update(id primitive.ObjectID, chat *models.Chat) error {
 	chat.UpdatedAt = time.Now()
 	filter := bson.M{"_id": id}
-	update := bson.M{"$set": chat}
+	update := bson.M{"$set": storedChat(chat)}
+	if chat.ConnectionID != nil {
+		// Drop the connection embedded before the chat used a saved connection
+		update["$unset"] = bson.M{"connection": ""}
+	}
 	_, err := r.chatCollection.UpdateOne(context.Background(), filter, update)
 	return err
 }
@@ -65,7 +84,13 @@ func (r *chatRepository) FindByID(id primitive.ObjectID) (*models.Chat, error) {
 	if err == mongo.ErrNoDocuments {
 		return nil, nil
 	}
-	return &chat, err
+	if err != nil {
+		return nil, err
+	}
+	if err := r.loadSavedConnections([]*models.Chat{&chat}); err != nil {
+		return nil, err
+	}
+	return &chat, nil
 }
 
 func (r *chatRepository) FindByUserID(userID primitive.ObjectID, page, pageSize int) ([]*models.Chat, int64, error) {
@@ -91,8 +116,100 @@ func (r *chatRepository) FindByUserID(userID primitive.ObjectID, page, pageSize
 	}
 	defer cursor.Close(context.Background())
 
-	err = cursor.All(context.Background(), &chats)
-	return chats, total, err
+	if err := cursor.All(context.Background(), &chats); err != nil {
+		return nil, 0, err
+	}
+	if err := r.loadSavedConnections(chats); err != nil {
+		return nil, 0, err
+	}
+	return chats, total, nil
+}
+
+func (r *chatRepository) FindByConnectionID(connectionID primitive.ObjectID) ([]*models.Chat, error) {
+	var chats []*models.Chat
+	cursor, err := r.chatCollection.Find(context.Background(), bson.M{"connection_id": connectionID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	if err := cursor.All(context.Background(), &chats); err != nil {
+		return nil, err
+	}
+	if err := r.loadSavedConnections(chats); err != nil {
+		return nil, err
+	}
+	return chats, nil
+}
+
+func (r *chatRepository) CountByConnectionID(connectionID primitive.ObjectID) (int64, error) {
+	return r.chatCollection.CountDocuments(context.Background(), bson.M{"connection_id": connectionID})
+}
+
+// DetachConnection embeds a copy of a saved connection in the chats referencing it, they no longer depend on it
+func (r *chatRepository) DetachConnection(connectionID primitive.ObjectID, connection models.Connection) (int64, error) {
+	filter := bson.M{"connection_id": connectionID}
+	update := bson.M{
+		"$set":   bson.M{"connection": connection, "updated_at": time.Now()},
+		"$unset": bson.M{"connection_id": ""},
+	}
+	result, err := r.chatCollection.UpdateMany(context.Background(), filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// loadSavedConnections sets the connection of the chats referencing a saved connection, a chat whose saved connection
+// is missing keeps an empty connection so the other chats still load
+func (r *chatRepository) loadSavedConnections(chats []*models.Chat) error {
+	var ids []primitive.ObjectID
+	for _, chat := range chats {
+		if chat.ConnectionID != nil {
+			ids = append(ids, *chat.ConnectionID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	cursor, err := r.connectionCollection.Find(context.Background(), bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.Background())
+
+	var connections []*models.SavedConnection
+	if err := cursor.All(context.Background(), &connections); err != nil {
+		return err
+	}
+	byID := make(map[primitive.ObjectID]*models.SavedConnection, len(connections))
+	for _, connection := range connections {
+		byID[connection.ID] = connection
+	}
+
+	for _, chat := range chats {
+		if chat.ConnectionID == nil {
+			continue
+		}
+		connection, found := byID[*chat.ConnectionID]
+		if !found {
+			log.Printf("ChatRepository -> loadSavedConnections -> Connection %s of chat %s not found", chat.ConnectionID.Hex(), chat.ID.Hex())
+			continue
+		}
+		chat.Connection = connection.Connection
+	}
+	return nil
+}
+
+// storedChat returns the document saved for a chat, the connection of a chat using a saved connection isn't embedded
+func storedChat(chat *models.Chat) *models.Chat {
+	if chat.ConnectionID == nil {
+		return chat
+	}
+	stored := *chat
+	stored.Connection = models.Connection{}
+	return &stored
 }
 
 func (r *chatRepository) CreateMessage(message *models.Message) error {
@@ -177,6 +294,34 @@ func (r *chatRepository) FindMessageByID(id primitive.ObjectID) (*models.Message
 	return &message, err
 }
 
+// FindMessageByQueryID finds the message that contains the query with the given ID
+func (r *chatRepository) FindMessageByQueryID(queryID primitive.ObjectID) (*models.Message, error) {
+	var message models.Message
+	err := r.messageCollection.FindOne(context.Background(), bson.M{"queries.id": queryID}).Decode(&message)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &message, err
+}
+
+func (r *chatRepository) SetQueryResultFile(messageID, queryID primitive.ObjectID, actionAt string, file *models.ResultFile) (bool, error) {
+	filter := bson.M{
+		"_id":     messageID,
+		"queries": bson.M{"$elemMatch": bson.M{"id": queryID, "action_at": actionAt, "is_rolled_back": false}},
+	}
+	result, err := r.messageCollection.UpdateOne(context.Background(), filter, bson.M{"$set": bson.M{"queries.$.result_file": file}})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (r *chatRepository) SetQueryKeysetUnique(messageID, queryID primitive.ObjectID, unique bool) error {
+	filter := bson.M{"_id": messageID, "queries.id": queryID}
+	_, err := r.messageCollection.UpdateOne(context.Background(), filter, bson.M{"$set": bson.M{"queries.$.pagination.keyset_unique": unique}})
+	return err
+}
+
 func (r *chatRepository) updateChatTimeStamp(chatID primitive.ObjectID) error {
 	go func() {
 		filter := bson.M{"_id": chatID}
@@ -233,3 +378,38 @@ func (r *chatRepository) FindNextMessageByID(id primitive.ObjectID) (*models.Mes
 		return &nextMsg, err
 	}
 }
+
+func (r *chatRepository) ForEachQueryMessage(ctx context.Context, since, until *time.Time, fn func(message *models.Message) error) error {
+	filter := bson.M{
+		"type":      "assistant",
+		"queries.0": bson.M{"$exists": true},
+	}
+	createdAt := bson.M{}
+	if since != nil {
+		createdAt["$gte"] = *since
+	}
+	if until != nil {
+		createdAt["$lt"] = *until
+	}
+	if len(createdAt) > 0 {
+		filter["created_at"] = createdAt
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.messageCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var message models.Message
+		if err := cursor.Decode(&message); err != nil {
+			return fmt.Errorf("failed to decode message: %v", err)
+		}
+		if err := fn(&message); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"context"
+	"neobase-ai/internal/models"
+	"neobase-ai/pkg/mongodb"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CommentRepository interface {
+	Create(comment *models.QueryComment) error
+	Update(id primitive.ObjectID, comment *models.QueryComment) error
+	Delete(id primitive.ObjectID) error
+	DeleteMany(ids []primitive.ObjectID) error
+	FindByID(id primitive.ObjectID) (*models.QueryComment, error)
+	FindByQueryID(queryID primitive.ObjectID) ([]*models.QueryComment, error)
+}
+
+type commentRepository struct {
+	commentCollection *mongo.Collection
+}
+
+func NewCommentRepository(mongoClient *mongodb.MongoDBClient) CommentRepository {
+	return &commentRepository{
+		commentCollection: mongoClient.GetCollectionByName("query_comments"),
+	}
+}
+
+func (r *commentRepository) Create(comment *models.QueryComment) error {
+	_, err := r.commentCollection.InsertOne(context.Background(), comment)
+	return err
+}
+
+func (r *commentRepository) Update(id primitive.ObjectID, comment *models.QueryComment) error {
+	comment.UpdatedAt = time.Now()
+	filter := bson.M{"_id": id}
+	update := bson.M{"$set": comment}
+	_, err := r.commentCollection.UpdateOne(context.Background(), filter, update)
+	return err
+}
+
+func (r *commentRepository) Delete(id primitive.ObjectID) error {
+	filter := bson.M{"_id": id}
+	_, err := r.commentCollection.DeleteOne(context.Background(), filter)
+	return err
+}
+
+func (r *commentRepository) DeleteMany(ids []primitive.ObjectID) error {
+	filter := bson.M{"_id": bson.M{"$in": ids}}
+	_, err := r.commentCollection.DeleteMany(context.Background(), filter)
+	return err
+}
+
+func (r *commentRepository) FindByID(id primitive.ObjectID) (*models.QueryComment, error) {
+	var comment models.QueryComment
+	err := r.commentCollection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&comment)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &comment, err
+}
+
+// FindByQueryID returns all comments of a query, oldest first so that threads read in order
+func (r *commentRepository) FindByQueryID(queryID primitive.ObjectID) ([]*models.QueryComment, error) {
+	var comments []*models.QueryComment
+	filter := bson.M{"query_id": queryID}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := r.commentCollection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	err = cursor.All(context.Background(), &comments)
+	return comments, err
+}
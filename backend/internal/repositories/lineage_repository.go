@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"context"
+	"neobase-ai/internal/models"
+	"neobase-ai/pkg/mongodb"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LineageRepository interface {
+	CreateMany(events []*models.LineageEvent) error
+	FindByChatID(chatID primitive.ObjectID, table, column string, page, pageSize int) ([]*models.LineageEvent, int64, error)
+	FindAllByChatID(chatID primitive.ObjectID, limit int) ([]*models.LineageEvent, error)
+	DeleteByChatID(chatID primitive.ObjectID) error
+}
+
+type lineageRepository struct {
+	collection *mongo.Collection
+}
+
+func NewLineageRepository(mongoClient *mongodb.MongoDBClient) LineageRepository {
+	return &lineageRepository{
+		collection: mongoClient.GetCollectionByName("query_lineage"),
+	}
+}
+
+func (r *lineageRepository) CreateMany(events []*models.LineageEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	docs := make([]interface{}, 0, len(events))
+	for _, event := range events {
+		docs = append(docs, event)
+	}
+	_, err := r.collection.InsertMany(context.Background(), docs)
+	return err
+}
+
+// FindByChatID returns the lineage events of a chat, optionally only the ones writing a table or a column of it
+func (r *lineageRepository) FindByChatID(chatID primitive.ObjectID, table, column string, page, pageSize int) ([]*models.LineageEvent, int64, error) {
+	var events []*models.LineageEvent
+	filter := bson.M{"chat_id": chatID}
+	if table != "" {
+		filter["target_table"] = table
+	}
+	if column != "" {
+		filter["columns.column"] = column
+	}
+
+	// Get total count
+	total, err := r.collection.CountDocuments(context.Background(), filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Setup pagination
+	skip := int64((page - 1) * pageSize)
+	opts := options.Find().
+		SetSkip(skip).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(context.Background())
+
+	err = cursor.All(context.Background(), &events)
+	return events, total, err
+}
+
+// FindAllByChatID returns the latest lineage events of a chat, used to build the lineage graph
+func (r *lineageRepository) FindAllByChatID(chatID primitive.ObjectID, limit int) ([]*models.LineageEvent, error) {
+	var events []*models.LineageEvent
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(context.Background(), bson.M{"chat_id": chatID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	err = cursor.All(context.Background(), &events)
+	return events, err
+}
+
+func (r *lineageRepository) DeleteByChatID(chatID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(context.Background(), bson.M{"chat_id": chatID})
+	return err
+}
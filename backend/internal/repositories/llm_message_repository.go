@@ -23,6 +23,10 @@ type LLMMessageRepository interface {
 	DeleteMessagesByChatID(chatID primitive.ObjectID, dontDeleteSystemMessages bool) error
 	DeleteMessagesByRole(chatID primitive.ObjectID, role string) error
 	GetByChatID(chatID primitive.ObjectID) ([]*models.LLMMessage, error)
+
+	// Usage operations
+	AggregateUsageByChatID(chatID primitive.ObjectID) ([]*models.LLMUsageByModel, error)
+	AggregateUsageByUserID(userID primitive.ObjectID, from, to time.Time) ([]*models.LLMUsageByModel, error)
 }
 
 type llmMessageRepository struct {
@@ -146,3 +150,53 @@ func (r *llmMessageRepository) DeleteMessagesByRole(chatID primitive.ObjectID, r
 	_, err := r.messageCollection.DeleteMany(context.Background(), filter)
 	return err
 }
+
+// AggregateUsageByChatID sums the usage of the LLM calls of a chat per model
+func (r *llmMessageRepository) AggregateUsageByChatID(chatID primitive.ObjectID) ([]*models.LLMUsageByModel, error) {
+	return r.aggregateUsage(bson.M{"chat_id": chatID})
+}
+
+// AggregateUsageByUserID sums the usage of the LLM calls of a user between from (inclusive) & to (exclusive) per model
+func (r *llmMessageRepository) AggregateUsageByUserID(userID primitive.ObjectID, from, to time.Time) ([]*models.LLMUsageByModel, error) {
+	return r.aggregateUsage(bson.M{
+		"user_id":    userID,
+		"created_at": bson.M{"$gte": from, "$lt": to},
+	})
+}
+
+func (r *llmMessageRepository) aggregateUsage(filter bson.M) ([]*models.LLMUsageByModel, error) {
+	// Only the assistant messages have a usage
+	filter["usage"] = bson.M{"$exists": true}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{
+			"_id":               bson.M{"provider": "$usage.provider", "model": "$usage.model"},
+			"calls":             bson.M{"$sum": 1},
+			"prompt_tokens":     bson.M{"$sum": "$usage.prompt_tokens"},
+			"completion_tokens": bson.M{"$sum": "$usage.completion_tokens"},
+			"total_tokens":      bson.M{"$sum": "$usage.total_tokens"},
+			"estimated_cost":    bson.M{"$sum": "$usage.estimated_cost"},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":               0,
+			"provider":          "$_id.provider",
+			"model":             "$_id.model",
+			"calls":             1,
+			"prompt_tokens":     1,
+			"completion_tokens": 1,
+			"total_tokens":      1,
+			"estimated_cost":    1,
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "total_tokens", Value: -1}}}},
+	}
+
+	cursor, err := r.messageCollection.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	usage := make([]*models.LLMUsageByModel, 0)
+	err = cursor.All(context.Background(), &usage)
+	return usage, err
+}
@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"context"
+	"neobase-ai/internal/models"
+	"neobase-ai/pkg/mongodb"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type NotificationRepository interface {
+	Create(notification *models.Notification) error
+	FindByUserID(userID primitive.ObjectID, unreadOnly bool, page, pageSize int) ([]*models.Notification, int64, error)
+	CountUnread(userID primitive.ObjectID) (int64, error)
+	MarkRead(userID primitive.ObjectID, ids []primitive.ObjectID) (int64, error)
+	MarkAllRead(userID primitive.ObjectID) (int64, error)
+	DeleteByChatID(chatID primitive.ObjectID) error
+}
+
+type notificationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewNotificationRepository(mongoClient *mongodb.MongoDBClient) NotificationRepository {
+	return &notificationRepository{
+		collection: mongoClient.GetCollectionByName("notifications"),
+	}
+}
+
+func (r *notificationRepository) Create(notification *models.Notification) error {
+	_, err := r.collection.InsertOne(context.Background(), notification)
+	return err
+}
+
+func (r *notificationRepository) FindByUserID(userID primitive.ObjectID, unreadOnly bool, page, pageSize int) ([]*models.Notification, int64, error) {
+	var notifications []*models.Notification
+	filter := bson.M{"user_id": userID}
+	if unreadOnly {
+		filter["read_at"] = bson.M{"$exists": false}
+	}
+
+	// Get total count
+	total, err := r.collection.CountDocuments(context.Background(), filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Setup pagination
+	skip := int64((page - 1) * pageSize)
+	opts := options.Find().
+		SetSkip(skip).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(context.Background())
+
+	err = cursor.All(context.Background(), &notifications)
+	return notifications, total, err
+}
+
+func (r *notificationRepository) CountUnread(userID primitive.ObjectID) (int64, error) {
+	filter := bson.M{"user_id": userID, "read_at": bson.M{"$exists": false}}
+	return r.collection.CountDocuments(context.Background(), filter)
+}
+
+// MarkRead marks the given notifications of the user as read, notifications of other users are left untouched
+func (r *notificationRepository) MarkRead(userID primitive.ObjectID, ids []primitive.ObjectID) (int64, error) {
+	filter := bson.M{
+		"_id":     bson.M{"$in": ids},
+		"user_id": userID,
+		"read_at": bson.M{"$exists": false},
+	}
+	now := time.Now()
+	update := bson.M{"$set": bson.M{"read_at": now, "updated_at": now}}
+	result, err := r.collection.UpdateMany(context.Background(), filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (r *notificationRepository) MarkAllRead(userID primitive.ObjectID) (int64, error) {
+	filter := bson.M{"user_id": userID, "read_at": bson.M{"$exists": false}}
+	now := time.Now()
+	update := bson.M{"$set": bson.M{"read_at": now, "updated_at": now}}
+	result, err := r.collection.UpdateMany(context.Background(), filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (r *notificationRepository) DeleteByChatID(chatID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(context.Background(), bson.M{"chat_id": chatID})
+	return err
+}
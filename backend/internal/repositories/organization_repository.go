@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"context"
+	"neobase-ai/internal/models"
+	"neobase-ai/pkg/mongodb"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type OrganizationRepository interface {
+	Create(organization *models.Organization) error
+	Update(id primitive.ObjectID, organization *models.Organization) error
+	Delete(id primitive.ObjectID) error
+	FindByID(id primitive.ObjectID) (*models.Organization, error)
+	FindByMemberID(userID primitive.ObjectID) (*models.Organization, error)
+	FindAll(page, pageSize int) ([]*models.Organization, int64, error)
+}
+
+type organizationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewOrganizationRepository(mongoClient *mongodb.MongoDBClient) OrganizationRepository {
+	return &organizationRepository{
+		collection: mongoClient.GetCollectionByName("organizations"),
+	}
+}
+
+func (r *organizationRepository) Create(organization *models.Organization) error {
+	_, err := r.collection.InsertOne(context.Background(), organization)
+	return err
+}
+
+func (r *organizationRepository) Update(id primitive.ObjectID, organization *models.Organization) error {
+	organization.UpdatedAt = time.Now()
+	filter := bson.M{"_id": id}
+	update := bson.M{"$set": organization}
+	_, err := r.collection.UpdateOne(context.Background(), filter, update)
+	return err
+}
+
+func (r *organizationRepository) Delete(id primitive.ObjectID) error {
+	filter := bson.M{"_id": id}
+	_, err := r.collection.DeleteOne(context.Background(), filter)
+	return err
+}
+
+func (r *organizationRepository) FindByID(id primitive.ObjectID) (*models.Organization, error) {
+	var organization models.Organization
+	err := r.collection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&organization)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &organization, err
+}
+
+// FindByMemberID returns the organization of a user, a user belongs to at most one organization
+func (r *organizationRepository) FindByMemberID(userID primitive.ObjectID) (*models.Organization, error) {
+	var organization models.Organization
+	err := r.collection.FindOne(context.Background(), bson.M{"member_ids": userID}).Decode(&organization)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &organization, err
+}
+
+func (r *organizationRepository) FindAll(page, pageSize int) ([]*models.Organization, int64, error) {
+	var organizations []*models.Organization
+	filter := bson.M{}
+
+	// Get total count
+	total, err := r.collection.CountDocuments(context.Background(), filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Setup pagination
+	skip := int64((page - 1) * pageSize)
+	opts := options.Find().
+		SetSkip(skip).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(context.Background())
+
+	err = cursor.All(context.Background(), &organizations)
+	return organizations, total, err
+}
@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"context"
+	"neobase-ai/internal/models"
+	"neobase-ai/pkg/mongodb"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PromptTemplateRepository interface {
+	Create(template *models.PromptTemplate) error
+	FindVersion(dbType, provider string, version int) (*models.PromptTemplate, error)
+	FindVersions(dbType, provider string) ([]*models.PromptTemplate, error)
+	FindLatestVersion(dbType, provider string) (int, error)
+	FindActive() ([]*models.PromptTemplate, error)
+	// Activate makes a version the active one of its database type & provider, version 0 deactivates them all
+	Activate(dbType, provider string, version int) error
+}
+
+type promptTemplateRepository struct {
+	collection *mongo.Collection
+}
+
+func NewPromptTemplateRepository(mongoClient *mongodb.MongoDBClient) PromptTemplateRepository {
+	return &promptTemplateRepository{
+		collection: mongoClient.GetCollectionByName("prompt_templates"),
+	}
+}
+
+func (r *promptTemplateRepository) Create(template *models.PromptTemplate) error {
+	_, err := r.collection.InsertOne(context.Background(), template)
+	return err
+}
+
+func (r *promptTemplateRepository) FindVersion(dbType, provider string, version int) (*models.PromptTemplate, error) {
+	var template models.PromptTemplate
+	filter := bson.M{"db_type": dbType, "provider": provider, "version": version}
+	err := r.collection.FindOne(context.Background(), filter).Decode(&template)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &template, err
+}
+
+// FindVersions returns the versions of the template of a database type & provider, latest first
+func (r *promptTemplateRepository) FindVersions(dbType, provider string) ([]*models.PromptTemplate, error) {
+	templates := make([]*models.PromptTemplate, 0)
+	filter := bson.M{"db_type": dbType, "provider": provider}
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: -1}})
+
+	cursor, err := r.collection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	err = cursor.All(context.Background(), &templates)
+	return templates, err
+}
+
+// FindLatestVersion returns the number of the latest version, 0 when the template has no version yet
+func (r *promptTemplateRepository) FindLatestVersion(dbType, provider string) (int, error) {
+	var template models.PromptTemplate
+	filter := bson.M{"db_type": dbType, "provider": provider}
+	opts := options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}}).SetProjection(bson.M{"version": 1})
+	err := r.collection.FindOne(context.Background(), filter, opts).Decode(&template)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return template.Version, nil
+}
+
+func (r *promptTemplateRepository) FindActive() ([]*models.PromptTemplate, error) {
+	templates := make([]*models.PromptTemplate, 0)
+	opts := options.Find().SetSort(bson.D{{Key: "db_type", Value: 1}, {Key: "provider", Value: 1}})
+
+	cursor, err := r.collection.Find(context.Background(), bson.M{"is_active": true}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	err = cursor.All(context.Background(), &templates)
+	return templates, err
+}
+
+func (r *promptTemplateRepository) Activate(dbType, provider string, version int) error {
+	now := time.Now()
+	filter := bson.M{"db_type": dbType, "provider": provider, "is_active": true, "version": bson.M{"$ne": version}}
+	if _, err := r.collection.UpdateMany(context.Background(), filter, bson.M{"$set": bson.M{"is_active": false, "updated_at": now}}); err != nil {
+		return err
+	}
+	if version == 0 {
+		return nil
+	}
+
+	filter = bson.M{"db_type": dbType, "provider": provider, "version": version}
+	_, err := r.collection.UpdateOne(context.Background(), filter, bson.M{"$set": bson.M{"is_active": true, "updated_at": now}})
+	return err
+}
@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"context"
+	"neobase-ai/internal/models"
+	"neobase-ai/pkg/mongodb"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type QueryExampleRepository interface {
+	Create(example *models.QueryExample) error
+	Update(example *models.QueryExample) error
+	Delete(id primitive.ObjectID) error
+	FindByID(id primitive.ObjectID) (*models.QueryExample, error)
+	FindByChat(chatID primitive.ObjectID, connectionID *primitive.ObjectID) ([]*models.QueryExample, error)
+	CountByChat(chatID primitive.ObjectID, connectionID *primitive.ObjectID) (int64, error)
+	DeleteByChatID(chatID primitive.ObjectID) error
+}
+
+type queryExampleRepository struct {
+	collection *mongo.Collection
+}
+
+func NewQueryExampleRepository(mongoClient *mongodb.MongoDBClient) QueryExampleRepository {
+	return &queryExampleRepository{
+		collection: mongoClient.GetCollectionByName("query_examples"),
+	}
+}
+
+// queryExampleFilter matches the examples of the chat & the ones shared through its saved connection
+func queryExampleFilter(chatID primitive.ObjectID, connectionID *primitive.ObjectID) bson.M {
+	if connectionID == nil {
+		return bson.M{"chat_id": chatID}
+	}
+	return bson.M{"$or": []bson.M{
+		{"chat_id": chatID},
+		{"connection_id": *connectionID},
+	}}
+}
+
+func (r *queryExampleRepository) Create(example *models.QueryExample) error {
+	_, err := r.collection.InsertOne(context.Background(), example)
+	return err
+}
+
+func (r *queryExampleRepository) Update(example *models.QueryExample) error {
+	_, err := r.collection.ReplaceOne(context.Background(), bson.M{"_id": example.ID}, example)
+	return err
+}
+
+func (r *queryExampleRepository) Delete(id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(context.Background(), bson.M{"_id": id})
+	return err
+}
+
+func (r *queryExampleRepository) FindByID(id primitive.ObjectID) (*models.QueryExample, error) {
+	var example models.QueryExample
+	err := r.collection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&example)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &example, err
+}
+
+func (r *queryExampleRepository) FindByChat(chatID primitive.ObjectID, connectionID *primitive.ObjectID) ([]*models.QueryExample, error) {
+	var examples []*models.QueryExample
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(context.Background(), queryExampleFilter(chatID, connectionID), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	err = cursor.All(context.Background(), &examples)
+	return examples, err
+}
+
+func (r *queryExampleRepository) CountByChat(chatID primitive.ObjectID, connectionID *primitive.ObjectID) (int64, error) {
+	return r.collection.CountDocuments(context.Background(), queryExampleFilter(chatID, connectionID))
+}
+
+// DeleteByChatID removes the examples of a chat, the ones shared through a saved connection are kept for its other chats
+func (r *queryExampleRepository) DeleteByChatID(chatID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(context.Background(), bson.M{"chat_id": chatID, "connection_id": bson.M{"$exists": false}})
+	return err
+}
@@ -0,0 +1,113 @@
+package repositories
+
+import (
+	"context"
+	"neobase-ai/internal/models"
+	"neobase-ai/pkg/mongodb"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// QueryHistoryFilter narrows the query history of a user, the zero values don't filter
+type QueryHistoryFilter struct {
+	ChatID       *primitive.ObjectID
+	ConnectionID *primitive.ObjectID
+	DatabaseType string
+	Status       string
+	IsRollback   *bool
+	From         *time.Time // Inclusive
+	To           *time.Time // Exclusive
+	Search       string     // Case-insensitive text the query contains
+}
+
+type QueryHistoryRepository interface {
+	Create(entry *models.QueryHistoryEntry) error
+	FindByUserID(userID primitive.ObjectID, filter QueryHistoryFilter, page, pageSize int) ([]*models.QueryHistoryEntry, int64, error)
+	FindByID(id primitive.ObjectID) (*models.QueryHistoryEntry, error)
+}
+
+type queryHistoryRepository struct {
+	collection *mongo.Collection
+}
+
+func NewQueryHistoryRepository(mongoClient *mongodb.MongoDBClient) QueryHistoryRepository {
+	return &queryHistoryRepository{
+		collection: mongoClient.GetCollectionByName("query_history"),
+	}
+}
+
+func (r *queryHistoryRepository) Create(entry *models.QueryHistoryEntry) error {
+	_, err := r.collection.InsertOne(context.Background(), entry)
+	return err
+}
+
+func (r *queryHistoryRepository) FindByID(id primitive.ObjectID) (*models.QueryHistoryEntry, error) {
+	var entry models.QueryHistoryEntry
+	err := r.collection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&entry)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &entry, err
+}
+
+// FindByUserID returns the executions of the user matching the filter, most recent first, without their results
+func (r *queryHistoryRepository) FindByUserID(userID primitive.ObjectID, filter QueryHistoryFilter, page, pageSize int) ([]*models.QueryHistoryEntry, int64, error) {
+	var entries []*models.QueryHistoryEntry
+	query := bson.M{"user_id": userID}
+	if filter.ChatID != nil {
+		query["chat_id"] = *filter.ChatID
+	}
+	if filter.ConnectionID != nil {
+		query["connection_id"] = *filter.ConnectionID
+	}
+	if filter.DatabaseType != "" {
+		query["database_type"] = filter.DatabaseType
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	if filter.IsRollback != nil {
+		query["is_rollback"] = *filter.IsRollback
+	}
+	if filter.From != nil || filter.To != nil {
+		executedAt := bson.M{}
+		if filter.From != nil {
+			executedAt["$gte"] = *filter.From
+		}
+		if filter.To != nil {
+			executedAt["$lt"] = *filter.To
+		}
+		query["executed_at"] = executedAt
+	}
+	if filter.Search != "" {
+		query["query"] = primitive.Regex{Pattern: regexp.QuoteMeta(filter.Search), Options: "i"}
+	}
+
+	// Get total count
+	total, err := r.collection.CountDocuments(context.Background(), query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Setup pagination
+	skip := int64((page - 1) * pageSize)
+	opts := options.Find().
+		SetSkip(skip).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "executed_at", Value: -1}}).
+		SetProjection(bson.M{"result": 0})
+
+	cursor, err := r.collection.Find(context.Background(), query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(context.Background())
+
+	err = cursor.All(context.Background(), &entries)
+	return entries, total, err
+}
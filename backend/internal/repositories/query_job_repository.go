@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"context"
+	"neobase-ai/internal/models"
+	"neobase-ai/pkg/mongodb"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type QueryJobRepository interface {
+	Create(job *models.QueryJob) error
+	Update(job *models.QueryJob) error
+	FindByID(id primitive.ObjectID) (*models.QueryJob, error)
+	FindByUserID(userID primitive.ObjectID, chatID *primitive.ObjectID, page, pageSize int) ([]*models.QueryJob, int64, error)
+}
+
+type queryJobRepository struct {
+	collection *mongo.Collection
+}
+
+func NewQueryJobRepository(mongoClient *mongodb.MongoDBClient) QueryJobRepository {
+	return &queryJobRepository{
+		collection: mongoClient.GetCollectionByName("query_jobs"),
+	}
+}
+
+func (r *queryJobRepository) Create(job *models.QueryJob) error {
+	_, err := r.collection.InsertOne(context.Background(), job)
+	return err
+}
+
+func (r *queryJobRepository) Update(job *models.QueryJob) error {
+	job.UpdatedAt = time.Now()
+	filter := bson.M{"_id": job.ID}
+	update := bson.M{"$set": job}
+	_, err := r.collection.UpdateOne(context.Background(), filter, update)
+	return err
+}
+
+func (r *queryJobRepository) FindByID(id primitive.ObjectID) (*models.QueryJob, error) {
+	var job models.QueryJob
+	err := r.collection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &job, err
+}
+
+// FindByUserID returns the jobs of the user, only the ones of the chat when chatID is not nil
+func (r *queryJobRepository) FindByUserID(userID primitive.ObjectID, chatID *primitive.ObjectID, page, pageSize int) ([]*models.QueryJob, int64, error) {
+	var jobs []*models.QueryJob
+	filter := bson.M{"user_id": userID}
+	if chatID != nil {
+		filter["chat_id"] = *chatID
+	}
+
+	// Get total count
+	total, err := r.collection.CountDocuments(context.Background(), filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Setup pagination
+	skip := int64((page - 1) * pageSize)
+	opts := options.Find().
+		SetSkip(skip).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(context.Background())
+
+	err = cursor.All(context.Background(), &jobs)
+	return jobs, total, err
+}
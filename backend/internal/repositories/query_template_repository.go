@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"context"
+	"neobase-ai/internal/models"
+	"neobase-ai/pkg/mongodb"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type QueryTemplateRepository interface {
+	Create(template *models.QueryTemplate) error
+	Update(template *models.QueryTemplate) error
+	Delete(id primitive.ObjectID) error
+	FindByID(id primitive.ObjectID) (*models.QueryTemplate, error)
+	FindByChatID(chatID primitive.ObjectID, page, pageSize int) ([]*models.QueryTemplate, int64, error)
+}
+
+type queryTemplateRepository struct {
+	collection *mongo.Collection
+}
+
+func NewQueryTemplateRepository(mongoClient *mongodb.MongoDBClient) QueryTemplateRepository {
+	return &queryTemplateRepository{
+		collection: mongoClient.GetCollectionByName("query_templates"),
+	}
+}
+
+func (r *queryTemplateRepository) Create(template *models.QueryTemplate) error {
+	_, err := r.collection.InsertOne(context.Background(), template)
+	return err
+}
+
+// Update replaces the template, so the description & the defaults are removed when unset
+func (r *queryTemplateRepository) Update(template *models.QueryTemplate) error {
+	template.UpdatedAt = time.Now()
+	_, err := r.collection.ReplaceOne(context.Background(), bson.M{"_id": template.ID}, template)
+	return err
+}
+
+func (r *queryTemplateRepository) Delete(id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(context.Background(), bson.M{"_id": id})
+	return err
+}
+
+func (r *queryTemplateRepository) FindByID(id primitive.ObjectID) (*models.QueryTemplate, error) {
+	var template models.QueryTemplate
+	err := r.collection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&template)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &template, err
+}
+
+func (r *queryTemplateRepository) FindByChatID(chatID primitive.ObjectID, page, pageSize int) ([]*models.QueryTemplate, int64, error) {
+	var templates []*models.QueryTemplate
+	filter := bson.M{"chat_id": chatID}
+
+	total, err := r.collection.CountDocuments(context.Background(), filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "name", Value: 1}})
+
+	cursor, err := r.collection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(context.Background())
+
+	err = cursor.All(context.Background(), &templates)
+	return templates, total, err
+}
@@ -0,0 +1,162 @@
+package repositories
+
+import (
+	"context"
+	"neobase-ai/internal/models"
+	"neobase-ai/pkg/mongodb"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RunbookRepository interface {
+	Create(runbook *models.Runbook) error
+	Update(id primitive.ObjectID, runbook *models.Runbook) error
+	Delete(id primitive.ObjectID) error
+	FindByID(id primitive.ObjectID) (*models.Runbook, error)
+	FindByChatID(chatID primitive.ObjectID, page, pageSize int) ([]*models.Runbook, int64, error)
+
+	CreateRun(run *models.RunbookRun) error
+	UpdateRun(run *models.RunbookRun) error
+	UpdateRunIfStatus(run *models.RunbookRun, status string) (bool, error)
+	FindRunByID(id primitive.ObjectID) (*models.RunbookRun, error)
+	FindRunsByRunbookID(runbookID primitive.ObjectID, page, pageSize int) ([]*models.RunbookRun, int64, error)
+	DeleteRunsByRunbookID(runbookID primitive.ObjectID) error
+}
+
+type runbookRepository struct {
+	runbookCollection *mongo.Collection
+	runCollection     *mongo.Collection
+}
+
+func NewRunbookRepository(mongoClient *mongodb.MongoDBClient) RunbookRepository {
+	return &runbookRepository{
+		runbookCollection: mongoClient.GetCollectionByName("runbooks"),
+		runCollection:     mongoClient.GetCollectionByName("runbook_runs"),
+	}
+}
+
+func (r *runbookRepository) Create(runbook *models.Runbook) error {
+	_, err := r.runbookCollection.InsertOne(context.Background(), runbook)
+	return err
+}
+
+func (r *runbookRepository) Update(id primitive.ObjectID, runbook *models.Runbook) error {
+	runbook.UpdatedAt = time.Now()
+	filter := bson.M{"_id": id}
+	update := bson.M{"$set": runbook}
+	_, err := r.runbookCollection.UpdateOne(context.Background(), filter, update)
+	return err
+}
+
+func (r *runbookRepository) Delete(id primitive.ObjectID) error {
+	filter := bson.M{"_id": id}
+	_, err := r.runbookCollection.DeleteOne(context.Background(), filter)
+	return err
+}
+
+func (r *runbookRepository) FindByID(id primitive.ObjectID) (*models.Runbook, error) {
+	var runbook models.Runbook
+	err := r.runbookCollection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&runbook)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &runbook, err
+}
+
+func (r *runbookRepository) FindByChatID(chatID primitive.ObjectID, page, pageSize int) ([]*models.Runbook, int64, error) {
+	var runbooks []*models.Runbook
+	filter := bson.M{"chat_id": chatID}
+
+	// Get total count
+	total, err := r.runbookCollection.CountDocuments(context.Background(), filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Setup pagination
+	skip := int64((page - 1) * pageSize)
+	opts := options.Find().
+		SetSkip(skip).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.runbookCollection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(context.Background())
+
+	err = cursor.All(context.Background(), &runbooks)
+	return runbooks, total, err
+}
+
+func (r *runbookRepository) CreateRun(run *models.RunbookRun) error {
+	_, err := r.runCollection.InsertOne(context.Background(), run)
+	return err
+}
+
+func (r *runbookRepository) UpdateRun(run *models.RunbookRun) error {
+	run.UpdatedAt = time.Now()
+	filter := bson.M{"_id": run.ID}
+	update := bson.M{"$set": run}
+	_, err := r.runCollection.UpdateOne(context.Background(), filter, update)
+	return err
+}
+
+// UpdateRunIfStatus updates the run only while its stored status is the given one, false when another request changed it first
+func (r *runbookRepository) UpdateRunIfStatus(run *models.RunbookRun, status string) (bool, error) {
+	run.UpdatedAt = time.Now()
+	filter := bson.M{"_id": run.ID, "status": status}
+	update := bson.M{"$set": run}
+	result, err := r.runCollection.UpdateOne(context.Background(), filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (r *runbookRepository) FindRunByID(id primitive.ObjectID) (*models.RunbookRun, error) {
+	var run models.RunbookRun
+	err := r.runCollection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&run)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &run, err
+}
+
+func (r *runbookRepository) FindRunsByRunbookID(runbookID primitive.ObjectID, page, pageSize int) ([]*models.RunbookRun, int64, error) {
+	var runs []*models.RunbookRun
+	filter := bson.M{"runbook_id": runbookID}
+
+	// Get total count
+	total, err := r.runCollection.CountDocuments(context.Background(), filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Setup pagination
+	skip := int64((page - 1) * pageSize)
+	opts := options.Find().
+		SetSkip(skip).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.runCollection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(context.Background())
+
+	err = cursor.All(context.Background(), &runs)
+	return runs, total, err
+}
+
+func (r *runbookRepository) DeleteRunsByRunbookID(runbookID primitive.ObjectID) error {
+	filter := bson.M{"runbook_id": runbookID}
+	_, err := r.runCollection.DeleteMany(context.Background(), filter)
+	return err
+}
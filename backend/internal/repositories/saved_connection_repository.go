@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"context"
+	"neobase-ai/internal/models"
+	"neobase-ai/pkg/mongodb"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SavedConnectionRepository interface {
+	Create(connection *models.SavedConnection) error
+	Update(id primitive.ObjectID, connection *models.SavedConnection) error
+	Delete(id primitive.ObjectID) error
+	FindByID(id primitive.ObjectID) (*models.SavedConnection, error)
+	FindByUserID(userID primitive.ObjectID, page, pageSize int) ([]*models.SavedConnection, int64, error)
+}
+
+type savedConnectionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSavedConnectionRepository(mongoClient *mongodb.MongoDBClient) SavedConnectionRepository {
+	return &savedConnectionRepository{
+		collection: mongoClient.GetCollectionByName(savedConnectionCollectionName),
+	}
+}
+
+func (r *savedConnectionRepository) Create(connection *models.SavedConnection) error {
+	_, err := r.collection.InsertOne(context.Background(), connection)
+	return err
+}
+
+func (r *savedConnectionRepository) Update(id primitive.ObjectID, connection *models.SavedConnection) error {
+	connection.UpdatedAt = time.Now()
+	filter := bson.M{"_id": id}
+	update := bson.M{"$set": connection}
+	_, err := r.collection.UpdateOne(context.Background(), filter, update)
+	return err
+}
+
+func (r *savedConnectionRepository) Delete(id primitive.ObjectID) error {
+	filter := bson.M{"_id": id}
+	_, err := r.collection.DeleteOne(context.Background(), filter)
+	return err
+}
+
+func (r *savedConnectionRepository) FindByID(id primitive.ObjectID) (*models.SavedConnection, error) {
+	var connection models.SavedConnection
+	err := r.collection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&connection)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &connection, err
+}
+
+func (r *savedConnectionRepository) FindByUserID(userID primitive.ObjectID, page, pageSize int) ([]*models.SavedConnection, int64, error) {
+	var connections []*models.SavedConnection
+	filter := bson.M{"user_id": userID}
+
+	// Get total count
+	total, err := r.collection.CountDocuments(context.Background(), filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Setup pagination
+	skip := int64((page - 1) * pageSize)
+	opts := options.Find().
+		SetSkip(skip).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(context.Background())
+
+	err = cursor.All(context.Background(), &connections)
+	return connections, total, err
+}
@@ -0,0 +1,147 @@
+package repositories
+
+import (
+	"context"
+	"neobase-ai/internal/models"
+	"neobase-ai/pkg/mongodb"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ScheduledQueryRepository interface {
+	Create(schedule *models.ScheduledQuery) error
+	Update(schedule *models.ScheduledQuery) error
+	Delete(id primitive.ObjectID) error
+	FindByID(id primitive.ObjectID) (*models.ScheduledQuery, error)
+	FindByUserID(userID primitive.ObjectID, chatID *primitive.ObjectID, page, pageSize int) ([]*models.ScheduledQuery, int64, error)
+	FindDue(now time.Time, limit int) ([]*models.ScheduledQuery, error)
+	ClaimRun(id primitive.ObjectID, dueAt, nextRunAt time.Time) (bool, error)
+	RecordRun(id primitive.ObjectID, ranAt time.Time, status string, runErr *models.QueryError, messageID *primitive.ObjectID) error
+	Disable(id primitive.ObjectID) error
+}
+
+type scheduledQueryRepository struct {
+	collection *mongo.Collection
+}
+
+func NewScheduledQueryRepository(mongoClient *mongodb.MongoDBClient) ScheduledQueryRepository {
+	return &scheduledQueryRepository{
+		collection: mongoClient.GetCollectionByName("scheduled_queries"),
+	}
+}
+
+func (r *scheduledQueryRepository) Create(schedule *models.ScheduledQuery) error {
+	_, err := r.collection.InsertOne(context.Background(), schedule)
+	return err
+}
+
+// Update replaces the scheduled query, so the webhook & the next run are removed when unset
+func (r *scheduledQueryRepository) Update(schedule *models.ScheduledQuery) error {
+	schedule.UpdatedAt = time.Now()
+	_, err := r.collection.ReplaceOne(context.Background(), bson.M{"_id": schedule.ID}, schedule)
+	return err
+}
+
+func (r *scheduledQueryRepository) Delete(id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(context.Background(), bson.M{"_id": id})
+	return err
+}
+
+func (r *scheduledQueryRepository) FindByID(id primitive.ObjectID) (*models.ScheduledQuery, error) {
+	var schedule models.ScheduledQuery
+	err := r.collection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&schedule)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &schedule, err
+}
+
+// FindByUserID returns the scheduled queries of the user, only the ones of the chat when chatID is not nil
+func (r *scheduledQueryRepository) FindByUserID(userID primitive.ObjectID, chatID *primitive.ObjectID, page, pageSize int) ([]*models.ScheduledQuery, int64, error) {
+	var schedules []*models.ScheduledQuery
+	filter := bson.M{"user_id": userID}
+	if chatID != nil {
+		filter["chat_id"] = *chatID
+	}
+
+	// Get total count
+	total, err := r.collection.CountDocuments(context.Background(), filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Setup pagination
+	skip := int64((page - 1) * pageSize)
+	opts := options.Find().
+		SetSkip(skip).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(context.Background())
+
+	err = cursor.All(context.Background(), &schedules)
+	return schedules, total, err
+}
+
+// FindDue returns the enabled scheduled queries whose next run is due, the most overdue first
+func (r *scheduledQueryRepository) FindDue(now time.Time, limit int) ([]*models.ScheduledQuery, error) {
+	var schedules []*models.ScheduledQuery
+	filter := bson.M{"enabled": true, "next_run_at": bson.M{"$lte": now}}
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "next_run_at", Value: 1}})
+
+	cursor, err := r.collection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	err = cursor.All(context.Background(), &schedules)
+	return schedules, err
+}
+
+// ClaimRun moves the next run of a due scheduled query forward, false when another instance claimed the run first
+func (r *scheduledQueryRepository) ClaimRun(id primitive.ObjectID, dueAt, nextRunAt time.Time) (bool, error) {
+	filter := bson.M{"_id": id, "enabled": true, "next_run_at": dueAt}
+	update := bson.M{"$set": bson.M{"next_run_at": nextRunAt, "updated_at": time.Now()}}
+	result, err := r.collection.UpdateOne(context.Background(), filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// RecordRun sets the outcome of the last run, without overwriting the changes made to the scheduled query while it ran
+func (r *scheduledQueryRepository) RecordRun(id primitive.ObjectID, ranAt time.Time, status string, runErr *models.QueryError, messageID *primitive.ObjectID) error {
+	update := bson.M{
+		"$set": bson.M{
+			"last_run_at":     ranAt,
+			"last_status":     status,
+			"last_error":      runErr,
+			"last_message_id": messageID,
+			"updated_at":      time.Now(),
+		},
+		"$inc": bson.M{"run_count": 1},
+	}
+	_, err := r.collection.UpdateOne(context.Background(), bson.M{"_id": id}, update)
+	return err
+}
+
+// Disable stops the runs of a scheduled query
+func (r *scheduledQueryRepository) Disable(id primitive.ObjectID) error {
+	update := bson.M{
+		"$set":   bson.M{"enabled": false, "updated_at": time.Now()},
+		"$unset": bson.M{"next_run_at": ""},
+	}
+	_, err := r.collection.UpdateOne(context.Background(), bson.M{"_id": id}, update)
+	return err
+}
@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"context"
+	"neobase-ai/internal/models"
+	"neobase-ai/pkg/mongodb"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SchemaEmbeddingRepository interface {
+	Upsert(embeddings []*models.SchemaEmbedding) error
+	FindByChatID(chatID primitive.ObjectID) ([]*models.SchemaEmbedding, error)
+	DeleteTables(chatID primitive.ObjectID, tables []string) error
+	DeleteByChatID(chatID primitive.ObjectID) error
+}
+
+type schemaEmbeddingRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSchemaEmbeddingRepository(mongoClient *mongodb.MongoDBClient) SchemaEmbeddingRepository {
+	return &schemaEmbeddingRepository{
+		collection: mongoClient.GetCollectionByName("schema_embeddings"),
+	}
+}
+
+// Upsert replaces the embeddings of the tables, the embedding of a table is created on its first upsert
+func (r *schemaEmbeddingRepository) Upsert(embeddings []*models.SchemaEmbedding) error {
+	if len(embeddings) == 0 {
+		return nil
+	}
+	now := time.Now()
+	writes := make([]mongo.WriteModel, 0, len(embeddings))
+	for _, embedding := range embeddings {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"chat_id": embedding.ChatID, "table": embedding.Table}).
+			SetUpdate(bson.M{
+				"$set": bson.M{
+					"checksum":   embedding.Checksum,
+					"model":      embedding.Model,
+					"vector":     embedding.Vector,
+					"updated_at": now,
+				},
+				"$setOnInsert": bson.M{
+					"_id":        primitive.NewObjectID(),
+					"created_at": now,
+				},
+			}).
+			SetUpsert(true))
+	}
+	_, err := r.collection.BulkWrite(context.Background(), writes, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+func (r *schemaEmbeddingRepository) FindByChatID(chatID primitive.ObjectID) ([]*models.SchemaEmbedding, error) {
+	var embeddings []*models.SchemaEmbedding
+	cursor, err := r.collection.Find(context.Background(), bson.M{"chat_id": chatID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	err = cursor.All(context.Background(), &embeddings)
+	return embeddings, err
+}
+
+// DeleteTables removes the embeddings of tables, e.g. once they were dropped
+func (r *schemaEmbeddingRepository) DeleteTables(chatID primitive.ObjectID, tables []string) error {
+	if len(tables) == 0 {
+		return nil
+	}
+	_, err := r.collection.DeleteMany(context.Background(), bson.M{"chat_id": chatID, "table": bson.M{"$in": tables}})
+	return err
+}
+
+func (r *schemaEmbeddingRepository) DeleteByChatID(chatID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(context.Background(), bson.M{"chat_id": chatID})
+	return err
+}
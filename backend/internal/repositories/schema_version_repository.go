@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"context"
+	"neobase-ai/internal/models"
+	"neobase-ai/pkg/mongodb"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SchemaVersionRepository interface {
+	Create(version *models.SchemaVersion) error
+	FindByID(id primitive.ObjectID) (*models.SchemaVersion, error)
+	FindByChatID(chatID primitive.ObjectID, page, pageSize int) ([]*models.SchemaVersion, int64, error)
+	FindLatestByChatID(chatID primitive.ObjectID, at *time.Time) (*models.SchemaVersion, error)
+	DeleteByChatID(chatID primitive.ObjectID) error
+}
+
+type schemaVersionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSchemaVersionRepository(mongoClient *mongodb.MongoDBClient) SchemaVersionRepository {
+	return &schemaVersionRepository{
+		collection: mongoClient.GetCollectionByName("schema_versions"),
+	}
+}
+
+func (r *schemaVersionRepository) Create(version *models.SchemaVersion) error {
+	_, err := r.collection.InsertOne(context.Background(), version)
+	return err
+}
+
+func (r *schemaVersionRepository) FindByID(id primitive.ObjectID) (*models.SchemaVersion, error) {
+	var version models.SchemaVersion
+	err := r.collection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&version)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &version, err
+}
+
+// FindByChatID returns the schema versions of a chat, most recent first, without their schemas
+func (r *schemaVersionRepository) FindByChatID(chatID primitive.ObjectID, page, pageSize int) ([]*models.SchemaVersion, int64, error) {
+	var versions []*models.SchemaVersion
+	filter := bson.M{"chat_id": chatID}
+
+	// Get total count
+	total, err := r.collection.CountDocuments(context.Background(), filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Setup pagination
+	skip := int64((page - 1) * pageSize)
+	opts := options.Find().
+		SetSkip(skip).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "captured_at", Value: -1}}).
+		SetProjection(bson.M{"schema": 0})
+
+	cursor, err := r.collection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(context.Background())
+
+	err = cursor.All(context.Background(), &versions)
+	return versions, total, err
+}
+
+// FindLatestByChatID returns the latest schema version of a chat captured at or before at, the latest one without at
+func (r *schemaVersionRepository) FindLatestByChatID(chatID primitive.ObjectID, at *time.Time) (*models.SchemaVersion, error) {
+	var version models.SchemaVersion
+	filter := bson.M{"chat_id": chatID}
+	if at != nil {
+		filter["captured_at"] = bson.M{"$lte": *at}
+	}
+	opts := options.FindOne().SetSort(bson.D{{Key: "captured_at", Value: -1}})
+
+	err := r.collection.FindOne(context.Background(), filter, opts).Decode(&version)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &version, err
+}
+
+func (r *schemaVersionRepository) DeleteByChatID(chatID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(context.Background(), bson.M{"chat_id": chatID})
+	return err
+}
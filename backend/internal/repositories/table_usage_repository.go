@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"context"
+	"neobase-ai/internal/models"
+	"neobase-ai/pkg/mongodb"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type TableUsageRepository interface {
+	Increment(chatID primitive.ObjectID, tables []string, usedAt time.Time) error
+	FindByChatID(chatID primitive.ObjectID) ([]*models.TableUsage, error)
+	DeleteTables(chatID primitive.ObjectID, tables []string) error
+	DeleteByChatID(chatID primitive.ObjectID) error
+}
+
+type tableUsageRepository struct {
+	collection *mongo.Collection
+}
+
+func NewTableUsageRepository(mongoClient *mongodb.MongoDBClient) TableUsageRepository {
+	return &tableUsageRepository{
+		collection: mongoClient.GetCollectionByName("table_usage"),
+	}
+}
+
+// Increment counts a query using the tables, the usage of a table is created on its first query
+func (r *tableUsageRepository) Increment(chatID primitive.ObjectID, tables []string, usedAt time.Time) error {
+	if len(tables) == 0 {
+		return nil
+	}
+	writes := make([]mongo.WriteModel, 0, len(tables))
+	for _, table := range tables {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"chat_id": chatID, "table": table}).
+			SetUpdate(bson.M{
+				"$inc": bson.M{"count": 1},
+				"$set": bson.M{"last_used_at": usedAt, "updated_at": usedAt},
+				"$setOnInsert": bson.M{
+					"_id":        primitive.NewObjectID(),
+					"created_at": usedAt,
+				},
+			}).
+			SetUpsert(true))
+	}
+	_, err := r.collection.BulkWrite(context.Background(), writes, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+func (r *tableUsageRepository) FindByChatID(chatID primitive.ObjectID) ([]*models.TableUsage, error) {
+	var usages []*models.TableUsage
+	opts := options.Find().SetSort(bson.D{{Key: "count", Value: -1}})
+
+	cursor, err := r.collection.Find(context.Background(), bson.M{"chat_id": chatID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	err = cursor.All(context.Background(), &usages)
+	return usages, err
+}
+
+// DeleteTables forgets the usage of tables, e.g. once they were dropped
+func (r *tableUsageRepository) DeleteTables(chatID primitive.ObjectID, tables []string) error {
+	if len(tables) == 0 {
+		return nil
+	}
+	_, err := r.collection.DeleteMany(context.Background(), bson.M{"chat_id": chatID, "table": bson.M{"$in": tables}})
+	return err
+}
+
+func (r *tableUsageRepository) DeleteByChatID(chatID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(context.Background(), bson.M{"chat_id": chatID})
+	return err
+}
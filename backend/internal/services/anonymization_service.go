@@ -0,0 +1,554 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/models"
+	"neobase-ai/internal/repositories"
+	"neobase-ai/pkg/dbmanager"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	anonymizationStreamIDPrefix = "anonymization-" // Prefix of the stream ID used to connect the databases of a job
+	// Progress is persisted at most this often, chunks are written much faster than the job state needs to be refreshed
+	anonymizationProgressInterval = 2 * time.Second
+)
+
+type AnonymizationService interface {
+	Create(userID string, req *dtos.CreateAnonymizationJobRequest) (*dtos.AnonymizationJobResponse, uint32, error)
+	List(userID string, page, pageSize int) (*dtos.AnonymizationJobListResponse, uint32, error)
+	Get(userID, jobID string) (*dtos.AnonymizationJobResponse, uint32, error)
+	Cancel(userID, jobID string) (*dtos.AnonymizationJobResponse, uint32, error)
+	GetReport(userID, jobID string) (*dtos.AnonymizationReportResponse, uint32, error)
+}
+
+type anonymizationService struct {
+	jobRepo             repositories.AnonymizationJobRepository
+	chatRepo            repositories.ChatRepository
+	dbManager           *dbmanager.Manager
+	chatService         ChatService
+	notificationService NotificationService
+
+	// Jobs being executed by this instance, the cancel func stops the copy
+	activeJobs   map[string]context.CancelFunc
+	activeJobsMu sync.Mutex
+}
+
+func NewAnonymizationService(jobRepo repositories.AnonymizationJobRepository, chatRepo repositories.ChatRepository, dbManager *dbmanager.Manager, chatService ChatService, notificationService NotificationService) AnonymizationService {
+	return &anonymizationService{
+		jobRepo:             jobRepo,
+		chatRepo:            chatRepo,
+		dbManager:           dbManager,
+		chatService:         chatService,
+		notificationService: notificationService,
+		activeJobs:          make(map[string]context.CancelFunc),
+	}
+}
+
+// Create validates the masking rules of the tables, creates the job & starts copying in the background
+func (s *anonymizationService) Create(userID string, req *dtos.CreateAnonymizationJobRequest) (*dtos.AnonymizationJobResponse, uint32, error) {
+	log.Printf("AnonymizationService -> Create -> userID: %s, source: %s, target: %s, tables: %d", userID, req.SourceChatID, req.TargetChatID, len(req.Tables))
+
+	if req.SourceChatID == req.TargetChatID {
+		return nil, http.StatusBadRequest, apperrors.New("SAME_SOURCE_AND_TARGET", "source and target connections must be different")
+	}
+
+	source, statusCode, err := s.verifyChatOwnership(userID, req.SourceChatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+	target, statusCode, err := s.verifyChatOwnership(userID, req.TargetChatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+	if source.Connection.Type != target.Connection.Type {
+		return nil, http.StatusBadRequest, apperrors.New("CONNECTION_TYPE_MISMATCH", "source ({source}) and target ({target}) must be the same database type").
+			With("source", source.Connection.Type).With("target", target.Connection.Type)
+	}
+	if !dbmanager.SupportsAnonymizedCopy(source.Connection.Type) {
+		return nil, http.StatusBadRequest, apperrors.New("ANONYMIZATION_NOT_SUPPORTED", "anonymized copies are not supported for {type}").With("type", source.Connection.Type)
+	}
+	// Two chats can use the same database, clearing the target would then delete the rows being copied
+	if sameAnonymizationDatabase(source, target) {
+		return nil, http.StatusBadRequest, apperrors.New("SAME_SOURCE_AND_TARGET", "source and target chats use the same database, the target must be another database")
+	}
+	// Clearing the target deletes all its rows, like the unfiltered deletes the guardrail blocks
+	if req.ClearTarget && !target.Settings.AllowDestructiveQueries {
+		return nil, http.StatusForbidden, apperrors.New("DESTRUCTIVE_QUERY_BLOCKED", "clear_target deletes the rows of the target tables, allow destructive queries in the settings of the target chat to clear it")
+	}
+
+	chunkSize := dbmanager.AnonymizationDefaultChunkSize
+	if req.ChunkSize != nil {
+		if *req.ChunkSize <= 0 || *req.ChunkSize > dbmanager.AnonymizationMaxChunkSize {
+			return nil, http.StatusBadRequest, apperrors.New("INVALID_CHUNK_SIZE", "chunk_size must be between 1 and {max}").With("max", dbmanager.AnonymizationMaxChunkSize)
+		}
+		chunkSize = *req.ChunkSize
+	}
+
+	tables := make([]models.AnonymizationTable, 0, len(req.Tables))
+	seen := make(map[string]bool, len(req.Tables))
+	for _, reqTable := range req.Tables {
+		table := models.AnonymizationTable{Table: strings.TrimSpace(reqTable.Table), Rules: make([]models.MaskingRule, 0, len(reqTable.Rules))}
+		for _, rule := range reqTable.Rules {
+			table.Rules = append(table.Rules, models.MaskingRule{Column: strings.TrimSpace(rule.Column), Strategy: rule.Strategy, Value: rule.Value})
+		}
+		if seen[table.Table] {
+			return nil, http.StatusBadRequest, apperrors.New("DUPLICATE_TABLE", "table {table} is listed several times").With("table", table.Table)
+		}
+		seen[table.Table] = true
+		if err := dbmanager.ValidateAnonymizationTable(toDBManagerAnonymizationTable(table)); err != nil {
+			return nil, http.StatusBadRequest, apperrors.New("INVALID_MASKING_RULES", "{error}").With("error", err)
+		}
+		tables = append(tables, table)
+	}
+
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_CREATE_ANONYMIZATION_JOB", "failed to generate salt: {error}").With("error", err)
+	}
+
+	job := models.NewAnonymizationJob(source.UserID, source.ID, target.ID, tables, chunkSize, req.ClearTarget, hex.EncodeToString(salt))
+	if err := s.jobRepo.Create(job); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_CREATE_ANONYMIZATION_JOB", "failed to create anonymization job: {error}").With("error", err)
+	}
+
+	// Build the response before the engine starts updating the job
+	response := buildAnonymizationJobResponse(job)
+	s.startEngine(job)
+	return response, http.StatusAccepted, nil
+}
+
+// List returns the anonymization jobs of the user
+func (s *anonymizationService) List(userID string, page, pageSize int) (*dtos.AnonymizationJobListResponse, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+
+	jobs, total, err := s.jobRepo.FindByUserID(userObjID, page, pageSize)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_ANONYMIZATION_JOBS", "failed to fetch anonymization jobs: {error}").With("error", err)
+	}
+
+	response := &dtos.AnonymizationJobListResponse{
+		Jobs:  make([]dtos.AnonymizationJobResponse, 0, len(jobs)),
+		Total: total,
+	}
+	for _, job := range jobs {
+		response.Jobs = append(response.Jobs, *buildAnonymizationJobResponse(job))
+	}
+	return response, http.StatusOK, nil
+}
+
+// Get returns the current state & progress of a job
+func (s *anonymizationService) Get(userID, jobID string) (*dtos.AnonymizationJobResponse, uint32, error) {
+	job, statusCode, err := s.findJob(userID, jobID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+	return buildAnonymizationJobResponse(job), http.StatusOK, nil
+}
+
+// Cancel stops a job, the rows already copied stay in the target
+func (s *anonymizationService) Cancel(userID, jobID string) (*dtos.AnonymizationJobResponse, uint32, error) {
+	job, statusCode, err := s.findJob(userID, jobID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+	if job.IsFinished() {
+		return nil, http.StatusConflict, apperrors.New("JOB_ALREADY_FINISHED", "anonymization job has already finished")
+	}
+
+	s.activeJobsMu.Lock()
+	cancel, active := s.activeJobs[job.ID.Hex()]
+	s.activeJobsMu.Unlock()
+
+	if active {
+		cancel()
+		job.Status = models.AnonymizationJobStatusCancelled
+		return buildAnonymizationJobResponse(job), http.StatusAccepted, nil
+	}
+
+	// The job was interrupted by a server restart, nothing is executing
+	now := time.Now()
+	job.Status = models.AnonymizationJobStatusCancelled
+	job.CompletedAt = &now
+	if err := s.jobRepo.Update(job); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_UPDATE_ANONYMIZATION_JOB", "failed to update anonymization job: {error}").With("error", err)
+	}
+	return buildAnonymizationJobResponse(job), http.StatusOK, nil
+}
+
+// GetReport builds the report of a job with the row counts, the verification of each table & a markdown summary
+func (s *anonymizationService) GetReport(userID, jobID string) (*dtos.AnonymizationReportResponse, uint32, error) {
+	job, statusCode, err := s.findJob(userID, jobID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	report := &dtos.AnonymizationReportResponse{
+		AnonymizationJobResponse: *buildAnonymizationJobResponse(job),
+	}
+
+	var summary strings.Builder
+	summary.WriteString("## Anonymization report\n\n")
+	summary.WriteString(fmt.Sprintf("Status: **%s**\n\n", job.Status))
+	if job.Error != nil {
+		summary.WriteString(fmt.Sprintf("Error: %s\n\n", *job.Error))
+	}
+
+	for i, table := range job.Tables {
+		report.TotalRows += table.TotalRows
+		report.CopiedRows += table.CopiedRows
+
+		line := fmt.Sprintf("%d. %s: %s, %d/%d rows, %d masked column(s)", i+1, table.Table, table.Status, table.CopiedRows, table.TotalRows, countMaskedColumns(table.Rules))
+		if table.Verification != nil {
+			if table.Verification.Passed {
+				report.TablesPassed++
+				line += fmt.Sprintf(", verification passed (%d values checked)", table.Verification.CheckedValues)
+			} else {
+				report.TablesFailed++
+				line += ", verification **failed**"
+				if !table.Verification.RowCountsMatch {
+					line += fmt.Sprintf(": %d source rows, %d target rows", table.Verification.SourceRows, table.Verification.TargetRows)
+				}
+				if table.Verification.LeakedValues > 0 {
+					line += fmt.Sprintf(": %d original value(s) found in %s", table.Verification.LeakedValues, strings.Join(table.Verification.LeakedColumns, ", "))
+				}
+			}
+		} else if table.Status == models.AnonymizationTableStatusFailed {
+			report.TablesFailed++
+		}
+		if table.Error != nil {
+			line += fmt.Sprintf(", error: %s", *table.Error)
+		}
+		summary.WriteString(line + "\n\n")
+	}
+
+	endTime := time.Now()
+	if job.CompletedAt != nil {
+		endTime = *job.CompletedAt
+	}
+	report.Duration = endTime.Sub(job.CreatedAt).Milliseconds()
+
+	summary.WriteString(fmt.Sprintf("%d rows copied across %d table(s), %d verified, %d failed in %s",
+		report.CopiedRows, len(job.Tables), report.TablesPassed, report.TablesFailed, endTime.Sub(job.CreatedAt).Round(time.Second)))
+	report.Summary = summary.String()
+
+	return report, http.StatusOK, nil
+}
+
+// startEngine copies the job's tables in the background until it completes, fails or is cancelled
+func (s *anonymizationService) startEngine(job *models.AnonymizationJob) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s.activeJobsMu.Lock()
+	s.activeJobs[job.ID.Hex()] = cancel
+	s.activeJobsMu.Unlock()
+
+	go func() {
+		defer func() {
+			s.activeJobsMu.Lock()
+			delete(s.activeJobs, job.ID.Hex())
+			s.activeJobsMu.Unlock()
+			cancel()
+		}()
+		s.executeJob(ctx, job)
+	}()
+}
+
+// executeJob copies & verifies the tables one after the other, persisting the job state as the copy progresses
+func (s *anonymizationService) executeJob(ctx context.Context, job *models.AnonymizationJob) {
+	log.Printf("AnonymizationService -> executeJob -> Starting job %s with %d tables", job.ID.Hex(), len(job.Tables))
+
+	for _, chatID := range []string{job.SourceChatID.Hex(), job.TargetChatID.Hex()} {
+		if err := s.ensureConnected(ctx, job, chatID); err != nil {
+			s.finishJob(ctx, job, fmt.Sprintf("failed to connect to database: %v", err))
+			return
+		}
+	}
+
+	for job.CurrentTable < len(job.Tables) {
+		table := &job.Tables[job.CurrentTable]
+		if err := s.copyTable(ctx, job, table); err != nil {
+			if ctx.Err() == nil {
+				message := err.Error()
+				table.Status = models.AnonymizationTableStatusFailed
+				table.Error = &message
+			}
+			s.finishJob(ctx, job, fmt.Sprintf("table %s: %v", table.Table, err))
+			return
+		}
+		job.CurrentTable++
+		s.saveJob(job)
+	}
+
+	s.finishJob(ctx, job, "")
+}
+
+// copyTable copies a table chunk by chunk then verifies the target
+func (s *anonymizationService) copyTable(ctx context.Context, job *models.AnonymizationJob, table *models.AnonymizationTable) error {
+	sourceChatID, targetChatID := job.SourceChatID.Hex(), job.TargetChatID.Hex()
+	dbTable := toDBManagerAnonymizationTable(*table)
+
+	now := time.Now()
+	table.Status = models.AnonymizationTableStatusCopying
+	table.StartedAt = &now
+	table.CopiedRows = 0
+	table.Error = nil
+
+	total, err := s.dbManager.CountTableRows(ctx, sourceChatID, table.Table)
+	if err != nil {
+		return err
+	}
+	table.TotalRows = total
+	s.saveJob(job)
+
+	lastSave := time.Now()
+	copied, err := s.dbManager.CopyAnonymizedTable(ctx, sourceChatID, targetChatID, dbTable, dbmanager.AnonymizedCopyOptions{
+		ChunkSize:   job.ChunkSize,
+		Salt:        job.Salt,
+		ClearTarget: job.ClearTarget,
+		OnChunk: func(copied int64) {
+			table.CopiedRows = copied
+			if time.Since(lastSave) >= anonymizationProgressInterval {
+				s.saveJob(job)
+				lastSave = time.Now()
+			}
+		},
+	})
+	table.CopiedRows = copied
+	if err != nil {
+		return err
+	}
+
+	table.Status = models.AnonymizationTableStatusVerifying
+	s.saveJob(job)
+
+	verification, err := s.dbManager.VerifyAnonymizedTable(ctx, sourceChatID, targetChatID, dbTable, job.Salt)
+	if err != nil {
+		return err
+	}
+	table.Verification = &models.AnonymizationTableVerification{
+		SourceRows:     verification.SourceRows,
+		TargetRows:     verification.TargetRows,
+		CheckedValues:  verification.CheckedValues,
+		LeakedValues:   verification.LeakedValues,
+		LeakedColumns:  verification.LeakedColumns,
+		RowCountsMatch: verification.RowCountsMatch,
+		Passed:         verification.RowCountsMatch && verification.LeakedValues == 0,
+	}
+
+	completedAt := time.Now()
+	table.Status = models.AnonymizationTableStatusCompleted
+	table.CompletedAt = &completedAt
+	log.Printf("AnonymizationService -> copyTable -> Job %s copied %d rows of table %s, verification passed: %v", job.ID.Hex(), copied, table.Table, table.Verification.Passed)
+	return nil
+}
+
+// finishJob records the final status of a job & notifies the user, failure is empty when the job completed
+func (s *anonymizationService) finishJob(ctx context.Context, job *models.AnonymizationJob, failure string) {
+	completedAt := time.Now()
+	job.CompletedAt = &completedAt
+
+	switch {
+	case ctx.Err() != nil:
+		// Cancelled by the user
+		job.Status = models.AnonymizationJobStatusCancelled
+		s.saveJob(job)
+		log.Printf("AnonymizationService -> finishJob -> Job %s cancelled", job.ID.Hex())
+		return
+	case failure != "":
+		job.Status = models.AnonymizationJobStatusFailed
+		job.Error = &failure
+		log.Printf("AnonymizationService -> finishJob -> Job %s failed: %s", job.ID.Hex(), failure)
+	default:
+		job.Status = models.AnonymizationJobStatusCompleted
+		log.Printf("AnonymizationService -> finishJob -> Job %s completed", job.ID.Hex())
+	}
+	s.saveJob(job)
+
+	s.notificationService.Notify(job.UserID.Hex(), job.TargetChatID.Hex(), models.NotificationTypeReportReady,
+		fmt.Sprintf("Anonymization job %s", job.Status),
+		"The verification report of the anonymized copy is ready",
+		map[string]interface{}{"job_id": job.ID.Hex(), "source_chat_id": job.SourceChatID.Hex(), "target_chat_id": job.TargetChatID.Hex()})
+}
+
+func (s *anonymizationService) ensureConnected(ctx context.Context, job *models.AnonymizationJob, chatID string) error {
+	if s.dbManager.IsConnected(chatID) {
+		return nil
+	}
+	if _, err := s.chatService.ConnectDB(ctx, job.UserID.Hex(), chatID, anonymizationStreamIDPrefix+job.ID.Hex()); err != nil {
+		return err
+	}
+	// Give a small delay for connection to stabilize
+	time.Sleep(1 * time.Second)
+	return nil
+}
+
+func (s *anonymizationService) saveJob(job *models.AnonymizationJob) {
+	if err := s.jobRepo.Update(job); err != nil {
+		log.Printf("AnonymizationService -> saveJob -> Failed to save job %s: %v", job.ID.Hex(), err)
+	}
+}
+
+func (s *anonymizationService) findJob(userID, jobID string) (*models.AnonymizationJob, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+
+	jobObjID, err := primitive.ObjectIDFromHex(jobID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_JOB_ID", "invalid job ID format")
+	}
+
+	job, err := s.jobRepo.FindByID(jobObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_ANONYMIZATION_JOB", "failed to fetch anonymization job: {error}").With("error", err)
+	}
+	if job == nil || job.UserID != userObjID {
+		return nil, http.StatusNotFound, apperrors.New("ANONYMIZATION_JOB_NOT_FOUND", "anonymization job not found")
+	}
+	return job, http.StatusOK, nil
+}
+
+func (s *anonymizationService) verifyChatOwnership(userID, chatID string) (*models.Chat, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+
+	chatObjID, err := primitive.ObjectIDFromHex(chatID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_CHAT_ID", "invalid chat ID format")
+	}
+
+	chat, err := s.chatRepo.FindByID(chatObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CHAT", "failed to fetch chat: {error}").With("error", err)
+	}
+	if chat == nil {
+		return nil, http.StatusNotFound, apperrors.New("CHAT_NOT_FOUND", "chat not found")
+	}
+	if chat.UserID != userObjID {
+		return nil, http.StatusForbidden, apperrors.New("CHAT_ACCESS_DENIED", "chat does not belong to user")
+	}
+	return chat, http.StatusOK, nil
+}
+
+// sameAnonymizationDatabase reports whether two chats use the same saved connection or the same database of a server. Unknown
+// ports match any port & loopback hosts match each other, a doubt refuses the job.
+func sameAnonymizationDatabase(source, target *models.Chat) bool {
+	if source.ConnectionID != nil && target.ConnectionID != nil && *source.ConnectionID == *target.ConnectionID {
+		return true
+	}
+	return dbmanager.SameDatabase(
+		dbmanager.ConnectionConfig{Host: source.Connection.Host, Port: source.Connection.Port, SocketPath: source.Connection.SocketPath, Database: source.Connection.Database},
+		dbmanager.ConnectionConfig{Host: target.Connection.Host, Port: target.Connection.Port, SocketPath: target.Connection.SocketPath, Database: target.Connection.Database},
+	)
+}
+
+func toDBManagerAnonymizationTable(table models.AnonymizationTable) dbmanager.AnonymizationTable {
+	rules := make([]dbmanager.MaskingRule, 0, len(table.Rules))
+	for _, rule := range table.Rules {
+		rules = append(rules, dbmanager.MaskingRule{Column: rule.Column, Strategy: rule.Strategy, Value: rule.Value})
+	}
+	return dbmanager.AnonymizationTable{Table: table.Table, Rules: rules}
+}
+
+func countMaskedColumns(rules []models.MaskingRule) int {
+	count := 0
+	for _, rule := range rules {
+		if rule.Strategy != dbmanager.MaskStrategyKeep {
+			count++
+		}
+	}
+	return count
+}
+
+// anonymizationProgress returns the percentage of copied rows
+func anonymizationProgress(copied, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	progress := float64(copied) * 100 / float64(total)
+	if progress > 100 {
+		// Rows inserted in the source during the copy
+		progress = 100
+	}
+	return progress
+}
+
+func buildAnonymizationJobResponse(job *models.AnonymizationJob) *dtos.AnonymizationJobResponse {
+	formatTime := func(t *time.Time) *string {
+		if t == nil {
+			return nil
+		}
+		formatted := t.Format(time.RFC3339)
+		return &formatted
+	}
+
+	var totalRows, copiedRows int64
+	tables := make([]dtos.AnonymizationTableResponse, 0, len(job.Tables))
+	for _, table := range job.Tables {
+		rules := make([]dtos.MaskingRuleResponse, 0, len(table.Rules))
+		for _, rule := range table.Rules {
+			rules = append(rules, dtos.MaskingRuleResponse{Column: rule.Column, Strategy: rule.Strategy, Value: rule.Value})
+		}
+
+		var verification *dtos.AnonymizationVerificationResponse
+		if table.Verification != nil {
+			verification = &dtos.AnonymizationVerificationResponse{
+				SourceRows:     table.Verification.SourceRows,
+				TargetRows:     table.Verification.TargetRows,
+				CheckedValues:  table.Verification.CheckedValues,
+				LeakedValues:   table.Verification.LeakedValues,
+				LeakedColumns:  table.Verification.LeakedColumns,
+				RowCountsMatch: table.Verification.RowCountsMatch,
+				Passed:         table.Verification.Passed,
+			}
+		}
+
+		totalRows += table.TotalRows
+		copiedRows += table.CopiedRows
+		tables = append(tables, dtos.AnonymizationTableResponse{
+			Table:        table.Table,
+			Rules:        rules,
+			Status:       table.Status,
+			TotalRows:    table.TotalRows,
+			CopiedRows:   table.CopiedRows,
+			Progress:     anonymizationProgress(table.CopiedRows, table.TotalRows),
+			Error:        table.Error,
+			Verification: verification,
+			StartedAt:    formatTime(table.StartedAt),
+			CompletedAt:  formatTime(table.CompletedAt),
+		})
+	}
+
+	return &dtos.AnonymizationJobResponse{
+		ID:           job.ID.Hex(),
+		SourceChatID: job.SourceChatID.Hex(),
+		TargetChatID: job.TargetChatID.Hex(),
+		Status:       job.Status,
+		CurrentTable: job.CurrentTable,
+		ChunkSize:    job.ChunkSize,
+		ClearTarget:  job.ClearTarget,
+		Tables:       tables,
+		Progress:     anonymizationProgress(copiedRows, totalRows),
+		Error:        job.Error,
+		StartedAt:    job.CreatedAt.Format(time.RFC3339),
+		CompletedAt:  formatTime(job.CompletedAt),
+	}
+}
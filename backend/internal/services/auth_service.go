@@ -1,11 +1,10 @@
 package services
 
 import (
-	"errors"
-	"fmt"
 	"log"
 	"neobase-ai/config"
 	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
 	"neobase-ai/internal/models"
 	"neobase-ai/internal/repositories"
 	"neobase-ai/internal/utils"
@@ -44,7 +43,7 @@ func (s *authService) SetChatService(chatService ChatService) {
 
 func (s *authService) Signup(req *dtos.SignupRequest) (*dtos.AuthResponse, uint, error) {
 	if req.Username == config.Env.AdminUser {
-		return nil, http.StatusBadRequest, errors.New("username already exists")
+		return nil, http.StatusBadRequest, apperrors.New("USERNAME_ALREADY_EXISTS", "username already exists")
 	}
 
 	if config.Env.Environment == "DEVELOPMENT" {
@@ -52,7 +51,7 @@ func (s *authService) Signup(req *dtos.SignupRequest) (*dtos.AuthResponse, uint,
 	} else {
 		validUserSignupSecret := s.userRepo.ValidateUserSignupSecret(req.UserSignupSecret)
 		if !validUserSignupSecret {
-			return nil, http.StatusUnauthorized, errors.New("invalid user signup secret")
+			return nil, http.StatusUnauthorized, apperrors.New("INVALID_USER_SIGNUP_SECRET", "invalid user signup secret")
 		}
 	}
 	existingUser, err := s.userRepo.FindByUsername(req.Username)
@@ -60,7 +59,7 @@ func (s *authService) Signup(req *dtos.SignupRequest) (*dtos.AuthResponse, uint,
 		return nil, http.StatusNotFound, err
 	}
 	if existingUser != nil {
-		return nil, http.StatusBadRequest, errors.New("username already exists")
+		return nil, http.StatusBadRequest, apperrors.New("USERNAME_ALREADY_EXISTS", "username already exists")
 	}
 
 	// Hash password
@@ -113,7 +112,7 @@ func (s *authService) Signup(req *dtos.SignupRequest) (*dtos.AuthResponse, uint,
 	// Create a default chat for the user in development mode
 	if config.Env.Environment == "DEVELOPMENT" {
 		chat, _, err := s.chatService.CreateWithoutConnectionPing(user.ID.Hex(), &dtos.CreateChatRequest{
-			Connection: dtos.CreateConnectionRequest{
+			Connection: &dtos.CreateConnectionRequest{
 				Type:     config.Env.ExampleDatabaseType,
 				Host:     config.Env.ExampleDatabaseHost,
 				Port:     utils.ToStringPtr(config.Env.ExampleDatabasePort),
@@ -148,7 +147,7 @@ func (s *authService) Login(req *dtos.LoginRequest) (*dtos.AuthResponse, uint, e
 	if req.Username == config.Env.AdminUser {
 		log.Println("Admin User Login")
 		if req.Password != config.Env.AdminPassword {
-			return nil, http.StatusUnauthorized, errors.New("invalid password")
+			return nil, http.StatusUnauthorized, apperrors.New("INVALID_PASSWORD", "invalid password")
 		}
 		user, err := s.userRepo.FindByUsername(req.Username)
 		// Checking if Admin user exists in the DB, if not then create user for admin creds
@@ -184,12 +183,12 @@ func (s *authService) Login(req *dtos.LoginRequest) (*dtos.AuthResponse, uint, e
 		}
 		if authUser == nil {
 			log.Println("User not found")
-			return nil, http.StatusUnauthorized, errors.New("invalid credentials")
+			return nil, http.StatusUnauthorized, apperrors.New("INVALID_CREDENTIALS", "invalid credentials")
 		}
 
 		if !utils.CheckPasswordHash(req.Password, authUser.Password) {
 			log.Println("Invalid credentials")
-			return nil, http.StatusUnauthorized, errors.New("invalid credentials")
+			return nil, http.StatusUnauthorized, apperrors.New("INVALID_CREDENTIALS", "invalid credentials")
 		}
 	}
 	accessToken, err := s.jwtService.GenerateToken(authUser.ID.Hex())
@@ -230,13 +229,13 @@ func (s *authService) RefreshToken(refreshToken string) (*dtos.RefreshTokenRespo
 	// Validate the refresh token
 	claims, err := s.jwtService.ValidateToken(refreshToken)
 	if err != nil {
-		return nil, http.StatusUnauthorized, fmt.Errorf("invalid refresh token")
+		return nil, http.StatusUnauthorized, apperrors.New("INVALID_REFRESH_TOKEN", "invalid refresh token")
 	}
 
 	log.Println("Validating refresh token:", refreshToken)
 	// Check if the refresh token exists in Redis
 	if !s.tokenRepo.ValidateRefreshToken(*claims, refreshToken) {
-		return nil, http.StatusUnauthorized, fmt.Errorf("refresh token not found")
+		return nil, http.StatusUnauthorized, apperrors.New("REFRESH_TOKEN_NOT_FOUND", "refresh token not found")
 	}
 
 	// Generate new tokens
@@ -254,7 +253,7 @@ func (s *authService) Logout(refreshToken string, accessToken string) (uint32, e
 	// Validate the refresh token
 	claims, err := s.jwtService.ValidateToken(refreshToken)
 	if err != nil {
-		return http.StatusUnauthorized, fmt.Errorf("invalid refresh token")
+		return http.StatusUnauthorized, apperrors.New("INVALID_REFRESH_TOKEN", "invalid refresh token")
 	}
 
 	// Delete the refresh token from Redis
@@ -265,7 +264,7 @@ func (s *authService) Logout(refreshToken string, accessToken string) (uint32, e
 	// Blacklist the access token until its original expiration
 	_, err = s.jwtService.ValidateToken(accessToken)
 	if err != nil {
-		return http.StatusUnauthorized, fmt.Errorf("invalid access token")
+		return http.StatusUnauthorized, apperrors.New("INVALID_ACCESS_TOKEN", "invalid access token")
 	}
 
 	if err := s.tokenRepo.BlacklistToken(accessToken, time.Duration(config.Env.JWTExpirationMilliseconds)); err != nil {
@@ -281,7 +280,7 @@ func (s *authService) GetUser(userID string) (*models.User, uint, error) {
 		return nil, http.StatusNotFound, err
 	}
 	if user == nil {
-		return nil, http.StatusNotFound, errors.New("user not found")
+		return nil, http.StatusNotFound, apperrors.New("USER_NOT_FOUND", "user not found")
 	}
 
 	return user, http.StatusOK, nil
@@ -0,0 +1,258 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"neobase-ai/config"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/models"
+	"neobase-ai/internal/repositories"
+	"neobase-ai/internal/utils"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type BookmarkService interface {
+	Create(userID, chatID string, req *dtos.CreateBookmarkRequest) (*dtos.BookmarkResponse, uint32, error)
+	List(userID, chatID string, page, pageSize int) (*dtos.BookmarkListResponse, uint32, error)
+	Delete(userID, chatID, bookmarkID string) (uint32, error)
+	GetByShareToken(userID, token string) (*dtos.BookmarkResponse, uint32, error)
+}
+
+type bookmarkService struct {
+	bookmarkRepo     repositories.BookmarkRepository
+	chatRepo         repositories.ChatRepository
+	organizationRepo repositories.OrganizationRepository
+}
+
+func NewBookmarkService(bookmarkRepo repositories.BookmarkRepository, chatRepo repositories.ChatRepository, organizationRepo repositories.OrganizationRepository) BookmarkService {
+	return &bookmarkService{
+		bookmarkRepo:     bookmarkRepo,
+		chatRepo:         chatRepo,
+		organizationRepo: organizationRepo,
+	}
+}
+
+// Create bookmarks a query execution with its result snapshot & generates a share token for the deep link
+func (s *bookmarkService) Create(userID, chatID string, req *dtos.CreateBookmarkRequest) (*dtos.BookmarkResponse, uint32, error) {
+	log.Printf("BookmarkService -> Create -> userID: %s, chatID: %s, messageID: %s, queryID: %s", userID, chatID, req.MessageID, req.QueryID)
+
+	chat, statusCode, err := s.verifyChatOwnership(userID, chatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	msgObjID, err := primitive.ObjectIDFromHex(req.MessageID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_MESSAGE_ID", "invalid message ID format")
+	}
+
+	queryObjID, err := primitive.ObjectIDFromHex(req.QueryID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_QUERY_ID", "invalid query ID format")
+	}
+
+	msg, err := s.chatRepo.FindMessageByID(msgObjID)
+	if err != nil {
+		return nil, http.StatusNotFound, apperrors.New("MESSAGE_NOT_FOUND", "message not found")
+	}
+	if msg.ChatID != chat.ID {
+		return nil, http.StatusForbidden, apperrors.New("MESSAGE_NOT_IN_CHAT", "message does not belong to this chat")
+	}
+
+	var query *models.Query
+	if msg.Queries != nil {
+		for i := range *msg.Queries {
+			if (*msg.Queries)[i].ID == queryObjID {
+				query = &(*msg.Queries)[i]
+				break
+			}
+		}
+	}
+	if query == nil {
+		return nil, http.StatusNotFound, apperrors.New("QUERY_NOT_FOUND_IN_MESSAGE", "query not found in message")
+	}
+
+	bookmark := models.NewQueryBookmark(chat.UserID, chat.ID, msg.ID, query.ID, strings.TrimSpace(req.Title), utils.GenerateSecret())
+	bookmark.Note = req.Note
+	bookmark.Query = query.Query
+	bookmark.QueryType = query.QueryType
+	bookmark.Tables = query.Tables
+	bookmark.Parameters = req.Parameters
+	bookmark.ResultSnapshot = query.ExecutionResult
+	bookmark.ExecutionTime = query.ExecutionTime
+	if query.Pagination != nil {
+		bookmark.TotalRecordsCount = query.Pagination.TotalRecordsCount
+	}
+	if req.ExpiresInHours != nil {
+		if *req.ExpiresInHours <= 0 {
+			return nil, http.StatusBadRequest, apperrors.New("INVALID_EXPIRES_IN_HOURS", "expires_in_hours must be positive")
+		}
+		expiresAt := time.Now().Add(time.Duration(*req.ExpiresInHours) * time.Hour)
+		bookmark.ExpiresAt = &expiresAt
+	}
+
+	if err := s.bookmarkRepo.Create(bookmark); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_CREATE_BOOKMARK", "failed to create bookmark: {error}").With("error", err)
+	}
+
+	return s.buildBookmarkResponse(bookmark), http.StatusCreated, nil
+}
+
+// List returns the bookmarks of a chat
+func (s *bookmarkService) List(userID, chatID string, page, pageSize int) (*dtos.BookmarkListResponse, uint32, error) {
+	chat, statusCode, err := s.verifyChatOwnership(userID, chatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	bookmarks, total, err := s.bookmarkRepo.FindByChatID(chat.ID, page, pageSize)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_BOOKMARKS", "failed to fetch bookmarks: {error}").With("error", err)
+	}
+
+	response := &dtos.BookmarkListResponse{
+		Bookmarks: make([]dtos.BookmarkResponse, 0, len(bookmarks)),
+		Total:     total,
+	}
+	for _, bookmark := range bookmarks {
+		response.Bookmarks = append(response.Bookmarks, *s.buildBookmarkResponse(bookmark))
+	}
+	return response, http.StatusOK, nil
+}
+
+// Delete removes a bookmark, this also revokes its deep link
+func (s *bookmarkService) Delete(userID, chatID, bookmarkID string) (uint32, error) {
+	chat, statusCode, err := s.verifyChatOwnership(userID, chatID)
+	if err != nil {
+		return statusCode, err
+	}
+
+	bookmarkObjID, err := primitive.ObjectIDFromHex(bookmarkID)
+	if err != nil {
+		return http.StatusBadRequest, apperrors.New("INVALID_BOOKMARK_ID", "invalid bookmark ID format")
+	}
+
+	bookmark, err := s.bookmarkRepo.FindByID(bookmarkObjID)
+	if err != nil {
+		return http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_BOOKMARK", "failed to fetch bookmark: {error}").With("error", err)
+	}
+	if bookmark == nil || bookmark.ChatID != chat.ID {
+		return http.StatusNotFound, apperrors.New("BOOKMARK_NOT_FOUND", "bookmark not found")
+	}
+
+	if err := s.bookmarkRepo.Delete(bookmarkObjID); err != nil {
+		return http.StatusInternalServerError, apperrors.New("FAILED_TO_DELETE_BOOKMARK", "failed to delete bookmark: {error}").With("error", err)
+	}
+	return http.StatusOK, nil
+}
+
+// GetByShareToken opens a bookmark through its deep link token for the owner of its chat & the members of their organization,
+// the token alone doesn't grant access to the snapshot
+func (s *bookmarkService) GetByShareToken(userID, token string) (*dtos.BookmarkResponse, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+
+	bookmark, err := s.bookmarkRepo.FindByShareToken(token)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_BOOKMARK", "failed to fetch bookmark: {error}").With("error", err)
+	}
+	if bookmark == nil {
+		return nil, http.StatusNotFound, apperrors.New("BOOKMARK_NOT_FOUND", "bookmark not found")
+	}
+	if bookmark.IsExpired() {
+		return nil, http.StatusGone, apperrors.New("BOOKMARK_LINK_EXPIRED", "bookmark link has expired")
+	}
+	if statusCode, err := s.verifyBookmarkAccess(userObjID, bookmark); err != nil {
+		return nil, statusCode, err
+	}
+
+	return s.buildBookmarkResponse(bookmark), http.StatusOK, nil
+}
+
+// verifyBookmarkAccess checks the user is the owner of the bookmark's chat or a member of the owner's organization
+func (s *bookmarkService) verifyBookmarkAccess(userID primitive.ObjectID, bookmark *models.QueryBookmark) (uint32, error) {
+	if bookmark.UserID == userID {
+		return http.StatusOK, nil
+	}
+
+	organization, err := s.organizationRepo.FindByMemberID(bookmark.UserID)
+	if err != nil {
+		return http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_ORGANIZATION", "failed to fetch organization: {error}").With("error", err)
+	}
+	if organization != nil {
+		for _, memberID := range organization.MemberIDs {
+			if memberID == userID {
+				return http.StatusOK, nil
+			}
+		}
+	}
+	return http.StatusForbidden, apperrors.New("BOOKMARK_ACCESS_DENIED", "bookmark is only shared with the organization of its chat")
+}
+
+func (s *bookmarkService) verifyChatOwnership(userID, chatID string) (*models.Chat, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+
+	chatObjID, err := primitive.ObjectIDFromHex(chatID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_CHAT_ID", "invalid chat ID format")
+	}
+
+	chat, err := s.chatRepo.FindByID(chatObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CHAT", "failed to fetch chat: {error}").With("error", err)
+	}
+	if chat == nil {
+		return nil, http.StatusNotFound, apperrors.New("CHAT_NOT_FOUND", "chat not found")
+	}
+	if chat.UserID != userObjID {
+		return nil, http.StatusForbidden, apperrors.New("CHAT_ACCESS_DENIED", "chat does not belong to user")
+	}
+	return chat, http.StatusOK, nil
+}
+
+func (s *bookmarkService) buildBookmarkResponse(bookmark *models.QueryBookmark) *dtos.BookmarkResponse {
+	var resultSnapshot interface{}
+	if bookmark.ResultSnapshot != nil {
+		if err := json.Unmarshal([]byte(*bookmark.ResultSnapshot), &resultSnapshot); err != nil {
+			log.Printf("BookmarkService -> buildBookmarkResponse -> Error unmarshalling result snapshot: %v", err)
+			resultSnapshot = *bookmark.ResultSnapshot
+		}
+	}
+
+	var expiresAt *string
+	if bookmark.ExpiresAt != nil {
+		formatted := bookmark.ExpiresAt.Format(time.RFC3339)
+		expiresAt = &formatted
+	}
+
+	return &dtos.BookmarkResponse{
+		ID:                bookmark.ID.Hex(),
+		ChatID:            bookmark.ChatID.Hex(),
+		MessageID:         bookmark.MessageID.Hex(),
+		QueryID:           bookmark.QueryID.Hex(),
+		Title:             bookmark.Title,
+		Note:              bookmark.Note,
+		Query:             bookmark.Query,
+		QueryType:         bookmark.QueryType,
+		Tables:            bookmark.Tables,
+		Parameters:        bookmark.Parameters,
+		ResultSnapshot:    resultSnapshot,
+		TotalRecordsCount: bookmark.TotalRecordsCount,
+		ExecutionTime:     bookmark.ExecutionTime,
+		ShareToken:        bookmark.ShareToken,
+		DeepLink:          fmt.Sprintf("%s/bookmarks/%s", strings.TrimRight(config.Env.CorsAllowedOrigin, "/"), bookmark.ShareToken),
+		ExpiresAt:         expiresAt,
+		CreatedAt:         bookmark.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"neobase-ai/internal/models"
+	"neobase-ai/internal/repositories"
+	"net/http"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fakeBookmarkRepository struct {
+	repositories.BookmarkRepository
+	bookmarks map[string]*models.QueryBookmark
+}
+
+func (r *fakeBookmarkRepository) FindByShareToken(token string) (*models.QueryBookmark, error) {
+	return r.bookmarks[token], nil
+}
+
+type fakeOrganizationRepository struct {
+	repositories.OrganizationRepository
+	organizations []*models.Organization
+}
+
+func (r *fakeOrganizationRepository) FindByMemberID(userID primitive.ObjectID) (*models.Organization, error) {
+	for _, organization := range r.organizations {
+		for _, memberID := range organization.MemberIDs {
+			if memberID == userID {
+				return organization, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func TestGetByShareTokenAccess(t *testing.T) {
+	owner, teammate, outsider, loner := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	shared := models.NewQueryBookmark(owner, primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), "Revenue", "shared-token")
+	private := models.NewQueryBookmark(loner, primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), "Notes", "private-token")
+
+	service := NewBookmarkService(
+		&fakeBookmarkRepository{bookmarks: map[string]*models.QueryBookmark{"shared-token": shared, "private-token": private}},
+		nil,
+		&fakeOrganizationRepository{organizations: []*models.Organization{
+			models.NewOrganization("Acme", []primitive.ObjectID{owner, teammate}),
+			models.NewOrganization("Other", []primitive.ObjectID{outsider}),
+		}},
+	)
+
+	tests := []struct {
+		name   string
+		userID primitive.ObjectID
+		token  string
+		want   uint32
+	}{
+		{"owner", owner, "shared-token", http.StatusOK},
+		{"teammate", teammate, "shared-token", http.StatusOK},
+		{"user outside the organization", outsider, "shared-token", http.StatusForbidden},
+		{"owner without an organization", loner, "private-token", http.StatusOK},
+		{"user when the owner has no organization", teammate, "private-token", http.StatusForbidden},
+		{"unknown token", owner, "missing-token", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, statusCode, err := service.GetByShareToken(tt.userID.Hex(), tt.token)
+			if statusCode != tt.want {
+				t.Fatalf("GetByShareToken() status = %d, %v, want %d", statusCode, err, tt.want)
+			}
+			if tt.want != http.StatusOK && response != nil {
+				t.Errorf("GetByShareToken() returned the bookmark to a refused user")
+			}
+		})
+	}
+}
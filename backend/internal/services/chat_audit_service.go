@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/models"
+	"net/http"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetAuditStatus returns the audit triggers installed by NeoBase on the chat's database
+func (s *chatService) GetAuditStatus(ctx context.Context, userID, chatID string) (*dtos.AuditStatusResponse, uint32, error) {
+	log.Printf("ChatService -> GetAuditStatus -> Starting for chatID: %s", chatID)
+
+	if _, status, err := s.getConnectedChat(ctx, userID, chatID); err != nil {
+		return nil, status, err
+	}
+
+	return s.buildAuditStatusResponse(ctx, chatID)
+}
+
+// InstallAuditTriggers installs audit triggers on the requested tables, the already audited tables are refreshed when none are given
+func (s *chatService) InstallAuditTriggers(ctx context.Context, userID, chatID string, req *dtos.InstallAuditRequest) (*dtos.AuditStatusResponse, uint32, error) {
+	log.Printf("ChatService -> InstallAuditTriggers -> Starting for chatID: %s", chatID)
+
+	if _, status, err := s.getConnectedChat(ctx, userID, chatID); err != nil {
+		return nil, status, err
+	}
+
+	tables := []string{}
+	for _, table := range req.Tables {
+		if table = strings.TrimSpace(table); table != "" {
+			tables = append(tables, table)
+		}
+	}
+	if len(tables) == 0 {
+		auditStatus, err := s.dbManager.GetAuditStatus(ctx, chatID)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		tables = auditStatus.AuditedTables
+	}
+	if len(tables) == 0 {
+		return nil, http.StatusBadRequest, apperrors.New("NO_TABLES_TO_AUDIT", "no tables to audit")
+	}
+
+	if _, err := s.dbManager.InstallAuditTriggers(ctx, chatID, tables); err != nil {
+		log.Printf("ChatService -> InstallAuditTriggers -> Error installing audit triggers: %v", err)
+		return nil, http.StatusBadRequest, err
+	}
+
+	return s.buildAuditStatusResponse(ctx, chatID)
+}
+
+// RemoveAuditTriggers removes the audit instrumentation from the chat's database & disables auditing for the chat
+func (s *chatService) RemoveAuditTriggers(ctx context.Context, userID, chatID string, dropLog bool) (*dtos.AuditStatusResponse, uint32, error) {
+	log.Printf("ChatService -> RemoveAuditTriggers -> Starting for chatID: %s, dropLog: %v", chatID, dropLog)
+
+	chat, status, err := s.getConnectedChat(ctx, userID, chatID)
+	if err != nil {
+		return nil, status, err
+	}
+
+	if _, err := s.dbManager.RemoveAuditTriggers(ctx, chatID, dropLog); err != nil {
+		log.Printf("ChatService -> RemoveAuditTriggers -> Error removing audit triggers: %v", err)
+		return nil, http.StatusBadRequest, err
+	}
+
+	// Changes would no longer be recorded, so the setting is turned off as well
+	if chat.Settings.AuditChanges {
+		chat.Settings.AuditChanges = false
+		if err := s.chatRepo.Update(chat.ID, chat); err != nil {
+			return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_UPDATE_CHAT", "failed to update chat: {error}").With("error", err)
+		}
+		s.dbManager.SetAuditChanges(chatID, false)
+	}
+
+	return s.buildAuditStatusResponse(ctx, chatID)
+}
+
+// getConnectedChat fetches the user's chat & makes sure its database is connected
+func (s *chatService) getConnectedChat(ctx context.Context, userID, chatID string) (*models.Chat, uint32, error) {
+	chatObjID, err := primitive.ObjectIDFromHex(chatID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_CHAT_ID", "invalid chat ID format")
+	}
+
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+
+	chat, err := s.chatRepo.FindByID(chatObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CHAT", "failed to fetch chat: {error}").With("error", err)
+	}
+	if chat == nil {
+		return nil, http.StatusNotFound, apperrors.New("CHAT_NOT_FOUND", "chat not found")
+	}
+	if chat.UserID != userObjID {
+		return nil, http.StatusForbidden, apperrors.New("CHAT_ACCESS_DENIED", "chat does not belong to user")
+	}
+
+	if !s.dbManager.IsConnected(chatID) {
+		log.Printf("ChatService -> getConnectedChat -> Database not connected, initiating connection")
+		if status, err := s.ConnectDB(ctx, userID, chatID, ""); err != nil {
+			return nil, status, err
+		}
+	}
+
+	return chat, http.StatusOK, nil
+}
+
+// buildAuditStatusResponse reads the current audit status of the chat's database
+func (s *chatService) buildAuditStatusResponse(ctx context.Context, chatID string) (*dtos.AuditStatusResponse, uint32, error) {
+	auditStatus, err := s.dbManager.GetAuditStatus(ctx, chatID)
+	if err != nil {
+		log.Printf("ChatService -> buildAuditStatusResponse -> Error getting audit status: %v", err)
+		return nil, http.StatusBadRequest, err
+	}
+
+	return &dtos.AuditStatusResponse{
+		Supported:      auditStatus.Supported,
+		Enabled:        auditStatus.Enabled,
+		LogTable:       auditStatus.LogTable,
+		LogTableExists: auditStatus.LogTableExists,
+		AuditedTables:  auditStatus.AuditedTables,
+	}, http.StatusOK, nil
+}
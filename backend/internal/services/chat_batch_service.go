@@ -0,0 +1,315 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/models"
+	"neobase-ai/internal/utils"
+	"neobase-ai/pkg/dbmanager"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ExecuteAllQueries executes the queries of a message in order in one transaction: the first failing query stops the batch & rolls
+// back the ones before it, they are committed together otherwise. The status of every query is sent to the stream in a single
+// batch-execution-finished event.
+func (s *chatService) ExecuteAllQueries(ctx context.Context, userID, chatID string, req *dtos.ExecuteAllQueriesRequest) (*dtos.ExecuteAllQueriesResponse, uint32, error) {
+	log.Printf("ChatService -> ExecuteAllQueries -> Starting for chatID: %s, messageID: %s", chatID, req.MessageID)
+
+	chat, status, err := s.getConnectedChat(ctx, userID, chatID)
+	if err != nil {
+		return nil, status, err
+	}
+
+	msgObjID, err := primitive.ObjectIDFromHex(req.MessageID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_MESSAGE_ID", "invalid message ID format")
+	}
+	msg, err := s.chatRepo.FindMessageByID(msgObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_MESSAGE", "failed to fetch message: {error}").With("error", err)
+	}
+	if msg == nil {
+		return nil, http.StatusNotFound, apperrors.New("MESSAGE_NOT_FOUND", "message not found")
+	}
+	if msg.ChatID.Hex() != chatID {
+		return nil, http.StatusForbidden, apperrors.New("MESSAGE_NOT_IN_CHAT", "message does not belong to this chat")
+	}
+
+	queries, err := batchQueries(msg, req.QueryIDs)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if len(queries) == 0 {
+		return nil, http.StatusBadRequest, apperrors.New("NO_QUERIES_TO_EXECUTE", "the message has no queries left to execute")
+	}
+
+	batch := make([]dbmanager.BatchQuery, 0, len(queries))
+	for _, query := range queries {
+		// The guardrail is checked again, the chat may have allowed destructive queries since the query was blocked
+		if reason := dbmanager.DestructiveQueryReason(chat.Connection.Type, query.Query); reason != "" && !chat.Settings.AllowDestructiveQueries {
+			return nil, http.StatusForbidden, apperrors.New("DESTRUCTIVE_QUERY_BLOCKED", "query blocked by the guardrail: {reason}, allow destructive queries in the chat settings to execute it").With("reason", reason)
+		}
+		// The queries run with the values of their last execution
+		if names := dbmanager.QueryParameterNames(chat.Connection.Type, query.Query); len(query.Params) == 0 && len(names) > 0 {
+			return nil, http.StatusBadRequest, apperrors.New("QUERY_PARAMETERS_REQUIRED", "a query has parameters, execute it on its own with their values: {names}").With("names", strings.Join(names, ", "))
+		}
+		queryType := ""
+		if query.QueryType != nil {
+			queryType = *query.QueryType
+		}
+		batch = append(batch, dbmanager.BatchQuery{
+			QueryID:   query.ID.Hex(),
+			Query:     query.Query,
+			QueryType: queryType,
+			Params:    query.Params,
+		})
+	}
+
+	executeReq := &dtos.ExecuteQueryRequest{TimeoutSeconds: req.TimeoutSeconds}
+	displayRows, maxRows, err := resultRowLimits(chat.Connection, executeReq)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	ctx = dbmanager.WithResultRowLimit(ctx, maxRows)
+	ctx = withAutoLimit(ctx, maxRows, req.FullScan)
+	timeout, err := queryTimeout(chat.Connection, executeReq)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	ctx = dbmanager.WithQueryTimeout(ctx, timeout)
+
+	startedAt := time.Now()
+	results, queryErr := s.dbManager.ExecuteBatch(ctx, chatID, req.MessageID, req.StreamID, batch)
+	if queryErr != nil {
+		log.Printf("ChatService -> ExecuteAllQueries -> Batch not started: %+v", queryErr)
+		return nil, http.StatusBadRequest, apperrors.New(queryErr.Code, "{error}").With("error", queryErr.Message)
+	}
+
+	response := &dtos.ExecuteAllQueriesResponse{
+		ChatID:    chatID,
+		MessageID: req.MessageID,
+		Success:   true,
+		Queries:   make([]dtos.BatchQueryExecution, 0, len(results)),
+	}
+	executed := map[string]dtos.BatchQueryExecution{}
+	for i, result := range results {
+		execution := dtos.BatchQueryExecution{QueryID: result.QueryID, Status: result.Status, Error: result.Error}
+		switch result.Status {
+		case dbmanager.BatchQueryStatusSucceeded:
+			execution.IsExecuted = true
+			execution.ExecutionTime = &result.Result.ExecutionTime
+			execution.ExecutionResult, result.Result.ResultJSON = displayedResult(result.Result.ResultJSON, displayRows)
+			execution.Columns = result.Result.Columns
+			execution.ActionAt = utils.ToStringPtr(time.Now().Format(time.RFC3339))
+		case dbmanager.BatchQueryStatusFailed:
+			response.Success = false
+			execution.IsExecuted = true
+			execution.ActionAt = utils.ToStringPtr(time.Now().Format(time.RFC3339))
+		default:
+			response.Success = false
+		}
+		response.Queries = append(response.Queries, execution)
+
+		// The rolled back & skipped queries are left to be executed again
+		if execution.IsExecuted {
+			executed[result.QueryID] = execution
+			s.queryHistory.RecordExecution(userID, chat, msg.ID, queries[i].ID, queries[i].Query, queries[i].QueryType, false, startedAt, result.Result, result.Error)
+		}
+	}
+
+	s.updateBatchQueries(chat, msg, results, executed)
+	response.ActionButtons = dtos.ToActionButtonDto(msg.ActionButtons)
+
+	s.sendStreamEvent(userID, chatID, req.StreamID, dtos.StreamResponse{
+		Event: "batch-execution-finished",
+		Data:  response,
+	})
+	log.Printf("ChatService -> ExecuteAllQueries -> Batch of %d queries finished for messageID: %s, success: %v", len(results), req.MessageID, response.Success)
+	return response, http.StatusOK, nil
+}
+
+// batchQueries returns the queries of a message a batch executes: the requested ones in their order, else the ones not executed yet
+func batchQueries(msg *models.Message, queryIDs []string) ([]models.Query, error) {
+	if msg.Queries == nil {
+		return nil, nil
+	}
+	if len(queryIDs) == 0 {
+		queries := []models.Query{}
+		for _, query := range *msg.Queries {
+			if !query.IsExecuted || query.IsRolledBack {
+				queries = append(queries, query)
+			}
+		}
+		return queries, nil
+	}
+
+	queries := make([]models.Query, 0, len(queryIDs))
+	for _, queryID := range queryIDs {
+		found := false
+		for _, query := range *msg.Queries {
+			if query.ID.Hex() == queryID {
+				queries = append(queries, query)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, apperrors.New("QUERY_NOT_FOUND", "query {queryID} not found in the message").With("queryID", queryID)
+		}
+	}
+	return queries, nil
+}
+
+// displayedResult caps the rows of a result to the rows shown, returns the rows shown & the capped result JSON stored
+func displayedResult(resultJSON string, displayRows int) (interface{}, string) {
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(resultJSON), &decoded); err != nil {
+		return nil, resultJSON
+	}
+
+	switch v := decoded.(type) {
+	case []interface{}:
+		if len(v) <= displayRows {
+			return v, resultJSON
+		}
+		decoded = v[:displayRows]
+	case map[string]interface{}:
+		rows, ok := v["results"].([]interface{})
+		if !ok || len(rows) <= displayRows {
+			return v, resultJSON
+		}
+		// The truncation reported by the driver is kept along the rows
+		v["results"] = rows[:displayRows]
+	default:
+		return decoded, resultJSON
+	}
+
+	capped, err := json.Marshal(decoded)
+	if err != nil {
+		log.Printf("ChatService -> displayedResult -> Error marshaling capped results: %v", err)
+		return decoded, resultJSON
+	}
+	return decoded, string(capped)
+}
+
+// updateBatchQueries saves the executions of a batch in the message & in the queries of its LLM message
+func (s *chatService) updateBatchQueries(chat *models.Chat, msg *models.Message, results []dbmanager.BatchQueryResult, executed map[string]dtos.BatchQueryExecution) {
+	failed := false
+	for _, result := range results {
+		if msg.Queries == nil {
+			break
+		}
+		execution, ok := executed[result.QueryID]
+		if !ok {
+			continue
+		}
+		for i := range *msg.Queries {
+			query := &(*msg.Queries)[i]
+			if query.ID.Hex() != result.QueryID {
+				continue
+			}
+			query.IsExecuted = true
+			query.IsRolledBack = false
+			query.ActionAt = execution.ActionAt
+			query.ExecutionTime = execution.ExecutionTime
+			if result.Error != nil {
+				failed = true
+				query.Error = &models.QueryError{
+					Code:    result.Error.Code,
+					Message: result.Error.Message,
+					Details: result.Error.Details,
+				}
+				break
+			}
+			query.Error = nil
+			query.ExecutionResult = &result.Result.ResultJSON
+			query.ResultColumns = dtos.ToResultColumnsModel(result.Result.Columns)
+			// A collMod only knows the options it replaced once executed, its rollback restores them
+			executedRollback, hasExecutedRollback := dbmanager.MongoDBExecutedRollback(result.Result.Result)
+			// A script undoable statement by statement is rolled back by its combined plan rather than the one written with it
+			if scriptRollback, ok := dbmanager.ScriptExecutedRollback(result.Result.Result); ok {
+				executedRollback, hasExecutedRollback = scriptRollback, true
+			}
+			if hasExecutedRollback {
+				query.RollbackQuery = &executedRollback
+				query.CanRollback = true
+			}
+			break
+		}
+	}
+
+	if failed {
+		s.addFixErrorButton(msg)
+	} else {
+		s.removeFixErrorButton(msg)
+	}
+	if err := s.chatRepo.UpdateMessage(msg.ID, msg); err != nil {
+		log.Printf("ChatService -> updateBatchQueries -> Error updating message: %v", err)
+	}
+
+	// Update LLM message with query execution results
+	llmMsg, err := s.llmRepo.FindMessageByChatMessageID(msg.ID)
+	if err != nil || llmMsg == nil {
+		log.Printf("ChatService -> updateBatchQueries -> Error finding LLM message: %v", err)
+		return
+	}
+	assistantResponse, ok := llmMsg.Content["assistant_response"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	var llmQueries []interface{}
+	switch queriesVal := assistantResponse["queries"].(type) {
+	case primitive.A:
+		llmQueries = []interface{}(queriesVal)
+	case []interface{}:
+		llmQueries = queriesVal
+	default:
+		return
+	}
+
+	for _, query := range *msg.Queries {
+		execution, ok := executed[query.ID.Hex()]
+		if !ok {
+			continue
+		}
+		for _, q := range llmQueries {
+			queryMap, ok := q.(map[string]interface{})
+			if !ok || queryMap["query"] != query.Query || query.QueryType == nil || queryMap["queryType"] != *query.QueryType || queryMap["explanation"] != query.Description {
+				continue
+			}
+			queryMap["isExecuted"] = true
+			queryMap["isRolledBack"] = false
+			queryMap["executionTime"] = execution.ExecutionTime
+			queryMap["actionAt"] = execution.ActionAt
+			queryMap["error"] = nil
+			if execution.Error != nil {
+				queryMap["error"] = map[string]interface{}{
+					"code":    execution.Error.Code,
+					"message": execution.Error.Message,
+					"details": execution.Error.Details,
+				}
+			} else if chat.Settings.ShareDataWithAI && query.ExecutionResult != nil {
+				// If share data with AI is true, then we need to share the result with AI
+				queryMap["executionResult"] = map[string]interface{}{
+					"result": *query.ExecutionResult,
+				}
+			} else {
+				queryMap["executionResult"] = map[string]interface{}{
+					"result": "Query executed successfully",
+				}
+			}
+		}
+	}
+	assistantResponse["queries"] = llmQueries
+	llmMsg.Content["assistant_response"] = assistantResponse
+	if err := s.llmRepo.UpdateMessage(llmMsg.ID, llmMsg); err != nil {
+		log.Printf("ChatService -> updateBatchQueries -> Error updating LLM message: %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/pkg/dbmanager"
+	"net/http"
+	"strings"
+)
+
+// BenchmarkQuery runs a read-only query of a message several times & reports its latency, a variant of the query can be
+// given to compare both. The runs are sequential on the chat's connection & not saved in the message.
+func (s *chatService) BenchmarkQuery(ctx context.Context, userID, chatID string, req *dtos.BenchmarkQueryRequest) (*dtos.BenchmarkQueryResponse, uint32, error) {
+	log.Printf("ChatService -> BenchmarkQuery -> Starting for chatID: %s, queryID: %s", chatID, req.QueryID)
+
+	if _, status, err := s.getConnectedChat(ctx, userID, chatID); err != nil {
+		return nil, status, err
+	}
+
+	_, _, query, err := s.verifyQueryOwnership(userID, chatID, req.MessageID, req.QueryID)
+	if err != nil {
+		return nil, http.StatusForbidden, err
+	}
+
+	queryToBenchmark := query.Query
+	if req.Query != nil {
+		if strings.TrimSpace(*req.Query) == "" {
+			return nil, http.StatusBadRequest, apperrors.New("QUERY_REQUIRED", "query cannot be empty")
+		}
+		queryToBenchmark = *req.Query
+	}
+	queryType := ""
+	if query.QueryType != nil {
+		queryType = *query.QueryType
+	}
+
+	opts := dbmanager.BenchmarkOptions{
+		Iterations: req.Iterations,
+		WarmUp:     dbmanager.BenchmarkDefaultWarmUp,
+	}
+	if req.WarmUp != nil {
+		opts.WarmUp = *req.WarmUp
+	}
+
+	// Replicas can lag behind the primary, the user can benchmark the primary instead
+	if req.UsePrimary {
+		ctx = dbmanager.WithPrimaryRouting(ctx)
+	}
+
+	result, queryErr := s.dbManager.BenchmarkQuery(ctx, chatID, req.StreamID, queryToBenchmark, queryType, opts)
+	if queryErr != nil {
+		log.Printf("ChatService -> BenchmarkQuery -> Benchmark failed: %+v", queryErr)
+		details := queryErr.Details
+		if details == "" {
+			details = queryErr.Message
+		}
+		return nil, http.StatusBadRequest, apperrors.New(queryErr.Code, "{error}").With("error", details)
+	}
+
+	runs := make([]dtos.BenchmarkQueryRun, len(result.Runs))
+	for i, run := range result.Runs {
+		runs[i] = dtos.BenchmarkQueryRun{
+			Iteration:  run.Iteration,
+			DurationMs: run.DurationMs,
+			Rows:       run.Rows,
+		}
+	}
+
+	return &dtos.BenchmarkQueryResponse{
+		ChatID:        chatID,
+		MessageID:     req.MessageID,
+		QueryID:       req.QueryID,
+		Query:         queryToBenchmark,
+		Iterations:    result.Iterations,
+		WarmUp:        result.WarmUp,
+		MinMs:         result.MinMs,
+		MedianMs:      result.MedianMs,
+		P95Ms:         result.P95Ms,
+		MaxMs:         result.MaxMs,
+		MeanMs:        result.MeanMs,
+		Rows:          result.Rows,
+		RowsVary:      result.RowsVary,
+		OnReadReplica: result.OnReadReplica,
+		Runs:          runs,
+	}, http.StatusOK, nil
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/pkg/dbmanager"
+	"net/http"
+	"strings"
+)
+
+// GetTableRows returns a page of rows of a table with a query built by the server, no LLM request is made
+func (s *chatService) GetTableRows(ctx context.Context, userID, chatID, table string, req *dtos.TableRowsRequest) (*dtos.TableRowsResponse, uint32, error) {
+	log.Printf("ChatService -> GetTableRows -> Starting for chatID: %s, table: %s", chatID, table)
+
+	opts := dbmanager.BrowseOptions{
+		Page:         req.Page,
+		PageSize:     req.PageSize,
+		SortBy:       strings.TrimSpace(req.SortBy),
+		IncludeTotal: req.IncludeTotal,
+	}
+
+	switch strings.ToLower(req.SortOrder) {
+	case "", "asc":
+	case "desc":
+		opts.SortDesc = true
+	default:
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_SORT_ORDER", "invalid sort order: {sort_order}").With("sort_order", req.SortOrder)
+	}
+
+	for _, rawFilter := range req.Filters {
+		filter, err := parseTableRowsFilter(rawFilter)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		opts.Filters = append(opts.Filters, filter)
+	}
+
+	if _, status, err := s.getConnectedChat(ctx, userID, chatID); err != nil {
+		return nil, status, err
+	}
+
+	result, err := s.dbManager.BrowseTable(ctx, chatID, table, opts)
+	if err != nil {
+		log.Printf("ChatService -> GetTableRows -> Error browsing table: %v", err)
+		return nil, http.StatusBadRequest, err
+	}
+
+	return &dtos.TableRowsResponse{
+		Table:         result.Table,
+		Columns:       result.Columns,
+		Rows:          result.Rows,
+		Page:          result.Page,
+		PageSize:      result.PageSize,
+		HasMore:       result.HasMore,
+		TotalCount:    result.TotalCount,
+		Query:         result.Query,
+		ExecutionTime: result.ExecutionTime,
+	}, http.StatusOK, nil
+}
+
+// parseTableRowsFilter parses a "column:operator:value" filter, the value is omitted for is_null & not_null
+func parseTableRowsFilter(rawFilter string) (dbmanager.BrowseFilter, error) {
+	parts := strings.SplitN(rawFilter, ":", 3)
+	if len(parts) < 2 || strings.TrimSpace(parts[0]) == "" {
+		return dbmanager.BrowseFilter{}, apperrors.New("INVALID_FILTER", "invalid filter \"{filter}\", expected column:operator:value").With("filter", rawFilter)
+	}
+
+	filter := dbmanager.BrowseFilter{
+		Column:   strings.TrimSpace(parts[0]),
+		Operator: strings.ToLower(strings.TrimSpace(parts[1])),
+	}
+	if len(parts) == 3 {
+		filter.Value = parts[2]
+	} else if filter.Operator != dbmanager.BrowseOpIsNull && filter.Operator != dbmanager.BrowseOpNotNull {
+		return dbmanager.BrowseFilter{}, apperrors.New("FILTER_VALUE_REQUIRED", "invalid filter \"{filter}\", a value is required for {operator}").With("filter", rawFilter).With("operator", filter.Operator)
+	}
+	return filter, nil
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/pkg/dbmanager"
+	"net/http"
+)
+
+// ChartQueryResults turns the whole result of a read-only query of a message into chart series, its rows are grouped by a column or
+// a time bucket of it & aggregated as they are read from the chat's connection, only the points are returned
+func (s *chatService) ChartQueryResults(ctx context.Context, userID, chatID string, req *dtos.ChartQueryResultsRequest) (*dtos.ChartQueryResultsResponse, uint32, error) {
+	log.Printf("ChatService -> ChartQueryResults -> Starting for chatID: %s, queryID: %s", chatID, req.QueryID)
+
+	if _, status, err := s.getConnectedChat(ctx, userID, chatID); err != nil {
+		return nil, status, err
+	}
+
+	_, _, query, err := s.verifyQueryOwnership(userID, chatID, req.MessageID, req.QueryID)
+	if err != nil {
+		return nil, http.StatusForbidden, err
+	}
+
+	// The rows are read with the values the query was executed with
+	if len(query.Params) > 0 {
+		ctx = dbmanager.WithQueryParams(ctx, query.Params)
+	}
+	// Replicas can lag behind the primary, the user can chart from the primary instead
+	if req.UsePrimary {
+		ctx = dbmanager.WithPrimaryRouting(ctx)
+	}
+
+	aggregate := req.Aggregate
+	if aggregate == "" {
+		aggregate = dbmanager.ChartAggregateCount
+		if req.YColumn != "" {
+			aggregate = dbmanager.ChartAggregateSum
+		}
+	}
+	chart, queryErr := s.dbManager.ChartQuerySeries(ctx, chatID, query.Query, dbmanager.ChartSpec{
+		XColumn:      req.XColumn,
+		YColumn:      req.YColumn,
+		SeriesColumn: req.SeriesColumn,
+		Aggregate:    aggregate,
+		TimeBucket:   req.TimeBucket,
+		MaxPoints:    req.MaxPoints,
+	})
+	if queryErr != nil {
+		log.Printf("ChatService -> ChartQueryResults -> Chart failed: %+v", queryErr)
+		details := queryErr.Details
+		if details == "" {
+			details = queryErr.Message
+		}
+		return nil, http.StatusBadRequest, apperrors.New(queryErr.Code, "{error}").With("error", details)
+	}
+
+	series := make([]dtos.ChartSeries, 0, len(chart.Series))
+	for _, chartSeries := range chart.Series {
+		points := make([]dtos.ChartPoint, 0, len(chartSeries.Points))
+		for _, point := range chartSeries.Points {
+			points = append(points, dtos.ChartPoint{X: point.X, Y: point.Y})
+		}
+		series = append(series, dtos.ChartSeries{Name: chartSeries.Name, Points: points})
+	}
+
+	log.Printf("ChatService -> ChartQueryResults -> Charted %d rows in %d series for queryID: %s", chart.Rows, len(series), req.QueryID)
+	return &dtos.ChartQueryResultsResponse{
+		ChatID:        chatID,
+		MessageID:     req.MessageID,
+		QueryID:       req.QueryID,
+		XColumn:       req.XColumn,
+		YColumn:       req.YColumn,
+		Aggregate:     aggregate,
+		TimeBucket:    req.TimeBucket,
+		Series:        series,
+		Rows:          chart.Rows,
+		Truncated:     chart.Truncated,
+		OnReadReplica: chart.OnReadReplica,
+	}, http.StatusOK, nil
+}
@@ -3,15 +3,17 @@ package services
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"neobase-ai/config"
 	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
 	"neobase-ai/internal/constants"
 	"neobase-ai/internal/models"
 	"neobase-ai/internal/repositories"
 	"neobase-ai/internal/utils"
 	"neobase-ai/pkg/dbmanager"
-	"neobase-ai/pkg/llm"
+	"neobase-ai/pkg/resultstore"
 	"net/http"
 	"sort"
 	"strconv"
@@ -26,6 +28,9 @@ import (
 // Used by Handler
 type StreamHandler interface {
 	HandleStreamEvent(userID, chatID, streamID string, response dtos.StreamResponse)
+	// DeliverStreamEvent waits until the stream takes the event instead of dropping it, an error is returned once the stream is
+	// closed or ctx is done
+	DeliverStreamEvent(ctx context.Context, userID, chatID, streamID string, response dtos.StreamResponse) error
 }
 
 type ChatService interface {
@@ -34,12 +39,15 @@ type ChatService interface {
 	// CRUD operations
 	Create(userID string, req *dtos.CreateChatRequest) (*dtos.ChatResponse, uint32, error)
 	CreateWithoutConnectionPing(userID string, req *dtos.CreateChatRequest) (*dtos.ChatResponse, uint32, error)
+	ImportConnections(userID string, req *dtos.ImportConnectionsRequest) (*dtos.ImportConnectionsResponse, uint32, error)
+	ParseConnectionString(req *dtos.ParseConnectionStringRequest) (*dtos.ParseConnectionStringResponse, uint32, error)
 	Update(userID, chatID string, req *dtos.UpdateChatRequest) (*dtos.ChatResponse, uint32, error)
 	Delete(userID, chatID string) (uint32, error)
 	GetByID(userID, chatID string) (*dtos.ChatResponse, uint32, error)
 	List(userID string, page, pageSize int) (*dtos.ChatListResponse, uint32, error)
 	CreateMessage(ctx context.Context, userID, chatID string, streamID string, content string) (*dtos.MessageResponse, uint16, error)
 	UpdateMessage(ctx context.Context, userID, chatID, messageID string, streamID string, req *dtos.CreateMessageRequest) (*dtos.MessageResponse, uint32, error)
+	AnswerClarification(ctx context.Context, userID, chatID, messageID string, req *dtos.AnswerClarificationRequest) (*dtos.MessageResponse, uint32, error)
 	DeleteMessages(userID, chatID string) (uint32, error)
 	Duplicate(userID, chatID string, duplicateMessages bool) (*dtos.ChatResponse, uint32, error)
 	ListMessages(userID, chatID string, page, pageSize int) (*dtos.MessageListResponse, uint32, error)
@@ -49,36 +57,81 @@ type ChatService interface {
 	HandleDBEvent(userID, chatID, streamID string, response dtos.StreamResponse)
 	GetAllTables(ctx context.Context, userID, chatID string) (*dtos.TablesResponse, uint32, error)
 	GetSelectedCollections(chatID string) (string, error)
+	HandleQueryExecuted(chatID, messageID, queryID, dbType, query string, isRollback bool)
+	HandleTransactionSessionEnded(userID, chatID string, end dbmanager.TransactionSessionEnd)
+	GetAuditStatus(ctx context.Context, userID, chatID string) (*dtos.AuditStatusResponse, uint32, error)
+	InstallAuditTriggers(ctx context.Context, userID, chatID string, req *dtos.InstallAuditRequest) (*dtos.AuditStatusResponse, uint32, error)
+	RemoveAuditTriggers(ctx context.Context, userID, chatID string, dropLog bool) (*dtos.AuditStatusResponse, uint32, error)
+	StartWatch(ctx context.Context, userID, chatID string, req *dtos.StartWatchRequest) (*dtos.WatchResponse, uint32, error)
+	ListWatches(ctx context.Context, userID, chatID string) (*dtos.WatchListResponse, uint32, error)
+	StopWatch(ctx context.Context, userID, chatID, watchID string) (uint32, error)
+	StartTransaction(ctx context.Context, userID, chatID string, req *dtos.StartTransactionRequest) (*dtos.TransactionResponse, uint32, error)
+	GetTransaction(ctx context.Context, userID, chatID string) (*dtos.TransactionResponse, uint32, error)
+	CommitTransaction(ctx context.Context, userID, chatID string) (*dtos.TransactionEndResponse, uint32, error)
+	RollbackTransaction(ctx context.Context, userID, chatID string) (*dtos.TransactionEndResponse, uint32, error)
+	GetTableRows(ctx context.Context, userID, chatID, table string, req *dtos.TableRowsRequest) (*dtos.TableRowsResponse, uint32, error)
 
 	// Execution operations
 	CancelProcessing(userID, chatID, streamID string)
 	ConnectDB(ctx context.Context, userID, chatID string, streamID string) (uint32, error)
 	DisconnectDB(ctx context.Context, userID, chatID string, streamID string) (uint32, error)
 	ExecuteQuery(ctx context.Context, userID, chatID string, req *dtos.ExecuteQueryRequest) (*dtos.QueryExecutionResponse, uint32, error)
+	ExecuteAllQueries(ctx context.Context, userID, chatID string, req *dtos.ExecuteAllQueriesRequest) (*dtos.ExecuteAllQueriesResponse, uint32, error)
 	RollbackQuery(ctx context.Context, userID, chatID string, req *dtos.RollbackQueryRequest) (*dtos.QueryExecutionResponse, uint32, error)
+	BenchmarkQuery(ctx context.Context, userID, chatID string, req *dtos.BenchmarkQueryRequest) (*dtos.BenchmarkQueryResponse, uint32, error)
+	StreamQueryResults(ctx context.Context, userID, chatID string, req *dtos.StreamQueryResultsRequest) (*dtos.StreamQueryResultsResponse, uint32, error)
+	ChartQueryResults(ctx context.Context, userID, chatID string, req *dtos.ChartQueryResultsRequest) (*dtos.ChartQueryResultsResponse, uint32, error)
+	ExportQueryResultsCSV(ctx context.Context, userID, chatID, messageID, queryID string, usePrimary bool, w io.Writer) (uint32, error)
+	ExportQueryResultsXLSX(ctx context.Context, userID, chatID, messageID, queryID string, usePrimary bool, w io.Writer) (uint32, error)
+	ExportQueryResultsParquet(ctx context.Context, userID, chatID, messageID, queryID string, usePrimary bool, w io.Writer) (uint32, error)
+	DownloadQueryResults(ctx context.Context, userID, chatID, queryID string, usePrimary bool, w io.Writer) (uint32, error)
+	GetQueryResultFile(ctx context.Context, userID, chatID, messageID, queryID string) (*dtos.ResultFile, uint32, error)
+	OpenResultFile(key, expires, signature string) (string, uint32, error)
 	CancelQueryExecution(userID, chatID, messageID, queryID, streamID string)
 	processMessage(ctx context.Context, userID, chatID string, messageID, streamID string) error
 	processLLMResponseAndRunQuery(ctx context.Context, userID, chatID string, messageID, streamID string) error
 	RefreshSchema(ctx context.Context, userID, chatID string, sync bool) (uint32, error)
-	GetQueryResults(ctx context.Context, userID, chatID, messageID, queryID, streamID string, offset int) (*dtos.QueryResultsResponse, uint32, error)
+	GetQueryResults(ctx context.Context, userID, chatID, messageID, queryID, streamID string, offset int, after interface{}) (*dtos.QueryResultsResponse, uint32, error)
 }
 
 type chatService struct {
-	chatRepo        repositories.ChatRepository
-	llmRepo         repositories.LLMMessageRepository
-	dbManager       *dbmanager.Manager
-	llmClient       llm.Client
-	streamChans     map[string]chan dtos.StreamResponse
-	streamHandler   StreamHandler
-	activeProcesses map[string]context.CancelFunc // key: streamID
-	processesMu     sync.RWMutex
+	chatRepo            repositories.ChatRepository
+	savedConnectionRepo repositories.SavedConnectionRepository
+	llmRepo             repositories.LLMMessageRepository
+	dbManager           *dbmanager.Manager
+	llmResolver         LLMClientResolver
+	lineageService      LineageService
+	tableUsageService   TableUsageService
+	notificationService NotificationService
+	llmUsageService     LLMUsageService
+	promptTemplates     PromptTemplateService
+	schemaRetrieval     SchemaRetrievalService
+	queryExamples       QueryExampleService
+	llmResponseCache    LLMResponseCacheService
+	queryHistory        QueryHistoryService
+	schemaVersions      SchemaVersionService
+	resultStore         resultstore.Store // nil when the large results are capped rather than spilled to files
+	streamChans         map[string]chan dtos.StreamResponse
+	streamHandler       StreamHandler
+	activeProcesses     map[string]context.CancelFunc // key: streamID
+	processesMu         sync.RWMutex
 }
 
 func isValidDBType(dbType string) bool {
+	// DB2 needs the IBM driver, which is only linked when building with the "db2" tag
+	if dbType == constants.DatabaseTypeDB2 {
+		return dbmanager.DB2Supported()
+	}
+
 	validTypes := []string{
 		constants.DatabaseTypePostgreSQL,
 		constants.DatabaseTypeYugabyteDB,
 		constants.DatabaseTypeMySQL,
+		constants.DatabaseTypeMariaDB,
+		constants.DatabaseTypeSingleStore,
+		constants.DatabaseTypeDB2,
+		constants.DatabaseTypeDatabricks,
+		constants.DatabaseTypeFirestore,
 		constants.DatabaseTypeClickhouse,
 		constants.DatabaseTypeMongoDB,
 		constants.DatabaseTypeRedis,
@@ -119,17 +172,41 @@ func (s *chatService) HandleDBEvent(userID, chatID, streamID string, response dt
 
 func NewChatService(
 	chatRepo repositories.ChatRepository,
+	savedConnectionRepo repositories.SavedConnectionRepository,
 	llmRepo repositories.LLMMessageRepository,
 	dbManager *dbmanager.Manager,
-	llmClient llm.Client,
+	llmResolver LLMClientResolver,
+	lineageService LineageService,
+	tableUsageService TableUsageService,
+	notificationService NotificationService,
+	llmUsageService LLMUsageService,
+	promptTemplates PromptTemplateService,
+	schemaRetrieval SchemaRetrievalService,
+	queryExamples QueryExampleService,
+	llmResponseCache LLMResponseCacheService,
+	queryHistory QueryHistoryService,
+	schemaVersions SchemaVersionService,
+	resultStore resultstore.Store,
 ) ChatService {
 	return &chatService{
-		chatRepo:        chatRepo,
-		llmRepo:         llmRepo,
-		dbManager:       dbManager,
-		llmClient:       llmClient,
-		streamChans:     make(map[string]chan dtos.StreamResponse),
-		activeProcesses: make(map[string]context.CancelFunc),
+		chatRepo:            chatRepo,
+		savedConnectionRepo: savedConnectionRepo,
+		llmRepo:             llmRepo,
+		dbManager:           dbManager,
+		llmResolver:         llmResolver,
+		lineageService:      lineageService,
+		tableUsageService:   tableUsageService,
+		notificationService: notificationService,
+		llmUsageService:     llmUsageService,
+		promptTemplates:     promptTemplates,
+		schemaRetrieval:     schemaRetrieval,
+		queryExamples:       queryExamples,
+		llmResponseCache:    llmResponseCache,
+		queryHistory:        queryHistory,
+		schemaVersions:      schemaVersions,
+		resultStore:         resultStore,
+		streamChans:         make(map[string]chan dtos.StreamResponse),
+		activeProcesses:     make(map[string]context.CancelFunc),
 	}
 }
 
@@ -142,67 +219,45 @@ func (s *chatService) Create(userID string, req *dtos.CreateChatRequest) (*dtos.
 		// Apply check that single user cannot have more than 1 chat
 		userObjID, err := primitive.ObjectIDFromHex(userID)
 		if err != nil {
-			return nil, http.StatusBadRequest, fmt.Errorf("invalid user ID format")
+			return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
 		}
 		chats, _, err := s.chatRepo.FindByUserID(userObjID, 1, 2)
 		if err != nil {
-			return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch chat: %v", err)
+			return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CHAT", "failed to fetch chat: {error}").With("error", err)
 		}
 		if len(chats) > 1 {
-			return nil, http.StatusBadRequest, fmt.Errorf("user cannot have more than 2 chats")
+			return nil, http.StatusBadRequest, apperrors.New("CHAT_LIMIT_REACHED", "user cannot have more than 2 chats")
 		}
 	}
 
+	// The saved connection was tested when it was saved
+	if req.ConnectionID != nil {
+		return s.createWithSavedConnection(userID, *req.ConnectionID, req.Settings)
+	}
+
 	// Validate database type
 	if !isValidDBType(req.Connection.Type) {
-		return nil, http.StatusBadRequest, fmt.Errorf("unsupported database type: %s", req.Connection.Type)
+		return nil, http.StatusBadRequest, apperrors.New("UNSUPPORTED_DATABASE_TYPE", "unsupported database type: {type}").With("type", req.Connection.Type)
 	}
 
 	// Test connection without creating a persistent connection
-	err := s.dbManager.TestConnection(&dbmanager.ConnectionConfig{
-		Type:           req.Connection.Type,
-		Host:           req.Connection.Host,
-		Port:           req.Connection.Port,
-		Username:       &req.Connection.Username,
-		Password:       req.Connection.Password,
-		Database:       req.Connection.Database,
-		AuthDatabase:   req.Connection.AuthDatabase,
-		SSLMode:        req.Connection.SSLMode,
-		UseSSL:         req.Connection.UseSSL,
-		SSLCertURL:     req.Connection.SSLCertURL,
-		SSLKeyURL:      req.Connection.SSLKeyURL,
-		SSLRootCertURL: req.Connection.SSLRootCertURL,
-	})
+	err := s.dbManager.TestConnection(connectionConfigFromRequest(req.Connection))
 	if err != nil {
-		return nil, http.StatusBadRequest, fmt.Errorf("%v", err)
+		return nil, http.StatusBadRequest, apperrors.New("CONNECTION_TEST_FAILED", "{error}").With("error", err)
 	}
 
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		return nil, http.StatusBadRequest, fmt.Errorf("invalid user ID format")
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
 	}
 
 	// Create connection object with SSL configuration
-	connection := models.Connection{
-		Type:           req.Connection.Type,
-		Host:           req.Connection.Host,
-		Port:           req.Connection.Port,
-		Username:       &req.Connection.Username,
-		Password:       req.Connection.Password,
-		Database:       req.Connection.Database,
-		AuthDatabase:   req.Connection.AuthDatabase,
-		SSLMode:        req.Connection.SSLMode,
-		UseSSL:         req.Connection.UseSSL,
-		SSLCertURL:     req.Connection.SSLCertURL,
-		SSLKeyURL:      req.Connection.SSLKeyURL,
-		SSLRootCertURL: req.Connection.SSLRootCertURL,
-		Base:           models.NewBase(),
-	}
+	connection := connectionFromRequest(req.Connection)
 
 	// Encrypt connection details
 	if err := utils.EncryptConnection(&connection); err != nil {
 		log.Printf("Warning: Failed to encrypt connection details: %v", err)
-		return nil, http.StatusInternalServerError, fmt.Errorf("failed to secure connection details: %v", err)
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_SECURE_CONNECTION_DETAILS", "failed to secure connection details: {error}").With("error", err)
 	}
 
 	settings := models.DefaultChatSettings()
@@ -212,6 +267,15 @@ func (s *chatService) Create(userID string, req *dtos.CreateChatRequest) (*dtos.
 	if req.Settings.ShareDataWithAI != nil {
 		settings.ShareDataWithAI = *req.Settings.ShareDataWithAI
 	}
+	if req.Settings.AuditChanges != nil {
+		settings.AuditChanges = *req.Settings.AuditChanges
+	}
+	if req.Settings.AllowDestructiveQueries != nil {
+		settings.AllowDestructiveQueries = *req.Settings.AllowDestructiveQueries
+	}
+	if req.Settings.VerifyQueries != nil {
+		settings.VerifyQueries = *req.Settings.VerifyQueries
+	}
 	// Create chat with connection
 	chat := models.NewChat(userObjID, connection, settings)
 	if err := s.chatRepo.Create(chat); err != nil {
@@ -229,49 +293,35 @@ func (s *chatService) CreateWithoutConnectionPing(userID string, req *dtos.Creat
 		// Apply check that single user cannot have more than 1 chat
 		userObjID, err := primitive.ObjectIDFromHex(userID)
 		if err != nil {
-			return nil, http.StatusBadRequest, fmt.Errorf("invalid user ID format")
+			return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
 		}
 		chats, _, err := s.chatRepo.FindByUserID(userObjID, 1, 2)
 		if err != nil {
-			return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch chat: %v", err)
+			return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CHAT", "failed to fetch chat: {error}").With("error", err)
 		}
 		if len(chats) > 1 {
-			return nil, http.StatusBadRequest, fmt.Errorf("user cannot have more than 2 chats")
+			return nil, http.StatusBadRequest, apperrors.New("CHAT_LIMIT_REACHED", "user cannot have more than 2 chats")
 		}
 	}
 
 	// Validate database type
 	if !isValidDBType(req.Connection.Type) {
-		return nil, http.StatusBadRequest, fmt.Errorf("unsupported database type: %s", req.Connection.Type)
+		return nil, http.StatusBadRequest, apperrors.New("UNSUPPORTED_DATABASE_TYPE", "unsupported database type: {type}").With("type", req.Connection.Type)
 	}
 
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		return nil, http.StatusBadRequest, fmt.Errorf("invalid user ID format")
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
 	}
 
 	// Create connection object with SSL configuration
-	connection := models.Connection{
-		Type:           req.Connection.Type,
-		Host:           req.Connection.Host,
-		Port:           req.Connection.Port,
-		Username:       &req.Connection.Username,
-		Password:       req.Connection.Password,
-		Database:       req.Connection.Database,
-		AuthDatabase:   req.Connection.AuthDatabase,
-		IsExampleDB:    true, // default is true, if false, then the database is a user's own database
-		UseSSL:         req.Connection.UseSSL,
-		SSLMode:        req.Connection.SSLMode,
-		SSLCertURL:     req.Connection.SSLCertURL,
-		SSLKeyURL:      req.Connection.SSLKeyURL,
-		SSLRootCertURL: req.Connection.SSLRootCertURL,
-		Base:           models.NewBase(),
-	}
+	connection := connectionFromRequest(req.Connection)
+	connection.IsExampleDB = true // default is true, if false, then the database is a user's own database
 
 	// Encrypt connection details
 	if err := utils.EncryptConnection(&connection); err != nil {
 		log.Printf("Warning: Failed to encrypt connection details: %v", err)
-		return nil, http.StatusInternalServerError, fmt.Errorf("failed to secure connection details: %v", err)
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_SECURE_CONNECTION_DETAILS", "failed to secure connection details: {error}").With("error", err)
 	}
 
 	settings := models.DefaultChatSettings()
@@ -282,6 +332,15 @@ func (s *chatService) CreateWithoutConnectionPing(userID string, req *dtos.Creat
 	if req.Settings.ShareDataWithAI != nil {
 		settings.ShareDataWithAI = *req.Settings.ShareDataWithAI
 	}
+	if req.Settings.AuditChanges != nil {
+		settings.AuditChanges = *req.Settings.AuditChanges
+	}
+	if req.Settings.AllowDestructiveQueries != nil {
+		settings.AllowDestructiveQueries = *req.Settings.AllowDestructiveQueries
+	}
+	if req.Settings.VerifyQueries != nil {
+		settings.VerifyQueries = *req.Settings.VerifyQueries
+	}
 	// Create chat with connection
 	chat := models.NewChat(userObjID, connection, settings)
 	if err := s.chatRepo.Create(chat); err != nil {
@@ -294,87 +353,75 @@ func (s *chatService) CreateWithoutConnectionPing(userID string, req *dtos.Creat
 func (s *chatService) Update(userID, chatID string, req *dtos.UpdateChatRequest) (*dtos.ChatResponse, uint32, error) {
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		return nil, http.StatusBadRequest, fmt.Errorf("invalid user ID format")
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
 	}
 
 	chatObjID, err := primitive.ObjectIDFromHex(chatID)
 	if err != nil {
-		return nil, http.StatusBadRequest, fmt.Errorf("invalid chat ID format")
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_CHAT_ID", "invalid chat ID format")
 	}
 
 	// Get the chat
 	chat, err := s.chatRepo.FindByID(chatObjID)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, http.StatusNotFound, fmt.Errorf("chat not found")
+			return nil, http.StatusNotFound, apperrors.New("CHAT_NOT_FOUND", "chat not found")
 		}
-		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch chat: %v", err)
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CHAT", "failed to fetch chat: {error}").With("error", err)
 	}
 
 	// Check if the chat belongs to the user
 	if chat.UserID != userObjID {
-		return nil, http.StatusForbidden, fmt.Errorf("chat does not belong to user")
+		return nil, http.StatusForbidden, apperrors.New("CHAT_ACCESS_DENIED", "chat does not belong to user")
 	}
 
 	// Check for connection changes
 	var credentialsChanged bool
-	if req.Connection != nil {
+	if req.ConnectionID != nil {
+		savedConnection, statusCode, err := s.findSavedConnection(userObjID, *req.ConnectionID)
+		if err != nil {
+			return nil, statusCode, err
+		}
+
+		// Another database, the schema & selected collections of the chat don't apply anymore
+		if chat.ConnectionID == nil || *chat.ConnectionID != savedConnection.ID {
+			log.Printf("ChatService -> Update -> Switching chat %s to saved connection %s", chatID, savedConnection.ID.Hex())
+			if err := s.dbManager.Disconnect(chatID, userID, true); err != nil {
+				log.Printf("ChatService -> Update -> Warning: Failed to disconnect existing connection: %v", err)
+			}
+			chat.ConnectionID = &savedConnection.ID
+			chat.Connection = savedConnection.Connection
+			chat.SelectedCollections = ""
+		}
+	} else if req.Connection != nil && chat.ConnectionID != nil {
+		return nil, http.StatusConflict, apperrors.New("CHAT_USES_SAVED_CONNECTION", "chat uses a saved connection, update it from the connections instead")
+	} else if req.Connection != nil {
 		// Validate database type
 		if !isValidDBType(req.Connection.Type) {
-			return nil, http.StatusBadRequest, fmt.Errorf("unsupported database type: %s", req.Connection.Type)
+			return nil, http.StatusBadRequest, apperrors.New("UNSUPPORTED_DATABASE_TYPE", "unsupported database type: {type}").With("type", req.Connection.Type)
 		}
 
 		// Create a copy of the existing connection and decrypt it for comparison
 		existingConn := chat.Connection
 		utils.DecryptConnection(&existingConn)
+		restoreRedactedProxyURL(existingConn, req.Connection)
 
 		// Check if critical connection details have changed
-		credentialsChanged = existingConn.Database != req.Connection.Database ||
-			existingConn.Host != req.Connection.Host ||
-			existingConn.Port != req.Connection.Port ||
-			*existingConn.Username != req.Connection.Username ||
-			(req.Connection.Password != nil && existingConn.Password != nil && *existingConn.Password != *req.Connection.Password)
+		credentialsChanged = connectionCredentialsChanged(existingConn, req.Connection)
 
 		// Test connection without creating a persistent connection
-		err = s.dbManager.TestConnection(&dbmanager.ConnectionConfig{
-			Type:           req.Connection.Type,
-			Host:           req.Connection.Host,
-			Port:           req.Connection.Port,
-			Username:       &req.Connection.Username,
-			Password:       req.Connection.Password,
-			Database:       req.Connection.Database,
-			AuthDatabase:   req.Connection.AuthDatabase,
-			UseSSL:         req.Connection.UseSSL,
-			SSLMode:        req.Connection.SSLMode,
-			SSLCertURL:     req.Connection.SSLCertURL,
-			SSLKeyURL:      req.Connection.SSLKeyURL,
-			SSLRootCertURL: req.Connection.SSLRootCertURL,
-		})
+		err = s.dbManager.TestConnection(connectionConfigFromRequest(req.Connection))
 		if err != nil {
-			return nil, http.StatusBadRequest, fmt.Errorf("%v", err)
+			return nil, http.StatusBadRequest, apperrors.New("CONNECTION_TEST_FAILED", "{error}").With("error", err)
 		}
 
 		// Create connection object with SSL configuration
-		connection := models.Connection{
-			Type:           req.Connection.Type,
-			Host:           req.Connection.Host,
-			Port:           req.Connection.Port,
-			Username:       &req.Connection.Username,
-			Password:       req.Connection.Password,
-			Database:       req.Connection.Database,
-			AuthDatabase:   req.Connection.AuthDatabase,
-			UseSSL:         req.Connection.UseSSL,
-			SSLMode:        req.Connection.SSLMode,
-			SSLCertURL:     req.Connection.SSLCertURL,
-			SSLKeyURL:      req.Connection.SSLKeyURL,
-			SSLRootCertURL: req.Connection.SSLRootCertURL,
-			Base:           models.NewBase(),
-		}
+		connection := connectionFromRequest(req.Connection)
 
 		// Encrypt connection details
 		if err := utils.EncryptConnection(&connection); err != nil {
 			log.Printf("Warning: Failed to encrypt connection details: %v", err)
-			return nil, http.StatusInternalServerError, fmt.Errorf("failed to secure connection details: %v", err)
+			return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_SECURE_CONNECTION_DETAILS", "failed to secure connection details: {error}").With("error", err)
 		}
 
 		// If credentials changed, disconnect existing connection
@@ -419,11 +466,49 @@ func (s *chatService) Update(userID, chatID string, req *dtos.UpdateChatRequest)
 			log.Printf("ChatService -> Update -> ShareDataWithAI: %v", *req.Settings.ShareDataWithAI)
 			chat.Settings.ShareDataWithAI = *req.Settings.ShareDataWithAI
 		}
+		if req.Settings.AuditChanges != nil {
+			log.Printf("ChatService -> Update -> AuditChanges: %v", *req.Settings.AuditChanges)
+			chat.Settings.AuditChanges = *req.Settings.AuditChanges
+			s.dbManager.SetAuditChanges(chatID, chat.Settings.AuditChanges)
+		}
+		if req.Settings.AllowDestructiveQueries != nil {
+			log.Printf("ChatService -> Update -> AllowDestructiveQueries: %v", *req.Settings.AllowDestructiveQueries)
+			chat.Settings.AllowDestructiveQueries = *req.Settings.AllowDestructiveQueries
+		}
+		if req.Settings.VerifyQueries != nil {
+			log.Printf("ChatService -> Update -> VerifyQueries: %v", *req.Settings.VerifyQueries)
+			chat.Settings.VerifyQueries = *req.Settings.VerifyQueries
+		}
+	}
+
+	// Replace the sampling overrides if provided, an empty object removes them
+	if req.LLMSampling != nil {
+		log.Printf("ChatService -> Update -> LLMSampling: %+v", *req.LLMSampling)
+		chat.Settings.LLMSampling = llmSamplingFromRequest(req.LLMSampling)
+	}
+
+	// The model is chosen among the allowed models of the organization's provider, an empty model uses its default one
+	if req.LLMModel != nil {
+		model := strings.TrimSpace(*req.LLMModel)
+		if model != "" {
+			allowedModels, err := s.llmResolver.AllowedLLMModels(userID)
+			if err != nil {
+				return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_RESOLVE_LLM_CLIENT", "failed to resolve LLM client: {error}").With("error", err)
+			}
+			if allowedModels == nil {
+				return nil, http.StatusBadRequest, apperrors.New("LLM_MODEL_NOT_CONFIGURABLE", "the model of a chat can only be chosen with the LLM provider of an organization")
+			}
+			if !containsString(allowedModels, model) {
+				return nil, http.StatusBadRequest, apperrors.New("LLM_MODEL_NOT_ALLOWED", "model {model} is not in the allowed models of the organization").With("model", model)
+			}
+		}
+		log.Printf("ChatService -> Update -> LLMModel: %q", model)
+		chat.Settings.LLMModel = model
 	}
 
 	// Update the chat
 	if err := s.chatRepo.Update(chatObjID, chat); err != nil {
-		return nil, http.StatusInternalServerError, fmt.Errorf("failed to update chat: %v", err)
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_UPDATE_CHAT", "failed to update chat: {error}").With("error", err)
 	}
 
 	// If selected collections changed, trigger a schema refresh
@@ -450,39 +535,69 @@ func (s *chatService) Update(userID, chatID string, req *dtos.UpdateChatRequest)
 func (s *chatService) Delete(userID, chatID string) (uint32, error) {
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		return http.StatusBadRequest, fmt.Errorf("invalid user ID format")
+		return http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
 	}
 
 	chatObjID, err := primitive.ObjectIDFromHex(chatID)
 	if err != nil {
-		return http.StatusBadRequest, fmt.Errorf("invalid chat ID format")
+		return http.StatusBadRequest, apperrors.New("INVALID_CHAT_ID", "invalid chat ID format")
 	}
 
 	// Verify ownership
 	chat, err := s.chatRepo.FindByID(chatObjID)
 	if err != nil {
-		return http.StatusInternalServerError, fmt.Errorf("failed to fetch chat: %v", err)
+		return http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CHAT", "failed to fetch chat: {error}").With("error", err)
 	}
 	if chat == nil {
-		return http.StatusNotFound, fmt.Errorf("chat not found")
+		return http.StatusNotFound, apperrors.New("CHAT_NOT_FOUND", "chat not found")
 	}
 	if chat.UserID != userObjID {
-		return http.StatusForbidden, fmt.Errorf("unauthorized access to chat")
+		return http.StatusForbidden, apperrors.New("CHAT_ACCESS_DENIED", "unauthorized access to chat")
 	}
 
 	// Delete chat and its messages
 	if err := s.chatRepo.Delete(chatObjID); err != nil {
-		return http.StatusInternalServerError, fmt.Errorf("failed to delete chat: %v", err)
+		return http.StatusInternalServerError, apperrors.New("FAILED_TO_DELETE_CHAT", "failed to delete chat: {error}").With("error", err)
 	}
 
 	// Delete messages
 	if err := s.chatRepo.DeleteMessages(chatObjID); err != nil {
-		return http.StatusInternalServerError, fmt.Errorf("failed to delete chat messages: %v", err)
+		return http.StatusInternalServerError, apperrors.New("FAILED_TO_DELETE_CHAT_MESSAGES", "failed to delete chat messages: {error}").With("error", err)
 	}
 
 	// Delete LLM messages
 	if err := s.llmRepo.DeleteMessagesByChatID(chatObjID, false); err != nil {
-		return http.StatusInternalServerError, fmt.Errorf("failed to delete chat messages: %v", err)
+		return http.StatusInternalServerError, apperrors.New("FAILED_TO_DELETE_CHAT_MESSAGES", "failed to delete chat messages: {error}").With("error", err)
+	}
+
+	// Delete recorded lineage
+	if err := s.lineageService.DeleteChatLineage(chatObjID); err != nil {
+		log.Printf("ChatService -> Delete -> Error deleting lineage: %v", err)
+	}
+
+	// Delete recorded schema versions
+	if err := s.schemaVersions.DeleteChatVersions(chatObjID); err != nil {
+		log.Printf("ChatService -> Delete -> Error deleting schema versions: %v", err)
+	}
+
+	// Delete recorded table usage
+	if err := s.tableUsageService.DeleteChatUsage(chatObjID); err != nil {
+		log.Printf("ChatService -> Delete -> Error deleting table usage: %v", err)
+	}
+
+	// Delete the embeddings of the tables
+	if err := s.schemaRetrieval.DeleteChatEmbeddings(chatObjID); err != nil {
+		log.Printf("ChatService -> Delete -> Error deleting schema embeddings: %v", err)
+	}
+
+	// Delete the query examples, the ones shared through a saved connection are kept
+	if err := s.queryExamples.DeleteChatExamples(chatObjID); err != nil {
+		log.Printf("ChatService -> Delete -> Error deleting query examples: %v", err)
+	}
+
+	// Delete notifications about the chat
+	if err := s.notificationService.DeleteChatNotifications(chatObjID); err != nil {
+		log.Printf("ChatService -> Delete -> Error deleting notifications: %v", err)
 	}
 
 	go func() {
@@ -499,23 +614,23 @@ func (s *chatService) Delete(userID, chatID string) (uint32, error) {
 func (s *chatService) GetByID(userID, chatID string) (*dtos.ChatResponse, uint32, error) {
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		return nil, http.StatusBadRequest, fmt.Errorf("invalid user ID format")
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
 	}
 
 	chatObjID, err := primitive.ObjectIDFromHex(chatID)
 	if err != nil {
-		return nil, http.StatusBadRequest, fmt.Errorf("invalid chat ID format")
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_CHAT_ID", "invalid chat ID format")
 	}
 
 	chat, err := s.chatRepo.FindByID(chatObjID)
 	if err != nil {
-		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch chat: %v", err)
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CHAT", "failed to fetch chat: {error}").With("error", err)
 	}
 	if chat == nil {
-		return nil, http.StatusNotFound, fmt.Errorf("chat not found")
+		return nil, http.StatusNotFound, apperrors.New("CHAT_NOT_FOUND", "chat not found")
 	}
 	if chat.UserID != userObjID {
-		return nil, http.StatusForbidden, fmt.Errorf("unauthorized access to chat")
+		return nil, http.StatusForbidden, apperrors.New("CHAT_ACCESS_DENIED", "unauthorized access to chat")
 	}
 
 	return s.buildChatResponse(chat), http.StatusOK, nil
@@ -525,12 +640,12 @@ func (s *chatService) GetByID(userID, chatID string) (*dtos.ChatResponse, uint32
 func (s *chatService) List(userID string, page, pageSize int) (*dtos.ChatListResponse, uint32, error) {
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		return nil, http.StatusBadRequest, fmt.Errorf("invalid user ID format")
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
 	}
 
 	chats, total, err := s.chatRepo.FindByUserID(userObjID, page, pageSize)
 	if err != nil {
-		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch chats: %v", err)
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CHATS", "failed to fetch chats: {error}").With("error", err)
 	}
 
 	response := &dtos.ChatListResponse{
@@ -550,21 +665,21 @@ func (s *chatService) CreateMessage(ctx context.Context, userID, chatID string,
 	// Validate chat exists and user has access
 	chatObjID, err := primitive.ObjectIDFromHex(chatID)
 	if err != nil {
-		return nil, http.StatusBadRequest, fmt.Errorf("invalid chat ID format")
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_CHAT_ID", "invalid chat ID format")
 	}
 
 	chat, err := s.chatRepo.FindByID(chatObjID)
 	if err != nil {
-		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch chat: %v", err)
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CHAT", "failed to fetch chat: {error}").With("error", err)
 	}
 	if chat == nil {
-		return nil, http.StatusNotFound, fmt.Errorf("chat not found")
+		return nil, http.StatusNotFound, apperrors.New("CHAT_NOT_FOUND", "chat not found")
 	}
 
 	// Create and save the user message first
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		return nil, http.StatusBadRequest, fmt.Errorf("invalid user ID format")
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
 	}
 
 	msg := &models.Message{
@@ -576,7 +691,7 @@ func (s *chatService) CreateMessage(ctx context.Context, userID, chatID string,
 	}
 
 	if err := s.chatRepo.CreateMessage(msg); err != nil {
-		return nil, http.StatusInternalServerError, fmt.Errorf("failed to save message: %v", err)
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_SAVE_MESSAGE", "failed to save message: {error}").With("error", err)
 	}
 
 	// Make LLM Message
@@ -591,19 +706,19 @@ func (s *chatService) CreateMessage(ctx context.Context, userID, chatID string,
 		},
 	}
 	if err := s.llmRepo.CreateMessage(llmMsg); err != nil {
-		return nil, http.StatusInternalServerError, fmt.Errorf("failed to save LLM message: %v", err)
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_SAVE_LLM_MESSAGE", "failed to save LLM message: {error}").With("error", err)
 	}
 
 	log.Printf("ChatService -> CreateMessage -> AutoExecuteQuery: %v", chat.Settings.AutoExecuteQuery)
 	// If auto execute query is true, we need to process LLM response & run query automatically
 	if chat.Settings.AutoExecuteQuery {
 		if err := s.processLLMResponseAndRunQuery(ctx, userID, chatID, msg.ID.Hex(), streamID); err != nil {
-			return nil, http.StatusInternalServerError, fmt.Errorf("failed to process message: %v", err)
+			return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_PROCESS_MESSAGE", "failed to process message: {error}").With("error", err)
 		}
 	} else {
 		// Start processing the message asynchronously
 		if err := s.processMessage(ctx, userID, chatID, msg.ID.Hex(), streamID); err != nil {
-			return nil, http.StatusInternalServerError, fmt.Errorf("failed to process message: %v", err)
+			return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_PROCESS_MESSAGE", "failed to process message: {error}").With("error", err)
 		}
 	}
 
@@ -617,39 +732,112 @@ func (s *chatService) CreateMessage(ctx context.Context, userID, chatID string,
 	}, http.StatusOK, nil
 }
 
+// AnswerClarification answers the clarification question of an assistant message with one of its options or a free answer.
+// The answer is sent as the next user message, the LLM resumes the generation with the conversation.
+func (s *chatService) AnswerClarification(ctx context.Context, userID, chatID, messageID string, req *dtos.AnswerClarificationRequest) (*dtos.MessageResponse, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+
+	chatObjID, err := primitive.ObjectIDFromHex(chatID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_CHAT_ID", "invalid chat ID format")
+	}
+
+	messageObjID, err := primitive.ObjectIDFromHex(messageID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_MESSAGE_ID", "invalid message ID format")
+	}
+
+	message, err := s.chatRepo.FindMessageByID(messageObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_MESSAGE", "failed to fetch message: {error}").With("error", err)
+	}
+
+	if message.UserID != userObjID {
+		return nil, http.StatusForbidden, apperrors.New("MESSAGE_ACCESS_DENIED", "unauthorized access to message")
+	}
+
+	if message.ChatID != chatObjID {
+		return nil, http.StatusBadRequest, apperrors.New("MESSAGE_NOT_IN_CHAT", "message does not belong to chat")
+	}
+
+	// The options of the clarification are the clarify buttons, they are removed once answered
+	var options []models.ActionButton
+	remainingButtons := []models.ActionButton{}
+	if message.ActionButtons != nil {
+		for _, button := range *message.ActionButtons {
+			if button.Action == clarificationAction {
+				options = append(options, button)
+			} else {
+				remainingButtons = append(remainingButtons, button)
+			}
+		}
+	}
+	if len(options) == 0 {
+		return nil, http.StatusBadRequest, apperrors.New("NO_PENDING_CLARIFICATION", "message has no clarification to answer")
+	}
+
+	answer := strings.TrimSpace(req.Answer)
+	if req.ButtonID != "" {
+		answer = ""
+		for _, option := range options {
+			if option.ID.Hex() == req.ButtonID {
+				answer = option.Label
+				break
+			}
+		}
+		if answer == "" {
+			return nil, http.StatusBadRequest, apperrors.New("CLARIFICATION_OPTION_NOT_FOUND", "option {button_id} not found in the clarification").With("button_id", req.ButtonID)
+		}
+	}
+	if answer == "" {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_CLARIFICATION_ANSWER", "either button_id or answer is required")
+	}
+
+	message.ActionButtons = &remainingButtons
+	if err := s.chatRepo.UpdateMessage(message.ID, message); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_UPDATE_MESSAGE", "failed to update message: {error}").With("error", err)
+	}
+
+	response, statusCode, err := s.CreateMessage(ctx, userID, chatID, req.StreamID, answer)
+	return response, uint32(statusCode), err
+}
+
 // Update a message
 func (s *chatService) UpdateMessage(ctx context.Context, userID, chatID, messageID string, streamID string, req *dtos.CreateMessageRequest) (*dtos.MessageResponse, uint32, error) {
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		return nil, http.StatusBadRequest, fmt.Errorf("invalid user ID format")
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
 	}
 
 	chatObjID, err := primitive.ObjectIDFromHex(chatID)
 	if err != nil {
-		return nil, http.StatusBadRequest, fmt.Errorf("invalid chat ID format")
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_CHAT_ID", "invalid chat ID format")
 	}
 
 	messageObjID, err := primitive.ObjectIDFromHex(messageID)
 	if err != nil {
-		return nil, http.StatusBadRequest, fmt.Errorf("invalid message ID format")
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_MESSAGE_ID", "invalid message ID format")
 	}
 
 	message, err := s.chatRepo.FindMessageByID(messageObjID)
 	if err != nil {
-		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch message: %v", err)
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_MESSAGE", "failed to fetch message: {error}").With("error", err)
 	}
 
 	if message.UserID != userObjID {
-		return nil, http.StatusForbidden, fmt.Errorf("unauthorized access to message")
+		return nil, http.StatusForbidden, apperrors.New("MESSAGE_ACCESS_DENIED", "unauthorized access to message")
 	}
 
 	if message.ChatID != chatObjID {
-		return nil, http.StatusBadRequest, fmt.Errorf("message does not belong to chat")
+		return nil, http.StatusBadRequest, apperrors.New("MESSAGE_NOT_IN_CHAT", "message does not belong to chat")
 	}
 
 	chat, err := s.chatRepo.FindByID(chatObjID)
 	if err != nil {
-		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch chat: %v", err)
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CHAT", "failed to fetch chat: {error}").With("error", err)
 	}
 
 	log.Printf("UpdateMessage -> content: %+v", req.Content)
@@ -660,7 +848,7 @@ func (s *chatService) UpdateMessage(ctx context.Context, userID, chatID, message
 	log.Printf("UpdateMessage -> message.Content: %+v", message.Content)
 	err = s.chatRepo.UpdateMessage(message.ID, message)
 	if err != nil {
-		return nil, http.StatusInternalServerError, fmt.Errorf("failed to update message: %v", err)
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_UPDATE_MESSAGE", "failed to update message: {error}").With("error", err)
 	}
 
 	// Find the next AI message after the edited message
@@ -688,7 +876,7 @@ func (s *chatService) UpdateMessage(ctx context.Context, userID, chatID, message
 
 	llmMsg, err := s.llmRepo.FindMessageByChatMessageID(message.ID)
 	if err != nil {
-		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch LLM message: %v", err)
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_LLM_MESSAGE", "failed to fetch LLM message: {error}").With("error", err)
 	}
 
 	log.Printf("UpdateMessage -> llmMsg: %+v", llmMsg)
@@ -697,18 +885,18 @@ func (s *chatService) UpdateMessage(ctx context.Context, userID, chatID, message
 	}
 
 	if err := s.llmRepo.UpdateMessage(llmMsg.ID, llmMsg); err != nil {
-		return nil, http.StatusInternalServerError, fmt.Errorf("failed to update LLM message: %v", err)
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_UPDATE_LLM_MESSAGE", "failed to update LLM message: {error}").With("error", err)
 	}
 
 	// If auto execute query is true, we need to process LLM response & run query automatically
 	if chat.Settings.AutoExecuteQuery {
 		if err := s.processLLMResponseAndRunQuery(ctx, userID, chatID, messageID, streamID); err != nil {
-			return nil, http.StatusInternalServerError, fmt.Errorf("failed to process message: %v", err)
+			return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_PROCESS_MESSAGE", "failed to process message: {error}").With("error", err)
 		}
 	} else {
 		// Start processing the message asynchronously
 		if err := s.processMessage(ctx, userID, chatID, messageID, streamID); err != nil {
-			return nil, http.StatusInternalServerError, fmt.Errorf("failed to process message: %v", err)
+			return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_PROCESS_MESSAGE", "failed to process message: {error}").With("error", err)
 		}
 	}
 	return s.buildMessageResponse(message), http.StatusOK, nil
@@ -718,33 +906,33 @@ func (s *chatService) UpdateMessage(ctx context.Context, userID, chatID, message
 func (s *chatService) DeleteMessages(userID, chatID string) (uint32, error) {
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		return http.StatusBadRequest, fmt.Errorf("invalid user ID format")
+		return http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
 	}
 
 	chatObjID, err := primitive.ObjectIDFromHex(chatID)
 	if err != nil {
-		return http.StatusBadRequest, fmt.Errorf("invalid chat ID format")
+		return http.StatusBadRequest, apperrors.New("INVALID_CHAT_ID", "invalid chat ID format")
 	}
 
 	// Verify chat ownership
 	chat, err := s.chatRepo.FindByID(chatObjID)
 	if err != nil {
-		return http.StatusInternalServerError, fmt.Errorf("failed to fetch chat: %v", err)
+		return http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CHAT", "failed to fetch chat: {error}").With("error", err)
 	}
 	if chat == nil {
-		return http.StatusNotFound, fmt.Errorf("chat not found")
+		return http.StatusNotFound, apperrors.New("CHAT_NOT_FOUND", "chat not found")
 	}
 	if chat.UserID != userObjID {
-		return http.StatusForbidden, fmt.Errorf("unauthorized access to chat")
+		return http.StatusForbidden, apperrors.New("CHAT_ACCESS_DENIED", "unauthorized access to chat")
 	}
 
 	if err := s.chatRepo.DeleteMessages(chatObjID); err != nil {
-		return http.StatusInternalServerError, fmt.Errorf("failed to delete messages: %v", err)
+		return http.StatusInternalServerError, apperrors.New("FAILED_TO_DELETE_MESSAGES", "failed to delete messages: {error}").With("error", err)
 	}
 
 	// Delete LLM messages
 	if err := s.llmRepo.DeleteMessagesByChatID(chatObjID, true); err != nil {
-		return http.StatusInternalServerError, fmt.Errorf("failed to delete LLM messages: %v", err)
+		return http.StatusInternalServerError, apperrors.New("FAILED_TO_DELETE_LLM_MESSAGES", "failed to delete LLM messages: {error}").With("error", err)
 	}
 
 	return http.StatusOK, nil
@@ -754,24 +942,24 @@ func (s *chatService) DeleteMessages(userID, chatID string) (uint32, error) {
 func (s *chatService) Duplicate(userID, chatID string, duplicateMessages bool) (*dtos.ChatResponse, uint32, error) {
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		return nil, http.StatusBadRequest, fmt.Errorf("invalid user ID format")
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
 	}
 
 	chatObjID, err := primitive.ObjectIDFromHex(chatID)
 	if err != nil {
-		return nil, http.StatusBadRequest, fmt.Errorf("invalid chat ID format")
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_CHAT_ID", "invalid chat ID format")
 	}
 
 	// Verify chat ownership
 	chat, err := s.chatRepo.FindByID(chatObjID)
 	if err != nil {
-		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch chat: %v", err)
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CHAT", "failed to fetch chat: {error}").With("error", err)
 	}
 	if chat == nil {
-		return nil, http.StatusNotFound, fmt.Errorf("chat not found")
+		return nil, http.StatusNotFound, apperrors.New("CHAT_NOT_FOUND", "chat not found")
 	}
 	if chat.UserID != userObjID {
-		return nil, http.StatusForbidden, fmt.Errorf("unauthorized access to chat")
+		return nil, http.StatusForbidden, apperrors.New("CHAT_ACCESS_DENIED", "unauthorized access to chat")
 	}
 
 	// Duplicate the chat
@@ -784,7 +972,7 @@ func (s *chatService) Duplicate(userID, chatID string, duplicateMessages bool) (
 	}
 
 	if err := s.chatRepo.Create(newChat); err != nil {
-		return nil, http.StatusInternalServerError, fmt.Errorf("failed to create duplicate chat: %v", err)
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_CREATE_DUPLICATE_CHAT", "failed to create duplicate chat: {error}").With("error", err)
 	}
 
 	// if duplicateMessages is true, then we duplicate both regular messages and LLM messages
@@ -851,11 +1039,17 @@ func (s *chatService) Duplicate(userID, chatID string, duplicateMessages bool) (
 							ExampleExecutionTime:   q.ExampleExecutionTime,
 							CanRollback:            q.CanRollback,
 							IsCritical:             q.IsCritical,
+							IsBlocked:              q.IsBlocked,
+							GuardrailReason:        q.GuardrailReason,
+							EstimatedRows:          q.EstimatedRows,
+							RequiresConfirmation:   q.RequiresConfirmation,
+							AffectedRowsPreview:    q.AffectedRowsPreview,
 							IsExecuted:             false, // Reset execution state in the duplicate
 							IsRolledBack:           false, // Reset rollback state
 							Error:                  q.Error,
 							ExampleResult:          q.ExampleResult,
 							ExecutionResult:        nil, // Clear execution results
+							Params:                 q.Params,
 							IsEdited:               q.IsEdited,
 							Metadata:               q.Metadata,
 							ActionAt:               q.ActionAt,
@@ -867,6 +1061,8 @@ func (s *chatService) Duplicate(userID, chatID string, duplicateMessages bool) (
 								TotalRecordsCount: q.Pagination.TotalRecordsCount,
 								PaginatedQuery:    q.Pagination.PaginatedQuery,
 								CountQuery:        q.Pagination.CountQuery,
+								KeysetColumn:      q.Pagination.KeysetColumn,
+								KeysetDescending:  q.Pagination.KeysetDescending,
 							}
 						}
 					}
@@ -993,29 +1189,29 @@ func (s *chatService) Duplicate(userID, chatID string, duplicateMessages bool) (
 func (s *chatService) ListMessages(userID, chatID string, page, pageSize int) (*dtos.MessageListResponse, uint32, error) {
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		return nil, http.StatusBadRequest, fmt.Errorf("invalid user ID format")
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
 	}
 
 	chatObjID, err := primitive.ObjectIDFromHex(chatID)
 	if err != nil {
-		return nil, http.StatusBadRequest, fmt.Errorf("invalid chat ID format")
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_CHAT_ID", "invalid chat ID format")
 	}
 
 	// Verify chat ownership
 	chat, err := s.chatRepo.FindByID(chatObjID)
 	if err != nil {
-		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch chat: %v", err)
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CHAT", "failed to fetch chat: {error}").With("error", err)
 	}
 	if chat == nil {
-		return nil, http.StatusNotFound, fmt.Errorf("chat not found")
+		return nil, http.StatusNotFound, apperrors.New("CHAT_NOT_FOUND", "chat not found")
 	}
 	if chat.UserID != userObjID {
-		return nil, http.StatusForbidden, fmt.Errorf("unauthorized access to chat")
+		return nil, http.StatusForbidden, apperrors.New("CHAT_ACCESS_DENIED", "unauthorized access to chat")
 	}
 
 	messages, total, err := s.chatRepo.FindLatestMessageByChat(chatObjID, page, pageSize)
 	if err != nil {
-		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch messages: %v", err)
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_MESSAGES", "failed to fetch messages: {error}").With("error", err)
 	}
 
 	response := &dtos.MessageListResponse{
@@ -1034,21 +1230,26 @@ func (s *chatService) ListMessages(userID, chatID string, page, pageSize int) (*
 func (s *chatService) EditQuery(ctx context.Context, userID, chatID, messageID, queryID string, query string) (*dtos.EditQueryResponse, uint32, error) {
 	log.Printf("ChatService -> EditQuery -> userID: %s, chatID: %s, messageID: %s, queryID: %s, query: %s", userID, chatID, messageID, queryID, query)
 
-	_, message, queryData, err := s.verifyQueryOwnership(userID, chatID, messageID, queryID)
+	chat, message, queryData, err := s.verifyQueryOwnership(userID, chatID, messageID, queryID)
 	if err != nil {
 		return nil, http.StatusBadRequest, err
 	}
 
 	if queryData.IsExecuted || queryData.IsRolledBack {
-		return nil, http.StatusBadRequest, fmt.Errorf("query has already been executed, cannot edit")
+		return nil, http.StatusBadRequest, apperrors.New("QUERY_ALREADY_EXECUTED", "query has already been executed, cannot edit")
 	}
 
 	originalQuery := queryData.Query
+	var affectedRows *models.AffectedRowsPreview
 	// Fix the query update logic
 	for i := range *message.Queries {
 		if (*message.Queries)[i].ID == queryData.ID {
 			(*message.Queries)[i].Query = query
 			(*message.Queries)[i].IsEdited = true
+			setMongoDBRollback(&(*message.Queries)[i])
+			applyQueryGuardrail(&(*message.Queries)[i], chat.Connection.Type, chat.Settings.AllowDestructiveQueries)
+			s.previewAffectedRows(ctx, chatID, &(*message.Queries)[i])
+			affectedRows = (*message.Queries)[i].AffectedRowsPreview
 			if (*message.Queries)[i].Pagination != nil && (*message.Queries)[i].Pagination.PaginatedQuery != nil {
 				(*message.Queries)[i].Pagination.PaginatedQuery = utils.ToStringPtr(strings.Replace(*(*message.Queries)[i].Pagination.PaginatedQuery, originalQuery, query, 1))
 			}
@@ -1057,13 +1258,13 @@ func (s *chatService) EditQuery(ctx context.Context, userID, chatID, messageID,
 
 	message.IsEdited = true
 	if err := s.chatRepo.UpdateMessage(message.ID, message); err != nil {
-		return nil, http.StatusBadRequest, fmt.Errorf("failed to update message: %v", err)
+		return nil, http.StatusBadRequest, apperrors.New("FAILED_TO_UPDATE_MESSAGE", "failed to update message: {error}").With("error", err)
 	}
 
 	// Update the query in LLM messages too
 	llmMsg, err := s.llmRepo.FindMessageByChatMessageID(message.ID)
 	if err != nil {
-		return nil, http.StatusBadRequest, fmt.Errorf("failed to find LLM message: %v", err)
+		return nil, http.StatusBadRequest, apperrors.New("FAILED_TO_FIND_LLM_MESSAGE", "failed to find LLM message: {error}").With("error", err)
 	}
 
 	if assistantResponse, ok := llmMsg.Content["assistant_response"].(map[string]interface{}); ok {
@@ -1118,15 +1319,16 @@ func (s *chatService) EditQuery(ctx context.Context, userID, chatID, messageID,
 	}
 
 	if err := s.llmRepo.UpdateMessage(llmMsg.ID, llmMsg); err != nil {
-		return nil, http.StatusBadRequest, fmt.Errorf("failed to update LLM message: %v", err)
+		return nil, http.StatusBadRequest, apperrors.New("FAILED_TO_UPDATE_LLM_MESSAGE", "failed to update LLM message: {error}").With("error", err)
 	}
 
 	return &dtos.EditQueryResponse{
-		ChatID:    chatID,
-		MessageID: messageID,
-		QueryID:   queryID,
-		Query:     query,
-		IsEdited:  true,
+		ChatID:              chatID,
+		MessageID:           messageID,
+		QueryID:             queryID,
+		Query:               query,
+		IsEdited:            true,
+		AffectedRowsPreview: dtos.ToAffectedRowsPreviewDto(affectedRows),
 	}, http.StatusOK, nil
 }
 
@@ -1135,7 +1337,7 @@ func (s *chatService) GetDBConnectionStatus(ctx context.Context, userID, chatID
 	// Get connection info
 	connInfo, exists := s.dbManager.GetConnectionInfo(chatID)
 	if !exists {
-		return nil, http.StatusNotFound, fmt.Errorf("no connection found")
+		return nil, http.StatusNotFound, apperrors.New("CONNECTION_NOT_FOUND", "no connection found")
 	}
 
 	// Check if connection is active
@@ -1153,14 +1355,20 @@ func (s *chatService) GetDBConnectionStatus(ctx context.Context, userID, chatID
 		}
 	}
 
-	return &dtos.ConnectionStatusResponse{
+	response := &dtos.ConnectionStatusResponse{
 		IsConnected: isConnected,
 		Type:        connInfo.Config.Type,
 		Host:        connInfo.Config.Host,
 		Port:        port,
 		Database:    connInfo.Config.Database,
 		Username:    *connInfo.Config.Username,
-	}, http.StatusOK, nil
+	}
+	if features, ok := s.dbManager.GetServerFeatures(chatID); ok {
+		response.ServerVersion = features.Version
+		response.ServerFlavor = features.Flavor
+		response.Features = features.Features
+	}
+	return response, http.StatusOK, nil
 }
 
 // HandleSchemaChange handles schema changes
@@ -1228,6 +1436,7 @@ func (s *chatService) HandleSchemaChange(userID, chatID, streamID string, diff *
 	// Format the schema changes for LLM
 	if diff != nil {
 		log.Printf("ChatService -> HandleSchemaChange -> diff: %+v", diff)
+		s.recordSchemaVersion(userID, chat, diff)
 
 		// Need to update the chat LLM messages with the new schema
 		// Only do full schema comparison if changes detected
@@ -1274,45 +1483,217 @@ func (s *chatService) HandleSchemaChange(userID, chatID, streamID string, diff *
 		}
 
 		log.Printf("ChatService -> HandleSchemaChange -> Schema update message saved")
+
+		if !diff.IsFirstTime {
+			s.notifySchemaChanged(userID, chatID, chat.Connection.Database, diff)
+			s.tableUsageService.ForgetTables(chatID, diff.RemovedTables)
+		}
+	}
+}
+
+// recordSchemaVersion adds the new schema of the chat's database to its schema versions, the full schema is only part of the diff
+// the first time
+func (s *chatService) recordSchemaVersion(userID string, chat *models.Chat, diff *dbmanager.SchemaDiff) {
+	schema := diff.FullSchema
+	if schema == nil {
+		storage, err := s.dbManager.GetSchemaManager().GetStoredSchema(context.Background(), chat.ID.Hex())
+		if err != nil {
+			log.Printf("ChatService -> recordSchemaVersion -> Error getting stored schema: %v", err)
+			return
+		}
+		schema = storage.FullSchema
 	}
+	s.schemaVersions.RecordVersion(userID, chat, schema)
+}
+
+// notifySchemaChanged adds a notification summarizing the tables added, removed & modified in the chat's database
+func (s *chatService) notifySchemaChanged(userID, chatID, database string, diff *dbmanager.SchemaDiff) {
+	modifiedTables := make([]string, 0, len(diff.ModifiedTables))
+	for table := range diff.ModifiedTables {
+		modifiedTables = append(modifiedTables, table)
+	}
+	sort.Strings(modifiedTables)
+
+	changes := []string{}
+	if len(diff.AddedTables) > 0 {
+		changes = append(changes, fmt.Sprintf("added: %s", strings.Join(diff.AddedTables, ", ")))
+	}
+	if len(diff.RemovedTables) > 0 {
+		changes = append(changes, fmt.Sprintf("removed: %s", strings.Join(diff.RemovedTables, ", ")))
+	}
+	if len(modifiedTables) > 0 {
+		changes = append(changes, fmt.Sprintf("modified: %s", strings.Join(modifiedTables, ", ")))
+	}
+	if len(changes) == 0 {
+		return
+	}
+
+	s.notificationService.Notify(userID, chatID, models.NotificationTypeSchemaChanged,
+		fmt.Sprintf("Schema of %s changed", database),
+		"Tables "+strings.Join(changes, "; "),
+		map[string]interface{}{
+			"added_tables":    diff.AddedTables,
+			"removed_tables":  diff.RemovedTables,
+			"modified_tables": modifiedTables,
+		})
 }
 
 // Helper methods for building responses
 
-func (s *chatService) buildChatResponse(chat *models.Chat) *dtos.ChatResponse {
-	// Create a copy of the connection to avoid modifying the original
-	connectionCopy := chat.Connection
+// createWithSavedConnection creates a chat using a saved connection of the user
+func (s *chatService) createWithSavedConnection(userID, connectionID string, reqSettings dtos.CreateChatSettings) (*dtos.ChatResponse, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+
+	savedConnection, statusCode, err := s.findSavedConnection(userObjID, connectionID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	settings := models.DefaultChatSettings()
+	if reqSettings.AutoExecuteQuery != nil {
+		settings.AutoExecuteQuery = *reqSettings.AutoExecuteQuery
+	}
+	if reqSettings.ShareDataWithAI != nil {
+		settings.ShareDataWithAI = *reqSettings.ShareDataWithAI
+	}
+	if reqSettings.AuditChanges != nil {
+		settings.AuditChanges = *reqSettings.AuditChanges
+	}
+	if reqSettings.AllowDestructiveQueries != nil {
+		settings.AllowDestructiveQueries = *reqSettings.AllowDestructiveQueries
+	}
+	if reqSettings.VerifyQueries != nil {
+		settings.VerifyQueries = *reqSettings.VerifyQueries
+	}
+
+	chat := models.NewChatWithSavedConnection(userObjID, savedConnection, settings)
+	if err := s.chatRepo.Create(chat); err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return s.buildChatResponse(chat), http.StatusCreated, nil
+}
 
-	// Decrypt connection details for the response
-	utils.DecryptConnection(&connectionCopy)
+// findSavedConnection returns a saved connection of the user
+func (s *chatService) findSavedConnection(userID primitive.ObjectID, connectionID string) (*models.SavedConnection, uint32, error) {
+	connectionObjID, err := primitive.ObjectIDFromHex(connectionID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_CONNECTION_ID", "invalid connection ID format")
+	}
+
+	savedConnection, err := s.savedConnectionRepo.FindByID(connectionObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CONNECTION", "failed to fetch connection: {error}").With("error", err)
+	}
+	if savedConnection == nil || savedConnection.UserID != userID {
+		return nil, http.StatusNotFound, apperrors.New("CONNECTION_NOT_FOUND", "connection not found")
+	}
+	return savedConnection, http.StatusOK, nil
+}
+
+func (s *chatService) buildChatResponse(chat *models.Chat) *dtos.ChatResponse {
+	var connectionID *string
+	if chat.ConnectionID != nil {
+		id := chat.ConnectionID.Hex()
+		connectionID = &id
+	}
 
 	return &dtos.ChatResponse{
-		ID:     chat.ID.Hex(),
-		UserID: chat.UserID.Hex(),
-		Connection: dtos.ConnectionResponse{
-			ID:             chat.ID.Hex(),
-			Type:           connectionCopy.Type,
-			Host:           connectionCopy.Host,
-			Port:           connectionCopy.Port,
-			Username:       *connectionCopy.Username,
-			Database:       connectionCopy.Database,
-			IsExampleDB:    connectionCopy.IsExampleDB,
-			UseSSL:         connectionCopy.UseSSL,
-			SSLMode:        connectionCopy.SSLMode,
-			SSLCertURL:     connectionCopy.SSLCertURL,
-			SSLKeyURL:      connectionCopy.SSLKeyURL,
-			SSLRootCertURL: connectionCopy.SSLRootCertURL,
-		},
+		ID:                  chat.ID.Hex(),
+		UserID:              chat.UserID.Hex(),
+		ConnectionID:        connectionID,
+		Connection:          buildConnectionResponse(chat.ID.Hex(), chat.Connection),
 		SelectedCollections: chat.SelectedCollections,
 		CreatedAt:           chat.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:           chat.UpdatedAt.Format(time.RFC3339),
 		Settings: dtos.ChatSettingsResponse{
-			AutoExecuteQuery: chat.Settings.AutoExecuteQuery,
-			ShareDataWithAI:  chat.Settings.ShareDataWithAI,
+			AutoExecuteQuery:        chat.Settings.AutoExecuteQuery,
+			ShareDataWithAI:         chat.Settings.ShareDataWithAI,
+			AuditChanges:            chat.Settings.AuditChanges,
+			AllowDestructiveQueries: chat.Settings.AllowDestructiveQueries,
+			VerifyQueries:           chat.Settings.VerifyQueries,
+			LLMSampling:             buildLLMSamplingResponse(chat.Settings.LLMSampling),
+			LLMModel:                chat.Settings.LLMModel,
 		},
 	}
 }
 
+// llmSamplingFromRequest returns the sampling overrides of a chat, nil when the request overrides nothing
+func llmSamplingFromRequest(req *dtos.LLMSamplingSettings) *models.LLMSampling {
+	if req.Temperature == nil && req.TopP == nil && req.MaxTokens == nil {
+		return nil
+	}
+	return &models.LLMSampling{
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+		MaxTokens:   req.MaxTokens,
+	}
+}
+
+func buildLLMSamplingResponse(sampling *models.LLMSampling) *dtos.LLMSamplingSettings {
+	if sampling == nil {
+		return nil
+	}
+	return &dtos.LLMSamplingSettings{
+		Temperature: sampling.Temperature,
+		TopP:        sampling.TopP,
+		MaxTokens:   sampling.MaxTokens,
+	}
+}
+
+// buildConnectionResponse returns the connection details without the secrets, the connection is stored encrypted
+func buildConnectionResponse(id string, connection models.Connection) dtos.ConnectionResponse {
+	// Decrypt a copy to avoid modifying the original
+	utils.DecryptConnection(&connection)
+
+	var username string
+	if connection.Username != nil {
+		username = *connection.Username
+	}
+
+	return dtos.ConnectionResponse{
+		ID:                     id,
+		Type:                   connection.Type,
+		Host:                   connection.Host,
+		Port:                   connection.Port,
+		Username:               username,
+		Database:               connection.Database,
+		IsExampleDB:            connection.IsExampleDB,
+		UseSSL:                 connection.UseSSL,
+		SSLMode:                connection.SSLMode,
+		SSLCertURL:             connection.SSLCertURL,
+		SSLKeyURL:              connection.SSLKeyURL,
+		SSLRootCertURL:         connection.SSLRootCertURL,
+		SSLCert:                connection.SSLCert,
+		SSLRootCert:            connection.SSLRootCert,
+		HTTPPath:               connection.HTTPPath,
+		AuthMode:               connection.AuthMode,
+		AzureTenantID:          connection.AzureTenantID,
+		AzureClientID:          connection.AzureClientID,
+		SocketPath:             connection.SocketPath,
+		ProxyURL:               redactedProxyURL(connection.ProxyURL),
+		ReadPreference:         connection.ReadPreference,
+		ReadConcern:            connection.ReadConcern,
+		WriteConcern:           connection.WriteConcern,
+		SchemaSampleSize:       connection.SchemaSampleSize,
+		SchemaMaxDepth:         connection.SchemaMaxDepth,
+		SchemaArraySampleSize:  connection.SchemaArraySampleSize,
+		SchemaNewestSampleSize: connection.SchemaNewestSampleSize,
+		QueryTimeoutSeconds:    connection.QueryTimeoutSeconds,
+		ResultDisplayRows:      connection.ResultDisplayRows,
+		MaxResultRows:          connection.MaxResultRows,
+		MaxConcurrentQueries:   connection.MaxConcurrentQueries,
+		ReadReplicas:           toDTOReadReplicas(connection.ReadReplicas),
+		AWSRegion:              connection.AWSRegion,
+		AWSAccessKeyID:         connection.AWSAccessKeyID,
+		KerberosRealm:          connection.KerberosRealm,
+		KerberosKDC:            connection.KerberosKDC,
+		KerberosServiceName:    connection.KerberosServiceName,
+	}
+}
+
 func (s *chatService) buildMessageResponse(msg *models.Message) *dtos.MessageResponse {
 	var userMessageID *string
 	if msg.UserMessageId != nil {
@@ -1343,33 +1724,33 @@ func (s *chatService) verifyQueryOwnership(_, chatID, messageID, queryID string)
 	// Get chat
 	chatObjID, err := primitive.ObjectIDFromHex(chatID)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("invalid chat ID format")
+		return nil, nil, nil, apperrors.New("INVALID_CHAT_ID", "invalid chat ID format")
 	}
 	chat, err := s.chatRepo.FindByID(chatObjID)
 
 	// Convert IDs to ObjectIDs
 	msgObjID, err := primitive.ObjectIDFromHex(messageID)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("invalid message ID format")
+		return nil, nil, nil, apperrors.New("INVALID_MESSAGE_ID", "invalid message ID format")
 	}
 
 	queryObjID, err := primitive.ObjectIDFromHex(queryID)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("invalid query ID format")
+		return nil, nil, nil, apperrors.New("INVALID_QUERY_ID", "invalid query ID format")
 	}
 
 	// Get message
 	msg, err := s.chatRepo.FindMessageByID(msgObjID)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("failed to fetch message: %v", err)
+		return nil, nil, nil, apperrors.New("FAILED_TO_FETCH_MESSAGE", "failed to fetch message: {error}").With("error", err)
 	}
 	if msg == nil {
-		return nil, nil, nil, fmt.Errorf("message not found")
+		return nil, nil, nil, apperrors.New("MESSAGE_NOT_FOUND", "message not found")
 	}
 
 	// Verify chat ownership
 	if msg.ChatID.Hex() != chatID {
-		return nil, nil, nil, fmt.Errorf("message does not belong to this chat")
+		return nil, nil, nil, apperrors.New("MESSAGE_NOT_IN_CHAT", "message does not belong to this chat")
 	}
 
 	log.Printf("ChatService -> verifyQueryOwnership -> msgObjID: %+v", msgObjID)
@@ -1388,12 +1769,21 @@ func (s *chatService) verifyQueryOwnership(_, chatID, messageID, queryID string)
 		}
 	}
 	if targetQuery == nil {
-		return nil, nil, nil, fmt.Errorf("query not found in message")
+		return nil, nil, nil, apperrors.New("QUERY_NOT_FOUND_IN_MESSAGE", "query not found in message")
 	}
 
 	return chat, msg, targetQuery, nil
 }
 
+// HandleQueryExecuted records the lineage of a query committed on the chat's connection & the tables it used
+func (s *chatService) HandleQueryExecuted(chatID, messageID, queryID, dbType, query string, isRollback bool) {
+	s.lineageService.RecordQuery(chatID, messageID, queryID, dbType, query, isRollback)
+	// Rollbacks undo a query, they don't tell which tables the chat is about
+	if !isRollback {
+		s.tableUsageService.RecordQuery(chatID, dbType, query)
+	}
+}
+
 // GetSelectedCollections retrieves the selected collections for a chat
 // NOTE: This is used for UI display
 func (s *chatService) GetSelectedCollections(chatID string) (string, error) {
@@ -1438,19 +1828,19 @@ func (s *chatService) GetAllTables(ctx context.Context, userID, chatID string) (
 
 	select {
 	case <-ctx.Done():
-		return nil, http.StatusRequestTimeout, fmt.Errorf("request timed out")
+		return nil, http.StatusRequestTimeout, apperrors.New("REQUEST_TIMEOUT", "request timed out")
 	default:
 		// Get chat details first
 		chatObjID, err := primitive.ObjectIDFromHex(chatID)
 		if err != nil {
 			log.Printf("ChatService -> GetAllTables -> Error getting chatID: %v", err)
-			return nil, http.StatusBadRequest, fmt.Errorf("invalid chat ID format")
+			return nil, http.StatusBadRequest, apperrors.New("INVALID_CHAT_ID", "invalid chat ID format")
 		}
 
 		chat, err := s.chatRepo.FindByID(chatObjID)
 		if err != nil {
 			log.Printf("ChatService -> GetAllTables -> Error finding chat: %v", err)
-			return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch chat: %v", err)
+			return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CHAT", "failed to fetch chat: {error}").With("error", err)
 		}
 
 		if chat != nil {
@@ -1460,7 +1850,7 @@ func (s *chatService) GetAllTables(ctx context.Context, userID, chatID string) (
 
 		if chat == nil {
 			log.Printf("ChatService -> GetAllTables -> Chat not found for chatID: %s", chatID)
-			return nil, http.StatusNotFound, fmt.Errorf("chat not found")
+			return nil, http.StatusNotFound, apperrors.New("CHAT_NOT_FOUND", "chat not found")
 		}
 
 		// Get database connection
@@ -1470,24 +1860,49 @@ func (s *chatService) GetAllTables(ctx context.Context, userID, chatID string) (
 
 			// Connection not found, try to connect with proper config
 			connectErr := s.dbManager.Connect(chatID, userID, "", dbmanager.ConnectionConfig{
-				Type:         chat.Connection.Type,
-				Host:         chat.Connection.Host,
-				Port:         chat.Connection.Port,
-				Username:     chat.Connection.Username,
-				Password:     chat.Connection.Password,
-				Database:     chat.Connection.Database,
-				AuthDatabase: chat.Connection.AuthDatabase,
+				Type:                   chat.Connection.Type,
+				Host:                   chat.Connection.Host,
+				Port:                   chat.Connection.Port,
+				Username:               chat.Connection.Username,
+				Password:               chat.Connection.Password,
+				Database:               chat.Connection.Database,
+				AuthDatabase:           chat.Connection.AuthDatabase,
+				HTTPPath:               chat.Connection.HTTPPath,
+				AccessToken:            chat.Connection.AccessToken,
+				CredentialsJSON:        chat.Connection.CredentialsJSON,
+				AuthMode:               chat.Connection.AuthMode,
+				AzureTenantID:          chat.Connection.AzureTenantID,
+				AzureClientID:          chat.Connection.AzureClientID,
+				SocketPath:             chat.Connection.SocketPath,
+				ProxyURL:               chat.Connection.ProxyURL,
+				ReadPreference:         chat.Connection.ReadPreference,
+				ReadConcern:            chat.Connection.ReadConcern,
+				WriteConcern:           chat.Connection.WriteConcern,
+				SchemaSampleSize:       chat.Connection.SchemaSampleSize,
+				SchemaMaxDepth:         chat.Connection.SchemaMaxDepth,
+				SchemaArraySampleSize:  chat.Connection.SchemaArraySampleSize,
+				SchemaNewestSampleSize: chat.Connection.SchemaNewestSampleSize,
+				MaxConcurrentQueries:   chat.Connection.MaxConcurrentQueries,
+				ReadReplicas:           modelToDBManagerReadReplicas(chat.Connection.ReadReplicas),
+				AWSRegion:              chat.Connection.AWSRegion,
+				AWSAccessKeyID:         chat.Connection.AWSAccessKeyID,
+				AWSSecretAccessKey:     chat.Connection.AWSSecretAccessKey,
+				AzureClientSecret:      chat.Connection.AzureClientSecret,
+				KerberosRealm:          chat.Connection.KerberosRealm,
+				KerberosKDC:            chat.Connection.KerberosKDC,
+				KerberosServiceName:    chat.Connection.KerberosServiceName,
+				KerberosKeytab:         chat.Connection.KerberosKeytab,
 			})
 			if connectErr != nil {
 				log.Printf("ChatService -> GetAllTables -> Failed to connect: %v", connectErr)
-				return nil, http.StatusNotFound, fmt.Errorf("failed to establish database connection: %v", connectErr)
+				return nil, http.StatusNotFound, apperrors.New("FAILED_TO_ESTABLISH_DATABASE_CONNECTION", "failed to establish database connection: {error}").With("error", connectErr)
 			}
 
 			// Try to get connection again after connecting
 			dbConn, err = s.dbManager.GetConnection(chatID)
 			if err != nil {
 				log.Printf("ChatService -> GetAllTables -> Still failed to get connection after connect: %v", err)
-				return nil, http.StatusNotFound, fmt.Errorf("connection established but not ready yet: %v", err)
+				return nil, http.StatusNotFound, apperrors.New("CONNECTION_NOT_READY", "connection established but not ready yet: {error}").With("error", err)
 			}
 		}
 
@@ -1495,7 +1910,7 @@ func (s *chatService) GetAllTables(ctx context.Context, userID, chatID string) (
 		connInfo, exists := s.dbManager.GetConnectionInfo(chatID)
 		if !exists {
 			log.Printf("ChatService -> GetAllTables -> Connection info not found")
-			return nil, http.StatusNotFound, fmt.Errorf("connection info not found")
+			return nil, http.StatusNotFound, apperrors.New("CONNECTION_INFO_NOT_FOUND", "connection info not found")
 		}
 
 		// Convert the selectedCollections string to a slice
@@ -1520,7 +1935,7 @@ func (s *chatService) GetAllTables(ctx context.Context, userID, chatID string) (
 		schema, err := schemaManager.GetSchema(ctx, chatID, dbConn, connInfo.Config.Type, []string{})
 		if err != nil {
 			log.Printf("ChatService -> GetAllTables -> Error getting schema: %v", err)
-			return nil, http.StatusInternalServerError, fmt.Errorf("failed to get schema: %v", err)
+			return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_GET_SCHEMA", "failed to get schema: {error}").With("error", err)
 		}
 
 		// Convert schema tables to TableInfo objects
@@ -1553,3 +1968,217 @@ func (s *chatService) GetAllTables(ctx context.Context, userID, chatID string) (
 		}, http.StatusOK, nil
 	}
 }
+
+// connectionConfigFromRequest returns the db manager configuration of a connection request, used to test it
+func connectionConfigFromRequest(req *dtos.CreateConnectionRequest) *dbmanager.ConnectionConfig {
+	return &dbmanager.ConnectionConfig{
+		Type:                   req.Type,
+		Host:                   req.Host,
+		Port:                   req.Port,
+		Username:               &req.Username,
+		Password:               req.Password,
+		Database:               req.Database,
+		AuthDatabase:           req.AuthDatabase,
+		UseSSL:                 req.UseSSL,
+		SSLMode:                req.SSLMode,
+		SSLCertURL:             req.SSLCertURL,
+		SSLKeyURL:              req.SSLKeyURL,
+		SSLRootCertURL:         req.SSLRootCertURL,
+		SSLCert:                req.SSLCert,
+		SSLKey:                 req.SSLKey,
+		SSLRootCert:            req.SSLRootCert,
+		HTTPPath:               req.HTTPPath,
+		AccessToken:            req.AccessToken,
+		CredentialsJSON:        req.CredentialsJSON,
+		AuthMode:               req.AuthMode,
+		AzureTenantID:          req.AzureTenantID,
+		AzureClientID:          req.AzureClientID,
+		SocketPath:             req.SocketPath,
+		ProxyURL:               req.ProxyURL,
+		ReadPreference:         req.ReadPreference,
+		ReadConcern:            req.ReadConcern,
+		WriteConcern:           req.WriteConcern,
+		SchemaSampleSize:       req.SchemaSampleSize,
+		SchemaMaxDepth:         req.SchemaMaxDepth,
+		SchemaArraySampleSize:  req.SchemaArraySampleSize,
+		SchemaNewestSampleSize: req.SchemaNewestSampleSize,
+		MaxConcurrentQueries:   req.MaxConcurrentQueries,
+		ReadReplicas:           toDBManagerReadReplicas(req.ReadReplicas),
+		AWSRegion:              req.AWSRegion,
+		AWSAccessKeyID:         req.AWSAccessKeyID,
+		AWSSecretAccessKey:     req.AWSSecretAccessKey,
+		AzureClientSecret:      req.AzureClientSecret,
+		KerberosRealm:          req.KerberosRealm,
+		KerberosKDC:            req.KerberosKDC,
+		KerberosServiceName:    req.KerberosServiceName,
+		KerberosKeytab:         req.KerberosKeytab,
+	}
+}
+
+// connectionFromRequest returns the connection to store for a connection request, it must be encrypted before saving
+func connectionFromRequest(req *dtos.CreateConnectionRequest) models.Connection {
+	return models.Connection{
+		Type:                   req.Type,
+		Host:                   req.Host,
+		Port:                   req.Port,
+		Username:               &req.Username,
+		Password:               req.Password,
+		Database:               req.Database,
+		AuthDatabase:           req.AuthDatabase,
+		UseSSL:                 req.UseSSL,
+		SSLMode:                req.SSLMode,
+		SSLCertURL:             req.SSLCertURL,
+		SSLKeyURL:              req.SSLKeyURL,
+		SSLRootCertURL:         req.SSLRootCertURL,
+		SSLCert:                req.SSLCert,
+		SSLKey:                 req.SSLKey,
+		SSLRootCert:            req.SSLRootCert,
+		HTTPPath:               req.HTTPPath,
+		AccessToken:            req.AccessToken,
+		CredentialsJSON:        req.CredentialsJSON,
+		AuthMode:               req.AuthMode,
+		AzureTenantID:          req.AzureTenantID,
+		AzureClientID:          req.AzureClientID,
+		SocketPath:             req.SocketPath,
+		ProxyURL:               req.ProxyURL,
+		ReadPreference:         req.ReadPreference,
+		ReadConcern:            req.ReadConcern,
+		WriteConcern:           req.WriteConcern,
+		SchemaSampleSize:       req.SchemaSampleSize,
+		SchemaMaxDepth:         req.SchemaMaxDepth,
+		SchemaArraySampleSize:  req.SchemaArraySampleSize,
+		SchemaNewestSampleSize: req.SchemaNewestSampleSize,
+		QueryTimeoutSeconds:    req.QueryTimeoutSeconds,
+		ResultDisplayRows:      req.ResultDisplayRows,
+		MaxResultRows:          req.MaxResultRows,
+		MaxConcurrentQueries:   req.MaxConcurrentQueries,
+		ReadReplicas:           toModelReadReplicas(req.ReadReplicas),
+		AWSRegion:              req.AWSRegion,
+		AWSAccessKeyID:         req.AWSAccessKeyID,
+		AWSSecretAccessKey:     req.AWSSecretAccessKey,
+		AzureClientSecret:      req.AzureClientSecret,
+		KerberosRealm:          req.KerberosRealm,
+		KerberosKDC:            req.KerberosKDC,
+		KerberosServiceName:    req.KerberosServiceName,
+		KerberosKeytab:         req.KerberosKeytab,
+		Base:                   models.NewBase(),
+	}
+}
+
+// connectionCredentialsChanged returns true when a connection request points to another database or authenticates differently,
+// the existing connection must be decrypted
+func connectionCredentialsChanged(existing models.Connection, req *dtos.CreateConnectionRequest) bool {
+	return existing.Database != req.Database ||
+		existing.Host != req.Host ||
+		existing.Port != req.Port ||
+		existing.Username == nil || *existing.Username != req.Username ||
+		(req.Password != nil && existing.Password != nil && *existing.Password != *req.Password) ||
+		readReplicasChanged(existing.ReadReplicas, req.ReadReplicas) ||
+		sslCertificatesChanged(existing, req) ||
+		kerberosCredentialsChanged(existing, req) ||
+		stringPointersDiffer([2]*string{existing.ProxyURL, req.ProxyURL})
+}
+
+// redactedProxyURL returns the proxy URL of a connection without its password
+func redactedProxyURL(proxyURL *string) *string {
+	if proxyURL == nil {
+		return nil
+	}
+	redacted := dbmanager.RedactProxyURL(*proxyURL)
+	return &redacted
+}
+
+// restoreRedactedProxyURL keeps the stored proxy URL when a connection request sends back the redacted one of the response
+func restoreRedactedProxyURL(existing models.Connection, req *dtos.CreateConnectionRequest) {
+	if req.ProxyURL == nil || existing.ProxyURL == nil {
+		return
+	}
+	if *req.ProxyURL != *existing.ProxyURL && *req.ProxyURL == dbmanager.RedactProxyURL(*existing.ProxyURL) {
+		req.ProxyURL = existing.ProxyURL
+	}
+}
+
+// toModelReadReplicas converts the read replicas of a connection request for storage
+func toModelReadReplicas(replicas []dtos.ReadReplica) []models.ReadReplica {
+	if len(replicas) == 0 {
+		return nil
+	}
+	result := make([]models.ReadReplica, len(replicas))
+	for i, replica := range replicas {
+		result[i] = models.ReadReplica{Host: strings.TrimSpace(replica.Host), Port: replica.Port}
+	}
+	return result
+}
+
+// toDBManagerReadReplicas converts the read replicas of a connection request for the db manager
+func toDBManagerReadReplicas(replicas []dtos.ReadReplica) []dbmanager.ReadReplica {
+	return modelToDBManagerReadReplicas(toModelReadReplicas(replicas))
+}
+
+// modelToDBManagerReadReplicas converts the stored read replicas of a connection for the db manager, the replicas must be decrypted
+func modelToDBManagerReadReplicas(replicas []models.ReadReplica) []dbmanager.ReadReplica {
+	if len(replicas) == 0 {
+		return nil
+	}
+	result := make([]dbmanager.ReadReplica, len(replicas))
+	for i, replica := range replicas {
+		result[i] = dbmanager.ReadReplica{Host: replica.Host, Port: replica.Port}
+	}
+	return result
+}
+
+func toDTOReadReplicas(replicas []models.ReadReplica) []dtos.ReadReplica {
+	if len(replicas) == 0 {
+		return nil
+	}
+	result := make([]dtos.ReadReplica, len(replicas))
+	for i, replica := range replicas {
+		result[i] = dtos.ReadReplica{Host: replica.Host, Port: replica.Port}
+	}
+	return result
+}
+
+// readReplicasChanged returns true when the requested read replicas differ from the stored (decrypted) ones
+func readReplicasChanged(existing []models.ReadReplica, requested []dtos.ReadReplica) bool {
+	updated := toModelReadReplicas(requested)
+	if len(existing) != len(updated) {
+		return true
+	}
+	for i := range existing {
+		if existing[i].Host != updated[i].Host {
+			return true
+		}
+		if (existing[i].Port == nil) != (updated[i].Port == nil) || (existing[i].Port != nil && *existing[i].Port != *updated[i].Port) {
+			return true
+		}
+	}
+	return false
+}
+
+// sslCertificatesChanged returns true when the uploaded certificates of a connection differ from the stored ones
+func sslCertificatesChanged(existing models.Connection, requested *dtos.CreateConnectionRequest) bool {
+	return stringPointersDiffer(
+		[2]*string{existing.SSLCert, requested.SSLCert},
+		[2]*string{existing.SSLKey, requested.SSLKey},
+		[2]*string{existing.SSLRootCert, requested.SSLRootCert},
+	)
+}
+
+// kerberosCredentialsChanged returns true when the connection authenticates as another Kerberos principal
+func kerberosCredentialsChanged(existing models.Connection, requested *dtos.CreateConnectionRequest) bool {
+	return stringPointersDiffer(
+		[2]*string{existing.AuthMode, requested.AuthMode},
+		[2]*string{existing.KerberosRealm, requested.KerberosRealm},
+		[2]*string{existing.KerberosKDC, requested.KerberosKDC},
+		[2]*string{existing.KerberosKeytab, requested.KerberosKeytab},
+	)
+}
+
+func stringPointersDiffer(pairs ...[2]*string) bool {
+	for _, pair := range pairs {
+		if (pair[0] == nil) != (pair[1] == nil) || (pair[0] != nil && *pair[0] != *pair[1]) {
+			return true
+		}
+	}
+	return false
+}
@@ -3,14 +3,19 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"neobase-ai/config"
 	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
 	"neobase-ai/internal/constants"
 	"neobase-ai/internal/models"
 	"neobase-ai/internal/utils"
 	"neobase-ai/pkg/dbmanager"
+	"neobase-ai/pkg/llm"
 	"net/http"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -102,6 +107,18 @@ func (s *chatService) processLLMResponse(ctx context.Context, userID, chatID, us
 			break
 		}
 	}
+	// The first question of a chat is answered from the cache when it was asked on the same schema, before the schema is retrieved
+	cacheQuestion, cacheSchema, cacheable := cacheableQuestion(filteredMessages)
+
+	// The rows of the database are only sent to the LLM when the chat shares its data with the AI
+	shareDataWithAI := false
+	llmModel := ""
+	var llmSampling *models.LLMSampling
+	if chat, err := s.chatRepo.FindByID(chatObjID); err == nil && chat != nil {
+		shareDataWithAI = chat.Settings.ShareDataWithAI
+		llmModel = chat.Settings.LLMModel
+		llmSampling = chat.Settings.LLMSampling
+	}
 
 	// Helper function to check cancellation
 	checkCancellation := func() bool {
@@ -124,6 +141,41 @@ func (s *chatService) processLLMResponse(ctx context.Context, userID, chatID, us
 		return nil, fmt.Errorf("operation cancelled")
 	}
 
+	// Databases with many tables only get the schema of the tables relevant to the request
+	if len(filteredMessages) > 0 {
+		filteredMessages = s.withRetrievedSchema(ctx, chatID, chatObjID, filteredMessages)
+	}
+
+	// Operational questions like "why is the app slow right now" need the current state of the database, not just the schema.
+	// The running statements hold the values of the application's queries, they're only sent when the chat shares its data with the AI.
+	if len(filteredMessages) > 0 && shareDataWithAI {
+		lastMessage := filteredMessages[len(filteredMessages)-1]
+		if userMsg, ok := lastMessage.Content["user_message"].(string); ok && lastMessage.Role == string(constants.MessageTypeUser) && isOperationalQuestion(userMsg) {
+			if !synchronous || allowSSEUpdates {
+				s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
+					Event: "ai-response-step",
+					Data:  "Checking the queries & locks currently active on the database..",
+				})
+			}
+			filteredMessages = s.withLiveActivity(ctx, chatID, filteredMessages)
+		}
+	}
+
+	// Generated queries must only use the syntax the server version supports
+	if len(filteredMessages) > 0 {
+		filteredMessages = s.withServerFeatures(chatID, filteredMessages)
+	}
+
+	// Long-lived chats focus on a few tables, the LLM is pointed to them
+	if len(filteredMessages) > 0 {
+		filteredMessages = s.withRelevantTables(chatObjID, filteredMessages)
+	}
+
+	// The examples the user registered for the connection show the LLM how its domain maps to the schema
+	if len(filteredMessages) > 0 {
+		filteredMessages = s.withQueryExamples(chatObjID, filteredMessages)
+	}
+
 	if !synchronous || allowSSEUpdates {
 		s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
 			Event: "ai-response-step",
@@ -140,8 +192,78 @@ func (s *chatService) processLLMResponse(ctx context.Context, userID, chatID, us
 		return nil, fmt.Errorf("operation cancelled")
 	}
 
-	// Generate LLM response
-	response, err := s.llmClient.GenerateResponse(ctx, filteredMessages, connInfo.Config.Type)
+	// Users who used their monthly token quota can't generate responses until it resets
+	if _, err := s.llmUsageService.CheckQuota(userID); err != nil {
+		if !synchronous || allowSSEUpdates {
+			s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
+				Event: "ai-response-error",
+				Data:  map[string]string{"error": "Error: " + err.Error()},
+			})
+		}
+		return nil, err
+	}
+
+	// Generate LLM response with the client of the user's organization
+	llmClient, err := s.llmResolver.ResolveLLMClient(ctx, userID, llmModel)
+	if err != nil {
+		if !synchronous || allowSSEUpdates {
+			s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
+				Event: "ai-response-error",
+				Data:  map[string]string{"error": "Error: " + err.Error()},
+			})
+		}
+		return nil, fmt.Errorf("failed to resolve LLM client: %v", err)
+	}
+
+	// The tokens of the call are stored with the assistant message, the prompts edited by the operators replace the built-in ones
+	usage := &llm.Usage{}
+	llmCtx := llm.WithPromptOverrides(llm.WithUsage(ctx, usage), s.promptTemplates)
+	llmCtx = s.withChatSampling(llmCtx, chatObjID)
+
+	// Long chats exceeding the context of the model have their older messages summarized instead of being cut
+	filteredMessages = s.withConversationSummary(llmCtx, llmClient, chatObjID, connInfo.Config.Type, filteredMessages)
+
+	// The LLM can read the schema of tables, explain queries & run read-only ones before answering
+	generateCtx := llm.WithTools(llmCtx, &chatToolExecutor{
+		dbManager: s.dbManager,
+		chatID:    chatID,
+		shareData: shareDataWithAI,
+		onCall: func(name string) {
+			if !synchronous || allowSSEUpdates {
+				s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
+					Event: "ai-response-step",
+					Data:  toolCallStep(name),
+				})
+			}
+		},
+	}, config.Env.LLMMaxToolRounds)
+
+	// Chats sending another prompt, model or sampling for the question don't share their cached responses
+	var response, cacheVariant string
+	fromCache := false
+	if cacheable {
+		cacheVariant = llmResponseCacheVariant(s.promptTemplates.TemplatesChecksum(connInfo.Config.Type), llmModel, llmSampling, filteredMessages)
+		response, fromCache = s.llmResponseCache.Get(ctx, connInfo.Config.Type, cacheSchema, cacheVariant, cacheQuestion)
+	}
+
+	// Clients able to stream send the assistant message as it is typed, the queries are only sent with the complete response
+	if fromCache {
+		log.Printf("processLLMResponse -> answered from the LLM response cache for chat %s", chatID)
+	} else if streamingClient, ok := llmClient.(llm.StreamingClient); ok && (!synchronous || allowSSEUpdates) {
+		response, err = streamingClient.GenerateResponseStream(generateCtx, filteredMessages, connInfo.Config.Type, func(chunk string) {
+			s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
+				Event: "ai-response-chunk",
+				Data:  chunk,
+			})
+		})
+	} else {
+		response, err = llmClient.GenerateResponse(generateCtx, filteredMessages, connInfo.Config.Type)
+	}
+	// A response not matching the schema is repaired below
+	var invalidResponseErr *llm.InvalidResponseError
+	if errors.As(err, &invalidResponseErr) {
+		response, err = invalidResponseErr.Response, nil
+	}
 	if err != nil {
 		if !synchronous || allowSSEUpdates {
 			s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
@@ -166,168 +288,93 @@ func (s *chatService) processLLMResponse(ctx context.Context, userID, chatID, us
 		})
 	}
 
-	var jsonResponse map[string]interface{}
-	if err := json.Unmarshal([]byte(response), &jsonResponse); err != nil {
-		s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
-			Event: "ai-response-error",
-			Data:  map[string]string{"error": "Error: " + err.Error()},
-		})
+	// The response is validated before anything is saved, the LLM is asked to repair an invalid one
+	parsedResponse, jsonResponse, err := generateValidLLMResponse(llmCtx, llmClient, filteredMessages, connInfo.Config.Type, response, func(attempt int) {
+		if !synchronous || allowSSEUpdates {
+			s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
+				Event: "ai-response-step",
+				Data:  "The response was incomplete, asking NeoBase to fix it..",
+			})
+		}
+	})
+	if err != nil {
+		if !synchronous || allowSSEUpdates {
+			s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
+				Event: "ai-response-error",
+				Data:  map[string]string{"error": "Error: " + err.Error()},
+			})
+		}
+		return nil, err
 	}
 
-	queries := []models.Query{}
-	if jsonResponse["queries"] != nil {
-		for _, query := range jsonResponse["queries"].([]interface{}) {
-			queryMap := query.(map[string]interface{})
-			var exampleResult *string
-			log.Printf("processLLMResponse -> queryMap: %v", queryMap)
-			if queryMap["exampleResult"] != nil {
-				log.Printf("processLLMResponse -> queryMap[\"exampleResult\"]: %v", queryMap["exampleResult"])
-				result, _ := json.Marshal(queryMap["exampleResult"].([]interface{}))
-				exampleResult = utils.ToStringPtr(string(result))
-				log.Printf("processLLMResponse -> saving exampleResult: %v", *exampleResult)
-			} else {
-				exampleResult = nil
-				log.Println("processLLMResponse -> saving exampleResult: nil")
-			}
-
-			var rollbackDependentQuery *string
-			if queryMap["rollbackDependentQuery"] != nil {
-				rollbackDependentQuery = utils.ToStringPtr(queryMap["rollbackDependentQuery"].(string))
-			} else {
-				rollbackDependentQuery = nil
-			}
-
-			var estimateResponseTime *float64
-			// First check if the estimateResponseTime is a string, if not string & it is float then set value
-			if queryMap["estimateResponseTime"] != nil {
-				switch v := queryMap["estimateResponseTime"].(type) {
-				case string:
-					if f, err := strconv.ParseFloat(v, 64); err == nil {
-						estimateResponseTime = &f
-					} else {
-						defaultVal := float64(100)
-						estimateResponseTime = &defaultVal
-					}
-				case float64:
-					estimateResponseTime = &v
-				default:
-					defaultVal := float64(100)
-					estimateResponseTime = &defaultVal
-				}
-			} else {
-				defaultVal := float64(100)
-				estimateResponseTime = &defaultVal
-			}
-
-			log.Printf("processLLMResponse -> queryMap[\"pagination\"]: %v", queryMap["pagination"])
-			pagination := &models.Pagination{}
-			if queryMap["pagination"] != nil {
-				if queryMap["pagination"].(map[string]interface{})["paginatedQuery"] != nil {
-					pagination.PaginatedQuery = utils.ToStringPtr(queryMap["pagination"].(map[string]interface{})["paginatedQuery"].(string))
-					log.Printf("processLLMResponse -> pagination.PaginatedQuery: %v", *pagination.PaginatedQuery)
-				}
-				if queryMap["pagination"].(map[string]interface{})["countQuery"] != nil {
-					pagination.CountQuery = utils.ToStringPtr(queryMap["pagination"].(map[string]interface{})["countQuery"].(string))
-					log.Printf("processLLMResponse -> pagination.CountQuery: %v", *pagination.CountQuery)
-				}
-			}
-			var tables *string
-			if queryMap["tables"] != nil {
-				tables = utils.ToStringPtr(queryMap["tables"].(string))
-			}
-
-			if queryMap["collections"] != nil {
-				tables = utils.ToStringPtr(queryMap["collections"].(string))
-			}
-			var queryType *string
-			if queryMap["queryType"] != nil {
-				queryType = utils.ToStringPtr(queryMap["queryType"].(string))
-			}
-
-			var rollbackQuery *string
-			if queryMap["rollbackQuery"] != nil {
-				rollbackQuery = utils.ToStringPtr(queryMap["rollbackQuery"].(string))
-			}
+	// Destructive queries are blocked unless the chat allows them, the chat can't be read then they are blocked
+	allowDestructive := false
+	verifyQueries := false
+	if chat, err := s.chatRepo.FindByID(chatObjID); err == nil && chat != nil {
+		allowDestructive = chat.Settings.AllowDestructiveQueries
+		verifyQueries = chat.Settings.VerifyQueries
+	}
 
-			// Create the query object
-			query := models.Query{
-				ID:                     primitive.NewObjectID(),
-				Query:                  queryMap["query"].(string),
-				Description:            queryMap["explanation"].(string),
-				ExecutionTime:          nil,
-				ExampleExecutionTime:   int(*estimateResponseTime),
-				CanRollback:            queryMap["canRollback"].(bool),
-				IsCritical:             queryMap["isCritical"].(bool),
-				IsExecuted:             false,
-				IsRolledBack:           false,
-				ExampleResult:          exampleResult,
-				ExecutionResult:        nil,
-				Error:                  nil,
-				QueryType:              queryType,
-				Tables:                 tables,
-				RollbackQuery:          rollbackQuery,
-				RollbackDependentQuery: rollbackDependentQuery,
-				Pagination:             pagination,
+	// The queries failing to plan are corrected by the LLM before the user sees them, the cached responses are corrected ones
+	if verifyQueries && len(parsedResponse.Queries) > 0 {
+		if !synchronous || allowSSEUpdates {
+			s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
+				Event: "ai-response-step",
+				Data:  "Checking the plan of the queries..",
+			})
+		}
+		parsedResponse, jsonResponse = s.verifyQueryPlans(llmCtx, llmClient, filteredMessages, chatID, connInfo.Config.Type, parsedResponse, jsonResponse, func(attempt int) {
+			if !synchronous || allowSSEUpdates {
+				s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
+					Event: "ai-response-step",
+					Data:  "A query failed to plan, asking NeoBase to correct it..",
+				})
 			}
+		})
+	}
+	if cacheable && !fromCache {
+		if validResponse, err := json.Marshal(jsonResponse); err == nil {
+			s.llmResponseCache.Set(ctx, connInfo.Config.Type, cacheSchema, cacheVariant, cacheQuestion, string(validResponse))
+		}
+	}
 
-			// Handle ClickHouse-specific metadata
-			if connInfo.Config.Type == constants.DatabaseTypeClickhouse {
-				metadata := make(map[string]interface{})
+	queries := make([]models.Query, 0, len(parsedResponse.Queries))
+	for i := range parsedResponse.Queries {
+		queryResponse := &parsedResponse.Queries[i]
+		query := queryResponse.toModelQuery()
+		query.ID = primitive.NewObjectID()
 
-				// Add ClickHouse-specific fields if they exist
-				if queryMap["engineType"] != nil {
-					metadata["engineType"] = queryMap["engineType"]
-				}
-				if queryMap["partitionKey"] != nil {
-					metadata["partitionKey"] = queryMap["partitionKey"]
-				}
-				if queryMap["orderByKey"] != nil {
-					metadata["orderByKey"] = queryMap["orderByKey"]
-				}
-
-				// Store metadata as JSON if we have any
-				if len(metadata) > 0 {
-					metadataJSON, err := json.Marshal(metadata)
-					if err == nil {
-						metadataStr := string(metadataJSON)
-						query.Metadata = &metadataStr
-					}
-				}
-			}
+		// Index creations & aggregation writes have a known rollback, it does not depend on the LLM
+		if connInfo.Config.Type == constants.DatabaseTypeMongoDB {
+			setMongoDBRollback(&query)
+		}
 
-			queries = append(queries, query)
+		// Handle ClickHouse-specific metadata
+		if connInfo.Config.Type == constants.DatabaseTypeClickhouse {
+			query.Metadata = queryResponse.clickhouseMetadata()
 		}
+
+		applyQueryGuardrail(&query, connInfo.Config.Type, allowDestructive)
+		s.previewAffectedRows(ctx, chatID, &query)
+		queries = append(queries, query)
 	}
 
 	log.Printf("processLLMResponse -> queries: %v", queries)
 
 	// Extract action buttons from the LLM response
-	var actionButtons []models.ActionButton
-	if jsonResponse["actionButtons"] != nil {
-		actionButtonsArray := jsonResponse["actionButtons"].([]interface{})
-		if len(actionButtonsArray) > 0 {
-			actionButtons = make([]models.ActionButton, 0, len(actionButtonsArray))
-			for _, btn := range actionButtonsArray {
-				btnMap := btn.(map[string]interface{})
-				actionButton := models.ActionButton{
-					ID:        primitive.NewObjectID(),
-					Label:     btnMap["label"].(string),
-					Action:    btnMap["action"].(string),
-					IsPrimary: btnMap["isPrimary"].(bool),
-				}
-				actionButtons = append(actionButtons, actionButton)
-			}
-		}
-	} else {
-		actionButtons = []models.ActionButton{}
+	actionButtons := make([]models.ActionButton, 0, len(parsedResponse.ActionButtons))
+	for _, btn := range parsedResponse.ActionButtons {
+		actionButtons = append(actionButtons, models.ActionButton{
+			ID:        primitive.NewObjectID(),
+			Label:     *btn.Label,
+			Action:    *btn.Action,
+			IsPrimary: btn.IsPrimary != nil && *btn.IsPrimary,
+		})
 	}
+	// The options of a clarification are answered through the clarify endpoint, which resumes the generation
+	actionButtons = append(actionButtons, parsedResponse.clarificationButtons()...)
 
-	assistantMessage := ""
-	if jsonResponse["assistantMessage"] != nil {
-		assistantMessage = jsonResponse["assistantMessage"].(string)
-	} else {
-		assistantMessage = ""
-	}
+	assistantMessage := parsedResponse.messageContent()
 
 	// Find existing AI response message
 	existingMessage, err := s.chatRepo.FindNextMessageByID(userMessageObjID)
@@ -378,6 +425,7 @@ func (s *chatService) processLLMResponse(ctx context.Context, userID, chatID, us
 			"assistant_response": jsonResponse,
 		}
 		existingLLMMsg.Content = formattedJsonResponse
+		existingLLMMsg.Usage = addLLMUsage(existingLLMMsg.Usage, usage)
 
 		if err := s.llmRepo.UpdateMessage(existingLLMMsg.ID, existingLLMMsg); err != nil {
 			s.handleError(ctx, chatID, err)
@@ -447,6 +495,7 @@ func (s *chatService) processLLMResponse(ctx context.Context, userID, chatID, us
 		MessageID: chatResponseMsg.ID,
 		Content:   formattedJsonResponse,
 		Role:      string(constants.MessageTypeAssistant),
+		Usage:     addLLMUsage(nil, usage),
 	}
 	if err := s.llmRepo.CreateMessage(llmMsg); err != nil {
 		log.Printf("processLLMResponse -> Error saving LLM message: %v", err)
@@ -482,6 +531,91 @@ func (s *chatService) processLLMResponse(ctx context.Context, userID, chatID, us
 	}, nil
 }
 
+// applyQueryGuardrail flags the destructive queries as critical with the reason, they are blocked unless the chat allows destructive queries
+func applyQueryGuardrail(query *models.Query, dbType string, allowDestructive bool) {
+	query.IsBlocked = false
+	query.GuardrailReason = nil
+
+	reason := dbmanager.DestructiveQueryReason(dbType, query.Query)
+	if reason == "" {
+		return
+	}
+	log.Printf("applyQueryGuardrail -> destructive query (allowed: %v): %s", allowDestructive, reason)
+	query.IsCritical = true
+	query.GuardrailReason = &reason
+	query.IsBlocked = !allowDestructive
+}
+
+// previewAffectedRows sets the count & a sample of the rows an UPDATE or a DELETE would change, read with a SELECT of the same
+// predicate so the message shows them before the query is executed. The other queries & the writes that can't be previewed have none.
+func (s *chatService) previewAffectedRows(ctx context.Context, chatID string, query *models.Query) {
+	query.AffectedRowsPreview = nil
+	if query.IsBlocked {
+		return
+	}
+
+	preview, queryErr := s.dbManager.PreviewAffectedRows(ctx, chatID, query.Query)
+	if queryErr != nil {
+		if queryErr.Code != "PREVIEW_NOT_SUPPORTED" {
+			log.Printf("ChatService -> previewAffectedRows -> Affected rows not previewed: %+v", queryErr)
+		}
+		return
+	}
+	log.Printf("ChatService -> previewAffectedRows -> Query %s will affect %d rows", query.ID.Hex(), preview.Count)
+
+	affectedRows := &models.AffectedRowsPreview{Count: preview.Count}
+	if sample, err := json.Marshal(preview.Sample); err == nil {
+		affectedRows.Sample = utils.ToStringPtr(string(sample))
+	}
+	query.AffectedRowsPreview = affectedRows
+}
+
+// capturePreImage reads the rows a write is about to change, nil for the reads & the writes that can't be captured. The queries
+// of an open transaction are not captured, the capture can't see the changes the transaction didn't commit.
+func (s *chatService) capturePreImage(ctx context.Context, chatID string, query *models.Query) *dbmanager.PreImage {
+	if s.dbManager.GetTransactionSession(chatID) != nil {
+		return nil
+	}
+
+	preImage, queryErr := s.dbManager.CapturePreImage(ctx, chatID, query.Query)
+	if queryErr != nil {
+		if queryErr.Code != "PRE_IMAGE_NOT_SUPPORTED" {
+			log.Printf("ChatService -> capturePreImage -> Rows not captured: %+v", queryErr)
+		}
+		return nil
+	}
+	log.Printf("ChatService -> capturePreImage -> Captured %d rows of query %s", len(preImage.Rows), query.ID.Hex())
+	return preImage
+}
+
+// preImageJSON returns the captured rows as the JSON string the query stores, nil when none were captured
+func preImageJSON(preImage *dbmanager.PreImage) *string {
+	if preImage == nil {
+		return nil
+	}
+	rows, err := json.Marshal(preImage.Rows)
+	if err != nil {
+		return nil
+	}
+	return utils.ToStringPtr(string(rows))
+}
+
+// setMongoDBRollback sets the rollback of the MongoDB queries whose rollback is known, whatever the LLM suggested:
+// a createIndex is undone by dropping the index, a renameCollection by renaming the collection back, an aggregation writing with $out or $merge can't be undone
+func setMongoDBRollback(query *models.Query) {
+	if rollbackQuery, ok := dbmanager.MongoDBCreateIndexRollback(query.Query); ok {
+		query.RollbackQuery = &rollbackQuery
+		query.CanRollback = true
+	} else if rollbackQuery, ok := dbmanager.MongoDBRenameCollectionRollback(query.Query); ok {
+		query.RollbackQuery = &rollbackQuery
+		query.CanRollback = true
+	} else if dbmanager.IsMongoDBAggregationWrite(query.Query) {
+		query.RollbackQuery = nil
+		query.CanRollback = false
+		query.IsCritical = true
+	}
+}
+
 // Cancels the ongoing LLM processing for the given streamID
 func (s *chatService) CancelProcessing(userID, chatID, streamID string) {
 	s.processesMu.Lock()
@@ -530,30 +664,30 @@ func (s *chatService) ConnectDB(ctx context.Context, userID, chatID string, stre
 	// Get chat
 	chatObjID, err := primitive.ObjectIDFromHex(chatID)
 	if err != nil {
-		return http.StatusBadRequest, fmt.Errorf("invalid chat ID format")
+		return http.StatusBadRequest, apperrors.New("INVALID_CHAT_ID", "invalid chat ID format")
 	}
 
 	chat, err := s.chatRepo.FindByID(chatObjID)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return http.StatusNotFound, fmt.Errorf("chat not found")
+			return http.StatusNotFound, apperrors.New("CHAT_NOT_FOUND", "chat not found")
 		}
-		return http.StatusInternalServerError, fmt.Errorf("failed to fetch chat: %v", err)
+		return http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CHAT", "failed to fetch chat: {error}").With("error", err)
 	}
 
 	// Check if chat belongs to user
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		return http.StatusBadRequest, fmt.Errorf("invalid user ID format")
+		return http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
 	}
 
 	if chat.UserID != userObjID {
-		return http.StatusForbidden, fmt.Errorf("chat does not belong to user")
+		return http.StatusForbidden, apperrors.New("CHAT_ACCESS_DENIED", "chat does not belong to user")
 	}
 
 	// Check if connection details are present
 	if chat.Connection.Host == "" || chat.Connection.Database == "" {
-		return http.StatusBadRequest, fmt.Errorf("connection details are incomplete")
+		return http.StatusBadRequest, apperrors.New("CONNECTION_DETAILS_INCOMPLETE", "connection details are incomplete")
 	}
 
 	// Decrypt connection details
@@ -567,8 +701,12 @@ func (s *chatService) ConnectDB(ctx context.Context, userID, chatID string, stre
 			defaultPort = "5432"
 		case constants.DatabaseTypeYugabyteDB:
 			defaultPort = "5433"
-		case constants.DatabaseTypeMySQL:
+		case constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB, constants.DatabaseTypeSingleStore:
 			defaultPort = "3306"
+		case constants.DatabaseTypeDB2:
+			defaultPort = "50000"
+		case constants.DatabaseTypeDatabricks, constants.DatabaseTypeFirestore:
+			defaultPort = "443"
 		case constants.DatabaseTypeClickhouse:
 			defaultPort = "9000"
 		case constants.DatabaseTypeMongoDB:
@@ -579,28 +717,59 @@ func (s *chatService) ConnectDB(ctx context.Context, userID, chatID string, stre
 
 	// Connect to database
 	err = s.dbManager.Connect(chatID, userID, streamID, dbmanager.ConnectionConfig{
-		Type:           chat.Connection.Type,
-		Host:           chat.Connection.Host,
-		Port:           chat.Connection.Port,
-		Username:       chat.Connection.Username,
-		Password:       chat.Connection.Password,
-		Database:       chat.Connection.Database,
-		AuthDatabase:   chat.Connection.AuthDatabase, // Added AuthDatabase
-		UseSSL:         chat.Connection.UseSSL,
-		SSLMode:        chat.Connection.SSLMode,
-		SSLCertURL:     chat.Connection.SSLCertURL,
-		SSLKeyURL:      chat.Connection.SSLKeyURL,
-		SSLRootCertURL: chat.Connection.SSLRootCertURL,
+		Type:                   chat.Connection.Type,
+		Host:                   chat.Connection.Host,
+		Port:                   chat.Connection.Port,
+		Username:               chat.Connection.Username,
+		Password:               chat.Connection.Password,
+		Database:               chat.Connection.Database,
+		AuthDatabase:           chat.Connection.AuthDatabase, // Added AuthDatabase
+		UseSSL:                 chat.Connection.UseSSL,
+		SSLMode:                chat.Connection.SSLMode,
+		SSLCertURL:             chat.Connection.SSLCertURL,
+		SSLKeyURL:              chat.Connection.SSLKeyURL,
+		SSLRootCertURL:         chat.Connection.SSLRootCertURL,
+		SSLCert:                chat.Connection.SSLCert,
+		SSLKey:                 chat.Connection.SSLKey,
+		SSLRootCert:            chat.Connection.SSLRootCert,
+		HTTPPath:               chat.Connection.HTTPPath,
+		AccessToken:            chat.Connection.AccessToken,
+		CredentialsJSON:        chat.Connection.CredentialsJSON,
+		AuthMode:               chat.Connection.AuthMode,
+		AzureTenantID:          chat.Connection.AzureTenantID,
+		AzureClientID:          chat.Connection.AzureClientID,
+		SocketPath:             chat.Connection.SocketPath,
+		ProxyURL:               chat.Connection.ProxyURL,
+		ReadPreference:         chat.Connection.ReadPreference,
+		ReadConcern:            chat.Connection.ReadConcern,
+		WriteConcern:           chat.Connection.WriteConcern,
+		SchemaSampleSize:       chat.Connection.SchemaSampleSize,
+		SchemaMaxDepth:         chat.Connection.SchemaMaxDepth,
+		SchemaArraySampleSize:  chat.Connection.SchemaArraySampleSize,
+		SchemaNewestSampleSize: chat.Connection.SchemaNewestSampleSize,
+		MaxConcurrentQueries:   chat.Connection.MaxConcurrentQueries,
+		ReadReplicas:           modelToDBManagerReadReplicas(chat.Connection.ReadReplicas),
+		AWSRegion:              chat.Connection.AWSRegion,
+		AWSAccessKeyID:         chat.Connection.AWSAccessKeyID,
+		AWSSecretAccessKey:     chat.Connection.AWSSecretAccessKey,
+		AzureClientSecret:      chat.Connection.AzureClientSecret,
+		KerberosRealm:          chat.Connection.KerberosRealm,
+		KerberosKDC:            chat.Connection.KerberosKDC,
+		KerberosServiceName:    chat.Connection.KerberosServiceName,
+		KerberosKeytab:         chat.Connection.KerberosKeytab,
 	})
 
 	if err != nil {
 		if strings.Contains(err.Error(), "already exists") {
 			log.Printf("ChatService -> ConnectDB -> Database already connected, skipping connection")
 		} else {
-			return http.StatusBadRequest, fmt.Errorf("failed to connect: %v", err)
+			return http.StatusBadRequest, apperrors.New("FAILED_TO_CONNECT", "failed to connect: {error}").With("error", err)
 		}
 	}
 
+	// Changes made through NeoBase are attributed in the audit log when enabled for the chat
+	s.dbManager.SetAuditChanges(chatID, chat.Settings.AuditChanges)
+
 	return http.StatusOK, nil
 }
 
@@ -614,13 +783,125 @@ func (s *chatService) DisconnectDB(ctx context.Context, userID, chatID string, s
 
 	if err := s.dbManager.Disconnect(chatID, userID, false); err != nil {
 		log.Printf("ChatService -> DisconnectDB -> failed to disconnect: %v", err)
-		return http.StatusBadRequest, fmt.Errorf("failed to disconnect: %v", err)
+		return http.StatusBadRequest, apperrors.New("FAILED_TO_DISCONNECT", "failed to disconnect: {error}").With("error", err)
 	}
 
 	log.Printf("ChatService -> DisconnectDB -> disconnected from chat: %s", chatID)
 	return http.StatusOK, nil
 }
 
+// explainQuery returns the plan of a query in the execution result without running it, the query is left as it was
+func (s *chatService) explainQuery(ctx context.Context, userID, chatID string, req *dtos.ExecuteQueryRequest, query *models.Query) (*dtos.QueryExecutionResponse, uint32, error) {
+	log.Printf("ChatService -> explainQuery -> Explaining queryID: %s of chatID: %s", req.QueryID, chatID)
+
+	if _, status, err := s.getConnectedChat(ctx, userID, chatID); err != nil {
+		return nil, status, err
+	}
+	if req.UsePrimary {
+		ctx = dbmanager.WithPrimaryRouting(ctx)
+	}
+
+	startTime := time.Now()
+	plan, queryErr := s.dbManager.ExplainQuery(ctx, chatID, query.Query)
+	if queryErr != nil {
+		log.Printf("ChatService -> explainQuery -> Explain failed: %+v", queryErr)
+		details := queryErr.Details
+		if details == "" {
+			details = queryErr.Message
+		}
+		return nil, http.StatusBadRequest, apperrors.New(queryErr.Code, "{error}").With("error", details)
+	}
+	executionTime := int(time.Since(startTime).Milliseconds())
+
+	var executionResult interface{}
+	if err := json.Unmarshal([]byte(plan), &executionResult); err != nil {
+		executionResult = plan
+	}
+
+	return &dtos.QueryExecutionResponse{
+		ChatID:          chatID,
+		MessageID:       req.MessageID,
+		QueryID:         req.QueryID,
+		IsExecuted:      query.IsExecuted,
+		IsRolledBack:    query.IsRolledBack,
+		ExplainOnly:     true,
+		ExecutionTime:   &executionTime,
+		ExecutionResult: executionResult,
+	}, http.StatusOK, nil
+}
+
+// queryTimeout returns the timeout of an execution: the request's, else the connection's, else QUERY_TIMEOUT_SECONDS. The
+// connection's is capped at MAX_QUERY_TIMEOUT_SECONDS, which may have been lowered since it was saved, a larger request is refused.
+func queryTimeout(connection models.Connection, req *dtos.ExecuteQueryRequest) (time.Duration, error) {
+	seconds := config.Env.QueryTimeoutSeconds
+	if seconds <= 0 {
+		seconds = int(dbmanager.DefaultQueryTimeout.Seconds())
+	}
+	maxSeconds := config.Env.MaxQueryTimeoutSeconds
+	if maxSeconds < seconds {
+		maxSeconds = seconds
+	}
+
+	switch {
+	case req.TimeoutSeconds != nil:
+		if *req.TimeoutSeconds <= 0 || *req.TimeoutSeconds > maxSeconds {
+			return 0, apperrors.New("INVALID_QUERY_TIMEOUT", "timeout_seconds must be between 1 and {max}").With("max", maxSeconds)
+		}
+		seconds = *req.TimeoutSeconds
+	case connection.QueryTimeoutSeconds != nil && *connection.QueryTimeoutSeconds > 0:
+		seconds = *connection.QueryTimeoutSeconds
+		if seconds > maxSeconds {
+			seconds = maxSeconds
+		}
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// withAutoLimit limits the SELECTs & finds without a limit to AUTO_LIMIT_ROWS rows, at most the rows read from their result,
+// unless the request is a full scan
+func withAutoLimit(ctx context.Context, maxRows int, fullScan bool) context.Context {
+	if fullScan || config.Env.AutoLimitRows <= 0 {
+		return ctx
+	}
+	return dbmanager.WithAutoLimit(ctx, min(config.Env.AutoLimitRows, maxRows))
+}
+
+// resultRowLimits returns the rows of a result shown & read per page, and the rows read from it at most: the request's, else the
+// connection's, else RESULT_DISPLAY_ROWS & MAX_RESULT_ROWS. The connection's are capped at MAX_RESULT_ROWS, a larger request is
+// refused. The rows shown never exceed the rows read.
+func resultRowLimits(connection models.Connection, req *dtos.ExecuteQueryRequest) (displayRows int, maxRows int, err error) {
+	serverMaxRows := config.Env.MaxResultRows
+	if serverMaxRows <= 0 {
+		serverMaxRows = dbmanager.DefaultMaxResultRows
+	}
+	displayRows = config.Env.ResultDisplayRows
+	if displayRows <= 0 {
+		displayRows = dbmanager.DefaultResultDisplayRows
+	}
+
+	maxRows = serverMaxRows
+	switch {
+	case req.MaxRows != nil:
+		if *req.MaxRows <= 0 || *req.MaxRows > serverMaxRows {
+			return 0, 0, apperrors.New("INVALID_RESULT_ROWS", "max_rows must be between 1 and {max}").With("max", serverMaxRows)
+		}
+		maxRows = *req.MaxRows
+	case connection.MaxResultRows != nil && *connection.MaxResultRows > 0:
+		maxRows = min(*connection.MaxResultRows, serverMaxRows)
+	}
+
+	switch {
+	case req.DisplayRows != nil:
+		if *req.DisplayRows <= 0 || *req.DisplayRows > maxRows {
+			return 0, 0, apperrors.New("INVALID_RESULT_ROWS", "display_rows must be between 1 and {max}").With("max", maxRows)
+		}
+		displayRows = *req.DisplayRows
+	case connection.ResultDisplayRows != nil && *connection.ResultDisplayRows > 0:
+		displayRows = *connection.ResultDisplayRows
+	}
+	return min(displayRows, maxRows), maxRows, nil
+}
+
 // ExecuteQuery executes a query, runs realtime query to connected database, stores the result in execution_result etc...
 func (s *chatService) ExecuteQuery(ctx context.Context, userID, chatID string, req *dtos.ExecuteQueryRequest) (*dtos.QueryExecutionResponse, uint32, error) {
 	// Verify message and query ownership
@@ -629,12 +910,55 @@ func (s *chatService) ExecuteQuery(ctx context.Context, userID, chatID string, r
 		return nil, http.StatusForbidden, err
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, 1*time.Minute)
+	// The values of the named parameters are bound by the drivers, the ones of the last execution are reused when none are sent
+	params := query.Params
+	if req.Params != nil {
+		params = req.Params
+	}
+	if names := dbmanager.QueryParameterNames(chat.Connection.Type, query.Query); len(params) == 0 && len(names) > 0 {
+		return nil, http.StatusBadRequest, apperrors.New("QUERY_PARAMETERS_REQUIRED", "the query has parameters, send their values in params: {names}").With("names", strings.Join(names, ", "))
+	}
+	if len(params) > 0 {
+		ctx = dbmanager.WithQueryParams(ctx, params)
+	}
+
+	// Explaining doesn't run the query, so the guardrail doesn't apply & destructive queries can be vetted before allowing them
+	if req.ExplainOnly {
+		return s.explainQuery(ctx, userID, chatID, req, query)
+	}
+
+	// The guardrail is checked again, the chat may have allowed destructive queries since the query was blocked
+	if reason := dbmanager.DestructiveQueryReason(chat.Connection.Type, query.Query); reason != "" && !chat.Settings.AllowDestructiveQueries {
+		return nil, http.StatusForbidden, apperrors.New("DESTRUCTIVE_QUERY_BLOCKED", "query blocked by the guardrail: {reason}, allow destructive queries in the chat settings to execute it").With("reason", reason)
+	}
+
+	// The rows shown in the chat & read per page, and the rows read from the result at most
+	displayRows, maxRows, err := resultRowLimits(chat.Connection, req)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	ctx = dbmanager.WithResultRowLimit(ctx, maxRows)
+	ctx = withAutoLimit(ctx, maxRows, req.FullScan)
+
+	// Background jobs run the query with the timeout of the job
+	if !dbmanager.HasQueryTimeout(ctx) {
+		timeout, err := queryTimeout(chat.Connection, req)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		ctx = dbmanager.WithQueryTimeout(ctx, timeout)
+	}
+	ctx, cancel := context.WithTimeout(ctx, dbmanager.QueryTimeout(ctx))
 	defer cancel()
 
+	// Replicas can lag behind the primary, the user can read rows just written from the primary
+	if req.UsePrimary {
+		ctx = dbmanager.WithPrimaryRouting(ctx)
+	}
+
 	select {
 	case <-ctx.Done():
-		return nil, http.StatusRequestTimeout, fmt.Errorf("query execution cancelled or timed out")
+		return nil, http.StatusRequestTimeout, apperrors.New("QUERY_EXECUTION_CANCELLED", "query execution cancelled or timed out")
 	default:
 		log.Printf("ChatService -> ExecuteQuery -> msg: %+v", msg)
 	}
@@ -811,29 +1135,45 @@ func (s *chatService) ExecuteQuery(ctx context.Context, userID, chatID string, r
 		log.Printf("ChatService -> ExecuteQuery -> totalRecordsCount: %+v", *totalRecordsCount)
 	}
 	queryToExecute := query.Query
+	firstPageQuery := ""
+	pageSize := 0
 
 	if query.Pagination != nil && query.Pagination.PaginatedQuery != nil && *query.Pagination.PaginatedQuery != "" {
-		log.Printf("ChatService -> ExecuteQuery -> query.Pagination.PaginatedQuery is present, will use it to cap the result to 50 records. query.Pagination.PaginatedQuery: %+v", *query.Pagination.PaginatedQuery)
-		// Capping the result to 50 records by default and skipping 0 records, we do not need to run the query.Query as we have better paginated query & already have the total records count
-
-		queryToExecute = strings.Replace(*query.Pagination.PaginatedQuery, "offset_size", strconv.Itoa(0), 1)
+		log.Printf("ChatService -> ExecuteQuery -> query.Pagination.PaginatedQuery is present, will use it to cap the result to %d records. query.Pagination.PaginatedQuery: %+v", displayRows, *query.Pagination.PaginatedQuery)
+		// Capping the result to the rows shown and skipping 0 records, we do not need to run the query.Query as we have better paginated query & already have the total records count
+		paginatedQuery := *query.Pagination.PaginatedQuery
+		// The pages are as large as the result shown, so the next pages follow it
+		if sizedQuery, ok := dbmanager.WithPageSize(chat.Connection.Type, paginatedQuery, displayRows); ok {
+			paginatedQuery, pageSize = sizedQuery, displayRows
+		}
+		firstPageQuery = strings.Replace(paginatedQuery, "offset_size", strconv.Itoa(0), 1)
+		queryToExecute = firstPageQuery
 	}
 
+	// The rows the query changes are captured before it runs, its rollback restores them even after later changes
+	preImage := s.capturePreImage(ctx, chatID, query)
+
 	log.Printf("ChatService -> ExecuteQuery -> queryToExecute: %+v", queryToExecute)
 	// Execute query, we will be executing the pagination.paginatedQuery if it exists, else the query.Query
+	startedAt := time.Now()
 	result, queryErr := s.dbManager.ExecuteQuery(ctx, chatID, req.MessageID, req.QueryID, req.StreamID, queryToExecute, *query.QueryType, false, false)
 	if queryErr != nil {
 		// Checking if executed query was paginatedQuery, if so, let's try to execute it again with the original query
-		if query.Pagination != nil && query.Pagination.PaginatedQuery != nil && *query.Pagination.PaginatedQuery != "" && queryToExecute == strings.Replace(*query.Pagination.PaginatedQuery, "offset_size", strconv.Itoa(0), 1) {
+		if firstPageQuery != "" && queryToExecute == firstPageQuery {
 			log.Printf("ChatService -> ExecuteQuery -> query.Pagination.PaginatedQuery was executed but faced an error, will try to execute the original query")
 			queryToExecute = query.Query
 			result, queryErr = s.dbManager.ExecuteQuery(ctx, chatID, req.MessageID, req.QueryID, req.StreamID, queryToExecute, *query.QueryType, false, false)
 		}
 	}
+
+	// The user may have left the chat while the query was running
+	go s.notifyQueryFinished(userID, chatID, req.MessageID, req.QueryID, result, queryErr)
+	s.queryHistory.RecordExecution(userID, chat, msg.ID, query.ID, query.Query, query.QueryType, false, startedAt, result, queryErr)
+
 	if queryErr != nil {
 		log.Printf("ChatService -> ExecuteQuery -> queryErr: %+v", queryErr)
 		if queryErr.Code == "FAILED_TO_START_TRANSACTION" || strings.Contains(queryErr.Message, "context deadline exceeded") || strings.Contains(queryErr.Message, "context canceled") {
-			return nil, http.StatusRequestTimeout, fmt.Errorf("query execution timed out")
+			return nil, http.StatusRequestTimeout, apperrors.New("QUERY_EXECUTION_TIMEOUT", "query execution timed out")
 		}
 
 		processCompleted := make(chan bool)
@@ -858,6 +1198,7 @@ func (s *chatService) ExecuteQuery(ctx context.Context, userID, chatID string, r
 							Details: queryErr.Details,
 						}
 						(*msg.Queries)[i].ActionAt = utils.ToStringPtr(time.Now().Format(time.RFC3339))
+						(*msg.Queries)[i].Params = params
 						break
 					}
 				}
@@ -982,8 +1323,11 @@ func (s *chatService) ExecuteQuery(ctx context.Context, userID, chatID string, r
 		}, http.StatusOK, nil
 	}
 
-	// Checking if the result record is a list with > 50 records, then cap it to 50 records.
-	// Then we need to save capped 50 results in DB
+	// A result too large for the chat is written whole to the result store once the capped rows are returned as its preview
+	spillResult := s.needsResultSpill(chat, query, result, totalRecordsCount, maxRows)
+
+	// Checking if the result record is a list with more records than the rows shown, then cap it to the rows shown.
+	// Then we need to save the capped results in DB
 	log.Printf("ChatService -> ExecuteQuery -> result: %+v", result)
 	log.Printf("ChatService -> ExecuteQuery -> result.ResultJSON: %+v", result.ResultJSON)
 
@@ -1007,12 +1351,12 @@ func (s *chatService) ExecuteQuery(ctx context.Context, userID, chatID string, r
 	if len(resultListFormatting) > 0 {
 		log.Printf("ChatService -> ExecuteQuery -> resultListFormatting: %+v", resultListFormatting)
 		formattedResultJSON = resultListFormatting
-		if len(resultListFormatting) > 50 {
-			log.Printf("ChatService -> ExecuteQuery -> resultListFormatting length > 50")
-			formattedResultJSON = resultListFormatting[:50] // Cap the result to 50 records
+		if len(resultListFormatting) > displayRows {
+			log.Printf("ChatService -> ExecuteQuery -> resultListFormatting length > %d", displayRows)
+			formattedResultJSON = resultListFormatting[:displayRows] // Cap the result to the rows shown
 
-			// Cap the result.ResultJSON to 50 records
-			cappedResults, err := json.Marshal(resultListFormatting[:50])
+			// Cap the result.ResultJSON to the rows shown
+			cappedResults, err := json.Marshal(resultListFormatting[:displayRows])
 			if err != nil {
 				log.Printf("ChatService -> ExecuteQuery -> Error marshaling capped results: %v", err)
 			} else {
@@ -1021,13 +1365,14 @@ func (s *chatService) ExecuteQuery(ctx context.Context, userID, chatID string, r
 		}
 	} else if resultMapFormatting != nil && resultMapFormatting["results"] != nil && len(resultMapFormatting["results"].([]interface{})) > 0 {
 		log.Printf("ChatService -> ExecuteQuery -> resultMapFormatting: %+v", resultMapFormatting)
-		if len(resultMapFormatting["results"].([]interface{})) > 50 {
-			formattedResultJSON = map[string]interface{}{
-				"results": resultMapFormatting["results"].([]interface{})[:50],
-			}
-			cappedResults := map[string]interface{}{
-				"results": resultMapFormatting["results"].([]interface{})[:50],
+		if len(resultMapFormatting["results"].([]interface{})) > displayRows {
+			// The truncation reported by the driver is kept along the rows
+			cappedResults := make(map[string]interface{}, len(resultMapFormatting))
+			for key, value := range resultMapFormatting {
+				cappedResults[key] = value
 			}
+			cappedResults["results"] = resultMapFormatting["results"].([]interface{})[:displayRows]
+			formattedResultJSON = cappedResults
 			cappedResultsJSON, err := json.Marshal(cappedResults)
 			if err != nil {
 				log.Printf("ChatService -> ExecuteQuery -> Error marshaling capped results: %v", err)
@@ -1050,13 +1395,40 @@ func (s *chatService) ExecuteQuery(ctx context.Context, userID, chatID string, r
 	query.IsRolledBack = false
 	query.ExecutionTime = &result.ExecutionTime
 	query.ExecutionResult = &result.ResultJSON
+	query.ResultColumns = dtos.ToResultColumnsModel(result.Columns)
+	// The file of a previous execution doesn't hold this result, the spill saves the new one
+	query.ResultFile = nil
 	query.ActionAt = utils.ToStringPtr(time.Now().Format(time.RFC3339))
+	// A collMod only knows the options it replaced once executed, its rollback restores them
+	executedRollback, hasExecutedRollback := dbmanager.MongoDBExecutedRollback(result.Result)
+	// A script undoable statement by statement is rolled back by its combined plan rather than the one written with it
+	if scriptRollback, ok := dbmanager.ScriptExecutedRollback(result.Result); ok {
+		executedRollback, hasExecutedRollback = scriptRollback, true
+	}
+	// A write whose rows were captured is rolled back by restoring them
+	if preImage != nil && preImage.RollbackQuery != "" && !hasExecutedRollback {
+		executedRollback, hasExecutedRollback = preImage.RollbackQuery, true
+	}
+	// The captured rows are enough for the rollback to be written, the query needs no dependent query anymore
+	preImageRows := preImageJSON(preImage)
+	query.PreImage = preImageRows
+	if preImageRows != nil {
+		query.CanRollback = true
+	}
+	if hasExecutedRollback {
+		query.RollbackQuery = &executedRollback
+		query.CanRollback = true
+	}
 	if totalRecordsCount != nil {
 		if query.Pagination == nil {
 			query.Pagination = &models.Pagination{}
 		}
 		query.Pagination.TotalRecordsCount = totalRecordsCount
 	}
+	if query.Pagination != nil {
+		query.Pagination.PageSize = pageSize
+		query.Pagination.MaxRows = maxRows
+	}
 	if result.Error != nil {
 		query.Error = &models.QueryError{
 			Code:    result.Error.Code,
@@ -1076,16 +1448,31 @@ func (s *chatService) ExecuteQuery(ctx context.Context, userID, chatID string, r
 					(*msg.Queries)[i].IsRolledBack = false
 					(*msg.Queries)[i].IsExecuted = true
 					(*msg.Queries)[i].ExecutionTime = &result.ExecutionTime
-					(*msg.Queries)[i].ActionAt = utils.ToStringPtr(time.Now().Format(time.RFC3339))
+					(*msg.Queries)[i].ActionAt = query.ActionAt
+					(*msg.Queries)[i].Params = params
+					(*msg.Queries)[i].PreImage = preImageRows
+					if preImageRows != nil {
+						(*msg.Queries)[i].CanRollback = true
+					}
+					if hasExecutedRollback {
+						(*msg.Queries)[i].RollbackQuery = &executedRollback
+						(*msg.Queries)[i].CanRollback = true
+					}
 					if totalRecordsCount != nil {
 						if (*msg.Queries)[i].Pagination == nil {
 							(*msg.Queries)[i].Pagination = &models.Pagination{}
 						}
 						(*msg.Queries)[i].Pagination.TotalRecordsCount = totalRecordsCount
 					}
+					if (*msg.Queries)[i].Pagination != nil {
+						(*msg.Queries)[i].Pagination.PageSize = pageSize
+						(*msg.Queries)[i].Pagination.MaxRows = maxRows
+					}
 					log.Printf("ChatService -> ExecuteQuery -> result.ResultJSON: %v", result.ResultJSON)
 					log.Printf("ChatService -> ExecuteQuery -> ExecutionResult before update: %v", (*msg.Queries)[i].ExecutionResult)
 					(*msg.Queries)[i].ExecutionResult = &result.ResultJSON
+					(*msg.Queries)[i].ResultColumns = query.ResultColumns
+					(*msg.Queries)[i].ResultFile = query.ResultFile
 					log.Printf("ChatService -> ExecuteQuery -> ExecutionResult after update: %v", (*msg.Queries)[i].ExecutionResult)
 					if result.Error != nil {
 						(*msg.Queries)[i].Error = &models.QueryError{
@@ -1113,6 +1500,10 @@ func (s *chatService) ExecuteQuery(ctx context.Context, userID, chatID string, r
 		} else {
 			s.removeFixErrorButton(msg)
 		}
+		// A query run in the open transaction is committed or rolled back with it
+		if result.Error == nil && s.inTransactionSession(chatID, query.ID.Hex()) {
+			addTransactionButtons(msg)
+		}
 		// Save updated message
 		if msg.ActionButtons != nil {
 			log.Printf("ChatService -> ExecuteQuery -> msg.ActionButtons: %+v", *msg.ActionButtons)
@@ -1230,6 +1621,11 @@ func (s *chatService) ExecuteQuery(ctx context.Context, userID, chatID string, r
 	}()
 
 	<-processCompleted
+
+	// The message is saved with this execution, the file is saved with it when written
+	if spillResult {
+		go s.spillResult(ctx, userID, chatID, req.StreamID, msg.ID, *query, *query.ActionAt)
+	}
 	return &dtos.QueryExecutionResponse{
 		ChatID:            chatID,
 		MessageID:         msg.ID.Hex(),
@@ -1238,6 +1634,8 @@ func (s *chatService) ExecuteQuery(ctx context.Context, userID, chatID string, r
 		IsRolledBack:      query.IsRolledBack,
 		ExecutionTime:     query.ExecutionTime,
 		ExecutionResult:   formattedResultJSON,
+		Columns:           result.Columns,
+		ResultFilePending: spillResult,
 		Error:             result.Error,
 		TotalRecordsCount: totalRecordsCount,
 		ActionButtons:     dtos.ToActionButtonDto(msg.ActionButtons),
@@ -1257,7 +1655,7 @@ func (s *chatService) RollbackQuery(ctx context.Context, userID, chatID string,
 
 	select {
 	case <-ctx.Done():
-		return nil, http.StatusRequestTimeout, fmt.Errorf("query rollback cancelled or timed out")
+		return nil, http.StatusRequestTimeout, apperrors.New("QUERY_ROLLBACK_CANCELLED", "query rollback cancelled or timed out")
 	default:
 		log.Printf("ChatService -> RollbackQuery -> msg: %+v", msg)
 		log.Printf("ChatService -> RollbackQuery -> query: %+v", query)
@@ -1265,129 +1663,137 @@ func (s *chatService) RollbackQuery(ctx context.Context, userID, chatID string,
 
 	// Validate query state
 	if !query.IsExecuted {
-		return nil, http.StatusBadRequest, fmt.Errorf("cannot rollback a query that hasn't been executed")
+		return nil, http.StatusBadRequest, apperrors.New("QUERY_NOT_EXECUTED", "cannot rollback a query that hasn't been executed")
 	}
 	if query.IsRolledBack {
-		return nil, http.StatusBadRequest, fmt.Errorf("query already rolled back")
+		return nil, http.StatusBadRequest, apperrors.New("QUERY_ALREADY_ROLLED_BACK", "query already rolled back")
 	}
 
 	if !query.CanRollback {
-		return nil, http.StatusBadRequest, fmt.Errorf("query cannot be rolled back")
+		return nil, http.StatusBadRequest, apperrors.New("QUERY_CANNOT_BE_ROLLED_BACK", "query cannot be rolled back")
 	}
 	// Check if we need to generate rollback query
 	if query.RollbackQuery == nil || *query.RollbackQuery == "" {
-		// First execute the dependent query to get context
-		if query.RollbackDependentQuery == nil {
-			return nil, http.StatusBadRequest, fmt.Errorf("rollback dependent query is required but not provided")
-		}
+		// The rows captured before the execution are the ones to restore, reading them now would see the later changes too
+		var dependentResultJSON string
+		if query.PreImage != nil {
+			log.Printf("ChatService -> RollbackQuery -> Using the rows captured before the execution")
+			dependentResultJSON = *query.PreImage
+		} else {
+			// First execute the dependent query to get context
+			if query.RollbackDependentQuery == nil {
+				return nil, http.StatusBadRequest, apperrors.New("ROLLBACK_DEPENDENT_QUERY_REQUIRED", "rollback dependent query is required but not provided")
+			}
 
-		log.Printf("ChatService -> RollbackQuery -> Executing dependent query: %s", *query.RollbackDependentQuery)
+			log.Printf("ChatService -> RollbackQuery -> Executing dependent query: %s", *query.RollbackDependentQuery)
 
-		// Check connection status and connect if needed
-		if !s.dbManager.IsConnected(chatID) {
-			log.Printf("ChatService -> RollbackQuery -> Database not connected, initiating connection")
-			status, err := s.ConnectDB(ctx, userID, chatID, req.StreamID)
-			if err != nil {
-				return nil, status, err
+			// Check connection status and connect if needed
+			if !s.dbManager.IsConnected(chatID) {
+				log.Printf("ChatService -> RollbackQuery -> Database not connected, initiating connection")
+				status, err := s.ConnectDB(ctx, userID, chatID, req.StreamID)
+				if err != nil {
+					return nil, status, err
+				}
+				time.Sleep(1 * time.Second)
 			}
-			time.Sleep(1 * time.Second)
-		}
 
-		// Execute dependent query
-		dependentResult, queryErr := s.dbManager.ExecuteQuery(ctx, chatID, req.MessageID, req.QueryID, req.StreamID, *query.RollbackDependentQuery, *query.QueryType, false, false)
-		if queryErr != nil {
-			log.Printf("ChatService -> RollbackQuery -> queryErr: %+v", queryErr)
-			if queryErr.Code == "FAILED_TO_START_TRANSACTION" || strings.Contains(queryErr.Message, "context deadline exceeded") || strings.Contains(queryErr.Message, "context canceled") {
-				return nil, http.StatusRequestTimeout, fmt.Errorf("query execution timed out")
-			}
-			// Update query status in message
-			go func() {
-				if msg.Queries != nil {
-					for i := range *msg.Queries {
-						if (*msg.Queries)[i].ID == query.ID {
-							(*msg.Queries)[i].IsExecuted = true
-							(*msg.Queries)[i].IsRolledBack = false
-							(*msg.Queries)[i].Error = &models.QueryError{
-								Code:    queryErr.Code,
-								Message: queryErr.Message,
-								Details: queryErr.Details,
+			// Execute dependent query, on the primary as it reads the rows the original query wrote
+			dependentResult, queryErr := s.dbManager.ExecuteQuery(dbmanager.WithPrimaryRouting(ctx), chatID, req.MessageID, req.QueryID, req.StreamID, *query.RollbackDependentQuery, *query.QueryType, false, false)
+			if queryErr != nil {
+				log.Printf("ChatService -> RollbackQuery -> queryErr: %+v", queryErr)
+				if queryErr.Code == "FAILED_TO_START_TRANSACTION" || strings.Contains(queryErr.Message, "context deadline exceeded") || strings.Contains(queryErr.Message, "context canceled") {
+					return nil, http.StatusRequestTimeout, apperrors.New("QUERY_EXECUTION_TIMEOUT", "query execution timed out")
+				}
+				// Update query status in message
+				go func() {
+					if msg.Queries != nil {
+						for i := range *msg.Queries {
+							if (*msg.Queries)[i].ID == query.ID {
+								(*msg.Queries)[i].IsExecuted = true
+								(*msg.Queries)[i].IsRolledBack = false
+								(*msg.Queries)[i].Error = &models.QueryError{
+									Code:    queryErr.Code,
+									Message: queryErr.Message,
+									Details: queryErr.Details,
+								}
 							}
 						}
 					}
-				}
-				if err := s.chatRepo.UpdateMessage(msg.ID, msg); err != nil {
-					log.Printf("ChatService -> RollbackQuery -> Error updating message: %v", err)
-				}
-
-				// Update LLM message with query execution results
-				llmMsg, err := s.llmRepo.FindMessageByChatMessageID(msg.ID)
-				if err != nil {
-					log.Printf("ChatService -> RollbackQuery -> Error finding LLM message: %v", err)
-				} else if llmMsg != nil {
-					content := llmMsg.Content
-					if content == nil {
-						content = make(map[string]interface{})
+					if err := s.chatRepo.UpdateMessage(msg.ID, msg); err != nil {
+						log.Printf("ChatService -> RollbackQuery -> Error updating message: %v", err)
 					}
-					if assistantResponse, ok := content["assistant_response"].(map[string]interface{}); ok {
-						if queries, ok := assistantResponse["queries"].([]interface{}); ok {
-							for _, q := range queries {
-								if queryMap, ok := q.(map[string]interface{}); ok {
-									if queryMap["query"] == query.Query && queryMap["queryType"] == *query.QueryType && queryMap["explanation"] == query.Description {
-										queryMap["isExecuted"] = true
-										queryMap["isRolledBack"] = false
-										queryMap["error"] = &models.QueryError{
-											Code:    queryErr.Code,
-											Message: queryErr.Message,
-											Details: queryErr.Details,
+
+					// Update LLM message with query execution results
+					llmMsg, err := s.llmRepo.FindMessageByChatMessageID(msg.ID)
+					if err != nil {
+						log.Printf("ChatService -> RollbackQuery -> Error finding LLM message: %v", err)
+					} else if llmMsg != nil {
+						content := llmMsg.Content
+						if content == nil {
+							content = make(map[string]interface{})
+						}
+						if assistantResponse, ok := content["assistant_response"].(map[string]interface{}); ok {
+							if queries, ok := assistantResponse["queries"].([]interface{}); ok {
+								for _, q := range queries {
+									if queryMap, ok := q.(map[string]interface{}); ok {
+										if queryMap["query"] == query.Query && queryMap["queryType"] == *query.QueryType && queryMap["explanation"] == query.Description {
+											queryMap["isExecuted"] = true
+											queryMap["isRolledBack"] = false
+											queryMap["error"] = &models.QueryError{
+												Code:    queryErr.Code,
+												Message: queryErr.Message,
+												Details: queryErr.Details,
+											}
 										}
 									}
 								}
 							}
 						}
-					}
 
-					llmMsg.Content = content
-					if err := s.llmRepo.UpdateMessage(llmMsg.ID, llmMsg); err != nil {
-						log.Printf("ChatService -> RollbackQuery -> Error updating LLM message: %v", err)
+						llmMsg.Content = content
+						if err := s.llmRepo.UpdateMessage(llmMsg.ID, llmMsg); err != nil {
+							log.Printf("ChatService -> RollbackQuery -> Error updating LLM message: %v", err)
+						}
 					}
+				}()
+
+				// Send event about dependent query failure
+				s.sendStreamEvent(userID, chatID, req.StreamID, dtos.StreamResponse{
+					Event: "rollback-query-failed",
+					Data: map[string]interface{}{
+						"chat_id":    chatID,
+						"message_id": msg.ID.Hex(),
+						"query_id":   query.ID.Hex(),
+						"error":      queryErr,
+					},
+				})
+				// Add "Fix Error" action button to the Message & LLM content if there's an error
+				if queryErr != nil {
+					s.addFixErrorButton(msg)
+				} else {
+					s.removeFixErrorButton(msg)
 				}
-			}()
-
-			// Send event about dependent query failure
-			s.sendStreamEvent(userID, chatID, req.StreamID, dtos.StreamResponse{
-				Event: "rollback-query-failed",
-				Data: map[string]interface{}{
-					"chat_id":    chatID,
-					"message_id": msg.ID.Hex(),
-					"query_id":   query.ID.Hex(),
-					"error":      queryErr,
-				},
-			})
-			// Add "Fix Error" action button to the Message & LLM content if there's an error
-			if queryErr != nil {
-				s.addFixErrorButton(msg)
-			} else {
-				s.removeFixErrorButton(msg)
-			}
 
-			return &dtos.QueryExecutionResponse{
-				ChatID:            chatID,
-				MessageID:         msg.ID.Hex(),
-				QueryID:           query.ID.Hex(),
-				IsExecuted:        true,
-				IsRolledBack:      false,
-				ExecutionTime:     query.ExecutionTime,
-				ExecutionResult:   nil,
-				Error:             queryErr,
-				TotalRecordsCount: nil,
-				ActionButtons:     dtos.ToActionButtonDto(msg.ActionButtons),
-			}, http.StatusOK, nil
+				return &dtos.QueryExecutionResponse{
+					ChatID:            chatID,
+					MessageID:         msg.ID.Hex(),
+					QueryID:           query.ID.Hex(),
+					IsExecuted:        true,
+					IsRolledBack:      false,
+					ExecutionTime:     query.ExecutionTime,
+					ExecutionResult:   nil,
+					Error:             queryErr,
+					TotalRecordsCount: nil,
+					ActionButtons:     dtos.ToActionButtonDto(msg.ActionButtons),
+				}, http.StatusOK, nil
+			}
+			dependentResultJSON = dependentResult.ResultJSON
 		}
 
 		// Get LLM context from previous messages
 		llmMsgs, err := s.llmRepo.GetByChatID(msg.ChatID)
 		if err != nil {
-			return nil, http.StatusInternalServerError, fmt.Errorf("failed to get LLM context: %v", err)
+			return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_GET_LLM_CONTEXT", "failed to get LLM context: {error}").With("error", err)
 		}
 
 		// Build context for LLM
@@ -1403,35 +1809,50 @@ func (s *chatService) RollbackQuery(ctx context.Context, userID, chatID string,
 		}
 		contextBuilder.WriteString(fmt.Sprintf("\nQuery id: %s\n", query.ID.Hex())) // This will help LLM to understand the context of the query to be rolled back
 		contextBuilder.WriteString(fmt.Sprintf("\nOriginal query: %s\n", query.Query))
-		contextBuilder.WriteString(fmt.Sprintf("Dependent query result: %s\n", dependentResult.ResultJSON))
+		contextBuilder.WriteString(fmt.Sprintf("Dependent query result: %s\n", dependentResultJSON))
 		contextBuilder.WriteString("\nPlease generate a rollback query that will undo the effects of the original query.")
 
 		// Get connection info for db type
 		conn, exists := s.dbManager.GetConnectionInfo(chatID)
 		if !exists {
-			return nil, http.StatusBadRequest, fmt.Errorf("no database connection found")
+			return nil, http.StatusBadRequest, apperrors.New("CONNECTION_NOT_FOUND", "no database connection found")
 		}
 
 		// Convert LLM messages to expected format
 		llmMessages := make([]*models.LLMMessage, len(llmMsgs))
 		// Use copy to avoid modifying original messages
 		copy(llmMessages, llmMsgs)
+		// The rows to restore are sent with the request, the LLM writes the rollback with their values
+		llmMessages = append(llmMessages, &models.LLMMessage{
+			ChatID:  msg.ChatID,
+			UserID:  msg.UserID,
+			Role:    string(constants.MessageTypeUser),
+			Content: map[string]interface{}{"user_message": contextBuilder.String()},
+		})
 
 		// Get rollback query from LLM
-		llmResponse, err := s.llmClient.GenerateResponse(
-			ctx,
+		if statusCode, err := s.llmUsageService.CheckQuota(userID); err != nil {
+			return nil, statusCode, err
+		}
+		llmClient, err := s.llmResolver.ResolveLLMClient(ctx, userID, chat.Settings.LLMModel)
+		if err != nil {
+			return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_RESOLVE_LLM_CLIENT", "failed to resolve LLM client: {error}").With("error", err)
+		}
+		usage := &llm.Usage{}
+		llmResponse, err := llmClient.GenerateResponse(
+			llm.WithPromptOverrides(llm.WithUsage(ctx, usage), s.promptTemplates),
 			llmMessages,      // Pass the LLM messages array
 			conn.Config.Type, // Pass the database type
 		)
 		if err != nil {
-			return nil, http.StatusInternalServerError, fmt.Errorf("failed to generate rollback query: %v", err)
+			return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_GENERATE_ROLLBACK_QUERY", "failed to generate rollback query: {error}").With("error", err)
 		}
 
 		// Parse LLM response to get rollback query
 		var rollbackQuery string
 		var jsonResponse map[string]interface{}
 		if err := json.Unmarshal([]byte(llmResponse), &jsonResponse); err != nil {
-			return nil, http.StatusInternalServerError, fmt.Errorf("failed to parse LLM response: %v", err)
+			return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_PARSE_LLM_RESPONSE", "failed to parse LLM response: {error}").With("error", err)
 		}
 
 		if msg.Queries != nil {
@@ -1443,10 +1864,10 @@ func (s *chatService) RollbackQuery(ctx context.Context, userID, chatID string,
 				}
 			}
 		}
-		if queryErr != nil {
-			s.addFixErrorButton(msg)
-		} else {
-			s.removeFixErrorButton(msg)
+		// The dependent query succeeded or was not needed
+		s.removeFixErrorButton(msg)
+		if s.inTransactionSession(chatID, query.ID.Hex()) {
+			addTransactionButtons(msg)
 		}
 		if msg.ActionButtons != nil {
 			log.Printf("ChatService -> RollbackQuery -> msg.ActionButtons: %+v", *msg.ActionButtons)
@@ -1489,7 +1910,7 @@ func (s *chatService) RollbackQuery(ctx context.Context, userID, chatID string,
 		}
 
 		if rollbackQuery == "" {
-			return nil, http.StatusInternalServerError, fmt.Errorf("failed to generate valid rollback query")
+			return nil, http.StatusInternalServerError, apperrors.New("INVALID_ROLLBACK_QUERY", "failed to generate valid rollback query")
 		}
 
 		// Update query with rollback query
@@ -1507,7 +1928,7 @@ func (s *chatService) RollbackQuery(ctx context.Context, userID, chatID string,
 		}
 		// Update message in DB
 		if err := s.chatRepo.UpdateMessage(msg.ID, msg); err != nil {
-			return nil, http.StatusInternalServerError, fmt.Errorf("failed to update message with rollback query: %v", err)
+			return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_UPDATE_ROLLBACK_QUERY", "failed to update message with rollback query: {error}").With("error", err)
 		}
 
 		// Update existing LLM message
@@ -1549,6 +1970,7 @@ func (s *chatService) RollbackQuery(ctx context.Context, userID, chatID string,
 			}
 
 			llmMsg.Content = content
+			llmMsg.Usage = addLLMUsage(llmMsg.Usage, usage)
 			if err := s.llmRepo.UpdateMessage(llmMsg.ID, llmMsg); err != nil {
 				log.Printf("ChatService -> RollbackQuery -> Error updating LLM message: %v", err)
 			}
@@ -1567,7 +1989,7 @@ func (s *chatService) RollbackQuery(ctx context.Context, userID, chatID string,
 				"error":      "No rollback query available",
 			},
 		})
-		return nil, http.StatusBadRequest, fmt.Errorf("no rollback query available")
+		return nil, http.StatusBadRequest, apperrors.New("NO_ROLLBACK_QUERY_AVAILABLE", "no rollback query available")
 	}
 
 	// Check connection status and connect if needed
@@ -1581,11 +2003,13 @@ func (s *chatService) RollbackQuery(ctx context.Context, userID, chatID string,
 	}
 
 	// Execute rollback query
+	startedAt := time.Now()
 	result, queryErr := s.dbManager.ExecuteQuery(ctx, chatID, req.MessageID, req.QueryID, req.StreamID, *query.RollbackQuery, *query.QueryType, true, false)
+	s.queryHistory.RecordExecution(userID, chat, msg.ID, query.ID, *query.RollbackQuery, query.QueryType, true, startedAt, result, queryErr)
 	if queryErr != nil {
 		log.Printf("ChatService -> RollbackQuery -> queryErr: %+v", queryErr)
 		if queryErr.Code == "FAILED_TO_START_TRANSACTION" || strings.Contains(queryErr.Message, "context deadline exceeded") || strings.Contains(queryErr.Message, "context canceled") {
-			return nil, http.StatusRequestTimeout, fmt.Errorf("query execution timed out")
+			return nil, http.StatusRequestTimeout, apperrors.New("QUERY_EXECUTION_TIMEOUT", "query execution timed out")
 		}
 		// Update query status in message
 		go func() {
@@ -1722,7 +2146,7 @@ func (s *chatService) RollbackQuery(ctx context.Context, userID, chatID string,
 	}
 	// Save updated message
 	if err := s.chatRepo.UpdateMessage(msg.ID, msg); err != nil {
-		return nil, http.StatusInternalServerError, fmt.Errorf("failed to update message with rollback results: %v", err)
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_UPDATE_ROLLBACK_RESULTS", "failed to update message with rollback results: {error}").With("error", err)
 	}
 
 	// Update LLM message with rollback results
@@ -1930,6 +2354,29 @@ func (s *chatService) processLLMResponseAndRunQuery(ctx context.Context, userID,
 				tempQueries := make([]dtos.Query, len(*msgResp.Queries))
 				for i, query := range *msgResp.Queries {
 					if query.Query != "" && !query.IsCritical {
+						// The writes whose affected rows were previewed wait for the user to confirm them
+						if query.AffectedRowsPreview != nil {
+							s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
+								Event: "ai-response-step",
+								Data:  fmt.Sprintf("The query will affect %d rows, execute it yourself to confirm.", query.AffectedRowsPreview.Count),
+							})
+							tempQueries[i] = query
+							continue
+						}
+
+						// Expensive queries wait for the user to execute them
+						if estimate := s.autoExecutionCostExceeded(ctx, chatID, query.Query); estimate != nil {
+							query.EstimatedRows = &estimate.Rows
+							query.RequiresConfirmation = true
+							s.requireQueryConfirmation(msgResp.ID, query.ID, estimate.Rows)
+							s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
+								Event: "ai-response-step",
+								Data:  fmt.Sprintf("The query is estimated to read %d rows, execute it yourself to confirm.", estimate.Rows),
+							})
+							tempQueries[i] = query
+							continue
+						}
+
 						executionResult, _, queryErr := s.ExecuteQuery(ctx, userID, chatID, &dtos.ExecuteQueryRequest{
 							MessageID: msgResp.ID,
 							QueryID:   query.ID,
@@ -1999,6 +2446,49 @@ func (s *chatService) processLLMResponseAndRunQuery(ctx context.Context, userID,
 	return nil
 }
 
+// autoExecutionCostExceeded returns the cost estimate of a generated query when it is above AUTO_EXECUTE_MAX_ESTIMATED_ROWS,
+// nil when the query can be executed automatically. The queries whose cost can't be estimated are executed.
+func (s *chatService) autoExecutionCostExceeded(ctx context.Context, chatID, query string) *dbmanager.QueryCostEstimate {
+	if config.Env.AutoExecuteMaxEstimatedRows <= 0 {
+		return nil
+	}
+
+	estimate, queryErr := s.dbManager.EstimateQueryCost(ctx, chatID, query)
+	if queryErr != nil {
+		log.Printf("ChatService -> autoExecutionCostExceeded -> Cost not estimated, executing the query: %+v", queryErr)
+		return nil
+	}
+	log.Printf("ChatService -> autoExecutionCostExceeded -> Estimated rows: %d (%s), limit: %d", estimate.Rows, estimate.Source, config.Env.AutoExecuteMaxEstimatedRows)
+	if estimate.Rows <= int64(config.Env.AutoExecuteMaxEstimatedRows) {
+		return nil
+	}
+	return estimate
+}
+
+// requireQueryConfirmation saves the estimate of a query left for the user to execute, the message shows it once reloaded
+func (s *chatService) requireQueryConfirmation(messageID, queryID string, estimatedRows int64) {
+	messageObjID, err := primitive.ObjectIDFromHex(messageID)
+	if err != nil {
+		return
+	}
+	message, err := s.chatRepo.FindMessageByID(messageObjID)
+	if err != nil || message == nil || message.Queries == nil {
+		log.Printf("ChatService -> requireQueryConfirmation -> Failed to fetch message %s: %v", messageID, err)
+		return
+	}
+
+	for i := range *message.Queries {
+		if (*message.Queries)[i].ID.Hex() == queryID {
+			(*message.Queries)[i].EstimatedRows = &estimatedRows
+			(*message.Queries)[i].RequiresConfirmation = true
+			if err := s.chatRepo.UpdateMessage(message.ID, message); err != nil {
+				log.Printf("ChatService -> requireQueryConfirmation -> Failed to update message %s: %v", messageID, err)
+			}
+			return
+		}
+	}
+}
+
 // ProcessMessage processes the message, updates SSE stream only if allowSSEUpdates is true, allowSSEUpdates is used to send SSE updates to the client except the final ai-response event
 func (s *chatService) processMessage(_ context.Context, userID, chatID, messageID, streamID string) error {
 	// Create a new context specifically for LLM processing
@@ -2084,25 +2574,25 @@ func (s *chatService) RefreshSchema(ctx context.Context, userID, chatID string,
 		_, exists := s.dbManager.GetConnectionInfo(chatID)
 		if !exists {
 			log.Printf("ChatService -> RefreshSchema -> Connection not found for chatID: %s", chatID)
-			return http.StatusNotFound, fmt.Errorf("connection not found")
+			return http.StatusNotFound, apperrors.New("CONNECTION_NOT_FOUND", "connection not found")
 		}
 
 		// Get chat to get selected collections
 		chatObjID, err := primitive.ObjectIDFromHex(chatID)
 		if err != nil {
 			log.Printf("ChatService -> RefreshSchema -> Error getting chatID: %v", err)
-			return http.StatusBadRequest, fmt.Errorf("invalid chat ID format")
+			return http.StatusBadRequest, apperrors.New("INVALID_CHAT_ID", "invalid chat ID format")
 		}
 
 		chat, err := s.chatRepo.FindByID(chatObjID)
 		if err != nil {
 			log.Printf("ChatService -> RefreshSchema -> Error finding chat: %v", err)
-			return http.StatusInternalServerError, fmt.Errorf("failed to fetch chat: %v", err)
+			return http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CHAT", "failed to fetch chat: {error}").With("error", err)
 		}
 
 		if chat == nil {
 			log.Printf("ChatService -> RefreshSchema -> Chat not found for chatID: %s", chatID)
-			return http.StatusNotFound, fmt.Errorf("chat not found")
+			return http.StatusNotFound, apperrors.New("CHAT_NOT_FOUND", "chat not found")
 		}
 
 		// Convert the selectedCollections string to a slice
@@ -2174,19 +2664,19 @@ func (s *chatService) RefreshSchema(ctx context.Context, userID, chatID string,
 	}
 }
 
-// Fetches paginated results for a query, default first 50 records of a large result are stored in execution_result so it fetches records after first 50 recordds
-func (s *chatService) GetQueryResults(ctx context.Context, userID, chatID, messageID, queryID, streamID string, offset int) (*dtos.QueryResultsResponse, uint32, error) {
-	log.Printf("ChatService -> GetQueryResults -> userID: %s, chatID: %s, messageID: %s, queryID: %s, streamID: %s, offset: %d", userID, chatID, messageID, queryID, streamID, offset)
-	_, _, query, err := s.verifyQueryOwnership(userID, chatID, messageID, queryID)
+// Fetches paginated results for a query, the first page of a large result is stored in execution_result so it fetches the records after it
+func (s *chatService) GetQueryResults(ctx context.Context, userID, chatID, messageID, queryID, streamID string, offset int, after interface{}) (*dtos.QueryResultsResponse, uint32, error) {
+	log.Printf("ChatService -> GetQueryResults -> userID: %s, chatID: %s, messageID: %s, queryID: %s, streamID: %s, offset: %d, after: %v", userID, chatID, messageID, queryID, streamID, offset, after)
+	chat, _, query, err := s.verifyQueryOwnership(userID, chatID, messageID, queryID)
 	if err != nil {
 		return nil, http.StatusBadRequest, err
 	}
 
 	if query.Pagination == nil {
-		return nil, http.StatusBadRequest, fmt.Errorf("query does not support pagination")
+		return nil, http.StatusBadRequest, apperrors.New("QUERY_NOT_PAGINATED", "query does not support pagination")
 	}
 	if query.Pagination.PaginatedQuery == nil {
-		return nil, http.StatusBadRequest, fmt.Errorf("query does not support pagination")
+		return nil, http.StatusBadRequest, apperrors.New("QUERY_NOT_PAGINATED", "query does not support pagination")
 	}
 
 	// Check the connection status and connect if needed
@@ -2197,12 +2687,46 @@ func (s *chatService) GetQueryResults(ctx context.Context, userID, chatID, messa
 		}
 	}
 	log.Printf("ChatService -> GetQueryResults -> query.Pagination.PaginatedQuery: %+v", query.Pagination.PaginatedQuery)
-	offSettPaginatedQuery := strings.Replace(*query.Pagination.PaginatedQuery, "offset_size", strconv.Itoa(offset), 1)
-	log.Printf("ChatService -> GetQueryResults -> offSettPaginatedQuery: %+v", offSettPaginatedQuery)
-	result, queryErr := s.dbManager.ExecuteQuery(ctx, chatID, messageID, queryID, streamID, offSettPaginatedQuery, *query.QueryType, false, false)
+
+	// The pages are read with the values the query was executed with
+	if len(query.Params) > 0 {
+		ctx = dbmanager.WithQueryParams(ctx, query.Params)
+	}
+
+	// The pages are as large as the first one & stop at the rows the execution could read
+	paginatedQuery := *query.Pagination.PaginatedQuery
+	pageSize := query.Pagination.PageSize
+	if pageSize > 0 {
+		paginatedQuery, _ = dbmanager.WithPageSize(chat.Connection.Type, paginatedQuery, pageSize)
+	}
+	if maxRows := query.Pagination.MaxRows; maxRows > 0 {
+		if offset >= maxRows {
+			return nil, http.StatusBadRequest, apperrors.New("RESULT_ROW_LIMIT_REACHED", "only the first {max} rows of the result can be read").With("max", maxRows)
+		}
+		ctx = dbmanager.WithResultRowLimit(ctx, maxRows-offset)
+	}
+
+	// Deep offsets read & skip every previous row, the page is read after the key of the last row seen when the query has a key
+	var result *dbmanager.QueryExecutionResult
+	var queryErr *dtos.QueryError
+	if query.Pagination.KeysetColumn != nil && after != nil && s.isKeysetColumnUnique(ctx, chat, messageID, streamID, query, paginatedQuery) {
+		if keysetCtx, keysetQuery, ok := dbmanager.WithKeysetPage(ctx, chat.Connection.Type, paginatedQuery, *query.Pagination.KeysetColumn, query.Pagination.KeysetDescending, after, pageSize); ok {
+			log.Printf("ChatService -> GetQueryResults -> keysetQuery: %+v", keysetQuery)
+			result, queryErr = s.dbManager.ExecuteQuery(keysetCtx, chatID, messageID, queryID, streamID, keysetQuery, *query.QueryType, false, false)
+			if queryErr != nil {
+				log.Printf("ChatService -> GetQueryResults -> Keyset page failed, falling back to the offset: %+v", queryErr)
+				result, queryErr = nil, nil
+			}
+		}
+	}
+	if result == nil {
+		offSettPaginatedQuery := strings.Replace(paginatedQuery, "offset_size", strconv.Itoa(offset), 1)
+		log.Printf("ChatService -> GetQueryResults -> offSettPaginatedQuery: %+v", offSettPaginatedQuery)
+		result, queryErr = s.dbManager.ExecuteQuery(ctx, chatID, messageID, queryID, streamID, offSettPaginatedQuery, *query.QueryType, false, false)
+	}
 	if queryErr != nil {
 		log.Printf("ChatService -> GetQueryResults -> queryErr: %+v", queryErr)
-		return nil, http.StatusBadRequest, fmt.Errorf(queryErr.Message)
+		return nil, http.StatusBadRequest, apperrors.New(queryErr.Code, "{error}").With("error", queryErr.Message)
 	}
 
 	var formattedResultJSON interface{}
@@ -2243,11 +2767,41 @@ func (s *chatService) GetQueryResults(ctx context.Context, userID, chatID, messa
 		MessageID:         messageID,
 		QueryID:           queryID,
 		ExecutionResult:   formattedResultJSON,
+		Columns:           result.Columns,
 		Error:             queryErr,
 		TotalRecordsCount: query.Pagination.TotalRecordsCount,
 	}, http.StatusOK, nil
 }
 
+// isKeysetColumnUnique checks once that the keyset column of a paginated query is unique & never null, the pages would skip or
+// repeat the rows of its ties otherwise & are read by offset. The verdict is stored with the query, a check that fails to run is
+// tried again with the next page.
+func (s *chatService) isKeysetColumnUnique(ctx context.Context, chat *models.Chat, messageID, streamID string, query *models.Query, paginatedQuery string) bool {
+	if query.Pagination.KeysetUnique != nil {
+		return *query.Pagination.KeysetUnique
+	}
+	checkQuery, ok := dbmanager.KeysetUniquenessQuery(chat.Connection.Type, paginatedQuery, *query.Pagination.KeysetColumn)
+	if !ok {
+		return false
+	}
+
+	chatID := chat.ID.Hex()
+	result, queryErr := s.dbManager.ExecuteQuery(ctx, chatID, messageID, query.ID.Hex(), streamID, checkQuery, "SELECT", false, false)
+	if queryErr != nil {
+		log.Printf("ChatService -> isKeysetColumnUnique -> Uniqueness check failed, reading the page by offset: %+v", queryErr)
+		return false
+	}
+	unique := dbmanager.IsKeysetColumnUnique(result)
+	log.Printf("ChatService -> isKeysetColumnUnique -> keysetColumn: %s, unique: %v", *query.Pagination.KeysetColumn, unique)
+
+	if messageObjID, err := primitive.ObjectIDFromHex(messageID); err == nil {
+		if err := s.chatRepo.SetQueryKeysetUnique(messageObjID, query.ID, unique); err != nil {
+			log.Printf("ChatService -> isKeysetColumnUnique -> Error saving the uniqueness of the keyset column: %v", err)
+		}
+	}
+	return unique
+}
+
 // Helper function to add a "Fix Rollback Error" button to a message
 func (s *chatService) addFixRollbackErrorButton(msg *models.Message) {
 	log.Printf("ChatService -> addFixRollbackErrorButton -> msg.id: %s", msg.ID)
@@ -2273,6 +2827,28 @@ func (s *chatService) addFixRollbackErrorButton(msg *models.Message) {
 	}
 }
 
+// notifyQueryFinished adds a notification with the outcome of a query execution
+func (s *chatService) notifyQueryFinished(userID, chatID, messageID, queryID string, result *dbmanager.QueryExecutionResult, queryErr *dtos.QueryError) {
+	data := map[string]interface{}{
+		"message_id": messageID,
+		"query_id":   queryID,
+		"success":    queryErr == nil,
+	}
+
+	if queryErr != nil {
+		data["error_code"] = queryErr.Code
+		s.notificationService.Notify(userID, chatID, models.NotificationTypeQueryFinished, "Query failed", queryErr.Message, data)
+		return
+	}
+
+	message := "Query executed successfully"
+	if result != nil {
+		data["execution_time"] = result.ExecutionTime
+		message = fmt.Sprintf("Query executed successfully in %d ms", result.ExecutionTime)
+	}
+	s.notificationService.Notify(userID, chatID, models.NotificationTypeQueryFinished, "Query executed", message, data)
+}
+
 // Helper function to add a "Fix Error" button to a message
 func (s *chatService) addFixErrorButton(msg *models.Message) {
 	log.Printf("ChatService -> addFixErrorButton -> msg.id: %s", msg.ID)
@@ -2387,3 +2963,210 @@ func (s *chatService) removeFixErrorButton(msg *models.Message) {
 		log.Printf("ChatService -> removeFixErrorButton -> msg.ActionButtons: nil")
 	}
 }
+
+// operationalKeywords mark questions about the current state of the database rather than its data
+var operationalKeywords = []string{
+	"slow", "slower", "slowness", "right now", "currently running", "running queries", "running statements", "long running", "long-running",
+	"lock", "locks", "locked", "locking", "blocked", "blocking", "deadlock", "deadlocks", "hanging", "stuck", "waiting on", "waiting for",
+	"active sessions", "active queries", "active connections", "processlist", "pg_stat_activity", "currentop", "what is running",
+	"what's running", "performance issue", "high load", "cpu",
+}
+
+// operationalKeywordsRegex matches the keywords as whole words, so "blocks" or "clock" in a question about the data don't match
+var operationalKeywordsRegex = func() *regexp.Regexp {
+	quoted := make([]string, 0, len(operationalKeywords))
+	for _, keyword := range operationalKeywords {
+		quoted = append(quoted, regexp.QuoteMeta(keyword))
+	}
+	return regexp.MustCompile(`(?i)\b(` + strings.Join(quoted, "|") + `)\b`)
+}()
+
+// isOperationalQuestion checks if the user is asking about the live state of the database
+func isOperationalQuestion(userMessage string) bool {
+	return operationalKeywordsRegex.MatchString(userMessage)
+}
+
+// withLiveActivity adds a snapshot of the running statements & locks right before the latest user message, the snapshot is only sent to the LLM & never persisted
+func (s *chatService) withLiveActivity(ctx context.Context, chatID string, messages []*models.LLMMessage) []*models.LLMMessage {
+	snapshot, err := s.dbManager.CollectActivity(ctx, chatID)
+	if err != nil {
+		log.Printf("withLiveActivity -> Error collecting live activity: %v", err)
+		return messages
+	}
+
+	activityMsg := &models.LLMMessage{
+		ChatID: messages[len(messages)-1].ChatID,
+		UserID: messages[len(messages)-1].UserID,
+		Role:   string(constants.MessageTypeSystem),
+		Content: map[string]interface{}{
+			"live_activity": dbmanager.FormatActivityForLLM(snapshot),
+		},
+	}
+
+	withActivity := make([]*models.LLMMessage, 0, len(messages)+1)
+	withActivity = append(withActivity, messages[:len(messages)-1]...)
+	withActivity = append(withActivity, activityMsg, messages[len(messages)-1])
+	return withActivity
+}
+
+// withRelevantTables adds the tables the chat's queries used the most before the latest message
+func (s *chatService) withRelevantTables(chatObjID primitive.ObjectID, messages []*models.LLMMessage) []*models.LLMMessage {
+	chat, err := s.chatRepo.FindByID(chatObjID)
+	if err != nil || chat == nil {
+		log.Printf("withRelevantTables -> Error finding chat: %v", err)
+		return messages
+	}
+
+	var selectedTables []string
+	if chat.SelectedCollections != "ALL" && chat.SelectedCollections != "" {
+		selectedTables = strings.Split(chat.SelectedCollections, ",")
+	}
+	relevantTables := s.tableUsageService.FormatRelevantTables(chatObjID.Hex(), selectedTables)
+	if relevantTables == "" {
+		return messages
+	}
+
+	relevantTablesMsg := &models.LLMMessage{
+		ChatID: chatObjID,
+		UserID: messages[len(messages)-1].UserID,
+		Role:   string(constants.MessageTypeSystem),
+		Content: map[string]interface{}{
+			"relevant_tables": relevantTables,
+		},
+	}
+
+	withRelevantTables := make([]*models.LLMMessage, 0, len(messages)+1)
+	withRelevantTables = append(withRelevantTables, messages[:len(messages)-1]...)
+	withRelevantTables = append(withRelevantTables, relevantTablesMsg, messages[len(messages)-1])
+	return withRelevantTables
+}
+
+// withRetrievedSchema replaces the schema of the messages by the schema of the tables relevant to the latest user message.
+// The stored messages are left as they are, the whole schema is kept when the retrieval is disabled or fails.
+func (s *chatService) withRetrievedSchema(ctx context.Context, chatID string, chatObjID primitive.ObjectID, messages []*models.LLMMessage) []*models.LLMMessage {
+	question := ""
+	for i := len(messages) - 1; i >= 0; i-- {
+		if userMsg, ok := messages[i].Content["user_message"].(string); ok && messages[i].Role == string(constants.MessageTypeUser) {
+			question = userMsg
+			break
+		}
+	}
+	if question == "" {
+		return messages
+	}
+
+	chat, err := s.chatRepo.FindByID(chatObjID)
+	if err != nil || chat == nil {
+		log.Printf("withRetrievedSchema -> Error finding chat: %v", err)
+		return messages
+	}
+	var selectedTables []string
+	if chat.SelectedCollections != "ALL" && chat.SelectedCollections != "" {
+		selectedTables = strings.Split(chat.SelectedCollections, ",")
+	}
+
+	schema, ok := s.schemaRetrieval.RetrieveSchema(ctx, chatID, question, selectedTables)
+	if !ok {
+		return messages
+	}
+
+	// The latest schema update is replaced, the earlier ones are outdated
+	lastSchemaIndex := -1
+	for i, msg := range messages {
+		if _, isSchema := msg.Content["schema_update"]; isSchema {
+			lastSchemaIndex = i
+		}
+	}
+	if lastSchemaIndex == -1 {
+		return messages
+	}
+
+	withSchema := make([]*models.LLMMessage, 0, len(messages))
+	for i, msg := range messages {
+		if _, isSchema := msg.Content["schema_update"]; isSchema && i != lastSchemaIndex {
+			continue
+		}
+		if i == lastSchemaIndex {
+			retrievedMsg := *msg
+			retrievedMsg.Content = map[string]interface{}{
+				"schema_update": schema,
+			}
+			msg = &retrievedMsg
+		}
+		withSchema = append(withSchema, msg)
+	}
+	return withSchema
+}
+
+// withQueryExamples adds the registered examples most relevant to the latest user message before it
+func (s *chatService) withQueryExamples(chatObjID primitive.ObjectID, messages []*models.LLMMessage) []*models.LLMMessage {
+	lastMessage := messages[len(messages)-1]
+	question, ok := lastMessage.Content["user_message"].(string)
+	if !ok || lastMessage.Role != string(constants.MessageTypeUser) {
+		return messages
+	}
+
+	chat, err := s.chatRepo.FindByID(chatObjID)
+	if err != nil || chat == nil {
+		log.Printf("withQueryExamples -> Error finding chat: %v", err)
+		return messages
+	}
+	examples := s.queryExamples.FormatRelevantExamples(chat, question)
+	if examples == "" {
+		return messages
+	}
+
+	examplesMsg := &models.LLMMessage{
+		ChatID: chatObjID,
+		UserID: lastMessage.UserID,
+		Role:   string(constants.MessageTypeSystem),
+		Content: map[string]interface{}{
+			"query_examples": examples,
+		},
+	}
+
+	withExamples := make([]*models.LLMMessage, 0, len(messages)+1)
+	withExamples = append(withExamples, messages[:len(messages)-1]...)
+	withExamples = append(withExamples, examplesMsg, lastMessage)
+	return withExamples
+}
+
+// withServerFeatures adds the server version & its unsupported syntax before the latest message
+func (s *chatService) withServerFeatures(chatID string, messages []*models.LLMMessage) []*models.LLMMessage {
+	connInfo, exists := s.dbManager.GetConnectionInfo(chatID)
+	features, ok := s.dbManager.GetServerFeatures(chatID)
+	if !exists || !ok {
+		return messages
+	}
+
+	featuresMsg := &models.LLMMessage{
+		ChatID: messages[len(messages)-1].ChatID,
+		UserID: messages[len(messages)-1].UserID,
+		Role:   string(constants.MessageTypeSystem),
+		Content: map[string]interface{}{
+			"server_features": dbmanager.FormatServerFeaturesForLLM(connInfo.Config.Type, features),
+		},
+	}
+
+	withFeatures := make([]*models.LLMMessage, 0, len(messages)+1)
+	withFeatures = append(withFeatures, messages[:len(messages)-1]...)
+	withFeatures = append(withFeatures, featuresMsg, messages[len(messages)-1])
+	return withFeatures
+}
+
+// withChatSampling applies the sampling overrides of the chat to the calls of the LLM, e.g. deterministic queries on production databases
+func (s *chatService) withChatSampling(ctx context.Context, chatObjID primitive.ObjectID) context.Context {
+	chat, err := s.chatRepo.FindByID(chatObjID)
+	if err != nil || chat == nil {
+		log.Printf("withChatSampling -> Error finding chat: %v", err)
+		return ctx
+	}
+	if chat.Settings.LLMSampling == nil {
+		return ctx
+	}
+	return llm.WithSampling(ctx, llm.Sampling{
+		Temperature: chat.Settings.LLMSampling.Temperature,
+		TopP:        chat.Settings.LLMSampling.TopP,
+		MaxTokens:   chat.Settings.LLMSampling.MaxTokens,
+	})
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/models"
+	"neobase-ai/pkg/dbmanager"
+	"net/http"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// storedResultCap is the number of records the execution of a query stores unless its page size says otherwise, larger results
+// are capped
+const storedResultCap = 50
+
+// ExportQueryResultsCSV writes the whole result of a read-only query of a message to w as CSV, read from the chat's connection
+// instead of the stored results which are capped. Nothing is written to w when an error is returned before the export started.
+func (s *chatService) ExportQueryResultsCSV(ctx context.Context, userID, chatID, messageID, queryID string, usePrimary bool, w io.Writer) (uint32, error) {
+	return s.exportQueryResults(ctx, "ExportQueryResultsCSV", userID, chatID, messageID, queryID, usePrimary, func(ctx context.Context, query *models.Query) (int64, *dtos.QueryError) {
+		return s.dbManager.ExportQueryCSV(ctx, chatID, query.Query, w)
+	})
+}
+
+// ExportQueryResultsXLSX writes the result of an executed read-only query of a message to w as an XLSX workbook, read again from the
+// chat's connection like the CSV export. A metadata sheet holds the query, its execution time & the connection.
+func (s *chatService) ExportQueryResultsXLSX(ctx context.Context, userID, chatID, messageID, queryID string, usePrimary bool, w io.Writer) (uint32, error) {
+	return s.exportQueryResults(ctx, "ExportQueryResultsXLSX", userID, chatID, messageID, queryID, usePrimary, func(ctx context.Context, query *models.Query) (int64, *dtos.QueryError) {
+		if !query.IsExecuted {
+			return 0, &dtos.QueryError{
+				Code:    "QUERY_NOT_EXECUTED",
+				Message: "the query must be executed before its results are exported",
+			}
+		}
+
+		metadata := []dbmanager.ExportMetadata{
+			{Name: "Query", Value: query.Query},
+			{Name: "Description", Value: query.Description},
+		}
+		if query.ExecutionTime != nil {
+			metadata = append(metadata, dbmanager.ExportMetadata{Name: "Execution time (ms)", Value: *query.ExecutionTime})
+		}
+		return s.dbManager.ExportQueryXLSX(ctx, chatID, query.Query, metadata, w)
+	})
+}
+
+// ExportQueryResultsParquet writes the whole result of a read-only query of a message to w as a Parquet file, read from the
+// chat's connection like the CSV export
+func (s *chatService) ExportQueryResultsParquet(ctx context.Context, userID, chatID, messageID, queryID string, usePrimary bool, w io.Writer) (uint32, error) {
+	return s.exportQueryResults(ctx, "ExportQueryResultsParquet", userID, chatID, messageID, queryID, usePrimary, func(ctx context.Context, query *models.Query) (int64, *dtos.QueryError) {
+		return s.dbManager.ExportQueryParquet(ctx, chatID, query.Query, w)
+	})
+}
+
+// DownloadQueryResults writes the whole result of a query to w as JSON Lines. The stored result is read when it holds every
+// record, the query is executed again otherwise, which requires a read-only query.
+func (s *chatService) DownloadQueryResults(ctx context.Context, userID, chatID, queryID string, usePrimary bool, w io.Writer) (uint32, error) {
+	queryObjID, err := primitive.ObjectIDFromHex(queryID)
+	if err != nil {
+		return http.StatusBadRequest, apperrors.New("INVALID_QUERY_ID", "invalid query ID")
+	}
+	message, err := s.chatRepo.FindMessageByQueryID(queryObjID)
+	if err != nil {
+		return http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_MESSAGE", "failed to fetch message: {error}").With("error", err)
+	}
+	if message == nil || message.ChatID.Hex() != chatID {
+		return http.StatusNotFound, apperrors.New("QUERY_NOT_FOUND", "query not found")
+	}
+
+	_, _, query, err := s.verifyQueryOwnership(userID, chatID, message.ID.Hex(), queryID)
+	if err != nil {
+		return http.StatusForbidden, err
+	}
+
+	if records, complete := storedQueryRecords(query); complete {
+		log.Printf("ChatService -> DownloadQueryResults -> Writing the %d stored records of queryID: %s", len(records), queryID)
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		for _, record := range records {
+			if err := encoder.Encode(record); err != nil {
+				return http.StatusInternalServerError, apperrors.New("EXPORT_FAILED", "{error}").With("error", err)
+			}
+		}
+		return http.StatusOK, nil
+	}
+
+	return s.exportQueryResults(ctx, "DownloadQueryResults", userID, chatID, message.ID.Hex(), queryID, usePrimary, func(ctx context.Context, query *models.Query) (int64, *dtos.QueryError) {
+		return s.dbManager.ExportQueryJSONL(ctx, chatID, query.Query, w)
+	})
+}
+
+// storedQueryRecords returns the records of the stored result of an executed query, false unless it holds all of them
+func storedQueryRecords(query *models.Query) ([]interface{}, bool) {
+	if !query.IsExecuted || query.IsRolledBack || query.ExecutionResult == nil {
+		return nil, false
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(*query.ExecutionResult))
+	decoder.UseNumber()
+	var result interface{}
+	if err := decoder.Decode(&result); err != nil {
+		return nil, false
+	}
+
+	var records []interface{}
+	switch value := result.(type) {
+	case []interface{}:
+		records = value
+	case map[string]interface{}:
+		if truncated, _ := value["truncated"].(bool); truncated {
+			return nil, false
+		}
+		if results, ok := value["results"].([]interface{}); ok {
+			records = results
+		} else {
+			records = []interface{}{value}
+		}
+	default:
+		records = []interface{}{value}
+	}
+
+	storedRecords := storedResultCap
+	if query.Pagination != nil && query.Pagination.PageSize > 0 {
+		storedRecords = query.Pagination.PageSize
+	}
+	if len(records) < storedRecords {
+		return records, true
+	}
+	if query.Pagination != nil && query.Pagination.TotalRecordsCount != nil && *query.Pagination.TotalRecordsCount <= len(records) {
+		return records, true
+	}
+	return nil, false
+}
+
+// exportQueryResults checks the chat & the query before calling export, method names the export in the logs
+func (s *chatService) exportQueryResults(ctx context.Context, method, userID, chatID, messageID, queryID string, usePrimary bool, export func(ctx context.Context, query *models.Query) (int64, *dtos.QueryError)) (uint32, error) {
+	log.Printf("ChatService -> %s -> Starting for chatID: %s, queryID: %s", method, chatID, queryID)
+
+	if _, status, err := s.getConnectedChat(ctx, userID, chatID); err != nil {
+		return status, err
+	}
+
+	_, _, query, err := s.verifyQueryOwnership(userID, chatID, messageID, queryID)
+	if err != nil {
+		return http.StatusForbidden, err
+	}
+
+	// Replicas can lag behind the primary, the user can export from the primary instead
+	if usePrimary {
+		ctx = dbmanager.WithPrimaryRouting(ctx)
+	}
+
+	rows, queryErr := export(ctx, query)
+	if queryErr != nil {
+		log.Printf("ChatService -> %s -> Export failed after %d rows: %+v", method, rows, queryErr)
+		details := queryErr.Details
+		if details == "" {
+			details = queryErr.Message
+		}
+		return http.StatusBadRequest, apperrors.New(queryErr.Code, "{error}").With("error", details)
+	}
+
+	log.Printf("ChatService -> %s -> Exported %d rows for queryID: %s", method, rows, queryID)
+	return http.StatusOK, nil
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/pkg/dbmanager"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	importStatusCreated = "created"
+	importStatusValid   = "valid"
+	importStatusFailed  = "failed"
+
+	// Connections tested at the same time on import
+	maxConcurrentImportTests = 5
+)
+
+// ImportConnections creates a chat for each connection of an import file, connections that can't be imported are reported without failing the others
+func (s *chatService) ImportConnections(userID string, req *dtos.ImportConnectionsRequest) (*dtos.ImportConnectionsResponse, uint32, error) {
+	log.Printf("ChatService -> ImportConnections -> Importing %s connections for user %s", req.Format, userID)
+
+	imported, err := dbmanager.ParseConnectionImport(req.Format, req.Content)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_IMPORT_FILE", "{error}").With("error", err)
+	}
+
+	results := make([]dtos.ImportedConnectionResult, len(imported))
+	errs := make([]error, len(imported))
+	seen := make(map[string]int)
+	for i, connection := range imported {
+		results[i] = buildImportedConnectionResult(connection)
+		if connection.Error != "" {
+			errs[i] = apperrors.New("INVALID_IMPORTED_CONNECTION", "{error}").With("error", connection.Error)
+			continue
+		}
+		if err := validateImportedConnection(connection.Config); err != nil {
+			errs[i] = err
+			continue
+		}
+
+		key := importedConnectionKey(connection.Config)
+		if first, found := seen[key]; found {
+			errs[i] = apperrors.New("DUPLICATE_IMPORTED_CONNECTION", "connection is a duplicate of {name}").With("name", imported[first].Name)
+			continue
+		}
+		seen[key] = i
+	}
+
+	// Test the valid connections concurrently, a connection test can take a while when the host is unreachable
+	if req.TestConnection && !req.DryRun {
+		var wg sync.WaitGroup
+		semaphore := make(chan struct{}, maxConcurrentImportTests)
+		for i := range imported {
+			if errs[i] != nil {
+				continue
+			}
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				semaphore <- struct{}{}
+				defer func() { <-semaphore }()
+
+				config := imported[i].Config
+				if err := s.dbManager.TestConnection(&config); err != nil {
+					log.Printf("ChatService -> ImportConnections -> Connection test failed for %s: %v", imported[i].Name, err)
+					errs[i] = apperrors.New("CONNECTION_TEST_FAILED", "{error}").With("error", err)
+				}
+			}(i)
+		}
+		wg.Wait()
+	}
+
+	response := &dtos.ImportConnectionsResponse{
+		Results: results,
+	}
+	for i, connection := range imported {
+		if errs[i] == nil && req.DryRun {
+			results[i].Status = importStatusValid
+			response.Valid++
+			continue
+		}
+		status := http.StatusBadRequest
+		if errs[i] == nil {
+			// The connection was already tested, the chats are created one by one to respect the chat limit of the user
+			connectionRequest := importedConnectionRequest(connection.Config)
+			chat, statusCode, err := s.CreateWithoutConnectionPing(userID, &dtos.CreateChatRequest{
+				Connection: &connectionRequest,
+				Settings:   req.Settings,
+			})
+			if err != nil {
+				errs[i], status = err, int(statusCode)
+			} else {
+				results[i].Status = importStatusCreated
+				results[i].Chat = chat
+				response.Created++
+				continue
+			}
+		}
+
+		results[i].Status = importStatusFailed
+		results[i].Error = dtos.NewErrorResponse(status, errs[i]).ErrorDetail
+		response.Failed++
+	}
+
+	log.Printf("ChatService -> ImportConnections -> Created: %d, valid: %d, failed: %d", response.Created, response.Valid, response.Failed)
+	return response, http.StatusOK, nil
+}
+
+// ParseConnectionString fills the connection form from a pasted connection URI, nothing is stored or tested
+func (s *chatService) ParseConnectionString(req *dtos.ParseConnectionStringRequest) (*dtos.ParseConnectionStringResponse, uint32, error) {
+	config, err := dbmanager.ParseConnectionString(req.ConnectionString)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_CONNECTION_STRING", "{error}").With("error", err)
+	}
+	if !isValidDBType(config.Type) {
+		return nil, http.StatusBadRequest, apperrors.New("UNSUPPORTED_DATABASE_TYPE", "unsupported database type: {type}").With("type", config.Type)
+	}
+
+	return &dtos.ParseConnectionStringResponse{
+		Connection: importedConnectionRequest(config),
+	}, http.StatusOK, nil
+}
+
+// validateImportedConnection applies the checks of a chat creation request to an imported connection
+func validateImportedConnection(config dbmanager.ConnectionConfig) error {
+	if !isValidDBType(config.Type) {
+		return apperrors.New("UNSUPPORTED_DATABASE_TYPE", "unsupported database type: {type}").With("type", config.Type)
+	}
+	if strings.TrimSpace(config.Host) == "" && !dbmanager.UsesUnixSocket(config) {
+		return apperrors.New("CONNECTION_HOST_REQUIRED", "host is required")
+	}
+	if config.Port != nil {
+		if _, err := strconv.Atoi(*config.Port); err != nil {
+			return apperrors.New("INVALID_PORT", "invalid port: {port}").With("port", *config.Port)
+		}
+	}
+	if config.Username == nil || strings.TrimSpace(*config.Username) == "" {
+		return apperrors.New("CONNECTION_USERNAME_REQUIRED", "username is required")
+	}
+	if strings.TrimSpace(config.Database) == "" {
+		return apperrors.New("CONNECTION_DATABASE_REQUIRED", "database is required")
+	}
+	if err := dbmanager.ValidateSocketPath(config); err != nil {
+		return apperrors.New("INVALID_SOCKET_PATH", "{error}").With("error", err)
+	}
+	if err := dbmanager.ValidateMongoDBConcerns(config); err != nil {
+		return apperrors.New("INVALID_MONGODB_CONCERNS", "{error}").With("error", err)
+	}
+	return nil
+}
+
+// importedConnectionKey identifies the same database account listed twice in an import file
+func importedConnectionKey(config dbmanager.ConnectionConfig) string {
+	parts := []string{config.Type, strings.ToLower(config.Host), config.Database}
+	for _, value := range []*string{config.Port, config.Username, config.SocketPath} {
+		if value != nil {
+			parts = append(parts, *value)
+		} else {
+			parts = append(parts, "")
+		}
+	}
+	return strings.Join(parts, "|")
+}
+
+func importedConnectionRequest(config dbmanager.ConnectionConfig) dtos.CreateConnectionRequest {
+	request := dtos.CreateConnectionRequest{
+		Type:                   config.Type,
+		Host:                   config.Host,
+		Port:                   config.Port,
+		Password:               config.Password,
+		Database:               config.Database,
+		AuthDatabase:           config.AuthDatabase,
+		SocketPath:             config.SocketPath,
+		ReadPreference:         config.ReadPreference,
+		ReadConcern:            config.ReadConcern,
+		WriteConcern:           config.WriteConcern,
+		SchemaSampleSize:       config.SchemaSampleSize,
+		SchemaMaxDepth:         config.SchemaMaxDepth,
+		SchemaArraySampleSize:  config.SchemaArraySampleSize,
+		SchemaNewestSampleSize: config.SchemaNewestSampleSize,
+		MaxConcurrentQueries:   config.MaxConcurrentQueries,
+		UseSSL:                 config.UseSSL,
+		SSLMode:                config.SSLMode,
+		SSLCertURL:             config.SSLCertURL,
+		SSLKeyURL:              config.SSLKeyURL,
+		SSLRootCertURL:         config.SSLRootCertURL,
+		SSLCert:                config.SSLCert,
+		SSLKey:                 config.SSLKey,
+		SSLRootCert:            config.SSLRootCert,
+	}
+	if config.Username != nil {
+		request.Username = *config.Username
+	}
+	return request
+}
+
+func buildImportedConnectionResult(connection dbmanager.ImportedConnection) dtos.ImportedConnectionResult {
+	result := dtos.ImportedConnectionResult{
+		Name:     connection.Name,
+		Type:     connection.Config.Type,
+		Host:     connection.Config.Host,
+		Port:     connection.Config.Port,
+		Database: connection.Config.Database,
+	}
+	if connection.Config.Username != nil {
+		result.Username = *connection.Config.Username
+	}
+	return result
+}
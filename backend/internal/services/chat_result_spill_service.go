@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"neobase-ai/config"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/models"
+	"neobase-ai/pkg/dbmanager"
+	"neobase-ai/pkg/resultstore"
+	"net/http"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// resultFileFormat is the format of the files of the spilled results, a JSON object per row like the downloads
+const resultFileFormat = "jsonl"
+
+// needsResultSpill reports whether the result of a read-only query doesn't fit in the chat: larger than the spill threshold, cut
+// at the row limit, or paginated over more rows than the pages can read.
+func (s *chatService) needsResultSpill(chat *models.Chat, query *models.Query, result *dbmanager.QueryExecutionResult, totalRecordsCount *int, maxRows int) bool {
+	if s.resultStore == nil || result == nil || result.Error != nil {
+		return false
+	}
+	truncated, _ := result.Result["truncated"].(bool)
+	threshold := config.Env.ResultSpillThresholdMB * 1024 * 1024
+	tooLarge := threshold > 0 && len(result.ResultJSON) > threshold
+	beyondPages := totalRecordsCount != nil && maxRows > 0 && *totalRecordsCount > maxRows
+	if !truncated && !tooLarge && !beyondPages {
+		return false
+	}
+	if !dbmanager.IsReadOnlyQuery(chat.Connection.Type, query.Query) {
+		log.Printf("ChatService -> needsResultSpill -> The result of queryID: %s is capped, only the results of read-only queries are spilled", query.ID.Hex())
+		return false
+	}
+	return true
+}
+
+// spillResult writes the whole result of a query to a file of the result store, run in the background once the execution was
+// answered with the capped preview. The query is read again like an export, none of the result limits apply. The file is saved
+// with the query if it still holds the execution of actionAt, & its link is sent to the stream in a query-result-file event;
+// the result-file route signs new ones. The preview is all there is when the file could not be written.
+func (s *chatService) spillResult(ctx context.Context, userID, chatID, streamID string, messageID primitive.ObjectID, query models.Query, actionAt string) {
+	// The export has its own timeout, the request is over by the time the file is written
+	ctx = context.WithoutCancel(ctx)
+
+	file, err := os.CreateTemp("", "neobase-result-*."+resultFileFormat)
+	if err != nil {
+		log.Printf("ChatService -> spillResult -> Error creating the result file: %v", err)
+		return
+	}
+	defer func() {
+		file.Close()
+		os.Remove(file.Name())
+	}()
+
+	rows, queryErr := s.dbManager.ExportQueryJSONL(ctx, chatID, query.Query, file)
+	if queryErr != nil {
+		log.Printf("ChatService -> spillResult -> Error reading the result of queryID: %s after %d rows: %+v", query.ID.Hex(), rows, queryErr)
+		return
+	}
+	info, err := file.Stat()
+	if err != nil {
+		log.Printf("ChatService -> spillResult -> Error reading the result file: %v", err)
+		return
+	}
+
+	key := fmt.Sprintf("%s/%s-%d.%s", chatID, query.ID.Hex(), time.Now().Unix(), resultFileFormat)
+	if err := s.resultStore.Put(ctx, key, file, "application/x-ndjson"); err != nil {
+		log.Printf("ChatService -> spillResult -> Error storing the result file %s: %v", key, err)
+		return
+	}
+	log.Printf("ChatService -> spillResult -> Spilled %d rows (%d bytes) of queryID: %s to %s", rows, info.Size(), query.ID.Hex(), key)
+
+	resultFile := &models.ResultFile{
+		Key:       key,
+		Format:    resultFileFormat,
+		Rows:      rows,
+		SizeBytes: info.Size(),
+		CreatedAt: time.Now(),
+	}
+	// The query may have been executed again or rolled back while its result was read
+	saved, err := s.chatRepo.SetQueryResultFile(messageID, query.ID, actionAt, resultFile)
+	if err != nil {
+		log.Printf("ChatService -> spillResult -> Error saving the result file of queryID: %s: %v", query.ID.Hex(), err)
+		return
+	}
+	if !saved {
+		log.Printf("ChatService -> spillResult -> queryID: %s changed since its execution, the result file %s is not saved", query.ID.Hex(), key)
+		return
+	}
+
+	link, err := s.resultFileLink(resultFile)
+	if err != nil {
+		log.Printf("ChatService -> spillResult -> Error signing the link of the result file: %v", err)
+		return
+	}
+	s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
+		Event: "query-result-file",
+		Data: map[string]interface{}{
+			"chat_id":     chatID,
+			"message_id":  messageID.Hex(),
+			"query_id":    query.ID.Hex(),
+			"result_file": link,
+		},
+	})
+}
+
+// resultFileLink signs a link downloading the file of a result
+func (s *chatService) resultFileLink(file *models.ResultFile) (*dtos.ResultFile, error) {
+	ttl := time.Duration(config.Env.ResultSpillLinkTTLMinutes) * time.Minute
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	if ttl > resultstore.MaxLinkTTL {
+		ttl = resultstore.MaxLinkTTL
+	}
+	url, err := s.resultStore.SignedURL(file.Key, ttl)
+	if err != nil {
+		return nil, err
+	}
+	return &dtos.ResultFile{
+		URL:       url,
+		ExpiresAt: time.Now().Add(ttl).Format(time.RFC3339),
+		Format:    file.Format,
+		Rows:      file.Rows,
+		SizeBytes: file.SizeBytes,
+	}, nil
+}
+
+// GetQueryResultFile returns a new link to the file the result of a query was spilled to, the links of the executions expire
+func (s *chatService) GetQueryResultFile(ctx context.Context, userID, chatID, messageID, queryID string) (*dtos.ResultFile, uint32, error) {
+	log.Printf("ChatService -> GetQueryResultFile -> Starting for chatID: %s, queryID: %s", chatID, queryID)
+
+	_, _, query, err := s.verifyQueryOwnership(userID, chatID, messageID, queryID)
+	if err != nil {
+		return nil, http.StatusForbidden, err
+	}
+	if query.ResultFile == nil || query.IsRolledBack {
+		return nil, http.StatusNotFound, apperrors.New("RESULT_FILE_NOT_FOUND", "the result of the query was not written to a file, or is still being written")
+	}
+	if s.resultStore == nil {
+		return nil, http.StatusNotFound, apperrors.New("RESULT_STORE_NOT_CONFIGURED", "the result files are no longer stored")
+	}
+
+	link, err := s.resultFileLink(query.ResultFile)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_SIGN_RESULT_LINK", "failed to sign the link of the result file: {error}").With("error", err)
+	}
+	return link, http.StatusOK, nil
+}
+
+// OpenResultFile returns the path of the file of a signed link of the local result store, the link is the credential
+func (s *chatService) OpenResultFile(key, expires, signature string) (string, uint32, error) {
+	store, ok := s.resultStore.(*resultstore.LocalStore)
+	if !ok {
+		return "", http.StatusNotFound, apperrors.New("RESULT_FILE_NOT_FOUND", "result file not found")
+	}
+
+	path, err := store.Open(key, expires, signature)
+	switch {
+	case errors.Is(err, resultstore.ErrLinkExpired):
+		return "", http.StatusGone, apperrors.New("RESULT_LINK_EXPIRED", "the link has expired, get a new one from the query")
+	case err != nil:
+		return "", http.StatusForbidden, apperrors.New("INVALID_RESULT_LINK", "invalid link")
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "", http.StatusNotFound, apperrors.New("RESULT_FILE_NOT_FOUND", "result file not found")
+	}
+	return path, http.StatusOK, nil
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/pkg/dbmanager"
+	"net/http"
+)
+
+// StreamQueryResults sends the whole result of a read-only query of a message to the stream in query-result-chunk events, read
+// from the chat's connection as the cursor advances rather than executed into a single JSON result. Nothing is saved in the message.
+func (s *chatService) StreamQueryResults(ctx context.Context, userID, chatID string, req *dtos.StreamQueryResultsRequest) (*dtos.StreamQueryResultsResponse, uint32, error) {
+	log.Printf("ChatService -> StreamQueryResults -> Starting for chatID: %s, queryID: %s", chatID, req.QueryID)
+
+	if s.streamHandler == nil {
+		return nil, http.StatusInternalServerError, apperrors.New("STREAM_NOT_AVAILABLE", "results can't be streamed")
+	}
+	if _, status, err := s.getConnectedChat(ctx, userID, chatID); err != nil {
+		return nil, status, err
+	}
+
+	_, _, query, err := s.verifyQueryOwnership(userID, chatID, req.MessageID, req.QueryID)
+	if err != nil {
+		return nil, http.StatusForbidden, err
+	}
+
+	// The rows are read with the values the query was executed with
+	if len(query.Params) > 0 {
+		ctx = dbmanager.WithQueryParams(ctx, query.Params)
+	}
+	// Replicas can lag behind the primary, the user can stream from the primary instead
+	if req.UsePrimary {
+		ctx = dbmanager.WithPrimaryRouting(ctx)
+	}
+
+	summary, queryErr := s.dbManager.StreamQueryChunks(ctx, chatID, req.StreamID, query.Query, req.ChunkSize, func(chunk dbmanager.ResultChunk) error {
+		return s.streamHandler.DeliverStreamEvent(ctx, userID, chatID, req.StreamID, dtos.StreamResponse{
+			Event: "query-result-chunk",
+			Data: map[string]interface{}{
+				"chat_id":     chatID,
+				"message_id":  req.MessageID,
+				"query_id":    req.QueryID,
+				"chunk_index": chunk.Index,
+				"columns":     chunk.Columns,
+				"rows":        chunk.Rows,
+				"is_last":     chunk.Last,
+			},
+		})
+	})
+	if queryErr != nil {
+		log.Printf("ChatService -> StreamQueryResults -> Streaming failed after %d rows: %+v", summary.Rows, queryErr)
+		details := queryErr.Details
+		if details == "" {
+			details = queryErr.Message
+		}
+		// The client is told the chunks stopped, it may still be reading the stream
+		s.sendStreamEvent(userID, chatID, req.StreamID, dtos.StreamResponse{
+			Event: "query-result-chunk-error",
+			Data: map[string]interface{}{
+				"chat_id":    chatID,
+				"message_id": req.MessageID,
+				"query_id":   req.QueryID,
+				"rows":       summary.Rows,
+				"error":      queryErr,
+			},
+		})
+		return nil, http.StatusBadRequest, apperrors.New(queryErr.Code, "{error}").With("error", details)
+	}
+
+	log.Printf("ChatService -> StreamQueryResults -> Streamed %d rows in %d chunks for queryID: %s", summary.Rows, summary.Chunks, req.QueryID)
+	return &dtos.StreamQueryResultsResponse{
+		ChatID:        chatID,
+		MessageID:     req.MessageID,
+		QueryID:       req.QueryID,
+		Rows:          summary.Rows,
+		Chunks:        summary.Chunks,
+		OnReadReplica: summary.OnReadReplica,
+	}, http.StatusOK, nil
+}
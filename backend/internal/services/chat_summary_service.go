@@ -0,0 +1,318 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"neobase-ai/internal/constants"
+	"neobase-ai/internal/models"
+	"neobase-ai/pkg/llm"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// Tokens kept for the system prompt & the response schema, they are not part of the messages
+	summaryPromptReserveTokens = 8000
+	// Executed queries listed in the summary, the oldest are dropped first
+	maxSummarizedQueries = 50
+	// Characters of an executed query kept in the summary
+	maxSummarizedQueryLength = 300
+)
+
+const conversationSummaryInstruction = `The earlier messages of this conversation no longer fit in your context, summarize them.
+Keep the user's goals, the tables & fields discussed, the decisions taken, the filters & preferences the user asked for and any open question.
+The executed queries are tracked separately, don't list them. Put the whole summary in assistantMessage, in at most 300 words, and return no queries.`
+
+// withConversationSummary replaces the older messages of a chat by a summary when the messages exceed the context of the model.
+// The summary is stored as a system message so the following requests reuse it, the schema updates are always kept as they are.
+func (s *chatService) withConversationSummary(ctx context.Context, llmClient llm.Client, chatObjID primitive.ObjectID, dbType string, messages []*models.LLMMessage) []*models.LLMMessage {
+	if len(messages) == 0 {
+		return messages
+	}
+
+	modelInfo := llmClient.GetModelInfo()
+	contextLimit := modelInfo.ContextLimit
+	if contextLimit <= 0 {
+		contextLimit = constants.DefaultLLMContextLimit
+	}
+	budget := contextLimit - modelInfo.MaxCompletionTokens - summaryPromptReserveTokens
+	if budget <= 0 {
+		return messages
+	}
+
+	summaryMsg, pinned, rest := splitSummarizedMessages(messages)
+	if estimateMessagesTokens(pinned)+estimateMessagesTokens([]*models.LLMMessage{summaryMsg})+estimateMessagesTokens(rest) <= budget {
+		return assembleSummarizedMessages(summaryMsg, pinned, rest)
+	}
+
+	// The latest messages are kept as they are, the older ones are summarized
+	recentBudget := budget / 2
+	recentStart := len(rest) - 1
+	recentTokens := estimateMessageTokens(rest[recentStart])
+	for recentStart > 0 {
+		tokens := estimateMessageTokens(rest[recentStart-1])
+		if recentTokens+tokens > recentBudget {
+			break
+		}
+		recentTokens += tokens
+		recentStart--
+	}
+	older, recent := rest[:recentStart], rest[recentStart:]
+
+	// Transient messages (live activity, server features...) are not stored, they can't be summarized
+	summarized := make([]*models.LLMMessage, 0, len(older))
+	for _, msg := range older {
+		if !msg.ID.IsZero() {
+			summarized = append(summarized, msg)
+		} else {
+			recent = append([]*models.LLMMessage{msg}, recent...)
+		}
+	}
+	if len(summarized) == 0 {
+		log.Printf("withConversationSummary -> chat %s exceeds the context of %s but has no message to summarize", chatObjID.Hex(), modelInfo.Name)
+		return assembleSummarizedMessages(summaryMsg, pinned, rest)
+	}
+
+	previousSummary := ""
+	var executedQueries []string
+	if summaryMsg != nil {
+		previousSummary, _ = summaryMsg.Content["summary"].(string)
+		executedQueries = toStringSlice(summaryMsg.Content["executed_queries"])
+	}
+
+	// The older messages are summarized in chunks fitting the context, each chunk folds the summary of the previous ones
+	summary := previousSummary
+	chunkBudget := budget - recentBudget
+	for start := 0; start < len(summarized); {
+		end := start
+		chunkTokens := 0
+		for end < len(summarized) && (end == start || chunkTokens+estimateMessageTokens(summarized[end]) <= chunkBudget) {
+			chunkTokens += estimateMessageTokens(summarized[end])
+			end++
+		}
+
+		chunkSummary, err := s.summarizeMessages(ctx, llmClient, chatObjID, dbType, summary, summarized[start:end])
+		if err != nil {
+			log.Printf("withConversationSummary -> Error summarizing the messages of chat %s: %v", chatObjID.Hex(), err)
+			return assembleSummarizedMessages(summaryMsg, pinned, rest)
+		}
+		summary = chunkSummary
+		start = end
+	}
+
+	for _, msg := range summarized {
+		executedQueries = append(executedQueries, executedQueryOutcomes(msg)...)
+	}
+	if len(executedQueries) > maxSummarizedQueries {
+		executedQueries = executedQueries[len(executedQueries)-maxSummarizedQueries:]
+	}
+
+	content := map[string]interface{}{
+		"conversation_summary": formatConversationSummary(summary, executedQueries),
+		"summary":              summary,
+		"executed_queries":     executedQueries,
+		"summarized_until":     summarized[len(summarized)-1].ID.Hex(),
+	}
+	if summaryMsg != nil && !summaryMsg.ID.IsZero() {
+		summaryMsg.Content = content
+		summaryMsg.UpdatedAt = time.Now()
+		if err := s.llmRepo.UpdateMessage(summaryMsg.ID, summaryMsg); err != nil {
+			log.Printf("withConversationSummary -> Error updating the summary of chat %s: %v", chatObjID.Hex(), err)
+		}
+	} else {
+		summaryMsg = &models.LLMMessage{
+			Base:    models.NewBase(),
+			ChatID:  chatObjID,
+			UserID:  messages[len(messages)-1].UserID,
+			Role:    string(constants.MessageTypeSystem),
+			Content: content,
+		}
+		if err := s.llmRepo.CreateMessage(summaryMsg); err != nil {
+			log.Printf("withConversationSummary -> Error saving the summary of chat %s: %v", chatObjID.Hex(), err)
+		}
+	}
+
+	log.Printf("withConversationSummary -> summarized %d messages of chat %s to fit the %d tokens context of %s", len(summarized), chatObjID.Hex(), contextLimit, modelInfo.Name)
+	return assembleSummarizedMessages(summaryMsg, pinned, recent)
+}
+
+// summarizeMessages asks the LLM to summarize the messages, folding in the summary of the messages before them
+func (s *chatService) summarizeMessages(ctx context.Context, llmClient llm.Client, chatObjID primitive.ObjectID, dbType, previousSummary string, messages []*models.LLMMessage) (string, error) {
+	summaryMessages := make([]*models.LLMMessage, 0, len(messages)+2)
+	if previousSummary != "" {
+		summaryMessages = append(summaryMessages, &models.LLMMessage{
+			ChatID:  chatObjID,
+			Role:    string(constants.MessageTypeSystem),
+			Content: map[string]interface{}{"conversation_summary": previousSummary},
+		})
+	}
+	summaryMessages = append(summaryMessages, messages...)
+	summaryMessages = append(summaryMessages, &models.LLMMessage{
+		ChatID:  chatObjID,
+		Role:    string(constants.MessageTypeUser),
+		Content: map[string]interface{}{"user_message": conversationSummaryInstruction},
+	})
+
+	response, err := llmClient.GenerateResponse(ctx, summaryMessages, dbType)
+	if err != nil {
+		return "", err
+	}
+
+	var jsonResponse map[string]interface{}
+	if err := json.Unmarshal([]byte(response), &jsonResponse); err != nil {
+		return "", fmt.Errorf("invalid summary response: %v", err)
+	}
+	summary, _ := jsonResponse["assistantMessage"].(string)
+	if strings.TrimSpace(summary) == "" {
+		return "", fmt.Errorf("empty summary")
+	}
+	return strings.TrimSpace(summary), nil
+}
+
+// splitSummarizedMessages returns the stored summary of the chat, the schema updates & the messages it doesn't cover.
+// A summary covering a message no longer in the list (edited or deleted) is outdated and ignored.
+func splitSummarizedMessages(messages []*models.LLMMessage) (*models.LLMMessage, []*models.LLMMessage, []*models.LLMMessage) {
+	var summaryMsg *models.LLMMessage
+	cutoff := -1
+	for _, msg := range messages {
+		summarizedUntil, ok := msg.Content["summarized_until"].(string)
+		if !ok {
+			continue
+		}
+		for i, candidate := range messages {
+			if candidate.ID.Hex() == summarizedUntil {
+				if i > cutoff {
+					summaryMsg, cutoff = msg, i
+				}
+				break
+			}
+		}
+	}
+
+	pinned := make([]*models.LLMMessage, 0)
+	rest := make([]*models.LLMMessage, 0, len(messages))
+	for i, msg := range messages {
+		if _, isSummary := msg.Content["conversation_summary"]; isSummary {
+			continue
+		}
+		if _, isSchema := msg.Content["schema_update"]; isSchema {
+			pinned = append(pinned, msg)
+			continue
+		}
+		if i <= cutoff {
+			continue
+		}
+		rest = append(rest, msg)
+	}
+	return summaryMsg, pinned, rest
+}
+
+func assembleSummarizedMessages(summaryMsg *models.LLMMessage, pinned, rest []*models.LLMMessage) []*models.LLMMessage {
+	assembled := make([]*models.LLMMessage, 0, len(pinned)+len(rest)+1)
+	assembled = append(assembled, pinned...)
+	if summaryMsg != nil {
+		assembled = append(assembled, summaryMsg)
+	}
+	return append(assembled, rest...)
+}
+
+// estimateMessagesTokens estimates the tokens of the messages, about 4 characters per token
+func estimateMessagesTokens(messages []*models.LLMMessage) int {
+	tokens := 0
+	for _, msg := range messages {
+		if msg != nil {
+			tokens += estimateMessageTokens(msg)
+		}
+	}
+	return tokens
+}
+
+func estimateMessageTokens(msg *models.LLMMessage) int {
+	content, err := json.Marshal(msg.Content)
+	if err != nil {
+		return 0
+	}
+	return len(content)/4 + 1
+}
+
+// executedQueryOutcomes lists the queries of an assistant message which were executed, with their outcome
+func executedQueryOutcomes(msg *models.LLMMessage) []string {
+	assistantResponse := toStringMap(msg.Content["assistant_response"])
+	if assistantResponse == nil {
+		return nil
+	}
+
+	var outcomes []string
+	for _, q := range toInterfaceSlice(assistantResponse["queries"]) {
+		queryMap := toStringMap(q)
+		if queryMap == nil {
+			continue
+		}
+		if isExecuted, _ := queryMap["isExecuted"].(bool); !isExecuted {
+			continue
+		}
+
+		query, _ := queryMap["query"].(string)
+		query = strings.Join(strings.Fields(query), " ")
+		if len(query) > maxSummarizedQueryLength {
+			query = query[:maxSummarizedQueryLength] + "..."
+		}
+
+		outcome := "succeeded"
+		if queryErr := toStringMap(queryMap["error"]); queryErr != nil {
+			message, _ := queryErr["message"].(string)
+			outcome = "failed: " + message
+		}
+		if isRolledBack, _ := queryMap["isRolledBack"].(bool); isRolledBack {
+			outcome = "rolled back"
+		}
+		outcomes = append(outcomes, fmt.Sprintf("%s -> %s", query, outcome))
+	}
+	return outcomes
+}
+
+func formatConversationSummary(summary string, executedQueries []string) string {
+	if len(executedQueries) == 0 {
+		return summary
+	}
+	return fmt.Sprintf("%s\n\nQueries executed earlier in this chat:\n- %s", summary, strings.Join(executedQueries, "\n- "))
+}
+
+func toStringMap(value interface{}) map[string]interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return v
+	case primitive.M:
+		return v
+	}
+	return nil
+}
+
+func toInterfaceSlice(value interface{}) []interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		return v
+	case primitive.A:
+		return v
+	}
+	return nil
+}
+
+func toStringSlice(value interface{}) []string {
+	var values []string
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}, primitive.A:
+		for _, item := range toInterfaceSlice(v) {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+	return values
+}
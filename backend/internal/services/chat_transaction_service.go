@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/models"
+	"neobase-ai/internal/utils"
+	"neobase-ai/pkg/dbmanager"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Actions of the buttons ending the open transaction of a chat, added to the messages whose queries ran in it
+const (
+	commitTransactionAction   = "commit_transaction"
+	rollbackTransactionAction = "rollback_transaction"
+)
+
+// StartTransaction opens a transaction on the chat's database, the queries executed next run in it until it is committed, rolled
+// back or expires
+func (s *chatService) StartTransaction(ctx context.Context, userID, chatID string, req *dtos.StartTransactionRequest) (*dtos.TransactionResponse, uint32, error) {
+	log.Printf("ChatService -> StartTransaction -> Starting for chatID: %s", chatID)
+
+	if _, status, err := s.getConnectedChat(ctx, userID, chatID); err != nil {
+		return nil, status, err
+	}
+
+	session, err := s.dbManager.StartTransactionSession(chatID, req.StreamID)
+	if err != nil {
+		log.Printf("ChatService -> StartTransaction -> Error starting transaction: %v", err)
+		return nil, http.StatusBadRequest, apperrors.New("TRANSACTION_NOT_STARTED", "failed to start the transaction: {error}").With("error", err)
+	}
+	return toTransactionResponse(session), http.StatusOK, nil
+}
+
+// GetTransaction returns the open transaction of the chat & the queries executed in it
+func (s *chatService) GetTransaction(ctx context.Context, userID, chatID string) (*dtos.TransactionResponse, uint32, error) {
+	if _, status, err := s.getConnectedChat(ctx, userID, chatID); err != nil {
+		return nil, status, err
+	}
+	return toTransactionResponse(s.dbManager.GetTransactionSession(chatID)), http.StatusOK, nil
+}
+
+// CommitTransaction commits the open transaction of the chat
+func (s *chatService) CommitTransaction(ctx context.Context, userID, chatID string) (*dtos.TransactionEndResponse, uint32, error) {
+	log.Printf("ChatService -> CommitTransaction -> Committing the transaction of chatID: %s", chatID)
+	return s.endTransaction(ctx, userID, chatID, s.dbManager.CommitTransactionSession)
+}
+
+// RollbackTransaction rolls back the open transaction of the chat, undoing every query executed in it
+func (s *chatService) RollbackTransaction(ctx context.Context, userID, chatID string) (*dtos.TransactionEndResponse, uint32, error) {
+	log.Printf("ChatService -> RollbackTransaction -> Rolling back the transaction of chatID: %s", chatID)
+	return s.endTransaction(ctx, userID, chatID, s.dbManager.RollbackTransactionSession)
+}
+
+func (s *chatService) endTransaction(ctx context.Context, userID, chatID string, end func(chatID string) (*dbmanager.TransactionSessionEnd, error)) (*dtos.TransactionEndResponse, uint32, error) {
+	if _, status, err := s.getConnectedChat(ctx, userID, chatID); err != nil {
+		return nil, status, err
+	}
+	if s.dbManager.GetTransactionSession(chatID) == nil {
+		return nil, http.StatusNotFound, apperrors.New("TRANSACTION_NOT_FOUND", "the chat has no open transaction")
+	}
+
+	ended, err := end(chatID)
+	if ended == nil {
+		return nil, http.StatusNotFound, apperrors.New("TRANSACTION_NOT_FOUND", "the chat has no open transaction")
+	}
+	if err != nil {
+		log.Printf("ChatService -> endTransaction -> %v", err)
+		return nil, http.StatusInternalServerError, apperrors.New("TRANSACTION_COMMIT_FAILED", "{error}").With("error", err)
+	}
+	return &dtos.TransactionEndResponse{
+		Reason:  ended.Reason,
+		Error:   ended.Error,
+		Queries: toTransactionQueryResponses(ended.Queries),
+	}, http.StatusOK, nil
+}
+
+// HandleTransactionSessionEnded updates the messages whose queries ran in an ended transaction: their transaction buttons are
+// removed & the queries are marked rolled back unless the transaction was committed
+func (s *chatService) HandleTransactionSessionEnded(userID, chatID string, end dbmanager.TransactionSessionEnd) {
+	log.Printf("ChatService -> HandleTransactionSessionEnded -> Transaction of chatID %s ended (%s)", chatID, end.Reason)
+
+	queriesByMessage := map[string]map[string]bool{}
+	for _, query := range end.Queries {
+		if queriesByMessage[query.MessageID] == nil {
+			queriesByMessage[query.MessageID] = map[string]bool{}
+		}
+		queriesByMessage[query.MessageID][query.QueryID] = true
+	}
+
+	rolledBack := end.Reason != dbmanager.TransactionEndReasonCommitted
+	for messageID, queryIDs := range queriesByMessage {
+		messageObjID, err := primitive.ObjectIDFromHex(messageID)
+		if err != nil {
+			continue
+		}
+		msg, err := s.chatRepo.FindMessageByID(messageObjID)
+		if err != nil || msg == nil {
+			log.Printf("ChatService -> HandleTransactionSessionEnded -> Error finding message %s: %v", messageID, err)
+			continue
+		}
+
+		if rolledBack && msg.Queries != nil {
+			for i := range *msg.Queries {
+				if queryIDs[(*msg.Queries)[i].ID.Hex()] {
+					(*msg.Queries)[i].IsRolledBack = true
+					(*msg.Queries)[i].ActionAt = utils.ToStringPtr(time.Now().Format(time.RFC3339))
+				}
+			}
+		}
+		removeTransactionButtons(msg)
+		if err := s.chatRepo.UpdateMessage(msg.ID, msg); err != nil {
+			log.Printf("ChatService -> HandleTransactionSessionEnded -> Error updating message %s: %v", messageID, err)
+		}
+	}
+}
+
+// inTransactionSession returns true when the query ran in the open transaction of the chat
+func (s *chatService) inTransactionSession(chatID, queryID string) bool {
+	session := s.dbManager.GetTransactionSession(chatID)
+	if session == nil {
+		return false
+	}
+	for _, query := range session.Queries {
+		if query.QueryID == queryID {
+			return true
+		}
+	}
+	return false
+}
+
+// addTransactionButtons adds the buttons committing & rolling back the open transaction to a message whose query ran in it
+func addTransactionButtons(msg *models.Message) {
+	actionButtons := []models.ActionButton{}
+	if msg.ActionButtons != nil {
+		for _, button := range *msg.ActionButtons {
+			if button.Action == commitTransactionAction {
+				return
+			}
+		}
+		actionButtons = append(actionButtons, *msg.ActionButtons...)
+	}
+	actionButtons = append(actionButtons,
+		models.ActionButton{
+			ID:        primitive.NewObjectID(),
+			Label:     "Commit Transaction",
+			Action:    commitTransactionAction,
+			IsPrimary: true,
+		},
+		models.ActionButton{
+			ID:        primitive.NewObjectID(),
+			Label:     "Rollback Transaction",
+			Action:    rollbackTransactionAction,
+			IsPrimary: false,
+		},
+	)
+	msg.ActionButtons = &actionButtons
+}
+
+// removeTransactionButtons removes the buttons of an ended transaction from a message
+func removeTransactionButtons(msg *models.Message) {
+	if msg.ActionButtons == nil {
+		return
+	}
+	var filteredButtons []models.ActionButton
+	for _, button := range *msg.ActionButtons {
+		if button.Action != commitTransactionAction && button.Action != rollbackTransactionAction {
+			filteredButtons = append(filteredButtons, button)
+		}
+	}
+	if len(filteredButtons) > 0 {
+		msg.ActionButtons = &filteredButtons
+	} else {
+		msg.ActionButtons = nil
+	}
+}
+
+func toTransactionResponse(session *dbmanager.TransactionSessionInfo) *dtos.TransactionResponse {
+	if session == nil {
+		return &dtos.TransactionResponse{Queries: []dtos.TransactionQueryResponse{}}
+	}
+	return &dtos.TransactionResponse{
+		Active:     true,
+		StreamID:   session.StreamID,
+		StartedAt:  session.StartedAt.Format(time.RFC3339),
+		LastUsedAt: session.LastUsedAt.Format(time.RFC3339),
+		ExpiresAt:  session.ExpiresAt.Format(time.RFC3339),
+		Queries:    toTransactionQueryResponses(session.Queries),
+	}
+}
+
+func toTransactionQueryResponses(queries []dbmanager.TransactionSessionQuery) []dtos.TransactionQueryResponse {
+	responses := make([]dtos.TransactionQueryResponse, 0, len(queries))
+	for _, query := range queries {
+		responses = append(responses, dtos.TransactionQueryResponse{
+			MessageID:  query.MessageID,
+			QueryID:    query.QueryID,
+			Query:      query.Query,
+			QueryType:  query.QueryType,
+			ExecutedAt: query.ExecutedAt.Format(time.RFC3339),
+		})
+	}
+	return responses
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/constants"
+	"neobase-ai/pkg/dbmanager"
+	"net/http"
+	"time"
+)
+
+// StartWatch opens a change stream on MongoDB collections of the chat, their changes are pushed to the given stream
+func (s *chatService) StartWatch(ctx context.Context, userID, chatID string, req *dtos.StartWatchRequest) (*dtos.WatchResponse, uint32, error) {
+	log.Printf("ChatService -> StartWatch -> Starting for chatID: %s, collections: %v", chatID, req.Collections)
+
+	chat, status, err := s.getConnectedChat(ctx, userID, chatID)
+	if err != nil {
+		return nil, status, err
+	}
+	if chat.Connection.Type != constants.DatabaseTypeMongoDB {
+		return nil, http.StatusBadRequest, apperrors.New("WATCH_NOT_SUPPORTED", "watching changes is only supported for MongoDB, not {type}").With("type", chat.Connection.Type)
+	}
+
+	watch, err := s.dbManager.StartChangeStreamWatch(chatID, req.StreamID, dbmanager.WatchOptions{
+		Collections:    req.Collections,
+		OperationTypes: req.OperationTypes,
+		Duration:       time.Duration(req.DurationMinutes) * time.Minute,
+	})
+	if err != nil {
+		log.Printf("ChatService -> StartWatch -> Error starting watch: %v", err)
+		return nil, http.StatusBadRequest, err
+	}
+
+	response := toWatchResponse(*watch)
+	return &response, http.StatusOK, nil
+}
+
+// ListWatches returns the open change stream watches of the chat
+func (s *chatService) ListWatches(ctx context.Context, userID, chatID string) (*dtos.WatchListResponse, uint32, error) {
+	if _, status, err := s.getConnectedChat(ctx, userID, chatID); err != nil {
+		return nil, status, err
+	}
+
+	watches := s.dbManager.ListChangeStreamWatches(chatID)
+	response := &dtos.WatchListResponse{Watches: make([]dtos.WatchResponse, 0, len(watches))}
+	for _, watch := range watches {
+		response.Watches = append(response.Watches, toWatchResponse(watch))
+	}
+	return response, http.StatusOK, nil
+}
+
+// StopWatch stops a change stream watch of the chat, a db-change-stopped event is sent to its stream
+func (s *chatService) StopWatch(ctx context.Context, userID, chatID, watchID string) (uint32, error) {
+	log.Printf("ChatService -> StopWatch -> Stopping watch %s of chatID: %s", watchID, chatID)
+
+	if _, status, err := s.getConnectedChat(ctx, userID, chatID); err != nil {
+		return status, err
+	}
+
+	if err := s.dbManager.StopChangeStreamWatch(chatID, watchID); err != nil {
+		return http.StatusNotFound, apperrors.New("WATCH_NOT_FOUND", "watch not found")
+	}
+	return http.StatusOK, nil
+}
+
+func toWatchResponse(watch dbmanager.ChangeStreamWatchInfo) dtos.WatchResponse {
+	return dtos.WatchResponse{
+		ID:             watch.ID,
+		StreamID:       watch.StreamID,
+		Collections:    watch.Collections,
+		OperationTypes: watch.OperationTypes,
+		StartedAt:      watch.StartedAt.Format(time.RFC3339),
+		ExpiresAt:      watch.ExpiresAt.Format(time.RFC3339),
+		Events:         watch.Events,
+	}
+}
@@ -0,0 +1,291 @@
+package services
+
+import (
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/models"
+	"neobase-ai/internal/repositories"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CommentService interface {
+	Create(userID, queryID string, req *dtos.CreateCommentRequest) (*dtos.CommentResponse, uint32, error)
+	List(userID, queryID string) (*dtos.CommentListResponse, uint32, error)
+	Update(userID, queryID, commentID string, req *dtos.UpdateCommentRequest) (*dtos.CommentResponse, uint32, error)
+	Delete(userID, queryID, commentID string) (uint32, error)
+}
+
+type commentService struct {
+	commentRepo repositories.CommentRepository
+	chatRepo    repositories.ChatRepository
+	userRepo    repositories.UserRepository
+}
+
+func NewCommentService(commentRepo repositories.CommentRepository, chatRepo repositories.ChatRepository, userRepo repositories.UserRepository) CommentService {
+	return &commentService{
+		commentRepo: commentRepo,
+		chatRepo:    chatRepo,
+		userRepo:    userRepo,
+	}
+}
+
+// Create adds a comment (or a reply to an existing comment) to a query
+func (s *commentService) Create(userID, queryID string, req *dtos.CreateCommentRequest) (*dtos.CommentResponse, uint32, error) {
+	log.Printf("CommentService -> Create -> userID: %s, queryID: %s", userID, queryID)
+
+	userObjID, msg, query, statusCode, err := s.verifyQueryAccess(userID, queryID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	content := strings.TrimSpace(req.Content)
+	if content == "" {
+		return nil, http.StatusBadRequest, apperrors.New("COMMENT_CONTENT_REQUIRED", "comment content cannot be empty")
+	}
+
+	for _, rowIndex := range req.RowIndexes {
+		if rowIndex < 0 {
+			return nil, http.StatusBadRequest, apperrors.New("INVALID_ROW_INDEXES", "row indexes must be positive")
+		}
+	}
+
+	var parentObjID *primitive.ObjectID
+	if req.ParentID != nil && *req.ParentID != "" {
+		parentID, err := primitive.ObjectIDFromHex(*req.ParentID)
+		if err != nil {
+			return nil, http.StatusBadRequest, apperrors.New("INVALID_PARENT_COMMENT_ID", "invalid parent comment ID format")
+		}
+		parent, err := s.commentRepo.FindByID(parentID)
+		if err != nil {
+			return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_PARENT_COMMENT", "failed to fetch parent comment: {error}").With("error", err)
+		}
+		if parent == nil || parent.QueryID != query.ID {
+			return nil, http.StatusNotFound, apperrors.New("PARENT_COMMENT_NOT_FOUND", "parent comment not found")
+		}
+		parentObjID = &parentID
+	}
+
+	comment := models.NewQueryComment(userObjID, msg.ChatID, msg.ID, query.ID, parentObjID, req.RowIndexes, content)
+	if err := s.commentRepo.Create(comment); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_CREATE_COMMENT", "failed to create comment: {error}").With("error", err)
+	}
+
+	return s.buildCommentResponse(comment, map[primitive.ObjectID]*models.User{}), http.StatusCreated, nil
+}
+
+// List returns the comment threads of a query
+func (s *commentService) List(userID, queryID string) (*dtos.CommentListResponse, uint32, error) {
+	_, _, query, statusCode, err := s.verifyQueryAccess(userID, queryID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	comments, err := s.commentRepo.FindByQueryID(query.ID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_COMMENTS", "failed to fetch comments: {error}").With("error", err)
+	}
+
+	// Build the threads, comments are sorted oldest first so replies keep their order
+	users := make(map[primitive.ObjectID]*models.User)
+	responses := make(map[primitive.ObjectID]*dtos.CommentResponse, len(comments))
+	for _, comment := range comments {
+		responses[comment.ID] = s.buildCommentResponse(comment, users)
+	}
+
+	children := make(map[primitive.ObjectID][]*models.QueryComment)
+	var roots []*models.QueryComment
+	for _, comment := range comments {
+		if comment.ParentID != nil {
+			if _, ok := responses[*comment.ParentID]; ok {
+				children[*comment.ParentID] = append(children[*comment.ParentID], comment)
+				continue
+			}
+		}
+		roots = append(roots, comment)
+	}
+	var attach func(comment *models.QueryComment) dtos.CommentResponse
+	attach = func(comment *models.QueryComment) dtos.CommentResponse {
+		response := *responses[comment.ID]
+		for _, child := range children[comment.ID] {
+			response.Replies = append(response.Replies, attach(child))
+		}
+		return response
+	}
+
+	threads := make([]dtos.CommentResponse, 0, len(roots))
+	for _, root := range roots {
+		threads = append(threads, attach(root))
+	}
+
+	return &dtos.CommentListResponse{
+		Comments: threads,
+		Total:    len(comments),
+	}, http.StatusOK, nil
+}
+
+// Update edits the content of a comment, only the author can edit it
+func (s *commentService) Update(userID, queryID, commentID string, req *dtos.UpdateCommentRequest) (*dtos.CommentResponse, uint32, error) {
+	userObjID, _, query, statusCode, err := s.verifyQueryAccess(userID, queryID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	comment, statusCode, err := s.findAuthoredComment(userObjID, query.ID, commentID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	content := strings.TrimSpace(req.Content)
+	if content == "" {
+		return nil, http.StatusBadRequest, apperrors.New("COMMENT_CONTENT_REQUIRED", "comment content cannot be empty")
+	}
+
+	comment.Content = content
+	comment.IsEdited = true
+	if err := s.commentRepo.Update(comment.ID, comment); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_UPDATE_COMMENT", "failed to update comment: {error}").With("error", err)
+	}
+
+	return s.buildCommentResponse(comment, map[primitive.ObjectID]*models.User{}), http.StatusOK, nil
+}
+
+// Delete removes a comment along with its replies, only the author can delete it
+func (s *commentService) Delete(userID, queryID, commentID string) (uint32, error) {
+	userObjID, _, query, statusCode, err := s.verifyQueryAccess(userID, queryID)
+	if err != nil {
+		return statusCode, err
+	}
+
+	comment, statusCode, err := s.findAuthoredComment(userObjID, query.ID, commentID)
+	if err != nil {
+		return statusCode, err
+	}
+
+	comments, err := s.commentRepo.FindByQueryID(query.ID)
+	if err != nil {
+		return http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_COMMENTS", "failed to fetch comments: {error}").With("error", err)
+	}
+
+	// Collect the comment & all of its descendants
+	idsToDelete := []primitive.ObjectID{comment.ID}
+	for i := 0; i < len(idsToDelete); i++ {
+		for _, c := range comments {
+			if c.ParentID != nil && *c.ParentID == idsToDelete[i] {
+				idsToDelete = append(idsToDelete, c.ID)
+			}
+		}
+	}
+
+	if err := s.commentRepo.DeleteMany(idsToDelete); err != nil {
+		return http.StatusInternalServerError, apperrors.New("FAILED_TO_DELETE_COMMENT", "failed to delete comment: {error}").With("error", err)
+	}
+	return http.StatusOK, nil
+}
+
+// verifyQueryAccess checks that the user has access to the chat the query belongs to
+func (s *commentService) verifyQueryAccess(userID, queryID string) (primitive.ObjectID, *models.Message, *models.Query, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return primitive.NilObjectID, nil, nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+
+	queryObjID, err := primitive.ObjectIDFromHex(queryID)
+	if err != nil {
+		return primitive.NilObjectID, nil, nil, http.StatusBadRequest, apperrors.New("INVALID_QUERY_ID", "invalid query ID format")
+	}
+
+	msg, err := s.chatRepo.FindMessageByQueryID(queryObjID)
+	if err != nil {
+		return primitive.NilObjectID, nil, nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_MESSAGE", "failed to fetch message: {error}").With("error", err)
+	}
+	if msg == nil {
+		return primitive.NilObjectID, nil, nil, http.StatusNotFound, apperrors.New("QUERY_NOT_FOUND", "query not found")
+	}
+
+	chat, err := s.chatRepo.FindByID(msg.ChatID)
+	if err != nil {
+		return primitive.NilObjectID, nil, nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CHAT", "failed to fetch chat: {error}").With("error", err)
+	}
+	if chat == nil {
+		return primitive.NilObjectID, nil, nil, http.StatusNotFound, apperrors.New("CHAT_NOT_FOUND", "chat not found")
+	}
+	if chat.UserID != userObjID {
+		return primitive.NilObjectID, nil, nil, http.StatusForbidden, apperrors.New("CHAT_ACCESS_DENIED", "chat does not belong to user")
+	}
+
+	var query *models.Query
+	for i := range *msg.Queries {
+		if (*msg.Queries)[i].ID == queryObjID {
+			query = &(*msg.Queries)[i]
+			break
+		}
+	}
+	if query == nil {
+		return primitive.NilObjectID, nil, nil, http.StatusNotFound, apperrors.New("QUERY_NOT_FOUND", "query not found")
+	}
+
+	return userObjID, msg, query, http.StatusOK, nil
+}
+
+func (s *commentService) findAuthoredComment(userObjID, queryObjID primitive.ObjectID, commentID string) (*models.QueryComment, uint32, error) {
+	commentObjID, err := primitive.ObjectIDFromHex(commentID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_COMMENT_ID", "invalid comment ID format")
+	}
+
+	comment, err := s.commentRepo.FindByID(commentObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_COMMENT", "failed to fetch comment: {error}").With("error", err)
+	}
+	if comment == nil || comment.QueryID != queryObjID {
+		return nil, http.StatusNotFound, apperrors.New("COMMENT_NOT_FOUND", "comment not found")
+	}
+	if comment.UserID != userObjID {
+		return nil, http.StatusForbidden, apperrors.New("COMMENT_ACCESS_DENIED", "comment does not belong to user")
+	}
+	return comment, http.StatusOK, nil
+}
+
+// buildCommentResponse converts a comment to its response, users are cached to avoid repeated lookups
+func (s *commentService) buildCommentResponse(comment *models.QueryComment, users map[primitive.ObjectID]*models.User) *dtos.CommentResponse {
+	author := dtos.CommentAuthor{
+		ID: comment.UserID.Hex(),
+	}
+	user, ok := users[comment.UserID]
+	if !ok {
+		var err error
+		user, err = s.userRepo.FindByID(comment.UserID.Hex())
+		if err != nil {
+			log.Printf("CommentService -> buildCommentResponse -> Error fetching user: %v", err)
+		}
+		users[comment.UserID] = user
+	}
+	if user != nil {
+		author.Username = user.Username
+	}
+
+	var parentID *string
+	if comment.ParentID != nil {
+		parentIDHex := comment.ParentID.Hex()
+		parentID = &parentIDHex
+	}
+
+	return &dtos.CommentResponse{
+		ID:         comment.ID.Hex(),
+		ChatID:     comment.ChatID.Hex(),
+		MessageID:  comment.MessageID.Hex(),
+		QueryID:    comment.QueryID.Hex(),
+		ParentID:   parentID,
+		RowIndexes: comment.RowIndexes,
+		Content:    comment.Content,
+		IsEdited:   comment.IsEdited,
+		Author:     author,
+		CreatedAt:  comment.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:  comment.UpdatedAt.Format(time.RFC3339),
+	}
+}
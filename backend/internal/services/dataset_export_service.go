@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/models"
+	"neobase-ai/internal/repositories"
+	"neobase-ai/pkg/dbmanager"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// datasetDateLayout is the format of the bounds of the exported period, e.g. 2025-03-01
+const datasetDateLayout = "2006-01-02"
+
+// Values users could have typed in questions & queries, they are redacted from the dataset
+var (
+	datasetEmailRegex  = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	datasetIPv4Regex   = regexp.MustCompile(`\b\d{1,3}(?:\.\d{1,3}){3}\b`)
+	datasetNumberRegex = regexp.MustCompile(`\b\d{6,}\b`) // phone, card & account numbers, short numbers are kept as they make the query
+)
+
+type DatasetExportService interface {
+	// ExportFineTuningDataset calls write with a record per query generated in the period, since & until are YYYY-MM-DD & optional.
+	// Writing stops at the first error of write.
+	ExportFineTuningDataset(ctx context.Context, since, until string, executedOnly bool, write func(record *dtos.FineTuningRecord) error) (uint32, error)
+}
+
+type datasetExportService struct {
+	chatRepo     repositories.ChatRepository
+	bookmarkRepo repositories.BookmarkRepository
+	commentRepo  repositories.CommentRepository
+	dbManager    *dbmanager.Manager
+}
+
+func NewDatasetExportService(chatRepo repositories.ChatRepository, bookmarkRepo repositories.BookmarkRepository, commentRepo repositories.CommentRepository, dbManager *dbmanager.Manager) DatasetExportService {
+	return &datasetExportService{
+		chatRepo:     chatRepo,
+		bookmarkRepo: bookmarkRepo,
+		commentRepo:  commentRepo,
+		dbManager:    dbManager,
+	}
+}
+
+// datasetChat is what the records of a chat share, loaded once per chat
+type datasetChat struct {
+	dbType string
+	schema *dbmanager.LLMSchemaInfo // nil when the schema of the chat is no longer stored
+}
+
+func (s *datasetExportService) ExportFineTuningDataset(ctx context.Context, since, until string, executedOnly bool, write func(record *dtos.FineTuningRecord) error) (uint32, error) {
+	log.Printf("DatasetExportService -> ExportFineTuningDataset -> since: %s, until: %s, executedOnly: %t", since, until, executedOnly)
+
+	var sinceTime, untilTime *time.Time
+	if since != "" {
+		parsed, err := time.Parse(datasetDateLayout, since)
+		if err != nil {
+			return http.StatusBadRequest, apperrors.New("INVALID_DATASET_PERIOD", "invalid since {since}, expected YYYY-MM-DD").With("since", since)
+		}
+		sinceTime = &parsed
+	}
+	if until != "" {
+		parsed, err := time.Parse(datasetDateLayout, until)
+		if err != nil {
+			return http.StatusBadRequest, apperrors.New("INVALID_DATASET_PERIOD", "invalid until {until}, expected YYYY-MM-DD").With("until", until)
+		}
+		// until is inclusive
+		parsed = parsed.AddDate(0, 0, 1)
+		untilTime = &parsed
+	}
+	if sinceTime != nil && untilTime != nil && !sinceTime.Before(*untilTime) {
+		return http.StatusBadRequest, apperrors.New("INVALID_DATASET_PERIOD", "since must not be after until")
+	}
+
+	chats := make(map[primitive.ObjectID]*datasetChat)
+	err := s.chatRepo.ForEachQueryMessage(ctx, sinceTime, untilTime, func(message *models.Message) error {
+		chat, err := s.loadChat(ctx, chats, message.ChatID)
+		if err != nil {
+			return err
+		}
+		if chat == nil {
+			// The chat was deleted since, its messages are orphans
+			return nil
+		}
+
+		question := ""
+		if message.UserMessageId != nil {
+			userMessage, err := s.chatRepo.FindMessageByID(*message.UserMessageId)
+			if err != nil && err != mongo.ErrNoDocuments {
+				return fmt.Errorf("failed to fetch question: %v", err)
+			}
+			if err == nil {
+				question = userMessage.Content
+			}
+		}
+		if strings.TrimSpace(question) == "" {
+			return nil
+		}
+
+		for _, query := range *message.Queries {
+			if executedOnly && !query.IsExecuted {
+				continue
+			}
+			record, err := s.buildRecord(chat, message, question, &query)
+			if err != nil {
+				return err
+			}
+			if err := write(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("DatasetExportService -> ExportFineTuningDataset -> Error: %v", err)
+		return http.StatusInternalServerError, apperrors.New("FAILED_TO_EXPORT_DATASET", "failed to export the dataset: {error}").With("error", err)
+	}
+	return http.StatusOK, nil
+}
+
+// loadChat returns the chat of a message, nil when it no longer exists
+func (s *datasetExportService) loadChat(ctx context.Context, chats map[primitive.ObjectID]*datasetChat, chatID primitive.ObjectID) (*datasetChat, error) {
+	if chat, loaded := chats[chatID]; loaded {
+		return chat, nil
+	}
+
+	found, err := s.chatRepo.FindByID(chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch chat: %v", err)
+	}
+	var chat *datasetChat
+	if found != nil {
+		chat = &datasetChat{dbType: found.Connection.Type}
+		if storage, err := s.dbManager.GetSchemaManager().GetStoredSchema(ctx, chatID.Hex()); err == nil {
+			chat.schema = storage.LLMSchema
+		}
+	}
+	chats[chatID] = chat
+	return chat, nil
+}
+
+func (s *datasetExportService) buildRecord(chat *datasetChat, message *models.Message, question string, query *models.Query) (*dtos.FineTuningRecord, error) {
+	bookmarks, err := s.bookmarkRepo.CountByQueryID(query.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count bookmarks: %v", err)
+	}
+	comments, err := s.commentRepo.FindByQueryID(query.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch comments: %v", err)
+	}
+
+	record := &dtos.FineTuningRecord{
+		ConversationID: textChecksum(message.ChatID.Hex()),
+		DBType:         chat.dbType,
+		Question:       redactDatasetText(question),
+		SchemaExcerpt:  redactDatasetText(schemaExcerpt(chat.schema, query.Tables)),
+		Query:          redactDatasetText(query.Query),
+		Description:    redactDatasetText(query.Description),
+		Outcome:        queryOutcome(query),
+		Feedback: dtos.FineTuningFeedback{
+			Edited:     query.IsEdited,
+			Bookmarked: bookmarks > 0,
+			Comments:   len(comments),
+		},
+		CreatedAt: message.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if query.QueryType != nil {
+		record.QueryType = *query.QueryType
+	}
+	return record, nil
+}
+
+// queryOutcome returns how the execution of a query went
+func queryOutcome(query *models.Query) dtos.FineTuningOutcome {
+	outcome := dtos.FineTuningOutcome{Status: "not_executed"}
+	switch {
+	case query.Error != nil:
+		outcome.Status = "failed"
+		outcome.ErrorCode = query.Error.Code
+		outcome.ErrorMessage = redactDatasetText(query.Error.Message)
+	case query.IsRolledBack:
+		outcome.Status = "rolled_back"
+	case query.IsExecuted:
+		outcome.Status = "succeeded"
+	}
+	if query.IsExecuted {
+		outcome.ExecutionTime = query.ExecutionTime
+	}
+	return outcome
+}
+
+// schemaExcerpt describes the tables of a query as "table(column type, ...)", one per line.
+// The example records of the stored schema are left out, they hold the data of the users.
+func schemaExcerpt(schema *dbmanager.LLMSchemaInfo, tables *string) string {
+	if schema == nil || tables == nil {
+		return ""
+	}
+
+	var lines []string
+	for _, name := range strings.Split(*tables, ",") {
+		name = strings.TrimSpace(name)
+		table, exists := schema.Tables[name]
+		if !exists {
+			continue
+		}
+		columns := make([]string, 0, len(table.Columns))
+		for _, column := range table.Columns {
+			columns = append(columns, column.Name+" "+column.Type)
+		}
+		lines = append(lines, fmt.Sprintf("%s(%s)", name, strings.Join(columns, ", ")))
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+func redactDatasetText(text string) string {
+	text = datasetEmailRegex.ReplaceAllString(text, "<email>")
+	text = datasetIPv4Regex.ReplaceAllString(text, "<ip>")
+	return datasetNumberRegex.ReplaceAllString(text, "<number>")
+}
@@ -0,0 +1,292 @@
+package services
+
+import (
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/models"
+	"neobase-ai/internal/repositories"
+	"neobase-ai/pkg/dbmanager"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultLineageDepth = 3
+	maxLineageDepth     = 10
+	// Only the latest events of a chat are walked to build the graph
+	maxLineageGraphEvents = 2000
+)
+
+type LineageService interface {
+	RecordQuery(chatID, messageID, queryID, dbType, query string, isRollback bool)
+	DeleteChatLineage(chatID primitive.ObjectID) error
+	ListEvents(userID, chatID, table, column string, page, pageSize int) (*dtos.LineageEventListResponse, uint32, error)
+	GetGraph(userID, chatID, table, column string, depth int) (*dtos.LineageGraphResponse, uint32, error)
+}
+
+type lineageService struct {
+	lineageRepo repositories.LineageRepository
+	chatRepo    repositories.ChatRepository
+}
+
+func NewLineageService(lineageRepo repositories.LineageRepository, chatRepo repositories.ChatRepository) LineageService {
+	return &lineageService{
+		lineageRepo: lineageRepo,
+		chatRepo:    chatRepo,
+	}
+}
+
+// RecordQuery parses a committed query & stores the tables/columns it wrote along with their sources
+func (s *lineageService) RecordQuery(chatID, messageID, queryID, dbType, query string, isRollback bool) {
+	statements := dbmanager.ExtractLineage(dbType, query)
+	if len(statements) == 0 {
+		return
+	}
+
+	chatObjID, err := primitive.ObjectIDFromHex(chatID)
+	if err != nil {
+		log.Printf("LineageService -> RecordQuery -> Invalid chat ID: %s", chatID)
+		return
+	}
+	// Runbook steps are executed without a message
+	msgObjID, _ := primitive.ObjectIDFromHex(messageID)
+	queryObjID, _ := primitive.ObjectIDFromHex(queryID)
+
+	events := make([]*models.LineageEvent, 0, len(statements))
+	for _, statement := range statements {
+		event := models.NewLineageEvent(chatObjID, msgObjID, queryObjID, statement.Operation, statement.TargetTable, statement.Statement, isRollback)
+		event.SourceTables = statement.SourceTables
+		for _, column := range statement.Columns {
+			lineageColumn := models.LineageColumn{
+				Column:     column.Column,
+				Expression: column.Expression,
+			}
+			for _, source := range column.Sources {
+				lineageColumn.Sources = append(lineageColumn.Sources, models.LineageSource{Table: source.Table, Column: source.Column})
+			}
+			event.Columns = append(event.Columns, lineageColumn)
+		}
+		events = append(events, event)
+	}
+
+	if err := s.lineageRepo.CreateMany(events); err != nil {
+		log.Printf("LineageService -> RecordQuery -> Error storing lineage events: %v", err)
+		return
+	}
+	log.Printf("LineageService -> RecordQuery -> Recorded %d lineage events for chat %s", len(events), chatID)
+}
+
+// DeleteChatLineage removes the lineage recorded for a chat
+func (s *lineageService) DeleteChatLineage(chatID primitive.ObjectID) error {
+	return s.lineageRepo.DeleteByChatID(chatID)
+}
+
+// ListEvents returns the recorded lineage events of a chat, optionally only the ones writing a table or column
+func (s *lineageService) ListEvents(userID, chatID, table, column string, page, pageSize int) (*dtos.LineageEventListResponse, uint32, error) {
+	chat, statusCode, err := s.verifyChatOwnership(userID, chatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	events, total, err := s.lineageRepo.FindByChatID(chat.ID, strings.TrimSpace(table), strings.TrimSpace(column), page, pageSize)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_LINEAGE_EVENTS", "failed to fetch lineage events: {error}").With("error", err)
+	}
+
+	response := &dtos.LineageEventListResponse{
+		Events: make([]dtos.LineageEventResponse, 0, len(events)),
+		Total:  total,
+	}
+	for _, event := range events {
+		response.Events = append(response.Events, s.buildEventResponse(event))
+	}
+	return response, http.StatusOK, nil
+}
+
+// GetGraph walks the lineage upstream from a table or one of its columns, answering where its data came from
+func (s *lineageService) GetGraph(userID, chatID, table, column string, depth int) (*dtos.LineageGraphResponse, uint32, error) {
+	chat, statusCode, err := s.verifyChatOwnership(userID, chatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	table = strings.TrimSpace(table)
+	column = strings.TrimSpace(column)
+	if table == "" {
+		return nil, http.StatusBadRequest, apperrors.New("TABLE_REQUIRED", "table is required")
+	}
+	if depth <= 0 {
+		depth = defaultLineageDepth
+	}
+	if depth > maxLineageDepth {
+		depth = maxLineageDepth
+	}
+
+	events, err := s.lineageRepo.FindAllByChatID(chat.ID, maxLineageGraphEvents)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_LINEAGE_EVENTS", "failed to fetch lineage events: {error}").With("error", err)
+	}
+
+	// Index the events by the table they wrote
+	eventsByTable := make(map[string][]*models.LineageEvent)
+	for _, event := range events {
+		key := strings.ToLower(event.TargetTable)
+		eventsByTable[key] = append(eventsByTable[key], event)
+	}
+
+	graph := &dtos.LineageGraphResponse{
+		Table:  table,
+		Column: column,
+		Depth:  depth,
+		Nodes:  []dtos.LineageNode{},
+		Edges:  []dtos.LineageEdge{},
+	}
+	seenNodes := make(map[string]bool)
+	seenEdges := make(map[string]bool)
+	addNode := func(nodeTable, nodeColumn string) string {
+		id := lineageNodeID(nodeTable, nodeColumn)
+		if !seenNodes[id] {
+			seenNodes[id] = true
+			graph.Nodes = append(graph.Nodes, dtos.LineageNode{ID: id, Table: nodeTable, Column: nodeColumn})
+		}
+		return id
+	}
+	addEdge := func(from, to, expression string, event *models.LineageEvent) {
+		key := from + "|" + to + "|" + event.ID.Hex()
+		if seenEdges[key] {
+			return
+		}
+		seenEdges[key] = true
+		edge := dtos.LineageEdge{
+			From:       from,
+			To:         to,
+			Operation:  event.Operation,
+			Expression: expression,
+			EventID:    event.ID.Hex(),
+			CreatedAt:  event.CreatedAt.Format(time.RFC3339),
+		}
+		if !event.QueryID.IsZero() {
+			edge.QueryID = event.QueryID.Hex()
+		}
+		graph.Edges = append(graph.Edges, edge)
+	}
+
+	type lineageVisit struct {
+		table  string
+		column string
+		depth  int
+	}
+	queue := []lineageVisit{{table: table, column: column}}
+	visited := map[string]bool{lineageNodeID(table, column): true}
+	addNode(table, column)
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current.depth >= depth {
+			continue
+		}
+		currentID := lineageNodeID(current.table, current.column)
+
+		enqueue := func(sourceTable, sourceColumn string) {
+			id := lineageNodeID(sourceTable, sourceColumn)
+			if visited[id] {
+				return
+			}
+			visited[id] = true
+			queue = append(queue, lineageVisit{table: sourceTable, column: sourceColumn, depth: current.depth + 1})
+		}
+
+		for _, event := range eventsByTable[strings.ToLower(current.table)] {
+			// Table level lineage, every table read by a statement writing the table is upstream of it
+			if current.column == "" {
+				for _, sourceTable := range event.SourceTables {
+					addEdge(addNode(sourceTable, ""), currentID, "", event)
+					enqueue(sourceTable, "")
+				}
+				continue
+			}
+
+			for _, written := range event.Columns {
+				if !strings.EqualFold(written.Column, current.column) && written.Column != dbmanager.LineageAllColumns {
+					continue
+				}
+				for _, source := range written.Sources {
+					sourceColumn := source.Column
+					// Whole rows copied from another table (e.g. INSERT ... SELECT *, renamed tables) keep their column names
+					if sourceColumn == dbmanager.LineageAllColumns && written.Column == dbmanager.LineageAllColumns {
+						sourceColumn = current.column
+					}
+					addEdge(addNode(source.Table, sourceColumn), currentID, written.Expression, event)
+					if sourceColumn != dbmanager.LineageAllColumns {
+						enqueue(source.Table, sourceColumn)
+					}
+				}
+			}
+		}
+	}
+
+	return graph, http.StatusOK, nil
+}
+
+func (s *lineageService) verifyChatOwnership(userID, chatID string) (*models.Chat, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+
+	chatObjID, err := primitive.ObjectIDFromHex(chatID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_CHAT_ID", "invalid chat ID format")
+	}
+
+	chat, err := s.chatRepo.FindByID(chatObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CHAT", "failed to fetch chat: {error}").With("error", err)
+	}
+	if chat == nil {
+		return nil, http.StatusNotFound, apperrors.New("CHAT_NOT_FOUND", "chat not found")
+	}
+	if chat.UserID != userObjID {
+		return nil, http.StatusForbidden, apperrors.New("CHAT_ACCESS_DENIED", "chat does not belong to user")
+	}
+	return chat, http.StatusOK, nil
+}
+
+func (s *lineageService) buildEventResponse(event *models.LineageEvent) dtos.LineageEventResponse {
+	response := dtos.LineageEventResponse{
+		ID:           event.ID.Hex(),
+		Operation:    event.Operation,
+		TargetTable:  event.TargetTable,
+		Columns:      event.Columns,
+		SourceTables: event.SourceTables,
+		Statement:    event.Statement,
+		IsRollback:   event.IsRollback,
+		CreatedAt:    event.CreatedAt.Format(time.RFC3339),
+	}
+	if !event.MessageID.IsZero() {
+		response.MessageID = event.MessageID.Hex()
+	}
+	if !event.QueryID.IsZero() {
+		response.QueryID = event.QueryID.Hex()
+	}
+	if response.Columns == nil {
+		response.Columns = []models.LineageColumn{}
+	}
+	if response.SourceTables == nil {
+		response.SourceTables = []string{}
+	}
+	return response
+}
+
+// lineageNodeID identifies a table or a column of a table in the lineage graph
+func lineageNodeID(table, column string) string {
+	if column == "" {
+		return table
+	}
+	return table + "." + column
+}
@@ -0,0 +1,315 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"neobase-ai/internal/constants"
+	"neobase-ai/internal/models"
+	"neobase-ai/internal/utils"
+	"neobase-ai/pkg/llm"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// Times the LLM is asked to fix a response failing the validation before the request fails
+	maxLLMResponseRepairs = 2
+	// Characters of the invalid response quoted in the repair request
+	maxRepairedResponseLength = 8000
+	// Estimated response time of a query when the LLM gives none, in milliseconds
+	defaultEstimateResponseTime = 100
+	// Action of the buttons rendering the options of a clarification, clicking one answers it
+	clarificationAction = "clarify"
+)
+
+// llmResponse is the response the LLM is asked for, decoded & validated before anything is saved
+type llmResponse struct {
+	AssistantMessage *string            `json:"assistantMessage"`
+	Queries          []llmQueryResponse `json:"queries"`
+	ActionButtons    []llmActionButton  `json:"actionButtons"`
+	Clarification    *llmClarification  `json:"clarification"`
+}
+
+type llmQueryResponse struct {
+	Query                  *string         `json:"query"`
+	Explanation            *string         `json:"explanation"`
+	QueryType              *string         `json:"queryType"`
+	Tables                 *string         `json:"tables"`
+	Collections            *string         `json:"collections"`
+	IsCritical             *bool           `json:"isCritical"`
+	CanRollback            *bool           `json:"canRollback"`
+	RollbackQuery          *string         `json:"rollbackQuery"`
+	RollbackDependentQuery *string         `json:"rollbackDependentQuery"`
+	EstimateResponseTime   json.RawMessage `json:"estimateResponseTime"` // A number, some LLMs send it as a string
+	ExampleResult          json.RawMessage `json:"exampleResult"`
+	Pagination             *struct {
+		PaginatedQuery   *string `json:"paginatedQuery"`
+		CountQuery       *string `json:"countQuery"`
+		KeysetColumn     *string `json:"keysetColumn"`
+		KeysetDescending *bool   `json:"keysetDescending"`
+	} `json:"pagination"`
+
+	// ClickHouse table metadata
+	EngineType   interface{} `json:"engineType"`
+	PartitionKey interface{} `json:"partitionKey"`
+	OrderByKey   interface{} `json:"orderByKey"`
+}
+
+type llmActionButton struct {
+	Label     *string `json:"label"`
+	Action    *string `json:"action"`
+	IsPrimary *bool   `json:"isPrimary"`
+}
+
+// llmClarification is the question the LLM asks instead of guessing when the request is ambiguous
+type llmClarification struct {
+	Question *string  `json:"question"`
+	Options  []string `json:"options"`
+}
+
+// parseLLMResponse decodes the response of the LLM & checks the fields the chat relies on,
+// the errors name the invalid field so the LLM can repair it
+func parseLLMResponse(response string) (*llmResponse, map[string]interface{}, error) {
+	var parsed llmResponse
+	if err := json.Unmarshal([]byte(response), &parsed); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, nil, fmt.Errorf("field %s must be a JSON %s, not %s", typeErr.Field, jsonTypeName(typeErr.Type.Kind().String()), typeErr.Value)
+		}
+		return nil, nil, fmt.Errorf("the response is not a valid JSON object: %v", err)
+	}
+	// The raw response is stored for the LLM history, it keeps the fields the chat doesn't use
+	var jsonResponse map[string]interface{}
+	if err := json.Unmarshal([]byte(response), &jsonResponse); err != nil {
+		return nil, nil, fmt.Errorf("the response is not a valid JSON object: %v", err)
+	}
+
+	if parsed.AssistantMessage == nil {
+		return nil, nil, fmt.Errorf("field assistantMessage is required")
+	}
+	for i, query := range parsed.Queries {
+		switch {
+		case query.Query == nil || strings.TrimSpace(*query.Query) == "":
+			return nil, nil, fmt.Errorf("field queries[%d].query is required", i)
+		case query.Explanation == nil:
+			return nil, nil, fmt.Errorf("field queries[%d].explanation is required", i)
+		case query.IsCritical == nil:
+			return nil, nil, fmt.Errorf("field queries[%d].isCritical is required", i)
+		case query.CanRollback == nil:
+			return nil, nil, fmt.Errorf("field queries[%d].canRollback is required", i)
+		}
+		if len(query.ExampleResult) > 0 && !bytes.Equal(query.ExampleResult, []byte("null")) {
+			var exampleResult []interface{}
+			if err := json.Unmarshal(query.ExampleResult, &exampleResult); err != nil {
+				return nil, nil, fmt.Errorf("field queries[%d].exampleResult must be a JSON array of records", i)
+			}
+		}
+	}
+	for i, button := range parsed.ActionButtons {
+		switch {
+		case button.Label == nil || *button.Label == "":
+			return nil, nil, fmt.Errorf("field actionButtons[%d].label is required", i)
+		case button.Action == nil || *button.Action == "":
+			return nil, nil, fmt.Errorf("field actionButtons[%d].action is required", i)
+		}
+	}
+	if clarification := parsed.Clarification; clarification != nil {
+		switch {
+		case clarification.Question == nil || strings.TrimSpace(*clarification.Question) == "":
+			return nil, nil, fmt.Errorf("field clarification.question is required")
+		case len(clarification.Options) == 0:
+			return nil, nil, fmt.Errorf("field clarification.options must list the answers the user can pick from")
+		}
+	}
+
+	return &parsed, jsonResponse, nil
+}
+
+// jsonTypeName names the Go kinds of the decode errors as JSON types
+func jsonTypeName(kind string) string {
+	switch kind {
+	case "string":
+		return "string"
+	case "bool":
+		return "boolean"
+	case "slice", "array":
+		return "array"
+	case "struct", "map":
+		return "object"
+	case "float64", "float32", "int", "int64":
+		return "number"
+	}
+	return kind
+}
+
+// repairLLMResponse asks the LLM to answer again, quoting its invalid response & the validation error
+func repairLLMResponse(ctx context.Context, llmClient llm.Client, messages []*models.LLMMessage, dbType, invalidResponse string, validationErr error) (string, error) {
+	if len(invalidResponse) > maxRepairedResponseLength {
+		invalidResponse = invalidResponse[:maxRepairedResponseLength] + "..."
+	}
+
+	lastMessage := messages[len(messages)-1]
+	repairMessages := make([]*models.LLMMessage, 0, len(messages)+1)
+	repairMessages = append(repairMessages, messages...)
+	repairMessages = append(repairMessages, &models.LLMMessage{
+		ChatID: lastMessage.ChatID,
+		UserID: lastMessage.UserID,
+		Role:   string(constants.MessageTypeUser),
+		Content: map[string]interface{}{
+			"user_message": fmt.Sprintf("Your previous response to my last request was invalid: %v.\nPrevious response:\n%s\n\nAnswer my last request again with a complete JSON response fixing this error, matching the response schema.", validationErr, invalidResponse),
+		},
+	})
+
+	response, err := llmClient.GenerateResponse(ctx, repairMessages, dbType)
+	if err != nil {
+		var invalidErr *llm.InvalidResponseError
+		if errors.As(err, &invalidErr) {
+			return invalidErr.Response, nil
+		}
+		return "", err
+	}
+	return response, nil
+}
+
+// generateValidLLMResponse validates the response of the LLM, an invalid one is repaired by the LLM up to maxLLMResponseRepairs times
+func generateValidLLMResponse(ctx context.Context, llmClient llm.Client, messages []*models.LLMMessage, dbType, response string, onRepair func(attempt int)) (*llmResponse, map[string]interface{}, error) {
+	parsed, jsonResponse, err := parseLLMResponse(response)
+	for attempt := 1; err != nil && attempt <= maxLLMResponseRepairs; attempt++ {
+		log.Printf("generateValidLLMResponse -> invalid LLM response, repair attempt %d: %v", attempt, err)
+		if onRepair != nil {
+			onRepair(attempt)
+		}
+		llm.RecordRetry(llmClient.GetModelInfo(), llm.RetryRepair)
+
+		repaired, repairErr := repairLLMResponse(ctx, llmClient, messages, dbType, response, err)
+		if repairErr != nil {
+			return nil, nil, fmt.Errorf("failed to repair the invalid LLM response (%v): %v", err, repairErr)
+		}
+		response = repaired
+		parsed, jsonResponse, err = parseLLMResponse(response)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid LLM response: %v", err)
+	}
+	return parsed, jsonResponse, nil
+}
+
+// clarificationButtons renders the options of the clarification as action buttons, the user answers by clicking one
+func (r *llmResponse) clarificationButtons() []models.ActionButton {
+	if r.Clarification == nil {
+		return nil
+	}
+	buttons := make([]models.ActionButton, 0, len(r.Clarification.Options))
+	for _, option := range r.Clarification.Options {
+		if option = strings.TrimSpace(option); option == "" {
+			continue
+		}
+		buttons = append(buttons, models.ActionButton{
+			ID:     primitive.NewObjectID(),
+			Label:  option,
+			Action: clarificationAction,
+		})
+	}
+	return buttons
+}
+
+// messageContent is the content of the assistant message, it ends with the clarification question when the LLM asks one
+func (r *llmResponse) messageContent() string {
+	content := *r.AssistantMessage
+	if r.Clarification == nil {
+		return content
+	}
+	question := strings.TrimSpace(*r.Clarification.Question)
+	if strings.Contains(content, question) {
+		return content
+	}
+	if strings.TrimSpace(content) == "" {
+		return question
+	}
+	return content + "\n\n" + question
+}
+
+// toModelQuery converts a validated query of the LLM response to the query of the chat message
+func (q *llmQueryResponse) toModelQuery() models.Query {
+	query := models.Query{
+		Query:                  *q.Query,
+		Description:            *q.Explanation,
+		ExampleExecutionTime:   int(q.estimateResponseTime()),
+		CanRollback:            *q.CanRollback,
+		IsCritical:             *q.IsCritical,
+		QueryType:              q.QueryType,
+		Tables:                 q.Tables,
+		RollbackQuery:          q.RollbackQuery,
+		RollbackDependentQuery: q.RollbackDependentQuery,
+		Pagination:             &models.Pagination{},
+	}
+	if q.Collections != nil {
+		query.Tables = q.Collections
+	}
+	if len(q.ExampleResult) > 0 && !bytes.Equal(q.ExampleResult, []byte("null")) {
+		var exampleResult bytes.Buffer
+		if err := json.Compact(&exampleResult, q.ExampleResult); err == nil {
+			query.ExampleResult = utils.ToStringPtr(exampleResult.String())
+		}
+	}
+	if q.Pagination != nil {
+		query.Pagination.PaginatedQuery = q.Pagination.PaginatedQuery
+		query.Pagination.CountQuery = q.Pagination.CountQuery
+		if q.Pagination.KeysetColumn != nil && strings.TrimSpace(*q.Pagination.KeysetColumn) != "" {
+			query.Pagination.KeysetColumn = utils.ToStringPtr(strings.TrimSpace(*q.Pagination.KeysetColumn))
+			query.Pagination.KeysetDescending = q.Pagination.KeysetDescending != nil && *q.Pagination.KeysetDescending
+		}
+	}
+	return query
+}
+
+// estimateResponseTime returns the estimated response time as a number, whether the LLM sent a number or a string
+func (q *llmQueryResponse) estimateResponseTime() float64 {
+	if len(q.EstimateResponseTime) == 0 {
+		return defaultEstimateResponseTime
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(q.EstimateResponseTime, &value); err != nil {
+		return defaultEstimateResponseTime
+	}
+	switch v := value.(type) {
+	case float64:
+		return v
+	case string:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			return f
+		}
+	}
+	return defaultEstimateResponseTime
+}
+
+// clickhouseMetadata returns the table metadata of a ClickHouse query as JSON, nil when the LLM sent none
+func (q *llmQueryResponse) clickhouseMetadata() *string {
+	metadata := make(map[string]interface{})
+	if q.EngineType != nil {
+		metadata["engineType"] = q.EngineType
+	}
+	if q.PartitionKey != nil {
+		metadata["partitionKey"] = q.PartitionKey
+	}
+	if q.OrderByKey != nil {
+		metadata["orderByKey"] = q.OrderByKey
+	}
+	if len(metadata) == 0 {
+		return nil
+	}
+
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return nil
+	}
+	metadataStr := string(metadataJSON)
+	return &metadataStr
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"neobase-ai/internal/constants"
+	"neobase-ai/internal/models"
+	"neobase-ai/pkg/redis"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const llmResponseCacheKeyPrefix = "llm_response_cache"
+
+// LLMResponseCacheService caches the responses of the LLM to the first question of a chat, keyed by the question, the schema, the database type
+// & the variant of the prompt (see llmResponseCacheVariant). The same question on the same schema is answered from the cache whichever user asks it,
+// as long as the chats send the same prompt, without calling the LLM.
+type LLMResponseCacheService interface {
+	Get(ctx context.Context, dbType, schema, variant, question string) (string, bool)
+	Set(ctx context.Context, dbType, schema, variant, question, response string)
+}
+
+type llmResponseCacheService struct {
+	redisRepo redis.IRedisRepositories
+	ttl       time.Duration // 0 disables the cache
+}
+
+func NewLLMResponseCacheService(redisRepo redis.IRedisRepositories, ttl time.Duration) LLMResponseCacheService {
+	return &llmResponseCacheService{
+		redisRepo: redisRepo,
+		ttl:       ttl,
+	}
+}
+
+func (s *llmResponseCacheService) Get(ctx context.Context, dbType, schema, variant, question string) (string, bool) {
+	if s.ttl <= 0 {
+		return "", false
+	}
+	response, err := s.redisRepo.Get(llmResponseCacheKey(dbType, schema, variant, question), ctx)
+	if err != nil || response == "" {
+		return "", false
+	}
+	return response, true
+}
+
+func (s *llmResponseCacheService) Set(ctx context.Context, dbType, schema, variant, question, response string) {
+	if s.ttl <= 0 {
+		return
+	}
+	if err := s.redisRepo.Set(llmResponseCacheKey(dbType, schema, variant, question), []byte(response), s.ttl, ctx); err != nil {
+		log.Printf("LLMResponseCacheService -> Set -> Error caching response: %v", err)
+	}
+}
+
+// llmResponseCacheKey holds the checksum of the schema, a chat whose schema changed no longer reads the responses of the previous one.
+// They aren't deleted, the other chats still on that schema keep using them until they expire.
+func llmResponseCacheKey(dbType, schema, variant, question string) string {
+	return fmt.Sprintf("%s:%s:%s:%s", llmResponseCacheKeyPrefix, textChecksum(schema), variant, textChecksum(dbType+"\n"+normalizeQuestion(question)))
+}
+
+// llmResponseCacheVariant is the checksum of what the response depends on besides the schema & the question: the active prompt templates,
+// the model & sampling of the chat & the context sent before the question, e.g. the query examples of the connection
+func llmResponseCacheVariant(promptTemplates, model string, sampling *models.LLMSampling, messages []*models.LLMMessage) string {
+	contents := make([]map[string]interface{}, 0, len(messages))
+	for _, msg := range messages[:len(messages)-1] {
+		contents = append(contents, msg.Content)
+	}
+	variant, err := json.Marshal(struct {
+		PromptTemplates string                   `json:"prompt_templates"`
+		Model           string                   `json:"model"`
+		Sampling        *models.LLMSampling      `json:"sampling"`
+		Context         []map[string]interface{} `json:"context"`
+	}{promptTemplates, model, sampling, contents})
+	if err != nil {
+		log.Printf("llmResponseCacheVariant -> Error encoding the variant: %v", err)
+	}
+	return textChecksum(string(variant))
+}
+
+func textChecksum(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeQuestion ignores the case, the spacing & the trailing punctuation of a question
+func normalizeQuestion(question string) string {
+	question = strings.ToLower(strings.Join(strings.Fields(question), " "))
+	return strings.TrimRightFunc(question, func(r rune) bool {
+		return unicode.IsPunct(r) || unicode.IsSpace(r)
+	})
+}
+
+// cacheableQuestion returns the question & the stored schema of a chat whose last message is its first question.
+// The answers to follow-up questions depend on the conversation, they are not cached.
+func cacheableQuestion(messages []*models.LLMMessage) (question string, schema string, ok bool) {
+	if len(messages) == 0 {
+		return "", "", false
+	}
+	lastMessage := messages[len(messages)-1]
+	question, isQuestion := lastMessage.Content["user_message"].(string)
+	if !isQuestion || lastMessage.Role != string(constants.MessageTypeUser) || strings.TrimSpace(question) == "" {
+		return "", "", false
+	}
+
+	for _, msg := range messages[:len(messages)-1] {
+		if msg.Role != string(constants.MessageTypeSystem) {
+			return "", "", false
+		}
+		if schemaUpdate, isSchema := msg.Content["schema_update"].(string); isSchema {
+			schema = schemaUpdate
+		}
+	}
+	if schema == "" {
+		return "", "", false
+	}
+	return question, schema, true
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"neobase-ai/internal/models"
+	"testing"
+)
+
+func TestLLMResponseCacheVariant(t *testing.T) {
+	messages := func(examples string) []*models.LLMMessage {
+		return []*models.LLMMessage{
+			{Role: "system", Content: map[string]interface{}{"schema_update": "CREATE TABLE users (id int)"}},
+			{Role: "system", Content: map[string]interface{}{"query_examples": examples}},
+			{Role: "user", Content: map[string]interface{}{"user_message": "How many users?"}},
+		}
+	}
+	zero := 0.0
+	base := llmResponseCacheVariant("", "", nil, messages("SELECT count(*) FROM users"))
+
+	tests := []struct {
+		name    string
+		variant string
+	}{
+		{"prompt template activated", llmResponseCacheVariant("checksum", "", nil, messages("SELECT count(*) FROM users"))},
+		{"chat model", llmResponseCacheVariant("", "gpt-4o", nil, messages("SELECT count(*) FROM users"))},
+		{"chat sampling", llmResponseCacheVariant("", "", &models.LLMSampling{Temperature: &zero}, messages("SELECT count(*) FROM users"))},
+		{"query examples", llmResponseCacheVariant("", "", nil, messages("SELECT id FROM users"))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.variant == base {
+				t.Errorf("variant unchanged, the chats would share their cached responses")
+			}
+		})
+	}
+
+	// Only the question differs, the key tells the questions apart
+	other := messages("SELECT count(*) FROM users")
+	other[2].Content["user_message"] = "List the users"
+	if llmResponseCacheVariant("", "", nil, other) != base {
+		t.Errorf("variant depends on the question")
+	}
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"neobase-ai/pkg/dbmanager"
+	"neobase-ai/pkg/llm"
+	"strings"
+)
+
+// Tools the LLM can call on the database of a chat before writing its queries
+const (
+	getSchemaTool       = "get_schema"
+	runExplainTool      = "run_explain"
+	executeReadOnlyTool = "execute_readonly"
+)
+
+var chatTools = []llm.Tool{
+	{
+		Name:        getSchemaTool,
+		Description: "Returns the columns, types, indexes & example records of tables (or collections), e.g. the tables of the schema you haven't been given in detail or aren't sure about.",
+		Parameters:  json.RawMessage(`{"type":"object","required":["tables"],"properties":{"tables":{"type":"array","items":{"type":"string"},"minItems":1,"description":"Names of the tables or collections"}}}`),
+	},
+	{
+		Name:        runExplainTool,
+		Description: "Returns the execution plan of a query without running it, to check the query is valid & uses the indexes. Writes can be explained too.",
+		Parameters:  json.RawMessage(`{"type":"object","required":["query"],"properties":{"query":{"type":"string","description":"A single query, without EXPLAIN"}}}`),
+	},
+	{
+		Name:        executeReadOnlyTool,
+		Description: "Runs a read-only query & returns its result, e.g. to look at the distinct values of a column before filtering on it. Keep the result small with a limit, writes are refused.",
+		Parameters:  json.RawMessage(`{"type":"object","required":["query"],"properties":{"query":{"type":"string","description":"A read-only query"}}}`),
+	},
+}
+
+// chatToolExecutor runs the tools called by the LLM on the connection of a chat, onCall reports each call to the user.
+// execute_readonly returns the rows of the database, it's only offered when the chat shares its data with the AI.
+type chatToolExecutor struct {
+	dbManager *dbmanager.Manager
+	chatID    string
+	shareData bool
+	onCall    func(name string)
+}
+
+func (e *chatToolExecutor) Tools() []llm.Tool {
+	if e.shareData {
+		return chatTools
+	}
+	tools := make([]llm.Tool, 0, len(chatTools))
+	for _, tool := range chatTools {
+		if tool.Name != executeReadOnlyTool {
+			tools = append(tools, tool)
+		}
+	}
+	return tools
+}
+
+func (e *chatToolExecutor) ExecuteTool(ctx context.Context, name string, arguments json.RawMessage) (string, error) {
+	var args struct {
+		Tables []string `json:"tables"`
+		Query  string   `json:"query"`
+	}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %v", err)
+	}
+	if name == executeReadOnlyTool && !e.shareData {
+		return "", fmt.Errorf("the data of the chat is not shared with the AI, %s is not available", executeReadOnlyTool)
+	}
+	if e.onCall != nil {
+		e.onCall(name)
+	}
+
+	switch name {
+	case getSchemaTool:
+		if len(args.Tables) == 0 {
+			return "", fmt.Errorf("tables is required")
+		}
+		return e.dbManager.FormatSchemaWithExamples(ctx, e.chatID, args.Tables)
+	case runExplainTool, executeReadOnlyTool:
+		if strings.TrimSpace(args.Query) == "" {
+			return "", fmt.Errorf("query is required")
+		}
+		run := e.dbManager.ExplainQuery
+		if name == executeReadOnlyTool {
+			run = e.dbManager.ExecuteReadOnlyQuery
+		}
+		result, queryErr := run(ctx, e.chatID, args.Query)
+		if queryErr != nil {
+			if queryErr.Details != "" {
+				return "", fmt.Errorf("%s: %s", queryErr.Message, queryErr.Details)
+			}
+			return "", fmt.Errorf("%s", queryErr.Message)
+		}
+		return result, nil
+	}
+	return "", fmt.Errorf("unknown tool %s", name)
+}
+
+// toolCallStep is the step shown to the user while the LLM grounds its response with a tool
+func toolCallStep(name string) string {
+	switch name {
+	case getSchemaTool:
+		return "Looking up the structure of the tables involved.."
+	case runExplainTool:
+		return "Checking the query plan of a draft query.."
+	case executeReadOnlyTool:
+		return "Looking at the data to ground the query.."
+	}
+	return "Gathering more context from the database.."
+}
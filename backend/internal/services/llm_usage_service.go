@@ -0,0 +1,167 @@
+package services
+
+import (
+	"log"
+	"neobase-ai/config"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/constants"
+	"neobase-ai/internal/models"
+	"neobase-ai/internal/repositories"
+	"neobase-ai/pkg/llm"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// usageMonthLayout is the format of the months of the usage, e.g. 2025-03
+const usageMonthLayout = "2006-01"
+
+type LLMUsageService interface {
+	GetChatUsage(userID, chatID string) (*dtos.LLMUsageResponse, uint32, error)
+	GetUserUsage(userID, month string) (*dtos.UserLLMUsageResponse, uint32, error)
+	// CheckQuota returns an error when the user has used their monthly token quota
+	CheckQuota(userID string) (uint32, error)
+}
+
+type llmUsageService struct {
+	llmRepo  repositories.LLMMessageRepository
+	chatRepo repositories.ChatRepository
+}
+
+func NewLLMUsageService(llmRepo repositories.LLMMessageRepository, chatRepo repositories.ChatRepository) LLMUsageService {
+	return &llmUsageService{
+		llmRepo:  llmRepo,
+		chatRepo: chatRepo,
+	}
+}
+
+// GetChatUsage returns the tokens & the estimated cost of the LLM calls of a chat
+func (s *llmUsageService) GetChatUsage(userID, chatID string) (*dtos.LLMUsageResponse, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+	chatObjID, err := primitive.ObjectIDFromHex(chatID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_CHAT_ID", "invalid chat ID format")
+	}
+
+	chat, err := s.chatRepo.FindByID(chatObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CHAT", "failed to fetch chat: {error}").With("error", err)
+	}
+	if chat == nil {
+		return nil, http.StatusNotFound, apperrors.New("CHAT_NOT_FOUND", "chat not found")
+	}
+	if chat.UserID != userObjID {
+		return nil, http.StatusForbidden, apperrors.New("CHAT_ACCESS_DENIED", "chat does not belong to user")
+	}
+
+	usage, err := s.llmRepo.AggregateUsageByChatID(chatObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_LLM_USAGE", "failed to fetch LLM usage: {error}").With("error", err)
+	}
+	return buildLLMUsageResponse(usage), http.StatusOK, nil
+}
+
+// GetUserUsage returns the tokens & the estimated cost of the LLM calls of a user over a month, the current one by default
+func (s *llmUsageService) GetUserUsage(userID, month string) (*dtos.UserLLMUsageResponse, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+
+	from := usageMonthStart(time.Now())
+	if month != "" {
+		from, err = time.Parse(usageMonthLayout, month)
+		if err != nil {
+			return nil, http.StatusBadRequest, apperrors.New("INVALID_USAGE_MONTH", "invalid month {month}, expected YYYY-MM").With("month", month)
+		}
+	}
+
+	usage, err := s.llmRepo.AggregateUsageByUserID(userObjID, from, from.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_LLM_USAGE", "failed to fetch LLM usage: {error}").With("error", err)
+	}
+
+	response := &dtos.UserLLMUsageResponse{
+		LLMUsageResponse: *buildLLMUsageResponse(usage),
+		Month:            from.Format(usageMonthLayout),
+	}
+	if quota := int64(config.Env.LLMMonthlyTokenQuota); quota > 0 {
+		remaining := max(quota-response.TotalTokens, 0)
+		response.MonthlyTokenQuota = quota
+		response.RemainingTokens = &remaining
+	}
+	return response, http.StatusOK, nil
+}
+
+// CheckQuota compares the tokens the user used this month with LLM_MONTHLY_TOKEN_QUOTA, no quota is enforced when it's 0
+func (s *llmUsageService) CheckQuota(userID string) (uint32, error) {
+	quota := int64(config.Env.LLMMonthlyTokenQuota)
+	if quota <= 0 {
+		return http.StatusOK, nil
+	}
+
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+
+	from := usageMonthStart(time.Now())
+	usage, err := s.llmRepo.AggregateUsageByUserID(userObjID, from, from.AddDate(0, 1, 0))
+	if err != nil {
+		// The quota must not block the chats when the usage can't be read
+		log.Printf("LLMUsageService -> CheckQuota -> Error fetching usage of user %s: %v", userID, err)
+		return http.StatusOK, nil
+	}
+
+	used := buildLLMUsageResponse(usage).TotalTokens
+	if used >= quota {
+		return http.StatusTooManyRequests, apperrors.New("LLM_TOKEN_QUOTA_EXCEEDED", "monthly token quota of {quota} tokens exceeded, it resets on {resetAt}").
+			With("quota", quota).
+			With("resetAt", from.AddDate(0, 1, 0).Format("2006-01-02"))
+	}
+	return http.StatusOK, nil
+}
+
+// usageMonthStart returns the start of the month of t in UTC, quotas reset on the first day of each month
+func usageMonthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func buildLLMUsageResponse(usage []*models.LLMUsageByModel) *dtos.LLMUsageResponse {
+	response := &dtos.LLMUsageResponse{
+		Models: make([]models.LLMUsageByModel, 0, len(usage)),
+	}
+	for _, modelUsage := range usage {
+		response.Calls += modelUsage.Calls
+		response.PromptTokens += modelUsage.PromptTokens
+		response.CompletionTokens += modelUsage.CompletionTokens
+		response.TotalTokens += modelUsage.TotalTokens
+		response.EstimatedCost += modelUsage.EstimatedCost
+		response.Models = append(response.Models, *modelUsage)
+	}
+	return response
+}
+
+// addLLMUsage adds the tokens of an LLM call to the usage of a message, a regenerated message keeps the tokens of its previous generations
+func addLLMUsage(messageUsage *models.LLMUsage, usage *llm.Usage) *models.LLMUsage {
+	if usage.PromptTokens == 0 && usage.CompletionTokens == 0 {
+		return messageUsage
+	}
+	if messageUsage == nil {
+		messageUsage = &models.LLMUsage{}
+	}
+
+	messageUsage.Provider = usage.Provider
+	messageUsage.Model = usage.Model
+	messageUsage.PromptTokens += usage.PromptTokens
+	messageUsage.CompletionTokens += usage.CompletionTokens
+	messageUsage.TotalTokens = messageUsage.PromptTokens + messageUsage.CompletionTokens
+	messageUsage.EstimatedCost += constants.EstimateLLMCost(usage.Model, usage.PromptTokens, usage.CompletionTokens)
+	return messageUsage
+}
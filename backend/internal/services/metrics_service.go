@@ -0,0 +1,61 @@
+package services
+
+import (
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/pkg/dbmanager"
+	"neobase-ai/pkg/llm"
+	"net/http"
+	"time"
+)
+
+type MetricsService interface {
+	GetMetrics() (*dtos.MetricsResponse, uint32, error)
+}
+
+type metricsService struct {
+	dbManager *dbmanager.Manager
+}
+
+func NewMetricsService(dbManager *dbmanager.Manager) MetricsService {
+	return &metricsService{
+		dbManager: dbManager,
+	}
+}
+
+// GetMetrics returns the LLM requests per model & the database pools, the metrics are kept in memory since the server started
+func (s *metricsService) GetMetrics() (*dtos.MetricsResponse, uint32, error) {
+	since, llmMetrics := llm.GetMetrics()
+
+	response := &dtos.MetricsResponse{
+		Since:         since.UTC().Format(time.RFC3339),
+		LLM:           make([]dtos.LLMModelMetrics, 0, len(llmMetrics)),
+		DatabasePools: s.dbManager.GetPoolMetrics(),
+	}
+	for _, model := range llmMetrics {
+		modelMetrics := dtos.LLMModelMetrics{
+			Provider:         model.Provider,
+			Model:            model.Model,
+			Requests:         model.Requests,
+			Failures:         model.Failures,
+			MaxDurationMs:    model.MaxDuration.Milliseconds(),
+			Latency:          make([]dtos.LLMLatencyBucket, 0, len(model.Latency)),
+			PromptTokens:     model.PromptTokens,
+			CompletionTokens: model.CompletionTokens,
+			Retries:          model.Retries,
+			Errors:           model.Errors,
+		}
+		if model.Requests > 0 {
+			modelMetrics.AvgDurationMs = model.TotalDuration.Milliseconds() / model.Requests
+		}
+		for _, bucket := range model.Latency {
+			latencyBucket := dtos.LLMLatencyBucket{Count: bucket.Count}
+			if bucket.UpTo > 0 {
+				upToMs := bucket.UpTo.Milliseconds()
+				latencyBucket.UpToMs = &upToMs
+			}
+			modelMetrics.Latency = append(modelMetrics.Latency, latencyBucket)
+		}
+		response.LLM = append(response.LLM, modelMetrics)
+	}
+	return response, http.StatusOK, nil
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/models"
+	"neobase-ai/internal/repositories"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NotificationPusher delivers events to every open stream of a user, whatever chat the stream belongs to
+type NotificationPusher interface {
+	HandleUserEvent(userID string, response dtos.StreamResponse)
+}
+
+type NotificationService interface {
+	SetPusher(pusher NotificationPusher)
+	Notify(userID, chatID, notificationType, title, message string, data map[string]interface{})
+	List(userID string, unreadOnly bool, page, pageSize int) (*dtos.NotificationListResponse, uint32, error)
+	MarkRead(userID string, req *dtos.MarkNotificationsReadRequest) (*dtos.MarkNotificationsReadResponse, uint32, error)
+	MarkAllRead(userID string) (*dtos.MarkNotificationsReadResponse, uint32, error)
+	DeleteChatNotifications(chatID primitive.ObjectID) error
+}
+
+type notificationService struct {
+	notificationRepo repositories.NotificationRepository
+	pusher           NotificationPusher
+	pusherMu         sync.RWMutex
+}
+
+func NewNotificationService(notificationRepo repositories.NotificationRepository) NotificationService {
+	return &notificationService{
+		notificationRepo: notificationRepo,
+	}
+}
+
+func (s *notificationService) SetPusher(pusher NotificationPusher) {
+	s.pusherMu.Lock()
+	defer s.pusherMu.Unlock()
+	s.pusher = pusher
+}
+
+// Notify stores a notification for the user & pushes it to the user's open streams
+func (s *notificationService) Notify(userID, chatID, notificationType, title, message string, data map[string]interface{}) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		log.Printf("NotificationService -> Notify -> Invalid user ID: %s", userID)
+		return
+	}
+
+	var chatObjID *primitive.ObjectID
+	if chatID != "" {
+		id, err := primitive.ObjectIDFromHex(chatID)
+		if err != nil {
+			log.Printf("NotificationService -> Notify -> Invalid chat ID: %s", chatID)
+			return
+		}
+		chatObjID = &id
+	}
+
+	notification := models.NewNotification(userObjID, chatObjID, notificationType, title, message, data)
+	if err := s.notificationRepo.Create(notification); err != nil {
+		log.Printf("NotificationService -> Notify -> Error creating notification: %v", err)
+		return
+	}
+	log.Printf("NotificationService -> Notify -> Created %s notification for user %s", notificationType, userID)
+
+	s.pusherMu.RLock()
+	pusher := s.pusher
+	s.pusherMu.RUnlock()
+	if pusher != nil {
+		pusher.HandleUserEvent(userID, dtos.StreamResponse{
+			Event: "notification",
+			Data:  s.buildNotificationResponse(notification),
+		})
+	}
+}
+
+// List returns the notifications of the user, newest first
+func (s *notificationService) List(userID string, unreadOnly bool, page, pageSize int) (*dtos.NotificationListResponse, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+
+	notifications, total, err := s.notificationRepo.FindByUserID(userObjID, unreadOnly, page, pageSize)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_NOTIFICATIONS", "failed to fetch notifications: {error}").With("error", err)
+	}
+
+	unreadCount, err := s.notificationRepo.CountUnread(userObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_COUNT_UNREAD_NOTIFICATIONS", "failed to count unread notifications: {error}").With("error", err)
+	}
+
+	response := &dtos.NotificationListResponse{
+		Notifications: make([]dtos.NotificationResponse, 0, len(notifications)),
+		Total:         total,
+		UnreadCount:   unreadCount,
+	}
+	for _, notification := range notifications {
+		response.Notifications = append(response.Notifications, s.buildNotificationResponse(notification))
+	}
+	return response, http.StatusOK, nil
+}
+
+// MarkRead marks the given notifications of the user as read
+func (s *notificationService) MarkRead(userID string, req *dtos.MarkNotificationsReadRequest) (*dtos.MarkNotificationsReadResponse, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(req.IDs))
+	for _, id := range req.IDs {
+		objID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, http.StatusBadRequest, apperrors.New("INVALID_NOTIFICATION_ID", "invalid notification ID format: {id}").With("id", id)
+		}
+		ids = append(ids, objID)
+	}
+
+	updated, err := s.notificationRepo.MarkRead(userObjID, ids)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_MARK_NOTIFICATIONS_AS_READ", "failed to mark notifications as read: {error}").With("error", err)
+	}
+	return s.buildMarkReadResponse(userObjID, updated)
+}
+
+// MarkAllRead marks every notification of the user as read
+func (s *notificationService) MarkAllRead(userID string) (*dtos.MarkNotificationsReadResponse, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+
+	updated, err := s.notificationRepo.MarkAllRead(userObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_MARK_NOTIFICATIONS_AS_READ", "failed to mark notifications as read: {error}").With("error", err)
+	}
+	return s.buildMarkReadResponse(userObjID, updated)
+}
+
+// DeleteChatNotifications removes the notifications of a deleted chat
+func (s *notificationService) DeleteChatNotifications(chatID primitive.ObjectID) error {
+	return s.notificationRepo.DeleteByChatID(chatID)
+}
+
+func (s *notificationService) buildMarkReadResponse(userID primitive.ObjectID, updated int64) (*dtos.MarkNotificationsReadResponse, uint32, error) {
+	unreadCount, err := s.notificationRepo.CountUnread(userID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_COUNT_UNREAD_NOTIFICATIONS", "failed to count unread notifications: {error}").With("error", err)
+	}
+	return &dtos.MarkNotificationsReadResponse{
+		Updated:     updated,
+		UnreadCount: unreadCount,
+	}, http.StatusOK, nil
+}
+
+func (s *notificationService) buildNotificationResponse(notification *models.Notification) dtos.NotificationResponse {
+	response := dtos.NotificationResponse{
+		ID:        notification.ID.Hex(),
+		Type:      notification.Type,
+		Title:     notification.Title,
+		Message:   notification.Message,
+		Data:      notification.Data,
+		IsRead:    notification.IsRead(),
+		CreatedAt: notification.CreatedAt.Format(time.RFC3339),
+	}
+	if notification.ChatID != nil {
+		chatID := notification.ChatID.Hex()
+		response.ChatID = &chatID
+	}
+	if notification.ReadAt != nil {
+		readAt := notification.ReadAt.Format(time.RFC3339)
+		response.ReadAt = &readAt
+	}
+	return response
+}
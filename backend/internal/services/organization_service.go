@@ -0,0 +1,559 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/constants"
+	"neobase-ai/internal/models"
+	"neobase-ai/internal/repositories"
+	"neobase-ai/internal/utils"
+	"neobase-ai/pkg/llm"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LLMClientResolver resolves the LLM client used for the requests of a user
+type LLMClientResolver interface {
+	// ResolveLLMClient returns the client of the user, with the model of the chat when the organization allows it
+	ResolveLLMClient(ctx context.Context, userID, model string) (llm.Client, error)
+	// AllowedLLMModels returns the models a chat of the user can choose, nil without the provider of an organization
+	AllowedLLMModels(userID string) ([]string, error)
+}
+
+// LLMConfigBuilder builds the client config of a provider, model & API key (token limits, prompts & response schemas)
+type LLMConfigBuilder func(provider, model, apiKey string) llm.Config
+
+type OrganizationService interface {
+	LLMClientResolver
+
+	Create(req *dtos.CreateOrganizationRequest) (*dtos.OrganizationResponse, uint32, error)
+	List(page, pageSize int) (*dtos.OrganizationListResponse, uint32, error)
+	Get(organizationID string) (*dtos.OrganizationResponse, uint32, error)
+	Update(organizationID string, req *dtos.UpdateOrganizationRequest) (*dtos.OrganizationResponse, uint32, error)
+	Delete(organizationID string) (uint32, error)
+
+	AddMember(organizationID string, req *dtos.OrganizationMemberRequest) (*dtos.OrganizationResponse, uint32, error)
+	RemoveMember(organizationID, userID string) (*dtos.OrganizationResponse, uint32, error)
+
+	SetLLMProvider(organizationID, provider string, req *dtos.SetOrganizationLLMProviderRequest) (*dtos.OrganizationResponse, uint32, error)
+	RemoveLLMProvider(organizationID, provider string) (*dtos.OrganizationResponse, uint32, error)
+}
+
+// organizationLLMClient is a client built with the credentials of an organization
+type organizationLLMClient struct {
+	client    llm.Client
+	updatedAt time.Time // Provider settings the client was built with
+}
+
+type organizationService struct {
+	organizationRepo repositories.OrganizationRepository
+	userRepo         repositories.UserRepository
+	llmManager       *llm.Manager
+	defaultClient    llm.Client
+	buildLLMConfig   LLMConfigBuilder
+
+	// Clients of the organizations, key: organizationID:provider
+	clients   map[string]*organizationLLMClient
+	clientsMu sync.Mutex
+}
+
+func NewOrganizationService(
+	organizationRepo repositories.OrganizationRepository,
+	userRepo repositories.UserRepository,
+	llmManager *llm.Manager,
+	defaultClient llm.Client,
+	buildLLMConfig LLMConfigBuilder,
+) OrganizationService {
+	return &organizationService{
+		organizationRepo: organizationRepo,
+		userRepo:         userRepo,
+		llmManager:       llmManager,
+		defaultClient:    defaultClient,
+		buildLLMConfig:   buildLLMConfig,
+		clients:          make(map[string]*organizationLLMClient),
+	}
+}
+
+// ResolveLLMClient returns the client of the user's organization, the default client (configured from env) is used when
+// the user isn't part of an organization or the organization has no provider configured. The model of the chat is used when
+// it is in the allowed models of the provider, the default model of the provider otherwise.
+func (s *organizationService) ResolveLLMClient(ctx context.Context, userID, model string) (llm.Client, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	organization, providerSettings, err := s.findUserLLMProvider(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if providerSettings == nil {
+		if s.defaultClient == nil {
+			return nil, fmt.Errorf("no LLM client configured, ask an administrator to configure an LLM provider")
+		}
+		return s.defaultClient, nil
+	}
+
+	// The allowed models can change after a chat chose its model
+	if model == "" || !containsString(providerSettings.AllowedModels, model) {
+		if model != "" {
+			log.Printf("OrganizationService -> ResolveLLMClient -> Model %s is no longer allowed for %s, using %s", model, providerSettings.Provider, providerSettings.DefaultModel)
+		}
+		model = providerSettings.DefaultModel
+	}
+	return s.getOrganizationClient(organization.ID, providerSettings, model)
+}
+
+// AllowedLLMModels returns the allowed models of the provider of the user's organization, nil when the user uses the default client
+func (s *organizationService) AllowedLLMModels(userID string) ([]string, error) {
+	_, providerSettings, err := s.findUserLLMProvider(userID)
+	if err != nil || providerSettings == nil {
+		return nil, err
+	}
+	return providerSettings.AllowedModels, nil
+}
+
+// findUserLLMProvider returns the organization of the user & the settings of the provider it uses, the default provider of the
+// organization or its first configured one. Both are nil when the user isn't part of an organization with a provider.
+func (s *organizationService) findUserLLMProvider(userID string) (*models.Organization, *models.OrganizationLLMProvider, error) {
+	organization, err := s.findUserOrganization(userID)
+	if err != nil || organization == nil {
+		return nil, nil, err
+	}
+
+	var providerSettings *models.OrganizationLLMProvider
+	if organization.DefaultLLMProvider != "" {
+		providerSettings = organization.GetLLMProvider(organization.DefaultLLMProvider)
+	}
+	if providerSettings == nil && len(organization.LLMProviders) > 0 {
+		providerSettings = &organization.LLMProviders[0]
+	}
+	if providerSettings == nil {
+		return nil, nil, nil
+	}
+	return organization, providerSettings, nil
+}
+
+// getOrganizationClient returns the cached client of an organization's provider & model, it is rebuilt when the settings change
+func (s *organizationService) getOrganizationClient(organizationID primitive.ObjectID, settings *models.OrganizationLLMProvider, model string) (llm.Client, error) {
+	key := organizationID.Hex() + ":" + settings.Provider + ":" + model
+
+	s.clientsMu.Lock()
+	defer s.clientsMu.Unlock()
+
+	if cached, ok := s.clients[key]; ok && cached.updatedAt.Equal(settings.UpdatedAt) {
+		return cached.client, nil
+	}
+
+	// Ollama servers run without a key
+	apiKey := ""
+	if settings.APIKey != "" {
+		decrypted, err := utils.DecryptSecret(settings.APIKey)
+		if err != nil {
+			log.Printf("OrganizationService -> getOrganizationClient -> Error decrypting API key of %s: %v", key, err)
+			return nil, fmt.Errorf("failed to read the %s API key of the organization", settings.Provider)
+		}
+		apiKey = decrypted
+	}
+
+	client, err := s.llmManager.NewClient(s.buildLLMConfig(settings.Provider, model, apiKey))
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("OrganizationService -> getOrganizationClient -> Created %s client (%s) for organization %s", settings.Provider, model, organizationID.Hex())
+	s.clients[key] = &organizationLLMClient{
+		client:    client,
+		updatedAt: settings.UpdatedAt,
+	}
+	return client, nil
+}
+
+// evictClients drops the cached clients of an organization
+func (s *organizationService) evictClients(organizationID primitive.ObjectID) {
+	s.clientsMu.Lock()
+	defer s.clientsMu.Unlock()
+
+	prefix := organizationID.Hex() + ":"
+	for key := range s.clients {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.clients, key)
+		}
+	}
+}
+
+// Create creates an organization with its initial members
+func (s *organizationService) Create(req *dtos.CreateOrganizationRequest) (*dtos.OrganizationResponse, uint32, error) {
+	log.Printf("OrganizationService -> Create -> name: %s, members: %d", req.Name, len(req.Usernames))
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, http.StatusBadRequest, apperrors.New("ORGANIZATION_NAME_REQUIRED", "organization name is required")
+	}
+
+	memberIDs := []primitive.ObjectID{}
+	for _, username := range req.Usernames {
+		user, statusCode, err := s.findMemberCandidate(username, primitive.NilObjectID)
+		if err != nil {
+			return nil, statusCode, err
+		}
+		memberIDs = appendUniqueObjectID(memberIDs, user.ID)
+	}
+
+	organization := models.NewOrganization(name, memberIDs)
+	if err := s.organizationRepo.Create(organization); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_CREATE_ORGANIZATION", "failed to create organization: {error}").With("error", err)
+	}
+
+	return s.buildOrganizationResponse(organization), http.StatusCreated, nil
+}
+
+// List lists the organizations
+func (s *organizationService) List(page, pageSize int) (*dtos.OrganizationListResponse, uint32, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	organizations, total, err := s.organizationRepo.FindAll(page, pageSize)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_ORGANIZATIONS", "failed to fetch organizations: {error}").With("error", err)
+	}
+
+	response := &dtos.OrganizationListResponse{
+		Organizations: make([]dtos.OrganizationResponse, len(organizations)),
+		Total:         total,
+	}
+	for i, organization := range organizations {
+		response.Organizations[i] = *s.buildOrganizationResponse(organization)
+	}
+	return response, http.StatusOK, nil
+}
+
+// Get returns an organization
+func (s *organizationService) Get(organizationID string) (*dtos.OrganizationResponse, uint32, error) {
+	organization, statusCode, err := s.getOrganization(organizationID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+	return s.buildOrganizationResponse(organization), http.StatusOK, nil
+}
+
+// Update renames an organization or changes the provider used by its members
+func (s *organizationService) Update(organizationID string, req *dtos.UpdateOrganizationRequest) (*dtos.OrganizationResponse, uint32, error) {
+	log.Printf("OrganizationService -> Update -> organizationID: %s", organizationID)
+
+	organization, statusCode, err := s.getOrganization(organizationID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, http.StatusBadRequest, apperrors.New("ORGANIZATION_NAME_REQUIRED", "organization name can't be empty")
+		}
+		organization.Name = name
+	}
+	if req.DefaultLLMProvider != nil {
+		if organization.GetLLMProvider(*req.DefaultLLMProvider) == nil {
+			return nil, http.StatusBadRequest, apperrors.New("LLM_PROVIDER_NOT_CONFIGURED", "LLM provider {provider} is not configured for the organization").With("provider", *req.DefaultLLMProvider)
+		}
+		organization.DefaultLLMProvider = *req.DefaultLLMProvider
+	}
+
+	if err := s.organizationRepo.Update(organization.ID, organization); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_UPDATE_ORGANIZATION", "failed to update organization: {error}").With("error", err)
+	}
+
+	return s.buildOrganizationResponse(organization), http.StatusOK, nil
+}
+
+// Delete deletes an organization, its members fall back to the default LLM client
+func (s *organizationService) Delete(organizationID string) (uint32, error) {
+	log.Printf("OrganizationService -> Delete -> organizationID: %s", organizationID)
+
+	organization, statusCode, err := s.getOrganization(organizationID)
+	if err != nil {
+		return statusCode, err
+	}
+
+	if err := s.organizationRepo.Delete(organization.ID); err != nil {
+		return http.StatusInternalServerError, apperrors.New("FAILED_TO_DELETE_ORGANIZATION", "failed to delete organization: {error}").With("error", err)
+	}
+	s.evictClients(organization.ID)
+
+	return http.StatusOK, nil
+}
+
+// AddMember adds a user to an organization
+func (s *organizationService) AddMember(organizationID string, req *dtos.OrganizationMemberRequest) (*dtos.OrganizationResponse, uint32, error) {
+	log.Printf("OrganizationService -> AddMember -> organizationID: %s, username: %s", organizationID, req.Username)
+
+	organization, statusCode, err := s.getOrganization(organizationID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	user, statusCode, err := s.findMemberCandidate(req.Username, organization.ID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	organization.MemberIDs = appendUniqueObjectID(organization.MemberIDs, user.ID)
+	if err := s.organizationRepo.Update(organization.ID, organization); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_UPDATE_ORGANIZATION", "failed to update organization: {error}").With("error", err)
+	}
+
+	return s.buildOrganizationResponse(organization), http.StatusOK, nil
+}
+
+// RemoveMember removes a user from an organization
+func (s *organizationService) RemoveMember(organizationID, userID string) (*dtos.OrganizationResponse, uint32, error) {
+	log.Printf("OrganizationService -> RemoveMember -> organizationID: %s, userID: %s", organizationID, userID)
+
+	organization, statusCode, err := s.getOrganization(organizationID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+
+	memberIDs := make([]primitive.ObjectID, 0, len(organization.MemberIDs))
+	for _, memberID := range organization.MemberIDs {
+		if memberID != userObjID {
+			memberIDs = append(memberIDs, memberID)
+		}
+	}
+	if len(memberIDs) == len(organization.MemberIDs) {
+		return nil, http.StatusNotFound, apperrors.New("NOT_ORGANIZATION_MEMBER", "user is not a member of the organization")
+	}
+
+	organization.MemberIDs = memberIDs
+	if err := s.organizationRepo.Update(organization.ID, organization); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_UPDATE_ORGANIZATION", "failed to update organization: {error}").With("error", err)
+	}
+
+	return s.buildOrganizationResponse(organization), http.StatusOK, nil
+}
+
+// SetLLMProvider stores the API key (encrypted) & the allowed models of a provider for an organization
+func (s *organizationService) SetLLMProvider(organizationID, provider string, req *dtos.SetOrganizationLLMProviderRequest) (*dtos.OrganizationResponse, uint32, error) {
+	log.Printf("OrganizationService -> SetLLMProvider -> organizationID: %s, provider: %s", organizationID, provider)
+
+	if !isOrganizationLLMProvider(provider) {
+		return nil, http.StatusBadRequest, apperrors.New("UNSUPPORTED_LLM_PROVIDER", "unsupported LLM provider: {provider}").With("provider", provider)
+	}
+
+	organization, statusCode, err := s.getOrganization(organizationID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	allowedModels := []string{}
+	for _, model := range req.AllowedModels {
+		if model = strings.TrimSpace(model); model != "" && !containsString(allowedModels, model) {
+			allowedModels = append(allowedModels, model)
+		}
+	}
+	if len(allowedModels) == 0 {
+		return nil, http.StatusBadRequest, apperrors.New("ALLOWED_MODELS_REQUIRED", "at least one allowed model is required")
+	}
+
+	defaultModel := strings.TrimSpace(req.DefaultModel)
+	if defaultModel == "" {
+		defaultModel = allowedModels[0]
+	} else if !containsString(allowedModels, defaultModel) {
+		return nil, http.StatusBadRequest, apperrors.New("DEFAULT_MODEL_NOT_ALLOWED", "default model {default_model} is not in the allowed models").With("default_model", defaultModel)
+	}
+
+	settings := organization.GetLLMProvider(provider)
+	if settings == nil {
+		if (req.APIKey == nil || strings.TrimSpace(*req.APIKey) == "") && provider != constants.Ollama {
+			return nil, http.StatusBadRequest, apperrors.New("API_KEY_REQUIRED", "API key is required")
+		}
+		organization.LLMProviders = append(organization.LLMProviders, models.OrganizationLLMProvider{Provider: provider})
+		settings = &organization.LLMProviders[len(organization.LLMProviders)-1]
+	}
+
+	if req.APIKey != nil && strings.TrimSpace(*req.APIKey) != "" {
+		encryptedKey, err := utils.EncryptSecret(strings.TrimSpace(*req.APIKey))
+		if err != nil {
+			return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_ENCRYPT_API_KEY", "failed to encrypt API key: {error}").With("error", err)
+		}
+		settings.APIKey = encryptedKey
+	}
+	settings.AllowedModels = allowedModels
+	settings.DefaultModel = defaultModel
+	settings.UpdatedAt = time.Now()
+
+	if err := s.organizationRepo.Update(organization.ID, organization); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_UPDATE_ORGANIZATION", "failed to update organization: {error}").With("error", err)
+	}
+	s.evictClients(organization.ID)
+
+	return s.buildOrganizationResponse(organization), http.StatusOK, nil
+}
+
+// isOrganizationLLMProvider reports whether an organization can use its own key with a provider. Azure OpenAI, Ollama & the
+// OpenAI-compatible servers are reached at the endpoint configured for the server. Bedrock is excluded: it signs its requests
+// with an AWS access key pair & a region, an organization only stores one key.
+func isOrganizationLLMProvider(provider string) bool {
+	switch provider {
+	case constants.OpenAI, constants.Gemini, constants.Claude, constants.AzureOpenAI, constants.Ollama, constants.OpenAICompatible:
+		return true
+	}
+	return false
+}
+
+// RemoveLLMProvider removes the settings of a provider from an organization
+func (s *organizationService) RemoveLLMProvider(organizationID, provider string) (*dtos.OrganizationResponse, uint32, error) {
+	log.Printf("OrganizationService -> RemoveLLMProvider -> organizationID: %s, provider: %s", organizationID, provider)
+
+	organization, statusCode, err := s.getOrganization(organizationID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	if organization.GetLLMProvider(provider) == nil {
+		return nil, http.StatusNotFound, apperrors.New("LLM_PROVIDER_NOT_CONFIGURED", "LLM provider {provider} is not configured for the organization").With("provider", provider)
+	}
+
+	providers := make([]models.OrganizationLLMProvider, 0, len(organization.LLMProviders))
+	for _, settings := range organization.LLMProviders {
+		if settings.Provider != provider {
+			providers = append(providers, settings)
+		}
+	}
+	organization.LLMProviders = providers
+	if organization.DefaultLLMProvider == provider {
+		organization.DefaultLLMProvider = ""
+	}
+
+	if err := s.organizationRepo.Update(organization.ID, organization); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_UPDATE_ORGANIZATION", "failed to update organization: {error}").With("error", err)
+	}
+	s.evictClients(organization.ID)
+
+	return s.buildOrganizationResponse(organization), http.StatusOK, nil
+}
+
+// getOrganization fetches an organization by its ID
+func (s *organizationService) getOrganization(organizationID string) (*models.Organization, uint32, error) {
+	organizationObjID, err := primitive.ObjectIDFromHex(organizationID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_ORGANIZATION_ID", "invalid organization ID format")
+	}
+
+	organization, err := s.organizationRepo.FindByID(organizationObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_ORGANIZATION", "failed to fetch organization: {error}").With("error", err)
+	}
+	if organization == nil {
+		return nil, http.StatusNotFound, apperrors.New("ORGANIZATION_NOT_FOUND", "organization not found")
+	}
+	return organization, http.StatusOK, nil
+}
+
+// findUserOrganization returns the organization of a user, nil if the user isn't part of one
+func (s *organizationService) findUserOrganization(userID string) (*models.Organization, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format")
+	}
+
+	organization, err := s.organizationRepo.FindByMemberID(userObjID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch organization: %v", err)
+	}
+	return organization, nil
+}
+
+// findMemberCandidate finds a user to add to an organization, users can't be part of two organizations
+func (s *organizationService) findMemberCandidate(username string, organizationID primitive.ObjectID) (*models.User, uint32, error) {
+	user, err := s.userRepo.FindByUsername(strings.TrimSpace(username))
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_USER", "failed to fetch user: {error}").With("error", err)
+	}
+	if user == nil {
+		return nil, http.StatusNotFound, apperrors.New("USER_NOT_FOUND", "user {username} not found").With("username", username)
+	}
+
+	current, err := s.organizationRepo.FindByMemberID(user.ID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_ORGANIZATION", "failed to fetch organization: {error}").With("error", err)
+	}
+	if current != nil && current.ID != organizationID {
+		return nil, http.StatusConflict, apperrors.New("USER_ALREADY_IN_ORGANIZATION", "user {username} is already a member of organization {organization}").With("username", username).With("organization", current.Name)
+	}
+	return user, http.StatusOK, nil
+}
+
+// buildOrganizationResponse converts an organization to its response, API keys are masked
+func (s *organizationService) buildOrganizationResponse(organization *models.Organization) *dtos.OrganizationResponse {
+	members := make([]dtos.OrganizationMemberResponse, 0, len(organization.MemberIDs))
+	for _, memberID := range organization.MemberIDs {
+		member := dtos.OrganizationMemberResponse{ID: memberID.Hex()}
+		if user, err := s.userRepo.FindByID(memberID.Hex()); err == nil && user != nil {
+			member.Username = user.Username
+		}
+		members = append(members, member)
+	}
+
+	providers := make([]dtos.OrganizationLLMProviderResponse, 0, len(organization.LLMProviders))
+	for _, settings := range organization.LLMProviders {
+		hint := ""
+		if apiKey, err := utils.DecryptSecret(settings.APIKey); err == nil && len(apiKey) > 4 {
+			hint = "..." + apiKey[len(apiKey)-4:]
+		}
+		providers = append(providers, dtos.OrganizationLLMProviderResponse{
+			Provider:      settings.Provider,
+			APIKeyHint:    hint,
+			AllowedModels: settings.AllowedModels,
+			DefaultModel:  settings.DefaultModel,
+			UpdatedAt:     settings.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+
+	return &dtos.OrganizationResponse{
+		ID:                 organization.ID.Hex(),
+		Name:               organization.Name,
+		Members:            members,
+		LLMProviders:       providers,
+		DefaultLLMProvider: organization.DefaultLLMProvider,
+		CreatedAt:          organization.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:          organization.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// appendUniqueObjectID appends an ID if it isn't in the slice yet
+func appendUniqueObjectID(ids []primitive.ObjectID, id primitive.ObjectID) []primitive.ObjectID {
+	for _, existing := range ids {
+		if existing == id {
+			return ids
+		}
+	}
+	return append(ids, id)
+}
+
+// containsString reports whether a slice contains a string
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/constants"
+	"neobase-ai/internal/models"
+	"neobase-ai/internal/repositories"
+	"neobase-ai/pkg/llm"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// maxPromptTemplateLength bounds the prompts sent with every request of a chat
+	maxPromptTemplateLength = 100000
+	// Active templates are reloaded periodically so the edits made through another instance are picked up
+	promptTemplateCacheTTL = 30 * time.Second
+)
+
+// PromptTemplateService stores the system prompts edited by the operators, they replace the built-in prompts without a redeploy
+type PromptTemplateService interface {
+	llm.PromptOverrides
+	ListActive() (*dtos.PromptTemplateListResponse, uint32, error)
+	GetVersions(dbType, provider string) (*dtos.PromptTemplateVersionsResponse, uint32, error)
+	CreateVersion(userID, dbType string, req *dtos.CreatePromptTemplateRequest) (*dtos.PromptTemplateResponse, uint32, error)
+	ActivateVersion(dbType, provider string, version int) (*dtos.PromptTemplateResponse, uint32, error)
+	// ResetToBuiltIn deactivates the template, the built-in prompt is used again
+	ResetToBuiltIn(dbType, provider string) (uint32, error)
+	// TemplatesChecksum changes whenever a template of the database type is activated or reset, "" when the built-in prompts are used
+	TemplatesChecksum(dbType string) string
+}
+
+type promptTemplateService struct {
+	promptTemplateRepo repositories.PromptTemplateRepository
+	// Active templates by database type, then by provider ("" for every provider)
+	active   map[string]map[string]string
+	loadedAt time.Time
+	mu       sync.RWMutex
+}
+
+func NewPromptTemplateService(promptTemplateRepo repositories.PromptTemplateRepository) PromptTemplateService {
+	return &promptTemplateService{
+		promptTemplateRepo: promptTemplateRepo,
+	}
+}
+
+// SystemPrompt returns the active template of a provider & database type, the template of every provider otherwise
+func (s *promptTemplateService) SystemPrompt(provider, dbType string) (string, bool) {
+	active := s.activeTemplates()
+	if prompt, ok := active[dbType][provider]; ok {
+		return prompt, true
+	}
+	prompt, ok := active[dbType][""]
+	return prompt, ok
+}
+
+// TemplatesChecksum returns the checksum of the active templates of a database type, for every provider
+func (s *promptTemplateService) TemplatesChecksum(dbType string) string {
+	templates := s.activeTemplates()[dbType]
+	if len(templates) == 0 {
+		return ""
+	}
+	// The providers are sorted by the encoding
+	encoded, err := json.Marshal(templates)
+	if err != nil {
+		log.Printf("PromptTemplateService -> TemplatesChecksum -> Error encoding templates: %v", err)
+		return ""
+	}
+	return textChecksum(string(encoded))
+}
+
+// ListActive lists the active templates
+func (s *promptTemplateService) ListActive() (*dtos.PromptTemplateListResponse, uint32, error) {
+	templates, err := s.promptTemplateRepo.FindActive()
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_PROMPT_TEMPLATES", "failed to fetch prompt templates: {error}").With("error", err)
+	}
+
+	response := &dtos.PromptTemplateListResponse{
+		Templates: make([]dtos.PromptTemplateResponse, 0, len(templates)),
+	}
+	for _, template := range templates {
+		response.Templates = append(response.Templates, buildPromptTemplateResponse(template))
+	}
+	return response, http.StatusOK, nil
+}
+
+// GetVersions returns the versions of the template of a database type & provider, latest first
+func (s *promptTemplateService) GetVersions(dbType, provider string) (*dtos.PromptTemplateVersionsResponse, uint32, error) {
+	if statusCode, err := validatePromptTemplateKey(dbType, provider); err != nil {
+		return nil, statusCode, err
+	}
+
+	templates, err := s.promptTemplateRepo.FindVersions(dbType, provider)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_PROMPT_TEMPLATES", "failed to fetch prompt templates: {error}").With("error", err)
+	}
+
+	// The template of every provider is based on the OpenAI prompts, the ones most providers share
+	builtInProvider := provider
+	if builtInProvider == "" {
+		builtInProvider = constants.OpenAI
+	}
+	response := &dtos.PromptTemplateVersionsResponse{
+		DBType:        dbType,
+		Provider:      provider,
+		BuiltInPrompt: constants.GetSystemPrompt(builtInProvider, dbType),
+		Versions:      make([]dtos.PromptTemplateResponse, 0, len(templates)),
+	}
+	for _, template := range templates {
+		if template.IsActive {
+			response.ActiveVersion = template.Version
+		}
+		response.Versions = append(response.Versions, buildPromptTemplateResponse(template))
+	}
+	return response, http.StatusOK, nil
+}
+
+// CreateVersion stores the next version of a template, it becomes the active one unless told otherwise
+func (s *promptTemplateService) CreateVersion(userID, dbType string, req *dtos.CreatePromptTemplateRequest) (*dtos.PromptTemplateResponse, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+	if statusCode, err := validatePromptTemplateKey(dbType, req.Provider); err != nil {
+		return nil, statusCode, err
+	}
+
+	content := strings.TrimSpace(req.Content)
+	if content == "" {
+		return nil, http.StatusBadRequest, apperrors.New("PROMPT_TEMPLATE_CONTENT_REQUIRED", "prompt template content is required")
+	}
+	if len(content) > maxPromptTemplateLength {
+		return nil, http.StatusBadRequest, apperrors.New("PROMPT_TEMPLATE_TOO_LONG", "prompt template can't be longer than {max} characters").With("max", maxPromptTemplateLength)
+	}
+
+	latestVersion, err := s.promptTemplateRepo.FindLatestVersion(dbType, req.Provider)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_PROMPT_TEMPLATES", "failed to fetch prompt templates: {error}").With("error", err)
+	}
+
+	template := models.NewPromptTemplate(dbType, req.Provider, latestVersion+1, content, strings.TrimSpace(req.Description), userObjID)
+	if err := s.promptTemplateRepo.Create(template); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_CREATE_PROMPT_TEMPLATE", "failed to create prompt template: {error}").With("error", err)
+	}
+	log.Printf("PromptTemplateService -> CreateVersion -> Created version %d of the %s template (provider: %q)", template.Version, dbType, req.Provider)
+
+	if req.Activate == nil || *req.Activate {
+		response, statusCode, err := s.ActivateVersion(dbType, req.Provider, template.Version)
+		if err != nil {
+			return nil, statusCode, err
+		}
+		return response, http.StatusCreated, nil
+	}
+	response := buildPromptTemplateResponse(template)
+	return &response, http.StatusCreated, nil
+}
+
+// ActivateVersion makes a version the active one, an older version can be activated to roll an edit back
+func (s *promptTemplateService) ActivateVersion(dbType, provider string, version int) (*dtos.PromptTemplateResponse, uint32, error) {
+	if statusCode, err := validatePromptTemplateKey(dbType, provider); err != nil {
+		return nil, statusCode, err
+	}
+
+	template, err := s.promptTemplateRepo.FindVersion(dbType, provider, version)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_PROMPT_TEMPLATES", "failed to fetch prompt templates: {error}").With("error", err)
+	}
+	if template == nil {
+		return nil, http.StatusNotFound, apperrors.New("PROMPT_TEMPLATE_VERSION_NOT_FOUND", "version {version} of the {dbType} prompt template not found").
+			With("version", version).
+			With("dbType", dbType)
+	}
+
+	if err := s.promptTemplateRepo.Activate(dbType, provider, version); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_ACTIVATE_PROMPT_TEMPLATE", "failed to activate prompt template: {error}").With("error", err)
+	}
+	s.invalidateCache()
+
+	template.IsActive = true
+	response := buildPromptTemplateResponse(template)
+	return &response, http.StatusOK, nil
+}
+
+// ResetToBuiltIn deactivates the versions of a template, they are kept to be activated again
+func (s *promptTemplateService) ResetToBuiltIn(dbType, provider string) (uint32, error) {
+	if statusCode, err := validatePromptTemplateKey(dbType, provider); err != nil {
+		return statusCode, err
+	}
+
+	if err := s.promptTemplateRepo.Activate(dbType, provider, 0); err != nil {
+		return http.StatusInternalServerError, apperrors.New("FAILED_TO_ACTIVATE_PROMPT_TEMPLATE", "failed to activate prompt template: {error}").With("error", err)
+	}
+	s.invalidateCache()
+	return http.StatusOK, nil
+}
+
+// activeTemplates returns the cached active templates, reloaded once the cache is stale.
+// The built-in prompts are used while the templates can't be loaded.
+func (s *promptTemplateService) activeTemplates() map[string]map[string]string {
+	s.mu.RLock()
+	if s.active != nil && time.Since(s.loadedAt) < promptTemplateCacheTTL {
+		defer s.mu.RUnlock()
+		return s.active
+	}
+	s.mu.RUnlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active != nil && time.Since(s.loadedAt) < promptTemplateCacheTTL {
+		return s.active
+	}
+
+	templates, err := s.promptTemplateRepo.FindActive()
+	if err != nil {
+		log.Printf("PromptTemplateService -> activeTemplates -> Error fetching active templates: %v", err)
+		return s.active
+	}
+
+	active := make(map[string]map[string]string)
+	for _, template := range templates {
+		if active[template.DBType] == nil {
+			active[template.DBType] = make(map[string]string)
+		}
+		active[template.DBType][template.Provider] = template.Content
+	}
+	s.active = active
+	s.loadedAt = time.Now()
+	return s.active
+}
+
+func (s *promptTemplateService) invalidateCache() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active = nil
+}
+
+// validatePromptTemplateKey checks the database type has a built-in prompt & the provider is supported, empty for every provider
+func validatePromptTemplateKey(dbType, provider string) (uint32, error) {
+	if !slices.Contains(constants.LLMDatabaseTypes, dbType) {
+		return http.StatusBadRequest, apperrors.New("UNSUPPORTED_PROMPT_TEMPLATE_DB_TYPE", "prompt templates are not supported for database type {dbType}").With("dbType", dbType)
+	}
+	switch provider {
+	case "", constants.OpenAI, constants.AzureOpenAI, constants.Gemini, constants.Ollama, constants.Claude, constants.Bedrock, constants.OpenAICompatible:
+		return http.StatusOK, nil
+	}
+	return http.StatusBadRequest, apperrors.New("UNSUPPORTED_LLM_PROVIDER", "unsupported LLM provider: {provider}").With("provider", provider)
+}
+
+func buildPromptTemplateResponse(template *models.PromptTemplate) dtos.PromptTemplateResponse {
+	return dtos.PromptTemplateResponse{
+		ID:          template.ID.Hex(),
+		DBType:      template.DBType,
+		Provider:    template.Provider,
+		Version:     template.Version,
+		Content:     template.Content,
+		Description: template.Description,
+		IsActive:    template.IsActive,
+		CreatedBy:   template.CreatedBy.Hex(),
+		CreatedAt:   template.CreatedAt.Format(time.RFC3339),
+	}
+}
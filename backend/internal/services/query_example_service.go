@@ -0,0 +1,301 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/models"
+	"neobase-ai/internal/repositories"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// Examples a chat (or the saved connection it uses) can register
+	maxQueryExamples = 200
+	// Examples added to the prompt, the most relevant to the request first
+	maxPromptExamples        = 3
+	maxExampleQuestionLength = 1000
+	maxExampleQueryLength    = 20000
+)
+
+// Words too common to tell examples apart
+var exampleStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "from": true, "that": true, "this": true,
+	"all": true, "are": true, "was": true, "were": true, "what": true, "which": true, "who": true,
+	"how": true, "many": true, "much": true, "show": true, "get": true, "list": true, "give": true,
+	"find": true, "can": true, "you": true, "have": true, "has": true, "into": true, "per": true,
+}
+
+type QueryExampleService interface {
+	Create(userID, chatID string, req *dtos.CreateQueryExampleRequest) (*dtos.QueryExampleResponse, uint32, error)
+	List(userID, chatID string) (*dtos.QueryExampleListResponse, uint32, error)
+	Update(userID, chatID, exampleID string, req *dtos.UpdateQueryExampleRequest) (*dtos.QueryExampleResponse, uint32, error)
+	Delete(userID, chatID, exampleID string) (uint32, error)
+	FormatRelevantExamples(chat *models.Chat, question string) string
+	DeleteChatExamples(chatID primitive.ObjectID) error
+}
+
+type queryExampleService struct {
+	exampleRepo repositories.QueryExampleRepository
+	chatRepo    repositories.ChatRepository
+}
+
+func NewQueryExampleService(exampleRepo repositories.QueryExampleRepository, chatRepo repositories.ChatRepository) QueryExampleService {
+	return &queryExampleService{
+		exampleRepo: exampleRepo,
+		chatRepo:    chatRepo,
+	}
+}
+
+// Create registers a question & its query, examples of a chat using a saved connection are shared with its other chats
+func (s *queryExampleService) Create(userID, chatID string, req *dtos.CreateQueryExampleRequest) (*dtos.QueryExampleResponse, uint32, error) {
+	chat, statusCode, err := s.verifyChatOwnership(userID, chatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	question := strings.TrimSpace(req.Question)
+	query := strings.TrimSpace(req.Query)
+	if statusCode, err := validateQueryExample(question, query); err != nil {
+		return nil, statusCode, err
+	}
+
+	count, err := s.exampleRepo.CountByChat(chat.ID, chat.ConnectionID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_QUERY_EXAMPLES", "failed to fetch query examples: {error}").With("error", err)
+	}
+	if count >= maxQueryExamples {
+		return nil, http.StatusBadRequest, apperrors.New("QUERY_EXAMPLE_LIMIT_REACHED", "a chat cannot have more than {max} query examples").With("max", maxQueryExamples)
+	}
+
+	example := models.NewQueryExample(chat.UserID, chat.ID, chat.ConnectionID, question, query)
+	example.Explanation = req.Explanation
+	if err := s.exampleRepo.Create(example); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_CREATE_QUERY_EXAMPLE", "failed to create query example: {error}").With("error", err)
+	}
+
+	return buildQueryExampleResponse(example), http.StatusCreated, nil
+}
+
+// List returns the examples of a chat, including the ones shared through its saved connection
+func (s *queryExampleService) List(userID, chatID string) (*dtos.QueryExampleListResponse, uint32, error) {
+	chat, statusCode, err := s.verifyChatOwnership(userID, chatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	examples, err := s.exampleRepo.FindByChat(chat.ID, chat.ConnectionID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_QUERY_EXAMPLES", "failed to fetch query examples: {error}").With("error", err)
+	}
+
+	response := &dtos.QueryExampleListResponse{
+		Examples: make([]dtos.QueryExampleResponse, 0, len(examples)),
+		Total:    len(examples),
+	}
+	for _, example := range examples {
+		response.Examples = append(response.Examples, *buildQueryExampleResponse(example))
+	}
+	return response, http.StatusOK, nil
+}
+
+func (s *queryExampleService) Update(userID, chatID, exampleID string, req *dtos.UpdateQueryExampleRequest) (*dtos.QueryExampleResponse, uint32, error) {
+	chat, statusCode, err := s.verifyChatOwnership(userID, chatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+	example, statusCode, err := s.findChatExample(chat, exampleID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	if req.Question != nil {
+		example.Question = strings.TrimSpace(*req.Question)
+	}
+	if req.Query != nil {
+		example.Query = strings.TrimSpace(*req.Query)
+	}
+	if req.Explanation != nil {
+		example.Explanation = req.Explanation
+	}
+	if statusCode, err := validateQueryExample(example.Question, example.Query); err != nil {
+		return nil, statusCode, err
+	}
+
+	example.UpdatedAt = time.Now()
+	if err := s.exampleRepo.Update(example); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_UPDATE_QUERY_EXAMPLE", "failed to update query example: {error}").With("error", err)
+	}
+	return buildQueryExampleResponse(example), http.StatusOK, nil
+}
+
+func (s *queryExampleService) Delete(userID, chatID, exampleID string) (uint32, error) {
+	chat, statusCode, err := s.verifyChatOwnership(userID, chatID)
+	if err != nil {
+		return statusCode, err
+	}
+	example, statusCode, err := s.findChatExample(chat, exampleID)
+	if err != nil {
+		return statusCode, err
+	}
+
+	if err := s.exampleRepo.Delete(example.ID); err != nil {
+		return http.StatusInternalServerError, apperrors.New("FAILED_TO_DELETE_QUERY_EXAMPLE", "failed to delete query example: {error}").With("error", err)
+	}
+	return http.StatusOK, nil
+}
+
+// FormatRelevantExamples formats the examples whose question shares the most words with the request, empty when none does
+func (s *queryExampleService) FormatRelevantExamples(chat *models.Chat, question string) string {
+	examples, err := s.exampleRepo.FindByChat(chat.ID, chat.ConnectionID)
+	if err != nil {
+		log.Printf("QueryExampleService -> FormatRelevantExamples -> Error fetching examples: %v", err)
+		return ""
+	}
+	if len(examples) == 0 {
+		return ""
+	}
+
+	questionWords := exampleWords(question)
+	if len(questionWords) == 0 {
+		return ""
+	}
+
+	type scoredExample struct {
+		example *models.QueryExample
+		score   float64
+	}
+	scored := make([]scoredExample, 0, len(examples))
+	for _, example := range examples {
+		exampleQuestionWords := exampleWords(example.Question)
+		shared := 0
+		for word := range exampleQuestionWords {
+			if questionWords[word] {
+				shared++
+			}
+		}
+		if shared == 0 {
+			continue
+		}
+		// Cosine of the word sets, long example questions don't win by their length
+		score := float64(shared) / math.Sqrt(float64(len(questionWords)*len(exampleQuestionWords)))
+		scored = append(scored, scoredExample{example: example, score: score})
+	}
+	if len(scored) == 0 {
+		return ""
+	}
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].score > scored[j].score
+	})
+
+	var result strings.Builder
+	for i, item := range scored[:min(maxPromptExamples, len(scored))] {
+		result.WriteString(fmt.Sprintf("Example %d:\nQuestion: %s\nQuery: %s\n", i+1, item.example.Question, item.example.Query))
+		if item.example.Explanation != nil && *item.example.Explanation != "" {
+			result.WriteString(fmt.Sprintf("Explanation: %s\n", *item.example.Explanation))
+		}
+		result.WriteString("\n")
+	}
+	return strings.TrimSpace(result.String())
+}
+
+// DeleteChatExamples removes the examples of a chat, the ones shared through a saved connection are kept
+func (s *queryExampleService) DeleteChatExamples(chatID primitive.ObjectID) error {
+	return s.exampleRepo.DeleteByChatID(chatID)
+}
+
+func (s *queryExampleService) findChatExample(chat *models.Chat, exampleID string) (*models.QueryExample, uint32, error) {
+	exampleObjID, err := primitive.ObjectIDFromHex(exampleID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_QUERY_EXAMPLE_ID", "invalid query example ID format")
+	}
+
+	example, err := s.exampleRepo.FindByID(exampleObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_QUERY_EXAMPLE", "failed to fetch query example: {error}").With("error", err)
+	}
+	sharedWithChat := example != nil && chat.ConnectionID != nil && example.ConnectionID != nil && *example.ConnectionID == *chat.ConnectionID
+	if example == nil || (example.ChatID != chat.ID && !sharedWithChat) {
+		return nil, http.StatusNotFound, apperrors.New("QUERY_EXAMPLE_NOT_FOUND", "query example not found")
+	}
+	return example, http.StatusOK, nil
+}
+
+func (s *queryExampleService) verifyChatOwnership(userID, chatID string) (*models.Chat, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+
+	chatObjID, err := primitive.ObjectIDFromHex(chatID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_CHAT_ID", "invalid chat ID format")
+	}
+
+	chat, err := s.chatRepo.FindByID(chatObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CHAT", "failed to fetch chat: {error}").With("error", err)
+	}
+	if chat == nil {
+		return nil, http.StatusNotFound, apperrors.New("CHAT_NOT_FOUND", "chat not found")
+	}
+	if chat.UserID != userObjID {
+		return nil, http.StatusForbidden, apperrors.New("CHAT_ACCESS_DENIED", "chat does not belong to user")
+	}
+	return chat, http.StatusOK, nil
+}
+
+func validateQueryExample(question, query string) (uint32, error) {
+	if question == "" || query == "" {
+		return http.StatusBadRequest, apperrors.New("INVALID_QUERY_EXAMPLE", "question and query are required")
+	}
+	if len(question) > maxExampleQuestionLength {
+		return http.StatusBadRequest, apperrors.New("QUERY_EXAMPLE_QUESTION_TOO_LONG", "question cannot be longer than {max} characters").With("max", maxExampleQuestionLength)
+	}
+	if len(query) > maxExampleQueryLength {
+		return http.StatusBadRequest, apperrors.New("QUERY_EXAMPLE_QUERY_TOO_LONG", "query cannot be longer than {max} characters").With("max", maxExampleQueryLength)
+	}
+	return http.StatusOK, nil
+}
+
+// exampleWords returns the distinct lowercase words of a question, without the common ones
+func exampleWords(text string) map[string]bool {
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}) {
+		if len(word) < 3 || exampleStopWords[word] {
+			continue
+		}
+		// Plurals match their singular, e.g. "orders" & "order"
+		words[strings.TrimSuffix(word, "s")] = true
+	}
+	return words
+}
+
+func buildQueryExampleResponse(example *models.QueryExample) *dtos.QueryExampleResponse {
+	var connectionID *string
+	if example.ConnectionID != nil {
+		hex := example.ConnectionID.Hex()
+		connectionID = &hex
+	}
+
+	return &dtos.QueryExampleResponse{
+		ID:           example.ID.Hex(),
+		ChatID:       example.ChatID.Hex(),
+		ConnectionID: connectionID,
+		Question:     example.Question,
+		Query:        example.Query,
+		Explanation:  example.Explanation,
+		CreatedAt:    example.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:    example.UpdatedAt.Format(time.RFC3339),
+	}
+}
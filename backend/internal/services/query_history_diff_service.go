@@ -0,0 +1,159 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/models"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DiffExecutions compares the results of two executions of the user, of the same query or of two queries: the rows are matched
+// by the value of the key column, the rows of one result only are added or removed and the matched rows whose values differ are
+// changed. Only the rows kept with the executions are compared.
+func (s *queryHistoryService) DiffExecutions(userID string, req *dtos.QueryHistoryDiffRequest) (*dtos.QueryHistoryDiffResponse, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+	keyColumn := strings.TrimSpace(req.KeyColumn)
+	if keyColumn == "" {
+		return nil, http.StatusBadRequest, apperrors.New("DIFF_KEY_COLUMN_REQUIRED", "key_column is required")
+	}
+
+	base, baseRows, status, err := s.diffExecution(userObjID, req.BaseID)
+	if err != nil {
+		return nil, status, err
+	}
+	target, targetRows, status, err := s.diffExecution(userObjID, req.TargetID)
+	if err != nil {
+		return nil, status, err
+	}
+
+	baseByKey, err := rowsByKey(baseRows, keyColumn, "base")
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	targetByKey, err := rowsByKey(targetRows, keyColumn, "target")
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	response := &dtos.QueryHistoryDiffResponse{
+		Base:        *buildQueryHistoryResponse(base),
+		Target:      *buildQueryHistoryResponse(target),
+		KeyColumn:   keyColumn,
+		Added:       []interface{}{},
+		Removed:     []interface{}{},
+		Changed:     []dtos.QueryHistoryRowDiff{},
+		IsTruncated: base.IsTruncated || target.IsTruncated,
+	}
+	// The rows are reported in the order of their result
+	for _, row := range baseRows {
+		before := row.(map[string]interface{})
+		after, ok := targetByKey[diffKey(before[keyColumn])]
+		if !ok {
+			response.Removed = append(response.Removed, before)
+			continue
+		}
+		if columns := changedColumns(before, after); len(columns) > 0 {
+			response.Changed = append(response.Changed, dtos.QueryHistoryRowDiff{
+				Key:     before[keyColumn],
+				Columns: columns,
+				Before:  before,
+				After:   after,
+			})
+		} else {
+			response.Unchanged++
+		}
+	}
+	for _, row := range targetRows {
+		after := row.(map[string]interface{})
+		if _, ok := baseByKey[diffKey(after[keyColumn])]; !ok {
+			response.Added = append(response.Added, after)
+		}
+	}
+
+	log.Printf("QueryHistoryService -> DiffExecutions -> %s..%s by %s: %d added, %d removed, %d changed", req.BaseID, req.TargetID, keyColumn, len(response.Added), len(response.Removed), len(response.Changed))
+	return response, http.StatusOK, nil
+}
+
+// diffExecution returns an execution of the user & the rows kept with it
+func (s *queryHistoryService) diffExecution(userID primitive.ObjectID, executionID string) (*models.QueryHistoryEntry, []interface{}, uint32, error) {
+	entryID, err := primitive.ObjectIDFromHex(executionID)
+	if err != nil {
+		return nil, nil, http.StatusBadRequest, apperrors.New("INVALID_EXECUTION_ID", "invalid execution ID format")
+	}
+	entry, err := s.historyRepo.FindByID(entryID)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_QUERY_HISTORY", "failed to fetch query history: {error}").With("error", err)
+	}
+	if entry == nil || entry.UserID != userID {
+		return nil, nil, http.StatusNotFound, apperrors.New("EXECUTION_NOT_FOUND", "execution {id} not found").With("id", executionID)
+	}
+	if entry.Status != models.QueryHistoryStatusSucceeded {
+		return nil, nil, http.StatusBadRequest, apperrors.New("EXECUTION_FAILED", "execution {id} failed, it has no result to compare").With("id", executionID)
+	}
+	if entry.Result == nil {
+		return nil, nil, http.StatusBadRequest, apperrors.New("EXECUTION_RESULT_NOT_KEPT", "the result of execution {id} has no rows or was too large to be kept").With("id", executionID)
+	}
+
+	var rows []interface{}
+	if err := json.Unmarshal([]byte(*entry.Result), &rows); err != nil {
+		return nil, nil, http.StatusInternalServerError, apperrors.New("INVALID_EXECUTION_RESULT", "failed to read the result of execution {id}: {error}").With("id", executionID).With("error", err)
+	}
+	return entry, rows, http.StatusOK, nil
+}
+
+// rowsByKey indexes the rows of a result by the value of their key column, which every row must hold once
+func rowsByKey(rows []interface{}, keyColumn, side string) (map[string]map[string]interface{}, error) {
+	byKey := make(map[string]map[string]interface{}, len(rows))
+	for _, row := range rows {
+		values, ok := row.(map[string]interface{})
+		if !ok {
+			return nil, apperrors.New("INVALID_DIFF_ROWS", "the rows of the {side} result are not objects with columns").With("side", side)
+		}
+		value, ok := values[keyColumn]
+		if !ok || value == nil {
+			return nil, apperrors.New("DIFF_KEY_COLUMN_MISSING", "a row of the {side} result has no {column}").With("side", side).With("column", keyColumn)
+		}
+		key := diffKey(value)
+		if _, ok := byKey[key]; ok {
+			return nil, apperrors.New("DIFF_KEY_NOT_UNIQUE", "{column} is not unique in the {side} result, {key} is repeated").With("column", keyColumn).With("side", side).With("key", key)
+		}
+		byKey[key] = values
+	}
+	return byKey, nil
+}
+
+// diffKey encodes a key value so that the keys of both results compare equal, whatever their type
+func diffKey(value interface{}) string {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(encoded)
+}
+
+// changedColumns returns the columns whose values differ between two rows, by name
+func changedColumns(before, after map[string]interface{}) []string {
+	columns := []string{}
+	for column, value := range before {
+		if afterValue, ok := after[column]; !ok || !reflect.DeepEqual(value, afterValue) {
+			columns = append(columns, column)
+		}
+	}
+	for column := range after {
+		if _, ok := before[column]; !ok {
+			columns = append(columns, column)
+		}
+	}
+	sort.Strings(columns)
+	return columns
+}
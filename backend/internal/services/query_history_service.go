@@ -0,0 +1,236 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/models"
+	"neobase-ai/internal/repositories"
+	"neobase-ai/pkg/dbmanager"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// queryHistoryDateLayout is the layout of the dates the history is filtered with, along with RFC3339 times
+const queryHistoryDateLayout = "2006-01-02"
+
+// The rows of a result kept with its execution to be compared by the diffs, the results larger once encoded aren't kept
+const (
+	queryHistoryResultRows     = 1000
+	queryHistoryMaxResultBytes = 1024 * 1024
+)
+
+type QueryHistoryService interface {
+	RecordExecution(userID string, chat *models.Chat, messageID, queryID primitive.ObjectID, query string, queryType *string, isRollback bool, startedAt time.Time, result *dbmanager.QueryExecutionResult, queryErr *dtos.QueryError)
+	List(userID string, req *dtos.QueryHistoryListRequest) (*dtos.QueryHistoryListResponse, uint32, error)
+	DiffExecutions(userID string, req *dtos.QueryHistoryDiffRequest) (*dtos.QueryHistoryDiffResponse, uint32, error)
+}
+
+type queryHistoryService struct {
+	historyRepo repositories.QueryHistoryRepository
+}
+
+func NewQueryHistoryService(historyRepo repositories.QueryHistoryRepository) QueryHistoryService {
+	return &queryHistoryService{
+		historyRepo: historyRepo,
+	}
+}
+
+// RecordExecution adds a query executed from a chat to the history of the user with the first rows of its result. The entry is
+// built before returning, as the caller caps the result afterwards, and stored in the background: a failure to store it is only logged
+func (s *queryHistoryService) RecordExecution(userID string, chat *models.Chat, messageID, queryID primitive.ObjectID, query string, queryType *string, isRollback bool, startedAt time.Time, result *dbmanager.QueryExecutionResult, queryErr *dtos.QueryError) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		log.Printf("QueryHistoryService -> RecordExecution -> Invalid user ID: %s", userID)
+		return
+	}
+
+	entry := &models.QueryHistoryEntry{
+		UserID:        userObjID,
+		ChatID:        chat.ID,
+		ConnectionID:  chat.ConnectionID,
+		DatabaseType:  chat.Connection.Type,
+		Database:      chat.Connection.Database,
+		MessageID:     messageID,
+		QueryID:       queryID,
+		Query:         query,
+		QueryType:     queryType,
+		IsRollback:    isRollback,
+		Status:        models.QueryHistoryStatusSucceeded,
+		ExecutionTime: int(time.Since(startedAt).Milliseconds()),
+		ExecutedAt:    startedAt,
+		Base:          models.NewBase(),
+	}
+	if result != nil && result.ExecutionTime > 0 {
+		entry.ExecutionTime = result.ExecutionTime
+	}
+	if queryErr != nil {
+		entry.Status = models.QueryHistoryStatusFailed
+		entry.Error = &models.QueryError{Code: queryErr.Code, Message: queryErr.Message, Details: queryErr.Details}
+	} else {
+		rows := dbmanager.CountResultRows(result)
+		entry.Rows = &rows
+		entry.Result, entry.IsTruncated = queryHistoryResult(result)
+	}
+
+	go func() {
+		if err := s.historyRepo.Create(entry); err != nil {
+			log.Printf("QueryHistoryService -> RecordExecution -> Error storing query history: %v", err)
+		}
+	}()
+}
+
+// queryHistoryResult returns the first rows of a result as a JSON array & whether rows were left out, nil for the results
+// without rows or too large to be kept
+func queryHistoryResult(result *dbmanager.QueryExecutionResult) (*string, bool) {
+	if result == nil || result.ResultJSON == "" {
+		return nil, false
+	}
+	rows, ok := resultJSONRows(result.ResultJSON)
+	if !ok {
+		return nil, false
+	}
+	truncated, _ := result.Result["truncated"].(bool)
+	if len(rows) > queryHistoryResultRows {
+		rows = rows[:queryHistoryResultRows]
+		truncated = true
+	}
+	encoded, err := json.Marshal(rows)
+	if err != nil || len(encoded) > queryHistoryMaxResultBytes {
+		return nil, false
+	}
+	resultJSON := string(encoded)
+	return &resultJSON, truncated
+}
+
+// resultJSONRows decodes the rows of a result, a JSON array of rows or an object holding them in "results"
+func resultJSONRows(resultJSON string) ([]interface{}, bool) {
+	var rows []interface{}
+	if err := json.Unmarshal([]byte(resultJSON), &rows); err == nil {
+		return rows, true
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(resultJSON), &object); err != nil {
+		return nil, false
+	}
+	rows, ok := object["results"].([]interface{})
+	return rows, ok
+}
+
+// List returns the query executions of the user matching the filters, most recent first
+func (s *queryHistoryService) List(userID string, req *dtos.QueryHistoryListRequest) (*dtos.QueryHistoryListResponse, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+
+	filter := repositories.QueryHistoryFilter{
+		DatabaseType: strings.TrimSpace(req.DatabaseType),
+		IsRollback:   req.IsRollback,
+		Search:       strings.TrimSpace(req.Search),
+	}
+	if req.ChatID != "" {
+		chatObjID, err := primitive.ObjectIDFromHex(req.ChatID)
+		if err != nil {
+			return nil, http.StatusBadRequest, apperrors.New("INVALID_CHAT_ID", "invalid chat ID format")
+		}
+		filter.ChatID = &chatObjID
+	}
+	if req.ConnectionID != "" {
+		connectionObjID, err := primitive.ObjectIDFromHex(req.ConnectionID)
+		if err != nil {
+			return nil, http.StatusBadRequest, apperrors.New("INVALID_CONNECTION_ID", "invalid connection ID format")
+		}
+		filter.ConnectionID = &connectionObjID
+	}
+	if req.Status != "" {
+		if req.Status != models.QueryHistoryStatusSucceeded && req.Status != models.QueryHistoryStatusFailed {
+			return nil, http.StatusBadRequest, apperrors.New("INVALID_HISTORY_STATUS", "invalid status {status}, expected succeeded or failed").With("status", req.Status)
+		}
+		filter.Status = req.Status
+	}
+	if req.From != "" {
+		from, err := parseQueryHistoryTime(req.From, false)
+		if err != nil {
+			return nil, http.StatusBadRequest, apperrors.New("INVALID_HISTORY_PERIOD", "invalid from {from}, expected an RFC3339 time or YYYY-MM-DD").With("from", req.From)
+		}
+		filter.From = &from
+	}
+	if req.To != "" {
+		to, err := parseQueryHistoryTime(req.To, true)
+		if err != nil {
+			return nil, http.StatusBadRequest, apperrors.New("INVALID_HISTORY_PERIOD", "invalid to {to}, expected an RFC3339 time or YYYY-MM-DD").With("to", req.To)
+		}
+		filter.To = &to
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_HISTORY_PERIOD", "from must be before to")
+	}
+
+	page, pageSize := req.Page, req.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	entries, total, err := s.historyRepo.FindByUserID(userObjID, filter, page, pageSize)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_QUERY_HISTORY", "failed to fetch query history: {error}").With("error", err)
+	}
+
+	response := &dtos.QueryHistoryListResponse{
+		Entries: make([]dtos.QueryHistoryResponse, 0, len(entries)),
+		Total:   total,
+	}
+	for _, entry := range entries {
+		response.Entries = append(response.Entries, *buildQueryHistoryResponse(entry))
+	}
+	return response, http.StatusOK, nil
+}
+
+// parseQueryHistoryTime parses an RFC3339 time or a date, a date ending a period includes its whole day
+func parseQueryHistoryTime(value string, endOfPeriod bool) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	parsed, err := time.Parse(queryHistoryDateLayout, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfPeriod {
+		parsed = parsed.AddDate(0, 0, 1)
+	}
+	return parsed, nil
+}
+
+func buildQueryHistoryResponse(entry *models.QueryHistoryEntry) *dtos.QueryHistoryResponse {
+	response := &dtos.QueryHistoryResponse{
+		ID:            entry.ID.Hex(),
+		ChatID:        entry.ChatID.Hex(),
+		DatabaseType:  entry.DatabaseType,
+		Database:      entry.Database,
+		MessageID:     entry.MessageID.Hex(),
+		QueryID:       entry.QueryID.Hex(),
+		Query:         entry.Query,
+		QueryType:     entry.QueryType,
+		IsRollback:    entry.IsRollback,
+		Status:        entry.Status,
+		ExecutionTime: entry.ExecutionTime,
+		Rows:          entry.Rows,
+		ExecutedAt:    entry.ExecutedAt.Format(time.RFC3339),
+	}
+	if entry.ConnectionID != nil {
+		connectionID := entry.ConnectionID.Hex()
+		response.ConnectionID = &connectionID
+	}
+	if entry.Error != nil {
+		response.Error = &dtos.QueryError{Code: entry.Error.Code, Message: entry.Error.Message, Details: entry.Error.Details}
+	}
+	return response
+}
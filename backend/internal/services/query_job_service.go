@@ -0,0 +1,387 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"neobase-ai/config"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/models"
+	"neobase-ai/internal/repositories"
+	"neobase-ai/pkg/dbmanager"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const queryJobStreamIDPrefix = "query-job-" // Prefix of the stream ID the query of a job is executed with
+
+type QueryJobService interface {
+	Create(userID string, req *dtos.CreateQueryJobRequest) (*dtos.QueryJobResponse, uint32, error)
+	List(userID, chatID string, page, pageSize int) (*dtos.QueryJobListResponse, uint32, error)
+	Get(userID, jobID string) (*dtos.QueryJobResponse, uint32, error)
+	Cancel(userID, jobID string) (*dtos.QueryJobResponse, uint32, error)
+}
+
+// queuedQueryJob is a job waiting for a worker, its context is cancelled when the job is
+type queuedQueryJob struct {
+	ctx context.Context
+	job *models.QueryJob
+}
+
+type queryJobService struct {
+	jobRepo     repositories.QueryJobRepository
+	chatRepo    repositories.ChatRepository
+	dbManager   *dbmanager.Manager
+	chatService ChatService
+
+	queue chan queuedQueryJob
+
+	// Jobs queued or executed by this instance, the cancel func stops the query
+	activeJobs   map[string]context.CancelFunc
+	activeJobsMu sync.Mutex
+}
+
+// NewQueryJobService starts the workers executing the queued jobs, QUERY_JOB_WORKERS of them
+func NewQueryJobService(jobRepo repositories.QueryJobRepository, chatRepo repositories.ChatRepository, dbManager *dbmanager.Manager, chatService ChatService) QueryJobService {
+	s := &queryJobService{
+		jobRepo:     jobRepo,
+		chatRepo:    chatRepo,
+		dbManager:   dbManager,
+		chatService: chatService,
+		queue:       make(chan queuedQueryJob, max(config.Env.QueryJobQueueSize, 1)),
+		activeJobs:  make(map[string]context.CancelFunc),
+	}
+	for i := 0; i < max(config.Env.QueryJobWorkers, 1); i++ {
+		go s.worker()
+	}
+	return s
+}
+
+// Create queues the execution of a query of the user, the job is polled or its completion is pushed to the stream
+func (s *queryJobService) Create(userID string, req *dtos.CreateQueryJobRequest) (*dtos.QueryJobResponse, uint32, error) {
+	log.Printf("QueryJobService -> Create -> userID: %s, chatID: %s, messageID: %s, queryID: %s", userID, req.ChatID, req.MessageID, req.QueryID)
+
+	timeoutMinutes := config.Env.QueryJobTimeoutMinutes
+	if req.TimeoutMinutes != nil {
+		if *req.TimeoutMinutes <= 0 || *req.TimeoutMinutes > config.Env.QueryJobTimeoutMinutes {
+			return nil, http.StatusBadRequest, apperrors.New("INVALID_JOB_TIMEOUT", "timeout_minutes must be between 1 and {max}").With("max", config.Env.QueryJobTimeoutMinutes)
+		}
+		timeoutMinutes = *req.TimeoutMinutes
+	}
+
+	chat, statusCode, err := s.verifyChatOwnership(userID, req.ChatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+	messageID, queryID, statusCode, err := s.findQuery(chat, req.MessageID, req.QueryID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	job := models.NewQueryJob(chat.UserID, chat.ID, messageID, queryID, req.StreamID, req.UsePrimary, timeoutMinutes)
+	if err := s.jobRepo.Create(job); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_CREATE_QUERY_JOB", "failed to create query job: {error}").With("error", err)
+	}
+
+	// Build the response before a worker starts updating the job
+	response := buildQueryJobResponse(job)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.activeJobsMu.Lock()
+	s.activeJobs[job.ID.Hex()] = cancel
+	s.activeJobsMu.Unlock()
+
+	select {
+	case s.queue <- queuedQueryJob{ctx: ctx, job: job}:
+	default:
+		s.releaseJob(job)
+		failure := "the queue of query jobs is full"
+		now := time.Now()
+		job.Status = models.QueryJobStatusFailed
+		job.Error = &models.QueryError{Code: "QUERY_JOB_QUEUE_FULL", Message: failure}
+		job.CompletedAt = &now
+		s.saveJob(job)
+		return nil, http.StatusServiceUnavailable, apperrors.New("QUERY_JOB_QUEUE_FULL", "too many query jobs are queued, try again later")
+	}
+	return response, http.StatusAccepted, nil
+}
+
+// List returns the query jobs of the user, only the ones of a chat when chatID is not empty
+func (s *queryJobService) List(userID, chatID string, page, pageSize int) (*dtos.QueryJobListResponse, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+
+	var chatObjID *primitive.ObjectID
+	if chatID != "" {
+		parsed, err := primitive.ObjectIDFromHex(chatID)
+		if err != nil {
+			return nil, http.StatusBadRequest, apperrors.New("INVALID_CHAT_ID", "invalid chat ID format")
+		}
+		chatObjID = &parsed
+	}
+
+	jobs, total, err := s.jobRepo.FindByUserID(userObjID, chatObjID, page, pageSize)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_QUERY_JOBS", "failed to fetch query jobs: {error}").With("error", err)
+	}
+
+	response := &dtos.QueryJobListResponse{
+		Jobs:  make([]dtos.QueryJobResponse, 0, len(jobs)),
+		Total: total,
+	}
+	for _, job := range jobs {
+		response.Jobs = append(response.Jobs, *buildQueryJobResponse(job))
+	}
+	return response, http.StatusOK, nil
+}
+
+// Get returns the status of a job, with the result of the query once completed
+func (s *queryJobService) Get(userID, jobID string) (*dtos.QueryJobResponse, uint32, error) {
+	job, statusCode, err := s.findJob(userID, jobID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+	return buildQueryJobResponse(job), http.StatusOK, nil
+}
+
+// Cancel removes a job from the queue or stops its query, the transaction of a running query is rolled back
+func (s *queryJobService) Cancel(userID, jobID string) (*dtos.QueryJobResponse, uint32, error) {
+	job, statusCode, err := s.findJob(userID, jobID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+	if job.IsFinished() {
+		return nil, http.StatusConflict, apperrors.New("JOB_ALREADY_FINISHED", "query job has already finished")
+	}
+
+	s.activeJobsMu.Lock()
+	cancel, active := s.activeJobs[job.ID.Hex()]
+	s.activeJobsMu.Unlock()
+
+	if active && job.Status == models.QueryJobStatusRunning {
+		cancel()
+		s.dbManager.CancelQueryExecution(queryJobStreamIDPrefix + job.ID.Hex())
+		job.Status = models.QueryJobStatusCancelled
+		return buildQueryJobResponse(job), http.StatusAccepted, nil
+	}
+
+	// The job is still queued, or was interrupted by a server restart so nothing is executing
+	if active {
+		cancel()
+	}
+	now := time.Now()
+	job.Status = models.QueryJobStatusCancelled
+	job.CompletedAt = &now
+	if err := s.jobRepo.Update(job); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_UPDATE_QUERY_JOB", "failed to update query job: {error}").With("error", err)
+	}
+	s.sendFinishedEvent(job)
+	return buildQueryJobResponse(job), http.StatusOK, nil
+}
+
+// worker executes the queued jobs one after the other
+func (s *queryJobService) worker() {
+	for queued := range s.queue {
+		if queued.ctx.Err() != nil {
+			// Cancelled while queued, Cancel already recorded it
+			s.releaseJob(queued.job)
+			continue
+		}
+		s.executeJob(queued.ctx, queued.job)
+		s.releaseJob(queued.job)
+	}
+}
+
+// executeJob executes the query like /queries/execute does, with the timeout of the job
+func (s *queryJobService) executeJob(ctx context.Context, job *models.QueryJob) {
+	log.Printf("QueryJobService -> executeJob -> Starting job %s, timeout: %d minutes", job.ID.Hex(), job.TimeoutMinutes)
+
+	startedAt := time.Now()
+	job.Status = models.QueryJobStatusRunning
+	job.StartedAt = &startedAt
+	s.saveJob(job)
+
+	ctx = dbmanager.WithQueryTimeout(ctx, time.Duration(job.TimeoutMinutes)*time.Minute)
+	response, _, err := s.chatService.ExecuteQuery(ctx, job.UserID.Hex(), job.ChatID.Hex(), &dtos.ExecuteQueryRequest{
+		MessageID:  job.MessageID.Hex(),
+		QueryID:    job.QueryID.Hex(),
+		StreamID:   queryJobStreamIDPrefix + job.ID.Hex(),
+		UsePrimary: job.UsePrimary,
+	})
+
+	completedAt := time.Now()
+	job.CompletedAt = &completedAt
+
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		// Cancelled by the user
+		job.Status = models.QueryJobStatusCancelled
+		log.Printf("QueryJobService -> executeJob -> Job %s cancelled", job.ID.Hex())
+	case err != nil:
+		job.Status = models.QueryJobStatusFailed
+		job.Error = &models.QueryError{Code: "QUERY_JOB_FAILED", Message: err.Error()}
+		if appErr, ok := err.(*apperrors.Error); ok {
+			job.Error.Code = appErr.Code
+		}
+		log.Printf("QueryJobService -> executeJob -> Job %s failed: %v", job.ID.Hex(), err)
+	case response.Error != nil:
+		job.Status = models.QueryJobStatusFailed
+		job.Error = &models.QueryError{Code: response.Error.Code, Message: response.Error.Message, Details: response.Error.Details}
+		log.Printf("QueryJobService -> executeJob -> Job %s query failed: %s", job.ID.Hex(), response.Error.Message)
+	default:
+		job.Status = models.QueryJobStatusCompleted
+		job.ExecutionTime = response.ExecutionTime
+		job.TotalRecordsCount = response.TotalRecordsCount
+		// The result is capped like the one stored in the message
+		if result, err := json.Marshal(response.ExecutionResult); err == nil {
+			resultJSON := string(result)
+			job.ExecutionResult = &resultJSON
+		}
+		log.Printf("QueryJobService -> executeJob -> Job %s completed in %v", job.ID.Hex(), completedAt.Sub(startedAt))
+	}
+	s.saveJob(job)
+	s.sendFinishedEvent(job)
+}
+
+// sendFinishedEvent pushes the final state of a job to its stream, the clients without one poll the job
+func (s *queryJobService) sendFinishedEvent(job *models.QueryJob) {
+	if job.StreamID == "" {
+		return
+	}
+	s.chatService.HandleDBEvent(job.UserID.Hex(), job.ChatID.Hex(), job.StreamID, dtos.StreamResponse{
+		Event: "query-job-finished",
+		Data:  buildQueryJobResponse(job),
+	})
+}
+
+func (s *queryJobService) releaseJob(job *models.QueryJob) {
+	s.activeJobsMu.Lock()
+	cancel, exists := s.activeJobs[job.ID.Hex()]
+	delete(s.activeJobs, job.ID.Hex())
+	s.activeJobsMu.Unlock()
+	if exists {
+		cancel()
+	}
+}
+
+func (s *queryJobService) saveJob(job *models.QueryJob) {
+	if err := s.jobRepo.Update(job); err != nil {
+		log.Printf("QueryJobService -> saveJob -> Failed to save job %s: %v", job.ID.Hex(), err)
+	}
+}
+
+func (s *queryJobService) findJob(userID, jobID string) (*models.QueryJob, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+
+	jobObjID, err := primitive.ObjectIDFromHex(jobID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_JOB_ID", "invalid job ID format")
+	}
+
+	job, err := s.jobRepo.FindByID(jobObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_QUERY_JOB", "failed to fetch query job: {error}").With("error", err)
+	}
+	if job == nil || job.UserID != userObjID {
+		return nil, http.StatusNotFound, apperrors.New("QUERY_JOB_NOT_FOUND", "query job not found")
+	}
+	return job, http.StatusOK, nil
+}
+
+func (s *queryJobService) verifyChatOwnership(userID, chatID string) (*models.Chat, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+
+	chatObjID, err := primitive.ObjectIDFromHex(chatID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_CHAT_ID", "invalid chat ID format")
+	}
+
+	chat, err := s.chatRepo.FindByID(chatObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CHAT", "failed to fetch chat: {error}").With("error", err)
+	}
+	if chat == nil {
+		return nil, http.StatusNotFound, apperrors.New("CHAT_NOT_FOUND", "chat not found")
+	}
+	if chat.UserID != userObjID {
+		return nil, http.StatusForbidden, apperrors.New("CHAT_ACCESS_DENIED", "chat does not belong to user")
+	}
+	return chat, http.StatusOK, nil
+}
+
+// findQuery checks the query is one of a message of the chat
+func (s *queryJobService) findQuery(chat *models.Chat, messageID, queryID string) (primitive.ObjectID, primitive.ObjectID, uint32, error) {
+	msgObjID, err := primitive.ObjectIDFromHex(messageID)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, http.StatusBadRequest, apperrors.New("INVALID_MESSAGE_ID", "invalid message ID format")
+	}
+	queryObjID, err := primitive.ObjectIDFromHex(queryID)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, http.StatusBadRequest, apperrors.New("INVALID_QUERY_ID", "invalid query ID format")
+	}
+
+	msg, err := s.chatRepo.FindMessageByID(msgObjID)
+	if err == mongo.ErrNoDocuments || (err == nil && (msg == nil || msg.ChatID != chat.ID)) {
+		return primitive.NilObjectID, primitive.NilObjectID, http.StatusNotFound, apperrors.New("MESSAGE_NOT_FOUND", "message not found")
+	}
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_MESSAGE", "failed to fetch message: {error}").With("error", err)
+	}
+	if msg.Queries != nil {
+		for _, query := range *msg.Queries {
+			if query.ID == queryObjID {
+				return msgObjID, queryObjID, http.StatusOK, nil
+			}
+		}
+	}
+	return primitive.NilObjectID, primitive.NilObjectID, http.StatusNotFound, apperrors.New("QUERY_NOT_FOUND", "query not found")
+}
+
+func buildQueryJobResponse(job *models.QueryJob) *dtos.QueryJobResponse {
+	formatTime := func(t *time.Time) *string {
+		if t == nil {
+			return nil
+		}
+		formatted := t.Format(time.RFC3339)
+		return &formatted
+	}
+
+	response := &dtos.QueryJobResponse{
+		ID:                job.ID.Hex(),
+		ChatID:            job.ChatID.Hex(),
+		MessageID:         job.MessageID.Hex(),
+		QueryID:           job.QueryID.Hex(),
+		Status:            job.Status,
+		TimeoutMinutes:    job.TimeoutMinutes,
+		ExecutionTime:     job.ExecutionTime,
+		TotalRecordsCount: job.TotalRecordsCount,
+		QueuedAt:          job.CreatedAt.Format(time.RFC3339),
+		StartedAt:         formatTime(job.StartedAt),
+		CompletedAt:       formatTime(job.CompletedAt),
+	}
+	if job.ExecutionResult != nil {
+		var result interface{}
+		if err := json.Unmarshal([]byte(*job.ExecutionResult), &result); err != nil {
+			result = *job.ExecutionResult
+		}
+		response.ExecutionResult = result
+	}
+	if job.Error != nil {
+		response.Error = &dtos.QueryError{Code: job.Error.Code, Message: job.Error.Message, Details: job.Error.Details}
+	}
+	return response
+}
@@ -0,0 +1,356 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/constants"
+	"neobase-ai/internal/models"
+	"neobase-ai/internal/repositories"
+	"neobase-ai/pkg/dbmanager"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type QueryTemplateService interface {
+	Create(userID, chatID string, req *dtos.CreateQueryTemplateRequest) (*dtos.QueryTemplateResponse, uint32, error)
+	List(userID, chatID string, page, pageSize int) (*dtos.QueryTemplateListResponse, uint32, error)
+	Get(userID, chatID, templateID string) (*dtos.QueryTemplateResponse, uint32, error)
+	Update(userID, chatID, templateID string, req *dtos.UpdateQueryTemplateRequest) (*dtos.QueryTemplateResponse, uint32, error)
+	Delete(userID, chatID, templateID string) (uint32, error)
+	Execute(ctx context.Context, userID, chatID, templateID string, req *dtos.ExecuteQueryTemplateRequest) (*dtos.QueryExecutionResponse, uint32, error)
+}
+
+type queryTemplateService struct {
+	templateRepo repositories.QueryTemplateRepository
+	chatRepo     repositories.ChatRepository
+	chatService  ChatService
+}
+
+func NewQueryTemplateService(templateRepo repositories.QueryTemplateRepository, chatRepo repositories.ChatRepository, chatService ChatService) QueryTemplateService {
+	return &queryTemplateService{
+		templateRepo: templateRepo,
+		chatRepo:     chatRepo,
+		chatService:  chatService,
+	}
+}
+
+// Create saves the query text, or a query of a message of the chat, as a template with the parameters found in the query
+func (s *queryTemplateService) Create(userID, chatID string, req *dtos.CreateQueryTemplateRequest) (*dtos.QueryTemplateResponse, uint32, error) {
+	log.Printf("QueryTemplateService -> Create -> userID: %s, chatID: %s, name: %s", userID, chatID, req.Name)
+
+	chat, statusCode, err := s.verifyChatOwnership(userID, chatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_TEMPLATE_NAME", "name is required")
+	}
+
+	var template *models.QueryTemplate
+	switch {
+	case req.Query != nil && strings.TrimSpace(*req.Query) != "":
+		query := strings.TrimSpace(*req.Query)
+		template = models.NewQueryTemplate(chat.UserID, chat.ID, name, query, dbmanager.QueryParameterNames(chat.Connection.Type, query))
+		template.QueryType = req.QueryType
+	case req.MessageID != nil && req.QueryID != nil:
+		query, statusCode, err := s.findQuery(chat, *req.MessageID, *req.QueryID)
+		if err != nil {
+			return nil, statusCode, err
+		}
+		template = models.NewQueryTemplate(chat.UserID, chat.ID, name, query.Query, dbmanager.QueryParameterNames(chat.Connection.Type, query.Query))
+		template.QueryType = query.QueryType
+		template.Tables = query.Tables
+		if req.QueryType != nil {
+			template.QueryType = req.QueryType
+		}
+	default:
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_TEMPLATE_SOURCE", "either query or message_id & query_id are required")
+	}
+
+	template.Description = req.Description
+	template.Defaults = req.Defaults
+	if err := validateQueryTemplate(chat, template); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	if err := s.templateRepo.Create(template); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_CREATE_QUERY_TEMPLATE", "failed to create query template: {error}").With("error", err)
+	}
+	return buildQueryTemplateResponse(template), http.StatusCreated, nil
+}
+
+// List returns the templates of a chat by name
+func (s *queryTemplateService) List(userID, chatID string, page, pageSize int) (*dtos.QueryTemplateListResponse, uint32, error) {
+	chat, statusCode, err := s.verifyChatOwnership(userID, chatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	templates, total, err := s.templateRepo.FindByChatID(chat.ID, page, pageSize)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_QUERY_TEMPLATES", "failed to fetch query templates: {error}").With("error", err)
+	}
+
+	response := &dtos.QueryTemplateListResponse{
+		Templates: make([]dtos.QueryTemplateResponse, 0, len(templates)),
+		Total:     total,
+	}
+	for _, template := range templates {
+		response.Templates = append(response.Templates, *buildQueryTemplateResponse(template))
+	}
+	return response, http.StatusOK, nil
+}
+
+func (s *queryTemplateService) Get(userID, chatID, templateID string) (*dtos.QueryTemplateResponse, uint32, error) {
+	_, template, statusCode, err := s.findTemplate(userID, chatID, templateID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+	return buildQueryTemplateResponse(template), http.StatusOK, nil
+}
+
+// Update changes the fields set, the parameters are found again when the query changes
+func (s *queryTemplateService) Update(userID, chatID, templateID string, req *dtos.UpdateQueryTemplateRequest) (*dtos.QueryTemplateResponse, uint32, error) {
+	chat, template, statusCode, err := s.findTemplate(userID, chatID, templateID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, http.StatusBadRequest, apperrors.New("INVALID_TEMPLATE_NAME", "name is required")
+		}
+		template.Name = name
+	}
+	if req.Description != nil {
+		template.Description = req.Description
+		if strings.TrimSpace(*req.Description) == "" {
+			template.Description = nil
+		}
+	}
+	if req.Query != nil {
+		query := strings.TrimSpace(*req.Query)
+		if query == "" {
+			return nil, http.StatusBadRequest, apperrors.New("INVALID_TEMPLATE_QUERY", "query can't be empty")
+		}
+		template.Query = query
+		template.Parameters = dbmanager.QueryParameterNames(chat.Connection.Type, query)
+		// The tables of the saved query may not be the ones of the new one
+		template.Tables = nil
+	}
+	if req.QueryType != nil {
+		template.QueryType = req.QueryType
+	}
+	if req.Defaults != nil {
+		template.Defaults = req.Defaults
+	}
+	if err := validateQueryTemplate(chat, template); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	if err := s.templateRepo.Update(template); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_UPDATE_QUERY_TEMPLATE", "failed to update query template: {error}").With("error", err)
+	}
+	return buildQueryTemplateResponse(template), http.StatusOK, nil
+}
+
+// Delete removes a template, the messages of its past executions are kept
+func (s *queryTemplateService) Delete(userID, chatID, templateID string) (uint32, error) {
+	_, template, statusCode, err := s.findTemplate(userID, chatID, templateID)
+	if err != nil {
+		return statusCode, err
+	}
+	if err := s.templateRepo.Delete(template.ID); err != nil {
+		return http.StatusInternalServerError, apperrors.New("FAILED_TO_DELETE_QUERY_TEMPLATE", "failed to delete query template: {error}").With("error", err)
+	}
+	return http.StatusOK, nil
+}
+
+// Execute adds a message with a copy of the template to the chat & executes it, the values sent are merged over the defaults
+// of the template and bound by the driver rather than written into the query
+func (s *queryTemplateService) Execute(ctx context.Context, userID, chatID, templateID string, req *dtos.ExecuteQueryTemplateRequest) (*dtos.QueryExecutionResponse, uint32, error) {
+	log.Printf("QueryTemplateService -> Execute -> userID: %s, chatID: %s, templateID: %s", userID, chatID, templateID)
+
+	chat, template, statusCode, err := s.findTemplate(userID, chatID, templateID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	params := make(map[string]interface{}, len(template.Parameters))
+	for name, value := range template.Defaults {
+		params[name] = value
+	}
+	var unknown []string
+	for name, value := range req.Params {
+		if !containsString(template.Parameters, name) {
+			unknown = append(unknown, name)
+			continue
+		}
+		params[name] = value
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, http.StatusBadRequest, apperrors.New("UNKNOWN_QUERY_PARAMETERS", "the template has no parameters named {names}").With("names", strings.Join(unknown, ", "))
+	}
+	var missing []string
+	for _, name := range template.Parameters {
+		if _, ok := params[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, http.StatusBadRequest, apperrors.New("MISSING_QUERY_PARAMETERS", "values are missing for the parameters {names}").With("names", strings.Join(missing, ", "))
+	}
+
+	queryType := "SELECT"
+	if template.QueryType != nil {
+		queryType = *template.QueryType
+	}
+	description := fmt.Sprintf("Execution of the template %s", template.Name)
+	if template.Description != nil {
+		description = *template.Description
+	}
+	query := models.Query{
+		ID:          primitive.NewObjectID(),
+		Query:       template.Query,
+		QueryType:   &queryType,
+		Tables:      template.Tables,
+		Description: description,
+		Params:      params,
+	}
+	content := fmt.Sprintf("Query template **%s** executed at %s.", template.Name, time.Now().UTC().Format(time.RFC1123))
+	msg := models.NewMessage(chat.UserID, chat.ID, string(constants.MessageTypeAssistant), content, &[]models.Query{query}, nil)
+	if err := s.chatRepo.CreateMessage(msg); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_SAVE_MESSAGE", "failed to save message: {error}").With("error", err)
+	}
+
+	return s.chatService.ExecuteQuery(ctx, userID, chatID, &dtos.ExecuteQueryRequest{
+		MessageID:      msg.ID.Hex(),
+		QueryID:        query.ID.Hex(),
+		StreamID:       req.StreamID,
+		UsePrimary:     req.UsePrimary,
+		TimeoutSeconds: req.TimeoutSeconds,
+		Params:         params,
+	})
+}
+
+// validateQueryTemplate checks the database of the chat can bind the parameters & the defaults are ones of the parameters
+func validateQueryTemplate(chat *models.Chat, template *models.QueryTemplate) error {
+	if len(template.Parameters) > 0 && !dbmanager.SupportsQueryParams(chat.Connection.Type) {
+		return apperrors.New("QUERY_PARAMETERS_NOT_SUPPORTED", "the queries of {type} databases can't have parameters").With("type", chat.Connection.Type)
+	}
+	var unknown []string
+	for name := range template.Defaults {
+		if !containsString(template.Parameters, name) {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return apperrors.New("UNKNOWN_QUERY_PARAMETERS", "the defaults name parameters the query doesn't have: {names}").With("names", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+func (s *queryTemplateService) findTemplate(userID, chatID, templateID string) (*models.Chat, *models.QueryTemplate, uint32, error) {
+	chat, statusCode, err := s.verifyChatOwnership(userID, chatID)
+	if err != nil {
+		return nil, nil, statusCode, err
+	}
+
+	templateObjID, err := primitive.ObjectIDFromHex(templateID)
+	if err != nil {
+		return nil, nil, http.StatusBadRequest, apperrors.New("INVALID_TEMPLATE_ID", "invalid template ID format")
+	}
+
+	template, err := s.templateRepo.FindByID(templateObjID)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_QUERY_TEMPLATE", "failed to fetch query template: {error}").With("error", err)
+	}
+	if template == nil || template.ChatID != chat.ID {
+		return nil, nil, http.StatusNotFound, apperrors.New("QUERY_TEMPLATE_NOT_FOUND", "query template not found")
+	}
+	return chat, template, http.StatusOK, nil
+}
+
+func (s *queryTemplateService) verifyChatOwnership(userID, chatID string) (*models.Chat, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+
+	chatObjID, err := primitive.ObjectIDFromHex(chatID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_CHAT_ID", "invalid chat ID format")
+	}
+
+	chat, err := s.chatRepo.FindByID(chatObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CHAT", "failed to fetch chat: {error}").With("error", err)
+	}
+	if chat == nil {
+		return nil, http.StatusNotFound, apperrors.New("CHAT_NOT_FOUND", "chat not found")
+	}
+	if chat.UserID != userObjID {
+		return nil, http.StatusForbidden, apperrors.New("CHAT_ACCESS_DENIED", "chat does not belong to user")
+	}
+	return chat, http.StatusOK, nil
+}
+
+// findQuery returns the query of a message of the chat
+func (s *queryTemplateService) findQuery(chat *models.Chat, messageID, queryID string) (*models.Query, uint32, error) {
+	msgObjID, err := primitive.ObjectIDFromHex(messageID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_MESSAGE_ID", "invalid message ID format")
+	}
+	queryObjID, err := primitive.ObjectIDFromHex(queryID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_QUERY_ID", "invalid query ID format")
+	}
+
+	msg, err := s.chatRepo.FindMessageByID(msgObjID)
+	if err == mongo.ErrNoDocuments || (err == nil && (msg == nil || msg.ChatID != chat.ID)) {
+		return nil, http.StatusNotFound, apperrors.New("MESSAGE_NOT_FOUND", "message not found")
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_MESSAGE", "failed to fetch message: {error}").With("error", err)
+	}
+	if msg.Queries != nil {
+		for i := range *msg.Queries {
+			if (*msg.Queries)[i].ID == queryObjID {
+				return &(*msg.Queries)[i], http.StatusOK, nil
+			}
+		}
+	}
+	return nil, http.StatusNotFound, apperrors.New("QUERY_NOT_FOUND", "query not found")
+}
+
+func buildQueryTemplateResponse(template *models.QueryTemplate) *dtos.QueryTemplateResponse {
+	parameters := template.Parameters
+	if parameters == nil {
+		parameters = []string{}
+	}
+	return &dtos.QueryTemplateResponse{
+		ID:          template.ID.Hex(),
+		ChatID:      template.ChatID.Hex(),
+		Name:        template.Name,
+		Description: template.Description,
+		Query:       template.Query,
+		QueryType:   template.QueryType,
+		Parameters:  parameters,
+		Defaults:    template.Defaults,
+		CreatedAt:   template.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   template.UpdatedAt.Format(time.RFC3339),
+	}
+}
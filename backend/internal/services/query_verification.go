@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"neobase-ai/internal/models"
+	"neobase-ai/pkg/dbmanager"
+	"neobase-ai/pkg/llm"
+	"strings"
+)
+
+// maxQueryPlanCorrections is the number of times the LLM is asked to correct the queries failing to plan
+const maxQueryPlanCorrections = 2
+
+// Errors of ExplainQuery which don't tell whether the query is valid, the query is presented as is
+var unverifiableQueryErrors = map[string]bool{
+	"EXPLAIN_NOT_SUPPORTED": true,
+	"NO_CONNECTION_FOUND":   true,
+	"NO_DRIVER_FOUND":       true,
+	"QUERY_TIMEOUT":         true,
+}
+
+// verifyQueryPlans explains the queries of a response without running them, the LLM is asked to correct the queries failing to plan
+// up to maxQueryPlanCorrections times. The last valid response is kept when the LLM can't correct them, the errors show up on execution.
+func (s *chatService) verifyQueryPlans(ctx context.Context, llmClient llm.Client, messages []*models.LLMMessage, chatID, dbType string, parsed *llmResponse, jsonResponse map[string]interface{}, onCorrection func(attempt int)) (*llmResponse, map[string]interface{}) {
+	for attempt := 1; attempt <= maxQueryPlanCorrections; attempt++ {
+		planErrors := s.queryPlanErrors(ctx, chatID, dbType, parsed)
+		if len(planErrors) == 0 {
+			return parsed, jsonResponse
+		}
+		log.Printf("verifyQueryPlans -> queries failing to plan, correction attempt %d: %v", attempt, planErrors)
+		if onCorrection != nil {
+			onCorrection(attempt)
+		}
+		llm.RecordRetry(llmClient.GetModelInfo(), llm.RetryQueryPlan)
+
+		previousResponse, err := json.Marshal(jsonResponse)
+		if err != nil {
+			return parsed, jsonResponse
+		}
+		planErr := fmt.Errorf("some queries fail to plan on the database, their EXPLAIN returned:\n%s", strings.Join(planErrors, "\n"))
+		corrected, err := repairLLMResponse(ctx, llmClient, messages, dbType, string(previousResponse), planErr)
+		if err != nil {
+			log.Printf("verifyQueryPlans -> failed to correct the queries: %v", err)
+			return parsed, jsonResponse
+		}
+		correctedParsed, correctedJSON, err := generateValidLLMResponse(ctx, llmClient, messages, dbType, corrected, nil)
+		if err != nil {
+			log.Printf("verifyQueryPlans -> invalid corrected response: %v", err)
+			return parsed, jsonResponse
+		}
+		parsed, jsonResponse = correctedParsed, correctedJSON
+	}
+	return parsed, jsonResponse
+}
+
+// queryPlanErrors returns the EXPLAIN error of each query of a response failing to plan.
+// The queries following a write are not verified, they may depend on it, e.g. an insert into a table created before.
+func (s *chatService) queryPlanErrors(ctx context.Context, chatID, dbType string, response *llmResponse) []string {
+	var planErrors []string
+	for i, query := range response.Queries {
+		if query.Query == nil || strings.TrimSpace(*query.Query) == "" {
+			continue
+		}
+
+		_, queryErr := s.dbManager.ExplainQuery(ctx, chatID, *query.Query)
+		if queryErr != nil && !unverifiableQueryErrors[queryErr.Code] {
+			details := queryErr.Details
+			if details == "" {
+				details = queryErr.Message
+			}
+			planErrors = append(planErrors, fmt.Sprintf("- query %d `%s`: %s", i+1, *query.Query, details))
+		}
+		if !dbmanager.IsReadOnlyQuery(dbType, *query.Query) {
+			break
+		}
+	}
+	return planErrors
+}
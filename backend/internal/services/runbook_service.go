@@ -0,0 +1,807 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/models"
+	"neobase-ai/internal/repositories"
+	"neobase-ai/pkg/dbmanager"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	maxRunbookPauseSeconds = 3600       // A pause step can wait at most an hour
+	maxRunbookResultSize   = 64 * 1024  // Step results bigger than this are truncated in the run state
+	runbookStreamIDPrefix  = "runbook-" // Prefix of the stream ID used to track (and cancel) the query executions of a run
+)
+
+type RunbookService interface {
+	Create(userID, chatID string, req *dtos.CreateRunbookRequest) (*dtos.RunbookResponse, uint32, error)
+	CreateFromMessage(userID, chatID string, req *dtos.CreateRunbookFromMessageRequest) (*dtos.RunbookResponse, uint32, error)
+	List(userID, chatID string, page, pageSize int) (*dtos.RunbookListResponse, uint32, error)
+	Get(userID, chatID, runbookID string) (*dtos.RunbookResponse, uint32, error)
+	Update(userID, chatID, runbookID string, req *dtos.UpdateRunbookRequest) (*dtos.RunbookResponse, uint32, error)
+	Delete(userID, chatID, runbookID string) (uint32, error)
+
+	Start(userID, chatID, runbookID string) (*dtos.RunbookRunResponse, uint32, error)
+	ListRuns(userID, chatID, runbookID string, page, pageSize int) (*dtos.RunbookRunListResponse, uint32, error)
+	GetRun(userID, chatID, runID string) (*dtos.RunbookRunResponse, uint32, error)
+	ConfirmStep(userID, chatID, runID string, req *dtos.ConfirmRunbookStepRequest) (*dtos.RunbookRunResponse, uint32, error)
+	Resume(userID, chatID, runID string, req *dtos.ResumeRunbookRunRequest) (*dtos.RunbookRunResponse, uint32, error)
+	Cancel(userID, chatID, runID string) (*dtos.RunbookRunResponse, uint32, error)
+	GetReport(userID, chatID, runID string) (*dtos.RunbookReportResponse, uint32, error)
+}
+
+type runbookService struct {
+	runbookRepo         repositories.RunbookRepository
+	chatRepo            repositories.ChatRepository
+	dbManager           *dbmanager.Manager
+	chatService         ChatService
+	notificationService NotificationService
+
+	// Runs being executed by this instance, the cancel func stops the execution engine
+	activeRuns   map[string]context.CancelFunc
+	activeRunsMu sync.Mutex
+}
+
+func NewRunbookService(runbookRepo repositories.RunbookRepository, chatRepo repositories.ChatRepository, dbManager *dbmanager.Manager, chatService ChatService, notificationService NotificationService) RunbookService {
+	return &runbookService{
+		runbookRepo:         runbookRepo,
+		chatRepo:            chatRepo,
+		dbManager:           dbManager,
+		chatService:         chatService,
+		notificationService: notificationService,
+		activeRuns:          make(map[string]context.CancelFunc),
+	}
+}
+
+// Create creates a runbook from a list of steps
+func (s *runbookService) Create(userID, chatID string, req *dtos.CreateRunbookRequest) (*dtos.RunbookResponse, uint32, error) {
+	log.Printf("RunbookService -> Create -> userID: %s, chatID: %s, steps: %d", userID, chatID, len(req.Steps))
+
+	chat, statusCode, err := s.verifyChatOwnership(userID, chatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	steps, err := buildRunbookSteps(req.Steps)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if err := checkRunbookGuardrail(chat, steps); err != nil {
+		return nil, http.StatusForbidden, err
+	}
+
+	runbook := models.NewRunbook(chat.UserID, chat.ID, strings.TrimSpace(req.Name), req.Description, steps)
+	if err := s.runbookRepo.Create(runbook); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_CREATE_RUNBOOK", "failed to create runbook: {error}").With("error", err)
+	}
+
+	return buildRunbookResponse(runbook), http.StatusCreated, nil
+}
+
+// CreateFromMessage assembles a runbook from the queries the assistant generated in a message,
+// critical queries are preceded by a confirmation step so the user reviews them during the run
+func (s *runbookService) CreateFromMessage(userID, chatID string, req *dtos.CreateRunbookFromMessageRequest) (*dtos.RunbookResponse, uint32, error) {
+	chat, statusCode, err := s.verifyChatOwnership(userID, chatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	msgObjID, err := primitive.ObjectIDFromHex(req.MessageID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_MESSAGE_ID", "invalid message ID format")
+	}
+
+	msg, err := s.chatRepo.FindMessageByID(msgObjID)
+	if err != nil {
+		return nil, http.StatusNotFound, apperrors.New("MESSAGE_NOT_FOUND", "message not found")
+	}
+	if msg.ChatID != chat.ID {
+		return nil, http.StatusForbidden, apperrors.New("MESSAGE_NOT_IN_CHAT", "message does not belong to this chat")
+	}
+	if msg.Queries == nil || len(*msg.Queries) == 0 {
+		return nil, http.StatusBadRequest, apperrors.New("MESSAGE_HAS_NO_QUERIES", "message does not contain any queries")
+	}
+
+	var steps []models.RunbookStep
+	for i := range *msg.Queries {
+		query := (*msg.Queries)[i]
+		name := query.Description
+		if name == "" {
+			name = fmt.Sprintf("Query %d", i+1)
+		}
+
+		if query.IsCritical {
+			message := fmt.Sprintf("Review before running: %s", query.Query)
+			steps = append(steps, models.RunbookStep{
+				ID:      primitive.NewObjectID(),
+				Name:    "Confirm: " + name,
+				Type:    models.RunbookStepTypeConfirmation,
+				Message: &message,
+			})
+		}
+
+		queryText := query.Query
+		steps = append(steps, models.RunbookStep{
+			ID:        primitive.NewObjectID(),
+			Name:      name,
+			Type:      models.RunbookStepTypeQuery,
+			Query:     &queryText,
+			QueryType: query.QueryType,
+		})
+	}
+	if err := checkRunbookGuardrail(chat, steps); err != nil {
+		return nil, http.StatusForbidden, err
+	}
+
+	runbook := models.NewRunbook(chat.UserID, chat.ID, strings.TrimSpace(req.Name), req.Description, steps)
+	if err := s.runbookRepo.Create(runbook); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_CREATE_RUNBOOK", "failed to create runbook: {error}").With("error", err)
+	}
+
+	return buildRunbookResponse(runbook), http.StatusCreated, nil
+}
+
+// List returns the runbooks of a chat
+func (s *runbookService) List(userID, chatID string, page, pageSize int) (*dtos.RunbookListResponse, uint32, error) {
+	chat, statusCode, err := s.verifyChatOwnership(userID, chatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	runbooks, total, err := s.runbookRepo.FindByChatID(chat.ID, page, pageSize)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_RUNBOOKS", "failed to fetch runbooks: {error}").With("error", err)
+	}
+
+	response := &dtos.RunbookListResponse{
+		Runbooks: make([]dtos.RunbookResponse, 0, len(runbooks)),
+		Total:    total,
+	}
+	for _, runbook := range runbooks {
+		response.Runbooks = append(response.Runbooks, *buildRunbookResponse(runbook))
+	}
+	return response, http.StatusOK, nil
+}
+
+// Get returns a runbook
+func (s *runbookService) Get(userID, chatID, runbookID string) (*dtos.RunbookResponse, uint32, error) {
+	runbook, statusCode, err := s.findRunbook(userID, chatID, runbookID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+	return buildRunbookResponse(runbook), http.StatusOK, nil
+}
+
+// Update updates the name, description or steps of a runbook, runs already started keep their own copy of the steps
+func (s *runbookService) Update(userID, chatID, runbookID string, req *dtos.UpdateRunbookRequest) (*dtos.RunbookResponse, uint32, error) {
+	runbook, statusCode, err := s.findRunbook(userID, chatID, runbookID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	if req.Name != nil {
+		if strings.TrimSpace(*req.Name) == "" {
+			return nil, http.StatusBadRequest, apperrors.New("NAME_REQUIRED", "name cannot be empty")
+		}
+		runbook.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		runbook.Description = req.Description
+	}
+	if req.Steps != nil {
+		steps, err := buildRunbookSteps(req.Steps)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		chat, statusCode, err := s.verifyChatOwnership(userID, chatID)
+		if err != nil {
+			return nil, statusCode, err
+		}
+		if err := checkRunbookGuardrail(chat, steps); err != nil {
+			return nil, http.StatusForbidden, err
+		}
+		runbook.Steps = steps
+	}
+
+	if err := s.runbookRepo.Update(runbook.ID, runbook); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_UPDATE_RUNBOOK", "failed to update runbook: {error}").With("error", err)
+	}
+	return buildRunbookResponse(runbook), http.StatusOK, nil
+}
+
+// Delete removes a runbook along with its run history
+func (s *runbookService) Delete(userID, chatID, runbookID string) (uint32, error) {
+	runbook, statusCode, err := s.findRunbook(userID, chatID, runbookID)
+	if err != nil {
+		return statusCode, err
+	}
+
+	if err := s.runbookRepo.DeleteRunsByRunbookID(runbook.ID); err != nil {
+		return http.StatusInternalServerError, apperrors.New("FAILED_TO_DELETE_RUNBOOK_RUNS", "failed to delete runbook runs: {error}").With("error", err)
+	}
+	if err := s.runbookRepo.Delete(runbook.ID); err != nil {
+		return http.StatusInternalServerError, apperrors.New("FAILED_TO_DELETE_RUNBOOK", "failed to delete runbook: {error}").With("error", err)
+	}
+	return http.StatusOK, nil
+}
+
+// Start creates a run of the runbook & starts the execution engine in the background
+func (s *runbookService) Start(userID, chatID, runbookID string) (*dtos.RunbookRunResponse, uint32, error) {
+	runbook, statusCode, err := s.findRunbook(userID, chatID, runbookID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+	if len(runbook.Steps) == 0 {
+		return nil, http.StatusBadRequest, apperrors.New("RUNBOOK_HAS_NO_STEPS", "runbook does not have any steps")
+	}
+
+	run := models.NewRunbookRun(runbook)
+	if err := s.runbookRepo.CreateRun(run); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_CREATE_RUNBOOK_RUN", "failed to create runbook run: {error}").With("error", err)
+	}
+
+	// Build the response before the engine starts updating the run
+	response := buildRunbookRunResponse(run)
+	s.startEngine(run)
+	return response, http.StatusAccepted, nil
+}
+
+// ListRuns returns the run history of a runbook
+func (s *runbookService) ListRuns(userID, chatID, runbookID string, page, pageSize int) (*dtos.RunbookRunListResponse, uint32, error) {
+	runbook, statusCode, err := s.findRunbook(userID, chatID, runbookID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	runs, total, err := s.runbookRepo.FindRunsByRunbookID(runbook.ID, page, pageSize)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_RUNBOOK_RUNS", "failed to fetch runbook runs: {error}").With("error", err)
+	}
+
+	response := &dtos.RunbookRunListResponse{
+		Runs:  make([]dtos.RunbookRunResponse, 0, len(runs)),
+		Total: total,
+	}
+	for _, run := range runs {
+		response.Runs = append(response.Runs, *buildRunbookRunResponse(run))
+	}
+	return response, http.StatusOK, nil
+}
+
+// GetRun returns the current state of a run
+func (s *runbookService) GetRun(userID, chatID, runID string) (*dtos.RunbookRunResponse, uint32, error) {
+	run, statusCode, err := s.findRun(userID, chatID, runID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+	return buildRunbookRunResponse(run), http.StatusOK, nil
+}
+
+// ConfirmStep confirms the manual step the run is waiting on & continues the execution
+func (s *runbookService) ConfirmStep(userID, chatID, runID string, req *dtos.ConfirmRunbookStepRequest) (*dtos.RunbookRunResponse, uint32, error) {
+	run, statusCode, err := s.findRun(userID, chatID, runID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+	if s.isActive(run.ID.Hex()) {
+		return nil, http.StatusConflict, apperrors.New("RUN_ALREADY_EXECUTING", "run is already executing")
+	}
+	if run.Status != models.RunbookRunStatusWaitingConfirmation {
+		return nil, http.StatusConflict, apperrors.New("RUN_NOT_AWAITING_CONFIRMATION", "run is not waiting for a confirmation")
+	}
+
+	now := time.Now()
+	result := &run.StepResults[run.CurrentStep]
+	result.Status = models.RunbookStepStatusSucceeded
+	result.Note = req.Note
+	result.CompletedAt = &now
+
+	run.CurrentStep++
+	run.Status = models.RunbookRunStatusRunning
+	// Only one of concurrent confirmations moves the run on, the others find it running already
+	updated, err := s.runbookRepo.UpdateRunIfStatus(run, models.RunbookRunStatusWaitingConfirmation)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_UPDATE_RUNBOOK_RUN", "failed to update runbook run: {error}").With("error", err)
+	}
+	if !updated {
+		return nil, http.StatusConflict, apperrors.New("RUN_NOT_AWAITING_CONFIRMATION", "run is not waiting for a confirmation")
+	}
+
+	// Build the response before the engine starts updating the run
+	response := buildRunbookRunResponse(run)
+	s.startEngine(run)
+	return response, http.StatusAccepted, nil
+}
+
+// Resume continues a failed run, or a run interrupted by a server restart, from the step it stopped at
+func (s *runbookService) Resume(userID, chatID, runID string, req *dtos.ResumeRunbookRunRequest) (*dtos.RunbookRunResponse, uint32, error) {
+	run, statusCode, err := s.findRun(userID, chatID, runID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+	if s.isActive(run.ID.Hex()) {
+		return nil, http.StatusConflict, apperrors.New("RUN_ALREADY_EXECUTING", "run is already executing")
+	}
+	if run.Status != models.RunbookRunStatusFailed && run.Status != models.RunbookRunStatusRunning {
+		return nil, http.StatusConflict, apperrors.New("RUN_NOT_RESUMABLE", "only failed or interrupted runs can be resumed")
+	}
+
+	if req.SkipFailedStep && run.Status == models.RunbookRunStatusFailed {
+		note := "Skipped by user after failure"
+		run.StepResults[run.CurrentStep].Status = models.RunbookStepStatusSkipped
+		run.StepResults[run.CurrentStep].Note = &note
+		run.CurrentStep++
+	}
+
+	run.Status = models.RunbookRunStatusRunning
+	run.CompletedAt = nil
+	if err := s.runbookRepo.UpdateRun(run); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_UPDATE_RUNBOOK_RUN", "failed to update runbook run: {error}").With("error", err)
+	}
+
+	// Build the response before the engine starts updating the run
+	response := buildRunbookRunResponse(run)
+	s.startEngine(run)
+	return response, http.StatusAccepted, nil
+}
+
+// Cancel stops a run, a step being executed is cancelled & the engine records the cancellation
+func (s *runbookService) Cancel(userID, chatID, runID string) (*dtos.RunbookRunResponse, uint32, error) {
+	run, statusCode, err := s.findRun(userID, chatID, runID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+	if run.IsFinished() {
+		return nil, http.StatusConflict, apperrors.New("RUN_ALREADY_FINISHED", "run has already finished")
+	}
+
+	s.activeRunsMu.Lock()
+	cancel, active := s.activeRuns[run.ID.Hex()]
+	s.activeRunsMu.Unlock()
+
+	if active {
+		cancel()
+		s.dbManager.CancelQueryExecution(runbookStreamIDPrefix + run.ID.Hex())
+		run.Status = models.RunbookRunStatusCancelled
+		return buildRunbookRunResponse(run), http.StatusAccepted, nil
+	}
+
+	// The run is waiting for a confirmation or has failed, nothing is executing
+	now := time.Now()
+	run.Status = models.RunbookRunStatusCancelled
+	run.CompletedAt = &now
+	if err := s.runbookRepo.UpdateRun(run); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_UPDATE_RUNBOOK_RUN", "failed to update runbook run: {error}").With("error", err)
+	}
+	return buildRunbookRunResponse(run), http.StatusOK, nil
+}
+
+// GetReport builds the report of a run with step counts & a markdown summary
+func (s *runbookService) GetReport(userID, chatID, runID string) (*dtos.RunbookReportResponse, uint32, error) {
+	run, statusCode, err := s.findRun(userID, chatID, runID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	report := &dtos.RunbookReportResponse{
+		RunbookRunResponse: *buildRunbookRunResponse(run),
+		TotalSteps:         len(run.Steps),
+	}
+
+	var summary strings.Builder
+	summary.WriteString(fmt.Sprintf("## Runbook report: %s\n\n", run.RunbookName))
+	summary.WriteString(fmt.Sprintf("Status: **%s**\n\n", run.Status))
+
+	for i, step := range run.Steps {
+		result := run.StepResults[i]
+		switch result.Status {
+		case models.RunbookStepStatusSucceeded:
+			report.Succeeded++
+		case models.RunbookStepStatusFailed:
+			report.Failed++
+		case models.RunbookStepStatusSkipped:
+			report.Skipped++
+		default:
+			report.Pending++
+		}
+
+		line := fmt.Sprintf("%d. %s (%s): %s", i+1, step.Name, step.Type, result.Status)
+		if result.ExecutionTime != nil {
+			line += fmt.Sprintf(", %dms", *result.ExecutionTime)
+		}
+		if result.Attempts > 1 {
+			line += fmt.Sprintf(", %d attempts", result.Attempts)
+		}
+		if result.Error != nil {
+			line += fmt.Sprintf(", error: %s", result.Error.Message)
+		}
+		if result.Note != nil {
+			line += fmt.Sprintf(", note: %s", *result.Note)
+		}
+		summary.WriteString(line + "\n\n")
+	}
+
+	endTime := time.Now()
+	if run.CompletedAt != nil {
+		endTime = *run.CompletedAt
+	}
+	report.Duration = endTime.Sub(run.CreatedAt).Milliseconds()
+
+	summary.WriteString(fmt.Sprintf("%d succeeded, %d failed, %d skipped, %d pending in %s",
+		report.Succeeded, report.Failed, report.Skipped, report.Pending, endTime.Sub(run.CreatedAt).Round(time.Second)))
+	report.Summary = summary.String()
+
+	return report, http.StatusOK, nil
+}
+
+// startEngine executes the run's steps in the background until it completes, fails or waits for a confirmation
+func (s *runbookService) startEngine(run *models.RunbookRun) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s.activeRunsMu.Lock()
+	s.activeRuns[run.ID.Hex()] = cancel
+	s.activeRunsMu.Unlock()
+
+	go func() {
+		defer func() {
+			s.activeRunsMu.Lock()
+			delete(s.activeRuns, run.ID.Hex())
+			s.activeRunsMu.Unlock()
+			cancel()
+		}()
+		s.executeRun(ctx, run)
+	}()
+}
+
+// executeRun walks the steps from the current step, persisting the run state after every step
+func (s *runbookService) executeRun(ctx context.Context, run *models.RunbookRun) {
+	log.Printf("RunbookService -> executeRun -> Starting run %s of runbook %s at step %d", run.ID.Hex(), run.RunbookName, run.CurrentStep)
+
+	for run.CurrentStep < len(run.Steps) {
+		step := run.Steps[run.CurrentStep]
+		result := &run.StepResults[run.CurrentStep]
+
+		if step.Type == models.RunbookStepTypeConfirmation {
+			result.Status = models.RunbookStepStatusWaiting
+			run.Status = models.RunbookRunStatusWaitingConfirmation
+			s.saveRun(run)
+			log.Printf("RunbookService -> executeRun -> Run %s waiting for confirmation of step %s", run.ID.Hex(), step.Name)
+			s.notificationService.Notify(run.UserID.Hex(), run.ChatID.Hex(), models.NotificationTypeApprovalRequested,
+				fmt.Sprintf("Runbook %s needs your confirmation", run.RunbookName),
+				fmt.Sprintf("Step %s is waiting for confirmation before the run continues", step.Name),
+				map[string]interface{}{"runbook_id": run.RunbookID.Hex(), "run_id": run.ID.Hex(), "step": run.CurrentStep})
+			return
+		}
+
+		now := time.Now()
+		result.Status = models.RunbookStepStatusRunning
+		result.Attempts++
+		result.StartedAt = &now
+		result.Error = nil
+		s.saveRun(run)
+
+		stepErr := s.executeStep(ctx, run, step, result)
+		completedAt := time.Now()
+		result.CompletedAt = &completedAt
+
+		if ctx.Err() != nil {
+			// Cancelled by the user
+			result.Status = models.RunbookStepStatusFailed
+			result.Error = &models.QueryError{Code: "RUN_CANCELLED", Message: "run cancelled", Details: "Run cancelled by the user"}
+			run.Status = models.RunbookRunStatusCancelled
+			run.CompletedAt = &completedAt
+			s.saveRun(run)
+			return
+		}
+
+		if stepErr != nil {
+			result.Status = models.RunbookStepStatusFailed
+			result.Error = stepErr
+			log.Printf("RunbookService -> executeRun -> Step %s of run %s failed: %s", step.Name, run.ID.Hex(), stepErr.Message)
+			if !step.ContinueOnError {
+				run.Status = models.RunbookRunStatusFailed
+				s.saveRun(run)
+				return
+			}
+		} else {
+			result.Status = models.RunbookStepStatusSucceeded
+		}
+
+		run.CurrentStep++
+		s.saveRun(run)
+	}
+
+	completedAt := time.Now()
+	run.Status = models.RunbookRunStatusCompleted
+	run.CompletedAt = &completedAt
+	s.saveRun(run)
+	log.Printf("RunbookService -> executeRun -> Run %s completed", run.ID.Hex())
+	s.notificationService.Notify(run.UserID.Hex(), run.ChatID.Hex(), models.NotificationTypeReportReady,
+		fmt.Sprintf("Runbook %s completed", run.RunbookName),
+		"The report of the run is ready",
+		map[string]interface{}{"runbook_id": run.RunbookID.Hex(), "run_id": run.ID.Hex()})
+}
+
+// executeStep executes a single pause, query or health check step
+func (s *runbookService) executeStep(ctx context.Context, run *models.RunbookRun, step models.RunbookStep, result *models.RunbookStepResult) *models.QueryError {
+	switch step.Type {
+	case models.RunbookStepTypePause:
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Duration(*step.PauseSeconds) * time.Second):
+		}
+		return nil
+
+	case models.RunbookStepTypeQuery, models.RunbookStepTypeHealthCheck:
+		// The guardrail is checked again, the chat may have disallowed destructive queries since the runbook was saved
+		chat, err := s.chatRepo.FindByID(run.ChatID)
+		if err != nil || chat == nil {
+			return &models.QueryError{Code: "CHAT_NOT_FOUND", Message: "failed to fetch chat", Details: fmt.Sprintf("Chat %s not found: %v", run.ChatID.Hex(), err)}
+		}
+		if reason := dbmanager.DestructiveQueryReason(chat.Connection.Type, *step.Query); reason != "" && !chat.Settings.AllowDestructiveQueries {
+			return &models.QueryError{Code: "DESTRUCTIVE_QUERY_BLOCKED", Message: "query blocked by the guardrail", Details: fmt.Sprintf("%s, allow destructive queries in the chat settings to run it", reason)}
+		}
+
+		if !s.dbManager.IsConnected(run.ChatID.Hex()) {
+			if _, err := s.chatService.ConnectDB(ctx, run.UserID.Hex(), run.ChatID.Hex(), runbookStreamIDPrefix+run.ID.Hex()); err != nil {
+				return &models.QueryError{Code: "CONNECTION_FAILED", Message: "failed to connect to database", Details: err.Error()}
+			}
+			// Give a small delay for connection to stabilize
+			time.Sleep(1 * time.Second)
+		}
+
+		queryType := "SELECT"
+		if step.QueryType != nil {
+			queryType = *step.QueryType
+		}
+
+		// Steps read what the previous steps wrote, a lagging replica would miss it
+		execResult, queryErr := s.dbManager.ExecuteQuery(dbmanager.WithPrimaryRouting(ctx), run.ChatID.Hex(), "", step.ID.Hex(), runbookStreamIDPrefix+run.ID.Hex(), *step.Query, queryType, false, false)
+		if execResult != nil {
+			executionTime := execResult.ExecutionTime
+			result.ExecutionTime = &executionTime
+			resultJSON := execResult.ResultJSON
+			if len(resultJSON) > maxRunbookResultSize {
+				resultJSON = resultJSON[:maxRunbookResultSize]
+			}
+			result.Result = &resultJSON
+		}
+		if queryErr != nil {
+			return &models.QueryError{Code: queryErr.Code, Message: queryErr.Message, Details: queryErr.Details}
+		}
+
+		if step.Type == models.RunbookStepTypeHealthCheck {
+			rows := dbmanager.CountResultRows(execResult)
+			if step.ExpectEmpty && rows > 0 {
+				return &models.QueryError{Code: "HEALTH_CHECK_FAILED", Message: "health check failed", Details: fmt.Sprintf("Expected no rows but the query returned %d row(s)", rows)}
+			}
+			if !step.ExpectEmpty && rows == 0 {
+				return &models.QueryError{Code: "HEALTH_CHECK_FAILED", Message: "health check failed", Details: "Expected at least one row but the query returned none"}
+			}
+		}
+		return nil
+	}
+
+	return &models.QueryError{Code: "INVALID_STEP", Message: "invalid step type", Details: "Unsupported step type: " + step.Type}
+}
+
+func (s *runbookService) saveRun(run *models.RunbookRun) {
+	if err := s.runbookRepo.UpdateRun(run); err != nil {
+		log.Printf("RunbookService -> saveRun -> Failed to save run %s: %v", run.ID.Hex(), err)
+	}
+}
+
+func (s *runbookService) isActive(runID string) bool {
+	s.activeRunsMu.Lock()
+	defer s.activeRunsMu.Unlock()
+	_, active := s.activeRuns[runID]
+	return active
+}
+
+func (s *runbookService) findRunbook(userID, chatID, runbookID string) (*models.Runbook, uint32, error) {
+	chat, statusCode, err := s.verifyChatOwnership(userID, chatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	runbookObjID, err := primitive.ObjectIDFromHex(runbookID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_RUNBOOK_ID", "invalid runbook ID format")
+	}
+
+	runbook, err := s.runbookRepo.FindByID(runbookObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_RUNBOOK", "failed to fetch runbook: {error}").With("error", err)
+	}
+	if runbook == nil || runbook.ChatID != chat.ID {
+		return nil, http.StatusNotFound, apperrors.New("RUNBOOK_NOT_FOUND", "runbook not found")
+	}
+	return runbook, http.StatusOK, nil
+}
+
+func (s *runbookService) findRun(userID, chatID, runID string) (*models.RunbookRun, uint32, error) {
+	chat, statusCode, err := s.verifyChatOwnership(userID, chatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	runObjID, err := primitive.ObjectIDFromHex(runID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_RUN_ID", "invalid run ID format")
+	}
+
+	run, err := s.runbookRepo.FindRunByID(runObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_RUNBOOK_RUN", "failed to fetch runbook run: {error}").With("error", err)
+	}
+	if run == nil || run.ChatID != chat.ID {
+		return nil, http.StatusNotFound, apperrors.New("RUNBOOK_RUN_NOT_FOUND", "runbook run not found")
+	}
+	return run, http.StatusOK, nil
+}
+
+func (s *runbookService) verifyChatOwnership(userID, chatID string) (*models.Chat, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+
+	chatObjID, err := primitive.ObjectIDFromHex(chatID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_CHAT_ID", "invalid chat ID format")
+	}
+
+	chat, err := s.chatRepo.FindByID(chatObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CHAT", "failed to fetch chat: {error}").With("error", err)
+	}
+	if chat == nil {
+		return nil, http.StatusNotFound, apperrors.New("CHAT_NOT_FOUND", "chat not found")
+	}
+	if chat.UserID != userObjID {
+		return nil, http.StatusForbidden, apperrors.New("CHAT_ACCESS_DENIED", "chat does not belong to user")
+	}
+	return chat, http.StatusOK, nil
+}
+
+// buildRunbookSteps validates the requested steps & converts them into runbook steps
+func buildRunbookSteps(reqSteps []dtos.RunbookStepRequest) ([]models.RunbookStep, error) {
+	if len(reqSteps) == 0 {
+		return nil, apperrors.New("RUNBOOK_STEPS_REQUIRED", "runbook must have at least one step")
+	}
+
+	steps := make([]models.RunbookStep, 0, len(reqSteps))
+	for i, reqStep := range reqSteps {
+		switch reqStep.Type {
+		case models.RunbookStepTypeQuery, models.RunbookStepTypeHealthCheck:
+			if reqStep.Query == nil || strings.TrimSpace(*reqStep.Query) == "" {
+				return nil, apperrors.New("RUNBOOK_STEP_QUERY_REQUIRED", "step {step}: query is required for {type} steps").With("step", i+1).With("type", reqStep.Type)
+			}
+		case models.RunbookStepTypePause:
+			if reqStep.PauseSeconds == nil || *reqStep.PauseSeconds <= 0 || *reqStep.PauseSeconds > maxRunbookPauseSeconds {
+				return nil, apperrors.New("INVALID_RUNBOOK_STEP_PAUSE", "step {step}: pause_seconds must be between 1 and {max}").With("step", i+1).With("max", maxRunbookPauseSeconds)
+			}
+		}
+
+		steps = append(steps, models.RunbookStep{
+			ID:              primitive.NewObjectID(),
+			Name:            strings.TrimSpace(reqStep.Name),
+			Type:            reqStep.Type,
+			Query:           reqStep.Query,
+			QueryType:       reqStep.QueryType,
+			PauseSeconds:    reqStep.PauseSeconds,
+			Message:         reqStep.Message,
+			ExpectEmpty:     reqStep.ExpectEmpty,
+			ContinueOnError: reqStep.ContinueOnError,
+		})
+	}
+	return steps, nil
+}
+
+// checkRunbookGuardrail refuses the steps running a destructive query unless the chat allows destructive queries
+func checkRunbookGuardrail(chat *models.Chat, steps []models.RunbookStep) error {
+	if chat.Settings.AllowDestructiveQueries {
+		return nil
+	}
+	for i, step := range steps {
+		if step.Query == nil {
+			continue
+		}
+		if reason := dbmanager.DestructiveQueryReason(chat.Connection.Type, *step.Query); reason != "" {
+			return apperrors.New("DESTRUCTIVE_QUERY_BLOCKED", "step {step}: query blocked by the guardrail: {reason}, allow destructive queries in the chat settings to run it").With("step", i+1).With("reason", reason)
+		}
+	}
+	return nil
+}
+
+func buildRunbookResponse(runbook *models.Runbook) *dtos.RunbookResponse {
+	steps := make([]dtos.RunbookStepResponse, 0, len(runbook.Steps))
+	for _, step := range runbook.Steps {
+		steps = append(steps, dtos.RunbookStepResponse{
+			ID:              step.ID.Hex(),
+			Name:            step.Name,
+			Type:            step.Type,
+			Query:           step.Query,
+			QueryType:       step.QueryType,
+			PauseSeconds:    step.PauseSeconds,
+			Message:         step.Message,
+			ExpectEmpty:     step.ExpectEmpty,
+			ContinueOnError: step.ContinueOnError,
+		})
+	}
+
+	return &dtos.RunbookResponse{
+		ID:          runbook.ID.Hex(),
+		ChatID:      runbook.ChatID.Hex(),
+		Name:        runbook.Name,
+		Description: runbook.Description,
+		Steps:       steps,
+		CreatedAt:   runbook.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   runbook.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func buildRunbookRunResponse(run *models.RunbookRun) *dtos.RunbookRunResponse {
+	formatTime := func(t *time.Time) *string {
+		if t == nil {
+			return nil
+		}
+		formatted := t.Format(time.RFC3339)
+		return &formatted
+	}
+
+	steps := make([]dtos.RunbookStepResultResponse, 0, len(run.StepResults))
+	for i, result := range run.StepResults {
+		step := run.Steps[i]
+
+		var stepResult interface{}
+		if result.Result != nil {
+			if err := json.Unmarshal([]byte(*result.Result), &stepResult); err != nil {
+				// Truncated results are not valid JSON anymore
+				stepResult = *result.Result
+			}
+		}
+
+		var stepErr *dtos.QueryError
+		if result.Error != nil {
+			stepErr = &dtos.QueryError{Code: result.Error.Code, Message: result.Error.Message, Details: result.Error.Details}
+		}
+
+		steps = append(steps, dtos.RunbookStepResultResponse{
+			StepID:        step.ID.Hex(),
+			Name:          step.Name,
+			Type:          step.Type,
+			Status:        result.Status,
+			Attempts:      result.Attempts,
+			Result:        stepResult,
+			Error:         stepErr,
+			Note:          result.Note,
+			Message:       step.Message,
+			ExecutionTime: result.ExecutionTime,
+			StartedAt:     formatTime(result.StartedAt),
+			CompletedAt:   formatTime(result.CompletedAt),
+		})
+	}
+
+	return &dtos.RunbookRunResponse{
+		ID:          run.ID.Hex(),
+		RunbookID:   run.RunbookID.Hex(),
+		RunbookName: run.RunbookName,
+		Status:      run.Status,
+		CurrentStep: run.CurrentStep,
+		Steps:       steps,
+		StartedAt:   run.CreatedAt.Format(time.RFC3339),
+		CompletedAt: formatTime(run.CompletedAt),
+	}
+}
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/models"
+	"neobase-ai/internal/repositories"
+	"neobase-ai/internal/utils"
+	"neobase-ai/pkg/dbmanager"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SavedConnectionService interface {
+	Create(userID string, req *dtos.CreateSavedConnectionRequest) (*dtos.SavedConnectionResponse, uint32, error)
+	CreateFromChat(userID string, req *dtos.SaveChatConnectionRequest) (*dtos.SavedConnectionResponse, uint32, error)
+	List(userID string, page, pageSize int) (*dtos.SavedConnectionListResponse, uint32, error)
+	Get(userID, connectionID string) (*dtos.SavedConnectionResponse, uint32, error)
+	Update(userID, connectionID string, req *dtos.UpdateSavedConnectionRequest) (*dtos.SavedConnectionResponse, uint32, error)
+	Delete(userID, connectionID string) (uint32, error)
+}
+
+type savedConnectionService struct {
+	savedConnectionRepo repositories.SavedConnectionRepository
+	chatRepo            repositories.ChatRepository
+	dbManager           *dbmanager.Manager
+}
+
+func NewSavedConnectionService(savedConnectionRepo repositories.SavedConnectionRepository, chatRepo repositories.ChatRepository, dbManager *dbmanager.Manager) SavedConnectionService {
+	return &savedConnectionService{
+		savedConnectionRepo: savedConnectionRepo,
+		chatRepo:            chatRepo,
+		dbManager:           dbManager,
+	}
+}
+
+// Create tests & saves a connection, chats are then created with its ID
+func (s *savedConnectionService) Create(userID string, req *dtos.CreateSavedConnectionRequest) (*dtos.SavedConnectionResponse, uint32, error) {
+	log.Printf("SavedConnectionService -> Create -> userID: %s, type: %s", userID, req.Connection.Type)
+
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, http.StatusBadRequest, apperrors.New("NAME_REQUIRED", "name cannot be empty")
+	}
+
+	connection, statusCode, err := s.testAndEncrypt(&req.Connection)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	savedConnection := models.NewSavedConnection(userObjID, strings.TrimSpace(req.Name), connection)
+	if err := s.savedConnectionRepo.Create(savedConnection); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_CREATE_CONNECTION", "failed to create connection: {error}").With("error", err)
+	}
+	return buildSavedConnectionResponse(savedConnection, 0), http.StatusCreated, nil
+}
+
+// CreateFromChat moves the connection embedded in a chat to a saved connection, other chats can then use it
+func (s *savedConnectionService) CreateFromChat(userID string, req *dtos.SaveChatConnectionRequest) (*dtos.SavedConnectionResponse, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, http.StatusBadRequest, apperrors.New("NAME_REQUIRED", "name cannot be empty")
+	}
+
+	chatObjID, err := primitive.ObjectIDFromHex(req.ChatID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_CHAT_ID", "invalid chat ID format")
+	}
+	chat, err := s.chatRepo.FindByID(chatObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CHAT", "failed to fetch chat: {error}").With("error", err)
+	}
+	if chat == nil {
+		return nil, http.StatusNotFound, apperrors.New("CHAT_NOT_FOUND", "chat not found")
+	}
+	if chat.UserID != userObjID {
+		return nil, http.StatusForbidden, apperrors.New("CHAT_ACCESS_DENIED", "chat does not belong to user")
+	}
+	if chat.ConnectionID != nil {
+		return nil, http.StatusConflict, apperrors.New("CHAT_USES_SAVED_CONNECTION", "chat already uses a saved connection")
+	}
+
+	// The embedded connection is already encrypted
+	savedConnection := models.NewSavedConnection(userObjID, strings.TrimSpace(req.Name), chat.Connection)
+	if err := s.savedConnectionRepo.Create(savedConnection); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_CREATE_CONNECTION", "failed to create connection: {error}").With("error", err)
+	}
+
+	chat.ConnectionID = &savedConnection.ID
+	if err := s.chatRepo.Update(chat.ID, chat); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_UPDATE_CHAT", "failed to update chat: {error}").With("error", err)
+	}
+	return buildSavedConnectionResponse(savedConnection, 1), http.StatusCreated, nil
+}
+
+// List returns the saved connections of the user
+func (s *savedConnectionService) List(userID string, page, pageSize int) (*dtos.SavedConnectionListResponse, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+
+	connections, total, err := s.savedConnectionRepo.FindByUserID(userObjID, page, pageSize)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CONNECTIONS", "failed to fetch connections: {error}").With("error", err)
+	}
+
+	response := &dtos.SavedConnectionListResponse{
+		Connections: make([]dtos.SavedConnectionResponse, 0, len(connections)),
+		Total:       total,
+	}
+	for _, connection := range connections {
+		chatCount, err := s.chatRepo.CountByConnectionID(connection.ID)
+		if err != nil {
+			return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CONNECTIONS", "failed to count chats of connection: {error}").With("error", err)
+		}
+		response.Connections = append(response.Connections, *buildSavedConnectionResponse(connection, chatCount))
+	}
+	return response, http.StatusOK, nil
+}
+
+// Get returns a saved connection
+func (s *savedConnectionService) Get(userID, connectionID string) (*dtos.SavedConnectionResponse, uint32, error) {
+	savedConnection, statusCode, err := s.findSavedConnection(userID, connectionID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	chatCount, err := s.chatRepo.CountByConnectionID(savedConnection.ID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CONNECTION", "failed to count chats of connection: {error}").With("error", err)
+	}
+	return buildSavedConnectionResponse(savedConnection, chatCount), http.StatusOK, nil
+}
+
+// Update renames a saved connection or updates its credentials for all the chats using it.
+// When the credentials change, the chats are disconnected & their selected collections reset like a chat's own connection update.
+func (s *savedConnectionService) Update(userID, connectionID string, req *dtos.UpdateSavedConnectionRequest) (*dtos.SavedConnectionResponse, uint32, error) {
+	savedConnection, statusCode, err := s.findSavedConnection(userID, connectionID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	if req.Name != nil {
+		if strings.TrimSpace(*req.Name) == "" {
+			return nil, http.StatusBadRequest, apperrors.New("NAME_REQUIRED", "name cannot be empty")
+		}
+		savedConnection.Name = strings.TrimSpace(*req.Name)
+	}
+
+	var credentialsChanged bool
+	if req.Connection != nil {
+		// Create a copy of the existing connection and decrypt it for comparison
+		existingConn := savedConnection.Connection
+		utils.DecryptConnection(&existingConn)
+		restoreRedactedProxyURL(existingConn, req.Connection)
+		credentialsChanged = connectionCredentialsChanged(existingConn, req.Connection)
+
+		connection, statusCode, err := s.testAndEncrypt(req.Connection)
+		if err != nil {
+			return nil, statusCode, err
+		}
+		savedConnection.Connection = connection
+	}
+
+	if err := s.savedConnectionRepo.Update(savedConnection.ID, savedConnection); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_UPDATE_CONNECTION", "failed to update connection: {error}").With("error", err)
+	}
+
+	chats, err := s.chatRepo.FindByConnectionID(savedConnection.ID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CHATS", "failed to fetch chats of connection: {error}").With("error", err)
+	}
+
+	if credentialsChanged {
+		log.Printf("SavedConnectionService -> Update -> Critical connection details changed, disconnecting %d chats", len(chats))
+		for _, chat := range chats {
+			if err := s.dbManager.Disconnect(chat.ID.Hex(), userID, true); err != nil {
+				log.Printf("SavedConnectionService -> Update -> Warning: Failed to disconnect chat %s: %v", chat.ID.Hex(), err)
+			}
+			chat.SelectedCollections = ""
+			if err := s.chatRepo.Update(chat.ID, chat); err != nil {
+				log.Printf("SavedConnectionService -> Update -> Warning: Failed to reset selected collections of chat %s: %v", chat.ID.Hex(), err)
+			}
+		}
+	}
+
+	return buildSavedConnectionResponse(savedConnection, int64(len(chats))), http.StatusOK, nil
+}
+
+// Delete removes a saved connection, it must not be used by any chat
+func (s *savedConnectionService) Delete(userID, connectionID string) (uint32, error) {
+	savedConnection, statusCode, err := s.findSavedConnection(userID, connectionID)
+	if err != nil {
+		return statusCode, err
+	}
+
+	// The chats using the connection keep a copy of it. They're detached again once it's deleted, for a chat created with the
+	// connection in the meantime.
+	detached, err := s.chatRepo.DetachConnection(savedConnection.ID, savedConnection.Connection)
+	if err != nil {
+		return http.StatusInternalServerError, apperrors.New("FAILED_TO_DELETE_CONNECTION", "failed to detach the chats of connection: {error}").With("error", err)
+	}
+	if err := s.savedConnectionRepo.Delete(savedConnection.ID); err != nil {
+		return http.StatusInternalServerError, apperrors.New("FAILED_TO_DELETE_CONNECTION", "failed to delete connection: {error}").With("error", err)
+	}
+	late, err := s.chatRepo.DetachConnection(savedConnection.ID, savedConnection.Connection)
+	if err != nil {
+		log.Printf("SavedConnectionService -> Delete -> Failed to detach the chats of connection %s: %v", savedConnection.ID.Hex(), err)
+	}
+	log.Printf("SavedConnectionService -> Delete -> Deleted connection %s, detached %d chat(s)", savedConnection.ID.Hex(), detached+late)
+	return http.StatusOK, nil
+}
+
+// testAndEncrypt tests a requested connection & returns it encrypted for storage
+func (s *savedConnectionService) testAndEncrypt(req *dtos.CreateConnectionRequest) (models.Connection, uint32, error) {
+	if !isValidDBType(req.Type) {
+		return models.Connection{}, http.StatusBadRequest, apperrors.New("UNSUPPORTED_DATABASE_TYPE", "unsupported database type: {type}").With("type", req.Type)
+	}
+
+	// Test connection without creating a persistent connection
+	if err := s.dbManager.TestConnection(connectionConfigFromRequest(req)); err != nil {
+		return models.Connection{}, http.StatusBadRequest, apperrors.New("CONNECTION_TEST_FAILED", "{error}").With("error", err)
+	}
+
+	connection := connectionFromRequest(req)
+	if err := utils.EncryptConnection(&connection); err != nil {
+		log.Printf("Warning: Failed to encrypt connection details: %v", err)
+		return models.Connection{}, http.StatusInternalServerError, apperrors.New("FAILED_TO_SECURE_CONNECTION_DETAILS", "failed to secure connection details: {error}").With("error", err)
+	}
+	return connection, http.StatusOK, nil
+}
+
+func (s *savedConnectionService) findSavedConnection(userID, connectionID string) (*models.SavedConnection, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+
+	connectionObjID, err := primitive.ObjectIDFromHex(connectionID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_CONNECTION_ID", "invalid connection ID format")
+	}
+
+	savedConnection, err := s.savedConnectionRepo.FindByID(connectionObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CONNECTION", "failed to fetch connection: {error}").With("error", err)
+	}
+	if savedConnection == nil || savedConnection.UserID != userObjID {
+		return nil, http.StatusNotFound, apperrors.New("CONNECTION_NOT_FOUND", "connection not found")
+	}
+	return savedConnection, http.StatusOK, nil
+}
+
+func buildSavedConnectionResponse(savedConnection *models.SavedConnection, chatCount int64) *dtos.SavedConnectionResponse {
+	return &dtos.SavedConnectionResponse{
+		ID:         savedConnection.ID.Hex(),
+		Name:       savedConnection.Name,
+		Connection: buildConnectionResponse(savedConnection.ID.Hex(), savedConnection.Connection),
+		ChatCount:  chatCount,
+		CreatedAt:  savedConnection.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:  savedConnection.UpdatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,801 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"mime"
+	"neobase-ai/config"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/constants"
+	"neobase-ai/internal/models"
+	"neobase-ai/internal/repositories"
+	"neobase-ai/internal/utils"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	scheduledQueryStreamIDPrefix = "scheduled-query-" // Prefix of the stream ID the query of a schedule is executed with
+	scheduledQueryBatchSize      = 100                // Due schedules read per poll
+	scheduledQueryMinInterval    = time.Minute        // Shortest time allowed between two runs of a schedule
+	scheduledQueryWebhookTimeout = 10 * time.Second
+	scheduledQueryEmailTimeout   = 30 * time.Second
+	scheduledQueryMaxEmailTo     = 10        // Recipients of the emails of a schedule at most
+	scheduledQueryEmailMaxResult = 64 * 1024 // Bytes of the result included in an email at most
+)
+
+type ScheduledQueryService interface {
+	Create(userID string, req *dtos.CreateScheduledQueryRequest) (*dtos.ScheduledQueryResponse, uint32, error)
+	List(userID, chatID string, page, pageSize int) (*dtos.ScheduledQueryListResponse, uint32, error)
+	Get(userID, scheduleID string) (*dtos.ScheduledQueryResponse, uint32, error)
+	Update(userID, scheduleID string, req *dtos.UpdateScheduledQueryRequest) (*dtos.ScheduledQueryResponse, uint32, error)
+	Delete(userID, scheduleID string) (uint32, error)
+}
+
+type scheduledQueryService struct {
+	scheduleRepo repositories.ScheduledQueryRepository
+	chatRepo     repositories.ChatRepository
+	bookmarkRepo repositories.BookmarkRepository
+	chatService  ChatService
+
+	webhookClient *http.Client
+
+	// Runs executing on this instance per connection, capped at SCHEDULED_QUERY_MAX_RUNS_PER_CONNECTION
+	activeRuns   map[string]int
+	activeRunsMu sync.Mutex
+}
+
+// NewScheduledQueryService starts the scheduler polling the due schedules every SCHEDULED_QUERY_POLL_SECONDS
+func NewScheduledQueryService(scheduleRepo repositories.ScheduledQueryRepository, chatRepo repositories.ChatRepository, bookmarkRepo repositories.BookmarkRepository, chatService ChatService) ScheduledQueryService {
+	s := &scheduledQueryService{
+		scheduleRepo:  scheduleRepo,
+		chatRepo:      chatRepo,
+		bookmarkRepo:  bookmarkRepo,
+		chatService:   chatService,
+		webhookClient: newWebhookClient(),
+		activeRuns:    make(map[string]int),
+	}
+	go s.scheduler()
+	return s
+}
+
+// Create schedules a bookmarked query or a query of a message of the chat, each run adds a message with the result to the chat
+func (s *scheduledQueryService) Create(userID string, req *dtos.CreateScheduledQueryRequest) (*dtos.ScheduledQueryResponse, uint32, error) {
+	log.Printf("ScheduledQueryService -> Create -> userID: %s, chatID: %s, cron: %s", userID, req.ChatID, req.Cron)
+
+	chat, statusCode, err := s.verifyChatOwnership(userID, req.ChatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	timezone := "UTC"
+	if req.Timezone != nil && strings.TrimSpace(*req.Timezone) != "" {
+		timezone = strings.TrimSpace(*req.Timezone)
+	}
+	cronExpr := strings.TrimSpace(req.Cron)
+	if _, err := parseSchedule(cronExpr, timezone); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_SCHEDULE_NAME", "name is required")
+	}
+
+	var schedule *models.ScheduledQuery
+	switch {
+	case req.BookmarkID != nil:
+		bookmark, statusCode, err := s.findBookmark(chat, *req.BookmarkID)
+		if err != nil {
+			return nil, statusCode, err
+		}
+		schedule = models.NewScheduledQuery(chat.UserID, chat.ID, name, bookmark.Query, cronExpr, timezone)
+		schedule.BookmarkID = &bookmark.ID
+		schedule.QueryType = bookmark.QueryType
+		schedule.Tables = bookmark.Tables
+	case req.MessageID != nil && req.QueryID != nil:
+		query, statusCode, err := s.findQuery(chat, *req.MessageID, *req.QueryID)
+		if err != nil {
+			return nil, statusCode, err
+		}
+		schedule = models.NewScheduledQuery(chat.UserID, chat.ID, name, query.Query, cronExpr, timezone)
+		schedule.QueryType = query.QueryType
+		schedule.Tables = query.Tables
+		if query.Pagination != nil {
+			// The count of the previous execution doesn't hold for the next runs
+			schedule.Pagination = &models.Pagination{PaginatedQuery: query.Pagination.PaginatedQuery, CountQuery: query.Pagination.CountQuery}
+		}
+		schedule.Params = query.Params
+	default:
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_SCHEDULE_SOURCE", "either bookmark_id or message_id & query_id are required")
+	}
+
+	schedule.UsePrimary = req.UsePrimary
+	if req.WebhookURL != nil && strings.TrimSpace(*req.WebhookURL) != "" {
+		webhookURL, err := validateWebhookURL(*req.WebhookURL)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		schedule.WebhookURL = &webhookURL
+	}
+	emailTo, err := validateEmailRecipients(req.EmailTo)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	schedule.EmailTo = emailTo
+	if req.Enabled != nil {
+		schedule.Enabled = *req.Enabled
+	}
+	if schedule.Enabled {
+		nextRunAt, err := nextScheduledRun(schedule.Cron, schedule.Timezone, time.Now())
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		schedule.NextRunAt = &nextRunAt
+	}
+
+	if err := s.scheduleRepo.Create(schedule); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_CREATE_SCHEDULED_QUERY", "failed to create scheduled query: {error}").With("error", err)
+	}
+	return buildScheduledQueryResponse(schedule), http.StatusCreated, nil
+}
+
+// List returns the scheduled queries of the user, only the ones of a chat when chatID is not empty
+func (s *scheduledQueryService) List(userID, chatID string, page, pageSize int) (*dtos.ScheduledQueryListResponse, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+
+	var chatObjID *primitive.ObjectID
+	if chatID != "" {
+		parsed, err := primitive.ObjectIDFromHex(chatID)
+		if err != nil {
+			return nil, http.StatusBadRequest, apperrors.New("INVALID_CHAT_ID", "invalid chat ID format")
+		}
+		chatObjID = &parsed
+	}
+
+	schedules, total, err := s.scheduleRepo.FindByUserID(userObjID, chatObjID, page, pageSize)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_SCHEDULED_QUERIES", "failed to fetch scheduled queries: {error}").With("error", err)
+	}
+
+	response := &dtos.ScheduledQueryListResponse{
+		Schedules: make([]dtos.ScheduledQueryResponse, 0, len(schedules)),
+		Total:     total,
+	}
+	for _, schedule := range schedules {
+		response.Schedules = append(response.Schedules, *buildScheduledQueryResponse(schedule))
+	}
+	return response, http.StatusOK, nil
+}
+
+// Get returns a scheduled query with the outcome of its last run
+func (s *scheduledQueryService) Get(userID, scheduleID string) (*dtos.ScheduledQueryResponse, uint32, error) {
+	schedule, statusCode, err := s.findSchedule(userID, scheduleID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+	return buildScheduledQueryResponse(schedule), http.StatusOK, nil
+}
+
+// Update changes the name, the schedule, the webhook or pauses a scheduled query, the next run is computed again
+func (s *scheduledQueryService) Update(userID, scheduleID string, req *dtos.UpdateScheduledQueryRequest) (*dtos.ScheduledQueryResponse, uint32, error) {
+	schedule, statusCode, err := s.findSchedule(userID, scheduleID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	if req.Name != nil {
+		if strings.TrimSpace(*req.Name) == "" {
+			return nil, http.StatusBadRequest, apperrors.New("INVALID_SCHEDULE_NAME", "name is required")
+		}
+		schedule.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Cron != nil {
+		schedule.Cron = strings.TrimSpace(*req.Cron)
+	}
+	if req.Timezone != nil {
+		schedule.Timezone = strings.TrimSpace(*req.Timezone)
+		if schedule.Timezone == "" {
+			schedule.Timezone = "UTC"
+		}
+	}
+	if _, err := parseSchedule(schedule.Cron, schedule.Timezone); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if req.UsePrimary != nil {
+		schedule.UsePrimary = *req.UsePrimary
+	}
+	if req.WebhookURL != nil {
+		if strings.TrimSpace(*req.WebhookURL) == "" {
+			schedule.WebhookURL = nil
+		} else {
+			webhookURL, err := validateWebhookURL(*req.WebhookURL)
+			if err != nil {
+				return nil, http.StatusBadRequest, err
+			}
+			schedule.WebhookURL = &webhookURL
+		}
+	}
+	if req.EmailTo != nil {
+		emailTo, err := validateEmailRecipients(*req.EmailTo)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		schedule.EmailTo = emailTo
+	}
+	if req.Enabled != nil {
+		schedule.Enabled = *req.Enabled
+	}
+
+	// A schedule changing only its name, webhook or emails keeps its next run
+	rescheduled := req.Cron != nil || req.Timezone != nil || req.Enabled != nil
+	if !schedule.Enabled {
+		schedule.NextRunAt = nil
+	} else if rescheduled || schedule.NextRunAt == nil {
+		nextRunAt, err := nextScheduledRun(schedule.Cron, schedule.Timezone, time.Now())
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		schedule.NextRunAt = &nextRunAt
+	}
+
+	if err := s.scheduleRepo.Update(schedule); err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_UPDATE_SCHEDULED_QUERY", "failed to update scheduled query: {error}").With("error", err)
+	}
+	return buildScheduledQueryResponse(schedule), http.StatusOK, nil
+}
+
+// Delete removes a scheduled query, the messages of its past runs are kept in the chat
+func (s *scheduledQueryService) Delete(userID, scheduleID string) (uint32, error) {
+	schedule, statusCode, err := s.findSchedule(userID, scheduleID)
+	if err != nil {
+		return statusCode, err
+	}
+	if err := s.scheduleRepo.Delete(schedule.ID); err != nil {
+		return http.StatusInternalServerError, apperrors.New("FAILED_TO_DELETE_SCHEDULED_QUERY", "failed to delete scheduled query: {error}").With("error", err)
+	}
+	return http.StatusOK, nil
+}
+
+// scheduler starts the runs of the due schedules every poll
+func (s *scheduledQueryService) scheduler() {
+	ticker := time.NewTicker(time.Duration(max(config.Env.ScheduledQueryPollSeconds, 1)) * time.Second)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		s.startDueRuns(now)
+	}
+}
+
+// startDueRuns claims the due schedules & runs them in the background. A schedule whose connection already runs the maximum
+// of scheduled queries stays due for the next poll, the claim keeps the other instances from running it too.
+func (s *scheduledQueryService) startDueRuns(now time.Time) {
+	schedules, err := s.scheduleRepo.FindDue(now, scheduledQueryBatchSize)
+	if err != nil {
+		log.Printf("ScheduledQueryService -> startDueRuns -> Failed to fetch due schedules: %v", err)
+		return
+	}
+
+	for _, schedule := range schedules {
+		chat, err := s.chatRepo.FindByID(schedule.ChatID)
+		if err != nil {
+			log.Printf("ScheduledQueryService -> startDueRuns -> Failed to fetch chat of schedule %s: %v", schedule.ID.Hex(), err)
+			continue
+		}
+		if chat == nil {
+			log.Printf("ScheduledQueryService -> startDueRuns -> Chat of schedule %s was deleted, disabling it", schedule.ID.Hex())
+			s.disableSchedule(schedule)
+			continue
+		}
+
+		nextRunAt, err := nextScheduledRun(schedule.Cron, schedule.Timezone, now)
+		if err != nil {
+			log.Printf("ScheduledQueryService -> startDueRuns -> Invalid schedule %s, disabling it: %v", schedule.ID.Hex(), err)
+			s.disableSchedule(schedule)
+			continue
+		}
+
+		connectionKey := scheduledQueryConnectionKey(chat)
+		if !s.acquireRun(connectionKey) {
+			log.Printf("ScheduledQueryService -> startDueRuns -> Connection of schedule %s is busy, retrying on the next poll", schedule.ID.Hex())
+			continue
+		}
+
+		// Missed runs, e.g. while the server was down, are run once
+		claimed, err := s.scheduleRepo.ClaimRun(schedule.ID, *schedule.NextRunAt, nextRunAt)
+		if err != nil || !claimed {
+			if err != nil {
+				log.Printf("ScheduledQueryService -> startDueRuns -> Failed to claim schedule %s: %v", schedule.ID.Hex(), err)
+			}
+			s.releaseRun(connectionKey)
+			continue
+		}
+		schedule.NextRunAt = &nextRunAt
+
+		go func(schedule *models.ScheduledQuery) {
+			defer s.releaseRun(connectionKey)
+			s.executeRun(schedule)
+		}(schedule)
+	}
+}
+
+// executeRun adds a message holding the query to the chat & executes it like /queries/execute does, the execution stores
+// the result in the message & notifies the user
+func (s *scheduledQueryService) executeRun(schedule *models.ScheduledQuery) {
+	log.Printf("ScheduledQueryService -> executeRun -> Starting schedule %s", schedule.ID.Hex())
+
+	ranAt := time.Now()
+	queryType := "SELECT"
+	if schedule.QueryType != nil {
+		queryType = *schedule.QueryType
+	}
+	query := models.Query{
+		ID:          primitive.NewObjectID(),
+		Query:       schedule.Query,
+		QueryType:   &queryType,
+		Tables:      schedule.Tables,
+		Pagination:  schedule.Pagination,
+		Params:      schedule.Params,
+		Description: fmt.Sprintf("Scheduled run of %s", schedule.Name),
+	}
+	content := fmt.Sprintf("Scheduled query **%s** ran at %s.", schedule.Name, ranAt.In(scheduleLocation(schedule.Timezone)).Format(time.RFC1123))
+	msg := models.NewMessage(schedule.UserID, schedule.ChatID, string(constants.MessageTypeAssistant), content, &[]models.Query{query}, nil)
+
+	var runErr *models.QueryError
+	var response *dtos.QueryExecutionResponse
+	var messageID *primitive.ObjectID
+	if err := s.chatRepo.CreateMessage(msg); err != nil {
+		runErr = &models.QueryError{Code: "FAILED_TO_SAVE_MESSAGE", Message: "failed to save message", Details: err.Error()}
+	} else {
+		messageID = &msg.ID
+		var err error
+		response, _, err = s.chatService.ExecuteQuery(context.Background(), schedule.UserID.Hex(), schedule.ChatID.Hex(), &dtos.ExecuteQueryRequest{
+			MessageID:  msg.ID.Hex(),
+			QueryID:    query.ID.Hex(),
+			StreamID:   scheduledQueryStreamIDPrefix + schedule.ID.Hex(),
+			UsePrimary: schedule.UsePrimary,
+		})
+		switch {
+		case err != nil:
+			runErr = &models.QueryError{Code: "SCHEDULED_QUERY_FAILED", Message: err.Error()}
+			if appErr, ok := err.(*apperrors.Error); ok {
+				runErr.Code = appErr.Code
+			}
+		case response.Error != nil:
+			runErr = &models.QueryError{Code: response.Error.Code, Message: response.Error.Message, Details: response.Error.Details}
+		}
+	}
+
+	status := models.ScheduledQueryStatusSucceeded
+	if runErr != nil {
+		status = models.ScheduledQueryStatusFailed
+		log.Printf("ScheduledQueryService -> executeRun -> Schedule %s failed: %s", schedule.ID.Hex(), runErr.Message)
+	} else {
+		log.Printf("ScheduledQueryService -> executeRun -> Schedule %s completed in %v", schedule.ID.Hex(), time.Since(ranAt))
+	}
+	if err := s.scheduleRepo.RecordRun(schedule.ID, ranAt, status, runErr, messageID); err != nil {
+		log.Printf("ScheduledQueryService -> executeRun -> Failed to record the run of schedule %s: %v", schedule.ID.Hex(), err)
+	}
+
+	if schedule.WebhookURL != nil {
+		s.sendWebhook(schedule, ranAt, status, messageID, query.ID, response, runErr)
+	}
+	if len(schedule.EmailTo) > 0 {
+		s.sendEmail(schedule, ranAt, status, response, runErr)
+	}
+}
+
+// sendWebhook posts the outcome of a run to the webhook of the schedule, a failed delivery is not retried
+func (s *scheduledQueryService) sendWebhook(schedule *models.ScheduledQuery, ranAt time.Time, status string, messageID *primitive.ObjectID, queryID primitive.ObjectID, response *dtos.QueryExecutionResponse, runErr *models.QueryError) {
+	payload := map[string]interface{}{
+		"schedule_id": schedule.ID.Hex(),
+		"name":        schedule.Name,
+		"chat_id":     schedule.ChatID.Hex(),
+		"query_id":    queryID.Hex(),
+		"status":      status,
+		"ran_at":      ranAt.Format(time.RFC3339),
+	}
+	if messageID != nil {
+		payload["message_id"] = messageID.Hex()
+	}
+	if response != nil && runErr == nil {
+		// The result is capped like the one stored in the message
+		payload["execution_time"] = response.ExecutionTime
+		payload["execution_result"] = response.ExecutionResult
+		payload["total_records_count"] = response.TotalRecordsCount
+	}
+	if runErr != nil {
+		payload["error"] = dtos.QueryError{Code: runErr.Code, Message: runErr.Message, Details: runErr.Details}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("ScheduledQueryService -> sendWebhook -> Failed to encode the payload of schedule %s: %v", schedule.ID.Hex(), err)
+		return
+	}
+	resp, err := s.webhookClient.Post(*schedule.WebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Printf("ScheduledQueryService -> sendWebhook -> Failed to call the webhook of schedule %s: %v", schedule.ID.Hex(), err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Printf("ScheduledQueryService -> sendWebhook -> Webhook of schedule %s responded with status %d", schedule.ID.Hex(), resp.StatusCode)
+	}
+}
+
+// sendEmail emails the outcome of a run to the recipients of the schedule, a failed delivery is not retried
+func (s *scheduledQueryService) sendEmail(schedule *models.ScheduledQuery, ranAt time.Time, status string, response *dtos.QueryExecutionResponse, runErr *models.QueryError) {
+	if config.Env.SMTPHost == "" {
+		log.Printf("ScheduledQueryService -> sendEmail -> SMTP_HOST is not set, the email of schedule %s is not sent", schedule.ID.Hex())
+		return
+	}
+
+	var body strings.Builder
+	body.WriteString(fmt.Sprintf("Scheduled query %s %s at %s.\r\n\r\n", schedule.Name, status, ranAt.In(scheduleLocation(schedule.Timezone)).Format(time.RFC1123)))
+	body.WriteString(fmt.Sprintf("Query:\r\n%s\r\n\r\n", schedule.Query))
+	if runErr != nil {
+		body.WriteString(fmt.Sprintf("Error: %s: %s\r\n%s\r\n", runErr.Code, runErr.Message, runErr.Details))
+	} else if response != nil {
+		if response.TotalRecordsCount != nil {
+			body.WriteString(fmt.Sprintf("Records: %d\r\n", *response.TotalRecordsCount))
+		}
+		// The result is capped like the one stored in the message, and again to keep the email small
+		if result, err := json.MarshalIndent(response.ExecutionResult, "", "  "); err == nil {
+			if len(result) > scheduledQueryEmailMaxResult {
+				result = append(result[:scheduledQueryEmailMaxResult], []byte("\n... (truncated, the whole result is in the chat)")...)
+			}
+			body.WriteString(fmt.Sprintf("Result:\r\n%s\r\n", strings.ReplaceAll(string(result), "\n", "\r\n")))
+		}
+	}
+
+	subject := fmt.Sprintf("Scheduled query %s %s", schedule.Name, status)
+	if err := sendSMTPMail(schedule.EmailTo, subject, body.String()); err != nil {
+		log.Printf("ScheduledQueryService -> sendEmail -> Failed to email the run of schedule %s: %v", schedule.ID.Hex(), err)
+	}
+}
+
+func (s *scheduledQueryService) disableSchedule(schedule *models.ScheduledQuery) {
+	if err := s.scheduleRepo.Disable(schedule.ID); err != nil {
+		log.Printf("ScheduledQueryService -> disableSchedule -> Failed to disable schedule %s: %v", schedule.ID.Hex(), err)
+	}
+}
+
+// acquireRun takes a run slot of the connection, false when all of them are taken
+func (s *scheduledQueryService) acquireRun(connectionKey string) bool {
+	s.activeRunsMu.Lock()
+	defer s.activeRunsMu.Unlock()
+	if s.activeRuns[connectionKey] >= max(config.Env.ScheduledQueryMaxRunsPerConnection, 1) {
+		return false
+	}
+	s.activeRuns[connectionKey]++
+	return true
+}
+
+func (s *scheduledQueryService) releaseRun(connectionKey string) {
+	s.activeRunsMu.Lock()
+	defer s.activeRunsMu.Unlock()
+	s.activeRuns[connectionKey]--
+	if s.activeRuns[connectionKey] <= 0 {
+		delete(s.activeRuns, connectionKey)
+	}
+}
+
+func (s *scheduledQueryService) findSchedule(userID, scheduleID string) (*models.ScheduledQuery, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+
+	scheduleObjID, err := primitive.ObjectIDFromHex(scheduleID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_SCHEDULE_ID", "invalid schedule ID format")
+	}
+
+	schedule, err := s.scheduleRepo.FindByID(scheduleObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_SCHEDULED_QUERY", "failed to fetch scheduled query: {error}").With("error", err)
+	}
+	if schedule == nil || schedule.UserID != userObjID {
+		return nil, http.StatusNotFound, apperrors.New("SCHEDULED_QUERY_NOT_FOUND", "scheduled query not found")
+	}
+	return schedule, http.StatusOK, nil
+}
+
+func (s *scheduledQueryService) verifyChatOwnership(userID, chatID string) (*models.Chat, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+
+	chatObjID, err := primitive.ObjectIDFromHex(chatID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_CHAT_ID", "invalid chat ID format")
+	}
+
+	chat, err := s.chatRepo.FindByID(chatObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CHAT", "failed to fetch chat: {error}").With("error", err)
+	}
+	if chat == nil {
+		return nil, http.StatusNotFound, apperrors.New("CHAT_NOT_FOUND", "chat not found")
+	}
+	if chat.UserID != userObjID {
+		return nil, http.StatusForbidden, apperrors.New("CHAT_ACCESS_DENIED", "chat does not belong to user")
+	}
+	return chat, http.StatusOK, nil
+}
+
+// findBookmark checks the bookmark is one of the chat
+func (s *scheduledQueryService) findBookmark(chat *models.Chat, bookmarkID string) (*models.QueryBookmark, uint32, error) {
+	bookmarkObjID, err := primitive.ObjectIDFromHex(bookmarkID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_BOOKMARK_ID", "invalid bookmark ID format")
+	}
+
+	bookmark, err := s.bookmarkRepo.FindByID(bookmarkObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_BOOKMARK", "failed to fetch bookmark: {error}").With("error", err)
+	}
+	if bookmark == nil || bookmark.ChatID != chat.ID || bookmark.UserID != chat.UserID {
+		return nil, http.StatusNotFound, apperrors.New("BOOKMARK_NOT_FOUND", "bookmark not found")
+	}
+	return bookmark, http.StatusOK, nil
+}
+
+// findQuery returns the query of a message of the chat
+func (s *scheduledQueryService) findQuery(chat *models.Chat, messageID, queryID string) (*models.Query, uint32, error) {
+	msgObjID, err := primitive.ObjectIDFromHex(messageID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_MESSAGE_ID", "invalid message ID format")
+	}
+	queryObjID, err := primitive.ObjectIDFromHex(queryID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_QUERY_ID", "invalid query ID format")
+	}
+
+	msg, err := s.chatRepo.FindMessageByID(msgObjID)
+	if err == mongo.ErrNoDocuments || (err == nil && (msg == nil || msg.ChatID != chat.ID)) {
+		return nil, http.StatusNotFound, apperrors.New("MESSAGE_NOT_FOUND", "message not found")
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_MESSAGE", "failed to fetch message: {error}").With("error", err)
+	}
+	if msg.Queries != nil {
+		for i := range *msg.Queries {
+			if (*msg.Queries)[i].ID == queryObjID {
+				return &(*msg.Queries)[i], http.StatusOK, nil
+			}
+		}
+	}
+	return nil, http.StatusNotFound, apperrors.New("QUERY_NOT_FOUND", "query not found")
+}
+
+// parseSchedule parses a standard 5 fields cron expression or a descriptor, its runs must be a minute apart at least
+func parseSchedule(expression, timezone string) (cron.Schedule, error) {
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return nil, apperrors.New("INVALID_TIMEZONE", "invalid timezone {timezone}").With("timezone", timezone)
+	}
+	schedule, err := cron.ParseStandard(expression)
+	if err != nil {
+		return nil, apperrors.New("INVALID_CRON_EXPRESSION", "invalid cron expression: {error}").With("error", err)
+	}
+
+	first := schedule.Next(time.Now())
+	if first.IsZero() {
+		return nil, apperrors.New("INVALID_CRON_EXPRESSION", "the cron expression never runs")
+	}
+	if second := schedule.Next(first); !second.IsZero() && second.Sub(first) < scheduledQueryMinInterval {
+		return nil, apperrors.New("INVALID_CRON_EXPRESSION", "the runs of a scheduled query must be a minute apart at least")
+	}
+	return schedule, nil
+}
+
+// nextScheduledRun returns the first run after now in the timezone of the schedule, delayed by a random jitter of up to
+// SCHEDULED_QUERY_MAX_JITTER_SECONDS
+func nextScheduledRun(expression, timezone string, now time.Time) (time.Time, error) {
+	schedule, err := parseSchedule(expression, timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+	next := schedule.Next(now.In(scheduleLocation(timezone)))
+	if config.Env.ScheduledQueryMaxJitterSeconds > 0 {
+		next = next.Add(time.Duration(rand.Int63n(int64(config.Env.ScheduledQueryMaxJitterSeconds) * int64(time.Second))))
+	}
+	return next.UTC(), nil
+}
+
+func scheduleLocation(timezone string) *time.Location {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// validateWebhookURL checks the webhook is an absolute http(s) URL of a public host, unless SCHEDULED_QUERY_ALLOW_PRIVATE_WEBHOOKS is set.
+// The webhook client checks the address again as it connects, the host may resolve to another one by then.
+func validateWebhookURL(rawURL string) (string, error) {
+	webhookURL := strings.TrimSpace(rawURL)
+	parsed, err := url.Parse(webhookURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return "", apperrors.New("INVALID_WEBHOOK_URL", "webhook_url must be an http or https URL")
+	}
+	if config.Env.ScheduledQueryAllowPrivateWebhooks {
+		return webhookURL, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), scheduledQueryWebhookTimeout)
+	defer cancel()
+	if err := utils.CheckPublicHost(ctx, parsed.Hostname()); err != nil {
+		return "", apperrors.New("INVALID_WEBHOOK_URL", "webhook_url must point to a public host: {error}").With("error", err)
+	}
+	return webhookURL, nil
+}
+
+// newWebhookClient returns the client calling the webhooks, it refuses to connect to addresses that aren't public
+func newWebhookClient() *http.Client {
+	if config.Env.ScheduledQueryAllowPrivateWebhooks {
+		return &http.Client{Timeout: scheduledQueryWebhookTimeout}
+	}
+	return utils.NewPublicHTTPClient(scheduledQueryWebhookTimeout)
+}
+
+// validateEmailRecipients parses the addresses the runs are emailed to, emails need an SMTP server
+func validateEmailRecipients(addresses []string) ([]string, error) {
+	var recipients []string
+	seen := make(map[string]bool)
+	for _, address := range addresses {
+		if strings.TrimSpace(address) == "" {
+			continue
+		}
+		parsed, err := mail.ParseAddress(strings.TrimSpace(address))
+		if err != nil {
+			return nil, apperrors.New("INVALID_EMAIL_ADDRESS", "invalid email address {address}").With("address", address)
+		}
+		if !seen[strings.ToLower(parsed.Address)] {
+			seen[strings.ToLower(parsed.Address)] = true
+			recipients = append(recipients, parsed.Address)
+		}
+	}
+	if len(recipients) == 0 {
+		return nil, nil
+	}
+	if len(recipients) > scheduledQueryMaxEmailTo {
+		return nil, apperrors.New("TOO_MANY_EMAIL_RECIPIENTS", "email_to can hold {max} addresses at most").With("max", scheduledQueryMaxEmailTo)
+	}
+	if config.Env.SMTPHost == "" {
+		return nil, apperrors.New("EMAIL_NOT_CONFIGURED", "emails can't be sent, SMTP_HOST is not set")
+	}
+	return recipients, nil
+}
+
+// sendSMTPMail sends a plain text email through the SMTP server of the config, upgrading the connection with STARTTLS
+// when the server offers it
+func sendSMTPMail(to []string, subject, body string) error {
+	host := config.Env.SMTPHost
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, config.Env.SMTPPort), scheduledQueryEmailTimeout)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(scheduledQueryEmailTimeout))
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if config.Env.SMTPUsername != "" {
+		if err := client.Auth(smtp.PlainAuth("", config.Env.SMTPUsername, config.Env.SMTPPassword, host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(config.Env.SMTPFrom); err != nil {
+		return err
+	}
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	headers := []string{
+		"From: " + config.Env.SMTPFrom,
+		"To: " + strings.Join(to, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		`Content-Type: text/plain; charset="utf-8"`,
+		"Content-Transfer-Encoding: 8bit",
+	}
+	if _, err := writer.Write([]byte(strings.Join(headers, "\r\n") + "\r\n\r\n" + body)); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// scheduledQueryConnectionKey identifies the database of a chat, the chats of the same database share its run slots
+func scheduledQueryConnectionKey(chat *models.Chat) string {
+	address := chat.Connection.Host
+	if chat.Connection.Port != nil {
+		address += ":" + *chat.Connection.Port
+	}
+	if chat.Connection.SocketPath != nil && *chat.Connection.SocketPath != "" {
+		address = *chat.Connection.SocketPath
+	}
+	return fmt.Sprintf("%s://%s/%s", chat.Connection.Type, address, chat.Connection.Database)
+}
+
+func buildScheduledQueryResponse(schedule *models.ScheduledQuery) *dtos.ScheduledQueryResponse {
+	formatTime := func(t *time.Time) *string {
+		if t == nil {
+			return nil
+		}
+		formatted := t.Format(time.RFC3339)
+		return &formatted
+	}
+
+	response := &dtos.ScheduledQueryResponse{
+		ID:         schedule.ID.Hex(),
+		ChatID:     schedule.ChatID.Hex(),
+		Name:       schedule.Name,
+		Query:      schedule.Query,
+		QueryType:  schedule.QueryType,
+		Cron:       schedule.Cron,
+		Timezone:   schedule.Timezone,
+		UsePrimary: schedule.UsePrimary,
+		HasWebhook: schedule.WebhookURL != nil,
+		EmailTo:    schedule.EmailTo,
+		Enabled:    schedule.Enabled,
+		NextRunAt:  formatTime(schedule.NextRunAt),
+		LastRunAt:  formatTime(schedule.LastRunAt),
+		LastStatus: schedule.LastStatus,
+		RunCount:   schedule.RunCount,
+		CreatedAt:  schedule.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:  schedule.UpdatedAt.Format(time.RFC3339),
+	}
+	if schedule.BookmarkID != nil {
+		bookmarkID := schedule.BookmarkID.Hex()
+		response.BookmarkID = &bookmarkID
+	}
+	if schedule.LastMessage != nil {
+		lastMessageID := schedule.LastMessage.Hex()
+		response.LastMessageID = &lastMessageID
+	}
+	if schedule.LastError != nil {
+		response.LastError = &dtos.QueryError{Code: schedule.LastError.Code, Message: schedule.LastError.Message, Details: schedule.LastError.Details}
+	}
+	return response
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math"
+	"neobase-ai/internal/models"
+	"neobase-ai/internal/repositories"
+	"neobase-ai/pkg/dbmanager"
+	"neobase-ai/pkg/llm"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SchemaRetrievalService selects the tables relevant to a request by the similarity of their embeddings,
+// the schema of databases with hundreds of tables would not fit in the prompt
+type SchemaRetrievalService interface {
+	// RetrieveSchema formats the schema of the tables most relevant to the question, ok is false when the whole schema should be sent
+	RetrieveSchema(ctx context.Context, chatID string, question string, selectedTables []string) (schema string, ok bool)
+	DeleteChatEmbeddings(chatID primitive.ObjectID) error
+}
+
+type schemaRetrievalService struct {
+	embeddingRepo   repositories.SchemaEmbeddingRepository
+	embeddingClient llm.EmbeddingClient // nil when schema retrieval is disabled
+	dbManager       *dbmanager.Manager
+	minTables       int
+	topK            int
+}
+
+func NewSchemaRetrievalService(embeddingRepo repositories.SchemaEmbeddingRepository, embeddingClient llm.EmbeddingClient, dbManager *dbmanager.Manager, minTables, topK int) SchemaRetrievalService {
+	return &schemaRetrievalService{
+		embeddingRepo:   embeddingRepo,
+		embeddingClient: embeddingClient,
+		dbManager:       dbManager,
+		minTables:       minTables,
+		topK:            max(topK, 1),
+	}
+}
+
+type scoredTable struct {
+	name  string
+	score float64
+}
+
+func (s *schemaRetrievalService) RetrieveSchema(ctx context.Context, chatID string, question string, selectedTables []string) (string, bool) {
+	if s.embeddingClient == nil || strings.TrimSpace(question) == "" {
+		return "", false
+	}
+
+	chatObjID, err := primitive.ObjectIDFromHex(chatID)
+	if err != nil {
+		return "", false
+	}
+	storage, err := s.dbManager.GetSchemaManager().GetStoredSchema(ctx, chatID)
+	if err != nil {
+		log.Printf("SchemaRetrievalService -> RetrieveSchema -> Error getting stored schema: %v", err)
+		return "", false
+	}
+
+	tableNames := retrievableTables(storage, selectedTables)
+	if len(tableNames) < s.minTables || len(tableNames) <= s.topK {
+		return "", false
+	}
+
+	vectors, err := s.syncEmbeddings(ctx, chatObjID, storage, tableNames)
+	if err != nil {
+		log.Printf("SchemaRetrievalService -> RetrieveSchema -> Error embedding the tables of chat %s: %v", chatID, err)
+		return "", false
+	}
+	questionVector, err := s.embeddingClient.EmbedQuery(ctx, question)
+	if err != nil {
+		log.Printf("SchemaRetrievalService -> RetrieveSchema -> Error embedding the question: %v", err)
+		return "", false
+	}
+
+	scored := make([]scoredTable, 0, len(vectors))
+	for tableName, vector := range vectors {
+		scored = append(scored, scoredTable{name: tableName, score: cosineSimilarity(questionVector, vector)})
+	}
+	sort.Slice(scored, func(i, j int) bool {
+		if scored[i].score != scored[j].score {
+			return scored[i].score > scored[j].score
+		}
+		return scored[i].name < scored[j].name
+	})
+
+	relevant := make([]string, 0, s.topK*2)
+	for _, table := range scored[:min(s.topK, len(scored))] {
+		relevant = append(relevant, table.name)
+	}
+	// Joins need the tables the relevant ones reference, they are added up to twice the top K
+	for _, tableName := range dbmanager.RelatedTables(storage, relevant) {
+		if len(relevant) >= s.topK*2 {
+			break
+		}
+		if _, ok := vectors[tableName]; ok {
+			relevant = append(relevant, tableName)
+		}
+	}
+
+	included := make(map[string]bool, len(relevant))
+	for _, tableName := range relevant {
+		included[tableName] = true
+	}
+	otherTables := make([]string, 0, len(tableNames)-len(relevant))
+	for _, tableName := range tableNames {
+		if !included[tableName] {
+			otherTables = append(otherTables, tableName)
+		}
+	}
+
+	log.Printf("SchemaRetrievalService -> RetrieveSchema -> selected %d of %d tables for chat %s", len(relevant), len(tableNames), chatID)
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Only the %d tables most relevant to the latest request are detailed, out of %d tables.\n", len(relevant), len(tableNames)))
+	result.WriteString(fmt.Sprintf("The other tables exist but are not detailed, ask the user to name the table if the request needs one of them: %s\n\n", strings.Join(otherTables, ", ")))
+	result.WriteString(s.dbManager.GetSchemaManager().FormatSchemaTablesForLLM(storage, relevant))
+	return result.String(), true
+}
+
+// syncEmbeddings returns the vectors of the tables, the tables which are new or changed since they were embedded are embedded again
+func (s *schemaRetrievalService) syncEmbeddings(ctx context.Context, chatObjID primitive.ObjectID, storage *dbmanager.SchemaStorage, tableNames []string) (map[string][]float32, error) {
+	stored, err := s.embeddingRepo.FindByChatID(chatObjID)
+	if err != nil {
+		return nil, err
+	}
+	storedByTable := make(map[string]*models.SchemaEmbedding, len(stored))
+	for _, embedding := range stored {
+		storedByTable[embedding.Table] = embedding
+	}
+
+	model := s.embeddingClient.GetModelName()
+	vectors := make(map[string][]float32, len(tableNames))
+	var staleTables, staleTexts, staleChecksums []string
+	for _, tableName := range tableNames {
+		text := dbmanager.TableEmbeddingText(storage, tableName)
+		checksum := sha256.Sum256([]byte(text))
+		checksumHex := hex.EncodeToString(checksum[:])
+
+		if embedding, ok := storedByTable[tableName]; ok && embedding.Checksum == checksumHex && embedding.Model == model {
+			vectors[tableName] = embedding.Vector
+			continue
+		}
+		staleTables = append(staleTables, tableName)
+		staleTexts = append(staleTexts, text)
+		staleChecksums = append(staleChecksums, checksumHex)
+	}
+
+	if len(staleTables) > 0 {
+		log.Printf("SchemaRetrievalService -> syncEmbeddings -> embedding %d tables of chat %s", len(staleTables), chatObjID.Hex())
+		staleVectors, err := s.embeddingClient.EmbedDocuments(ctx, staleTexts)
+		if err != nil {
+			return nil, err
+		}
+
+		embeddings := make([]*models.SchemaEmbedding, 0, len(staleTables))
+		for i, tableName := range staleTables {
+			vectors[tableName] = staleVectors[i]
+			embeddings = append(embeddings, &models.SchemaEmbedding{
+				ChatID:   chatObjID,
+				Table:    tableName,
+				Checksum: staleChecksums[i],
+				Model:    model,
+				Vector:   staleVectors[i],
+			})
+		}
+		if err := s.embeddingRepo.Upsert(embeddings); err != nil {
+			// The vectors are still used for this request, they are embedded again by the next one
+			log.Printf("SchemaRetrievalService -> syncEmbeddings -> Error saving embeddings: %v", err)
+		}
+	}
+
+	// The tables dropped from the database are forgotten, the unselected ones are kept for when they are selected again
+	var droppedTables []string
+	for tableName := range storedByTable {
+		if _, exists := storage.LLMSchema.Tables[tableName]; !exists {
+			droppedTables = append(droppedTables, tableName)
+		}
+	}
+	if err := s.embeddingRepo.DeleteTables(chatObjID, droppedTables); err != nil {
+		log.Printf("SchemaRetrievalService -> syncEmbeddings -> Error deleting embeddings of dropped tables: %v", err)
+	}
+
+	return vectors, nil
+}
+
+// DeleteChatEmbeddings removes the embeddings of the tables of a chat
+func (s *schemaRetrievalService) DeleteChatEmbeddings(chatID primitive.ObjectID) error {
+	return s.embeddingRepo.DeleteByChatID(chatID)
+}
+
+// retrievableTables returns the tables of the stored schema the chat selected, sorted by name
+func retrievableTables(storage *dbmanager.SchemaStorage, selectedTables []string) []string {
+	selected := make(map[string]bool, len(selectedTables))
+	for _, tableName := range selectedTables {
+		selected[strings.TrimSpace(tableName)] = true
+	}
+
+	tableNames := make([]string, 0, len(storage.LLMSchema.Tables))
+	for tableName := range storage.LLMSchema.Tables {
+		if len(selected) == 0 || selected[tableName] {
+			tableNames = append(tableNames, tableName)
+		}
+	}
+	sort.Strings(tableNames)
+	return tableNames
+}
+
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
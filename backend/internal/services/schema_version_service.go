@@ -0,0 +1,299 @@
+package services
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/apperrors"
+	"neobase-ai/internal/models"
+	"neobase-ai/internal/repositories"
+	"neobase-ai/pkg/dbmanager"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SchemaVersionService interface {
+	RecordVersion(userID string, chat *models.Chat, schema *dbmanager.SchemaInfo)
+	DeleteChatVersions(chatID primitive.ObjectID) error
+	List(userID, chatID string, page, pageSize int) (*dtos.SchemaVersionListResponse, uint32, error)
+	Diff(userID, chatID, from, to string) (*dtos.SchemaVersionDiffResponse, uint32, error)
+}
+
+type schemaVersionService struct {
+	versionRepo repositories.SchemaVersionRepository
+	chatRepo    repositories.ChatRepository
+	dbManager   *dbmanager.Manager
+}
+
+func NewSchemaVersionService(versionRepo repositories.SchemaVersionRepository, chatRepo repositories.ChatRepository, dbManager *dbmanager.Manager) SchemaVersionService {
+	return &schemaVersionService{
+		versionRepo: versionRepo,
+		chatRepo:    chatRepo,
+		dbManager:   dbManager,
+	}
+}
+
+// RecordVersion adds the schema fetched from a chat's database to its versions along with the changes from the previous version,
+// the schema is not recorded when its checksum is the one of the previous version. A failure to record it is only logged.
+func (s *schemaVersionService) RecordVersion(userID string, chat *models.Chat, schema *dbmanager.SchemaInfo) {
+	if schema == nil {
+		return
+	}
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		log.Printf("SchemaVersionService -> RecordVersion -> Invalid user ID: %s", userID)
+		return
+	}
+
+	checksum := schemaChecksum(schema)
+	latest, err := s.versionRepo.FindLatestByChatID(chat.ID, nil)
+	if err != nil {
+		log.Printf("SchemaVersionService -> RecordVersion -> Error fetching the latest version of chat %s: %v", chat.ID.Hex(), err)
+		return
+	}
+	if latest != nil && latest.Checksum == checksum {
+		log.Printf("SchemaVersionService -> RecordVersion -> Schema of chat %s is unchanged since version %s", chat.ID.Hex(), latest.ID.Hex())
+		return
+	}
+
+	schemaJSON, err := json.Marshal(schema)
+	if err != nil {
+		log.Printf("SchemaVersionService -> RecordVersion -> Error encoding the schema of chat %s: %v", chat.ID.Hex(), err)
+		return
+	}
+	version := &models.SchemaVersion{
+		UserID:       userObjID,
+		ChatID:       chat.ID,
+		ConnectionID: chat.ConnectionID,
+		DatabaseType: chat.Connection.Type,
+		Database:     chat.Connection.Database,
+		Checksum:     checksum,
+		Tables:       len(schema.Tables),
+		Schema:       string(schemaJSON),
+		CapturedAt:   time.Now(),
+		Base:         models.NewBase(),
+	}
+	if latest != nil {
+		// The changes are computed from the previous version rather than the schema cached for the chat, which may have expired
+		previous, err := decodeVersionSchema(latest)
+		if err != nil {
+			log.Printf("SchemaVersionService -> RecordVersion -> Error decoding version %s: %v", latest.ID.Hex(), err)
+		} else {
+			diff, _ := s.dbManager.GetSchemaManager().CompareSchemas(previous, schema)
+			version.Changes = buildSchemaChanges(diff)
+		}
+	}
+
+	if err := s.versionRepo.Create(version); err != nil {
+		log.Printf("SchemaVersionService -> RecordVersion -> Error storing the schema version of chat %s: %v", chat.ID.Hex(), err)
+		return
+	}
+	log.Printf("SchemaVersionService -> RecordVersion -> Recorded schema version %s (checksum %s) of chat %s", version.ID.Hex(), checksum, chat.ID.Hex())
+}
+
+// DeleteChatVersions removes the schema versions recorded for a chat
+func (s *schemaVersionService) DeleteChatVersions(chatID primitive.ObjectID) error {
+	return s.versionRepo.DeleteByChatID(chatID)
+}
+
+// List returns the schema versions of a chat, most recent first, each with the changes from the version before it
+func (s *schemaVersionService) List(userID, chatID string, page, pageSize int) (*dtos.SchemaVersionListResponse, uint32, error) {
+	chat, statusCode, err := s.verifyChatOwnership(userID, chatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	versions, total, err := s.versionRepo.FindByChatID(chat.ID, page, pageSize)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_SCHEMA_VERSIONS", "failed to fetch schema versions: {error}").With("error", err)
+	}
+
+	response := &dtos.SchemaVersionListResponse{
+		Versions: make([]dtos.SchemaVersionResponse, 0, len(versions)),
+		Total:    total,
+	}
+	for _, version := range versions {
+		response.Versions = append(response.Versions, *buildSchemaVersionResponse(version))
+	}
+	return response, http.StatusOK, nil
+}
+
+// Diff returns what changed in the schema of a chat between two points, each a version ID or an RFC3339 time standing for the
+// version the schema was at then. to defaults to the latest version.
+func (s *schemaVersionService) Diff(userID, chatID, from, to string) (*dtos.SchemaVersionDiffResponse, uint32, error) {
+	chat, statusCode, err := s.verifyChatOwnership(userID, chatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+	if strings.TrimSpace(from) == "" {
+		return nil, http.StatusBadRequest, apperrors.New("SCHEMA_VERSION_FROM_REQUIRED", "from is required, a version ID or an RFC3339 time")
+	}
+
+	fromVersion, statusCode, err := s.findVersion(chat, strings.TrimSpace(from))
+	if err != nil {
+		return nil, statusCode, err
+	}
+	toVersion, statusCode, err := s.findVersion(chat, strings.TrimSpace(to))
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	response := &dtos.SchemaVersionDiffResponse{
+		From:    *buildSchemaVersionResponse(fromVersion),
+		To:      *buildSchemaVersionResponse(toVersion),
+		Changes: *buildSchemaChanges(nil),
+	}
+	if fromVersion.ID == toVersion.ID {
+		return response, http.StatusOK, nil
+	}
+
+	fromSchema, err := decodeVersionSchema(fromVersion)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("INVALID_SCHEMA_VERSION", "failed to read schema version {id}: {error}").With("id", fromVersion.ID.Hex()).With("error", err)
+	}
+	toSchema, err := decodeVersionSchema(toVersion)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("INVALID_SCHEMA_VERSION", "failed to read schema version {id}: {error}").With("id", toVersion.ID.Hex()).With("error", err)
+	}
+	diff, hasChanges := s.dbManager.GetSchemaManager().CompareSchemas(fromSchema, toSchema)
+	response.HasChanges = hasChanges
+	response.Changes = *buildSchemaChanges(diff)
+	return response, http.StatusOK, nil
+}
+
+// findVersion returns the version of a chat with an ID, the version the schema was at an RFC3339 time, or the latest version
+// when point is empty
+func (s *schemaVersionService) findVersion(chat *models.Chat, point string) (*models.SchemaVersion, uint32, error) {
+	var version *models.SchemaVersion
+	var err error
+	switch {
+	case point == "":
+		version, err = s.versionRepo.FindLatestByChatID(chat.ID, nil)
+	case primitive.IsValidObjectID(point):
+		versionID, _ := primitive.ObjectIDFromHex(point)
+		version, err = s.versionRepo.FindByID(versionID)
+		if err == nil && version != nil && version.ChatID != chat.ID {
+			version = nil
+		}
+	default:
+		at, parseErr := time.Parse(time.RFC3339, point)
+		if parseErr != nil {
+			return nil, http.StatusBadRequest, apperrors.New("INVALID_SCHEMA_VERSION_POINT", "invalid {point}, expected a version ID or an RFC3339 time").With("point", point)
+		}
+		version, err = s.versionRepo.FindLatestByChatID(chat.ID, &at)
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_SCHEMA_VERSIONS", "failed to fetch schema versions: {error}").With("error", err)
+	}
+	if version == nil {
+		if point == "" {
+			return nil, http.StatusNotFound, apperrors.New("SCHEMA_VERSION_NOT_FOUND", "no schema version was recorded for the chat")
+		}
+		return nil, http.StatusNotFound, apperrors.New("SCHEMA_VERSION_NOT_FOUND", "no schema version at {point}").With("point", point)
+	}
+	return version, http.StatusOK, nil
+}
+
+func (s *schemaVersionService) verifyChatOwnership(userID, chatID string) (*models.Chat, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_USER_ID", "invalid user ID format")
+	}
+
+	chatObjID, err := primitive.ObjectIDFromHex(chatID)
+	if err != nil {
+		return nil, http.StatusBadRequest, apperrors.New("INVALID_CHAT_ID", "invalid chat ID format")
+	}
+
+	chat, err := s.chatRepo.FindByID(chatObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apperrors.New("FAILED_TO_FETCH_CHAT", "failed to fetch chat: {error}").With("error", err)
+	}
+	if chat == nil {
+		return nil, http.StatusNotFound, apperrors.New("CHAT_NOT_FOUND", "chat not found")
+	}
+	if chat.UserID != userObjID {
+		return nil, http.StatusForbidden, apperrors.New("CHAT_ACCESS_DENIED", "chat does not belong to user")
+	}
+	return chat, http.StatusOK, nil
+}
+
+// schemaChecksum returns the checksum of a schema, computed over its tables like the fetchers do when they didn't set one
+func schemaChecksum(schema *dbmanager.SchemaInfo) string {
+	if schema.Checksum != "" {
+		return schema.Checksum
+	}
+	schemaData, _ := json.Marshal(schema.Tables)
+	return fmt.Sprintf("%x", md5.Sum(schemaData))
+}
+
+func decodeVersionSchema(version *models.SchemaVersion) (*dbmanager.SchemaInfo, error) {
+	var schema dbmanager.SchemaInfo
+	if err := json.Unmarshal([]byte(version.Schema), &schema); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// buildSchemaChanges converts a schema diff to the changes of a version, a nil diff has no changes
+func buildSchemaChanges(diff *dbmanager.SchemaDiff) *models.SchemaChanges {
+	changes := &models.SchemaChanges{
+		AddedTables:    []string{},
+		RemovedTables:  []string{},
+		ModifiedTables: []models.SchemaTableChange{},
+	}
+	if diff == nil {
+		return changes
+	}
+
+	changes.AddedTables = append(changes.AddedTables, diff.AddedTables...)
+	changes.RemovedTables = append(changes.RemovedTables, diff.RemovedTables...)
+	sort.Strings(changes.AddedTables)
+	sort.Strings(changes.RemovedTables)
+	for table, tableDiff := range diff.ModifiedTables {
+		changes.ModifiedTables = append(changes.ModifiedTables, models.SchemaTableChange{
+			Table:           table,
+			AddedColumns:    tableDiff.AddedColumns,
+			RemovedColumns:  tableDiff.RemovedColumns,
+			ModifiedColumns: tableDiff.ModifiedColumns,
+			AddedIndexes:    tableDiff.AddedIndexes,
+			RemovedIndexes:  tableDiff.RemovedIndexes,
+			AddedFKs:        tableDiff.AddedFKs,
+			RemovedFKs:      tableDiff.RemovedFKs,
+		})
+	}
+	sort.Slice(changes.ModifiedTables, func(i, j int) bool {
+		return changes.ModifiedTables[i].Table < changes.ModifiedTables[j].Table
+	})
+	return changes
+}
+
+func buildSchemaVersionResponse(version *models.SchemaVersion) *dtos.SchemaVersionResponse {
+	response := &dtos.SchemaVersionResponse{
+		ID:           version.ID.Hex(),
+		ChatID:       version.ChatID.Hex(),
+		DatabaseType: version.DatabaseType,
+		Database:     version.Database,
+		Checksum:     version.Checksum,
+		Tables:       version.Tables,
+		Changes:      version.Changes,
+		CapturedAt:   version.CapturedAt.Format(time.RFC3339),
+	}
+	if version.ConnectionID != nil {
+		connectionID := version.ConnectionID.Hex()
+		response.ConnectionID = &connectionID
+	}
+	return response
+}
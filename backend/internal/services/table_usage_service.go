@@ -0,0 +1,127 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"neobase-ai/internal/models"
+	"neobase-ai/internal/repositories"
+	"neobase-ai/pkg/dbmanager"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// Tables listed as relevant in the prompt
+	maxRelevantTables = 10
+	// A use of a table counts half as much after this period, the recent focus of a chat wins over its history
+	tableUsageHalfLife = 14 * 24 * time.Hour
+)
+
+type TableUsageService interface {
+	RecordQuery(chatID, dbType, query string)
+	FormatRelevantTables(chatID string, selectedTables []string) string
+	ForgetTables(chatID string, tables []string)
+	DeleteChatUsage(chatID primitive.ObjectID) error
+}
+
+type tableUsageService struct {
+	tableUsageRepo repositories.TableUsageRepository
+}
+
+func NewTableUsageService(tableUsageRepo repositories.TableUsageRepository) TableUsageService {
+	return &tableUsageService{
+		tableUsageRepo: tableUsageRepo,
+	}
+}
+
+// RecordQuery counts the use of the tables an executed query read or wrote
+func (s *tableUsageService) RecordQuery(chatID, dbType, query string) {
+	tables := dbmanager.ExtractReferencedTables(dbType, query)
+	if len(tables) == 0 {
+		return
+	}
+
+	chatObjID, err := primitive.ObjectIDFromHex(chatID)
+	if err != nil {
+		log.Printf("TableUsageService -> RecordQuery -> Invalid chat ID: %s", chatID)
+		return
+	}
+	if err := s.tableUsageRepo.Increment(chatObjID, tables, time.Now()); err != nil {
+		log.Printf("TableUsageService -> RecordQuery -> Error storing table usage: %v", err)
+	}
+}
+
+// FormatRelevantTables lists the tables the chat's queries use the most for the LLM, recent uses weigh more.
+// Only the selected tables are listed when the chat has a selection, an empty string is returned when nothing was used yet.
+func (s *tableUsageService) FormatRelevantTables(chatID string, selectedTables []string) string {
+	chatObjID, err := primitive.ObjectIDFromHex(chatID)
+	if err != nil {
+		return ""
+	}
+	usages, err := s.tableUsageRepo.FindByChatID(chatObjID)
+	if err != nil {
+		log.Printf("TableUsageService -> FormatRelevantTables -> Error fetching table usage: %v", err)
+		return ""
+	}
+
+	selected := make(map[string]bool, len(selectedTables))
+	for _, table := range selectedTables {
+		selected[strings.ToLower(strings.TrimSpace(table))] = true
+	}
+
+	now := time.Now()
+	type rankedTable struct {
+		usage *models.TableUsage
+		score float64
+	}
+	ranked := make([]rankedTable, 0, len(usages))
+	for _, usage := range usages {
+		if len(selected) > 0 && !selected[strings.ToLower(usage.Table)] {
+			continue
+		}
+		age := now.Sub(usage.LastUsedAt)
+		ranked = append(ranked, rankedTable{
+			usage: usage,
+			score: float64(usage.Count) * math.Pow(0.5, float64(age)/float64(tableUsageHalfLife)),
+		})
+	}
+	if len(ranked) == 0 {
+		return ""
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].score > ranked[j].score
+	})
+	if len(ranked) > maxRelevantTables {
+		ranked = ranked[:maxRelevantTables]
+	}
+
+	var result strings.Builder
+	for _, table := range ranked {
+		queries := "queries"
+		if table.usage.Count == 1 {
+			queries = "query"
+		}
+		result.WriteString(fmt.Sprintf("- %s (%d %s, last on %s)\n", table.usage.Table, table.usage.Count, queries, table.usage.LastUsedAt.Format("2006-01-02")))
+	}
+	return result.String()
+}
+
+// ForgetTables drops the usage of tables removed from the chat's database
+func (s *tableUsageService) ForgetTables(chatID string, tables []string) {
+	chatObjID, err := primitive.ObjectIDFromHex(chatID)
+	if err != nil {
+		return
+	}
+	if err := s.tableUsageRepo.DeleteTables(chatObjID, tables); err != nil {
+		log.Printf("TableUsageService -> ForgetTables -> Error deleting table usage: %v", err)
+	}
+}
+
+// DeleteChatUsage removes the table usage recorded for a chat
+func (s *tableUsageService) DeleteChatUsage(chatID primitive.ObjectID) error {
+	return s.tableUsageRepo.DeleteByChatID(chatID)
+}
@@ -3,18 +3,19 @@ package utils
 import (
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net/http"
-	"os"
 	"time"
 )
 
+// maxCertificateSize caps the size of a certificate fetched from a URL
+const maxCertificateSize = 1 << 20
+
 // GenerateConfigKey creates a unique string key for a database configuration
 func GenerateConfigKey(config map[string]interface{}) string {
 	var username string
 	if config["username"] != nil {
-		if username, ok := config["username"].(string); ok {
-			username = username
+		if usernameStr, ok := config["username"].(string); ok {
+			username = usernameStr
 		} else if usernameString, ok := config["username"].(*string); ok {
 			username = *usernameString
 		}
@@ -36,18 +37,16 @@ func GenerateConfigKey(config map[string]interface{}) string {
 		username,
 		config["database"])
 
+	// Databricks warehouses share the workspace host, the HTTP path identifies the warehouse
+	if httpPath, ok := config["httpPath"].(*string); ok && httpPath != nil && *httpPath != "" {
+		key += ":" + *httpPath
+	}
+
 	return key
 }
 
-// FetchCertificateFromURL downloads a certificate from a URL and stores it temporarily
-func FetchCertificateFromURL(url string) (string, error) {
-	// Create a temporary file
-	tmpFile, err := ioutil.TempFile("", "cert-*.pem")
-	if err != nil {
-		return "", fmt.Errorf("failed to create temporary file: %v", err)
-	}
-	defer tmpFile.Close()
-
+// FetchCertificateContent downloads a certificate from a URL, the certificate is kept in memory
+func FetchCertificateContent(url string) ([]byte, error) {
 	// Create HTTP client with timeout
 	client := &http.Client{
 		Timeout: 30 * time.Second,
@@ -56,65 +55,22 @@ func FetchCertificateFromURL(url string) (string, error) {
 	// Fetch the certificate
 	resp, err := client.Get(url)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch certificate from URL: %v", err)
+		return nil, fmt.Errorf("failed to fetch certificate from URL: %v", err)
 	}
 	defer resp.Body.Close()
 
 	// Check response status
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("failed to fetch certificate, status: %s", resp.Status)
+		return nil, fmt.Errorf("failed to fetch certificate, status: %s", resp.Status)
 	}
 
-	// Copy the certificate to the temporary file
-	_, err = io.Copy(tmpFile, resp.Body)
+	// Certificates are a few KB, a larger response isn't a certificate
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxCertificateSize+1))
 	if err != nil {
-		return "", fmt.Errorf("failed to save certificate: %v", err)
+		return nil, fmt.Errorf("failed to read certificate: %v", err)
 	}
-
-	// Return the path to the temporary file
-	return tmpFile.Name(), nil
-}
-
-// PrepareCertificatesFromURLs fetches certificates from URLs and returns their local paths
-func PrepareCertificatesFromURLs(sslCertURL, sslKeyURL, sslRootCertURL string) (certPath, keyPath, rootCertPath string, tempFiles []string, err error) {
-	// Fetch client certificate if URL provided
-	if sslCertURL != "" {
-		certPath, err = FetchCertificateFromURL(sslCertURL)
-		if err != nil {
-			// Clean up any files already created
-			for _, file := range tempFiles {
-				os.Remove(file)
-			}
-			return "", "", "", nil, fmt.Errorf("failed to fetch client certificate: %v", err)
-		}
-		tempFiles = append(tempFiles, certPath)
-	}
-
-	// Fetch client key if URL provided
-	if sslKeyURL != "" {
-		keyPath, err = FetchCertificateFromURL(sslKeyURL)
-		if err != nil {
-			// Clean up any files already created
-			for _, file := range tempFiles {
-				os.Remove(file)
-			}
-			return "", "", "", nil, fmt.Errorf("failed to fetch client key: %v", err)
-		}
-		tempFiles = append(tempFiles, keyPath)
+	if len(content) > maxCertificateSize {
+		return nil, fmt.Errorf("certificate is larger than %d bytes", maxCertificateSize)
 	}
-
-	// Fetch CA certificate if URL provided
-	if sslRootCertURL != "" {
-		rootCertPath, err = FetchCertificateFromURL(sslRootCertURL)
-		if err != nil {
-			// Clean up any files already created
-			for _, file := range tempFiles {
-				os.Remove(file)
-			}
-			return "", "", "", nil, fmt.Errorf("failed to fetch CA certificate: %v", err)
-		}
-		tempFiles = append(tempFiles, rootCertPath)
-	}
-
-	return certPath, keyPath, rootCertPath, tempFiles, nil
+	return content, nil
 }
@@ -83,6 +83,108 @@ func EncryptConnection(conn *models.Connection) error {
 		}
 	}
 
+	// Encrypt uploaded SSL certificates if present
+	if conn.SSLCert != nil {
+		if encryptedCert, err := encrypt(*conn.SSLCert, key); err == nil {
+			*conn.SSLCert = encryptedCert
+		} else {
+			return fmt.Errorf("failed to encrypt SSL certificate: %v", err)
+		}
+	}
+
+	if conn.SSLKey != nil {
+		if encryptedKey, err := encrypt(*conn.SSLKey, key); err == nil {
+			*conn.SSLKey = encryptedKey
+		} else {
+			return fmt.Errorf("failed to encrypt SSL key: %v", err)
+		}
+	}
+
+	if conn.SSLRootCert != nil {
+		if encryptedCert, err := encrypt(*conn.SSLRootCert, key); err == nil {
+			*conn.SSLRootCert = encryptedCert
+		} else {
+			return fmt.Errorf("failed to encrypt SSL root certificate: %v", err)
+		}
+	}
+
+	// Encrypt Databricks access token if present
+	if conn.AccessToken != nil {
+		if encryptedToken, err := encrypt(*conn.AccessToken, key); err == nil {
+			*conn.AccessToken = encryptedToken
+		} else {
+			return fmt.Errorf("failed to encrypt access token: %v", err)
+		}
+	}
+
+	// Encrypt Firestore service account credentials if present
+	if conn.CredentialsJSON != nil {
+		if encryptedCredentials, err := encrypt(*conn.CredentialsJSON, key); err == nil {
+			*conn.CredentialsJSON = encryptedCredentials
+		} else {
+			return fmt.Errorf("failed to encrypt credentials: %v", err)
+		}
+	}
+
+	// Encrypt Azure AD client secret if present
+	if conn.AzureClientSecret != nil {
+		if encryptedSecret, err := encrypt(*conn.AzureClientSecret, key); err == nil {
+			*conn.AzureClientSecret = encryptedSecret
+		} else {
+			return fmt.Errorf("failed to encrypt azure client secret: %v", err)
+		}
+	}
+
+	// Encrypt AWS secret access key if present
+	if conn.AWSSecretAccessKey != nil {
+		if encryptedKey, err := encrypt(*conn.AWSSecretAccessKey, key); err == nil {
+			*conn.AWSSecretAccessKey = encryptedKey
+		} else {
+			return fmt.Errorf("failed to encrypt aws secret access key: %v", err)
+		}
+	}
+
+	// Encrypt Kerberos keytab if present
+	if conn.KerberosKeytab != nil {
+		if encryptedKeytab, err := encrypt(*conn.KerberosKeytab, key); err == nil {
+			*conn.KerberosKeytab = encryptedKeytab
+		} else {
+			return fmt.Errorf("failed to encrypt kerberos keytab: %v", err)
+		}
+	}
+
+	// Encrypt Unix socket path if present
+	if conn.SocketPath != nil {
+		if encryptedPath, err := encrypt(*conn.SocketPath, key); err == nil {
+			*conn.SocketPath = encryptedPath
+		} else {
+			return fmt.Errorf("failed to encrypt socket path: %v", err)
+		}
+	}
+
+	// Encrypt proxy URL if present, it may contain the proxy credentials
+	if conn.ProxyURL != nil {
+		if encryptedProxyURL, err := encrypt(*conn.ProxyURL, key); err == nil {
+			*conn.ProxyURL = encryptedProxyURL
+		} else {
+			return fmt.Errorf("failed to encrypt proxy URL: %v", err)
+		}
+	}
+
+	// Encrypt read replica endpoints like the primary's
+	for i := range conn.ReadReplicas {
+		encryptedHost, err := encrypt(conn.ReadReplicas[i].Host, key)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt read replica host: %v", err)
+		}
+		conn.ReadReplicas[i].Host = encryptedHost
+		if conn.ReadReplicas[i].Port != nil {
+			if encryptedPort, err := encrypt(*conn.ReadReplicas[i].Port, key); err == nil {
+				*conn.ReadReplicas[i].Port = encryptedPort
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -156,6 +258,118 @@ func DecryptConnection(conn *models.Connection) {
 			log.Printf("Warning: Failed to decrypt SSL root certificate URL, using as-is: %v", err)
 		}
 	}
+
+	// Decrypt uploaded SSL certificates if present
+	if conn.SSLCert != nil {
+		if decryptedCert, err := decrypt(*conn.SSLCert, key); err == nil {
+			*conn.SSLCert = decryptedCert
+		} else {
+			log.Printf("Warning: Failed to decrypt SSL certificate, using as-is: %v", err)
+		}
+	}
+
+	if conn.SSLKey != nil {
+		if decryptedKey, err := decrypt(*conn.SSLKey, key); err == nil {
+			*conn.SSLKey = decryptedKey
+		} else {
+			log.Printf("Warning: Failed to decrypt SSL key, using as-is: %v", err)
+		}
+	}
+
+	if conn.SSLRootCert != nil {
+		if decryptedCert, err := decrypt(*conn.SSLRootCert, key); err == nil {
+			*conn.SSLRootCert = decryptedCert
+		} else {
+			log.Printf("Warning: Failed to decrypt SSL root certificate, using as-is: %v", err)
+		}
+	}
+
+	// Decrypt Databricks access token if present
+	if conn.AccessToken != nil {
+		if decryptedToken, err := decrypt(*conn.AccessToken, key); err == nil {
+			*conn.AccessToken = decryptedToken
+		} else {
+			log.Printf("Warning: Failed to decrypt access token, using as-is: %v", err)
+		}
+	}
+
+	// Decrypt Firestore service account credentials if present
+	if conn.CredentialsJSON != nil {
+		if decryptedCredentials, err := decrypt(*conn.CredentialsJSON, key); err == nil {
+			*conn.CredentialsJSON = decryptedCredentials
+		} else {
+			log.Printf("Warning: Failed to decrypt credentials, using as-is: %v", err)
+		}
+	}
+
+	// Decrypt Azure AD client secret if present
+	if conn.AzureClientSecret != nil {
+		if decryptedSecret, err := decrypt(*conn.AzureClientSecret, key); err == nil {
+			*conn.AzureClientSecret = decryptedSecret
+		} else {
+			log.Printf("Warning: Failed to decrypt azure client secret, using as-is: %v", err)
+		}
+	}
+
+	// Decrypt AWS secret access key if present
+	if conn.AWSSecretAccessKey != nil {
+		if decryptedKey, err := decrypt(*conn.AWSSecretAccessKey, key); err == nil {
+			*conn.AWSSecretAccessKey = decryptedKey
+		} else {
+			log.Printf("Warning: Failed to decrypt aws secret access key, using as-is: %v", err)
+		}
+	}
+
+	// Decrypt Kerberos keytab if present
+	if conn.KerberosKeytab != nil {
+		if decryptedKeytab, err := decrypt(*conn.KerberosKeytab, key); err == nil {
+			*conn.KerberosKeytab = decryptedKeytab
+		} else {
+			log.Printf("Warning: Failed to decrypt kerberos keytab, using as-is: %v", err)
+		}
+	}
+
+	// Decrypt Unix socket path if present
+	if conn.SocketPath != nil {
+		if decryptedPath, err := decrypt(*conn.SocketPath, key); err == nil {
+			*conn.SocketPath = decryptedPath
+		} else {
+			log.Printf("Warning: Failed to decrypt socket path, using as-is: %v", err)
+		}
+	}
+
+	// Decrypt proxy URL if present
+	if conn.ProxyURL != nil {
+		if decryptedProxyURL, err := decrypt(*conn.ProxyURL, key); err == nil {
+			*conn.ProxyURL = decryptedProxyURL
+		} else {
+			log.Printf("Warning: Failed to decrypt proxy URL, using as-is: %v", err)
+		}
+	}
+
+	// Decrypt read replica endpoints
+	for i := range conn.ReadReplicas {
+		if decryptedHost, err := decrypt(conn.ReadReplicas[i].Host, key); err == nil {
+			conn.ReadReplicas[i].Host = decryptedHost
+		} else {
+			log.Printf("Warning: Failed to decrypt read replica host, using as-is: %v", err)
+		}
+		if conn.ReadReplicas[i].Port != nil {
+			if decryptedPort, err := decrypt(*conn.ReadReplicas[i].Port, key); err == nil {
+				*conn.ReadReplicas[i].Port = decryptedPort
+			}
+		}
+	}
+}
+
+// EncryptSecret encrypts a standalone secret (e.g. an LLM provider API key) with the schema encryption key
+func EncryptSecret(secret string) (string, error) {
+	return encrypt(secret, []byte(config.Env.SchemaEncryptionKey))
+}
+
+// DecryptSecret decrypts a secret encrypted with EncryptSecret
+func DecryptSecret(encryptedSecret string) (string, error) {
+	return decrypt(encryptedSecret, []byte(config.Env.SchemaEncryptionKey))
 }
 
 // encrypt encrypts a string using AES-GCM
@@ -0,0 +1,442 @@
+package dbmanager
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"neobase-ai/internal/constants"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"gorm.io/gorm"
+)
+
+const (
+	activityStatementLimit = 20  // Max running statements kept in a snapshot
+	activityQueryMaxLength = 500 // Statements are truncated so the snapshot stays small in the LLM context
+	activityCollectTimeout = 5 * time.Second
+)
+
+// ActiveStatement is a statement currently executing on the database server
+type ActiveStatement struct {
+	PID        string `json:"pid"`
+	User       string `json:"user,omitempty"`
+	State      string `json:"state,omitempty"`
+	WaitEvent  string `json:"wait_event,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	Query      string `json:"query"`
+}
+
+// LockWait is a session waiting on a lock held by another session
+type LockWait struct {
+	WaitingPID    string `json:"waiting_pid"`
+	BlockingPID   string `json:"blocking_pid,omitempty"`
+	WaitingQuery  string `json:"waiting_query,omitempty"`
+	BlockingQuery string `json:"blocking_query,omitempty"`
+	WaitMs        int64  `json:"wait_ms"`
+	LockedObject  string `json:"locked_object,omitempty"`
+}
+
+// ActivitySnapshot is the live activity of a database at a point in time
+type ActivitySnapshot struct {
+	DBType     string            `json:"db_type"`
+	CapturedAt time.Time         `json:"captured_at"`
+	Statements []ActiveStatement `json:"statements"`
+	LockWaits  []LockWait        `json:"lock_waits"`
+	Warnings   []string          `json:"warnings,omitempty"` // Collectors that failed, e.g. missing privileges on the monitoring views
+}
+
+// Live activity queries, every collector aliases its columns the same way so the rows can be mapped generically
+const (
+	postgresActivityQuery = `SELECT pid::text AS pid, usename AS user_name, state, COALESCE(wait_event_type || ':' || wait_event, '') AS wait_event,
+	(EXTRACT(EPOCH FROM (now() - query_start)) * 1000)::bigint AS duration_ms, LEFT(query, 500) AS query
+FROM pg_stat_activity
+WHERE state <> 'idle' AND pid <> pg_backend_pid() AND backend_type = 'client backend'
+ORDER BY query_start
+LIMIT 20`
+
+	postgresLockWaitsQuery = `SELECT blocked.pid::text AS waiting_pid, blocking.pid::text AS blocking_pid,
+	LEFT(blocked.query, 500) AS waiting_query, LEFT(blocking.query, 500) AS blocking_query,
+	(EXTRACT(EPOCH FROM (now() - blocked.query_start)) * 1000)::bigint AS wait_ms, '' AS locked_object
+FROM pg_stat_activity blocked
+JOIN LATERAL unnest(pg_blocking_pids(blocked.pid)) AS b(pid) ON true
+JOIN pg_stat_activity blocking ON blocking.pid = b.pid
+LIMIT 20`
+
+	mysqlActivityQuery = `SELECT ID AS pid, USER AS user_name, COMMAND AS state, COALESCE(STATE, '') AS wait_event,
+	TIME * 1000 AS duration_ms, LEFT(COALESCE(INFO, ''), 500) AS query
+FROM information_schema.PROCESSLIST
+WHERE COMMAND NOT IN ('Sleep', 'Daemon', 'Binlog Dump') AND ID <> CONNECTION_ID()
+ORDER BY TIME DESC
+LIMIT 20`
+
+	mysqlLockWaitsQuery = `SELECT waiting_pid, blocking_pid, LEFT(waiting_query, 500) AS waiting_query, LEFT(blocking_query, 500) AS blocking_query,
+	wait_age_secs * 1000 AS wait_ms, locked_table AS locked_object
+FROM sys.innodb_lock_waits
+LIMIT 20`
+
+	// Fallback for servers without the sys schema (MySQL 5.7 without sys, older MariaDB)
+	mysqlLegacyLockWaitsQuery = `SELECT r.trx_mysql_thread_id AS waiting_pid, b.trx_mysql_thread_id AS blocking_pid,
+	LEFT(r.trx_query, 500) AS waiting_query, LEFT(b.trx_query, 500) AS blocking_query,
+	TIMESTAMPDIFF(SECOND, r.trx_wait_started, NOW()) * 1000 AS wait_ms, '' AS locked_object
+FROM information_schema.INNODB_LOCK_WAITS w
+JOIN information_schema.INNODB_TRX b ON b.trx_id = w.blocking_trx_id
+JOIN information_schema.INNODB_TRX r ON r.trx_id = w.requesting_trx_id
+LIMIT 20`
+
+	clickhouseActivityQuery = `SELECT query_id AS pid, user AS user_name, '' AS state, '' AS wait_event,
+	toInt64(elapsed * 1000) AS duration_ms, substring(query, 1, 500) AS query
+FROM system.processes
+WHERE query_id != queryID()
+ORDER BY elapsed DESC
+LIMIT 20`
+
+	db2ActivityQuery = `SELECT VARCHAR(APPLICATION_HANDLE) AS pid, '' AS user_name, ACTIVITY_STATE AS state, '' AS wait_event,
+	ELAPSED_TIME_SEC * 1000 AS duration_ms, SUBSTR(STMT_TEXT, 1, 500) AS query
+FROM SYSIBMADM.MON_CURRENT_SQL
+WHERE APPLICATION_HANDLE <> MON_GET_APPLICATION_HANDLE()
+ORDER BY ELAPSED_TIME_SEC DESC
+FETCH FIRST 20 ROWS ONLY`
+
+	db2LockWaitsQuery = `SELECT VARCHAR(REQ_APPLICATION_HANDLE) AS waiting_pid, VARCHAR(HLD_APPLICATION_HANDLE) AS blocking_pid,
+	SUBSTR(REQ_STMT_TEXT, 1, 500) AS waiting_query, SUBSTR(HLD_CURRENT_STMT_TEXT, 1, 500) AS blocking_query,
+	LOCK_WAIT_ELAPSED_TIME * 1000 AS wait_ms, TRIM(TABSCHEMA) || '.' || TRIM(TABNAME) AS locked_object
+FROM SYSIBMADM.MON_LOCKWAITS
+FETCH FIRST 20 ROWS ONLY`
+)
+
+// CollectActivity captures the statements currently running and the lock waits on the chat's database
+func (m *Manager) CollectActivity(ctx context.Context, chatID string) (*ActivitySnapshot, error) {
+	m.mu.RLock()
+	conn, exists := m.connections[chatID]
+	m.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("connection not found for chat %s", chatID)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, activityCollectTimeout)
+	defer cancel()
+
+	snapshot := &ActivitySnapshot{
+		DBType:     conn.Config.Type,
+		CapturedAt: time.Now(),
+		Statements: []ActiveStatement{},
+		LockWaits:  []LockWait{},
+	}
+
+	switch conn.Config.Type {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB:
+		m.collectSQLActivity(ctx, conn.DB, snapshot, postgresActivityQuery, postgresLockWaitsQuery)
+	case constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB:
+		m.collectSQLActivity(ctx, conn.DB, snapshot, mysqlActivityQuery, mysqlLockWaitsQuery, mysqlLegacyLockWaitsQuery)
+	case constants.DatabaseTypeSingleStore:
+		// SingleStore doesn't expose InnoDB lock waits, the process list is all we can get
+		m.collectSQLActivity(ctx, conn.DB, snapshot, mysqlActivityQuery)
+	case constants.DatabaseTypeClickhouse:
+		m.collectSQLActivity(ctx, conn.DB, snapshot, clickhouseActivityQuery)
+	case constants.DatabaseTypeDB2:
+		m.collectSQLActivity(ctx, conn.DB, snapshot, db2ActivityQuery, db2LockWaitsQuery)
+	case constants.DatabaseTypeMongoDB:
+		m.collectMongoDBActivity(ctx, conn, snapshot)
+	default:
+		return nil, fmt.Errorf("live activity is not supported for %s", conn.Config.Type)
+	}
+
+	log.Printf("DBManager -> CollectActivity -> chatID: %s, statements: %d, lock waits: %d, warnings: %d",
+		chatID, len(snapshot.Statements), len(snapshot.LockWaits), len(snapshot.Warnings))
+	return snapshot, nil
+}
+
+// collectSQLActivity runs the activity query, then the lock wait queries in order until one succeeds
+func (m *Manager) collectSQLActivity(ctx context.Context, db *gorm.DB, snapshot *ActivitySnapshot, activityQuery string, lockQueries ...string) {
+	if db == nil {
+		snapshot.Warnings = append(snapshot.Warnings, "database connection is not available")
+		return
+	}
+
+	var statementRows []map[string]interface{}
+	if err := db.WithContext(ctx).Raw(activityQuery).Scan(&statementRows).Error; err != nil {
+		log.Printf("DBManager -> collectSQLActivity -> Error fetching running statements: %v", err)
+		snapshot.Warnings = append(snapshot.Warnings, fmt.Sprintf("could not read running statements: %v", err))
+	}
+	for _, row := range statementRows {
+		row = lowerActivityKeys(row)
+		snapshot.Statements = append(snapshot.Statements, ActiveStatement{
+			PID:        activityString(row["pid"]),
+			User:       activityString(row["user_name"]),
+			State:      activityString(row["state"]),
+			WaitEvent:  activityString(row["wait_event"]),
+			DurationMs: activityInt(row["duration_ms"]),
+			Query:      truncateActivityQuery(maskSQLLiterals(activityString(row["query"]))),
+		})
+	}
+
+	var lockErr error
+	for _, lockQuery := range lockQueries {
+		var lockRows []map[string]interface{}
+		if lockErr = db.WithContext(ctx).Raw(lockQuery).Scan(&lockRows).Error; lockErr != nil {
+			continue
+		}
+		for _, row := range lockRows {
+			row = lowerActivityKeys(row)
+			snapshot.LockWaits = append(snapshot.LockWaits, LockWait{
+				WaitingPID:    activityString(row["waiting_pid"]),
+				BlockingPID:   activityString(row["blocking_pid"]),
+				WaitingQuery:  truncateActivityQuery(maskSQLLiterals(activityString(row["waiting_query"]))),
+				BlockingQuery: truncateActivityQuery(maskSQLLiterals(activityString(row["blocking_query"]))),
+				WaitMs:        activityInt(row["wait_ms"]),
+				LockedObject:  activityString(row["locked_object"]),
+			})
+		}
+		break
+	}
+	if lockErr != nil {
+		log.Printf("DBManager -> collectSQLActivity -> Error fetching lock waits: %v", lockErr)
+		snapshot.Warnings = append(snapshot.Warnings, fmt.Sprintf("could not read lock waits: %v", lockErr))
+	}
+}
+
+// collectMongoDBActivity reads the in-progress operations through the currentOp command
+func (m *Manager) collectMongoDBActivity(ctx context.Context, conn *Connection, snapshot *ActivitySnapshot) {
+	wrapper, ok := conn.MongoDBObj.(*MongoDBWrapper)
+	if !ok {
+		snapshot.Warnings = append(snapshot.Warnings, "invalid MongoDB connection")
+		return
+	}
+
+	var result struct {
+		InProg []bson.M `bson:"inprog"`
+	}
+	cmd := bson.D{{Key: "currentOp", Value: 1}, {Key: "active", Value: true}}
+	if err := wrapper.Client.Database("admin").RunCommand(ctx, cmd).Decode(&result); err != nil {
+		log.Printf("DBManager -> collectMongoDBActivity -> Error running currentOp: %v", err)
+		snapshot.Warnings = append(snapshot.Warnings, fmt.Sprintf("could not read current operations: %v", err))
+		return
+	}
+
+	for _, op := range result.InProg {
+		ns := activityString(op["ns"])
+		// Skip our own currentOp & internal operations on other databases
+		if ns != "" && !strings.HasPrefix(ns, wrapper.Database+".") {
+			continue
+		}
+		if cmdDoc, ok := op["command"].(bson.M); ok {
+			if _, isCurrentOp := cmdDoc["currentOp"]; isCurrentOp {
+				continue
+			}
+		}
+
+		query := ""
+		if cmdDoc, ok := op["command"]; ok {
+			if data, err := bson.MarshalExtJSON(maskMongoLiterals(cmdDoc, true), false, false); err == nil {
+				query = truncateActivityQuery(string(data))
+			}
+		}
+
+		opID := activityString(op["opid"])
+		durationMs := activityInt(op["microsecs_running"]) / 1000
+		if len(snapshot.Statements) < activityStatementLimit {
+			snapshot.Statements = append(snapshot.Statements, ActiveStatement{
+				PID:        opID,
+				State:      activityString(op["op"]),
+				DurationMs: durationMs,
+				Query:      query,
+			})
+		}
+
+		// currentOp doesn't report the lock holder, only that the operation is waiting
+		if waiting, _ := op["waitingForLock"].(bool); waiting {
+			snapshot.LockWaits = append(snapshot.LockWaits, LockWait{
+				WaitingPID:   opID,
+				WaitingQuery: query,
+				WaitMs:       durationMs,
+				LockedObject: ns,
+			})
+		}
+	}
+}
+
+// FormatActivityForLLM renders the snapshot as plain text for the LLM context
+func FormatActivityForLLM(snapshot *ActivitySnapshot) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Live activity of the %s database captured at %s.\n", snapshot.DBType, snapshot.CapturedAt.UTC().Format(time.RFC3339)))
+
+	if len(snapshot.Statements) == 0 {
+		sb.WriteString("\nRunning statements: none (no other session is executing a statement).\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("\nRunning statements (%d, longest first):\n", len(snapshot.Statements)))
+		for _, stmt := range snapshot.Statements {
+			sb.WriteString(fmt.Sprintf("- [pid %s] running for %dms", stmt.PID, stmt.DurationMs))
+			if stmt.User != "" {
+				sb.WriteString(fmt.Sprintf(", user %s", stmt.User))
+			}
+			if stmt.State != "" {
+				sb.WriteString(fmt.Sprintf(", state %s", stmt.State))
+			}
+			if stmt.WaitEvent != "" {
+				sb.WriteString(fmt.Sprintf(", waiting on %s", stmt.WaitEvent))
+			}
+			if stmt.Query != "" {
+				sb.WriteString(fmt.Sprintf(": %s", stmt.Query))
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	if len(snapshot.LockWaits) == 0 {
+		sb.WriteString("\nLock waits: none.\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("\nLock waits (%d):\n", len(snapshot.LockWaits)))
+		for _, wait := range snapshot.LockWaits {
+			sb.WriteString(fmt.Sprintf("- [pid %s] waiting %dms", wait.WaitingPID, wait.WaitMs))
+			if wait.BlockingPID != "" {
+				sb.WriteString(fmt.Sprintf(", blocked by pid %s", wait.BlockingPID))
+			}
+			if wait.LockedObject != "" {
+				sb.WriteString(fmt.Sprintf(" on %s", wait.LockedObject))
+			}
+			if wait.WaitingQuery != "" {
+				sb.WriteString(fmt.Sprintf("\n  waiting statement: %s", wait.WaitingQuery))
+			}
+			if wait.BlockingQuery != "" {
+				sb.WriteString(fmt.Sprintf("\n  blocking statement: %s", wait.BlockingQuery))
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	for _, warning := range snapshot.Warnings {
+		sb.WriteString(fmt.Sprintf("\nNote: %s\n", warning))
+	}
+
+	return sb.String()
+}
+
+func lowerActivityKeys(row map[string]interface{}) map[string]interface{} {
+	lowered := make(map[string]interface{}, len(row))
+	for key, value := range row {
+		lowered[strings.ToLower(key)] = value
+	}
+	return lowered
+}
+
+func activityString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, activityString(item))
+		}
+		return strings.Join(parts, ", ")
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func activityInt(value interface{}) int64 {
+	switch v := value.(type) {
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case int64:
+		return v
+	case uint32:
+		return int64(v)
+	case uint64:
+		return int64(v)
+	case float32:
+		return int64(v)
+	case float64:
+		return int64(v)
+	case []byte, string:
+		var parsed float64
+		fmt.Sscanf(activityString(v), "%f", &parsed)
+		return int64(parsed)
+	default:
+		return 0
+	}
+}
+
+// maskSQLLiterals replaces the strings & numbers of a statement by "?", the statements of other sessions hold the values of
+// the application's data. Comments are dropped as they may hold values too.
+func maskSQLLiterals(query string) string {
+	tokens := tokenizeSQL(query, false)
+	if len(tokens) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	for i, token := range tokens {
+		if i > 0 && token.start > tokens[i-1].end {
+			sb.WriteByte(' ')
+		}
+		if token.kind == sqlTokenString || token.kind == sqlTokenNumber {
+			sb.WriteByte('?')
+		} else {
+			sb.WriteString(token.text)
+		}
+	}
+	return sb.String()
+}
+
+// mongoCommandTargets are the top level command fields naming the collection or the database, they're kept when masking
+var mongoCommandTargets = map[string]bool{
+	"find": true, "aggregate": true, "count": true, "distinct": true, "insert": true, "update": true, "delete": true,
+	"findAndModify": true, "findandmodify": true, "mapReduce": true, "createIndexes": true, "collection": true, "$db": true,
+}
+
+// maskMongoLiterals replaces the values of a command by "?", the field names & the collection are kept
+func maskMongoLiterals(value interface{}, top bool) interface{} {
+	switch v := value.(type) {
+	case bson.M:
+		masked := make(bson.M, len(v))
+		for key, item := range v {
+			if top && mongoCommandTargets[key] {
+				masked[key] = item
+				continue
+			}
+			masked[key] = maskMongoLiterals(item, false)
+		}
+		return masked
+	case bson.D:
+		masked := make(bson.D, 0, len(v))
+		for _, elem := range v {
+			if top && mongoCommandTargets[elem.Key] {
+				masked = append(masked, elem)
+				continue
+			}
+			masked = append(masked, bson.E{Key: elem.Key, Value: maskMongoLiterals(elem.Value, false)})
+		}
+		return masked
+	case bson.A:
+		masked := make(bson.A, 0, len(v))
+		for _, item := range v {
+			masked = append(masked, maskMongoLiterals(item, false))
+		}
+		return masked
+	case []interface{}:
+		masked := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			masked = append(masked, maskMongoLiterals(item, false))
+		}
+		return masked
+	default:
+		return "?"
+	}
+}
+
+func truncateActivityQuery(query string) string {
+	query = strings.Join(strings.Fields(query), " ")
+	if len(query) > activityQueryMaxLength {
+		return query[:activityQueryMaxLength] + "..."
+	}
+	return query
+}
@@ -0,0 +1,303 @@
+package dbmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/constants"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// affectedRowsSampleSize is the rows of a preview read along the count
+const affectedRowsSampleSize = 5
+
+// mongoWriteCallRegex matches the MongoDB writes whose filter selects the documents they change, e.g. updateMany({...}, {...})
+var mongoWriteCallRegex = regexp.MustCompile(`(?s)^(updateOne|updateMany|replaceOne|deleteOne|deleteMany|remove)\s*\((.*)\)\s*;?\s*$`)
+
+// AffectedRowsPreview is what an UPDATE or a DELETE would change, read before it runs with a query of the same predicate
+type AffectedRowsPreview struct {
+	Count  int64         `json:"count"`
+	Sample []interface{} `json:"sample"` // The first rows or documents the query would change
+}
+
+// affectedRowsQueries are the reads previewing a write: the count of the rows it changes & a sample of them
+type affectedRowsQueries struct {
+	count  string
+	sample string
+	single bool // The write changes one document at most, e.g. updateOne
+}
+
+// PreviewAffectedRows counts the rows an UPDATE or a DELETE would change & reads a sample of them, with a SELECT of the same table &
+// predicate, updateMany & deleteMany are previewed with countDocuments & find of their filter. Only a single write of one table can be
+// previewed, a multi-table or a joined write is not.
+func (m *Manager) PreviewAffectedRows(ctx context.Context, chatID, query string) (*AffectedRowsPreview, *dtos.QueryError) {
+	conn, driver, queryErr := m.groundingConnection(chatID)
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	query, queryErr = prepareQueryParams(ctx, conn.Config.Type, query)
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	reads, ok := affectedRowsReads(conn.Config.Type, query)
+	if !ok {
+		return nil, &dtos.QueryError{
+			Code:    "PREVIEW_NOT_SUPPORTED",
+			Message: "the rows the query changes can't be previewed",
+			Details: "Only a single UPDATE or DELETE of one table, or a MongoDB update or delete, can be previewed",
+		}
+	}
+
+	countJSON, queryErr := m.runGroundingQuery(ctx, conn, driver, reads.count, true)
+	if queryErr != nil {
+		return nil, queryErr
+	}
+	count, ok := previewCount(countJSON)
+	if !ok {
+		return nil, &dtos.QueryError{
+			Code:    "INVALID_RESULT",
+			Message: "failed to read the count of the rows",
+			Details: countJSON,
+		}
+	}
+
+	sampleJSON, queryErr := m.runGroundingQuery(ctx, conn, driver, reads.sample, true)
+	if queryErr != nil {
+		return nil, queryErr
+	}
+	sample := previewRows(sampleJSON)
+	limit := affectedRowsSampleSize
+	if reads.single {
+		count = min(count, 1)
+		limit = 1
+	}
+	if len(sample) > limit {
+		sample = sample[:limit]
+	}
+	return &AffectedRowsPreview{Count: count, Sample: sample}, nil
+}
+
+// sqlWrite is a single UPDATE or DELETE of one table, split into the clauses a SELECT of the same rows is built from
+type sqlWrite struct {
+	delete  bool
+	table   []sqlToken // The table with its alias
+	set     []sqlToken // The assignments of an UPDATE
+	where   []sqlToken // The WHERE clause, empty without one
+	ordered []sqlToken // The ORDER BY & LIMIT clauses limiting the write to its first rows
+}
+
+// affectedRowsReads returns the reads previewing a write, false when the query is not a write they can be built for
+func affectedRowsReads(dbType, query string) (affectedRowsQueries, bool) {
+	if dbType == constants.DatabaseTypeMongoDB {
+		write, ok := parseMongoDBWrite(strings.TrimSpace(query))
+		if !ok {
+			return affectedRowsQueries{}, false
+		}
+		return affectedRowsQueries{
+			count:  fmt.Sprintf("%scountDocuments(%s)", write.prefix, write.filter),
+			sample: write.findQuery(affectedRowsSampleSize),
+			single: write.single,
+		}, true
+	}
+
+	write, ok := parseSQLWrite(dbType, query)
+	if !ok {
+		return affectedRowsQueries{}, false
+	}
+	return affectedRowsQueries{
+		count:  "SELECT COUNT(*) AS affected_rows " + write.from(),
+		sample: write.rowsQuery(affectedRowsSampleSize),
+	}, true
+}
+
+// parseSQLWrite splits a single UPDATE or DELETE of one table, false for the other queries & the multi-table or joined writes
+func parseSQLWrite(dbType, query string) (*sqlWrite, bool) {
+	switch dbType {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB, constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB,
+		constants.DatabaseTypeSingleStore:
+	default:
+		return nil, false
+	}
+
+	foldCase := dbType == constants.DatabaseTypePostgreSQL || dbType == constants.DatabaseTypeYugabyteDB
+	statements := splitSQLTokens(tokenizeSQL(strings.TrimSpace(query), foldCase))
+	if len(statements) != 1 || len(statements[0]) == 0 {
+		return nil, false
+	}
+	tokens := statements[0]
+
+	write := &sqlWrite{}
+	var rest []sqlToken
+	switch {
+	case tokens[0].isKeyword("UPDATE"):
+		i := skipKeywords(tokens, 1, "LOW_PRIORITY", "IGNORE")
+		set := findTopLevel(tokens, i, "SET")
+		if set >= len(tokens) {
+			return nil, false
+		}
+		write.table = tokens[i:set]
+		rest = tokens[set+1:]
+		// UPDATE ... FROM joins other tables, the rows it changes are not those of a plain SELECT
+		if from := findTopLevel(rest, 0, "FROM"); from < findTopLevel(rest, 0, "WHERE") {
+			return nil, false
+		}
+		end := findTopLevel(rest, 0, "WHERE", "ORDER", "LIMIT", "RETURNING")
+		write.set = rest[:end]
+		rest = rest[end:]
+	case tokens[0].isKeyword("DELETE"):
+		write.delete = true
+		i := skipKeywords(tokens, 1, "LOW_PRIORITY", "QUICK", "IGNORE")
+		// DELETE t1, t2 FROM ... deletes from several tables
+		if i >= len(tokens) || !tokens[i].isKeyword("FROM") {
+			return nil, false
+		}
+		end := findTopLevel(tokens, i+1, "WHERE", "USING", "ORDER", "LIMIT", "RETURNING")
+		write.table = tokens[i+1 : end]
+		rest = tokens[end:]
+		if len(rest) > 0 && rest[0].isKeyword("USING") {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+	if len(write.table) == 0 || findTopLevel(write.table, 0, "JOIN") < len(write.table) || len(splitTopLevel(write.table)) != 1 {
+		return nil, false
+	}
+
+	// RETURNING only shapes the output of the write
+	rest = rest[:findTopLevel(rest, 0, "RETURNING")]
+	write.where = rest[:findTopLevel(rest, 0, "ORDER", "LIMIT")]
+	write.ordered = rest[len(write.where):]
+	if len(write.where) > 1 && write.where[1].isKeyword("CURRENT") {
+		// WHERE CURRENT OF a cursor has no meaning outside the cursor's transaction
+		return nil, false
+	}
+	return write, true
+}
+
+// from returns the FROM clause selecting the rows of the write, a write limited to its first rows changes the first rows of
+// the same SELECT
+func (w *sqlWrite) from() string {
+	selected := "FROM " + joinSQLTokens(w.table)
+	if len(w.where) > 0 {
+		selected += " " + joinSQLTokens(w.where)
+	}
+	if len(w.ordered) == 0 {
+		return selected
+	}
+	return fmt.Sprintf("FROM (SELECT * %s %s) AS affected", selected, joinSQLTokens(w.ordered))
+}
+
+// rowsQuery returns the SELECT of the first rows of the write
+func (w *sqlWrite) rowsQuery(limit int) string {
+	return fmt.Sprintf("SELECT * %s LIMIT %d", w.from(), limit)
+}
+
+// mongoDBWrite is a MongoDB update or delete, split into the filter selecting the documents it changes
+type mongoDBWrite struct {
+	prefix string // The collection the write runs on, e.g. db.users.
+	filter string
+	single bool // The write changes one document at most, e.g. updateOne
+}
+
+// parseMongoDBWrite splits a MongoDB update or delete, false for the other queries
+func parseMongoDBWrite(query string) (*mongoDBWrite, bool) {
+	collection, operation, ok := splitMongoCollectionQuery(query)
+	if !ok || collection == "" {
+		return nil, false
+	}
+	match := mongoWriteCallRegex.FindStringSubmatch(strings.TrimSpace(operation))
+	if match == nil {
+		return nil, false
+	}
+	filter := "{}"
+	if args := splitMongoArgs(match[2]); len(args) > 0 {
+		filter = args[0]
+	}
+	return &mongoDBWrite{
+		prefix: strings.TrimSuffix(query, operation),
+		filter: filter,
+		single: match[1] == "updateOne" || match[1] == "replaceOne" || match[1] == "deleteOne",
+	}, true
+}
+
+// findQuery returns the find of the first documents of the write
+func (w *mongoDBWrite) findQuery(limit int) string {
+	if w.single {
+		limit = 1
+	}
+	return fmt.Sprintf("%sfind(%s).limit(%d)", w.prefix, w.filter, limit)
+}
+
+// previewCount reads the count of a count query result: the count of countDocuments or the single value of the first row
+func previewCount(resultJSON string) (int64, bool) {
+	var decoded interface{}
+	decoder := json.NewDecoder(strings.NewReader(resultJSON))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err != nil {
+		return 0, false
+	}
+	if result, ok := decoded.(map[string]interface{}); ok {
+		if count, ok := result["count"]; ok {
+			return previewNumber(count)
+		}
+	}
+	rows := previewRowsOf(decoded)
+	if len(rows) == 0 {
+		return 0, false
+	}
+	row, ok := rows[0].(map[string]interface{})
+	if !ok {
+		return 0, false
+	}
+	if count, ok := row["affected_rows"]; ok {
+		return previewNumber(count)
+	}
+	for _, value := range row {
+		return previewNumber(value)
+	}
+	return 0, false
+}
+
+func previewNumber(value interface{}) (int64, bool) {
+	var text string
+	switch v := value.(type) {
+	case json.Number:
+		text = v.String()
+	case string:
+		text = v
+	default:
+		return 0, false
+	}
+	count, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+	return count, err == nil
+}
+
+// previewRows returns the rows of a read result, their numbers are kept as written so large integers keep their precision
+func previewRows(resultJSON string) []interface{} {
+	var decoded interface{}
+	decoder := json.NewDecoder(strings.NewReader(resultJSON))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err != nil {
+		return []interface{}{}
+	}
+	return previewRowsOf(decoded)
+}
+
+// previewRowsOf returns the rows of a decoded result, a list or the results of a map
+func previewRowsOf(decoded interface{}) []interface{} {
+	switch v := decoded.(type) {
+	case []interface{}:
+		return v
+	case map[string]interface{}:
+		if rows, ok := v["results"].([]interface{}); ok {
+			return rows
+		}
+	}
+	return []interface{}{}
+}
@@ -0,0 +1,436 @@
+package dbmanager
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"neobase-ai/internal/constants"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Masking strategies of an anonymized copy
+const (
+	MaskStrategyKeep      = "keep"       // Copied as is
+	MaskStrategyHash      = "hash"       // Deterministic hash, equal values stay equal so joins on the column keep working
+	MaskStrategyRedact    = "redact"     // Replaced with REDACTED
+	MaskStrategyNull      = "null"       // Replaced with NULL
+	MaskStrategyFixed     = "fixed"      // Replaced with the value of the rule
+	MaskStrategyPartial   = "partial"    // Everything but the last 4 characters is replaced with *
+	MaskStrategyFakeEmail = "fake_email" // Deterministic user_<hash>@example.com address
+	MaskStrategyFakeName  = "fake_name"  // Deterministic name picked from a list
+)
+
+const (
+	AnonymizationDefaultChunkSize = 500
+	AnonymizationMaxChunkSize     = 5000
+	// Bound parameters of a multi-row INSERT, PostgreSQL accepts at most 65535
+	anonymizationMaxInsertParams = 60000
+	// Source rows whose masked values are looked up in the target by the verification
+	anonymizationVerifySampleSize = 100
+	anonymizationRedactedValue    = "REDACTED"
+	anonymizationPartialKeptChars = 4
+)
+
+var anonymizationFakeFirstNames = []string{"Alex", "Sam", "Jordan", "Taylor", "Morgan", "Casey", "Riley", "Jamie", "Avery", "Quinn", "Robin", "Drew"}
+var anonymizationFakeLastNames = []string{"Smith", "Johnson", "Lee", "Brown", "Garcia", "Miller", "Davis", "Martin", "Clark", "Lewis", "Walker", "Young"}
+
+// MaskingRule masks a column of an anonymized table, the columns without a rule are copied as is
+type MaskingRule struct {
+	Column   string  `json:"column"`
+	Strategy string  `json:"strategy"`
+	Value    *string `json:"value,omitempty"` // Replacement of the fixed strategy
+}
+
+// AnonymizationTable is a table copied from the source to the target with its masking rules
+type AnonymizationTable struct {
+	Table string        `json:"table"`
+	Rules []MaskingRule `json:"rules"`
+}
+
+// AnonymizedCopyOptions holds the settings of an anonymized table copy
+type AnonymizedCopyOptions struct {
+	ChunkSize   int
+	Salt        string             // Secret of the hashes, a job uses the same salt for all its tables so hashed keys still match
+	ClearTarget bool               // Deletes the rows of the target table before copying
+	OnChunk     func(copied int64) // Called after every chunk written to the target
+}
+
+// AnonymizationVerification is the verification of an anonymized table copy
+type AnonymizationVerification struct {
+	SourceRows     int64    `json:"source_rows"`
+	TargetRows     int64    `json:"target_rows"`
+	CheckedValues  int      `json:"checked_values"` // Masked source values looked up in the target
+	LeakedValues   int64    `json:"leaked_values"`  // Target rows still holding one of the checked source values
+	LeakedColumns  []string `json:"leaked_columns"`
+	RowCountsMatch bool     `json:"row_counts_match"`
+}
+
+// ValidateAnonymizationTable checks the masking rules of a table
+func ValidateAnonymizationTable(table AnonymizationTable) error {
+	if strings.TrimSpace(table.Table) == "" {
+		return fmt.Errorf("table name is required")
+	}
+	seen := make(map[string]bool, len(table.Rules))
+	for _, rule := range table.Rules {
+		if strings.TrimSpace(rule.Column) == "" {
+			return fmt.Errorf("table %s: masking rule column is required", table.Table)
+		}
+		if seen[rule.Column] {
+			return fmt.Errorf("table %s: column %s has several masking rules", table.Table, rule.Column)
+		}
+		seen[rule.Column] = true
+
+		switch rule.Strategy {
+		case MaskStrategyKeep, MaskStrategyHash, MaskStrategyRedact, MaskStrategyNull, MaskStrategyPartial, MaskStrategyFakeEmail, MaskStrategyFakeName:
+		case MaskStrategyFixed:
+			if rule.Value == nil {
+				return fmt.Errorf("table %s: column %s needs a value for the fixed strategy", table.Table, rule.Column)
+			}
+		default:
+			return fmt.Errorf("table %s: unsupported masking strategy %s", table.Table, rule.Strategy)
+		}
+	}
+	return nil
+}
+
+// SupportsAnonymizedCopy returns true when tables of the database type can be copied with masking
+func SupportsAnonymizedCopy(dbType string) bool {
+	if dbType == constants.DatabaseTypeMongoDB {
+		return false
+	}
+	_, ok := getBrowseDialect(dbType)
+	return ok
+}
+
+// MaskValue applies a masking rule to a value, NULL stays NULL
+func MaskValue(value interface{}, rule MaskingRule, salt string) interface{} {
+	if value == nil {
+		return nil
+	}
+	if bytes, ok := value.([]byte); ok {
+		value = string(bytes)
+	}
+
+	switch rule.Strategy {
+	case MaskStrategyNull:
+		return nil
+	case MaskStrategyRedact:
+		return anonymizationRedactedValue
+	case MaskStrategyFixed:
+		return *rule.Value
+	case MaskStrategyHash:
+		digest := anonymizationDigest(value, salt)
+		// Numbers stay numbers so the column type still accepts them, MySQL returns them as text
+		switch typed := value.(type) {
+		case int64, int32, int, int16, int8, uint64, uint32, uint, uint16, uint8:
+			return int64(binary.BigEndian.Uint64(digest[:8]) % 1000000000)
+		case float64, float32:
+			return float64(binary.BigEndian.Uint64(digest[:8])%1000000000) / 100
+		case string:
+			if _, err := strconv.ParseInt(typed, 10, 64); err == nil {
+				return int64(binary.BigEndian.Uint64(digest[:8]) % 1000000000)
+			}
+		}
+		return hex.EncodeToString(digest[:8])
+	case MaskStrategyPartial:
+		text := []rune(fmt.Sprintf("%v", value))
+		for i := 0; i < len(text)-anonymizationPartialKeptChars; i++ {
+			text[i] = '*'
+		}
+		return string(text)
+	case MaskStrategyFakeEmail:
+		digest := anonymizationDigest(value, salt)
+		return fmt.Sprintf("user_%s@example.com", hex.EncodeToString(digest[:5]))
+	case MaskStrategyFakeName:
+		digest := anonymizationDigest(value, salt)
+		first := anonymizationFakeFirstNames[int(digest[0])%len(anonymizationFakeFirstNames)]
+		last := anonymizationFakeLastNames[int(digest[1])%len(anonymizationFakeLastNames)]
+		return first + " " + last
+	}
+	return value
+}
+
+func anonymizationDigest(value interface{}, salt string) []byte {
+	mac := hmac.New(sha256.New, []byte(salt))
+	mac.Write([]byte(fmt.Sprintf("%v", value)))
+	return mac.Sum(nil)
+}
+
+// CountTableRows counts the rows of a table of a chat's database
+func (m *Manager) CountTableRows(ctx context.Context, chatID, table string) (int64, error) {
+	conn, dialect, err := m.anonymizationConnection(chatID)
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	if err := conn.DB.WithContext(ctx).Raw("SELECT COUNT(*) FROM " + quoteQualifiedTable(dialect, table)).Row().Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count rows of table %s: %v", table, err)
+	}
+	return count, nil
+}
+
+// SameDatabase reports whether two connections reach the same database of a server: same unix socket, or same host & port.
+// Loopback hosts match each other & a missing port matches any port, so a doubt is taken as the same database.
+func SameDatabase(a, b ConnectionConfig) bool {
+	if !strings.EqualFold(strings.TrimSpace(a.Database), strings.TrimSpace(b.Database)) {
+		return false
+	}
+	if socketA, socketB := optionalConcern(a.SocketPath), optionalConcern(b.SocketPath); socketA != "" || socketB != "" {
+		return socketA == socketB
+	}
+	if normalizedHost(a.Host) != normalizedHost(b.Host) {
+		return false
+	}
+	portA, portB := optionalConcern(a.Port), optionalConcern(b.Port)
+	return portA == "" || portB == "" || portA == portB
+}
+
+// normalizedHost lowercases a host, the loopback names & addresses are one host
+func normalizedHost(host string) string {
+	host = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(host), "."))
+	switch host {
+	case "localhost", "127.0.0.1", "::1", "[::1]", "0.0.0.0":
+		return "localhost"
+	}
+	return host
+}
+
+// CopyAnonymizedTable copies a table from the source chat's database to the target chat's database with its columns masked.
+// The rows are read with a single cursor & written in chunks, the target table must exist with the columns of the source.
+func (m *Manager) CopyAnonymizedTable(ctx context.Context, sourceChatID, targetChatID string, table AnonymizationTable, opts AnonymizedCopyOptions) (int64, error) {
+	source, dialect, err := m.anonymizationConnection(sourceChatID)
+	if err != nil {
+		return 0, err
+	}
+	target, targetDialect, err := m.anonymizationConnection(targetChatID)
+	if err != nil {
+		return 0, err
+	}
+	if source.Config.Type != target.Config.Type {
+		return 0, fmt.Errorf("source (%s) & target (%s) must be the same database type", source.Config.Type, target.Config.Type)
+	}
+	if SameDatabase(source.Config, target.Config) {
+		return 0, fmt.Errorf("source & target are the same database, the copy would overwrite the rows it reads")
+	}
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = AnonymizationDefaultChunkSize
+	}
+
+	quotedTable := quoteQualifiedTable(dialect, table.Table)
+	if opts.ClearTarget {
+		clearQuery := "DELETE FROM " + quotedTable
+		if target.Config.Type == constants.DatabaseTypeClickhouse {
+			clearQuery = "TRUNCATE TABLE " + quotedTable
+		}
+		if err := target.DB.WithContext(ctx).Exec(clearQuery).Error; err != nil {
+			return 0, fmt.Errorf("failed to clear target table %s: %v", table.Table, err)
+		}
+	}
+
+	rows, err := source.DB.WithContext(ctx).Raw("SELECT * FROM " + quotedTable).Rows()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read table %s: %v", table.Table, err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read columns of table %s: %v", table.Table, err)
+	}
+	rules, err := anonymizationColumnRules(table, columns)
+	if err != nil {
+		return 0, err
+	}
+
+	// A chunk is written with a single INSERT, its bound parameters are capped
+	chunkSize := opts.ChunkSize
+	if maxRows := anonymizationMaxInsertParams / len(columns); chunkSize > maxRows {
+		chunkSize = maxRows
+	}
+
+	quotedColumns := make([]string, len(columns))
+	for i, column := range columns {
+		quotedColumns[i] = targetDialect.quoteIdent(column)
+	}
+	insertPrefix := "INSERT INTO " + quotedTable + " (" + strings.Join(quotedColumns, ", ") + ") VALUES "
+
+	values := make([]interface{}, len(columns))
+	scanArgs := make([]interface{}, len(columns))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+
+	var copied int64
+	chunk := make([][]interface{}, 0, chunkSize)
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		args := make([]interface{}, 0, len(chunk)*len(columns))
+		tuples := make([]string, len(chunk))
+		for i, row := range chunk {
+			placeholders := make([]string, len(row))
+			for j, value := range row {
+				args = append(args, value)
+				placeholders[j] = targetDialect.placeholder(len(args))
+			}
+			tuples[i] = "(" + strings.Join(placeholders, ", ") + ")"
+		}
+		if err := target.DB.WithContext(ctx).Exec(insertPrefix+strings.Join(tuples, ", "), args...).Error; err != nil {
+			return fmt.Errorf("failed to write rows to table %s: %v", table.Table, err)
+		}
+		copied += int64(len(chunk))
+		chunk = chunk[:0]
+		if opts.OnChunk != nil {
+			opts.OnChunk(copied)
+		}
+		return nil
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(scanArgs...); err != nil {
+			return copied, fmt.Errorf("failed to scan row of table %s: %v", table.Table, err)
+		}
+		row := make([]interface{}, len(columns))
+		for i, value := range values {
+			if rule, masked := rules[i]; masked {
+				row[i] = MaskValue(value, rule, opts.Salt)
+			} else {
+				row[i] = value
+			}
+		}
+		chunk = append(chunk, row)
+		if len(chunk) >= chunkSize {
+			if err := flush(); err != nil {
+				return copied, err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return copied, fmt.Errorf("error iterating rows of table %s: %v", table.Table, err)
+	}
+	if err := flush(); err != nil {
+		return copied, err
+	}
+
+	log.Printf("DBManager -> CopyAnonymizedTable -> Copied %d rows of table %s from chat %s to chat %s", copied, table.Table, sourceChatID, targetChatID)
+	return copied, nil
+}
+
+// VerifyAnonymizedTable compares the row counts of a copied table & looks up a sample of the masked source values in the target.
+// Values the masking leaves unchanged (e.g. a fixed value equal to the original) aren't checked.
+func (m *Manager) VerifyAnonymizedTable(ctx context.Context, sourceChatID, targetChatID string, table AnonymizationTable, salt string) (*AnonymizationVerification, error) {
+	source, dialect, err := m.anonymizationConnection(sourceChatID)
+	if err != nil {
+		return nil, err
+	}
+	target, _, err := m.anonymizationConnection(targetChatID)
+	if err != nil {
+		return nil, err
+	}
+
+	verification := &AnonymizationVerification{LeakedColumns: []string{}}
+	if verification.SourceRows, err = m.CountTableRows(ctx, sourceChatID, table.Table); err != nil {
+		return nil, err
+	}
+	if verification.TargetRows, err = m.CountTableRows(ctx, targetChatID, table.Table); err != nil {
+		return nil, err
+	}
+	verification.RowCountsMatch = verification.SourceRows == verification.TargetRows
+
+	quotedTable := quoteQualifiedTable(dialect, table.Table)
+	sampleRows, err := source.DB.WithContext(ctx).Raw("SELECT * FROM " + quotedTable + dialect.paginate(anonymizationVerifySampleSize, 0)).Rows()
+	if err != nil {
+		return nil, fmt.Errorf("failed to sample table %s: %v", table.Table, err)
+	}
+	sample, err := processRows(sampleRows, time.Now(), 0)
+	sampleRows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rule := range table.Rules {
+		if rule.Strategy == MaskStrategyKeep {
+			continue
+		}
+		var originals []interface{}
+		for _, row := range sample {
+			original := row[rule.Column]
+			if original == nil || fmt.Sprintf("%v", original) == fmt.Sprintf("%v", MaskValue(original, rule, salt)) {
+				continue
+			}
+			originals = append(originals, original)
+		}
+		if len(originals) == 0 {
+			continue
+		}
+		verification.CheckedValues += len(originals)
+
+		placeholders := make([]string, len(originals))
+		for i := range originals {
+			placeholders[i] = dialect.placeholder(i + 1)
+		}
+		query := "SELECT COUNT(*) FROM " + quotedTable + " WHERE " + dialect.quoteIdent(rule.Column) + " IN (" + strings.Join(placeholders, ", ") + ")"
+		var leaked int64
+		if err := target.DB.WithContext(ctx).Raw(query, originals...).Row().Scan(&leaked); err != nil {
+			return nil, fmt.Errorf("failed to verify column %s of table %s: %v", rule.Column, table.Table, err)
+		}
+		if leaked > 0 {
+			verification.LeakedValues += leaked
+			verification.LeakedColumns = append(verification.LeakedColumns, rule.Column)
+		}
+	}
+	return verification, nil
+}
+
+// anonymizationConnection returns the connection of a chat & the SQL dialect used to copy its tables
+func (m *Manager) anonymizationConnection(chatID string) (*Connection, browseDialect, error) {
+	m.mu.RLock()
+	conn, exists := m.connections[chatID]
+	m.mu.RUnlock()
+	if !exists {
+		return nil, browseDialect{}, fmt.Errorf("connection not found for chat %s", chatID)
+	}
+	if !SupportsAnonymizedCopy(conn.Config.Type) {
+		return nil, browseDialect{}, fmt.Errorf("anonymized copies are not supported for %s", conn.Config.Type)
+	}
+	if conn.DB == nil {
+		return nil, browseDialect{}, fmt.Errorf("database connection is not available")
+	}
+	dialect, _ := getBrowseDialect(conn.Config.Type)
+	return conn, dialect, nil
+}
+
+// anonymizationColumnRules maps the index of each masked column to its rule, every rule must name a column of the table
+func anonymizationColumnRules(table AnonymizationTable, columns []string) (map[int]MaskingRule, error) {
+	indexes := make(map[string]int, len(columns))
+	for i, column := range columns {
+		indexes[column] = i
+	}
+	rules := make(map[int]MaskingRule, len(table.Rules))
+	for _, rule := range table.Rules {
+		index, found := indexes[rule.Column]
+		if !found {
+			return nil, fmt.Errorf("table %s has no column %s", table.Table, rule.Column)
+		}
+		if rule.Strategy != MaskStrategyKeep {
+			rules[index] = rule
+		}
+	}
+	return rules, nil
+}
+
+// quoteQualifiedTable quotes every part of a schema qualified table name
+func quoteQualifiedTable(dialect browseDialect, table string) string {
+	parts := strings.Split(strings.TrimSpace(table), ".")
+	for i, part := range parts {
+		parts[i] = dialect.quoteIdent(part)
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,29 @@
+package dbmanager
+
+import "testing"
+
+func TestSameDatabase(t *testing.T) {
+	str := func(value string) *string { return &value }
+	tests := []struct {
+		name string
+		a, b ConnectionConfig
+		want bool
+	}{
+		{"same host, port & database", ConnectionConfig{Host: "db.internal", Port: str("5432"), Database: "app"}, ConnectionConfig{Host: "DB.internal.", Port: str("5432"), Database: "app"}, true},
+		{"other database", ConnectionConfig{Host: "db.internal", Port: str("5432"), Database: "app"}, ConnectionConfig{Host: "db.internal", Port: str("5432"), Database: "app_staging"}, false},
+		{"other port", ConnectionConfig{Host: "db.internal", Port: str("5432"), Database: "app"}, ConnectionConfig{Host: "db.internal", Port: str("5433"), Database: "app"}, false},
+		{"missing port", ConnectionConfig{Host: "db.internal", Database: "app"}, ConnectionConfig{Host: "db.internal", Port: str("5432"), Database: "app"}, true},
+		{"other host", ConnectionConfig{Host: "prod.internal", Port: str("5432"), Database: "app"}, ConnectionConfig{Host: "staging.internal", Port: str("5432"), Database: "app"}, false},
+		{"loopback names", ConnectionConfig{Host: "localhost", Port: str("3306"), Database: "app"}, ConnectionConfig{Host: "127.0.0.1", Port: str("3306"), Database: "app"}, true},
+		{"same socket", ConnectionConfig{SocketPath: str("/var/run/postgresql"), Database: "app"}, ConnectionConfig{SocketPath: str("/var/run/postgresql"), Database: "app"}, true},
+		{"socket & host", ConnectionConfig{SocketPath: str("/var/run/postgresql"), Database: "app"}, ConnectionConfig{Host: "localhost", Database: "app"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SameDatabase(tt.a, tt.b); got != tt.want {
+				t.Errorf("SameDatabase() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,475 @@
+package dbmanager
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"neobase-ai/internal/constants"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const (
+	auditLogTable       = "neobase_audit_log"
+	auditTriggerPrefix  = "neobase_audit"
+	auditPostgresSetter = "neobase.actor"  // Transaction scoped setting read by the PostgreSQL trigger function
+	auditMySQLVariable  = "@neobase_actor" // Session variable read by the MySQL triggers
+)
+
+// AuditStatus describes the audit instrumentation installed for a chat's database
+type AuditStatus struct {
+	Supported      bool     `json:"supported"`
+	Enabled        bool     `json:"enabled"`
+	LogTable       string   `json:"log_table"`
+	LogTableExists bool     `json:"log_table_exists"`
+	AuditedTables  []string `json:"audited_tables"`
+}
+
+// modifiedTableRegex matches the target table of a data modifying statement, only the leading keyword of a statement is considered
+// so "ON DUPLICATE KEY UPDATE" or sub-selects are not mistaken for a target
+var modifiedTableRegex = regexp.MustCompile("(?is)^\\s*(?:INSERT(?:\\s+(?:LOW_PRIORITY|DELAYED|HIGH_PRIORITY|IGNORE))*\\s+INTO|REPLACE(?:\\s+(?:LOW_PRIORITY|DELAYED))*\\s+INTO|UPDATE(?:\\s+(?:LOW_PRIORITY|IGNORE))*|DELETE(?:\\s+(?:LOW_PRIORITY|QUICK|IGNORE))*\\s+FROM|MERGE\\s+INTO)\\s+(?:ONLY\\s+)?((?:[`\"]?[\\w$]+[`\"]?\\s*\\.\\s*)?[`\"]?[\\w$]+[`\"]?)")
+
+// isAuditSupported reports whether audit triggers can be installed for a database type
+func isAuditSupported(dbType string) bool {
+	switch dbType {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB, constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB:
+		return true
+	}
+	return false
+}
+
+// isPostgresAudit reports whether the PostgreSQL flavour of the audit instrumentation is used
+func isPostgresAudit(dbType string) bool {
+	return dbType == constants.DatabaseTypePostgreSQL || dbType == constants.DatabaseTypeYugabyteDB
+}
+
+// ExtractModifiedTables returns the tables targeted by the INSERT, UPDATE, DELETE, REPLACE & MERGE statements of a query.
+// Unquoted PostgreSQL identifiers are folded to lower case the same way the server does.
+func ExtractModifiedTables(dbType string, query string) []string {
+	var statements []string
+	if isPostgresAudit(dbType) {
+		statements = splitStatements(query)
+	} else {
+		statements = splitMySQLStatements(query)
+	}
+
+	seen := make(map[string]bool)
+	tables := []string{}
+	for _, stmt := range statements {
+		match := modifiedTableRegex.FindStringSubmatch(stmt)
+		if match == nil {
+			continue
+		}
+
+		parts := strings.Split(match[1], ".")
+		for i, part := range parts {
+			part = strings.TrimSpace(part)
+			quoted := strings.HasPrefix(part, "\"") || strings.HasPrefix(part, "`")
+			part = strings.Trim(part, "\"`")
+			if isPostgresAudit(dbType) && !quoted {
+				part = strings.ToLower(part)
+			}
+			parts[i] = part
+		}
+
+		table := strings.Join(parts, ".")
+		if strings.EqualFold(parts[len(parts)-1], auditLogTable) || seen[table] {
+			continue
+		}
+		seen[table] = true
+		tables = append(tables, table)
+	}
+	return tables
+}
+
+// SetAuditChanges enables or disables the attribution of changes made through NeoBase for a chat's connection
+func (m *Manager) SetAuditChanges(chatID string, enabled bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if conn, exists := m.connections[chatID]; exists {
+		conn.AuditChanges = enabled
+	}
+}
+
+// InstallAuditTriggers installs the audit log table & triggers on the given tables, returns the tables that were instrumented
+func (m *Manager) InstallAuditTriggers(ctx context.Context, chatID string, tables []string) ([]string, error) {
+	conn, err := m.getAuditConnection(chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	installed := []string{}
+	if isPostgresAudit(conn.Config.Type) {
+		schema, err := postgresAuditSchema(ctx, conn.DB)
+		if err != nil {
+			return nil, err
+		}
+		if err := execAuditStatements(ctx, conn.DB, postgresAuditSetup(schema)); err != nil {
+			return nil, fmt.Errorf("failed to create audit log: %v", err)
+		}
+		for _, table := range tables {
+			if err := execAuditStatements(ctx, conn.DB, postgresAuditTrigger(schema, table)); err != nil {
+				return installed, fmt.Errorf("failed to install audit trigger on %s: %v", table, err)
+			}
+			installed = append(installed, table)
+		}
+	} else {
+		if err := execAuditStatements(ctx, conn.DB, []string{mysqlAuditLogTableDDL}); err != nil {
+			return nil, fmt.Errorf("failed to create audit log: %v", err)
+		}
+		for _, table := range tables {
+			statements, err := mysqlAuditTriggers(ctx, conn.DB, table)
+			if err != nil {
+				return installed, err
+			}
+			if err := execAuditStatements(ctx, conn.DB, statements); err != nil {
+				return installed, fmt.Errorf("failed to install audit triggers on %s: %v", table, err)
+			}
+			installed = append(installed, table)
+		}
+	}
+
+	log.Printf("DBManager -> InstallAuditTriggers -> chatID: %s, installed on: %v", chatID, installed)
+	return installed, nil
+}
+
+// RemoveAuditTriggers removes every audit trigger installed by NeoBase, the audit log itself is only dropped when dropLog is set
+func (m *Manager) RemoveAuditTriggers(ctx context.Context, chatID string, dropLog bool) ([]string, error) {
+	conn, err := m.getAuditConnection(chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	tables, triggers, err := listAuditTriggers(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var statements []string
+	if isPostgresAudit(conn.Config.Type) {
+		schema, err := postgresAuditSchema(ctx, conn.DB)
+		if err != nil {
+			return nil, err
+		}
+		for _, table := range tables {
+			statements = append(statements, fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s", auditTriggerPrefix, quotePostgresTable(table)))
+		}
+		statements = append(statements, fmt.Sprintf("DROP FUNCTION IF EXISTS %s.neobase_audit_trigger()", quotePostgresIdent(schema)))
+		if dropLog {
+			statements = append(statements, fmt.Sprintf("DROP TABLE IF EXISTS %s.%s", quotePostgresIdent(schema), auditLogTable))
+		}
+	} else {
+		for _, trigger := range triggers {
+			statements = append(statements, fmt.Sprintf("DROP TRIGGER IF EXISTS %s", quoteMySQLIdent(trigger)))
+		}
+		if dropLog {
+			statements = append(statements, "DROP TABLE IF EXISTS "+auditLogTable)
+		}
+	}
+
+	if err := execAuditStatements(ctx, conn.DB, statements); err != nil {
+		return nil, fmt.Errorf("failed to remove audit instrumentation: %v", err)
+	}
+
+	log.Printf("DBManager -> RemoveAuditTriggers -> chatID: %s, removed from: %v, dropLog: %v", chatID, tables, dropLog)
+	return tables, nil
+}
+
+// GetAuditStatus returns the audited tables & whether the audit log exists
+func (m *Manager) GetAuditStatus(ctx context.Context, chatID string) (*AuditStatus, error) {
+	m.mu.RLock()
+	conn, exists := m.connections[chatID]
+	m.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("connection not found for chat %s", chatID)
+	}
+
+	status := &AuditStatus{
+		Supported:     isAuditSupported(conn.Config.Type),
+		Enabled:       conn.AuditChanges,
+		LogTable:      auditLogTable,
+		AuditedTables: []string{},
+	}
+	if !status.Supported {
+		return status, nil
+	}
+
+	tables, _, err := listAuditTriggers(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	status.AuditedTables = tables
+
+	existsQuery := "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?"
+	if isPostgresAudit(conn.Config.Type) {
+		existsQuery = "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = ?"
+	}
+	var count int64
+	if err := conn.DB.WithContext(ctx).Raw(existsQuery, auditLogTable).Scan(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to check audit log: %v", err)
+	}
+	status.LogTableExists = count > 0
+
+	return status, nil
+}
+
+// prepareAuditedQuery prefixes a query modifying tables with the statement that identifies NeoBase as the author of the changes,
+// the original statement stays last so its result is returned. Queries of connections without auditing are left as is, the
+// triggers are only installed through InstallAuditTriggers.
+func (m *Manager) prepareAuditedQuery(conn *Connection, chatID, messageID, queryID, query string) string {
+	if !conn.AuditChanges || !isAuditSupported(conn.Config.Type) {
+		return query
+	}
+	if len(ExtractModifiedTables(conn.Config.Type, query)) == 0 {
+		return query
+	}
+
+	actor := auditActor(conn.UserID, chatID, messageID, queryID)
+	if isPostgresAudit(conn.Config.Type) {
+		return fmt.Sprintf("SET LOCAL %s = '%s'; %s", auditPostgresSetter, actor, query)
+	}
+	// The session variable outlives the query on the pooled connection, it is reset when a connection is checked out once
+	// auditing is disabled, see resetAuditActor
+	conn.auditActorSet.Store(true)
+	return fmt.Sprintf("SET %s = '%s'; %s", auditMySQLVariable, actor, query)
+}
+
+// resetAuditActor clears the actor a pooled MySQL connection kept from an audited query, so the triggers don't attribute later
+// changes to it once auditing is disabled. Connections that never audited a query are left as is.
+func resetAuditActor(tx *gorm.DB, conn *Connection) {
+	if conn.AuditChanges || !conn.auditActorSet.Load() {
+		return
+	}
+	if err := tx.Exec(fmt.Sprintf("SET %s = NULL", auditMySQLVariable)).Error; err != nil {
+		log.Printf("DBManager -> resetAuditActor -> Failed to reset the audit actor: %v", err)
+	}
+}
+
+// getAuditConnection returns the chat's connection if audit triggers are supported for it
+func (m *Manager) getAuditConnection(chatID string) (*Connection, error) {
+	m.mu.RLock()
+	conn, exists := m.connections[chatID]
+	m.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("connection not found for chat %s", chatID)
+	}
+	if !isAuditSupported(conn.Config.Type) {
+		return nil, fmt.Errorf("audit triggers are not supported for %s", conn.Config.Type)
+	}
+	return conn, nil
+}
+
+// auditActor builds the identifier stored with every audited change
+func auditActor(userID, chatID, messageID, queryID string) string {
+	parts := []string{"neobase"}
+	for _, part := range []struct{ key, value string }{
+		{"user", userID}, {"chat", chatID}, {"message", messageID}, {"query", queryID},
+	} {
+		if part.value != "" {
+			parts = append(parts, part.key+"="+part.value)
+		}
+	}
+	// IDs are hex strings, quotes are stripped anyway since the actor is inlined in a SET statement
+	return strings.NewReplacer("'", "", "\\", "").Replace(strings.Join(parts, ";"))
+}
+
+// listAuditTriggers returns the audited tables & the names of the audit triggers on them
+func listAuditTriggers(ctx context.Context, conn *Connection) ([]string, []string, error) {
+	query := `SELECT DISTINCT event_object_schema AS table_schema, event_object_table AS table_name, trigger_name
+FROM information_schema.triggers
+WHERE trigger_name = ?`
+	args := []interface{}{auditTriggerPrefix}
+	if !isPostgresAudit(conn.Config.Type) {
+		query = `SELECT EVENT_OBJECT_SCHEMA AS table_schema, EVENT_OBJECT_TABLE AS table_name, TRIGGER_NAME AS trigger_name
+FROM information_schema.TRIGGERS
+WHERE TRIGGER_SCHEMA = DATABASE() AND TRIGGER_NAME LIKE ?`
+		args = []interface{}{auditTriggerPrefix + "\\_%"}
+	}
+
+	var rows []struct {
+		TableSchema string `gorm:"column:table_schema"`
+		TableName   string `gorm:"column:table_name"`
+		TriggerName string `gorm:"column:trigger_name"`
+	}
+	if err := conn.DB.WithContext(ctx).Raw(query, args...).Scan(&rows).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to list audit triggers: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	tables := []string{}
+	triggers := []string{}
+	for _, row := range rows {
+		table := row.TableName
+		if isPostgresAudit(conn.Config.Type) {
+			table = row.TableSchema + "." + row.TableName
+		}
+		if !seen[table] {
+			seen[table] = true
+			tables = append(tables, table)
+		}
+		triggers = append(triggers, row.TriggerName)
+	}
+	sort.Strings(tables)
+	return tables, triggers, nil
+}
+
+// execAuditStatements executes the instrumentation statements one by one, outside of the query transaction
+func execAuditStatements(ctx context.Context, db *gorm.DB, statements []string) error {
+	for _, stmt := range statements {
+		if err := db.WithContext(ctx).Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unqualifiedTable strips the schema from a table name
+func unqualifiedTable(table string) string {
+	return table[strings.LastIndex(table, ".")+1:]
+}
+
+// postgresAuditSchema returns the schema the audit log & trigger function are created in
+func postgresAuditSchema(ctx context.Context, db *gorm.DB) (string, error) {
+	var schema string
+	if err := db.WithContext(ctx).Raw("SELECT current_schema()").Scan(&schema).Error; err != nil {
+		return "", fmt.Errorf("failed to detect current schema: %v", err)
+	}
+	if schema == "" {
+		schema = "public"
+	}
+	return schema, nil
+}
+
+// postgresAuditSetup creates the audit log & the trigger function, the function only records changes made through NeoBase
+func postgresAuditSetup(schema string) []string {
+	qualifiedLog := quotePostgresIdent(schema) + "." + auditLogTable
+	return []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id BIGSERIAL PRIMARY KEY,
+	table_name TEXT NOT NULL,
+	operation TEXT NOT NULL,
+	actor TEXT NOT NULL,
+	db_user TEXT NOT NULL DEFAULT current_user,
+	changed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	old_data JSONB,
+	new_data JSONB
+)`, qualifiedLog),
+		fmt.Sprintf(`CREATE OR REPLACE FUNCTION %s.neobase_audit_trigger() RETURNS trigger AS $neobase$
+DECLARE
+	actor TEXT := current_setting('%s', true);
+BEGIN
+	IF actor IS NULL OR actor = '' THEN
+		RETURN NULL;
+	END IF;
+	INSERT INTO %s (table_name, operation, actor, old_data, new_data)
+	VALUES (TG_TABLE_SCHEMA || '.' || TG_TABLE_NAME, TG_OP, actor,
+		CASE WHEN TG_OP IN ('UPDATE', 'DELETE') THEN to_jsonb(OLD) END,
+		CASE WHEN TG_OP IN ('INSERT', 'UPDATE') THEN to_jsonb(NEW) END);
+	RETURN NULL;
+END;
+$neobase$ LANGUAGE plpgsql`, quotePostgresIdent(schema), auditPostgresSetter, qualifiedLog),
+	}
+}
+
+// postgresAuditTrigger (re)creates the row level audit trigger on a table
+func postgresAuditTrigger(schema, table string) []string {
+	qualifiedTable := quotePostgresTable(table)
+	return []string{
+		fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s", auditTriggerPrefix, qualifiedTable),
+		fmt.Sprintf("CREATE TRIGGER %s AFTER INSERT OR UPDATE OR DELETE ON %s FOR EACH ROW EXECUTE PROCEDURE %s.neobase_audit_trigger()",
+			auditTriggerPrefix, qualifiedTable, quotePostgresIdent(schema)),
+	}
+}
+
+// quotePostgresTable quotes a possibly schema qualified table name
+func quotePostgresTable(table string) string {
+	parts := strings.Split(table, ".")
+	for i, part := range parts {
+		parts[i] = quotePostgresIdent(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// quotePostgresIdent quotes a PostgreSQL identifier
+func quotePostgresIdent(ident string) string {
+	return `"` + strings.ReplaceAll(ident, `"`, `""`) + `"`
+}
+
+// quoteMySQLIdent quotes a MySQL identifier
+func quoteMySQLIdent(ident string) string {
+	return "`" + strings.ReplaceAll(ident, "`", "``") + "`"
+}
+
+const mysqlAuditLogTableDDL = `CREATE TABLE IF NOT EXISTS ` + auditLogTable + ` (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	table_name VARCHAR(255) NOT NULL,
+	operation VARCHAR(10) NOT NULL,
+	actor VARCHAR(512) NOT NULL,
+	db_user VARCHAR(288) NOT NULL,
+	changed_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+	old_data JSON NULL,
+	new_data JSON NULL
+)`
+
+// mysqlAuditTriggers builds the insert, update & delete triggers of a table. MySQL has no generic row to JSON conversion so the
+// columns are listed explicitly, installing again after the table structure changed refreshes them.
+func mysqlAuditTriggers(ctx context.Context, db *gorm.DB, table string) ([]string, error) {
+	table = unqualifiedTable(table)
+
+	var columns []string
+	if err := db.WithContext(ctx).Raw(`SELECT COLUMN_NAME FROM information_schema.COLUMNS
+WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?
+ORDER BY ORDINAL_POSITION`, table).Scan(&columns).Error; err != nil {
+		return nil, fmt.Errorf("failed to read columns of %s: %v", table, err)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("table %s not found", table)
+	}
+
+	rowJSON := func(row string) string {
+		pairs := make([]string, 0, len(columns))
+		for _, column := range columns {
+			pairs = append(pairs, fmt.Sprintf("'%s', %s.%s", strings.ReplaceAll(column, "'", "''"), row, quoteMySQLIdent(column)))
+		}
+		return "JSON_OBJECT(" + strings.Join(pairs, ", ") + ")"
+	}
+
+	var statements []string
+	for _, event := range []struct{ op, suffix, oldData, newData string }{
+		{"INSERT", "ins", "NULL", rowJSON("NEW")},
+		{"UPDATE", "upd", rowJSON("OLD"), rowJSON("NEW")},
+		{"DELETE", "del", rowJSON("OLD"), "NULL"},
+	} {
+		name := quoteMySQLIdent(mysqlAuditTriggerName(table, event.suffix))
+		statements = append(statements,
+			"DROP TRIGGER IF EXISTS "+name,
+			fmt.Sprintf(`CREATE TRIGGER %s AFTER %s ON %s FOR EACH ROW
+BEGIN
+	IF %s IS NOT NULL THEN
+		INSERT INTO %s (table_name, operation, actor, db_user, old_data, new_data)
+		VALUES ('%s', '%s', %s, USER(), %s, %s);
+	END IF;
+END`, name, event.op, quoteMySQLIdent(table), auditMySQLVariable, auditLogTable,
+				strings.ReplaceAll(table, "'", "''"), event.op, auditMySQLVariable, event.oldData, event.newData),
+		)
+	}
+	return statements, nil
+}
+
+// mysqlAuditTriggerName returns the trigger name for a table & event, long names are shortened with a hash to fit the 64 characters limit
+func mysqlAuditTriggerName(table, suffix string) string {
+	name := fmt.Sprintf("%s_%s_%s", auditTriggerPrefix, table, suffix)
+	if len(name) <= 64 {
+		return name
+	}
+	sum := md5.Sum([]byte(table))
+	return fmt.Sprintf("%s_%s_%s", auditTriggerPrefix, hex.EncodeToString(sum[:])[:16], suffix)
+}
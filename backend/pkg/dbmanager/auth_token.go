@@ -0,0 +1,177 @@
+package dbmanager
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+// Authentication modes of a SQL connection
+const (
+	AuthModePassword = "password" // Default, username & password
+	AuthModeAzureAD  = "azure_ad" // Azure AD / Entra ID access token used as the password
+	AuthModeAWSIAM   = "aws_iam"  // AWS RDS IAM authentication token used as the password
+	AuthModeKerberos = "kerberos" // Kerberos ticket obtained with the keytab of the principal, no password
+)
+
+// authTokenFunc returns the password to use for a new connection
+type authTokenFunc func(ctx context.Context) (string, error)
+
+// UsesTokenAuth returns true when the password of the connection is a short-lived token generated per connection
+func UsesTokenAuth(config ConnectionConfig) bool {
+	return config.AuthMode != nil && (*config.AuthMode == AuthModeAzureAD || *config.AuthMode == AuthModeAWSIAM)
+}
+
+// ValidateAuthMode checks the auth mode is known & supported by the database type
+func ValidateAuthMode(config ConnectionConfig) error {
+	if config.AuthMode == nil || *config.AuthMode == "" || *config.AuthMode == AuthModePassword {
+		return nil
+	}
+
+	switch *config.AuthMode {
+	case AuthModeAzureAD:
+		return validateAzureADAuth(config)
+	case AuthModeAWSIAM:
+		return validateAWSIAMAuth(config)
+	case AuthModeKerberos:
+		return validateKerberosAuth(config)
+	default:
+		return fmt.Errorf("unsupported auth mode: %s", *config.AuthMode)
+	}
+}
+
+// getAuthTokenFunc returns the token generator of the connection's auth mode, nil for password authentication
+func getAuthTokenFunc(config ConnectionConfig) authTokenFunc {
+	if config.AuthMode == nil {
+		return nil
+	}
+
+	switch *config.AuthMode {
+	case AuthModeAzureAD:
+		return func(ctx context.Context) (string, error) {
+			return getAzureADToken(ctx, config)
+		}
+	case AuthModeAWSIAM:
+		return func(ctx context.Context) (string, error) {
+			return getAWSIAMAuthToken(ctx, config)
+		}
+	default:
+		return nil
+	}
+}
+
+// tokenAuthPostgresConnector opens PostgreSQL connections with a fresh token as the password,
+// so connections opened by the pool after the previous token expired keep working
+type tokenAuthPostgresConnector struct {
+	dsn      string
+	getToken authTokenFunc
+	dial     proxyDialFunc
+}
+
+// Connect implements driver.Connector
+func (c *tokenAuthPostgresConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	token, err := c.getToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	connector, err := newPostgresConnector(fmt.Sprintf("%s password='%s'", c.dsn, token), c.dial)
+	if err != nil {
+		return nil, err
+	}
+	return connector.Connect(ctx)
+}
+
+// Driver implements driver.Connector
+func (c *tokenAuthPostgresConnector) Driver() driver.Driver {
+	return &pq.Driver{}
+}
+
+// newPostgresConnector creates a lib/pq connector for the DSN, dialing through the proxy of the connection if any
+func newPostgresConnector(dsn string, dial proxyDialFunc) (*pq.Connector, error) {
+	connector, err := pq.NewConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if dial != nil {
+		connector.Dialer(dial)
+	}
+	return connector, nil
+}
+
+// openPostgresDB opens a PostgreSQL pool for the DSN, the password is added per connection with token authentication
+func openPostgresDB(config ConnectionConfig, dsn string) (*sql.DB, error) {
+	dial, err := getProxyDialFunc(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if UsesKerberosAuth(config) {
+		if err := ValidateAuthMode(config); err != nil {
+			return nil, err
+		}
+		return sql.OpenDB(&kerberosPostgresConnector{dsn: dsn, config: config, dial: dial}), nil
+	}
+	if !UsesTokenAuth(config) {
+		if dial == nil {
+			return sql.Open("postgres", dsn)
+		}
+		connector, err := newPostgresConnector(dsn, dial)
+		if err != nil {
+			return nil, err
+		}
+		return sql.OpenDB(connector), nil
+	}
+	if err := ValidateAuthMode(config); err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(&tokenAuthPostgresConnector{dsn: dsn, getToken: getAuthTokenFunc(config), dial: dial}), nil
+}
+
+// openMySQLDB opens a MySQL pool for the DSN, the password is set before every new connection with token authentication
+func openMySQLDB(config ConnectionConfig, dsn string) (*sql.DB, error) {
+	dial, err := getProxyDialFunc(config)
+	if err != nil {
+		return nil, err
+	}
+	if !UsesTokenAuth(config) && dial == nil {
+		return sql.Open("mysql", dsn)
+	}
+
+	cfg, err := mysqldriver.ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if dial != nil {
+		cfg.DialFunc = dial
+	}
+
+	if UsesTokenAuth(config) {
+		if err := ValidateAuthMode(config); err != nil {
+			return nil, err
+		}
+		// Tokens are sent with the cleartext plugin, the connection is encrypted with TLS
+		cfg.AllowCleartextPasswords = true
+		getToken := getAuthTokenFunc(config)
+		if err := cfg.Apply(mysqldriver.BeforeConnect(func(ctx context.Context, cfg *mysqldriver.Config) error {
+			token, err := getToken(ctx)
+			if err != nil {
+				return err
+			}
+			cfg.Passwd = token
+			return nil
+		})); err != nil {
+			return nil, err
+		}
+	}
+
+	connector, err := mysqldriver.NewConnector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(connector), nil
+}
@@ -0,0 +1,156 @@
+package dbmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"neobase-ai/internal/constants"
+	"reflect"
+	"regexp"
+)
+
+// mongoFindRegex matches the find of a collection starting a MongoDB query
+var mongoFindRegex = regexp.MustCompile(`^db\.(?:getCollection\([^)]*\)|[^(]+)\.find\s*$`)
+
+// mongoFindCursorMethods are the cursor methods a find can chain before its limit, any other method leaves the find as is
+var mongoFindCursorMethods = map[string]bool{
+	"sort": true, "skip": true, "project": true, "projection": true, "hint": true, "collation": true, "comment": true,
+	"maxTimeMS": true, "batchSize": true, "allowDiskUse": true, "readConcern": true, "readPref": true,
+}
+
+type autoLimitKey struct{}
+
+// WithAutoLimit makes the SELECTs & finds executed with the context that have no limit of their own read up to limit rows, the
+// database stops at the limit rather than scanning the whole table. The result is marked as truncated when the limit cut it.
+func WithAutoLimit(ctx context.Context, limit int) context.Context {
+	return context.WithValue(ctx, autoLimitKey{}, limit)
+}
+
+// autoLimit returns the rows the unbounded queries executed with the context are limited to, 0 without a limit
+func autoLimit(ctx context.Context) int {
+	if limit, ok := ctx.Value(autoLimitKey{}).(int); ok && limit > 0 {
+		return limit
+	}
+	return 0
+}
+
+// SupportsAutoLimit returns true when the unbounded queries of the database type can be limited
+func SupportsAutoLimit(dbType string) bool {
+	switch dbType {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB, constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB,
+		constants.DatabaseTypeSingleStore, constants.DatabaseTypeClickhouse, constants.DatabaseTypeDB2, constants.DatabaseTypeDatabricks,
+		constants.DatabaseTypeMongoDB:
+		return true
+	}
+	return false
+}
+
+// applyAutoLimit returns the query limited to the auto limit of the context when it's a single SELECT or find without a limit of
+// its own, along the limit applied, 0 when the query is left as is. A row more than the limit is read to tell whether the limit
+// cut the result, markAutoLimited leaves it out.
+func applyAutoLimit(ctx context.Context, dbType, query string) (string, int) {
+	limit := autoLimit(ctx)
+	if limit == 0 || !SupportsAutoLimit(dbType) {
+		return query, 0
+	}
+
+	var limited string
+	var ok bool
+	if dbType == constants.DatabaseTypeMongoDB {
+		limited, ok = limitMongoFind(query, limit+1)
+	} else {
+		limited, ok = limitSQLSelect(dbType, query, limit+1)
+	}
+	if !ok {
+		return query, 0
+	}
+	log.Printf("DBManager -> applyAutoLimit -> The query has no limit, limited to %d rows", limit)
+	return limited, limit
+}
+
+// limitSQLSelect appends a LIMIT (FETCH FIRST on DB2) to a single SELECT reading from a table without LIMIT, FETCH or OFFSET.
+// The SELECTs limited with TOP, locking their rows or writing them into a table are left as is, and so are ClickHouse's UNIONs,
+// whose LIMIT applies to their last SELECT only.
+func limitSQLSelect(dbType, query string, limit int) (string, bool) {
+	foldCase := dbType == constants.DatabaseTypePostgreSQL || dbType == constants.DatabaseTypeYugabyteDB
+	statements := splitSQLTokens(tokenizeSQL(query, foldCase))
+	if len(statements) != 1 {
+		return query, false
+	}
+	tokens := statements[0]
+
+	selectAt := 0
+	if tokens[0].isKeyword("WITH") {
+		// The statement of the CTEs is the first top level one, the CTEs are in parentheses
+		selectAt = findTopLevel(tokens, 1, "SELECT", "INSERT", "UPDATE", "DELETE", "MERGE")
+	}
+	if selectAt >= len(tokens) || !tokens[selectAt].isKeyword("SELECT") {
+		return query, false
+	}
+	if findTopLevel(tokens, selectAt, "FROM") == len(tokens) ||
+		findTopLevel(tokens, selectAt, "LIMIT", "FETCH", "OFFSET", "INTO", "FOR", "LOCK", "SETTINGS", "FORMAT") < len(tokens) {
+		return query, false
+	}
+	// SELECT [DISTINCT] TOP n is limited already
+	if topAt := skipKeywords(tokens, selectAt+1, "ALL", "DISTINCT"); topAt < len(tokens) && tokens[topAt].isKeyword("TOP") {
+		return query, false
+	}
+	if dbType == constants.DatabaseTypeClickhouse && findTopLevel(tokens, selectAt, "UNION", "EXCEPT", "INTERSECT") < len(tokens) {
+		return query, false
+	}
+
+	if dbType == constants.DatabaseTypeDB2 {
+		return fmt.Sprintf("%s FETCH FIRST %d ROWS ONLY", joinSQLTokens(tokens), limit), true
+	}
+	return fmt.Sprintf("%s LIMIT %d", joinSQLTokens(tokens), limit), true
+}
+
+// limitMongoFind appends .limit() to a find whose chained methods are cursor options, the finds counted, explained or already
+// limited are left as is
+func limitMongoFind(query string, limit int) (string, bool) {
+	tokens := tokenizeSQL(query, false)
+	// The trailing semicolons are dropped, a query holding more than the find is left as is
+	for len(tokens) > 0 && tokens[len(tokens)-1].isSymbol(";") {
+		tokens = tokens[:len(tokens)-1]
+	}
+	findAt := -1
+	for i := 1; i+1 < len(tokens); i++ {
+		if tokens[i].isKeyword("find") && tokens[i-1].isSymbol(".") && tokens[i+1].isSymbol("(") {
+			findAt = i + 1
+			break
+		}
+	}
+	if findAt < 0 || !mongoFindRegex.MatchString(joinSQLTokens(tokens[:findAt])) {
+		return query, false
+	}
+
+	// The find & each chained method are a name followed by their arguments in parentheses
+	for i := matchingParen(tokens, findAt) + 1; i < len(tokens); {
+		if i+2 >= len(tokens) || !tokens[i].isSymbol(".") || tokens[i+1].kind != sqlTokenIdent || !tokens[i+2].isSymbol("(") ||
+			!mongoFindCursorMethods[tokens[i+1].text] {
+			return query, false
+		}
+		i = matchingParen(tokens, i+2) + 1
+	}
+	return fmt.Sprintf("%s.limit(%d)", joinSQLTokens(tokens), limit), true
+}
+
+// markAutoLimited leaves out the row read past the auto limit of a result & reports the result as truncated, the result is left as
+// is when the limit didn't cut it
+func markAutoLimited(result *QueryExecutionResult, limit int) {
+	if limit == 0 || result == nil || result.Result == nil {
+		return
+	}
+	rows := reflect.ValueOf(result.Result["results"])
+	if rows.Kind() != reflect.Slice || rows.Len() <= limit {
+		return
+	}
+
+	result.Result["results"] = rows.Slice(0, limit).Interface()
+	result.Result["truncated"] = true
+	result.Result["truncatedReason"] = fmt.Sprintf("the query has no limit, it was limited to %d rows, execute it as a full scan to read every row", limit)
+	if resultJSON, err := json.Marshal(result.Result); err == nil {
+		result.ResultJSON = string(resultJSON)
+	}
+}
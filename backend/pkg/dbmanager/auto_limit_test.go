@@ -0,0 +1,107 @@
+package dbmanager
+
+import (
+	"context"
+	"neobase-ai/internal/constants"
+	"testing"
+)
+
+func TestLimitSQLSelect(t *testing.T) {
+	tests := []struct {
+		name   string
+		dbType string
+		query  string
+		want   string
+		ok     bool
+	}{
+		{"select", constants.DatabaseTypePostgreSQL, "SELECT * FROM users", "SELECT * FROM users LIMIT 101", true},
+		{"db2 fetch first", constants.DatabaseTypeDB2, "SELECT * FROM users", "SELECT * FROM users FETCH FIRST 101 ROWS ONLY", true},
+		{"trailing semicolon", constants.DatabaseTypeMySQL, "SELECT * FROM users;", "SELECT * FROM users LIMIT 101", true},
+		{"trailing comment", constants.DatabaseTypePostgreSQL, "SELECT * FROM users -- all users", "SELECT * FROM users LIMIT 101", true},
+		{"comment after the semicolon", constants.DatabaseTypeMySQL, "SELECT * FROM users; -- all users", "SELECT * FROM users LIMIT 101", true},
+		{"leading comment", constants.DatabaseTypePostgreSQL, "/* users */ SELECT * FROM users", "SELECT * FROM users LIMIT 101", true},
+		{"semicolon in a string", constants.DatabaseTypePostgreSQL, "SELECT * FROM users WHERE name = 'a;b'", "SELECT * FROM users WHERE name = 'a;b' LIMIT 101", true},
+		{"existing limit", constants.DatabaseTypePostgreSQL, "SELECT * FROM users LIMIT 5", "", false},
+		{"existing lowercase limit", constants.DatabaseTypeMySQL, "select * from users limit 5", "", false},
+		{"existing offset", constants.DatabaseTypePostgreSQL, "SELECT * FROM users OFFSET 10", "", false},
+		{"existing fetch", constants.DatabaseTypeDB2, "SELECT * FROM users FETCH FIRST 5 ROWS ONLY", "", false},
+		{"existing top", constants.DatabaseTypeDatabricks, "SELECT TOP 5 * FROM users", "", false},
+		{"existing distinct top", constants.DatabaseTypeDatabricks, "SELECT DISTINCT TOP 5 name FROM users", "", false},
+		{"union", constants.DatabaseTypePostgreSQL, "SELECT id FROM a UNION SELECT id FROM b", "SELECT id FROM a UNION SELECT id FROM b LIMIT 101", true},
+		{"clickhouse union", constants.DatabaseTypeClickhouse, "SELECT id FROM a UNION ALL SELECT id FROM b", "", false},
+		{"cte", constants.DatabaseTypePostgreSQL, "WITH x AS (SELECT * FROM users) SELECT * FROM x", "WITH x AS (SELECT * FROM users) SELECT * FROM x LIMIT 101", true},
+		{"limit in a cte only", constants.DatabaseTypePostgreSQL, "WITH x AS (SELECT * FROM users LIMIT 3) SELECT * FROM x", "WITH x AS (SELECT * FROM users LIMIT 3) SELECT * FROM x LIMIT 101", true},
+		{"cte writing", constants.DatabaseTypePostgreSQL, "WITH x AS (SELECT id FROM users) DELETE FROM users WHERE id IN (SELECT id FROM x)", "", false},
+		{"limit in a subquery only", constants.DatabaseTypeMySQL, "SELECT * FROM (SELECT * FROM users LIMIT 3) u", "SELECT * FROM (SELECT * FROM users LIMIT 3) u LIMIT 101", true},
+		{"for update", constants.DatabaseTypePostgreSQL, "SELECT * FROM users FOR UPDATE", "", false},
+		{"lock in share mode", constants.DatabaseTypeMySQL, "SELECT * FROM users LOCK IN SHARE MODE", "", false},
+		{"select into", constants.DatabaseTypePostgreSQL, "SELECT * INTO archive FROM users", "", false},
+		{"without a table", constants.DatabaseTypePostgreSQL, "SELECT 1", "", false},
+		{"two statements", constants.DatabaseTypePostgreSQL, "SELECT * FROM users; SELECT * FROM orders", "", false},
+		{"insert", constants.DatabaseTypeMySQL, "INSERT INTO users (name) VALUES ('a')", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := limitSQLSelect(tt.dbType, tt.query, 101)
+			if ok != tt.ok {
+				t.Fatalf("limitSQLSelect(%q, %q) ok = %v, want %v (%q)", tt.dbType, tt.query, ok, tt.ok, got)
+			}
+			if !tt.ok {
+				tt.want = tt.query
+			}
+			if got != tt.want {
+				t.Errorf("limitSQLSelect(%q, %q) = %q, want %q", tt.dbType, tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimitMongoFind(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+		ok    bool
+	}{
+		{"find", "db.users.find({})", "db.users.find({}).limit(101)", true},
+		{"find without a filter", "db.users.find()", "db.users.find().limit(101)", true},
+		{"get collection", `db.getCollection("users").find({a: "x"})`, `db.getCollection("users").find({a: "x"}).limit(101)`, true},
+		{"cursor options", "db.users.find({}).sort({a: 1}).skip(10)", "db.users.find({}).sort({a: 1}).skip(10).limit(101)", true},
+		{"trailing semicolon", "db.users.find({});", "db.users.find({}).limit(101)", true},
+		{"existing limit", "db.users.find({}).limit(5)", "", false},
+		{"existing limit before sort", "db.users.find({}).limit(5).sort({a: 1})", "", false},
+		{"count", "db.users.find().count()", "", false},
+		{"explain", "db.users.find({}).explain()", "", false},
+		{"aggregate", "db.users.aggregate([])", "", false},
+		{"find one", "db.users.findOne({})", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := limitMongoFind(tt.query, 101)
+			if ok != tt.ok {
+				t.Fatalf("limitMongoFind(%q) ok = %v, want %v (%q)", tt.query, ok, tt.ok, got)
+			}
+			if !tt.ok {
+				tt.want = tt.query
+			}
+			if got != tt.want {
+				t.Errorf("limitMongoFind(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyAutoLimit(t *testing.T) {
+	if got, limit := applyAutoLimit(context.Background(), constants.DatabaseTypePostgreSQL, "SELECT * FROM users"); limit != 0 || got != "SELECT * FROM users" {
+		t.Errorf("applyAutoLimit without a limit = %q, %d, want the query as is", got, limit)
+	}
+	ctx := WithAutoLimit(context.Background(), 100)
+	if got, limit := applyAutoLimit(ctx, constants.DatabaseTypePostgreSQL, "SELECT * FROM users"); limit != 100 || got != "SELECT * FROM users LIMIT 101" {
+		t.Errorf("applyAutoLimit = %q, %d, want a row more than the limit", got, limit)
+	}
+	if got, limit := applyAutoLimit(ctx, constants.DatabaseTypeNeo4j, "MATCH (n) RETURN n"); limit != 0 || got != "MATCH (n) RETURN n" {
+		t.Errorf("applyAutoLimit on an unsupported type = %q, %d, want the query as is", got, limit)
+	}
+}
@@ -0,0 +1,292 @@
+package dbmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"neobase-ai/internal/constants"
+	"neobase-ai/pkg/sigv4"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	awsRDSSigningService = "rds-db"
+	// RDS accepts an authentication token for 15 minutes after it was generated
+	awsIAMTokenLifetime = 15 * time.Minute
+	// Tokens are regenerated a few minutes before they expire so new connections & pings never use a stale one
+	awsIAMTokenRefreshMargin = 5 * time.Minute
+	// Instance Metadata Service (IMDSv2) endpoints serving the credentials of the EC2 instance role
+	awsIMDSTokenURL       = "http://169.254.169.254/latest/api/token"
+	awsIMDSCredentialsURL = "http://169.254.169.254/latest/meta-data/iam/security-credentials/"
+	// Endpoint serving the credentials of the ECS task role
+	awsECSCredentialsHost = "http://169.254.170.2"
+)
+
+type awsCredentials struct {
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	expiresAt       time.Time // Zero for static credentials
+}
+
+type awsIAMToken struct {
+	value     string
+	expiresAt time.Time
+}
+
+// awsIAMTokens caches authentication tokens per endpoint & database user
+var awsIAMTokens = struct {
+	sync.Mutex
+	tokens map[string]awsIAMToken
+}{tokens: make(map[string]awsIAMToken)}
+
+// awsRoleCredentials caches the temporary credentials of the instance or task role
+var awsRoleCredentials = struct {
+	sync.Mutex
+	credentials *awsCredentials
+}{}
+
+// validateAWSIAMAuth checks the connection can authenticate with RDS IAM authentication tokens
+func validateAWSIAMAuth(config ConnectionConfig) error {
+	switch config.Type {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeMySQL:
+	default:
+		return fmt.Errorf("AWS IAM authentication is not supported for %s connections", config.Type)
+	}
+
+	if UsesUnixSocket(config) {
+		return fmt.Errorf("AWS IAM authentication requires a host & port, not a unix socket")
+	}
+
+	// Tokens are sent as cleartext passwords, they must never leave an unencrypted connection
+	if !config.UseSSL || (config.SSLMode != nil && *config.SSLMode == "disable") {
+		return fmt.Errorf("AWS IAM authentication requires SSL to be enabled")
+	}
+
+	if getAWSRegion(config) == "" {
+		return fmt.Errorf("AWS region is required when it can not be read from the RDS endpoint")
+	}
+
+	// Without an access key the credentials of the environment, the ECS task role or the EC2 instance role are used
+	hasAccessKey := config.AWSAccessKeyID != nil && *config.AWSAccessKeyID != ""
+	hasSecretKey := config.AWSSecretAccessKey != nil && *config.AWSSecretAccessKey != ""
+	if hasAccessKey != hasSecretKey {
+		return fmt.Errorf("AWS access key ID and secret access key must be provided together")
+	}
+	return nil
+}
+
+// getAWSRegion returns the configured region, or the one of the RDS endpoint (e.g. mydb.abc123.us-east-1.rds.amazonaws.com)
+func getAWSRegion(config ConnectionConfig) string {
+	if config.AWSRegion != nil && *config.AWSRegion != "" {
+		return *config.AWSRegion
+	}
+
+	parts := strings.Split(strings.ToLower(config.Host), ".")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] == "rds" {
+			return parts[i-1]
+		}
+	}
+
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		return region
+	}
+	return os.Getenv("AWS_DEFAULT_REGION")
+}
+
+// getAWSIAMAuthToken returns a cached RDS authentication token for the endpoint & user, a new one is generated when it is about to expire
+func getAWSIAMAuthToken(ctx context.Context, config ConnectionConfig) (string, error) {
+	port := "5432"
+	if config.Type == constants.DatabaseTypeMySQL {
+		port = "3306"
+	}
+	if config.Port != nil && *config.Port != "" {
+		port = *config.Port
+	}
+	endpoint := fmt.Sprintf("%s:%s", config.Host, port)
+	region := getAWSRegion(config)
+	accessKeyID := ""
+	if config.AWSAccessKeyID != nil {
+		accessKeyID = *config.AWSAccessKeyID
+	}
+
+	cacheKey := endpoint + "|" + *config.Username + "|" + region + "|" + accessKeyID
+
+	awsIAMTokens.Lock()
+	defer awsIAMTokens.Unlock()
+
+	if cached, ok := awsIAMTokens.tokens[cacheKey]; ok && time.Now().Add(awsIAMTokenRefreshMargin).Before(cached.expiresAt) {
+		return cached.value, nil
+	}
+
+	credentials, err := resolveAWSCredentials(ctx, config)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	token := buildRDSAuthToken(endpoint, region, *config.Username, credentials, now)
+
+	// The token can not outlive the temporary credentials it was signed with
+	expiresAt := now.Add(awsIAMTokenLifetime)
+	if !credentials.expiresAt.IsZero() && credentials.expiresAt.Before(expiresAt) {
+		expiresAt = credentials.expiresAt
+	}
+
+	log.Printf("AWSIAM -> getAWSIAMAuthToken -> Generated token for %s valid until %s", endpoint, expiresAt.Format(time.RFC3339))
+	awsIAMTokens.tokens[cacheKey] = awsIAMToken{value: token, expiresAt: expiresAt}
+	return token, nil
+}
+
+// buildRDSAuthToken presigns a connect action with Signature Version 4, the presigned URL without scheme is the token
+func buildRDSAuthToken(endpoint, region, dbUser string, credentials *awsCredentials, now time.Time) string {
+	connectURL := &url.URL{
+		Scheme:   "https",
+		Host:     endpoint,
+		Path:     "/",
+		RawQuery: url.Values{"Action": {"connect"}, "DBUser": {dbUser}}.Encode(),
+	}
+	signed := sigv4.PresignURL(http.MethodGet, connectURL, sigv4.PayloadHash(nil), sigv4.Credentials{
+		AccessKeyID:     credentials.accessKeyID,
+		SecretAccessKey: credentials.secretAccessKey,
+		SessionToken:    credentials.sessionToken,
+	}, region, awsRDSSigningService, awsIAMTokenLifetime, now)
+	return strings.TrimPrefix(signed.String(), "https://")
+}
+
+// resolveAWSCredentials returns the access key of the connection, falling back to the environment, the ECS task role & the EC2 instance role
+func resolveAWSCredentials(ctx context.Context, config ConnectionConfig) (*awsCredentials, error) {
+	if config.AWSAccessKeyID != nil && *config.AWSAccessKeyID != "" && config.AWSSecretAccessKey != nil {
+		return &awsCredentials{accessKeyID: *config.AWSAccessKeyID, secretAccessKey: *config.AWSSecretAccessKey}, nil
+	}
+
+	if accessKeyID := os.Getenv("AWS_ACCESS_KEY_ID"); accessKeyID != "" {
+		return &awsCredentials{
+			accessKeyID:     accessKeyID,
+			secretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			sessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}, nil
+	}
+
+	awsRoleCredentials.Lock()
+	defer awsRoleCredentials.Unlock()
+
+	if cached := awsRoleCredentials.credentials; cached != nil && time.Now().Add(awsIAMTokenRefreshMargin).Before(cached.expiresAt) {
+		return cached, nil
+	}
+
+	var credentials *awsCredentials
+	var err error
+	if relativeURI := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); relativeURI != "" {
+		credentials, err = fetchAWSRoleCredentials(ctx, awsECSCredentialsHost+relativeURI, nil)
+	} else {
+		credentials, err = fetchAWSInstanceRoleCredentials(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	awsRoleCredentials.credentials = credentials
+	return credentials, nil
+}
+
+// fetchAWSInstanceRoleCredentials reads the credentials of the EC2 instance role through IMDSv2
+func fetchAWSInstanceRoleCredentials(ctx context.Context) (*awsCredentials, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, awsIMDSTokenURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("AWS IAM: failed to create metadata token request: %v", err)
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "300")
+	imdsToken, err := doAWSMetadataRequest(req)
+	if err != nil {
+		return nil, fmt.Errorf("AWS IAM: no credentials configured & instance metadata is not available: %v", err)
+	}
+	headers := map[string]string{"X-aws-ec2-metadata-token": imdsToken}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, awsIMDSCredentialsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("AWS IAM: failed to create instance role request: %v", err)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	roleName, err := doAWSMetadataRequest(req)
+	if err != nil {
+		return nil, fmt.Errorf("AWS IAM: failed to read the instance role: %v", err)
+	}
+	roleName = strings.TrimSpace(strings.Split(roleName, "\n")[0])
+	if roleName == "" {
+		return nil, fmt.Errorf("AWS IAM: no role is attached to the instance")
+	}
+
+	return fetchAWSRoleCredentials(ctx, awsIMDSCredentialsURL+url.PathEscape(roleName), headers)
+}
+
+// fetchAWSRoleCredentials reads temporary role credentials from the ECS or EC2 metadata endpoint
+func fetchAWSRoleCredentials(ctx context.Context, credentialsURL string, headers map[string]string) (*awsCredentials, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, credentialsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("AWS IAM: failed to create role credentials request: %v", err)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	body, err := doAWSMetadataRequest(req)
+	if err != nil {
+		return nil, fmt.Errorf("AWS IAM: failed to read role credentials: %v", err)
+	}
+
+	var credentialsResp struct {
+		AccessKeyID     string `json:"AccessKeyId"`
+		SecretAccessKey string `json:"SecretAccessKey"`
+		Token           string `json:"Token"`
+		Expiration      string `json:"Expiration"`
+	}
+	if err := json.Unmarshal([]byte(body), &credentialsResp); err != nil {
+		return nil, fmt.Errorf("AWS IAM: failed to decode role credentials: %v", err)
+	}
+	if credentialsResp.AccessKeyID == "" || credentialsResp.SecretAccessKey == "" {
+		return nil, fmt.Errorf("AWS IAM: role credentials response is missing the access key")
+	}
+
+	// Fall back to the shortest role session duration if the expiration can not be read
+	expiresAt := time.Now().Add(15 * time.Minute)
+	if parsed, err := time.Parse(time.RFC3339, credentialsResp.Expiration); err == nil {
+		expiresAt = parsed
+	}
+
+	return &awsCredentials{
+		accessKeyID:     credentialsResp.AccessKeyID,
+		secretAccessKey: credentialsResp.SecretAccessKey,
+		sessionToken:    credentialsResp.Token,
+		expiresAt:       expiresAt,
+	}, nil
+}
+
+// doAWSMetadataRequest sends a request to a metadata endpoint & returns the response body
+func doAWSMetadataRequest(req *http.Request) (string, error) {
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return string(body), nil
+}
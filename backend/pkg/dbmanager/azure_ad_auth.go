@@ -0,0 +1,183 @@
+package dbmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"neobase-ai/internal/constants"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	azureADAuthorityURL = "https://login.microsoftonline.com"
+	// Resource of Azure Database for PostgreSQL & MySQL flexible servers
+	azureADDatabaseResource = "https://ossrdbms-aad.database.windows.net"
+	// Instance Metadata Service endpoint serving managed identity tokens
+	azureManagedIdentityURL = "http://169.254.169.254/metadata/identity/oauth2/token"
+	// Tokens are refreshed a few minutes before they expire so new connections never use a stale one
+	azureADTokenRefreshMargin = 5 * time.Minute
+)
+
+type azureADToken struct {
+	value     string
+	expiresAt time.Time
+}
+
+// azureADTokens caches access tokens per identity, shared by every connection using the same identity
+var azureADTokens = struct {
+	sync.Mutex
+	tokens map[string]azureADToken
+}{tokens: make(map[string]azureADToken)}
+
+// validateAzureADAuth checks the connection can authenticate with Azure AD / Entra ID tokens
+func validateAzureADAuth(config ConnectionConfig) error {
+	switch config.Type {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeMySQL:
+	default:
+		return fmt.Errorf("azure AD authentication is not supported for %s connections", config.Type)
+	}
+
+	// Tokens are sent as cleartext passwords, they must never leave an unencrypted connection
+	if !config.UseSSL || (config.SSLMode != nil && *config.SSLMode == "disable") {
+		return fmt.Errorf("azure AD authentication requires SSL to be enabled")
+	}
+
+	// Without a client secret a managed identity is used, the client ID then selects a user-assigned identity
+	hasSecret := config.AzureClientSecret != nil && *config.AzureClientSecret != ""
+	if hasSecret && (config.AzureTenantID == nil || *config.AzureTenantID == "" || config.AzureClientID == nil || *config.AzureClientID == "") {
+		return fmt.Errorf("azure tenant ID and client ID are required when a client secret is provided")
+	}
+	return nil
+}
+
+// getAzureADToken returns a cached access token for the identity of the connection, a new one is requested when it is about to expire
+func getAzureADToken(ctx context.Context, config ConnectionConfig) (string, error) {
+	tenantID, clientID, clientSecret := "", "", ""
+	if config.AzureTenantID != nil {
+		tenantID = *config.AzureTenantID
+	}
+	if config.AzureClientID != nil {
+		clientID = *config.AzureClientID
+	}
+	if config.AzureClientSecret != nil {
+		clientSecret = *config.AzureClientSecret
+	}
+
+	cacheKey := tenantID + "|" + clientID + "|" + clientSecret
+
+	azureADTokens.Lock()
+	defer azureADTokens.Unlock()
+
+	if cached, ok := azureADTokens.tokens[cacheKey]; ok && time.Now().Add(azureADTokenRefreshMargin).Before(cached.expiresAt) {
+		return cached.value, nil
+	}
+
+	var token azureADToken
+	var err error
+	if clientSecret != "" {
+		token, err = fetchAzureADClientCredentialsToken(ctx, tenantID, clientID, clientSecret)
+	} else {
+		token, err = fetchAzureManagedIdentityToken(ctx, clientID)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	log.Printf("AzureAD -> getAzureADToken -> Acquired token valid until %s", token.expiresAt.Format(time.RFC3339))
+	azureADTokens.tokens[cacheKey] = token
+	return token.value, nil
+}
+
+// fetchAzureADClientCredentialsToken requests a token for a service principal with the client credentials grant
+func fetchAzureADClientCredentialsToken(ctx context.Context, tenantID, clientID, clientSecret string) (azureADToken, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", clientID)
+	form.Set("client_secret", clientSecret)
+	form.Set("scope", azureADDatabaseResource+"/.default")
+
+	tokenURL := fmt.Sprintf("%s/%s/oauth2/v2.0/token", azureADAuthorityURL, url.PathEscape(tenantID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return azureADToken{}, fmt.Errorf("azure AD: failed to create token request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var tokenResp struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	statusCode, err := doAzureADTokenRequest(req, &tokenResp)
+	if err != nil {
+		return azureADToken{}, err
+	}
+	if statusCode != http.StatusOK || tokenResp.AccessToken == "" {
+		return azureADToken{}, fmt.Errorf("azure AD: failed to obtain access token: %s %s", tokenResp.Error, tokenResp.ErrorDescription)
+	}
+
+	return azureADToken{
+		value:     tokenResp.AccessToken,
+		expiresAt: time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// fetchAzureManagedIdentityToken requests a token for the managed identity of the host running NeoBase
+func fetchAzureManagedIdentityToken(ctx context.Context, clientID string) (azureADToken, error) {
+	params := url.Values{}
+	params.Set("api-version", "2018-02-01")
+	params.Set("resource", azureADDatabaseResource)
+	if clientID != "" {
+		params.Set("client_id", clientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, azureManagedIdentityURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return azureADToken{}, fmt.Errorf("azure AD: failed to create managed identity token request: %v", err)
+	}
+	req.Header.Set("Metadata", "true")
+
+	var tokenResp struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresOn        string `json:"expires_on"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	statusCode, err := doAzureADTokenRequest(req, &tokenResp)
+	if err != nil {
+		return azureADToken{}, err
+	}
+	if statusCode != http.StatusOK || tokenResp.AccessToken == "" {
+		return azureADToken{}, fmt.Errorf("azure AD: failed to obtain managed identity token: %s %s", tokenResp.Error, tokenResp.ErrorDescription)
+	}
+
+	// expires_on is a unix timestamp in seconds, fall back to the shortest token lifetime if it can not be read
+	expiresAt := time.Now().Add(time.Hour)
+	if seconds, err := strconv.ParseInt(tokenResp.ExpiresOn, 10, 64); err == nil {
+		expiresAt = time.Unix(seconds, 0)
+	}
+
+	return azureADToken{value: tokenResp.AccessToken, expiresAt: expiresAt}, nil
+}
+
+// doAzureADTokenRequest sends a token request & decodes the JSON response
+func doAzureADTokenRequest(req *http.Request, out interface{}) (int, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("azure AD: token request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.StatusCode, fmt.Errorf("azure AD: failed to decode token response (status %d): %v", resp.StatusCode, err)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,226 @@
+package dbmanager
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/constants"
+	"strings"
+	"time"
+)
+
+// Statuses of the queries of a batch
+const (
+	BatchQueryStatusSucceeded  = "succeeded"   // The query ran & the batch was committed
+	BatchQueryStatusFailed     = "failed"      // The query failed, the batch was rolled back
+	BatchQueryStatusRolledBack = "rolled_back" // The query ran but a later query failed, the batch was rolled back
+	BatchQueryStatusSkipped    = "skipped"     // A previous query failed, the query was not run
+)
+
+// BatchQuery is a query of a batch, the queries of a batch run in order in one transaction
+type BatchQuery struct {
+	QueryID   string
+	Query     string
+	QueryType string
+	Params    map[string]interface{} // Values of the named parameters of the query
+}
+
+// BatchQueryResult is how a query of a batch ended, Result is only set for the queries that ran
+type BatchQueryResult struct {
+	QueryID string
+	Status  string
+	Result  *QueryExecutionResult
+	Error   *dtos.QueryError
+}
+
+// ExecuteBatch runs the queries in order in one transaction, the first failing query stops the batch & the transaction is rolled
+// back whole, the queries are committed together otherwise. The batch runs on the primary within the query timeout of the context
+// & can be cancelled through its streamID like a single query. Returns an error when the batch could not start.
+func (m *Manager) ExecuteBatch(ctx context.Context, chatID, messageID, streamID string, queries []BatchQuery) ([]BatchQueryResult, *dtos.QueryError) {
+	m.executionMu.Lock()
+	runCtx, cancel := context.WithCancel(ctx)
+	execution := &QueryExecution{
+		MessageID:   messageID,
+		StartTime:   time.Now(),
+		IsExecuting: true,
+		CancelFunc:  cancel,
+	}
+	m.activeExecutions[streamID] = execution
+	m.executionMu.Unlock()
+
+	defer func() {
+		m.executionMu.Lock()
+		delete(m.activeExecutions, streamID)
+		m.executionMu.Unlock()
+		cancel()
+	}()
+
+	conn, driver, queryErr := m.groundingConnection(chatID)
+	if queryErr != nil {
+		return nil, queryErr
+	}
+	if !transactionalDatabases[conn.Config.Type] {
+		return nil, &dtos.QueryError{
+			Code:    "BATCH_NOT_SUPPORTED",
+			Message: "the queries can't run in one transaction",
+			Details: fmt.Sprintf("%s has no transactions, execute the queries one by one", conn.Config.Type),
+		}
+	}
+	// The queries of an open transaction already commit or roll back together
+	if m.transactionSessionFor(chatID) != nil {
+		return nil, &dtos.QueryError{
+			Code:    "TRANSACTION_SESSION_OPEN",
+			Message: "the chat has an open transaction",
+			Details: "Execute the queries one by one in the open transaction, or end it first",
+		}
+	}
+	for _, query := range queries {
+		if err := CheckServerFeatures(conn.Dialect(), conn.ServerVersion, query.Query); err != nil {
+			return nil, &dtos.QueryError{
+				Code:    "UNSUPPORTED_BY_SERVER_VERSION",
+				Message: "query uses a feature the server version does not support",
+				Details: err.Error(),
+			}
+		}
+	}
+
+	// The batch takes a single slot of the connection, its queries run one after the other
+	release, queueErr := m.acquireQuerySlot(runCtx, conn, messageID, "", streamID)
+	if queueErr != nil {
+		return nil, queueErr
+	}
+	defer release()
+
+	execCtx, cancelTimeout := context.WithTimeout(runCtx, QueryTimeout(ctx))
+	defer cancelTimeout()
+
+	tx := driver.BeginTx(execCtx, conn)
+	if tx == nil {
+		return nil, &dtos.QueryError{
+			Code:    "FAILED_TO_START_TRANSACTION",
+			Message: "failed to start transaction",
+			Details: "Failed to start transaction",
+		}
+	}
+	if mongoTx, ok := tx.(*MongoDBTransaction); ok && mongoTx.Error != nil {
+		return nil, &dtos.QueryError{
+			Code:    "FAILED_TO_START_TRANSACTION",
+			Message: "failed to start transaction",
+			Details: mongoTx.Error.Error(),
+		}
+	}
+	m.executionMu.Lock()
+	execution.Tx = tx
+	m.executionMu.Unlock()
+
+	results := make([]BatchQueryResult, len(queries))
+	for i, query := range queries {
+		results[i] = BatchQueryResult{QueryID: query.QueryID, Status: BatchQueryStatusSkipped}
+	}
+
+	for i, query := range queries {
+		log.Printf("DBManager -> ExecuteBatch -> Executing query %d of %d", i+1, len(queries))
+		result, queryErr := m.executeBatchQuery(execCtx, tx, conn, chatID, messageID, query)
+		results[i].Result = result
+		if queryErr == nil {
+			results[i].Status = BatchQueryStatusSucceeded
+			continue
+		}
+
+		log.Printf("DBManager -> ExecuteBatch -> Query %d of %d failed, rolling back the batch: %s", i+1, len(queries), queryErr.Message)
+		if execCtx.Err() != nil {
+			cancelOnServer(tx)
+		}
+		if err := tx.Rollback(); err != nil {
+			log.Printf("DBManager -> ExecuteBatch -> Error rolling back transaction: %v", err)
+		}
+		results[i].Status, results[i].Error = BatchQueryStatusFailed, queryErr
+		for j := 0; j < i; j++ {
+			results[j].Status = BatchQueryStatusRolledBack
+		}
+		return results, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		commitErr := &dtos.QueryError{
+			Code:    "QUERY_EXECUTION_FAILED",
+			Message: "failed to commit the batch",
+			Details: err.Error(),
+		}
+		for i := range results {
+			results[i].Status, results[i].Error = BatchQueryStatusRolledBack, commitErr
+		}
+		return results, nil
+	}
+
+	schemaChanged := false
+	for i, query := range queries {
+		if conn.Config.Type != constants.DatabaseTypeMongoDB {
+			capResultRows(ctx, results[i].Result)
+		}
+		describeResultColumns(results[i].Result)
+		if m.streamHandler != nil {
+			go m.streamHandler.HandleQueryExecuted(chatID, messageID, query.QueryID, conn.Config.Type, query.Query, false)
+		}
+		schemaChanged = schemaChanged || isSchemaChangingQuery(conn.Config.Type, query.QueryType)
+	}
+	if schemaChanged && conn.OnSchemaChange != nil {
+		go func() {
+			time.Sleep(2 * time.Second)
+			conn.OnSchemaChange(conn.ChatID)
+		}()
+	}
+	return results, nil
+}
+
+// executeBatchQuery runs a query of a batch in the transaction of the batch, it stops at the timeout or the cancellation of the batch
+func (m *Manager) executeBatchQuery(ctx context.Context, tx Transaction, conn *Connection, chatID, messageID string, batchQuery BatchQuery) (*QueryExecutionResult, *dtos.QueryError) {
+	queryCtx := ctx
+	if len(batchQuery.Params) > 0 {
+		queryCtx = WithQueryParams(ctx, batchQuery.Params)
+	}
+	query, queryErr := prepareQueryParams(queryCtx, conn.Config.Type, batchQuery.Query)
+	if queryErr != nil {
+		return nil, queryErr
+	}
+	query, limit := applyAutoLimit(queryCtx, conn.Config.Type, query)
+
+	originalQuery := query
+	statements := scriptStatements(conn.Config.Type, originalQuery)
+	query = m.prepareAuditedQuery(conn, chatID, messageID, batchQuery.QueryID, query)
+	query = withStatementTimeout(queryCtx, conn.Config.Type, query, true)
+
+	var result *QueryExecutionResult
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if len(statements) > 0 && strings.HasSuffix(query, originalQuery) {
+			result = executeScript(queryCtx, tx, conn, strings.TrimSuffix(query, originalQuery), statements, batchQuery.QueryType)
+		} else {
+			result = tx.ExecuteQuery(queryCtx, conn, query, batchQuery.QueryType, false)
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, executionStoppedError(ctx)
+	case <-done:
+	}
+	if result == nil {
+		return nil, &dtos.QueryError{Code: "QUERY_EXECUTION_FAILED", Message: "query execution failed"}
+	}
+	if result.Error != nil {
+		return result, result.Error
+	}
+	markAutoLimited(result, limit)
+	return result, nil
+}
+
+// isSchemaChangingQuery returns true when a committed query of the type changes the schema the chat knows
+func isSchemaChangingQuery(dbType, queryType string) bool {
+	if dbType == constants.DatabaseTypeMongoDB {
+		return queryType == "CREATE_COLLECTION" || queryType == "DROP_COLLECTION"
+	}
+	return queryType == "DDL" || queryType == "ALTER" || queryType == "DROP"
+}
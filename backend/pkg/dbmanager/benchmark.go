@@ -0,0 +1,222 @@
+package dbmanager
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"neobase-ai/internal/apis/dtos"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Benchmark limits, a benchmark runs the query sequentially so they bound the time the connection is kept busy
+const (
+	BenchmarkDefaultIterations = 10
+	BenchmarkMaxIterations     = 100
+	BenchmarkDefaultWarmUp     = 1
+	BenchmarkMaxWarmUp         = 10
+	benchmarkTimeout           = 5 * time.Minute
+)
+
+// BenchmarkOptions configures a query benchmark, the warm-up runs fill the caches & are not measured
+type BenchmarkOptions struct {
+	Iterations int
+	WarmUp     int
+}
+
+// BenchmarkRun is a measured execution of a benchmarked query
+type BenchmarkRun struct {
+	Iteration  int     `json:"iteration"`
+	DurationMs float64 `json:"duration_ms"`
+	Rows       int     `json:"rows"`
+}
+
+// BenchmarkResult summarizes the latency & row counts of the measured executions of a query
+type BenchmarkResult struct {
+	Iterations    int            `json:"iterations"`
+	WarmUp        int            `json:"warm_up"`
+	MinMs         float64        `json:"min_ms"`
+	MedianMs      float64        `json:"median_ms"`
+	P95Ms         float64        `json:"p95_ms"`
+	MaxMs         float64        `json:"max_ms"`
+	MeanMs        float64        `json:"mean_ms"`
+	Rows          int            `json:"rows"`            // Rows returned by the first measured run
+	RowsVary      bool           `json:"rows_vary"`       // The runs returned different row counts, e.g. the data changed meanwhile
+	OnReadReplica bool           `json:"on_read_replica"` // The query was served by a read replica
+	Runs          []BenchmarkRun `json:"runs"`
+}
+
+// BenchmarkQuery executes a read-only query several times in a row (concurrency of 1) & measures each execution.
+// Writes are refused since they would be applied on every run. The benchmark can be cancelled like an execution, with its stream ID.
+func (m *Manager) BenchmarkQuery(ctx context.Context, chatID, streamID, query, queryType string, opts BenchmarkOptions) (*BenchmarkResult, *dtos.QueryError) {
+	m.mu.RLock()
+	conn, exists := m.connections[chatID]
+	m.mu.RUnlock()
+	if !exists {
+		return nil, &dtos.QueryError{
+			Code:    "NO_CONNECTION_FOUND",
+			Message: "no connection found",
+			Details: "No connection found for chat ID: " + chatID,
+		}
+	}
+
+	driver, exists := m.drivers[conn.Config.Type]
+	if !exists {
+		return nil, &dtos.QueryError{
+			Code:    "NO_DRIVER_FOUND",
+			Message: "no driver found",
+			Details: "No driver found for type: " + conn.Config.Type,
+		}
+	}
+
+	if !IsReadOnlyQuery(conn.Config.Type, query) {
+		return nil, &dtos.QueryError{
+			Code:    "BENCHMARK_REQUIRES_READ_ONLY_QUERY",
+			Message: "only read-only queries can be benchmarked",
+			Details: "The query would modify the database on every run",
+		}
+	}
+	if err := CheckServerFeatures(conn.Dialect(), conn.ServerVersion, query); err != nil {
+		return nil, &dtos.QueryError{
+			Code:    "UNSUPPORTED_BY_SERVER_VERSION",
+			Message: "query uses a feature the server version does not support",
+			Details: err.Error(),
+		}
+	}
+
+	if opts.Iterations <= 0 {
+		opts.Iterations = BenchmarkDefaultIterations
+	}
+	opts.Iterations = min(opts.Iterations, BenchmarkMaxIterations)
+	opts.WarmUp = max(min(opts.WarmUp, BenchmarkMaxWarmUp), 0)
+
+	benchCtx, cancel := context.WithTimeout(ctx, benchmarkTimeout)
+	m.executionMu.Lock()
+	m.activeExecutions[streamID] = &QueryExecution{
+		StartTime:   time.Now(),
+		IsExecuting: true,
+		CancelFunc:  cancel,
+	}
+	m.executionMu.Unlock()
+
+	defer func() {
+		m.executionMu.Lock()
+		delete(m.activeExecutions, streamID)
+		m.executionMu.Unlock()
+		cancel()
+	}()
+
+	// The runs follow each other in one slot of the connection, the benchmark waits in its queue like an execution
+	release, queueErr := m.acquireQuerySlot(benchCtx, conn, "", "", streamID)
+	if queueErr != nil {
+		return nil, queueErr
+	}
+	defer release()
+
+	// Every run goes to the same connection, alternating replicas would mix their latencies
+	execConn := m.routeQuery(ctx, conn, query)
+	result := &BenchmarkResult{
+		Iterations:    opts.Iterations,
+		WarmUp:        opts.WarmUp,
+		OnReadReplica: execConn != conn,
+		Runs:          make([]BenchmarkRun, 0, opts.Iterations),
+	}
+
+	log.Printf("DBManager -> BenchmarkQuery -> Running %d warm-up & %d measured executions for chatID: %s", opts.WarmUp, opts.Iterations, chatID)
+	for i := 0; i < opts.WarmUp+opts.Iterations; i++ {
+		if err := benchCtx.Err(); err != nil {
+			return nil, benchmarkContextError(err)
+		}
+
+		startTime := time.Now()
+		execution := driver.ExecuteQuery(benchCtx, execConn, query, queryType, false)
+		duration := time.Since(startTime)
+
+		if err := benchCtx.Err(); err != nil {
+			return nil, benchmarkContextError(err)
+		}
+		if execution.Error != nil {
+			return nil, execution.Error
+		}
+		if i < opts.WarmUp {
+			continue
+		}
+
+		run := BenchmarkRun{
+			Iteration:  i - opts.WarmUp + 1,
+			DurationMs: float64(duration.Microseconds()) / 1000,
+			Rows:       CountResultRows(execution),
+		}
+		if len(result.Runs) == 0 {
+			result.Rows = run.Rows
+		} else if run.Rows != result.Rows {
+			result.RowsVary = true
+		}
+		result.Runs = append(result.Runs, run)
+	}
+
+	summarizeBenchmark(result)
+	return result, nil
+}
+
+// summarizeBenchmark computes the latency statistics of the measured runs, p95 is the nearest-rank percentile
+func summarizeBenchmark(result *BenchmarkResult) {
+	if len(result.Runs) == 0 {
+		return
+	}
+
+	durations := make([]float64, len(result.Runs))
+	total := 0.0
+	for i, run := range result.Runs {
+		durations[i] = run.DurationMs
+		total += run.DurationMs
+	}
+	sort.Float64s(durations)
+
+	count := len(durations)
+	result.MinMs = durations[0]
+	result.MaxMs = durations[count-1]
+	result.MeanMs = math.Round(total/float64(count)*1000) / 1000
+	if count%2 == 1 {
+		result.MedianMs = durations[count/2]
+	} else {
+		result.MedianMs = math.Round((durations[count/2-1]+durations[count/2])/2*1000) / 1000
+	}
+	result.P95Ms = durations[int(math.Ceil(0.95*float64(count)))-1]
+}
+
+func benchmarkContextError(err error) *dtos.QueryError {
+	if err == context.DeadlineExceeded {
+		return &dtos.QueryError{
+			Code:    "BENCHMARK_TIMED_OUT",
+			Message: "benchmark timed out",
+			Details: fmt.Sprintf("The benchmark did not finish within %s", benchmarkTimeout),
+		}
+	}
+	return &dtos.QueryError{
+		Code:    "BENCHMARK_CANCELLED",
+		Message: "benchmark cancelled",
+		Details: "Benchmark cancelled",
+	}
+}
+
+// CountResultRows returns the number of rows in a query result
+func CountResultRows(result *QueryExecutionResult) int {
+	if result == nil || result.Result == nil {
+		return 0
+	}
+
+	rows, ok := result.Result["results"]
+	if !ok || rows == nil {
+		return 0
+	}
+
+	value := reflect.ValueOf(rows)
+	if value.Kind() == reflect.Slice || value.Kind() == reflect.Array {
+		return value.Len()
+	}
+	// Single document results
+	return 1
+}
@@ -0,0 +1,335 @@
+package dbmanager
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/constants"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Change stream watches push the changes of MongoDB collections to the chat's stream, they are bounded so a forgotten
+// watch does not keep a cursor open forever
+const (
+	ChangeStreamEvent        = "db-change"         // SSE event carrying a change of a watched collection
+	ChangeStreamStoppedEvent = "db-change-stopped" // SSE event sent when a watch ends
+	DefaultWatchDuration     = 30 * time.Minute
+	MaxWatchDuration         = 2 * time.Hour
+	maxWatchesPerChat        = 5
+	maxWatchedCollections    = 10
+)
+
+// Reasons a watch ended, sent with the ChangeStreamStoppedEvent
+const (
+	WatchStopReasonStopped      = "stopped"
+	WatchStopReasonExpired      = "expired"
+	WatchStopReasonDisconnected = "disconnected"
+	WatchStopReasonError        = "error"
+)
+
+// watchableOperationTypes are the change stream operations a watch can be notified of
+var watchableOperationTypes = map[string]bool{"insert": true, "update": true, "replace": true, "delete": true}
+
+// defaultWatchOperationTypes notify of the new & the modified documents
+var defaultWatchOperationTypes = []string{"insert", "update", "replace"}
+
+// WatchOptions configures a change stream watch
+type WatchOptions struct {
+	Collections    []string
+	OperationTypes []string      // insert, update, replace & delete, inserts & updates by default
+	Duration       time.Duration // DefaultWatchDuration when zero, up to MaxWatchDuration
+}
+
+// ChangeStreamWatchInfo describes an open change stream watch
+type ChangeStreamWatchInfo struct {
+	ID             string    `json:"id"`
+	ChatID         string    `json:"chat_id"`
+	StreamID       string    `json:"stream_id"`
+	Collections    []string  `json:"collections"`
+	OperationTypes []string  `json:"operation_types"`
+	StartedAt      time.Time `json:"started_at"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	Events         int64     `json:"events"` // Changes pushed to the stream so far
+}
+
+// ChangeEvent is the data of a ChangeStreamEvent
+type ChangeEvent struct {
+	WatchID       string      `json:"watch_id"`
+	Collection    string      `json:"collection"`
+	OperationType string      `json:"operation_type"`
+	DocumentKey   interface{} `json:"document_key"`
+	FullDocument  interface{} `json:"full_document,omitempty"`  // Document after the change, not sent for deletes
+	UpdatedFields interface{} `json:"updated_fields,omitempty"` // Fields set by an update
+	RemovedFields interface{} `json:"removed_fields,omitempty"` // Fields unset by an update
+	ClusterTime   time.Time   `json:"cluster_time"`
+}
+
+// WatchStoppedNotice is the data of a ChangeStreamStoppedEvent
+type WatchStoppedNotice struct {
+	WatchID string `json:"watch_id"`
+	Reason  string `json:"reason"`
+	Error   string `json:"error,omitempty"`
+	Events  int64  `json:"events"`
+}
+
+// changeStreamWatch is an open change stream pushing its changes to the stream of a chat
+type changeStreamWatch struct {
+	info       ChangeStreamWatchInfo
+	userID     string
+	events     atomic.Int64
+	stopReason string // Set before the watch is cancelled, guarded by the watches lock
+	cancel     context.CancelFunc
+}
+
+// snapshot returns the description of the watch with its current event count
+func (w *changeStreamWatch) snapshot() ChangeStreamWatchInfo {
+	info := w.info
+	info.Events = w.events.Load()
+	return info
+}
+
+// StartChangeStreamWatch opens a change stream on collections of the chat's MongoDB database & pushes their changes to the
+// stream as ChangeStreamEvent events until the watch is stopped, expires or the chat disconnects.
+// Change streams need a replica set or a sharded cluster, the error of a standalone server is returned.
+func (m *Manager) StartChangeStreamWatch(chatID, streamID string, opts WatchOptions) (*ChangeStreamWatchInfo, error) {
+	m.mu.RLock()
+	conn, exists := m.connections[chatID]
+	m.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("connection not found for chat %s", chatID)
+	}
+	if conn.Config.Type != constants.DatabaseTypeMongoDB {
+		return nil, fmt.Errorf("watching changes is only supported for MongoDB connections")
+	}
+	wrapper, ok := conn.MongoDBObj.(*MongoDBWrapper)
+	if !ok || wrapper == nil {
+		return nil, fmt.Errorf("invalid MongoDB connection")
+	}
+
+	collections, operationTypes, err := normalizeWatchOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	duration := opts.Duration
+	if duration <= 0 {
+		duration = DefaultWatchDuration
+	}
+	duration = min(duration, MaxWatchDuration)
+
+	m.watchesMu.Lock()
+	count := 0
+	for _, watch := range m.watches {
+		if watch.info.ChatID == chatID {
+			count++
+		}
+	}
+	m.watchesMu.Unlock()
+	if count >= maxWatchesPerChat {
+		return nil, fmt.Errorf("a chat can have at most %d watches, stop one first", maxWatchesPerChat)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: "ns.coll", Value: bson.D{{Key: "$in", Value: collections}}},
+			{Key: "operationType", Value: bson.D{{Key: "$in", Value: operationTypes}}},
+		}}},
+	}
+	// The full document is looked up for updates, so the user sees the document as it is now
+	stream, err := wrapper.Client.Database(wrapper.Database).Watch(ctx, pipeline, options.ChangeStream().SetFullDocument(options.UpdateLookup))
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to open change stream, change streams need a replica set or a sharded cluster: %v", err)
+	}
+
+	now := time.Now()
+	watch := &changeStreamWatch{
+		info: ChangeStreamWatchInfo{
+			ID:             primitive.NewObjectID().Hex(),
+			ChatID:         chatID,
+			StreamID:       streamID,
+			Collections:    collections,
+			OperationTypes: operationTypes,
+			StartedAt:      now,
+			ExpiresAt:      now.Add(duration),
+		},
+		userID: conn.UserID,
+		cancel: cancel,
+	}
+
+	m.watchesMu.Lock()
+	m.watches[watch.info.ID] = watch
+	m.watchesMu.Unlock()
+
+	log.Printf("DBManager -> StartChangeStreamWatch -> Watching %v of chatID %s until %s, watchID: %s", collections, chatID, watch.info.ExpiresAt.Format(time.RFC3339), watch.info.ID)
+	go m.runChangeStreamWatch(ctx, watch, stream)
+
+	info := watch.snapshot()
+	return &info, nil
+}
+
+// StopChangeStreamWatch stops a watch of the chat
+func (m *Manager) StopChangeStreamWatch(chatID, watchID string) error {
+	m.watchesMu.Lock()
+	watch, exists := m.watches[watchID]
+	if !exists || watch.info.ChatID != chatID {
+		m.watchesMu.Unlock()
+		return fmt.Errorf("watch %s not found", watchID)
+	}
+	watch.stopReason = WatchStopReasonStopped
+	m.watchesMu.Unlock()
+
+	watch.cancel()
+	return nil
+}
+
+// ListChangeStreamWatches returns the open watches of the chat, the oldest first
+func (m *Manager) ListChangeStreamWatches(chatID string) []ChangeStreamWatchInfo {
+	m.watchesMu.Lock()
+	watches := []ChangeStreamWatchInfo{}
+	for _, watch := range m.watches {
+		if watch.info.ChatID == chatID {
+			watches = append(watches, watch.snapshot())
+		}
+	}
+	m.watchesMu.Unlock()
+
+	sort.Slice(watches, func(i, j int) bool {
+		return watches[i].StartedAt.Before(watches[j].StartedAt)
+	})
+	return watches
+}
+
+// stopChangeStreamWatches stops every watch of the chat, e.g. when its database is disconnected
+func (m *Manager) stopChangeStreamWatches(chatID, reason string) {
+	m.watchesMu.Lock()
+	for _, watch := range m.watches {
+		if watch.info.ChatID == chatID {
+			watch.stopReason = reason
+			watch.cancel()
+		}
+	}
+	m.watchesMu.Unlock()
+}
+
+// runChangeStreamWatch pushes the changes of the stream until its context ends, then tells the user why the watch ended
+func (m *Manager) runChangeStreamWatch(ctx context.Context, watch *changeStreamWatch, stream *mongo.ChangeStream) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("DBManager -> runChangeStreamWatch -> Panic recovered for watchID %s: %v", watch.info.ID, r)
+		}
+	}()
+	defer stream.Close(context.Background())
+
+	for stream.Next(ctx) {
+		var change bson.M
+		if err := stream.Decode(&change); err != nil {
+			log.Printf("DBManager -> runChangeStreamWatch -> Error decoding change for watchID %s: %v", watch.info.ID, err)
+			continue
+		}
+
+		watch.events.Add(1)
+		m.sendWatchEvent(watch, ChangeStreamEvent, buildChangeEvent(watch.info.ID, change))
+	}
+
+	notice := WatchStoppedNotice{WatchID: watch.info.ID}
+	m.watchesMu.Lock()
+	notice.Reason = watch.stopReason
+	delete(m.watches, watch.info.ID)
+	m.watchesMu.Unlock()
+	watch.cancel()
+
+	if notice.Reason == "" {
+		switch {
+		case ctx.Err() == context.DeadlineExceeded:
+			notice.Reason = WatchStopReasonExpired
+		case stream.Err() != nil:
+			notice.Reason = WatchStopReasonError
+			notice.Error = stream.Err().Error()
+		default:
+			notice.Reason = WatchStopReasonStopped
+		}
+	}
+	notice.Events = watch.events.Load()
+
+	log.Printf("DBManager -> runChangeStreamWatch -> Watch %s of chatID %s ended (%s) after %d changes", watch.info.ID, watch.info.ChatID, notice.Reason, notice.Events)
+	m.sendWatchEvent(watch, ChangeStreamStoppedEvent, notice)
+}
+
+// sendWatchEvent sends an event of the watch to the stream it was started from
+func (m *Manager) sendWatchEvent(watch *changeStreamWatch, event string, data interface{}) {
+	if m.streamHandler == nil || watch.info.StreamID == "" {
+		return
+	}
+	m.streamHandler.HandleDBEvent(watch.userID, watch.info.ChatID, watch.info.StreamID, dtos.StreamResponse{
+		Event: event,
+		Data:  data,
+	})
+}
+
+// buildChangeEvent converts a change stream document to the event sent to the user
+func buildChangeEvent(watchID string, change bson.M) ChangeEvent {
+	event := ChangeEvent{WatchID: watchID}
+	event.OperationType, _ = change["operationType"].(string)
+	if ns, ok := change["ns"].(bson.M); ok {
+		event.Collection, _ = ns["coll"].(string)
+	}
+	if documentKey, ok := change["documentKey"]; ok {
+		event.DocumentKey = convertMongoDBValue(documentKey)
+	}
+	if fullDocument, ok := change["fullDocument"]; ok && fullDocument != nil {
+		event.FullDocument = convertMongoDBValue(fullDocument)
+	}
+	if description, ok := change["updateDescription"].(bson.M); ok {
+		event.UpdatedFields = convertMongoDBValue(description["updatedFields"])
+		event.RemovedFields = convertMongoDBValue(description["removedFields"])
+	}
+	if clusterTime, ok := change["clusterTime"].(primitive.Timestamp); ok {
+		event.ClusterTime = time.Unix(int64(clusterTime.T), 0).UTC()
+	}
+	return event
+}
+
+// normalizeWatchOptions validates the collections & the operation types of a watch, duplicates are dropped
+func normalizeWatchOptions(opts WatchOptions) ([]string, []string, error) {
+	collections := uniqueTrimmed(opts.Collections)
+	if len(collections) == 0 {
+		return nil, nil, fmt.Errorf("at least one collection must be watched")
+	}
+	if len(collections) > maxWatchedCollections {
+		return nil, nil, fmt.Errorf("at most %d collections can be watched at once", maxWatchedCollections)
+	}
+
+	operationTypes := uniqueTrimmed(opts.OperationTypes)
+	if len(operationTypes) == 0 {
+		operationTypes = defaultWatchOperationTypes
+	}
+	for _, operationType := range operationTypes {
+		if !watchableOperationTypes[operationType] {
+			return nil, nil, fmt.Errorf("unsupported operation type %q, use insert, update, replace or delete", operationType)
+		}
+	}
+	return collections, operationTypes, nil
+}
+
+func uniqueTrimmed(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := []string{}
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value != "" && !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
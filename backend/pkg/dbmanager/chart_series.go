@@ -0,0 +1,370 @@
+package dbmanager
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"neobase-ai/internal/apis/dtos"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Aggregates of the y values of a chart point
+const (
+	ChartAggregateCount = "count" // The rows of the point, those whose y value is not NULL when a y column is set
+	ChartAggregateSum   = "sum"
+	ChartAggregateAvg   = "avg"
+	ChartAggregateMin   = "min"
+	ChartAggregateMax   = "max"
+)
+
+const (
+	// DefaultChartPoints is the number of x values of a chart grouped by value unless the request sets another
+	DefaultChartPoints = 100
+	// maxChartGroups bounds the points held while the result is read, a chart of more points is of little use
+	maxChartGroups = 10000
+)
+
+// chartTimeBuckets truncate a time to the start of its bucket, the weeks start on Monday
+var chartTimeBuckets = map[string]func(t time.Time) time.Time{
+	"minute": func(t time.Time) time.Time { return t.Truncate(time.Minute) },
+	"hour":   func(t time.Time) time.Time { return t.Truncate(time.Hour) },
+	"day": func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	},
+	"week": func(t time.Time) time.Time {
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	},
+	"month": func(t time.Time) time.Time { return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location()) },
+	"year":  func(t time.Time) time.Time { return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, t.Location()) },
+}
+
+// ChartSpec is how the rows of a result are turned into chart series
+type ChartSpec struct {
+	XColumn      string
+	YColumn      string // The column aggregated, empty to count the rows
+	SeriesColumn string // Splits the points in a series per value of the column, empty for a single series
+	Aggregate    string
+	TimeBucket   string // Groups the x values by minute, hour, day, week, month or year, empty to group them by value
+	MaxPoints    int    // The x values kept when grouped by value, those of the largest totals
+}
+
+// ChartSeries is a line or a set of bars of a chart, the points are ordered by time when the x values are bucketed
+type ChartSeries struct {
+	Name   string
+	Points []ChartPoint
+}
+
+// ChartPoint is an x value & the aggregate of the y values of its rows, X is nil for the rows whose x value is NULL
+type ChartPoint struct {
+	X interface{}
+	Y float64
+}
+
+// ChartData is the series of a result & the rows they were read from
+type ChartData struct {
+	Series        []ChartSeries
+	Rows          int64
+	Truncated     bool // The x values beyond MaxPoints were left out
+	OnReadReplica bool
+}
+
+// ChartQuerySeries reads the whole result of a read-only query & aggregates its rows into chart series as they are read, only the
+// points are held, never the rows. The rows are read like an export of the result, with the same limits.
+func (m *Manager) ChartQuerySeries(ctx context.Context, chatID, query string, spec ChartSpec) (*ChartData, *dtos.QueryError) {
+	if spec.Aggregate == "" {
+		spec.Aggregate = ChartAggregateCount
+	}
+	if spec.MaxPoints <= 0 {
+		spec.MaxPoints = DefaultChartPoints
+	}
+	if spec.Aggregate != ChartAggregateCount && spec.YColumn == "" {
+		return nil, &dtos.QueryError{
+			Code:    "INVALID_CHART",
+			Message: "the aggregate needs a y column",
+			Details: fmt.Sprintf("Set the column whose values are the %s of the points", spec.Aggregate),
+		}
+	}
+	if _, ok := chartTimeBuckets[spec.TimeBucket]; spec.TimeBucket != "" && !ok {
+		return nil, &dtos.QueryError{
+			Code:    "INVALID_CHART",
+			Message: "unknown time bucket",
+			Details: "The time bucket is one of minute, hour, day, week, month & year",
+		}
+	}
+
+	writer := &chartResultWriter{spec: spec, groups: map[string]*chartGroup{}}
+	summary, queryErr := m.exportQuery(ctx, chatID, query, 0, writer)
+	// The errors of the chart are more telling than the failed export they stopped
+	if writer.queryErr != nil {
+		return nil, writer.queryErr
+	}
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	series, truncated := writer.series()
+	return &ChartData{
+		Series:        series,
+		Rows:          summary.rows,
+		Truncated:     truncated,
+		OnReadReplica: summary.onReplica,
+	}, nil
+}
+
+// chartGroup aggregates the y values of the rows of a point
+type chartGroup struct {
+	series string
+	x      interface{} // The text of the x value, its bucket when the x values are bucketed, nil for NULL
+	time   time.Time   // The bucket of the x value
+	count  int64
+	values int64 // The y values read as numbers
+	sum    float64
+	min    float64
+	max    float64
+}
+
+// y returns the aggregate of the point, false when none of its y values is a number
+func (g *chartGroup) y(aggregate string) (float64, bool) {
+	switch aggregate {
+	case ChartAggregateCount:
+		return float64(g.count), true
+	case ChartAggregateSum:
+		return g.sum, true
+	}
+	if g.values == 0 {
+		return 0, false
+	}
+	switch aggregate {
+	case ChartAggregateAvg:
+		return g.sum / float64(g.values), true
+	case ChartAggregateMin:
+		return g.min, true
+	default:
+		return g.max, true
+	}
+}
+
+// chartResultWriter aggregates the rows of a result into the points of the chart
+type chartResultWriter struct {
+	spec     ChartSpec
+	format   exportFormat
+	columns  []exportColumn
+	x        int
+	y        int // -1 without a y column
+	split    int // -1 without a series column
+	groups   map[string]*chartGroup
+	queryErr *dtos.QueryError // Why the chart stopped the reading of the result
+}
+
+func (w *chartResultWriter) writeHeader(columns []exportColumn, format exportFormat) error {
+	w.columns, w.format = columns, format
+	w.x, w.y, w.split = -1, -1, -1
+	for _, column := range []struct {
+		name  string
+		index *int
+	}{{w.spec.XColumn, &w.x}, {w.spec.YColumn, &w.y}, {w.spec.SeriesColumn, &w.split}} {
+		if column.name == "" {
+			continue
+		}
+		if *column.index = chartColumnIndex(columns, column.name); *column.index < 0 {
+			w.queryErr = &dtos.QueryError{
+				Code:    "CHART_COLUMN_NOT_FOUND",
+				Message: "the result has no column " + column.name,
+				Details: "The columns of the result are " + strings.Join(chartColumnNames(columns), ", "),
+			}
+			return fmt.Errorf("column %s not found", column.name)
+		}
+	}
+	return nil
+}
+
+func (w *chartResultWriter) writeRow(values []interface{}) error {
+	series := ""
+	if w.split >= 0 {
+		series = "NULL"
+		if !isNullValue(values[w.split]) {
+			series = w.format.text(values[w.split], w.columns[w.split])
+		}
+	}
+
+	var x interface{}
+	var bucket time.Time
+	if !isNullValue(values[w.x]) {
+		if w.spec.TimeBucket == "" {
+			x = w.format.text(values[w.x], w.columns[w.x])
+		} else if t, ok := w.timeValue(values[w.x], w.columns[w.x]); ok {
+			// The values that are not times are left with the NULL ones
+			bucket = chartTimeBuckets[w.spec.TimeBucket](t)
+			x = bucket.Format(time.RFC3339)
+		}
+	}
+
+	key := fmt.Sprintf("%q\x00%v\x00%t", series, x, x == nil)
+	group, ok := w.groups[key]
+	if !ok {
+		if len(w.groups) >= maxChartGroups {
+			w.queryErr = &dtos.QueryError{
+				Code:    "CHART_TOO_MANY_POINTS",
+				Message: "the chart has too many points",
+				Details: fmt.Sprintf("The chart would have more than %d points, bucket the x values by time or group them by a column of fewer values", maxChartGroups),
+			}
+			return fmt.Errorf("more than %d points", maxChartGroups)
+		}
+		group = &chartGroup{series: series, x: x, time: bucket}
+		w.groups[key] = group
+	}
+
+	if w.y < 0 {
+		group.count++
+		return nil
+	}
+	if isNullValue(values[w.y]) {
+		return nil
+	}
+	group.count++
+	value, err := strconv.ParseFloat(strings.TrimSpace(w.format.text(values[w.y], w.columns[w.y])), 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil
+	}
+	if group.values == 0 || value < group.min {
+		group.min = value
+	}
+	if group.values == 0 || value > group.max {
+		group.max = value
+	}
+	group.values++
+	group.sum += value
+	return nil
+}
+
+// flush does nothing, the points are only read once the result is
+func (w *chartResultWriter) flush() error {
+	return nil
+}
+
+// timeValue returns the time of a value, the dates & timestamps some drivers return as text included
+func (w *chartResultWriter) timeValue(value interface{}, column exportColumn) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case primitive.DateTime:
+		return v.Time().UTC(), true
+	}
+	return parseTimeText(w.format.text(value, column))
+}
+
+// series returns the series of the chart by name, the points ordered by time when the x values are bucketed, else by their
+// totals across the series, of which the MaxPoints largest are kept
+func (w *chartResultWriter) series() ([]ChartSeries, bool) {
+	groupsBySeries := map[string][]*chartGroup{}
+	totals := map[interface{}]float64{}
+	for _, group := range w.groups {
+		groupsBySeries[group.series] = append(groupsBySeries[group.series], group)
+		if y, ok := group.y(w.spec.Aggregate); ok {
+			totals[group.x] += y
+		}
+	}
+
+	truncated := false
+	var kept map[interface{}]bool
+	rank := map[interface{}]int{}
+	if w.spec.TimeBucket == "" {
+		xs := make([]interface{}, 0, len(totals))
+		for x := range totals {
+			xs = append(xs, x)
+		}
+		sort.Slice(xs, func(i, j int) bool {
+			if totals[xs[i]] != totals[xs[j]] {
+				return totals[xs[i]] > totals[xs[j]]
+			}
+			return fmt.Sprint(xs[i]) < fmt.Sprint(xs[j])
+		})
+		if len(xs) > w.spec.MaxPoints {
+			xs, truncated = xs[:w.spec.MaxPoints], true
+		}
+		kept = make(map[interface{}]bool, len(xs))
+		for i, x := range xs {
+			kept[x], rank[x] = true, i
+		}
+	}
+
+	names := make([]string, 0, len(groupsBySeries))
+	for name := range groupsBySeries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	series := make([]ChartSeries, 0, len(names))
+	for _, name := range names {
+		groups := groupsBySeries[name]
+		if w.spec.TimeBucket != "" {
+			// The rows without a time come last
+			sort.Slice(groups, func(i, j int) bool {
+				if (groups[i].x == nil) != (groups[j].x == nil) {
+					return groups[j].x == nil
+				}
+				return groups[i].time.Before(groups[j].time)
+			})
+		} else {
+			sort.Slice(groups, func(i, j int) bool { return rank[groups[i].x] < rank[groups[j].x] })
+		}
+
+		points := make([]ChartPoint, 0, len(groups))
+		for _, group := range groups {
+			y, ok := group.y(w.spec.Aggregate)
+			if !ok || kept != nil && !kept[group.x] {
+				continue
+			}
+			points = append(points, ChartPoint{X: group.x, Y: y})
+		}
+		if len(points) == 0 && w.split >= 0 {
+			continue
+		}
+		if w.split < 0 {
+			name = w.spec.Aggregate
+			if w.spec.YColumn != "" {
+				name = fmt.Sprintf("%s(%s)", w.spec.Aggregate, w.spec.YColumn)
+			}
+		}
+		series = append(series, ChartSeries{Name: name, Points: points})
+	}
+	return series, truncated
+}
+
+// chartColumnIndex returns the index of a column of the result, its name may be written in another case, -1 if it has none
+func chartColumnIndex(columns []exportColumn, name string) int {
+	for i, column := range columns {
+		if column.name == name {
+			return i
+		}
+	}
+	for i, column := range columns {
+		if strings.EqualFold(column.name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func chartColumnNames(columns []exportColumn) []string {
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.name
+	}
+	return names
+}
+
+// isNullValue returns true for the NULL values of the drivers
+func isNullValue(value interface{}) bool {
+	switch value.(type) {
+	case nil, primitive.Null, primitive.Undefined:
+		return true
+	}
+	return false
+}
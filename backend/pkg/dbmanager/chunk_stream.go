@@ -0,0 +1,142 @@
+package dbmanager
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"neobase-ai/internal/apis/dtos"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	// DefaultResultChunkRows is the number of rows of a streamed chunk unless the request sets another
+	DefaultResultChunkRows = 500
+	// MaxResultChunkRows bounds the rows of a chunk, each chunk is a single SSE event
+	MaxResultChunkRows = 5000
+)
+
+// ResultChunk is a part of a streamed result, the rows are JSON objects in the order they were read
+type ResultChunk struct {
+	Index   int               // position of the chunk in the result, from 0
+	Columns []string          // names of the columns, nil for the MongoDB documents whose fields vary
+	Rows    []json.RawMessage // rows of the chunk, empty for the last chunk of a result ending on a chunk boundary
+	Last    bool
+}
+
+// StreamSummary describes the rows of a streamed result
+type StreamSummary struct {
+	Rows          int64
+	Chunks        int
+	OnReadReplica bool
+}
+
+// StreamQueryChunks reads the whole result of a read-only query & hands it to emit in chunks of chunkRows rows as the cursor
+// advances, the result is never held whole. The reading waits while emit does, a slow client slows it down rather than filling
+// the memory. The last chunk, possibly empty, is marked as such. The stream is cancelled with the query cancel route.
+func (m *Manager) StreamQueryChunks(ctx context.Context, chatID, streamID, query string, chunkRows int, emit func(chunk ResultChunk) error) (StreamSummary, *dtos.QueryError) {
+	if chunkRows <= 0 {
+		chunkRows = DefaultResultChunkRows
+	}
+	chunkRows = min(chunkRows, MaxResultChunkRows)
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	m.executionMu.Lock()
+	m.activeExecutions[streamID] = &QueryExecution{
+		StartTime:   time.Now(),
+		IsExecuting: true,
+		CancelFunc:  cancel,
+	}
+	m.executionMu.Unlock()
+
+	defer func() {
+		m.executionMu.Lock()
+		delete(m.activeExecutions, streamID)
+		m.executionMu.Unlock()
+		cancel()
+	}()
+
+	writer := &chunkResultWriter{chunkRows: chunkRows, emit: emit}
+	writer.rows.buffer = bufio.NewWriter(&writer.pending)
+	summary, queryErr := m.exportQuery(streamCtx, chatID, query, 0, writer)
+	if queryErr == nil {
+		if err := writer.emitChunk(true); err != nil {
+			queryErr = &dtos.QueryError{
+				Code:    "STREAM_FAILED",
+				Message: "failed to stream the results",
+				Details: err.Error(),
+			}
+		}
+	}
+	return StreamSummary{Rows: summary.rows, Chunks: writer.index, OnReadReplica: summary.onReplica}, queryErr
+}
+
+// chunkResultWriter encodes the rows as the JSON Lines export does & emits them once a chunk is full
+type chunkResultWriter struct {
+	rows      jsonlResultWriter // encodes the rows into pending, a line per row
+	pending   bytes.Buffer
+	columns   []string
+	count     int // rows in pending
+	chunkRows int
+	index     int // chunks emitted
+	emit      func(chunk ResultChunk) error
+}
+
+func (w *chunkResultWriter) writeHeader(columns []exportColumn, format exportFormat) error {
+	if err := w.rows.writeHeader(columns, format); err != nil {
+		return err
+	}
+	w.columns = make([]string, len(columns))
+	for i, column := range columns {
+		w.columns[i] = column.name
+	}
+	return nil
+}
+
+func (w *chunkResultWriter) writeRow(values []interface{}) error {
+	if err := w.rows.writeRow(values); err != nil {
+		return err
+	}
+	return w.rowWritten()
+}
+
+func (w *chunkResultWriter) writeDocument(document bson.D) error {
+	if err := w.rows.writeDocument(document); err != nil {
+		return err
+	}
+	return w.rowWritten()
+}
+
+func (w *chunkResultWriter) rowWritten() error {
+	w.count++
+	if w.count < w.chunkRows {
+		return nil
+	}
+	return w.emitChunk(false)
+}
+
+// flush does nothing, the chunks are emitted once full rather than every exportFlushRows rows
+func (w *chunkResultWriter) flush() error {
+	return nil
+}
+
+// emitChunk hands the pending rows to emit, a line holds a row as neither encoding writes a line break within a row
+func (w *chunkResultWriter) emitChunk(last bool) error {
+	if err := w.rows.buffer.Flush(); err != nil {
+		return err
+	}
+	rows := make([]json.RawMessage, 0, w.count)
+	for _, line := range bytes.Split(bytes.TrimSuffix(w.pending.Bytes(), []byte("\n")), []byte("\n")) {
+		if len(line) > 0 {
+			rows = append(rows, json.RawMessage(bytes.Clone(line)))
+		}
+	}
+	w.pending.Reset()
+	w.count = 0
+
+	chunk := ResultChunk{Index: w.index, Columns: w.columns, Rows: rows, Last: last}
+	w.index++
+	return w.emit(chunk)
+}
@@ -3,18 +3,18 @@ package dbmanager
 import (
 	"context"
 	"crypto/tls"
-	"crypto/x509"
+	"database/sql"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"neobase-ai/internal/apis/dtos"
-	"neobase-ai/internal/utils"
+	"net"
 	"os"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/ClickHouse/clickhouse-go/v2"
 	clickhousedriver "gorm.io/driver/clickhouse"
 	"gorm.io/gorm"
 )
@@ -27,10 +27,30 @@ func NewClickHouseDriver() DatabaseDriver {
 	return &ClickHouseDriver{}
 }
 
+// openClickHouseDB opens a ClickHouse pool for the DSN, dialing through the proxy of the connection if any
+func openClickHouseDB(config ConnectionConfig, dsn string) (*sql.DB, error) {
+	dial, err := getProxyDialFunc(config)
+	if err != nil {
+		return nil, err
+	}
+	if dial == nil {
+		return sql.Open("clickhouse", dsn)
+	}
+
+	options, err := clickhouse.ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	// Used by both the native & HTTP protocols
+	options.DialContext = func(ctx context.Context, addr string) (net.Conn, error) {
+		return dial(ctx, "tcp", addr)
+	}
+	return clickhouse.OpenDB(options), nil
+}
+
 // Connect establishes a connection to a ClickHouse database
 func (d *ClickHouseDriver) Connect(config ConnectionConfig) (*Connection, error) {
 	var dsn string
-	var tempFiles []string
 
 	// Base connection parameters
 	protocol := "tcp"
@@ -46,15 +66,12 @@ func (d *ClickHouseDriver) Connect(config ConnectionConfig) (*Connection, error)
 		if sslMode == "disable" {
 			tlsConfig = nil
 		} else {
-			// Fetch certificates from URLs
-			certPath, keyPath, rootCertPath, certTempFiles, err := utils.PrepareCertificatesFromURLs(*config.SSLCertURL, *config.SSLKeyURL, *config.SSLRootCertURL)
+			// Load certificates, uploaded ones are used over the URLs
+			certs, err := loadSSLCertificates(config)
 			if err != nil {
 				return nil, err
 			}
 
-			// Track temporary files for cleanup
-			tempFiles = certTempFiles
-
 			// Create TLS config
 			tlsConfig = &tls.Config{
 				ServerName: config.Host,
@@ -76,38 +93,9 @@ func (d *ClickHouseDriver) Connect(config ConnectionConfig) (*Connection, error)
 				}
 			}
 
-			// Add client certificates if provided
-			if certPath != "" && keyPath != "" {
-				cert, err := tls.LoadX509KeyPair(certPath, keyPath)
-				if err != nil {
-					// Clean up temporary files
-					for _, file := range tempFiles {
-						os.Remove(file)
-					}
-					return nil, fmt.Errorf("failed to load client certificates: %v", err)
-				}
-				tlsConfig.Certificates = []tls.Certificate{cert}
-			}
-
-			// Add CA certificate if provided
-			if rootCertPath != "" {
-				rootCertPool := x509.NewCertPool()
-				pem, err := ioutil.ReadFile(rootCertPath)
-				if err != nil {
-					// Clean up temporary files
-					for _, file := range tempFiles {
-						os.Remove(file)
-					}
-					return nil, fmt.Errorf("failed to read CA certificate: %v", err)
-				}
-				if ok := rootCertPool.AppendCertsFromPEM(pem); !ok {
-					// Clean up temporary files
-					for _, file := range tempFiles {
-						os.Remove(file)
-					}
-					return nil, fmt.Errorf("failed to append CA certificate")
-				}
-				tlsConfig.RootCAs = rootCertPool
+			// Add client & CA certificates if provided
+			if err := certs.applyTo(tlsConfig); err != nil {
+				return nil, err
 			}
 		}
 
@@ -140,31 +128,26 @@ func (d *ClickHouseDriver) Connect(config ConnectionConfig) (*Connection, error)
 		options.DSN = dsn
 	}
 
+	// Open the pool ourselves so the connections can be dialed through the proxy
+	db, err := openClickHouseDB(config, options.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ClickHouse: %v", err)
+	}
+	options.Conn = db
+
 	// Create GORM DB
 	gormDB, err := gorm.Open(clickhousedriver.New(*options), &gorm.Config{})
 	if err != nil {
-		// Clean up temporary files
-		for _, file := range tempFiles {
-			os.Remove(file)
-		}
 		return nil, fmt.Errorf("failed to connect to ClickHouse: %v", err)
 	}
 
 	// Test connection
 	sqlDB, err := gormDB.DB()
 	if err != nil {
-		// Clean up temporary files
-		for _, file := range tempFiles {
-			os.Remove(file)
-		}
 		return nil, fmt.Errorf("failed to get SQL DB: %v", err)
 	}
 
 	if err := sqlDB.Ping(); err != nil {
-		// Clean up temporary files
-		for _, file := range tempFiles {
-			os.Remove(file)
-		}
 		sqlDB.Close()
 		return nil, fmt.Errorf("failed to connect to database: %v", err)
 	}
@@ -182,7 +165,6 @@ func (d *ClickHouseDriver) Connect(config ConnectionConfig) (*Connection, error)
 		Config:      config,
 		Subscribers: make(map[string]bool),
 		SubLock:     sync.RWMutex{},
-		TempFiles:   tempFiles,
 	}
 
 	return conn, nil
@@ -302,13 +284,16 @@ func (d *ClickHouseDriver) ExecuteQuery(ctx context.Context, conn *Connection, q
 			return result
 		}
 
+		// The named parameters of the statement are bound to the values of the context
+		boundStmt, args := bindQueryParams(ctx, stmt, placeholderQuestionMark)
+
 		// Execute the statement based on query type
 		if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(stmt)), "SELECT") ||
 			strings.HasPrefix(strings.ToUpper(strings.TrimSpace(stmt)), "SHOW") ||
 			strings.HasPrefix(strings.ToUpper(strings.TrimSpace(stmt)), "DESCRIBE") {
 			// For SELECT, SHOW, DESCRIBE queries, return the results
-			var rows []map[string]interface{}
-			if err := conn.DB.WithContext(ctx).Raw(stmt).Scan(&rows).Error; err != nil {
+			rows, columns, err := queryResultRows(ctx, conn.DB, boundStmt, args...)
+			if err != nil {
 				result.Error = &dtos.QueryError{
 					Message: err.Error(),
 					Code:    "EXECUTION_ERROR",
@@ -352,9 +337,12 @@ func (d *ClickHouseDriver) ExecuteQuery(ctx context.Context, conn *Connection, q
 			result.Result = map[string]interface{}{
 				"results": processedRows,
 			}
+			result.Columns = columns
 		} else {
+			// A statement without rows ends the result, the columns of an earlier SELECT are dropped
+			result.Columns = nil
 			// For other queries (INSERT, CREATE, ALTER, etc.), execute and return affected rows
-			execResult := conn.DB.WithContext(ctx).Exec(stmt)
+			execResult := conn.DB.WithContext(ctx).Exec(boundStmt, args...)
 			if execResult.Error != nil {
 				result.Error = &dtos.QueryError{
 					Message: execResult.Error.Error(),
@@ -49,13 +49,16 @@ func (t *ClickHouseTransaction) ExecuteQuery(ctx context.Context, conn *Connecti
 			return result
 		}
 
+		// The named parameters of the statement are bound to the values of the context
+		boundStmt, args := bindQueryParams(ctx, stmt, placeholderQuestionMark)
+
 		// Execute the statement based on query type
 		if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(stmt)), "SELECT") ||
 			strings.HasPrefix(strings.ToUpper(strings.TrimSpace(stmt)), "SHOW") ||
 			strings.HasPrefix(strings.ToUpper(strings.TrimSpace(stmt)), "DESCRIBE") {
 			// For SELECT, SHOW, DESCRIBE queries, return the results
-			var rows []map[string]interface{}
-			if err := t.tx.WithContext(ctx).Raw(stmt).Scan(&rows).Error; err != nil {
+			rows, columns, err := queryResultRows(ctx, t.tx, boundStmt, args...)
+			if err != nil {
 				result.Error = &dtos.QueryError{
 					Message: err.Error(),
 					Code:    "EXECUTION_ERROR",
@@ -99,9 +102,12 @@ func (t *ClickHouseTransaction) ExecuteQuery(ctx context.Context, conn *Connecti
 			result.Result = map[string]interface{}{
 				"results": processedRows,
 			}
+			result.Columns = columns
 		} else {
+			// A statement without rows ends the result, the columns of an earlier SELECT are dropped
+			result.Columns = nil
 			// For other queries (INSERT, CREATE, ALTER, etc.), execute and return affected rows
-			execResult := t.tx.WithContext(ctx).Exec(stmt)
+			execResult := t.tx.WithContext(ctx).Exec(boundStmt, args...)
 			if execResult.Error != nil {
 				result.Error = &dtos.QueryError{
 					Message: execResult.Error.Error(),
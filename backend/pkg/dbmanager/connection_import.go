@@ -0,0 +1,400 @@
+package dbmanager
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"neobase-ai/internal/constants"
+	"sort"
+	"strings"
+)
+
+// Formats of the connection files that can be imported
+const (
+	ImportFormatDBeaver  = "dbeaver"  // data-sources.json of a DBeaver workspace
+	ImportFormatDataGrip = "datagrip" // dataSources.xml (optionally followed by dataSources.local.xml) of a DataGrip/IntelliJ project
+	ImportFormatPgpass   = "pgpass"   // .pgpass file, hostname:port:database:username:password per line
+	ImportFormatURIList  = "uri_list" // One connection URI or JDBC URL per line
+)
+
+// MaxImportedConnections caps the number of connections read from a single import file
+const MaxImportedConnections = 200
+
+// ImportedConnection is a connection read from an import file, Error is set when the entry can't be used
+type ImportedConnection struct {
+	Name   string
+	Config ConnectionConfig
+	Error  string
+}
+
+// importDatabaseTypes maps driver/provider names of the import formats to database types, checked in order
+var importDatabaseTypes = []struct {
+	match  string
+	dbType string
+}{
+	{"yugabyte", constants.DatabaseTypeYugabyteDB},
+	{"postgre", constants.DatabaseTypePostgreSQL},
+	{"mariadb", constants.DatabaseTypeMariaDB},
+	{"singlestore", constants.DatabaseTypeSingleStore},
+	{"memsql", constants.DatabaseTypeSingleStore},
+	{"mysql", constants.DatabaseTypeMySQL},
+	{"clickhouse", constants.DatabaseTypeClickhouse},
+	{"mongo", constants.DatabaseTypeMongoDB},
+	{"db2", constants.DatabaseTypeDB2},
+	{"databricks", constants.DatabaseTypeDatabricks},
+	{"redis", constants.DatabaseTypeRedis},
+	{"neo4j", constants.DatabaseTypeNeo4j},
+	{"bolt", constants.DatabaseTypeNeo4j},
+	{"cassandra", constants.DatabaseTypeCassandra},
+}
+
+// ParseConnectionImport reads the connections of an import file, entries that can't be used are returned with their error
+func ParseConnectionImport(format, content string) ([]ImportedConnection, error) {
+	if strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("import file is empty")
+	}
+
+	var connections []ImportedConnection
+	var err error
+	switch format {
+	case ImportFormatDBeaver:
+		connections, err = parseDBeaverDataSources(content)
+	case ImportFormatDataGrip:
+		connections, err = parseDataGripDataSources(content)
+	case ImportFormatPgpass:
+		connections, err = parsePgpass(content)
+	case ImportFormatURIList:
+		connections, err = parseURIList(content)
+	default:
+		return nil, fmt.Errorf("unsupported import format: %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(connections) == 0 {
+		return nil, fmt.Errorf("no connections found in the import file")
+	}
+	if len(connections) > MaxImportedConnections {
+		return nil, fmt.Errorf("import file has %d connections, at most %d can be imported at once", len(connections), MaxImportedConnections)
+	}
+
+	for i := range connections {
+		if connections[i].Name == "" {
+			connections[i].Name = importedConnectionName(connections[i].Config)
+		}
+	}
+	return connections, nil
+}
+
+// dbeaverDataSources is the part of DBeaver's data-sources.json describing the connections
+type dbeaverDataSources struct {
+	Connections map[string]struct {
+		Provider      string `json:"provider"`
+		Driver        string `json:"driver"`
+		Name          string `json:"name"`
+		Configuration struct {
+			Host     string      `json:"host"`
+			Port     interface{} `json:"port"` // String in recent versions, number in older ones
+			Database string      `json:"database"`
+			URL      string      `json:"url"`
+			User     string      `json:"user"`
+			Password string      `json:"password"` // Only present when credentials aren't stored in credentials-config.json
+			Handlers map[string]struct {
+				Enabled    bool                   `json:"enabled"`
+				Properties map[string]interface{} `json:"properties"`
+			} `json:"handlers"`
+		} `json:"configuration"`
+	} `json:"connections"`
+}
+
+func parseDBeaverDataSources(content string) ([]ImportedConnection, error) {
+	var dataSources dbeaverDataSources
+	if err := json.Unmarshal([]byte(content), &dataSources); err != nil {
+		return nil, fmt.Errorf("invalid DBeaver data-sources.json: %v", err)
+	}
+
+	// Connections are keyed by their id, sort them to keep the import order stable
+	ids := make([]string, 0, len(dataSources.Connections))
+	for id := range dataSources.Connections {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	connections := make([]ImportedConnection, 0, len(ids))
+	for _, id := range ids {
+		dataSource := dataSources.Connections[id]
+		cfg := dataSource.Configuration
+		imported := ImportedConnection{Name: dataSource.Name}
+
+		// Connections configured by URL only have the JDBC URL
+		if cfg.Host == "" && cfg.URL != "" {
+			config, err := ParseConnectionString(cfg.URL)
+			if err != nil {
+				imported.Error = err.Error()
+				connections = append(connections, imported)
+				continue
+			}
+			imported.Config = config
+		} else {
+			imported.Config = ConnectionConfig{
+				Type:     importDatabaseType(dataSource.Provider, dataSource.Driver),
+				Host:     cfg.Host,
+				Port:     importOptionalString(importPortString(cfg.Port)),
+				Database: cfg.Database,
+			}
+		}
+		if imported.Config.Type == "" {
+			imported.Error = fmt.Sprintf("unsupported DBeaver driver: %s", dataSource.Driver)
+		}
+		if cfg.User != "" {
+			imported.Config.Username = importOptionalString(cfg.User)
+		}
+		if cfg.Password != "" {
+			imported.Config.Password = importOptionalString(cfg.Password)
+		}
+
+		// SSL is configured by a network handler, e.g. postgre_ssl or mysql_ssl
+		for handlerID, handler := range cfg.Handlers {
+			if !handler.Enabled || !strings.Contains(strings.ToLower(handlerID), "ssl") {
+				continue
+			}
+			imported.Config.UseSSL = true
+			for key, value := range handler.Properties {
+				if normalized := strings.ToLower(strings.ReplaceAll(key, ".", "")); normalized == "sslmode" {
+					applySSLModeParam(&imported.Config, fmt.Sprint(value))
+				}
+			}
+		}
+		connections = append(connections, imported)
+	}
+	return connections, nil
+}
+
+// dataGripDataSource is a data-source element of dataSources.xml or dataSources.local.xml
+type dataGripDataSource struct {
+	Name      string `xml:"name,attr"`
+	UUID      string `xml:"uuid,attr"`
+	DriverRef string `xml:"driver-ref"`
+	JDBCURL   string `xml:"jdbc-url"`
+	UserName  string `xml:"user-name"`
+}
+
+// parseDataGripDataSources reads the data sources of dataSources.xml, the user names are kept in dataSources.local.xml
+// so both files can be pasted one after the other, their entries are merged by uuid
+func parseDataGripDataSources(content string) ([]ImportedConnection, error) {
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	var dataSources []*dataGripDataSource
+	byUUID := make(map[string]*dataGripDataSource)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid DataGrip dataSources.xml: %v", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "data-source" {
+			continue
+		}
+
+		var dataSource dataGripDataSource
+		if err := decoder.DecodeElement(&dataSource, &start); err != nil {
+			return nil, fmt.Errorf("invalid DataGrip dataSources.xml: %v", err)
+		}
+		if existing, found := byUUID[dataSource.UUID]; found && dataSource.UUID != "" {
+			if existing.Name == "" {
+				existing.Name = dataSource.Name
+			}
+			if existing.DriverRef == "" {
+				existing.DriverRef = dataSource.DriverRef
+			}
+			if existing.JDBCURL == "" {
+				existing.JDBCURL = dataSource.JDBCURL
+			}
+			if existing.UserName == "" {
+				existing.UserName = dataSource.UserName
+			}
+			continue
+		}
+		dataSources = append(dataSources, &dataSource)
+		if dataSource.UUID != "" {
+			byUUID[dataSource.UUID] = &dataSource
+		}
+	}
+
+	connections := make([]ImportedConnection, 0, len(dataSources))
+	for _, dataSource := range dataSources {
+		imported := ImportedConnection{Name: dataSource.Name}
+		if dataSource.JDBCURL == "" {
+			imported.Error = "data source has no JDBC URL, dataSources.local.xml must be imported along with dataSources.xml"
+			connections = append(connections, imported)
+			continue
+		}
+
+		config, err := ParseConnectionString(dataSource.JDBCURL)
+		if err != nil {
+			imported.Error = err.Error()
+			connections = append(connections, imported)
+			continue
+		}
+		// The driver tells apart databases sharing a JDBC scheme, e.g. MariaDB servers used through jdbc:mysql
+		if dbType := importDatabaseType(dataSource.DriverRef); dbType != "" {
+			config.Type = dbType
+		}
+		if dataSource.UserName != "" {
+			config.Username = importOptionalString(dataSource.UserName)
+		}
+		imported.Config = config
+		connections = append(connections, imported)
+	}
+	return connections, nil
+}
+
+// parsePgpass reads a .pgpass file, see https://www.postgresql.org/docs/current/libpq-pgpass.html
+func parsePgpass(content string) ([]ImportedConnection, error) {
+	var connections []ImportedConnection
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := splitPgpassLine(line)
+		imported := ImportedConnection{}
+		if len(fields) != 5 {
+			imported.Name = fmt.Sprintf("line %d", lineNumber)
+			imported.Error = fmt.Sprintf("line %d: expected hostname:port:database:username:password", lineNumber)
+			connections = append(connections, imported)
+			continue
+		}
+
+		host, port, database, username, password := fields[0], fields[1], fields[2], fields[3], fields[4]
+		imported.Config = ConnectionConfig{
+			Type:     constants.DatabaseTypePostgreSQL,
+			Host:     host,
+			Database: database,
+			Username: importOptionalString(username),
+			Password: importOptionalString(password),
+		}
+		// A wildcard port matches any port, the default one is used
+		if port != "*" {
+			imported.Config.Port = importOptionalString(port)
+		}
+		// Other wildcards can't be turned into a connection
+		for i, name := range []string{"hostname", "", "database", "username"} {
+			if name != "" && fields[i] == "*" {
+				imported.Error = fmt.Sprintf("line %d: wildcard %s can't be imported", lineNumber, name)
+				break
+			}
+		}
+		connections = append(connections, imported)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("invalid .pgpass file: %v", err)
+	}
+	return connections, nil
+}
+
+// splitPgpassLine splits a .pgpass line on colons, \: and \\ are escapes of a colon and a backslash
+func splitPgpassLine(line string) []string {
+	var fields []string
+	var field strings.Builder
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			field.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == ':':
+			fields = append(fields, field.String())
+			field.Reset()
+		default:
+			field.WriteRune(r)
+		}
+	}
+	return append(fields, field.String())
+}
+
+func parseURIList(content string) ([]ImportedConnection, error) {
+	var connections []ImportedConnection
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		config, err := ParseConnectionString(line)
+		if err != nil {
+			connections = append(connections, ImportedConnection{
+				Name:  fmt.Sprintf("line %d", lineNumber),
+				Error: fmt.Sprintf("line %d: %v", lineNumber, err),
+			})
+			continue
+		}
+		connections = append(connections, ImportedConnection{Config: config})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("invalid URI list: %v", err)
+	}
+	return connections, nil
+}
+
+func importDatabaseType(names ...string) string {
+	for _, name := range names {
+		name = strings.ToLower(name)
+		if name == "" {
+			continue
+		}
+		for _, candidate := range importDatabaseTypes {
+			if strings.Contains(name, candidate.match) {
+				return candidate.dbType
+			}
+		}
+	}
+	return ""
+}
+
+func importPortString(port interface{}) string {
+	switch value := port.(type) {
+	case nil:
+		return ""
+	case float64:
+		return fmt.Sprintf("%d", int(value))
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+func importOptionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// importedConnectionName describes a connection without a name, e.g. postgresql://app@db.internal:5432/orders
+func importedConnectionName(config ConnectionConfig) string {
+	host := config.Host
+	if UsesUnixSocket(config) {
+		host = *config.SocketPath
+	}
+	if config.Port != nil {
+		host += ":" + *config.Port
+	}
+	if config.Username != nil {
+		host = *config.Username + "@" + host
+	}
+	return fmt.Sprintf("%s://%s/%s", config.Type, host, config.Database)
+}
@@ -0,0 +1,181 @@
+package dbmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/constants"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Sources of a cost estimate
+const (
+	CostEstimateSourceExplain         = "explain"
+	CostEstimateSourceCollectionCount = "collection_count"
+)
+
+// planRowsRegex matches the row estimates of a PostgreSQL text plan, e.g. (cost=0.00..35.50 rows=2550 width=4)
+var planRowsRegex = regexp.MustCompile(`\brows=(\d+)`)
+
+// QueryCostEstimate is the cost of a query estimated without running it
+type QueryCostEstimate struct {
+	// Rows is the largest row estimate of the steps of the plan, the documents of the collection for MongoDB
+	Rows   int64  `json:"rows"`
+	Source string `json:"source"`
+}
+
+// EstimateQueryCost estimates the number of rows a query reads from its plan, ClickHouse estimates the rows of the parts read
+// & MongoDB the documents of the collection from its metadata. Only the databases EXPLAIN gives row estimates for are supported.
+func (m *Manager) EstimateQueryCost(ctx context.Context, chatID, query string) (*QueryCostEstimate, *dtos.QueryError) {
+	conn, driver, queryErr := m.groundingConnection(chatID)
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	switch conn.Config.Type {
+	case constants.DatabaseTypeMongoDB:
+		return estimateMongoDBCost(ctx, conn, query)
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB, constants.DatabaseTypeMySQL,
+		constants.DatabaseTypeMariaDB, constants.DatabaseTypeClickhouse:
+	default:
+		return nil, &dtos.QueryError{
+			Code:    "COST_ESTIMATE_NOT_SUPPORTED",
+			Message: "the cost of the query can't be estimated",
+			Details: fmt.Sprintf("The plans of %s have no row estimates", conn.Config.Type),
+		}
+	}
+
+	explained, ok := explainStatement(conn.Config.Type, query)
+	if !ok {
+		return nil, &dtos.QueryError{
+			Code:    "COST_ESTIMATE_NOT_SUPPORTED",
+			Message: "the cost of the query can't be estimated",
+			Details: "Only a single read or DML statement can be explained",
+		}
+	}
+	if conn.Config.Type == constants.DatabaseTypeClickhouse {
+		// The plain plan of ClickHouse has no row estimates
+		explained = "EXPLAIN ESTIMATE " + strings.TrimPrefix(explained, "EXPLAIN ")
+	}
+
+	plan, queryErr := m.runGroundingQuery(ctx, conn, driver, explained, IsReadOnlyQuery(conn.Config.Type, query))
+	if queryErr != nil {
+		return nil, queryErr
+	}
+	var decoded interface{}
+	decoder := json.NewDecoder(strings.NewReader(plan))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, &dtos.QueryError{
+			Code:    "INVALID_RESULT",
+			Message: "failed to decode the plan",
+			Details: err.Error(),
+		}
+	}
+
+	rows, found := maxPlanRows(decoded)
+	if !found {
+		return nil, &dtos.QueryError{
+			Code:    "COST_ESTIMATE_NOT_SUPPORTED",
+			Message: "the cost of the query can't be estimated",
+			Details: "The plan has no row estimates",
+		}
+	}
+	return &QueryCostEstimate{Rows: rows, Source: CostEstimateSourceExplain}, nil
+}
+
+// estimateMongoDBCost returns the documents of the collection a query targets, estimated from the collection metadata
+func estimateMongoDBCost(ctx context.Context, conn *Connection, query string) (*QueryCostEstimate, *dtos.QueryError) {
+	wrapper, ok := conn.MongoDBObj.(*MongoDBWrapper)
+	if !ok {
+		return nil, &dtos.QueryError{
+			Code:    "INVALID_CONNECTION",
+			Message: "invalid MongoDB connection",
+		}
+	}
+	collection, _, ok := splitMongoCollectionQuery(strings.TrimSpace(query))
+	if !ok || collection == "" {
+		return nil, &dtos.QueryError{
+			Code:    "COST_ESTIMATE_NOT_SUPPORTED",
+			Message: "the cost of the query can't be estimated",
+			Details: "Only the queries of a collection can be estimated",
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, groundingQueryTimeout)
+	defer cancel()
+	count, err := wrapper.Client.Database(wrapper.Database).Collection(collection).EstimatedDocumentCount(ctx)
+	if err != nil {
+		log.Printf("DBManager -> estimateMongoDBCost -> Failed to count the documents of %s: %v", collection, err)
+		return nil, &dtos.QueryError{
+			Code:    "COST_ESTIMATE_FAILED",
+			Message: "failed to estimate the cost of the query",
+			Details: err.Error(),
+		}
+	}
+	return &QueryCostEstimate{Rows: count, Source: CostEstimateSourceCollectionCount}, nil
+}
+
+// maxPlanRows returns the largest row estimate of a decoded plan: the rows columns of MySQL & ClickHouse, the Plan Rows of
+// a JSON plan & the rows= of the lines of a PostgreSQL text plan
+func maxPlanRows(value interface{}) (int64, bool) {
+	var rows int64
+	found := false
+	keep := func(estimate int64) {
+		if !found || estimate > rows {
+			rows = estimate
+		}
+		found = true
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if strings.EqualFold(key, "rows") || strings.EqualFold(key, "Plan Rows") {
+				if estimate, ok := planRowsValue(field); ok {
+					keep(estimate)
+					continue
+				}
+			}
+			if estimate, ok := maxPlanRows(field); ok {
+				keep(estimate)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if estimate, ok := maxPlanRows(item); ok {
+				keep(estimate)
+			}
+		}
+	case string:
+		for _, match := range planRowsRegex.FindAllStringSubmatch(v, -1) {
+			if estimate, err := strconv.ParseInt(match[1], 10, 64); err == nil {
+				keep(estimate)
+			}
+		}
+	}
+	return rows, found
+}
+
+// planRowsValue returns the row estimate of a field, drivers return the numbers as numbers or as text
+func planRowsValue(value interface{}) (int64, bool) {
+	var text string
+	switch v := value.(type) {
+	case json.Number:
+		text = v.String()
+	case string:
+		text = v
+	default:
+		return 0, false
+	}
+	estimate, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+	if err != nil || estimate < 0 || math.IsInf(estimate, 0) || math.IsNaN(estimate) {
+		return 0, false
+	}
+	return int64(math.Ceil(estimate)), true
+}
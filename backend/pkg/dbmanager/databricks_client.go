@@ -0,0 +1,461 @@
+package dbmanager
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The Databricks SQL Warehouse is reached through the SQL Statement Execution REST API, wrapped as a database/sql driver
+// so that it can be used through GORM like every other SQL database.
+// See https://docs.databricks.com/api/workspace/statementexecution
+
+const (
+	databricksStatementsPath = "/api/2.0/sql/statements"
+	databricksWaitTimeout    = "30s" // Max time the API holds the submit request before we start polling
+	databricksPollInterval   = 500 * time.Millisecond
+	databricksMaxPollBackoff = 5 * time.Second
+)
+
+// databricksConnector implements driver.Connector for a SQL Warehouse
+type databricksConnector struct {
+	baseURL     string // https://<workspace-host>[:port]
+	warehouseID string
+	token       string
+	catalog     string
+	schema      string
+	client      *http.Client
+}
+
+func (c *databricksConnector) Connect(_ context.Context) (driver.Conn, error) {
+	return &databricksConn{connector: c}, nil
+}
+
+func (c *databricksConnector) Driver() driver.Driver {
+	return databricksSQLDriver{}
+}
+
+// databricksSQLDriver only exists to satisfy driver.Connector, connections are always opened through the connector
+type databricksSQLDriver struct{}
+
+func (databricksSQLDriver) Open(_ string) (driver.Conn, error) {
+	return nil, fmt.Errorf("databricks: open the connection with sql.OpenDB and a connector")
+}
+
+// databricksConn is a stateless connection, every statement is an independent API call
+type databricksConn struct {
+	connector *databricksConnector
+}
+
+func (c *databricksConn) Prepare(query string) (driver.Stmt, error) {
+	return &databricksStmt{conn: c, query: query}, nil
+}
+
+func (c *databricksConn) Close() error {
+	return nil
+}
+
+// Begin returns a no-op transaction, statements on a SQL Warehouse are committed as soon as they complete
+func (c *databricksConn) Begin() (driver.Tx, error) {
+	return databricksTx{}, nil
+}
+
+func (c *databricksConn) BeginTx(_ context.Context, _ driver.TxOptions) (driver.Tx, error) {
+	return databricksTx{}, nil
+}
+
+func (c *databricksConn) Ping(ctx context.Context) error {
+	_, err := c.execute(ctx, "SELECT 1", nil)
+	return err
+}
+
+func (c *databricksConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	resp, err := c.execute(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return newDatabricksRows(ctx, c.connector, resp), nil
+}
+
+func (c *databricksConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	resp, err := c.execute(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	// DML statements on Delta tables return a single row with num_affected_rows
+	var affected int64
+	if resp.Manifest != nil && resp.Result != nil && len(resp.Result.DataArray) > 0 {
+		for i, column := range resp.Manifest.Schema.Columns {
+			if column.Name == "num_affected_rows" && i < len(resp.Result.DataArray[0]) && resp.Result.DataArray[0][i] != nil {
+				affected, _ = strconv.ParseInt(*resp.Result.DataArray[0][i], 10, 64)
+				break
+			}
+		}
+	}
+	return driver.RowsAffected(affected), nil
+}
+
+type databricksStmt struct {
+	conn  *databricksConn
+	query string
+}
+
+func (s *databricksStmt) Close() error {
+	return nil
+}
+
+// NumInput returns -1 as placeholders are only counted when the statement is submitted
+func (s *databricksStmt) NumInput() int {
+	return -1
+}
+
+func (s *databricksStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.conn.ExecContext(context.Background(), s.query, valuesToNamedValues(args))
+}
+
+func (s *databricksStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.conn.QueryContext(context.Background(), s.query, valuesToNamedValues(args))
+}
+
+type databricksTx struct{}
+
+func (databricksTx) Commit() error   { return nil }
+func (databricksTx) Rollback() error { return nil }
+
+// Statement Execution API payloads
+
+type databricksParameter struct {
+	Name  string  `json:"name"`
+	Value *string `json:"value,omitempty"`
+	Type  string  `json:"type,omitempty"`
+}
+
+type databricksStatementRequest struct {
+	Statement     string                `json:"statement"`
+	WarehouseID   string                `json:"warehouse_id"`
+	Catalog       string                `json:"catalog,omitempty"`
+	Schema        string                `json:"schema,omitempty"`
+	Parameters    []databricksParameter `json:"parameters,omitempty"`
+	WaitTimeout   string                `json:"wait_timeout"`
+	OnWaitTimeout string                `json:"on_wait_timeout"`
+	Disposition   string                `json:"disposition"`
+	Format        string                `json:"format"`
+}
+
+type databricksStatementResponse struct {
+	StatementID string `json:"statement_id"`
+	Status      struct {
+		State string `json:"state"` // PENDING, RUNNING, SUCCEEDED, FAILED, CANCELED, CLOSED
+		Error *struct {
+			ErrorCode string `json:"error_code"`
+			Message   string `json:"message"`
+		} `json:"error,omitempty"`
+	} `json:"status"`
+	Manifest *struct {
+		Schema struct {
+			Columns []databricksColumn `json:"columns"`
+		} `json:"schema"`
+		TotalRowCount int64 `json:"total_row_count"`
+		Truncated     bool  `json:"truncated"`
+	} `json:"manifest,omitempty"`
+	Result *databricksResultChunk `json:"result,omitempty"`
+}
+
+type databricksColumn struct {
+	Name     string `json:"name"`
+	TypeName string `json:"type_name"`
+	TypeText string `json:"type_text"`
+	Position int    `json:"position"`
+}
+
+type databricksResultChunk struct {
+	ChunkIndex            int         `json:"chunk_index"`
+	RowCount              int64       `json:"row_count"`
+	DataArray             [][]*string `json:"data_array"`
+	NextChunkInternalLink string      `json:"next_chunk_internal_link,omitempty"`
+}
+
+type databricksAPIError struct {
+	ErrorCode string `json:"error_code"`
+	Message   string `json:"message"`
+}
+
+// execute submits a statement and waits for it to reach a terminal state
+func (c *databricksConn) execute(ctx context.Context, query string, args []driver.NamedValue) (*databricksStatementResponse, error) {
+	statement, params, err := bindDatabricksParameters(query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	reqBody := databricksStatementRequest{
+		Statement:     statement,
+		WarehouseID:   c.connector.warehouseID,
+		Catalog:       c.connector.catalog,
+		Schema:        c.connector.schema,
+		Parameters:    params,
+		WaitTimeout:   databricksWaitTimeout,
+		OnWaitTimeout: "CONTINUE",
+		Disposition:   "INLINE",
+		Format:        "JSON_ARRAY",
+	}
+
+	var resp databricksStatementResponse
+	if err := c.connector.do(ctx, http.MethodPost, databricksStatementsPath, reqBody, &resp); err != nil {
+		return nil, err
+	}
+
+	// Poll until the statement completes, cancelling it on the warehouse if the caller gives up
+	backoff := databricksPollInterval
+	for resp.Status.State == "PENDING" || resp.Status.State == "RUNNING" {
+		select {
+		case <-ctx.Done():
+			c.connector.cancelStatement(resp.StatementID)
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		if err := c.connector.do(ctx, http.MethodGet, databricksStatementsPath+"/"+resp.StatementID, nil, &resp); err != nil {
+			if ctx.Err() != nil {
+				c.connector.cancelStatement(resp.StatementID)
+			}
+			return nil, err
+		}
+
+		backoff *= 2
+		if backoff > databricksMaxPollBackoff {
+			backoff = databricksMaxPollBackoff
+		}
+	}
+
+	switch resp.Status.State {
+	case "SUCCEEDED":
+		return &resp, nil
+	case "FAILED":
+		if resp.Status.Error != nil {
+			return nil, fmt.Errorf("databricks: %s: %s", resp.Status.Error.ErrorCode, resp.Status.Error.Message)
+		}
+		return nil, fmt.Errorf("databricks: statement failed")
+	default:
+		return nil, fmt.Errorf("databricks: statement %s", strings.ToLower(resp.Status.State))
+	}
+}
+
+// cancelStatement cancels a running statement, best effort as the caller already gave up on it
+func (c *databricksConnector) cancelStatement(statementID string) {
+	if statementID == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := c.do(ctx, http.MethodPost, databricksStatementsPath+"/"+statementID+"/cancel", nil, nil); err != nil {
+		log.Printf("DatabricksDriver -> cancelStatement -> Failed to cancel statement %s: %v", statementID, err)
+	}
+}
+
+// do sends an authenticated request to the workspace & decodes the JSON response into out
+func (c *databricksConnector) do(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("databricks: failed to encode request: %v", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("databricks: failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("databricks: request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("databricks: failed to read response: %v", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr databricksAPIError
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("databricks: %s: %s", apiErr.ErrorCode, apiErr.Message)
+		}
+		return fmt.Errorf("databricks: unexpected status %s", resp.Status)
+	}
+
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("databricks: failed to decode response: %v", err)
+	}
+	return nil
+}
+
+// databricksRows iterates over the inline result chunks, fetching the next chunk when the current one is consumed
+type databricksRows struct {
+	ctx       context.Context
+	connector *databricksConnector
+	columns   []databricksColumn
+	chunk     *databricksResultChunk
+	rowIndex  int
+}
+
+func newDatabricksRows(ctx context.Context, connector *databricksConnector, resp *databricksStatementResponse) *databricksRows {
+	rows := &databricksRows{
+		ctx:       ctx,
+		connector: connector,
+		chunk:     resp.Result,
+	}
+	if resp.Manifest != nil {
+		rows.columns = resp.Manifest.Schema.Columns
+	}
+	return rows
+}
+
+func (r *databricksRows) Columns() []string {
+	names := make([]string, len(r.columns))
+	for i, column := range r.columns {
+		names[i] = column.Name
+	}
+	return names
+}
+
+func (r *databricksRows) ColumnTypeDatabaseTypeName(index int) string {
+	return r.columns[index].TypeName
+}
+
+func (r *databricksRows) Close() error {
+	r.chunk = nil
+	return nil
+}
+
+func (r *databricksRows) Next(dest []driver.Value) error {
+	for r.chunk != nil && r.rowIndex >= len(r.chunk.DataArray) {
+		if r.chunk.NextChunkInternalLink == "" {
+			return io.EOF
+		}
+		var next databricksResultChunk
+		if err := r.connector.do(r.ctx, http.MethodGet, r.chunk.NextChunkInternalLink, nil, &next); err != nil {
+			return err
+		}
+		r.chunk = &next
+		r.rowIndex = 0
+	}
+	if r.chunk == nil {
+		return io.EOF
+	}
+
+	row := r.chunk.DataArray[r.rowIndex]
+	r.rowIndex++
+	for i := range dest {
+		if i >= len(row) || row[i] == nil {
+			dest[i] = nil
+			continue
+		}
+		dest[i] = convertDatabricksValue(*row[i], r.columns[i].TypeName)
+	}
+	return nil
+}
+
+// convertDatabricksValue converts the JSON_ARRAY string representation into a Go value, DECIMAL stays a string to keep its precision
+func convertDatabricksValue(value, typeName string) driver.Value {
+	switch strings.ToUpper(typeName) {
+	case "BYTE", "SHORT", "INT", "LONG", "TINYINT", "SMALLINT", "BIGINT", "INTEGER":
+		if parsed, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return parsed
+		}
+	case "FLOAT", "DOUBLE":
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return parsed
+		}
+	case "BOOLEAN":
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	}
+	return value
+}
+
+// bindDatabricksParameters rewrites "?" placeholders into the named markers supported by the API (:p1, :p2...)
+func bindDatabricksParameters(query string, args []driver.NamedValue) (string, []databricksParameter, error) {
+	if len(args) == 0 {
+		return query, nil, nil
+	}
+
+	var sb strings.Builder
+	var quote rune
+	index := 0
+	for _, char := range query {
+		switch {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '\'' || char == '"' || char == '`':
+			quote = char
+		case char == '?':
+			index++
+			sb.WriteString(fmt.Sprintf(":p%d", index))
+			continue
+		}
+		sb.WriteRune(char)
+	}
+
+	if index != len(args) {
+		return "", nil, fmt.Errorf("databricks: expected %d arguments, got %d", index, len(args))
+	}
+
+	params := make([]databricksParameter, len(args))
+	for i, arg := range args {
+		param := databricksParameter{Name: fmt.Sprintf("p%d", i+1)}
+		switch v := arg.Value.(type) {
+		case nil:
+			// An omitted value binds NULL
+		case int64:
+			param.Value, param.Type = databricksStringPtr(strconv.FormatInt(v, 10)), "BIGINT"
+		case float64:
+			param.Value, param.Type = databricksStringPtr(strconv.FormatFloat(v, 'f', -1, 64)), "DOUBLE"
+		case bool:
+			param.Value, param.Type = databricksStringPtr(strconv.FormatBool(v)), "BOOLEAN"
+		case time.Time:
+			param.Value, param.Type = databricksStringPtr(v.UTC().Format("2006-01-02 15:04:05.999999")), "TIMESTAMP"
+		case []byte:
+			param.Value, param.Type = databricksStringPtr(string(v)), "STRING"
+		default:
+			param.Value, param.Type = databricksStringPtr(fmt.Sprintf("%v", v)), "STRING"
+		}
+		params[i] = param
+	}
+	return sb.String(), params, nil
+}
+
+func valuesToNamedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return named
+}
+
+func databricksStringPtr(value string) *string {
+	return &value
+}
@@ -0,0 +1,261 @@
+package dbmanager
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// DatabricksDriver implements the DatabaseDriver interface for Databricks SQL Warehouses.
+// The database is "<catalog>" or "<catalog>.<schema>", the schema defaults to "default".
+type DatabricksDriver struct{}
+
+// NewDatabricksDriver creates a new Databricks driver
+func NewDatabricksDriver() DatabaseDriver {
+	return &DatabricksDriver{}
+}
+
+// Connect establishes a connection to a Databricks SQL Warehouse
+func (d *DatabricksDriver) Connect(config ConnectionConfig) (*Connection, error) {
+	connector, err := newDatabricksConnector(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Open connection
+	db := sql.OpenDB(connector)
+
+	// Test connection, this also wakes up a stopped warehouse so it can take a while
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// Configure connection pool, connections are stateless HTTP calls
+	db.SetMaxOpenConns(10)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(time.Hour)
+
+	// Create GORM DB, there is no GORM dialect for Databricks but only raw SQL is executed & "?" placeholders are bound by the connector
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create GORM connection: %v", err)
+	}
+
+	// Create connection object
+	conn := &Connection{
+		DB:          gormDB,
+		LastUsed:    time.Now(),
+		Status:      StatusConnected,
+		Config:      config,
+		Subscribers: make(map[string]bool),
+		SubLock:     sync.RWMutex{},
+	}
+
+	// Detect the server version, used for logging & dialect hints
+	var version string
+	if err := gormDB.Raw("SELECT current_version().dbsql_version").Scan(&version).Error; err != nil {
+		log.Printf("DatabricksDriver -> Connect -> Failed to detect server version: %v", err)
+	} else {
+		conn.ServerVersion = strings.TrimSpace(version)
+		log.Printf("DatabricksDriver -> Connect -> Connected to Databricks SQL version %s", conn.ServerVersion)
+	}
+
+	return conn, nil
+}
+
+// Disconnect closes a Databricks connection
+func (d *DatabricksDriver) Disconnect(conn *Connection) error {
+	// Get the underlying SQL DB
+	sqlDB, err := conn.DB.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get SQL DB: %v", err)
+	}
+
+	// Close the connection
+	if err := sqlDB.Close(); err != nil {
+		return fmt.Errorf("failed to close connection: %v", err)
+	}
+
+	return nil
+}
+
+// Ping checks if the Databricks connection is alive
+func (d *DatabricksDriver) Ping(conn *Connection) error {
+	if conn == nil || conn.DB == nil {
+		return fmt.Errorf("no active connection to ping")
+	}
+
+	sqlDB, err := conn.DB.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %v", err)
+	}
+
+	return sqlDB.Ping()
+}
+
+// IsAlive checks if the Databricks connection is still valid
+func (d *DatabricksDriver) IsAlive(conn *Connection) bool {
+	if conn == nil || conn.DB == nil {
+		return false
+	}
+
+	sqlDB, err := conn.DB.DB()
+	if err != nil {
+		return false
+	}
+
+	return sqlDB.Ping() == nil
+}
+
+// ExecuteQuery executes a SQL query on the Databricks SQL Warehouse
+func (d *DatabricksDriver) ExecuteQuery(ctx context.Context, conn *Connection, query string, queryType string, findCount bool) *QueryExecutionResult {
+	if conn == nil || conn.DB == nil {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Message: "No active connection",
+				Code:    "CONNECTION_ERROR",
+			},
+		}
+	}
+
+	return executeRawSQLStatements(ctx, conn.DB, query, isDatabricksResultStatement)
+}
+
+// BeginTx starts a new transaction, Databricks commits every statement on completion so the transaction only groups the execution
+func (d *DatabricksDriver) BeginTx(ctx context.Context, conn *Connection) Transaction {
+	if conn == nil || conn.DB == nil {
+		log.Printf("DatabricksDriver.BeginTx: Connection or DB is nil")
+		return nil
+	}
+
+	// Start a new transaction
+	tx := conn.DB.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		log.Printf("Failed to begin transaction: %v", tx.Error)
+		return nil
+	}
+
+	return &DatabricksTransaction{
+		tx:   tx,
+		conn: conn,
+	}
+}
+
+// GetSchema retrieves the database schema
+func (d *DatabricksDriver) GetSchema(ctx context.Context, db DBExecutor, selectedTables []string) (*SchemaInfo, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("DatabricksDriver -> GetSchema -> Context cancelled: %v", err)
+		return nil, err
+	}
+
+	fetcher := NewDatabricksSchemaFetcher(db)
+	return fetcher.GetSchema(ctx, db, selectedTables)
+}
+
+// GetTableChecksum calculates a checksum for a table
+func (d *DatabricksDriver) GetTableChecksum(ctx context.Context, db DBExecutor, table string) (string, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("DatabricksDriver -> GetTableChecksum -> Context cancelled: %v", err)
+		return "", err
+	}
+
+	fetcher := NewDatabricksSchemaFetcher(db)
+	return fetcher.GetTableChecksum(ctx, db, table)
+}
+
+// FetchExampleRecords fetches example records from a table
+func (d *DatabricksDriver) FetchExampleRecords(ctx context.Context, db DBExecutor, table string, limit int) ([]map[string]interface{}, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("DatabricksDriver -> FetchExampleRecords -> Context cancelled: %v", err)
+		return nil, err
+	}
+
+	fetcher := NewDatabricksSchemaFetcher(db)
+	return fetcher.FetchExampleRecords(ctx, db, table, limit)
+}
+
+// newDatabricksConnector validates the connection config & builds the Statement Execution API connector
+func newDatabricksConnector(config ConnectionConfig) (*databricksConnector, error) {
+	if config.HTTPPath == nil || strings.TrimSpace(*config.HTTPPath) == "" {
+		return nil, fmt.Errorf("HTTP path of the SQL warehouse is required (e.g. /sql/1.0/warehouses/<warehouse-id>)")
+	}
+
+	// The warehouse ID is the last segment of the HTTP path, /sql/1.0/warehouses/<id> or the legacy /sql/1.0/endpoints/<id>
+	httpPath := strings.TrimRight(strings.TrimSpace(*config.HTTPPath), "/")
+	warehouseID := httpPath[strings.LastIndex(httpPath, "/")+1:]
+	if warehouseID == "" || (!strings.Contains(httpPath, "/warehouses/") && !strings.Contains(httpPath, "/endpoints/")) {
+		return nil, fmt.Errorf("invalid HTTP path %q, expected /sql/1.0/warehouses/<warehouse-id>", httpPath)
+	}
+
+	// Personal access token, the password is accepted as well since Databricks tools use "token" as username & the PAT as password
+	token := ""
+	if config.AccessToken != nil && *config.AccessToken != "" {
+		token = *config.AccessToken
+	} else if config.Password != nil {
+		token = *config.Password
+	}
+	if token == "" {
+		return nil, fmt.Errorf("access token is required for Databricks connections")
+	}
+
+	// Hosts are often copied with the scheme from the workspace URL
+	host := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(config.Host, "https://"), "http://"), "/")
+	baseURL := "https://" + host
+	if config.Port != nil && *config.Port != "" && *config.Port != "443" {
+		baseURL += ":" + *config.Port
+	}
+
+	catalog, schema := splitDatabricksDatabase(config.Database)
+
+	httpClient, err := proxyHTTPClient(config, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &databricksConnector{
+		baseURL:     baseURL,
+		warehouseID: warehouseID,
+		token:       token,
+		catalog:     catalog,
+		schema:      schema,
+		client:      httpClient,
+	}, nil
+}
+
+// splitDatabricksDatabase splits "<catalog>.<schema>" into its parts, the schema defaults to "default"
+func splitDatabricksDatabase(database string) (string, string) {
+	database = strings.ReplaceAll(strings.TrimSpace(database), "`", "")
+	if catalog, schema, found := strings.Cut(database, "."); found && schema != "" {
+		return catalog, schema
+	}
+	return database, "default"
+}
+
+// isDatabricksResultStatement reports whether a statement returns a result set
+func isDatabricksResultStatement(stmt string) bool {
+	upper := strings.ToUpper(strings.TrimSpace(stmt))
+	for _, prefix := range []string{"SELECT", "WITH", "VALUES", "SHOW", "DESCRIBE", "DESC", "EXPLAIN", "FROM", "TABLE"} {
+		if strings.HasPrefix(upper, prefix) {
+			return true
+		}
+	}
+	return false
+}
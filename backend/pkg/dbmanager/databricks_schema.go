@@ -0,0 +1,562 @@
+package dbmanager
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DatabricksSchemaFetcher implements schema fetching for Databricks using the Unity Catalog information_schema.
+// Objects are read from the catalog & schema of the connection, see splitDatabricksDatabase.
+type DatabricksSchemaFetcher struct {
+	db DBExecutor
+}
+
+// NewDatabricksSchemaFetcher creates a new Databricks schema fetcher
+func NewDatabricksSchemaFetcher(db DBExecutor) SchemaFetcher {
+	return &DatabricksSchemaFetcher{db: db}
+}
+
+// databricksTableInfo holds the Unity Catalog metadata of a table
+type databricksTableInfo struct {
+	Name       string
+	TableType  string // MANAGED, EXTERNAL, STREAMING_TABLE...
+	DataFormat string // DELTA, PARQUET, CSV...
+	Owner      string
+	Comment    string
+}
+
+// GetSchema retrieves the schema for the selected tables
+func (f *DatabricksSchemaFetcher) GetSchema(ctx context.Context, db DBExecutor, selectedTables []string) (*SchemaInfo, error) {
+	log.Printf("DatabricksSchemaFetcher -> GetSchema -> Starting schema fetch with selected tables: %v", selectedTables)
+
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("DatabricksSchemaFetcher -> GetSchema -> Context cancelled: %v", err)
+		return nil, err
+	}
+
+	schema, err := f.FetchSchema(ctx)
+	if err != nil {
+		log.Printf("DatabricksSchemaFetcher -> GetSchema -> Error fetching schema: %v", err)
+		return nil, err
+	}
+
+	filteredSchema := f.filterSchemaForSelectedTables(schema, selectedTables)
+	filteredSchema.DialectHints = f.buildDialectHints(ctx)
+	log.Printf("DatabricksSchemaFetcher -> GetSchema -> Filtered schema to %d tables", len(filteredSchema.Tables))
+
+	return filteredSchema, nil
+}
+
+// FetchSchema retrieves the full schema of the current catalog & schema
+func (f *DatabricksSchemaFetcher) FetchSchema(ctx context.Context) (*SchemaInfo, error) {
+	log.Printf("DatabricksSchemaFetcher -> FetchSchema -> Starting full schema fetch")
+
+	schema := &SchemaInfo{
+		Tables:    make(map[string]TableSchema),
+		Views:     make(map[string]ViewSchema),
+		UpdatedAt: time.Now(),
+	}
+
+	tables, err := f.fetchTables(ctx)
+	if err != nil {
+		log.Printf("DatabricksSchemaFetcher -> FetchSchema -> Error fetching tables: %v", err)
+		return nil, err
+	}
+
+	log.Printf("DatabricksSchemaFetcher -> FetchSchema -> Processing %d tables", len(tables))
+
+	for _, tableInfo := range tables {
+		// Check for context cancellation
+		if err := ctx.Err(); err != nil {
+			log.Printf("DatabricksSchemaFetcher -> FetchSchema -> Context cancelled: %v", err)
+			return nil, err
+		}
+
+		tableSchema, err := f.fetchTableSchema(ctx, tableInfo)
+		if err != nil {
+			return nil, err
+		}
+
+		// Calculate table schema checksum
+		tableData, _ := json.Marshal(tableSchema)
+		tableSchema.Checksum = fmt.Sprintf("%x", md5.Sum(tableData))
+
+		schema.Tables[tableInfo.Name] = tableSchema
+	}
+
+	views, err := f.fetchViews(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch views: %v", err)
+	}
+	schema.Views = views
+
+	// Calculate overall schema checksum
+	schemaData, _ := json.Marshal(schema.Tables)
+	schema.Checksum = fmt.Sprintf("%x", md5.Sum(schemaData))
+
+	log.Printf("DatabricksSchemaFetcher -> FetchSchema -> Successfully completed schema fetch with %d tables and %d views",
+		len(schema.Tables), len(schema.Views))
+
+	return schema, nil
+}
+
+// fetchTableSchema retrieves the columns & constraints of a table, Databricks has no indexes
+func (f *DatabricksSchemaFetcher) fetchTableSchema(ctx context.Context, tableInfo databricksTableInfo) (TableSchema, error) {
+	table := tableInfo.Name
+	tableSchema := TableSchema{
+		Name:        table,
+		Columns:     make(map[string]ColumnInfo),
+		Indexes:     make(map[string]IndexInfo),
+		ForeignKeys: make(map[string]ForeignKey),
+		Constraints: make(map[string]ConstraintInfo),
+	}
+
+	columns, partitionColumns, err := f.fetchColumns(ctx, table)
+	if err != nil {
+		log.Printf("DatabricksSchemaFetcher -> fetchTableSchema -> Error fetching columns for table %s: %v", table, err)
+		return tableSchema, fmt.Errorf("failed to fetch columns for table %s: %v", table, err)
+	}
+	tableSchema.Columns = columns
+	tableSchema.Comment = describeDatabricksTable(tableInfo, partitionColumns)
+
+	tableSchema.ForeignKeys = f.fetchForeignKeys(ctx, table)
+	tableSchema.Constraints = f.fetchConstraints(ctx, table)
+	tableSchema.RowCount = f.getTableRowCount(ctx, table)
+
+	log.Printf("DatabricksSchemaFetcher -> fetchTableSchema -> Table %s: %d columns, %d foreign keys, %d rows",
+		table, len(tableSchema.Columns), len(tableSchema.ForeignKeys), tableSchema.RowCount)
+
+	return tableSchema, nil
+}
+
+// fetchTables retrieves all tables in the current schema, views are fetched separately
+func (f *DatabricksSchemaFetcher) fetchTables(_ context.Context) ([]databricksTableInfo, error) {
+	var rows []map[string]interface{}
+	query := `
+        SELECT table_name, table_type, data_source_format, table_owner, comment
+        FROM information_schema.tables
+        WHERE table_catalog = current_catalog()
+        AND table_schema = current_schema()
+        AND table_type NOT IN ('VIEW', 'MATERIALIZED_VIEW')
+        ORDER BY table_name
+    `
+	if err := f.db.QueryRows(query, &rows); err != nil {
+		return nil, fmt.Errorf("failed to fetch tables: %v", err)
+	}
+
+	tables := make([]databricksTableInfo, 0, len(rows))
+	for _, row := range rows {
+		tables = append(tables, databricksTableInfo{
+			Name:       databricksString(row, "table_name"),
+			TableType:  databricksString(row, "table_type"),
+			DataFormat: databricksString(row, "data_source_format"),
+			Owner:      databricksString(row, "table_owner"),
+			Comment:    databricksString(row, "comment"),
+		})
+	}
+	log.Printf("DatabricksSchemaFetcher -> fetchTables -> Found %d tables", len(tables))
+	return tables, nil
+}
+
+// fetchColumns retrieves all columns for a specific table along with its partition columns in partition order
+func (f *DatabricksSchemaFetcher) fetchColumns(_ context.Context, table string) (map[string]ColumnInfo, []string, error) {
+	columns := make(map[string]ColumnInfo)
+
+	var rows []map[string]interface{}
+	query := `
+        SELECT column_name, full_data_type, is_nullable, column_default, comment, partition_index,
+            is_identity, identity_generation, identity_start, identity_increment,
+            is_generated, generation_expression
+        FROM information_schema.columns
+        WHERE table_catalog = current_catalog()
+        AND table_schema = current_schema()
+        AND table_name = ?
+        ORDER BY ordinal_position
+    `
+	if err := f.db.QueryRows(query, &rows, table); err != nil {
+		return nil, nil, err
+	}
+
+	partitions := make(map[int64]string)
+	for _, row := range rows {
+		name := databricksString(row, "column_name")
+		column := ColumnInfo{
+			Name:         name,
+			Type:         databricksString(row, "full_data_type"),
+			IsNullable:   databricksString(row, "is_nullable") == "YES",
+			DefaultValue: databricksString(row, "column_default"),
+			Comment:      databricksString(row, "comment"),
+		}
+
+		// Identity columns, GENERATED ALWAYS ones reject explicit values on INSERT
+		if databricksString(row, "is_identity") == "YES" {
+			mode := databricksString(row, "identity_generation")
+			column.DefaultValue = fmt.Sprintf("GENERATED %s AS IDENTITY (START WITH %s INCREMENT BY %s)",
+				mode, databricksString(row, "identity_start"), databricksString(row, "identity_increment"))
+			column.Comment = appendDatabricksTag(column.Comment, "[Identity: GENERATED "+mode+"]")
+		} else if databricksString(row, "is_generated") == "ALWAYS" {
+			column.DefaultValue = databricksString(row, "generation_expression")
+			column.Comment = appendDatabricksTag(column.Comment, "[Generated column]")
+		}
+
+		if partitionIndex := databricksString(row, "partition_index"); partitionIndex != "" {
+			partitions[databricksInt(row, "partition_index")] = name
+			column.Comment = appendDatabricksTag(column.Comment, "[Partition column]")
+		}
+
+		columns[name] = column
+	}
+
+	partitionIndexes := make([]int64, 0, len(partitions))
+	for index := range partitions {
+		partitionIndexes = append(partitionIndexes, index)
+	}
+	sort.Slice(partitionIndexes, func(i, j int) bool { return partitionIndexes[i] < partitionIndexes[j] })
+	partitionColumns := make([]string, 0, len(partitionIndexes))
+	for _, index := range partitionIndexes {
+		partitionColumns = append(partitionColumns, partitions[index])
+	}
+
+	return columns, partitionColumns, nil
+}
+
+// fetchForeignKeys retrieves the informational foreign keys of a table, Unity Catalog doesn't enforce them
+func (f *DatabricksSchemaFetcher) fetchForeignKeys(_ context.Context, table string) map[string]ForeignKey {
+	fkeys := make(map[string]ForeignKey)
+
+	var rows []map[string]interface{}
+	query := `
+        SELECT k.constraint_name, k.column_name, pk.table_name AS ref_table, pk.column_name AS ref_column,
+            r.update_rule, r.delete_rule
+        FROM information_schema.key_column_usage k
+        JOIN information_schema.referential_constraints r
+            ON r.constraint_catalog = k.constraint_catalog
+            AND r.constraint_schema = k.constraint_schema
+            AND r.constraint_name = k.constraint_name
+        JOIN information_schema.key_column_usage pk
+            ON pk.constraint_catalog = r.unique_constraint_catalog
+            AND pk.constraint_schema = r.unique_constraint_schema
+            AND pk.constraint_name = r.unique_constraint_name
+            AND pk.ordinal_position = k.position_in_unique_constraint
+        WHERE k.table_catalog = current_catalog()
+        AND k.table_schema = current_schema()
+        AND k.table_name = ?
+        ORDER BY k.constraint_name, k.ordinal_position
+    `
+	if err := f.db.QueryRows(query, &rows, table); err != nil {
+		// Return empty foreign keys rather than failing
+		log.Printf("DatabricksSchemaFetcher -> fetchForeignKeys -> Error for table %s: %v", table, err)
+		return fkeys
+	}
+
+	for _, row := range rows {
+		name := databricksString(row, "constraint_name")
+		fkeys[name] = ForeignKey{
+			Name:       name,
+			ColumnName: databricksString(row, "column_name"),
+			RefTable:   databricksString(row, "ref_table"),
+			RefColumn:  databricksString(row, "ref_column"),
+			OnDelete:   databricksString(row, "delete_rule"),
+			OnUpdate:   databricksString(row, "update_rule"),
+		}
+	}
+	return fkeys
+}
+
+// fetchConstraints retrieves primary key & check constraints for a specific table
+func (f *DatabricksSchemaFetcher) fetchConstraints(_ context.Context, table string) map[string]ConstraintInfo {
+	constraints := make(map[string]ConstraintInfo)
+
+	var rows []map[string]interface{}
+	query := `
+        SELECT tc.constraint_name, tc.constraint_type, k.column_name
+        FROM information_schema.table_constraints tc
+        JOIN information_schema.key_column_usage k
+            ON k.constraint_catalog = tc.constraint_catalog
+            AND k.constraint_schema = tc.constraint_schema
+            AND k.constraint_name = tc.constraint_name
+        WHERE tc.table_catalog = current_catalog()
+        AND tc.table_schema = current_schema()
+        AND tc.table_name = ?
+        AND tc.constraint_type = 'PRIMARY KEY'
+        ORDER BY tc.constraint_name, k.ordinal_position
+    `
+	if err := f.db.QueryRows(query, &rows, table); err != nil {
+		// Continue without key constraints rather than failing
+		log.Printf("DatabricksSchemaFetcher -> fetchConstraints -> Key constraints error for table %s: %v", table, err)
+	} else {
+		for _, row := range rows {
+			name := databricksString(row, "constraint_name")
+			constraint, exists := constraints[name]
+			if !exists {
+				constraint = ConstraintInfo{Name: name, Type: "PRIMARY KEY"}
+			}
+			constraint.Columns = append(constraint.Columns, databricksString(row, "column_name"))
+			constraints[name] = constraint
+		}
+	}
+
+	var checkRows []map[string]interface{}
+	checkQuery := `
+        SELECT cc.constraint_name, cc.check_clause
+        FROM information_schema.check_constraints cc
+        JOIN information_schema.table_constraints tc
+            ON tc.constraint_catalog = cc.constraint_catalog
+            AND tc.constraint_schema = cc.constraint_schema
+            AND tc.constraint_name = cc.constraint_name
+        WHERE tc.table_catalog = current_catalog()
+        AND tc.table_schema = current_schema()
+        AND tc.table_name = ?
+    `
+	if err := f.db.QueryRows(checkQuery, &checkRows, table); err != nil {
+		// Continue without check constraints rather than failing
+		log.Printf("DatabricksSchemaFetcher -> fetchConstraints -> Check constraints error for table %s: %v", table, err)
+		return constraints
+	}
+
+	for _, row := range checkRows {
+		name := databricksString(row, "constraint_name")
+		constraints[name] = ConstraintInfo{
+			Name:       name,
+			Type:       "CHECK",
+			Definition: databricksString(row, "check_clause"),
+		}
+	}
+	return constraints
+}
+
+// fetchViews retrieves all views & materialized views in the current schema
+func (f *DatabricksSchemaFetcher) fetchViews(_ context.Context) (map[string]ViewSchema, error) {
+	views := make(map[string]ViewSchema)
+
+	var rows []map[string]interface{}
+	query := `
+        SELECT table_name, view_definition
+        FROM information_schema.views
+        WHERE table_catalog = current_catalog()
+        AND table_schema = current_schema()
+        ORDER BY table_name
+    `
+	if err := f.db.QueryRows(query, &rows); err != nil {
+		// Return empty views rather than failing
+		log.Printf("DatabricksSchemaFetcher -> fetchViews -> Error: %v", err)
+		return views, nil
+	}
+
+	for _, row := range rows {
+		name := databricksString(row, "table_name")
+		views[name] = ViewSchema{
+			Name:       name,
+			Definition: databricksString(row, "view_definition"),
+		}
+	}
+	return views, nil
+}
+
+// getTableRowCount gets the number of rows in a table, Delta answers COUNT(*) from the transaction log statistics
+func (f *DatabricksSchemaFetcher) getTableRowCount(_ context.Context, table string) int64 {
+	var count int64
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s", quoteDatabricksIdentifier(table))
+	if err := f.db.Query(query, &count); err != nil {
+		log.Printf("DatabricksSchemaFetcher -> getTableRowCount -> Error for table %s: %v", table, err)
+		return 0
+	}
+	return count
+}
+
+// GetTableChecksum calculates a checksum for a table's structure
+func (f *DatabricksSchemaFetcher) GetTableChecksum(ctx context.Context, db DBExecutor, table string) (string, error) {
+	fetcher := &DatabricksSchemaFetcher{db: db}
+
+	columns, partitionColumns, err := fetcher.fetchColumns(ctx, table)
+	if err != nil {
+		return "", fmt.Errorf("failed to get table definition: %v", err)
+	}
+
+	definition, _ := json.Marshal(struct {
+		Columns          map[string]ColumnInfo     `json:"columns"`
+		PartitionColumns []string                  `json:"partition_columns"`
+		ForeignKeys      map[string]ForeignKey     `json:"foreign_keys"`
+		Constraints      map[string]ConstraintInfo `json:"constraints"`
+	}{
+		Columns:          columns,
+		PartitionColumns: partitionColumns,
+		ForeignKeys:      fetcher.fetchForeignKeys(ctx, table),
+		Constraints:      fetcher.fetchConstraints(ctx, table),
+	})
+
+	return fmt.Sprintf("%x", md5.Sum(definition)), nil
+}
+
+// FetchExampleRecords retrieves sample records from a table
+func (f *DatabricksSchemaFetcher) FetchExampleRecords(ctx context.Context, db DBExecutor, table string, limit int) ([]map[string]interface{}, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("DatabricksSchemaFetcher -> FetchExampleRecords -> Context cancelled: %v", err)
+		return nil, err
+	}
+
+	// Ensure limit is reasonable
+	if limit <= 0 {
+		limit = 3 // Default to 3 records
+	} else if limit > 10 {
+		limit = 10 // Cap at 10 records to avoid large data transfers
+	}
+
+	query := fmt.Sprintf("SELECT * FROM %s LIMIT %d", quoteDatabricksIdentifier(table), limit)
+
+	var records []map[string]interface{}
+	if err := db.QueryRows(query, &records); err != nil {
+		log.Printf("DatabricksSchemaFetcher -> FetchExampleRecords -> Error fetching records from table %s: %v", table, err)
+		return nil, fmt.Errorf("failed to fetch example records for table %s: %v", table, err)
+	}
+
+	// Process records to ensure all values are properly formatted
+	processedRecords := make([]map[string]interface{}, len(records))
+	for i, record := range records {
+		processedRecords[i] = make(map[string]interface{})
+		for key, value := range record {
+			if byteVal, ok := value.([]byte); ok {
+				processedRecords[i][key] = string(byteVal)
+			} else {
+				processedRecords[i][key] = value
+			}
+		}
+	}
+
+	log.Printf("DatabricksSchemaFetcher -> FetchExampleRecords -> Successfully fetched %d records from table %s", len(processedRecords), table)
+	return processedRecords, nil
+}
+
+// FetchTableList retrieves a list of all tables in the current schema
+func (f *DatabricksSchemaFetcher) FetchTableList(ctx context.Context) ([]string, error) {
+	tables, err := f.fetchTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tableNames := make([]string, 0, len(tables))
+	for _, table := range tables {
+		tableNames = append(tableNames, table.Name)
+	}
+	return tableNames, nil
+}
+
+// filterSchemaForSelectedTables filters the schema to only include the selected tables
+func (f *DatabricksSchemaFetcher) filterSchemaForSelectedTables(schema *SchemaInfo, selectedTables []string) *SchemaInfo {
+	// If no tables are selected or "ALL" is selected, return the full schema
+	if len(selectedTables) == 0 || (len(selectedTables) == 1 && selectedTables[0] == "ALL") {
+		return schema
+	}
+
+	selectedTablesMap := make(map[string]bool)
+	for _, table := range selectedTables {
+		selectedTablesMap[table] = true
+	}
+
+	filteredSchema := &SchemaInfo{
+		Tables:    make(map[string]TableSchema),
+		Views:     schema.Views,
+		UpdatedAt: schema.UpdatedAt,
+	}
+
+	for tableName, tableSchema := range schema.Tables {
+		if selectedTablesMap[tableName] {
+			filteredSchema.Tables[tableName] = tableSchema
+		}
+	}
+
+	// Calculate new checksum for filtered schema
+	schemaData, _ := json.Marshal(filteredSchema.Tables)
+	filteredSchema.Checksum = fmt.Sprintf("%x", md5.Sum(schemaData))
+
+	return filteredSchema
+}
+
+// buildDialectHints describes the Databricks SQL specific syntax the LLM should use
+func (f *DatabricksSchemaFetcher) buildDialectHints(_ context.Context) []string {
+	hints := []string{}
+
+	var location []map[string]interface{}
+	if err := f.db.QueryRows("SELECT current_catalog() AS catalog, current_schema() AS schema, current_version().dbsql_version AS version", &location); err != nil {
+		log.Printf("DatabricksSchemaFetcher -> buildDialectHints -> Error fetching current catalog: %v", err)
+	} else if len(location) > 0 {
+		hints = append(hints, fmt.Sprintf("Unity Catalog location: %s.%s, tables of other schemas or catalogs must be referenced with their three-level name catalog.schema.table",
+			databricksString(location[0], "catalog"), databricksString(location[0], "schema")))
+		if version := databricksString(location[0], "version"); version != "" {
+			hints = append(hints, fmt.Sprintf("Server: Databricks SQL %s", version))
+		}
+	}
+
+	return append(hints,
+		"Quote identifiers with backticks, e.g. `order`",
+		"Limit rows with LIMIT n and paginate with LIMIT n OFFSET m",
+		"Primary & foreign keys are informational only, they are not enforced",
+		"There are no indexes, filter on partition columns to prune the data read",
+		"Use MERGE INTO for upserts, UPDATE & DELETE are only supported on Delta tables",
+		"Every statement is committed on completion, multi statement transactions are not available",
+		"Restore a Delta table with RESTORE TABLE t TO VERSION AS OF n & read old data with SELECT ... FROM t VERSION AS OF n",
+	)
+}
+
+// describeDatabricksTable builds the table comment including the table type, storage format & partitioning
+func describeDatabricksTable(tableInfo databricksTableInfo, partitionColumns []string) string {
+	comment := tableInfo.Comment
+	if tableInfo.TableType != "" && tableInfo.TableType != "MANAGED" {
+		comment = appendDatabricksTag(comment, fmt.Sprintf("[Type: %s]", tableInfo.TableType))
+	}
+	if tableInfo.DataFormat != "" {
+		comment = appendDatabricksTag(comment, fmt.Sprintf("[Format: %s]", tableInfo.DataFormat))
+	}
+	if len(partitionColumns) > 0 {
+		comment = appendDatabricksTag(comment, fmt.Sprintf("[Partitioned by: %s]", strings.Join(partitionColumns, ", ")))
+	}
+	if tableInfo.Owner != "" {
+		comment = appendDatabricksTag(comment, fmt.Sprintf("[Owner: %s]", tableInfo.Owner))
+	}
+	return comment
+}
+
+// quoteDatabricksIdentifier quotes an identifier with backticks
+func quoteDatabricksIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// appendDatabricksTag appends a metadata tag to a comment
+func appendDatabricksTag(comment, tag string) string {
+	if comment == "" {
+		return tag
+	}
+	return comment + " " + tag
+}
+
+// databricksString reads an information_schema value as a string
+func databricksString(row map[string]interface{}, key string) string {
+	value, ok := row[key]
+	if !ok || value == nil {
+		return ""
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// databricksInt reads an information_schema value as an int64
+func databricksInt(row map[string]interface{}, key string) int64 {
+	var value int64
+	fmt.Sscanf(databricksString(row, key), "%d", &value)
+	return value
+}
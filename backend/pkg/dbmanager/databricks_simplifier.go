@@ -0,0 +1,35 @@
+package dbmanager
+
+import (
+	"strings"
+)
+
+// DatabricksSimplifier implements the SchemaSimplifier interface for Databricks.
+// Column constraints are reported the same way as MySQL, only the data types differ.
+type DatabricksSimplifier struct {
+	MySQLSimplifier
+}
+
+// SimplifyDataType converts Databricks data types to simplified versions for LLM
+func (s *DatabricksSimplifier) SimplifyDataType(dbType string) string {
+	lowerType := strings.ToLower(dbType)
+
+	switch {
+	case strings.HasPrefix(lowerType, "array"):
+		return "array"
+	case strings.HasPrefix(lowerType, "map") || strings.HasPrefix(lowerType, "struct") ||
+		strings.HasPrefix(lowerType, "variant"):
+		return "json"
+	case strings.HasPrefix(lowerType, "string"):
+		return "string"
+	case strings.HasPrefix(lowerType, "boolean"):
+		return "boolean"
+	case strings.HasPrefix(lowerType, "long") || strings.HasPrefix(lowerType, "short") ||
+		strings.HasPrefix(lowerType, "byte"):
+		return "integer"
+	case strings.HasPrefix(lowerType, "timestamp_ntz") || strings.HasPrefix(lowerType, "interval"):
+		return "datetime"
+	}
+
+	return s.MySQLSimplifier.SimplifyDataType(dbType)
+}
@@ -0,0 +1,46 @@
+package dbmanager
+
+import (
+	"context"
+	"fmt"
+	"neobase-ai/internal/apis/dtos"
+
+	"gorm.io/gorm"
+)
+
+// DatabricksTransaction implements the Transaction interface for Databricks.
+// SQL Warehouses commit each statement as it completes, rollbacks rely on the rollback queries generated by NeoBase.
+type DatabricksTransaction struct {
+	tx   *gorm.DB
+	conn *Connection
+}
+
+// ExecuteQuery executes a query within a transaction
+func (t *DatabricksTransaction) ExecuteQuery(ctx context.Context, conn *Connection, query string, queryType string, findCount bool) *QueryExecutionResult {
+	if t.tx == nil {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Message: "No active transaction",
+				Code:    "TRANSACTION_ERROR",
+			},
+		}
+	}
+
+	return executeRawSQLStatements(ctx, t.tx, query, isDatabricksResultStatement)
+}
+
+// Commit commits the transaction
+func (t *DatabricksTransaction) Commit() error {
+	if t.tx == nil {
+		return fmt.Errorf("no active transaction to commit")
+	}
+	return t.tx.Commit().Error
+}
+
+// Rollback rolls back the transaction
+func (t *DatabricksTransaction) Rollback() error {
+	if t.tx == nil {
+		return fmt.Errorf("no active transaction to rollback")
+	}
+	return t.tx.Rollback().Error
+}
@@ -0,0 +1,7 @@
+//go:build db2
+
+package dbmanager
+
+// Links the IBM DB2 database/sql driver, it needs cgo & the IBM Data Server CLI driver (clidriver) at build time.
+// Build with: CGO_ENABLED=1 go build -tags db2 ./... once clidriver is installed, see "Building with DB2 Support" in SETUP.md
+import _ "github.com/ibmdb/go_ibm_db"
@@ -0,0 +1,94 @@
+package dbmanager
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/migrator"
+	"gorm.io/gorm/schema"
+)
+
+// db2Dialector is the GORM dialect of DB2 connections. There is no upstream GORM dialect for DB2, only raw SQL is executed on the
+// connections so this one quotes identifiers & binds variables the DB2 way and leaves the migrations to the generic migrator.
+type db2Dialector struct {
+	conn *sql.DB
+}
+
+func newDB2Dialector(conn *sql.DB) gorm.Dialector {
+	return db2Dialector{conn: conn}
+}
+
+func (d db2Dialector) Name() string {
+	return "db2"
+}
+
+func (d db2Dialector) Initialize(db *gorm.DB) error {
+	if d.conn == nil {
+		return fmt.Errorf("the DB2 dialect needs an open connection")
+	}
+	db.ConnPool = d.conn
+	callbacks.RegisterDefaultCallbacks(db, &callbacks.Config{})
+	return nil
+}
+
+func (d db2Dialector) Migrator(db *gorm.DB) gorm.Migrator {
+	return migrator.Migrator{Config: migrator.Config{DB: db, Dialector: d}}
+}
+
+// DataTypeOf returns the DB2 column type of a field
+func (d db2Dialector) DataTypeOf(field *schema.Field) string {
+	switch field.DataType {
+	case schema.Bool:
+		return "BOOLEAN"
+	case schema.Int, schema.Uint:
+		if field.Size > 0 && field.Size <= 16 {
+			return "SMALLINT"
+		}
+		if field.Size > 0 && field.Size <= 32 {
+			return "INTEGER"
+		}
+		return "BIGINT"
+	case schema.Float:
+		return "DOUBLE"
+	case schema.String:
+		size := field.Size
+		if size <= 0 {
+			size = 255
+		}
+		return fmt.Sprintf("VARCHAR(%d)", size)
+	case schema.Time:
+		return "TIMESTAMP"
+	case schema.Bytes:
+		return "BLOB"
+	}
+	return string(field.DataType)
+}
+
+func (d db2Dialector) DefaultValueOf(field *schema.Field) clause.Expression {
+	return clause.Expr{SQL: "DEFAULT"}
+}
+
+func (d db2Dialector) BindVarTo(writer clause.Writer, stmt *gorm.Statement, v interface{}) {
+	writer.WriteByte('?')
+}
+
+// QuoteTo quotes an identifier with double quotes, a qualified name like SCHEMA.TABLE is quoted part by part
+func (d db2Dialector) QuoteTo(writer clause.Writer, str string) {
+	for i, part := range strings.Split(str, ".") {
+		if i > 0 {
+			writer.WriteByte('.')
+		}
+		writer.WriteByte('"')
+		writer.WriteString(strings.ReplaceAll(part, `"`, `""`))
+		writer.WriteByte('"')
+	}
+}
+
+func (d db2Dialector) Explain(sql string, vars ...interface{}) string {
+	return logger.ExplainSQL(sql, nil, `'`, vars...)
+}
@@ -0,0 +1,399 @@
+package dbmanager
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// db2DriverName is the database/sql driver name registered by github.com/ibmdb/go_ibm_db.
+// The IBM driver requires cgo & the IBM Data Server CLI driver, so it is only linked when building with the "db2" tag (see db2_cgo.go).
+const db2DriverName = "go_ibm_db"
+
+// DB2Driver implements the DatabaseDriver interface for IBM DB2
+type DB2Driver struct{}
+
+// DB2Supported returns true when the binary is built with the IBM DB2 driver, the DB2 connections are refused otherwise
+func DB2Supported() bool {
+	return isSQLDriverRegistered(db2DriverName)
+}
+
+// db2DSNValue quotes a value of a DB2 CLI connection string, a value holding a separator or a brace is enclosed
+// in braces with its closing braces doubled so a ; in a password can't end the keyword
+func db2DSNValue(value string) string {
+	if !strings.ContainsAny(value, ";{}=") && strings.TrimSpace(value) == value {
+		return value
+	}
+	return "{" + strings.ReplaceAll(value, "}", "}}") + "}"
+}
+
+// NewDB2Driver creates a new DB2 driver
+func NewDB2Driver() DatabaseDriver {
+	return &DB2Driver{}
+}
+
+// Connect establishes a connection to a DB2 database
+func (d *DB2Driver) Connect(config ConnectionConfig) (*Connection, error) {
+	if !DB2Supported() {
+		return nil, fmt.Errorf("DB2 support is not available in this build, rebuild the backend with the 'db2' build tag and the IBM CLI driver installed")
+	}
+
+	var tempFiles []string
+
+	port := "50000"
+	if config.Port != nil && *config.Port != "" {
+		port = *config.Port
+	}
+
+	// Base connection parameters
+	dsn := fmt.Sprintf("HOSTNAME=%s;PORT=%s;DATABASE=%s;PROTOCOL=TCPIP;", db2DSNValue(config.Host), db2DSNValue(port), db2DSNValue(config.Database))
+	if config.Username != nil {
+		dsn += fmt.Sprintf("UID=%s;", db2DSNValue(*config.Username))
+	}
+	if config.Password != nil {
+		dsn += fmt.Sprintf("PWD=%s;", db2DSNValue(*config.Password))
+	}
+
+	// Configure SSL/TLS, DB2 only needs the server CA certificate to verify the connection
+	if config.UseSSL && (config.SSLMode == nil || *config.SSLMode != "disable") {
+		dsn += "Security=SSL;"
+
+		// The DB2 CLI driver reads the CA certificate from disk only
+		certs, err := loadSSLCertificates(config)
+		if err != nil {
+			return nil, err
+		}
+		if len(certs.rootCert) > 0 {
+			rootCertPath, err := writeCertificateFile(certs.rootCert)
+			if err != nil {
+				return nil, err
+			}
+
+			// Track temporary files for cleanup
+			tempFiles = []string{rootCertPath}
+			dsn += fmt.Sprintf("SSLServerCertificate=%s;", db2DSNValue(rootCertPath))
+		}
+	}
+
+	// Open connection
+	db, err := sql.Open(db2DriverName, dsn)
+	if err != nil {
+		// Clean up temporary files
+		for _, file := range tempFiles {
+			os.Remove(file)
+		}
+		return nil, err
+	}
+
+	// Test connection
+	if err := db.Ping(); err != nil {
+		// Clean up temporary files
+		for _, file := range tempFiles {
+			os.Remove(file)
+		}
+		db.Close()
+		return nil, err
+	}
+
+	// Configure connection pool
+	db.SetMaxOpenConns(25)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(time.Hour)
+
+	// Create GORM DB
+	gormDB, err := gorm.Open(newDB2Dialector(db), &gorm.Config{})
+	if err != nil {
+		// Clean up temporary files
+		for _, file := range tempFiles {
+			os.Remove(file)
+		}
+		db.Close()
+		return nil, fmt.Errorf("failed to create GORM connection: %v", err)
+	}
+
+	// Create connection object
+	conn := &Connection{
+		DB:          gormDB,
+		LastUsed:    time.Now(),
+		Status:      StatusConnected,
+		Config:      config,
+		Subscribers: make(map[string]bool),
+		SubLock:     sync.RWMutex{},
+		TempFiles:   tempFiles,
+	}
+
+	// Detect the server version, used for logging & dialect hints
+	var version string
+	if err := gormDB.Raw("SELECT service_level FROM sysibmadm.env_inst_info").Scan(&version).Error; err != nil {
+		log.Printf("DB2Driver -> Connect -> Failed to detect server version: %v", err)
+	} else {
+		conn.ServerVersion = strings.TrimSpace(version)
+		log.Printf("DB2Driver -> Connect -> Connected to DB2 server version %s", conn.ServerVersion)
+	}
+
+	return conn, nil
+}
+
+// Disconnect closes a DB2 database connection
+func (d *DB2Driver) Disconnect(conn *Connection) error {
+	// Get the underlying SQL DB
+	sqlDB, err := conn.DB.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get SQL DB: %v", err)
+	}
+
+	// Close the connection
+	if err := sqlDB.Close(); err != nil {
+		return fmt.Errorf("failed to close connection: %v", err)
+	}
+
+	// Clean up temporary certificate files
+	for _, file := range conn.TempFiles {
+		os.Remove(file)
+	}
+
+	return nil
+}
+
+// Ping checks if the DB2 connection is alive
+func (d *DB2Driver) Ping(conn *Connection) error {
+	if conn == nil || conn.DB == nil {
+		return fmt.Errorf("no active connection to ping")
+	}
+
+	sqlDB, err := conn.DB.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %v", err)
+	}
+
+	return sqlDB.Ping()
+}
+
+// IsAlive checks if the DB2 connection is still valid
+func (d *DB2Driver) IsAlive(conn *Connection) bool {
+	if conn == nil || conn.DB == nil {
+		return false
+	}
+
+	sqlDB, err := conn.DB.DB()
+	if err != nil {
+		return false
+	}
+
+	return sqlDB.Ping() == nil
+}
+
+// ExecuteQuery executes a SQL query on the DB2 database
+func (d *DB2Driver) ExecuteQuery(ctx context.Context, conn *Connection, query string, queryType string, findCount bool) *QueryExecutionResult {
+	if conn == nil || conn.DB == nil {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Message: "No active connection",
+				Code:    "CONNECTION_ERROR",
+			},
+		}
+	}
+
+	return executeDB2Statements(ctx, conn.DB, query)
+}
+
+// BeginTx starts a new transaction
+func (d *DB2Driver) BeginTx(ctx context.Context, conn *Connection) Transaction {
+	if conn == nil || conn.DB == nil {
+		log.Printf("DB2Driver.BeginTx: Connection or DB is nil")
+		return nil
+	}
+
+	// Start a new transaction
+	tx := conn.DB.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		log.Printf("Failed to begin transaction: %v", tx.Error)
+		return nil
+	}
+
+	return &DB2Transaction{
+		tx:   tx,
+		conn: conn,
+	}
+}
+
+// GetSchema retrieves the database schema
+func (d *DB2Driver) GetSchema(ctx context.Context, db DBExecutor, selectedTables []string) (*SchemaInfo, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("DB2Driver -> GetSchema -> Context cancelled: %v", err)
+		return nil, err
+	}
+
+	fetcher := NewDB2SchemaFetcher(db)
+	return fetcher.GetSchema(ctx, db, selectedTables)
+}
+
+// GetTableChecksum calculates a checksum for a table
+func (d *DB2Driver) GetTableChecksum(ctx context.Context, db DBExecutor, table string) (string, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("DB2Driver -> GetTableChecksum -> Context cancelled: %v", err)
+		return "", err
+	}
+
+	fetcher := NewDB2SchemaFetcher(db)
+	return fetcher.GetTableChecksum(ctx, db, table)
+}
+
+// FetchExampleRecords fetches example records from a table
+func (d *DB2Driver) FetchExampleRecords(ctx context.Context, db DBExecutor, table string, limit int) ([]map[string]interface{}, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("DB2Driver -> FetchExampleRecords -> Context cancelled: %v", err)
+		return nil, err
+	}
+
+	fetcher := NewDB2SchemaFetcher(db)
+	return fetcher.FetchExampleRecords(ctx, db, table, limit)
+}
+
+// executeDB2Statements executes one or more DB2 statements on a connection or transaction
+func executeDB2Statements(ctx context.Context, db *gorm.DB, query string) *QueryExecutionResult {
+	return executeRawSQLStatements(ctx, db, query, isDB2ResultStatement)
+}
+
+// executeRawSQLStatements executes semicolon separated statements through GORM raw SQL, used by the drivers without a dedicated GORM dialect
+func executeRawSQLStatements(ctx context.Context, db *gorm.DB, query string, isResultStatement func(stmt string) bool) *QueryExecutionResult {
+	startTime := time.Now()
+	result := &QueryExecutionResult{}
+
+	// Statements are separated by semicolons just like MySQL
+	statements := splitMySQLStatements(query)
+
+	// Execute each statement
+	for _, stmt := range statements {
+		if strings.TrimSpace(stmt) == "" {
+			continue
+		}
+
+		// Check for context cancellation
+		if ctx.Err() != nil {
+			result.Error = &dtos.QueryError{
+				Message: "Query execution cancelled",
+				Code:    "EXECUTION_CANCELLED",
+			}
+			return result
+		}
+
+		// The named parameters of the statement are bound to the values of the context
+		boundStmt, args := bindQueryParams(ctx, stmt, placeholderQuestionMark)
+
+		if isResultStatement(stmt) {
+			// For SELECT like queries, return the results
+			rows, columns, err := queryResultRows(ctx, db, boundStmt, args...)
+			if err != nil {
+				result.Error = &dtos.QueryError{
+					Message: err.Error(),
+					Code:    "EXECUTION_ERROR",
+				}
+				return result
+			}
+
+			// Process the rows to ensure proper type handling
+			processedRows := make([]map[string]interface{}, len(rows))
+			for i, row := range rows {
+				processedRow := make(map[string]interface{})
+				for key, val := range row {
+					switch v := val.(type) {
+					case []byte:
+						// Convert []byte to string
+						processedRow[key] = string(v)
+					case string, float64, float32, int64, int32, int16, bool, nil:
+						// Keep primitives as is
+						processedRow[key] = v
+					case time.Time:
+						processedRow[key] = v.Format(time.RFC3339)
+					default:
+						// For other types, convert to string
+						processedRow[key] = fmt.Sprintf("%v", v)
+					}
+				}
+				processedRows[i] = processedRow
+			}
+
+			result.Result = map[string]interface{}{
+				"results": processedRows,
+			}
+			result.Columns = columns
+		} else {
+			// A statement without rows ends the result, the columns of an earlier SELECT are dropped
+			result.Columns = nil
+			// For other queries (INSERT, UPDATE, DELETE, etc.), execute and return affected rows
+			execResult := db.WithContext(ctx).Exec(boundStmt, args...)
+			if execResult.Error != nil {
+				result.Error = &dtos.QueryError{
+					Message: execResult.Error.Error(),
+					Code:    "EXECUTION_ERROR",
+				}
+				return result
+			}
+
+			rowsAffected := execResult.RowsAffected
+			if rowsAffected > 0 {
+				result.Result = map[string]interface{}{
+					"rowsAffected": rowsAffected,
+					"message":      fmt.Sprintf("%d row(s) affected", rowsAffected),
+				}
+			} else {
+				result.Result = map[string]interface{}{
+					"message": "Query performed successfully",
+				}
+			}
+		}
+	}
+
+	// Calculate execution time
+	result.ExecutionTime = int(time.Since(startTime).Milliseconds())
+
+	// Marshal the result to JSON
+	resultJSON, err := json.Marshal(result.Result)
+	if err != nil {
+		return &QueryExecutionResult{
+			ExecutionTime: int(time.Since(startTime).Milliseconds()),
+			Error: &dtos.QueryError{
+				Code:    "JSON_MARSHAL_FAILED",
+				Message: err.Error(),
+				Details: "Failed to marshal query results",
+			},
+		}
+	}
+	result.ResultJSON = string(resultJSON)
+
+	return result
+}
+
+// isDB2ResultStatement reports whether a statement returns a result set
+func isDB2ResultStatement(stmt string) bool {
+	upper := strings.ToUpper(strings.TrimSpace(stmt))
+	for _, prefix := range []string{"SELECT", "WITH", "VALUES"} {
+		if strings.HasPrefix(upper, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSQLDriverRegistered reports whether a database/sql driver is linked into the binary
+func isSQLDriverRegistered(name string) bool {
+	for _, driver := range sql.Drivers() {
+		if driver == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,26 @@
+package dbmanager
+
+import "testing"
+
+func TestDB2DSNValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"plain", "db2inst1", "db2inst1"},
+		{"semicolon", "pa;ss", "{pa;ss}"},
+		{"keyword injection", "x;Security=NONE", "{x;Security=NONE}"},
+		{"closing brace", "pa}ss;", "{pa}}ss;}"},
+		{"opening brace", "{pass}", "{{pass}}}"},
+		{"surrounding spaces", " pass ", "{ pass }"},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := db2DSNValue(tt.value); got != tt.want {
+				t.Errorf("db2DSNValue(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,686 @@
+package dbmanager
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// DB2SchemaFetcher implements schema fetching for IBM DB2 using the SYSCAT catalog views.
+// Objects are read from the connection's CURRENT SCHEMA, which defaults to the connecting user.
+type DB2SchemaFetcher struct {
+	db DBExecutor
+}
+
+// NewDB2SchemaFetcher creates a new DB2 schema fetcher
+func NewDB2SchemaFetcher(db DBExecutor) SchemaFetcher {
+	return &DB2SchemaFetcher{db: db}
+}
+
+// db2TableInfo holds the catalog metadata of a DB2 table
+type db2TableInfo struct {
+	Name          string
+	Tablespace    string
+	IndexSpace    string
+	LongSpace     string
+	Cardinality   int64
+	Remarks       string
+	IsPartitioned bool
+}
+
+// GetSchema retrieves the schema for the selected tables
+func (f *DB2SchemaFetcher) GetSchema(ctx context.Context, db DBExecutor, selectedTables []string) (*SchemaInfo, error) {
+	log.Printf("DB2SchemaFetcher -> GetSchema -> Starting schema fetch with selected tables: %v", selectedTables)
+
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("DB2SchemaFetcher -> GetSchema -> Context cancelled: %v", err)
+		return nil, err
+	}
+
+	schema, err := f.FetchSchema(ctx)
+	if err != nil {
+		log.Printf("DB2SchemaFetcher -> GetSchema -> Error fetching schema: %v", err)
+		return nil, err
+	}
+
+	filteredSchema := f.filterSchemaForSelectedTables(schema, selectedTables)
+	filteredSchema.DialectHints = f.buildDialectHints(ctx)
+	log.Printf("DB2SchemaFetcher -> GetSchema -> Filtered schema to %d tables", len(filteredSchema.Tables))
+
+	return filteredSchema, nil
+}
+
+// FetchSchema retrieves the full database schema
+func (f *DB2SchemaFetcher) FetchSchema(ctx context.Context) (*SchemaInfo, error) {
+	log.Printf("DB2SchemaFetcher -> FetchSchema -> Starting full schema fetch")
+
+	schema := &SchemaInfo{
+		Tables:    make(map[string]TableSchema),
+		Views:     make(map[string]ViewSchema),
+		Sequences: make(map[string]SequenceSchema),
+		UpdatedAt: time.Now(),
+	}
+
+	tables, err := f.fetchTables(ctx)
+	if err != nil {
+		log.Printf("DB2SchemaFetcher -> FetchSchema -> Error fetching tables: %v", err)
+		return nil, err
+	}
+
+	log.Printf("DB2SchemaFetcher -> FetchSchema -> Processing %d tables", len(tables))
+
+	for _, tableInfo := range tables {
+		// Check for context cancellation
+		if err := ctx.Err(); err != nil {
+			log.Printf("DB2SchemaFetcher -> FetchSchema -> Context cancelled: %v", err)
+			return nil, err
+		}
+
+		tableSchema, err := f.fetchTableSchema(ctx, tableInfo)
+		if err != nil {
+			return nil, err
+		}
+
+		// Calculate table schema checksum
+		tableData, _ := json.Marshal(tableSchema)
+		tableSchema.Checksum = fmt.Sprintf("%x", md5.Sum(tableData))
+
+		schema.Tables[tableInfo.Name] = tableSchema
+	}
+
+	views, err := f.fetchViews(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch views: %v", err)
+	}
+	schema.Views = views
+
+	sequences, err := f.fetchSequences(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sequences: %v", err)
+	}
+	schema.Sequences = sequences
+
+	// Calculate overall schema checksum
+	schemaData, _ := json.Marshal(schema.Tables)
+	schema.Checksum = fmt.Sprintf("%x", md5.Sum(schemaData))
+
+	log.Printf("DB2SchemaFetcher -> FetchSchema -> Successfully completed schema fetch with %d tables, %d views and %d sequences",
+		len(schema.Tables), len(schema.Views), len(schema.Sequences))
+
+	return schema, nil
+}
+
+// fetchTableSchema retrieves the columns, indexes, foreign keys & constraints of a table
+func (f *DB2SchemaFetcher) fetchTableSchema(ctx context.Context, tableInfo db2TableInfo) (TableSchema, error) {
+	table := tableInfo.Name
+	tableSchema := TableSchema{
+		Name:        table,
+		Comment:     describeDB2Table(tableInfo),
+		Columns:     make(map[string]ColumnInfo),
+		Indexes:     make(map[string]IndexInfo),
+		ForeignKeys: make(map[string]ForeignKey),
+		Constraints: make(map[string]ConstraintInfo),
+	}
+
+	columns, err := f.fetchColumns(ctx, table)
+	if err != nil {
+		log.Printf("DB2SchemaFetcher -> fetchTableSchema -> Error fetching columns for table %s: %v", table, err)
+		return tableSchema, fmt.Errorf("failed to fetch columns for table %s: %v", table, err)
+	}
+	tableSchema.Columns = columns
+
+	indexes, err := f.fetchIndexes(ctx, table)
+	if err != nil {
+		log.Printf("DB2SchemaFetcher -> fetchTableSchema -> Error fetching indexes for table %s: %v", table, err)
+		return tableSchema, fmt.Errorf("failed to fetch indexes for table %s: %v", table, err)
+	}
+	tableSchema.Indexes = indexes
+
+	tableSchema.ForeignKeys = f.fetchForeignKeys(ctx, table)
+	tableSchema.Constraints = f.fetchConstraints(ctx, table)
+	tableSchema.RowCount = f.getTableRowCount(ctx, tableInfo)
+
+	log.Printf("DB2SchemaFetcher -> fetchTableSchema -> Table %s: %d columns, %d indexes, %d foreign keys, %d rows",
+		table, len(tableSchema.Columns), len(tableSchema.Indexes), len(tableSchema.ForeignKeys), tableSchema.RowCount)
+
+	return tableSchema, nil
+}
+
+// fetchTables retrieves all tables in the current schema along with their tablespaces
+func (f *DB2SchemaFetcher) fetchTables(_ context.Context) ([]db2TableInfo, error) {
+	var rows []map[string]interface{}
+	query := `
+        SELECT TABNAME, TBSPACE, INDEX_TBSPACE, LONG_TBSPACE, CARD, REMARKS, PARTITION_MODE
+        FROM SYSCAT.TABLES
+        WHERE TABSCHEMA = CURRENT SCHEMA
+        AND TYPE = 'T'
+        ORDER BY TABNAME
+    `
+	if err := f.db.QueryRows(query, &rows); err != nil {
+		return nil, fmt.Errorf("failed to fetch tables: %v", err)
+	}
+
+	tables := make([]db2TableInfo, 0, len(rows))
+	for _, row := range rows {
+		tables = append(tables, db2TableInfo{
+			Name:          db2String(row, "TABNAME"),
+			Tablespace:    db2String(row, "TBSPACE"),
+			IndexSpace:    db2String(row, "INDEX_TBSPACE"),
+			LongSpace:     db2String(row, "LONG_TBSPACE"),
+			Cardinality:   db2Int(row, "CARD"),
+			Remarks:       db2String(row, "REMARKS"),
+			IsPartitioned: db2String(row, "PARTITION_MODE") == "H",
+		})
+	}
+	log.Printf("DB2SchemaFetcher -> fetchTables -> Found %d tables", len(tables))
+	return tables, nil
+}
+
+// fetchColumns retrieves all columns for a specific table, including identity & generated column details
+func (f *DB2SchemaFetcher) fetchColumns(_ context.Context, table string) (map[string]ColumnInfo, error) {
+	columns := make(map[string]ColumnInfo)
+
+	var rows []map[string]interface{}
+	query := `
+        SELECT
+            c.COLNAME, c.TYPENAME, c.LENGTH, c.SCALE, c.NULLS, c.DEFAULT,
+            c.IDENTITY, c.GENERATED, c.TEXT, c.REMARKS,
+            CAST(i.START AS BIGINT) AS IDENTITY_START,
+            CAST(i.INCREMENT AS BIGINT) AS IDENTITY_INCREMENT
+        FROM SYSCAT.COLUMNS c
+        LEFT JOIN SYSCAT.COLIDENTATTRIBUTES i
+            ON i.TABSCHEMA = c.TABSCHEMA
+            AND i.TABNAME = c.TABNAME
+            AND i.COLNAME = c.COLNAME
+        WHERE c.TABSCHEMA = CURRENT SCHEMA
+        AND c.TABNAME = ?
+        ORDER BY c.COLNO
+    `
+	if err := f.db.QueryRows(query, &rows, table); err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		name := db2String(row, "COLNAME")
+		column := ColumnInfo{
+			Name:         name,
+			Type:         formatDB2ColumnType(db2String(row, "TYPENAME"), db2Int(row, "LENGTH"), db2Int(row, "SCALE")),
+			IsNullable:   db2String(row, "NULLS") == "Y",
+			DefaultValue: db2String(row, "DEFAULT"),
+			Comment:      db2String(row, "REMARKS"),
+		}
+
+		// Identity columns, GENERATED ALWAYS ones reject explicit values on INSERT
+		generated := db2String(row, "GENERATED")
+		if db2String(row, "IDENTITY") == "Y" {
+			mode := "BY DEFAULT"
+			if generated == "A" {
+				mode = "ALWAYS"
+			}
+			column.DefaultValue = fmt.Sprintf("GENERATED %s AS IDENTITY (START WITH %d, INCREMENT BY %d)",
+				mode, db2Int(row, "IDENTITY_START"), db2Int(row, "IDENTITY_INCREMENT"))
+			column.Comment = appendDB2Tag(column.Comment, "[Identity: GENERATED "+mode+"]")
+		} else if generated != "" && db2String(row, "TEXT") != "" {
+			// Generated expression columns, e.g. GENERATED ALWAYS AS (price * qty)
+			column.DefaultValue = db2String(row, "TEXT")
+			column.Comment = appendDB2Tag(column.Comment, "[Generated column]")
+		}
+
+		columns[name] = column
+	}
+	return columns, nil
+}
+
+// fetchIndexes retrieves all indexes for a specific table
+func (f *DB2SchemaFetcher) fetchIndexes(_ context.Context, table string) (map[string]IndexInfo, error) {
+	indexes := make(map[string]IndexInfo)
+
+	var rows []map[string]interface{}
+	query := `
+        SELECT INDNAME, COLNAMES, UNIQUERULE
+        FROM SYSCAT.INDEXES
+        WHERE TABSCHEMA = CURRENT SCHEMA
+        AND TABNAME = ?
+        ORDER BY INDNAME
+    `
+	if err := f.db.QueryRows(query, &rows, table); err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		name := db2String(row, "INDNAME")
+		indexes[name] = IndexInfo{
+			Name:     name,
+			Columns:  parseDB2ColumnNames(db2String(row, "COLNAMES")),
+			IsUnique: db2String(row, "UNIQUERULE") != "D",
+		}
+	}
+	return indexes, nil
+}
+
+// fetchForeignKeys retrieves all foreign keys for a specific table
+func (f *DB2SchemaFetcher) fetchForeignKeys(_ context.Context, table string) map[string]ForeignKey {
+	fkeys := make(map[string]ForeignKey)
+
+	var rows []map[string]interface{}
+	query := `
+        SELECT r.CONSTNAME, k.COLNAME, r.REFTABNAME, pk.COLNAME AS REFCOLNAME, r.DELETERULE, r.UPDATERULE
+        FROM SYSCAT.REFERENCES r
+        JOIN SYSCAT.KEYCOLUSE k
+            ON k.CONSTNAME = r.CONSTNAME
+            AND k.TABSCHEMA = r.TABSCHEMA
+            AND k.TABNAME = r.TABNAME
+        JOIN SYSCAT.KEYCOLUSE pk
+            ON pk.CONSTNAME = r.REFKEYNAME
+            AND pk.TABSCHEMA = r.REFTABSCHEMA
+            AND pk.TABNAME = r.REFTABNAME
+            AND pk.COLSEQ = k.COLSEQ
+        WHERE r.TABSCHEMA = CURRENT SCHEMA
+        AND r.TABNAME = ?
+        ORDER BY r.CONSTNAME, k.COLSEQ
+    `
+	if err := f.db.QueryRows(query, &rows, table); err != nil {
+		// Return empty foreign keys rather than failing
+		log.Printf("DB2SchemaFetcher -> fetchForeignKeys -> Error for table %s: %v", table, err)
+		return fkeys
+	}
+
+	for _, row := range rows {
+		name := db2String(row, "CONSTNAME")
+		fkeys[name] = ForeignKey{
+			Name:       name,
+			ColumnName: db2String(row, "COLNAME"),
+			RefTable:   db2String(row, "REFTABNAME"),
+			RefColumn:  db2String(row, "REFCOLNAME"),
+			OnDelete:   db2ReferentialRule(db2String(row, "DELETERULE")),
+			OnUpdate:   db2ReferentialRule(db2String(row, "UPDATERULE")),
+		}
+	}
+	return fkeys
+}
+
+// fetchConstraints retrieves primary key, unique & check constraints for a specific table
+func (f *DB2SchemaFetcher) fetchConstraints(_ context.Context, table string) map[string]ConstraintInfo {
+	constraints := make(map[string]ConstraintInfo)
+
+	var rows []map[string]interface{}
+	query := `
+        SELECT tc.CONSTNAME, tc.TYPE, k.COLNAME
+        FROM SYSCAT.TABCONST tc
+        JOIN SYSCAT.KEYCOLUSE k
+            ON k.CONSTNAME = tc.CONSTNAME
+            AND k.TABSCHEMA = tc.TABSCHEMA
+            AND k.TABNAME = tc.TABNAME
+        WHERE tc.TABSCHEMA = CURRENT SCHEMA
+        AND tc.TABNAME = ?
+        AND tc.TYPE IN ('P', 'U')
+        ORDER BY tc.CONSTNAME, k.COLSEQ
+    `
+	if err := f.db.QueryRows(query, &rows, table); err != nil {
+		// Continue without key constraints rather than failing
+		log.Printf("DB2SchemaFetcher -> fetchConstraints -> Key constraints error for table %s: %v", table, err)
+	} else {
+		for _, row := range rows {
+			name := db2String(row, "CONSTNAME")
+			constraint, exists := constraints[name]
+			if !exists {
+				constraintType := "UNIQUE"
+				if db2String(row, "TYPE") == "P" {
+					constraintType = "PRIMARY KEY"
+				}
+				constraint = ConstraintInfo{Name: name, Type: constraintType}
+			}
+			constraint.Columns = append(constraint.Columns, db2String(row, "COLNAME"))
+			constraints[name] = constraint
+		}
+	}
+
+	var checkRows []map[string]interface{}
+	checkQuery := `
+        SELECT CONSTNAME, TEXT
+        FROM SYSCAT.CHECKS
+        WHERE TABSCHEMA = CURRENT SCHEMA
+        AND TABNAME = ?
+        AND TYPE = 'C'
+    `
+	if err := f.db.QueryRows(checkQuery, &checkRows, table); err != nil {
+		// Continue without check constraints rather than failing
+		log.Printf("DB2SchemaFetcher -> fetchConstraints -> Check constraints error for table %s: %v", table, err)
+		return constraints
+	}
+
+	for _, row := range checkRows {
+		name := db2String(row, "CONSTNAME")
+		constraints[name] = ConstraintInfo{
+			Name:       name,
+			Type:       "CHECK",
+			Definition: db2String(row, "TEXT"),
+		}
+	}
+	return constraints
+}
+
+// fetchViews retrieves all views in the current schema
+func (f *DB2SchemaFetcher) fetchViews(_ context.Context) (map[string]ViewSchema, error) {
+	views := make(map[string]ViewSchema)
+
+	var rows []map[string]interface{}
+	query := `
+        SELECT VIEWNAME, TEXT
+        FROM SYSCAT.VIEWS
+        WHERE VIEWSCHEMA = CURRENT SCHEMA
+        ORDER BY VIEWNAME
+    `
+	if err := f.db.QueryRows(query, &rows); err != nil {
+		// Return empty views rather than failing
+		log.Printf("DB2SchemaFetcher -> fetchViews -> Error: %v", err)
+		return views, nil
+	}
+
+	for _, row := range rows {
+		name := db2String(row, "VIEWNAME")
+		views[name] = ViewSchema{
+			Name:       name,
+			Definition: db2String(row, "TEXT"),
+		}
+	}
+	return views, nil
+}
+
+// fetchSequences retrieves user defined sequences, identity column sequences are reported on their columns instead
+func (f *DB2SchemaFetcher) fetchSequences(_ context.Context) (map[string]SequenceSchema, error) {
+	sequences := make(map[string]SequenceSchema)
+
+	var rows []map[string]interface{}
+	query := `
+        SELECT SEQNAME,
+            CAST(START AS BIGINT) AS START_VALUE,
+            CAST(INCREMENT AS BIGINT) AS INCREMENT,
+            CAST(MINVALUE AS BIGINT) AS MIN_VALUE,
+            CAST(MAXVALUE AS BIGINT) AS MAX_VALUE,
+            CACHE, CYCLE
+        FROM SYSCAT.SEQUENCES
+        WHERE SEQSCHEMA = CURRENT SCHEMA
+        AND SEQTYPE = 'S'
+        ORDER BY SEQNAME
+    `
+	if err := f.db.QueryRows(query, &rows); err != nil {
+		// Return empty sequences rather than failing, e.g. DECIMAL sequences overflowing BIGINT
+		log.Printf("DB2SchemaFetcher -> fetchSequences -> Error: %v", err)
+		return sequences, nil
+	}
+
+	for _, row := range rows {
+		name := db2String(row, "SEQNAME")
+		sequences[name] = SequenceSchema{
+			Name:       name,
+			StartValue: db2Int(row, "START_VALUE"),
+			Increment:  db2Int(row, "INCREMENT"),
+			MinValue:   db2Int(row, "MIN_VALUE"),
+			MaxValue:   db2Int(row, "MAX_VALUE"),
+			CacheSize:  db2Int(row, "CACHE"),
+			IsCycled:   db2String(row, "CYCLE") == "Y",
+		}
+	}
+	return sequences, nil
+}
+
+// getTableRowCount gets the number of rows in a table, falling back to the catalog statistics
+func (f *DB2SchemaFetcher) getTableRowCount(_ context.Context, tableInfo db2TableInfo) int64 {
+	var count int64
+	query := fmt.Sprintf("SELECT COUNT_BIG(*) FROM %s", quoteDB2Identifier(tableInfo.Name))
+	if err := f.db.Query(query, &count); err != nil {
+		log.Printf("DB2SchemaFetcher -> getTableRowCount -> Error for table %s: %v, using catalog cardinality", tableInfo.Name, err)
+		// CARD is -1 when RUNSTATS has never been run on the table
+		if tableInfo.Cardinality < 0 {
+			return 0
+		}
+		return tableInfo.Cardinality
+	}
+	return count
+}
+
+// GetTableChecksum calculates a checksum for a table's structure
+func (f *DB2SchemaFetcher) GetTableChecksum(ctx context.Context, db DBExecutor, table string) (string, error) {
+	fetcher := &DB2SchemaFetcher{db: db}
+
+	columns, err := fetcher.fetchColumns(ctx, table)
+	if err != nil {
+		return "", fmt.Errorf("failed to get table definition: %v", err)
+	}
+
+	indexes, err := fetcher.fetchIndexes(ctx, table)
+	if err != nil {
+		return "", fmt.Errorf("failed to get indexes: %v", err)
+	}
+
+	definition, _ := json.Marshal(struct {
+		Columns     map[string]ColumnInfo     `json:"columns"`
+		Indexes     map[string]IndexInfo      `json:"indexes"`
+		ForeignKeys map[string]ForeignKey     `json:"foreign_keys"`
+		Constraints map[string]ConstraintInfo `json:"constraints"`
+	}{
+		Columns:     columns,
+		Indexes:     indexes,
+		ForeignKeys: fetcher.fetchForeignKeys(ctx, table),
+		Constraints: fetcher.fetchConstraints(ctx, table),
+	})
+
+	return fmt.Sprintf("%x", md5.Sum(definition)), nil
+}
+
+// FetchExampleRecords retrieves sample records from a table
+func (f *DB2SchemaFetcher) FetchExampleRecords(ctx context.Context, db DBExecutor, table string, limit int) ([]map[string]interface{}, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("DB2SchemaFetcher -> FetchExampleRecords -> Context cancelled: %v", err)
+		return nil, err
+	}
+
+	// Ensure limit is reasonable
+	if limit <= 0 {
+		limit = 3 // Default to 3 records
+	} else if limit > 10 {
+		limit = 10 // Cap at 10 records to avoid large data transfers
+	}
+
+	query := fmt.Sprintf("SELECT * FROM %s FETCH FIRST %d ROWS ONLY", quoteDB2Identifier(table), limit)
+
+	var records []map[string]interface{}
+	if err := db.QueryRows(query, &records); err != nil {
+		log.Printf("DB2SchemaFetcher -> FetchExampleRecords -> Error fetching records from table %s: %v", table, err)
+		return nil, fmt.Errorf("failed to fetch example records for table %s: %v", table, err)
+	}
+
+	// Process records to ensure all values are properly formatted
+	processedRecords := make([]map[string]interface{}, len(records))
+	for i, record := range records {
+		processedRecords[i] = make(map[string]interface{})
+		for key, value := range record {
+			if byteVal, ok := value.([]byte); ok {
+				processedRecords[i][key] = string(byteVal)
+			} else {
+				processedRecords[i][key] = value
+			}
+		}
+	}
+
+	log.Printf("DB2SchemaFetcher -> FetchExampleRecords -> Successfully fetched %d records from table %s", len(processedRecords), table)
+	return processedRecords, nil
+}
+
+// FetchTableList retrieves a list of all tables in the current schema
+func (f *DB2SchemaFetcher) FetchTableList(ctx context.Context) ([]string, error) {
+	tables, err := f.fetchTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tableNames := make([]string, 0, len(tables))
+	for _, table := range tables {
+		tableNames = append(tableNames, table.Name)
+	}
+	return tableNames, nil
+}
+
+// filterSchemaForSelectedTables filters the schema to only include the selected tables
+func (f *DB2SchemaFetcher) filterSchemaForSelectedTables(schema *SchemaInfo, selectedTables []string) *SchemaInfo {
+	// If no tables are selected or "ALL" is selected, return the full schema
+	if len(selectedTables) == 0 || (len(selectedTables) == 1 && selectedTables[0] == "ALL") {
+		return schema
+	}
+
+	selectedTablesMap := make(map[string]bool)
+	for _, table := range selectedTables {
+		selectedTablesMap[table] = true
+	}
+
+	filteredSchema := &SchemaInfo{
+		Tables:    make(map[string]TableSchema),
+		Views:     schema.Views,
+		Sequences: schema.Sequences,
+		UpdatedAt: schema.UpdatedAt,
+	}
+
+	for tableName, tableSchema := range schema.Tables {
+		if selectedTablesMap[tableName] {
+			filteredSchema.Tables[tableName] = tableSchema
+		}
+	}
+
+	// Calculate new checksum for filtered schema
+	schemaData, _ := json.Marshal(filteredSchema.Tables)
+	filteredSchema.Checksum = fmt.Sprintf("%x", md5.Sum(schemaData))
+
+	return filteredSchema
+}
+
+// buildDialectHints describes the DB2 specific syntax the LLM should use
+func (f *DB2SchemaFetcher) buildDialectHints(_ context.Context) []string {
+	hints := []string{}
+
+	var version string
+	if err := f.db.Query("SELECT service_level FROM sysibmadm.env_inst_info", &version); err != nil {
+		log.Printf("DB2SchemaFetcher -> buildDialectHints -> Error fetching server version: %v", err)
+	} else if version != "" {
+		hints = append(hints, fmt.Sprintf("Server: %s", strings.TrimSpace(version)))
+	}
+
+	return append(hints,
+		"Limit rows with FETCH FIRST n ROWS ONLY and paginate with OFFSET n ROWS FETCH NEXT m ROWS ONLY",
+		"Unquoted identifiers are stored in upper case, quote mixed case identifiers with double quotes",
+		"Identity columns GENERATED ALWAYS must be omitted from INSERT column lists",
+		"Read generated keys with SELECT ... FROM FINAL TABLE (INSERT ...)",
+		"Use SYSIBM.SYSDUMMY1 or VALUES for single row expressions, e.g. SELECT CURRENT TIMESTAMP FROM SYSIBM.SYSDUMMY1",
+	)
+}
+
+// describeDB2Table builds the table comment including the tablespace placement
+func describeDB2Table(tableInfo db2TableInfo) string {
+	comment := tableInfo.Remarks
+	if tableInfo.Tablespace != "" {
+		comment = appendDB2Tag(comment, fmt.Sprintf("[Tablespace: %s]", tableInfo.Tablespace))
+	}
+	if tableInfo.IndexSpace != "" && tableInfo.IndexSpace != tableInfo.Tablespace {
+		comment = appendDB2Tag(comment, fmt.Sprintf("[Index Tablespace: %s]", tableInfo.IndexSpace))
+	}
+	if tableInfo.LongSpace != "" && tableInfo.LongSpace != tableInfo.Tablespace {
+		comment = appendDB2Tag(comment, fmt.Sprintf("[LOB Tablespace: %s]", tableInfo.LongSpace))
+	}
+	if tableInfo.IsPartitioned {
+		comment = appendDB2Tag(comment, "[Hash partitioned]")
+	}
+	return comment
+}
+
+// formatDB2ColumnType appends the length & scale to the types that need it
+func formatDB2ColumnType(typeName string, length, scale int64) string {
+	switch strings.ToUpper(typeName) {
+	case "DECIMAL", "NUMERIC":
+		return fmt.Sprintf("%s(%d,%d)", typeName, length, scale)
+	case "CHARACTER", "VARCHAR", "GRAPHIC", "VARGRAPHIC", "BINARY", "VARBINARY", "CLOB", "BLOB", "DBCLOB":
+		return fmt.Sprintf("%s(%d)", typeName, length)
+	default:
+		return typeName
+	}
+}
+
+// parseDB2ColumnNames parses SYSCAT.INDEXES.COLNAMES (e.g. "+LASTNAME-HIREDATE") into column names
+func parseDB2ColumnNames(colNames string) []string {
+	var columns []string
+	var current strings.Builder
+	for _, char := range colNames {
+		if char == '+' || char == '-' || char == '*' {
+			if current.Len() > 0 {
+				columns = append(columns, current.String())
+				current.Reset()
+			}
+			continue
+		}
+		current.WriteRune(char)
+	}
+	if current.Len() > 0 {
+		columns = append(columns, current.String())
+	}
+	return columns
+}
+
+// db2ReferentialRule converts SYSCAT.REFERENCES rule codes into SQL keywords
+func db2ReferentialRule(rule string) string {
+	switch rule {
+	case "C":
+		return "CASCADE"
+	case "N":
+		return "SET NULL"
+	case "R":
+		return "RESTRICT"
+	default:
+		return "NO ACTION"
+	}
+}
+
+// quoteDB2Identifier quotes an identifier with double quotes
+func quoteDB2Identifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// appendDB2Tag appends a metadata tag to a comment
+func appendDB2Tag(comment, tag string) string {
+	if comment == "" {
+		return tag
+	}
+	return comment + " " + tag
+}
+
+// db2String reads a catalog value as a trimmed string, DB2 pads CHAR catalog columns with spaces
+func db2String(row map[string]interface{}, key string) string {
+	value, ok := row[key]
+	if !ok {
+		// Some drivers return lower case column labels
+		value, ok = row[strings.ToLower(key)]
+	}
+	if !ok || value == nil {
+		return ""
+	}
+
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case []byte:
+		return strings.TrimSpace(string(v))
+	default:
+		return strings.TrimSpace(fmt.Sprintf("%v", v))
+	}
+}
+
+// db2Int reads a catalog value as an int64
+func db2Int(row map[string]interface{}, key string) int64 {
+	var value int64
+	fmt.Sscanf(db2String(row, key), "%d", &value)
+	return value
+}
@@ -0,0 +1,28 @@
+package dbmanager
+
+import (
+	"strings"
+)
+
+// DB2Simplifier implements the SchemaSimplifier interface for IBM DB2.
+// Column constraints are reported the same way as MySQL, only the data types differ.
+type DB2Simplifier struct {
+	MySQLSimplifier
+}
+
+// SimplifyDataType converts DB2 data types to simplified versions for LLM
+func (s *DB2Simplifier) SimplifyDataType(dbType string) string {
+	lowerType := strings.ToLower(dbType)
+
+	switch {
+	case strings.Contains(lowerType, "decfloat") || strings.HasPrefix(lowerType, "real"):
+		return "number"
+	case strings.Contains(lowerType, "graphic") || strings.HasPrefix(lowerType, "clob") ||
+		strings.HasPrefix(lowerType, "dbclob") || strings.HasPrefix(lowerType, "xml"):
+		return "string"
+	case strings.HasPrefix(lowerType, "boolean"):
+		return "boolean"
+	}
+
+	return s.MySQLSimplifier.SimplifyDataType(dbType)
+}
@@ -0,0 +1,45 @@
+package dbmanager
+
+import (
+	"context"
+	"fmt"
+	"neobase-ai/internal/apis/dtos"
+
+	"gorm.io/gorm"
+)
+
+// DB2Transaction implements the Transaction interface for IBM DB2
+type DB2Transaction struct {
+	tx   *gorm.DB
+	conn *Connection
+}
+
+// ExecuteQuery executes a query within a transaction
+func (t *DB2Transaction) ExecuteQuery(ctx context.Context, conn *Connection, query string, queryType string, findCount bool) *QueryExecutionResult {
+	if t.tx == nil {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Message: "No active transaction",
+				Code:    "TRANSACTION_ERROR",
+			},
+		}
+	}
+
+	return executeDB2Statements(ctx, t.tx, query)
+}
+
+// Commit commits the transaction
+func (t *DB2Transaction) Commit() error {
+	if t.tx == nil {
+		return fmt.Errorf("no active transaction to commit")
+	}
+	return t.tx.Commit().Error
+}
+
+// Rollback rolls back the transaction
+func (t *DB2Transaction) Rollback() error {
+	if t.tx == nil {
+		return fmt.Errorf("no active transaction to rollback")
+	}
+	return t.tx.Rollback().Error
+}
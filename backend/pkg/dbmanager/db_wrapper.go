@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"neobase-ai/internal/constants"
 	"strings"
 
 	"gorm.io/gorm"
@@ -184,6 +185,7 @@ func (w *PostgresWrapper) Close() error {
 // MySQLWrapper implements DBExecutor for MySQL
 type MySQLWrapper struct {
 	BaseWrapper
+	dbType string // Driver & fetcher key, MySQL compatible databases (e.g. MariaDB) share this wrapper
 }
 
 func NewMySQLWrapper(db *gorm.DB, manager *Manager, chatID string) *MySQLWrapper {
@@ -193,9 +195,45 @@ func NewMySQLWrapper(db *gorm.DB, manager *Manager, chatID string) *MySQLWrapper
 			manager: manager,
 			chatID:  chatID,
 		},
+		dbType: constants.DatabaseTypeMySQL,
 	}
 }
 
+// NewMariaDBWrapper creates a MySQL wrapper that uses the MariaDB driver & schema fetcher
+func NewMariaDBWrapper(db *gorm.DB, manager *Manager, chatID string) *MySQLWrapper {
+	wrapper := NewMySQLWrapper(db, manager, chatID)
+	wrapper.dbType = constants.DatabaseTypeMariaDB
+	return wrapper
+}
+
+// NewSingleStoreWrapper creates a MySQL wrapper that uses the SingleStore driver & schema fetcher
+func NewSingleStoreWrapper(db *gorm.DB, manager *Manager, chatID string) *MySQLWrapper {
+	wrapper := NewMySQLWrapper(db, manager, chatID)
+	wrapper.dbType = constants.DatabaseTypeSingleStore
+	return wrapper
+}
+
+// NewDB2Wrapper creates a SQL wrapper that uses the DB2 driver & schema fetcher, the wrapper only runs raw SQL through GORM so it is not MySQL specific
+func NewDB2Wrapper(db *gorm.DB, manager *Manager, chatID string) *MySQLWrapper {
+	wrapper := NewMySQLWrapper(db, manager, chatID)
+	wrapper.dbType = constants.DatabaseTypeDB2
+	return wrapper
+}
+
+// NewDatabricksWrapper creates a SQL wrapper that uses the Databricks driver & schema fetcher
+func NewDatabricksWrapper(db *gorm.DB, manager *Manager, chatID string) *MySQLWrapper {
+	wrapper := NewMySQLWrapper(db, manager, chatID)
+	wrapper.dbType = constants.DatabaseTypeDatabricks
+	return wrapper
+}
+
+// NewFirestoreWrapper creates a wrapper that uses the Firestore driver & schema fetcher, queries don't go through GORM
+func NewFirestoreWrapper(db *gorm.DB, manager *Manager, chatID string) *MySQLWrapper {
+	wrapper := NewMySQLWrapper(db, manager, chatID)
+	wrapper.dbType = constants.DatabaseTypeFirestore
+	return wrapper
+}
+
 // GetDB returns the underlying *sql.DB
 func (w *MySQLWrapper) GetDB() *sql.DB {
 	sqlDB, err := w.db.DB()
@@ -215,13 +253,13 @@ func (w *MySQLWrapper) GetSchema(ctx context.Context) (*SchemaInfo, error) {
 	}
 
 	// Check if MySQL driver exists
-	_, exists := w.manager.drivers["mysql"]
+	_, exists := w.manager.drivers[w.dbType]
 	if !exists {
 		return nil, fmt.Errorf("MySQL driver not found")
 	}
 
 	// Get the schema fetcher factory for MySQL
-	fetcherFactory, exists := w.manager.fetchers["mysql"]
+	fetcherFactory, exists := w.manager.fetchers[w.dbType]
 	if !exists {
 		return nil, fmt.Errorf("MySQL schema fetcher not found")
 	}
@@ -272,7 +310,7 @@ func (w *MySQLWrapper) GetTableChecksum(ctx context.Context, table string) (stri
 	}
 
 	// Get the schema fetcher factory for MySQL
-	fetcherFactory, exists := w.manager.fetchers["mysql"]
+	fetcherFactory, exists := w.manager.fetchers[w.dbType]
 	if !exists {
 		return "", fmt.Errorf("MySQL schema fetcher not found")
 	}
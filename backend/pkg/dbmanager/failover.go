@@ -0,0 +1,213 @@
+package dbmanager
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/constants"
+	"strings"
+	"time"
+)
+
+// Failover replay settings, a managed database (e.g. RDS Multi-AZ, Atlas) usually promotes its standby within a minute
+const (
+	maxFailoverReplays        = 2                       // Replays of a read interrupted by a failover
+	failoverReconnectTimeout  = 30 * time.Second        // Time given to the new primary to accept connections
+	failoverReconnectInterval = 2 * time.Second         // Wait between the reconnect attempts
+	defaultMaxIdleConns       = 5                       // Idle pool size set by the SQL drivers on connect
+	FailoverEvent             = "query-failover-replay" // SSE event sent when a query was interrupted by a failover
+)
+
+// failoverErrorPatterns are the errors of a server going away or stepping down, lowercased
+var failoverErrorPatterns = []string{
+	"driver: bad connection",
+	"connection reset by peer",
+	"broken pipe",
+	"unexpected eof",
+	"connection refused",
+	"use of closed network connection",
+}
+
+// failoverErrorPatternsByType are the failover errors specific to a database type, lowercased
+var failoverErrorPatternsByType = map[string][]string{
+	constants.DatabaseTypePostgreSQL: {
+		"terminating connection due to administrator command", // 57P01, the server is restarted or demoted
+		"the database system is shutting down",                // 57P03
+		"the database system is starting up",                  // 57P03
+		"the database system is in recovery mode",             // 57P03
+		"server closed the connection unexpectedly",
+		"canceling statement due to conflict with recovery", // Reads on a standby being promoted
+	},
+	constants.DatabaseTypeMySQL: {
+		"invalid connection",              // go-sql-driver, the connection died mid-query
+		"server has gone away",            // 2006
+		"lost connection to mysql server", // 2013
+		"server shutdown in progress",     // 1053
+		"wsrep has not yet prepared node", // 1047, Galera node leaving the cluster
+	},
+	constants.DatabaseTypeClickhouse: {
+		"code: 210", // NETWORK_ERROR
+	},
+	constants.DatabaseTypeMongoDB: {
+		"not primary",
+		"notwritableprimary",
+		"not master",
+		"node is recovering",
+		"interruptedduetoreplstatechange",
+		"primarysteppeddown",
+		"shutdowninprogress",
+		"interruptedatshutdown",
+		"hostunreachable",
+		"socketexception",
+		"incomplete read of message header",
+	},
+}
+
+// FailoverNotice is the data of the SSE event telling a query was interrupted by a failover & replayed
+type FailoverNotice struct {
+	MessageID   string `json:"message_id"`
+	QueryID     string `json:"query_id"`
+	Error       string `json:"error"`       // Error the query failed with
+	Reconnected bool   `json:"reconnected"` // The new primary accepted a connection
+	Replayed    bool   `json:"replayed"`    // The query succeeded on the new primary
+	Attempts    int    `json:"attempts"`
+	Message     string `json:"message"`
+}
+
+// IsFailoverError returns true when a query failed because the server went away or stepped down,
+// in which case the same query can succeed once the new primary is reached
+func IsFailoverError(dbType string, queryErr *dtos.QueryError) bool {
+	if queryErr == nil {
+		return false
+	}
+
+	text := strings.ToLower(queryErr.Message + " " + queryErr.Details)
+	for _, patterns := range [][]string{failoverErrorPatterns, failoverErrorPatternsByType[failoverPatternType(dbType)]} {
+		for _, pattern := range patterns {
+			if strings.Contains(text, pattern) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// failoverPatternType returns the database type whose failover errors a type reports, e.g. MariaDB reports the MySQL ones
+func failoverPatternType(dbType string) string {
+	switch dbType {
+	case constants.DatabaseTypeYugabyteDB:
+		return constants.DatabaseTypePostgreSQL
+	case constants.DatabaseTypeMariaDB, constants.DatabaseTypeSingleStore:
+		return constants.DatabaseTypeMySQL
+	default:
+		return dbType
+	}
+}
+
+// canReplayAfterFailover returns true when a failed query can be executed again, only reads are replayed since a write
+// may have been applied before the connection was lost
+func canReplayAfterFailover(dbType, query string, isRollback bool, queryErr *dtos.QueryError) bool {
+	return !isRollback && IsReadOnlyQuery(dbType, query) && IsFailoverError(dbType, queryErr)
+}
+
+// replayAfterFailover reconnects to the new primary & executes the read again outside of a transaction, as reads on
+// replicas do. The user is told on the stream what happened whether the replay succeeded or not.
+func (m *Manager) replayAfterFailover(ctx context.Context, driver DatabaseDriver, conn, execConn *Connection, messageID, queryID, streamID, query, queryType string, findCount bool, queryErr *dtos.QueryError) (*QueryExecutionResult, *dtos.QueryError) {
+	notice := FailoverNotice{
+		MessageID: messageID,
+		QueryID:   queryID,
+		Error:     queryErr.Details,
+	}
+	if notice.Error == "" {
+		notice.Error = queryErr.Message
+	}
+
+	// A read served by a replica is replayed on the primary, the stale connections of the replica are dropped
+	if execConn != conn {
+		resetConnectionPool(execConn)
+	}
+
+	var result *QueryExecutionResult
+	for notice.Attempts < maxFailoverReplays {
+		notice.Attempts++
+		log.Printf("DBManager -> replayAfterFailover -> Query %s interrupted by a failover, reconnecting (attempt %d): %s", queryID, notice.Attempts, notice.Error)
+
+		if err := m.reconnectAfterFailover(ctx, driver, conn); err != nil {
+			log.Printf("DBManager -> replayAfterFailover -> Failed to reconnect for chatID %s: %v", conn.ChatID, err)
+			break
+		}
+		notice.Reconnected = true
+
+		result = driver.ExecuteQuery(ctx, conn, query, queryType, findCount)
+		if result.Error == nil {
+			notice.Replayed = true
+			break
+		}
+		queryErr = result.Error
+		if !IsFailoverError(conn.Config.Type, queryErr) {
+			break
+		}
+	}
+
+	switch {
+	case notice.Replayed:
+		notice.Message = "The database failed over while the query was running, it was executed again on the new primary"
+	case notice.Reconnected:
+		notice.Message = "The database failed over while the query was running, executing it again on the new primary failed"
+	default:
+		notice.Message = "The database failed over while the query was running, the new primary could not be reached yet"
+	}
+	m.sendFailoverNotice(conn, streamID, notice)
+
+	if !notice.Replayed {
+		return result, queryErr
+	}
+	return result, nil
+}
+
+// reconnectAfterFailover drops the pooled connections, which still point to the old primary, & waits for the new one to accept connections.
+// Managed databases move their endpoint to the new primary, the connections dialed afterwards reach it.
+func (m *Manager) reconnectAfterFailover(ctx context.Context, driver DatabaseDriver, conn *Connection) error {
+	resetConnectionPool(conn)
+
+	deadline := time.Now().Add(failoverReconnectTimeout)
+	for {
+		err := driver.Ping(conn)
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("new primary not reachable after %s: %v", failoverReconnectTimeout, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(failoverReconnectInterval):
+		}
+	}
+}
+
+// resetConnectionPool closes the idle connections of a SQL pool so the next queries dial the server again.
+// The MongoDB driver monitors the replica set & discovers the new primary by itself.
+func resetConnectionPool(conn *Connection) {
+	if conn.DB == nil {
+		return
+	}
+	if sqlDB, err := conn.DB.DB(); err == nil {
+		sqlDB.SetMaxIdleConns(0)
+		sqlDB.SetMaxIdleConns(defaultMaxIdleConns)
+	}
+}
+
+// sendFailoverNotice tells the user on the stream of the execution that the query was interrupted by a failover
+func (m *Manager) sendFailoverNotice(conn *Connection, streamID string, notice FailoverNotice) {
+	if m.streamHandler == nil || streamID == "" {
+		return
+	}
+	m.streamHandler.HandleDBEvent(conn.UserID, conn.ChatID, streamID, dtos.StreamResponse{
+		Event: FailoverEvent,
+		Data:  notice,
+	})
+}
@@ -0,0 +1,593 @@
+package dbmanager
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Firestore is reached through its REST API, wrapped as a database/sql driver so the connection shares the pool & lifecycle
+// handling of the SQL databases. Queries are executed natively through the client, see firestore_query.go.
+// See https://cloud.google.com/firestore/docs/reference/rest
+
+const (
+	firestoreDefaultHost     = "firestore.googleapis.com"
+	firestoreDefaultDatabase = "(default)"
+	firestoreOAuthScope      = "https://www.googleapis.com/auth/datastore"
+	firestoreTokenURL        = "https://oauth2.googleapis.com/token"
+	firestoreCommitBatchSize = 500 // Max writes per commit
+)
+
+// firestoreServiceAccount is the content of a service account key file
+type firestoreServiceAccount struct {
+	Type        string `json:"type"`
+	ProjectID   string `json:"project_id"`
+	PrivateKey  string `json:"private_key"`
+	ClientEmail string `json:"client_email"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// firestoreClient calls the Firestore REST API of a single database
+type firestoreClient struct {
+	baseURL        string // https://firestore.googleapis.com or http://<emulator-host>
+	projectID      string
+	databaseID     string
+	serviceAccount *firestoreServiceAccount // nil when connecting to the emulator
+	privateKey     *rsa.PrivateKey
+	httpClient     *http.Client
+
+	tokenMu     sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// databasePath returns projects/<project>/databases/<database>
+func (c *firestoreClient) databasePath() string {
+	return fmt.Sprintf("projects/%s/databases/%s", c.projectID, c.databaseID)
+}
+
+// documentsPath returns the root of the documents, collection & document paths are relative to it
+func (c *firestoreClient) documentsPath() string {
+	return c.databasePath() + "/documents"
+}
+
+// accessToken returns a cached OAuth token, a new one is requested with a signed JWT when it is about to expire
+func (c *firestoreClient) accessToken(ctx context.Context) (string, error) {
+	if c.serviceAccount == nil {
+		// The emulator accepts any token, "owner" bypasses the security rules
+		return "owner", nil
+	}
+
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	if c.token != "" && time.Now().Add(time.Minute).Before(c.tokenExpiry) {
+		return c.token, nil
+	}
+
+	tokenURL := c.serviceAccount.TokenURI
+	if tokenURL == "" {
+		tokenURL = firestoreTokenURL
+	}
+
+	assertion, err := c.signJWT(tokenURL)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	form.Set("assertion", assertion)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("firestore: failed to create token request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("firestore: token request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var tokenResp struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int    `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return "", fmt.Errorf("firestore: failed to decode token response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || tokenResp.AccessToken == "" {
+		return "", fmt.Errorf("firestore: failed to obtain access token: %s %s", tokenResp.Error, tokenResp.ErrorDescription)
+	}
+
+	c.token = tokenResp.AccessToken
+	c.tokenExpiry = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	return c.token, nil
+}
+
+// signJWT builds the RS256 signed assertion exchanged for an access token
+func (c *firestoreClient) signJWT(audience string) (string, error) {
+	now := time.Now()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   c.serviceAccount.ClientEmail,
+		"scope": firestoreOAuthScope,
+		"aud":   audience,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, c.privateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("firestore: failed to sign token assertion: %v", err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// do sends an authenticated request & decodes the JSON response into out
+func (c *firestoreClient) do(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("firestore: failed to encode request: %v", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+"/v1/"+path, reader)
+	if err != nil {
+		return fmt.Errorf("firestore: failed to create request: %v", err)
+	}
+
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("firestore: request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("firestore: failed to read response: %v", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// Streaming methods wrap the error in an array
+		var apiErr struct {
+			Error struct {
+				Status  string `json:"status"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		trimmed := bytes.TrimSpace(data)
+		if len(trimmed) > 0 && trimmed[0] == '[' {
+			var apiErrs []json.RawMessage
+			if json.Unmarshal(trimmed, &apiErrs) == nil && len(apiErrs) > 0 {
+				trimmed = apiErrs[0]
+			}
+		}
+		if json.Unmarshal(trimmed, &apiErr) == nil && apiErr.Error.Message != "" {
+			return fmt.Errorf("firestore: %s: %s", apiErr.Error.Status, apiErr.Error.Message)
+		}
+		return fmt.Errorf("firestore: unexpected status %s", resp.Status)
+	}
+
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(out); err != nil {
+		return fmt.Errorf("firestore: failed to decode response: %v", err)
+	}
+	return nil
+}
+
+// firestoreDocument is a document as returned by the REST API
+type firestoreDocument struct {
+	Name       string                            `json:"name"`
+	Fields     map[string]map[string]interface{} `json:"fields"`
+	CreateTime string                            `json:"createTime"`
+	UpdateTime string                            `json:"updateTime"`
+}
+
+// runQuery runs a structured query under the parent path (the documents root or a document for sub-collections)
+func (c *firestoreClient) runQuery(ctx context.Context, parent string, structuredQuery map[string]interface{}) ([]firestoreDocument, error) {
+	var resp []struct {
+		Document *firestoreDocument `json:"document"`
+	}
+	if err := c.do(ctx, http.MethodPost, parent+":runQuery", map[string]interface{}{"structuredQuery": structuredQuery}, &resp); err != nil {
+		return nil, err
+	}
+
+	documents := make([]firestoreDocument, 0, len(resp))
+	for _, item := range resp {
+		if item.Document != nil {
+			documents = append(documents, *item.Document)
+		}
+	}
+	return documents, nil
+}
+
+// runCount counts the documents matched by a structured query without reading them
+func (c *firestoreClient) runCount(ctx context.Context, parent string, structuredQuery map[string]interface{}) (int64, error) {
+	body := map[string]interface{}{
+		"structuredAggregationQuery": map[string]interface{}{
+			"structuredQuery": structuredQuery,
+			"aggregations": []interface{}{
+				map[string]interface{}{"alias": "count", "count": map[string]interface{}{}},
+			},
+		},
+	}
+
+	var resp []struct {
+		Result *struct {
+			AggregateFields map[string]map[string]interface{} `json:"aggregateFields"`
+		} `json:"result"`
+	}
+	if err := c.do(ctx, http.MethodPost, parent+":runAggregationQuery", body, &resp); err != nil {
+		return 0, err
+	}
+
+	for _, item := range resp {
+		if item.Result == nil {
+			continue
+		}
+		if count, ok := decodeFirestoreValue(item.Result.AggregateFields["count"]).(int64); ok {
+			return count, nil
+		}
+	}
+	return 0, nil
+}
+
+// listCollectionIDs lists the collections under the parent path
+func (c *firestoreClient) listCollectionIDs(ctx context.Context, parent string) ([]string, error) {
+	var collectionIDs []string
+	pageToken := ""
+	for {
+		body := map[string]interface{}{"pageSize": 300}
+		if pageToken != "" {
+			body["pageToken"] = pageToken
+		}
+
+		var resp struct {
+			CollectionIDs []string `json:"collectionIds"`
+			NextPageToken string   `json:"nextPageToken"`
+		}
+		if err := c.do(ctx, http.MethodPost, parent+":listCollectionIds", body, &resp); err != nil {
+			return nil, err
+		}
+
+		collectionIDs = append(collectionIDs, resp.CollectionIDs...)
+		if resp.NextPageToken == "" {
+			break
+		}
+		pageToken = resp.NextPageToken
+	}
+
+	sort.Strings(collectionIDs)
+	return collectionIDs, nil
+}
+
+// getDocument fetches a single document, nil is returned when it doesn't exist
+func (c *firestoreClient) getDocument(ctx context.Context, name string) (*firestoreDocument, error) {
+	var document firestoreDocument
+	if err := c.do(ctx, http.MethodGet, name, nil, &document); err != nil {
+		if strings.Contains(err.Error(), "NOT_FOUND") {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &document, nil
+}
+
+// commit applies the writes atomically, larger write sets are split into batches of 500
+func (c *firestoreClient) commit(ctx context.Context, writes []map[string]interface{}) error {
+	for start := 0; start < len(writes); start += firestoreCommitBatchSize {
+		end := start + firestoreCommitBatchSize
+		if end > len(writes) {
+			end = len(writes)
+		}
+		if err := c.do(ctx, http.MethodPost, c.documentsPath()+":commit", map[string]interface{}{"writes": writes[start:end]}, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newFirestoreDocumentID generates a random 20 characters ID like the client SDKs do
+func newFirestoreDocumentID() string {
+	const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+	id := make([]byte, 20)
+	for i := range id {
+		n, _ := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+		id[i] = alphabet[n.Int64()]
+	}
+	return string(id)
+}
+
+// decodeFirestoreValue converts a typed REST value into a plain Go value
+func decodeFirestoreValue(value map[string]interface{}) interface{} {
+	for kind, raw := range value {
+		switch kind {
+		case "nullValue":
+			return nil
+		case "booleanValue":
+			return raw
+		case "integerValue":
+			parsed, err := strconv.ParseInt(fmt.Sprint(raw), 10, 64)
+			if err != nil {
+				return fmt.Sprint(raw)
+			}
+			return parsed
+		case "doubleValue":
+			if number, ok := raw.(json.Number); ok {
+				if parsed, err := number.Float64(); err == nil {
+					return parsed
+				}
+			}
+			return fmt.Sprint(raw) // NaN & Infinity are sent as strings
+		case "timestampValue", "stringValue", "bytesValue":
+			return raw
+		case "referenceValue":
+			// Keep the path relative to the database, e.g. users/abc
+			reference := fmt.Sprint(raw)
+			if index := strings.Index(reference, "/documents/"); index >= 0 {
+				return reference[index+len("/documents/"):]
+			}
+			return reference
+		case "geoPointValue":
+			return raw
+		case "arrayValue":
+			items := []interface{}{}
+			if array, ok := raw.(map[string]interface{}); ok {
+				if values, ok := array["values"].([]interface{}); ok {
+					for _, item := range values {
+						if itemValue, ok := item.(map[string]interface{}); ok {
+							items = append(items, decodeFirestoreValue(itemValue))
+						}
+					}
+				}
+			}
+			return items
+		case "mapValue":
+			fields := map[string]interface{}{}
+			if mapValue, ok := raw.(map[string]interface{}); ok {
+				if mapFields, ok := mapValue["fields"].(map[string]interface{}); ok {
+					for key, item := range mapFields {
+						if itemValue, ok := item.(map[string]interface{}); ok {
+							fields[key] = decodeFirestoreValue(itemValue)
+						}
+					}
+				}
+			}
+			return fields
+		}
+	}
+	return nil
+}
+
+// firestoreValueType returns the Firestore type name of a typed REST value, used for schema inference
+func firestoreValueType(value map[string]interface{}) string {
+	for kind := range value {
+		switch kind {
+		case "nullValue":
+			return "null"
+		case "booleanValue":
+			return "boolean"
+		case "integerValue":
+			return "integer"
+		case "doubleValue":
+			return "double"
+		case "timestampValue":
+			return "timestamp"
+		case "stringValue":
+			return "string"
+		case "bytesValue":
+			return "bytes"
+		case "referenceValue":
+			return "reference"
+		case "geoPointValue":
+			return "geopoint"
+		case "arrayValue":
+			return "array"
+		case "mapValue":
+			return "map"
+		}
+	}
+	return "unknown"
+}
+
+// encodeFirestoreValue converts a Go value parsed from a query into a typed REST value
+func encodeFirestoreValue(value interface{}) map[string]interface{} {
+	switch v := value.(type) {
+	case nil:
+		return map[string]interface{}{"nullValue": nil}
+	case bool:
+		return map[string]interface{}{"booleanValue": v}
+	case int:
+		return map[string]interface{}{"integerValue": strconv.Itoa(v)}
+	case int64:
+		return map[string]interface{}{"integerValue": strconv.FormatInt(v, 10)}
+	case float64:
+		return map[string]interface{}{"doubleValue": v}
+	case string:
+		return map[string]interface{}{"stringValue": v}
+	case time.Time:
+		return map[string]interface{}{"timestampValue": v.UTC().Format(time.RFC3339Nano)}
+	case firestoreReference:
+		return map[string]interface{}{"referenceValue": string(v)}
+	case firestoreGeoPoint:
+		return map[string]interface{}{"geoPointValue": map[string]interface{}{"latitude": v.Latitude, "longitude": v.Longitude}}
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i, item := range v {
+			values[i] = encodeFirestoreValue(item)
+		}
+		return map[string]interface{}{"arrayValue": map[string]interface{}{"values": values}}
+	case map[string]interface{}:
+		return map[string]interface{}{"mapValue": map[string]interface{}{"fields": encodeFirestoreFields(v)}}
+	default:
+		return map[string]interface{}{"stringValue": fmt.Sprint(v)}
+	}
+}
+
+// encodeFirestoreFields converts a Go map into the fields of a document
+func encodeFirestoreFields(values map[string]interface{}) map[string]interface{} {
+	fields := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		fields[key] = encodeFirestoreValue(value)
+	}
+	return fields
+}
+
+// firestoreReference is a document reference, the full resource name
+type firestoreReference string
+
+// firestoreGeoPoint is a geographical point
+type firestoreGeoPoint struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// parseFirestoreServiceAccount parses a service account key file & its RSA private key
+func parseFirestoreServiceAccount(credentials string) (*firestoreServiceAccount, *rsa.PrivateKey, error) {
+	var account firestoreServiceAccount
+	if err := json.Unmarshal([]byte(credentials), &account); err != nil {
+		return nil, nil, fmt.Errorf("invalid service account credentials: %v", err)
+	}
+	if account.ClientEmail == "" || account.PrivateKey == "" {
+		return nil, nil, fmt.Errorf("service account credentials must contain client_email & private_key")
+	}
+
+	block, _ := pem.Decode([]byte(account.PrivateKey))
+	if block == nil {
+		return nil, nil, fmt.Errorf("invalid service account private key")
+	}
+
+	var privateKey *rsa.PrivateKey
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, nil, fmt.Errorf("service account private key is not an RSA key")
+		}
+		privateKey = rsaKey
+	} else if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		privateKey = key
+	} else {
+		return nil, nil, fmt.Errorf("failed to parse service account private key: %v", err)
+	}
+
+	return &account, privateKey, nil
+}
+
+// database/sql adapter, only used for the connection lifecycle (pool, ping, close). The Firestore driver, transaction
+// & schema fetcher reach the client through withFirestoreClient.
+
+// firestoreConnector implements driver.Connector for a Firestore database
+type firestoreConnector struct {
+	client *firestoreClient
+}
+
+func (c *firestoreConnector) Connect(_ context.Context) (driver.Conn, error) {
+	return &firestoreConn{client: c.client}, nil
+}
+
+func (c *firestoreConnector) Driver() driver.Driver {
+	return firestoreSQLDriver{}
+}
+
+// firestoreSQLDriver only exists to satisfy driver.Connector, connections are always opened through the connector
+type firestoreSQLDriver struct{}
+
+func (firestoreSQLDriver) Open(_ string) (driver.Conn, error) {
+	return nil, fmt.Errorf("firestore: open the connection with sql.OpenDB and a connector")
+}
+
+// firestoreConn is a stateless connection, every operation is an independent API call
+type firestoreConn struct {
+	client *firestoreClient
+}
+
+func (c *firestoreConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("firestore: prepared statements are not supported, queries are executed by the Firestore driver")
+}
+
+func (c *firestoreConn) Close() error {
+	return nil
+}
+
+// Begin returns a no-op transaction, every Firestore write request is committed atomically on its own
+func (c *firestoreConn) Begin() (driver.Tx, error) {
+	return firestoreTx{}, nil
+}
+
+func (c *firestoreConn) BeginTx(_ context.Context, _ driver.TxOptions) (driver.Tx, error) {
+	return firestoreTx{}, nil
+}
+
+// Ping lists the root collections, it fails on invalid credentials or a missing database
+func (c *firestoreConn) Ping(ctx context.Context) error {
+	return c.client.do(ctx, http.MethodPost, c.client.documentsPath()+":listCollectionIds", map[string]interface{}{"pageSize": 1}, nil)
+}
+
+type firestoreTx struct{}
+
+func (firestoreTx) Commit() error   { return nil }
+func (firestoreTx) Rollback() error { return nil }
+
+// withFirestoreClient runs fn with the Firestore client behind a database/sql pool
+func withFirestoreClient(ctx context.Context, sqlDB *sql.DB, fn func(client *firestoreClient) error) error {
+	if sqlDB == nil {
+		return fmt.Errorf("no active Firestore connection")
+	}
+
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn interface{}) error {
+		firestoreConn, ok := driverConn.(*firestoreConn)
+		if !ok {
+			return fmt.Errorf("not a Firestore connection")
+		}
+		return fn(firestoreConn.client)
+	})
+}
@@ -0,0 +1,292 @@
+package dbmanager
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// FirestoreDriver implements the DatabaseDriver interface for Google Cloud Firestore (native mode).
+// The database is "<project-id>" or "<project-id>/<database-id>", the database ID defaults to "(default)".
+// Without credentials the host is expected to be a Firestore emulator.
+type FirestoreDriver struct{}
+
+// NewFirestoreDriver creates a new Firestore driver
+func NewFirestoreDriver() DatabaseDriver {
+	return &FirestoreDriver{}
+}
+
+// Connect establishes a connection to a Firestore database
+func (d *FirestoreDriver) Connect(config ConnectionConfig) (*Connection, error) {
+	client, err := newFirestoreClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Open connection
+	db := sql.OpenDB(&firestoreConnector{client: client})
+
+	// Test connection
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// Configure connection pool, connections are stateless HTTP calls
+	db.SetMaxOpenConns(10)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(time.Hour)
+
+	// Create GORM DB, it is only used for the connection lifecycle as queries are executed through the Firestore client
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create GORM connection: %v", err)
+	}
+
+	log.Printf("FirestoreDriver -> Connect -> Connected to Firestore database %s", client.databasePath())
+
+	return &Connection{
+		DB:          gormDB,
+		LastUsed:    time.Now(),
+		Status:      StatusConnected,
+		Config:      config,
+		Subscribers: make(map[string]bool),
+		SubLock:     sync.RWMutex{},
+	}, nil
+}
+
+// Disconnect closes a Firestore connection
+func (d *FirestoreDriver) Disconnect(conn *Connection) error {
+	// Get the underlying SQL DB
+	sqlDB, err := conn.DB.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get SQL DB: %v", err)
+	}
+
+	// Close the connection
+	if err := sqlDB.Close(); err != nil {
+		return fmt.Errorf("failed to close connection: %v", err)
+	}
+
+	return nil
+}
+
+// Ping checks if the Firestore connection is alive
+func (d *FirestoreDriver) Ping(conn *Connection) error {
+	if conn == nil || conn.DB == nil {
+		return fmt.Errorf("no active connection to ping")
+	}
+
+	sqlDB, err := conn.DB.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %v", err)
+	}
+
+	return sqlDB.Ping()
+}
+
+// IsAlive checks if the Firestore connection is still valid
+func (d *FirestoreDriver) IsAlive(conn *Connection) bool {
+	return d.Ping(conn) == nil
+}
+
+// ExecuteQuery executes a Firestore query
+func (d *FirestoreDriver) ExecuteQuery(ctx context.Context, conn *Connection, query string, queryType string, findCount bool) *QueryExecutionResult {
+	if conn == nil || conn.DB == nil {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Message: "No active connection",
+				Code:    "CONNECTION_ERROR",
+			},
+		}
+	}
+
+	return executeFirestoreStatements(ctx, conn, query)
+}
+
+// BeginTx starts a new transaction, every Firestore write request is committed on its own so the transaction only groups the execution
+func (d *FirestoreDriver) BeginTx(ctx context.Context, conn *Connection) Transaction {
+	if conn == nil || conn.DB == nil {
+		log.Printf("FirestoreDriver.BeginTx: Connection or DB is nil")
+		return nil
+	}
+
+	return &FirestoreTransaction{conn: conn}
+}
+
+// GetSchema retrieves the collections & the fields inferred from sampled documents
+func (d *FirestoreDriver) GetSchema(ctx context.Context, db DBExecutor, selectedTables []string) (*SchemaInfo, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("FirestoreDriver -> GetSchema -> Context cancelled: %v", err)
+		return nil, err
+	}
+
+	fetcher := NewFirestoreSchemaFetcher(db)
+	return fetcher.GetSchema(ctx, db, selectedTables)
+}
+
+// GetTableChecksum calculates a checksum for a collection
+func (d *FirestoreDriver) GetTableChecksum(ctx context.Context, db DBExecutor, table string) (string, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("FirestoreDriver -> GetTableChecksum -> Context cancelled: %v", err)
+		return "", err
+	}
+
+	fetcher := NewFirestoreSchemaFetcher(db)
+	return fetcher.GetTableChecksum(ctx, db, table)
+}
+
+// FetchExampleRecords fetches example documents from a collection
+func (d *FirestoreDriver) FetchExampleRecords(ctx context.Context, db DBExecutor, table string, limit int) ([]map[string]interface{}, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("FirestoreDriver -> FetchExampleRecords -> Context cancelled: %v", err)
+		return nil, err
+	}
+
+	fetcher := NewFirestoreSchemaFetcher(db)
+	return fetcher.FetchExampleRecords(ctx, db, table, limit)
+}
+
+// executeFirestoreStatements runs a query through the client of the connection & wraps the result
+func executeFirestoreStatements(ctx context.Context, conn *Connection, query string) *QueryExecutionResult {
+	startTime := time.Now()
+
+	sqlDB, err := conn.DB.DB()
+	if err != nil {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Message: err.Error(),
+				Code:    "CONNECTION_ERROR",
+			},
+		}
+	}
+
+	var result map[string]interface{}
+	err = withFirestoreClient(ctx, sqlDB, func(client *firestoreClient) error {
+		var execErr error
+		result, execErr = executeFirestoreQuery(ctx, client, query)
+		return execErr
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return &QueryExecutionResult{
+				Error: &dtos.QueryError{
+					Message: "Query execution cancelled",
+					Code:    "EXECUTION_CANCELLED",
+				},
+			}
+		}
+		return &QueryExecutionResult{
+			ExecutionTime: int(time.Since(startTime).Milliseconds()),
+			Error: &dtos.QueryError{
+				Message: err.Error(),
+				Code:    "EXECUTION_ERROR",
+			},
+		}
+	}
+
+	// Marshal the result to JSON
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return &QueryExecutionResult{
+			ExecutionTime: int(time.Since(startTime).Milliseconds()),
+			Error: &dtos.QueryError{
+				Message: fmt.Sprintf("Failed to marshal result: %v", err),
+				Code:    "RESULT_ERROR",
+			},
+		}
+	}
+
+	return &QueryExecutionResult{
+		Result:        result,
+		ResultJSON:    string(resultJSON),
+		ExecutionTime: int(time.Since(startTime).Milliseconds()),
+	}
+}
+
+// newFirestoreClient validates the connection config & builds the REST client
+func newFirestoreClient(config ConnectionConfig) (*firestoreClient, error) {
+	projectID, databaseID := splitFirestoreDatabase(config.Database)
+
+	httpClient, err := proxyHTTPClient(config, 2*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &firestoreClient{
+		projectID:  projectID,
+		databaseID: databaseID,
+		httpClient: httpClient,
+	}
+
+	host := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(config.Host), "https://"), "http://"), "/")
+	if host == "" {
+		host = firestoreDefaultHost
+	}
+
+	if config.CredentialsJSON != nil && strings.TrimSpace(*config.CredentialsJSON) != "" {
+		account, privateKey, err := parseFirestoreServiceAccount(*config.CredentialsJSON)
+		if err != nil {
+			return nil, err
+		}
+		client.serviceAccount = account
+		client.privateKey = privateKey
+		if client.projectID == "" {
+			client.projectID = account.ProjectID
+		}
+
+		client.baseURL = "https://" + host
+		if config.Port != nil && *config.Port != "" && *config.Port != "443" {
+			client.baseURL += ":" + *config.Port
+		}
+	} else {
+		// Without credentials only the emulator can be reached
+		if host == firestoreDefaultHost {
+			return nil, fmt.Errorf("service account credentials are required for Firestore connections")
+		}
+		scheme := "http://"
+		if config.UseSSL {
+			scheme = "https://"
+		}
+		client.baseURL = scheme + host
+		if config.Port != nil && *config.Port != "" {
+			client.baseURL += ":" + *config.Port
+		}
+	}
+
+	if client.projectID == "" {
+		return nil, fmt.Errorf("project ID is required for Firestore connections")
+	}
+	return client, nil
+}
+
+// splitFirestoreDatabase splits "<project-id>/<database-id>" into its parts, the database ID defaults to "(default)"
+func splitFirestoreDatabase(database string) (string, string) {
+	database = strings.Trim(strings.TrimSpace(database), "/")
+	// Full resource names are accepted as well, e.g. projects/<project-id>/databases/<database-id>
+	if strings.HasPrefix(database, "projects/") {
+		database = strings.Replace(strings.TrimPrefix(database, "projects/"), "/databases/", "/", 1)
+	}
+	if projectID, databaseID, found := strings.Cut(database, "/"); found && databaseID != "" {
+		return projectID, databaseID
+	}
+	return database, firestoreDefaultDatabase
+}
@@ -0,0 +1,1016 @@
+package dbmanager
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Firestore queries are written with the chained syntax of the client SDKs & translated into structured queries, e.g.
+//   db.collection("users").where("age", ">=", 21).orderBy("age", "desc").limit(10).get()
+//   db.collection("users").where("status", "==", "active").count().get()
+//   db.collection("users").doc("abc").update({"address.city": "Paris"})
+//   db.collectionGroup("orders").where("total", ">", 100).get()
+//   db.runQuery({"from": [{"collectionId": "users"}], "limit": 5})
+
+// firestoreOperation is a parsed Firestore query
+type firestoreOperation struct {
+	action          string // get, count, add, set, update, delete, listCollections, runQuery
+	collectionPath  string // Path of the target collection relative to the documents root, e.g. users/abc/orders
+	documentPath    string // Path of the target document relative to the documents root, e.g. users/abc
+	collectionGroup bool   // Query every collection with the collection ID, at any depth
+	filters         []interface{}
+	orderBy         []interface{}
+	selectFields    []string
+	limit           *int64
+	offset          *int64
+	startAt         map[string]interface{}
+	endAt           map[string]interface{}
+	args            []interface{} // Arguments of the terminal method
+}
+
+// firestoreDocumentIDField stands for FieldPath.documentId(), the document name in filters & ordering
+const firestoreDocumentIDField = "__name__"
+
+// firestoreDeleteField stands for FieldValue.delete() in updates
+type firestoreDeleteField struct{}
+
+var (
+	firestoreSimpleFieldRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z_0-9]*$`)
+	firestoreOperators        = map[string]string{
+		"<":                  "LESS_THAN",
+		"<=":                 "LESS_THAN_OR_EQUAL",
+		">":                  "GREATER_THAN",
+		">=":                 "GREATER_THAN_OR_EQUAL",
+		"==":                 "EQUAL",
+		"!=":                 "NOT_EQUAL",
+		"array-contains":     "ARRAY_CONTAINS",
+		"array-contains-any": "ARRAY_CONTAINS_ANY",
+		"in":                 "IN",
+		"not-in":             "NOT_IN",
+	}
+)
+
+// executeFirestoreQuery executes every statement of a query, the result of the last one is returned
+func executeFirestoreQuery(ctx context.Context, client *firestoreClient, query string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	for _, stmt := range splitMySQLStatements(query) {
+		if strings.TrimSpace(stmt) == "" {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		operation, err := parseFirestoreQuery(stmt)
+		if err != nil {
+			return nil, err
+		}
+		result, err = operation.execute(ctx, client)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if result == nil {
+		return nil, fmt.Errorf("empty query")
+	}
+	return result, nil
+}
+
+// execute runs the operation against the database
+func (o *firestoreOperation) execute(ctx context.Context, client *firestoreClient) (map[string]interface{}, error) {
+	root := client.documentsPath()
+
+	switch o.action {
+	case "listCollections":
+		parent := root
+		if o.documentPath != "" {
+			parent = root + "/" + o.documentPath
+		}
+		collectionIDs, err := client.listCollectionIDs(ctx, parent)
+		if err != nil {
+			return nil, err
+		}
+		results := make([]interface{}, len(collectionIDs))
+		for i, id := range collectionIDs {
+			results[i] = map[string]interface{}{"collection": id}
+		}
+		return map[string]interface{}{"results": results}, nil
+
+	case "runQuery":
+		if len(o.args) == 0 {
+			return nil, fmt.Errorf("runQuery() expects a structured query")
+		}
+		structuredQuery, ok := o.args[0].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("runQuery() expects a structured query object")
+		}
+		// The structured query is sent as is, values must use the typed REST representation (e.g. {"integerValue": "21"})
+		parent := root
+		if len(o.args) > 1 {
+			if parentPath, ok := o.args[1].(string); ok && parentPath != "" {
+				parent = root + "/" + strings.Trim(parentPath, "/")
+			}
+		}
+		documents, err := client.runQuery(ctx, parent, structuredQuery)
+		if err != nil {
+			return nil, err
+		}
+		return firestoreDocumentsResult(documents), nil
+
+	case "get":
+		if o.documentPath != "" {
+			document, err := client.getDocument(ctx, root+"/"+o.documentPath)
+			if err != nil {
+				return nil, err
+			}
+			documents := []firestoreDocument{}
+			if document != nil {
+				documents = append(documents, *document)
+			}
+			return firestoreDocumentsResult(documents), nil
+		}
+		parent, structuredQuery, err := o.structuredQuery(root)
+		if err != nil {
+			return nil, err
+		}
+		documents, err := client.runQuery(ctx, parent, structuredQuery)
+		if err != nil {
+			return nil, err
+		}
+		return firestoreDocumentsResult(documents), nil
+
+	case "count":
+		parent, structuredQuery, err := o.structuredQuery(root)
+		if err != nil {
+			return nil, err
+		}
+		count, err := client.runCount(ctx, parent, structuredQuery)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"count": count}, nil
+
+	case "add":
+		if o.collectionPath == "" || o.collectionGroup {
+			return nil, fmt.Errorf("add() must be called on a collection")
+		}
+		data, err := o.dataArgument()
+		if err != nil {
+			return nil, err
+		}
+		id := newFirestoreDocumentID()
+		write := map[string]interface{}{
+			"update":          map[string]interface{}{"name": root + "/" + o.collectionPath + "/" + id, "fields": encodeFirestoreFields(data)},
+			"currentDocument": map[string]interface{}{"exists": false},
+		}
+		if err := client.commit(ctx, []map[string]interface{}{write}); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"insertedId":   id,
+			"rowsAffected": int64(1),
+			"message":      fmt.Sprintf("Document %s/%s added", o.collectionPath, id),
+		}, nil
+
+	case "set":
+		if o.documentPath == "" {
+			return nil, fmt.Errorf("set() must be called on a document")
+		}
+		data, err := o.dataArgument()
+		if err != nil {
+			return nil, err
+		}
+		write := map[string]interface{}{
+			"update": map[string]interface{}{"name": root + "/" + o.documentPath, "fields": encodeFirestoreFields(data)},
+		}
+		// set(data, {merge: true}) only replaces the given fields
+		if len(o.args) > 1 {
+			if options, ok := o.args[1].(map[string]interface{}); ok && options["merge"] == true {
+				fieldPaths := make([]interface{}, 0, len(data))
+				for key := range data {
+					fieldPaths = append(fieldPaths, quoteFirestoreFieldPath(key))
+				}
+				write["updateMask"] = map[string]interface{}{"fieldPaths": fieldPaths}
+			}
+		}
+		if err := client.commit(ctx, []map[string]interface{}{write}); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"rowsAffected": int64(1), "message": fmt.Sprintf("Document %s written", o.documentPath)}, nil
+
+	case "update":
+		fields, mask, err := o.updateArguments()
+		if err != nil {
+			return nil, err
+		}
+		names, err := o.targetDocumentNames(ctx, client)
+		if err != nil {
+			return nil, err
+		}
+		writes := make([]map[string]interface{}, len(names))
+		for i, name := range names {
+			writes[i] = map[string]interface{}{
+				"update":          map[string]interface{}{"name": name, "fields": fields},
+				"updateMask":      map[string]interface{}{"fieldPaths": mask},
+				"currentDocument": map[string]interface{}{"exists": true},
+			}
+		}
+		if err := client.commit(ctx, writes); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"rowsAffected": int64(len(names)), "message": fmt.Sprintf("%d document(s) updated", len(names))}, nil
+
+	case "delete":
+		names, err := o.targetDocumentNames(ctx, client)
+		if err != nil {
+			return nil, err
+		}
+		writes := make([]map[string]interface{}, len(names))
+		for i, name := range names {
+			writes[i] = map[string]interface{}{"delete": name}
+		}
+		if err := client.commit(ctx, writes); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"rowsAffected": int64(len(names)), "message": fmt.Sprintf("%d document(s) deleted", len(names))}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported Firestore operation: %s", o.action)
+}
+
+// targetDocumentNames returns the document targeted by an update or delete, or every document matched by the query
+func (o *firestoreOperation) targetDocumentNames(ctx context.Context, client *firestoreClient) ([]string, error) {
+	root := client.documentsPath()
+	if o.documentPath != "" {
+		return []string{root + "/" + o.documentPath}, nil
+	}
+
+	parent, structuredQuery, err := o.structuredQuery(root)
+	if err != nil {
+		return nil, err
+	}
+	// Only the document names are needed
+	structuredQuery["select"] = map[string]interface{}{
+		"fields": []interface{}{map[string]interface{}{"fieldPath": firestoreDocumentIDField}},
+	}
+	documents, err := client.runQuery(ctx, parent, structuredQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(documents))
+	for i, document := range documents {
+		names[i] = document.Name
+	}
+	return names, nil
+}
+
+// structuredQuery builds the REST structured query & its parent path
+func (o *firestoreOperation) structuredQuery(root string) (string, map[string]interface{}, error) {
+	if o.collectionPath == "" {
+		return "", nil, fmt.Errorf("%s() must be called on a collection or a query", o.action)
+	}
+
+	parent := root
+	collectionID := o.collectionPath
+	if index := strings.LastIndex(o.collectionPath, "/"); index >= 0 {
+		parent = root + "/" + o.collectionPath[:index]
+		collectionID = o.collectionPath[index+1:]
+	}
+
+	from := map[string]interface{}{"collectionId": collectionID}
+	if o.collectionGroup {
+		from["allDescendants"] = true
+	}
+	structuredQuery := map[string]interface{}{"from": []interface{}{from}}
+
+	// Document IDs in filters are converted into references of the queried collection
+	for _, filter := range o.filters {
+		o.resolveDocumentIDs(root, filter)
+	}
+	switch len(o.filters) {
+	case 0:
+	case 1:
+		structuredQuery["where"] = o.filters[0]
+	default:
+		structuredQuery["where"] = map[string]interface{}{
+			"compositeFilter": map[string]interface{}{"op": "AND", "filters": o.filters},
+		}
+	}
+
+	if len(o.orderBy) > 0 {
+		structuredQuery["orderBy"] = o.orderBy
+	}
+	if len(o.selectFields) > 0 {
+		fields := make([]interface{}, len(o.selectFields))
+		for i, field := range o.selectFields {
+			fields[i] = map[string]interface{}{"fieldPath": quoteFirestoreFieldPath(field)}
+		}
+		structuredQuery["select"] = map[string]interface{}{"fields": fields}
+	}
+	if o.startAt != nil {
+		structuredQuery["startAt"] = o.startAt
+	}
+	if o.endAt != nil {
+		structuredQuery["endAt"] = o.endAt
+	}
+	if o.offset != nil {
+		structuredQuery["offset"] = *o.offset
+	}
+	if o.limit != nil {
+		structuredQuery["limit"] = *o.limit
+	}
+	return parent, structuredQuery, nil
+}
+
+// resolveDocumentIDs converts plain document IDs compared with FieldPath.documentId() into references
+func (o *firestoreOperation) resolveDocumentIDs(root string, filter interface{}) {
+	fieldFilter, ok := filter.(map[string]interface{})["fieldFilter"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	if field, _ := fieldFilter["field"].(map[string]interface{}); field["fieldPath"] != firestoreDocumentIDField {
+		return
+	}
+
+	toReference := func(value map[string]interface{}) map[string]interface{} {
+		id, ok := value["stringValue"].(string)
+		if !ok {
+			return value
+		}
+		if !strings.Contains(id, "/") && !o.collectionGroup {
+			id = o.collectionPath + "/" + id
+		}
+		return map[string]interface{}{"referenceValue": root + "/" + strings.Trim(id, "/")}
+	}
+
+	value, _ := fieldFilter["value"].(map[string]interface{})
+	if array, ok := value["arrayValue"].(map[string]interface{}); ok {
+		values, _ := array["values"].([]interface{})
+		for i, item := range values {
+			if itemValue, ok := item.(map[string]interface{}); ok {
+				values[i] = toReference(itemValue)
+			}
+		}
+		return
+	}
+	fieldFilter["value"] = toReference(value)
+}
+
+// dataArgument returns the document data passed to add() or set()
+func (o *firestoreOperation) dataArgument() (map[string]interface{}, error) {
+	if len(o.args) == 0 {
+		return nil, fmt.Errorf("%s() expects the document data", o.action)
+	}
+	data, ok := o.args[0].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s() expects an object", o.action)
+	}
+	return data, nil
+}
+
+// updateArguments returns the fields & update mask of update({...}) or update("field", value, ...), dotted keys update nested fields
+func (o *firestoreOperation) updateArguments() (map[string]interface{}, []interface{}, error) {
+	updates := map[string]interface{}{}
+	switch {
+	case len(o.args) == 1:
+		data, ok := o.args[0].(map[string]interface{})
+		if !ok {
+			return nil, nil, fmt.Errorf("update() expects an object")
+		}
+		updates = data
+	case len(o.args) > 1 && len(o.args)%2 == 0:
+		for i := 0; i < len(o.args); i += 2 {
+			field, ok := o.args[i].(string)
+			if !ok {
+				return nil, nil, fmt.Errorf("update() expects field & value pairs")
+			}
+			updates[field] = o.args[i+1]
+		}
+	default:
+		return nil, nil, fmt.Errorf("update() expects an object or field & value pairs")
+	}
+	if len(updates) == 0 {
+		return nil, nil, fmt.Errorf("update() expects at least one field")
+	}
+
+	nested := map[string]interface{}{}
+	mask := make([]interface{}, 0, len(updates))
+	for key, value := range updates {
+		segments := strings.Split(key, ".")
+		quoted := make([]string, len(segments))
+		for i, segment := range segments {
+			quoted[i] = quoteFirestoreFieldSegment(segment)
+		}
+		mask = append(mask, strings.Join(quoted, "."))
+
+		// Deleted fields are only part of the mask
+		if _, isDelete := value.(firestoreDeleteField); isDelete {
+			continue
+		}
+		current := nested
+		for _, segment := range segments[:len(segments)-1] {
+			child, ok := current[segment].(map[string]interface{})
+			if !ok {
+				child = map[string]interface{}{}
+				current[segment] = child
+			}
+			current = child
+		}
+		current[segments[len(segments)-1]] = value
+	}
+	return encodeFirestoreFields(nested), mask, nil
+}
+
+// firestoreDocumentsResult converts documents into result rows, "_id" & "_path" identify the document
+func firestoreDocumentsResult(documents []firestoreDocument) map[string]interface{} {
+	results := make([]interface{}, len(documents))
+	for i, document := range documents {
+		results[i] = firestoreDocumentToRow(document)
+	}
+	return map[string]interface{}{"results": results}
+}
+
+// firestoreDocumentToRow decodes the fields of a document
+func firestoreDocumentToRow(document firestoreDocument) map[string]interface{} {
+	row := make(map[string]interface{}, len(document.Fields)+2)
+	path := document.Name
+	if index := strings.Index(path, "/documents/"); index >= 0 {
+		path = path[index+len("/documents/"):]
+	}
+	row["_id"] = path[strings.LastIndex(path, "/")+1:]
+	row["_path"] = path
+	for key, value := range document.Fields {
+		row[key] = decodeFirestoreValue(value)
+	}
+	return row
+}
+
+// quoteFirestoreFieldPath quotes the segments of a dotted field path that aren't simple identifiers
+func quoteFirestoreFieldPath(field string) string {
+	if field == firestoreDocumentIDField {
+		return field
+	}
+	segments := strings.Split(field, ".")
+	for i, segment := range segments {
+		segments[i] = quoteFirestoreFieldSegment(segment)
+	}
+	return strings.Join(segments, ".")
+}
+
+// quoteFirestoreFieldSegment quotes a single field name with backticks when needed
+func quoteFirestoreFieldSegment(segment string) string {
+	if firestoreSimpleFieldRegex.MatchString(segment) {
+		return segment
+	}
+	return "`" + strings.NewReplacer("\\", "\\\\", "`", "\\`").Replace(segment) + "`"
+}
+
+// Parser of the chained query syntax
+
+type firestoreQueryParser struct {
+	input string
+	pos   int
+}
+
+// parseFirestoreQuery parses a db.<method>(...).<method>(...) chain
+func parseFirestoreQuery(query string) (*firestoreOperation, error) {
+	p := &firestoreQueryParser{input: strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(query), ";"))}
+
+	if p.parseIdentifier() != "db" {
+		return nil, fmt.Errorf("Firestore queries must start with db, e.g. db.collection(\"users\").get()")
+	}
+
+	operation := &firestoreOperation{}
+	for {
+		p.skipSpaces()
+		if p.pos >= len(p.input) {
+			break
+		}
+		if !p.consume('.') {
+			return nil, p.errorf("expected '.'")
+		}
+		method := p.parseIdentifier()
+		if method == "" {
+			return nil, p.errorf("expected a method name")
+		}
+		args, err := p.parseArguments()
+		if err != nil {
+			return nil, err
+		}
+		if err := operation.apply(method, args); err != nil {
+			return nil, err
+		}
+	}
+
+	if operation.action == "" {
+		// A query without terminal method reads the documents
+		operation.action = "get"
+	}
+	if operation.collectionPath == "" && operation.documentPath == "" && operation.action != "listCollections" && operation.action != "runQuery" {
+		return nil, fmt.Errorf("the query must target a collection or a document")
+	}
+	return operation, nil
+}
+
+// apply adds a chained method to the operation
+func (o *firestoreOperation) apply(method string, args []interface{}) error {
+	if o.action != "" && !(o.action == "count" && method == "get") {
+		return fmt.Errorf("%s() can't be chained after %s()", method, o.action)
+	}
+
+	switch method {
+	case "collection":
+		path, err := firestorePathArgument(method, args)
+		if err != nil {
+			return err
+		}
+		switch {
+		case o.documentPath != "":
+			path = o.documentPath + "/" + path
+		case o.collectionPath != "":
+			return fmt.Errorf("collection() must be called on db or a document")
+		}
+		if strings.Count(path, "/")%2 != 0 {
+			return fmt.Errorf("%q is a document path, use doc()", path)
+		}
+		o.collectionPath, o.documentPath = path, ""
+
+	case "collectionGroup":
+		path, err := firestorePathArgument(method, args)
+		if err != nil {
+			return err
+		}
+		if o.collectionPath != "" || o.documentPath != "" || strings.Contains(path, "/") {
+			return fmt.Errorf("collectionGroup() must be called on db with a collection ID")
+		}
+		o.collectionPath, o.collectionGroup = path, true
+
+	case "doc":
+		var path string
+		if len(args) == 0 {
+			path = newFirestoreDocumentID()
+		} else {
+			var err error
+			if path, err = firestorePathArgument(method, args); err != nil {
+				return err
+			}
+		}
+		if o.collectionGroup || o.documentPath != "" {
+			return fmt.Errorf("doc() must be called on db or a collection")
+		}
+		if o.collectionPath != "" {
+			path = o.collectionPath + "/" + path
+		}
+		if strings.Count(path, "/")%2 != 1 {
+			return fmt.Errorf("%q is not a document path", path)
+		}
+		o.documentPath, o.collectionPath = path, ""
+
+	case "where":
+		if len(args) != 3 {
+			return fmt.Errorf("where() expects a field, an operator & a value")
+		}
+		field, ok := args[0].(string)
+		operator, okOperator := args[1].(string)
+		if !ok || !okOperator {
+			return fmt.Errorf("where() expects a field name & an operator")
+		}
+		filter, err := firestoreFieldFilter(field, operator, args[2])
+		if err != nil {
+			return err
+		}
+		o.filters = append(o.filters, filter)
+
+	case "orderBy":
+		if len(args) == 0 || len(args) > 2 {
+			return fmt.Errorf("orderBy() expects a field & an optional direction")
+		}
+		field, ok := args[0].(string)
+		if !ok {
+			return fmt.Errorf("orderBy() expects a field name")
+		}
+		direction := "ASCENDING"
+		if len(args) == 2 {
+			if dir, _ := args[1].(string); strings.EqualFold(dir, "desc") || strings.EqualFold(dir, "descending") {
+				direction = "DESCENDING"
+			}
+		}
+		o.orderBy = append(o.orderBy, map[string]interface{}{
+			"field":     map[string]interface{}{"fieldPath": quoteFirestoreFieldPath(field)},
+			"direction": direction,
+		})
+
+	case "limit", "offset":
+		if len(args) != 1 {
+			return fmt.Errorf("%s() expects a number", method)
+		}
+		value, ok := args[0].(int64)
+		if !ok || value < 0 {
+			return fmt.Errorf("%s() expects a positive integer", method)
+		}
+		if method == "limit" {
+			o.limit = &value
+		} else {
+			o.offset = &value
+		}
+
+	case "select":
+		for _, arg := range args {
+			switch v := arg.(type) {
+			case string:
+				o.selectFields = append(o.selectFields, v)
+			case []interface{}:
+				for _, item := range v {
+					if field, ok := item.(string); ok {
+						o.selectFields = append(o.selectFields, field)
+					}
+				}
+			}
+		}
+
+	case "startAt", "startAfter", "endAt", "endBefore":
+		if len(args) == 0 {
+			return fmt.Errorf("%s() expects at least one value", method)
+		}
+		values := make([]interface{}, len(args))
+		for i, arg := range args {
+			values[i] = encodeFirestoreValue(arg)
+		}
+		cursor := map[string]interface{}{
+			"values": values,
+			"before": method == "startAt" || method == "endBefore",
+		}
+		if strings.HasPrefix(method, "start") {
+			o.startAt = cursor
+		} else {
+			o.endAt = cursor
+		}
+
+	case "get", "count", "add", "set", "update", "delete", "listCollections", "runQuery":
+		if o.action == "count" {
+			return nil // count().get()
+		}
+		o.action, o.args = method, args
+
+	default:
+		return fmt.Errorf("unsupported Firestore method: %s()", method)
+	}
+	return nil
+}
+
+// firestorePathArgument returns the path passed to collection(), collectionGroup() or doc()
+func firestorePathArgument(method string, args []interface{}) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("%s() expects a path", method)
+	}
+	path, ok := args[0].(string)
+	path = strings.Trim(strings.TrimSpace(path), "/")
+	if !ok || path == "" {
+		return "", fmt.Errorf("%s() expects a non empty path", method)
+	}
+	return path, nil
+}
+
+// firestoreFieldFilter builds the filter of a where() clause, == null & != null are unary filters
+func firestoreFieldFilter(field, operator string, value interface{}) (map[string]interface{}, error) {
+	fieldReference := map[string]interface{}{"fieldPath": quoteFirestoreFieldPath(field)}
+
+	if value == nil && (operator == "==" || operator == "!=") {
+		op := "IS_NULL"
+		if operator == "!=" {
+			op = "IS_NOT_NULL"
+		}
+		return map[string]interface{}{"unaryFilter": map[string]interface{}{"op": op, "field": fieldReference}}, nil
+	}
+
+	op, ok := firestoreOperators[operator]
+	if !ok {
+		return nil, fmt.Errorf("unsupported where() operator %q", operator)
+	}
+	return map[string]interface{}{
+		"fieldFilter": map[string]interface{}{"field": fieldReference, "op": op, "value": encodeFirestoreValue(value)},
+	}, nil
+}
+
+func (p *firestoreQueryParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid Firestore query at position %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *firestoreQueryParser) skipSpaces() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *firestoreQueryParser) consume(ch byte) bool {
+	p.skipSpaces()
+	if p.pos < len(p.input) && p.input[p.pos] == ch {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *firestoreQueryParser) parseIdentifier() string {
+	p.skipSpaces()
+	start := p.pos
+	for p.pos < len(p.input) {
+		ch := p.input[p.pos]
+		if ch == '_' || ch == '$' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (p.pos > start && ch >= '0' && ch <= '9') {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.input[start:p.pos]
+}
+
+// parseArguments parses "(value, value...)"
+func (p *firestoreQueryParser) parseArguments() ([]interface{}, error) {
+	if !p.consume('(') {
+		return nil, p.errorf("expected '('")
+	}
+	args := []interface{}{}
+	if p.consume(')') {
+		return args, nil
+	}
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, value)
+		if p.consume(')') {
+			return args, nil
+		}
+		if !p.consume(',') {
+			return nil, p.errorf("expected ',' or ')'")
+		}
+	}
+}
+
+// parseValue parses a JavaScript literal, plus Date, Timestamp, GeoPoint, FieldPath, FieldValue & db.doc() references
+func (p *firestoreQueryParser) parseValue() (interface{}, error) {
+	p.skipSpaces()
+	if p.pos >= len(p.input) {
+		return nil, p.errorf("unexpected end of query")
+	}
+
+	switch ch := p.input[p.pos]; {
+	case ch == '"' || ch == '\'' || ch == '`':
+		return p.parseString()
+	case ch == '-' || ch == '+' || ch == '.' || (ch >= '0' && ch <= '9'):
+		return p.parseNumber()
+	case ch == '[':
+		return p.parseArray()
+	case ch == '{':
+		return p.parseObject()
+	}
+
+	identifier := p.parseIdentifier()
+	switch identifier {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null", "undefined":
+		return nil, nil
+	case "new":
+		return p.parseConstructor()
+	case "Timestamp", "FieldPath", "FieldValue", "Date", "db":
+		return p.parseHelper(identifier)
+	case "":
+		return nil, p.errorf("unexpected character %q", p.input[p.pos])
+	}
+	return nil, p.errorf("unsupported value %s", identifier)
+}
+
+// parseConstructor parses new Date(...) & new GeoPoint(lat, lng)
+func (p *firestoreQueryParser) parseConstructor() (interface{}, error) {
+	name := p.parseIdentifier()
+	args, err := p.parseArguments()
+	if err != nil {
+		return nil, err
+	}
+	switch name {
+	case "Date":
+		return firestoreDate(args)
+	case "GeoPoint":
+		if len(args) == 2 {
+			latitude, okLatitude := firestoreNumber(args[0])
+			longitude, okLongitude := firestoreNumber(args[1])
+			if okLatitude && okLongitude {
+				return firestoreGeoPoint{Latitude: latitude, Longitude: longitude}, nil
+			}
+		}
+		return nil, p.errorf("new GeoPoint() expects a latitude & a longitude")
+	}
+	return nil, p.errorf("unsupported constructor %s", name)
+}
+
+// parseHelper parses the static helpers of the SDKs
+func (p *firestoreQueryParser) parseHelper(object string) (interface{}, error) {
+	if !p.consume('.') {
+		return nil, p.errorf("expected '.' after %s", object)
+	}
+	method := p.parseIdentifier()
+	args, err := p.parseArguments()
+	if err != nil {
+		return nil, err
+	}
+
+	switch object + "." + method {
+	case "Timestamp.now", "Date.now":
+		return time.Now().UTC(), nil
+	case "Timestamp.fromDate":
+		if len(args) == 1 {
+			if date, ok := args[0].(time.Time); ok {
+				return date, nil
+			}
+		}
+		return firestoreDate(args)
+	case "Timestamp.fromMillis":
+		return firestoreDate(args)
+	case "FieldPath.documentId":
+		return firestoreDocumentIDField, nil
+	case "FieldValue.serverTimestamp":
+		// Resolved by NeoBase rather than by a server transform, close enough for documents written through the chat
+		return time.Now().UTC(), nil
+	case "FieldValue.delete":
+		return firestoreDeleteField{}, nil
+	case "db.doc":
+		if len(args) == 1 {
+			if path, ok := args[0].(string); ok {
+				return firestoreReference(strings.Trim(path, "/")), nil
+			}
+		}
+		return nil, p.errorf("db.doc() expects a document path")
+	}
+	return nil, p.errorf("unsupported helper %s.%s()", object, method)
+}
+
+// firestoreDate converts the arguments of new Date() into a time, no argument means now
+func firestoreDate(args []interface{}) (interface{}, error) {
+	if len(args) == 0 {
+		return time.Now().UTC(), nil
+	}
+	switch v := args[0].(type) {
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"} {
+			if parsed, err := time.Parse(layout, v); err == nil {
+				return parsed.UTC(), nil
+			}
+		}
+		return nil, fmt.Errorf("invalid date %q", v)
+	case int64:
+		return time.UnixMilli(v).UTC(), nil
+	case float64:
+		return time.UnixMilli(int64(v)).UTC(), nil
+	}
+	return nil, fmt.Errorf("invalid date argument")
+}
+
+// firestoreNumber converts a parsed number into a float
+func firestoreNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+func (p *firestoreQueryParser) parseString() (string, error) {
+	quote := p.input[p.pos]
+	p.pos++
+	var builder strings.Builder
+	for p.pos < len(p.input) {
+		ch := p.input[p.pos]
+		p.pos++
+		switch {
+		case ch == quote:
+			return builder.String(), nil
+		case ch == '\\' && p.pos < len(p.input):
+			escaped := p.input[p.pos]
+			p.pos++
+			switch escaped {
+			case 'n':
+				builder.WriteByte('\n')
+			case 't':
+				builder.WriteByte('\t')
+			case 'r':
+				builder.WriteByte('\r')
+			case 'u':
+				if p.pos+4 <= len(p.input) {
+					if code, err := strconv.ParseUint(p.input[p.pos:p.pos+4], 16, 32); err == nil {
+						builder.WriteRune(rune(code))
+						p.pos += 4
+						continue
+					}
+				}
+				builder.WriteByte(escaped)
+			default:
+				builder.WriteByte(escaped)
+			}
+		default:
+			builder.WriteByte(ch)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *firestoreQueryParser) parseNumber() (interface{}, error) {
+	start := p.pos
+	for p.pos < len(p.input) && strings.ContainsRune("+-0123456789.eE_", rune(p.input[p.pos])) {
+		p.pos++
+	}
+	literal := strings.ReplaceAll(p.input[start:p.pos], "_", "")
+	if value, err := strconv.ParseInt(literal, 10, 64); err == nil {
+		return value, nil
+	}
+	value, err := strconv.ParseFloat(literal, 64)
+	if err != nil {
+		return nil, p.errorf("invalid number %q", literal)
+	}
+	return value, nil
+}
+
+func (p *firestoreQueryParser) parseArray() ([]interface{}, error) {
+	p.pos++ // [
+	items := []interface{}{}
+	if p.consume(']') {
+		return items, nil
+	}
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, value)
+		if p.consume(']') {
+			return items, nil
+		}
+		if !p.consume(',') {
+			return nil, p.errorf("expected ',' or ']'")
+		}
+		// Trailing comma
+		if p.consume(']') {
+			return items, nil
+		}
+	}
+}
+
+func (p *firestoreQueryParser) parseObject() (map[string]interface{}, error) {
+	p.pos++ // {
+	object := map[string]interface{}{}
+	if p.consume('}') {
+		return object, nil
+	}
+	for {
+		p.skipSpaces()
+		if p.pos >= len(p.input) {
+			return nil, p.errorf("unterminated object")
+		}
+
+		var key string
+		if ch := p.input[p.pos]; ch == '"' || ch == '\'' || ch == '`' {
+			parsed, err := p.parseString()
+			if err != nil {
+				return nil, err
+			}
+			key = parsed
+		} else {
+			key = p.parseIdentifier()
+			if key == "" {
+				return nil, p.errorf("expected an object key")
+			}
+		}
+
+		if !p.consume(':') {
+			return nil, p.errorf("expected ':' after %q", key)
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		object[key] = value
+
+		if p.consume('}') {
+			return object, nil
+		}
+		if !p.consume(',') {
+			return nil, p.errorf("expected ',' or '}'")
+		}
+		if p.consume('}') {
+			return object, nil
+		}
+	}
+}
@@ -0,0 +1,398 @@
+package dbmanager
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	firestoreSampleSize          = 50 // Documents sampled per collection for schema inference
+	firestoreSubCollectionProbes = 5  // Sampled documents checked for sub-collections
+)
+
+// FirestoreSchemaFetcher implements SchemaFetcher for Firestore.
+// Firestore is schemaless, the fields of a collection are inferred from a sample of its documents.
+type FirestoreSchemaFetcher struct {
+	db DBExecutor
+}
+
+// NewFirestoreSchemaFetcher creates a new Firestore schema fetcher
+func NewFirestoreSchemaFetcher(db DBExecutor) SchemaFetcher {
+	return &FirestoreSchemaFetcher{
+		db: db,
+	}
+}
+
+// firestoreField is a field inferred from the sampled documents
+type firestoreField struct {
+	types     map[string]int
+	count     int
+	refTables map[string]bool
+}
+
+// GetSchema fetches the root collections & infers their fields
+func (f *FirestoreSchemaFetcher) GetSchema(ctx context.Context, db DBExecutor, selectedCollections []string) (*SchemaInfo, error) {
+	log.Printf("FirestoreSchemaFetcher -> GetSchema -> Fetching Firestore schema")
+
+	schema := &SchemaInfo{
+		Tables:    make(map[string]TableSchema),
+		Views:     make(map[string]ViewSchema),
+		UpdatedAt: time.Now(),
+	}
+
+	err := withFirestoreClient(ctx, db.GetDB(), func(client *firestoreClient) error {
+		collections, err := client.listCollectionIDs(ctx, client.documentsPath())
+		if err != nil {
+			return fmt.Errorf("failed to list collections: %v", err)
+		}
+		log.Printf("FirestoreSchemaFetcher -> GetSchema -> Found %d collections: %v", len(collections), collections)
+
+		// Filter collections if specific ones are selected
+		var targetCollections []string
+		if len(selectedCollections) == 0 || (len(selectedCollections) == 1 && selectedCollections[0] == "ALL") {
+			targetCollections = collections
+		} else {
+			for _, selected := range selectedCollections {
+				for _, coll := range collections {
+					if selected == coll {
+						targetCollections = append(targetCollections, selected)
+						break
+					}
+				}
+			}
+		}
+
+		for _, collName := range targetCollections {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			table, err := f.inferCollection(ctx, client, collName)
+			if err != nil {
+				log.Printf("FirestoreSchemaFetcher -> GetSchema -> Error inferring collection %s: %v", collName, err)
+				continue
+			}
+
+			// Composite indexes need the datastore.indexes.list permission, they are optional
+			indexes, err := f.getCompositeIndexes(ctx, client, collName)
+			if err != nil {
+				log.Printf("FirestoreSchemaFetcher -> GetSchema -> Skipping indexes for collection %s: %v", collName, err)
+			} else {
+				for _, index := range indexes {
+					table.Indexes[index.Name] = index
+				}
+			}
+
+			schema.Tables[collName] = *table
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	schema.DialectHints = []string{
+		"Firestore queries use the chained SDK syntax, e.g. db.collection(\"users\").where(\"age\", \">=\", 18).orderBy(\"age\", \"desc\").limit(10).get()",
+		"Sub-collections are addressed by path, e.g. db.collection(\"users/<userId>/orders\") or db.collectionGroup(\"orders\") for all of them",
+		"Fields are inferred from sampled documents, \"_id\" is the document ID & can be filtered with FieldPath.documentId()",
+		"Queries combining equality & range/order filters on different fields need a composite index",
+	}
+
+	log.Printf("FirestoreSchemaFetcher -> GetSchema -> Inferred %d collections", len(schema.Tables))
+	return schema, nil
+}
+
+// inferCollection samples a collection & infers its fields, document count & sub-collections
+func (f *FirestoreSchemaFetcher) inferCollection(ctx context.Context, client *firestoreClient, collName string) (*TableSchema, error) {
+	query := map[string]interface{}{
+		"from":  []interface{}{map[string]interface{}{"collectionId": collName}},
+		"limit": firestoreSampleSize,
+	}
+	samples, err := client.runQuery(ctx, client.documentsPath(), query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sample collection: %v", err)
+	}
+	log.Printf("FirestoreSchemaFetcher -> inferCollection -> Sampled %d documents from %s", len(samples), collName)
+
+	// Count is an aggregation query, it is billed per 1000 index entries
+	documentCount, err := client.runCount(ctx, client.documentsPath(), map[string]interface{}{
+		"from": []interface{}{map[string]interface{}{"collectionId": collName}},
+	})
+	if err != nil {
+		log.Printf("FirestoreSchemaFetcher -> inferCollection -> Error counting documents of %s: %v", collName, err)
+		documentCount = int64(len(samples))
+	}
+
+	fields := make(map[string]*firestoreField)
+	for _, sample := range samples {
+		seen := make(map[string]bool)
+		f.analyzeFields(sample.Fields, "", fields, seen)
+	}
+
+	table := &TableSchema{
+		Name:        collName,
+		Columns:     make(map[string]ColumnInfo),
+		Indexes:     make(map[string]IndexInfo),
+		ForeignKeys: make(map[string]ForeignKey),
+		Constraints: make(map[string]ConstraintInfo),
+		RowCount:    documentCount,
+	}
+
+	// The document ID is always present
+	table.Columns["_id"] = ColumnInfo{
+		Name:       "_id",
+		Type:       "string",
+		IsNullable: false,
+		Comment:    "Document ID",
+	}
+	table.Indexes["__name__"] = IndexInfo{
+		Name:     "__name__",
+		Columns:  []string{"_id"},
+		IsUnique: true,
+	}
+
+	for fieldName, field := range fields {
+		frequency := float64(field.count) / float64(len(samples))
+		table.Columns[fieldName] = ColumnInfo{
+			Name:       fieldName,
+			Type:       field.typeName(),
+			IsNullable: frequency <= 0.9, // Consider required if present in >90% of samples
+			Comment:    fmt.Sprintf("Present in %.0f%% of sampled documents", frequency*100),
+		}
+
+		// References point to documents of another collection, they are exposed as foreign keys
+		refTables := make([]string, 0, len(field.refTables))
+		for refTable := range field.refTables {
+			refTables = append(refTables, refTable)
+		}
+		sort.Strings(refTables)
+		for _, refTable := range refTables {
+			name := fmt.Sprintf("%s_%s_ref", fieldName, strings.ReplaceAll(refTable, "/", "_"))
+			table.ForeignKeys[name] = ForeignKey{
+				Name:       name,
+				ColumnName: fieldName,
+				RefTable:   refTable,
+				RefColumn:  "_id",
+			}
+		}
+	}
+
+	// Sub-collections aren't part of the documents, they are listed on the collection instead
+	subCollections := make(map[string]bool)
+	for i, sample := range samples {
+		if i >= firestoreSubCollectionProbes {
+			break
+		}
+		ids, err := client.listCollectionIDs(ctx, sample.Name)
+		if err != nil {
+			log.Printf("FirestoreSchemaFetcher -> inferCollection -> Error listing sub-collections of %s: %v", sample.Name, err)
+			break
+		}
+		for _, id := range ids {
+			subCollections[id] = true
+		}
+	}
+	if len(subCollections) > 0 {
+		names := make([]string, 0, len(subCollections))
+		for name := range subCollections {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		table.Comment = fmt.Sprintf("Sub-collections: %s (query with db.collection(\"%s/<id>/<sub-collection>\") or db.collectionGroup(\"<sub-collection>\"))",
+			strings.Join(names, ", "), collName)
+	}
+
+	return table, nil
+}
+
+// analyzeFields recursively collects the fields of a document, nested maps use dotted names
+func (f *FirestoreSchemaFetcher) analyzeFields(values map[string]map[string]interface{}, prefix string, fields map[string]*firestoreField, seen map[string]bool) {
+	for key, value := range values {
+		fieldName := key
+		if prefix != "" {
+			fieldName = prefix + "." + key
+		}
+
+		field, exists := fields[fieldName]
+		if !exists {
+			field = &firestoreField{
+				types:     make(map[string]int),
+				refTables: make(map[string]bool),
+			}
+			fields[fieldName] = field
+		}
+		if !seen[fieldName] {
+			seen[fieldName] = true
+			field.count++
+		}
+
+		valueType := firestoreValueType(value)
+		switch valueType {
+		case "array":
+			elementType := "unknown"
+			if arrayValue, ok := value["arrayValue"].(map[string]interface{}); ok {
+				if items, ok := arrayValue["values"].([]interface{}); ok && len(items) > 0 {
+					if item, ok := items[0].(map[string]interface{}); ok {
+						elementType = firestoreValueType(item)
+						if elementType == "reference" {
+							f.addReference(field, item)
+						}
+					}
+				}
+			}
+			valueType = "array<" + elementType + ">"
+		case "map":
+			if mapValue, ok := value["mapValue"].(map[string]interface{}); ok {
+				if nested, ok := mapValue["fields"].(map[string]interface{}); ok {
+					nestedFields := make(map[string]map[string]interface{}, len(nested))
+					for nestedKey, nestedValue := range nested {
+						if typed, ok := nestedValue.(map[string]interface{}); ok {
+							nestedFields[nestedKey] = typed
+						}
+					}
+					f.analyzeFields(nestedFields, fieldName, fields, seen)
+				}
+			}
+		case "reference":
+			f.addReference(field, value)
+		}
+
+		// Nulls don't tell the type of the field
+		if valueType != "null" || len(field.types) == 0 {
+			field.types[valueType]++
+		}
+	}
+}
+
+// addReference records the collection a reference value points to
+func (f *FirestoreSchemaFetcher) addReference(field *firestoreField, value map[string]interface{}) {
+	reference, ok := value["referenceValue"].(string)
+	if !ok {
+		return
+	}
+	if index := strings.Index(reference, "/documents/"); index >= 0 {
+		reference = reference[index+len("/documents/"):]
+	}
+	if index := strings.LastIndex(reference, "/"); index > 0 {
+		field.refTables[reference[:index]] = true
+	}
+}
+
+// typeName returns the most frequent type of the field, mixed types are joined with "|"
+func (field *firestoreField) typeName() string {
+	types := make([]string, 0, len(field.types))
+	for valueType := range field.types {
+		if valueType != "null" || len(field.types) == 1 {
+			types = append(types, valueType)
+		}
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if field.types[types[i]] != field.types[types[j]] {
+			return field.types[types[i]] > field.types[types[j]]
+		}
+		return types[i] < types[j]
+	})
+	return strings.Join(types, "|")
+}
+
+// getCompositeIndexes lists the composite indexes of a collection group through the admin API
+func (f *FirestoreSchemaFetcher) getCompositeIndexes(ctx context.Context, client *firestoreClient, collName string) ([]IndexInfo, error) {
+	var resp struct {
+		Indexes []struct {
+			Name   string `json:"name"`
+			State  string `json:"state"`
+			Fields []struct {
+				FieldPath   string `json:"fieldPath"`
+				Order       string `json:"order"`
+				ArrayConfig string `json:"arrayConfig"`
+			} `json:"fields"`
+		} `json:"indexes"`
+	}
+	path := fmt.Sprintf("%s/collectionGroups/%s/indexes", client.databasePath(), url.PathEscape(collName))
+	if err := client.do(ctx, "GET", path, nil, &resp); err != nil {
+		return nil, err
+	}
+
+	indexes := make([]IndexInfo, 0, len(resp.Indexes))
+	for _, index := range resp.Indexes {
+		if index.State != "" && index.State != "READY" {
+			continue
+		}
+		columns := make([]string, 0, len(index.Fields))
+		for _, field := range index.Fields {
+			if field.FieldPath == firestoreDocumentIDField {
+				continue
+			}
+			columns = append(columns, field.FieldPath)
+		}
+		if len(columns) == 0 {
+			continue
+		}
+		indexes = append(indexes, IndexInfo{
+			Name:    index.Name[strings.LastIndex(index.Name, "/")+1:],
+			Columns: columns,
+		})
+	}
+	return indexes, nil
+}
+
+// GetTableChecksum calculates a checksum of the fields inferred for a collection
+func (f *FirestoreSchemaFetcher) GetTableChecksum(ctx context.Context, db DBExecutor, collection string) (string, error) {
+	var table *TableSchema
+	err := withFirestoreClient(ctx, db.GetDB(), func(client *firestoreClient) error {
+		var inferErr error
+		table, inferErr = f.inferCollection(ctx, client, collection)
+		return inferErr
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get collection checksum: %v", err)
+	}
+
+	// Only the structure is hashed, the document count changes with every write
+	columns := make([]string, 0, len(table.Columns))
+	for name, column := range table.Columns {
+		columns = append(columns, fmt.Sprintf("%s:%s:%v", name, column.Type, column.IsNullable))
+	}
+	sort.Strings(columns)
+
+	hash := md5.Sum([]byte(strings.Join(columns, ",") + "|" + table.Comment))
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// FetchExampleRecords fetches example documents from a collection
+func (f *FirestoreSchemaFetcher) FetchExampleRecords(ctx context.Context, db DBExecutor, collection string, limit int) ([]map[string]interface{}, error) {
+	// Ensure limit is reasonable
+	if limit <= 0 {
+		limit = 3
+	} else if limit > 10 {
+		limit = 10
+	}
+
+	var records []map[string]interface{}
+	err := withFirestoreClient(ctx, db.GetDB(), func(client *firestoreClient) error {
+		documents, err := client.runQuery(ctx, client.documentsPath(), map[string]interface{}{
+			"from":  []interface{}{map[string]interface{}{"collectionId": collection}},
+			"limit": limit,
+		})
+		if err != nil {
+			return err
+		}
+
+		records = make([]map[string]interface{}, 0, len(documents))
+		for _, document := range documents {
+			records = append(records, firestoreDocumentToRow(document))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch example records: %v", err)
+	}
+	return records, nil
+}
@@ -0,0 +1,70 @@
+package dbmanager
+
+import (
+	"strings"
+)
+
+// FirestoreSimplifier implements SchemaSimplifier for Firestore
+type FirestoreSimplifier struct{}
+
+// SimplifyDataType simplifies Firestore value types for better readability
+func (s *FirestoreSimplifier) SimplifyDataType(dbType string) string {
+	lowerType := strings.ToLower(dbType)
+
+	switch {
+	case strings.Contains(lowerType, "|"):
+		return "mixed"
+	case strings.HasPrefix(lowerType, "array"):
+		return "array"
+	case lowerType == "integer" || lowerType == "double":
+		return "number"
+	case lowerType == "map":
+		return "object"
+	case lowerType == "timestamp":
+		return "date"
+	case lowerType == "reference":
+		return "reference"
+	case lowerType == "geopoint":
+		return "geopoint"
+	default:
+		return lowerType
+	}
+}
+
+// GetColumnConstraints returns constraints for a Firestore field
+func (s *FirestoreSimplifier) GetColumnConstraints(col ColumnInfo, table TableSchema) []string {
+	constraints := []string{}
+
+	// The document ID is the primary key of a collection
+	if col.Name == "_id" {
+		constraints = append(constraints, "PRIMARY KEY")
+	} else {
+		// Fields of composite indexes
+		for _, idx := range table.Indexes {
+			isIndexed := false
+			for _, idxCol := range idx.Columns {
+				if idxCol == col.Name {
+					isIndexed = true
+					break
+				}
+			}
+			if isIndexed {
+				constraints = append(constraints, "INDEXED")
+				break
+			}
+		}
+	}
+
+	if !col.IsNullable {
+		constraints = append(constraints, "NOT NULL")
+	}
+
+	// References point to a document of another collection
+	for _, fk := range table.ForeignKeys {
+		if fk.ColumnName == col.Name {
+			constraints = append(constraints, "REFERENCES "+fk.RefTable)
+		}
+	}
+
+	return constraints
+}
@@ -0,0 +1,46 @@
+package dbmanager
+
+import (
+	"context"
+	"fmt"
+	"neobase-ai/internal/apis/dtos"
+)
+
+// FirestoreTransaction implements the Transaction interface for Firestore.
+// Every write request is committed atomically on its own, rollbacks rely on the rollback queries generated by NeoBase.
+type FirestoreTransaction struct {
+	conn *Connection
+	done bool
+}
+
+// ExecuteQuery executes a query within a transaction
+func (t *FirestoreTransaction) ExecuteQuery(ctx context.Context, conn *Connection, query string, queryType string, findCount bool) *QueryExecutionResult {
+	if t.conn == nil || t.done {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Message: "No active transaction",
+				Code:    "TRANSACTION_ERROR",
+			},
+		}
+	}
+
+	return executeFirestoreStatements(ctx, t.conn, query)
+}
+
+// Commit commits the transaction
+func (t *FirestoreTransaction) Commit() error {
+	if t.conn == nil || t.done {
+		return fmt.Errorf("no active transaction to commit")
+	}
+	t.done = true
+	return nil
+}
+
+// Rollback rolls back the transaction
+func (t *FirestoreTransaction) Rollback() error {
+	if t.conn == nil || t.done {
+		return fmt.Errorf("no active transaction to rollback")
+	}
+	t.done = true
+	return nil
+}
@@ -0,0 +1,264 @@
+package dbmanager
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/constants"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+)
+
+// groundingQueryTimeout bounds the queries the LLM runs to ground its response, they must not keep the connection busy
+const groundingQueryTimeout = 30 * time.Second
+
+// The results of the read-only queries the LLM runs are added to its prompt, they are cut to a few rows & bytes
+const (
+	groundingMaxRows        = 100
+	groundingMaxResultBytes = 32 * 1024
+)
+
+// ExecuteReadOnlyQuery runs a read-only query for the LLM, e.g. to look at the distinct values of a column before filtering on them.
+// Writes are refused, the result is returned as JSON, cut to groundingMaxRows rows & groundingMaxResultBytes bytes.
+func (m *Manager) ExecuteReadOnlyQuery(ctx context.Context, chatID, query string) (string, *dtos.QueryError) {
+	conn, driver, queryErr := m.groundingConnection(chatID)
+	if queryErr != nil {
+		return "", queryErr
+	}
+	if !IsReadOnlyQuery(conn.Config.Type, query) {
+		return "", &dtos.QueryError{
+			Code:    "READ_ONLY_QUERY_REQUIRED",
+			Message: "only read-only queries can be run",
+			Details: "The query would modify the database",
+		}
+	}
+
+	// The query is limited like an execution, the database stops at the limit when the query has none of its own
+	ctx = WithResultRowLimit(WithAutoLimit(ctx, groundingMaxRows), groundingMaxRows)
+	query, limit := applyAutoLimit(ctx, conn.Config.Type, query)
+	result, queryErr := m.runGroundingResult(ctx, conn, driver, query, true)
+	if queryErr != nil {
+		return "", queryErr
+	}
+	markAutoLimited(result, limit)
+	capResultRows(ctx, result)
+	return groundingResultJSON(result, groundingMaxResultBytes)
+}
+
+// ExplainQuery returns the plan of a query without running it, writes can be explained as well.
+// Db2 & Firestore have no plan the LLM can read.
+func (m *Manager) ExplainQuery(ctx context.Context, chatID, query string) (string, *dtos.QueryError) {
+	conn, driver, queryErr := m.groundingConnection(chatID)
+	if queryErr != nil {
+		return "", queryErr
+	}
+
+	query, queryErr = prepareQueryParams(ctx, conn.Config.Type, query)
+	if queryErr != nil {
+		return "", queryErr
+	}
+
+	explained, ok := explainStatement(conn.Config.Type, query)
+	if !ok {
+		return "", &dtos.QueryError{
+			Code:    "EXPLAIN_NOT_SUPPORTED",
+			Message: "the query can't be explained",
+			Details: "Only a single read or DML statement can be explained, Db2 & Firestore queries can't be",
+		}
+	}
+	// An EXPLAIN doesn't run the query, the plans of reads are still read in a read-only transaction
+	return m.runGroundingQuery(ctx, conn, driver, explained, IsReadOnlyQuery(conn.Config.Type, query))
+}
+
+func (m *Manager) groundingConnection(chatID string) (*Connection, DatabaseDriver, *dtos.QueryError) {
+	m.mu.RLock()
+	conn, exists := m.connections[chatID]
+	m.mu.RUnlock()
+	if !exists {
+		return nil, nil, &dtos.QueryError{
+			Code:    "NO_CONNECTION_FOUND",
+			Message: "no connection found",
+			Details: "No connection found for chat ID: " + chatID,
+		}
+	}
+
+	driver, exists := m.drivers[conn.Config.Type]
+	if !exists {
+		return nil, nil, &dtos.QueryError{
+			Code:    "NO_DRIVER_FOUND",
+			Message: "no driver found",
+			Details: "No driver found for type: " + conn.Config.Type,
+		}
+	}
+	return conn, driver, nil
+}
+
+// runGroundingQuery runs a query on the connection the reads are routed to & returns its whole result as JSON
+func (m *Manager) runGroundingQuery(ctx context.Context, conn *Connection, driver DatabaseDriver, query string, readOnly bool) (string, *dtos.QueryError) {
+	result, queryErr := m.runGroundingResult(ctx, conn, driver, query, readOnly)
+	if queryErr != nil {
+		return "", queryErr
+	}
+	return groundingResultJSON(result, 0)
+}
+
+// runGroundingResult runs a query on the connection the reads are routed to, within groundingQueryTimeout. The query holds a
+// slot of the connection like the executions, it waits in its queue without notices. A readOnly query is run so the database
+// refuses its writes too, see executeReadOnly.
+func (m *Manager) runGroundingResult(ctx context.Context, conn *Connection, driver DatabaseDriver, query string, readOnly bool) (*QueryExecutionResult, *dtos.QueryError) {
+	ctx, cancel := context.WithTimeout(ctx, groundingQueryTimeout)
+	defer cancel()
+
+	release, queueErr := m.acquireQuerySlot(ctx, conn, "", "", "")
+	if queueErr != nil {
+		return nil, queueErr
+	}
+	defer release()
+
+	var result *QueryExecutionResult
+	if readOnly {
+		result = executeReadOnly(ctx, driver, m.routeQuery(ctx, conn, query), query)
+	} else {
+		result = driver.ExecuteQuery(ctx, m.routeQuery(ctx, conn, query), query, "", false)
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, &dtos.QueryError{
+			Code:    "QUERY_TIMEOUT",
+			Message: "the query took too long",
+			Details: "The query was cancelled after " + groundingQueryTimeout.String(),
+		}
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return result, nil
+}
+
+// readOnlyTransactionTypes are the databases whose transactions can be started read-only, their server refuses the writes
+// the classifier can't see, e.g. functions with side effects called from a SELECT
+var readOnlyTransactionTypes = map[string]bool{
+	constants.DatabaseTypePostgreSQL: true,
+	constants.DatabaseTypeYugabyteDB: true,
+	constants.DatabaseTypeMySQL:      true,
+	constants.DatabaseTypeMariaDB:    true,
+}
+
+type readOnlyTransactionKey struct{}
+
+// withReadOnlyTransaction makes the transactions the drivers begin with the context read-only
+func withReadOnlyTransaction(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readOnlyTransactionKey{}, true)
+}
+
+// readOnlyTxOptions returns the options of the transactions begun with the context, nil for the default ones
+func readOnlyTxOptions(ctx context.Context) *sql.TxOptions {
+	if readOnly, _ := ctx.Value(readOnlyTransactionKey{}).(bool); readOnly {
+		return &sql.TxOptions{ReadOnly: true}
+	}
+	return nil
+}
+
+// executeReadOnly runs a query the classifier found read-only so the database refuses its writes as well: in a read-only
+// transaction always rolled back, or with readonly=1 on ClickHouse. The other databases only rely on the classifier.
+func executeReadOnly(ctx context.Context, driver DatabaseDriver, conn *Connection, query string) *QueryExecutionResult {
+	if conn.Config.Type == constants.DatabaseTypeClickhouse {
+		return driver.ExecuteQuery(clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{"readonly": 1})), conn, query, "", false)
+	}
+	if !readOnlyTransactionTypes[conn.Config.Type] {
+		return driver.ExecuteQuery(ctx, conn, query, "", false)
+	}
+
+	tx := driver.BeginTx(withReadOnlyTransaction(ctx), conn)
+	if tx == nil {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Code:    "FAILED_TO_START_TRANSACTION",
+				Message: "failed to start a read-only transaction",
+				Details: "Failed to start a read-only transaction",
+			},
+		}
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil {
+			log.Printf("DBManager -> executeReadOnly -> Error rolling back the read-only transaction: %v", err)
+		}
+	}()
+	return tx.ExecuteQuery(ctx, conn, query, "", false)
+}
+
+// groundingResultJSON encodes a result for the LLM. A result larger than maxBytes, unless 0, keeps the first rows fitting in it
+// & is marked as truncated, a result without rows is cut at maxBytes.
+func groundingResultJSON(result *QueryExecutionResult, maxBytes int) (string, *dtos.QueryError) {
+	resultJSON := result.ResultJSON
+	if resultJSON == "" {
+		encoded, err := json.Marshal(result.Result)
+		if err != nil {
+			return "", &dtos.QueryError{
+				Code:    "INVALID_RESULT",
+				Message: "failed to encode the result",
+				Details: err.Error(),
+			}
+		}
+		resultJSON = string(encoded)
+	}
+	if maxBytes <= 0 || len(resultJSON) <= maxBytes {
+		return resultJSON, nil
+	}
+
+	rows := reflect.ValueOf(result.Result["results"])
+	for rows.Kind() == reflect.Slice && rows.Len() > 1 && len(resultJSON) > maxBytes {
+		rows = rows.Slice(0, rows.Len()/2)
+		result.Result["results"] = rows.Interface()
+		result.Result["truncated"] = true
+		result.Result["truncatedReason"] = fmt.Sprintf("the result was cut to %d rows to fit in %d bytes, select fewer columns or rows", rows.Len(), maxBytes)
+		encoded, err := json.Marshal(result.Result)
+		if err != nil {
+			break
+		}
+		resultJSON = string(encoded)
+	}
+	if len(resultJSON) > maxBytes {
+		resultJSON = resultJSON[:maxBytes] + "... (truncated)"
+	}
+	return resultJSON, nil
+}
+
+// explainStatement returns the statement explaining a query, false when the query can't be explained without running it.
+// A SQL query must be a single statement not explained already, an EXPLAIN ANALYZE would run it. Only reads & DML have a plan,
+// ClickHouse only plans reads.
+func explainStatement(dbType, query string) (string, bool) {
+	query = strings.TrimSpace(query)
+	switch dbType {
+	case constants.DatabaseTypeMongoDB:
+		// The queryPlanner verbosity only plans the query, executionStats would run it
+		query = mongoExplainRegex.ReplaceAllString(query, "")
+		if !mongoReadMethodRegex.MatchString(query) {
+			return "", false
+		}
+		return query + `.explain("queryPlanner")`, true
+	case constants.DatabaseTypeDB2, constants.DatabaseTypeFirestore:
+		return "", false
+	}
+
+	foldCase := dbType == constants.DatabaseTypePostgreSQL || dbType == constants.DatabaseTypeYugabyteDB
+	statements := splitSQLTokens(tokenizeSQL(query, foldCase))
+	if len(statements) != 1 || len(statements[0]) == 0 {
+		return "", false
+	}
+	switch strings.ToUpper(statements[0][0].text) {
+	case "SELECT", "WITH", "VALUES", "TABLE":
+	case "INSERT", "UPDATE", "DELETE", "REPLACE", "MERGE":
+		if dbType == constants.DatabaseTypeClickhouse {
+			return "", false
+		}
+	default:
+		return "", false
+	}
+	return "EXPLAIN " + strings.TrimRight(query, "; \n\t"), true
+}
@@ -0,0 +1,56 @@
+package dbmanager
+
+import (
+	"context"
+	"neobase-ai/internal/constants"
+	"testing"
+)
+
+func TestExplainStatement(t *testing.T) {
+	tests := []struct {
+		name      string
+		dbType    string
+		query     string
+		want      string
+		explained bool
+	}{
+		{"select", constants.DatabaseTypePostgreSQL, "SELECT * FROM users", "EXPLAIN SELECT * FROM users", true},
+		{"trailing semicolon", constants.DatabaseTypePostgreSQL, "SELECT * FROM users;\n", "EXPLAIN SELECT * FROM users", true},
+		{"lowercase with", constants.DatabaseTypePostgreSQL, "with t as (select 1) select * from t", "EXPLAIN with t as (select 1) select * from t", true},
+		{"leading comment", constants.DatabaseTypeMySQL, "/* report */ SELECT 1", "EXPLAIN /* report */ SELECT 1", true},
+		{"semicolon in a string", constants.DatabaseTypeMySQL, "SELECT * FROM notes WHERE body = 'a; b'", "EXPLAIN SELECT * FROM notes WHERE body = 'a; b'", true},
+		{"update", constants.DatabaseTypePostgreSQL, "UPDATE users SET name = 'x' WHERE id = 1", "EXPLAIN UPDATE users SET name = 'x' WHERE id = 1", true},
+		{"delete", constants.DatabaseTypeMySQL, "DELETE FROM users WHERE id = 1", "EXPLAIN DELETE FROM users WHERE id = 1", true},
+		{"two statements", constants.DatabaseTypePostgreSQL, "SELECT 1; SELECT 2", "", false},
+		{"read then write", constants.DatabaseTypePostgreSQL, "SELECT 1; DELETE FROM users", "", false},
+		{"explain analyze", constants.DatabaseTypePostgreSQL, "EXPLAIN ANALYZE DELETE FROM users", "", false},
+		{"explained already", constants.DatabaseTypeMySQL, "EXPLAIN SELECT 1", "", false},
+		{"ddl", constants.DatabaseTypePostgreSQL, "CREATE TABLE t (id int)", "", false},
+		{"empty", constants.DatabaseTypePostgreSQL, "  ", "", false},
+		{"only a comment", constants.DatabaseTypePostgreSQL, "-- nothing", "", false},
+		{"clickhouse select", constants.DatabaseTypeClickhouse, "SELECT count() FROM events", "EXPLAIN SELECT count() FROM events", true},
+		{"clickhouse write", constants.DatabaseTypeClickhouse, "INSERT INTO events VALUES (1)", "", false},
+		{"db2", constants.DatabaseTypeDB2, "SELECT 1 FROM SYSIBM.SYSDUMMY1", "", false},
+		{"mongo find", constants.DatabaseTypeMongoDB, "db.users.find({age: 3})", `db.users.find({age: 3}).explain("queryPlanner")`, true},
+		{"mongo explained with execution stats", constants.DatabaseTypeMongoDB, `db.users.find({}).explain("executionStats")`, `db.users.find({}).explain("queryPlanner")`, true},
+		{"mongo write", constants.DatabaseTypeMongoDB, "db.users.deleteMany({age: 3})", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, explained := explainStatement(tt.dbType, tt.query)
+			if got != tt.want || explained != tt.explained {
+				t.Errorf("explainStatement(%q, %q) = %q, %v, want %q, %v", tt.dbType, tt.query, got, explained, tt.want, tt.explained)
+			}
+		})
+	}
+}
+
+func TestReadOnlyTxOptions(t *testing.T) {
+	if opts := readOnlyTxOptions(context.Background()); opts != nil {
+		t.Errorf("readOnlyTxOptions() = %+v without withReadOnlyTransaction, want nil", opts)
+	}
+	if opts := readOnlyTxOptions(withReadOnlyTransaction(context.Background())); opts == nil || !opts.ReadOnly {
+		t.Errorf("readOnlyTxOptions() = %+v with withReadOnlyTransaction, want a read-only transaction", opts)
+	}
+}
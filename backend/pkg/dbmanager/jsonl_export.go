@@ -0,0 +1,136 @@
+package dbmanager
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"neobase-ai/internal/apis/dtos"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// documentWriter is implemented by the result writers writing the MongoDB documents whole rather than the fields of
+// the first document
+type documentWriter interface {
+	writeDocument(document bson.D) error
+}
+
+// ExportQueryJSONL writes the whole result of a read-only query to w as JSON Lines, a JSON object per row. The rows are
+// sent to the client as they are read, a slow client slows the reading down rather than filling the memory.
+// The MongoDB documents are written as relaxed extended JSON, like mongoexport. Returns the number of rows written.
+func (m *Manager) ExportQueryJSONL(ctx context.Context, chatID, query string, w io.Writer) (int64, *dtos.QueryError) {
+	summary, queryErr := m.exportQuery(ctx, chatID, query, 0, &jsonlResultWriter{client: w, buffer: bufio.NewWriter(w)})
+	return summary.rows, queryErr
+}
+
+// jsonlResultWriter writes a row per line, the keys are the column names in the order of the result
+type jsonlResultWriter struct {
+	client  io.Writer
+	buffer  *bufio.Writer
+	format  exportFormat
+	keys    [][]byte // column names encoded as JSON keys
+	columns []exportColumn
+	line    bytes.Buffer
+}
+
+func (w *jsonlResultWriter) writeHeader(columns []exportColumn, format exportFormat) error {
+	w.columns = columns
+	w.format = format
+	w.keys = make([][]byte, len(columns))
+	used := make(map[string]bool, len(columns))
+	for i, column := range columns {
+		// Joins can return columns of the same name, a JSON object can't hold them twice
+		name := column.name
+		for n := 2; used[name]; n++ {
+			name = column.name + "_" + strconv.Itoa(n)
+		}
+		used[name] = true
+		key, err := json.Marshal(name)
+		if err != nil {
+			return err
+		}
+		w.keys[i] = append(key, ':')
+	}
+	return nil
+}
+
+func (w *jsonlResultWriter) writeRow(values []interface{}) error {
+	w.line.Reset()
+	w.line.WriteByte('{')
+	for i, value := range values {
+		if i > 0 {
+			w.line.WriteByte(',')
+		}
+		encoded, err := json.Marshal(w.jsonValue(value, w.columns[i]))
+		if err != nil {
+			return err
+		}
+		w.line.Write(w.keys[i])
+		w.line.Write(encoded)
+	}
+	w.line.WriteString("}\n")
+	_, err := w.buffer.Write(w.line.Bytes())
+	return err
+}
+
+func (w *jsonlResultWriter) writeDocument(document bson.D) error {
+	encoded, err := bson.MarshalExtJSON(document, false, false)
+	if err != nil {
+		return err
+	}
+	if _, err := w.buffer.Write(encoded); err != nil {
+		return err
+	}
+	return w.buffer.WriteByte('\n')
+}
+
+// flush sends the buffered rows through the connection, the writes block while the client doesn't read
+func (w *jsonlResultWriter) flush() error {
+	if err := w.buffer.Flush(); err != nil {
+		return err
+	}
+	if flusher, ok := w.client.(interface{ Flush() }); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// jsonValue keeps the numbers & the booleans typed, the numbers drivers return as text included. The other values are
+// written as in the CSV export.
+func (w *jsonlResultWriter) jsonValue(value interface{}, column exportColumn) interface{} {
+	switch v := value.(type) {
+	case nil, primitive.Null, primitive.Undefined:
+		return nil
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return v
+	case float32:
+		return w.jsonValue(float64(v), column)
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return w.format.text(v, column)
+		}
+		return v
+	case time.Time:
+		return w.format.text(v, column)
+	}
+
+	text := w.format.text(value, column)
+	switch column.kind() {
+	case kindInteger, kindUnsigned, kindFloat, kindDecimal:
+		// The decimals keep their digits as a JSON number
+		if text != "" && (text[0] == '-' || text[0] >= '0' && text[0] <= '9') && json.Valid([]byte(text)) {
+			return json.Number(text)
+		}
+	case kindBoolean:
+		if boolean, err := strconv.ParseBool(text); err == nil {
+			return boolean
+		}
+	}
+	return text
+}
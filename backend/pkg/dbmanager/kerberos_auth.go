@@ -0,0 +1,282 @@
+package dbmanager
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"neobase-ai/internal/constants"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// Service of the database server principal, e.g. postgres/db.example.com@EXAMPLE.COM
+	defaultKerberosServiceName = "postgres"
+	// Tickets are renewed a few minutes before they expire so new connections & pings never use a stale one
+	kerberosTicketRenewMargin = 5 * time.Minute
+	defaultKRB5ConfigPath     = "/etc/krb5.conf"
+)
+
+// kerberosClient holds the tickets of a principal, it is implemented with gokrb5 in kerberos_gss.go
+type kerberosClient interface {
+	// Login gets a new ticket-granting ticket with the keytab of the principal & returns when it expires
+	Login() (time.Time, error)
+	// InitSecContext returns the GSS-API initial context token for the service principal name
+	InitSecContext(spn string) ([]byte, error)
+	Destroy()
+}
+
+// newKerberosClient creates the client of a principal from its keytab & the krb5.conf content
+var newKerberosClient = newGokrb5Client
+
+type kerberosTicket struct {
+	client    kerberosClient
+	expiresAt time.Time
+}
+
+// kerberosTickets caches the logged-in client of each principal & keytab
+var kerberosTickets = struct {
+	sync.Mutex
+	tickets map[string]*kerberosTicket
+}{tickets: make(map[string]*kerberosTicket)}
+
+// kerberosHandshake is the client & service principal name of a Kerberos connection being opened
+type kerberosHandshake struct {
+	client kerberosClient
+	spn    string
+}
+
+// kerberosHandshakes holds the handshakes of the connections being opened by ID. lib/pq only supports a process-wide GSS
+// provider, so the krbspn of each connection carries its handshake ID after the real SPN & the provider looks its client up.
+var kerberosHandshakes = struct {
+	sync.Mutex
+	nextID     uint64
+	handshakes map[uint64]kerberosHandshake
+}{handshakes: make(map[uint64]kerberosHandshake)}
+
+// kerberosHandshakeSeparator separates the service principal name from the handshake ID in krbspn
+const kerberosHandshakeSeparator = "#"
+
+func init() {
+	pq.RegisterGSSProvider(func() (pq.GSS, error) {
+		return &kerberosGSS{}, nil
+	})
+}
+
+// startKerberosHandshake registers the client of a connection being opened & returns the krbspn carrying its handshake ID,
+// the returned function unregisters it
+func startKerberosHandshake(client kerberosClient, spn string) (string, func()) {
+	kerberosHandshakes.Lock()
+	kerberosHandshakes.nextID++
+	id := kerberosHandshakes.nextID
+	kerberosHandshakes.handshakes[id] = kerberosHandshake{client: client, spn: spn}
+	kerberosHandshakes.Unlock()
+
+	return fmt.Sprintf("%s%s%d", spn, kerberosHandshakeSeparator, id), func() {
+		kerberosHandshakes.Lock()
+		delete(kerberosHandshakes.handshakes, id)
+		kerberosHandshakes.Unlock()
+	}
+}
+
+// lookupKerberosHandshake returns the handshake of a krbspn set by startKerberosHandshake
+func lookupKerberosHandshake(krbspn string) (kerberosHandshake, bool) {
+	separator := strings.LastIndex(krbspn, kerberosHandshakeSeparator)
+	if separator < 0 {
+		return kerberosHandshake{}, false
+	}
+	id, err := strconv.ParseUint(krbspn[separator+1:], 10, 64)
+	if err != nil {
+		return kerberosHandshake{}, false
+	}
+
+	kerberosHandshakes.Lock()
+	defer kerberosHandshakes.Unlock()
+	handshake, found := kerberosHandshakes.handshakes[id]
+	return handshake, found && handshake.spn == krbspn[:separator]
+}
+
+// UsesKerberosAuth returns true when the connection authenticates with a Kerberos ticket instead of a password
+func UsesKerberosAuth(config ConnectionConfig) bool {
+	return config.AuthMode != nil && *config.AuthMode == AuthModeKerberos
+}
+
+// validateKerberosAuth checks the connection can authenticate with Kerberos. Only PostgreSQL connections can: NeoBase has no
+// SQL Server database type, so Kerberos for SQL Server is out of scope until one is added.
+func validateKerberosAuth(config ConnectionConfig) error {
+	if config.Type != constants.DatabaseTypePostgreSQL {
+		return fmt.Errorf("Kerberos authentication is not supported for %s connections", config.Type)
+	}
+
+	// The service principal name is built from the host
+	if UsesUnixSocket(config) {
+		return fmt.Errorf("Kerberos authentication requires a host & port, not a unix socket")
+	}
+
+	principal, realm := kerberosPrincipal(config)
+	if principal == "" {
+		return fmt.Errorf("Kerberos principal is required as the username")
+	}
+	if realm == "" {
+		return fmt.Errorf("Kerberos realm is required when the username is not a principal@REALM")
+	}
+	if config.KerberosKeytab == nil || strings.TrimSpace(*config.KerberosKeytab) == "" {
+		return fmt.Errorf("Kerberos keytab is required")
+	}
+	if _, err := base64.StdEncoding.DecodeString(strings.TrimSpace(*config.KerberosKeytab)); err != nil {
+		return fmt.Errorf("Kerberos keytab must be base64 encoded: %v", err)
+	}
+	return nil
+}
+
+// kerberosPrincipal returns the principal name & realm of the connection, a username like alice@EXAMPLE.COM carries the realm
+func kerberosPrincipal(config ConnectionConfig) (string, string) {
+	var principal, realm string
+	if config.Username != nil {
+		principal = strings.TrimSpace(*config.Username)
+	}
+	if at := strings.LastIndex(principal, "@"); at >= 0 {
+		principal, realm = principal[:at], principal[at+1:]
+	}
+	if config.KerberosRealm != nil && strings.TrimSpace(*config.KerberosRealm) != "" {
+		realm = strings.TrimSpace(*config.KerberosRealm)
+	}
+	return principal, strings.ToUpper(realm)
+}
+
+// kerberosServiceName returns the service of the database server principal
+func kerberosServiceName(config ConnectionConfig) string {
+	if config.KerberosServiceName != nil && strings.TrimSpace(*config.KerberosServiceName) != "" {
+		return strings.TrimSpace(*config.KerberosServiceName)
+	}
+	return defaultKerberosServiceName
+}
+
+// kerberosConfig returns the krb5.conf content of the connection.
+// The realm's KDC is used when set, the krb5.conf of the server (KRB5_CONFIG or /etc/krb5.conf) otherwise.
+func kerberosConfig(config ConnectionConfig, realm string) (string, error) {
+	if config.KerberosKDC != nil && strings.TrimSpace(*config.KerberosKDC) != "" {
+		return fmt.Sprintf("[libdefaults]\n  default_realm = %s\n  dns_lookup_kdc = false\n  dns_lookup_realm = false\n\n[realms]\n  %s = {\n    kdc = %s\n  }\n",
+			realm, realm, strings.TrimSpace(*config.KerberosKDC)), nil
+	}
+
+	path := os.Getenv("KRB5_CONFIG")
+	if path == "" {
+		path = defaultKRB5ConfigPath
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("Kerberos KDC is required when the server has no krb5.conf: %v", err)
+	}
+	return string(content), nil
+}
+
+// getKerberosClient returns the client of the connection's principal with a valid ticket-granting ticket.
+// Tickets are renewed with the keytab when they are about to expire.
+func getKerberosClient(config ConnectionConfig) (kerberosClient, error) {
+	principal, realm := kerberosPrincipal(config)
+	keytab, err := base64.StdEncoding.DecodeString(strings.TrimSpace(*config.KerberosKeytab))
+	if err != nil {
+		return nil, fmt.Errorf("Kerberos keytab must be base64 encoded: %v", err)
+	}
+	krb5Conf, err := kerberosConfig(config, realm)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.New()
+	hash.Write(keytab)
+	hash.Write([]byte(krb5Conf))
+	cacheKey := fmt.Sprintf("%s@%s:%s", principal, realm, hex.EncodeToString(hash.Sum(nil)))
+
+	kerberosTickets.Lock()
+	defer kerberosTickets.Unlock()
+
+	ticket, found := kerberosTickets.tickets[cacheKey]
+	if found && time.Until(ticket.expiresAt) > kerberosTicketRenewMargin {
+		return ticket.client, nil
+	}
+
+	if !found {
+		client, err := newKerberosClient(principal, realm, keytab, krb5Conf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Kerberos client: %v", err)
+		}
+		ticket = &kerberosTicket{client: client}
+	}
+
+	expiresAt, err := ticket.client.Login()
+	if err != nil {
+		if !found {
+			ticket.client.Destroy()
+		}
+		return nil, fmt.Errorf("failed to get Kerberos ticket for %s@%s: %v", principal, realm, err)
+	}
+	if found {
+		log.Printf("DBManager -> getKerberosClient -> Renewed Kerberos ticket for %s@%s", principal, realm)
+	}
+	ticket.expiresAt = expiresAt
+	kerberosTickets.tickets[cacheKey] = ticket
+	return ticket.client, nil
+}
+
+// kerberosGSS implements pq.GSS with the client of the handshake named by the krbspn of the connection being opened
+type kerberosGSS struct{}
+
+// GetInitToken is called for the connections without krbspn, which are not in the kerberos auth mode
+func (g *kerberosGSS) GetInitToken(host string, service string) ([]byte, error) {
+	return nil, fmt.Errorf("the server requested Kerberos authentication, set the connection's auth mode to kerberos")
+}
+
+func (g *kerberosGSS) GetInitTokenFromSpn(krbspn string) ([]byte, error) {
+	handshake, found := lookupKerberosHandshake(krbspn)
+	if !found {
+		return nil, fmt.Errorf("the server requested Kerberos authentication, set the connection's auth mode to kerberos")
+	}
+	return handshake.client.InitSecContext(handshake.spn)
+}
+
+// Continue completes the handshake, the Kerberos mechanism needs a single token from the client
+func (g *kerberosGSS) Continue(inToken []byte) (bool, []byte, error) {
+	return true, nil, nil
+}
+
+// kerberosPostgresConnector opens PostgreSQL connections authenticated with the Kerberos ticket of the connection's principal,
+// connections opened by the pool after the ticket expired get a renewed one
+type kerberosPostgresConnector struct {
+	dsn    string
+	config ConnectionConfig
+	dial   proxyDialFunc
+}
+
+// Connect implements driver.Connector
+func (c *kerberosPostgresConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	client, err := getKerberosClient(c.config)
+	if err != nil {
+		return nil, err
+	}
+
+	// The handshakes of the connections opened at once are told apart by the krbspn of each
+	krbspn, endHandshake := startKerberosHandshake(client, kerberosServiceName(c.config)+"/"+c.config.Host)
+	defer endHandshake()
+
+	connector, err := newPostgresConnector(fmt.Sprintf("%s krbspn=%s", c.dsn, quotePostgresParam([]byte(krbspn))), c.dial)
+	if err != nil {
+		return nil, err
+	}
+	return connector.Connect(ctx)
+}
+
+// Driver implements driver.Connector
+func (c *kerberosPostgresConnector) Driver() driver.Driver {
+	return &pq.Driver{}
+}
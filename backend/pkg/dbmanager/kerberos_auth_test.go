@@ -0,0 +1,63 @@
+package dbmanager
+
+import (
+	"testing"
+	"time"
+)
+
+type fakeKerberosClient struct {
+	name string
+	spns []string
+}
+
+func (c *fakeKerberosClient) Login() (time.Time, error) { return time.Now().Add(time.Hour), nil }
+
+func (c *fakeKerberosClient) InitSecContext(spn string) ([]byte, error) {
+	c.spns = append(c.spns, spn)
+	return []byte(c.name), nil
+}
+
+func (c *fakeKerberosClient) Destroy() {}
+
+func TestKerberosHandshakes(t *testing.T) {
+	alice := &fakeKerberosClient{name: "alice"}
+	bob := &fakeKerberosClient{name: "bob"}
+
+	// Two connections opened at once each get the token of their own principal
+	aliceSPN, endAlice := startKerberosHandshake(alice, "postgres/db.example.com")
+	bobSPN, endBob := startKerberosHandshake(bob, "post'gres/db.example.com")
+	gss := &kerberosGSS{}
+
+	token, err := gss.GetInitTokenFromSpn(bobSPN)
+	if err != nil || string(token) != "bob" {
+		t.Fatalf("GetInitTokenFromSpn(%q) = %q, %v, want bob's token", bobSPN, token, err)
+	}
+	token, err = gss.GetInitTokenFromSpn(aliceSPN)
+	if err != nil || string(token) != "alice" {
+		t.Fatalf("GetInitTokenFromSpn(%q) = %q, %v, want alice's token", aliceSPN, token, err)
+	}
+	if len(bob.spns) != 1 || bob.spns[0] != "post'gres/db.example.com" {
+		t.Errorf("bob's token was requested for %v, want the service principal name without the handshake ID", bob.spns)
+	}
+
+	endAlice()
+	if _, err := gss.GetInitTokenFromSpn(aliceSPN); err == nil {
+		t.Error("GetInitTokenFromSpn() succeeded for an ended handshake")
+	}
+	endBob()
+
+	if _, err := gss.GetInitToken("db.example.com", "postgres"); err == nil {
+		t.Error("GetInitToken() succeeded for a connection without a handshake")
+	}
+	if _, err := gss.GetInitTokenFromSpn("postgres/db.example.com"); err == nil {
+		t.Error("GetInitTokenFromSpn() succeeded for a krbspn without a handshake ID")
+	}
+}
+
+func TestQuotePostgresParamKerberosSPN(t *testing.T) {
+	got := quotePostgresParam([]byte(`post'gres\x/db.example.com#1`))
+	want := `'post\'gres\\x/db.example.com#1'`
+	if got != want {
+		t.Errorf("quotePostgresParam() = %s, want %s", got, want)
+	}
+}
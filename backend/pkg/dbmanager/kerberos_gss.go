@@ -0,0 +1,57 @@
+package dbmanager
+
+// Implements Kerberos authentication with gokrb5, a pure Go Kerberos client
+import (
+	"fmt"
+	"time"
+
+	"github.com/jcmturner/gokrb5/v8/client"
+	"github.com/jcmturner/gokrb5/v8/config"
+	"github.com/jcmturner/gokrb5/v8/keytab"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+)
+
+type gokrb5Client struct {
+	client         *client.Client
+	ticketLifetime time.Duration
+}
+
+func newGokrb5Client(principal, realm string, keytabContent []byte, krb5Conf string) (kerberosClient, error) {
+	cfg, err := config.NewFromString(krb5Conf)
+	if err != nil {
+		return nil, fmt.Errorf("invalid krb5.conf: %v", err)
+	}
+	kt := keytab.New()
+	if err := kt.Unmarshal(keytabContent); err != nil {
+		return nil, fmt.Errorf("invalid keytab: %v", err)
+	}
+
+	// Active Directory KDCs reject FAST pre-authentication
+	return &gokrb5Client{
+		client:         client.NewWithKeytab(principal, realm, kt, cfg, client.DisablePAFXFAST(true)),
+		ticketLifetime: cfg.LibDefaults.TicketLifetime,
+	}, nil
+}
+
+// Login implements kerberosClient. The KDC may cap the ticket lifetime, gokrb5 then renews its session in the background.
+func (c *gokrb5Client) Login() (time.Time, error) {
+	requestedAt := time.Now()
+	if err := c.client.Login(); err != nil {
+		return time.Time{}, err
+	}
+	return requestedAt.Add(c.ticketLifetime), nil
+}
+
+// InitSecContext implements kerberosClient
+func (c *gokrb5Client) InitSecContext(spn string) ([]byte, error) {
+	token, err := spnego.SPNEGOClient(c.client, spn).InitSecContext()
+	if err != nil {
+		return nil, fmt.Errorf("kerberos error (InitSecContext): %v", err)
+	}
+	return token.Marshal()
+}
+
+// Destroy implements kerberosClient
+func (c *gokrb5Client) Destroy() {
+	c.client.Destroy()
+}
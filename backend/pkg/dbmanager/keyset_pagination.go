@@ -0,0 +1,136 @@
+package dbmanager
+
+import (
+	"context"
+	"fmt"
+	"neobase-ai/internal/constants"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+const (
+	// keysetAfterParam is the parameter the key value of the last row seen is bound to
+	keysetAfterParam = "neobase_keyset_after"
+	// keysetDuplicatesColumn is the column counting the null & repeated keys of the KeysetUniquenessQuery
+	keysetDuplicatesColumn = "neobase_duplicates"
+)
+
+// keysetOffsetClause matches the trailing OFFSET offset_size & LIMIT 50 clauses of a paginated query, in the orders the
+// databases take them
+var keysetOffsetClause = regexp.MustCompile(`(?is)\s+(?:` +
+	`LIMIT\s+\d+\s+OFFSET\s+offset_size|` +
+	`OFFSET\s+offset_size\s+LIMIT\s+\d+|` +
+	`LIMIT\s+offset_size\s*,\s*\d+|` +
+	`OFFSET\s+offset_size\s+ROWS?\s+FETCH\s+(?:NEXT|FIRST)\s+\d+\s+ROWS?\s+ONLY` +
+	`)\s*;?\s*$`)
+
+// SupportsKeysetPagination returns true when the pages of the queries of the database type can be read by key
+func SupportsKeysetPagination(dbType string) bool {
+	switch dbType {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB, constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB,
+		constants.DatabaseTypeSingleStore, constants.DatabaseTypeClickhouse, constants.DatabaseTypeDB2, constants.DatabaseTypeDatabricks:
+		return true
+	}
+	return false
+}
+
+// WithKeysetPage returns the query reading the page of pageSize rows following the row whose key column is after, & the context
+// binding after to it. The paginated query is read without its OFFSET & LIMIT, filtered on the key & ordered by it, so the database seeks to
+// the page through the index of the key instead of reading & skipping every previous row. ok is false when the paginated
+// query doesn't end with its OFFSET & LIMIT or the key is not a plain column name, the page is read by offset then.
+// The key must be unique & never null, or rows are skipped or repeated at the ties, see KeysetUniquenessQuery.
+func WithKeysetPage(ctx context.Context, dbType, paginatedQuery, keyColumn string, descending bool, after interface{}, pageSize int) (context.Context, string, bool) {
+	if after == nil {
+		return ctx, "", false
+	}
+	base, ok := keysetBaseQuery(dbType, paginatedQuery, keyColumn)
+	if !ok {
+		return ctx, "", false
+	}
+
+	key := "neobase_keyset." + quoteKeysetColumn(dbType, keyColumn)
+	comparison, order := ">", "ASC"
+	if descending {
+		comparison, order = "<", "DESC"
+	}
+	if pageSize <= 0 {
+		pageSize = DefaultResultDisplayRows
+	}
+	limit := fmt.Sprintf("LIMIT %d", pageSize)
+	if dbType == constants.DatabaseTypeDB2 {
+		limit = fmt.Sprintf("FETCH FIRST %d ROWS ONLY", pageSize)
+	}
+	// The base query ends on a line of its own, a trailing comment would swallow the rest otherwise
+	query := fmt.Sprintf("SELECT * FROM (\n%s\n) AS neobase_keyset WHERE %s %s :%s ORDER BY %s %s %s", base, key, comparison, keysetAfterParam, key, order, limit)
+
+	params := map[string]interface{}{keysetAfterParam: after}
+	for name, value := range queryParams(ctx) {
+		params[name] = value
+	}
+	return WithQueryParams(ctx, params), query, true
+}
+
+// KeysetUniquenessQuery returns the query counting the rows of the paginated query whose key column is null or repeated, the
+// pages can be read by key when it counts none. ok is false when the pages can't be read by key anyway.
+func KeysetUniquenessQuery(dbType, paginatedQuery, keyColumn string) (string, bool) {
+	base, ok := keysetBaseQuery(dbType, paginatedQuery, keyColumn)
+	if !ok {
+		return "", false
+	}
+	// COUNT(DISTINCT) leaves the nulls out, so nulls & duplicates both make the counts differ
+	key := "neobase_keyset." + quoteKeysetColumn(dbType, keyColumn)
+	return fmt.Sprintf("SELECT COUNT(*) - COUNT(DISTINCT %s) AS %s FROM (\n%s\n) AS neobase_keyset", key, keysetDuplicatesColumn, base), true
+}
+
+// IsKeysetColumnUnique reports whether the result of the KeysetUniquenessQuery counted no null or repeated key
+func IsKeysetColumnUnique(result *QueryExecutionResult) bool {
+	if result == nil || result.Error != nil || result.Result == nil {
+		return false
+	}
+	rows := reflect.ValueOf(result.Result["results"])
+	if rows.Kind() != reflect.Slice || rows.Len() != 1 {
+		return false
+	}
+	row, ok := rows.Index(0).Interface().(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for column, value := range row {
+		if !strings.EqualFold(column, keysetDuplicatesColumn) {
+			continue
+		}
+		if bytes, isBytes := value.([]byte); isBytes {
+			value = string(bytes)
+		}
+		return fmt.Sprint(value) == "0"
+	}
+	return false
+}
+
+// keysetBaseQuery returns the paginated query without its OFFSET & LIMIT, ok is false when it doesn't end with them or the key is
+// not a plain column name
+func keysetBaseQuery(dbType, paginatedQuery, keyColumn string) (string, bool) {
+	if !SupportsKeysetPagination(dbType) || !isParameterName(keyColumn) {
+		return "", false
+	}
+	loc := keysetOffsetClause.FindStringIndex(paginatedQuery)
+	if loc == nil {
+		return "", false
+	}
+	base := strings.TrimSpace(paginatedQuery[:loc[0]])
+	if strings.Contains(base, "offset_size") || !strings.HasPrefix(strings.ToUpper(base), "SELECT") {
+		return "", false
+	}
+	return base, true
+}
+
+// quoteKeysetColumn quotes the key column as written, the name of the column in the result. Unquoted, PostgreSQL would fold a
+// mixed case name to lower case & DB2 to upper case, and a reserved word couldn't be a key.
+func quoteKeysetColumn(dbType, column string) string {
+	switch dbType {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB, constants.DatabaseTypeDB2:
+		return `"` + column + `"`
+	}
+	return "`" + column + "`"
+}
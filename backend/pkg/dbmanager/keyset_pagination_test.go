@@ -0,0 +1,91 @@
+package dbmanager
+
+import (
+	"context"
+	"neobase-ai/internal/constants"
+	"testing"
+)
+
+func TestWithKeysetPage(t *testing.T) {
+	tests := []struct {
+		name       string
+		dbType     string
+		query      string
+		key        string
+		descending bool
+		want       string
+		ok         bool
+	}{
+		{"ascending", constants.DatabaseTypePostgreSQL, "SELECT id, name FROM users ORDER BY id LIMIT 50 OFFSET offset_size", "id", false,
+			"SELECT * FROM (\nSELECT id, name FROM users ORDER BY id\n) AS neobase_keyset WHERE neobase_keyset.\"id\" > :neobase_keyset_after ORDER BY neobase_keyset.\"id\" ASC LIMIT 25", true},
+		{"descending", constants.DatabaseTypePostgreSQL, "SELECT id, name FROM users ORDER BY id DESC LIMIT 50 OFFSET offset_size;", "id", true,
+			"SELECT * FROM (\nSELECT id, name FROM users ORDER BY id DESC\n) AS neobase_keyset WHERE neobase_keyset.\"id\" < :neobase_keyset_after ORDER BY neobase_keyset.\"id\" DESC LIMIT 25", true},
+		{"mixed case postgres column", constants.DatabaseTypePostgreSQL, `SELECT "userId" FROM events LIMIT 50 OFFSET offset_size`, "userId", false,
+			"SELECT * FROM (\nSELECT \"userId\" FROM events\n) AS neobase_keyset WHERE neobase_keyset.\"userId\" > :neobase_keyset_after ORDER BY neobase_keyset.\"userId\" ASC LIMIT 25", true},
+		{"mysql offset first", constants.DatabaseTypeMySQL, "SELECT id FROM users LIMIT offset_size, 50", "id", false,
+			"SELECT * FROM (\nSELECT id FROM users\n) AS neobase_keyset WHERE neobase_keyset.`id` > :neobase_keyset_after ORDER BY neobase_keyset.`id` ASC LIMIT 25", true},
+		{"db2 fetch", constants.DatabaseTypeDB2, "SELECT ID FROM USERS OFFSET offset_size ROWS FETCH NEXT 50 ROWS ONLY", "ID", true,
+			"SELECT * FROM (\nSELECT ID FROM USERS\n) AS neobase_keyset WHERE neobase_keyset.\"ID\" < :neobase_keyset_after ORDER BY neobase_keyset.\"ID\" DESC FETCH FIRST 25 ROWS ONLY", true},
+		{"key with a table prefix", constants.DatabaseTypePostgreSQL, "SELECT u.id FROM users u LIMIT 50 OFFSET offset_size", "u.id", false, "", false},
+		{"key with a quote", constants.DatabaseTypePostgreSQL, "SELECT id FROM users LIMIT 50 OFFSET offset_size", `id"`, false, "", false},
+		{"without offset", constants.DatabaseTypePostgreSQL, "SELECT id FROM users LIMIT 50", "id", false, "", false},
+		{"offset in the middle", constants.DatabaseTypePostgreSQL, "SELECT id FROM (SELECT id FROM users LIMIT 50 OFFSET offset_size) u LIMIT 50 OFFSET offset_size", "id", false, "", false},
+		{"unsupported type", constants.DatabaseTypeMongoDB, "db.users.find({}).skip(offset_size).limit(50)", "_id", false, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, got, ok := WithKeysetPage(context.Background(), tt.dbType, tt.query, tt.key, tt.descending, 42, 25)
+			if ok != tt.ok {
+				t.Fatalf("WithKeysetPage(%q) ok = %v, want %v (%q)", tt.query, ok, tt.ok, got)
+			}
+			if !ok {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("WithKeysetPage(%q) =\n%q\nwant\n%q", tt.query, got, tt.want)
+			}
+			if after := queryParams(ctx)[keysetAfterParam]; after != 42 {
+				t.Errorf("WithKeysetPage(%q) binds %v to %s, want 42", tt.query, after, keysetAfterParam)
+			}
+		})
+	}
+
+	if _, _, ok := WithKeysetPage(context.Background(), constants.DatabaseTypePostgreSQL, "SELECT id FROM users LIMIT 50 OFFSET offset_size", "id", false, nil, 25); ok {
+		t.Errorf("WithKeysetPage without a key value read the page by key, want by offset")
+	}
+}
+
+func TestKeysetUniquenessQuery(t *testing.T) {
+	got, ok := KeysetUniquenessQuery(constants.DatabaseTypePostgreSQL, `SELECT "userId" FROM events ORDER BY "userId" LIMIT 50 OFFSET offset_size`, "userId")
+	want := "SELECT COUNT(*) - COUNT(DISTINCT neobase_keyset.\"userId\") AS neobase_duplicates FROM (\nSELECT \"userId\" FROM events ORDER BY \"userId\"\n) AS neobase_keyset"
+	if !ok || got != want {
+		t.Errorf("KeysetUniquenessQuery() = %q, %v, want %q", got, ok, want)
+	}
+	if _, ok := KeysetUniquenessQuery(constants.DatabaseTypeMySQL, "SELECT id FROM users LIMIT 50", "id"); ok {
+		t.Errorf("KeysetUniquenessQuery() of a query without offset is ok, want not ok")
+	}
+}
+
+func TestIsKeysetColumnUnique(t *testing.T) {
+	tests := []struct {
+		name   string
+		result *QueryExecutionResult
+		want   bool
+	}{
+		{"no duplicates", &QueryExecutionResult{Result: map[string]interface{}{"results": []map[string]interface{}{{"neobase_duplicates": int64(0)}}}}, true},
+		{"db2 upper case column", &QueryExecutionResult{Result: map[string]interface{}{"results": []map[string]interface{}{{"NEOBASE_DUPLICATES": "0"}}}}, true},
+		{"bytes", &QueryExecutionResult{Result: map[string]interface{}{"results": []interface{}{map[string]interface{}{"neobase_duplicates": []byte("0")}}}}, true},
+		{"duplicates or nulls", &QueryExecutionResult{Result: map[string]interface{}{"results": []map[string]interface{}{{"neobase_duplicates": int64(3)}}}}, false},
+		{"no rows", &QueryExecutionResult{Result: map[string]interface{}{"results": []map[string]interface{}{}}}, false},
+		{"nil", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsKeysetColumnUnique(tt.result); got != tt.want {
+				t.Errorf("IsKeysetColumnUnique() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
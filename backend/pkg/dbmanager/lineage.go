@@ -0,0 +1,1236 @@
+package dbmanager
+
+import (
+	"regexp"
+	"strings"
+
+	"neobase-ai/internal/constants"
+)
+
+// Operations recorded in the lineage of a connection
+const (
+	LineageOpInsert   = "INSERT"
+	LineageOpUpdate   = "UPDATE"
+	LineageOpMerge    = "MERGE"
+	LineageOpDelete   = "DELETE"
+	LineageOpCreate   = "CREATE"
+	LineageOpAlter    = "ALTER"
+	LineageOpRename   = "RENAME"
+	LineageOpDrop     = "DROP"
+	LineageOpTruncate = "TRUNCATE"
+)
+
+// LineageAllColumns stands for every column of a table, e.g. SELECT * or table level operations
+const LineageAllColumns = "*"
+
+// LineageColumnRef references a column of a table, the table is empty when an unqualified column could not be resolved
+type LineageColumnRef struct {
+	Table  string `json:"table"`
+	Column string `json:"column"`
+}
+
+// LineageColumn describes where the data written to a column comes from
+type LineageColumn struct {
+	Column     string             `json:"column"`
+	Expression string             `json:"expression,omitempty"`
+	Sources    []LineageColumnRef `json:"sources"`
+}
+
+// LineageStatement is the lineage of a single data modifying or DDL statement
+type LineageStatement struct {
+	Operation    string          `json:"operation"`
+	TargetTable  string          `json:"target_table"`
+	Columns      []LineageColumn `json:"columns"`
+	SourceTables []string        `json:"source_tables"`
+	Statement    string          `json:"statement"`
+}
+
+// ExtractLineage parses the statements of an executed query & returns which tables/columns they wrote and which inputs the data came from.
+// SELECT statements have no lineage and are skipped. Unquoted PostgreSQL identifiers are folded to lower case like the server does.
+func ExtractLineage(dbType string, query string) []LineageStatement {
+	if dbType == constants.DatabaseTypeMongoDB {
+		if lineage := extractMongoDBLineage(query); lineage != nil {
+			return []LineageStatement{*lineage}
+		}
+		return nil
+	}
+
+	foldCase := dbType == constants.DatabaseTypePostgreSQL || dbType == constants.DatabaseTypeYugabyteDB
+	statements := []LineageStatement{}
+	for _, tokens := range splitSQLTokens(tokenizeSQL(query, foldCase)) {
+		parser := &lineageParser{tokens: tokens}
+		lineage := parser.parseStatement()
+		if lineage == nil || lineage.TargetTable == "" {
+			continue
+		}
+		lineage.Statement = joinSQLTokens(tokens)
+		if lineage.Columns == nil {
+			lineage.Columns = []LineageColumn{}
+		}
+		lineage.SourceTables = lineageSourceTables(lineage.Columns, lineage.SourceTables)
+		statements = append(statements, *lineage)
+	}
+	return statements
+}
+
+const (
+	sqlTokenIdent = iota
+	sqlTokenQuoted
+	sqlTokenString
+	sqlTokenNumber
+	sqlTokenSymbol
+)
+
+// sqlToken is a lexical token of a statement, value holds the unquoted (& folded) identifier.
+// The position in the source query is kept so expressions are reported as written.
+type sqlToken struct {
+	kind  int
+	text  string
+	value string
+	src   []rune
+	start int
+	end   int
+}
+
+// tokenizeSQL splits a query into tokens, comments are dropped
+func tokenizeSQL(query string, foldCase bool) []sqlToken {
+	tokens := []sqlToken{}
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			i++
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-', r == '#' && !foldCase:
+			// MySQL also accepts "#" comments, it is an operator in PostgreSQL
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i < len(runes) && !(runes[i] == '*' && i+1 < len(runes) && runes[i+1] == '/') {
+				i++
+			}
+			i += 2
+		case r == '\'' || r == '"' || r == '`':
+			j := i + 1
+			for j < len(runes) {
+				if runes[j] == '\\' && r == '\'' {
+					j += 2
+					continue
+				}
+				if runes[j] == r {
+					// Doubled quotes escape the quote character
+					if j+1 < len(runes) && runes[j+1] == r {
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+			if j >= len(runes) {
+				j = len(runes) - 1
+			}
+			text := string(runes[i : j+1])
+			if r == '\'' {
+				tokens = append(tokens, sqlToken{kind: sqlTokenString, text: text, src: runes, start: i, end: j + 1})
+			} else {
+				value := strings.ReplaceAll(strings.Trim(text, string(r)), string(r)+string(r), string(r))
+				tokens = append(tokens, sqlToken{kind: sqlTokenQuoted, text: text, value: value, src: runes, start: i, end: j + 1})
+			}
+			i = j + 1
+		case r == '_' || r == '$' || r == '@' || isLetter(r):
+			j := i + 1
+			for j < len(runes) && (runes[j] == '_' || runes[j] == '$' || isLetter(runes[j]) || isDigit(runes[j])) {
+				j++
+			}
+			text := string(runes[i:j])
+			value := text
+			if foldCase {
+				value = strings.ToLower(text)
+			}
+			tokens = append(tokens, sqlToken{kind: sqlTokenIdent, text: text, value: value, src: runes, start: i, end: j})
+			i = j
+		case isDigit(r):
+			j := i + 1
+			for j < len(runes) && (isDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, sqlToken{kind: sqlTokenNumber, text: string(runes[i:j]), src: runes, start: i, end: j})
+			i = j
+		case r == ':' && i+1 < len(runes) && runes[i+1] == ':':
+			tokens = append(tokens, sqlToken{kind: sqlTokenSymbol, text: "::", src: runes, start: i, end: i + 2})
+			i += 2
+		default:
+			tokens = append(tokens, sqlToken{kind: sqlTokenSymbol, text: string(r), src: runes, start: i, end: i + 1})
+			i++
+		}
+	}
+	return tokens
+}
+
+func isLetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r > 127
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+// splitSQLTokens splits the tokens of a query into statements on the top level semicolons
+func splitSQLTokens(tokens []sqlToken) [][]sqlToken {
+	statements := [][]sqlToken{}
+	start, depth := 0, 0
+	for i, token := range tokens {
+		switch {
+		case token.isSymbol("("):
+			depth++
+		case token.isSymbol(")"):
+			depth--
+		case token.isSymbol(";") && depth <= 0:
+			if i > start {
+				statements = append(statements, tokens[start:i])
+			}
+			start, depth = i+1, 0
+		}
+	}
+	if start < len(tokens) {
+		statements = append(statements, tokens[start:])
+	}
+	return statements
+}
+
+// joinSQLTokens returns the source text spanned by the tokens
+func joinSQLTokens(tokens []sqlToken) string {
+	if len(tokens) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(string(tokens[0].src[tokens[0].start:tokens[len(tokens)-1].end]))
+}
+
+func (t sqlToken) isSymbol(symbol string) bool {
+	return t.kind == sqlTokenSymbol && t.text == symbol
+}
+
+func (t sqlToken) isKeyword(keywords ...string) bool {
+	if t.kind != sqlTokenIdent {
+		return false
+	}
+	for _, keyword := range keywords {
+		if strings.EqualFold(t.text, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t sqlToken) isName() bool {
+	// Session variables (@var) are not columns
+	return t.kind == sqlTokenQuoted || (t.kind == sqlTokenIdent && !strings.HasPrefix(t.text, "@") && !lineageReservedWords[strings.ToUpper(t.text)])
+}
+
+// lineageReservedWords are never taken as column names or aliases
+var lineageReservedWords = map[string]bool{}
+
+func init() {
+	for _, word := range strings.Fields(`SELECT FROM WHERE AND OR NOT NULL IS IN AS CASE WHEN THEN ELSE END LIKE ILIKE BETWEEN TRUE FALSE
+		DISTINCT ON JOIN INNER LEFT RIGHT FULL OUTER CROSS NATURAL USING GROUP BY ORDER HAVING LIMIT OFFSET FETCH ASC DESC INTERVAL
+		EXISTS ANY ALL SOME DEFAULT WITH OVER PARTITION ROWS RANGE PRECEDING FOLLOWING UNBOUNDED ROW FILTER UNION INTERSECT EXCEPT
+		MINUS INTO VALUES SET RETURNING WINDOW QUALIFY LATERAL CURRENT_DATE CURRENT_TIMESTAMP CURRENT_TIME LOCALTIMESTAMP CURRENT_USER
+		SESSION_USER COLLATE ESCAPE SIMILAR TO FOR NULLS UPDATE DELETE INSERT MERGE TABLE ONLY PREWHERE STRAIGHT_JOIN`) {
+		lineageReservedWords[word] = true
+	}
+}
+
+// lineageDateParts are the fields of EXTRACT(field FROM column)
+var lineageDateParts = map[string]bool{"YEAR": true, "QUARTER": true, "MONTH": true, "WEEK": true, "DAY": true, "DOW": true, "DOY": true,
+	"HOUR": true, "MINUTE": true, "SECOND": true, "EPOCH": true, "MILLISECOND": true, "MICROSECOND": true}
+
+// lineageSource is a relation referenced in a FROM clause, either a table or a derived table (subquery / CTE)
+type lineageSource struct {
+	table   string
+	derived *lineageSelect
+}
+
+// lineageSelectItem is an output column of a SELECT
+type lineageSelectItem struct {
+	name       string
+	expression string
+	refs       []LineageColumnRef
+	star       bool
+}
+
+// lineageSelect is the parsed lineage of a SELECT
+type lineageSelect struct {
+	items  []lineageSelectItem
+	tables []string
+}
+
+type lineageParser struct {
+	tokens []sqlToken
+	ctes   map[string]*lineageSelect
+}
+
+// parseStatement dispatches on the leading keyword of a statement
+func (p *lineageParser) parseStatement() *LineageStatement {
+	tokens := p.tokens
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	// Leading CTEs are available to the statement that follows
+	if tokens[0].isKeyword("WITH") {
+		tokens = p.parseCTEs(tokens[1:])
+		if len(tokens) == 0 {
+			return nil
+		}
+	}
+
+	switch {
+	case tokens[0].isKeyword("INSERT", "REPLACE", "UPSERT"):
+		return p.parseInsert(tokens)
+	case tokens[0].isKeyword("UPDATE"):
+		return p.parseUpdate(tokens)
+	case tokens[0].isKeyword("MERGE"):
+		return p.parseMerge(tokens)
+	case tokens[0].isKeyword("DELETE"):
+		i := skipKeywords(tokens, 1, "LOW_PRIORITY", "QUICK", "IGNORE", "FROM", "ONLY")
+		if table, _ := readQualifiedName(tokens, i); table != "" {
+			return &LineageStatement{Operation: LineageOpDelete, TargetTable: table}
+		}
+	case tokens[0].isKeyword("TRUNCATE"):
+		i := skipKeywords(tokens, 1, "TABLE", "ONLY")
+		if table, _ := readQualifiedName(tokens, i); table != "" {
+			return &LineageStatement{Operation: LineageOpTruncate, TargetTable: table}
+		}
+	case tokens[0].isKeyword("CREATE"):
+		return p.parseCreate(tokens)
+	case tokens[0].isKeyword("ALTER"):
+		return p.parseAlter(tokens)
+	case tokens[0].isKeyword("DROP"):
+		i := skipKeywords(tokens, 1, "TEMPORARY", "MATERIALIZED")
+		if i < len(tokens) && tokens[i].isKeyword("TABLE", "VIEW") {
+			i = skipKeywords(tokens, i+1, "IF", "EXISTS")
+			if table, _ := readQualifiedName(tokens, i); table != "" {
+				return &LineageStatement{Operation: LineageOpDrop, TargetTable: table}
+			}
+		}
+	case tokens[0].isKeyword("RENAME"):
+		// MySQL: RENAME TABLE old TO new
+		i := skipKeywords(tokens, 1, "TABLE")
+		oldName, next := readQualifiedName(tokens, i)
+		if next < len(tokens) && tokens[next].isKeyword("TO") {
+			if newName, _ := readQualifiedName(tokens, next+1); oldName != "" && newName != "" {
+				return renamedTableLineage(oldName, newName)
+			}
+		}
+	}
+	return nil
+}
+
+// parseCTEs registers the CTEs of a WITH clause & returns the tokens of the statement that follows
+func (p *lineageParser) parseCTEs(tokens []sqlToken) []sqlToken {
+	if p.ctes == nil {
+		p.ctes = map[string]*lineageSelect{}
+	}
+	i := skipKeywords(tokens, 0, "RECURSIVE")
+	for i < len(tokens) {
+		name, next := readQualifiedName(tokens, i)
+		if name == "" {
+			return nil
+		}
+		i = next
+		var columns []string
+		if i < len(tokens) && tokens[i].isSymbol("(") {
+			end := matchingParen(tokens, i)
+			columns = readNameList(tokens[i+1 : end])
+			i = end + 1
+		}
+		i = skipKeywords(tokens, i, "AS", "NOT", "MATERIALIZED")
+		if i >= len(tokens) || !tokens[i].isSymbol("(") {
+			return nil
+		}
+		end := matchingParen(tokens, i)
+		selectLineage := p.parseSelect(tokens[i+1 : end])
+		renameSelectItems(selectLineage, columns)
+		p.ctes[strings.ToLower(name)] = selectLineage
+		i = end + 1
+		if i < len(tokens) && tokens[i].isSymbol(",") {
+			i++
+			continue
+		}
+		return tokens[i:]
+	}
+	return nil
+}
+
+// parseInsert handles INSERT/REPLACE ... VALUES, INSERT ... SELECT & MySQL INSERT ... SET
+func (p *lineageParser) parseInsert(tokens []sqlToken) *LineageStatement {
+	i := skipKeywords(tokens, 1, "LOW_PRIORITY", "DELAYED", "HIGH_PRIORITY", "IGNORE", "OR", "REPLACE", "INTO", "TABLE", "OVERWRITE")
+	table, i := readQualifiedName(tokens, i)
+	if table == "" {
+		return nil
+	}
+	lineage := &LineageStatement{Operation: LineageOpInsert, TargetTable: table}
+
+	// Optional alias of the target
+	if i < len(tokens) && tokens[i].isKeyword("AS") {
+		i += 2
+	}
+
+	var columns []string
+	if i < len(tokens) && tokens[i].isSymbol("(") {
+		end := matchingParen(tokens, i)
+		inner := tokens[i+1 : end]
+		if len(inner) > 0 && !inner[0].isKeyword("SELECT", "WITH") {
+			columns = readNameList(inner)
+			i = end + 1
+		}
+	}
+
+	rest := tokens[i:]
+	rest = rest[:findInsertEnd(rest)]
+	switch {
+	case len(rest) == 0:
+	case rest[0].isKeyword("VALUES", "VALUE"):
+		for _, column := range columns {
+			lineage.Columns = append(lineage.Columns, LineageColumn{Column: column, Expression: "VALUES", Sources: []LineageColumnRef{}})
+		}
+	case rest[0].isKeyword("SET"):
+		lineage.Columns = p.parseAssignments(rest[1:], nil)
+	case rest[0].isKeyword("DEFAULT"):
+	default:
+		if rest[0].isKeyword("WITH") {
+			rest = p.parseCTEs(rest[1:])
+		}
+		selectLineage := p.parseSelect(rest)
+		lineage.SourceTables = selectLineage.tables
+		lineage.Columns = mapSelectToColumns(selectLineage, columns)
+	}
+	return lineage
+}
+
+// findInsertEnd returns the index of the upsert or RETURNING clause ending the rows of an INSERT
+func findInsertEnd(tokens []sqlToken) int {
+	for i := findTopLevel(tokens, 0, "ON", "RETURNING"); i < len(tokens); i = findTopLevel(tokens, i+1, "ON", "RETURNING") {
+		if tokens[i].isKeyword("RETURNING") || (i+1 < len(tokens) && tokens[i+1].isKeyword("CONFLICT", "DUPLICATE")) {
+			return i
+		}
+	}
+	return len(tokens)
+}
+
+// parseUpdate handles UPDATE ... SET with the PostgreSQL FROM clause & the MySQL multi-table syntax
+func (p *lineageParser) parseUpdate(tokens []sqlToken) *LineageStatement {
+	setIndex := findTopLevel(tokens, 1, "SET")
+	if setIndex >= len(tokens) {
+		return nil
+	}
+
+	sources := map[string]lineageSource{}
+	var order []string
+	relations := tokens[1:setIndex]
+	tables := p.parseFrom(relations[skipKeywords(relations, 0, "LOW_PRIORITY", "IGNORE", "ONLY"):], sources, &order)
+	if len(tables) == 0 {
+		return nil
+	}
+	lineage := &LineageStatement{Operation: LineageOpUpdate, TargetTable: tables[0]}
+
+	end := findTopLevel(tokens, setIndex+1, "FROM", "WHERE", "RETURNING", "ORDER", "LIMIT")
+	if end < len(tokens) && tokens[end].isKeyword("FROM") {
+		fromEnd := findTopLevel(tokens, end+1, "WHERE", "RETURNING")
+		tables = append(tables, p.parseFrom(tokens[end+1:fromEnd], sources, &order)...)
+	}
+
+	lineage.SourceTables = tables[1:]
+	lineage.Columns = p.parseAssignments(tokens[setIndex+1:end], &lineageScope{sources: sources, order: order})
+	return lineage
+}
+
+// parseMerge handles MERGE INTO target USING source ON ... WHEN [NOT] MATCHED THEN UPDATE SET / INSERT
+func (p *lineageParser) parseMerge(tokens []sqlToken) *LineageStatement {
+	usingIndex := findTopLevel(tokens, 1, "USING")
+	onIndex := findTopLevel(tokens, usingIndex+1, "ON")
+	if usingIndex >= len(tokens) || onIndex >= len(tokens) {
+		return nil
+	}
+
+	sources := map[string]lineageSource{}
+	var order []string
+	targets := p.parseFrom(tokens[skipKeywords(tokens, 1, "INTO"):usingIndex], sources, &order)
+	if len(targets) == 0 {
+		return nil
+	}
+	sourceTables := p.parseFrom(tokens[usingIndex+1:onIndex], sources, &order)
+	scope := &lineageScope{sources: sources, order: order}
+
+	lineage := &LineageStatement{Operation: LineageOpMerge, TargetTable: targets[0], SourceTables: sourceTables}
+	for i := findTopLevel(tokens, onIndex, "WHEN"); i < len(tokens); {
+		next := findTopLevel(tokens, i+1, "WHEN")
+		clause := tokens[i:next]
+		thenIndex := findTopLevel(clause, 0, "THEN")
+		if thenIndex+1 < len(clause) {
+			action := clause[thenIndex+1:]
+			switch {
+			case action[0].isKeyword("UPDATE") && len(action) > 1 && action[1].isKeyword("SET"):
+				lineage.Columns = append(lineage.Columns, p.parseAssignments(action[2:], scope)...)
+			case action[0].isKeyword("INSERT"):
+				lineage.Columns = append(lineage.Columns, p.parseMergeInsert(action[1:], scope)...)
+			}
+		}
+		i = next
+	}
+	return lineage
+}
+
+// parseMergeInsert maps the column list of a MERGE INSERT to its VALUES
+func (p *lineageParser) parseMergeInsert(tokens []sqlToken, scope *lineageScope) []LineageColumn {
+	if len(tokens) == 0 || !tokens[0].isSymbol("(") {
+		return nil
+	}
+	end := matchingParen(tokens, 0)
+	columns := readNameList(tokens[1:end])
+	valuesIndex := findTopLevel(tokens, end+1, "VALUES")
+	if valuesIndex+1 >= len(tokens) || !tokens[valuesIndex+1].isSymbol("(") {
+		return nil
+	}
+	valuesEnd := matchingParen(tokens, valuesIndex+1)
+	values := splitTopLevel(tokens[valuesIndex+2 : valuesEnd])
+
+	result := []LineageColumn{}
+	for idx, column := range columns {
+		lineageColumn := LineageColumn{Column: column, Sources: []LineageColumnRef{}}
+		if idx < len(values) {
+			lineageColumn.Expression = joinSQLTokens(values[idx])
+			lineageColumn.Sources = p.expressionRefs(values[idx], scope)
+		}
+		result = append(result, lineageColumn)
+	}
+	return result
+}
+
+// parseCreate handles CREATE TABLE ... AS SELECT, CREATE VIEW ... AS SELECT & plain CREATE TABLE
+func (p *lineageParser) parseCreate(tokens []sqlToken) *LineageStatement {
+	i := skipKeywords(tokens, 1, "OR", "REPLACE", "TEMP", "TEMPORARY", "UNLOGGED", "GLOBAL", "LOCAL", "MATERIALIZED", "SECURE", "RECURSIVE", "ALGORITHM")
+	// MySQL view options, e.g. ALGORITHM = MERGE DEFINER = user SQL SECURITY DEFINER
+	for i < len(tokens) && !tokens[i].isKeyword("TABLE", "VIEW") {
+		if tokens[i].isKeyword("FUNCTION", "PROCEDURE", "TRIGGER", "INDEX", "SCHEMA", "DATABASE", "SEQUENCE", "TYPE", "EXTENSION", "ROLE", "USER", "EVENT") {
+			return nil
+		}
+		i++
+	}
+	if i >= len(tokens) {
+		return nil
+	}
+	i = skipKeywords(tokens, i+1, "IF", "NOT", "EXISTS")
+	table, i := readQualifiedName(tokens, i)
+	if table == "" {
+		return nil
+	}
+	lineage := &LineageStatement{Operation: LineageOpCreate, TargetTable: table}
+
+	var columns []string
+	asIndex := findTopLevel(tokens, i, "AS")
+	if asIndex >= len(tokens) || asIndex+1 >= len(tokens) {
+		// Plain CREATE TABLE, the defined columns have no inputs
+		if i < len(tokens) && tokens[i].isSymbol("(") {
+			end := matchingParen(tokens, i)
+			for _, definition := range splitTopLevel(tokens[i+1 : end]) {
+				if len(definition) > 0 && definition[0].isName() && !definition[0].isKeyword("PRIMARY", "CONSTRAINT", "UNIQUE", "FOREIGN", "CHECK", "INDEX", "KEY") {
+					lineage.Columns = append(lineage.Columns, LineageColumn{Column: definition[0].value, Sources: []LineageColumnRef{}})
+				}
+			}
+		}
+		return lineage
+	}
+
+	if i < asIndex && tokens[i].isSymbol("(") {
+		end := matchingParen(tokens, i)
+		columns = readNameList(tokens[i+1 : end])
+	}
+
+	query := tokens[asIndex+1:]
+	if len(query) > 0 && query[0].isSymbol("(") {
+		query = query[1:matchingParen(query, 0)]
+	}
+	if len(query) > 0 && query[0].isKeyword("WITH") {
+		query = p.parseCTEs(query[1:])
+	}
+	if len(query) == 0 || !query[0].isKeyword("SELECT") {
+		return lineage
+	}
+
+	selectLineage := p.parseSelect(query)
+	lineage.SourceTables = selectLineage.tables
+	lineage.Columns = mapSelectToColumns(selectLineage, columns)
+	return lineage
+}
+
+// parseAlter handles column additions & renames, other alterations are recorded at the table level
+func (p *lineageParser) parseAlter(tokens []sqlToken) *LineageStatement {
+	i := skipKeywords(tokens, 1, "ONLINE", "IGNORE")
+	if i >= len(tokens) || !tokens[i].isKeyword("TABLE") {
+		return nil
+	}
+	i = skipKeywords(tokens, i+1, "IF", "EXISTS", "ONLY")
+	table, i := readQualifiedName(tokens, i)
+	if table == "" {
+		return nil
+	}
+	lineage := &LineageStatement{Operation: LineageOpAlter, TargetTable: table}
+
+	for _, action := range splitTopLevel(tokens[i:]) {
+		switch {
+		case len(action) >= 3 && action[0].isKeyword("RENAME") && action[1].isKeyword("TO", "AS"):
+			if newName, _ := readQualifiedName(action, 2); newName != "" {
+				return renamedTableLineage(table, newName)
+			}
+		case len(action) >= 4 && action[0].isKeyword("RENAME"):
+			// RENAME [COLUMN] old TO new
+			j := skipKeywords(action, 1, "COLUMN")
+			if j+2 < len(action) && action[j].isName() && action[j+1].isKeyword("TO") && action[j+2].isName() {
+				lineage.Operation = LineageOpRename
+				lineage.Columns = append(lineage.Columns, LineageColumn{
+					Column:     action[j+2].value,
+					Expression: "RENAME " + action[j].value,
+					Sources:    []LineageColumnRef{{Table: table, Column: action[j].value}},
+				})
+			}
+		case len(action) >= 3 && action[0].isKeyword("CHANGE"):
+			// MySQL: CHANGE [COLUMN] old new definition
+			j := skipKeywords(action, 1, "COLUMN")
+			if j+1 < len(action) && action[j].isName() && action[j+1].isName() && action[j].value != action[j+1].value {
+				lineage.Operation = LineageOpRename
+				lineage.Columns = append(lineage.Columns, LineageColumn{
+					Column:     action[j+1].value,
+					Expression: "RENAME " + action[j].value,
+					Sources:    []LineageColumnRef{{Table: table, Column: action[j].value}},
+				})
+			}
+		case len(action) >= 2 && action[0].isKeyword("ADD"):
+			j := skipKeywords(action, 1, "COLUMN", "IF", "NOT", "EXISTS")
+			if j < len(action) && action[j].isName() && !action[j].isKeyword("CONSTRAINT", "PRIMARY", "UNIQUE", "FOREIGN", "CHECK", "INDEX", "KEY") {
+				expression := ""
+				if defaultIndex := findTopLevel(action, j, "DEFAULT"); defaultIndex+1 < len(action) {
+					expression = "DEFAULT " + joinSQLTokens(action[defaultIndex+1:])
+				}
+				lineage.Columns = append(lineage.Columns, LineageColumn{Column: action[j].value, Expression: expression, Sources: []LineageColumnRef{}})
+			}
+		}
+	}
+	return lineage
+}
+
+// renamedTableLineage records that every column of the new table comes from the old one
+func renamedTableLineage(oldName, newName string) *LineageStatement {
+	return &LineageStatement{
+		Operation:   LineageOpRename,
+		TargetTable: newName,
+		Columns: []LineageColumn{{
+			Column:     LineageAllColumns,
+			Expression: "RENAME " + oldName,
+			Sources:    []LineageColumnRef{{Table: oldName, Column: LineageAllColumns}},
+		}},
+		SourceTables: []string{oldName},
+	}
+}
+
+// parseAssignments parses "column = expression" pairs, the sources are resolved against the scope
+func (p *lineageParser) parseAssignments(tokens []sqlToken, scope *lineageScope) []LineageColumn {
+	columns := []LineageColumn{}
+	for _, assignment := range splitTopLevel(tokens) {
+		eqIndex := -1
+		for i, token := range assignment {
+			if token.isSymbol("=") {
+				eqIndex = i
+				break
+			}
+		}
+		if eqIndex <= 0 {
+			continue
+		}
+
+		// Tuple assignments, e.g. (a, b) = (SELECT x, y FROM ...)
+		targets := []string{}
+		if assignment[0].isSymbol("(") {
+			targets = readNameList(assignment[1:matchingParen(assignment, 0)])
+		} else if name, _ := readQualifiedName(assignment, 0); name != "" {
+			parts := strings.Split(name, ".")
+			targets = append(targets, parts[len(parts)-1])
+		}
+
+		expression := assignment[eqIndex+1:]
+		refs := p.expressionRefs(expression, scope)
+		for _, target := range targets {
+			columns = append(columns, LineageColumn{Column: target, Expression: joinSQLTokens(expression), Sources: refs})
+		}
+	}
+	return columns
+}
+
+// parseSelect parses the output columns & the inputs of a SELECT, set operations are merged positionally
+func (p *lineageParser) parseSelect(tokens []sqlToken) *lineageSelect {
+	result := &lineageSelect{}
+	for len(tokens) > 0 && tokens[0].isSymbol("(") && matchingParen(tokens, 0) == len(tokens)-1 {
+		tokens = tokens[1 : len(tokens)-1]
+	}
+	if len(tokens) > 0 && tokens[0].isKeyword("WITH") {
+		tokens = p.parseCTEs(tokens[1:])
+	}
+
+	for _, part := range splitTopLevelKeywords(tokens, "UNION", "INTERSECT", "EXCEPT", "MINUS") {
+		partLineage := p.parseSimpleSelect(part)
+		if partLineage == nil {
+			continue
+		}
+		result.tables = appendUniqueStrings(result.tables, partLineage.tables...)
+		if len(result.items) == 0 {
+			result.items = partLineage.items
+			continue
+		}
+		for i := range result.items {
+			if i < len(partLineage.items) {
+				result.items[i].refs = appendUniqueRefs(result.items[i].refs, partLineage.items[i].refs...)
+			}
+		}
+	}
+	return result
+}
+
+// parseSimpleSelect parses a SELECT without set operations
+func (p *lineageParser) parseSimpleSelect(tokens []sqlToken) *lineageSelect {
+	for len(tokens) > 0 && tokens[0].isSymbol("(") && matchingParen(tokens, 0) == len(tokens)-1 {
+		tokens = tokens[1 : len(tokens)-1]
+	}
+	if len(tokens) == 0 || !tokens[0].isKeyword("SELECT") {
+		return nil
+	}
+
+	i := skipKeywords(tokens, 1, "DISTINCT", "ALL", "STRAIGHT_JOIN", "SQL_CALC_FOUND_ROWS", "SQL_NO_CACHE", "HIGH_PRIORITY")
+	// DISTINCT ON (...) & TOP n
+	if i < len(tokens) && tokens[i].isKeyword("ON") && i+1 < len(tokens) && tokens[i+1].isSymbol("(") {
+		i = matchingParen(tokens, i+1) + 1
+	}
+
+	fromIndex := findTopLevel(tokens, i, "FROM", "INTO")
+	itemsEnd := fromIndex
+	if fromIndex >= len(tokens) {
+		itemsEnd = findTopLevel(tokens, i, "WHERE", "GROUP", "HAVING", "ORDER", "LIMIT")
+	}
+
+	sources := map[string]lineageSource{}
+	var order []string
+	result := &lineageSelect{}
+	if fromIndex < len(tokens) && tokens[fromIndex].isKeyword("FROM") {
+		fromEnd := findTopLevel(tokens, fromIndex+1, "WHERE", "GROUP", "HAVING", "ORDER", "LIMIT", "OFFSET", "FETCH", "WINDOW", "QUALIFY", "FOR", "PREWHERE", "SETTINGS", "FORMAT")
+		result.tables = p.parseFrom(tokens[fromIndex+1:fromEnd], sources, &order)
+	}
+	scope := &lineageScope{sources: sources, order: order}
+
+	for _, item := range splitTopLevel(tokens[i:itemsEnd]) {
+		if len(item) == 0 {
+			continue
+		}
+
+		// "*" & "alias.*" pass every column of the inputs through
+		if item[len(item)-1].isSymbol("*") && (len(item) == 1 || (len(item) >= 3 && item[len(item)-2].isSymbol("."))) {
+			selectItem := lineageSelectItem{name: LineageAllColumns, expression: joinSQLTokens(item), star: true}
+			if len(item) == 1 {
+				for _, alias := range order {
+					selectItem.refs = appendUniqueRefs(selectItem.refs, scope.resolveStar(alias)...)
+				}
+			} else {
+				qualifier, _ := readQualifiedName(item[:len(item)-2], 0)
+				selectItem.refs = scope.resolveStar(qualifier)
+			}
+			result.items = append(result.items, selectItem)
+			continue
+		}
+
+		expression, alias := splitSelectAlias(item)
+		refs := p.expressionRefs(expression, scope)
+		name := alias
+		if name == "" {
+			// Unaliased column references keep the column name
+			if qualified, next := readQualifiedName(expression, 0); qualified != "" && next == len(expression) {
+				parts := strings.Split(qualified, ".")
+				name = parts[len(parts)-1]
+			} else {
+				name = joinSQLTokens(expression)
+			}
+		}
+		result.items = append(result.items, lineageSelectItem{name: name, expression: joinSQLTokens(expression), refs: refs})
+	}
+	return result
+}
+
+// parseFrom registers the relations of a FROM clause in the sources & returns the tables read, derived tables contribute their inputs
+func (p *lineageParser) parseFrom(tokens []sqlToken, sources map[string]lineageSource, order *[]string) []string {
+	tables := []string{}
+	expectRelation := true
+	for i := 0; i < len(tokens); {
+		token := tokens[i]
+		switch {
+		case token.isSymbol(","):
+			expectRelation = true
+			i++
+		case token.isKeyword("JOIN", "STRAIGHT_JOIN"):
+			expectRelation = true
+			i++
+		case token.isKeyword("ON"):
+			// Skip the join condition up to the next relation
+			expectRelation = false
+			i++
+		case token.isSymbol("("):
+			end := matchingParen(tokens, i)
+			if expectRelation {
+				derived := p.parseSelect(tokens[i+1 : end])
+				tables = appendUniqueStrings(tables, derived.tables...)
+				alias, next := readAlias(tokens, end+1)
+				if alias == "" {
+					alias = "__derived_" + string(rune('a'+len(*order)))
+				}
+				sources[strings.ToLower(alias)] = lineageSource{derived: derived}
+				*order = append(*order, strings.ToLower(alias))
+				expectRelation = false
+				i = next
+				continue
+			}
+			i = end + 1
+		case expectRelation && (token.isName() || token.isKeyword("ONLY", "LATERAL")):
+			if token.isKeyword("ONLY", "LATERAL") {
+				i++
+				continue
+			}
+			name, next := readQualifiedName(tokens, i)
+			i = next
+			// Table functions don't reference tables
+			if i < len(tokens) && tokens[i].isSymbol("(") {
+				i = matchingParen(tokens, i) + 1
+				_, i = readAlias(tokens, i)
+				expectRelation = false
+				continue
+			}
+			alias, next := readAlias(tokens, i)
+			i = next
+
+			source := lineageSource{table: name}
+			if cte, ok := p.ctes[strings.ToLower(name)]; ok {
+				source = lineageSource{derived: cte}
+				tables = appendUniqueStrings(tables, cte.tables...)
+			} else {
+				tables = appendUniqueStrings(tables, name)
+			}
+			key := strings.ToLower(alias)
+			if key == "" {
+				parts := strings.Split(name, ".")
+				key = strings.ToLower(parts[len(parts)-1])
+				// The fully qualified name can be used as a qualifier as well
+				if len(parts) > 1 {
+					sources[strings.ToLower(name)] = source
+				}
+			}
+			sources[key] = source
+			*order = append(*order, key)
+			expectRelation = false
+		default:
+			i++
+		}
+	}
+	return tables
+}
+
+// lineageScope resolves column references against the relations of a FROM clause
+type lineageScope struct {
+	sources map[string]lineageSource
+	order   []string
+}
+
+// resolve returns the input columns a (possibly qualified) column reference stands for
+func (s *lineageScope) resolve(qualifier, column string) []LineageColumnRef {
+	if s == nil {
+		return []LineageColumnRef{{Column: column}}
+	}
+	if qualifier != "" {
+		source, ok := s.sources[strings.ToLower(qualifier)]
+		if !ok {
+			return []LineageColumnRef{{Table: qualifier, Column: column}}
+		}
+		return source.resolve(column)
+	}
+
+	if len(s.order) == 1 {
+		return s.sources[s.order[0]].resolve(column)
+	}
+	// Several relations, only derived tables know their columns
+	for _, alias := range s.order {
+		if derived := s.sources[alias].derived; derived != nil {
+			for _, item := range derived.items {
+				if !item.star && strings.EqualFold(item.name, column) {
+					return item.refs
+				}
+			}
+		}
+	}
+	return []LineageColumnRef{{Column: column}}
+}
+
+// resolveStar returns the inputs of "alias.*"
+func (s *lineageScope) resolveStar(alias string) []LineageColumnRef {
+	source, ok := s.sources[strings.ToLower(alias)]
+	if !ok {
+		return []LineageColumnRef{{Table: alias, Column: LineageAllColumns}}
+	}
+	if source.derived == nil {
+		return []LineageColumnRef{{Table: source.table, Column: LineageAllColumns}}
+	}
+	refs := []LineageColumnRef{}
+	for _, item := range source.derived.items {
+		refs = appendUniqueRefs(refs, item.refs...)
+	}
+	return refs
+}
+
+func (s lineageSource) resolve(column string) []LineageColumnRef {
+	if s.derived == nil {
+		return []LineageColumnRef{{Table: s.table, Column: column}}
+	}
+	for _, item := range s.derived.items {
+		if !item.star && strings.EqualFold(item.name, column) {
+			return item.refs
+		}
+	}
+	// Column passed through a SELECT * of the derived table
+	refs := []LineageColumnRef{}
+	for _, item := range s.derived.items {
+		if item.star {
+			for _, ref := range item.refs {
+				refs = appendUniqueRefs(refs, LineageColumnRef{Table: ref.Table, Column: column})
+			}
+		}
+	}
+	if len(refs) == 0 && len(s.derived.tables) == 1 {
+		refs = append(refs, LineageColumnRef{Table: s.derived.tables[0], Column: column})
+	}
+	return refs
+}
+
+// expressionRefs returns the input columns referenced by an expression, sub-queries are resolved in their own scope
+func (p *lineageParser) expressionRefs(tokens []sqlToken, scope *lineageScope) []LineageColumnRef {
+	refs := []LineageColumnRef{}
+	for i := 0; i < len(tokens); {
+		token := tokens[i]
+		switch {
+		case token.isSymbol("(") && i+1 < len(tokens) && tokens[i+1].isKeyword("SELECT", "WITH"):
+			end := matchingParen(tokens, i)
+			subquery := p.parseSelect(tokens[i+1 : end])
+			for _, item := range subquery.items {
+				refs = appendUniqueRefs(refs, item.refs...)
+			}
+			i = end + 1
+		case token.isKeyword("AS") || token.isSymbol("::"):
+			// Type of a cast
+			i += 2
+		case token.isKeyword("INTERVAL"):
+			i += 2
+		case token.isName():
+			name, next := readQualifiedName(tokens, i)
+			// Function calls & EXTRACT(field FROM ...)
+			if next < len(tokens) && (tokens[next].isSymbol("(") || (tokens[next].isKeyword("FROM") && lineageDateParts[strings.ToUpper(name)])) {
+				i = next
+				continue
+			}
+			parts := strings.Split(name, ".")
+			column := parts[len(parts)-1]
+			qualifier := strings.Join(parts[:len(parts)-1], ".")
+			refs = appendUniqueRefs(refs, scope.resolve(qualifier, column)...)
+			i = next
+		default:
+			i++
+		}
+	}
+	return refs
+}
+
+// mapSelectToColumns maps the output columns of a SELECT to the target columns, positionally when a column list is given
+func mapSelectToColumns(selectLineage *lineageSelect, columns []string) []LineageColumn {
+	result := []LineageColumn{}
+	for i, item := range selectLineage.items {
+		column := item.name
+		if i < len(columns) {
+			column = columns[i]
+		} else if len(columns) > 0 {
+			break
+		}
+		refs := item.refs
+		if refs == nil {
+			refs = []LineageColumnRef{}
+		}
+		result = append(result, LineageColumn{Column: column, Expression: item.expression, Sources: refs})
+	}
+	return result
+}
+
+// renameSelectItems applies the column list of a CTE or view to its output columns
+func renameSelectItems(selectLineage *lineageSelect, columns []string) {
+	for i := range selectLineage.items {
+		if i < len(columns) {
+			selectLineage.items[i].name = columns[i]
+		}
+	}
+}
+
+// splitSelectAlias splits "expression [AS] alias"
+func splitSelectAlias(item []sqlToken) ([]sqlToken, string) {
+	n := len(item)
+	if n >= 3 && item[n-2].isKeyword("AS") && (item[n-1].isName() || item[n-1].kind == sqlTokenString) {
+		return item[:n-2], strings.Trim(item[n-1].valueOrText(), "'")
+	}
+	if n >= 2 && item[n-1].isName() {
+		previous := item[n-2]
+		if previous.isSymbol(")") || previous.kind == sqlTokenString || previous.kind == sqlTokenNumber || previous.isName() ||
+			previous.isKeyword("END", "NULL", "TRUE", "FALSE") {
+			return item[:n-1], item[n-1].value
+		}
+	}
+	return item, ""
+}
+
+func (t sqlToken) valueOrText() string {
+	if t.value != "" {
+		return t.value
+	}
+	return t.text
+}
+
+// readQualifiedName reads "name[.name...]" starting at i & returns the unquoted name with the index after it
+func readQualifiedName(tokens []sqlToken, i int) (string, int) {
+	if i >= len(tokens) || !tokens[i].isName() {
+		return "", i
+	}
+	parts := []string{tokens[i].value}
+	i++
+	for i+1 < len(tokens) && tokens[i].isSymbol(".") && (tokens[i+1].isName() || tokens[i+1].kind == sqlTokenIdent) {
+		parts = append(parts, tokens[i+1].value)
+		i += 2
+	}
+	return strings.Join(parts, "."), i
+}
+
+// readAlias reads an optional "[AS] alias" starting at i
+func readAlias(tokens []sqlToken, i int) (string, int) {
+	if i < len(tokens) && tokens[i].isKeyword("AS") {
+		i++
+	}
+	if i < len(tokens) && tokens[i].isName() && !tokens[i].isKeyword("USE", "FORCE", "STRAIGHT_JOIN", "TABLESAMPLE") {
+		alias := tokens[i].value
+		i++
+		// Column aliases of a derived table, e.g. AS t(a, b)
+		if i < len(tokens) && tokens[i].isSymbol("(") {
+			i = matchingParen(tokens, i) + 1
+		}
+		return alias, i
+	}
+	return "", i
+}
+
+// readNameList reads a comma separated list of column names
+func readNameList(tokens []sqlToken) []string {
+	names := []string{}
+	for _, part := range splitTopLevel(tokens) {
+		if name, _ := readQualifiedName(part, 0); name != "" {
+			segments := strings.Split(name, ".")
+			names = append(names, segments[len(segments)-1])
+		}
+	}
+	return names
+}
+
+// skipKeywords skips any of the keywords starting at i
+func skipKeywords(tokens []sqlToken, i int, keywords ...string) int {
+	for i < len(tokens) && tokens[i].isKeyword(keywords...) {
+		i++
+	}
+	return i
+}
+
+// matchingParen returns the index of the parenthesis closing the one at i
+func matchingParen(tokens []sqlToken, i int) int {
+	depth := 0
+	for j := i; j < len(tokens); j++ {
+		if tokens[j].isSymbol("(") {
+			depth++
+		} else if tokens[j].isSymbol(")") {
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return len(tokens) - 1
+}
+
+// findTopLevel returns the index of the first top level keyword starting at i, len(tokens) if there is none
+func findTopLevel(tokens []sqlToken, i int, keywords ...string) int {
+	depth := 0
+	for j := i; j < len(tokens); j++ {
+		switch {
+		case tokens[j].isSymbol("("):
+			depth++
+		case tokens[j].isSymbol(")"):
+			depth--
+		case depth == 0 && tokens[j].isKeyword(keywords...):
+			return j
+		}
+	}
+	return len(tokens)
+}
+
+// splitTopLevel splits tokens on the top level commas
+func splitTopLevel(tokens []sqlToken) [][]sqlToken {
+	parts := [][]sqlToken{}
+	start, depth := 0, 0
+	for i, token := range tokens {
+		switch {
+		case token.isSymbol("("):
+			depth++
+		case token.isSymbol(")"):
+			depth--
+		case token.isSymbol(",") && depth == 0:
+			parts = append(parts, tokens[start:i])
+			start = i + 1
+		}
+	}
+	if start < len(tokens) {
+		parts = append(parts, tokens[start:])
+	}
+	return parts
+}
+
+// splitTopLevelKeywords splits tokens on top level keywords, e.g. the set operations of a SELECT
+func splitTopLevelKeywords(tokens []sqlToken, keywords ...string) [][]sqlToken {
+	parts := [][]sqlToken{}
+	for {
+		index := findTopLevel(tokens, 0, keywords...)
+		parts = append(parts, tokens[:index])
+		if index >= len(tokens) {
+			return parts
+		}
+		tokens = tokens[skipKeywords(tokens, index+1, "ALL", "DISTINCT"):]
+	}
+}
+
+// lineageSourceTables adds the tables of the column sources to the source tables of a statement
+func lineageSourceTables(columns []LineageColumn, tables []string) []string {
+	result := appendUniqueStrings([]string{}, tables...)
+	for _, column := range columns {
+		for _, source := range column.Sources {
+			if source.Table != "" {
+				result = appendUniqueStrings(result, source.Table)
+			}
+		}
+	}
+	return result
+}
+
+func appendUniqueStrings(values []string, additions ...string) []string {
+	for _, addition := range additions {
+		exists := false
+		for _, value := range values {
+			if value == addition {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			values = append(values, addition)
+		}
+	}
+	return values
+}
+
+func appendUniqueRefs(refs []LineageColumnRef, additions ...LineageColumnRef) []LineageColumnRef {
+	if refs == nil {
+		refs = []LineageColumnRef{}
+	}
+	for _, addition := range additions {
+		exists := false
+		for _, ref := range refs {
+			if ref == addition {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			refs = append(refs, addition)
+		}
+	}
+	return refs
+}
+
+var (
+	mongoLineageCallRegex  = regexp.MustCompile(`^\s*db\.(?:getCollection\(\s*["']([^"']+)["']\s*\)|([\w$-]+))\.(\w+)\s*\(`)
+	mongoLineageOutRegex   = regexp.MustCompile(`["']?\$out["']?\s*:\s*(?:["']([^"']+)["']|\{[^}]*["']?coll["']?\s*:\s*["']([^"']+)["'])`)
+	mongoLineageMergeRegex = regexp.MustCompile(`["']?\$merge["']?\s*:\s*(?:["']([^"']+)["']|\{[^}]*["']?into["']?\s*:\s*(?:["']([^"']+)["']|\{[^}]*["']?coll["']?\s*:\s*["']([^"']+)["']))`)
+	mongoLineageFromRegex  = regexp.MustCompile(`["']?(?:from|coll)["']?\s*:\s*["']([^"']+)["']`)
+)
+
+// extractMongoDBLineage records the collection written by a MongoDB operation, aggregations writing with $out/$merge read from
+// the source collection & the $lookup/$unionWith collections
+func extractMongoDBLineage(query string) *LineageStatement {
+	match := mongoLineageCallRegex.FindStringSubmatch(query)
+	if match == nil {
+		return nil
+	}
+	collection := match[1]
+	if collection == "" {
+		collection = match[2]
+	}
+
+	switch method := match[3]; method {
+	case "insertOne", "insertMany", "insert":
+		return &LineageStatement{Operation: LineageOpInsert, TargetTable: collection, Columns: []LineageColumn{}, SourceTables: []string{}, Statement: query}
+	case "updateOne", "updateMany", "update", "replaceOne", "findOneAndUpdate", "findOneAndReplace", "findAndModify", "bulkWrite":
+		return &LineageStatement{Operation: LineageOpUpdate, TargetTable: collection, Columns: []LineageColumn{}, SourceTables: []string{}, Statement: query}
+	case "deleteOne", "deleteMany", "remove", "findOneAndDelete":
+		return &LineageStatement{Operation: LineageOpDelete, TargetTable: collection, Columns: []LineageColumn{}, SourceTables: []string{}, Statement: query}
+	case "drop":
+		return &LineageStatement{Operation: LineageOpDrop, TargetTable: collection, Columns: []LineageColumn{}, SourceTables: []string{}, Statement: query}
+	case "renameCollection":
+		if target := regexp.MustCompile(`renameCollection\(\s*["']([^"']+)["']`).FindStringSubmatch(query); target != nil {
+			lineage := renamedTableLineage(collection, target[1])
+			lineage.Statement = query
+			return lineage
+		}
+	case "aggregate":
+		target := ""
+		if out := mongoLineageOutRegex.FindStringSubmatch(query); out != nil {
+			target = out[1] + out[2]
+		} else if merge := mongoLineageMergeRegex.FindStringSubmatch(query); merge != nil {
+			target = merge[1] + merge[2] + merge[3]
+		}
+		if target == "" {
+			return nil
+		}
+
+		sources := []string{collection}
+		for _, from := range mongoLineageFromRegex.FindAllStringSubmatch(query, -1) {
+			if from[1] != target {
+				sources = appendUniqueStrings(sources, from[1])
+			}
+		}
+		refs := []LineageColumnRef{}
+		for _, source := range sources {
+			refs = append(refs, LineageColumnRef{Table: source, Column: LineageAllColumns})
+		}
+		return &LineageStatement{
+			Operation:    LineageOpInsert,
+			TargetTable:  target,
+			Columns:      []LineageColumn{{Column: LineageAllColumns, Expression: "aggregate", Sources: refs}},
+			SourceTables: sources,
+			Statement:    query,
+		}
+	}
+	return nil
+}
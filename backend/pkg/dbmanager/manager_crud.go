@@ -34,33 +34,41 @@ type cleanupMetrics struct {
 
 // DatabasePool represents a shared database connection with reference counting
 type DatabasePool struct {
-	DB         *sql.DB
-	GORMDB     *gorm.DB
-	RefCount   int
-	Config     ConnectionConfig
-	LastUsed   time.Time
-	Mutex      sync.Mutex // For thread-safe reference counting
-	MongoDBObj interface{}
+	DB            *sql.DB
+	GORMDB        *gorm.DB
+	RefCount      int
+	Config        ConnectionConfig
+	LastUsed      time.Time
+	Mutex         sync.Mutex // For thread-safe reference counting
+	MongoDBObj    interface{}
+	ServerVersion string // Detected when the pool was opened
+	ServerFlavor  string // Detected with the version, see detectServerFlavor
 }
 
 // Manager handles database connections
 type Manager struct {
-	connections      map[string]*Connection    // chatID -> connection
-	drivers          map[string]DatabaseDriver // type -> driver
-	mu               sync.RWMutex
-	redisRepo        redis.IRedisRepositories
-	stopCleanup      chan struct{} // Channel to stop cleanup routine
-	eventChan        chan SSEEvent // Channel for SSE events
-	schemaManager    *SchemaManager
-	streamHandler    StreamHandler              // Changed from *StreamHandler to StreamHandler
-	activeExecutions map[string]*QueryExecution // key: streamID
-	executionMu      sync.RWMutex
-	cleanupMetrics   cleanupMetrics
-	fetchers         map[string]FetcherFactory
-	fetchersMu       sync.RWMutex
-	dbPools          map[string]*DatabasePool // key: hash of connection config
-	dbPoolsMu        sync.RWMutex
-	poolMetrics      struct {
+	connections           map[string]*Connection    // chatID -> connection
+	drivers               map[string]DatabaseDriver // type -> driver
+	mu                    sync.RWMutex
+	redisRepo             redis.IRedisRepositories
+	stopCleanup           chan struct{} // Channel to stop cleanup routine
+	eventChan             chan SSEEvent // Channel for SSE events
+	schemaManager         *SchemaManager
+	streamHandler         StreamHandler              // Changed from *StreamHandler to StreamHandler
+	activeExecutions      map[string]*QueryExecution // key: streamID
+	executionMu           sync.RWMutex
+	cleanupMetrics        cleanupMetrics
+	fetchers              map[string]FetcherFactory
+	fetchersMu            sync.RWMutex
+	dbPools               map[string]*DatabasePool // key: hash of connection config
+	dbPoolsMu             sync.RWMutex
+	watches               map[string]*changeStreamWatch // key: watch ID
+	watchesMu             sync.Mutex
+	queryLimiters         map[string]*queryLimiter // key: connection pool, see query_limiter.go
+	queryLimitersMu       sync.Mutex
+	transactionSessions   map[string]*transactionSession // key: chatID, see transaction_session.go
+	transactionSessionsMu sync.Mutex
+	poolMetrics           struct {
 		totalPools       int
 		totalConnections int
 		reuseCount       int
@@ -75,16 +83,19 @@ func NewManager(redisRepo redis.IRedisRepositories, encryptionKey string) (*Mana
 	}
 
 	m := &Manager{
-		connections:      make(map[string]*Connection),
-		drivers:          make(map[string]DatabaseDriver),
-		redisRepo:        redisRepo,
-		stopCleanup:      make(chan struct{}),
-		eventChan:        make(chan SSEEvent, 100),
-		schemaManager:    schemaManager,
-		activeExecutions: make(map[string]*QueryExecution),
-		executionMu:      sync.RWMutex{},
-		fetchers:         make(map[string]FetcherFactory),
-		dbPools:          make(map[string]*DatabasePool),
+		connections:         make(map[string]*Connection),
+		drivers:             make(map[string]DatabaseDriver),
+		redisRepo:           redisRepo,
+		stopCleanup:         make(chan struct{}),
+		eventChan:           make(chan SSEEvent, 100),
+		schemaManager:       schemaManager,
+		activeExecutions:    make(map[string]*QueryExecution),
+		executionMu:         sync.RWMutex{},
+		fetchers:            make(map[string]FetcherFactory),
+		dbPools:             make(map[string]*DatabasePool),
+		watches:             make(map[string]*changeStreamWatch),
+		queryLimiters:       make(map[string]*queryLimiter),
+		transactionSessions: make(map[string]*transactionSession),
 	}
 
 	// Set the DBManager in the SchemaManager
@@ -116,6 +127,31 @@ func NewManager(redisRepo redis.IRedisRepositories, encryptionKey string) (*Mana
 		return NewMySQLSchemaFetcher(db)
 	})
 
+	// Add MariaDB schema fetcher registration
+	m.RegisterFetcher("mariadb", func(db DBExecutor) SchemaFetcher {
+		return NewMariaDBSchemaFetcher(db)
+	})
+
+	// Add SingleStore schema fetcher registration
+	m.RegisterFetcher("singlestore", func(db DBExecutor) SchemaFetcher {
+		return NewSingleStoreSchemaFetcher(db)
+	})
+
+	// Add DB2 schema fetcher registration
+	m.RegisterFetcher("db2", func(db DBExecutor) SchemaFetcher {
+		return NewDB2SchemaFetcher(db)
+	})
+
+	// Add Databricks schema fetcher registration
+	m.RegisterFetcher("databricks", func(db DBExecutor) SchemaFetcher {
+		return NewDatabricksSchemaFetcher(db)
+	})
+
+	// Add Firestore schema fetcher registration
+	m.RegisterFetcher("firestore", func(db DBExecutor) SchemaFetcher {
+		return NewFirestoreSchemaFetcher(db)
+	})
+
 	// Add ClickHouse schema fetcher registration
 	m.RegisterFetcher("clickhouse", func(db DBExecutor) SchemaFetcher {
 		return NewClickHouseSchemaFetcher(db)
@@ -140,6 +176,23 @@ func (m *Manager) registerDefaultDrivers() {
 	// Register MySQL driver
 	m.RegisterDriver("mysql", NewMySQLDriver())
 
+	// Register MariaDB driver (MySQL protocol with MariaDB aware schema)
+	m.RegisterDriver("mariadb", NewMariaDBDriver())
+
+	// Register SingleStore driver (MySQL protocol)
+	m.RegisterDriver("singlestore", NewSingleStoreDriver())
+
+	// Register DB2 driver, only linked when building with the "db2" tag
+	if DB2Supported() {
+		m.RegisterDriver("db2", NewDB2Driver())
+	}
+
+	// Register Databricks driver
+	m.RegisterDriver("databricks", NewDatabricksDriver())
+
+	// Register Firestore driver
+	m.RegisterDriver("firestore", NewFirestoreDriver())
+
 	// Register ClickHouse driver
 	m.RegisterDriver("clickhouse", NewClickHouseDriver())
 
@@ -205,12 +258,20 @@ func (m *Manager) Connect(chatID, userID, streamID string, config ConnectionConf
 
 	// Generate a unique key for this database configuration
 	configKey := utils.GenerateConfigKey(map[string]interface{}{
-		"type":     config.Type,
-		"host":     config.Host,
-		"port":     config.Port,
-		"username": config.Username,
-		"password": config.Password,
-		"database": config.Database, // Add database to the key to differentiate connections to different databases
+		"type":           config.Type,
+		"host":           config.Host,
+		"port":           config.Port,
+		"username":       config.Username,
+		"password":       config.Password,
+		"database":       config.Database, // Add database to the key to differentiate connections to different databases
+		"httpPath":       config.HTTPPath, // Differentiate Databricks warehouses sharing a workspace host
+		"authMode":       config.AuthMode,
+		"azureClientID":  config.AzureClientID, // Differentiate Azure AD identities sharing a database user
+		"socketPath":     config.SocketPath,
+		"awsAccessKeyID": config.AWSAccessKeyID, // Differentiate AWS identities sharing a database user
+		"readPreference": config.ReadPreference, // MongoDB clients apply the preference & concerns to all their operations
+		"readConcern":    config.ReadConcern,
+		"writeConcern":   config.WriteConcern,
 	})
 	log.Printf("DBManager -> Connect -> Generated config key: %s", configKey)
 
@@ -259,16 +320,18 @@ func (m *Manager) Connect(chatID, userID, streamID string, config ConnectionConf
 		log.Printf("DBManager -> Connect -> Connection config: %+v", config)
 		// Create a new connection using the shared pool
 		conn = &Connection{
-			DB:          pool.GORMDB,
-			LastUsed:    time.Now(),
-			Status:      StatusConnected,
-			Config:      config,
-			UserID:      userID,
-			ChatID:      chatID,
-			StreamID:    streamID,
-			Subscribers: make(map[string]bool),
-			SubLock:     sync.RWMutex{},
-			ConfigKey:   configKey, // Store the config key for reference
+			DB:            pool.GORMDB,
+			LastUsed:      time.Now(),
+			Status:        StatusConnected,
+			Config:        config,
+			UserID:        userID,
+			ChatID:        chatID,
+			StreamID:      streamID,
+			Subscribers:   make(map[string]bool),
+			SubLock:       sync.RWMutex{},
+			ConfigKey:     configKey, // Store the config key for reference
+			ServerVersion: pool.ServerVersion,
+			ServerFlavor:  pool.ServerFlavor,
 		}
 
 		// Set MongoDBObj for MongoDB connections when reusing from pool
@@ -289,13 +352,24 @@ func (m *Manager) Connect(chatID, userID, streamID string, config ConnectionConf
 
 		log.Printf("DBManager -> Connect -> Connection Host, Name, Type: %+v, %+v, %+v", config.Host, config.Database, config.Type)
 		log.Printf("DBManager -> Connect -> Driver connection successful, creating new pool")
+
+		// Generated queries & their validation depend on the server version, some drivers detect it while connecting
+		if conn.ServerVersion == "" {
+			conn.ServerVersion = detectServerVersion(conn)
+		}
+		// A MariaDB server is reachable through a mysql connection & the other way round, schema & syntax follow the server
+		conn.ServerFlavor = detectServerFlavor(config.Type, conn.ServerVersion)
+		log.Printf("DBManager -> Connect -> Server version: %s, flavor: %s", conn.ServerVersion, conn.ServerFlavor)
+
 		// Create and store the new pool
 		newPool := &DatabasePool{
-			DB:       nil, // The driver doesn't expose sql.DB directly
-			GORMDB:   conn.DB,
-			RefCount: 1,
-			Config:   config,
-			LastUsed: time.Now(),
+			DB:            nil, // The driver doesn't expose sql.DB directly
+			GORMDB:        conn.DB,
+			RefCount:      1,
+			Config:        config,
+			LastUsed:      time.Now(),
+			ServerVersion: conn.ServerVersion,
+			ServerFlavor:  conn.ServerFlavor,
 		}
 
 		// For MongoDB, store the MongoDB client in the pool
@@ -320,6 +394,9 @@ func (m *Manager) Connect(chatID, userID, streamID string, config ConnectionConf
 		conn.ConfigKey = configKey
 	}
 
+	// Open the read replicas of the connection, reads are routed to them by ExecuteQuery
+	m.connectReadReplicas(driver, conn)
+
 	// Initialize subscribers map with existing subscribers
 	conn.Subscribers = make(map[string]bool)
 
@@ -383,6 +460,11 @@ func (m *Manager) Disconnect(chatID, userID string, deleteSchema bool) error {
 
 	log.Printf("DBManager -> Disconnect -> Starting disconnect for chatID: %s", chatID)
 
+	// Change streams are closed with the connection they were opened on
+	m.stopChangeStreamWatches(chatID, WatchStopReasonDisconnected)
+	// An open transaction is rolled back before its connection is released
+	m.endTransactionSessions(chatID, TransactionEndReasonDisconnected)
+
 	// Get the config key for the shared pool
 	configKey := conn.ConfigKey
 
@@ -416,6 +498,11 @@ func (m *Manager) Disconnect(chatID, userID string, deleteSchema bool) error {
 	}
 	m.dbPoolsMu.Unlock()
 
+	// Read replica connections aren't pooled, they belong to the chat's connection
+	if driver := m.drivers[conn.Config.Type]; driver != nil {
+		m.disconnectReadReplicas(driver, conn)
+	}
+
 	// Remove from connections map
 	m.mu.Lock()
 	delete(m.connections, chatID)
@@ -471,12 +558,22 @@ func (m *Manager) GetConnection(chatID string) (DBExecutor, error) {
 	log.Printf("DBManager -> GetConnection -> Returning connection for chatID: %s, database: %s",
 		chatID, conn.Config.Database)
 
-	// Create appropriate wrapper based on database type
-	switch conn.Config.Type {
+	// Create appropriate wrapper based on the database type the server speaks
+	switch conn.Dialect() {
 	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB:
 		return NewPostgresWrapper(conn.DB, m, chatID), nil
 	case constants.DatabaseTypeMySQL:
 		return NewMySQLWrapper(conn.DB, m, chatID), nil
+	case constants.DatabaseTypeMariaDB:
+		return NewMariaDBWrapper(conn.DB, m, chatID), nil
+	case constants.DatabaseTypeSingleStore:
+		return NewSingleStoreWrapper(conn.DB, m, chatID), nil
+	case constants.DatabaseTypeDB2:
+		return NewDB2Wrapper(conn.DB, m, chatID), nil
+	case constants.DatabaseTypeDatabricks:
+		return NewDatabricksWrapper(conn.DB, m, chatID), nil
+	case constants.DatabaseTypeFirestore:
+		return NewFirestoreWrapper(conn.DB, m, chatID), nil
 	case constants.DatabaseTypeClickhouse:
 		return NewClickHouseWrapper(conn.DB, m, chatID), nil
 	case constants.DatabaseTypeMongoDB:
@@ -524,7 +621,10 @@ func (m *Manager) cleanup() {
 		if time.Since(conn.LastUsed) > idleTimeout {
 			log.Printf("DBManager -> cleanup -> Removing idle connection for chatID: %s (idle for %v)", chatID, time.Since(conn.LastUsed))
 
-			// Don't actually disconnect here, just remove from the map
+			// Don't actually disconnect here, just remove from the map, read replicas aren't pooled so they are closed
+			if driver, exists := m.drivers[conn.Config.Type]; exists {
+				m.disconnectReadReplicas(driver, conn)
+			}
 			delete(m.connections, chatID)
 			m.cleanupMetrics.connectionsRemoved++
 		}
@@ -575,6 +675,7 @@ func (m *Manager) Stop() error {
 	m.mu.Lock()
 	for chatID, conn := range m.connections {
 		if driver, exists := m.drivers[conn.Config.Type]; exists {
+			m.disconnectReadReplicas(driver, conn)
 			if err := driver.Disconnect(conn); err != nil {
 				log.Printf("DBManager -> Stop -> Error disconnecting chat %s: %v", chatID, err)
 			} else {
@@ -822,7 +923,7 @@ func (m *Manager) doSchemaCheck(chatID string) error {
 	defer cancel()
 
 	// Pass selectedTables instead of hardcoded "ALL"
-	diff, hasChanged, err := m.schemaManager.CheckSchemaChanges(ctx, chatID, conn, dbConn.Config.Type, selectedTables)
+	diff, hasChanged, err := m.schemaManager.CheckSchemaChanges(ctx, chatID, conn, dbConn.Dialect(), selectedTables)
 	if err != nil {
 		// Check if this is a first-time schema storage error, which we can ignore
 		if strings.Contains(err.Error(), "first-time schema storage") || strings.Contains(err.Error(), "key does not exist") {
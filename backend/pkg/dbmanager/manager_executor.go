@@ -2,9 +2,7 @@ package dbmanager
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"net/url"
 	"os"
@@ -17,10 +15,8 @@ import (
 	_ "github.com/lib/pq" // PostgreSQL/YugabyteDB Driver
 
 	"crypto/tls"
-	"crypto/x509"
 	"neobase-ai/internal/apis/dtos"
 	"neobase-ai/internal/constants"
-	"neobase-ai/internal/utils"
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -38,33 +34,45 @@ type QueryExecution struct {
 }
 
 func (m *Manager) CancelQueryExecution(streamID string) {
+	// The execution is taken out under the lock, stopping it on the server is a round trip that must not block the other executions
 	m.executionMu.Lock()
-	defer m.executionMu.Unlock()
+	execution, exists := m.activeExecutions[streamID]
+	var tx Transaction
+	if exists {
+		tx = execution.Tx
+		delete(m.activeExecutions, streamID)
+	}
+	m.executionMu.Unlock()
+
+	if !exists {
+		return
+	}
+	log.Printf("Cancelling query execution for streamID: %s", streamID)
 
-	if execution, exists := m.activeExecutions[streamID]; exists {
-		log.Printf("Cancelling query execution for streamID: %s", streamID)
+	// Cancel the context first
+	execution.CancelFunc()
 
-		// Cancel the context first
-		execution.CancelFunc()
+	// The drivers stop waiting for the statement, the server is told to stop running it too.
+	// Reads routed to a replica don't know the session running them, they only stop through the cancelled context:
+	// lib/pq sends a cancel request to the server & the MySQL driver closes the connection.
+	if tx != nil {
+		cancelOnServer(tx)
 
 		// Rollback transaction if it exists
-		if execution.Tx != nil {
-			if err := execution.Tx.Rollback(); err != nil {
-				log.Printf("Error rolling back transaction: %v", err)
-			}
+		if err := tx.Rollback(); err != nil {
+			log.Printf("Error rolling back transaction: %v", err)
 		}
-
-		delete(m.activeExecutions, streamID)
-		log.Printf("Query execution cancelled for streamID: %s", streamID)
 	}
+
+	log.Printf("Query execution cancelled for streamID: %s", streamID)
 }
 
 // ExecuteQuery executes a query and returns the result, synchronous, no SSE events are sent, findCount is used to strictly get the number/count of records that the query returns
 func (m *Manager) ExecuteQuery(ctx context.Context, chatID, messageID, queryID, streamID string, query string, queryType string, isRollback bool, findCount bool) (*QueryExecutionResult, *dtos.QueryError) {
 	m.executionMu.Lock()
 
-	// Create cancellable context with timeout
-	execCtx, cancel := context.WithTimeout(ctx, 1*time.Minute) // 1 minute timeout
+	// Create cancellable context, the query can be cancelled while it waits in the queue of the connection too
+	runCtx, cancel := context.WithCancel(ctx)
 
 	// Track execution
 	execution := &QueryExecution{
@@ -105,118 +113,225 @@ func (m *Manager) ExecuteQuery(ctx context.Context, chatID, messageID, queryID,
 		}
 	}
 
-	log.Printf("Manager -> ExecuteQuery -> Driver: %v", driver)
-	// Begin transaction
-	tx := driver.BeginTx(execCtx, conn)
-	if tx == nil {
+	// Syntax the server is too old for fails before a transaction is started
+	if err := CheckServerFeatures(conn.Dialect(), conn.ServerVersion, query); err != nil {
 		return nil, &dtos.QueryError{
-			Code:    "FAILED_TO_START_TRANSACTION",
-			Message: "failed to start transaction",
-			Details: "Failed to start transaction",
+			Code:    "UNSUPPORTED_BY_SERVER_VERSION",
+			Message: "query uses a feature the server version does not support",
+			Details: err.Error(),
 		}
 	}
 
-	// Check if transaction has an error (MongoDB transaction might return a non-nil transaction with an error)
-	if mongoTx, ok := tx.(*MongoDBTransaction); ok && mongoTx.Error != nil {
-		log.Printf("Manager -> ExecuteQuery -> MongoDB transaction error: %v", mongoTx.Error)
-		return nil, &dtos.QueryError{
-			Code:    "FAILED_TO_START_TRANSACTION",
-			Message: "failed to start transaction",
-			Details: mongoTx.Error.Error(),
-		}
+	// Every named parameter needs a value, the drivers bind them to the statements
+	query, paramsErr := prepareQueryParams(ctx, conn.Config.Type, query)
+	if paramsErr != nil {
+		return nil, paramsErr
 	}
 
-	execution.Tx = tx
+	// A SELECT or find without a limit reads up to the auto limit of the context, unless it's a full scan
+	limit := 0
+	if !findCount && !isRollback {
+		query, limit = applyAutoLimit(ctx, conn.Config.Type, query)
+	}
 
-	// Execute query with proper cancellation handling
-	var result *QueryExecutionResult
-	done := make(chan struct{})
-	var queryErr *dtos.QueryError
+	// The connection runs a limited number of queries at once, the others wait for their turn
+	release, queueErr := m.acquireQuerySlot(runCtx, conn, messageID, queryID, streamID)
+	if queueErr != nil {
+		return nil, queueErr
+	}
+	defer release()
 
-	go func() {
-		defer close(done)
-		log.Printf("Manager -> ExecuteQuery -> Executing query: %v", query)
-		result = tx.ExecuteQuery(execCtx, conn, query, queryType, findCount)
-		// log.Printf("Manager -> ExecuteQuery -> Result: %v", result)
-		if result.Error != nil {
-			queryErr = result.Error
-		}
-	}()
+	// The timeout starts once the query runs
+	execCtx, cancelTimeout := context.WithTimeout(runCtx, QueryTimeout(ctx)) // 1 minute timeout unless the context sets another
+	defer cancelTimeout()
 
-	select {
-	case <-execCtx.Done():
-		if err := tx.Rollback(); err != nil {
-			log.Printf("Error rolling back transaction: %v", err)
+	// A chat with an open transaction runs its queries in it, they are committed or rolled back with the transaction
+	if session := m.transactionSessionFor(chatID); session != nil {
+		result, queryErr := m.executeInTransactionSession(execCtx, session, conn, messageID, queryID, query, queryType, isRollback, findCount)
+		finishQueryResult(ctx, conn.Config.Type, result, limit, findCount)
+		return result, queryErr
+	}
+
+	// Lineage is parsed from the query as written, without the audit statements
+	originalQuery := query
+
+	// Read-only queries are served by a read replica when the connection has some
+	execConn := m.routeQuery(ctx, conn, query)
+
+	// The statements of a script run one by one so each reports its result
+	var statements []string
+	if !findCount {
+		statements = scriptStatements(conn.Config.Type, originalQuery)
+	}
+
+	// Identify NeoBase as the author of the changes for the audit triggers
+	query = m.prepareAuditedQuery(conn, chatID, messageID, queryID, query)
+
+	// The database stops the query at the timeout too, rather than running it for nobody
+	query = withStatementTimeout(execCtx, conn.Config.Type, query, execConn == conn)
+
+	log.Printf("Manager -> ExecuteQuery -> Driver: %v", driver)
+	// Transient errors (deadlocks, serialization failures, dropped connections) are retried in a new transaction
+	var tx Transaction
+	var result *QueryExecutionResult
+	for attempt := 1; ; attempt++ {
+		// Begin transaction
+		if execConn != conn {
+			log.Printf("Manager -> ExecuteQuery -> Routing read-only query to read replica: %s", execConn.Config.Host)
+			tx = &readReplicaTx{driver: driver}
+		} else {
+			tx = driver.BeginTx(execCtx, conn)
 		}
-		if execCtx.Err() == context.DeadlineExceeded {
+		if tx == nil {
 			return nil, &dtos.QueryError{
-				Code:    "QUERY_EXECUTION_TIMED_OUT",
-				Message: "query execution timed out",
-				Details: "Query execution timed out",
+				Code:    "FAILED_TO_START_TRANSACTION",
+				Message: "failed to start transaction",
+				Details: "Failed to start transaction",
 			}
 		}
-		return nil, &dtos.QueryError{
-			Code:    "QUERY_EXECUTION_CANCELLED",
-			Message: "query execution cancelled",
-			Details: "Query execution cancelled",
+
+		// Check if transaction has an error (MongoDB transaction might return a non-nil transaction with an error)
+		if mongoTx, ok := tx.(*MongoDBTransaction); ok && mongoTx.Error != nil {
+			log.Printf("Manager -> ExecuteQuery -> MongoDB transaction error: %v", mongoTx.Error)
+			return nil, &dtos.QueryError{
+				Code:    "FAILED_TO_START_TRANSACTION",
+				Message: "failed to start transaction",
+				Details: mongoTx.Error.Error(),
+			}
 		}
 
-	case <-done:
-		if queryErr != nil {
+		m.executionMu.Lock()
+		execution.Tx = tx
+		m.executionMu.Unlock()
+
+		// Execute query with proper cancellation handling
+		done := make(chan struct{})
+		var queryErr *dtos.QueryError
+
+		go func() {
+			defer close(done)
+			log.Printf("Manager -> ExecuteQuery -> Executing query: %v", query)
+			if len(statements) > 0 && strings.HasSuffix(query, originalQuery) {
+				// The audit & timeout statements prefixing the query run along the first statement of the script
+				result = executeScript(execCtx, tx, execConn, strings.TrimSuffix(query, originalQuery), statements, queryType)
+			} else {
+				result = tx.ExecuteQuery(execCtx, execConn, query, queryType, findCount)
+			}
+			// log.Printf("Manager -> ExecuteQuery -> Result: %v", result)
+			if result.Error != nil {
+				queryErr = result.Error
+			}
+		}()
+
+		select {
+		case <-execCtx.Done():
+			cancelOnServer(tx)
 			if err := tx.Rollback(); err != nil {
 				log.Printf("Error rolling back transaction: %v", err)
 			}
+			return nil, executionStoppedError(execCtx)
+
+		case <-done:
+		}
+		if queryErr == nil {
+			break
+		}
+
+		if err := tx.Rollback(); err != nil {
+			log.Printf("Error rolling back transaction: %v", err)
+		}
+		// Reads interrupted by a failover are executed again once the new primary is reached
+		if canReplayAfterFailover(conn.Config.Type, originalQuery, isRollback, queryErr) {
+			result, queryErr = m.replayAfterFailover(execCtx, driver, conn, execConn, messageID, queryID, streamID, query, queryType, findCount, queryErr)
+			finishQueryResult(ctx, conn.Config.Type, result, limit, findCount)
 			return result, queryErr
 		}
-		if err := tx.Commit(); err != nil {
-			return nil, &dtos.QueryError{
-				Code:    "QUERY_EXECUTION_FAILED",
-				Message: "query execution failed",
-				Details: err.Error(),
-			}
+		if !canRetryQuery(conn.Config.Type, originalQuery, queryErr) {
+			return result, withRetryAttempts(queryErr, attempt, false)
 		}
-		log.Println("Manager -> ExecuteQuery -> Commit completed:")
-		log.Printf("Manager -> ExecuteQuery -> Query type: %v", queryType)
+		if attempt >= queryRetryPolicy.MaxAttempts {
+			return result, withRetryAttempts(queryErr, attempt, true)
+		}
+		delay := queryRetryPolicy.delay(attempt)
+		log.Printf("Manager -> ExecuteQuery -> Transient error on attempt %d of %d, retrying in %v: %s", attempt, queryRetryPolicy.MaxAttempts, delay, queryErr.Message)
+		select {
+		case <-execCtx.Done():
+			return nil, executionStoppedError(execCtx)
+		case <-time.After(delay):
+		}
+	}
 
-		go func() {
-			log.Println("Manager -> ExecuteQuery -> Checking if schema trigger is needed")
-			time.Sleep(2 * time.Second)
-			switch conn.Config.Type {
-			case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB:
-				if queryType == "DDL" || queryType == "ALTER" || queryType == "DROP" {
-					if conn.OnSchemaChange != nil {
-						conn.OnSchemaChange(conn.ChatID)
-					}
+	if err := tx.Commit(); err != nil {
+		return nil, &dtos.QueryError{
+			Code:    "QUERY_EXECUTION_FAILED",
+			Message: "query execution failed",
+			Details: err.Error(),
+		}
+	}
+	finishQueryResult(ctx, conn.Config.Type, result, limit, findCount)
+	log.Println("Manager -> ExecuteQuery -> Commit completed:")
+	log.Printf("Manager -> ExecuteQuery -> Query type: %v", queryType)
+
+	// Record which tables & columns the committed query wrote, and from which inputs
+	if !findCount && m.streamHandler != nil {
+		go m.streamHandler.HandleQueryExecuted(chatID, messageID, queryID, conn.Config.Type, originalQuery, isRollback)
+	}
+
+	go func() {
+		log.Println("Manager -> ExecuteQuery -> Checking if schema trigger is needed")
+		time.Sleep(2 * time.Second)
+		switch conn.Config.Type {
+		case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB:
+			if queryType == "DDL" || queryType == "ALTER" || queryType == "DROP" {
+				if conn.OnSchemaChange != nil {
+					conn.OnSchemaChange(conn.ChatID)
 				}
-			case constants.DatabaseTypeMySQL:
-				if queryType == "DDL" || queryType == "ALTER" || queryType == "DROP" {
-					if conn.OnSchemaChange != nil {
-						conn.OnSchemaChange(conn.ChatID)
-					}
+			}
+		case constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB, constants.DatabaseTypeSingleStore, constants.DatabaseTypeDB2, constants.DatabaseTypeDatabricks:
+			if queryType == "DDL" || queryType == "ALTER" || queryType == "DROP" {
+				if conn.OnSchemaChange != nil {
+					conn.OnSchemaChange(conn.ChatID)
 				}
-			case constants.DatabaseTypeClickhouse:
-				if queryType == "DDL" || queryType == "ALTER" || queryType == "DROP" {
-					if conn.OnSchemaChange != nil {
-						conn.OnSchemaChange(conn.ChatID)
-					}
+			}
+		case constants.DatabaseTypeClickhouse:
+			if queryType == "DDL" || queryType == "ALTER" || queryType == "DROP" {
+				if conn.OnSchemaChange != nil {
+					conn.OnSchemaChange(conn.ChatID)
 				}
-			case constants.DatabaseTypeMongoDB:
-				if queryType == "CREATE_COLLECTION" || queryType == "DROP_COLLECTION" {
-					if conn.OnSchemaChange != nil {
-						conn.OnSchemaChange(conn.ChatID)
-					}
+			}
+		case constants.DatabaseTypeMongoDB:
+			if queryType == "CREATE_COLLECTION" || queryType == "DROP_COLLECTION" {
+				if conn.OnSchemaChange != nil {
+					conn.OnSchemaChange(conn.ChatID)
 				}
 			}
-		}()
+		}
+	}()
 
-		return result, nil
-	}
+	return result, nil
 }
 
 // TestConnection tests if the provided credentials are valid without creating a persistent connection
 func (m *Manager) TestConnection(config *ConnectionConfig) error {
 	var tempFiles []string
 
+	if err := m.testReadReplicas(config); err != nil {
+		return err
+	}
+
+	if err := ValidateAuthMode(*config); err != nil {
+		return err
+	}
+	if err := ValidateSocketPath(*config); err != nil {
+		return err
+	}
+	if err := ValidateProxy(*config); err != nil {
+		return err
+	}
+	if err := ValidateMongoDBConcerns(*config); err != nil {
+		return err
+	}
+
 	switch config.Type {
 	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB:
 		var dsn string
@@ -228,14 +343,18 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 		if config.Port != nil && *config.Port != "" {
 			port = *config.Port
 		}
-		// Base connection parameters
+		// Base connection parameters, the socket directory is used as host for Unix socket connections
+		host := config.Host
+		if UsesUnixSocket(*config) {
+			host, port = postgresSocketHostPort(*config)
+		}
 		baseParams := fmt.Sprintf(
 			"host=%s port=%s user=%s dbname=%s",
-			config.Host, port, *config.Username, config.Database,
+			host, port, *config.Username, config.Database,
 		)
 
-		// Add password if provided
-		if config.Password != nil {
+		// Add password if provided, Azure AD & AWS IAM tokens are added per connection, Kerberos needs none
+		if config.Password != nil && !UsesTokenAuth(*config) && !UsesKerberosAuth(*config) {
 			baseParams += fmt.Sprintf(" password=%s", *config.Password)
 		}
 
@@ -248,27 +367,19 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 				baseParams += " sslmode=require"
 			}
 
-			// Fetch certificates from URLs
-			certPath, keyPath, rootCertPath, certTempFiles, err := utils.PrepareCertificatesFromURLs(*config.SSLCertURL, *config.SSLKeyURL, *config.SSLRootCertURL)
+			// Load certificates, uploaded ones are passed inline
+			certs, err := loadSSLCertificates(*config)
+			if err != nil {
+				return err
+			}
+			certParams, certTempFiles, err := certs.postgresParams()
 			if err != nil {
 				return err
 			}
 
 			// Track temporary files for cleanup
 			tempFiles = certTempFiles
-
-			// Add certificate paths to connection string
-			if certPath != "" {
-				baseParams += fmt.Sprintf(" sslcert=%s", certPath)
-			}
-
-			if keyPath != "" {
-				baseParams += fmt.Sprintf(" sslkey=%s", keyPath)
-			}
-
-			if rootCertPath != "" {
-				baseParams += fmt.Sprintf(" sslrootcert=%s", rootCertPath)
-			}
+			baseParams += certParams
 		} else {
 			baseParams += " sslmode=disable"
 		}
@@ -276,7 +387,7 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 		dsn = baseParams
 
 		// Open connection
-		db, err := sql.Open("postgres", dsn)
+		db, err := openPostgresDB(*config, dsn)
 		if err != nil {
 			// Clean up temporary files
 			for _, file := range tempFiles {
@@ -302,7 +413,7 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 
 		return nil
 
-	case constants.DatabaseTypeMySQL:
+	case constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB, constants.DatabaseTypeSingleStore:
 		var dsn string
 		port := "3306" // Default port for MySQL
 
@@ -310,16 +421,16 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 			port = *config.Port
 		}
 
-		// Base connection parameters
-		if config.Password != nil {
+		// Base connection parameters, Azure AD & AWS IAM tokens are set before every new connection
+		if config.Password != nil && !UsesTokenAuth(*config) {
 			dsn = fmt.Sprintf(
-				"%s:%s@tcp(%s:%s)/%s",
-				*config.Username, *config.Password, config.Host, port, config.Database,
+				"%s:%s@%s/%s",
+				*config.Username, *config.Password, mysqlAddress(*config, port), config.Database,
 			)
 		} else {
 			dsn = fmt.Sprintf(
-				"%s@tcp(%s:%s)/%s",
-				*config.Username, config.Host, port, config.Database,
+				"%s@%s/%s",
+				*config.Username, mysqlAddress(*config, port), config.Database,
 			)
 		}
 
@@ -331,53 +442,21 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 			// Create a unique TLS config name
 			tlsConfigName := fmt.Sprintf("custom-test-%d", time.Now().UnixNano())
 
-			// Fetch certificates from URLs
-			certPath, keyPath, rootCertPath, certTempFiles, err := utils.PrepareCertificatesFromURLs(*config.SSLCertURL, *config.SSLKeyURL, *config.SSLRootCertURL)
+			// Load certificates, uploaded ones are used over the URLs
+			certs, err := loadSSLCertificates(*config)
 			if err != nil {
 				return err
 			}
 
-			// Track temporary files for cleanup
-			tempFiles = certTempFiles
-
 			// Create TLS config
 			tlsConfig := &tls.Config{
 				ServerName: config.Host,
 				MinVersion: tls.VersionTLS12,
 			}
 
-			// Add client certificates if provided
-			if certPath != "" && keyPath != "" {
-				cert, err := tls.LoadX509KeyPair(certPath, keyPath)
-				if err != nil {
-					// Clean up temporary files
-					for _, file := range tempFiles {
-						os.Remove(file)
-					}
-					return fmt.Errorf("failed to load client certificates: %v", err)
-				}
-				tlsConfig.Certificates = []tls.Certificate{cert}
-			}
-
-			// Add CA certificate if provided
-			if rootCertPath != "" {
-				rootCertPool := x509.NewCertPool()
-				pem, err := ioutil.ReadFile(rootCertPath)
-				if err != nil {
-					// Clean up temporary files
-					for _, file := range tempFiles {
-						os.Remove(file)
-					}
-					return fmt.Errorf("failed to read CA certificate: %v", err)
-				}
-				if ok := rootCertPool.AppendCertsFromPEM(pem); !ok {
-					// Clean up temporary files
-					for _, file := range tempFiles {
-						os.Remove(file)
-					}
-					return fmt.Errorf("failed to append CA certificate")
-				}
-				tlsConfig.RootCAs = rootCertPool
+			// Add client & CA certificates if provided
+			if err := certs.applyTo(tlsConfig); err != nil {
+				return err
 			}
 
 			// Register TLS config
@@ -388,12 +467,8 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 		}
 
 		// Open connection
-		db, err := sql.Open("mysql", dsn)
+		db, err := openMySQLDB(*config, dsn)
 		if err != nil {
-			// Clean up temporary files
-			for _, file := range tempFiles {
-				os.Remove(file)
-			}
 			return fmt.Errorf("failed to create connection: %v", err)
 		}
 
@@ -403,16 +478,38 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 		// Close connection
 		db.Close()
 
-		// Clean up temporary files
-		for _, file := range tempFiles {
-			os.Remove(file)
+		if err != nil {
+			return fmt.Errorf("failed to connect to database: %v", err)
 		}
 
+		return nil
+
+	case constants.DatabaseTypeDB2:
+		// The DB2 driver builds the CLI connection string & SSL settings itself
+		driver := NewDB2Driver()
+		conn, err := driver.Connect(*config)
 		if err != nil {
 			return fmt.Errorf("failed to connect to database: %v", err)
 		}
+		return driver.Disconnect(conn)
 
-		return nil
+	case constants.DatabaseTypeDatabricks:
+		// Databricks is reached through the Statement Execution API using the HTTP path & access token
+		driver := NewDatabricksDriver()
+		conn, err := driver.Connect(*config)
+		if err != nil {
+			return fmt.Errorf("failed to connect to database: %v", err)
+		}
+		return driver.Disconnect(conn)
+
+	case constants.DatabaseTypeFirestore:
+		// Firestore is reached through its REST API using the service account credentials (or an emulator)
+		driver := NewFirestoreDriver()
+		conn, err := driver.Connect(*config)
+		if err != nil {
+			return fmt.Errorf("failed to connect to database: %v", err)
+		}
+		return driver.Disconnect(conn)
 
 	case constants.DatabaseTypeClickhouse:
 		var dsn string
@@ -427,15 +524,11 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 
 		// Configure SSL/TLS
 		if config.UseSSL {
-			// Fetch certificates from URLs
-			_, _, _, certTempFiles, err := utils.PrepareCertificatesFromURLs(*config.SSLCertURL, *config.SSLKeyURL, *config.SSLRootCertURL)
-			if err != nil {
+			// Make sure the certificates can be loaded
+			if _, err := loadSSLCertificates(*config); err != nil {
 				return err
 			}
 
-			// Track temporary files for cleanup
-			tempFiles = certTempFiles
-
 			// Use secure protocol
 			protocol = "https"
 		}
@@ -458,12 +551,8 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 		}
 
 		// Open connection
-		db, err := sql.Open("clickhouse", dsn)
+		db, err := openClickHouseDB(*config, dsn)
 		if err != nil {
-			// Clean up temporary files
-			for _, file := range tempFiles {
-				os.Remove(file)
-			}
 			return fmt.Errorf("failed to create connection: %v", err)
 		}
 
@@ -473,11 +562,6 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 		// Close connection
 		db.Close()
 
-		// Clean up temporary files
-		for _, file := range tempFiles {
-			os.Remove(file)
-		}
-
 		if err != nil {
 			return fmt.Errorf("failed to connect to database: %v", err)
 		}
@@ -574,18 +658,18 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 
 		// Configure client options
 		clientOptions := options.Client().ApplyURI(uri)
+		if err := applyMongoDBConcerns(clientOptions, *config); err != nil {
+			return err
+		}
 
 		// Configure SSL/TLS
 		if config.UseSSL {
-			// Fetch certificates from URLs
-			certPath, keyPath, rootCertPath, certTempFiles, err := utils.PrepareCertificatesFromURLs(*config.SSLCertURL, *config.SSLKeyURL, *config.SSLRootCertURL)
+			// Load certificates, uploaded ones are used over the URLs
+			certs, err := loadSSLCertificates(*config)
 			if err != nil {
 				return err
 			}
 
-			// Track temporary files for cleanup
-			tempFiles = certTempFiles
-
 			// Configure TLS
 			tlsConfig := &tls.Config{
 				InsecureSkipVerify: false, // Default: verify certificates
@@ -614,56 +698,30 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 				}
 			}
 
-			// Add client certificates if provided
-			if certPath != "" && keyPath != "" {
-				cert, err := tls.LoadX509KeyPair(certPath, keyPath)
-				if err != nil {
-					// Clean up temporary files
-					for _, file := range tempFiles {
-						os.Remove(file)
-					}
-					return fmt.Errorf("failed to load client certificates: %v", err)
-				}
-				tlsConfig.Certificates = []tls.Certificate{cert}
-			}
-
-			// Add root CA if provided
-			if rootCertPath != "" {
-				rootCA, err := os.ReadFile(rootCertPath)
-				if err != nil {
-					// Clean up temporary files
-					for _, file := range tempFiles {
-						os.Remove(file)
-					}
-					return fmt.Errorf("failed to read root CA: %v", err)
-				}
-
-				rootCertPool := x509.NewCertPool()
-				if ok := rootCertPool.AppendCertsFromPEM(rootCA); !ok {
-					// Clean up temporary files
-					for _, file := range tempFiles {
-						os.Remove(file)
-					}
-					return fmt.Errorf("failed to parse root CA certificate")
-				}
-
-				tlsConfig.RootCAs = rootCertPool
+			// Add client & CA certificates if provided
+			if err := certs.applyTo(tlsConfig); err != nil {
+				return err
 			}
 
 			clientOptions.SetTLSConfig(tlsConfig)
 		}
 
 	skipTLSConfig:
+		// Dial through the proxy of the connection if any
+		dial, err := getProxyDialFunc(*config)
+		if err != nil {
+			return err
+		}
+		if dial != nil {
+			clientOptions.SetDialer(dial)
+		}
+
 		// Connect to MongoDB with timeout
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
 		client, err := mongo.Connect(ctx, clientOptions)
 		if err != nil {
-			// Clean up temporary files
-			for _, file := range tempFiles {
-				os.Remove(file)
-			}
 			log.Printf("DBManager -> TestConnection -> Error connecting to MongoDB: %v", err)
 			return fmt.Errorf("failed to connect to MongoDB: %v", err)
 		}
@@ -674,11 +732,6 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 		// Disconnect regardless of ping result
 		client.Disconnect(ctx)
 
-		// Clean up temporary files
-		for _, file := range tempFiles {
-			os.Remove(file)
-		}
-
 		if err != nil {
 			log.Printf("DBManager -> TestConnection -> Error pinging MongoDB: %v", err)
 			return fmt.Errorf("failed to ping MongoDB: %v", err)
@@ -714,7 +767,7 @@ func (m *Manager) FormatSchemaWithExamples(ctx context.Context, chatID string, s
 	}
 
 	// Use schema manager to format schema with examples and selected collections
-	formattedSchema, err := m.schemaManager.FormatSchemaWithExamplesAndCollections(ctx, chatID, db, conn.Config.Type, selectedCollections)
+	formattedSchema, err := m.schemaManager.FormatSchemaWithExamplesAndCollections(ctx, chatID, db, conn.Dialect(), selectedCollections)
 	if err != nil {
 		log.Printf("DBManager -> FormatSchemaWithExamples -> Error formatting schema: %v", err)
 		return "", fmt.Errorf("failed to format schema with examples: %v", err)
@@ -746,7 +799,7 @@ func (m *Manager) GetSchemaWithExamples(ctx context.Context, chatID string, sele
 	}
 
 	// Use schema manager to get schema with examples
-	storage, err := m.schemaManager.GetSchemaWithExamples(ctx, chatID, db, conn.Config.Type, selectedCollections)
+	storage, err := m.schemaManager.GetSchemaWithExamples(ctx, chatID, db, conn.Dialect(), selectedCollections)
 	if err != nil {
 		log.Printf("DBManager -> GetSchemaWithExamples -> Error getting schema: %v", err)
 		return nil, fmt.Errorf("failed to get schema with examples: %v", err)
@@ -803,14 +856,14 @@ func (m *Manager) RefreshSchemaWithExamples(ctx context.Context, chatID string,
 	}
 
 	// Fetch fresh schema directly with the longer timeout context
-	freshSchema, err := m.schemaManager.GetSchema(schemaCtx, chatID, db, conn.Config.Type, selectedTables)
+	freshSchema, err := m.schemaManager.GetSchema(schemaCtx, chatID, db, conn.Dialect(), selectedTables)
 	if err != nil {
 		log.Printf("DBManager -> RefreshSchemaWithExamples -> Error fetching fresh schema: %v", err)
 		return "", fmt.Errorf("failed to fetch fresh schema: %v", err)
 	}
 
 	// Store the fresh schema
-	err = m.schemaManager.storeSchema(schemaCtx, chatID, freshSchema, db, conn.Config.Type)
+	err = m.schemaManager.storeSchema(schemaCtx, chatID, freshSchema, db, conn.Dialect())
 	if err != nil {
 		log.Printf("DBManager -> RefreshSchemaWithExamples -> Error storing fresh schema: %v", err)
 		// Continue anyway, as we have the fresh schema
@@ -823,7 +876,7 @@ func (m *Manager) RefreshSchemaWithExamples(ctx context.Context, chatID string,
 	}
 
 	// Format schema with examples and selected collections
-	formattedSchema, err := m.schemaManager.FormatSchemaWithExamplesAndCollections(schemaCtx, chatID, db, conn.Config.Type, selectedCollections)
+	formattedSchema, err := m.schemaManager.FormatSchemaWithExamplesAndCollections(schemaCtx, chatID, db, conn.Dialect(), selectedCollections)
 	if err != nil {
 		log.Printf("DBManager -> RefreshSchemaWithExamples -> Error formatting schema: %v", err)
 		return "", fmt.Errorf("failed to format schema with examples: %v", err)
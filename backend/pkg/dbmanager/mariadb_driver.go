@@ -0,0 +1,109 @@
+package dbmanager
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// MariaDBDriver implements the DatabaseDriver interface for MariaDB.
+// MariaDB speaks the MySQL wire protocol, so connection handling, query execution and
+// transactions are delegated to the MySQL driver while schema fetching is MariaDB aware.
+type MariaDBDriver struct {
+	MySQLDriver
+}
+
+// NewMariaDBDriver creates a new MariaDB driver
+func NewMariaDBDriver() DatabaseDriver {
+	return &MariaDBDriver{}
+}
+
+// Connect establishes a connection to a MariaDB database and detects the server flavor
+func (d *MariaDBDriver) Connect(config ConnectionConfig) (*Connection, error) {
+	conn, err := d.MySQLDriver.Connect(config)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := detectMySQLServerVersion(conn)
+	if err != nil {
+		log.Printf("MariaDBDriver -> Connect -> Failed to detect server version: %v", err)
+		return conn, nil
+	}
+
+	conn.ServerVersion = version
+	if !isMariaDBVersion(version) {
+		log.Printf("MariaDBDriver -> Connect -> Warning: server reports version %s which is not a MariaDB server, MariaDB specific features may not be available", version)
+	} else {
+		log.Printf("MariaDBDriver -> Connect -> Connected to MariaDB server version %s", version)
+	}
+
+	return conn, nil
+}
+
+// GetSchema retrieves the database schema
+func (d *MariaDBDriver) GetSchema(ctx context.Context, db DBExecutor, selectedTables []string) (*SchemaInfo, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("MariaDBDriver -> GetSchema -> Context cancelled: %v", err)
+		return nil, err
+	}
+
+	fetcher := NewMariaDBSchemaFetcher(db)
+	return fetcher.GetSchema(ctx, db, selectedTables)
+}
+
+// GetTableChecksum calculates a checksum for a table
+func (d *MariaDBDriver) GetTableChecksum(ctx context.Context, db DBExecutor, table string) (string, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("MariaDBDriver -> GetTableChecksum -> Context cancelled: %v", err)
+		return "", err
+	}
+
+	fetcher := NewMariaDBSchemaFetcher(db)
+	return fetcher.GetTableChecksum(ctx, db, table)
+}
+
+// FetchExampleRecords fetches example records from a table
+func (d *MariaDBDriver) FetchExampleRecords(ctx context.Context, db DBExecutor, table string, limit int) ([]map[string]interface{}, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("MariaDBDriver -> FetchExampleRecords -> Context cancelled: %v", err)
+		return nil, err
+	}
+
+	fetcher := NewMariaDBSchemaFetcher(db)
+	return fetcher.FetchExampleRecords(ctx, db, table, limit)
+}
+
+// detectMySQLServerVersion returns the server version string reported by a MySQL protocol server
+func detectMySQLServerVersion(conn *Connection) (string, error) {
+	if conn == nil || conn.DB == nil {
+		return "", fmt.Errorf("no active connection")
+	}
+
+	var version string
+	if err := conn.DB.Raw("SELECT VERSION()").Scan(&version).Error; err != nil {
+		return "", err
+	}
+	return version, nil
+}
+
+// isMariaDBVersion reports whether a VERSION() string belongs to a MariaDB server
+func isMariaDBVersion(version string) bool {
+	return strings.Contains(strings.ToLower(version), "mariadb")
+}
+
+// parseMariaDBVersion extracts the major and minor version from a MariaDB VERSION() string (e.g. 10.11.6-MariaDB-1:10.11.6+maria~ubu2204)
+func parseMariaDBVersion(version string) (int, int) {
+	// Older servers prefix the real version with a replication compatibility version (5.5.5-10.6.12-MariaDB)
+	version = strings.TrimPrefix(version, "5.5.5-")
+
+	var major, minor int
+	if _, err := fmt.Sscanf(version, "%d.%d", &major, &minor); err != nil {
+		return 0, 0
+	}
+	return major, minor
+}
@@ -0,0 +1,116 @@
+package dbmanager
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// MariaDBSchemaFetcher implements schema fetching for MariaDB.
+// It reuses the MySQL fetcher and enriches the result with MariaDB-only features.
+type MariaDBSchemaFetcher struct {
+	*MySQLSchemaFetcher
+}
+
+// NewMariaDBSchemaFetcher creates a new MariaDB schema fetcher
+func NewMariaDBSchemaFetcher(db DBExecutor) SchemaFetcher {
+	return &MariaDBSchemaFetcher{
+		MySQLSchemaFetcher: &MySQLSchemaFetcher{db: db},
+	}
+}
+
+// GetSchema retrieves the schema for the selected tables along with MariaDB dialect hints
+func (f *MariaDBSchemaFetcher) GetSchema(ctx context.Context, db DBExecutor, selectedTables []string) (*SchemaInfo, error) {
+	log.Printf("MariaDBSchemaFetcher -> GetSchema -> Starting schema fetch with selected tables: %v", selectedTables)
+
+	schema, err := f.MySQLSchemaFetcher.GetSchema(ctx, db, selectedTables)
+	if err != nil {
+		return nil, err
+	}
+
+	// Mark system-versioned tables so the LLM knows temporal queries are available
+	versionedTables, err := f.fetchSystemVersionedTables(ctx)
+	if err != nil {
+		log.Printf("MariaDBSchemaFetcher -> GetSchema -> Error fetching system-versioned tables: %v", err)
+	} else {
+		for tableName, table := range schema.Tables {
+			if !versionedTables[tableName] {
+				continue
+			}
+			if table.Comment != "" {
+				table.Comment += " "
+			}
+			table.Comment += "[System-Versioned: supports FOR SYSTEM_TIME queries]"
+
+			// Recalculate the table checksum as the table definition changed
+			table.Checksum = ""
+			tableData, _ := json.Marshal(table)
+			table.Checksum = fmt.Sprintf("%x", md5.Sum(tableData))
+			schema.Tables[tableName] = table
+		}
+	}
+
+	schema.DialectHints = f.buildDialectHints(len(versionedTables) > 0)
+	log.Printf("MariaDBSchemaFetcher -> GetSchema -> Added %d dialect hints", len(schema.DialectHints))
+
+	return schema, nil
+}
+
+// fetchSystemVersionedTables returns the set of system-versioned tables in the current database
+func (f *MariaDBSchemaFetcher) fetchSystemVersionedTables(_ context.Context) (map[string]bool, error) {
+	var tables []string
+	query := `
+        SELECT table_name
+        FROM information_schema.tables
+        WHERE table_schema = DATABASE()
+        AND table_type = 'SYSTEM VERSIONED';
+    `
+	if err := f.db.Query(query, &tables); err != nil {
+		return nil, err
+	}
+
+	versioned := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		versioned[table] = true
+	}
+	return versioned, nil
+}
+
+// buildDialectHints describes the MariaDB specific syntax supported by the connected server
+func (f *MariaDBSchemaFetcher) buildDialectHints(hasVersionedTables bool) []string {
+	var version string
+	if err := f.db.Query("SELECT VERSION()", &version); err != nil {
+		log.Printf("MariaDBSchemaFetcher -> buildDialectHints -> Error fetching server version: %v", err)
+	}
+
+	hints := []string{}
+	if version != "" {
+		hints = append(hints, fmt.Sprintf("Server: %s", version))
+	}
+	if version != "" && !isMariaDBVersion(version) {
+		// Not a MariaDB server, avoid suggesting MariaDB-only syntax
+		hints = append(hints, "Server is not MariaDB, use MySQL compatible syntax only")
+		return hints
+	}
+
+	major, minor := parseMariaDBVersion(version)
+	atLeast := func(wantMajor, wantMinor int) bool {
+		return major > wantMajor || (major == wantMajor && minor >= wantMinor)
+	}
+
+	if atLeast(10, 0) {
+		hints = append(hints, "DELETE ... RETURNING is supported")
+	}
+	if atLeast(10, 5) {
+		hints = append(hints, "INSERT ... RETURNING and REPLACE ... RETURNING are supported")
+	}
+	if atLeast(10, 3) {
+		hints = append(hints, "Sequences (CREATE SEQUENCE, NEXTVAL) and system-versioned tables (WITH SYSTEM VERSIONING) are supported")
+	}
+	if hasVersionedTables {
+		hints = append(hints, "System-versioned tables can be queried historically with FOR SYSTEM_TIME AS OF / BETWEEN / ALL")
+	}
+	return hints
+}
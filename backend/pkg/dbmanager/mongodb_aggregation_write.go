@@ -0,0 +1,156 @@
+package dbmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoAggregateRegex matches the aggregate queries, db.collection.aggregate(...) or db.getCollection("name").aggregate(...)
+var mongoAggregateRegex = regexp.MustCompile(`^\s*db\.(?:getCollection\([^)]*\)|[^(]+)\.aggregate\s*\(`)
+
+// aggregationWriteTarget is the collection written by the $out or $merge stage of an aggregation
+type aggregationWriteTarget struct {
+	Stage      string // $out or $merge
+	Database   string // Empty for the database of the connection
+	Collection string
+}
+
+// IsMongoDBAggregationWrite returns true when an aggregate query writes its result with $out or $merge, the replaced or
+// merged documents can't be restored by a rollback
+func IsMongoDBAggregationWrite(query string) bool {
+	return mongoAggregateRegex.MatchString(query) && mongoWriteStageRegex.MatchString(query)
+}
+
+// findAggregationWriteTarget returns the target of the $out or $merge stage ending the pipeline, false when the pipeline
+// does not write. Both stages must be the last one of a pipeline.
+func findAggregationWriteTarget(pipeline []bson.M) (aggregationWriteTarget, bool, error) {
+	for i, stage := range pipeline {
+		for _, stageName := range []string{"$out", "$merge"} {
+			spec, ok := stage[stageName]
+			if !ok {
+				continue
+			}
+			if i != len(pipeline)-1 {
+				return aggregationWriteTarget{}, true, fmt.Errorf("%s must be the last stage of the pipeline", stageName)
+			}
+
+			target := aggregationWriteTarget{Stage: stageName}
+			// $merge names its target in "into", $out in the stage itself
+			if stageName == "$merge" {
+				switch specMap := spec.(type) {
+				case map[string]interface{}:
+					spec = specMap["into"]
+				case bson.M:
+					spec = specMap["into"]
+				}
+			}
+			switch value := spec.(type) {
+			case string:
+				target.Collection = value
+			case map[string]interface{}:
+				target.Database, _ = value["db"].(string)
+				target.Collection, _ = value["coll"].(string)
+			case bson.M:
+				target.Database, _ = value["db"].(string)
+				target.Collection, _ = value["coll"].(string)
+			}
+			if target.Collection == "" {
+				return aggregationWriteTarget{}, true, fmt.Errorf("%s requires a target collection", stageName)
+			}
+			return target, true, nil
+		}
+	}
+	return aggregationWriteTarget{}, false, nil
+}
+
+// executeAggregationWrite runs an aggregation ending with $out or $merge as a write, reporting the target collection & the
+// documents written instead of the (empty) aggregation result. The write can't be rolled back.
+func executeAggregationWrite(ctx context.Context, collection *mongo.Collection, pipeline []bson.M, target aggregationWriteTarget, collation *options.Collation, startTime time.Time) *QueryExecutionResult {
+	database := collection.Database()
+	if target.Database != "" {
+		database = collection.Database().Client().Database(target.Database)
+	}
+	targetCollection := database.Collection(target.Collection)
+
+	documentsBefore, err := targetCollection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Message: fmt.Sprintf("Failed to count the documents of %s: %v", target.Collection, err),
+				Code:    "EXECUTION_ERROR",
+			},
+		}
+	}
+
+	log.Printf("MongoDBDriver -> executeAggregationWrite -> Writing the aggregation of %s to %s with %s", collection.Name(), target.Collection, target.Stage)
+	aggregateOptions := options.Aggregate().SetCollation(collation)
+	if maxTime, ok := remainingQueryTime(ctx); ok {
+		aggregateOptions.SetMaxTime(maxTime)
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline, aggregateOptions)
+	if err != nil {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Message: fmt.Sprintf("Failed to execute aggregation: %v", err),
+				Code:    "EXECUTION_ERROR",
+			},
+		}
+	}
+	// The cursor of a writing aggregation is empty, it is closed once the write is done
+	cursor.Close(ctx)
+
+	documentsAfter, err := targetCollection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Message: fmt.Sprintf("Failed to count the documents of %s: %v", target.Collection, err),
+				Code:    "EXECUTION_ERROR",
+			},
+		}
+	}
+
+	result := map[string]interface{}{
+		"ok":                1,
+		"stage":             target.Stage,
+		"targetCollection":  target.Collection,
+		"documentsInTarget": documentsAfter,
+		"canRollback":       false,
+	}
+	if target.Database != "" {
+		result["targetDatabase"] = target.Database
+	}
+	if target.Stage == "$out" {
+		// $out replaces the target collection with the result
+		result["writtenCount"] = documentsAfter
+		result["message"] = fmt.Sprintf("Replaced collection '%s' with %d documents", target.Collection, documentsAfter)
+	} else {
+		// Merged documents may update existing ones, only the new documents can be counted
+		result["insertedCount"] = max(documentsAfter-documentsBefore, 0)
+		result["message"] = fmt.Sprintf("Merged the results into collection '%s', which now has %d documents", target.Collection, documentsAfter)
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Message: fmt.Sprintf("Failed to marshal result to JSON: %v", err),
+				Code:    "JSON_ERROR",
+			},
+		}
+	}
+
+	return &QueryExecutionResult{
+		Result:        result,
+		ResultJSON:    string(resultJSON),
+		ExecutionTime: int(time.Since(startTime).Milliseconds()),
+	}
+}
@@ -0,0 +1,171 @@
+package dbmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// executeBulkWrite executes db.collection.bulkWrite([...], {ordered}) in one round trip, the operations are
+// insertOne, updateOne, updateMany, replaceOne, deleteOne & deleteMany as in the mongo shell
+func executeBulkWrite(ctx context.Context, collection *mongo.Collection, paramsStr string) (map[string]interface{}, *dtos.QueryError) {
+	models, ordered, err := parseBulkWriteParams(paramsStr)
+	if err != nil {
+		return nil, &dtos.QueryError{
+			Message: fmt.Sprintf("Failed to parse bulkWrite operations: %v", err),
+			Code:    "INVALID_PARAMETERS",
+		}
+	}
+
+	log.Printf("MongoDBDriver -> executeBulkWrite -> Executing %d operations on %s (ordered: %t)", len(models), collection.Name(), ordered)
+	bulkResult, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(ordered))
+	if err != nil {
+		return nil, &dtos.QueryError{
+			Message: fmt.Sprintf("Failed to execute bulkWrite operation: %v", err),
+			Code:    "EXECUTION_ERROR",
+		}
+	}
+
+	upsertedIDs := bulkResult.UpsertedIDs
+	if upsertedIDs == nil {
+		upsertedIDs = map[int64]interface{}{}
+	}
+	return map[string]interface{}{
+		"insertedCount": bulkResult.InsertedCount,
+		"matchedCount":  bulkResult.MatchedCount,
+		"modifiedCount": bulkResult.ModifiedCount,
+		"deletedCount":  bulkResult.DeletedCount,
+		"upsertedCount": bulkResult.UpsertedCount,
+		"upsertedIds":   upsertedIDs,
+	}, nil
+}
+
+// parseBulkWriteParams parses the operations array & the options of a bulkWrite, operations are ordered by default
+func parseBulkWriteParams(paramsStr string) ([]mongo.WriteModel, bool, error) {
+	// The operations & the options are parsed together as the arguments array
+	argsStr := "[" + paramsStr + "]"
+	var args []interface{}
+	if err := json.Unmarshal([]byte(argsStr), &args); err != nil {
+		jsonStr, err := processMongoDBQueryParams(argsStr)
+		if err != nil {
+			return nil, false, err
+		}
+		if err := json.Unmarshal([]byte(jsonStr), &args); err != nil {
+			return nil, false, fmt.Errorf("invalid operations after conversion: %v", err)
+		}
+	}
+	if len(args) == 0 {
+		return nil, false, fmt.Errorf("bulkWrite requires an array of operations")
+	}
+
+	operations, ok := args[0].([]interface{})
+	if !ok {
+		return nil, false, fmt.Errorf("bulkWrite requires an array of operations")
+	}
+	if len(operations) == 0 {
+		return nil, false, fmt.Errorf("bulkWrite requires at least one operation")
+	}
+
+	ordered := true
+	if len(args) > 1 {
+		opts, ok := args[1].(map[string]interface{})
+		if !ok {
+			return nil, false, fmt.Errorf("bulkWrite options must be an object")
+		}
+		if value, exists := opts["ordered"]; exists {
+			if ordered, ok = value.(bool); !ok {
+				return nil, false, fmt.Errorf("ordered option must be a boolean")
+			}
+		}
+	}
+
+	models := make([]mongo.WriteModel, 0, len(operations))
+	for i, operation := range operations {
+		opMap, ok := operation.(map[string]interface{})
+		if !ok || len(opMap) != 1 {
+			return nil, false, fmt.Errorf("operation %d must be an object with a single operation name", i)
+		}
+		if err := processObjectIds(opMap); err != nil {
+			return nil, false, fmt.Errorf("operation %d: %v", i, err)
+		}
+
+		model, err := bulkWriteModel(opMap)
+		if err != nil {
+			return nil, false, fmt.Errorf("operation %d: %v", i, err)
+		}
+		models = append(models, model)
+	}
+	return models, ordered, nil
+}
+
+// bulkWriteModel converts an operation of a bulkWrite, e.g. {updateOne: {filter, update, upsert}}, to its write model
+func bulkWriteModel(operation map[string]interface{}) (mongo.WriteModel, error) {
+	for name, value := range operation {
+		spec, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s must be an object", name)
+		}
+		filter, hasFilter := spec["filter"].(map[string]interface{})
+		upsert, _ := spec["upsert"].(bool)
+
+		switch name {
+		case "insertOne":
+			document, ok := spec["document"].(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("insertOne requires a document")
+			}
+			return mongo.NewInsertOneModel().SetDocument(document), nil
+		case "updateOne", "updateMany":
+			if !hasFilter {
+				return nil, fmt.Errorf("%s requires a filter", name)
+			}
+			// The update is an update document or an aggregation pipeline
+			update := spec["update"]
+			switch update.(type) {
+			case map[string]interface{}, []interface{}:
+			default:
+				return nil, fmt.Errorf("%s requires an update", name)
+			}
+			arrayFilters, _ := spec["arrayFilters"].([]interface{})
+			if name == "updateOne" {
+				model := mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update).SetUpsert(upsert)
+				if len(arrayFilters) > 0 {
+					model.SetArrayFilters(options.ArrayFilters{Filters: arrayFilters})
+				}
+				return model, nil
+			}
+			model := mongo.NewUpdateManyModel().SetFilter(filter).SetUpdate(update).SetUpsert(upsert)
+			if len(arrayFilters) > 0 {
+				model.SetArrayFilters(options.ArrayFilters{Filters: arrayFilters})
+			}
+			return model, nil
+		case "replaceOne":
+			if !hasFilter {
+				return nil, fmt.Errorf("replaceOne requires a filter")
+			}
+			replacement, ok := spec["replacement"].(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("replaceOne requires a replacement")
+			}
+			return mongo.NewReplaceOneModel().SetFilter(filter).SetReplacement(replacement).SetUpsert(upsert), nil
+		case "deleteOne":
+			if !hasFilter {
+				return nil, fmt.Errorf("deleteOne requires a filter")
+			}
+			return mongo.NewDeleteOneModel().SetFilter(filter), nil
+		case "deleteMany":
+			if !hasFilter {
+				return nil, fmt.Errorf("deleteMany requires a filter")
+			}
+			return mongo.NewDeleteManyModel().SetFilter(filter), nil
+		default:
+			return nil, fmt.Errorf("unsupported bulkWrite operation: %s", name)
+		}
+	}
+	return nil, fmt.Errorf("empty operation")
+}
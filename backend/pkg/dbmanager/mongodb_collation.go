@@ -0,0 +1,68 @@
+package dbmanager
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoCollationOperations are the operations applying the .collation() modifier
+var mongoCollationOperations = map[string]bool{
+	"find":           true,
+	"findOne":        true,
+	"countDocuments": true,
+	"aggregate":      true,
+}
+
+// extractCollationModifier returns the document of the .collation() modifier of a query, false when the query has none
+func extractCollationModifier(modifiersStr string) (string, bool, error) {
+	start := strings.Index(modifiersStr, ".collation(")
+	if start == -1 {
+		return "", false, nil
+	}
+
+	collationStr, _, err := extractParenthesisContent(modifiersStr, start+len(".collation"))
+	if err != nil {
+		return "", true, fmt.Errorf("invalid collation: %v", err)
+	}
+	return strings.TrimSpace(collationStr), true, nil
+}
+
+// mongoCollationFromModifiers returns the collation of the .collation() modifier, nil when the query has none.
+// A collation on an operation that can't apply it is an error rather than being dropped, the results would differ from what was asked.
+func mongoCollationFromModifiers(operation string, modifiers map[string]interface{}) (*options.Collation, error) {
+	collationStr, ok := modifiers["collation"].(string)
+	if !ok {
+		return nil, nil
+	}
+	if !mongoCollationOperations[operation] {
+		return nil, fmt.Errorf("collation is only supported by find, findOne, countDocuments & aggregate, not by %s", operation)
+	}
+	return parseMongoCollation(collationStr)
+}
+
+// parseMongoCollation parses a collation document, e.g. {locale: "fr", strength: 2}
+func parseMongoCollation(collationStr string) (*options.Collation, error) {
+	jsonStr := collationStr
+	if !json.Valid([]byte(jsonStr)) {
+		var err error
+		if jsonStr, err = processMongoDBQueryParams(collationStr); err != nil {
+			return nil, fmt.Errorf("invalid collation %s: %v", collationStr, err)
+		}
+	}
+
+	// The options of the collation match the fields of options.Collation, e.g. caseLevel or numericOrdering
+	var collation options.Collation
+	decoder := json.NewDecoder(bytes.NewReader([]byte(jsonStr)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&collation); err != nil {
+		return nil, fmt.Errorf("invalid collation %s: %v", collationStr, err)
+	}
+	if collation.Locale == "" {
+		return nil, fmt.Errorf("invalid collation %s: locale is required", collationStr)
+	}
+	return &collation, nil
+}
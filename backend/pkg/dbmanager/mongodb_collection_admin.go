@@ -0,0 +1,299 @@
+package dbmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MongoDBRollbackQueryKey is the key of the rollback query in the result of the operations that only know it once executed
+const MongoDBRollbackQueryKey = "rollbackQuery"
+
+var (
+	// mongoRunCommandRegex matches db.runCommand(...)
+	mongoRunCommandRegex = regexp.MustCompile(`^\s*db\.runCommand\s*\(`)
+	// mongoRenameCollectionRegex matches db.collection.renameCollection(...), the collection is captured
+	mongoRenameCollectionRegex = regexp.MustCompile(`^\s*db\.([\w$-]+)\.renameCollection\s*\(`)
+)
+
+// Default options of a collection, restored when a collMod sets an option the collection didn't have
+var mongoCollModDefaults = map[string]interface{}{
+	"validator":        bson.D{},
+	"validationLevel":  "strict",
+	"validationAction": "error",
+}
+
+// executeRenameCollection executes db.collection.renameCollection("newName", dropTarget), the collection keeps its documents, indexes & options
+func executeRenameCollection(ctx context.Context, collection *mongo.Collection, paramsStr string) (map[string]interface{}, *dtos.QueryError) {
+	newName, dropTarget, err := parseRenameCollectionParams(paramsStr)
+	if err != nil {
+		return nil, &dtos.QueryError{
+			Message: fmt.Sprintf("Failed to parse renameCollection parameters: %v", err),
+			Code:    "INVALID_PARAMETERS",
+		}
+	}
+
+	// renameCollection is an admin command taking the full namespaces
+	databaseName := collection.Database().Name()
+	command := bson.D{
+		{Key: "renameCollection", Value: databaseName + "." + collection.Name()},
+		{Key: "to", Value: databaseName + "." + newName},
+		{Key: "dropTarget", Value: dropTarget},
+	}
+
+	log.Printf("MongoDBDriver -> executeRenameCollection -> Renaming %s to %s", collection.Name(), newName)
+	if err := collection.Database().Client().Database("admin").RunCommand(ctx, command).Err(); err != nil {
+		return nil, &dtos.QueryError{
+			Message: fmt.Sprintf("Failed to execute renameCollection operation: %v", err),
+			Code:    "EXECUTION_ERROR",
+		}
+	}
+
+	return map[string]interface{}{
+		"ok":      1,
+		"message": fmt.Sprintf("Collection '%s' renamed to '%s'", collection.Name(), newName),
+	}, nil
+}
+
+// parseRenameCollectionParams parses the new name & the optional dropTarget flag of a renameCollection
+func parseRenameCollectionParams(paramsStr string) (string, bool, error) {
+	args, err := parseOrderedMongoArgs(paramsStr)
+	if err != nil {
+		return "", false, err
+	}
+	if len(args) == 0 || len(args) > 2 {
+		return "", false, fmt.Errorf("renameCollection expects the new name and an optional dropTarget flag")
+	}
+
+	newName, ok := args[0].(string)
+	if !ok || strings.TrimSpace(newName) == "" {
+		return "", false, fmt.Errorf("the new name must be a non empty string")
+	}
+	dropTarget := false
+	if len(args) == 2 {
+		if dropTarget, ok = args[1].(bool); !ok {
+			return "", false, fmt.Errorf("dropTarget must be a boolean")
+		}
+	}
+	return newName, dropTarget, nil
+}
+
+// MongoDBRenameCollectionRollback returns the renameCollection query undoing a renameCollection query, false when the query is not a renameCollection.
+// A rename dropping an existing target can't be undone as the dropped collection is lost.
+func MongoDBRenameCollectionRollback(query string) (string, bool) {
+	match := mongoRenameCollectionRegex.FindStringSubmatch(query)
+	if match == nil {
+		return "", false
+	}
+
+	openParenIndex := strings.Index(query, ".renameCollection") + len(".renameCollection")
+	openParenIndex += strings.Index(query[openParenIndex:], "(")
+	paramsStr, _, err := extractParenthesisContent(query, openParenIndex)
+	if err != nil {
+		return "", false
+	}
+	newName, dropTarget, err := parseRenameCollectionParams(paramsStr)
+	if err != nil || dropTarget {
+		return "", false
+	}
+	return fmt.Sprintf("db.%s.renameCollection(%q)", newName, match[1]), true
+}
+
+// executeRunCommand executes db.runCommand({collMod: ...}), the rollback query restoring the modified options is returned with the result.
+// Other commands are rejected, they would bypass the checks of the dedicated operations.
+func executeRunCommand(ctx context.Context, database *mongo.Database, query string, startTime time.Time) *QueryExecutionResult {
+	openParenIndex := strings.Index(query, "(")
+	paramsStr, _, err := extractParenthesisContent(query, openParenIndex)
+	if err != nil {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Message: fmt.Sprintf("Invalid MongoDB query format: %v", err),
+				Code:    "INVALID_QUERY",
+			},
+		}
+	}
+	command, err := parseOrderedMongoDocument(paramsStr)
+	if err != nil {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Message: fmt.Sprintf("Failed to parse runCommand parameters: %v", err),
+				Code:    "INVALID_PARAMETERS",
+			},
+		}
+	}
+	if len(command) == 0 || command[0].Key != "collMod" {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Message: "db.runCommand() only supports the collMod command",
+				Code:    "UNSUPPORTED_OPERATION",
+			},
+		}
+	}
+	collectionName, ok := command[0].Value.(string)
+	if !ok || collectionName == "" {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Message: "collMod expects the name of the collection to modify",
+				Code:    "INVALID_PARAMETERS",
+			},
+		}
+	}
+
+	// The options are read before they are modified, the rollback restores them
+	rollbackCommand, err := collModRollbackCommand(ctx, database.Collection(collectionName), command)
+	if err != nil {
+		log.Printf("MongoDBDriver -> executeRunCommand -> No rollback for collMod on %s: %v", collectionName, err)
+	}
+
+	log.Printf("MongoDBDriver -> executeRunCommand -> Modifying collection %s", collectionName)
+	var output bson.M
+	if err := database.RunCommand(ctx, command).Decode(&output); err != nil {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Message: fmt.Sprintf("Failed to execute collMod operation: %v", err),
+				Code:    "EXECUTION_ERROR",
+			},
+		}
+	}
+
+	result := map[string]interface{}{
+		"ok":      1,
+		"message": fmt.Sprintf("Collection '%s' modified", collectionName),
+	}
+	// The previous options of an index modified by collMod are reported by the server
+	for _, key := range []string{"expireAfterSeconds_old", "expireAfterSeconds_new", "hidden_old", "hidden_new"} {
+		if value, ok := output[key]; ok {
+			result[key] = value
+		}
+	}
+	if rollbackCommand != nil {
+		rollbackJSON, err := bson.MarshalExtJSON(rollbackCommand, false, false)
+		if err == nil {
+			result[MongoDBRollbackQueryKey] = fmt.Sprintf("db.runCommand(%s)", rollbackJSON)
+		}
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Message: fmt.Sprintf("Failed to marshal result to JSON: %v", err),
+				Code:    "JSON_ERROR",
+			},
+		}
+	}
+
+	return &QueryExecutionResult{
+		Result:        result,
+		ResultJSON:    string(resultJSON),
+		ExecutionTime: int(time.Since(startTime).Milliseconds()),
+	}
+}
+
+// collModRollbackCommand builds the collMod restoring the options a collMod modifies, from the current options of the collection & its indexes
+func collModRollbackCommand(ctx context.Context, collection *mongo.Collection, command bson.D) (bson.D, error) {
+	specs, err := collection.Database().ListCollectionSpecifications(ctx, bson.M{"name": collection.Name()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the collection options: %v", err)
+	}
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("collection %s does not exist", collection.Name())
+	}
+	options := specs[0].Options
+
+	rollback := bson.D{{Key: "collMod", Value: collection.Name()}}
+	for _, option := range command[1:] {
+		switch option.Key {
+		case "validator", "validationLevel", "validationAction":
+			var previous interface{} = mongoCollModDefaults[option.Key]
+			if value, err := options.LookupErr(option.Key); err == nil {
+				previous = value
+			}
+			rollback = append(rollback, bson.E{Key: option.Key, Value: previous})
+		case "expireAfterSeconds":
+			// Time-series collections without expiry have no expireAfterSeconds option
+			var previous interface{} = "off"
+			if value, err := options.LookupErr("expireAfterSeconds"); err == nil {
+				previous = value
+			}
+			rollback = append(rollback, bson.E{Key: "expireAfterSeconds", Value: previous})
+		case "index":
+			indexRollback, err := collModIndexRollback(ctx, collection, option.Value)
+			if err != nil {
+				return nil, err
+			}
+			rollback = append(rollback, bson.E{Key: "index", Value: indexRollback})
+		default:
+			return nil, fmt.Errorf("the %s option can't be rolled back", option.Key)
+		}
+	}
+	return rollback, nil
+}
+
+// collModIndexRollback returns the index option of a collMod restoring the TTL & the visibility of the modified index
+func collModIndexRollback(ctx context.Context, collection *mongo.Collection, value interface{}) (bson.D, error) {
+	indexOption, ok := value.(bson.D)
+	if !ok {
+		return nil, fmt.Errorf("the index option must be an object")
+	}
+	index := indexOption.Map()
+
+	// The index is given by its name or its key pattern, key patterns are compared by their default index name
+	name, _ := index["name"].(string)
+	keyPatternName := ""
+	if keyPattern, ok := index["keyPattern"].(bson.D); ok {
+		keyPatternName = mongoIndexName(keyPattern)
+	}
+	if name == "" && keyPatternName == "" {
+		return nil, fmt.Errorf("the index option must have a name or a keyPattern")
+	}
+
+	cursor, err := collection.Indexes().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the indexes: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var spec struct {
+			Name               string `bson:"name"`
+			Key                bson.D `bson:"key"`
+			ExpireAfterSeconds *int64 `bson:"expireAfterSeconds"`
+			Hidden             bool   `bson:"hidden"`
+		}
+		if err := cursor.Decode(&spec); err != nil {
+			return nil, fmt.Errorf("failed to decode index: %v", err)
+		}
+		if (name == "" || spec.Name != name) && (keyPatternName == "" || mongoIndexName(spec.Key) != keyPatternName) {
+			continue
+		}
+
+		rollback := bson.D{{Key: "name", Value: spec.Name}}
+		if _, ok := index["expireAfterSeconds"]; ok {
+			// A TTL can be changed but not removed from an index
+			if spec.ExpireAfterSeconds == nil {
+				return nil, fmt.Errorf("index %s had no TTL", spec.Name)
+			}
+			rollback = append(rollback, bson.E{Key: "expireAfterSeconds", Value: *spec.ExpireAfterSeconds})
+		}
+		if _, ok := index["hidden"]; ok {
+			rollback = append(rollback, bson.E{Key: "hidden", Value: spec.Hidden})
+		}
+		return rollback, nil
+	}
+	return nil, fmt.Errorf("index not found")
+}
+
+// MongoDBExecutedRollback returns the rollback query reported by the result of an executed operation, false when the operation didn't report one
+func MongoDBExecutedRollback(result map[string]interface{}) (string, bool) {
+	rollbackQuery, ok := result[MongoDBRollbackQueryKey].(string)
+	return rollbackQuery, ok && rollbackQuery != ""
+}
@@ -0,0 +1,103 @@
+package dbmanager
+
+import (
+	"fmt"
+	"neobase-ai/internal/constants"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// The read concern levels of MongoDB, snapshot is only applied to the transactions as most commands reject it outside of one
+var mongoDBReadConcernLevels = map[string]bool{"local": true, "available": true, "majority": true, "linearizable": true, "snapshot": true}
+
+// ValidateMongoDBConcerns checks the read preference & the read/write concerns of the connection, they are only used by MongoDB
+func ValidateMongoDBConcerns(config ConnectionConfig) error {
+	readPreference := optionalConcern(config.ReadPreference)
+	readConcern := optionalConcern(config.ReadConcern)
+	writeConcern := optionalConcern(config.WriteConcern)
+	if readPreference == "" && readConcern == "" && writeConcern == "" {
+		return nil
+	}
+	if config.Type != constants.DatabaseTypeMongoDB {
+		return fmt.Errorf("read preference and read/write concerns are only supported for MongoDB connections")
+	}
+
+	if readPreference != "" {
+		if _, err := readpref.ModeFromString(readPreference); err != nil {
+			return fmt.Errorf("invalid read preference %q, use primary, primaryPreferred, secondary, secondaryPreferred or nearest", readPreference)
+		}
+	}
+	if readConcern != "" && !mongoDBReadConcernLevels[readConcern] {
+		return fmt.Errorf("invalid read concern %q, use local, available, majority, linearizable or snapshot", readConcern)
+	}
+	if writeConcern != "" {
+		if _, err := parseMongoDBWriteConcern(writeConcern); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyMongoDBConcerns sets the read preference & the read/write concerns of the connection on the client options.
+// Read replica connections keep reading from the secondaries.
+func applyMongoDBConcerns(clientOptions *options.ClientOptions, config ConnectionConfig) error {
+	if err := ValidateMongoDBConcerns(config); err != nil {
+		return err
+	}
+
+	if readPreference := optionalConcern(config.ReadPreference); readPreference != "" && !config.isReadReplica {
+		mode, _ := readpref.ModeFromString(readPreference)
+		pref, err := readpref.New(mode)
+		if err != nil {
+			return fmt.Errorf("invalid read preference: %v", err)
+		}
+		clientOptions.SetReadPreference(pref)
+	}
+	if readConcern := optionalConcern(config.ReadConcern); readConcern != "" && readConcern != "snapshot" {
+		clientOptions.SetReadConcern(&readconcern.ReadConcern{Level: readConcern})
+	}
+	if writeConcern := optionalConcern(config.WriteConcern); writeConcern != "" {
+		concern, _ := parseMongoDBWriteConcern(writeConcern)
+		clientOptions.SetWriteConcern(concern)
+	}
+	return nil
+}
+
+// mongoDBTransactionOptions returns the options of the transactions of the connection, transactions read from the primary
+// whatever the read preference of the connection
+func mongoDBTransactionOptions(config ConnectionConfig) *options.TransactionOptions {
+	transactionOptions := options.Transaction().SetReadPreference(readpref.Primary())
+	if readConcern := optionalConcern(config.ReadConcern); readConcern != "" && readConcern != "available" && readConcern != "linearizable" {
+		transactionOptions.SetReadConcern(&readconcern.ReadConcern{Level: readConcern})
+	}
+	if writeConcern := optionalConcern(config.WriteConcern); writeConcern != "" {
+		if concern, err := parseMongoDBWriteConcern(writeConcern); err == nil {
+			transactionOptions.SetWriteConcern(concern)
+		}
+	}
+	return transactionOptions
+}
+
+// parseMongoDBWriteConcern parses a write concern, majority or the number of members acknowledging the writes
+func parseMongoDBWriteConcern(value string) (*writeconcern.WriteConcern, error) {
+	if value == "majority" {
+		return writeconcern.Majority(), nil
+	}
+	members, err := strconv.Atoi(value)
+	if err != nil || members < 0 {
+		return nil, fmt.Errorf("invalid write concern %q, use majority or a number of members", value)
+	}
+	return &writeconcern.WriteConcern{W: members}, nil
+}
+
+func optionalConcern(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return strings.TrimSpace(*value)
+}
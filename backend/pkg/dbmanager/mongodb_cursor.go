@@ -0,0 +1,143 @@
+package dbmanager
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MongoDBCursorLimits bounds the documents of a find or an aggregate kept in memory, the cursor is read batch by batch &
+// closed once a limit is reached so a query matching millions of documents does not load all of them
+type MongoDBCursorLimits struct {
+	BatchSize    int32 // Documents fetched from the server per round trip
+	MaxDocuments int   // Documents kept in the result
+	MaxBytes     int   // BSON size of the documents kept in the result
+}
+
+// DefaultMongoDBCursorLimits are used unless SetMongoDBCursorLimits is called
+var DefaultMongoDBCursorLimits = MongoDBCursorLimits{
+	BatchSize:    1000,
+	MaxDocuments: 10000,
+	MaxBytes:     32 * 1024 * 1024,
+}
+
+var mongoDBCursorLimits = DefaultMongoDBCursorLimits
+
+// SetMongoDBCursorLimits sets the limits of the MongoDB cursors, the limits left to zero keep their default
+func SetMongoDBCursorLimits(limits MongoDBCursorLimits) {
+	if limits.BatchSize <= 0 {
+		limits.BatchSize = DefaultMongoDBCursorLimits.BatchSize
+	}
+	if limits.MaxDocuments <= 0 {
+		limits.MaxDocuments = DefaultMongoDBCursorLimits.MaxDocuments
+	}
+	if limits.MaxBytes <= 0 {
+		limits.MaxBytes = DefaultMongoDBCursorLimits.MaxBytes
+	}
+	mongoDBCursorLimits = limits
+}
+
+// mongoCursorBatchHandler receives the documents of each batch read from a cursor
+type mongoCursorBatchHandler func(batch []bson.M)
+
+// readMongoCursor reads the documents of a cursor batch by batch until it is exhausted or a limit of mongoDBCursorLimits is
+// reached, the reason is returned when the documents were truncated. onBatch is called with each batch read, it can be nil.
+func readMongoCursor(ctx context.Context, cursor *mongo.Cursor, onBatch mongoCursorBatchHandler) ([]bson.M, string, error) {
+	if sink, ok := ctx.Value(mongoDocumentSinkKey{}).(mongoDocumentSink); ok {
+		return []bson.M{}, "", drainMongoCursor(ctx, cursor, sink)
+	}
+
+	limits := mongoDBCursorLimits
+	if maxRows := resultRowLimit(ctx); maxRows > 0 && maxRows < limits.MaxDocuments {
+		limits.MaxDocuments = maxRows
+	}
+	documents := []bson.M{}
+	batch := []bson.M{}
+	totalBytes := 0
+	truncatedReason := ""
+
+	for cursor.Next(ctx) {
+		if len(documents) >= limits.MaxDocuments {
+			truncatedReason = fmt.Sprintf("the result was limited to %d documents", limits.MaxDocuments)
+			break
+		}
+		if totalBytes+len(cursor.Current) > limits.MaxBytes {
+			truncatedReason = fmt.Sprintf("the result was limited to %d MB", limits.MaxBytes/(1024*1024))
+			break
+		}
+
+		var document bson.M
+		if err := cursor.Decode(&document); err != nil {
+			return nil, "", err
+		}
+		totalBytes += len(cursor.Current)
+		documents = append(documents, document)
+		batch = append(batch, document)
+
+		// The batch ends when no document of the last server response is left
+		if cursor.RemainingBatchLength() == 0 && onBatch != nil {
+			onBatch(batch)
+			batch = []bson.M{}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, "", err
+	}
+	if len(batch) > 0 && onBatch != nil {
+		onBatch(batch)
+	}
+
+	if truncatedReason != "" {
+		log.Printf("MongoDBDriver -> readMongoCursor -> Stopped reading the cursor after %d documents (%d bytes), %s", len(documents), totalBytes, truncatedReason)
+	}
+	return documents, truncatedReason, nil
+}
+
+// mongoDocumentSink receives each document read from a cursor, in field order
+type mongoDocumentSink func(document bson.D) error
+
+type mongoDocumentSinkKey struct{}
+
+// withMongoDocumentSink makes the finds & aggregates executed with the context hand their documents to sink as they are read.
+// None is kept in the result & the cursor limits don't apply, it streams the whole result of a query.
+func withMongoDocumentSink(ctx context.Context, sink mongoDocumentSink) context.Context {
+	return context.WithValue(ctx, mongoDocumentSinkKey{}, sink)
+}
+
+// drainMongoCursor hands every document of a cursor to sink, reading stops at the first error of sink
+func drainMongoCursor(ctx context.Context, cursor *mongo.Cursor, sink mongoDocumentSink) error {
+	for cursor.Next(ctx) {
+		var document bson.D
+		if err := cursor.Decode(&document); err != nil {
+			return err
+		}
+		if err := sink(document); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// logMongoCursorProgress logs the documents read so far, the batch handler of the finds & aggregates
+func logMongoCursorProgress(operation string) mongoCursorBatchHandler {
+	read := 0
+	return func(batch []bson.M) {
+		read += len(batch)
+		log.Printf("MongoDBDriver -> %s -> Read a batch of %d documents, %d so far", operation, len(batch), read)
+	}
+}
+
+// mongoCursorResult wraps the documents read from a cursor, the truncation is reported along the documents
+func mongoCursorResult(documents []bson.M, truncatedReason string) map[string]interface{} {
+	result := map[string]interface{}{
+		"results": documents,
+	}
+	if truncatedReason != "" {
+		result["truncated"] = true
+		result["truncatedReason"] = truncatedReason + ", refine the filter or add a limit to get the rest"
+	}
+	return result
+}
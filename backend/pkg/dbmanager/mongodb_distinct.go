@@ -0,0 +1,75 @@
+package dbmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// executeDistinct executes db.collection.distinct("field", {filter}) & returns the distinct values of the field
+func executeDistinct(ctx context.Context, collection *mongo.Collection, paramsStr string) ([]interface{}, *dtos.QueryError) {
+	field, filter, err := parseDistinctParams(paramsStr)
+	if err != nil {
+		return nil, &dtos.QueryError{
+			Message: fmt.Sprintf("Failed to parse distinct parameters: %v", err),
+			Code:    "INVALID_PARAMETERS",
+		}
+	}
+
+	log.Printf("MongoDBDriver -> executeDistinct -> Distinct values of %s in %s", field, collection.Name())
+	values, err := collection.Distinct(ctx, field, filter)
+	if err != nil {
+		return nil, &dtos.QueryError{
+			Message: fmt.Sprintf("Failed to execute distinct operation: %v", err),
+			Code:    "EXECUTION_ERROR",
+		}
+	}
+	if values == nil {
+		values = []interface{}{}
+	}
+	return values, nil
+}
+
+// parseDistinctParams parses the field name & the optional filter of a distinct, the filter defaults to every document
+func parseDistinctParams(paramsStr string) (string, bson.M, error) {
+	if strings.TrimSpace(paramsStr) == "" {
+		return "", nil, fmt.Errorf("distinct requires a field name")
+	}
+
+	// The field & the filter are parsed together as the arguments array
+	argsStr := "[" + paramsStr + "]"
+	var args []interface{}
+	if err := json.Unmarshal([]byte(argsStr), &args); err != nil {
+		jsonStr, err := processMongoDBQueryParams(argsStr)
+		if err != nil {
+			return "", nil, err
+		}
+		if err := json.Unmarshal([]byte(jsonStr), &args); err != nil {
+			return "", nil, fmt.Errorf("invalid parameters after conversion: %v", err)
+		}
+	}
+
+	field, ok := args[0].(string)
+	if !ok || strings.TrimSpace(field) == "" {
+		return "", nil, fmt.Errorf("distinct requires a field name")
+	}
+
+	filter := bson.M{}
+	if len(args) > 1 && args[1] != nil {
+		filterMap, ok := args[1].(map[string]interface{})
+		if !ok {
+			return "", nil, fmt.Errorf("distinct filter must be an object")
+		}
+		if err := processObjectIds(filterMap); err != nil {
+			return "", nil, err
+		}
+		filter = bson.M(filterMap)
+	}
+	return field, filter, nil
+}
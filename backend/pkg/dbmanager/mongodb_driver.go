@@ -15,10 +15,7 @@ import (
 	"strings"
 	"time"
 
-	"crypto/x509"
-
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
@@ -51,6 +48,8 @@ func (d *MongoDBDriver) GetSchema(ctx context.Context, db DBExecutor, selectedCo
 		return nil, fmt.Errorf("invalid MongoDB connection")
 	}
 
+	sampling := mongoSchemaSamplingFor(executor.conn)
+
 	// Get all collections in the database
 	var filter bson.M
 	if len(selectedCollections) > 0 && selectedCollections[0] != "ALL" {
@@ -92,7 +91,7 @@ func (d *MongoDBDriver) GetSchema(ctx context.Context, db DBExecutor, selectedCo
 		log.Printf("MongoDBDriver -> GetSchema -> Processing collection: %s", collName)
 
 		// Get collection details
-		collection, err := d.getCollectionDetails(ctx, wrapper, collName)
+		collection, err := d.getCollectionDetails(ctx, executor, collName, sampling)
 		if err != nil {
 			log.Printf("MongoDBDriver -> GetSchema -> Error getting collection details: %v", err)
 			continue
@@ -118,8 +117,10 @@ func (d *MongoDBDriver) GetSchema(ctx context.Context, db DBExecutor, selectedCo
 	return convertMongoDBSchemaToSchemaInfo(mongoSchema), nil
 }
 
-// getCollectionDetails retrieves details about a MongoDB collection
-func (d *MongoDBDriver) getCollectionDetails(ctx context.Context, wrapper *MongoDBWrapper, collName string) (MongoDBCollection, error) {
+// getCollectionDetails retrieves details about a MongoDB collection, its fields are inferred from a sample of documents
+func (d *MongoDBDriver) getCollectionDetails(ctx context.Context, executor *MongoDBExecutor, collName string, sampling mongoSchemaSampling) (MongoDBCollection, error) {
+	wrapper := executor.wrapper
+
 	// Create a new collection
 	collection := MongoDBCollection{
 		Name:   collName,
@@ -138,22 +139,13 @@ func (d *MongoDBDriver) getCollectionDetails(ctx context.Context, wrapper *Mongo
 		return collection, nil
 	}
 
-	// Sample documents to infer schema
-	sampleLimit := int64(50) // Sample up to 50 documents
-	log.Printf("MongoDBDriver -> getCollectionDetails -> Will sample up to %d documents from collection %s for schema inference", sampleLimit, collName)
+	// Sample random documents to infer schema, the first documents of a collection would miss the fields added recently
+	log.Printf("MongoDBDriver -> getCollectionDetails -> Will sample up to %d random & %d newest documents from collection %s for schema inference", sampling.SampleSize, sampling.NewestSize, collName)
 
-	opts := options.Find().SetLimit(sampleLimit)
-	cursor, err := wrapper.Client.Database(wrapper.Database).Collection(collName).Find(ctx, bson.M{}, opts)
+	documents, err := executor.sampleDocumentsForSchema(ctx, collName, sampling)
 	if err != nil {
 		return collection, fmt.Errorf("failed to sample documents: %v", err)
 	}
-	defer cursor.Close(ctx)
-
-	// Process each document to infer schema
-	var documents []bson.M
-	if err := cursor.All(ctx, &documents); err != nil {
-		return collection, fmt.Errorf("failed to decode documents: %v", err)
-	}
 
 	log.Printf("MongoDBDriver -> getCollectionDetails -> Retrieved exactly %d documents from collection %s for schema inference", len(documents), collName)
 
@@ -175,31 +167,8 @@ func (d *MongoDBDriver) getCollectionDetails(ctx context.Context, wrapper *Mongo
 				}
 			}
 
-			// Determine field type
-			fieldType := getMongoDBFieldType(value)
-			if field.Type == "" {
-				field.Type = fieldType
-			} else if field.Type != fieldType && fieldType != "null" {
-				// If types don't match, use a more generic type
-				field.Type = "mixed"
-			}
-
-			// Check if it's an array
-			if _, isArray := value.(primitive.A); isArray {
-				field.IsArray = true
-			}
-
-			// Handle nested fields for objects
-			if doc, isDoc := value.(bson.M); isDoc {
-				for nestedKey, nestedValue := range doc {
-					nestedField := MongoDBField{
-						Name:       nestedKey,
-						Type:       getMongoDBFieldType(nestedValue),
-						IsRequired: true,
-					}
-					field.NestedFields[nestedKey] = nestedField
-				}
-			}
+			// Determine field type, types that don't match become mixed & nested documents are inferred up to the max depth
+			inferMongoDBFieldValue(&field, value, 1, sampling)
 
 			fields[key] = field
 		}
@@ -281,8 +250,10 @@ func (d *MongoDBDriver) GetTableChecksum(ctx context.Context, db DBExecutor, col
 		return "", fmt.Errorf("invalid MongoDB connection")
 	}
 
-	// Get collection schema
-	coll, err := d.getCollectionDetails(ctx, wrapper, collection)
+	// Get collection schema, a random sample would change the checksum between calls
+	sampling := mongoSchemaSamplingFor(executor.conn)
+	sampling.NewestOnly = true
+	coll, err := d.getCollectionDetails(ctx, executor, collection, sampling)
 	if err != nil {
 		return "", fmt.Errorf("failed to get collection details: %v", err)
 	}
@@ -351,6 +322,10 @@ func (d *MongoDBDriver) FetchExampleRecords(ctx context.Context, db DBExecutor,
 
 	// Fetch sample documents
 	opts := options.Find().SetLimit(int64(limit))
+	if strings.HasSuffix(collection, gridFSChunksSuffix) {
+		// The chunks of a GridFS bucket hold the file content, it is left out of the examples
+		opts.SetProjection(bson.M{"data": 0})
+	}
 	cursor, err := wrapper.Client.Database(wrapper.Database).Collection(collection).Find(ctx, bson.M{}, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch example records: %v", err)
@@ -392,7 +367,6 @@ func (d *MongoDBDriver) FetchExampleRecords(ctx context.Context, db DBExecutor,
 
 // Connect establishes a connection to a MongoDB database
 func (d *MongoDBDriver) Connect(config ConnectionConfig) (*Connection, error) {
-	var tempFiles []string
 	log.Printf("MongoDBDriver -> Connect -> Connecting to MongoDB at %s:%v", config.Host, config.Port)
 
 	var uri string
@@ -481,6 +455,11 @@ func (d *MongoDBDriver) Connect(config ConnectionConfig) (*Connection, error) {
 		log.Printf("MongoDBDriver -> Connect -> Using authentication database: %s", *config.AuthDatabase)
 	}
 
+	// Reads of a read replica connection are served by the secondaries
+	if config.isReadReplica {
+		uri += "&readPreference=secondaryPreferred"
+	}
+
 	// Log the final URI (with sensitive parts masked)
 	maskedUri := uri
 	if config.Password != nil && *config.Password != "" {
@@ -490,6 +469,9 @@ func (d *MongoDBDriver) Connect(config ConnectionConfig) (*Connection, error) {
 
 	// Configure client options
 	clientOptions := options.Client().ApplyURI(uri)
+	if err := applyMongoDBConcerns(clientOptions, config); err != nil {
+		return nil, err
+	}
 
 	// Set a shorter connection timeout for encrypted connections
 	if strings.Contains(config.Host, "+") || strings.Contains(config.Host, "/") || strings.Contains(config.Host, "=") {
@@ -511,54 +493,20 @@ func (d *MongoDBDriver) Connect(config ConnectionConfig) (*Connection, error) {
 		if sslMode == "disable" {
 			// Do nothing
 		} else {
-			// Fetch certificates from URLs
-			certPath, keyPath, rootCertPath, certTempFiles, err := utils.PrepareCertificatesFromURLs(*config.SSLCertURL, *config.SSLKeyURL, *config.SSLRootCertURL)
+			// Load certificates, uploaded ones are used over the URLs
+			certs, err := loadSSLCertificates(config)
 			if err != nil {
 				return nil, err
 			}
 
-			// Track temporary files for cleanup
-			tempFiles = certTempFiles
-
 			// Configure TLS
 			tlsConfig := &tls.Config{
 				InsecureSkipVerify: false, // Always verify certificates
 			}
 
-			// Add client certificates if provided
-			if certPath != "" && keyPath != "" {
-				cert, err := tls.LoadX509KeyPair(certPath, keyPath)
-				if err != nil {
-					// Clean up temporary files
-					for _, file := range tempFiles {
-						os.Remove(file)
-					}
-					return nil, fmt.Errorf("failed to load client certificates: %v", err)
-				}
-				tlsConfig.Certificates = []tls.Certificate{cert}
-			}
-
-			// Add root CA if provided
-			if rootCertPath != "" {
-				rootCA, err := os.ReadFile(rootCertPath)
-				if err != nil {
-					// Clean up temporary files
-					for _, file := range tempFiles {
-						os.Remove(file)
-					}
-					return nil, fmt.Errorf("failed to read root CA: %v", err)
-				}
-
-				rootCertPool := x509.NewCertPool()
-				if ok := rootCertPool.AppendCertsFromPEM(rootCA); !ok {
-					// Clean up temporary files
-					for _, file := range tempFiles {
-						os.Remove(file)
-					}
-					return nil, fmt.Errorf("failed to parse root CA certificate")
-				}
-
-				tlsConfig.RootCAs = rootCertPool
+			// Add client & CA certificates if provided
+			if err := certs.applyTo(tlsConfig); err != nil {
+				return nil, err
 			}
 
 			clientOptions.SetTLSConfig(tlsConfig)
@@ -572,16 +520,21 @@ func (d *MongoDBDriver) Connect(config ConnectionConfig) (*Connection, error) {
 	clientOptions.SetMinPoolSize(5)
 	clientOptions.SetMaxConnIdleTime(time.Hour)
 
+	// Dial through the proxy of the connection if any
+	dial, err := getProxyDialFunc(config)
+	if err != nil {
+		return nil, err
+	}
+	if dial != nil {
+		clientOptions.SetDialer(dial)
+	}
+
 	// Connect to MongoDB with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
-		// Clean up temporary files
-		for _, file := range tempFiles {
-			os.Remove(file)
-		}
 		log.Printf("MongoDBDriver -> Connect -> Error connecting to MongoDB: %v", err)
 		return nil, fmt.Errorf("failed to connect to MongoDB: %v", err)
 	}
@@ -589,10 +542,6 @@ func (d *MongoDBDriver) Connect(config ConnectionConfig) (*Connection, error) {
 	// Ping the database to verify connection
 	err = client.Ping(ctx, readpref.Primary())
 	if err != nil {
-		// Clean up temporary files
-		for _, file := range tempFiles {
-			os.Remove(file)
-		}
 		client.Disconnect(ctx)
 		log.Printf("MongoDBDriver -> Connect -> Error pinging MongoDB: %v", err)
 		return nil, fmt.Errorf("failed to ping MongoDB: %v", err)
@@ -611,7 +560,6 @@ func (d *MongoDBDriver) Connect(config ConnectionConfig) (*Connection, error) {
 		Status:     StatusConnected,
 		Config:     config,
 		MongoDBObj: mongoWrapper, // Store MongoDB client in a custom field
-		TempFiles:  tempFiles,    // Store temporary files for cleanup
 		// Other fields will be set by the manager
 	}
 
@@ -695,6 +643,11 @@ func (d *MongoDBDriver) ExecuteQuery(ctx context.Context, conn *Connection, quer
 		}
 	}
 
+	// db.runCommand() only runs collMod, its document may hold dots the database-level operations don't expect
+	if mongoRunCommandRegex.MatchString(query) {
+		return executeRunCommand(ctx, wrapper.Client.Database(wrapper.Database), query, startTime)
+	}
+
 	// Handle special query format for MongoDB operations like db.getCollectionNames()
 	if strings.HasPrefix(query, "db.") && !strings.Contains(query[3:], ".") {
 		// Operations that are not tied to a specific collection
@@ -717,6 +670,9 @@ func (d *MongoDBDriver) ExecuteQuery(ctx context.Context, conn *Connection, quer
 		log.Printf("MongoDBDriver -> ExecuteQuery -> Matched database operation: %s with params: %s", operation, paramsStr)
 
 		switch operation {
+		case "getGridFSBuckets":
+			return executeGetGridFSBuckets(ctx, wrapper.Client.Database(wrapper.Database), startTime)
+
 		case "getCollectionNames":
 			// List all collections in the database
 			collections, err := wrapper.Client.Database(wrapper.Database).ListCollectionNames(ctx, bson.M{})
@@ -764,9 +720,9 @@ func (d *MongoDBDriver) ExecuteQuery(ctx context.Context, conn *Connection, quer
 	}
 
 	// Parse the query
-	// Example: db.collection.find({name: "John"})
-	parts := strings.SplitN(query, ".", 3)
-	if len(parts) < 3 || !strings.HasPrefix(parts[0], "db") {
+	// Example: db.collection.find({name: "John"}) or db.getCollection("fs.files").find({})
+	collectionName, operationWithParams, ok := splitMongoCollectionQuery(query)
+	if !ok {
 		return &QueryExecutionResult{
 			Error: &dtos.QueryError{
 				Message: "Invalid MongoDB query format. Expected: db.collection.operation({...}) or db.operation(...)",
@@ -775,9 +731,6 @@ func (d *MongoDBDriver) ExecuteQuery(ctx context.Context, conn *Connection, quer
 		}
 	}
 
-	collectionName := parts[1]
-	operationWithParams := parts[2]
-
 	// Special case handling for empty find() with modifiers
 	// Like db.collection.find().sort()
 	if strings.HasPrefix(operationWithParams, "find()") && len(operationWithParams) > 6 {
@@ -874,6 +827,21 @@ func (d *MongoDBDriver) ExecuteQuery(ctx context.Context, conn *Connection, quer
 				modifiers["sort"] = sortExpr
 			}
 		}
+
+		// Extract collation modifier, applied to the comparisons of the filter, the sort & the pipeline
+		collationStr, found, err := extractCollationModifier(modifiersStr)
+		if err != nil {
+			return &QueryExecutionResult{
+				Error: &dtos.QueryError{
+					Message: err.Error(),
+					Code:    "INVALID_PARAMETERS",
+				},
+			}
+		}
+		if found {
+			modifiers["collation"] = collationStr
+			log.Printf("MongoDBDriver -> ExecuteQuery -> Found collation modifier: %s", collationStr)
+		}
 	}
 
 	// Get the MongoDB collection
@@ -902,9 +870,24 @@ func (d *MongoDBDriver) ExecuteQuery(ctx context.Context, conn *Connection, quer
 		}
 	}
 
+	// .explain() returns the plan of a find or an aggregate instead of its documents
+	if explainModifiers := operationWithParams[closeParenIndex+1:]; mongoExplainRegex.MatchString(explainModifiers) {
+		return executeExplain(ctx, collection, operation, paramsStr, modifiers, explainModifiers, startTime)
+	}
+
 	var result interface{}
 	var err error
 
+	collation, err := mongoCollationFromModifiers(operation, modifiers)
+	if err != nil {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Message: err.Error(),
+				Code:    "INVALID_PARAMETERS",
+			},
+		}
+	}
+
 	log.Printf("MongoDBDriver -> ExecuteQuery -> operation: %s", operation)
 	// Execute the operation based on the type
 	switch operation {
@@ -1038,7 +1021,7 @@ func (d *MongoDBDriver) ExecuteQuery(ctx context.Context, conn *Connection, quer
 		// If count() modifier is present, perform a count operation instead of find
 		if modifiers.Count {
 			// Execute the countDocuments operation
-			count, err := collection.CountDocuments(ctx, filter)
+			count, err := collection.CountDocuments(ctx, filter, options.Count().SetCollation(collation))
 			if err != nil {
 				return &QueryExecutionResult{
 					Error: &dtos.QueryError{
@@ -1055,7 +1038,12 @@ func (d *MongoDBDriver) ExecuteQuery(ctx context.Context, conn *Connection, quer
 		}
 
 		// Create find options
-		findOptions := options.Find()
+		findOptions := options.Find().SetCollation(collation)
+
+		// The server stops the query at the timeout too
+		if maxTime, ok := remainingQueryTime(ctx); ok {
+			findOptions.SetMaxTime(maxTime)
+		}
 
 		// Apply limit if specified
 		if modifiers.Limit > 0 {
@@ -1154,7 +1142,8 @@ func (d *MongoDBDriver) ExecuteQuery(ctx context.Context, conn *Connection, quer
 			findOptions.SetProjection(projectionDoc)
 		}
 
-		// Execute the find operation
+		// Execute the find operation, fetching the documents in batches
+		findOptions.SetBatchSize(mongoDBCursorLimits.BatchSize)
 		cursor, err := collection.Find(ctx, filter, findOptions)
 		if err != nil {
 			return &QueryExecutionResult{
@@ -1166,9 +1155,9 @@ func (d *MongoDBDriver) ExecuteQuery(ctx context.Context, conn *Connection, quer
 		}
 		defer cursor.Close(ctx)
 
-		// Decode the results
-		var results []bson.M
-		if err := cursor.All(ctx, &results); err != nil {
+		// Decode the results batch by batch, up to the cursor limits
+		results, truncatedReason, err := readMongoCursor(ctx, cursor, logMongoCursorProgress("ExecuteQuery"))
+		if err != nil {
 			return &QueryExecutionResult{
 				Error: &dtos.QueryError{
 					Message: fmt.Sprintf("Failed to decode find results: %v", err),
@@ -1177,7 +1166,11 @@ func (d *MongoDBDriver) ExecuteQuery(ctx context.Context, conn *Connection, quer
 			}
 		}
 
-		result = results
+		if truncatedReason != "" {
+			result = mongoCursorResult(results, truncatedReason)
+		} else {
+			result = results
+		}
 
 	case "findOne":
 		// Parse the parameters as a BSON filter
@@ -1225,7 +1218,7 @@ func (d *MongoDBDriver) ExecuteQuery(ctx context.Context, conn *Connection, quer
 
 		// Execute the findOne operation
 		var doc bson.M
-		err = collection.FindOne(ctx, filter).Decode(&doc)
+		err = collection.FindOne(ctx, filter, options.FindOne().SetCollation(collation)).Decode(&doc)
 		if err != nil {
 			if err == mongo.ErrNoDocuments {
 				// No documents found, return empty result
@@ -1242,6 +1235,18 @@ func (d *MongoDBDriver) ExecuteQuery(ctx context.Context, conn *Connection, quer
 			result = doc
 		}
 
+	case "findOneAndUpdate", "findOneAndReplace", "findOneAndDelete":
+		doc, queryErr := executeFindAndModify(ctx, collection, operation, paramsStr)
+		if queryErr != nil {
+			return &QueryExecutionResult{Error: queryErr}
+		}
+		if doc == nil {
+			// No document matched, as findOne
+			result = nil
+		} else {
+			result = doc
+		}
+
 	case "insertOne":
 		// Parse the parameters as a BSON document
 		var document bson.M
@@ -1841,8 +1846,25 @@ func (d *MongoDBDriver) ExecuteQuery(ctx context.Context, conn *Connection, quer
 			log.Printf("MongoDBDriver -> ExecuteQuery -> Error processing dot notation in pipeline: %v", err)
 		}
 
+		// Aggregations ending with $out or $merge are writes, their result is the written collection
+		if target, isWrite, err := findAggregationWriteTarget(pipeline); isWrite {
+			if err != nil {
+				return &QueryExecutionResult{
+					Error: &dtos.QueryError{
+						Message: err.Error(),
+						Code:    "INVALID_PARAMETERS",
+					},
+				}
+			}
+			return executeAggregationWrite(ctx, collection, pipeline, target, collation, startTime)
+		}
+
 		// Execute the aggregation
-		cursor, err := collection.Aggregate(ctx, pipeline)
+		aggregateOptions := options.Aggregate().SetBatchSize(mongoDBCursorLimits.BatchSize).SetCollation(collation)
+		if maxTime, ok := remainingQueryTime(ctx); ok {
+			aggregateOptions.SetMaxTime(maxTime)
+		}
+		cursor, err := collection.Aggregate(ctx, pipeline, aggregateOptions)
 		if err != nil {
 			log.Printf("MongoDBDriver -> ExecuteQuery -> Error executing aggregation: %v", err)
 			return &QueryExecutionResult{
@@ -1915,7 +1937,7 @@ func (d *MongoDBDriver) ExecuteQuery(ctx context.Context, conn *Connection, quer
 		}
 
 		// Execute the countDocuments operation
-		count, err := collection.CountDocuments(ctx, filter)
+		count, err := collection.CountDocuments(ctx, filter, options.Count().SetCollation(collation))
 		if err != nil {
 			return &QueryExecutionResult{
 				Error: &dtos.QueryError{
@@ -1929,6 +1951,34 @@ func (d *MongoDBDriver) ExecuteQuery(ctx context.Context, conn *Connection, quer
 			"count": count,
 		}
 
+	case "distinct":
+		values, queryErr := executeDistinct(ctx, collection, paramsStr)
+		if queryErr != nil {
+			return &QueryExecutionResult{Error: queryErr}
+		}
+		result = values
+
+	case "createIndex":
+		indexResult, queryErr := executeCreateIndex(ctx, collection, paramsStr)
+		if queryErr != nil {
+			return &QueryExecutionResult{Error: queryErr}
+		}
+		result = indexResult
+
+	case "dropIndex":
+		indexResult, queryErr := executeDropIndex(ctx, collection, paramsStr)
+		if queryErr != nil {
+			return &QueryExecutionResult{Error: queryErr}
+		}
+		result = indexResult
+
+	case "renameCollection":
+		renameResult, queryErr := executeRenameCollection(ctx, collection, paramsStr)
+		if queryErr != nil {
+			return &QueryExecutionResult{Error: queryErr}
+		}
+		result = renameResult
+
 	case "createCollection":
 		// Execute the createCollection operation with default options
 		// We're simplifying this implementation to avoid complex option handling
@@ -2012,6 +2062,13 @@ func (d *MongoDBDriver) ExecuteQuery(ctx context.Context, conn *Connection, quer
 			"message": fmt.Sprintf("Collection '%s' dropped successfully", collectionName),
 		}
 
+	case "bulkWrite":
+		bulkResult, queryErr := executeBulkWrite(ctx, collection, paramsStr)
+		if queryErr != nil {
+			return &QueryExecutionResult{Error: queryErr}
+		}
+		result = bulkResult
+
 	default:
 		return &QueryExecutionResult{
 			Error: &dtos.QueryError{
@@ -2120,7 +2177,7 @@ func (d *MongoDBDriver) BeginTx(ctx context.Context, conn *Connection) Transacti
 
 	// Start a transaction with retry logic
 	for attempts := 0; attempts < 3; attempts++ {
-		err = session.StartTransaction()
+		err = session.StartTransaction(mongoDBTransactionOptions(conn.Config))
 		if err == nil {
 			break
 		}
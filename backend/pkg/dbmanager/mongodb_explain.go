@@ -0,0 +1,300 @@
+package dbmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Verbosities of explain, executionStats is used by default as it gives the documents examined & the execution time
+const (
+	ExplainVerbosityQueryPlanner      = "queryPlanner"
+	ExplainVerbosityExecutionStats    = "executionStats"
+	ExplainVerbosityAllPlansExecution = "allPlansExecution"
+)
+
+// mongoExplainRegex matches the .explain() modifier, the verbosity argument is captured
+var mongoExplainRegex = regexp.MustCompile(`\.explain\(\s*([^)]*?)\s*\)`)
+
+// extractExplainVerbosity returns the verbosity of the .explain() modifier of a query, false when the query is not explained
+func extractExplainVerbosity(modifiersStr string) (string, bool, error) {
+	match := mongoExplainRegex.FindStringSubmatch(modifiersStr)
+	if match == nil {
+		return "", false, nil
+	}
+
+	switch verbosity := strings.Trim(match[1], `"'`); verbosity {
+	case "", ExplainVerbosityExecutionStats:
+		return ExplainVerbosityExecutionStats, true, nil
+	case ExplainVerbosityQueryPlanner, "false":
+		return ExplainVerbosityQueryPlanner, true, nil
+	case ExplainVerbosityAllPlansExecution, "true":
+		return ExplainVerbosityAllPlansExecution, true, nil
+	default:
+		return "", true, fmt.Errorf("unsupported explain verbosity %q, use queryPlanner, executionStats or allPlansExecution", verbosity)
+	}
+}
+
+// executeExplain runs the explain command for a find or an aggregate & summarizes the winning plan, the documents examined &
+// the execution time. Modifiers are the limit, skip & sort of a find, modifiersStr contains the .explain() call.
+func executeExplain(ctx context.Context, collection *mongo.Collection, operation, paramsStr string, modifiers map[string]interface{}, modifiersStr string, startTime time.Time) *QueryExecutionResult {
+	verbosity, _, err := extractExplainVerbosity(modifiersStr)
+	if err != nil {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Message: err.Error(),
+				Code:    "INVALID_PARAMETERS",
+			},
+		}
+	}
+
+	explained, err := buildExplainedCommand(collection.Name(), operation, paramsStr, modifiers)
+	if err != nil {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Message: fmt.Sprintf("Failed to parse %s parameters for explain: %v", operation, err),
+				Code:    "INVALID_PARAMETERS",
+			},
+		}
+	}
+
+	log.Printf("MongoDBDriver -> executeExplain -> Explaining %s on %s with verbosity %s", operation, collection.Name(), verbosity)
+	var output bson.M
+	command := bson.D{{Key: "explain", Value: explained}, {Key: "verbosity", Value: verbosity}}
+	if err := collection.Database().RunCommand(ctx, command).Decode(&output); err != nil {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Message: fmt.Sprintf("Failed to execute explain: %v", err),
+				Code:    "EXECUTION_ERROR",
+			},
+		}
+	}
+
+	result := summarizeExplain(output)
+	result["operation"] = operation
+	result["verbosity"] = verbosity
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Message: fmt.Sprintf("Failed to marshal result to JSON: %v", err),
+				Code:    "JSON_ERROR",
+			},
+		}
+	}
+
+	return &QueryExecutionResult{
+		Result:        result,
+		ResultJSON:    string(resultJSON),
+		ExecutionTime: int(time.Since(startTime).Milliseconds()),
+	}
+}
+
+// buildExplainedCommand builds the find or aggregate command to explain
+func buildExplainedCommand(collectionName, operation, paramsStr string, modifiers map[string]interface{}) (bson.D, error) {
+	args := splitMongoArgs(paramsStr)
+
+	switch operation {
+	case "find":
+		command := bson.D{{Key: "find", Value: collectionName}}
+		if len(args) > 2 {
+			return nil, fmt.Errorf("find expects a filter and an optional projection")
+		}
+		for i, name := range []string{"filter", "projection"} {
+			if i >= len(args) {
+				break
+			}
+			doc, err := parseOrderedMongoDocument(args[i])
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %v", name, err)
+			}
+			command = append(command, bson.E{Key: name, Value: doc})
+		}
+		if sortStr, ok := modifiers["sort"].(string); ok {
+			sort, err := parseOrderedMongoDocument(sortStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid sort: %v", err)
+			}
+			command = append(command, bson.E{Key: "sort", Value: sort})
+		}
+		if skip, ok := modifiers["skip"].(int); ok {
+			command = append(command, bson.E{Key: "skip", Value: int64(skip)})
+		}
+		if limit, ok := modifiers["limit"].(int); ok {
+			command = append(command, bson.E{Key: "limit", Value: int64(limit)})
+		}
+		return appendExplainedCollation(command, operation, modifiers)
+	case "aggregate":
+		if len(args) == 0 {
+			return nil, fmt.Errorf("aggregate expects a pipeline")
+		}
+		pipelineStr := strings.TrimSpace(args[0])
+		if !strings.HasPrefix(pipelineStr, "[") || !strings.HasSuffix(pipelineStr, "]") {
+			return nil, fmt.Errorf("the pipeline must be an array of stages")
+		}
+
+		// The stages are parsed one by one, as the aggregate operation does
+		pipeline := bson.A{}
+		for _, stageStr := range splitMongoArgs(pipelineStr[1 : len(pipelineStr)-1]) {
+			stage, err := parseOrderedMongoDocument(stageStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid stage %s: %v", stageStr, err)
+			}
+			pipeline = append(pipeline, stage)
+		}
+		return appendExplainedCollation(bson.D{
+			{Key: "aggregate", Value: collectionName},
+			{Key: "pipeline", Value: pipeline},
+			{Key: "cursor", Value: bson.D{}},
+		}, operation, modifiers)
+	default:
+		return nil, fmt.Errorf("explain is only supported for find and aggregate")
+	}
+}
+
+// appendExplainedCollation adds the collation of the .collation() modifier to the explained command, the collation changes the plan as indexes only serve matching collations
+func appendExplainedCollation(command bson.D, operation string, modifiers map[string]interface{}) (bson.D, error) {
+	collation, err := mongoCollationFromModifiers(operation, modifiers)
+	if err != nil || collation == nil {
+		return command, err
+	}
+	// options.Collation has no bson keys, ToDocument writes the keys expected by the server, e.g. caseLevel
+	return append(command, bson.E{Key: "collation", Value: collation.ToDocument()}), nil
+}
+
+// summarizeExplain extracts the winning plan, the indexes used & the execution stats of an explain output.
+// Aggregations starting with a $cursor stage report the plan of the underlying query in that stage.
+func summarizeExplain(output bson.M) map[string]interface{} {
+	queryPlanner, _ := output["queryPlanner"].(bson.M)
+	executionStats, _ := output["executionStats"].(bson.M)
+	if queryPlanner == nil {
+		if stages, ok := output["stages"].(bson.A); ok && len(stages) > 0 {
+			if firstStage, ok := stages[0].(bson.M); ok {
+				if cursor, ok := firstStage["$cursor"].(bson.M); ok {
+					queryPlanner, _ = cursor["queryPlanner"].(bson.M)
+					executionStats, _ = cursor["executionStats"].(bson.M)
+				}
+			}
+		}
+	}
+
+	summary := map[string]interface{}{}
+	var winningPlan bson.M
+	if queryPlanner != nil {
+		winningPlan, _ = queryPlanner["winningPlan"].(bson.M)
+		// The slot based engine nests the plan
+		if queryPlan, ok := winningPlan["queryPlan"].(bson.M); ok {
+			winningPlan = queryPlan
+		}
+		summary["namespace"] = queryPlanner["namespace"]
+	}
+	summary["winningPlan"] = winningPlan
+
+	indexes := []string{}
+	stages := []string{}
+	collectExplainStages(winningPlan, &stages, &indexes)
+	summary["stages"] = stages
+	summary["indexesUsed"] = indexes
+	summary["collectionScan"] = containsString(stages, "COLLSCAN")
+
+	if executionStats != nil {
+		summary["nReturned"] = executionStats["nReturned"]
+		summary["executionTimeMillis"] = executionStats["executionTimeMillis"]
+		summary["totalDocsExamined"] = executionStats["totalDocsExamined"]
+		summary["totalKeysExamined"] = executionStats["totalKeysExamined"]
+	}
+	return summary
+}
+
+// collectExplainStages walks a plan tree from the root, recording the stages & the indexes scanned
+func collectExplainStages(plan bson.M, stages, indexes *[]string) {
+	if plan == nil {
+		return
+	}
+	if stage, ok := plan["stage"].(string); ok {
+		*stages = append(*stages, stage)
+	}
+	if indexName, ok := plan["indexName"].(string); ok && !containsString(*indexes, indexName) {
+		*indexes = append(*indexes, indexName)
+	}
+
+	if inputStage, ok := plan["inputStage"].(bson.M); ok {
+		collectExplainStages(inputStage, stages, indexes)
+	}
+	if inputStages, ok := plan["inputStages"].(bson.A); ok {
+		for _, input := range inputStages {
+			if inputStage, ok := input.(bson.M); ok {
+				collectExplainStages(inputStage, stages, indexes)
+			}
+		}
+	}
+}
+
+// parseOrderedMongoDocument parses a single document of the mongo shell syntax, keeping the order of its keys.
+// The document is processed alone, as $project stages are rewritten by processMongoDBQueryParams.
+func parseOrderedMongoDocument(docStr string) (bson.D, error) {
+	jsonStr, err := processMongoDBQueryParams(strings.TrimSpace(docStr))
+	if err != nil {
+		return nil, err
+	}
+	var doc bson.D
+	if err := bson.UnmarshalExtJSON([]byte(jsonStr), false, &doc); err != nil {
+		return nil, fmt.Errorf("expected an object: %v", err)
+	}
+	return doc, nil
+}
+
+// splitMongoArgs splits arguments or array elements at their top level commas, ignoring the ones in strings & nested values
+func splitMongoArgs(argsStr string) []string {
+	args := []string{}
+	depth := 0
+	var quote rune
+	escaped := false
+	start := 0
+
+	for i, char := range argsStr {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0:
+			if char == '\\' {
+				escaped = true
+			} else if char == quote {
+				quote = 0
+			}
+		case char == '"' || char == '\'':
+			quote = char
+		case char == '{' || char == '[' || char == '(':
+			depth++
+		case char == '}' || char == ']' || char == ')':
+			depth--
+		case char == ',' && depth == 0:
+			if arg := strings.TrimSpace(argsStr[start:i]); arg != "" {
+				args = append(args, arg)
+			}
+			start = i + 1
+		}
+	}
+	if arg := strings.TrimSpace(argsStr[start:]); arg != "" {
+		args = append(args, arg)
+	}
+	return args
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
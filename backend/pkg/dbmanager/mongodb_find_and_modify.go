@@ -0,0 +1,192 @@
+package dbmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// findAndModifyOptions are the options of findOneAndUpdate, findOneAndReplace & findOneAndDelete, as in the mongo shell
+type findAndModifyOptions struct {
+	ReturnAfter  bool // returnDocument: "after" or returnNewDocument: true
+	Upsert       bool
+	Projection   map[string]interface{}
+	Sort         map[string]interface{}
+	ArrayFilters []interface{}
+}
+
+// executeFindAndModify executes findOneAndUpdate, findOneAndReplace or findOneAndDelete atomically & returns the
+// document before or after the change, nil when no document matched
+func executeFindAndModify(ctx context.Context, collection *mongo.Collection, operation, paramsStr string) (bson.M, *dtos.QueryError) {
+	// findOneAndDelete has no update argument, its options come right after the filter
+	expectedArgs := 2
+	if operation == "findOneAndDelete" {
+		expectedArgs = 1
+	}
+
+	filter, change, opts, err := parseFindAndModifyParams(paramsStr, expectedArgs)
+	if err != nil {
+		return nil, &dtos.QueryError{
+			Message: fmt.Sprintf("Failed to parse %s parameters: %v", operation, err),
+			Code:    "INVALID_PARAMETERS",
+		}
+	}
+
+	returnDocument := options.Before
+	if opts.ReturnAfter {
+		returnDocument = options.After
+	}
+
+	log.Printf("MongoDBDriver -> executeFindAndModify -> Executing %s on %s (return after: %t)", operation, collection.Name(), opts.ReturnAfter)
+	var singleResult *mongo.SingleResult
+	switch operation {
+	case "findOneAndUpdate":
+		switch change.(type) {
+		case map[string]interface{}, []interface{}:
+		default:
+			return nil, &dtos.QueryError{
+				Message: "findOneAndUpdate requires an update document or pipeline",
+				Code:    "INVALID_PARAMETERS",
+			}
+		}
+		findOpts := options.FindOneAndUpdate().SetReturnDocument(returnDocument).SetUpsert(opts.Upsert)
+		if opts.Projection != nil {
+			findOpts.SetProjection(opts.Projection)
+		}
+		if opts.Sort != nil {
+			findOpts.SetSort(opts.Sort)
+		}
+		if len(opts.ArrayFilters) > 0 {
+			findOpts.SetArrayFilters(options.ArrayFilters{Filters: opts.ArrayFilters})
+		}
+		singleResult = collection.FindOneAndUpdate(ctx, filter, change, findOpts)
+	case "findOneAndReplace":
+		replacement, ok := change.(map[string]interface{})
+		if !ok {
+			return nil, &dtos.QueryError{
+				Message: "findOneAndReplace requires a replacement document",
+				Code:    "INVALID_PARAMETERS",
+			}
+		}
+		findOpts := options.FindOneAndReplace().SetReturnDocument(returnDocument).SetUpsert(opts.Upsert)
+		if opts.Projection != nil {
+			findOpts.SetProjection(opts.Projection)
+		}
+		if opts.Sort != nil {
+			findOpts.SetSort(opts.Sort)
+		}
+		singleResult = collection.FindOneAndReplace(ctx, filter, replacement, findOpts)
+	default:
+		findOpts := options.FindOneAndDelete()
+		if opts.Projection != nil {
+			findOpts.SetProjection(opts.Projection)
+		}
+		if opts.Sort != nil {
+			findOpts.SetSort(opts.Sort)
+		}
+		singleResult = collection.FindOneAndDelete(ctx, filter, findOpts)
+	}
+
+	var doc bson.M
+	if err := singleResult.Decode(&doc); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, &dtos.QueryError{
+			Message: fmt.Sprintf("Failed to execute %s operation: %v", operation, err),
+			Code:    "EXECUTION_ERROR",
+		}
+	}
+	return doc, nil
+}
+
+// parseFindAndModifyParams parses the filter, the update or replacement & the options of a find and modify operation.
+// expectedArgs is the number of arguments before the options, 1 for findOneAndDelete & 2 otherwise.
+func parseFindAndModifyParams(paramsStr string, expectedArgs int) (bson.M, interface{}, findAndModifyOptions, error) {
+	var opts findAndModifyOptions
+
+	// The arguments are parsed together as an array
+	argsStr := "[" + paramsStr + "]"
+	var args []interface{}
+	if err := json.Unmarshal([]byte(argsStr), &args); err != nil {
+		jsonStr, err := processMongoDBQueryParams(argsStr)
+		if err != nil {
+			return nil, nil, opts, err
+		}
+		if err := json.Unmarshal([]byte(jsonStr), &args); err != nil {
+			return nil, nil, opts, fmt.Errorf("invalid parameters after conversion: %v", err)
+		}
+	}
+	if len(args) < expectedArgs {
+		if expectedArgs == 1 {
+			return nil, nil, opts, fmt.Errorf("a filter is required")
+		}
+		return nil, nil, opts, fmt.Errorf("a filter and an update are required")
+	}
+	if len(args) > expectedArgs+1 {
+		return nil, nil, opts, fmt.Errorf("too many arguments")
+	}
+
+	filter, ok := args[0].(map[string]interface{})
+	if !ok {
+		return nil, nil, opts, fmt.Errorf("the filter must be an object")
+	}
+	if err := processObjectIds(filter); err != nil {
+		return nil, nil, opts, err
+	}
+
+	var change interface{}
+	if expectedArgs == 2 {
+		change = args[1]
+		switch value := change.(type) {
+		case map[string]interface{}:
+			if err := processObjectIds(value); err != nil {
+				return nil, nil, opts, err
+			}
+		case []interface{}:
+			// Update pipelines are processed stage by stage
+			if err := processObjectIds(map[string]interface{}{"pipeline": value}); err != nil {
+				return nil, nil, opts, err
+			}
+		}
+	}
+
+	if len(args) > expectedArgs {
+		optsMap, ok := args[expectedArgs].(map[string]interface{})
+		if !ok {
+			return nil, nil, opts, fmt.Errorf("the options must be an object")
+		}
+		if err := processObjectIds(optsMap); err != nil {
+			return nil, nil, opts, err
+		}
+
+		if returnDocument, exists := optsMap["returnDocument"]; exists {
+			value, _ := returnDocument.(string)
+			switch strings.ToLower(value) {
+			case "after":
+				opts.ReturnAfter = true
+			case "before":
+				opts.ReturnAfter = false
+			default:
+				return nil, nil, opts, fmt.Errorf("returnDocument must be \"before\" or \"after\"")
+			}
+		}
+		// Legacy shell option
+		if returnNew, ok := optsMap["returnNewDocument"].(bool); ok {
+			opts.ReturnAfter = returnNew
+		}
+		opts.Upsert, _ = optsMap["upsert"].(bool)
+		opts.Projection, _ = optsMap["projection"].(map[string]interface{})
+		opts.Sort, _ = optsMap["sort"].(map[string]interface{})
+		opts.ArrayFilters, _ = optsMap["arrayFilters"].([]interface{})
+	}
+
+	return bson.M(filter), change, opts, nil
+}
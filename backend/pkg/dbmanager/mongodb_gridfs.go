@@ -0,0 +1,184 @@
+package dbmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// A GridFS bucket stores the file metadata in <bucket>.files & the file content split in chunks in <bucket>.chunks
+const (
+	gridFSFilesSuffix  = ".files"
+	gridFSChunksSuffix = ".chunks"
+)
+
+// mongoGetCollectionRegex matches db.getCollection("name"). used for the collections whose name has a dot, e.g. fs.files
+var mongoGetCollectionRegex = regexp.MustCompile(`^db\.getCollection\(\s*["']([^"']+)["']\s*\)\.`)
+
+// GridFSBucket summarizes a GridFS bucket of a database
+type GridFSBucket struct {
+	Name       string `json:"name"`
+	Files      int64  `json:"files"`
+	Chunks     int64  `json:"chunks"`
+	TotalBytes int64  `json:"totalBytes"`
+}
+
+// splitMongoCollectionQuery splits a collection query into the collection name & the operation with its parameters,
+// e.g. db.users.find({}) or db.getCollection("fs.files").find({})
+func splitMongoCollectionQuery(query string) (string, string, bool) {
+	if match := mongoGetCollectionRegex.FindStringSubmatch(query); match != nil {
+		return match[1], query[len(match[0]):], true
+	}
+
+	parts := strings.SplitN(query, ".", 3)
+	if len(parts) < 3 || !strings.HasPrefix(parts[0], "db") {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+// detectGridFSBuckets returns the GridFS bucket of the collections storing one, a bucket needs both its files & chunks collections
+func detectGridFSBuckets(collections []string) map[string]string {
+	names := make(map[string]bool, len(collections))
+	for _, name := range collections {
+		names[name] = true
+	}
+
+	buckets := make(map[string]string)
+	for _, name := range collections {
+		if !strings.HasSuffix(name, gridFSFilesSuffix) {
+			continue
+		}
+		bucket := strings.TrimSuffix(name, gridFSFilesSuffix)
+		if bucket != "" && names[bucket+gridFSChunksSuffix] {
+			buckets[name] = bucket
+			buckets[bucket+gridFSChunksSuffix] = bucket
+		}
+	}
+	return buckets
+}
+
+// gridFSChunksFields are the fields of a chunks collection, the collection is not sampled as its documents hold the file content
+func gridFSChunksFields() map[string]MongoDBField {
+	return map[string]MongoDBField{
+		"_id":      {Name: "_id", Type: "ObjectId", IsRequired: true, Frequency: 1.0},
+		"files_id": {Name: "files_id", Type: "ObjectId", IsRequired: true, Frequency: 1.0},
+		"n":        {Name: "n", Type: "int", IsRequired: true, Frequency: 1.0},
+		"data":     {Name: "data", Type: "binData", IsRequired: true, Frequency: 1.0},
+	}
+}
+
+// gridFSCollectionComment describes the role of a collection of a GridFS bucket for the LLM
+func gridFSCollectionComment(collName, bucket string) string {
+	if strings.HasSuffix(collName, gridFSChunksSuffix) {
+		return fmt.Sprintf("GridFS chunks of the bucket '%s': binary content of the files in %s, split in chunks numbered by n. "+
+			"Query the file metadata in db.getCollection(\"%s\") instead of the chunks", bucket, bucket+gridFSFilesSuffix, bucket+gridFSFilesSuffix)
+	}
+	return fmt.Sprintf("GridFS file metadata of the bucket '%s' (filename, length in bytes, chunkSize, uploadDate, metadata), "+
+		"the content is in %s. Query it with db.getCollection(\"%s\")", bucket, bucket+gridFSChunksSuffix, collName)
+}
+
+// gridFSForeignKeys links the chunks of a bucket to their file
+func gridFSForeignKeys(collName, bucket string) map[string]ForeignKey {
+	foreignKeys := make(map[string]ForeignKey)
+	if strings.HasSuffix(collName, gridFSChunksSuffix) {
+		foreignKeys["files_id"] = ForeignKey{
+			Name:       "files_id",
+			ColumnName: "files_id",
+			RefTable:   bucket + gridFSFilesSuffix,
+			RefColumn:  "_id",
+			OnDelete:   "NO ACTION",
+			OnUpdate:   "NO ACTION",
+		}
+	}
+	return foreignKeys
+}
+
+// executeGetGridFSBuckets executes db.getGridFSBuckets(), listing the GridFS buckets with their file count & size
+func executeGetGridFSBuckets(ctx context.Context, database *mongo.Database, startTime time.Time) *QueryExecutionResult {
+	buckets, err := listGridFSBuckets(ctx, database)
+	if err != nil {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Message: fmt.Sprintf("Failed to list GridFS buckets: %v", err),
+				Code:    "EXECUTION_ERROR",
+			},
+		}
+	}
+
+	result := map[string]interface{}{
+		"buckets": buckets,
+	}
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Message: fmt.Sprintf("Failed to marshal result to JSON: %v", err),
+				Code:    "JSON_ERROR",
+			},
+		}
+	}
+
+	return &QueryExecutionResult{
+		Result:        result,
+		ResultJSON:    string(resultJSON),
+		ExecutionTime: int(time.Since(startTime).Milliseconds()),
+	}
+}
+
+// listGridFSBuckets returns the GridFS buckets of a database, the counts are estimated from the collection metadata
+func listGridFSBuckets(ctx context.Context, database *mongo.Database) ([]GridFSBucket, error) {
+	collections, err := database.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	bucketNames := []string{}
+	for collName, bucket := range detectGridFSBuckets(collections) {
+		if strings.HasSuffix(collName, gridFSFilesSuffix) {
+			bucketNames = append(bucketNames, bucket)
+		}
+	}
+	sort.Strings(bucketNames)
+
+	buckets := make([]GridFSBucket, 0, len(bucketNames))
+	for _, name := range bucketNames {
+		bucket := GridFSBucket{Name: name}
+		files := database.Collection(name + gridFSFilesSuffix)
+		if bucket.Files, err = files.EstimatedDocumentCount(ctx); err != nil {
+			return nil, err
+		}
+		if bucket.Chunks, err = database.Collection(name + gridFSChunksSuffix).EstimatedDocumentCount(ctx); err != nil {
+			return nil, err
+		}
+
+		cursor, err := files.Aggregate(ctx, mongo.Pipeline{
+			{{Key: "$group", Value: bson.D{{Key: "_id", Value: nil}, {Key: "totalBytes", Value: bson.D{{Key: "$sum", Value: "$length"}}}}}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		var totals []bson.M
+		if err := cursor.All(ctx, &totals); err != nil {
+			return nil, err
+		}
+		if len(totals) > 0 {
+			if total, ok := bsonNumberToInt64(totals[0]["totalBytes"]); ok {
+				bucket.TotalBytes = total
+			}
+		}
+
+		log.Printf("MongoDBDriver -> listGridFSBuckets -> Bucket %s has %d files", name, bucket.Files)
+		buckets = append(buckets, bucket)
+	}
+	return buckets, nil
+}
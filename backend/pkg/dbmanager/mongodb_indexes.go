@@ -0,0 +1,247 @@
+package dbmanager
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoCreateIndexRegex matches db.collection.createIndex(...), the collection is captured
+var mongoCreateIndexRegex = regexp.MustCompile(`^\s*db\.([\w$-]+)\.createIndex\s*\(`)
+
+// executeCreateIndex executes db.collection.createIndex({keys}, {options}) & returns the name of the index
+func executeCreateIndex(ctx context.Context, collection *mongo.Collection, paramsStr string) (map[string]interface{}, *dtos.QueryError) {
+	model, err := parseCreateIndexParams(paramsStr)
+	if err != nil {
+		return nil, &dtos.QueryError{
+			Message: fmt.Sprintf("Failed to parse createIndex parameters: %v", err),
+			Code:    "INVALID_PARAMETERS",
+		}
+	}
+
+	log.Printf("MongoDBDriver -> executeCreateIndex -> Creating index %v on %s", model.Keys, collection.Name())
+	name, err := collection.Indexes().CreateOne(ctx, model)
+	if err != nil {
+		return nil, &dtos.QueryError{
+			Message: fmt.Sprintf("Failed to execute createIndex operation: %v", err),
+			Code:    "EXECUTION_ERROR",
+		}
+	}
+
+	return map[string]interface{}{
+		"ok":        1,
+		"indexName": name,
+		"message":   fmt.Sprintf("Index '%s' created on collection '%s'", name, collection.Name()),
+	}, nil
+}
+
+// executeDropIndex executes db.collection.dropIndex("name") or dropIndex({keys}), an index given by its keys must have the default name
+func executeDropIndex(ctx context.Context, collection *mongo.Collection, paramsStr string) (map[string]interface{}, *dtos.QueryError) {
+	name, err := parseDropIndexParams(paramsStr)
+	if err != nil {
+		return nil, &dtos.QueryError{
+			Message: fmt.Sprintf("Failed to parse dropIndex parameters: %v", err),
+			Code:    "INVALID_PARAMETERS",
+		}
+	}
+	if name == "_id_" {
+		return nil, &dtos.QueryError{
+			Message: "The _id index cannot be dropped",
+			Code:    "INVALID_PARAMETERS",
+		}
+	}
+
+	log.Printf("MongoDBDriver -> executeDropIndex -> Dropping index %s on %s", name, collection.Name())
+	if _, err := collection.Indexes().DropOne(ctx, name); err != nil {
+		return nil, &dtos.QueryError{
+			Message: fmt.Sprintf("Failed to execute dropIndex operation: %v", err),
+			Code:    "EXECUTION_ERROR",
+		}
+	}
+
+	return map[string]interface{}{
+		"ok":        1,
+		"indexName": name,
+		"message":   fmt.Sprintf("Index '%s' dropped from collection '%s'", name, collection.Name()),
+	}, nil
+}
+
+// MongoDBCreateIndexRollback returns the dropIndex query undoing a createIndex query, false when the query is not a createIndex
+func MongoDBCreateIndexRollback(query string) (string, bool) {
+	match := mongoCreateIndexRegex.FindStringSubmatch(query)
+	if match == nil {
+		return "", false
+	}
+
+	openParenIndex := strings.Index(query, "(")
+	paramsStr, _, err := extractParenthesisContent(query, openParenIndex)
+	if err != nil {
+		return "", false
+	}
+	model, err := parseCreateIndexParams(paramsStr)
+	if err != nil {
+		return "", false
+	}
+
+	name := mongoIndexName(model.Keys.(bson.D))
+	if model.Options != nil && model.Options.Name != nil {
+		name = *model.Options.Name
+	}
+	return fmt.Sprintf("db.%s.dropIndex(%q)", match[1], name), true
+}
+
+// parseCreateIndexParams parses the keys & the options of a createIndex, the keys keep their order as it defines the index
+func parseCreateIndexParams(paramsStr string) (mongo.IndexModel, error) {
+	args, err := parseOrderedMongoArgs(paramsStr)
+	if err != nil {
+		return mongo.IndexModel{}, err
+	}
+	if len(args) == 0 || len(args) > 2 {
+		return mongo.IndexModel{}, fmt.Errorf("createIndex expects the index keys and optional options")
+	}
+
+	keys, ok := args[0].(bson.D)
+	if !ok || len(keys) == 0 {
+		return mongo.IndexModel{}, fmt.Errorf("the index keys must be a non empty object")
+	}
+	model := mongo.IndexModel{Keys: keys}
+	if len(args) == 1 {
+		return model, nil
+	}
+
+	opts, ok := args[1].(bson.D)
+	if !ok {
+		return mongo.IndexModel{}, fmt.Errorf("the index options must be an object")
+	}
+	indexOpts := options.Index()
+	for _, opt := range opts {
+		switch opt.Key {
+		case "name":
+			name, ok := opt.Value.(string)
+			if !ok || name == "" {
+				return mongo.IndexModel{}, fmt.Errorf("name must be a non empty string")
+			}
+			indexOpts.SetName(name)
+		case "unique":
+			value, ok := opt.Value.(bool)
+			if !ok {
+				return mongo.IndexModel{}, fmt.Errorf("unique must be a boolean")
+			}
+			indexOpts.SetUnique(value)
+		case "sparse":
+			value, ok := opt.Value.(bool)
+			if !ok {
+				return mongo.IndexModel{}, fmt.Errorf("sparse must be a boolean")
+			}
+			indexOpts.SetSparse(value)
+		case "hidden":
+			value, ok := opt.Value.(bool)
+			if !ok {
+				return mongo.IndexModel{}, fmt.Errorf("hidden must be a boolean")
+			}
+			indexOpts.SetHidden(value)
+		case "expireAfterSeconds":
+			seconds, ok := bsonNumberToInt64(opt.Value)
+			if !ok {
+				return mongo.IndexModel{}, fmt.Errorf("expireAfterSeconds must be a number")
+			}
+			indexOpts.SetExpireAfterSeconds(int32(seconds))
+		case "partialFilterExpression":
+			indexOpts.SetPartialFilterExpression(opt.Value)
+		case "weights":
+			indexOpts.SetWeights(opt.Value)
+		case "default_language":
+			if value, ok := opt.Value.(string); ok {
+				indexOpts.SetDefaultLanguage(value)
+			}
+		case "background":
+			// Ignored by the server since MongoDB 4.2
+		default:
+			return mongo.IndexModel{}, fmt.Errorf("unsupported index option: %s", opt.Key)
+		}
+	}
+	model.Options = indexOpts
+	return model, nil
+}
+
+// parseDropIndexParams returns the name of the index to drop, given by its name or its keys
+func parseDropIndexParams(paramsStr string) (string, error) {
+	args, err := parseOrderedMongoArgs(paramsStr)
+	if err != nil {
+		return "", err
+	}
+	if len(args) != 1 {
+		return "", fmt.Errorf("dropIndex expects the index name or keys")
+	}
+
+	switch value := args[0].(type) {
+	case string:
+		if value == "" || value == "*" {
+			return "", fmt.Errorf("dropIndex expects a single index name")
+		}
+		return value, nil
+	case bson.D:
+		if len(value) == 0 {
+			return "", fmt.Errorf("the index keys must be a non empty object")
+		}
+		return mongoIndexName(value), nil
+	default:
+		return "", fmt.Errorf("dropIndex expects the index name or keys")
+	}
+}
+
+// parseOrderedMongoArgs parses the arguments of a shell call, objects are decoded to bson.D to keep the order of their keys
+func parseOrderedMongoArgs(paramsStr string) (bson.A, error) {
+	if strings.TrimSpace(paramsStr) == "" {
+		return bson.A{}, nil
+	}
+
+	jsonStr, err := processMongoDBQueryParams("[" + paramsStr + "]")
+	if err != nil {
+		return nil, err
+	}
+	var wrapper bson.D
+	if err := bson.UnmarshalExtJSON([]byte(`{"args":`+jsonStr+`}`), false, &wrapper); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %v", err)
+	}
+	args, _ := wrapper[0].Value.(bson.A)
+	return args, nil
+}
+
+// mongoIndexName returns the default name the server gives an index, its keys & directions joined, e.g. "email_1_createdAt_-1"
+func mongoIndexName(keys bson.D) string {
+	parts := make([]string, 0, len(keys)*2)
+	for _, key := range keys {
+		value := ""
+		switch v := key.Value.(type) {
+		case string:
+			value = v
+		case float64:
+			value = fmt.Sprintf("%d", int(v))
+		default:
+			value = fmt.Sprintf("%v", v)
+		}
+		parts = append(parts, key.Key, value)
+	}
+	return strings.Join(parts, "_")
+}
+
+func bsonNumberToInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case float64:
+		return int64(v), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,111 @@
+package dbmanager
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxReferenceSamples is the number of sampled ObjectIds looked up in the referenced collection to confirm a reference
+const maxReferenceSamples = 20
+
+// mongoReferenceFieldRegex matches the fields named after the collection they reference, e.g. userId, userID or user_id
+var mongoReferenceFieldRegex = regexp.MustCompile(`^(\w+?)(?:Id|ID|_id)$`)
+
+// collectReferenceCandidates returns the ObjectIds of the sampled top level fields named like a reference, by field
+func collectReferenceCandidates(samples []bson.M) map[string][]primitive.ObjectID {
+	candidates := make(map[string][]primitive.ObjectID)
+	for _, sample := range samples {
+		for field, value := range sample {
+			id, ok := value.(primitive.ObjectID)
+			if !ok || !mongoReferenceFieldRegex.MatchString(field) || len(candidates[field]) >= maxReferenceSamples {
+				continue
+			}
+			candidates[field] = append(candidates[field], id)
+		}
+	}
+	return candidates
+}
+
+// inferReferences links the candidate fields of each collection to the collection they are named after, a reference is
+// kept only when some of its sampled ObjectIds are _ids of that collection
+func (f *MongoDBSchemaFetcher) inferReferences(ctx context.Context, executor *MongoDBExecutor, mongoSchema *MongoDBSchema, candidates map[string]map[string][]primitive.ObjectID, collections []string) {
+	database := executor.GetMongoDatabase()
+	for collName, fields := range candidates {
+		collection, ok := mongoSchema.Collections[collName]
+		if !ok {
+			continue
+		}
+
+		for field, ids := range fields {
+			refCollection := findReferencedCollection(mongoReferenceFieldRegex.FindStringSubmatch(field)[1], collections)
+			if refCollection == "" {
+				continue
+			}
+
+			count, err := database.Collection(refCollection).CountDocuments(ctx, bson.M{"_id": bson.M{"$in": ids}}, options.Count().SetLimit(1))
+			if err != nil {
+				log.Printf("MongoDBSchemaFetcher -> inferReferences -> Error checking %s.%s against %s: %v", collName, field, refCollection, err)
+				continue
+			}
+			if count == 0 {
+				continue
+			}
+
+			log.Printf("MongoDBSchemaFetcher -> inferReferences -> %s.%s references %s._id", collName, field, refCollection)
+			if collection.References == nil {
+				collection.References = make(map[string]string)
+			}
+			collection.References[field] = refCollection
+		}
+		mongoSchema.Collections[collName] = collection
+	}
+}
+
+// findReferencedCollection returns the collection a reference field is named after, matching the singular or plural name
+// regardless of case & separators, e.g. user or order_item for the users & orderItems collections
+func findReferencedCollection(base string, collections []string) string {
+	base = normalizeReferenceName(base)
+	names := []string{base, base + "s", base + "es"}
+	if strings.HasSuffix(base, "y") {
+		names = append(names, strings.TrimSuffix(base, "y")+"ies")
+	}
+
+	sorted := append([]string{}, collections...)
+	sort.Strings(sorted)
+	for _, collName := range sorted {
+		if strings.HasPrefix(collName, "system.") {
+			continue
+		}
+		if containsString(names, normalizeReferenceName(collName)) {
+			return collName
+		}
+	}
+	return ""
+}
+
+func normalizeReferenceName(name string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(name))
+}
+
+// referenceForeignKeys converts the inferred references of a collection to foreign keys on their _id
+func referenceForeignKeys(references map[string]string) map[string]ForeignKey {
+	foreignKeys := make(map[string]ForeignKey, len(references))
+	for field, refCollection := range references {
+		foreignKeys[field] = ForeignKey{
+			Name:       field,
+			ColumnName: field,
+			RefTable:   refCollection,
+			RefColumn:  "_id",
+			OnDelete:   "NO ACTION",
+			OnUpdate:   "NO ACTION",
+		}
+	}
+	return foreignKeys
+}
@@ -0,0 +1,163 @@
+package dbmanager
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	// Defaults of the schema inference, a connection can override them
+	DefaultMongoDBSchemaSampleSize      = 50
+	DefaultMongoDBSchemaMaxDepth        = 5
+	DefaultMongoDBSchemaArraySampleSize = 5
+
+	maxMongoDBSchemaSampleSize      = 1000 // SampleCollection caps the sample size at 1000
+	maxMongoDBSchemaMaxDepth        = 20
+	maxMongoDBSchemaArraySampleSize = 100
+	maxMongoDBSchemaNewestSize      = 1000
+)
+
+// mongoSchemaSampling controls how much of a collection is read to infer its schema
+type mongoSchemaSampling struct {
+	SampleSize      int  // Documents sampled per collection
+	MaxDepth        int  // Nesting levels of embedded documents inferred, 1 only infers the top level fields
+	ArraySampleSize int  // Elements sampled per array, spread over the array
+	NewestSize      int  // Newest documents by _id added to the random sample, none by default
+	NewestOnly      bool // Reads the newest documents instead of a random sample, for results that must not change between calls
+}
+
+// mongoSchemaSamplingFor returns the schema sampling of a connection, values out of range are clamped
+func mongoSchemaSamplingFor(conn *Connection) mongoSchemaSampling {
+	sampling := mongoSchemaSampling{
+		SampleSize:      DefaultMongoDBSchemaSampleSize,
+		MaxDepth:        DefaultMongoDBSchemaMaxDepth,
+		ArraySampleSize: DefaultMongoDBSchemaArraySampleSize,
+	}
+	if conn == nil {
+		return sampling
+	}
+
+	sampling.SampleSize = clampedSamplingValue(conn.Config.SchemaSampleSize, sampling.SampleSize, maxMongoDBSchemaSampleSize)
+	sampling.MaxDepth = clampedSamplingValue(conn.Config.SchemaMaxDepth, sampling.MaxDepth, maxMongoDBSchemaMaxDepth)
+	sampling.ArraySampleSize = clampedSamplingValue(conn.Config.SchemaArraySampleSize, sampling.ArraySampleSize, maxMongoDBSchemaArraySampleSize)
+	sampling.NewestSize = clampedSamplingValue(conn.Config.SchemaNewestSampleSize, 0, maxMongoDBSchemaNewestSize)
+	return sampling
+}
+
+func clampedSamplingValue(value *int, defaultValue, maxValue int) int {
+	if value == nil || *value <= 0 {
+		return defaultValue
+	}
+	return min(*value, maxValue)
+}
+
+// sampleDocumentsForSchema returns a random sample of the collection, with the newest documents when the sampling asks for them.
+// A random sample is representative of the whole collection while the newest documents hold the fields added recently.
+func (e *MongoDBExecutor) sampleDocumentsForSchema(ctx context.Context, collectionName string, sampling mongoSchemaSampling) ([]bson.M, error) {
+	if sampling.NewestOnly {
+		return e.SampleNewestDocuments(ctx, collectionName, sampling.SampleSize)
+	}
+
+	samples, err := e.SampleCollection(ctx, collectionName, sampling.SampleSize)
+	if err != nil {
+		return nil, err
+	}
+	if sampling.NewestSize == 0 || len(samples) == 0 {
+		return samples, nil
+	}
+
+	newest, err := e.SampleNewestDocuments(ctx, collectionName, sampling.NewestSize)
+	if err != nil {
+		// The random sample is enough to infer the schema
+		log.Printf("MongoDBExecutor -> sampleDocumentsForSchema -> Error reading the newest documents of %s: %v", collectionName, err)
+		return samples, nil
+	}
+	return mergeSampledDocuments(samples, newest), nil
+}
+
+// mergeSampledDocuments appends the documents not already sampled, documents are identified by their _id
+func mergeSampledDocuments(samples []bson.M, documents []bson.M) []bson.M {
+	sampled := make(map[string]bool, len(samples))
+	for _, doc := range samples {
+		sampled[fmt.Sprintf("%v", doc["_id"])] = true
+	}
+	for _, doc := range documents {
+		id := fmt.Sprintf("%v", doc["_id"])
+		if !sampled[id] {
+			sampled[id] = true
+			samples = append(samples, doc)
+		}
+	}
+	return samples
+}
+
+// sampleArrayElements returns up to size elements spread over the array, the first & last elements are always included
+func sampleArrayElements(arr bson.A, size int) []interface{} {
+	if len(arr) <= size {
+		return arr
+	}
+	if size == 1 {
+		return arr[:1]
+	}
+
+	elements := make([]interface{}, 0, size)
+	for i := 0; i < size; i++ {
+		elements = append(elements, arr[i*(len(arr)-1)/(size-1)])
+	}
+	return elements
+}
+
+// mergeMongoDBFieldType combines the type seen so far for a field with the type of a new value
+func mergeMongoDBFieldType(current, valueType string) string {
+	switch {
+	case current == "" || current == "null":
+		return valueType
+	case valueType == "null" || current == valueType:
+		return current
+	default:
+		return "mixed"
+	}
+}
+
+// inferMongoDBNestedFields adds the fields of an embedded document to the nested fields of its parent, up to the max depth of the sampling
+func inferMongoDBNestedFields(nestedFields map[string]MongoDBField, doc bson.M, depth int, sampling mongoSchemaSampling) {
+	for key, value := range doc {
+		field, exists := nestedFields[key]
+		if !exists {
+			field = MongoDBField{
+				Name:         key,
+				IsRequired:   true,
+				NestedFields: make(map[string]MongoDBField),
+			}
+		}
+		inferMongoDBFieldValue(&field, value, depth, sampling)
+		nestedFields[key] = field
+	}
+}
+
+// inferMongoDBFieldValue updates the type of a field from one of its values, embedded documents & sampled array elements are inferred recursively
+func inferMongoDBFieldValue(field *MongoDBField, value interface{}, depth int, sampling mongoSchemaSampling) {
+	arr, isArray := value.(bson.A)
+	if !isArray {
+		field.Type = mergeMongoDBFieldType(field.Type, getMongoDBFieldType(value))
+		if doc, isDoc := value.(bson.M); isDoc && depth < sampling.MaxDepth {
+			inferMongoDBNestedFields(field.NestedFields, doc, depth+1, sampling)
+		}
+		return
+	}
+
+	// The type of an array field is the type of its elements
+	field.IsArray = true
+	if len(arr) == 0 {
+		field.Type = mergeMongoDBFieldType(field.Type, "null")
+	}
+	for _, element := range sampleArrayElements(arr, sampling.ArraySampleSize) {
+		field.Type = mergeMongoDBFieldType(field.Type, getMongoDBFieldType(element))
+		if doc, isDoc := element.(bson.M); isDoc && depth < sampling.MaxDepth {
+			inferMongoDBNestedFields(field.NestedFields, doc, depth+1, sampling)
+		}
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // ColumnDiff represents a difference in a column between two schemas
@@ -43,9 +45,31 @@ func (f *MongoDBSchemaFetcher) GetSchema(ctx context.Context, db DBExecutor, sel
 		return nil, fmt.Errorf("failed to list collections: %v", err)
 	}
 	log.Printf("MongoDBSchemaFetcher -> GetSchema -> Found %d collections: %v", len(collections), collections)
+
+	sampling := mongoSchemaSamplingFor(executor.conn)
+	log.Printf("MongoDBSchemaFetcher -> GetSchema -> Sampling %d random & %d newest documents per collection, %d nesting levels & %d elements per array", sampling.SampleSize, sampling.NewestSize, sampling.MaxDepth, sampling.ArraySampleSize)
+
+	// Views are listed with the collections but have no stats nor indexes, they are described by their pipeline instead
+	views, err := executor.ListViews(ctx)
+	if err != nil {
+		log.Printf("MongoDBSchemaFetcher -> GetSchema -> Error listing views: %v", err)
+		views = map[string]MongoDBView{}
+	}
+	timeSeriesCollections, err := executor.ListTimeSeriesCollections(ctx)
+	if err != nil {
+		log.Printf("MongoDBSchemaFetcher -> GetSchema -> Error listing time-series collections: %v", err)
+		timeSeriesCollections = map[string]MongoDBTimeSeries{}
+	}
+	validators, err := executor.ListJSONSchemaValidators(ctx)
+	if err != nil {
+		log.Printf("MongoDBSchemaFetcher -> GetSchema -> Error listing validators: %v", err)
+		validators = map[string]bson.M{}
+	}
+	selectAll := len(selectedCollections) == 0 || (len(selectedCollections) == 1 && selectedCollections[0] == "ALL")
+
 	// Filter collections if specific ones are selected
 	var targetCollections []string
-	if len(selectedCollections) == 0 || (len(selectedCollections) == 1 && selectedCollections[0] == "ALL") {
+	if selectAll {
 		log.Printf("MongoDBSchemaFetcher -> GetSchema -> Selecting all collections")
 		targetCollections = collections
 	} else {
@@ -66,18 +90,38 @@ func (f *MongoDBSchemaFetcher) GetSchema(ctx context.Context, db DBExecutor, sel
 	mongoSchema := MongoDBSchema{
 		Collections: make(map[string]MongoDBCollection),
 		Indexes:     make(map[string][]MongoDBIndex),
+		Views:       make(map[string]MongoDBView),
 		Version:     time.Now().Unix(),
 		UpdatedAt:   time.Now(),
 	}
 
+	gridFSBuckets := detectGridFSBuckets(collections)
+	referenceCandidates := make(map[string]map[string][]primitive.ObjectID)
+
 	// Process each collection
 	for _, collName := range targetCollections {
-		// Sample documents from collection, 50 is the default sample size
-		samples, err := executor.SampleCollection(ctx, collName, 50)
-		if err != nil {
-			log.Printf("MongoDBSchemaFetcher -> GetSchema -> Error sampling collection %s: %v", collName, err)
+		if view, isView := views[collName]; isView {
+			mongoSchema.Views[collName] = view
 			continue
 		}
+		// system.views stores the definitions of the views & system.buckets.* the internal buckets of the time-series collections
+		if collName == "system.views" || strings.HasPrefix(collName, "system.buckets.") {
+			continue
+		}
+
+		// The chunks of a GridFS bucket hold the file content, they are not sampled as it would load whole files
+		gridFSBucket := gridFSBuckets[collName]
+		isGridFSChunks := gridFSBucket != "" && strings.HasSuffix(collName, gridFSChunksSuffix)
+
+		// Sample random documents from collection, with the newest ones when set on the connection
+		var samples []bson.M
+		if !isGridFSChunks {
+			samples, err = executor.sampleDocumentsForSchema(ctx, collName, sampling)
+			if err != nil {
+				log.Printf("MongoDBSchemaFetcher -> GetSchema -> Error sampling collection %s: %v", collName, err)
+				continue
+			}
+		}
 
 		log.Printf("MongoDBSchemaFetcher -> GetSchema -> Sampling collection %s, found %d samples", collName, len(samples))
 		if len(samples) > 0 {
@@ -108,6 +152,11 @@ func (f *MongoDBSchemaFetcher) GetSchema(ctx context.Context, db DBExecutor, sel
 			Fields:         make(map[string]MongoDBField),
 			DocumentCount:  documentCount,
 			SampleDocument: bson.M{},
+			GridFSBucket:   gridFSBucket,
+			Storage:        mongoCollectionStorage(stats),
+		}
+		if timeSeries, ok := timeSeriesCollections[collName]; ok {
+			collection.TimeSeries = &timeSeries
 		}
 
 		log.Printf("MongoDBSchemaFetcher -> GetSchema -> Using first sample as sample document for %s", collName)
@@ -120,12 +169,17 @@ func (f *MongoDBSchemaFetcher) GetSchema(ctx context.Context, db DBExecutor, sel
 		// Analyze fields from all samples
 		fieldFrequency := make(map[string]int)
 		for _, sample := range samples {
-			f.analyzeDocument(sample, "", &collection.Fields, fieldFrequency)
+			f.analyzeDocument(sample, "", &collection.Fields, fieldFrequency, 1, sampling)
+		}
+		if candidates := collectReferenceCandidates(samples); len(candidates) > 0 {
+			referenceCandidates[collName] = candidates
 		}
 
 		// If collection is empty (no samples), add a default _id field
 		// This ensures empty collections are still included in the schema
-		if len(samples) == 0 {
+		if isGridFSChunks {
+			collection.Fields = gridFSChunksFields()
+		} else if len(samples) == 0 {
 			log.Printf("MongoDBSchemaFetcher -> GetSchema -> Collection %s is empty, adding default _id field", collName)
 			collection.Fields["_id"] = MongoDBField{
 				Name:       "_id",
@@ -147,6 +201,12 @@ func (f *MongoDBSchemaFetcher) GetSchema(ctx context.Context, db DBExecutor, sel
 			}
 		}
 
+		// The validator of the collection declares the fields its documents must have, whatever the sample holds
+		if jsonSchema, ok := validators[collName]; ok && !isGridFSChunks {
+			log.Printf("MongoDBSchemaFetcher -> GetSchema -> Merging the $jsonSchema validator of %s", collName)
+			applyJSONSchemaValidator(collection.Fields, jsonSchema, 1, sampling)
+		}
+
 		log.Printf("MongoDBSchemaFetcher -> GetSchema -> Getting indexes for %s", collName)
 		// Get indexes
 		indexes, err := f.getCollectionIndexes(ctx, executor, collName)
@@ -161,13 +221,22 @@ func (f *MongoDBSchemaFetcher) GetSchema(ctx context.Context, db DBExecutor, sel
 		mongoSchema.Collections[collName] = collection
 	}
 
+	// Fields named after another collection are checked against its _ids, views can't be referenced
+	referencedCollections := []string{}
+	for _, collName := range collections {
+		if _, isView := views[collName]; !isView {
+			referencedCollections = append(referencedCollections, collName)
+		}
+	}
+	f.inferReferences(ctx, executor, &mongoSchema, referenceCandidates, referencedCollections)
+
 	// Convert MongoDB schema to generic SchemaInfo
 	schemaInfo := f.convertToSchemaInfo(mongoSchema)
 	return schemaInfo, nil
 }
 
-// analyzeDocument recursively analyzes a document to extract field information
-func (f *MongoDBSchemaFetcher) analyzeDocument(doc bson.M, prefix string, fields *map[string]MongoDBField, fieldFrequency map[string]int) {
+// analyzeDocument recursively analyzes a document to extract field information, embedded documents deeper than the max depth of the sampling are not analyzed
+func (f *MongoDBSchemaFetcher) analyzeDocument(doc bson.M, prefix string, fields *map[string]MongoDBField, fieldFrequency map[string]int, depth int, sampling mongoSchemaSampling) {
 	for key, value := range doc {
 		fieldName := key
 		if prefix != "" {
@@ -196,20 +265,26 @@ func (f *MongoDBSchemaFetcher) analyzeDocument(doc bson.M, prefix string, fields
 		if arr, ok := value.(bson.A); ok {
 			field.IsArray = true
 			log.Printf("MongoDBSchemaFetcher -> GetSchema -> Field %s is an array", fieldName)
-			// If array has elements, analyze the first one to determine element type
-			if len(arr) > 0 {
-				field.Type = f.getMongoDBFieldType(arr[0])
+			// Analyze elements spread over the array to determine element type, elements of different types make the field mixed
+			elementType := ""
+			for _, element := range sampleArrayElements(arr, sampling.ArraySampleSize) {
+				elementType = mergeMongoDBFieldType(elementType, f.getMongoDBFieldType(element))
 
 				// If array element is a document, analyze its structure
-				if doc, ok := arr[0].(bson.M); ok {
-					f.analyzeDocument(doc, fieldName+"[]", &field.NestedFields, fieldFrequency)
+				if doc, ok := element.(bson.M); ok && depth < sampling.MaxDepth {
+					f.analyzeDocument(doc, fieldName+"[]", &field.NestedFields, fieldFrequency, depth+1, sampling)
 				}
 			}
+			if elementType != "" {
+				field.Type = elementType
+			}
 		} else if nestedDoc, ok := value.(bson.M); ok {
 			log.Printf("MongoDBSchemaFetcher -> GetSchema -> Field %s is a nested document", fieldName)
 			// Handle nested document
 			field.Type = "object"
-			f.analyzeDocument(nestedDoc, fieldName, &field.NestedFields, fieldFrequency)
+			if depth < sampling.MaxDepth {
+				f.analyzeDocument(nestedDoc, fieldName, &field.NestedFields, fieldFrequency, depth+1, sampling)
+			}
 		}
 
 		// Update field in map
@@ -306,6 +381,17 @@ func (f *MongoDBSchemaFetcher) convertToSchemaInfo(mongoSchema MongoDBSchema) *S
 			ForeignKeys: make(map[string]ForeignKey),
 			Constraints: make(map[string]ConstraintInfo),
 			RowCount:    coll.DocumentCount,
+			Storage:     coll.Storage,
+		}
+		if coll.GridFSBucket != "" {
+			tableSchema.Comment = gridFSCollectionComment(collName, coll.GridFSBucket)
+			tableSchema.ForeignKeys = gridFSForeignKeys(collName, coll.GridFSBucket)
+		}
+		for fkName, foreignKey := range referenceForeignKeys(coll.References) {
+			tableSchema.ForeignKeys[fkName] = foreignKey
+		}
+		if coll.TimeSeries != nil {
+			tableSchema.Comment = timeSeriesCollectionComment(*coll.TimeSeries)
 		}
 
 		// Convert fields to columns
@@ -320,7 +406,7 @@ func (f *MongoDBSchemaFetcher) convertToSchemaInfo(mongoSchema MongoDBSchema) *S
 				Type:         columnType,
 				IsNullable:   !field.IsRequired,
 				DefaultValue: "",
-				Comment:      fmt.Sprintf("Present in %.1f%% of documents", field.Frequency*100),
+				Comment:      mongoFieldComment(field),
 			}
 
 			// Add nested fields as separate columns with dot notation
@@ -347,9 +433,54 @@ func (f *MongoDBSchemaFetcher) convertToSchemaInfo(mongoSchema MongoDBSchema) *S
 		schema.Tables[collName] = tableSchema
 	}
 
+	// Convert views, their definition is the shell call creating them
+	for viewName, view := range mongoSchema.Views {
+		schema.Views[viewName] = ViewSchema{
+			Name:       viewName,
+			Definition: fmt.Sprintf("read-only view, db.createView(%q, %q, %s)", viewName, view.ViewOn, view.Pipeline),
+		}
+	}
+
 	return schema
 }
 
+// mongoCollectionStorage extracts the sizes of a collection & its indexes from its collStats output
+func mongoCollectionStorage(stats bson.M) *TableStorage {
+	storage := &TableStorage{
+		IndexSizes: make(map[string]int64),
+	}
+	storage.StorageSize, _ = bsonNumberToInt64(stats["storageSize"])
+	storage.AvgDocumentSize, _ = bsonNumberToInt64(stats["avgObjSize"])
+	storage.TotalIndexSize, _ = bsonNumberToInt64(stats["totalIndexSize"])
+	if indexSizes, ok := stats["indexSizes"].(bson.M); ok {
+		for indexName, size := range indexSizes {
+			if size, ok := bsonNumberToInt64(size); ok {
+				storage.IndexSizes[indexName] = size
+			}
+		}
+	}
+
+	// Capped collections have a fixed size, optionally a max number of documents
+	storage.Capped, _ = stats["capped"].(bool)
+	if storage.Capped {
+		storage.MaxSize, _ = bsonNumberToInt64(stats["maxSize"])
+		storage.MaxDocuments, _ = bsonNumberToInt64(stats["max"])
+	}
+	return storage
+}
+
+// timeSeriesCollectionComment describes a time-series collection for the LLM, so it buckets & windows on its time & meta fields
+func timeSeriesCollectionComment(timeSeries MongoDBTimeSeries) string {
+	comment := fmt.Sprintf("Time-series collection, timeField: '%s'", timeSeries.TimeField)
+	if timeSeries.MetaField != "" {
+		comment += fmt.Sprintf(", metaField: '%s'", timeSeries.MetaField)
+	}
+	if timeSeries.Granularity != "" {
+		comment += fmt.Sprintf(", granularity: %s", timeSeries.Granularity)
+	}
+	return comment + ". Filter on a range of the time field & aggregate with $dateTrunc or $setWindowFields rather than scanning single documents"
+}
+
 // addNestedFieldsAsColumns adds nested fields as columns with dot notation
 func (f *MongoDBSchemaFetcher) addNestedFieldsAsColumns(nestedFields map[string]MongoDBField, prefix string, columns *map[string]ColumnInfo) {
 	for fieldName, field := range nestedFields {
@@ -365,7 +496,7 @@ func (f *MongoDBSchemaFetcher) addNestedFieldsAsColumns(nestedFields map[string]
 			Type:         columnType,
 			IsNullable:   !field.IsRequired,
 			DefaultValue: "",
-			Comment:      fmt.Sprintf("Present in %.1f%% of documents", field.Frequency*100),
+			Comment:      mongoFieldComment(field),
 		}
 
 		// Recursively add nested fields
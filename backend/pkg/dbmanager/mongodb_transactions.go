@@ -22,11 +22,14 @@ type MongoDBTransaction struct {
 	Session mongo.Session
 	Wrapper *MongoDBWrapper
 	Error   error
+
+	cancelGuard serverCancelGuard // The session is pooled once the transaction ended, see server_cancel.go
 }
 
 // Commit commits a MongoDB transaction
 func (tx *MongoDBTransaction) Commit() error {
 	log.Printf("MongoDBTransaction -> Commit -> Committing MongoDB transaction")
+	tx.cancelGuard.end()
 
 	// Check if the session is nil (which can happen if there was an error creating the transaction)
 	if tx.Session == nil {
@@ -87,6 +90,7 @@ func (tx *MongoDBTransaction) Commit() error {
 // Rollback rolls back a MongoDB transaction
 func (tx *MongoDBTransaction) Rollback() error {
 	log.Printf("MongoDBTransaction -> Rollback -> Rolling back MongoDB transaction")
+	tx.cancelGuard.end()
 
 	// Check if the session is nil (which can happen if there was an error creating the transaction)
 	if tx.Session == nil {
@@ -317,9 +321,14 @@ func (tx *MongoDBTransaction) ExecuteQuery(ctx context.Context, conn *Connection
 		}
 	}
 
-	// Handle database-level operations
+	// db.runCommand() only runs collMod, its document may hold dots the database-level operations don't expect
+	if mongoRunCommandRegex.MatchString(query) {
+		return executeRunCommand(ctx, tx.Wrapper.Client.Database(tx.Wrapper.Database), query, startTime)
+	}
+
+	// Handle database-level operations, db.getCollection("name") selects a collection
 	dbOperationRegex := regexp.MustCompile(`db\.(\w+)\(\s*(.*)\s*\)`)
-	if dbOperationMatches := dbOperationRegex.FindStringSubmatch(query); len(dbOperationMatches) >= 2 {
+	if dbOperationMatches := dbOperationRegex.FindStringSubmatch(query); len(dbOperationMatches) >= 2 && dbOperationMatches[1] != "getCollection" {
 		operation := dbOperationMatches[1]
 		paramsStr := ""
 		if len(dbOperationMatches) >= 3 {
@@ -329,6 +338,9 @@ func (tx *MongoDBTransaction) ExecuteQuery(ctx context.Context, conn *Connection
 		log.Printf("MongoDBTransaction -> ExecuteQuery -> Matched database operation: %s with params: %s", operation, paramsStr)
 
 		switch operation {
+		case "getGridFSBuckets":
+			return executeGetGridFSBuckets(ctx, tx.Wrapper.Client.Database(tx.Wrapper.Database), startTime)
+
 		case "getCollectionNames":
 			// List all collections in the database
 			collections, err := tx.Wrapper.Client.Database(tx.Wrapper.Database).ListCollectionNames(ctx, bson.M{})
@@ -378,9 +390,9 @@ func (tx *MongoDBTransaction) ExecuteQuery(ctx context.Context, conn *Connection
 	}
 
 	// Parse the query
-	// Example: db.collection.find({name: "John"})
-	parts := strings.SplitN(query, ".", 3)
-	if len(parts) < 3 || !strings.HasPrefix(parts[0], "db") {
+	// Example: db.collection.find({name: "John"}) or db.getCollection("fs.files").find({})
+	collectionName, operationWithParams, ok := splitMongoCollectionQuery(query)
+	if !ok {
 		return &QueryExecutionResult{
 			Error: &dtos.QueryError{
 				Message: "Invalid MongoDB query format. Expected: db.collection.operation({...})",
@@ -389,9 +401,6 @@ func (tx *MongoDBTransaction) ExecuteQuery(ctx context.Context, conn *Connection
 		}
 	}
 
-	collectionName := parts[1]
-	operationWithParams := parts[2]
-
 	// Special case handling for empty find() with modifiers
 	// Like db.collection.find().sort()
 	if strings.HasPrefix(operationWithParams, "find()") && len(operationWithParams) > 6 {
@@ -488,6 +497,21 @@ func (tx *MongoDBTransaction) ExecuteQuery(ctx context.Context, conn *Connection
 				modifiers["sort"] = sortExpr
 			}
 		}
+
+		// Extract collation modifier, applied to the comparisons of the filter, the sort & the pipeline
+		collationStr, found, err := extractCollationModifier(modifiersStr)
+		if err != nil {
+			return &QueryExecutionResult{
+				Error: &dtos.QueryError{
+					Message: err.Error(),
+					Code:    "INVALID_PARAMETERS",
+				},
+			}
+		}
+		if found {
+			modifiers["collation"] = collationStr
+			log.Printf("MongoDBTransaction -> ExecuteQuery -> Found collation modifier: %s", collationStr)
+		}
 	}
 
 	// Get the MongoDB collection
@@ -516,9 +540,24 @@ func (tx *MongoDBTransaction) ExecuteQuery(ctx context.Context, conn *Connection
 		}
 	}
 
+	// .explain() returns the plan of a find or an aggregate instead of its documents
+	if explainModifiers := operationWithParams[closeParenIndex+1:]; mongoExplainRegex.MatchString(explainModifiers) {
+		return executeExplain(ctx, collection, operation, paramsStr, modifiers, explainModifiers, startTime)
+	}
+
 	var result interface{}
 	var err error
 
+	collation, err := mongoCollationFromModifiers(operation, modifiers)
+	if err != nil {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Message: err.Error(),
+				Code:    "INVALID_PARAMETERS",
+			},
+		}
+	}
+
 	log.Printf("MongoDBTransaction -> ExecuteQuery -> operation: %s", operation)
 	// Execute the operation based on the type
 	switch operation {
@@ -652,7 +691,7 @@ func (tx *MongoDBTransaction) ExecuteQuery(ctx context.Context, conn *Connection
 		// If count() modifier is present, perform a count operation instead of find
 		if modifiers.Count {
 			// Execute the countDocuments operation
-			count, err := collection.CountDocuments(ctx, filter)
+			count, err := collection.CountDocuments(ctx, filter, options.Count().SetCollation(collation))
 			if err != nil {
 				return &QueryExecutionResult{
 					Error: &dtos.QueryError{
@@ -669,7 +708,12 @@ func (tx *MongoDBTransaction) ExecuteQuery(ctx context.Context, conn *Connection
 		}
 
 		// Create find options
-		findOptions := options.Find()
+		findOptions := options.Find().SetCollation(collation)
+
+		// The server stops the query at the timeout too
+		if maxTime, ok := remainingQueryTime(ctx); ok {
+			findOptions.SetMaxTime(maxTime)
+		}
 
 		// Apply limit if specified
 		if modifiers.Limit > 0 {
@@ -768,7 +812,8 @@ func (tx *MongoDBTransaction) ExecuteQuery(ctx context.Context, conn *Connection
 			findOptions.SetProjection(projectionDoc)
 		}
 
-		// Execute the find operation
+		// Execute the find operation, fetching the documents in batches
+		findOptions.SetBatchSize(mongoDBCursorLimits.BatchSize)
 		cursor, err := collection.Find(ctx, filter, findOptions)
 		if err != nil {
 			return &QueryExecutionResult{
@@ -837,7 +882,7 @@ func (tx *MongoDBTransaction) ExecuteQuery(ctx context.Context, conn *Connection
 
 		// Execute the findOne operation
 		var doc bson.M
-		err = collection.FindOne(ctx, filter).Decode(&doc)
+		err = collection.FindOne(ctx, filter, options.FindOne().SetCollation(collation)).Decode(&doc)
 		if err != nil {
 			if err == mongo.ErrNoDocuments {
 				// No documents found, return empty result
@@ -854,6 +899,18 @@ func (tx *MongoDBTransaction) ExecuteQuery(ctx context.Context, conn *Connection
 			result = doc
 		}
 
+	case "findOneAndUpdate", "findOneAndReplace", "findOneAndDelete":
+		doc, queryErr := executeFindAndModify(ctx, collection, operation, paramsStr)
+		if queryErr != nil {
+			return &QueryExecutionResult{Error: queryErr}
+		}
+		if doc == nil {
+			// No document matched, as findOne
+			result = nil
+		} else {
+			result = doc
+		}
+
 	case "insertOne":
 		// Parse the parameters as a BSON document
 		var document bson.M
@@ -1449,8 +1506,25 @@ func (tx *MongoDBTransaction) ExecuteQuery(ctx context.Context, conn *Connection
 			log.Printf("MongoDBTransaction -> ExecuteQuery -> Error processing dot notation in pipeline: %v", err)
 		}
 
+		// Aggregations ending with $out or $merge are writes, their result is the written collection
+		if target, isWrite, err := findAggregationWriteTarget(pipeline); isWrite {
+			if err != nil {
+				return &QueryExecutionResult{
+					Error: &dtos.QueryError{
+						Message: err.Error(),
+						Code:    "INVALID_PARAMETERS",
+					},
+				}
+			}
+			return executeAggregationWrite(ctx, collection, pipeline, target, collation, startTime)
+		}
+
 		// Execute the aggregation
-		cursor, err := collection.Aggregate(ctx, pipeline)
+		aggregateOptions := options.Aggregate().SetBatchSize(mongoDBCursorLimits.BatchSize).SetCollation(collation)
+		if maxTime, ok := remainingQueryTime(ctx); ok {
+			aggregateOptions.SetMaxTime(maxTime)
+		}
+		cursor, err := collection.Aggregate(ctx, pipeline, aggregateOptions)
 		if err != nil {
 			log.Printf("MongoDBTransaction -> ExecuteQuery -> Error executing aggregation: %v", err)
 			return &QueryExecutionResult{
@@ -1522,7 +1596,7 @@ func (tx *MongoDBTransaction) ExecuteQuery(ctx context.Context, conn *Connection
 		}
 
 		// Execute the countDocuments operation
-		count, err := collection.CountDocuments(ctx, filter)
+		count, err := collection.CountDocuments(ctx, filter, options.Count().SetCollation(collation))
 		if err != nil {
 			return &QueryExecutionResult{
 				Error: &dtos.QueryError{
@@ -1536,6 +1610,34 @@ func (tx *MongoDBTransaction) ExecuteQuery(ctx context.Context, conn *Connection
 			"count": count,
 		}
 
+	case "distinct":
+		values, queryErr := executeDistinct(ctx, collection, paramsStr)
+		if queryErr != nil {
+			return &QueryExecutionResult{Error: queryErr}
+		}
+		result = values
+
+	case "createIndex":
+		indexResult, queryErr := executeCreateIndex(ctx, collection, paramsStr)
+		if queryErr != nil {
+			return &QueryExecutionResult{Error: queryErr}
+		}
+		result = indexResult
+
+	case "dropIndex":
+		indexResult, queryErr := executeDropIndex(ctx, collection, paramsStr)
+		if queryErr != nil {
+			return &QueryExecutionResult{Error: queryErr}
+		}
+		result = indexResult
+
+	case "renameCollection":
+		renameResult, queryErr := executeRenameCollection(ctx, collection, paramsStr)
+		if queryErr != nil {
+			return &QueryExecutionResult{Error: queryErr}
+		}
+		result = renameResult
+
 	case "createCollection":
 		// Execute the createCollection operation with default options
 		// We're simplifying this implementation to avoid complex option handling
@@ -1619,6 +1721,13 @@ func (tx *MongoDBTransaction) ExecuteQuery(ctx context.Context, conn *Connection
 			"message": fmt.Sprintf("Collection '%s' dropped successfully", collectionName),
 		}
 
+	case "bulkWrite":
+		bulkResult, queryErr := executeBulkWrite(ctx, collection, paramsStr)
+		if queryErr != nil {
+			return &QueryExecutionResult{Error: queryErr}
+		}
+		result = bulkResult
+
 	default:
 		return &QueryExecutionResult{
 			Error: &dtos.QueryError{
@@ -17,6 +17,7 @@ type MongoDBWrapper struct {
 type MongoDBSchema struct {
 	Collections map[string]MongoDBCollection
 	Indexes     map[string][]MongoDBIndex
+	Views       map[string]MongoDBView
 	Version     int64
 	UpdatedAt   time.Time
 }
@@ -28,6 +29,24 @@ type MongoDBCollection struct {
 	Indexes        []MongoDBIndex
 	DocumentCount  int64
 	SampleDocument bson.M
+	GridFSBucket   string // Bucket whose files or chunks the collection stores, empty for a regular collection
+	TimeSeries     *MongoDBTimeSeries
+	References     map[string]string // Field -> collection whose _id it references, inferred from the field names & values
+	Storage        *TableStorage     // Sizes & capped options reported by collStats
+}
+
+// MongoDBTimeSeries represents the options of a time-series collection
+type MongoDBTimeSeries struct {
+	TimeField   string
+	MetaField   string // Optional field identifying the series, e.g. a sensor ID
+	Granularity string // seconds, minutes or hours, empty when custom bucketing is used
+}
+
+// MongoDBView represents a read-only view, the result of an aggregation pipeline on a source collection or view
+type MongoDBView struct {
+	Name     string
+	ViewOn   string
+	Pipeline string // Extended JSON of the pipeline stages
 }
 
 // MongoDBField represents a field in a MongoDB collection
@@ -38,6 +57,8 @@ type MongoDBField struct {
 	IsArray      bool
 	NestedFields map[string]MongoDBField
 	Frequency    float64 // Percentage of documents containing this field
+	Validated    bool    // Type & required flag declared by the $jsonSchema validator of the collection
+	Description  string  // Description of the field in the validator
 }
 
 // MongoDBIndex represents an index in a MongoDB collection
@@ -215,8 +215,9 @@ func processMongoDBQueryParams(paramsStr string) (string, error) {
 	}
 
 	// Handle numerical values in sort expressions like {field: -1}
-	// Preserve negative numbers in sort expressions
-	sortPattern := regexp.MustCompile(`\{([^{}]+):\s*(-?\d+)\s*\}`)
+	// Preserve negative numbers in sort expressions, only single field objects are matched so that
+	// compound ones like {email: 1, createdAt: -1} are quoted field by field below
+	sortPattern := regexp.MustCompile(`\{\s*([\w$.]+|"[^"]*"|'[^']*')\s*:\s*(-?\d+)\s*\}`)
 	paramsStr = sortPattern.ReplaceAllStringFunc(paramsStr, func(match string) string {
 		// Extract the field and direction
 		sortMatches := sortPattern.FindStringSubmatch(match)
@@ -648,9 +649,9 @@ func processSortExpression(sortExpr string) (string, error) {
 
 // Process the aggregation results from a cursor
 func processAggregationResultsFromCursor(cursor *mongo.Cursor, ctx context.Context) *QueryExecutionResult {
-	// Decode the results
-	var results []bson.M
-	if err := cursor.All(ctx, &results); err != nil {
+	// Decode the results batch by batch, up to the cursor limits
+	results, truncatedReason, err := readMongoCursor(ctx, cursor, logMongoCursorProgress("processAggregationResultsFromCursor"))
+	if err != nil {
 		return &QueryExecutionResult{
 			Error: &dtos.QueryError{
 				Message: fmt.Sprintf("Failed to decode aggregation results: %v", err),
@@ -660,9 +661,7 @@ func processAggregationResultsFromCursor(cursor *mongo.Cursor, ctx context.Conte
 	}
 
 	// Create a wrapper for the results to maintain compatibility with existing code
-	resultMap := map[string]interface{}{
-		"results": results,
-	}
+	resultMap := mongoCursorResult(results, truncatedReason)
 
 	// Marshal the results to JSON for ResultJSON field
 	resultJSON, err := json.Marshal(resultMap)
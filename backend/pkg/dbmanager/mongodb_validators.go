@@ -0,0 +1,146 @@
+package dbmanager
+
+import (
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// mongoJSONSchemaTypes maps the bsonType of a $jsonSchema to the field types inferred from the sampled documents
+var mongoJSONSchemaTypes = map[string]string{
+	"string":   "string",
+	"int":      "integer",
+	"long":     "integer",
+	"double":   "number",
+	"decimal":  "number",
+	"number":   "number",
+	"bool":     "boolean",
+	"boolean":  "boolean",
+	"object":   "object",
+	"array":    "array",
+	"objectId": "objectId",
+	"date":     "date",
+	"binData":  "binary",
+	"null":     "null",
+}
+
+// applyJSONSchemaValidator merges the $jsonSchema validator of a collection into the fields inferred by sampling.
+// The validator is authoritative: the types & required flags it declares replace the inferred ones, fields missing from the sample are added.
+func applyJSONSchemaValidator(fields map[string]MongoDBField, jsonSchema bson.M, depth int, sampling mongoSchemaSampling) {
+	required := make(map[string]bool)
+	if names, ok := jsonSchema["required"].(bson.A); ok {
+		for _, name := range names {
+			if name, ok := name.(string); ok {
+				required[name] = true
+			}
+		}
+	}
+
+	properties, _ := jsonSchema["properties"].(bson.M)
+	for name, property := range properties {
+		property, ok := property.(bson.M)
+		if !ok {
+			continue
+		}
+
+		field, exists := fields[name]
+		if !exists {
+			field = MongoDBField{
+				Name:         name,
+				NestedFields: make(map[string]MongoDBField),
+			}
+		}
+		field.Validated = true
+		field.IsRequired = required[name]
+		if description, ok := property["description"].(string); ok {
+			field.Description = description
+		}
+
+		if fieldType := jsonSchemaFieldType(property); fieldType == "array" {
+			// The type of an array field is the type of its items, as for sampled arrays
+			field.IsArray = true
+			if items, ok := property["items"].(bson.M); ok {
+				if itemType := jsonSchemaFieldType(items); itemType != "" {
+					field.Type = itemType
+				}
+				if depth < sampling.MaxDepth {
+					applyJSONSchemaValidator(field.NestedFields, items, depth+1, sampling)
+				}
+			}
+		} else if fieldType != "" {
+			field.Type = fieldType
+			field.IsArray = false
+		}
+		if depth < sampling.MaxDepth {
+			applyJSONSchemaValidator(field.NestedFields, property, depth+1, sampling)
+		}
+		if field.Type == "" {
+			field.Type = "mixed"
+		}
+		fields[name] = field
+	}
+
+	// Required fields without properties are required whatever their type
+	for name := range required {
+		if _, declared := properties[name]; declared {
+			continue
+		}
+		field, exists := fields[name]
+		if !exists {
+			field = MongoDBField{
+				Name:         name,
+				Type:         "mixed",
+				NestedFields: make(map[string]MongoDBField),
+			}
+		}
+		field.Validated = true
+		field.IsRequired = true
+		fields[name] = field
+	}
+}
+
+// jsonSchemaFieldType returns the field type of a $jsonSchema property, bsonType or type may list several types of which null only makes the field nullable
+func jsonSchemaFieldType(property bson.M) string {
+	declared := property["bsonType"]
+	if declared == nil {
+		declared = property["type"]
+	}
+
+	var types []string
+	switch declared := declared.(type) {
+	case string:
+		types = []string{declared}
+	case bson.A:
+		for _, declaredType := range declared {
+			if declaredType, ok := declaredType.(string); ok {
+				types = append(types, declaredType)
+			}
+		}
+	}
+
+	fieldType := ""
+	for _, declaredType := range types {
+		if declaredType == "null" {
+			continue
+		}
+		mapped, ok := mongoJSONSchemaTypes[declaredType]
+		if !ok {
+			mapped = declaredType
+		}
+		fieldType = mergeMongoDBFieldType(fieldType, mapped)
+	}
+	return fieldType
+}
+
+// mongoFieldComment describes a field for the LLM, fields declared by the validator are described by it rather than by the sample
+func mongoFieldComment(field MongoDBField) string {
+	if !field.Validated {
+		return fmt.Sprintf("Present in %.1f%% of documents", field.Frequency*100)
+	}
+
+	comment := "Enforced by the collection's $jsonSchema validator"
+	if field.Description != "" {
+		comment += ": " + field.Description
+	}
+	return comment
+}
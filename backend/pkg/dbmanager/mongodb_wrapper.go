@@ -315,6 +315,114 @@ func (e *MongoDBExecutor) ListCollections(ctx context.Context) ([]string, error)
 	return collections, nil
 }
 
+// ListViews lists the views of the MongoDB database with their source & pipeline
+func (e *MongoDBExecutor) ListViews(ctx context.Context) (map[string]MongoDBView, error) {
+	cursor, err := e.wrapper.Client.Database(e.wrapper.Database).ListCollections(ctx, bson.M{"type": "view"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list views: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	views := make(map[string]MongoDBView)
+	for cursor.Next(ctx) {
+		var spec struct {
+			Name    string `bson:"name"`
+			Options struct {
+				ViewOn   string     `bson:"viewOn"`
+				Pipeline []bson.Raw `bson:"pipeline"`
+			} `bson:"options"`
+		}
+		if err := cursor.Decode(&spec); err != nil {
+			return nil, fmt.Errorf("failed to decode view: %v", err)
+		}
+
+		stages := make([]string, 0, len(spec.Options.Pipeline))
+		for _, stage := range spec.Options.Pipeline {
+			stageJSON, err := bson.MarshalExtJSON(stage, false, false)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert the pipeline of view %s: %v", spec.Name, err)
+			}
+			stages = append(stages, string(stageJSON))
+		}
+		views[spec.Name] = MongoDBView{
+			Name:     spec.Name,
+			ViewOn:   spec.Options.ViewOn,
+			Pipeline: "[" + strings.Join(stages, ", ") + "]",
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list views: %v", err)
+	}
+	log.Printf("MongoDBExecutor -> ListViews -> Found %d views", len(views))
+	return views, nil
+}
+
+// ListTimeSeriesCollections lists the time-series collections of the MongoDB database with their options
+func (e *MongoDBExecutor) ListTimeSeriesCollections(ctx context.Context) (map[string]MongoDBTimeSeries, error) {
+	cursor, err := e.wrapper.Client.Database(e.wrapper.Database).ListCollections(ctx, bson.M{"type": "timeseries"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list time-series collections: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	collections := make(map[string]MongoDBTimeSeries)
+	for cursor.Next(ctx) {
+		var spec struct {
+			Name    string `bson:"name"`
+			Options struct {
+				TimeSeries struct {
+					TimeField   string `bson:"timeField"`
+					MetaField   string `bson:"metaField"`
+					Granularity string `bson:"granularity"`
+				} `bson:"timeseries"`
+			} `bson:"options"`
+		}
+		if err := cursor.Decode(&spec); err != nil {
+			return nil, fmt.Errorf("failed to decode time-series collection: %v", err)
+		}
+		collections[spec.Name] = MongoDBTimeSeries{
+			TimeField:   spec.Options.TimeSeries.TimeField,
+			MetaField:   spec.Options.TimeSeries.MetaField,
+			Granularity: spec.Options.TimeSeries.Granularity,
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list time-series collections: %v", err)
+	}
+	log.Printf("MongoDBExecutor -> ListTimeSeriesCollections -> Found %d time-series collections", len(collections))
+	return collections, nil
+}
+
+// ListJSONSchemaValidators lists the $jsonSchema of the collections validated by one
+func (e *MongoDBExecutor) ListJSONSchemaValidators(ctx context.Context) (map[string]bson.M, error) {
+	cursor, err := e.wrapper.Client.Database(e.wrapper.Database).ListCollections(ctx, bson.M{"options.validator.$jsonSchema": bson.M{"$exists": true}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list validators: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	validators := make(map[string]bson.M)
+	for cursor.Next(ctx) {
+		var spec struct {
+			Name    string `bson:"name"`
+			Options struct {
+				Validator struct {
+					JSONSchema bson.M `bson:"$jsonSchema"`
+				} `bson:"validator"`
+			} `bson:"options"`
+		}
+		if err := cursor.Decode(&spec); err != nil {
+			return nil, fmt.Errorf("failed to decode validator: %v", err)
+		}
+		validators[spec.Name] = spec.Options.Validator.JSONSchema
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list validators: %v", err)
+	}
+	log.Printf("MongoDBExecutor -> ListJSONSchemaValidators -> Found %d validated collections", len(validators))
+	return validators, nil
+}
+
 // SampleCollection samples documents from a MongoDB collection
 func (e *MongoDBExecutor) SampleCollection(ctx context.Context, collectionName string, sampleSize int) ([]bson.M, error) {
 	log.Printf("MongoDBExecutor -> SampleCollection -> Sampling collection %s with sample size %d", collectionName, sampleSize)
@@ -361,8 +469,9 @@ func (e *MongoDBExecutor) SampleCollection(ctx context.Context, collectionName s
 	cursor, err := e.wrapper.Client.Database(e.wrapper.Database).Collection(collectionName).Aggregate(ctx, pipeline)
 	if err != nil {
 		log.Printf("MongoDBExecutor -> SampleCollection -> Error using $sample aggregation: %v, falling back to find()", err)
-		// Fall back to find() if aggregation fails
-		findCursor, findErr := e.wrapper.Client.Database(e.wrapper.Database).Collection(collectionName).Find(ctx, bson.M{})
+		// Fall back to find() if aggregation fails, the first documents of the collection are less representative but still describe it
+		findOpts := options.Find().SetLimit(int64(sampleSize))
+		findCursor, findErr := e.wrapper.Client.Database(e.wrapper.Database).Collection(collectionName).Find(ctx, bson.M{}, findOpts)
 		if findErr != nil {
 			log.Printf("MongoDBExecutor -> SampleCollection -> Error using find(): %v", findErr)
 			return nil, fmt.Errorf("failed to query collection: %v", findErr)
@@ -375,11 +484,6 @@ func (e *MongoDBExecutor) SampleCollection(ctx context.Context, collectionName s
 			return nil, fmt.Errorf("failed to decode find results: %v", err)
 		}
 
-		// Limit results to sample size
-		if len(results) > sampleSize {
-			results = results[:sampleSize]
-		}
-
 		log.Printf("MongoDBExecutor -> SampleCollection -> Retrieved %d documents using find() from collection %s", len(results), collectionName)
 		return results, nil
 	}
@@ -395,6 +499,26 @@ func (e *MongoDBExecutor) SampleCollection(ctx context.Context, collectionName s
 	return results, nil
 }
 
+// SampleNewestDocuments returns the newest documents of a MongoDB collection, ObjectIds start with their creation time so the highest _ids are the newest
+func (e *MongoDBExecutor) SampleNewestDocuments(ctx context.Context, collectionName string, limit int) ([]bson.M, error) {
+	log.Printf("MongoDBExecutor -> SampleNewestDocuments -> Reading the %d newest documents of collection %s", limit, collectionName)
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := e.wrapper.Client.Database(e.wrapper.Database).Collection(collectionName).Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query newest documents: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []bson.M
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode newest documents: %v", err)
+	}
+
+	log.Printf("MongoDBExecutor -> SampleNewestDocuments -> Retrieved %d documents from collection %s", len(results), collectionName)
+	return results, nil
+}
+
 // ExecuteRawCommand executes a raw MongoDB command
 func (e *MongoDBExecutor) ExecuteRawCommand(ctx context.Context, command interface{}) (bson.M, error) {
 	var result bson.M
@@ -3,14 +3,10 @@ package dbmanager
 import (
 	"context"
 	"crypto/tls"
-	"crypto/x509"
-	"database/sql"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"neobase-ai/internal/apis/dtos"
-	"neobase-ai/internal/utils"
 	"os"
 	"strings"
 	"sync"
@@ -32,18 +28,27 @@ func NewMySQLDriver() DatabaseDriver {
 // Connect establishes a connection to a MySQL database
 func (d *MySQLDriver) Connect(config ConnectionConfig) (*Connection, error) {
 	var dsn string
-	var tempFiles []string
 
-	// Base connection parameters
-	if config.Password != nil {
+	if err := ValidateSocketPath(config); err != nil {
+		return nil, err
+	}
+
+	// Default port, not used by Unix socket connections
+	port := "3306"
+	if config.Port != nil && *config.Port != "" {
+		port = *config.Port
+	}
+
+	// Base connection parameters, Azure AD & AWS IAM tokens are set before every new connection
+	if config.Password != nil && !UsesTokenAuth(config) {
 		dsn = fmt.Sprintf(
-			"%s:%s@tcp(%s:%s)/%s",
-			*config.Username, *config.Password, config.Host, *config.Port, config.Database,
+			"%s:%s@%s/%s",
+			*config.Username, *config.Password, mysqlAddress(config, port), config.Database,
 		)
 	} else {
 		dsn = fmt.Sprintf(
-			"%s@tcp(%s:%s)/%s",
-			*config.Username, config.Host, *config.Port, config.Database,
+			"%s@%s/%s",
+			*config.Username, mysqlAddress(config, port), config.Database,
 		)
 	}
 
@@ -63,15 +68,12 @@ func (d *MySQLDriver) Connect(config ConnectionConfig) (*Connection, error) {
 			// Create a unique TLS config name
 			tlsConfigName := fmt.Sprintf("custom-%d", time.Now().UnixNano())
 
-			// Fetch certificates from URLs
-			certPath, keyPath, rootCertPath, certTempFiles, err := utils.PrepareCertificatesFromURLs(*config.SSLCertURL, *config.SSLKeyURL, *config.SSLRootCertURL)
+			// Load certificates, uploaded ones are used over the URLs
+			certs, err := loadSSLCertificates(config)
 			if err != nil {
 				return nil, err
 			}
 
-			// Track temporary files for cleanup
-			tempFiles = certTempFiles
-
 			// Create TLS config
 			tlsConfig := &tls.Config{
 				ServerName: config.Host,
@@ -93,38 +95,9 @@ func (d *MySQLDriver) Connect(config ConnectionConfig) (*Connection, error) {
 				}
 			}
 
-			// Add client certificates if provided
-			if certPath != "" && keyPath != "" {
-				cert, err := tls.LoadX509KeyPair(certPath, keyPath)
-				if err != nil {
-					// Clean up temporary files
-					for _, file := range tempFiles {
-						os.Remove(file)
-					}
-					return nil, fmt.Errorf("failed to load client certificates: %v", err)
-				}
-				tlsConfig.Certificates = []tls.Certificate{cert}
-			}
-
-			// Add CA certificate if provided
-			if rootCertPath != "" {
-				rootCertPool := x509.NewCertPool()
-				pem, err := ioutil.ReadFile(rootCertPath)
-				if err != nil {
-					// Clean up temporary files
-					for _, file := range tempFiles {
-						os.Remove(file)
-					}
-					return nil, fmt.Errorf("failed to read CA certificate: %v", err)
-				}
-				if ok := rootCertPool.AppendCertsFromPEM(pem); !ok {
-					// Clean up temporary files
-					for _, file := range tempFiles {
-						os.Remove(file)
-					}
-					return nil, fmt.Errorf("failed to append CA certificate")
-				}
-				tlsConfig.RootCAs = rootCertPool
+			// Add client & CA certificates if provided
+			if err := certs.applyTo(tlsConfig); err != nil {
+				return nil, err
 			}
 
 			// Register TLS config
@@ -136,21 +109,13 @@ func (d *MySQLDriver) Connect(config ConnectionConfig) (*Connection, error) {
 	}
 
 	// Open connection
-	db, err := sql.Open("mysql", dsn)
+	db, err := openMySQLDB(config, dsn)
 	if err != nil {
-		// Clean up temporary files
-		for _, file := range tempFiles {
-			os.Remove(file)
-		}
 		return nil, err
 	}
 
 	// Test connection
 	if err := db.Ping(); err != nil {
-		// Clean up temporary files
-		for _, file := range tempFiles {
-			os.Remove(file)
-		}
 		db.Close()
 		return nil, err
 	}
@@ -160,16 +125,14 @@ func (d *MySQLDriver) Connect(config ConnectionConfig) (*Connection, error) {
 	db.SetMaxIdleConns(5)
 	db.SetConnMaxLifetime(time.Hour)
 
-	// Create GORM DB
-	gormDB, err := gorm.Open(mysql.New(mysql.Config{
-		DSN: dsn,
-	}), &gorm.Config{})
+	// Create GORM DB, the pool is reused with token authentication as the DSN holds no password
+	gormConfig := mysql.Config{DSN: dsn}
+	if UsesTokenAuth(config) {
+		gormConfig = mysql.Config{Conn: db}
+	}
+	gormDB, err := gorm.Open(mysql.New(gormConfig), &gorm.Config{})
 
 	if err != nil {
-		// Clean up temporary files
-		for _, file := range tempFiles {
-			os.Remove(file)
-		}
 		db.Close()
 		return nil, fmt.Errorf("failed to create GORM connection: %v", err)
 	}
@@ -182,7 +145,6 @@ func (d *MySQLDriver) Connect(config ConnectionConfig) (*Connection, error) {
 		Config:      config,
 		Subscribers: make(map[string]bool),
 		SubLock:     sync.RWMutex{},
-		TempFiles:   tempFiles,
 	}
 
 	return conn, nil
@@ -269,13 +231,16 @@ func (d *MySQLDriver) ExecuteQuery(ctx context.Context, conn *Connection, query
 			return result
 		}
 
+		// The named parameters of the statement are bound to the values of the context
+		boundStmt, args := bindQueryParams(ctx, stmt, placeholderQuestionMark)
+
 		// Execute the statement based on query type
 		if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(stmt)), "SELECT") ||
 			strings.HasPrefix(strings.ToUpper(strings.TrimSpace(stmt)), "SHOW") ||
 			strings.HasPrefix(strings.ToUpper(strings.TrimSpace(stmt)), "DESCRIBE") {
 			// For SELECT, SHOW, DESCRIBE queries, return the results
-			var rows []map[string]interface{}
-			if err := conn.DB.WithContext(ctx).Raw(stmt).Scan(&rows).Error; err != nil {
+			rows, columns, err := queryResultRows(ctx, conn.DB, boundStmt, args...)
+			if err != nil {
 				result.Error = &dtos.QueryError{
 					Message: err.Error(),
 					Code:    "EXECUTION_ERROR",
@@ -319,9 +284,12 @@ func (d *MySQLDriver) ExecuteQuery(ctx context.Context, conn *Connection, query
 			result.Result = map[string]interface{}{
 				"results": processedRows,
 			}
+			result.Columns = columns
 		} else {
+			// A statement without rows ends the result, the columns of an earlier SELECT are dropped
+			result.Columns = nil
 			// For other queries (INSERT, UPDATE, DELETE, etc.), execute and return affected rows
-			execResult := conn.DB.WithContext(ctx).Exec(stmt)
+			execResult := conn.DB.WithContext(ctx).Exec(boundStmt, args...)
 			if execResult.Error != nil {
 				result.Error = &dtos.QueryError{
 					Message: execResult.Error.Error(),
@@ -372,16 +340,29 @@ func (d *MySQLDriver) BeginTx(ctx context.Context, conn *Connection) Transaction
 		return nil
 	}
 
-	// Start a new transaction
-	tx := conn.DB.WithContext(ctx).Begin()
+	// Start a new transaction, read-only when the context asks for it
+	var tx *gorm.DB
+	if opts := readOnlyTxOptions(ctx); opts != nil {
+		tx = conn.DB.WithContext(ctx).Begin(opts)
+	} else {
+		tx = conn.DB.WithContext(ctx).Begin()
+	}
 	if tx.Error != nil {
 		log.Printf("Failed to begin transaction: %v", tx.Error)
 		return nil
 	}
 
+	// The query of the connection is killed from another connection when it is cancelled, see server_cancel.go
+	var connectionID uint64
+	if err := tx.Raw("SELECT CONNECTION_ID()").Scan(&connectionID).Error; err != nil {
+		log.Printf("MySQLDriver.BeginTx: Failed to read the connection ID: %v", err)
+	}
+	resetAuditActor(tx, conn)
+
 	return &MySQLTransaction{
-		tx:   tx,
-		conn: conn,
+		tx:           tx,
+		conn:         conn,
+		connectionID: connectionID,
 	}
 }
 
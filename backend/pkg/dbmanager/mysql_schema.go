@@ -165,7 +165,7 @@ func (f *MySQLSchemaFetcher) fetchTables(_ context.Context) ([]string, error) {
         SELECT table_name 
         FROM information_schema.tables 
         WHERE table_schema = DATABASE() 
-        AND table_type = 'BASE TABLE'
+        AND table_type IN ('BASE TABLE', 'SYSTEM VERSIONED')
         ORDER BY table_name;
     `
 	log.Printf("MySQLSchemaFetcher -> fetchTables -> Executing query: %s", query)
@@ -827,7 +827,7 @@ func (f *MySQLSchemaFetcher) FetchTableList(ctx context.Context) ([]string, erro
         SELECT table_name 
         FROM information_schema.tables 
         WHERE table_schema = DATABASE() 
-        AND table_type = 'BASE TABLE'
+        AND table_type IN ('BASE TABLE', 'SYSTEM VERSIONED')
         ORDER BY table_name;
     `
 	err := f.db.Query(query, &tables)
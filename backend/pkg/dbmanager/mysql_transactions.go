@@ -13,8 +13,10 @@ import (
 
 // MySQLTransaction implements the Transaction interface for MySQL
 type MySQLTransaction struct {
-	tx   *gorm.DB
-	conn *Connection
+	tx           *gorm.DB
+	conn         *Connection
+	connectionID uint64 // Server connection running the transaction, its queries are killed with KILL QUERY
+	cancelGuard  serverCancelGuard
 }
 
 // ExecuteQuery executes a query within a transaction
@@ -49,13 +51,16 @@ func (t *MySQLTransaction) ExecuteQuery(ctx context.Context, conn *Connection, q
 			return result
 		}
 
+		// The named parameters of the statement are bound to the values of the context
+		boundStmt, args := bindQueryParams(ctx, stmt, placeholderQuestionMark)
+
 		// Execute the statement based on query type
 		if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(stmt)), "SELECT") ||
 			strings.HasPrefix(strings.ToUpper(strings.TrimSpace(stmt)), "SHOW") ||
 			strings.HasPrefix(strings.ToUpper(strings.TrimSpace(stmt)), "DESCRIBE") {
 			// For SELECT, SHOW, DESCRIBE queries, return the results
-			var rows []map[string]interface{}
-			if err := t.tx.WithContext(ctx).Raw(stmt).Scan(&rows).Error; err != nil {
+			rows, columns, err := queryResultRows(ctx, t.tx, boundStmt, args...)
+			if err != nil {
 				result.Error = &dtos.QueryError{
 					Message: err.Error(),
 					Code:    "EXECUTION_ERROR",
@@ -99,9 +104,12 @@ func (t *MySQLTransaction) ExecuteQuery(ctx context.Context, conn *Connection, q
 			result.Result = map[string]interface{}{
 				"results": processedRows,
 			}
+			result.Columns = columns
 		} else {
+			// A statement without rows ends the result, the columns of an earlier SELECT are dropped
+			result.Columns = nil
 			// For other queries (INSERT, UPDATE, DELETE, etc.), execute and return affected rows
-			execResult := t.tx.WithContext(ctx).Exec(stmt)
+			execResult := t.tx.WithContext(ctx).Exec(boundStmt, args...)
 			if execResult.Error != nil {
 				result.Error = &dtos.QueryError{
 					Message: execResult.Error.Error(),
@@ -150,6 +158,7 @@ func (t *MySQLTransaction) Commit() error {
 	if t.tx == nil {
 		return fmt.Errorf("no active transaction to commit")
 	}
+	t.cancelGuard.end()
 	return t.tx.Commit().Error
 }
 
@@ -158,5 +167,6 @@ func (t *MySQLTransaction) Rollback() error {
 	if t.tx == nil {
 		return fmt.Errorf("no active transaction to rollback")
 	}
+	t.cancelGuard.end()
 	return t.tx.Rollback().Error
 }
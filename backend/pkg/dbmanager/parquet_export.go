@@ -0,0 +1,192 @@
+package dbmanager
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"neobase-ai/internal/apis/dtos"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xitongsys/parquet-go/writer"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// parquetRowGroupRows is the number of rows of a row group, a row group is kept in memory until it is written
+	parquetRowGroupRows = 100000
+	// parquetRowGroupSize bounds the memory of a row group of wide rows, in bytes
+	parquetRowGroupSize = 64 * 1024 * 1024
+)
+
+// parquetNameRegex matches the characters the schema of the writer can't hold in a column name
+var parquetNameRegex = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// ExportQueryParquet writes the whole result of a read-only query to w as a Parquet file, a row group at a time.
+// The columns are typed from the types of the result, the decimals are text to keep their precision & the fields of
+// MongoDB documents are text since their types vary between documents. Returns the number of rows written.
+func (m *Manager) ExportQueryParquet(ctx context.Context, chatID, query string, w io.Writer) (int64, *dtos.QueryError) {
+	resultWriter := &parquetResultWriter{w: w}
+	summary, queryErr := m.exportQuery(ctx, chatID, query, 0, resultWriter)
+	if queryErr != nil {
+		return summary.rows, queryErr
+	}
+	if resultWriter.parquet == nil {
+		// The columns of MongoDB come from the first document
+		return 0, &dtos.QueryError{
+			Code:    "EXPORT_EMPTY_RESULT",
+			Message: "the result is empty",
+			Details: "The query returned no document, a Parquet file needs the columns of one",
+		}
+	}
+	if err := resultWriter.parquet.WriteStop(); err != nil {
+		return summary.rows, &dtos.QueryError{
+			Code:    "EXPORT_FAILED",
+			Message: "failed to export the results",
+			Details: err.Error(),
+		}
+	}
+	return summary.rows, nil
+}
+
+// parquetResultWriter writes a result in a Parquet file, the file is started with the header so that nothing is written
+// to the client before the query succeeded
+type parquetResultWriter struct {
+	w       io.Writer
+	parquet *writer.CSVWriter
+	format  exportFormat
+	columns []exportColumn
+	kinds   []columnKind
+	rows    int
+}
+
+func (w *parquetResultWriter) writeHeader(columns []exportColumn, format exportFormat) error {
+	w.columns = columns
+	w.format = format
+	w.kinds = make([]columnKind, len(columns))
+
+	schema := make([]string, len(columns))
+	names := make(map[string]bool, len(columns))
+	for i, column := range columns {
+		w.kinds[i] = column.kind()
+		schema[i] = "name=" + uniqueParquetName(column.name, names) + ", " + parquetType(w.kinds[i]) + ", repetitiontype=OPTIONAL"
+	}
+
+	parquetWriter, err := writer.NewCSVWriterFromWriter(schema, w.w, 1)
+	if err != nil {
+		return err
+	}
+	parquetWriter.RowGroupSize = parquetRowGroupSize
+	w.parquet = parquetWriter
+	return nil
+}
+
+func (w *parquetResultWriter) writeRow(values []interface{}) error {
+	// The writer keeps the rows until the row group is written, each row needs its own slice
+	row := make([]interface{}, len(values))
+	for i, value := range values {
+		converted, err := w.cellValue(value, i)
+		if err != nil {
+			return fmt.Errorf("failed to convert %s: %v", w.columns[i].name, err)
+		}
+		row[i] = converted
+	}
+	if err := w.parquet.Write(row); err != nil {
+		return err
+	}
+
+	w.rows++
+	if w.rows%parquetRowGroupRows == 0 {
+		return w.parquet.Flush(true)
+	}
+	return nil
+}
+
+// flush does nothing, the rows are written a row group at a time
+func (w *parquetResultWriter) flush() error {
+	return nil
+}
+
+// parquetType returns the schema type of a column kind
+func parquetType(kind columnKind) string {
+	switch kind {
+	case kindBoolean:
+		return "type=BOOLEAN"
+	case kindInteger:
+		return "type=INT64"
+	case kindUnsigned:
+		return "type=INT64, convertedtype=UINT_64"
+	case kindFloat:
+		return "type=DOUBLE"
+	case kindDate:
+		return "type=INT32, convertedtype=DATE"
+	case kindTimestamp:
+		return "type=INT64, convertedtype=TIMESTAMP_MICROS"
+	case kindBinary:
+		return "type=BYTE_ARRAY"
+	}
+	return "type=BYTE_ARRAY, convertedtype=UTF8"
+}
+
+// cellValue converts a value read from the database to the Go type of its Parquet column, nil is null
+func (w *parquetResultWriter) cellValue(value interface{}, index int) (interface{}, error) {
+	switch value.(type) {
+	case nil, primitive.Null, primitive.Undefined:
+		return nil, nil
+	}
+
+	kind := w.kinds[index]
+	switch kind {
+	case kindDate, kindTimestamp:
+		t, ok := value.(time.Time)
+		if !ok {
+			if t, ok = parseTimeText(valueText(value)); !ok {
+				return nil, fmt.Errorf("%q is not a date", valueText(value))
+			}
+		}
+		if kind == kindDate {
+			return int32(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400), nil
+		}
+		return t.UnixMicro(), nil
+	case kindBoolean:
+		return strconv.ParseBool(valueText(value))
+	case kindInteger:
+		return strconv.ParseInt(valueText(value), 10, 64)
+	case kindUnsigned:
+		number, err := strconv.ParseUint(valueText(value), 10, 64)
+		return int64(number), err
+	case kindFloat:
+		return strconv.ParseFloat(valueText(value), 64)
+	case kindBinary:
+		return valueText(value), nil
+	}
+	return w.format.text(value, w.columns[index]), nil
+}
+
+// valueText returns the text of a value a driver returned as bytes, as text or as a Go value
+func valueText(value interface{}) string {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	}
+	return fmt.Sprint(value)
+}
+
+// uniqueParquetName replaces the characters a column name can't hold & suffixes the names already used, the writer
+// ignores the case of the names
+func uniqueParquetName(name string, used map[string]bool) string {
+	name = parquetNameRegex.ReplaceAllString(name, "_")
+	if name == "" {
+		name = "column"
+	}
+	unique := name
+	for i := 2; used[strings.ToLower(unique)]; i++ {
+		unique = name + "_" + strconv.Itoa(i)
+	}
+	used[strings.ToLower(unique)] = true
+	return unique
+}
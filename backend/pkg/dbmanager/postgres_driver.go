@@ -26,17 +26,27 @@ func (d *PostgresDriver) Connect(config ConnectionConfig) (*Connection, error) {
 	var dsn string
 	var tempFiles []string
 
-	// Base connection parameters
+	if err := ValidateSocketPath(config); err != nil {
+		return nil, err
+	}
+
+	// Base connection parameters, the socket directory is used as host for Unix socket connections
+	host, port := config.Host, ""
+	if UsesUnixSocket(config) {
+		host, port = postgresSocketHostPort(config)
+	} else {
+		port = *config.Port // Dereference the port pointer
+	}
 	baseParams := fmt.Sprintf(
 		"host=%s port=%s user=%s dbname=%s",
-		config.Host,
-		*config.Port, // Dereference the port pointer
+		host,
+		port,
 		*config.Username,
 		config.Database,
 	)
 
-	// Add password if provided
-	if config.Password != nil {
+	// Add password if provided, Azure AD & AWS IAM tokens are added per connection, Kerberos needs none
+	if config.Password != nil && !UsesTokenAuth(config) && !UsesKerberosAuth(config) {
 		baseParams += fmt.Sprintf(" password=%s", *config.Password)
 	}
 
@@ -53,27 +63,19 @@ func (d *PostgresDriver) Connect(config ConnectionConfig) (*Connection, error) {
 			baseParams += fmt.Sprintf(" sslmode=%s", sslMode)
 		}
 
-		// Fetch certificates from URLs
-		certPath, keyPath, rootCertPath, certTempFiles, err := utils.PrepareCertificatesFromURLs(*config.SSLCertURL, *config.SSLKeyURL, *config.SSLRootCertURL)
+		// Load certificates, uploaded ones are passed inline
+		certs, err := loadSSLCertificates(config)
+		if err != nil {
+			return nil, err
+		}
+		certParams, certTempFiles, err := certs.postgresParams()
 		if err != nil {
 			return nil, err
 		}
 
 		// Track temporary files for cleanup
 		tempFiles = certTempFiles
-
-		// Add certificate paths to connection string
-		if certPath != "" {
-			baseParams += fmt.Sprintf(" sslcert=%s", certPath)
-		}
-
-		if keyPath != "" {
-			baseParams += fmt.Sprintf(" sslkey=%s", keyPath)
-		}
-
-		if rootCertPath != "" {
-			baseParams += fmt.Sprintf(" sslrootcert=%s", rootCertPath)
-		}
+		baseParams += certParams
 	} else {
 		baseParams += " sslmode=disable"
 	}
@@ -81,7 +83,7 @@ func (d *PostgresDriver) Connect(config ConnectionConfig) (*Connection, error) {
 	dsn = baseParams
 
 	// Open connection
-	db, err := sql.Open("postgres", dsn)
+	db, err := openPostgresDB(config, dsn)
 	if err != nil {
 		// Clean up temporary files
 		for _, file := range tempFiles {
@@ -198,7 +200,9 @@ func (d *PostgresDriver) ExecuteQuery(ctx context.Context, conn *Connection, que
 			continue
 		}
 
-		lastResult, lastError = sqlDB.QueryContext(ctx, stmt)
+		// The named parameters of the statement are bound to the values of the context
+		boundStmt, args := bindQueryParams(ctx, stmt, placeholderDollar)
+		lastResult, lastError = sqlDB.QueryContext(ctx, boundStmt, args...)
 		if lastError != nil {
 			log.Printf("PostgreSQL/YugabyteDB Driver -> ExecuteQuery -> Query execution failed: %v", lastError)
 			return &QueryExecutionResult{
@@ -218,7 +222,9 @@ func (d *PostgresDriver) ExecuteQuery(ctx context.Context, conn *Connection, que
 	// Process results from the last statement if it returned rows
 	var result *QueryExecutionResult
 	if lastResult != nil {
-		results, err := processRows(lastResult, startTime)
+		// The columns are described before the rows are read, the rows are closed once read
+		columns := sqlResultColumns(lastResult)
+		results, err := processRows(lastResult, startTime, resultRowLimit(ctx))
 		if err != nil {
 			return &QueryExecutionResult{
 				ExecutionTime: int(time.Since(startTime).Milliseconds()),
@@ -234,6 +240,7 @@ func (d *PostgresDriver) ExecuteQuery(ctx context.Context, conn *Connection, que
 			Result: map[string]interface{}{
 				"results": results,
 			},
+			Columns: columns,
 		}
 	} else {
 		result = &QueryExecutionResult{
@@ -261,16 +268,23 @@ func (d *PostgresDriver) BeginTx(ctx context.Context, conn *Connection) Transact
 		return nil
 	}
 
-	tx, err := sqlDB.BeginTx(ctx, nil)
+	tx, err := sqlDB.BeginTx(ctx, readOnlyTxOptions(ctx))
 	if err != nil {
 		log.Printf("PostgreSQL/YugabyteDB Driver -> BeginTx -> Failed to begin transaction: %v", err)
 		return nil
 	}
 
+	// The backend is cancelled from another connection when the query is cancelled, see server_cancel.go
+	var backendPID int
+	if err := tx.QueryRowContext(ctx, "SELECT pg_backend_pid()").Scan(&backendPID); err != nil {
+		log.Printf("PostgreSQL/YugabyteDB Driver -> BeginTx -> Failed to read the backend PID: %v", err)
+	}
+
 	// Pass connection to transaction
 	return &PostgresTransaction{
-		tx:   tx,
-		conn: conn,
+		tx:         tx,
+		conn:       conn,
+		backendPID: backendPID,
 	}
 }
 
@@ -24,7 +24,7 @@ func splitStatements(query string) []string {
 }
 
 // Update the processRows function signature to return results and error
-func processRows(rows *sql.Rows, startTime time.Time) ([]map[string]interface{}, error) {
+func processRows(rows *sql.Rows, startTime time.Time, maxRows int) ([]map[string]interface{}, error) {
 	columns, err := rows.Columns()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get columns: %v", err)
@@ -39,6 +39,10 @@ func processRows(rows *sql.Rows, startTime time.Time) ([]map[string]interface{},
 	}
 
 	for rows.Next() {
+		// A row past the limit is kept so the result is known to be truncated
+		if maxRows > 0 && len(results) > maxRows {
+			break
+		}
 		err := rows.Scan(scanArgs...)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan row: %v", err)
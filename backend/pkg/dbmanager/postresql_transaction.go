@@ -12,8 +12,10 @@ import (
 )
 
 type PostgresTransaction struct {
-	tx   *sql.Tx
-	conn *Connection // Add connection reference
+	tx          *sql.Tx
+	conn        *Connection // Add connection reference
+	backendPID  int         // Server process running the transaction, its queries are cancelled with pg_cancel_backend
+	cancelGuard serverCancelGuard
 }
 
 func (tx *PostgresTransaction) ExecuteQuery(ctx context.Context, conn *Connection, query string, queryType string, findCount bool) *QueryExecutionResult {
@@ -33,9 +35,12 @@ func (tx *PostgresTransaction) ExecuteQuery(ctx context.Context, conn *Connectio
 			continue
 		}
 
+		// The named parameters of the statement are bound to the values of the context
+		boundStmt, args := bindQueryParams(ctx, stmt, placeholderDollar)
+
 		// For SELECT queries
 		if strings.HasPrefix(strings.ToUpper(stmt), "SELECT") {
-			rows, err = tx.tx.QueryContext(ctx, stmt)
+			rows, err = tx.tx.QueryContext(ctx, boundStmt, args...)
 			if err != nil {
 				return &QueryExecutionResult{
 					Error: &dtos.QueryError{
@@ -47,7 +52,7 @@ func (tx *PostgresTransaction) ExecuteQuery(ctx context.Context, conn *Connectio
 			}
 		} else {
 			// For non-SELECT queries
-			lastResult, err = tx.tx.ExecContext(ctx, stmt)
+			lastResult, err = tx.tx.ExecContext(ctx, boundStmt, args...)
 			if err != nil {
 				return &QueryExecutionResult{
 					Error: &dtos.QueryError{
@@ -92,7 +97,9 @@ func (tx *PostgresTransaction) ExecuteQuery(ctx context.Context, conn *Connectio
 
 	if rows != nil {
 		defer rows.Close()
-		results, err := processRows(rows, startTime)
+		// The columns are described before the rows are read, the rows are closed once read
+		result.Columns = sqlResultColumns(rows)
+		results, err := processRows(rows, startTime, resultRowLimit(ctx))
 		if err != nil {
 			return &QueryExecutionResult{
 				ExecutionTime: int(time.Since(startTime).Milliseconds()),
@@ -138,10 +145,12 @@ func (tx *PostgresTransaction) ExecuteQuery(ctx context.Context, conn *Connectio
 
 func (t *PostgresTransaction) Commit() error {
 	log.Printf("PostgreSQL Transaction -> Commit -> Committing transaction")
+	t.cancelGuard.end()
 	return t.tx.Commit()
 }
 
 func (t *PostgresTransaction) Rollback() error {
 	log.Printf("PostgreSQL Transaction -> Rollback -> Rolling back transaction")
+	t.cancelGuard.end()
 	return t.tx.Rollback()
 }
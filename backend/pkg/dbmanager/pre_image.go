@@ -0,0 +1,270 @@
+package dbmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/constants"
+	"sort"
+	"strings"
+)
+
+// maxPreImageRows bounds the rows a pre-image holds, the writes changing more are rolled back as they were before
+const maxPreImageRows = 1000
+
+// PreImage is the state of the rows or documents a write changes, read before it runs so the write can be undone whatever
+// happened to them since
+type PreImage struct {
+	Rows          []interface{}
+	RollbackQuery string // Restores the rows, empty when it can't be built from them, e.g. for MongoDB documents
+}
+
+// CapturePreImage reads the rows an UPDATE or a DELETE is about to change, or the documents of a MongoDB update or delete, from
+// the primary. A SQL write of a single table is also given the rollback restoring the rows: the INSERT of the deleted rows, or
+// the UPDATE of each updated row back to its values by its primary key.
+func (m *Manager) CapturePreImage(ctx context.Context, chatID, query string) (*PreImage, *dtos.QueryError) {
+	conn, driver, queryErr := m.groundingConnection(chatID)
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	query, queryErr = prepareQueryParams(ctx, conn.Config.Type, query)
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	var rowsQuery string
+	var write *sqlWrite
+	if conn.Config.Type == constants.DatabaseTypeMongoDB {
+		mongoWrite, ok := parseMongoDBWrite(strings.TrimSpace(query))
+		if ok {
+			rowsQuery = mongoWrite.findQuery(maxPreImageRows + 1)
+		}
+	} else if parsed, ok := parseSQLWrite(conn.Config.Type, query); ok {
+		write, rowsQuery = parsed, parsed.rowsQuery(maxPreImageRows+1)
+	}
+	if rowsQuery == "" {
+		return nil, &dtos.QueryError{
+			Code:    "PRE_IMAGE_NOT_SUPPORTED",
+			Message: "the rows the query changes can't be captured",
+			Details: "Only a single UPDATE or DELETE of one table, or a MongoDB update or delete, can be captured",
+		}
+	}
+
+	// The rows are read where the write runs, a replica may not have the last changes yet
+	ctx = WithPrimaryRouting(ctx)
+	resultJSON, queryErr := m.runGroundingQuery(ctx, conn, driver, rowsQuery, true)
+	if queryErr != nil {
+		return nil, queryErr
+	}
+	rows := previewRows(resultJSON)
+	if len(rows) > maxPreImageRows {
+		return nil, &dtos.QueryError{
+			Code:    "PRE_IMAGE_TOO_LARGE",
+			Message: "the query changes too many rows to capture them",
+			Details: fmt.Sprintf("The query changes more than %d rows", maxPreImageRows),
+		}
+	}
+
+	image := &PreImage{Rows: rows}
+	if write != nil && len(rows) > 0 {
+		image.RollbackQuery = preImageRollback(ctx, conn, write, rows)
+	}
+	return image, nil
+}
+
+// preImageRollback returns the statements restoring the rows a SQL write changes, empty when they can't be restored
+func preImageRollback(ctx context.Context, conn *Connection, write *sqlWrite, rows []interface{}) string {
+	dialect, ok := getBrowseDialect(conn.Config.Type)
+	if !ok {
+		return ""
+	}
+	start := skipKeywords(write.table, 0, "ONLY")
+	table, end := readQualifiedName(write.table, start)
+	if _, end = readAlias(write.table, end); table == "" || end != len(write.table) {
+		return ""
+	}
+	parts := strings.Split(table, ".")
+	for i, part := range parts {
+		parts[i] = dialect.quoteIdent(part)
+	}
+	quotedTable := strings.Join(parts, ".")
+
+	records := make([]map[string]interface{}, 0, len(rows))
+	for _, row := range rows {
+		record, ok := row.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		records = append(records, record)
+	}
+
+	if write.delete {
+		return preImageInsert(conn.Config.Type, dialect, quotedTable, records)
+	}
+
+	columns := assignedColumns(write.set)
+	if len(columns) == 0 {
+		return ""
+	}
+	keys, err := primaryKeyColumns(ctx, conn, table)
+	if err != nil || len(keys) == 0 {
+		return ""
+	}
+	for _, column := range columns {
+		for _, key := range keys {
+			// A row whose key is updated can't be found back by it
+			if strings.EqualFold(column, key) {
+				return ""
+			}
+		}
+	}
+
+	return preImageUpdates(conn.Config.Type, dialect, quotedTable, columns, keys, records)
+}
+
+// preImageUpdates returns the UPDATE of each updated row back to the values of its columns, found by its primary key.
+// Empty when a row lacks a column or has a NULL key, it couldn't be found back.
+func preImageUpdates(dbType string, dialect browseDialect, quotedTable string, columns, keys []string, records []map[string]interface{}) string {
+	statements := make([]string, 0, len(records))
+	for _, record := range records {
+		assignments := make([]string, 0, len(columns))
+		for _, column := range columns {
+			name, value, ok := recordValue(record, column)
+			if !ok {
+				return ""
+			}
+			assignments = append(assignments, dialect.quoteIdent(name)+" = "+sqlLiteral(dbType, value))
+		}
+		conditions := make([]string, 0, len(keys))
+		for _, key := range keys {
+			name, value, ok := recordValue(record, key)
+			if !ok || value == nil {
+				return ""
+			}
+			conditions = append(conditions, dialect.quoteIdent(name)+" = "+sqlLiteral(dbType, value))
+		}
+		statements = append(statements, fmt.Sprintf("UPDATE %s SET %s WHERE %s;", quotedTable, strings.Join(assignments, ", "), strings.Join(conditions, " AND ")))
+	}
+	return strings.Join(statements, "\n")
+}
+
+// preImageInsert returns the INSERT of the deleted rows
+func preImageInsert(dbType string, dialect browseDialect, quotedTable string, records []map[string]interface{}) string {
+	columns := make([]string, 0, len(records[0]))
+	for column := range records[0] {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	quotedColumns := make([]string, len(columns))
+	for i, column := range columns {
+		quotedColumns[i] = dialect.quoteIdent(column)
+	}
+	values := make([]string, 0, len(records))
+	for _, record := range records {
+		literals := make([]string, len(columns))
+		for i, column := range columns {
+			value, ok := record[column]
+			if !ok {
+				return ""
+			}
+			literals[i] = sqlLiteral(dbType, value)
+		}
+		values = append(values, "("+strings.Join(literals, ", ")+")")
+	}
+	// The deleted rows get their identity values back
+	overriding := ""
+	if dbType == constants.DatabaseTypePostgreSQL || dbType == constants.DatabaseTypeYugabyteDB {
+		overriding = " OVERRIDING SYSTEM VALUE"
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s)%s VALUES %s;", quotedTable, strings.Join(quotedColumns, ", "), overriding, strings.Join(values, ", "))
+}
+
+// assignedColumns returns the columns an UPDATE sets, nil when an assignment sets several at once, e.g. (a, b) = (...)
+func assignedColumns(set []sqlToken) []string {
+	columns := []string{}
+	for _, assignment := range splitTopLevel(set) {
+		name, end := readQualifiedName(assignment, 0)
+		if name == "" || end >= len(assignment) || !assignment[end].isSymbol("=") {
+			return nil
+		}
+		parts := strings.Split(name, ".")
+		columns = append(columns, parts[len(parts)-1])
+	}
+	return columns
+}
+
+// recordValue returns the value of a column of a row with the column name as the row holds it, the name in the query may be
+// written in another case
+func recordValue(record map[string]interface{}, column string) (string, interface{}, bool) {
+	if value, ok := record[column]; ok {
+		return column, value, true
+	}
+	for name, value := range record {
+		if strings.EqualFold(name, column) {
+			return name, value, true
+		}
+	}
+	return "", nil, false
+}
+
+// primaryKeyColumns returns the columns of the primary key of a table, none when it has no primary key
+func primaryKeyColumns(ctx context.Context, conn *Connection, table string) ([]string, error) {
+	if conn.DB == nil {
+		return nil, fmt.Errorf("database connection is not available")
+	}
+
+	var keys []string
+	switch conn.Config.Type {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB:
+		err := conn.DB.WithContext(ctx).Raw(`SELECT a.attname FROM pg_index i
+			JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+			WHERE i.indrelid = CAST(? AS regclass) AND i.indisprimary`, quotePostgresTable(table)).Scan(&keys).Error
+		return keys, err
+	default:
+		schema, name := "", table
+		if index := strings.LastIndex(table, "."); index >= 0 {
+			schema, name = table[:index], table[index+1:]
+		}
+		err := conn.DB.WithContext(ctx).Raw(`SELECT COLUMN_NAME FROM information_schema.KEY_COLUMN_USAGE
+			WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND TABLE_NAME = ? AND CONSTRAINT_NAME = 'PRIMARY'
+			ORDER BY ORDINAL_POSITION`, schema, name).Scan(&keys).Error
+		return keys, err
+	}
+}
+
+// sqlLiteral writes a value of a result as a SQL literal, the strings are left for the database to cast to the column type
+func sqlLiteral(dbType string, value interface{}) string {
+	var text string
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case json.Number:
+		return v.String()
+	case float64:
+		return fmt.Sprint(v)
+	case string:
+		text = v
+	default:
+		// JSON columns
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return "NULL"
+		}
+		text = string(encoded)
+	}
+
+	text = strings.ReplaceAll(text, "'", "''")
+	if dbType != constants.DatabaseTypePostgreSQL && dbType != constants.DatabaseTypeYugabyteDB {
+		// MySQL reads backslashes in literals as escapes
+		text = strings.ReplaceAll(text, `\`, `\\`)
+	}
+	return "'" + text + "'"
+}
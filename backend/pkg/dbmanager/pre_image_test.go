@@ -0,0 +1,168 @@
+package dbmanager
+
+import (
+	"context"
+	"encoding/json"
+	"neobase-ai/internal/constants"
+	"testing"
+)
+
+func TestSQLLiteral(t *testing.T) {
+	tests := []struct {
+		name   string
+		dbType string
+		value  interface{}
+		want   string
+	}{
+		{"null", constants.DatabaseTypePostgreSQL, nil, "NULL"},
+		{"true", constants.DatabaseTypeMySQL, true, "TRUE"},
+		{"false", constants.DatabaseTypePostgreSQL, false, "FALSE"},
+		{"number", constants.DatabaseTypePostgreSQL, json.Number("12.50"), "12.50"},
+		{"float", constants.DatabaseTypeMySQL, float64(3), "3"},
+		{"string", constants.DatabaseTypePostgreSQL, "abc", "'abc'"},
+		{"quote", constants.DatabaseTypePostgreSQL, "O'Brien", "'O''Brien'"},
+		{"quote on mysql", constants.DatabaseTypeMySQL, "O'Brien", "'O''Brien'"},
+		{"backslash on postgres", constants.DatabaseTypePostgreSQL, `C:\temp`, `'C:\temp'`},
+		{"backslash on mysql", constants.DatabaseTypeMySQL, `C:\temp`, `'C:\\temp'`},
+		{"backslash before a quote on mysql", constants.DatabaseTypeMariaDB, `a\'b`, `'a\\''b'`},
+		{"json object", constants.DatabaseTypePostgreSQL, map[string]interface{}{"name": "O'Brien"}, `'{"name":"O''Brien"}'`},
+		{"json array on mysql", constants.DatabaseTypeMySQL, []interface{}{"a\\b", json.Number("1")}, `'["a\\\\b",1]'`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sqlLiteral(tt.dbType, tt.value); got != tt.want {
+				t.Errorf("sqlLiteral(%q, %#v) = %s, want %s", tt.dbType, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreImageRollbackOfDelete(t *testing.T) {
+	tests := []struct {
+		name   string
+		dbType string
+		query  string
+		rows   []interface{}
+		want   string
+	}{
+		{
+			name:   "postgres",
+			dbType: constants.DatabaseTypePostgreSQL,
+			query:  "DELETE FROM users WHERE id = 1",
+			rows:   []interface{}{map[string]interface{}{"id": json.Number("1"), "name": "O'Brien", "note": nil}},
+			want:   `INSERT INTO "users" ("id", "name", "note") OVERRIDING SYSTEM VALUE VALUES (1, 'O''Brien', NULL);`,
+		},
+		{
+			name:   "alias",
+			dbType: constants.DatabaseTypePostgreSQL,
+			query:  "DELETE FROM public.users AS u WHERE u.id = 1",
+			rows:   []interface{}{map[string]interface{}{"id": json.Number("1")}},
+			want:   `INSERT INTO "public"."users" ("id") OVERRIDING SYSTEM VALUE VALUES (1);`,
+		},
+		{
+			name:   "mysql",
+			dbType: constants.DatabaseTypeMySQL,
+			query:  "DELETE FROM shop.users WHERE id IN (1, 2)",
+			rows: []interface{}{
+				map[string]interface{}{"id": json.Number("1"), "path": `C:\tmp`},
+				map[string]interface{}{"id": json.Number("2"), "path": nil},
+			},
+			want: "INSERT INTO `shop`.`users` (`id`, `path`) VALUES (1, 'C:\\\\tmp'), (2, NULL);",
+		},
+		{
+			name:   "json column",
+			dbType: constants.DatabaseTypePostgreSQL,
+			query:  "DELETE FROM events",
+			rows:   []interface{}{map[string]interface{}{"id": json.Number("7"), "payload": map[string]interface{}{"quote": "it's"}}},
+			want:   `INSERT INTO "events" ("id", "payload") OVERRIDING SYSTEM VALUE VALUES (7, '{"quote":"it''s"}');`,
+		},
+		{
+			name:   "rows that are not records",
+			dbType: constants.DatabaseTypePostgreSQL,
+			query:  "DELETE FROM users",
+			rows:   []interface{}{"not a row"},
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			write, ok := parseSQLWrite(tt.dbType, tt.query)
+			if !ok {
+				t.Fatalf("parseSQLWrite(%q, %q) failed", tt.dbType, tt.query)
+			}
+			conn := &Connection{Config: ConnectionConfig{Type: tt.dbType}}
+			if got := preImageRollback(context.Background(), conn, write, tt.rows); got != tt.want {
+				t.Errorf("preImageRollback() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreImageUpdates(t *testing.T) {
+	dialect, _ := getBrowseDialect(constants.DatabaseTypePostgreSQL)
+	tests := []struct {
+		name    string
+		columns []string
+		keys    []string
+		records []map[string]interface{}
+		want    string
+	}{
+		{
+			name:    "one row",
+			columns: []string{"name"},
+			keys:    []string{"id"},
+			records: []map[string]interface{}{{"id": json.Number("1"), "name": "O'Brien"}},
+			want:    `UPDATE "users" SET "name" = 'O''Brien' WHERE "id" = 1;`,
+		},
+		{
+			name:    "column written in another case",
+			columns: []string{"NAME"},
+			keys:    []string{"ID"},
+			records: []map[string]interface{}{{"id": json.Number("1"), "name": nil}},
+			want:    `UPDATE "users" SET "name" = NULL WHERE "id" = 1;`,
+		},
+		{
+			name:    "composite key",
+			columns: []string{"qty"},
+			keys:    []string{"order_id", "line"},
+			records: []map[string]interface{}{
+				{"order_id": "A-1", "line": json.Number("1"), "qty": json.Number("3")},
+				{"order_id": "A-1", "line": json.Number("2"), "qty": json.Number("5")},
+			},
+			want: "UPDATE \"users\" SET \"qty\" = 3 WHERE \"order_id\" = 'A-1' AND \"line\" = 1;\n" +
+				"UPDATE \"users\" SET \"qty\" = 5 WHERE \"order_id\" = 'A-1' AND \"line\" = 2;",
+		},
+		{
+			name:    "null key",
+			columns: []string{"name"},
+			keys:    []string{"id"},
+			records: []map[string]interface{}{{"id": nil, "name": "a"}},
+			want:    "",
+		},
+		{
+			name:    "missing key",
+			columns: []string{"name"},
+			keys:    []string{"id"},
+			records: []map[string]interface{}{{"name": "a"}},
+			want:    "",
+		},
+		{
+			name:    "missing column",
+			columns: []string{"email"},
+			keys:    []string{"id"},
+			records: []map[string]interface{}{{"id": json.Number("1")}},
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := preImageUpdates(constants.DatabaseTypePostgreSQL, dialect, `"users"`, tt.columns, tt.keys, tt.records)
+			if got != tt.want {
+				t.Errorf("preImageUpdates() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
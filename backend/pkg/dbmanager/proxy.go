@@ -0,0 +1,254 @@
+package dbmanager
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"neobase-ai/internal/constants"
+
+	"golang.org/x/net/proxy"
+)
+
+// Schemes of the outbound proxy of a connection
+const (
+	ProxySchemeSOCKS5  = "socks5"
+	ProxySchemeSOCKS5H = "socks5h" // Same as socks5, the host name is always resolved by the proxy
+	ProxySchemeHTTP    = "http"    // HTTP CONNECT tunnel
+	ProxySchemeHTTPS   = "https"   // HTTP CONNECT tunnel over TLS to the proxy
+)
+
+// proxyDialTimeout is the timeout to reach the proxy & open the tunnel when the caller has no deadline
+const proxyDialTimeout = 30 * time.Second
+
+// proxyDialFunc opens a connection to the database address through the proxy of the connection.
+// It implements pq.Dialer, pq.DialerContext & the mongo ContextDialer so it can be given to every driver.
+type proxyDialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// DialContext implements pq.DialerContext & options.ContextDialer
+func (f proxyDialFunc) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	return f(ctx, network, addr)
+}
+
+// Dial implements pq.Dialer
+func (f proxyDialFunc) Dial(network, addr string) (net.Conn, error) {
+	return f(context.Background(), network, addr)
+}
+
+// DialTimeout implements pq.Dialer
+func (f proxyDialFunc) DialTimeout(network, addr string, timeout time.Duration) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return f(ctx, network, addr)
+}
+
+// UsesProxy returns true when the connection is dialed through an outbound proxy
+func UsesProxy(config ConnectionConfig) bool {
+	return config.ProxyURL != nil && strings.TrimSpace(*config.ProxyURL) != ""
+}
+
+// ValidateProxy checks the proxy URL of the connection & that the database type can be dialed through it
+func ValidateProxy(config ConnectionConfig) error {
+	if !UsesProxy(config) {
+		return nil
+	}
+
+	switch config.Type {
+	case constants.DatabaseTypeDB2:
+		// The DB2 CLI driver opens its sockets itself
+		return fmt.Errorf("proxy connections are not supported for %s connections", config.Type)
+	}
+	if UsesUnixSocket(config) {
+		return fmt.Errorf("a proxy can not be used with a unix socket connection")
+	}
+
+	_, err := parseProxyURL(config)
+	return err
+}
+
+// RedactProxyURL hides the password of the proxy URL, e.g. to return it to the client
+func RedactProxyURL(proxyURL string) string {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return ""
+	}
+	return u.Redacted()
+}
+
+// parseProxyURL parses the proxy URL of the connection, the port defaults to the one of the scheme
+func parseProxyURL(config ConnectionConfig) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(*config.ProxyURL))
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %v", err)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	switch u.Scheme {
+	case ProxySchemeSOCKS5, ProxySchemeSOCKS5H, ProxySchemeHTTP, ProxySchemeHTTPS:
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q, use socks5, socks5h, http or https", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("proxy URL must contain a host")
+	}
+	if u.Path != "" && u.Path != "/" {
+		return nil, fmt.Errorf("proxy URL must not contain a path")
+	}
+
+	if u.Port() == "" {
+		port := "1080"
+		switch u.Scheme {
+		case ProxySchemeHTTP:
+			port = "80"
+		case ProxySchemeHTTPS:
+			port = "443"
+		}
+		u.Host = net.JoinHostPort(u.Hostname(), port)
+	}
+	return u, nil
+}
+
+// getProxyDialFunc returns the dialer going through the proxy of the connection, nil when the connection has no proxy
+func getProxyDialFunc(config ConnectionConfig) (proxyDialFunc, error) {
+	if !UsesProxy(config) {
+		return nil, nil
+	}
+	if err := ValidateProxy(config); err != nil {
+		return nil, err
+	}
+
+	proxyURL, err := parseProxyURL(config)
+	if err != nil {
+		return nil, err
+	}
+	forward := &net.Dialer{Timeout: proxyDialTimeout, KeepAlive: 30 * time.Second}
+
+	switch proxyURL.Scheme {
+	case ProxySchemeSOCKS5, ProxySchemeSOCKS5H:
+		var auth *proxy.Auth
+		if proxyURL.User != nil {
+			password, _ := proxyURL.User.Password()
+			auth = &proxy.Auth{User: proxyURL.User.Username(), Password: password}
+		}
+		dialer, err := proxy.SOCKS5("tcp", proxyURL.Host, auth, forward)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create SOCKS5 proxy dialer: %v", err)
+		}
+		contextDialer := dialer.(proxy.ContextDialer)
+		return func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := contextDialer.DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, fmt.Errorf("failed to connect through proxy %s: %v", proxyURL.Redacted(), err)
+			}
+			return conn, nil
+		}, nil
+	default:
+		return func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := dialHTTPConnect(ctx, proxyURL, forward, addr)
+			if err != nil {
+				return nil, fmt.Errorf("failed to connect through proxy %s: %v", proxyURL.Redacted(), err)
+			}
+			return conn, nil
+		}, nil
+	}
+}
+
+// dialHTTPConnect opens a tunnel to the address with the CONNECT method of an HTTP proxy
+func dialHTTPConnect(ctx context.Context, proxyURL *url.URL, forward *net.Dialer, addr string) (net.Conn, error) {
+	conn, err := forward.DialContext(ctx, "tcp", proxyURL.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	// The tunnel must be open before the deadline of the caller, the database driver sets its own afterwards
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(proxyDialTimeout)
+	}
+	conn.SetDeadline(deadline)
+
+	if proxyURL.Scheme == ProxySchemeHTTPS {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: proxyURL.Hostname(), MinVersion: tls.VersionTLS12})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy refused the tunnel: %s", resp.Status)
+	}
+
+	conn.SetDeadline(time.Time{})
+
+	// Servers such as MySQL speak first, their greeting may already be buffered with the proxy response
+	if reader.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, reader: reader}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn reads the bytes buffered while reading the proxy response before the ones of the connection
+type bufferedConn struct {
+	net.Conn
+	reader io.Reader
+}
+
+// Read implements net.Conn
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+// proxyHTTPClient returns an HTTP client sending its requests through the proxy of the connection,
+// for the databases reached with a REST API
+func proxyHTTPClient(config ConnectionConfig, timeout time.Duration) (*http.Client, error) {
+	client := &http.Client{Timeout: timeout}
+	if !UsesProxy(config) {
+		return client, nil
+	}
+	if err := ValidateProxy(config); err != nil {
+		return nil, err
+	}
+
+	proxyURL, err := parseProxyURL(config)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(proxyURL)
+	client.Transport = transport
+	return client, nil
+}
@@ -0,0 +1,191 @@
+package dbmanager
+
+import (
+	"fmt"
+	"neobase-ai/internal/constants"
+	"regexp"
+	"strings"
+)
+
+var (
+	mongoDropRegex = regexp.MustCompile(`\.(drop|dropDatabase)\s*\(`)
+	// deleteMany({}) & remove({}) delete every document of the collection, as without a filter
+	mongoUnfilteredDeleteRegex = regexp.MustCompile(`\.(deleteMany|remove)\s*\(\s*(?:\{\s*\}\s*)?[,)]`)
+)
+
+// DestructiveQueryReason returns why a query destroys data without a way back, empty when it doesn't.
+// Dropped objects & columns, truncated tables, deletes & updates without a filter are destructive, filtered ones are not.
+func DestructiveQueryReason(dbType, query string) string {
+	switch dbType {
+	case constants.DatabaseTypeMongoDB:
+		if match := mongoDropRegex.FindStringSubmatch(query); match != nil {
+			if match[1] == "dropDatabase" {
+				return "dropDatabase() removes the database for good"
+			}
+			return "drop() removes the collection for good"
+		}
+		if match := mongoUnfilteredDeleteRegex.FindStringSubmatch(query); match != nil {
+			return fmt.Sprintf("%s() without a filter deletes every document of the collection", match[1])
+		}
+		return ""
+	case constants.DatabaseTypeFirestore:
+		for _, stmt := range splitMySQLStatements(query) {
+			if strings.TrimSpace(stmt) == "" {
+				continue
+			}
+			operation, err := parseFirestoreQuery(stmt)
+			if err == nil && operation.action == "delete" && operation.documentPath == "" && len(operation.filters) == 0 {
+				return "delete() without a where() filter deletes every document of the collection"
+			}
+		}
+		return ""
+	}
+
+	foldCase := dbType == constants.DatabaseTypePostgreSQL || dbType == constants.DatabaseTypeYugabyteDB
+	for _, tokens := range splitSQLTokens(tokenizeSQL(query, foldCase)) {
+		if reason := destructiveSQLStatementReason(tokens); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+// destructiveSQLStatementReason returns why a statement is destructive, Cypher & CQL drops are caught as SQL ones.
+// The DELETEs & UPDATEs of the CTEs run along the statement, they are checked as statements of their own.
+func destructiveSQLStatementReason(tokens []sqlToken) string {
+	if len(tokens) == 0 || tokens[0].kind != sqlTokenIdent {
+		return ""
+	}
+
+	if tokens[0].isKeyword("WITH") {
+		i := 1
+		for i < len(tokens) && !tokens[i].isKeyword("SELECT", "INSERT", "UPDATE", "DELETE", "MERGE", "VALUES", "TABLE") {
+			// The body of a CTE is in parentheses after AS [NOT] [MATERIALIZED], its column list after its name
+			if !tokens[i].isSymbol("(") {
+				i++
+				continue
+			}
+			end := matchingParen(tokens, i)
+			if tokens[i-1].isKeyword("AS", "MATERIALIZED") {
+				if reason := destructiveSQLStatementReason(tokens[i+1 : end]); reason != "" {
+					return reason
+				}
+			}
+			i = end + 1
+		}
+		if i >= len(tokens) {
+			return ""
+		}
+		tokens = tokens[i:]
+	}
+
+	switch strings.ToUpper(tokens[0].text) {
+	case "DROP":
+		if len(tokens) > 1 {
+			return fmt.Sprintf("DROP %s removes the %s for good", strings.ToUpper(tokens[1].text), strings.ToLower(tokens[1].text))
+		}
+		return "DROP removes the object for good"
+	case "TRUNCATE":
+		return "TRUNCATE deletes every row of the table"
+	case "DELETE":
+		if !filteredSQLStatement(tokens) {
+			return "DELETE without a WHERE clause deletes every row of the table"
+		}
+	case "UPDATE":
+		if !filteredSQLStatement(tokens) {
+			return "UPDATE without a WHERE clause overwrites every row of the table"
+		}
+	case "ALTER":
+		// ALTER TABLE ... DROP removes a column, partition, constraint or index, MySQL's DROP <column> has no keyword
+		if i := findTopLevel(tokens, 1, "DROP"); i < len(tokens) {
+			target := "COLUMN"
+			if i+1 < len(tokens) && tokens[i+1].isKeyword("COLUMN", "PARTITION", "CONSTRAINT", "INDEX", "KEY", "PRIMARY", "FOREIGN", "CHECK", "DEFAULT") {
+				target = strings.ToUpper(tokens[i+1].text)
+			}
+			return fmt.Sprintf("ALTER ... DROP %s removes the %s for good", target, strings.ToLower(target))
+		}
+	}
+	return ""
+}
+
+// filteredSQLStatement returns true when a DELETE, UPDATE or SELECT has a top level WHERE clause that doesn't keep every row.
+// A WHERE of a subquery (USING (SELECT ... WHERE ...)) doesn't filter the statement.
+func filteredSQLStatement(tokens []sqlToken) bool {
+	whereAt := findTopLevel(tokens, 1, "WHERE")
+	if whereAt == len(tokens) {
+		return false
+	}
+	end := findTopLevel(tokens, whereAt+1, "RETURNING", "ORDER", "LIMIT", "GROUP", "HAVING", "UNION", "EXCEPT", "INTERSECT")
+	return !alwaysTrueCondition(tokens[whereAt+1 : end])
+}
+
+// alwaysTrueCondition returns true when a WHERE condition keeps every row: a constant truth (TRUE, 1, 1 = 1, 'a' = 'a'), an OR
+// with one, or an IN / EXISTS over a subquery without a filter of its own.
+func alwaysTrueCondition(condition []sqlToken) bool {
+	for len(condition) > 2 && condition[0].isSymbol("(") && matchingParen(condition, 0) == len(condition)-1 {
+		condition = condition[1 : len(condition)-1]
+	}
+	if len(condition) == 0 {
+		return false
+	}
+	if parts := splitTopLevelKeyword(condition, "OR"); len(parts) > 1 {
+		for _, part := range parts {
+			if alwaysTrueCondition(part) {
+				return true
+			}
+		}
+		return false
+	}
+	if parts := splitTopLevelKeyword(condition, "AND"); len(parts) > 1 {
+		for _, part := range parts {
+			if !alwaysTrueCondition(part) {
+				return false
+			}
+		}
+		return true
+	}
+
+	switch {
+	case len(condition) == 1:
+		return condition[0].isKeyword("TRUE") || (condition[0].kind == sqlTokenNumber && strings.Trim(condition[0].text, "0.") != "")
+	case len(condition) == 3 && condition[1].isSymbol("="):
+		return condition[0].kind != sqlTokenSymbol && condition[0].kind == condition[2].kind && condition[0].text == condition[2].text
+	}
+
+	// x IN (SELECT ...) & EXISTS (SELECT ...) keep every row the unfiltered subquery reads
+	for i := 0; i+2 < len(condition); i++ {
+		if !condition[i].isKeyword("IN", "EXISTS") || !condition[i+1].isSymbol("(") || !condition[i+2].isKeyword("SELECT") {
+			continue
+		}
+		if (i > 0 && condition[i-1].isKeyword("NOT")) || matchingParen(condition, i+1) != len(condition)-1 {
+			return false
+		}
+		subquery := condition[i+2 : len(condition)-1]
+		return !filteredSQLStatement(subquery) && findTopLevel(subquery, 1, "LIMIT", "FETCH") == len(subquery)
+	}
+	return false
+}
+
+// splitTopLevelKeyword splits tokens on a top level keyword, the ANDs of BETWEEN ... AND are kept in their part
+func splitTopLevelKeyword(tokens []sqlToken, keyword string) [][]sqlToken {
+	parts := [][]sqlToken{}
+	start, depth, between := 0, 0, false
+	for i, token := range tokens {
+		switch {
+		case token.isSymbol("("):
+			depth++
+		case token.isSymbol(")"):
+			depth--
+		case depth == 0 && token.isKeyword("BETWEEN"):
+			between = true
+		case depth == 0 && token.isKeyword(keyword):
+			if between && strings.EqualFold(keyword, "AND") {
+				between = false
+				continue
+			}
+			parts = append(parts, tokens[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, tokens[start:])
+}
@@ -0,0 +1,67 @@
+package dbmanager
+
+import (
+	"neobase-ai/internal/constants"
+	"testing"
+)
+
+func TestDestructiveQueryReason(t *testing.T) {
+	tests := []struct {
+		name        string
+		dbType      string
+		query       string
+		destructive bool
+	}{
+		{"select", constants.DatabaseTypePostgreSQL, "SELECT * FROM users", false},
+		{"drop table", constants.DatabaseTypePostgreSQL, "DROP TABLE users", true},
+		{"drop lowercase", constants.DatabaseTypePostgreSQL, "drop table users", true},
+		{"truncate mixed case", constants.DatabaseTypeMySQL, "TrUnCaTe orders", true},
+		{"delete without where", constants.DatabaseTypeMySQL, "DELETE FROM orders", true},
+		{"delete with where", constants.DatabaseTypeMySQL, "DELETE FROM orders WHERE id = 1", false},
+		{"delete with lowercase where", constants.DatabaseTypePostgreSQL, "delete from orders where id = 1", false},
+		{"where only in a string", constants.DatabaseTypePostgreSQL, "DELETE FROM notes WHERE_ = 'WHERE'", true},
+		{"where only in a comment", constants.DatabaseTypePostgreSQL, "DELETE FROM orders -- WHERE id = 1", true},
+		{"where only in a block comment", constants.DatabaseTypeMySQL, "DELETE FROM orders /* WHERE id = 1 */", true},
+		{"leading comment", constants.DatabaseTypePostgreSQL, "/* cleanup */ DROP TABLE users", true},
+		{"leading line comment", constants.DatabaseTypeMySQL, "-- cleanup\nTRUNCATE orders", true},
+		{"drop in a string", constants.DatabaseTypePostgreSQL, "SELECT 'DROP TABLE users'", false},
+		{"second statement", constants.DatabaseTypePostgreSQL, "SELECT 1; DROP TABLE users", true},
+		{"second statement after a filtered delete", constants.DatabaseTypeMySQL, "DELETE FROM a WHERE id = 1; DELETE FROM b", true},
+		{"semicolon in a string", constants.DatabaseTypePostgreSQL, "UPDATE notes SET body = 'a; DROP TABLE users' WHERE id = 1", false},
+		{"alter drop column", constants.DatabaseTypePostgreSQL, "ALTER TABLE users DROP COLUMN email", true},
+		{"alter drop constraint", constants.DatabaseTypePostgreSQL, "ALTER TABLE users DROP CONSTRAINT users_pkey", true},
+		{"alter drop index", constants.DatabaseTypeMySQL, "ALTER TABLE users DROP INDEX idx_email", true},
+		{"mysql alter drop without column", constants.DatabaseTypeMySQL, "ALTER TABLE users DROP email", true},
+		{"alter drop in a default", constants.DatabaseTypePostgreSQL, "ALTER TABLE users ADD COLUMN note TEXT DEFAULT 'DROP'", false},
+		{"where only in a subquery", constants.DatabaseTypePostgreSQL, "DELETE FROM t USING (SELECT id FROM u WHERE active) s", true},
+		{"in an unfiltered subquery", constants.DatabaseTypePostgreSQL, "DELETE FROM t WHERE id IN (SELECT id FROM u WHERE 1=1)", true},
+		{"in a filtered subquery", constants.DatabaseTypePostgreSQL, "DELETE FROM t WHERE id IN (SELECT id FROM u WHERE active)", false},
+		{"not in a subquery", constants.DatabaseTypePostgreSQL, "DELETE FROM t WHERE id NOT IN (SELECT id FROM u)", false},
+		{"always true where", constants.DatabaseTypeMySQL, "DELETE FROM orders WHERE 1 = 1", true},
+		{"always true or", constants.DatabaseTypeMySQL, "DELETE FROM orders WHERE id = 1 OR TRUE", true},
+		{"filtered and", constants.DatabaseTypeMySQL, "DELETE FROM orders WHERE 1 = 1 AND id = 1", false},
+		{"between", constants.DatabaseTypePostgreSQL, "DELETE FROM orders WHERE id BETWEEN 1 AND 5", false},
+		{"update without where", constants.DatabaseTypePostgreSQL, "UPDATE users SET active = false", true},
+		{"update with where", constants.DatabaseTypePostgreSQL, "UPDATE users SET active = false WHERE id = 1", false},
+		{"delete in a cte", constants.DatabaseTypePostgreSQL, "WITH x AS (DELETE FROM t RETURNING *) SELECT * FROM x", true},
+		{"update in a materialized cte", constants.DatabaseTypePostgreSQL, "WITH x (id) AS MATERIALIZED (UPDATE t SET a = 1 RETURNING id) SELECT * FROM x", true},
+		{"filtered delete in a cte", constants.DatabaseTypePostgreSQL, "WITH x AS (DELETE FROM t WHERE id = 1 RETURNING *) SELECT * FROM x", false},
+		{"cte before a delete", constants.DatabaseTypePostgreSQL, "WITH old AS (SELECT id FROM t WHERE created_at < now()) DELETE FROM t", true},
+		{"select cte", constants.DatabaseTypePostgreSQL, "WITH x AS (SELECT * FROM t) SELECT * FROM x", false},
+		{"alter add column", constants.DatabaseTypeMySQL, "ALTER TABLE users ADD COLUMN age INT", false},
+		{"mongo drop", constants.DatabaseTypeMongoDB, "db.users.drop()", true},
+		{"mongo drop database", constants.DatabaseTypeMongoDB, "db.dropDatabase()", true},
+		{"mongo delete many without filter", constants.DatabaseTypeMongoDB, "db.users.deleteMany({})", true},
+		{"mongo delete many with filter", constants.DatabaseTypeMongoDB, "db.users.deleteMany({status: 'inactive'})", false},
+		{"mongo find", constants.DatabaseTypeMongoDB, "db.users.find({})", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := DestructiveQueryReason(tt.dbType, tt.query)
+			if (reason != "") != tt.destructive {
+				t.Errorf("DestructiveQueryReason(%q, %q) = %q, want destructive %v", tt.dbType, tt.query, reason, tt.destructive)
+			}
+		})
+	}
+}
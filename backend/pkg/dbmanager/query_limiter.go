@@ -0,0 +1,214 @@
+package dbmanager
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// DefaultMaxConcurrentQueries is the number of queries a connection runs at once unless configured
+	DefaultMaxConcurrentQueries = 5
+	QueryQueuedEvent            = "query-queued" // SSE event sent when a query waits for a free slot of its connection
+)
+
+var maxConcurrentQueries = DefaultMaxConcurrentQueries
+
+// SetMaxConcurrentQueries sets the number of queries a connection runs at once when its configuration sets none, zero keeps the default
+func SetMaxConcurrentQueries(limit int) {
+	if limit <= 0 {
+		limit = DefaultMaxConcurrentQueries
+	}
+	maxConcurrentQueries = limit
+}
+
+// QueryQueueNotice tells the user where a query waiting for a free slot of its connection stands in the queue
+type QueryQueueNotice struct {
+	MessageID string `json:"message_id"`
+	QueryID   string `json:"query_id"`
+	Position  int    `json:"position"` // 1 for the next query to run, 0 once the query runs
+	Running   int    `json:"running"`  // Queries the connection is running
+	Limit     int    `json:"limit"`
+	Message   string `json:"message"`
+}
+
+// queryLimiter is a semaphore over the queries of a database, the queries past the limit wait in the order they came
+type queryLimiter struct {
+	mu      sync.Mutex
+	running int
+	queue   []*queuedQuery
+}
+
+type queuedQuery struct {
+	ready    chan struct{} // Closed once the query holds a slot
+	notify   func(position, running int)
+	position int
+}
+
+// acquire waits for a slot, notify is called with the position of the query whenever it changes while queued. The returned
+// function frees the slot.
+func (l *queryLimiter) acquire(ctx context.Context, limit int, notify func(position, running int)) (func(), error) {
+	l.mu.Lock()
+	if l.running < limit && len(l.queue) == 0 {
+		l.running++
+		l.mu.Unlock()
+		return l.release, nil
+	}
+	position := len(l.queue) + 1
+	waiter := &queuedQuery{ready: make(chan struct{}), notify: notify, position: position}
+	l.queue = append(l.queue, waiter)
+	running := l.running
+	l.mu.Unlock()
+	notify(position, running)
+
+	select {
+	case <-waiter.ready:
+		return l.release, nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		select {
+		case <-waiter.ready:
+			// The slot was handed over as the wait ended, it is passed on
+			l.mu.Unlock()
+			l.release()
+			return nil, ctx.Err()
+		default:
+		}
+		for i, queued := range l.queue {
+			if queued == waiter {
+				l.queue = append(l.queue[:i], l.queue[i+1:]...)
+				break
+			}
+		}
+		moved := l.reposition()
+		l.mu.Unlock()
+		l.notifyMoved(moved)
+		return nil, ctx.Err()
+	}
+}
+
+// release frees a slot & hands it to the first queued query
+func (l *queryLimiter) release() {
+	l.mu.Lock()
+	l.running--
+	if len(l.queue) > 0 {
+		next := l.queue[0]
+		l.queue = l.queue[1:]
+		l.running++
+		close(next.ready)
+	}
+	moved := l.reposition()
+	l.mu.Unlock()
+	l.notifyMoved(moved)
+}
+
+// reposition updates the positions of the queued queries & returns those that moved, called with the lock held
+func (l *queryLimiter) reposition() []*queuedQuery {
+	moved := []*queuedQuery{}
+	for i, queued := range l.queue {
+		if queued.position != i+1 {
+			queued.position = i + 1
+			moved = append(moved, queued)
+		}
+	}
+	return moved
+}
+
+func (l *queryLimiter) notifyMoved(moved []*queuedQuery) {
+	if len(moved) == 0 {
+		return
+	}
+	l.mu.Lock()
+	running := l.running
+	positions := make([]int, len(moved))
+	for i, queued := range moved {
+		positions[i] = queued.position
+	}
+	l.mu.Unlock()
+	for i, queued := range moved {
+		queued.notify(positions[i], running)
+	}
+}
+
+// connectionQueryLimit returns the number of queries the connection runs at once
+func connectionQueryLimit(conn *Connection) int {
+	if conn.Config.MaxConcurrentQueries != nil && *conn.Config.MaxConcurrentQueries > 0 {
+		return *conn.Config.MaxConcurrentQueries
+	}
+	return maxConcurrentQueries
+}
+
+// queryLimiterFor returns the limiter of the database of a connection, the chats sharing a connection pool share its limiter
+func (m *Manager) queryLimiterFor(conn *Connection) *queryLimiter {
+	key := conn.ConfigKey
+	if key == "" {
+		key = conn.ChatID
+	}
+	m.queryLimitersMu.Lock()
+	defer m.queryLimitersMu.Unlock()
+	limiter, ok := m.queryLimiters[key]
+	if !ok {
+		limiter = &queryLimiter{}
+		m.queryLimiters[key] = limiter
+	}
+	return limiter
+}
+
+// acquireQuerySlot waits until the connection runs fewer queries than its limit, for as long as the query could run. The
+// user is told on the stream of the execution where the query stands in the queue. The returned function frees the slot.
+func (m *Manager) acquireQuerySlot(ctx context.Context, conn *Connection, messageID, queryID, streamID string) (func(), *dtos.QueryError) {
+	limit := connectionQueryLimit(conn)
+	waitCtx, cancel := context.WithTimeout(ctx, QueryTimeout(ctx))
+	defer cancel()
+
+	var queued atomic.Bool
+	release, err := m.queryLimiterFor(conn).acquire(waitCtx, limit, func(position, running int) {
+		queued.Store(true)
+		log.Printf("DBManager -> acquireQuerySlot -> Query %s queued at position %d, %d of %d queries running", queryID, position, running, limit)
+		m.sendQueueNotice(conn, streamID, QueryQueueNotice{
+			MessageID: messageID,
+			QueryID:   queryID,
+			Position:  position,
+			Running:   running,
+			Limit:     limit,
+			Message:   fmt.Sprintf("The connection is running %d queries, the query is number %d in the queue", running, position),
+		})
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, &dtos.QueryError{
+				Code:    "QUERY_EXECUTION_CANCELLED",
+				Message: "query execution cancelled",
+				Details: "Query execution cancelled while waiting in the queue",
+			}
+		}
+		return nil, &dtos.QueryError{
+			Code:    "QUERY_QUEUE_TIMED_OUT",
+			Message: "query waited too long for the connection",
+			Details: fmt.Sprintf("The connection kept running %d queries at once for longer than the query timeout", limit),
+		}
+	}
+	if queued.Load() {
+		m.sendQueueNotice(conn, streamID, QueryQueueNotice{
+			MessageID: messageID,
+			QueryID:   queryID,
+			Limit:     limit,
+			Message:   "The query is running",
+		})
+	}
+	return release, nil
+}
+
+// sendQueueNotice tells the user on the stream of the execution where the query stands in the queue
+func (m *Manager) sendQueueNotice(conn *Connection, streamID string, notice QueryQueueNotice) {
+	if m.streamHandler == nil || streamID == "" {
+		return
+	}
+	m.streamHandler.HandleDBEvent(conn.UserID, conn.ChatID, streamID, dtos.StreamResponse{
+		Event: QueryQueuedEvent,
+		Data:  notice,
+	})
+}
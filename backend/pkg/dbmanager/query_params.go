@@ -0,0 +1,317 @@
+package dbmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/constants"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// placeholderStyle is how a driver numbers the positional parameters of a statement
+type placeholderStyle int
+
+const (
+	placeholderQuestionMark placeholderStyle = iota // ? for each value, MySQL, ClickHouse, DB2 & Databricks
+	placeholderDollar                               // $1, $2... reused by the repeated names, PostgreSQL & YugabyteDB
+)
+
+type queryParamsKey struct{}
+
+// queryPlaceholder is a named parameter of a query, start & end are its byte offsets
+type queryPlaceholder struct {
+	start int
+	end   int
+	name  string
+}
+
+// WithQueryParams binds the values to the named parameters (:name or {{name}}) of the queries executed with the context
+func WithQueryParams(ctx context.Context, params map[string]interface{}) context.Context {
+	return context.WithValue(ctx, queryParamsKey{}, params)
+}
+
+// queryParams returns the values bound with WithQueryParams, nil when none were
+func queryParams(ctx context.Context) map[string]interface{} {
+	params, _ := ctx.Value(queryParamsKey{}).(map[string]interface{})
+	if len(params) == 0 {
+		return nil
+	}
+	return params
+}
+
+// SupportsQueryParams returns true when the queries of the database type can have named parameters
+func SupportsQueryParams(dbType string) bool {
+	switch dbType {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB, constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB,
+		constants.DatabaseTypeSingleStore, constants.DatabaseTypeClickhouse, constants.DatabaseTypeDB2, constants.DatabaseTypeDatabricks,
+		constants.DatabaseTypeMongoDB:
+		return true
+	}
+	return false
+}
+
+// QueryParameterNames returns the names of the parameters of a query in their order of appearance, without duplicates
+func QueryParameterNames(dbType, query string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, placeholder := range findQueryPlaceholders(query, dbType != constants.DatabaseTypeMongoDB) {
+		if !seen[placeholder.name] {
+			seen[placeholder.name] = true
+			names = append(names, placeholder.name)
+		}
+	}
+	return names
+}
+
+// checkQueryParams checks every parameter of the query has a value the driver can bind, nothing is checked without values
+func checkQueryParams(ctx context.Context, dbType, query string) *dtos.QueryError {
+	params := queryParams(ctx)
+	if params == nil {
+		return nil
+	}
+	if !SupportsQueryParams(dbType) {
+		return &dtos.QueryError{
+			Code:    "QUERY_PARAMETERS_NOT_SUPPORTED",
+			Message: "the queries of this database can't have parameters",
+			Details: fmt.Sprintf("Named parameters are not supported for %s", dbType),
+		}
+	}
+
+	var missing []string
+	for _, name := range QueryParameterNames(dbType, query) {
+		value, ok := params[name]
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		if dbType == constants.DatabaseTypeMongoDB {
+			continue
+		}
+		switch value.(type) {
+		case nil, string, bool, float64, float32, int, int32, int64, json.Number:
+		default:
+			return &dtos.QueryError{
+				Code:    "INVALID_QUERY_PARAMETER",
+				Message: "invalid query parameter",
+				Details: fmt.Sprintf("The value of %s must be a string, a number, a boolean or null", name),
+			}
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return &dtos.QueryError{
+			Code:    "MISSING_QUERY_PARAMETERS",
+			Message: "values are missing for parameters of the query",
+			Details: "Missing values for: " + strings.Join(missing, ", "),
+		}
+	}
+	return nil
+}
+
+// prepareQueryParams checks the parameters of a query & substitutes the ones of a MongoDB query, the SQL drivers bind theirs to
+// each statement with bindQueryParams
+func prepareQueryParams(ctx context.Context, dbType, query string) (string, *dtos.QueryError) {
+	if queryErr := checkQueryParams(ctx, dbType, query); queryErr != nil {
+		return "", queryErr
+	}
+	if dbType == constants.DatabaseTypeMongoDB {
+		return substituteMongoDBQueryParams(ctx, query)
+	}
+	return query, nil
+}
+
+// bindQueryParams replaces the named parameters of a statement with the positional ones of the driver & returns the values to
+// bind to them, so the values are sent apart from the statement. The statement is unchanged without values in the context.
+func bindQueryParams(ctx context.Context, stmt string, style placeholderStyle) (string, []interface{}) {
+	params := queryParams(ctx)
+	if params == nil {
+		return stmt, nil
+	}
+	placeholders := findQueryPlaceholders(stmt, true)
+	if len(placeholders) == 0 {
+		return stmt, nil
+	}
+
+	var builder strings.Builder
+	var args []interface{}
+	positions := make(map[string]int)
+	last := 0
+	for _, placeholder := range placeholders {
+		builder.WriteString(stmt[last:placeholder.start])
+		last = placeholder.end
+
+		switch style {
+		case placeholderDollar:
+			position, ok := positions[placeholder.name]
+			if !ok {
+				args = append(args, normalizeQueryParam(params[placeholder.name]))
+				position = len(args)
+				positions[placeholder.name] = position
+			}
+			builder.WriteString("$" + strconv.Itoa(position))
+		default:
+			args = append(args, normalizeQueryParam(params[placeholder.name]))
+			builder.WriteString("?")
+		}
+	}
+	builder.WriteString(stmt[last:])
+	return builder.String(), args
+}
+
+// substituteMongoDBQueryParams replaces the {{name}} parameters of a MongoDB query with their values encoded as JSON, the
+// shell syntax of the queries has no binding so a value is always a single literal & can't change the query around it
+func substituteMongoDBQueryParams(ctx context.Context, query string) (string, *dtos.QueryError) {
+	params := queryParams(ctx)
+	if params == nil {
+		return query, nil
+	}
+	placeholders := findQueryPlaceholders(query, false)
+	if len(placeholders) == 0 {
+		return query, nil
+	}
+
+	var builder strings.Builder
+	last := 0
+	for _, placeholder := range placeholders {
+		literal, err := json.Marshal(params[placeholder.name])
+		if err != nil {
+			return "", &dtos.QueryError{
+				Code:    "INVALID_QUERY_PARAMETER",
+				Message: "invalid query parameter",
+				Details: fmt.Sprintf("The value of %s can't be encoded: %v", placeholder.name, err),
+			}
+		}
+		builder.WriteString(query[last:placeholder.start])
+		builder.Write(literal)
+		last = placeholder.end
+	}
+	builder.WriteString(query[last:])
+	return builder.String(), nil
+}
+
+// normalizeQueryParam binds the whole numbers decoded from JSON as integers, the drivers would send 42 as 42.0 otherwise
+func normalizeQueryParam(value interface{}) interface{} {
+	switch v := value.(type) {
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int64(v)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+	}
+	return value
+}
+
+// findQueryPlaceholders returns the {{name}} parameters of a query and its :name ones when colonSyntax is set, the ones in
+// literals, quoted identifiers & comments are skipped. A colon following a name or another colon is a cast (::int), a slice
+// or a ClickHouse parameter type ({name:String}) rather than a parameter.
+func findQueryPlaceholders(query string, colonSyntax bool) []queryPlaceholder {
+	var placeholders []queryPlaceholder
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			i = skipQuoted(query, i, c)
+		case c == '-' && i+1 < len(query) && query[i+1] == '-' && colonSyntax:
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(query) && query[i+1] == '*':
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				return placeholders
+			}
+			i += end + 4
+		case c == '$' && colonSyntax:
+			i = skipDollarQuoted(query, i)
+		case c == '{' && i+1 < len(query) && query[i+1] == '{':
+			end := strings.Index(query[i+2:], "}}")
+			if end < 0 {
+				return placeholders
+			}
+			name := strings.TrimSpace(query[i+2 : i+2+end])
+			if isParameterName(name) {
+				placeholders = append(placeholders, queryPlaceholder{start: i, end: i + end + 4, name: name})
+				i += end + 4
+			} else {
+				i += 2
+			}
+		case c == ':' && colonSyntax:
+			if i > 0 && (isIdentifierByte(query[i-1]) || query[i-1] == ':') {
+				i++
+				continue
+			}
+			end := i + 1
+			for end < len(query) && isIdentifierByte(query[end]) {
+				end++
+			}
+			if end > i+1 && isParameterName(query[i+1:end]) && (end == len(query) || query[end] != ':') {
+				placeholders = append(placeholders, queryPlaceholder{start: i, end: end, name: query[i+1 : end]})
+			}
+			i = end
+		default:
+			i++
+		}
+	}
+	return placeholders
+}
+
+// skipQuoted returns the offset following the literal or identifier starting at start, doubled & escaped quotes included
+func skipQuoted(query string, start int, quote byte) int {
+	for i := start + 1; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			i++
+		case quote:
+			if i+1 < len(query) && query[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(query)
+}
+
+// skipDollarQuoted returns the offset following the PostgreSQL $tag$ literal starting at start, or the next one when it is a $1
+// parameter rather than a literal
+func skipDollarQuoted(query string, start int) int {
+	end := start + 1
+	for end < len(query) && isIdentifierByte(query[end]) && !(query[end] >= '0' && query[end] <= '9' && end == start+1) {
+		end++
+	}
+	if end >= len(query) || query[end] != '$' {
+		return start + 1
+	}
+	tag := query[start : end+1]
+	closing := strings.Index(query[end+1:], tag)
+	if closing < 0 {
+		return len(query)
+	}
+	return end + 1 + closing + len(tag)
+}
+
+func isParameterName(name string) bool {
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isIdentifierByte(name[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isIdentifierByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
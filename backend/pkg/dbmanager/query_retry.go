@@ -0,0 +1,173 @@
+package dbmanager
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/constants"
+	"strings"
+	"time"
+)
+
+// QueryRetryPolicy is how the executions failing on a transient error are retried, each in a new transaction
+type QueryRetryPolicy struct {
+	MaxAttempts int           // Executions of a query at most, 1 to never retry
+	BaseDelay   time.Duration // Wait before the first retry, doubled for each of the next ones
+	MaxDelay    time.Duration // Bound of the wait between two attempts
+}
+
+// DefaultQueryRetryPolicy is used unless SetQueryRetryPolicy is called
+var DefaultQueryRetryPolicy = QueryRetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   200 * time.Millisecond,
+	MaxDelay:    2 * time.Second,
+}
+
+var queryRetryPolicy = DefaultQueryRetryPolicy
+
+// SetQueryRetryPolicy sets the retry policy of the executions, the settings left to zero keep their default
+func SetQueryRetryPolicy(policy QueryRetryPolicy) {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = DefaultQueryRetryPolicy.MaxAttempts
+	}
+	if policy.BaseDelay <= 0 {
+		policy.BaseDelay = DefaultQueryRetryPolicy.BaseDelay
+	}
+	if policy.MaxDelay <= 0 {
+		policy.MaxDelay = DefaultQueryRetryPolicy.MaxDelay
+	}
+	policy.MaxDelay = max(policy.MaxDelay, policy.BaseDelay)
+	queryRetryPolicy = policy
+}
+
+// delay returns the wait before the attempt following attempt, exponential with up to half of it added at random so the
+// transactions that deadlocked each other don't retry in lockstep
+func (p QueryRetryPolicy) delay(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, p.MaxDelay)
+	return delay + time.Duration(rand.Int63n(int64(delay)/2+1))
+}
+
+// rolledBackErrorPatterns are the deadlocks & serialization failures, the database rolled the failed attempt back, lowercased
+var rolledBackErrorPatterns = []string{
+	"deadlock",
+}
+
+// rolledBackErrorPatternsByType are the errors rolling the attempt back specific to a database type, lowercased
+var rolledBackErrorPatternsByType = map[string][]string{
+	constants.DatabaseTypePostgreSQL: {
+		"could not serialize access", // 40001, serialization failure
+	},
+	constants.DatabaseTypeMySQL: {
+		"error 1213", // ER_LOCK_DEADLOCK
+	},
+	constants.DatabaseTypeMongoDB: {
+		"transienttransactionerror",
+		"writeconflict",
+		"write conflict",
+	},
+	constants.DatabaseTypeDB2: {
+		"sqlcode=-911", // The transaction was rolled back on a deadlock or a timeout
+		"sqlcode=-913", // The statement was rolled back on a deadlock or a timeout
+	},
+}
+
+// transientErrorPatterns are the other errors an execution may not fail with a second time, lowercased. Nothing tells the
+// attempt changed nothing: the connection can drop once the statement is applied, and MySQL commits before each DDL statement.
+var transientErrorPatterns = []string{
+	"driver: bad connection",
+	"connection reset by peer",
+	"broken pipe",
+	"unexpected eof",
+}
+
+// transientErrorPatternsByType are the other transient errors specific to a database type, lowercased
+var transientErrorPatternsByType = map[string][]string{
+	constants.DatabaseTypeMySQL: {
+		"error 1205", // ER_LOCK_WAIT_TIMEOUT, only the statement is rolled back
+		"lock wait timeout exceeded",
+	},
+}
+
+// transactionalDatabases roll back a failed execution whole, the other databases only have their reads retried
+var transactionalDatabases = map[string]bool{
+	constants.DatabaseTypePostgreSQL:  true,
+	constants.DatabaseTypeYugabyteDB:  true,
+	constants.DatabaseTypeMySQL:       true,
+	constants.DatabaseTypeMariaDB:     true,
+	constants.DatabaseTypeSingleStore: true,
+	constants.DatabaseTypeDB2:         true,
+	constants.DatabaseTypeMongoDB:     true,
+}
+
+// IsTransientError returns true when a query failed on a deadlock, a serialization failure, a lock wait or a dropped connection,
+// an execution of it in a new transaction may succeed
+func IsTransientError(dbType string, queryErr *dtos.QueryError) bool {
+	return isRolledBackError(dbType, queryErr) ||
+		matchesErrorPatterns(queryErr, transientErrorPatterns, transientErrorPatternsByType[failoverPatternType(dbType)])
+}
+
+// isRolledBackError returns true when a query failed on a deadlock or a serialization failure, the database rolled it back
+func isRolledBackError(dbType string, queryErr *dtos.QueryError) bool {
+	return matchesErrorPatterns(queryErr, rolledBackErrorPatterns, rolledBackErrorPatternsByType[failoverPatternType(dbType)])
+}
+
+func matchesErrorPatterns(queryErr *dtos.QueryError, patternLists ...[]string) bool {
+	if queryErr == nil {
+		return false
+	}
+
+	text := strings.ToLower(queryErr.Message + " " + queryErr.Details)
+	for _, patterns := range patternLists {
+		for _, pattern := range patterns {
+			if strings.Contains(text, pattern) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// canRetryQuery returns true when the failed execution of a query changed nothing & can be executed again: a read failing
+// on any transient error, or a write the database rolled back on a deadlock or a serialization failure
+func canRetryQuery(dbType, query string, queryErr *dtos.QueryError) bool {
+	if IsReadOnlyQuery(dbType, query) {
+		return IsTransientError(dbType, queryErr)
+	}
+	return transactionalDatabases[dbType] && isRolledBackError(dbType, queryErr)
+}
+
+// withRetryAttempts reports in the details of the error the attempts the query failed, exhausted when the last one was the
+// maximum of the retry policy
+func withRetryAttempts(queryErr *dtos.QueryError, attempts int, exhausted bool) *dtos.QueryError {
+	if attempts <= 1 {
+		return queryErr
+	}
+	reported := *queryErr
+	if exhausted {
+		reported.Details = fmt.Sprintf("%s (failed on a transient error in all %d attempts, the maximum, each attempt was rolled back)", queryErr.Details, attempts)
+	} else {
+		reported.Details = fmt.Sprintf("%s (failed on attempt %d of %d, the previous attempts failed on transient errors & were rolled back)", queryErr.Details, attempts, queryRetryPolicy.MaxAttempts)
+	}
+	return &reported
+}
+
+// executionStoppedError returns the error of an execution whose context ended, on its timeout or a cancellation
+func executionStoppedError(execCtx context.Context) *dtos.QueryError {
+	if execCtx.Err() == context.DeadlineExceeded {
+		return &dtos.QueryError{
+			Code:    "QUERY_EXECUTION_TIMED_OUT",
+			Message: "query execution timed out",
+			Details: "Query execution timed out",
+		}
+	}
+	return &dtos.QueryError{
+		Code:    "QUERY_EXECUTION_CANCELLED",
+		Message: "query execution cancelled",
+		Details: "Query execution cancelled",
+	}
+}
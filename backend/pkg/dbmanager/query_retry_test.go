@@ -0,0 +1,42 @@
+package dbmanager
+
+import (
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/constants"
+	"testing"
+)
+
+func TestCanRetryQuery(t *testing.T) {
+	deadlock := &dtos.QueryError{Message: "query failed", Details: "Error 1213 (40001): Deadlock found when trying to get lock; try restarting transaction"}
+	serialization := &dtos.QueryError{Message: "query failed", Details: "ERROR: could not serialize access due to concurrent update (SQLSTATE 40001)"}
+	badConnection := &dtos.QueryError{Message: "query failed", Details: "driver: bad connection"}
+	lockWait := &dtos.QueryError{Message: "query failed", Details: "Error 1205 (HY000): Lock wait timeout exceeded; try restarting transaction"}
+	syntax := &dtos.QueryError{Message: "query failed", Details: "syntax error at or near \"SELEC\""}
+
+	tests := []struct {
+		name     string
+		dbType   string
+		query    string
+		queryErr *dtos.QueryError
+		want     bool
+	}{
+		{"write on a deadlock", constants.DatabaseTypeMySQL, "UPDATE users SET name = 'a' WHERE id = 1", deadlock, true},
+		{"write on a serialization failure", constants.DatabaseTypePostgreSQL, "UPDATE users SET name = 'a' WHERE id = 1", serialization, true},
+		{"write on a dropped connection", constants.DatabaseTypePostgreSQL, "UPDATE users SET name = 'a' WHERE id = 1", badConnection, false},
+		{"script with ddl on a dropped connection", constants.DatabaseTypeMySQL, "INSERT INTO a VALUES (1); ALTER TABLE a ADD b INT; INSERT INTO a VALUES (2)", badConnection, false},
+		{"write on a lock wait timeout", constants.DatabaseTypeMariaDB, "DELETE FROM users WHERE id = 1", lockWait, false},
+		{"write without transactions on a deadlock", constants.DatabaseTypeClickhouse, "INSERT INTO events VALUES (1)", deadlock, false},
+		{"read on a dropped connection", constants.DatabaseTypePostgreSQL, "SELECT * FROM users", badConnection, true},
+		{"read on a lock wait timeout", constants.DatabaseTypeMySQL, "SELECT * FROM users", lockWait, true},
+		{"read without transactions on a dropped connection", constants.DatabaseTypeClickhouse, "SELECT * FROM events", badConnection, true},
+		{"read on another error", constants.DatabaseTypePostgreSQL, "SELEC * FROM users", syntax, false},
+		{"no error", constants.DatabaseTypePostgreSQL, "SELECT 1", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canRetryQuery(tt.dbType, tt.query, tt.queryErr); got != tt.want {
+				t.Errorf("canRetryQuery(%q, %q) = %v, want %v", tt.dbType, tt.query, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package dbmanager
+
+import (
+	"context"
+	"fmt"
+	"neobase-ai/internal/constants"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultQueryTimeout is the time a query can run for when the context sets none, the background jobs allow longer ones
+const DefaultQueryTimeout = 1 * time.Minute
+
+// mysqlSelectRegex matches the SELECT keyword starting a statement, the optimizer hints follow it
+var mysqlSelectRegex = regexp.MustCompile(`(?i)^\s*SELECT\b`)
+
+type queryTimeoutKey struct{}
+
+// WithQueryTimeout makes the queries executed with the context run for up to timeout instead of DefaultQueryTimeout
+func WithQueryTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, queryTimeoutKey{}, timeout)
+}
+
+// HasQueryTimeout returns true when WithQueryTimeout set the timeout of the queries executed with the context
+func HasQueryTimeout(ctx context.Context) bool {
+	timeout, ok := ctx.Value(queryTimeoutKey{}).(time.Duration)
+	return ok && timeout > 0
+}
+
+// QueryTimeout returns the time the queries executed with the context can run for
+func QueryTimeout(ctx context.Context) time.Duration {
+	if timeout, ok := ctx.Value(queryTimeoutKey{}).(time.Duration); ok && timeout > 0 {
+		return timeout
+	}
+	return DefaultQueryTimeout
+}
+
+// remainingQueryTime returns the time left before the deadline of the context, false when it has none
+func remainingQueryTime(ctx context.Context) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	remaining := time.Until(deadline)
+	if remaining < time.Millisecond {
+		remaining = time.Millisecond
+	}
+	return remaining, true
+}
+
+// withStatementTimeout sets the time left before the deadline of the context as the server-side timeout of the query, so the
+// database stops the query the client gave up on. PostgreSQL sets it for the transaction, MySQL for a single SELECT through an
+// optimizer hint & MariaDB for a single write, its SELECTs must start with the keyword. ClickHouse reads the deadline of the
+// context itself & MongoDB sets it on the find & aggregate options.
+func withStatementTimeout(ctx context.Context, dbType, query string, inTransaction bool) string {
+	remaining, ok := remainingQueryTime(ctx)
+	if !ok {
+		return query
+	}
+	milliseconds := remaining.Milliseconds()
+
+	switch dbType {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB:
+		// SET LOCAL has no effect outside a transaction, the replicas are read without one
+		if !inTransaction {
+			return query
+		}
+		return fmt.Sprintf("SET LOCAL statement_timeout = %d; %s", milliseconds, query)
+	case constants.DatabaseTypeMySQL:
+		if !isSingleMySQLStatement(query) || !mysqlSelectRegex.MatchString(query) || strings.Contains(strings.ToUpper(query), "MAX_EXECUTION_TIME") {
+			return query
+		}
+		keyword := mysqlSelectRegex.FindString(query)
+		return fmt.Sprintf("%s /*+ MAX_EXECUTION_TIME(%d) */%s", keyword, milliseconds, query[len(keyword):])
+	case constants.DatabaseTypeMariaDB:
+		upperQuery := strings.ToUpper(strings.TrimSpace(query))
+		if !isSingleMySQLStatement(query) || strings.HasPrefix(upperQuery, "SELECT") || strings.HasPrefix(upperQuery, "SHOW") ||
+			strings.HasPrefix(upperQuery, "DESCRIBE") || strings.HasPrefix(upperQuery, "SET") {
+			return query
+		}
+		return fmt.Sprintf("SET STATEMENT max_statement_time = %.3f FOR %s", remaining.Seconds(), strings.TrimSpace(query))
+	}
+	return query
+}
+
+// isSingleMySQLStatement returns true when the query holds a single statement
+func isSingleMySQLStatement(query string) bool {
+	count := 0
+	for _, statement := range splitMySQLStatements(query) {
+		if strings.TrimSpace(statement) != "" {
+			count++
+		}
+	}
+	return count == 1
+}
@@ -0,0 +1,196 @@
+package dbmanager
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"neobase-ai/internal/constants"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// MaxReadReplicas caps the read replicas of a connection
+const MaxReadReplicas = 5
+
+// ReadReplica is a read-only copy of the primary database, it is reached with the credentials & settings of the primary
+type ReadReplica struct {
+	Host string  `json:"host"`
+	Port *string `json:"port,omitempty"` // The port of the primary is used when empty
+}
+
+// readReplicaTypes are the database types whose reads can be routed to replicas
+var readReplicaTypes = map[string]bool{
+	constants.DatabaseTypePostgreSQL:  true,
+	constants.DatabaseTypeYugabyteDB:  true,
+	constants.DatabaseTypeMySQL:       true,
+	constants.DatabaseTypeMariaDB:     true,
+	constants.DatabaseTypeSingleStore: true,
+	constants.DatabaseTypeClickhouse:  true,
+	constants.DatabaseTypeMongoDB:     true,
+}
+
+// readOnlySQLStatements are the statements served by a replica, every statement of a query must be one of them
+var readOnlySQLStatements = map[string]bool{
+	"SELECT": true, "WITH": true, "SHOW": true, "EXPLAIN": true, "DESCRIBE": true, "DESC": true, "VALUES": true, "TABLE": true, "EXISTS": true,
+}
+
+// writeSQLKeywords make a read statement write, e.g. data modifying CTEs, SELECT ... INTO, locking reads or sequence calls
+var writeSQLKeywords = map[string]bool{
+	"INSERT": true, "UPDATE": true, "DELETE": true, "MERGE": true, "UPSERT": true, "INTO": true, "NEXTVAL": true, "SETVAL": true,
+}
+
+var (
+	mongoReadMethodRegex = regexp.MustCompile(`^db\.(?:getCollection\([^)]*\)|[^(]+)\.(find|findOne|aggregate|countDocuments|count|distinct|estimatedDocumentCount)\s*\(`)
+	// $out & $merge stages write the aggregation result to a collection
+	mongoWriteStageRegex = regexp.MustCompile(`["']?\$(out|merge)["']?\s*:`)
+)
+
+type primaryRoutingKey struct{}
+
+// WithPrimaryRouting makes the queries executed with the context go to the primary even when they only read, e.g. to read rows just written
+func WithPrimaryRouting(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryRoutingKey{}, true)
+}
+
+func primaryRoutingRequested(ctx context.Context) bool {
+	usePrimary, _ := ctx.Value(primaryRoutingKey{}).(bool)
+	return usePrimary
+}
+
+// ValidateReadReplicas checks the read replicas can be used by the connection
+func ValidateReadReplicas(config ConnectionConfig) error {
+	if len(config.ReadReplicas) == 0 {
+		return nil
+	}
+	if !readReplicaTypes[config.Type] {
+		return fmt.Errorf("read replicas are not supported for %s connections", config.Type)
+	}
+	if len(config.ReadReplicas) > MaxReadReplicas {
+		return fmt.Errorf("at most %d read replicas can be configured", MaxReadReplicas)
+	}
+	for i, replica := range config.ReadReplicas {
+		if strings.TrimSpace(replica.Host) == "" {
+			return fmt.Errorf("read replica %d: host is required", i+1)
+		}
+		if replica.Port != nil && *replica.Port != "" {
+			if _, err := strconv.Atoi(*replica.Port); err != nil {
+				return fmt.Errorf("read replica %d: invalid port %s", i+1, *replica.Port)
+			}
+		}
+	}
+	return nil
+}
+
+// readReplicaConfig returns the configuration of a replica, everything but the endpoint is taken from the primary
+func readReplicaConfig(config ConnectionConfig, replica ReadReplica) ConnectionConfig {
+	replicaConfig := config
+	replicaConfig.Host = replica.Host
+	if replica.Port != nil && *replica.Port != "" {
+		replicaConfig.Port = replica.Port
+	}
+	replicaConfig.SocketPath = nil
+	replicaConfig.ReadReplicas = nil
+	replicaConfig.isReadReplica = true
+	return replicaConfig
+}
+
+// testReadReplicas tests the connection to every replica of a configuration
+func (m *Manager) testReadReplicas(config *ConnectionConfig) error {
+	if err := ValidateReadReplicas(*config); err != nil {
+		return err
+	}
+	for i, replica := range config.ReadReplicas {
+		replicaConfig := readReplicaConfig(*config, replica)
+		if err := m.TestConnection(&replicaConfig); err != nil {
+			return fmt.Errorf("read replica %d (%s): %v", i+1, replica.Host, err)
+		}
+	}
+	return nil
+}
+
+// connectReadReplicas opens the replica connections of a primary connection.
+// Replicas failing to connect are skipped, their reads go to the primary.
+func (m *Manager) connectReadReplicas(driver DatabaseDriver, conn *Connection) {
+	if err := ValidateReadReplicas(conn.Config); err != nil {
+		log.Printf("DBManager -> connectReadReplicas -> Read replicas ignored: %v", err)
+		return
+	}
+
+	for _, replica := range conn.Config.ReadReplicas {
+		replicaConn, err := driver.Connect(readReplicaConfig(conn.Config, replica))
+		if err != nil {
+			log.Printf("DBManager -> connectReadReplicas -> Failed to connect to read replica %s for chatID %s: %v", replica.Host, conn.ChatID, err)
+			continue
+		}
+		replicaConn.Status = StatusConnected
+		replicaConn.ChatID = conn.ChatID
+		replicaConn.UserID = conn.UserID
+		conn.Replicas = append(conn.Replicas, replicaConn)
+		log.Printf("DBManager -> connectReadReplicas -> Connected to read replica %s for chatID %s", replica.Host, conn.ChatID)
+	}
+}
+
+// disconnectReadReplicas closes the replica connections of a primary connection
+func (m *Manager) disconnectReadReplicas(driver DatabaseDriver, conn *Connection) {
+	for _, replicaConn := range conn.Replicas {
+		if err := driver.Disconnect(replicaConn); err != nil {
+			log.Printf("DBManager -> disconnectReadReplicas -> Failed to disconnect read replica %s for chatID %s: %v", replicaConn.Config.Host, conn.ChatID, err)
+		}
+	}
+	conn.Replicas = nil
+}
+
+// routeQuery returns the connection a query is executed on, read-only queries go to the replicas in turn
+func (m *Manager) routeQuery(ctx context.Context, conn *Connection, query string) *Connection {
+	if len(conn.Replicas) == 0 || primaryRoutingRequested(ctx) || !IsReadOnlyQuery(conn.Config.Type, query) {
+		return conn
+	}
+	index := atomic.AddUint32(&conn.replicaCursor, 1)
+	return conn.Replicas[int(index-1)%len(conn.Replicas)]
+}
+
+// IsReadOnlyQuery returns true when a query only reads, queries that can't be classified are taken as writes
+func IsReadOnlyQuery(dbType, query string) bool {
+	if dbType == constants.DatabaseTypeMongoDB {
+		query = strings.TrimSpace(query)
+		return mongoReadMethodRegex.MatchString(query) && !mongoWriteStageRegex.MatchString(query)
+	}
+
+	foldCase := dbType == constants.DatabaseTypePostgreSQL || dbType == constants.DatabaseTypeYugabyteDB
+	statements := splitSQLTokens(tokenizeSQL(query, foldCase))
+	if len(statements) == 0 {
+		return false
+	}
+	for _, tokens := range statements {
+		if len(tokens) == 0 || tokens[0].kind != sqlTokenIdent || !readOnlySQLStatements[strings.ToUpper(tokens[0].text)] {
+			return false
+		}
+		for _, token := range tokens {
+			if token.kind == sqlTokenIdent && writeSQLKeywords[strings.ToUpper(token.text)] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// readReplicaTx runs a read on a replica, reads need no transaction & replicas of some databases (e.g. MongoDB secondaries) can't start one
+// It isn't a serverCanceler, the read runs on a pooled connection of the replica whose session isn't known, so a cancelled read
+// relies on the driver stopping it when its context is cancelled.
+type readReplicaTx struct {
+	driver DatabaseDriver
+}
+
+func (tx *readReplicaTx) ExecuteQuery(ctx context.Context, conn *Connection, query string, queryType string, findCount bool) *QueryExecutionResult {
+	return tx.driver.ExecuteQuery(ctx, conn, query, queryType, findCount)
+}
+
+func (tx *readReplicaTx) Commit() error {
+	return nil
+}
+
+func (tx *readReplicaTx) Rollback() error {
+	return nil
+}
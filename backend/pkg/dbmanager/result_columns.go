@@ -0,0 +1,188 @@
+package dbmanager
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"neobase-ai/internal/apis/dtos"
+	"reflect"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm"
+)
+
+// sqlResultColumns returns the columns of rows in their order with the types the driver reports, the nullability is only set when
+// the driver knows it
+func sqlResultColumns(rows *sql.Rows) []dtos.ResultColumn {
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil
+	}
+
+	columns := make([]dtos.ResultColumn, len(columnTypes))
+	for i, columnType := range columnTypes {
+		columns[i] = dtos.ResultColumn{
+			Name:         columnType.Name(),
+			DatabaseType: columnType.DatabaseTypeName(),
+		}
+		if nullable, ok := columnType.Nullable(); ok {
+			columns[i].Nullable = &nullable
+		}
+	}
+	return columns
+}
+
+// queryResultRows reads the rows of a raw statement through GORM like Scan into maps does, along the columns of the rows
+func queryResultRows(ctx context.Context, db *gorm.DB, stmt string, args ...interface{}) ([]map[string]interface{}, []dtos.ResultColumn, error) {
+	rows, err := db.WithContext(ctx).Raw(stmt, args...).Rows()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	columns := sqlResultColumns(rows)
+	results := []map[string]interface{}{}
+	for rows.Next() {
+		row := map[string]interface{}{}
+		if err := db.ScanRows(rows, &row); err != nil {
+			return nil, nil, err
+		}
+		results = append(results, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return results, columns, nil
+}
+
+// describeResultColumns sets the columns of a result its driver didn't describe, they are inferred from the rows of the result
+func describeResultColumns(result *QueryExecutionResult) {
+	if result == nil || result.Error != nil || len(result.Columns) > 0 || result.Result == nil {
+		return
+	}
+	if rows, ok := result.Result["results"]; ok {
+		result.Columns = inferResultColumns(rows)
+	}
+}
+
+// inferResultColumns describes the columns of rows without a schema, documents mostly: a column is a field of any of the rows & is
+// nullable when a row lacks it or holds null. The fields keep the order of ordered documents, else "_id" comes first & the others
+// by name. Returns nil when the rows are not documents.
+func inferResultColumns(rows interface{}) []dtos.ResultColumn {
+	value := reflect.ValueOf(rows)
+	if !value.IsValid() || value.Kind() != reflect.Slice && value.Kind() != reflect.Array || value.Len() == 0 {
+		return nil
+	}
+
+	type inferredColumn struct {
+		types    map[string]bool
+		rows     int // The rows holding the field, null or not
+		hasNulls bool
+	}
+	inferred := map[string]*inferredColumn{}
+	names := []string{}
+	ordered := true
+	add := func(name string, fieldValue interface{}) {
+		column, ok := inferred[name]
+		if !ok {
+			column = &inferredColumn{types: map[string]bool{}}
+			inferred[name] = column
+			names = append(names, name)
+		}
+		column.rows++
+		if isNullValue(fieldValue) {
+			column.hasNulls = true
+			return
+		}
+		column.types[documentValueType(fieldValue)] = true
+	}
+
+	for i := 0; i < value.Len(); i++ {
+		switch row := value.Index(i).Interface().(type) {
+		case primitive.D:
+			for _, element := range row {
+				add(element.Key, element.Value)
+			}
+		case primitive.M:
+			ordered = false
+			for name, fieldValue := range row {
+				add(name, fieldValue)
+			}
+		case map[string]interface{}:
+			ordered = false
+			for name, fieldValue := range row {
+				add(name, fieldValue)
+			}
+		default:
+			return nil
+		}
+	}
+
+	if !ordered {
+		sort.Slice(names, func(i, j int) bool {
+			if (names[i] == "_id") != (names[j] == "_id") {
+				return names[i] == "_id"
+			}
+			return names[i] < names[j]
+		})
+	}
+
+	columns := make([]dtos.ResultColumn, len(names))
+	for i, name := range names {
+		column := inferred[name]
+		databaseType := "null"
+		switch len(column.types) {
+		case 0:
+		case 1:
+			for valueType := range column.types {
+				databaseType = valueType
+			}
+		default:
+			databaseType = "mixed"
+		}
+		nullable := column.hasNulls || column.rows < value.Len()
+		columns[i] = dtos.ResultColumn{Name: name, DatabaseType: databaseType, Nullable: &nullable}
+	}
+	return columns
+}
+
+// documentValueType returns the BSON type name of a field value, as $type names it
+func documentValueType(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case int, int8, int16, int32, uint8, uint16:
+		return "int"
+	case int64, uint32, uint64:
+		return "long"
+	case float32, float64:
+		return "double"
+	case primitive.Decimal128:
+		return "decimal"
+	case primitive.ObjectID:
+		return "objectId"
+	case primitive.DateTime, time.Time:
+		return "date"
+	case primitive.Timestamp:
+		return "timestamp"
+	case primitive.Binary, []byte:
+		return "binData"
+	case primitive.Regex:
+		return "regex"
+	case primitive.D, primitive.M, map[string]interface{}:
+		return "object"
+	case primitive.A, []interface{}:
+		return "array"
+	default:
+		if kind := reflect.ValueOf(v).Kind(); kind == reflect.Slice || kind == reflect.Array {
+			return "array"
+		} else if kind == reflect.Map || kind == reflect.Struct {
+			return "object"
+		}
+		return fmt.Sprintf("%T", v)
+	}
+}
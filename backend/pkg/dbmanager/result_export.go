@@ -0,0 +1,433 @@
+package dbmanager
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/constants"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// exportTimeout bounds an export, the rows are written as they are read so it mostly depends on the speed of the client
+	exportTimeout = 30 * time.Minute
+	// exportFlushRows is the number of rows buffered before they are sent to the client
+	exportFlushRows = 1000
+)
+
+// Only the finds & aggregates of MongoDB return their documents through a cursor, the other reads return a single value
+var mongoExportMethodRegex = regexp.MustCompile(`^db\.(?:getCollection\([^)]*\)|[^(]+)\.(find|aggregate)\s*\(`)
+
+// Layouts of the dates & timestamps drivers return as text, e.g. MySQL without parseTime
+var textTimeLayouts = []string{"2006-01-02 15:04:05.999999999", "2006-01-02T15:04:05.999999999Z07:00", "2006-01-02"}
+
+// errExportRowLimit stops reading a MongoDB cursor once the row limit of an export is reached
+var errExportRowLimit = errors.New("row limit reached")
+
+// exportFormat is how the values of a database type are written as text
+type exportFormat struct {
+	binaryPrefix    string // prefix of the hexadecimal binary values, as the database prints them
+	timestampLayout string // layout of the timestamps, the dates are always YYYY-MM-DD
+	extendedJSON    bool   // the documents & arrays are written as relaxed extended JSON
+}
+
+// getExportFormat returns the format of a database type, false if the export doesn't support it
+func getExportFormat(dbType string) (exportFormat, bool) {
+	switch dbType {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB:
+		return exportFormat{binaryPrefix: `\x`, timestampLayout: time.RFC3339Nano}, true
+	case constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB, constants.DatabaseTypeSingleStore, constants.DatabaseTypeClickhouse:
+		// DATETIME values have no time zone
+		return exportFormat{binaryPrefix: "0x", timestampLayout: "2006-01-02 15:04:05.999999"}, true
+	case constants.DatabaseTypeDB2:
+		return exportFormat{binaryPrefix: "0x", timestampLayout: "2006-01-02-15.04.05.999999"}, true
+	case constants.DatabaseTypeMongoDB:
+		return exportFormat{timestampLayout: time.RFC3339Nano, extendedJSON: true}, true
+	}
+	return exportFormat{}, false
+}
+
+// exportColumn is a column of an exported result
+type exportColumn struct {
+	name         string
+	databaseType string // type name reported by the driver, e.g. DATE or NUMERIC, empty for MongoDB
+}
+
+// columnKind classifies the type of a column, the type names vary with the database & the size
+type columnKind int
+
+const (
+	kindOther columnKind = iota
+	kindBoolean
+	kindInteger
+	kindUnsigned // may not fit a signed 64 bits integer, e.g. UInt64 of ClickHouse
+	kindFloat
+	kindDecimal
+	kindDate
+	kindTimestamp
+	kindBinary
+)
+
+// kind returns the kind of the column from its type name, the MongoDB columns have no type
+func (c exportColumn) kind() columnKind {
+	databaseType := strings.ToUpper(c.databaseType)
+	// ClickHouse wraps the types, e.g. Nullable(Int32)
+	for _, wrapper := range []string{"NULLABLE(", "LOWCARDINALITY("} {
+		databaseType = strings.TrimPrefix(databaseType, wrapper)
+	}
+
+	hasPrefix := func(prefixes ...string) bool {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(databaseType, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case databaseType == "", hasPrefix("INTERVAL"):
+		return kindOther
+	case hasPrefix("BOOL"):
+		return kindBoolean
+	case hasPrefix("UINT"):
+		return kindUnsigned
+	case hasPrefix("INT", "TINYINT", "SMALLINT", "MEDIUMINT", "BIGINT"):
+		return kindInteger
+	case hasPrefix("FLOAT", "DOUBLE", "REAL"):
+		return kindFloat
+	case hasPrefix("DECIMAL", "NUMERIC", "DECFLOAT"):
+		return kindDecimal
+	case hasPrefix("DATETIME", "TIMESTAMP"):
+		return kindTimestamp
+	case hasPrefix("DATE"):
+		return kindDate
+	case hasPrefix("BYTEA", "BINARY", "VARBINARY"), strings.Contains(databaseType, "BLOB"):
+		return kindBinary
+	}
+	return kindOther
+}
+
+// resultWriter writes the rows of an exported result in a file format, writeHeader is called before the first row.
+// A MongoDB result without documents has no header.
+type resultWriter interface {
+	writeHeader(columns []exportColumn, format exportFormat) error
+	writeRow(values []interface{}) error
+	flush() error // sends the buffered rows to the client
+}
+
+// exportSummary describes the rows read by an export
+type exportSummary struct {
+	conn      *Connection // the connection the rows were read from, a read replica or the primary
+	onReplica bool
+	rows      int64
+	truncated bool // the rows beyond the limit of the export were left out
+}
+
+// ExportQueryCSV writes the whole result of a read-only query to w as CSV, the first line holds the column names.
+// The rows are written as they are read from the connection, none of the result limits apply. Returns the number of rows written.
+// An error after the first row was written leaves the CSV truncated.
+func (m *Manager) ExportQueryCSV(ctx context.Context, chatID, query string, w io.Writer) (int64, *dtos.QueryError) {
+	summary, queryErr := m.exportQuery(ctx, chatID, query, 0, &csvResultWriter{csv: csv.NewWriter(w)})
+	return summary.rows, queryErr
+}
+
+// exportQuery reads the result of a read-only query from the chat's connection into writer, up to maxRows rows unless 0
+func (m *Manager) exportQuery(ctx context.Context, chatID, query string, maxRows int64, writer resultWriter) (exportSummary, *dtos.QueryError) {
+	conn, driver, queryErr := m.groundingConnection(chatID)
+	if queryErr != nil {
+		return exportSummary{}, queryErr
+	}
+	dbType := conn.Config.Type
+	query = strings.TrimSpace(query)
+
+	format, supported := getExportFormat(dbType)
+	if !supported {
+		return exportSummary{}, &dtos.QueryError{
+			Code:    "EXPORT_NOT_SUPPORTED",
+			Message: "the results can't be exported",
+			Details: "Exporting results is not supported for " + dbType,
+		}
+	}
+	if !IsReadOnlyQuery(dbType, query) {
+		return exportSummary{}, &dtos.QueryError{
+			Code:    "EXPORT_REQUIRES_READ_ONLY_QUERY",
+			Message: "only read-only queries can be exported",
+			Details: "The query would modify the database",
+		}
+	}
+	if dbType == constants.DatabaseTypeMongoDB && !mongoExportMethodRegex.MatchString(query) {
+		return exportSummary{}, &dtos.QueryError{
+			Code:    "EXPORT_NOT_SUPPORTED",
+			Message: "the results can't be exported",
+			Details: "Only the results of find & aggregate can be exported",
+		}
+	}
+	if err := CheckServerFeatures(conn.Dialect(), conn.ServerVersion, query); err != nil {
+		return exportSummary{}, &dtos.QueryError{
+			Code:    "UNSUPPORTED_BY_SERVER_VERSION",
+			Message: "query uses a feature the server version does not support",
+			Details: err.Error(),
+		}
+	}
+	query, paramsErr := prepareQueryParams(ctx, dbType, query)
+	if paramsErr != nil {
+		return exportSummary{}, paramsErr
+	}
+	// Exports hold a slot of the connection like the executions, they wait in its queue without notices
+	release, queueErr := m.acquireQuerySlot(ctx, conn, "", "", "")
+	if queueErr != nil {
+		return exportSummary{}, queueErr
+	}
+	defer release()
+
+	exportCtx, cancel := context.WithTimeout(ctx, exportTimeout)
+	defer cancel()
+
+	m.UpdateLastUsed(chatID)
+	summary := exportSummary{conn: m.routeQuery(ctx, conn, query)}
+	summary.onReplica = summary.conn != conn
+
+	log.Printf("DBManager -> exportQuery -> Exporting the result of the query for chatID: %s", chatID)
+	var err error
+	if dbType == constants.DatabaseTypeMongoDB {
+		err = exportMongoDBResult(exportCtx, driver, query, format, maxRows, writer, &summary)
+	} else {
+		err = exportSQLResult(exportCtx, query, format, maxRows, writer, &summary)
+	}
+	if err == nil {
+		err = writer.flush()
+	}
+	if err != nil {
+		log.Printf("DBManager -> exportQuery -> Export failed after %d rows: %v", summary.rows, err)
+		if errors.Is(exportCtx.Err(), context.DeadlineExceeded) {
+			return summary, &dtos.QueryError{
+				Code:    "QUERY_TIMEOUT",
+				Message: "the export took too long",
+				Details: fmt.Sprintf("The export was stopped after %s", exportTimeout),
+			}
+		}
+		return summary, &dtos.QueryError{
+			Code:    "EXPORT_FAILED",
+			Message: "failed to export the results",
+			Details: err.Error(),
+		}
+	}
+
+	log.Printf("DBManager -> exportQuery -> Exported %d rows for chatID: %s (truncated: %t)", summary.rows, chatID, summary.truncated)
+	return summary, nil
+}
+
+// exportSQLResult streams the rows of a single SQL statement
+func exportSQLResult(ctx context.Context, query string, format exportFormat, maxRows int64, writer resultWriter, summary *exportSummary) error {
+	statements := splitStatements(query)
+	if len(statements) != 1 {
+		return fmt.Errorf("only a single statement can be exported, got %d", len(statements))
+	}
+
+	// Closing the rows reads the ones left unless the query is cancelled first
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	style := placeholderQuestionMark
+	if dbType := summary.conn.Config.Type; dbType == constants.DatabaseTypePostgreSQL || dbType == constants.DatabaseTypeYugabyteDB {
+		style = placeholderDollar
+	}
+	stmt, args := bindQueryParams(ctx, statements[0], style)
+	rows, err := summary.conn.DB.WithContext(ctx).Raw(stmt, args...).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return fmt.Errorf("failed to get columns: %v", err)
+	}
+	columns := make([]exportColumn, len(columnTypes))
+	for i, columnType := range columnTypes {
+		columns[i] = exportColumn{name: columnType.Name(), databaseType: columnType.DatabaseTypeName()}
+	}
+	if err := writer.writeHeader(columns, format); err != nil {
+		return err
+	}
+
+	values := make([]interface{}, len(columns))
+	scanArgs := make([]interface{}, len(columns))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if maxRows > 0 && summary.rows >= maxRows {
+			summary.truncated = true
+			cancel()
+			return nil
+		}
+		if err := rows.Scan(scanArgs...); err != nil {
+			return fmt.Errorf("failed to scan row: %v", err)
+		}
+		if err := writer.writeRow(values); err != nil {
+			return err
+		}
+		summary.rows++
+		if summary.rows%exportFlushRows == 0 {
+			if err := writer.flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return rows.Err()
+}
+
+// exportMongoDBResult streams the documents of a find or an aggregate. The columns are the fields of the first document,
+// the fields the next documents don't share with it are left out, unless the writer writes the documents whole.
+func exportMongoDBResult(ctx context.Context, driver DatabaseDriver, query string, format exportFormat, maxRows int64, writer resultWriter, summary *exportSummary) error {
+	var columns []exportColumn
+	sink := func(document bson.D) error {
+		if maxRows > 0 && summary.rows >= maxRows {
+			summary.truncated = true
+			return errExportRowLimit
+		}
+		if documents, ok := writer.(documentWriter); ok {
+			if err := documents.writeDocument(document); err != nil {
+				return err
+			}
+			summary.rows++
+			if summary.rows%exportFlushRows == 0 {
+				return writer.flush()
+			}
+			return nil
+		}
+
+		if columns == nil {
+			columns = make([]exportColumn, 0, len(document))
+			for _, field := range document {
+				columns = append(columns, exportColumn{name: field.Key})
+			}
+			if err := writer.writeHeader(columns, format); err != nil {
+				return err
+			}
+		}
+
+		fields := make(map[string]interface{}, len(document))
+		for _, field := range document {
+			fields[field.Key] = field.Value
+		}
+		values := make([]interface{}, len(columns))
+		for i, column := range columns {
+			values[i] = fields[column.name]
+		}
+		if err := writer.writeRow(values); err != nil {
+			return err
+		}
+		summary.rows++
+		if summary.rows%exportFlushRows == 0 {
+			return writer.flush()
+		}
+		return nil
+	}
+
+	execution := driver.ExecuteQuery(withMongoDocumentSink(ctx, sink), summary.conn, query, "SELECT", false)
+	if execution.Error != nil && !summary.truncated {
+		return errors.New(strings.TrimSpace(execution.Error.Message + " " + execution.Error.Details))
+	}
+	return nil
+}
+
+// text formats a value read from the database, NULL is an empty string
+func (f exportFormat) text(value interface{}, column exportColumn) string {
+	switch v := value.(type) {
+	case nil, primitive.Null, primitive.Undefined:
+		return ""
+	case []byte:
+		if utf8.Valid(v) {
+			return string(v)
+		}
+		return f.binaryPrefix + hex.EncodeToString(v)
+	case string:
+		return v
+	case time.Time:
+		if strings.EqualFold(column.databaseType, "DATE") {
+			return v.Format("2006-01-02")
+		}
+		return v.Format(f.timestampLayout)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case primitive.ObjectID:
+		return v.Hex()
+	case primitive.DateTime:
+		return v.Time().UTC().Format(f.timestampLayout)
+	case primitive.Decimal128:
+		return v.String()
+	}
+
+	if f.extendedJSON {
+		// Extended JSON is only marshalled from documents, the value is wrapped in one
+		if encoded, err := bson.MarshalExtJSON(bson.D{{Key: "v", Value: value}}, false, false); err == nil {
+			var wrapper struct {
+				V json.RawMessage `json:"v"`
+			}
+			if err := json.Unmarshal(encoded, &wrapper); err == nil {
+				return string(wrapper.V)
+			}
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+// parseTimeText parses a date or a timestamp a driver returned as text
+func parseTimeText(text string) (time.Time, bool) {
+	for _, layout := range textTimeLayouts {
+		if parsed, err := time.Parse(layout, text); err == nil {
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// csvResultWriter writes a result as CSV with proper quoting, every value is text
+type csvResultWriter struct {
+	csv     *csv.Writer
+	format  exportFormat
+	columns []exportColumn
+	record  []string
+}
+
+func (w *csvResultWriter) writeHeader(columns []exportColumn, format exportFormat) error {
+	w.columns = columns
+	w.format = format
+	w.record = make([]string, len(columns))
+	for i, column := range columns {
+		w.record[i] = column.name
+	}
+	return w.csv.Write(w.record)
+}
+
+func (w *csvResultWriter) writeRow(values []interface{}) error {
+	for i, value := range values {
+		w.record[i] = w.format.text(value, w.columns[i])
+	}
+	return w.csv.Write(w.record)
+}
+
+func (w *csvResultWriter) flush() error {
+	w.csv.Flush()
+	return w.csv.Error()
+}
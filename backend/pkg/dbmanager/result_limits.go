@@ -0,0 +1,101 @@
+package dbmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"neobase-ai/internal/constants"
+	"reflect"
+	"regexp"
+	"strconv"
+)
+
+const (
+	// DefaultResultDisplayRows is the number of rows of a result shown after an execution & read per page, unless configured
+	DefaultResultDisplayRows = 50
+	// DefaultMaxResultRows is the number of rows read from a result at most, unless configured
+	DefaultMaxResultRows = 10000
+)
+
+// pageLimitNumber matches the page size in the OFFSET & LIMIT clauses matched by keysetOffsetClause, offset_size is a word
+var pageLimitNumber = regexp.MustCompile(`\d+`)
+
+// mongoPageLimit matches the limit of a paginated MongoDB query, e.g. .skip(offset_size).limit(50)
+var mongoPageLimit = regexp.MustCompile(`\.limit\(\s*\d+\s*\)`)
+
+type resultRowLimitKey struct{}
+
+// WithResultRowLimit makes the queries executed with the context return up to maxRows rows, the rows beyond are left out &
+// the result is marked as truncated. The PostgreSQL & MongoDB drivers stop reading at the limit, the others drop the rows past it.
+func WithResultRowLimit(ctx context.Context, maxRows int) context.Context {
+	return context.WithValue(ctx, resultRowLimitKey{}, maxRows)
+}
+
+// resultRowLimit returns the rows the queries executed with the context can return, 0 without a limit
+func resultRowLimit(ctx context.Context) int {
+	if maxRows, ok := ctx.Value(resultRowLimitKey{}).(int); ok && maxRows > 0 {
+		return maxRows
+	}
+	return 0
+}
+
+// finishQueryResult applies the row limits to the result of an executed query & describes its columns, whether it ran in its own
+// transaction, in the transaction of the chat or was replayed after a failover. The row read past the auto limit is left out & the
+// rows are capped.
+func finishQueryResult(ctx context.Context, dbType string, result *QueryExecutionResult, limit int, findCount bool) {
+	markAutoLimited(result, limit)
+	// The MongoDB cursors stop at the row limit themselves
+	if !findCount && dbType != constants.DatabaseTypeMongoDB {
+		capResultRows(ctx, result)
+	}
+	if !findCount {
+		describeResultColumns(result)
+	}
+}
+
+// capResultRows leaves the rows of a result beyond the limit of the context out & reports it along the rows, like the MongoDB
+// cursors do for their limits
+func capResultRows(ctx context.Context, result *QueryExecutionResult) {
+	maxRows := resultRowLimit(ctx)
+	if maxRows == 0 || result == nil || result.Result == nil {
+		return
+	}
+	rows := reflect.ValueOf(result.Result["results"])
+	if rows.Kind() != reflect.Slice || rows.Len() <= maxRows {
+		return
+	}
+
+	log.Printf("DBManager -> capResultRows -> Leaving %d rows out of the result, limited to %d rows", rows.Len()-maxRows, maxRows)
+	result.Result["results"] = rows.Slice(0, maxRows).Interface()
+	result.Result["truncated"] = true
+	result.Result["truncatedReason"] = fmt.Sprintf("the result was limited to %d rows, refine the filter or add a limit to get the rest", maxRows)
+	if resultJSON, err := json.Marshal(result.Result); err == nil {
+		result.ResultJSON = string(resultJSON)
+	}
+}
+
+// WithPageSize returns the paginated query reading pages of pageSize rows instead of the LIMIT it was written with. ok is false
+// when the query doesn't end with its OFFSET & LIMIT the way the prompts ask for, its pages keep their size then.
+func WithPageSize(dbType, paginatedQuery string, pageSize int) (string, bool) {
+	if pageSize <= 0 {
+		return paginatedQuery, false
+	}
+	size := strconv.Itoa(pageSize)
+
+	if dbType == constants.DatabaseTypeMongoDB {
+		matches := mongoPageLimit.FindAllStringIndex(paginatedQuery, -1)
+		if len(matches) == 0 {
+			return paginatedQuery, false
+		}
+		last := matches[len(matches)-1]
+		return paginatedQuery[:last[0]] + ".limit(" + size + ")" + paginatedQuery[last[1]:], true
+	}
+
+	loc := keysetOffsetClause.FindStringIndex(paginatedQuery)
+	if loc == nil {
+		return paginatedQuery, false
+	}
+	clause := pageLimitNumber.ReplaceAllString(paginatedQuery[loc[0]:loc[1]], size)
+	return paginatedQuery[:loc[0]] + clause + paginatedQuery[loc[1]:], true
+}
@@ -0,0 +1,123 @@
+package dbmanager
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Columns described per table in its embedding text, wide tables are cut
+const maxEmbeddedColumns = 100
+
+// GetStoredSchema returns the schema of a chat stored at its last fetch, without querying the database
+func (sm *SchemaManager) GetStoredSchema(ctx context.Context, chatID string) (*SchemaStorage, error) {
+	storage, err := sm.getStoredSchema(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+	if storage == nil || storage.LLMSchema == nil {
+		return nil, fmt.Errorf("no stored schema for chat ID: %s", chatID)
+	}
+	return storage, nil
+}
+
+// FormatSchemaTablesForLLM formats the schema like FormatSchemaForLLMWithExamples, with only the given tables.
+// Views, sequences & enums are kept as they are small and shared by the tables.
+func (sm *SchemaManager) FormatSchemaTablesForLLM(storage *SchemaStorage, tableNames []string) string {
+	subset := &SchemaStorage{
+		FullSchema: storage.FullSchema,
+		LLMSchema: &LLMSchemaInfo{
+			Tables: make(map[string]LLMTableInfo, len(tableNames)),
+		},
+		TableChecksums: storage.TableChecksums,
+		UpdatedAt:      storage.UpdatedAt,
+	}
+	if subset.FullSchema == nil {
+		subset.FullSchema = &SchemaInfo{}
+	}
+
+	included := make(map[string]bool, len(tableNames))
+	for _, tableName := range tableNames {
+		if table, ok := storage.LLMSchema.Tables[tableName]; ok {
+			subset.LLMSchema.Tables[tableName] = table
+			included[tableName] = true
+		}
+	}
+	for _, relationship := range storage.LLMSchema.Relationships {
+		if included[relationship.FromTable] && included[relationship.ToTable] {
+			subset.LLMSchema.Relationships = append(subset.LLMSchema.Relationships, relationship)
+		}
+	}
+
+	return sm.FormatSchemaForLLMWithExamples(subset)
+}
+
+// TableEmbeddingText describes a table for its embedding: its name, description, columns & the tables it relates to.
+// Example records are left out, their values would make tables with similar data closer than tables with similar meaning.
+func TableEmbeddingText(storage *SchemaStorage, tableName string) string {
+	table, ok := storage.LLMSchema.Tables[tableName]
+	if !ok {
+		return ""
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Table: %s\n", tableName))
+	if table.Description != "" {
+		result.WriteString(fmt.Sprintf("Description: %s\n", table.Description))
+	}
+
+	result.WriteString("Columns:\n")
+	for i, column := range table.Columns {
+		if i == maxEmbeddedColumns {
+			result.WriteString(fmt.Sprintf("  ... %d more columns\n", len(table.Columns)-maxEmbeddedColumns))
+			break
+		}
+		result.WriteString(fmt.Sprintf("  - %s (%s)", column.Name, column.Type))
+		if column.Description != "" {
+			result.WriteString(fmt.Sprintf(": %s", column.Description))
+		}
+		result.WriteString("\n")
+	}
+
+	if related := RelatedTables(storage, []string{tableName}); len(related) > 0 {
+		result.WriteString(fmt.Sprintf("Related tables: %s\n", strings.Join(related, ", ")))
+	}
+	return result.String()
+}
+
+// RelatedTables returns the tables referencing or referenced by the given tables, excluding them, sorted by name
+func RelatedTables(storage *SchemaStorage, tableNames []string) []string {
+	given := make(map[string]bool, len(tableNames))
+	for _, tableName := range tableNames {
+		given[tableName] = true
+	}
+
+	related := make(map[string]bool)
+	addRelation := func(from, to string) {
+		if given[from] && !given[to] && to != "" {
+			if _, exists := storage.LLMSchema.Tables[to]; exists {
+				related[to] = true
+			}
+		}
+	}
+	for _, relationship := range storage.LLMSchema.Relationships {
+		addRelation(relationship.FromTable, relationship.ToTable)
+		addRelation(relationship.ToTable, relationship.FromTable)
+	}
+	if storage.FullSchema != nil {
+		for tableName, table := range storage.FullSchema.Tables {
+			for _, foreignKey := range table.ForeignKeys {
+				addRelation(tableName, foreignKey.RefTable)
+				addRelation(foreignKey.RefTable, tableName)
+			}
+		}
+	}
+
+	relatedTables := make([]string, 0, len(related))
+	for tableName := range related {
+		relatedTables = append(relatedTables, tableName)
+	}
+	sort.Strings(relatedTables)
+	return relatedTables
+}
@@ -0,0 +1,359 @@
+package dbmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/constants"
+	"strings"
+	"time"
+)
+
+// ScriptRollbackQueryKey is the key of the rollback plan of a whole script in its result, only set when every statement of the
+// script can be undone
+const ScriptRollbackQueryKey = "scriptRollbackQuery"
+
+// scriptTransactionKeywords control the transaction themselves, a query using them runs whole as it was written
+var scriptTransactionKeywords = map[string]bool{
+	"BEGIN": true, "START": true, "COMMIT": true, "ROLLBACK": true, "SAVEPOINT": true, "RELEASE": true, "END": true,
+}
+
+// scriptStatements returns the statements of a SQL query holding more than one, nil when the query is a single statement or
+// must run whole, e.g. it manages its own transaction or holds PostgreSQL dollar quoted bodies the tokenizer can't read
+func scriptStatements(dbType, query string) []string {
+	switch dbType {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB, constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB,
+		constants.DatabaseTypeSingleStore, constants.DatabaseTypeClickhouse, constants.DatabaseTypeDB2, constants.DatabaseTypeDatabricks:
+	default:
+		return nil
+	}
+
+	foldCase := dbType == constants.DatabaseTypePostgreSQL || dbType == constants.DatabaseTypeYugabyteDB
+	if foldCase && strings.Contains(query, "$") {
+		for i := 0; i < len(query); i++ {
+			if query[i] == '$' && skipDollarQuoted(query, i) != i+1 {
+				return nil
+			}
+		}
+	}
+
+	tokenStatements := splitSQLTokens(tokenizeSQL(query, foldCase))
+	if len(tokenStatements) < 2 {
+		return nil
+	}
+	statements := make([]string, 0, len(tokenStatements))
+	for _, tokens := range tokenStatements {
+		if tokens[0].kind == sqlTokenIdent && scriptTransactionKeywords[strings.ToUpper(tokens[0].text)] {
+			return nil
+		}
+		statements = append(statements, joinSQLTokens(tokens))
+	}
+	return statements
+}
+
+// executeScript runs the statements of a script one after the other in the transaction, the first failing statement stops the
+// script & its error names it so the transaction is rolled back whole. prefix holds the statements the query was prepared with
+// (audit & timeout), they run along the first statement. The result is the one of the last statement, with the results of every
+// statement & the rollback plan of the script.
+func executeScript(ctx context.Context, tx Transaction, conn *Connection, prefix string, statements []string, queryType string) *QueryExecutionResult {
+	startTime := time.Now()
+	statementResults := make([]map[string]interface{}, 0, len(statements))
+	var last *QueryExecutionResult
+
+	for i, statement := range statements {
+		statementQuery := statement
+		if i == 0 {
+			statementQuery = prefix + statement
+		}
+		log.Printf("DBManager -> executeScript -> Executing statement %d of %d", i+1, len(statements))
+		result := tx.ExecuteQuery(ctx, conn, statementQuery, queryType, false)
+		if result == nil {
+			result = &QueryExecutionResult{Error: &dtos.QueryError{Code: "QUERY_EXECUTION_FAILED", Message: "query execution failed"}}
+		}
+		if result.Error != nil {
+			log.Printf("DBManager -> executeScript -> Statement %d of %d failed: %v", i+1, len(statements), result.Error.Message)
+			return &QueryExecutionResult{
+				ExecutionTime: int(time.Since(startTime).Milliseconds()),
+				Error: &dtos.QueryError{
+					Code:    result.Error.Code,
+					Message: fmt.Sprintf("statement %d of %d failed, the script was rolled back: %s", i+1, len(statements), result.Error.Message),
+					Details: fmt.Sprintf("%s\n\nFailed statement: %s", result.Error.Details, statement),
+				},
+			}
+		}
+		capResultRows(ctx, result)
+
+		entry := map[string]interface{}{
+			"statement":     statement,
+			"executionTime": result.ExecutionTime,
+			"result":        result.Result,
+		}
+		statementResults = append(statementResults, entry)
+		last = result
+	}
+
+	plan, complete := scriptRollbackPlan(conn.Config.Type, statements)
+	for i, step := range plan {
+		if step != "" {
+			statementResults[i]["rollbackQuery"] = step
+		}
+	}
+
+	combined := map[string]interface{}{}
+	for key, value := range last.Result {
+		combined[key] = value
+	}
+	combined["statements"] = statementResults
+	if complete {
+		combined[ScriptRollbackQueryKey] = combineRollbackPlan(plan)
+	}
+
+	resultJSON, err := json.Marshal(combined)
+	if err != nil {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Code:    "JSON_ERROR",
+				Message: "Failed to marshal script results",
+				Details: err.Error(),
+			},
+		}
+	}
+	return &QueryExecutionResult{
+		Result:        combined,
+		ResultJSON:    string(resultJSON),
+		ExecutionTime: int(time.Since(startTime).Milliseconds()),
+		Columns:       last.Columns, // The rows of the script are the ones of its last statement
+	}
+}
+
+// ScriptExecutedRollback returns the rollback plan reported by the result of an executed script, false when the script didn't
+// report one
+func ScriptExecutedRollback(result map[string]interface{}) (string, bool) {
+	rollbackQuery, ok := result[ScriptRollbackQueryKey].(string)
+	return rollbackQuery, ok && rollbackQuery != ""
+}
+
+// combineRollbackPlan undoes the statements in the reverse order they ran
+func combineRollbackPlan(plan []string) string {
+	steps := []string{}
+	for i := len(plan) - 1; i >= 0; i-- {
+		if plan[i] != "" {
+			steps = append(steps, plan[i]+";")
+		}
+	}
+	return strings.Join(steps, "\n")
+}
+
+// scriptRollbackPlan returns the statement undoing each statement of a script, empty for the statements needing none: the reads &
+// the changes to the tables the script created, dropping them undoes those. complete is false when a statement can't be undone.
+func scriptRollbackPlan(dbType string, statements []string) ([]string, bool) {
+	foldCase := dbType == constants.DatabaseTypePostgreSQL || dbType == constants.DatabaseTypeYugabyteDB
+	plan := make([]string, len(statements))
+	created := map[string]int{} // tables the script created, by name, to the statement creating them
+	complete := true
+
+	for i, statement := range statements {
+		if IsReadOnlyQuery(dbType, statement) {
+			continue
+		}
+		tokens := tokenizeSQL(statement, foldCase)
+		switch {
+		case tokens[0].isKeyword("CREATE"):
+			step, table := createRollback(dbType, tokens)
+			if step == "" {
+				complete = false
+				continue
+			}
+			if table != "" {
+				if _, ok := created[table]; ok {
+					// The index of a created table goes with it
+					continue
+				}
+				if strings.HasPrefix(step, "DROP TABLE") {
+					created[table] = i
+				}
+			}
+			plan[i] = step
+
+		case tokens[0].isKeyword("DROP"):
+			// Dropping a table the script created undoes its creation already
+			table := droppedTable(tokens)
+			if j, ok := created[table]; ok && table != "" {
+				plan[j] = ""
+				delete(created, table)
+				continue
+			}
+			complete = false
+
+		case tokens[0].isKeyword("ALTER"):
+			step, table, renamed := alterRollback(tokens)
+			if j, ok := created[table]; ok && table != "" {
+				if renamed != "" {
+					delete(created, table)
+					created[renamed] = j
+					plan[j] = "DROP TABLE " + renamedName(tokens)
+				}
+				continue
+			}
+			if step == "" {
+				complete = false
+				continue
+			}
+			plan[i] = step
+
+		case tokens[0].isKeyword("INSERT", "UPDATE", "DELETE", "REPLACE", "TRUNCATE", "MERGE"):
+			tables := ExtractModifiedTables(dbType, statement)
+			if len(tables) == 0 {
+				complete = false
+			}
+			for _, table := range tables {
+				if _, ok := created[normalizeScriptName(table, foldCase)]; !ok {
+					complete = false
+				}
+			}
+
+		default:
+			complete = false
+		}
+	}
+	return plan, complete
+}
+
+// createRollback returns the statement undoing a CREATE statement & the name of the table it creates or indexes, an empty
+// statement when it can't be undone, e.g. it may have replaced or kept an object that existed before
+func createRollback(dbType string, tokens []sqlToken) (string, string) {
+	i := 1
+	if i < len(tokens) && tokens[i].isKeyword("OR") {
+		return "", ""
+	}
+	i = skipKeywords(tokens, i, "GLOBAL", "LOCAL", "TEMP", "TEMPORARY", "UNLOGGED", "UNIQUE", "MATERIALIZED")
+	if i >= len(tokens) {
+		return "", ""
+	}
+	kind := strings.ToUpper(tokens[i].text)
+	objectKind := kind
+	if kind == "VIEW" && tokens[i-1].isKeyword("MATERIALIZED") {
+		objectKind = "MATERIALIZED VIEW"
+	}
+	i++
+	if i < len(tokens) && tokens[i].isKeyword("CONCURRENTLY") {
+		i++
+	}
+	if i < len(tokens) && tokens[i].isKeyword("IF") {
+		return "", ""
+	}
+
+	switch kind {
+	case "TABLE", "VIEW", "SCHEMA", "SEQUENCE":
+		name, end := readQualifiedName(tokens, i)
+		if name == "" {
+			return "", ""
+		}
+		if kind != "TABLE" {
+			return "DROP " + objectKind + " " + joinSQLTokens(tokens[i:end]), ""
+		}
+		return "DROP TABLE " + joinSQLTokens(tokens[i:end]), name
+
+	case "INDEX":
+		name, end := readQualifiedName(tokens, i)
+		if name == "" || end >= len(tokens) || !tokens[end].isKeyword("ON") {
+			return "", ""
+		}
+		indexName := joinSQLTokens(tokens[i:end])
+		table, tableEnd := readQualifiedName(tokens, end+1)
+		if table == "" {
+			return "", ""
+		}
+		tableName := joinSQLTokens(tokens[end+1 : tableEnd])
+		switch dbType {
+		case constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB, constants.DatabaseTypeSingleStore:
+			return "DROP INDEX " + indexName + " ON " + tableName, table
+		case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB, constants.DatabaseTypeDB2:
+			// The index is created in the schema of its table
+			if !strings.Contains(name, ".") && strings.Contains(table, ".") {
+				schemaEnd := end + 1
+				for schemaEnd < tableEnd && !tokens[schemaEnd].isSymbol(".") {
+					schemaEnd++
+				}
+				indexName = joinSQLTokens(tokens[end+1:schemaEnd]) + "." + indexName
+			}
+			return "DROP INDEX " + indexName, table
+		}
+	}
+	return "", ""
+}
+
+// alterRollback returns the statement undoing an ALTER TABLE adding a single column or renaming the table, the table it alters &
+// its new name when renamed
+func alterRollback(tokens []sqlToken) (string, string, string) {
+	if len(tokens) < 4 || !tokens[1].isKeyword("TABLE") {
+		return "", "", ""
+	}
+	i := 2
+	if tokens[i].isKeyword("ONLY") {
+		i++
+	}
+	if i < len(tokens) && tokens[i].isKeyword("IF") {
+		return "", "", ""
+	}
+	table, end := readQualifiedName(tokens, i)
+	if table == "" || end >= len(tokens) {
+		return "", "", ""
+	}
+	tableName := joinSQLTokens(tokens[i:end])
+	action := tokens[end:]
+	if len(splitTopLevel(action)) > 1 {
+		return "", table, ""
+	}
+
+	switch {
+	case action[0].isKeyword("RENAME") && len(action) > 2 && action[1].isKeyword("TO"):
+		renamed, renamedEnd := readQualifiedName(action, 2)
+		if renamed == "" || renamedEnd != len(action) {
+			return "", table, ""
+		}
+		return "ALTER TABLE " + joinSQLTokens(action[2:renamedEnd]) + " RENAME TO " + tableName, table, renamed
+
+	case action[0].isKeyword("ADD"):
+		j := 1
+		if j < len(action) && action[j].isKeyword("COLUMN") {
+			j++
+		}
+		if j >= len(action) || action[j].isKeyword("IF", "CONSTRAINT", "PRIMARY", "FOREIGN", "UNIQUE", "CHECK", "INDEX", "KEY", "FULLTEXT", "SPATIAL", "PARTITION") ||
+			!action[j].isName() {
+			return "", table, ""
+		}
+		return "ALTER TABLE " + tableName + " DROP COLUMN " + joinSQLTokens(action[j:j+1]), table, ""
+	}
+	return "", table, ""
+}
+
+// renamedName returns the new name of the table renamed by an ALTER TABLE ... RENAME TO statement as written
+func renamedName(tokens []sqlToken) string {
+	for i := len(tokens) - 1; i > 0; i-- {
+		if tokens[i].isKeyword("TO") {
+			return joinSQLTokens(tokens[i+1:])
+		}
+	}
+	return ""
+}
+
+// droppedTable returns the name of the single table dropped by a DROP TABLE statement
+func droppedTable(tokens []sqlToken) string {
+	if len(tokens) < 3 || !tokens[1].isKeyword("TABLE") {
+		return ""
+	}
+	table, end := readQualifiedName(tokens, 2)
+	if end != len(tokens) && !tokens[end].isKeyword("CASCADE", "RESTRICT") {
+		return ""
+	}
+	return table
+}
+
+// normalizeScriptName returns a table name as read by the tokenizer, the unquoted parts folded the way the database does
+func normalizeScriptName(table string, foldCase bool) string {
+	name, _ := readQualifiedName(tokenizeSQL(table, foldCase), 0)
+	return name
+}
@@ -0,0 +1,27 @@
+package dbmanager
+
+import (
+	"context"
+	"neobase-ai/internal/apis/dtos"
+	"strings"
+)
+
+// ExecuteStatements runs statements one by one on the connection of a chat & discards their results, e.g. to seed a test database.
+// It stops at the first failing statement.
+func (m *Manager) ExecuteStatements(ctx context.Context, chatID string, statements []string) *dtos.QueryError {
+	conn, driver, queryErr := m.groundingConnection(chatID)
+	if queryErr != nil {
+		return queryErr
+	}
+
+	for _, statement := range statements {
+		if strings.TrimSpace(statement) == "" {
+			continue
+		}
+		result := driver.ExecuteQuery(ctx, conn, statement, "", false)
+		if result.Error != nil {
+			return result.Error
+		}
+	}
+	return nil
+}
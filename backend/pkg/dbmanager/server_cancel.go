@@ -0,0 +1,115 @@
+package dbmanager
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// serverCancelTimeout bounds the statement stopping a query on the database server
+const serverCancelTimeout = 5 * time.Second
+
+// serverCanceler is implemented by the transactions able to stop their running statement on the database server, a cancelled
+// context only makes the driver stop waiting for it & the server may run it to the end
+type serverCanceler interface {
+	cancelOnServer(ctx context.Context) error
+}
+
+// serverCancelGuard keeps a cancellation from reaching the session of a transaction once it ended, the session goes back to
+// the pool then & may run the query of another execution
+type serverCancelGuard struct {
+	mu    sync.Mutex
+	ended bool
+}
+
+// end marks the transaction ended, it waits for a cancellation in progress
+func (g *serverCancelGuard) end() {
+	g.mu.Lock()
+	g.ended = true
+	g.mu.Unlock()
+}
+
+// run calls cancel unless the transaction ended
+func (g *serverCancelGuard) run(cancel func() error) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.ended {
+		return nil
+	}
+	return cancel()
+}
+
+// cancelOnServer stops the statement a transaction runs on the database server, when its database supports it
+func cancelOnServer(tx Transaction) {
+	canceler, ok := tx.(serverCanceler)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), serverCancelTimeout)
+	defer cancel()
+	if err := canceler.cancelOnServer(ctx); err != nil {
+		log.Printf("DBManager -> cancelOnServer -> Failed to cancel the query on the server: %v", err)
+	}
+}
+
+// cancelOnServer cancels the statement of the backend running the transaction, from another connection of the pool
+func (t *PostgresTransaction) cancelOnServer(ctx context.Context) error {
+	if t.backendPID == 0 || t.conn == nil || t.conn.DB == nil {
+		return nil
+	}
+	return t.cancelGuard.run(func() error {
+		sqlDB, err := t.conn.DB.DB()
+		if err != nil {
+			return err
+		}
+		log.Printf("PostgreSQL Transaction -> cancelOnServer -> Cancelling the query of backend %d", t.backendPID)
+		_, err = sqlDB.ExecContext(ctx, "SELECT pg_cancel_backend($1)", t.backendPID)
+		return err
+	})
+}
+
+// cancelOnServer kills the statement of the connection running the transaction, the connection & its transaction are kept
+func (t *MySQLTransaction) cancelOnServer(ctx context.Context) error {
+	if t.connectionID == 0 || t.conn == nil || t.conn.DB == nil {
+		return nil
+	}
+	return t.cancelGuard.run(func() error {
+		log.Printf("MySQL Transaction -> cancelOnServer -> Killing the query of connection %d", t.connectionID)
+		return t.conn.DB.WithContext(ctx).Exec(fmt.Sprintf("KILL QUERY %d", t.connectionID)).Error
+	})
+}
+
+// cancelOnServer kills the operations the session of the transaction is running, a user can list & kill its own operations
+// without the inprog & killop privileges
+func (tx *MongoDBTransaction) cancelOnServer(ctx context.Context) error {
+	if tx.Session == nil || tx.Wrapper == nil || tx.Wrapper.Client == nil {
+		return nil
+	}
+	return tx.cancelGuard.run(func() error {
+		lsid, err := tx.Session.ID().LookupErr("id")
+		if err != nil {
+			return fmt.Errorf("failed to read the session ID: %v", err)
+		}
+
+		admin := tx.Wrapper.Client.Database("admin")
+		var current struct {
+			InProg []struct {
+				OpID interface{} `bson:"opid"`
+			} `bson:"inprog"`
+		}
+		if err := admin.RunCommand(ctx, bson.D{{Key: "currentOp", Value: 1}, {Key: "$ownOps", Value: true}, {Key: "lsid.id", Value: lsid}}).Decode(&current); err != nil {
+			return fmt.Errorf("failed to list the operations of the session: %v", err)
+		}
+		for _, op := range current.InProg {
+			log.Printf("MongoDBTransaction -> cancelOnServer -> Killing operation %v", op.OpID)
+			if err := admin.RunCommand(ctx, bson.D{{Key: "killOp", Value: 1}, {Key: "op", Value: op.OpID}}).Err(); err != nil {
+				return fmt.Errorf("failed to kill operation %v: %v", op.OpID, err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,298 @@
+package dbmanager
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"neobase-ai/internal/constants"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Features whose support depends on the version of the server
+const (
+	FeatureCTE              = "cte"
+	FeatureWindowFunctions  = "window_functions"
+	FeatureMerge            = "merge"
+	FeatureMergeReturning   = "merge_returning"
+	FeatureReturning        = "returning"
+	FeatureJSONTable        = "json_table"
+	FeatureLateral          = "lateral"
+	FeatureGeneratedColumns = "generated_columns"
+	FeatureNullsNotDistinct = "nulls_not_distinct"
+	FeatureAnyValue         = "any_value"
+	FeatureIntersectExcept  = "intersect_except"
+	FeatureMergeStage       = "merge_stage"
+	FeatureUnionWith        = "union_with"
+	FeatureSetWindowFields  = "set_window_fields"
+	FeatureDateTrunc        = "date_trunc"
+	FeatureDensify          = "densify"
+	FeatureFill             = "fill"
+	FeaturePercentile       = "percentile"
+)
+
+// serverVersionDetectLimit bounds the version query run on connect
+const serverVersionDetectLimit = 5 * time.Second
+
+// ServerFeatures is the feature matrix of a connection's server, Features maps every known feature of the database type to its support
+type ServerFeatures struct {
+	Version  string          `json:"version"`
+	Flavor   string          `json:"flavor,omitempty"` // Database type of the server when detected, e.g. mariadb behind a mysql connection
+	Features map[string]bool `json:"features"`
+}
+
+// serverVersion is a parsed server version, e.g. 8.0.32
+type serverVersion struct {
+	major, minor, patch int
+}
+
+func (v serverVersion) atLeast(other serverVersion) bool {
+	if v.major != other.major {
+		return v.major > other.major
+	}
+	if v.minor != other.minor {
+		return v.minor > other.minor
+	}
+	return v.patch >= other.patch
+}
+
+func (v serverVersion) String() string {
+	if v.patch > 0 {
+		return fmt.Sprintf("%d.%d.%d", v.major, v.minor, v.patch)
+	}
+	return fmt.Sprintf("%d.%d", v.major, v.minor)
+}
+
+// featureRequirement is the first server version supporting a feature, pattern detects the feature in a query
+type featureRequirement struct {
+	feature     string
+	description string
+	minVersion  serverVersion
+	unsupported bool // No version supports the feature
+	pattern     *regexp.Regexp
+}
+
+var postgresFeatureRequirements = []featureRequirement{
+	{feature: FeatureGeneratedColumns, description: "generated columns (GENERATED ALWAYS AS ... STORED)", minVersion: serverVersion{12, 0, 0}, pattern: regexp.MustCompile(`(?i)\bGENERATED\s+ALWAYS\s+AS\s*\(`)},
+	{feature: FeatureMerge, description: "MERGE statements", minVersion: serverVersion{15, 0, 0}, pattern: regexp.MustCompile(`(?i)\bMERGE\s+INTO\b`)},
+	{feature: FeatureNullsNotDistinct, description: "UNIQUE NULLS NOT DISTINCT", minVersion: serverVersion{15, 0, 0}, pattern: regexp.MustCompile(`(?i)\bNULLS\s+NOT\s+DISTINCT\b`)},
+	{feature: FeatureAnyValue, description: "ANY_VALUE aggregates", minVersion: serverVersion{16, 0, 0}, pattern: regexp.MustCompile(`(?i)\bANY_VALUE\s*\(`)},
+	{feature: FeatureJSONTable, description: "JSON_TABLE functions", minVersion: serverVersion{17, 0, 0}, pattern: regexp.MustCompile(`(?i)\bJSON_TABLE\s*\(`)},
+	{feature: FeatureMergeReturning, description: "RETURNING in MERGE statements", minVersion: serverVersion{17, 0, 0}, pattern: regexp.MustCompile(`(?is)\bMERGE\s+INTO\b.*\bRETURNING\b`)},
+}
+
+var mysqlFeatureRequirements = []featureRequirement{
+	{feature: FeatureCTE, description: "WITH clauses (common table expressions)", minVersion: serverVersion{8, 0, 0}, pattern: regexp.MustCompile(`(?i)(^|\()\s*WITH\b`)},
+	{feature: FeatureWindowFunctions, description: "window functions (OVER)", minVersion: serverVersion{8, 0, 0}, pattern: regexp.MustCompile(`(?i)\)\s*OVER\s*[(\w]`)},
+	{feature: FeatureJSONTable, description: "JSON_TABLE functions", minVersion: serverVersion{8, 0, 4}, pattern: regexp.MustCompile(`(?i)\bJSON_TABLE\s*\(`)},
+	{feature: FeatureLateral, description: "LATERAL derived tables", minVersion: serverVersion{8, 0, 14}, pattern: regexp.MustCompile(`(?i)\bLATERAL\s*\(`)},
+	{feature: FeatureIntersectExcept, description: "INTERSECT & EXCEPT", minVersion: serverVersion{8, 0, 31}, pattern: regexp.MustCompile(`(?i)\b(INTERSECT|EXCEPT)\b`)},
+	{feature: FeatureReturning, description: "RETURNING clauses", unsupported: true, pattern: regexp.MustCompile(`(?i)\bRETURNING\b`)},
+	{feature: FeatureMerge, description: "MERGE statements", unsupported: true, pattern: regexp.MustCompile(`(?i)\bMERGE\s+INTO\b`)},
+}
+
+var mariaDBFeatureRequirements = []featureRequirement{
+	{feature: FeatureCTE, description: "WITH clauses (common table expressions)", minVersion: serverVersion{10, 2, 1}, pattern: regexp.MustCompile(`(?i)(^|\()\s*WITH\b`)},
+	{feature: FeatureWindowFunctions, description: "window functions (OVER)", minVersion: serverVersion{10, 2, 0}, pattern: regexp.MustCompile(`(?i)\)\s*OVER\s*[(\w]`)},
+	{feature: FeatureIntersectExcept, description: "INTERSECT & EXCEPT", minVersion: serverVersion{10, 3, 0}, pattern: regexp.MustCompile(`(?i)\b(INTERSECT|EXCEPT)\b`)},
+	{feature: FeatureReturning, description: "INSERT/REPLACE ... RETURNING", minVersion: serverVersion{10, 5, 0}, pattern: regexp.MustCompile(`(?is)^\s*(INSERT|REPLACE)\b.*\bRETURNING\b`)},
+	{feature: FeatureJSONTable, description: "JSON_TABLE functions", minVersion: serverVersion{10, 6, 0}, pattern: regexp.MustCompile(`(?i)\bJSON_TABLE\s*\(`)},
+	{feature: FeatureMerge, description: "MERGE statements", unsupported: true, pattern: regexp.MustCompile(`(?i)\bMERGE\s+INTO\b`)},
+}
+
+var mongoDBFeatureRequirements = []featureRequirement{
+	{feature: FeatureMergeStage, description: "$merge stages", minVersion: serverVersion{4, 2, 0}, pattern: regexp.MustCompile(`["']?\$merge["']?\s*:`)},
+	{feature: FeatureUnionWith, description: "$unionWith stages", minVersion: serverVersion{4, 4, 0}, pattern: regexp.MustCompile(`["']?\$unionWith["']?\s*:`)},
+	{feature: FeatureSetWindowFields, description: "$setWindowFields stages", minVersion: serverVersion{5, 0, 0}, pattern: regexp.MustCompile(`["']?\$setWindowFields["']?\s*:`)},
+	{feature: FeatureDateTrunc, description: "$dateTrunc operators", minVersion: serverVersion{5, 0, 0}, pattern: regexp.MustCompile(`["']?\$dateTrunc["']?\s*:`)},
+	{feature: FeatureDensify, description: "$densify stages", minVersion: serverVersion{5, 1, 0}, pattern: regexp.MustCompile(`["']?\$densify["']?\s*:`)},
+	{feature: FeatureFill, description: "$fill stages", minVersion: serverVersion{5, 3, 0}, pattern: regexp.MustCompile(`["']?\$fill["']?\s*:`)},
+	{feature: FeaturePercentile, description: "$percentile & $median operators", minVersion: serverVersion{7, 0, 0}, pattern: regexp.MustCompile(`["']?\$(percentile|median)["']?\s*:`)},
+}
+
+var (
+	serverVersionRegex = regexp.MustCompile(`(\d+)\.(\d+)(?:\.(\d+))?`)
+	sqlStringRegex     = regexp.MustCompile(`'(?:[^'\\]|\\.|'')*'`)
+)
+
+// serverFeatureRequirements returns the version dependent features of a database type
+func serverFeatureRequirements(dbType string) []featureRequirement {
+	switch dbType {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB:
+		// YugabyteDB reports the PostgreSQL version its query layer is based on, e.g. 11.2-YB-2.20.1.0
+		return postgresFeatureRequirements
+	case constants.DatabaseTypeMySQL:
+		return mysqlFeatureRequirements
+	case constants.DatabaseTypeMariaDB:
+		return mariaDBFeatureRequirements
+	case constants.DatabaseTypeMongoDB:
+		return mongoDBFeatureRequirements
+	}
+	return nil
+}
+
+// parseServerVersion reads the version number of a server version string, e.g. 16.2 (Debian 16.2-1.pgdg120+2) or 5.5.5-10.6.12-MariaDB
+func parseServerVersion(version string) (serverVersion, bool) {
+	// Older MariaDB servers prefix the real version with a replication compatibility version
+	version = strings.TrimPrefix(version, "5.5.5-")
+	match := serverVersionRegex.FindStringSubmatch(version)
+	if match == nil {
+		return serverVersion{}, false
+	}
+	major, _ := strconv.Atoi(match[1])
+	minor, _ := strconv.Atoi(match[2])
+	patch, _ := strconv.Atoi(match[3])
+	return serverVersion{major, minor, patch}, true
+}
+
+// GetServerFeatures returns the feature matrix of a server, nil when the version is unknown or the database type has no version dependent features
+func GetServerFeatures(dbType, version string) *ServerFeatures {
+	requirements := serverFeatureRequirements(dbType)
+	parsed, ok := parseServerVersion(version)
+	if len(requirements) == 0 || !ok {
+		return nil
+	}
+
+	features := &ServerFeatures{
+		Version:  version,
+		Features: make(map[string]bool, len(requirements)),
+	}
+	for _, requirement := range requirements {
+		features.Features[requirement.feature] = !requirement.unsupported && parsed.atLeast(requirement.minVersion)
+	}
+	return features
+}
+
+// CheckServerFeatures returns an error when a query uses a feature the server version does not support.
+// Queries of servers with an unknown version are not checked.
+func CheckServerFeatures(dbType, version, query string) error {
+	requirements := serverFeatureRequirements(dbType)
+	parsed, ok := parseServerVersion(version)
+	if len(requirements) == 0 || !ok {
+		return nil
+	}
+
+	// String literals could contain the keywords of a feature
+	if dbType != constants.DatabaseTypeMongoDB {
+		query = sqlStringRegex.ReplaceAllString(query, "''")
+	}
+	for _, requirement := range requirements {
+		if requirement.unsupported || !parsed.atLeast(requirement.minVersion) {
+			if requirement.pattern.MatchString(query) {
+				return fmt.Errorf("%s are not supported by server version %s (%s)", requirement.description, version, requirementVersionText(dbType, requirement))
+			}
+		}
+	}
+	return nil
+}
+
+// FormatServerFeaturesForLLM describes the server version & the syntax generated queries must avoid
+func FormatServerFeaturesForLLM(dbType string, features *ServerFeatures) string {
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Server version: %s\n", features.Version))
+	if features.Flavor != "" && features.Flavor != dbType {
+		result.WriteString(fmt.Sprintf("The server of this %s connection is %s, generate %s syntax\n", dbType, features.Flavor, features.Flavor))
+		dbType = features.Flavor
+	}
+
+	var unsupported, supported []string
+	for _, requirement := range serverFeatureRequirements(dbType) {
+		if features.Features[requirement.feature] {
+			supported = append(supported, requirement.description)
+		} else {
+			unsupported = append(unsupported, fmt.Sprintf("%s (%s)", requirement.description, requirementVersionText(dbType, requirement)))
+		}
+	}
+	sort.Strings(supported)
+	if len(unsupported) > 0 {
+		result.WriteString("Not supported, never generate queries using:\n")
+		for _, description := range unsupported {
+			result.WriteString("- " + description + "\n")
+		}
+	}
+	if len(supported) > 0 {
+		result.WriteString("Supported: " + strings.Join(supported, ", ") + "\n")
+	}
+	return result.String()
+}
+
+// requirementVersionText describes the server version a feature needs
+func requirementVersionText(dbType string, requirement featureRequirement) string {
+	if requirement.unsupported {
+		return fmt.Sprintf("not available in %s", dbType)
+	}
+	return fmt.Sprintf("requires %s %s", dbType, requirement.minVersion)
+}
+
+// GetServerFeatures returns the feature matrix of a chat's connected server
+func (m *Manager) GetServerFeatures(chatID string) (*ServerFeatures, bool) {
+	m.mu.RLock()
+	conn, exists := m.connections[chatID]
+	m.mu.RUnlock()
+	if !exists {
+		return nil, false
+	}
+	features := GetServerFeatures(conn.Dialect(), conn.ServerVersion)
+	if features != nil {
+		features.Flavor = conn.ServerFlavor
+	}
+	return features, features != nil
+}
+
+// Dialect returns the database type whose SQL the connection's server speaks, the detected flavor of the server wins over the configured type
+func (c *Connection) Dialect() string {
+	if c.ServerFlavor != "" {
+		return c.ServerFlavor
+	}
+	return c.Config.Type
+}
+
+// detectServerFlavor tells MariaDB & MySQL servers apart by their version, both are reachable through either database type.
+// An empty string is returned for the other database types & unknown versions.
+func detectServerFlavor(dbType, version string) string {
+	if version == "" || (dbType != constants.DatabaseTypeMySQL && dbType != constants.DatabaseTypeMariaDB) {
+		return ""
+	}
+	if isMariaDBVersion(version) {
+		return constants.DatabaseTypeMariaDB
+	}
+	return constants.DatabaseTypeMySQL
+}
+
+// detectServerVersion queries the version of a connection's server, an empty string is returned when it can't be detected
+func detectServerVersion(conn *Connection) string {
+	ctx, cancel := context.WithTimeout(context.Background(), serverVersionDetectLimit)
+	defer cancel()
+
+	var version string
+	var err error
+	switch conn.Config.Type {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB:
+		if conn.DB != nil {
+			err = conn.DB.WithContext(ctx).Raw("SHOW server_version").Scan(&version).Error
+		}
+	case constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB, constants.DatabaseTypeSingleStore, constants.DatabaseTypeClickhouse:
+		if conn.DB != nil {
+			err = conn.DB.WithContext(ctx).Raw("SELECT version()").Scan(&version).Error
+		}
+	case constants.DatabaseTypeMongoDB:
+		if wrapper, ok := conn.MongoDBObj.(*MongoDBWrapper); ok && wrapper.Client != nil {
+			var buildInfo struct {
+				Version string `bson:"version"`
+			}
+			err = wrapper.Client.Database("admin").RunCommand(ctx, bson.D{{Key: "buildInfo", Value: 1}}).Decode(&buildInfo)
+			version = buildInfo.Version
+		}
+	}
+	if err != nil {
+		log.Printf("DBManager -> detectServerVersion -> Failed to detect the %s server version: %v", conn.Config.Type, err)
+		return ""
+	}
+	return strings.TrimSpace(version)
+}
@@ -0,0 +1,60 @@
+package dbmanager
+
+import (
+	"neobase-ai/internal/constants"
+	"strings"
+	"testing"
+)
+
+func TestDetectServerFlavor(t *testing.T) {
+	tests := []struct {
+		name    string
+		dbType  string
+		version string
+		want    string
+	}{
+		{"mysql server", constants.DatabaseTypeMySQL, "8.0.36", constants.DatabaseTypeMySQL},
+		{"mariadb behind mysql", constants.DatabaseTypeMySQL, "10.11.6-MariaDB-1:10.11.6+maria~ubu2204", constants.DatabaseTypeMariaDB},
+		{"mariadb with compatibility prefix", constants.DatabaseTypeMySQL, "5.5.5-10.6.12-MariaDB", constants.DatabaseTypeMariaDB},
+		{"mysql behind mariadb", constants.DatabaseTypeMariaDB, "8.0.36", constants.DatabaseTypeMySQL},
+		{"mariadb server", constants.DatabaseTypeMariaDB, "11.4.2-MariaDB", constants.DatabaseTypeMariaDB},
+		{"unknown version", constants.DatabaseTypeMySQL, "", ""},
+		{"other database type", constants.DatabaseTypePostgreSQL, "16.2", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectServerFlavor(tt.dbType, tt.version); got != tt.want {
+				t.Errorf("detectServerFlavor(%q, %q) = %q, want %q", tt.dbType, tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServerFlavorFeatures(t *testing.T) {
+	conn := &Connection{
+		Config:        ConnectionConfig{Type: constants.DatabaseTypeMySQL},
+		ServerVersion: "10.6.12-MariaDB",
+	}
+	conn.ServerFlavor = detectServerFlavor(conn.Config.Type, conn.ServerVersion)
+	if conn.Dialect() != constants.DatabaseTypeMariaDB {
+		t.Fatalf("Dialect() = %q, want %q", conn.Dialect(), constants.DatabaseTypeMariaDB)
+	}
+
+	// The MySQL rules would reject RETURNING & read 10.6 as a MySQL version newer than 8.0
+	if err := CheckServerFeatures(conn.Dialect(), conn.ServerVersion, "INSERT INTO users (name) VALUES ('a') RETURNING id"); err != nil {
+		t.Errorf("RETURNING rejected on MariaDB 10.6: %v", err)
+	}
+	if err := CheckServerFeatures(conn.Dialect(), conn.ServerVersion, "MERGE INTO users USING staged ON users.id = staged.id"); err == nil {
+		t.Error("MERGE accepted on MariaDB 10.6")
+	}
+
+	features := GetServerFeatures(conn.Dialect(), conn.ServerVersion)
+	features.Flavor = conn.ServerFlavor
+	hint := FormatServerFeaturesForLLM(conn.Config.Type, features)
+	if !strings.Contains(hint, "generate mariadb syntax") {
+		t.Errorf("hint doesn't name the MariaDB flavor: %q", hint)
+	}
+	if !strings.Contains(hint, "requires mariadb") && !strings.Contains(hint, "not available in mariadb") {
+		t.Errorf("hint doesn't use the MariaDB requirements: %q", hint)
+	}
+}
@@ -0,0 +1,72 @@
+package dbmanager
+
+import (
+	"context"
+	"log"
+)
+
+// SingleStoreDriver implements the DatabaseDriver interface for SingleStore (formerly MemSQL).
+// SingleStore is MySQL wire compatible, so connection handling, query execution and transactions
+// are delegated to the MySQL driver while schema fetching exposes SingleStore storage metadata.
+type SingleStoreDriver struct {
+	MySQLDriver
+}
+
+// NewSingleStoreDriver creates a new SingleStore driver
+func NewSingleStoreDriver() DatabaseDriver {
+	return &SingleStoreDriver{}
+}
+
+// Connect establishes a connection to a SingleStore database
+func (d *SingleStoreDriver) Connect(config ConnectionConfig) (*Connection, error) {
+	conn, err := d.MySQLDriver.Connect(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var version string
+	if err := conn.DB.Raw("SELECT @@memsql_version").Scan(&version).Error; err != nil {
+		log.Printf("SingleStoreDriver -> Connect -> Warning: failed to detect SingleStore version, server may not be SingleStore: %v", err)
+		return conn, nil
+	}
+
+	conn.ServerVersion = version
+	log.Printf("SingleStoreDriver -> Connect -> Connected to SingleStore version %s", version)
+	return conn, nil
+}
+
+// GetSchema retrieves the database schema
+func (d *SingleStoreDriver) GetSchema(ctx context.Context, db DBExecutor, selectedTables []string) (*SchemaInfo, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("SingleStoreDriver -> GetSchema -> Context cancelled: %v", err)
+		return nil, err
+	}
+
+	fetcher := NewSingleStoreSchemaFetcher(db)
+	return fetcher.GetSchema(ctx, db, selectedTables)
+}
+
+// GetTableChecksum calculates a checksum for a table
+func (d *SingleStoreDriver) GetTableChecksum(ctx context.Context, db DBExecutor, table string) (string, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("SingleStoreDriver -> GetTableChecksum -> Context cancelled: %v", err)
+		return "", err
+	}
+
+	fetcher := NewSingleStoreSchemaFetcher(db)
+	return fetcher.GetTableChecksum(ctx, db, table)
+}
+
+// FetchExampleRecords fetches example records from a table
+func (d *SingleStoreDriver) FetchExampleRecords(ctx context.Context, db DBExecutor, table string, limit int) ([]map[string]interface{}, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("SingleStoreDriver -> FetchExampleRecords -> Context cancelled: %v", err)
+		return nil, err
+	}
+
+	fetcher := NewSingleStoreSchemaFetcher(db)
+	return fetcher.FetchExampleRecords(ctx, db, table, limit)
+}
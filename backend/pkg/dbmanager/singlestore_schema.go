@@ -0,0 +1,200 @@
+package dbmanager
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+)
+
+var (
+	singleStoreShardKeyRegex = regexp.MustCompile("(?i)SHARD\\s+KEY\\s*(?:`?\\w*`?\\s*)?\\(([^)]*)\\)")
+	singleStoreSortKeyRegex  = regexp.MustCompile("(?i)SORT\\s+KEY\\s*(?:`?\\w*`?\\s*)?\\(([^)]*)\\)")
+)
+
+// SingleStoreSchemaFetcher implements schema fetching for SingleStore.
+// It reuses the MySQL fetcher and enriches tables with storage type & shard key metadata.
+type SingleStoreSchemaFetcher struct {
+	*MySQLSchemaFetcher
+}
+
+// SingleStoreTableInfo holds SingleStore specific table metadata
+type SingleStoreTableInfo struct {
+	StorageType string   // COLUMNSTORE or ROWSTORE, empty when unknown
+	ShardKey    []string // Columns the table is distributed on, empty means keyless (random) sharding
+	SortKey     []string // Columnstore sort key columns
+	IsReference bool     // Reference tables are replicated to every node
+}
+
+// NewSingleStoreSchemaFetcher creates a new SingleStore schema fetcher
+func NewSingleStoreSchemaFetcher(db DBExecutor) SchemaFetcher {
+	return &SingleStoreSchemaFetcher{
+		MySQLSchemaFetcher: &MySQLSchemaFetcher{db: db},
+	}
+}
+
+// GetSchema retrieves the schema for the selected tables with storage & shard key metadata
+func (f *SingleStoreSchemaFetcher) GetSchema(ctx context.Context, db DBExecutor, selectedTables []string) (*SchemaInfo, error) {
+	log.Printf("SingleStoreSchemaFetcher -> GetSchema -> Starting schema fetch with selected tables: %v", selectedTables)
+
+	schema, err := f.MySQLSchemaFetcher.GetSchema(ctx, db, selectedTables)
+	if err != nil {
+		return nil, err
+	}
+
+	storageTypes, err := f.fetchStorageTypes()
+	if err != nil {
+		log.Printf("SingleStoreSchemaFetcher -> GetSchema -> Error fetching storage types, falling back to the table definitions: %v", err)
+	}
+
+	for tableName, table := range schema.Tables {
+		// Check for context cancellation
+		if err := ctx.Err(); err != nil {
+			log.Printf("SingleStoreSchemaFetcher -> GetSchema -> Context cancelled: %v", err)
+			return nil, err
+		}
+
+		info, err := f.fetchTableInfo(tableName)
+		if err != nil {
+			log.Printf("SingleStoreSchemaFetcher -> GetSchema -> Error fetching storage info for table %s: %v", tableName, err)
+			continue
+		}
+		if storageType := storageTypes[tableName]; storageType != "" {
+			info.StorageType = storageType
+		}
+
+		table.Comment = appendSingleStoreTableInfo(table.Comment, info)
+
+		// Recalculate the table checksum as the table definition changed
+		table.Checksum = ""
+		tableData, _ := json.Marshal(table)
+		table.Checksum = fmt.Sprintf("%x", md5.Sum(tableData))
+		schema.Tables[tableName] = table
+	}
+
+	schema.DialectHints = []string{
+		"Server: SingleStore, tables are distributed across leaf nodes by their Shard Key",
+		"Joins & GROUP BY on the shard key columns run locally on each partition, prefer filtering and joining on shard keys",
+		"Reference tables are replicated on every node and can be joined with any table without data movement",
+		"Columnstore tables are optimized for analytical scans, filter on their Sort Key for segment elimination",
+	}
+
+	return schema, nil
+}
+
+// fetchStorageTypes reads the storage type of the tables of the database, SHOW CREATE TABLE omits it for tables of the default type
+func (f *SingleStoreSchemaFetcher) fetchStorageTypes() (map[string]string, error) {
+	var rows []map[string]interface{}
+	query := "SELECT TABLE_NAME AS table_name, STORAGE_TYPE AS storage_type FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE()"
+	if err := f.db.QueryRows(query, &rows); err != nil {
+		return nil, err
+	}
+
+	storageTypes := make(map[string]string, len(rows))
+	for _, row := range rows {
+		storageTypes[valueText(row["table_name"])] = singleStoreStorageType(valueText(row["storage_type"]))
+	}
+	return storageTypes, nil
+}
+
+// singleStoreStorageType maps the STORAGE_TYPE of information_schema.TABLES (e.g. INMEMORY_ROWSTORE) to COLUMNSTORE or ROWSTORE,
+// other types (e.g. FOREIGN) are kept as they are
+func singleStoreStorageType(storageType string) string {
+	upper := strings.ToUpper(strings.TrimSpace(storageType))
+	switch {
+	case strings.Contains(upper, "ROWSTORE"):
+		return "ROWSTORE"
+	case strings.Contains(upper, "COLUMNSTORE"):
+		return "COLUMNSTORE"
+	}
+	return upper
+}
+
+// fetchTableInfo reads the storage type, shard key & sort key of a table from its definition
+func (f *SingleStoreSchemaFetcher) fetchTableInfo(table string) (*SingleStoreTableInfo, error) {
+	var rows []map[string]interface{}
+	if err := f.db.QueryRows(fmt.Sprintf("SHOW CREATE TABLE `%s`", table), &rows); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("no definition found for table %s", table)
+	}
+
+	var createStmt string
+	switch v := rows[0]["Create Table"].(type) {
+	case string:
+		createStmt = v
+	case []byte:
+		createStmt = string(v)
+	}
+	if createStmt == "" {
+		return nil, fmt.Errorf("empty definition for table %s", table)
+	}
+
+	return parseSingleStoreCreateTable(createStmt), nil
+}
+
+// parseSingleStoreCreateTable extracts SingleStore storage metadata from a CREATE TABLE statement.
+// The storage type is left empty when the statement doesn't state it, the default type depends on the server's
+// default_table_type, information_schema.TABLES tells it.
+func parseSingleStoreCreateTable(createStmt string) *SingleStoreTableInfo {
+	info := &SingleStoreTableInfo{}
+
+	upperStmt := strings.ToUpper(createStmt)
+	if strings.Contains(upperStmt, "CREATE ROWSTORE") || strings.Contains(upperStmt, "ROWSTORE REFERENCE") {
+		info.StorageType = "ROWSTORE"
+	}
+	if strings.Contains(upperStmt, "REFERENCE TABLE") {
+		info.IsReference = true
+	}
+
+	if matches := singleStoreShardKeyRegex.FindStringSubmatch(createStmt); len(matches) > 1 {
+		info.ShardKey = splitSingleStoreKeyColumns(matches[1])
+	}
+	if matches := singleStoreSortKeyRegex.FindStringSubmatch(createStmt); len(matches) > 1 {
+		info.SortKey = splitSingleStoreKeyColumns(matches[1])
+		// Tables with a sort key are always columnstore
+		info.StorageType = "COLUMNSTORE"
+	}
+
+	return info
+}
+
+func splitSingleStoreKeyColumns(columns string) []string {
+	var result []string
+	for _, column := range strings.Split(columns, ",") {
+		column = strings.TrimSpace(strings.Trim(strings.TrimSpace(column), "`"))
+		// Remove sort direction from sort key columns
+		column = strings.TrimSuffix(strings.TrimSuffix(column, " DESC"), " ASC")
+		if column != "" {
+			result = append(result, strings.Trim(column, "`"))
+		}
+	}
+	return result
+}
+
+// appendSingleStoreTableInfo appends storage metadata to the table comment shown to the LLM
+func appendSingleStoreTableInfo(comment string, info *SingleStoreTableInfo) string {
+	var parts []string
+	if info.StorageType != "" {
+		parts = append(parts, fmt.Sprintf("[Storage: %s]", info.StorageType))
+	}
+	if info.IsReference {
+		parts = append(parts, "[Reference Table: replicated on all nodes]")
+	} else if len(info.ShardKey) > 0 {
+		parts = append(parts, fmt.Sprintf("[Shard Key: %s]", strings.Join(info.ShardKey, ", ")))
+	} else {
+		parts = append(parts, "[Shard Key: none, keyless sharding]")
+	}
+	if len(info.SortKey) > 0 {
+		parts = append(parts, fmt.Sprintf("[Sort Key: %s]", strings.Join(info.SortKey, ", ")))
+	}
+
+	if comment != "" {
+		return comment + " " + strings.Join(parts, " ")
+	}
+	return strings.Join(parts, " ")
+}
@@ -33,6 +33,9 @@ type SchemaInfo struct {
 	Enums     map[string]EnumSchema     `json:"enums,omitempty"`
 	UpdatedAt time.Time                 `json:"updated_at"`
 	Checksum  string                    `json:"checksum"`
+
+	// DialectHints describe database flavor specific syntax & features (e.g. MariaDB RETURNING)
+	DialectHints []string `json:"dialect_hints,omitempty"`
 }
 
 type TableSchema struct {
@@ -44,6 +47,18 @@ type TableSchema struct {
 	Comment     string                    `json:"comment,omitempty"`
 	Checksum    string                    `json:"checksum"`
 	RowCount    int64                     `json:"row_count"`
+	Storage     *TableStorage             `json:"storage,omitempty"` // Set by the databases reporting it, not part of the checksum as it changes with every write
+}
+
+// TableStorage is the storage footprint of a table, sizes are in bytes
+type TableStorage struct {
+	StorageSize     int64            `json:"storage_size"`
+	AvgDocumentSize int64            `json:"avg_document_size"`
+	TotalIndexSize  int64            `json:"total_index_size"`
+	IndexSizes      map[string]int64 `json:"index_sizes,omitempty"`
+	Capped          bool             `json:"capped"`
+	MaxSize         int64            `json:"max_size,omitempty"`      // Capped collections only
+	MaxDocuments    int64            `json:"max_documents,omitempty"` // Capped collections only, 0 when only the size is capped
 }
 
 type ColumnInfo struct {
@@ -558,7 +573,7 @@ func (sm *SchemaManager) getTableChecksums(ctx context.Context, db DBExecutor, d
 			checksums[tableName] = checksum
 		}
 		return checksums, nil
-	case constants.DatabaseTypeMySQL:
+	case constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB, constants.DatabaseTypeSingleStore, constants.DatabaseTypeDB2, constants.DatabaseTypeDatabricks:
 		// Implement MySQL checksum calculation
 		checksums := make(map[string]string)
 
@@ -626,7 +641,7 @@ func (sm *SchemaManager) getTableChecksums(ctx context.Context, db DBExecutor, d
 			checksums[tableName] = checksum
 		}
 		return checksums, nil
-	case constants.DatabaseTypeMongoDB:
+	case constants.DatabaseTypeMongoDB, constants.DatabaseTypeFirestore:
 		// Implement MongoDB checksum calculation
 		checksums := make(map[string]string)
 
@@ -893,15 +908,69 @@ func (m *SchemaManager) FormatSchemaForLLM(schema *SchemaInfo) string {
 
 		// Add row count information
 		result.WriteString(fmt.Sprintf("Row Count: %d\n", table.RowCount))
+		if table.Storage != nil {
+			result.WriteString(fmt.Sprintf("Storage: %s\n", formatTableStorage(table.Storage)))
+		}
 
 		result.WriteString("\n")
 	}
 
+	// Add dialect hints
+	if len(schema.DialectHints) > 0 {
+		result.WriteString("Dialect Hints:\n")
+		for _, hint := range schema.DialectHints {
+			result.WriteString(fmt.Sprintf("  - %s\n", hint))
+		}
+		result.WriteString("\n")
+	}
+
 	log.Printf("FormatSchemaForLLM -> Completed formatting schema with %d tables", len(tableNames))
 	return result.String()
 }
 
 // FormatSchemaForLLMWithExamples formats the schema into a LLM-friendly string with example records
+// formatTableStorage describes the storage of a table in one line, e.g. "12.0 MB, avg document 512 B, indexes 1.5 MB (_id_ 1.0 MB, email_1 512.0 KB)"
+func formatTableStorage(storage *TableStorage) string {
+	description := fmt.Sprintf("%s, avg document %s, indexes %s", formatStorageBytes(storage.StorageSize), formatStorageBytes(storage.AvgDocumentSize), formatStorageBytes(storage.TotalIndexSize))
+
+	if len(storage.IndexSizes) > 0 {
+		indexNames := make([]string, 0, len(storage.IndexSizes))
+		for indexName := range storage.IndexSizes {
+			indexNames = append(indexNames, indexName)
+		}
+		sort.Strings(indexNames)
+
+		indexSizes := make([]string, 0, len(indexNames))
+		for _, indexName := range indexNames {
+			indexSizes = append(indexSizes, fmt.Sprintf("%s %s", indexName, formatStorageBytes(storage.IndexSizes[indexName])))
+		}
+		description += fmt.Sprintf(" (%s)", strings.Join(indexSizes, ", "))
+	}
+
+	if storage.Capped {
+		description += fmt.Sprintf(", capped at %s", formatStorageBytes(storage.MaxSize))
+		if storage.MaxDocuments > 0 {
+			description += fmt.Sprintf(" or %d documents", storage.MaxDocuments)
+		}
+		description += ", the oldest documents are overwritten"
+	}
+	return description
+}
+
+// formatStorageBytes formats a size in bytes with a binary unit, e.g. 1536 -> 1.5 KB
+func formatStorageBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value, exponent := float64(size)/unit, 0
+	for value >= unit && exponent < 4 {
+		value /= unit
+		exponent++
+	}
+	return fmt.Sprintf("%.1f %cB", value, "KMGTP"[exponent])
+}
+
 func (m *SchemaManager) FormatSchemaForLLMWithExamples(storage *SchemaStorage) string {
 	log.Printf("FormatSchemaForLLMWithExamples -> Starting with %d tables", len(storage.LLMSchema.Tables))
 
@@ -1041,6 +1110,9 @@ func (m *SchemaManager) FormatSchemaForLLMWithExamples(storage *SchemaStorage) s
 
 		// Add row count information
 		result.WriteString(fmt.Sprintf("\nRow Count: %d\n", table.RowCount))
+		if fullTable, ok := storage.FullSchema.Tables[tableName]; ok && fullTable.Storage != nil {
+			result.WriteString(fmt.Sprintf("Storage: %s\n", formatTableStorage(fullTable.Storage)))
+		}
 
 		// Add example records if available
 		if len(table.ExampleRecords) > 0 {
@@ -1178,6 +1250,15 @@ func (m *SchemaManager) FormatSchemaForLLMWithExamples(storage *SchemaStorage) s
 		result.WriteString("\n")
 	}
 
+	// Add dialect hints
+	if len(storage.FullSchema.DialectHints) > 0 {
+		result.WriteString("Dialect Hints:\n")
+		for _, hint := range storage.FullSchema.DialectHints {
+			result.WriteString(fmt.Sprintf("  - %s\n", hint))
+		}
+		result.WriteString("\n")
+	}
+
 	log.Printf("FormatSchemaForLLMWithExamples -> Completed formatting schema with %d tables", len(tableNames))
 	return result.String()
 }
@@ -1641,6 +1722,31 @@ func (sm *SchemaManager) registerDefaultFetchers() {
 		return NewMySQLSchemaFetcher(db)
 	})
 
+	// Register MariaDB schema fetcher
+	sm.RegisterFetcher("mariadb", func(db DBExecutor) SchemaFetcher {
+		return NewMariaDBSchemaFetcher(db)
+	})
+
+	// Register SingleStore schema fetcher
+	sm.RegisterFetcher("singlestore", func(db DBExecutor) SchemaFetcher {
+		return NewSingleStoreSchemaFetcher(db)
+	})
+
+	// Register DB2 schema fetcher
+	sm.RegisterFetcher("db2", func(db DBExecutor) SchemaFetcher {
+		return NewDB2SchemaFetcher(db)
+	})
+
+	// Register Databricks schema fetcher
+	sm.RegisterFetcher("databricks", func(db DBExecutor) SchemaFetcher {
+		return NewDatabricksSchemaFetcher(db)
+	})
+
+	// Register Firestore schema fetcher
+	sm.RegisterFetcher("firestore", func(db DBExecutor) SchemaFetcher {
+		return NewFirestoreSchemaFetcher(db)
+	})
+
 	// Register ClickHouse schema fetcher
 	sm.RegisterFetcher("clickhouse", func(db DBExecutor) SchemaFetcher {
 		return NewClickHouseSchemaFetcher(db)
@@ -2099,6 +2205,21 @@ func (sm *SchemaManager) registerDefaultSimplifiers() {
 	// Register MySQL simplifier
 	sm.RegisterSimplifier("mysql", &MySQLSimplifier{})
 
+	// Register MariaDB simplifier
+	sm.RegisterSimplifier("mariadb", &MySQLSimplifier{})
+
+	// Register SingleStore simplifier
+	sm.RegisterSimplifier("singlestore", &MySQLSimplifier{})
+
+	// Register DB2 simplifier
+	sm.RegisterSimplifier("db2", &DB2Simplifier{})
+
+	// Register Databricks simplifier
+	sm.RegisterSimplifier("databricks", &DatabricksSimplifier{})
+
+	// Register Firestore simplifier
+	sm.RegisterSimplifier("firestore", &FirestoreSimplifier{})
+
 	// Register ClickHouse simplifier
 	sm.RegisterSimplifier("clickhouse", &ClickHouseSimplifier{})
 
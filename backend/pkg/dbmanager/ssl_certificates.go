@@ -0,0 +1,112 @@
+package dbmanager
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"neobase-ai/internal/utils"
+	"os"
+	"strings"
+)
+
+// sslCertificates holds the PEM content of the certificates of a connection
+type sslCertificates struct {
+	cert     []byte
+	key      []byte
+	rootCert []byte
+}
+
+// loadSSLCertificates returns the certificates of a connection, uploaded PEM content is used over the URLs.
+// Certificates behind URLs are fetched in memory, nothing is written to disk.
+func loadSSLCertificates(config ConnectionConfig) (*sslCertificates, error) {
+	certs := &sslCertificates{}
+	var err error
+	if certs.cert, err = loadSSLCertificate(config.SSLCert, config.SSLCertURL); err != nil {
+		return nil, fmt.Errorf("failed to fetch client certificate: %v", err)
+	}
+	if certs.key, err = loadSSLCertificate(config.SSLKey, config.SSLKeyURL); err != nil {
+		return nil, fmt.Errorf("failed to fetch client key: %v", err)
+	}
+	if certs.rootCert, err = loadSSLCertificate(config.SSLRootCert, config.SSLRootCertURL); err != nil {
+		return nil, fmt.Errorf("failed to fetch CA certificate: %v", err)
+	}
+	return certs, nil
+}
+
+func loadSSLCertificate(content *string, url *string) ([]byte, error) {
+	if content != nil && strings.TrimSpace(*content) != "" {
+		return []byte(*content), nil
+	}
+	if url != nil && *url != "" {
+		return utils.FetchCertificateContent(*url)
+	}
+	return nil, nil
+}
+
+// hasClientCertificate returns true when the connection authenticates with a client certificate, it needs both the certificate & its key
+func (c *sslCertificates) hasClientCertificate() bool {
+	return len(c.cert) > 0 && len(c.key) > 0
+}
+
+// applyTo adds the client certificate & the CA certificate to a TLS configuration
+func (c *sslCertificates) applyTo(tlsConfig *tls.Config) error {
+	if c.hasClientCertificate() {
+		cert, err := tls.X509KeyPair(c.cert, c.key)
+		if err != nil {
+			return fmt.Errorf("failed to load client certificates: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if len(c.rootCert) > 0 {
+		rootCertPool := x509.NewCertPool()
+		if ok := rootCertPool.AppendCertsFromPEM(c.rootCert); !ok {
+			return fmt.Errorf("failed to parse CA certificate")
+		}
+		tlsConfig.RootCAs = rootCertPool
+	}
+	return nil
+}
+
+// postgresParams returns the lib/pq connection parameters of the certificates.
+// lib/pq reads inline certificates only along with a client certificate, a lone CA certificate is written to a temporary file.
+func (c *sslCertificates) postgresParams() (string, []string, error) {
+	if c.hasClientCertificate() {
+		params := fmt.Sprintf(" sslinline=true sslcert=%s sslkey=%s", quotePostgresParam(c.cert), quotePostgresParam(c.key))
+		if len(c.rootCert) > 0 {
+			params += fmt.Sprintf(" sslrootcert=%s", quotePostgresParam(c.rootCert))
+		}
+		return params, nil, nil
+	}
+
+	if len(c.rootCert) > 0 {
+		rootCertPath, err := writeCertificateFile(c.rootCert)
+		if err != nil {
+			return "", nil, err
+		}
+		return fmt.Sprintf(" sslrootcert=%s", rootCertPath), []string{rootCertPath}, nil
+	}
+	return "", nil, nil
+}
+
+// quotePostgresParam quotes a key=value connection parameter value, PEM content spans several lines
+func quotePostgresParam(value []byte) string {
+	escaped := strings.ReplaceAll(string(value), `\`, `\\`)
+	return "'" + strings.ReplaceAll(escaped, `'`, `\'`) + "'"
+}
+
+// writeCertificateFile stores a certificate in a temporary file for the drivers reading certificates from disk only.
+// The caller removes the file.
+func writeCertificateFile(content []byte) (string, error) {
+	file, err := os.CreateTemp("", "cert-*.pem")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(content); err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to save certificate: %v", err)
+	}
+	return file.Name(), nil
+}
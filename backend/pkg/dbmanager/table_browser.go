@@ -0,0 +1,449 @@
+package dbmanager
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"neobase-ai/internal/constants"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	BrowseDefaultPageSize = 50
+	BrowseMaxPageSize     = 500
+	browseQueryTimeout    = 30 * time.Second
+)
+
+// Filter operators supported by the table browser
+const (
+	BrowseOpEquals     = "eq"
+	BrowseOpNotEquals  = "neq"
+	BrowseOpGreater    = "gt"
+	BrowseOpGreaterEq  = "gte"
+	BrowseOpLess       = "lt"
+	BrowseOpLessEq     = "lte"
+	BrowseOpContains   = "contains"
+	BrowseOpStartsWith = "starts_with"
+	BrowseOpIsNull     = "is_null"
+	BrowseOpNotNull    = "not_null"
+)
+
+var browseComparisons = map[string]string{
+	BrowseOpEquals:    "=",
+	BrowseOpNotEquals: "<>",
+	BrowseOpGreater:   ">",
+	BrowseOpGreaterEq: ">=",
+	BrowseOpLess:      "<",
+	BrowseOpLessEq:    "<=",
+}
+
+var browseMongoComparisons = map[string]string{
+	BrowseOpGreater:   "$gt",
+	BrowseOpGreaterEq: "$gte",
+	BrowseOpLess:      "$lt",
+	BrowseOpLessEq:    "$lte",
+}
+
+// mongoBrowseFieldRegex restricts field paths so filters can't inject operators
+var mongoBrowseFieldRegex = regexp.MustCompile(`^[^$.\s][^$\s]*$`)
+
+// BrowseFilter filters the rows of a browsed table on a single column
+type BrowseFilter struct {
+	Column   string `json:"column"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+}
+
+// BrowseOptions holds the pagination, sorting & filters of a table browse request
+type BrowseOptions struct {
+	Page         int
+	PageSize     int
+	SortBy       string
+	SortDesc     bool
+	Filters      []BrowseFilter
+	IncludeTotal bool
+}
+
+// BrowseResult holds a page of rows of a browsed table
+type BrowseResult struct {
+	Table         string                   `json:"table"`
+	Columns       []string                 `json:"columns"`
+	Rows          []map[string]interface{} `json:"rows"`
+	Page          int                      `json:"page"`
+	PageSize      int                      `json:"page_size"`
+	HasMore       bool                     `json:"has_more"`
+	TotalCount    *int64                   `json:"total_count,omitempty"`
+	Query         string                   `json:"query"`
+	ExecutionTime int                      `json:"execution_time"`
+}
+
+// browseDialect describes how the table browser builds SQL for a database type
+type browseDialect struct {
+	quoteIdent  func(ident string) string
+	placeholder func(n int) string
+	textCast    func(column string) string
+	paginate    func(limit, offset int) string
+	likeEscape  string
+}
+
+// getBrowseDialect returns the SQL dialect of a database type, false if the table browser doesn't support it
+func getBrowseDialect(dbType string) (browseDialect, bool) {
+	questionMark := func(int) string { return "?" }
+	limitOffset := func(limit, offset int) string { return fmt.Sprintf(" LIMIT %d OFFSET %d", limit, offset) }
+
+	switch dbType {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB:
+		return browseDialect{
+			quoteIdent:  quotePostgresIdent,
+			placeholder: func(n int) string { return fmt.Sprintf("$%d", n) },
+			textCast:    func(column string) string { return "CAST(" + column + " AS TEXT)" },
+			paginate:    limitOffset,
+		}, true
+	case constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB, constants.DatabaseTypeSingleStore:
+		return browseDialect{
+			quoteIdent:  quoteMySQLIdent,
+			placeholder: questionMark,
+			textCast:    func(column string) string { return "CAST(" + column + " AS CHAR)" },
+			paginate:    limitOffset,
+		}, true
+	case constants.DatabaseTypeClickhouse:
+		return browseDialect{
+			quoteIdent:  quoteMySQLIdent,
+			placeholder: questionMark,
+			textCast:    func(column string) string { return "toString(" + column + ")" },
+			paginate:    limitOffset,
+		}, true
+	case constants.DatabaseTypeDB2:
+		return browseDialect{
+			quoteIdent:  quotePostgresIdent,
+			placeholder: questionMark,
+			textCast:    func(column string) string { return "VARCHAR(" + column + ")" },
+			paginate: func(limit, offset int) string {
+				return fmt.Sprintf(" OFFSET %d ROWS FETCH FIRST %d ROWS ONLY", offset, limit)
+			},
+			likeEscape: ` ESCAPE '\'`,
+		}, true
+	}
+	return browseDialect{}, false
+}
+
+// normalize applies the defaults & bounds of the pagination
+func (o *BrowseOptions) normalize() {
+	if o.Page < 1 {
+		o.Page = 1
+	}
+	if o.PageSize < 1 {
+		o.PageSize = BrowseDefaultPageSize
+	}
+	if o.PageSize > BrowseMaxPageSize {
+		o.PageSize = BrowseMaxPageSize
+	}
+}
+
+// BrowseTable reads a page of rows of a table with a server built query, identifiers are quoted & values are bound as parameters
+func (m *Manager) BrowseTable(ctx context.Context, chatID, table string, opts BrowseOptions) (*BrowseResult, error) {
+	m.mu.RLock()
+	conn, exists := m.connections[chatID]
+	m.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("connection not found for chat %s", chatID)
+	}
+
+	table = strings.TrimSpace(table)
+	if table == "" {
+		return nil, fmt.Errorf("table name is required")
+	}
+
+	opts.normalize()
+	for _, filter := range opts.Filters {
+		if filter.Operator == BrowseOpContains || filter.Operator == BrowseOpStartsWith || filter.Operator == BrowseOpIsNull || filter.Operator == BrowseOpNotNull {
+			continue
+		}
+		if _, ok := browseComparisons[filter.Operator]; !ok {
+			return nil, fmt.Errorf("unsupported filter operator: %s", filter.Operator)
+		}
+	}
+
+	// Browsing holds a slot of the connection like the executions, it waits in its queue without notices
+	release, queueErr := m.acquireQuerySlot(ctx, conn, "", "", "")
+	if queueErr != nil {
+		return nil, fmt.Errorf("%s", queueErr.Details)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(ctx, browseQueryTimeout)
+	defer cancel()
+
+	m.UpdateLastUsed(chatID)
+
+	var result *BrowseResult
+	var err error
+	if conn.Config.Type == constants.DatabaseTypeMongoDB {
+		result, err = browseMongoDBCollection(ctx, conn, table, opts)
+	} else {
+		dialect, ok := getBrowseDialect(conn.Config.Type)
+		if !ok {
+			return nil, fmt.Errorf("table browsing is not supported for %s", conn.Config.Type)
+		}
+		result, err = browseSQLTable(ctx, conn, dialect, table, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("DBManager -> BrowseTable -> chatID: %s, table: %s, page: %d, rows: %d", chatID, table, opts.Page, len(result.Rows))
+	return result, nil
+}
+
+// browseSQLTable builds & runs the SELECT of a page, the columns are read first so sorting & filters only accept existing ones
+func browseSQLTable(ctx context.Context, conn *Connection, dialect browseDialect, table string, opts BrowseOptions) (*BrowseResult, error) {
+	if conn.DB == nil {
+		return nil, fmt.Errorf("database connection is not available")
+	}
+
+	parts := strings.Split(table, ".")
+	for i, part := range parts {
+		parts[i] = dialect.quoteIdent(part)
+	}
+	quotedTable := strings.Join(parts, ".")
+
+	columnRows, err := conn.DB.WithContext(ctx).Raw("SELECT * FROM " + quotedTable + " WHERE 1 = 0").Rows()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read table %s: %v", table, err)
+	}
+	columns, err := columnRows.Columns()
+	columnRows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns of table %s: %v", table, err)
+	}
+
+	knownColumns := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		knownColumns[column] = true
+	}
+
+	// WHERE clause, values are always bound as parameters
+	var conditions []string
+	var args []interface{}
+	for _, filter := range opts.Filters {
+		if !knownColumns[filter.Column] {
+			return nil, fmt.Errorf("unknown column: %s", filter.Column)
+		}
+		column := dialect.quoteIdent(filter.Column)
+
+		switch filter.Operator {
+		case BrowseOpIsNull:
+			conditions = append(conditions, column+" IS NULL")
+		case BrowseOpNotNull:
+			conditions = append(conditions, column+" IS NOT NULL")
+		case BrowseOpContains, BrowseOpStartsWith:
+			pattern := escapeLikePattern(filter.Value) + "%"
+			if filter.Operator == BrowseOpContains {
+				pattern = "%" + pattern
+			}
+			args = append(args, pattern)
+			conditions = append(conditions, dialect.textCast(column)+" LIKE "+dialect.placeholder(len(args))+dialect.likeEscape)
+		default:
+			args = append(args, filter.Value)
+			conditions = append(conditions, column+" "+browseComparisons[filter.Operator]+" "+dialect.placeholder(len(args)))
+		}
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	orderClause := ""
+	if opts.SortBy != "" {
+		if !knownColumns[opts.SortBy] {
+			return nil, fmt.Errorf("unknown column: %s", opts.SortBy)
+		}
+		direction := "ASC"
+		if opts.SortDesc {
+			direction = "DESC"
+		}
+		orderClause = " ORDER BY " + dialect.quoteIdent(opts.SortBy) + " " + direction
+	}
+
+	// One extra row tells whether there is a next page without counting the whole table
+	offset := (opts.Page - 1) * opts.PageSize
+	query := "SELECT * FROM " + quotedTable + whereClause + orderClause + dialect.paginate(opts.PageSize+1, offset)
+
+	startTime := time.Now()
+	rows, err := conn.DB.WithContext(ctx).Raw(query, args...).Rows()
+	if err != nil {
+		return nil, fmt.Errorf("failed to browse table %s: %v", table, err)
+	}
+	defer rows.Close()
+
+	records, err := processRows(rows, startTime, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BrowseResult{
+		Table:    table,
+		Columns:  columns,
+		Rows:     records,
+		Page:     opts.Page,
+		PageSize: opts.PageSize,
+		Query:    query,
+	}
+	if len(records) > opts.PageSize {
+		result.Rows = records[:opts.PageSize]
+		result.HasMore = true
+	}
+
+	if opts.IncludeTotal {
+		var total int64
+		if err := conn.DB.WithContext(ctx).Raw("SELECT COUNT(*) FROM "+quotedTable+whereClause, args...).Row().Scan(&total); err != nil {
+			return nil, fmt.Errorf("failed to count rows of table %s: %v", table, err)
+		}
+		result.TotalCount = &total
+	}
+
+	result.ExecutionTime = int(time.Since(startTime).Milliseconds())
+	return result, nil
+}
+
+// escapeLikePattern escapes the LIKE wildcards of a filter value
+func escapeLikePattern(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+// browseMongoDBCollection runs the find of a page through the driver, filter values are typed the same way they'd be written in a query
+func browseMongoDBCollection(ctx context.Context, conn *Connection, collection string, opts BrowseOptions) (*BrowseResult, error) {
+	wrapper, ok := conn.MongoDBObj.(*MongoDBWrapper)
+	if !ok {
+		return nil, fmt.Errorf("invalid MongoDB connection")
+	}
+
+	conditions := bson.A{}
+	for _, f := range opts.Filters {
+		if !mongoBrowseFieldRegex.MatchString(f.Column) {
+			return nil, fmt.Errorf("invalid field: %s", f.Column)
+		}
+
+		var condition interface{}
+		switch f.Operator {
+		case BrowseOpIsNull:
+			condition = nil
+		case BrowseOpNotNull:
+			condition = bson.M{"$ne": nil}
+		case BrowseOpContains:
+			condition = bson.M{"$regex": regexp.QuoteMeta(f.Value)}
+		case BrowseOpStartsWith:
+			condition = bson.M{"$regex": "^" + regexp.QuoteMeta(f.Value)}
+		case BrowseOpEquals:
+			condition = bson.M{"$in": mongoBrowseValues(f.Value)}
+		case BrowseOpNotEquals:
+			condition = bson.M{"$nin": mongoBrowseValues(f.Value)}
+		default:
+			values := mongoBrowseValues(f.Value)
+			condition = bson.M{browseMongoComparisons[f.Operator]: values[len(values)-1]}
+		}
+		conditions = append(conditions, bson.M{f.Column: condition})
+	}
+
+	// Filters are combined with $and so several of them can target the same field
+	filter := bson.M{}
+	if len(conditions) == 1 {
+		filter = conditions[0].(bson.M)
+	} else if len(conditions) > 1 {
+		filter = bson.M{"$and": conditions}
+	}
+
+	offset := (opts.Page - 1) * opts.PageSize
+	findOptions := options.Find().SetSkip(int64(offset)).SetLimit(int64(opts.PageSize + 1))
+	sortClause := ""
+	if opts.SortBy != "" {
+		if !mongoBrowseFieldRegex.MatchString(opts.SortBy) {
+			return nil, fmt.Errorf("invalid field: %s", opts.SortBy)
+		}
+		direction := 1
+		if opts.SortDesc {
+			direction = -1
+		}
+		findOptions.SetSort(bson.D{{Key: opts.SortBy, Value: direction}})
+		sortClause = fmt.Sprintf(".sort({%s: %d})", strconv.Quote(opts.SortBy), direction)
+	}
+
+	filterJSON, err := bson.MarshalExtJSON(filter, false, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build filter: %v", err)
+	}
+	query := fmt.Sprintf("db.%s.find(%s)%s.skip(%d).limit(%d)", collection, string(filterJSON), sortClause, offset, opts.PageSize)
+
+	startTime := time.Now()
+	coll := wrapper.Client.Database(wrapper.Database).Collection(collection)
+	cursor, err := coll.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to browse collection %s: %v", collection, err)
+	}
+	defer cursor.Close(ctx)
+
+	var documents []bson.M
+	if err := cursor.All(ctx, &documents); err != nil {
+		return nil, fmt.Errorf("failed to read documents of collection %s: %v", collection, err)
+	}
+
+	result := &BrowseResult{
+		Table:    collection,
+		Columns:  []string{},
+		Rows:     make([]map[string]interface{}, 0, len(documents)),
+		Page:     opts.Page,
+		PageSize: opts.PageSize,
+		Query:    query,
+	}
+	if len(documents) > opts.PageSize {
+		documents = documents[:opts.PageSize]
+		result.HasMore = true
+	}
+
+	// Documents don't share a fixed set of fields, the columns are the union of the fields of the page
+	seenFields := map[string]bool{}
+	for _, document := range documents {
+		for field := range document {
+			if !seenFields[field] {
+				seenFields[field] = true
+				result.Columns = append(result.Columns, field)
+			}
+		}
+		result.Rows = append(result.Rows, map[string]interface{}(document))
+	}
+
+	if opts.IncludeTotal {
+		total, err := coll.CountDocuments(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count documents of collection %s: %v", collection, err)
+		}
+		result.TotalCount = &total
+	}
+
+	result.ExecutionTime = int(time.Since(startTime).Milliseconds())
+	return result, nil
+}
+
+// mongoBrowseValues returns the filter value as a string followed by its typed form when it looks like a number, boolean or ObjectID
+func mongoBrowseValues(value string) []interface{} {
+	values := []interface{}{value}
+	if objectID, err := primitive.ObjectIDFromHex(value); err == nil {
+		values = append(values, objectID)
+	} else if intValue, err := strconv.ParseInt(value, 10, 64); err == nil {
+		values = append(values, intValue)
+	} else if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+		values = append(values, floatValue)
+	} else if boolValue, err := strconv.ParseBool(value); err == nil && (value == "true" || value == "false") {
+		values = append(values, boolValue)
+	}
+	return values
+}
@@ -0,0 +1,46 @@
+package dbmanager
+
+import (
+	"neobase-ai/internal/constants"
+)
+
+// ExtractReferencedTables returns the tables (collections for MongoDB) a query reads or writes, without their schema qualifier.
+// Queries that can't be parsed reference no table.
+func ExtractReferencedTables(dbType string, query string) []string {
+	tables := []string{}
+	if dbType == constants.DatabaseTypeMongoDB {
+		match := mongoLineageCallRegex.FindStringSubmatch(query)
+		if match == nil {
+			return tables
+		}
+		tables = appendUniqueStrings(tables, match[1]+match[2])
+		// $lookup, $graphLookup & $unionWith read other collections
+		for _, from := range mongoLineageFromRegex.FindAllStringSubmatch(query, -1) {
+			tables = appendUniqueStrings(tables, from[1])
+		}
+		if lineage := extractMongoDBLineage(query); lineage != nil {
+			tables = appendUniqueStrings(tables, lineage.TargetTable)
+		}
+		return tables
+	}
+
+	foldCase := dbType == constants.DatabaseTypePostgreSQL || dbType == constants.DatabaseTypeYugabyteDB
+	for _, tokens := range splitSQLTokens(tokenizeSQL(query, foldCase)) {
+		parser := &lineageParser{tokens: tokens}
+		if lineage := parser.parseStatement(); lineage != nil {
+			if lineage.TargetTable != "" {
+				tables = appendUniqueStrings(tables, unqualifiedTable(lineage.TargetTable))
+			}
+			for _, table := range lineageSourceTables(lineage.Columns, lineage.SourceTables) {
+				tables = appendUniqueStrings(tables, unqualifiedTable(table))
+			}
+			continue
+		}
+
+		// Reads, the CTEs of the statement are resolved to the tables they read
+		for _, table := range (&lineageParser{tokens: tokens}).parseSelect(tokens).tables {
+			tables = appendUniqueStrings(tables, unqualifiedTable(table))
+		}
+	}
+	return tables
+}
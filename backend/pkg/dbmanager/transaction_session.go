@@ -0,0 +1,491 @@
+package dbmanager
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/internal/constants"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Transaction sessions keep a transaction of a chat open across messages, its queries are committed or rolled back together.
+// They are bounded so a forgotten session does not hold its locks & its connection forever.
+const (
+	TransactionSessionEndedEvent         = "transaction-ended" // SSE event sent when a transaction session ends
+	DefaultTransactionSessionIdleTimeout = 5 * time.Minute
+	DefaultTransactionSessionMaxDuration = 30 * time.Minute
+	transactionSessionSavepoint          = "neobase_query"
+)
+
+// Reasons a transaction session ended, sent with the TransactionSessionEndedEvent
+const (
+	TransactionEndReasonCommitted    = "committed"
+	TransactionEndReasonRolledBack   = "rolled_back"
+	TransactionEndReasonExpired      = "expired"
+	TransactionEndReasonDisconnected = "disconnected"
+	TransactionEndReasonFailed       = "failed"
+)
+
+var (
+	transactionSessionIdleTimeout = DefaultTransactionSessionIdleTimeout
+	transactionSessionMaxDuration = DefaultTransactionSessionMaxDuration
+)
+
+// SetTransactionSessionLimits sets how long a transaction session may stay unused & open at most, zero keeps the default
+func SetTransactionSessionLimits(idleTimeout, maxDuration time.Duration) {
+	if idleTimeout <= 0 {
+		idleTimeout = DefaultTransactionSessionIdleTimeout
+	}
+	if maxDuration <= 0 {
+		maxDuration = DefaultTransactionSessionMaxDuration
+	}
+	transactionSessionIdleTimeout = idleTimeout
+	transactionSessionMaxDuration = max(maxDuration, idleTimeout)
+}
+
+// transactionSessionDatabases can hold a transaction across queries & undo a failed query alone with a savepoint
+var transactionSessionDatabases = map[string]bool{
+	constants.DatabaseTypePostgreSQL: true,
+	constants.DatabaseTypeYugabyteDB: true,
+	constants.DatabaseTypeMySQL:      true,
+	constants.DatabaseTypeMariaDB:    true,
+}
+
+// implicitCommitKeywords start the MySQL statements committing the transaction they run in
+var implicitCommitKeywords = map[string]bool{"CREATE": true, "ALTER": true, "DROP": true, "TRUNCATE": true, "RENAME": true}
+
+// TransactionSessionQuery is a query executed in a transaction session
+type TransactionSessionQuery struct {
+	MessageID  string    `json:"message_id"`
+	QueryID    string    `json:"query_id"`
+	Query      string    `json:"query"`
+	QueryType  string    `json:"query_type"`
+	IsRollback bool      `json:"is_rollback"`
+	ExecutedAt time.Time `json:"executed_at"`
+}
+
+// TransactionSessionInfo describes the open transaction session of a chat
+type TransactionSessionInfo struct {
+	ChatID     string                    `json:"chat_id"`
+	StreamID   string                    `json:"stream_id"`
+	StartedAt  time.Time                 `json:"started_at"`
+	LastUsedAt time.Time                 `json:"last_used_at"`
+	ExpiresAt  time.Time                 `json:"expires_at"` // When the session is rolled back unless used again or ended
+	Queries    []TransactionSessionQuery `json:"queries"`
+}
+
+// TransactionSessionEnd is the data of a TransactionSessionEndedEvent
+type TransactionSessionEnd struct {
+	ChatID  string                    `json:"chat_id"`
+	Reason  string                    `json:"reason"`
+	Error   string                    `json:"error,omitempty"`
+	Queries []TransactionSessionQuery `json:"queries"` // Committed, or undone by the rollback
+}
+
+// savepointExecer is implemented by the transactions of the databases holding transaction sessions
+type savepointExecer interface {
+	execSavepoint(ctx context.Context, statement string) error
+}
+
+// transactionSession is the open transaction of a chat, its queries run one at a time
+type transactionSession struct {
+	mu       sync.Mutex // Held while a query runs in the session & while it ends
+	ended    bool       // Guarded by mu
+	info     TransactionSessionInfo
+	userID   string
+	conn     *Connection
+	tx       Transaction
+	ctx      context.Context // Ended once the session ends, it interrupts the running query
+	cancel   context.CancelFunc
+	timer    *time.Timer
+	deadline time.Time
+}
+
+// snapshot returns the description of the session, called with the sessions lock held
+func (s *transactionSession) snapshot() TransactionSessionInfo {
+	info := s.info
+	info.Queries = append([]TransactionSessionQuery{}, s.info.Queries...)
+	return info
+}
+
+// StartTransactionSession opens a transaction on the chat's database, the queries of the chat run in it until it is committed,
+// rolled back or expires. streamID is where the end of the session is announced.
+func (m *Manager) StartTransactionSession(chatID, streamID string) (*TransactionSessionInfo, error) {
+	m.mu.RLock()
+	conn, exists := m.connections[chatID]
+	m.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("connection not found for chat %s", chatID)
+	}
+	if !transactionSessionDatabases[conn.Config.Type] {
+		return nil, fmt.Errorf("transactions across messages are not supported for %s connections", conn.Config.Type)
+	}
+	driver, exists := m.drivers[conn.Config.Type]
+	if !exists {
+		return nil, fmt.Errorf("no driver found for type %s", conn.Config.Type)
+	}
+
+	m.transactionSessionsMu.Lock()
+	_, open := m.transactionSessions[chatID]
+	m.transactionSessionsMu.Unlock()
+	if open {
+		return nil, fmt.Errorf("the chat already has an open transaction, commit or roll it back first")
+	}
+
+	// The transaction outlives the request starting it, it ends with the session
+	ctx, cancel := context.WithCancel(context.Background())
+	tx := driver.BeginTx(ctx, conn)
+	if tx == nil {
+		cancel()
+		return nil, fmt.Errorf("failed to start transaction")
+	}
+
+	now := time.Now()
+	session := &transactionSession{
+		info: TransactionSessionInfo{
+			ChatID:     chatID,
+			StreamID:   streamID,
+			StartedAt:  now,
+			LastUsedAt: now,
+			ExpiresAt:  now.Add(transactionSessionIdleTimeout),
+			Queries:    []TransactionSessionQuery{},
+		},
+		userID:   conn.UserID,
+		conn:     conn,
+		tx:       tx,
+		ctx:      ctx,
+		cancel:   cancel,
+		deadline: now.Add(transactionSessionMaxDuration),
+	}
+
+	m.transactionSessionsMu.Lock()
+	if _, open := m.transactionSessions[chatID]; open {
+		m.transactionSessionsMu.Unlock()
+		if err := tx.Rollback(); err != nil {
+			log.Printf("DBManager -> StartTransactionSession -> Error rolling back transaction: %v", err)
+		}
+		cancel()
+		return nil, fmt.Errorf("the chat already has an open transaction, commit or roll it back first")
+	}
+	m.transactionSessions[chatID] = session
+	session.timer = time.AfterFunc(session.info.ExpiresAt.Sub(now), func() {
+		m.expireTransactionSession(session)
+	})
+	info := session.snapshot()
+	m.transactionSessionsMu.Unlock()
+
+	log.Printf("DBManager -> StartTransactionSession -> Transaction opened for chatID %s, expires at %s", chatID, info.ExpiresAt.Format(time.RFC3339))
+	return &info, nil
+}
+
+// GetTransactionSession returns the open transaction session of the chat, nil when it has none
+func (m *Manager) GetTransactionSession(chatID string) *TransactionSessionInfo {
+	m.transactionSessionsMu.Lock()
+	defer m.transactionSessionsMu.Unlock()
+	session, exists := m.transactionSessions[chatID]
+	if !exists {
+		return nil
+	}
+	info := session.snapshot()
+	return &info
+}
+
+// CommitTransactionSession commits the open transaction of the chat, waiting for its running query
+func (m *Manager) CommitTransactionSession(chatID string) (*TransactionSessionEnd, error) {
+	return m.endTransactionSession(chatID, nil, TransactionEndReasonCommitted)
+}
+
+// RollbackTransactionSession rolls back the open transaction of the chat, its running query is stopped
+func (m *Manager) RollbackTransactionSession(chatID string) (*TransactionSessionEnd, error) {
+	return m.endTransactionSession(chatID, nil, TransactionEndReasonRolledBack)
+}
+
+// expireTransactionSession rolls back a session left unused for too long or open for longer than allowed
+func (m *Manager) expireTransactionSession(session *transactionSession) {
+	log.Printf("DBManager -> expireTransactionSession -> Transaction of chatID %s expired", session.info.ChatID)
+	if _, err := m.endTransactionSession(session.info.ChatID, session, TransactionEndReasonExpired); err != nil {
+		log.Printf("DBManager -> expireTransactionSession -> %v", err)
+	}
+}
+
+// endTransactionSession commits the session of the chat for the committed reason & rolls it back for the others. expected is
+// the session to end, any open session of the chat when nil.
+func (m *Manager) endTransactionSession(chatID string, expected *transactionSession, reason string) (*TransactionSessionEnd, error) {
+	m.transactionSessionsMu.Lock()
+	session, exists := m.transactionSessions[chatID]
+	if !exists || (expected != nil && session != expected) {
+		m.transactionSessionsMu.Unlock()
+		return nil, fmt.Errorf("the chat has no open transaction")
+	}
+	delete(m.transactionSessions, chatID)
+	session.timer.Stop()
+	m.transactionSessionsMu.Unlock()
+
+	// A rollback doesn't wait for the running query, it is stopped
+	if reason != TransactionEndReasonCommitted {
+		session.cancel()
+		cancelOnServer(session.tx)
+	}
+
+	session.mu.Lock()
+	session.ended = true
+	end := &TransactionSessionEnd{ChatID: chatID, Reason: reason}
+	var endErr error
+	if reason == TransactionEndReasonCommitted {
+		if endErr = session.tx.Commit(); endErr != nil {
+			end.Reason = TransactionEndReasonFailed
+			end.Error = endErr.Error()
+			if err := session.tx.Rollback(); err != nil {
+				log.Printf("DBManager -> endTransactionSession -> Error rolling back transaction: %v", err)
+			}
+		}
+	} else if err := session.tx.Rollback(); err != nil {
+		// The transaction is rolled back by its database once its connection is released
+		log.Printf("DBManager -> endTransactionSession -> Error rolling back transaction: %v", err)
+	}
+	session.cancel()
+	session.mu.Unlock()
+
+	m.transactionSessionsMu.Lock()
+	end.Queries = session.snapshot().Queries
+	m.transactionSessionsMu.Unlock()
+
+	log.Printf("DBManager -> endTransactionSession -> Transaction of chatID %s ended (%s) after %d queries", chatID, end.Reason, len(end.Queries))
+	m.transactionSessionEnded(session, end)
+	if endErr != nil {
+		return end, fmt.Errorf("failed to commit the transaction, it was rolled back: %v", endErr)
+	}
+	return end, nil
+}
+
+// transactionSessionEnded records the lineage of the committed queries & tells the user how the session ended
+func (m *Manager) transactionSessionEnded(session *transactionSession, end *TransactionSessionEnd) {
+	if end.Reason == TransactionEndReasonCommitted {
+		schemaChanged := false
+		for _, query := range end.Queries {
+			if m.streamHandler != nil {
+				go m.streamHandler.HandleQueryExecuted(end.ChatID, query.MessageID, query.QueryID, session.conn.Config.Type, query.Query, query.IsRollback)
+			}
+			if query.QueryType == "DDL" || query.QueryType == "ALTER" || query.QueryType == "DROP" {
+				schemaChanged = true
+			}
+		}
+		if schemaChanged && session.conn.OnSchemaChange != nil {
+			go session.conn.OnSchemaChange(end.ChatID)
+		}
+	}
+
+	if m.streamHandler == nil {
+		return
+	}
+	m.streamHandler.HandleTransactionSessionEnded(session.userID, end.ChatID, *end)
+	if session.info.StreamID != "" {
+		m.streamHandler.HandleDBEvent(session.userID, end.ChatID, session.info.StreamID, dtos.StreamResponse{
+			Event: TransactionSessionEndedEvent,
+			Data:  end,
+		})
+	}
+}
+
+// endTransactionSessions rolls back the session of the chat, e.g. when its database is disconnected
+func (m *Manager) endTransactionSessions(chatID, reason string) {
+	m.transactionSessionsMu.Lock()
+	_, exists := m.transactionSessions[chatID]
+	m.transactionSessionsMu.Unlock()
+	if !exists {
+		return
+	}
+	if _, err := m.endTransactionSession(chatID, nil, reason); err != nil {
+		log.Printf("DBManager -> endTransactionSessions -> %v", err)
+	}
+}
+
+// transactionSessionFor returns the open session of the chat, nil when it has none
+func (m *Manager) transactionSessionFor(chatID string) *transactionSession {
+	m.transactionSessionsMu.Lock()
+	defer m.transactionSessionsMu.Unlock()
+	return m.transactionSessions[chatID]
+}
+
+// executeInTransactionSession runs a query in the open transaction of its chat. The query runs behind a savepoint, a failed
+// or cancelled query is undone alone & the session goes on. The query is neither retried nor committed, it is with the session.
+func (m *Manager) executeInTransactionSession(execCtx context.Context, session *transactionSession, conn *Connection, messageID, queryID, query, queryType string, isRollback, findCount bool) (*QueryExecutionResult, *dtos.QueryError) {
+	if err := checkTransactionSessionQuery(conn.Config.Type, query); err != nil {
+		return nil, &dtos.QueryError{
+			Code:    "NOT_ALLOWED_IN_TRANSACTION",
+			Message: "query can't run in the open transaction",
+			Details: err.Error(),
+		}
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	if session.ended {
+		return nil, transactionSessionEndedError()
+	}
+	if !m.holdTransactionSession(session) {
+		return nil, transactionSessionEndedError()
+	}
+	defer m.releaseTransactionSession(session)
+
+	savepointer, ok := session.tx.(savepointExecer)
+	if !ok {
+		return nil, &dtos.QueryError{
+			Code:    "QUERY_EXECUTION_FAILED",
+			Message: "query execution failed",
+			Details: "The transaction does not support savepoints",
+		}
+	}
+	if err := savepointer.execSavepoint(execCtx, "SAVEPOINT "+transactionSessionSavepoint); err != nil {
+		go m.endTransactionSession(conn.ChatID, session, TransactionEndReasonFailed)
+		return nil, &dtos.QueryError{
+			Code:    "QUERY_EXECUTION_FAILED",
+			Message: "query execution failed",
+			Details: fmt.Sprintf("Failed to continue the transaction, it was rolled back: %v", err),
+		}
+	}
+
+	var statements []string
+	if !findCount {
+		statements = scriptStatements(conn.Config.Type, query)
+	}
+	originalQuery := query
+	query = m.prepareAuditedQuery(conn, conn.ChatID, messageID, queryID, query)
+	query = withStatementTimeout(execCtx, conn.Config.Type, query, true)
+
+	done := make(chan struct{})
+	var result *QueryExecutionResult
+	go func() {
+		defer close(done)
+		log.Printf("Manager -> executeInTransactionSession -> Executing query in the transaction of chatID %s: %v", conn.ChatID, query)
+		if len(statements) > 0 && strings.HasSuffix(query, originalQuery) {
+			result = executeScript(execCtx, session.tx, conn, strings.TrimSuffix(query, originalQuery), statements, queryType)
+		} else {
+			result = session.tx.ExecuteQuery(execCtx, conn, query, queryType, findCount)
+		}
+	}()
+
+	var queryErr *dtos.QueryError
+	select {
+	case <-done:
+		if result == nil {
+			queryErr = &dtos.QueryError{Code: "QUERY_EXECUTION_FAILED", Message: "query execution failed"}
+		} else {
+			queryErr = result.Error
+		}
+	case <-execCtx.Done():
+		cancelOnServer(session.tx)
+		<-done
+		queryErr = executionStoppedError(execCtx)
+	case <-session.ctx.Done():
+		cancelOnServer(session.tx)
+		<-done
+		return nil, transactionSessionEndedError()
+	}
+
+	// The savepoint statements outlive the execution context, a cancelled query is undone too
+	savepointCtx, cancel := context.WithTimeout(session.ctx, serverCancelTimeout)
+	defer cancel()
+	if queryErr != nil {
+		if err := savepointer.execSavepoint(savepointCtx, "ROLLBACK TO SAVEPOINT "+transactionSessionSavepoint); err != nil {
+			log.Printf("Manager -> executeInTransactionSession -> Failed to undo the query: %v", err)
+			go m.endTransactionSession(conn.ChatID, session, TransactionEndReasonFailed)
+			queryErr = &dtos.QueryError{
+				Code:    queryErr.Code,
+				Message: queryErr.Message,
+				Details: fmt.Sprintf("%s (the transaction could not continue & was rolled back)", queryErr.Details),
+			}
+		}
+		return result, queryErr
+	}
+	if err := savepointer.execSavepoint(savepointCtx, "RELEASE SAVEPOINT "+transactionSessionSavepoint); err != nil {
+		log.Printf("Manager -> executeInTransactionSession -> Failed to release the savepoint: %v", err)
+	}
+
+	if !findCount {
+		m.transactionSessionsMu.Lock()
+		session.info.Queries = append(session.info.Queries, TransactionSessionQuery{
+			MessageID:  messageID,
+			QueryID:    queryID,
+			Query:      originalQuery,
+			QueryType:  queryType,
+			IsRollback: isRollback,
+			ExecutedAt: time.Now(),
+		})
+		m.transactionSessionsMu.Unlock()
+	}
+	return result, nil
+}
+
+// holdTransactionSession keeps the session from expiring while a query runs in it, false when it is ending or past its maximum
+// duration
+func (m *Manager) holdTransactionSession(session *transactionSession) bool {
+	m.transactionSessionsMu.Lock()
+	defer m.transactionSessionsMu.Unlock()
+	if m.transactionSessions[session.info.ChatID] != session || !time.Now().Before(session.deadline) {
+		return false
+	}
+	session.timer.Stop()
+	return true
+}
+
+// releaseTransactionSession restarts the idle timeout of the session once its query ended
+func (m *Manager) releaseTransactionSession(session *transactionSession) {
+	m.transactionSessionsMu.Lock()
+	defer m.transactionSessionsMu.Unlock()
+	if m.transactionSessions[session.info.ChatID] != session {
+		return
+	}
+	now := time.Now()
+	session.info.LastUsedAt = now
+	session.info.ExpiresAt = now.Add(transactionSessionIdleTimeout)
+	if session.info.ExpiresAt.After(session.deadline) {
+		session.info.ExpiresAt = session.deadline
+	}
+	session.timer.Reset(session.info.ExpiresAt.Sub(now))
+}
+
+// checkTransactionSessionQuery refuses the statements ending the transaction of the session: its own transaction control, and
+// on MySQL & MariaDB the DDL statements committing it implicitly
+func checkTransactionSessionQuery(dbType, query string) error {
+	foldCase := dbType == constants.DatabaseTypePostgreSQL || dbType == constants.DatabaseTypeYugabyteDB
+	mysql := dbType == constants.DatabaseTypeMySQL || dbType == constants.DatabaseTypeMariaDB
+	for _, tokens := range splitSQLTokens(tokenizeSQL(query, foldCase)) {
+		if len(tokens) == 0 || tokens[0].kind != sqlTokenIdent {
+			continue
+		}
+		keyword := strings.ToUpper(tokens[0].text)
+		if scriptTransactionKeywords[keyword] {
+			return fmt.Errorf("%s is not allowed in the open transaction, commit or roll it back with the transaction actions", keyword)
+		}
+		if mysql && implicitCommitKeywords[keyword] {
+			return fmt.Errorf("%s statements commit the open transaction on %s, commit or roll it back before running them", keyword, dbType)
+		}
+	}
+	return nil
+}
+
+func transactionSessionEndedError() *dtos.QueryError {
+	return &dtos.QueryError{
+		Code:    "TRANSACTION_ENDED",
+		Message: "the transaction ended",
+		Details: "The open transaction of the chat ended before the query ran, it was rolled back",
+	}
+}
+
+// execSavepoint runs a savepoint statement in the transaction
+func (t *PostgresTransaction) execSavepoint(ctx context.Context, statement string) error {
+	_, err := t.tx.ExecContext(ctx, statement)
+	return err
+}
+
+// execSavepoint runs a savepoint statement in the transaction
+func (t *MySQLTransaction) execSavepoint(ctx context.Context, statement string) error {
+	if t.tx == nil {
+		return fmt.Errorf("no active transaction")
+	}
+	return t.tx.WithContext(ctx).Exec(statement).Error
+}
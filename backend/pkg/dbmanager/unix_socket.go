@@ -0,0 +1,71 @@
+package dbmanager
+
+import (
+	"fmt"
+	"neobase-ai/internal/constants"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	defaultPostgresPort = "5432"
+	// PostgreSQL names its socket file .s.PGSQL.<port> inside the socket directory
+	postgresSocketFilePrefix = ".s.PGSQL."
+)
+
+// UsesUnixSocket returns true when the connection goes through a Unix socket instead of host & port
+func UsesUnixSocket(config ConnectionConfig) bool {
+	return config.SocketPath != nil && strings.TrimSpace(*config.SocketPath) != ""
+}
+
+// ValidateSocketPath checks the Unix socket path can be used by the database type
+func ValidateSocketPath(config ConnectionConfig) error {
+	if !UsesUnixSocket(config) {
+		return nil
+	}
+
+	switch config.Type {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeMySQL:
+	default:
+		return fmt.Errorf("unix socket connections are not supported for %s connections", config.Type)
+	}
+
+	socketPath := *config.SocketPath
+	if !filepath.IsAbs(socketPath) {
+		return fmt.Errorf("socket path must be an absolute path")
+	}
+	// The path is embedded in the DSN, characters used by the DSN syntax would change its meaning
+	if strings.ContainsAny(socketPath, " '\"\\()?&=\t\n") {
+		return fmt.Errorf("socket path contains unsupported characters")
+	}
+
+	// The server does not negotiate SSL over a local socket
+	if config.UseSSL && (config.SSLMode == nil || *config.SSLMode != "disable") {
+		return fmt.Errorf("SSL is not supported over unix socket connections")
+	}
+	return nil
+}
+
+// postgresSocketHostPort returns the socket directory & port for lib/pq, which expects the directory as host.
+// Both the directory (e.g. /var/run/postgresql) and the socket file (e.g. /var/run/postgresql/.s.PGSQL.5432) are accepted.
+func postgresSocketHostPort(config ConnectionConfig) (string, string) {
+	socketPath := filepath.Clean(*config.SocketPath)
+
+	if base := filepath.Base(socketPath); strings.HasPrefix(base, postgresSocketFilePrefix) {
+		return filepath.Dir(socketPath), strings.TrimPrefix(base, postgresSocketFilePrefix)
+	}
+
+	port := defaultPostgresPort
+	if config.Port != nil && *config.Port != "" {
+		port = *config.Port
+	}
+	return socketPath, port
+}
+
+// mysqlAddress returns the network address part of a MySQL DSN, e.g. tcp(host:port) or unix(/path/mysqld.sock)
+func mysqlAddress(config ConnectionConfig, port string) string {
+	if UsesUnixSocket(config) {
+		return fmt.Sprintf("unix(%s)", filepath.Clean(*config.SocketPath))
+	}
+	return fmt.Sprintf("tcp(%s:%s)", config.Host, port)
+}
@@ -0,0 +1,181 @@
+package dbmanager
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"neobase-ai/internal/apis/dtos"
+	"neobase-ai/pkg/xlsx"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Sheets of an XLSX export
+const (
+	xlsxResultsSheet  = "Results"
+	xlsxMetadataSheet = "Metadata"
+)
+
+// ExportMetadata is a line of the metadata sheet of an XLSX export
+type ExportMetadata struct {
+	Name  string
+	Value interface{} // typed like the cells of the results, e.g. a time.Time is a date
+}
+
+// ExportQueryXLSX writes the result of a read-only query to w as an XLSX workbook. The Results sheet has a typed column per
+// column of the result, the Metadata sheet has the given lines followed by the connection & the number of rows. The rows beyond
+// the sheet limit of Excel are left out, the metadata tells it. Returns the number of rows written.
+func (m *Manager) ExportQueryXLSX(ctx context.Context, chatID, query string, metadata []ExportMetadata, w io.Writer) (int64, *dtos.QueryError) {
+	writer := &xlsxResultWriter{workbook: xlsx.NewWriter(w)}
+	summary, queryErr := m.exportQuery(ctx, chatID, query, xlsx.MaxRows-1, writer)
+	if queryErr != nil {
+		return summary.rows, queryErr
+	}
+
+	config := summary.conn.Config
+	metadata = append(metadata,
+		ExportMetadata{Name: "Database type", Value: config.Type},
+		ExportMetadata{Name: "Host", Value: config.Host},
+		ExportMetadata{Name: "Database", Value: config.Database},
+		ExportMetadata{Name: "Read replica", Value: summary.onReplica},
+		ExportMetadata{Name: "Rows", Value: summary.rows},
+		ExportMetadata{Name: "Exported at", Value: time.Now().UTC()},
+	)
+	if summary.truncated {
+		metadata = append(metadata, ExportMetadata{
+			Name:  "Truncated",
+			Value: fmt.Sprintf("Only the first %d rows were exported, the limit of a sheet", summary.rows),
+		})
+	}
+
+	if err := writer.writeMetadata(metadata); err != nil {
+		return summary.rows, &dtos.QueryError{
+			Code:    "EXPORT_FAILED",
+			Message: "failed to export the results",
+			Details: err.Error(),
+		}
+	}
+	return summary.rows, nil
+}
+
+// xlsxResultWriter writes a result in the Results sheet, the sheet is started with the header so that nothing is written
+// to the client before the query succeeded
+type xlsxResultWriter struct {
+	workbook *xlsx.Writer
+	format   exportFormat
+	columns  []exportColumn
+	started  bool
+	row      []interface{}
+}
+
+func (w *xlsxResultWriter) writeHeader(columns []exportColumn, format exportFormat) error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	w.columns = columns
+	w.format = format
+	w.row = make([]interface{}, len(columns))
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.name
+	}
+	return w.workbook.WriteHeader(names)
+}
+
+func (w *xlsxResultWriter) writeRow(values []interface{}) error {
+	for i, value := range values {
+		w.row[i] = w.cellValue(value, w.columns[i])
+	}
+	return w.workbook.WriteRow(w.row)
+}
+
+func (w *xlsxResultWriter) flush() error {
+	return w.workbook.Flush()
+}
+
+func (w *xlsxResultWriter) start() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+	return w.workbook.AddSheet(xlsxResultsSheet)
+}
+
+// writeMetadata adds the metadata sheet & ends the workbook, the results sheet is added empty when the result had no header
+func (w *xlsxResultWriter) writeMetadata(metadata []ExportMetadata) error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	if err := w.workbook.AddSheet(xlsxMetadataSheet); err != nil {
+		return err
+	}
+	if err := w.workbook.WriteHeader([]string{"Name", "Value"}); err != nil {
+		return err
+	}
+	for _, line := range metadata {
+		if err := w.workbook.WriteRow([]interface{}{line.Name, line.Value}); err != nil {
+			return err
+		}
+	}
+	return w.workbook.Close()
+}
+
+// cellValue types a value read from the database, the numbers & the times drivers return as text are parsed
+// according to the type of their column
+func (w *xlsxResultWriter) cellValue(value interface{}, column exportColumn) interface{} {
+	switch v := value.(type) {
+	case nil, primitive.Null, primitive.Undefined:
+		return nil
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, time.Time:
+		return v
+	case primitive.DateTime:
+		return v.Time().UTC()
+	case []byte:
+		if utf8.Valid(v) {
+			return typedText(string(v), column)
+		}
+	case string:
+		return typedText(v, column)
+	}
+	return w.format.text(value, column)
+}
+
+// typedText returns the number or the time held by the text of a numeric or temporal column, the text itself otherwise.
+// The numbers with more significant digits than Excel keeps stay text.
+func typedText(text string, column exportColumn) interface{} {
+	switch column.kind() {
+	case kindInteger, kindUnsigned, kindFloat, kindDecimal:
+		number, err := strconv.ParseFloat(text, 64)
+		if err != nil || significantDigits(text) > 15 {
+			return text
+		}
+		return number
+	case kindDate, kindTimestamp:
+		if parsed, ok := parseTimeText(text); ok {
+			return parsed
+		}
+	}
+	return text
+}
+
+// significantDigits counts the digits of a number from the first non-zero one, the ones of the exponent & the trailing
+// zeros of the decimals excluded
+func significantDigits(text string) int {
+	if i := strings.IndexAny(text, "eE"); i >= 0 {
+		text = text[:i]
+	}
+	if strings.Contains(text, ".") {
+		text = strings.TrimRight(text, "0")
+	}
+	digits := strings.TrimLeft(strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, text), "0")
+	return len(digits)
+}
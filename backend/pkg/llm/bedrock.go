@@ -0,0 +1,244 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"neobase-ai/internal/constants"
+	"neobase-ai/internal/models"
+	"neobase-ai/pkg/sigv4"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// BedrockClient calls the models of AWS Bedrock (Claude, Titan...) through the Converse API, inference stays in the AWS account
+type BedrockClient struct {
+	httpClient          *http.Client
+	credentials         sigv4.Credentials
+	region              string
+	model               string
+	maxCompletionTokens int
+	temperature         float64
+	DBConfigs           []LLMDBConfig
+}
+
+type bedrockContentBlock struct {
+	Text    string          `json:"text,omitempty"`
+	ToolUse *bedrockToolUse `json:"toolUse,omitempty"`
+}
+
+type bedrockToolUse struct {
+	ToolUseID string          `json:"toolUseId"`
+	Name      string          `json:"name"`
+	Input     json.RawMessage `json:"input"`
+}
+
+type bedrockMessage struct {
+	Role    string                `json:"role"`
+	Content []bedrockContentBlock `json:"content"`
+}
+
+type bedrockConverseRequest struct {
+	Messages        []bedrockMessage       `json:"messages"`
+	System          []bedrockContentBlock  `json:"system,omitempty"`
+	InferenceConfig map[string]interface{} `json:"inferenceConfig,omitempty"`
+	ToolConfig      map[string]interface{} `json:"toolConfig,omitempty"`
+}
+
+type bedrockConverseResponse struct {
+	Output struct {
+		Message bedrockMessage `json:"message"`
+	} `json:"output"`
+	StopReason string `json:"stopReason"`
+	Usage      struct {
+		InputTokens  int `json:"inputTokens"`
+		OutputTokens int `json:"outputTokens"`
+	} `json:"usage"`
+	Message string `json:"message,omitempty"` // Error message
+}
+
+func NewBedrockClient(config Config) (*BedrockClient, error) {
+	if config.APIKey == "" || config.SecretKey == "" {
+		return nil, fmt.Errorf("AWS access key ID and secret access key are required for Bedrock")
+	}
+	if config.Region == "" {
+		return nil, fmt.Errorf("AWS region is required for Bedrock")
+	}
+
+	model := config.Model
+	if model == "" {
+		model = constants.BedrockModel
+	}
+	maxCompletionTokens := config.MaxCompletionTokens
+	if maxCompletionTokens <= 0 {
+		maxCompletionTokens = constants.BedrockMaxCompletionTokens
+	}
+
+	return &BedrockClient{
+		httpClient: &http.Client{Timeout: 5 * time.Minute},
+		credentials: sigv4.Credentials{
+			AccessKeyID:     config.APIKey,
+			SecretAccessKey: config.SecretKey,
+			SessionToken:    config.SessionToken,
+		},
+		region:              config.Region,
+		model:               model,
+		maxCompletionTokens: maxCompletionTokens,
+		// Claude & Titan accept temperatures between 0 & 1
+		temperature: min(max(config.Temperature, 0), 1),
+		DBConfigs:   config.DBConfigs,
+	}, nil
+}
+
+func (c *BedrockClient) GenerateResponse(ctx context.Context, messages []*models.LLMMessage, dbType string) (string, error) {
+	// Check if the context is cancelled
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+
+	systemPrompt := ""
+	responseSchema := ""
+
+	for _, dbConfig := range c.DBConfigs {
+		if dbConfig.DBType == dbType {
+			systemPrompt = dbConfig.SystemPrompt
+			responseSchema = dbConfig.Schema.(string)
+			break
+		}
+	}
+	systemPrompt = systemPromptFor(ctx, "bedrock", dbType, systemPrompt)
+
+	// Bedrock requires alternating turns starting with a user turn, as Claude
+	bedrockMessages := make([]bedrockMessage, 0, len(messages))
+	for _, msg := range claudeConversation(messages) {
+		bedrockMessages = append(bedrockMessages, bedrockMessage{
+			Role:    msg.Role,
+			Content: []bedrockContentBlock{{Text: msg.Content}},
+		})
+	}
+
+	// The chat may override the sampling, Claude & Titan accept temperatures between 0 & 1
+	sampling := samplingFor(ctx, c.temperature, c.maxCompletionTokens, 1)
+	req := bedrockConverseRequest{
+		Messages: bedrockMessages,
+		InferenceConfig: map[string]interface{}{
+			"maxTokens":   sampling.maxTokens,
+			"temperature": sampling.temperature,
+		},
+	}
+	if sampling.topP != nil {
+		req.InferenceConfig["topP"] = *sampling.topP
+	}
+
+	// Claude answers with a forced tool call taking the response schema as input,
+	// Titan has neither tools nor system prompts so the schema is asked in the first user turn
+	if c.isAnthropicModel() {
+		req.System = []bedrockContentBlock{{
+			Text: systemPrompt + fmt.Sprintf("\n\nAlways answer by calling the %s tool, its input is your whole response.", claudeResponseTool),
+		}}
+		if responseSchema != "" {
+			req.ToolConfig = map[string]interface{}{
+				"tools": []map[string]interface{}{{
+					"toolSpec": map[string]interface{}{
+						"name":        claudeResponseTool,
+						"description": "A friendly AI Response/Explanation or clarification question (Must Send this)",
+						"inputSchema": map[string]interface{}{"json": json.RawMessage(responseSchema)},
+					},
+				}},
+				"toolChoice": map[string]interface{}{"tool": map[string]string{"name": claudeResponseTool}},
+			}
+		}
+	} else {
+		instructions := systemPrompt
+		if responseSchema != "" {
+			instructions += fmt.Sprintf("\n\nRespond only with a JSON object matching this JSON schema, without any other text:\n%s", responseSchema)
+		}
+		req.Messages[0].Content[0].Text = instructions + "\n\n" + req.Messages[0].Content[0].Text
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal Bedrock request: %v", err)
+	}
+
+	// Model IDs contain colons, e.g. anthropic.claude-3-5-sonnet-20240620-v1:0, they are escaped in the path
+	endpoint := fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com/model/%s/converse", c.region, sigv4.URIEncode(c.model, true))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create Bedrock request: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+	sigv4.SignRequest(httpReq, sigv4.PayloadHash(body), c.credentials, c.region, "bedrock", time.Now())
+
+	// Call Bedrock API
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		log.Printf("BEDROCK -> GenerateResponse -> err: %v", err)
+		return "", fmt.Errorf("bedrock API error: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read Bedrock response: %v", err)
+	}
+
+	var resp bedrockConverseResponse
+	unmarshalErr := json.Unmarshal(respBody, &resp)
+	if httpResp.StatusCode != http.StatusOK {
+		return "", &StatusError{Provider: "bedrock", StatusCode: httpResp.StatusCode, Message: resp.Message}
+	}
+	if unmarshalErr != nil {
+		return "", fmt.Errorf("invalid Bedrock response: %v", unmarshalErr)
+	}
+	recordUsage(ctx, c.GetModelInfo(), resp.Usage.InputTokens, resp.Usage.OutputTokens)
+
+	log.Printf("BEDROCK -> GenerateResponse -> stopReason: %s", resp.StopReason)
+	// A response cut by maxTokens is an incomplete JSON
+	if resp.StopReason == "max_tokens" {
+		return "", fmt.Errorf("bedrock response exceeded the %d max tokens", sampling.maxTokens)
+	}
+
+	responseText := ""
+	for _, block := range resp.Output.Message.Content {
+		if block.ToolUse != nil && len(block.ToolUse.Input) > 0 {
+			responseText = string(block.ToolUse.Input)
+			break
+		}
+		responseText += block.Text
+	}
+	responseText = strings.TrimSpace(responseText)
+	responseText = strings.TrimPrefix(responseText, "```json")
+	responseText = strings.TrimPrefix(responseText, "```")
+	responseText = strings.TrimSuffix(responseText, "```")
+	if responseText == "" {
+		return "", fmt.Errorf("no response from Bedrock")
+	}
+
+	// Validate response against schema
+	var llmResponse constants.LLMResponse
+	if err := json.Unmarshal([]byte(responseText), &llmResponse); err != nil {
+		return "", &InvalidResponseError{Response: responseText, Err: err}
+	}
+
+	return responseText, nil
+}
+
+// isAnthropicModel reports whether the model is a Claude model, including the cross-region inference profiles, e.g. us.anthropic.claude-...
+func (c *BedrockClient) isAnthropicModel() bool {
+	return strings.Contains(c.model, "anthropic.")
+}
+
+func (c *BedrockClient) GetModelInfo() ModelInfo {
+	return ModelInfo{
+		Name:                c.model,
+		Provider:            "bedrock",
+		MaxCompletionTokens: c.maxCompletionTokens,
+		ContextLimit:        constants.GetLLMContextLimit(c.model),
+	}
+}
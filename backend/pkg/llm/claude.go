@@ -0,0 +1,517 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"neobase-ai/internal/constants"
+	"neobase-ai/internal/models"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	claudeMessagesURL    = "https://api.anthropic.com/v1/messages"
+	claudeAPIVersion     = "2023-06-01"
+	claudeResponseTool   = "neobase_response"
+	claudeRequestTimeout = 5 * time.Minute
+)
+
+type ClaudeClient struct {
+	httpClient          *http.Client
+	apiKey              string
+	baseURL             string
+	model               string
+	maxCompletionTokens int
+	temperature         float64
+	DBConfigs           []LLMDBConfig
+}
+
+type claudeMessage struct {
+	Role    string
+	Content string
+	Blocks  []claudeContentBlock // Content of the turns calling tools or returning their results, replacing the text
+}
+
+func (m claudeMessage) MarshalJSON() ([]byte, error) {
+	if m.Blocks != nil {
+		return json.Marshal(struct {
+			Role    string               `json:"role"`
+			Content []claudeContentBlock `json:"content"`
+		}{m.Role, m.Blocks})
+	}
+	return json.Marshal(struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}{m.Role, m.Content})
+}
+
+// claudeContentBlock is a block of a message: text, a tool call (tool_use) or the result of a call (tool_result)
+type claudeContentBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+}
+
+type claudeTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+type claudeMessagesRequest struct {
+	Model       string                 `json:"model"`
+	System      string                 `json:"system,omitempty"`
+	Messages    []claudeMessage        `json:"messages"`
+	MaxTokens   int                    `json:"max_tokens"`
+	Temperature float64                `json:"temperature"`
+	TopP        *float64               `json:"top_p,omitempty"`
+	Tools       []claudeTool           `json:"tools,omitempty"`
+	ToolChoice  map[string]interface{} `json:"tool_choice,omitempty"`
+	Stream      bool                   `json:"stream,omitempty"`
+}
+
+type claudeMessagesResponse struct {
+	Content    []claudeContentBlock `json:"content"`
+	StopReason string               `json:"stop_reason"`
+	Usage      claudeUsage          `json:"usage"`
+	Error      *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// claudeUsage is the number of tokens of a message, the output tokens of a stream are the ones of its last message_delta
+type claudeUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// claudeStreamEvent is the data of a server-sent event of a streamed message
+type claudeStreamEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Type        string `json:"type"`
+		Text        string `json:"text,omitempty"`
+		PartialJSON string `json:"partial_json,omitempty"`
+		StopReason  string `json:"stop_reason,omitempty"`
+	} `json:"delta"`
+	Message struct {
+		Usage claudeUsage `json:"usage"`
+	} `json:"message"` // message_start only
+	Usage claudeUsage `json:"usage"` // message_delta only
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+func NewClaudeClient(config Config) (*ClaudeClient, error) {
+	if config.APIKey == "" {
+		return nil, fmt.Errorf("claude API key is required")
+	}
+
+	model := config.Model
+	if model == "" {
+		model = constants.ClaudeModel
+	}
+	baseURL := claudeMessagesURL
+	if config.BaseURL != "" {
+		baseURL = strings.TrimRight(config.BaseURL, "/") + "/v1/messages"
+	}
+
+	// max_tokens is required by Claude & rejected above the output limit of the model
+	maxCompletionTokens := config.MaxCompletionTokens
+	if maxCompletionTokens <= 0 {
+		maxCompletionTokens = constants.ClaudeMaxCompletionTokens
+	}
+	maxCompletionTokens = min(maxCompletionTokens, constants.ClaudeMaxOutputTokens)
+
+	// Claude accepts temperatures between 0 & 1, the other providers up to 2
+	temperature := min(max(config.Temperature, 0), 1)
+
+	return &ClaudeClient{
+		httpClient:          &http.Client{Timeout: claudeRequestTimeout},
+		apiKey:              config.APIKey,
+		baseURL:             baseURL,
+		model:               model,
+		maxCompletionTokens: maxCompletionTokens,
+		temperature:         temperature,
+		DBConfigs:           config.DBConfigs,
+	}, nil
+}
+
+func (c *ClaudeClient) GenerateResponse(ctx context.Context, messages []*models.LLMMessage, dbType string) (string, error) {
+	// Check if the context is cancelled
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+
+	req := c.buildRequest(ctx, messages, dbType)
+	resp, err := c.createMessageWithTools(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	log.Printf("CLAUDE -> GenerateResponse -> stop_reason: %s", resp.StopReason)
+	// A response cut by max_tokens is an incomplete JSON
+	if resp.StopReason == "max_tokens" {
+		return "", fmt.Errorf("claude response exceeded the %d max tokens", req.MaxTokens)
+	}
+
+	responseText := ""
+	for _, block := range resp.Content {
+		if block.Type == "tool_use" && block.Name == claudeResponseTool && len(block.Input) > 0 {
+			responseText = string(block.Input)
+			break
+		}
+		if block.Type == "text" {
+			responseText += block.Text
+		}
+	}
+	return claudeResponseText(responseText)
+}
+
+// GenerateResponseStream streams the message, the input of the response tool is streamed as partial JSON
+func (c *ClaudeClient) GenerateResponseStream(ctx context.Context, messages []*models.LLMMessage, dbType string, onChunk func(chunk string)) (string, error) {
+	// Check if the context is cancelled
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+
+	// The rounds of tool calls are not streamed, the assistant message is sent at once with the response
+	if _, _, ok := toolsFor(ctx); ok {
+		response, err := c.GenerateResponse(ctx, messages, dbType)
+		if err == nil {
+			streamAssistantMessage(response, onChunk)
+		}
+		return response, err
+	}
+
+	req := c.buildRequest(ctx, messages, dbType)
+	req.Stream = true
+	httpResp, err := c.postMessages(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		var resp claudeMessagesResponse
+		respBody, _ := io.ReadAll(httpResp.Body)
+		_ = json.Unmarshal(respBody, &resp)
+		return "", claudeStatusError(httpResp.StatusCode, resp)
+	}
+
+	// Each server-sent event has a data line, the deltas of the tool input & of the text are assembled
+	streamer := newAssistantMessageStreamer(onChunk)
+	stopReason := ""
+	var usage claudeUsage
+	scanner := bufio.NewScanner(httpResp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+
+		var event claudeStreamEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &event); err != nil {
+			return "", fmt.Errorf("invalid Claude stream event: %v", err)
+		}
+		switch event.Type {
+		case "message_start":
+			usage.InputTokens = event.Message.Usage.InputTokens
+		case "content_block_delta":
+			switch event.Delta.Type {
+			case "input_json_delta":
+				streamer.Write(event.Delta.PartialJSON)
+			case "text_delta":
+				streamer.Write(event.Delta.Text)
+			}
+		case "message_delta":
+			usage.OutputTokens = event.Usage.OutputTokens
+			if event.Delta.StopReason != "" {
+				stopReason = event.Delta.StopReason
+			}
+		case "error":
+			if event.Error != nil {
+				return "", fmt.Errorf("claude API error (%s): %s", event.Error.Type, event.Error.Message)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read Claude response: %v", err)
+	}
+	recordUsage(ctx, c.GetModelInfo(), usage.InputTokens, usage.OutputTokens)
+
+	log.Printf("CLAUDE -> GenerateResponseStream -> stop_reason: %s", stopReason)
+	// A response cut by max_tokens is an incomplete JSON
+	if stopReason == "max_tokens" {
+		return "", fmt.Errorf("claude response exceeded the %d max tokens", req.MaxTokens)
+	}
+	return claudeResponseText(streamer.Response())
+}
+
+// buildRequest converts the messages to a request answering with the response tool of the database type
+func (c *ClaudeClient) buildRequest(ctx context.Context, messages []*models.LLMMessage, dbType string) claudeMessagesRequest {
+	systemPrompt := ""
+	responseSchema := ""
+
+	for _, dbConfig := range c.DBConfigs {
+		if dbConfig.DBType == dbType {
+			systemPrompt = dbConfig.SystemPrompt
+			responseSchema = dbConfig.Schema.(string)
+			break
+		}
+	}
+	systemPrompt = systemPromptFor(ctx, "claude", dbType, systemPrompt)
+
+	// The system prompt is a parameter of the request, not a message
+	systemPrompt += fmt.Sprintf("\n\nAlways answer by calling the %s tool, its input is your whole response.", claudeResponseTool)
+
+	claudeMessages := claudeConversation(messages)
+
+	// The chat may override the sampling, within the temperatures & output tokens Claude accepts
+	sampling := samplingFor(ctx, c.temperature, c.maxCompletionTokens, 1)
+
+	// The response schema is the input of a tool Claude is forced to call, its input is the JSON response
+	req := claudeMessagesRequest{
+		Model:       c.model,
+		System:      systemPrompt,
+		Messages:    claudeMessages,
+		MaxTokens:   min(sampling.maxTokens, constants.ClaudeMaxOutputTokens),
+		Temperature: sampling.temperature,
+		TopP:        sampling.topP,
+	}
+	if responseSchema != "" {
+		req.Tools = []claudeTool{{
+			Name:        claudeResponseTool,
+			Description: "A friendly AI Response/Explanation or clarification question (Must Send this)",
+			InputSchema: json.RawMessage(responseSchema),
+		}}
+		req.ToolChoice = map[string]interface{}{"type": "tool", "name": claudeResponseTool}
+	}
+	return req
+}
+
+// createMessage sends a request to the Messages API & decodes the message answering it
+func (c *ClaudeClient) createMessage(ctx context.Context, req claudeMessagesRequest) (*claudeMessagesResponse, error) {
+	httpResp, err := c.postMessages(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Claude response: %v", err)
+	}
+
+	var resp claudeMessagesResponse
+	unmarshalErr := json.Unmarshal(respBody, &resp)
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, claudeStatusError(httpResp.StatusCode, resp)
+	}
+	if unmarshalErr != nil {
+		return nil, fmt.Errorf("invalid Claude response: %v", unmarshalErr)
+	}
+	if resp.Error != nil {
+		return nil, fmt.Errorf("claude API error (%s): %s", resp.Error.Type, resp.Error.Message)
+	}
+	recordUsage(ctx, c.GetModelInfo(), resp.Usage.InputTokens, resp.Usage.OutputTokens)
+	return &resp, nil
+}
+
+// createMessageWithTools lets Claude call the tools of the context before it answers by calling the response tool.
+// Once the rounds of calls are used up, Claude is forced to call the response tool.
+func (c *ClaudeClient) createMessageWithTools(ctx context.Context, req claudeMessagesRequest) (*claudeMessagesResponse, error) {
+	executor, maxRounds, ok := toolsFor(ctx)
+	if !ok || len(req.Tools) == 0 {
+		return c.createMessage(ctx, req)
+	}
+
+	req.System += toolsPrompt
+	for _, tool := range executor.Tools() {
+		req.Tools = append(req.Tools, claudeTool{
+			Name:        tool.Name,
+			Description: tool.Description,
+			InputSchema: tool.Parameters,
+		})
+	}
+	req.ToolChoice = map[string]interface{}{"type": "any"}
+
+	for round := 1; ; round++ {
+		resp, err := c.createMessage(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		calls := make([]claudeContentBlock, 0)
+		assistantBlocks := make([]claudeContentBlock, 0, len(resp.Content))
+		for _, block := range resp.Content {
+			if block.Type == "tool_use" && block.Name == claudeResponseTool {
+				return resp, nil
+			}
+			if block.Type == "tool_use" {
+				calls = append(calls, block)
+			}
+			// Empty text blocks are rejected when sent back
+			if block.Type != "text" || block.Text != "" {
+				assistantBlocks = append(assistantBlocks, block)
+			}
+		}
+		if len(calls) == 0 || resp.StopReason == "max_tokens" {
+			return resp, nil
+		}
+
+		// The results of the calls are the next user turn, Claude then calls more tools or answers
+		results := make([]claudeContentBlock, 0, len(calls))
+		for _, call := range calls {
+			results = append(results, claudeContentBlock{
+				Type:      "tool_result",
+				ToolUseID: call.ID,
+				Content:   runTool(ctx, executor, call.Name, string(call.Input)),
+			})
+		}
+		req.Messages = append(req.Messages,
+			claudeMessage{Role: "assistant", Blocks: assistantBlocks},
+			claudeMessage{Role: "user", Blocks: results},
+		)
+		if round >= maxRounds {
+			req.ToolChoice = map[string]interface{}{"type": "tool", "name": claudeResponseTool}
+		}
+	}
+}
+
+// postMessages sends a request to the Messages API
+func (c *ClaudeClient) postMessages(ctx context.Context, req claudeMessagesRequest) (*http.Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Claude request: %v", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Claude request: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", c.apiKey)
+	httpReq.Header.Set("anthropic-version", claudeAPIVersion)
+
+	// Call Claude API
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		log.Printf("CLAUDE -> postMessages -> err: %v", err)
+		return nil, fmt.Errorf("claude API error: %w", err)
+	}
+	return httpResp, nil
+}
+
+// claudeStatusError returns the error of a response with an error status, e.g. 429 rate_limit_error or 529 overloaded_error
+func claudeStatusError(statusCode int, resp claudeMessagesResponse) *StatusError {
+	statusErr := &StatusError{Provider: "claude", StatusCode: statusCode}
+	if resp.Error != nil {
+		statusErr.Message = fmt.Sprintf("%s: %s", resp.Error.Type, resp.Error.Message)
+	}
+	return statusErr
+}
+
+// claudeResponseText validates the JSON response, the input of the response tool
+func claudeResponseText(responseText string) (string, error) {
+	if responseText == "" {
+		return "", fmt.Errorf("no response from Claude")
+	}
+
+	// Validate response against schema
+	var llmResponse constants.LLMResponse
+	if err := json.Unmarshal([]byte(responseText), &llmResponse); err != nil {
+		return "", &InvalidResponseError{Response: responseText, Err: err}
+	}
+
+	return responseText, nil
+}
+
+func (c *ClaudeClient) GetModelInfo() ModelInfo {
+	return ModelInfo{
+		Name:                c.model,
+		Provider:            "claude",
+		MaxCompletionTokens: c.maxCompletionTokens,
+		ContextLimit:        constants.GetLLMContextLimit(c.model),
+	}
+}
+
+// claudeConversation converts the messages of a chat to the alternating user & assistant turns of Claude, starting with a user turn
+func claudeConversation(messages []*models.LLMMessage) []claudeMessage {
+	claudeMessages := make([]claudeMessage, 0, len(messages))
+	for _, msg := range messages {
+		content := ""
+
+		// Handle different message types, the context of system messages is given in tags as Claude has no system turns
+		switch msg.Role {
+		case "user":
+			if userMsg, ok := msg.Content["user_message"].(string); ok {
+				content = userMsg
+			}
+		case "assistant":
+			if assistantMsg, ok := msg.Content["assistant_response"].(map[string]interface{}); ok {
+				content = formatAssistantResponse(assistantMsg)
+			}
+		case "system":
+			if schemaUpdate, ok := msg.Content["schema_update"].(string); ok {
+				content = fmt.Sprintf("<schema_update>\nDatabase schema update:\n%s\n</schema_update>", schemaUpdate)
+			}
+			if liveActivity, ok := msg.Content["live_activity"].(string); ok {
+				content = fmt.Sprintf("<live_activity>\nCurrent database activity, use it to explain what is happening on the database right now (reference the actual statements, sessions & locks):\n%s\n</live_activity>", liveActivity)
+			}
+			if serverFeatures, ok := msg.Content["server_features"].(string); ok {
+				content = fmt.Sprintf("<server_features>\nDatabase server capabilities, generated queries must only use syntax this version supports:\n%s\n</server_features>", serverFeatures)
+			}
+			if relevantTables, ok := msg.Content["relevant_tables"].(string); ok {
+				content = fmt.Sprintf("<relevant_tables>\nTables the previous queries of this chat used the most, most relevant first. Prefer them when the request doesn't name its tables, the rest of the schema remains available:\n%s\n</relevant_tables>", relevantTables)
+			}
+			if summary, ok := msg.Content["conversation_summary"].(string); ok {
+				content = fmt.Sprintf("<conversation_summary>\nSummary of the earlier messages of this chat, they were summarized to fit the context:\n%s\n</conversation_summary>", summary)
+			}
+			if queryExamples, ok := msg.Content["query_examples"].(string); ok {
+				content = fmt.Sprintf("<query_examples>\nExamples of questions & the queries answering them on this database, written by the user. Follow their conventions (tables, joins, filters) when they match the request:\n%s\n</query_examples>", queryExamples)
+			}
+		}
+
+		if content != "" {
+			claudeMessages = appendClaudeMessage(claudeMessages, claudeRole(msg.Role), content)
+		}
+	}
+
+	// The conversation must start with a user turn
+	if len(claudeMessages) == 0 || claudeMessages[0].Role != "user" {
+		claudeMessages = append([]claudeMessage{{Role: "user", Content: "Continue the conversation."}}, claudeMessages...)
+	}
+	return claudeMessages
+}
+
+// claudeRole maps a message role to a Claude role, Claude only has user & assistant turns
+func claudeRole(role string) string {
+	if mapRole(role) == "assistant" {
+		return "assistant"
+	}
+	return "user"
+}
+
+// appendClaudeMessage appends a message, consecutive messages of the same role are merged as Claude requires alternating turns
+func appendClaudeMessage(messages []claudeMessage, role, content string) []claudeMessage {
+	if len(messages) > 0 && messages[len(messages)-1].Role == role {
+		messages[len(messages)-1].Content += "\n\n" + content
+		return messages
+	}
+	return append(messages, claudeMessage{Role: role, Content: content})
+}
@@ -0,0 +1,250 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"neobase-ai/internal/constants"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/generative-ai-go/genai"
+	"github.com/sashabaranov/go-openai"
+	"google.golang.org/api/option"
+)
+
+// Texts embedded per request, Gemini accepts at most 100 texts per batch
+const embeddingBatchSize = 100
+
+// EmbeddingClient turns texts into vectors, the vectors of similar texts are close
+type EmbeddingClient interface {
+	// EmbedDocuments embeds the texts to search in, e.g. the descriptions of the tables
+	EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error)
+	// EmbedQuery embeds the text searched for, e.g. the question of the user
+	EmbedQuery(ctx context.Context, text string) ([]float32, error)
+	// GetModelName returns the model of the vectors, vectors of different models can't be compared
+	GetModelName() string
+}
+
+// NewEmbeddingClient creates the embedding client of a provider, Claude & Bedrock have no embedding models supported here
+func NewEmbeddingClient(config Config) (EmbeddingClient, error) {
+	switch config.Provider {
+	case constants.OpenAI:
+		if config.APIKey == "" {
+			return nil, fmt.Errorf("OpenAI API key is required for embeddings")
+		}
+		model := config.Model
+		if model == "" {
+			model = string(openai.SmallEmbedding3)
+		}
+		return &openAIEmbeddingClient{client: openai.NewClient(config.APIKey), model: model}, nil
+	case constants.Gemini:
+		if config.APIKey == "" {
+			return nil, fmt.Errorf("gemini API key is required for embeddings")
+		}
+		client, err := genai.NewClient(context.Background(), option.WithAPIKey(config.APIKey))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Gemini client: %v", err)
+		}
+		model := config.Model
+		if model == "" {
+			model = constants.GeminiEmbeddingModel
+		}
+		return &geminiEmbeddingClient{client: client, model: model}, nil
+	case constants.Ollama:
+		baseURL := strings.TrimRight(config.BaseURL, "/")
+		if baseURL == "" {
+			baseURL = constants.OllamaBaseURL
+		}
+		model := config.Model
+		if model == "" {
+			model = constants.OllamaEmbeddingModel
+		}
+		return &ollamaEmbeddingClient{
+			httpClient: &http.Client{Timeout: 5 * time.Minute},
+			baseURL:    baseURL,
+			model:      model,
+		}, nil
+	default:
+		return nil, fmt.Errorf("embeddings are not supported for provider: %s", config.Provider)
+	}
+}
+
+// embedInBatches embeds the texts in batches the providers accept
+func embedInBatches(ctx context.Context, texts []string, embed func(ctx context.Context, batch []string) ([][]float32, error)) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += embeddingBatchSize {
+		end := min(start+embeddingBatchSize, len(texts))
+		batchVectors, err := embed(ctx, texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		if len(batchVectors) != end-start {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", end-start, len(batchVectors))
+		}
+		vectors = append(vectors, batchVectors...)
+	}
+	return vectors, nil
+}
+
+type openAIEmbeddingClient struct {
+	client *openai.Client
+	model  string
+}
+
+func (c *openAIEmbeddingClient) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	return embedInBatches(ctx, texts, c.embed)
+}
+
+func (c *openAIEmbeddingClient) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	vectors, err := c.embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+func (c *openAIEmbeddingClient) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	resp, err := c.client.CreateEmbeddings(ctx, openai.EmbeddingRequestStrings{
+		Input: texts,
+		Model: openai.EmbeddingModel(c.model),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("OpenAI embeddings API error: %w", err)
+	}
+	if len(resp.Data) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings from OpenAI, got %d", len(texts), len(resp.Data))
+	}
+
+	// The embeddings are returned with the index of their text
+	vectors := make([][]float32, len(texts))
+	for _, embedding := range resp.Data {
+		if embedding.Index < 0 || embedding.Index >= len(texts) {
+			return nil, fmt.Errorf("invalid embedding index from OpenAI: %d", embedding.Index)
+		}
+		vectors[embedding.Index] = embedding.Embedding
+	}
+	return vectors, nil
+}
+
+func (c *openAIEmbeddingClient) GetModelName() string {
+	return c.model
+}
+
+type geminiEmbeddingClient struct {
+	client *genai.Client
+	model  string
+}
+
+func (c *geminiEmbeddingClient) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	return embedInBatches(ctx, texts, func(ctx context.Context, batch []string) ([][]float32, error) {
+		return c.embed(ctx, batch, genai.TaskTypeRetrievalDocument)
+	})
+}
+
+func (c *geminiEmbeddingClient) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	vectors, err := c.embed(ctx, []string{text}, genai.TaskTypeRetrievalQuery)
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+func (c *geminiEmbeddingClient) embed(ctx context.Context, texts []string, taskType genai.TaskType) ([][]float32, error) {
+	model := c.client.EmbeddingModel(c.model)
+	model.TaskType = taskType
+
+	batch := model.NewBatch()
+	for _, text := range texts {
+		batch.AddContent(genai.Text(text))
+	}
+	resp, err := model.BatchEmbedContents(ctx, batch)
+	if err != nil {
+		return nil, fmt.Errorf("gemini embeddings API error: %w", err)
+	}
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings from Gemini, got %d", len(texts), len(resp.Embeddings))
+	}
+
+	vectors := make([][]float32, len(resp.Embeddings))
+	for i, embedding := range resp.Embeddings {
+		vectors[i] = embedding.Values
+	}
+	return vectors, nil
+}
+
+func (c *geminiEmbeddingClient) GetModelName() string {
+	return c.model
+}
+
+type ollamaEmbeddingClient struct {
+	httpClient *http.Client
+	baseURL    string
+	model      string
+}
+
+type ollamaEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type ollamaEmbedResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+	Error      string      `json:"error,omitempty"`
+}
+
+func (c *ollamaEmbeddingClient) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	return embedInBatches(ctx, texts, c.embed)
+}
+
+func (c *ollamaEmbeddingClient) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	vectors, err := c.embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+func (c *ollamaEmbeddingClient) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(ollamaEmbedRequest{Model: c.model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Ollama request: %v", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/embed", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Ollama request: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("ollama embeddings API error: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Ollama response: %v", err)
+	}
+
+	var resp ollamaEmbedResponse
+	unmarshalErr := json.Unmarshal(respBody, &resp)
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, &StatusError{Provider: "ollama", StatusCode: httpResp.StatusCode, Message: resp.Error}
+	}
+	if unmarshalErr != nil {
+		return nil, fmt.Errorf("invalid Ollama response: %v", unmarshalErr)
+	}
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings from Ollama, got %d", len(texts), len(resp.Embeddings))
+	}
+	return resp.Embeddings, nil
+}
+
+func (c *ollamaEmbeddingClient) GetModelName() string {
+	return c.model
+}
@@ -0,0 +1,160 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/sashabaranov/go-openai"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StatusError is returned by the clients calling a provider API over HTTP when it answers with an error status
+type StatusError struct {
+	Provider   string
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s API error (status %d): %s", e.Provider, e.StatusCode, e.Message)
+}
+
+// InvalidResponseError is returned by the clients when the response doesn't match the response schema,
+// the response is kept so the caller can ask the LLM to repair it
+type InvalidResponseError struct {
+	Response string
+	Err      error
+}
+
+func (e *InvalidResponseError) Error() string {
+	return fmt.Sprintf("invalid response format: %v", e.Err)
+}
+
+func (e *InvalidResponseError) Unwrap() error {
+	return e.Err
+}
+
+// isRetryableStatus reports whether the status is a rate limit or a server error, another provider may still answer
+func isRetryableStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// isRetryableLLMError reports whether the error is a rate limit, a server error or a timeout of the provider.
+// The errors of the request itself (invalid key, invalid response...) would fail with any provider and are not retried.
+func isRetryableLLMError(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		// The request was cancelled or timed out, not the provider
+		return false
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		// Connection refused or reset, the provider is down
+		return true
+	}
+
+	if statusCode, ok := errorStatusCode(err); ok {
+		return isRetryableStatus(statusCode)
+	}
+	if grpcStatus, ok := status.FromError(err); ok {
+		switch grpcStatus.Code() {
+		case codes.ResourceExhausted, codes.Unavailable, codes.DeadlineExceeded, codes.Internal:
+			return true
+		}
+	}
+
+	return false
+}
+
+// errorStatusCode returns the HTTP status a provider API answered an error with.
+// Gemini errors are Google API errors, carrying either an HTTP or a gRPC status.
+func errorStatusCode(err error) (int, bool) {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode, true
+	}
+	var openAIErr *openai.APIError
+	if errors.As(err, &openAIErr) {
+		return openAIErr.HTTPStatusCode, true
+	}
+	var openAIRequestErr *openai.RequestError
+	if errors.As(err, &openAIRequestErr) {
+		return openAIRequestErr.HTTPStatusCode, true
+	}
+	var httpCodeErr interface{ HTTPCode() int }
+	if errors.As(err, &httpCodeErr) && httpCodeErr.HTTPCode() > 0 {
+		return httpCodeErr.HTTPCode(), true
+	}
+	return 0, false
+}
+
+// Classes of the errors of the LLM requests, counted by the metrics
+const (
+	ErrorClassCancelled       = "cancelled"        // the request was cancelled or timed out, not the provider
+	ErrorClassTimeout         = "timeout"          // the provider didn't answer in time
+	ErrorClassConnection      = "connection"       // the provider couldn't be reached
+	ErrorClassRateLimit       = "rate_limit"       // 429 or resource exhausted
+	ErrorClassServer          = "server_error"     // 5xx or unavailable
+	ErrorClassRequest         = "request_error"    // 4xx, e.g. an invalid API key or a too long prompt
+	ErrorClassInvalidResponse = "invalid_response" // the response doesn't match the response schema
+	ErrorClassOther           = "other"
+)
+
+// errorClass returns the class of the error of a request, see the ErrorClass constants
+func errorClass(ctx context.Context, err error) string {
+	if ctx.Err() != nil {
+		return ErrorClassCancelled
+	}
+
+	var invalidErr *InvalidResponseError
+	if errors.As(err, &invalidErr) {
+		return ErrorClassInvalidResponse
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorClassTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrorClassTimeout
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return ErrorClassConnection
+	}
+
+	if statusCode, ok := errorStatusCode(err); ok {
+		switch {
+		case statusCode == http.StatusTooManyRequests:
+			return ErrorClassRateLimit
+		case statusCode >= http.StatusInternalServerError:
+			return ErrorClassServer
+		case statusCode >= http.StatusBadRequest:
+			return ErrorClassRequest
+		}
+		return ErrorClassOther
+	}
+	if grpcStatus, ok := status.FromError(err); ok {
+		switch grpcStatus.Code() {
+		case codes.ResourceExhausted:
+			return ErrorClassRateLimit
+		case codes.Unavailable, codes.Internal:
+			return ErrorClassServer
+		case codes.DeadlineExceeded:
+			return ErrorClassTimeout
+		case codes.InvalidArgument, codes.PermissionDenied, codes.Unauthenticated, codes.NotFound, codes.FailedPrecondition:
+			return ErrorClassRequest
+		}
+	}
+	return ErrorClassOther
+}
@@ -0,0 +1,161 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"neobase-ai/internal/models"
+	"sync"
+	"time"
+)
+
+// FailoverProvider is a provider of the failover chain, the name is used in the logs
+type FailoverProvider struct {
+	Name   string
+	Client Client
+}
+
+// circuitBreaker stops calling a provider after consecutive failures, until the cooldown is over
+type circuitBreaker struct {
+	failures  int
+	openUntil time.Time
+}
+
+// FailoverClient tries its providers in order, the next provider is tried when one is rate limited, fails or times out.
+// A provider failing failureThreshold times in a row is skipped for the cooldown, then tried again by a single request.
+type FailoverClient struct {
+	providers        []FailoverProvider
+	breakers         []*circuitBreaker
+	failureThreshold int
+	cooldown         time.Duration
+	lastUsed         int // Index of the provider which answered last
+	mu               sync.Mutex
+}
+
+func NewFailoverClient(providers []FailoverProvider, failureThreshold int, cooldown time.Duration) (*FailoverClient, error) {
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("at least one LLM provider is required for failover")
+	}
+	if failureThreshold <= 0 {
+		failureThreshold = 1
+	}
+
+	breakers := make([]*circuitBreaker, len(providers))
+	for i := range providers {
+		breakers[i] = &circuitBreaker{}
+	}
+
+	return &FailoverClient{
+		providers:        providers,
+		breakers:         breakers,
+		failureThreshold: failureThreshold,
+		cooldown:         cooldown,
+	}, nil
+}
+
+func (c *FailoverClient) GenerateResponse(ctx context.Context, messages []*models.LLMMessage, dbType string) (string, error) {
+	return c.generate(ctx, func(client Client) (string, bool, error) {
+		response, err := client.GenerateResponse(ctx, messages, dbType)
+		return response, false, err
+	})
+}
+
+// GenerateResponseStream streams the response of the providers able to, a provider failing once it has streamed
+// a part of the assistant message is not failed over as the client has already shown it
+func (c *FailoverClient) GenerateResponseStream(ctx context.Context, messages []*models.LLMMessage, dbType string, onChunk func(chunk string)) (string, error) {
+	return c.generate(ctx, func(client Client) (string, bool, error) {
+		streamingClient, ok := client.(StreamingClient)
+		if !ok {
+			response, err := client.GenerateResponse(ctx, messages, dbType)
+			return response, false, err
+		}
+
+		streamed := false
+		response, err := streamingClient.GenerateResponseStream(ctx, messages, dbType, func(chunk string) {
+			streamed = true
+			onChunk(chunk)
+		})
+		return response, streamed, err
+	})
+}
+
+// generate calls the providers in order until one answers, call reports whether the response was partly sent to the user
+func (c *FailoverClient) generate(ctx context.Context, call func(client Client) (string, bool, error)) (string, error) {
+	var lastErr error
+	for i, provider := range c.providers {
+		if !c.allow(i) {
+			log.Printf("FailoverClient -> generate -> skipping %s, its circuit is open", provider.Name)
+			continue
+		}
+
+		response, partlySent, err := call(provider.Client)
+		if err == nil {
+			c.recordSuccess(i)
+			return response, nil
+		}
+
+		if !isRetryableLLMError(ctx, err) {
+			// The provider is up, the same request would fail with the next ones
+			c.recordSuccess(i)
+			return "", err
+		}
+
+		c.recordFailure(i, provider.Name)
+		lastErr = err
+		if partlySent {
+			return "", err
+		}
+		RecordRetry(provider.Client.GetModelInfo(), RetryFailover)
+		log.Printf("FailoverClient -> generate -> %s failed, trying the next provider: %v", provider.Name, err)
+	}
+
+	if lastErr == nil {
+		return "", fmt.Errorf("all LLM providers are unavailable")
+	}
+	return "", fmt.Errorf("all LLM providers are unavailable: %w", lastErr)
+}
+
+// allow reports whether the provider can be called, an open circuit lets a single request through once its cooldown is over
+func (c *FailoverClient) allow(i int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	breaker := c.breakers[i]
+	if breaker.failures < c.failureThreshold {
+		return true
+	}
+	if time.Now().Before(breaker.openUntil) {
+		return false
+	}
+	// Half-open, the circuit stays open for the other requests until this one ends
+	breaker.openUntil = time.Now().Add(c.cooldown)
+	return true
+}
+
+func (c *FailoverClient) recordSuccess(i int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.breakers[i].failures = 0
+	c.lastUsed = i
+}
+
+func (c *FailoverClient) recordFailure(i int, name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	breaker := c.breakers[i]
+	breaker.failures++
+	if breaker.failures >= c.failureThreshold {
+		breaker.openUntil = time.Now().Add(c.cooldown)
+		log.Printf("FailoverClient -> recordFailure -> %s failed %d times in a row, circuit open for %v", name, breaker.failures, c.cooldown)
+	}
+}
+
+// GetModelInfo returns the model of the provider which answered last
+func (c *FailoverClient) GetModelInfo() ModelInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.providers[c.lastUsed].Client.GetModelInfo()
+}
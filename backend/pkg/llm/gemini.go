@@ -3,6 +3,7 @@ package llm
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"neobase-ai/internal/constants"
@@ -19,9 +20,45 @@ type GeminiClient struct {
 	model               string
 	maxCompletionTokens int
 	temperature         float64
+	safetySettings      []*genai.SafetySetting
 	DBConfigs           []LLMDBConfig
 }
 
+// geminiSafetyThresholds maps the configurable safety thresholds to the Gemini harm block thresholds
+var geminiSafetyThresholds = map[string]genai.HarmBlockThreshold{
+	"none":             genai.HarmBlockNone,
+	"only_high":        genai.HarmBlockOnlyHigh,
+	"medium_and_above": genai.HarmBlockMediumAndAbove,
+	"low_and_above":    genai.HarmBlockLowAndAbove,
+}
+
+// geminiSafetySettings applies the safety threshold to the harm categories of the Gemini models, none by default.
+// Schemas & queries are often flagged as harmful (e.g. DROP, DELETE, tables named users), blocking them fails the response.
+func geminiSafetySettings(threshold string) ([]*genai.SafetySetting, error) {
+	if threshold == "" {
+		threshold = constants.GeminiSafetyThreshold
+	}
+	harmBlockThreshold, ok := geminiSafetyThresholds[strings.ToLower(threshold)]
+	if !ok {
+		return nil, fmt.Errorf("invalid Gemini safety threshold %s, expected none, only_high, medium_and_above or low_and_above", threshold)
+	}
+
+	categories := []genai.HarmCategory{
+		genai.HarmCategoryHarassment,
+		genai.HarmCategoryHateSpeech,
+		genai.HarmCategorySexuallyExplicit,
+		genai.HarmCategoryDangerousContent,
+	}
+	safetySettings := make([]*genai.SafetySetting, 0, len(categories))
+	for _, category := range categories {
+		safetySettings = append(safetySettings, &genai.SafetySetting{
+			Category:  category,
+			Threshold: harmBlockThreshold,
+		})
+	}
+	return safetySettings, nil
+}
+
 func NewGeminiClient(config Config) (*GeminiClient, error) {
 	if config.APIKey == "" {
 		return nil, fmt.Errorf("gemini API key is required")
@@ -31,6 +68,10 @@ func NewGeminiClient(config Config) (*GeminiClient, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Gemini client: %v", err)
 	}
+	safetySettings, err := geminiSafetySettings(config.SafetyThreshold)
+	if err != nil {
+		return nil, err
+	}
 	maxCompletionTokens := config.MaxCompletionTokens
 	temperature := config.Temperature
 	DBConfigs := config.DBConfigs
@@ -40,6 +81,7 @@ func NewGeminiClient(config Config) (*GeminiClient, error) {
 		model:               config.Model,
 		maxCompletionTokens: maxCompletionTokens,
 		temperature:         temperature,
+		safetySettings:      safetySettings,
 		DBConfigs:           DBConfigs,
 	}, nil
 }
@@ -64,6 +106,7 @@ func (c *GeminiClient) GenerateResponse(ctx context.Context, messages []*models.
 			break
 		}
 	}
+	systemPrompt = systemPromptFor(ctx, "gemini", dbType, systemPrompt)
 
 	// Add system message first
 	geminiMessages = append(geminiMessages, &genai.Content{
@@ -92,6 +135,21 @@ func (c *GeminiClient) GenerateResponse(ctx context.Context, messages []*models.
 			if schemaUpdate, ok := msg.Content["schema_update"].(string); ok {
 				content = fmt.Sprintf("Database schema update:\n%s", schemaUpdate)
 			}
+			if liveActivity, ok := msg.Content["live_activity"].(string); ok {
+				content = fmt.Sprintf("Current database activity, use it to explain what is happening on the database right now (reference the actual statements, sessions & locks):\n%s", liveActivity)
+			}
+			if serverFeatures, ok := msg.Content["server_features"].(string); ok {
+				content = fmt.Sprintf("Database server capabilities, generated queries must only use syntax this version supports:\n%s", serverFeatures)
+			}
+			if relevantTables, ok := msg.Content["relevant_tables"].(string); ok {
+				content = fmt.Sprintf("Tables the previous queries of this chat used the most, most relevant first. Prefer them when the request doesn't name its tables, the rest of the schema remains available:\n%s", relevantTables)
+			}
+			if summary, ok := msg.Content["conversation_summary"].(string); ok {
+				content = fmt.Sprintf("Summary of the earlier messages of this chat, they were summarized to fit the context:\n%s", summary)
+			}
+			if queryExamples, ok := msg.Content["query_examples"].(string); ok {
+				content = fmt.Sprintf("Examples of questions & the queries answering them on this database, written by the user. Follow their conventions (tables, joins, filters) when they match the request:\n%s", queryExamples)
+			}
 		}
 
 		if content != "" {
@@ -114,24 +172,20 @@ func (c *GeminiClient) GenerateResponse(ctx context.Context, messages []*models.
 	// }
 	// Build the request with a single content bundle.
 	// Call Gemini's content generation API.
+	// The chat may override the sampling, Gemini accepts temperatures up to 2
+	sampling := samplingFor(ctx, c.temperature, c.maxCompletionTokens, 2)
 	model := c.client.GenerativeModel(c.model)
-	model.MaxOutputTokens = utils.ToInt32Ptr(int32(c.maxCompletionTokens))
-	model.SetTemperature(float32(c.temperature))
+	model.MaxOutputTokens = utils.ToInt32Ptr(int32(sampling.maxTokens))
+	model.SetTemperature(float32(sampling.temperature))
+	if sampling.topP != nil {
+		model.SetTopP(float32(*sampling.topP))
+	}
 	model.ResponseMIMEType = "application/json"
 	model.SystemInstruction = &genai.Content{
 		Parts: []genai.Part{genai.Text(systemPrompt)},
 	}
 	model.ResponseSchema = responseSchema
-	model.SafetySettings = []*genai.SafetySetting{
-		{
-			Category:  genai.HarmCategoryHarassment,
-			Threshold: genai.HarmBlockNone,
-		},
-		{
-			Category:  genai.HarmCategoryHateSpeech,
-			Threshold: genai.HarmBlockNone,
-		},
-	}
+	model.SafetySettings = c.safetySettings
 
 	// Start chat session
 	session := model.StartChat()
@@ -144,35 +198,62 @@ func (c *GeminiClient) GenerateResponse(ctx context.Context, messages []*models.
 	// Send empty message to get response based on history
 	result, err := session.SendMessage(ctx, genai.Text("Please provide a response based on our conversation history."))
 	if err != nil {
+		var blockedErr *genai.BlockedError
+		if errors.As(err, &blockedErr) {
+			log.Printf("GEMINI -> GenerateResponse -> blocked: %v", blockedErr)
+			return "", fmt.Errorf("gemini blocked the response, lower GEMINI_SAFETY_THRESHOLD to allow it: %v", blockedErr)
+		}
 		log.Printf("Gemini API error: %v", err)
-		return "", fmt.Errorf("gemini API error: %v", err)
+		return "", fmt.Errorf("gemini API error: %w", err)
+	}
+	if result.UsageMetadata != nil {
+		recordUsage(ctx, c.GetModelInfo(), int(result.UsageMetadata.PromptTokenCount), int(result.UsageMetadata.CandidatesTokenCount))
+	}
+	if len(result.Candidates) == 0 || result.Candidates[0].Content == nil {
+		return "", fmt.Errorf("no response from Gemini")
 	}
 
 	log.Printf("GEMINI -> GenerateResponse -> result: %v", result)
-	log.Printf("GEMINI -> GenerateResponse -> result.Candidates[0].Content.Parts[0]: %v", result.Candidates[0].Content.Parts[0])
-	responseText := strings.ReplaceAll(fmt.Sprintf("%v", result.Candidates[0].Content.Parts[0]), "```json", "")
-	responseText = strings.ReplaceAll(responseText, "```", "")
+	candidate := result.Candidates[0]
+	// A response cut by the max tokens is an incomplete JSON
+	if candidate.FinishReason == genai.FinishReasonMaxTokens {
+		return "", fmt.Errorf("gemini response exceeded the %d max tokens", sampling.maxTokens)
+	}
+
+	// JSON mode returns the whole response as text, it may be split over several parts
+	var responseBuilder strings.Builder
+	for _, part := range candidate.Content.Parts {
+		if text, ok := part.(genai.Text); ok {
+			responseBuilder.WriteString(string(text))
+		}
+	}
+	responseText := strings.ReplaceAll(responseBuilder.String(), "```json", "")
+	responseText = strings.TrimSpace(strings.ReplaceAll(responseText, "```", ""))
 
 	var llmResponse constants.LLMResponse
 	if err := json.Unmarshal([]byte(responseText), &llmResponse); err != nil {
 		log.Printf("Warning: Gemini response didn't match expected JSON schema: %v", err)
-		return "", fmt.Errorf("invalid JSON response: %v", err)
+		return "", &InvalidResponseError{Response: responseText, Err: err}
 	}
 
 	var mapResponse map[string]interface{}
 	if err := json.Unmarshal([]byte(responseText), &mapResponse); err != nil {
 		log.Printf("Warning: Gemini response didn't match expected JSON schema: %v", err)
-		return "", fmt.Errorf("invalid JSON response: %v", err)
+		return "", &InvalidResponseError{Response: responseText, Err: err}
 	}
 
+	// Gemini schemas can't describe the example results, they are returned as a JSON string the response parser expects as an array
 	temporaryQueries := []map[string]interface{}{}
-	if mapResponse["queries"] != nil {
-		for _, v := range mapResponse["queries"].([]interface{}) {
-			value := v.(map[string]interface{})
+	if queries, ok := mapResponse["queries"].([]interface{}); ok {
+		for _, v := range queries {
+			value, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
 			log.Printf("gemini responseMap loop queries: %v", value)
 			var exampleResult []map[string]interface{}
-			if value["exampleResultString"] != nil && value["exampleResultString"] != "" {
-				if err := json.Unmarshal([]byte(value["exampleResultString"].(string)), &exampleResult); err == nil {
+			if exampleResultString, ok := value["exampleResultString"].(string); ok && exampleResultString != "" {
+				if err := json.Unmarshal([]byte(exampleResultString), &exampleResult); err == nil {
 					value["exampleResult"] = exampleResult
 				}
 			}
@@ -196,5 +277,6 @@ func (c *GeminiClient) GetModelInfo() ModelInfo {
 		Name:                c.model,
 		Provider:            "gemini",
 		MaxCompletionTokens: c.maxCompletionTokens,
+		ContextLimit:        constants.GetLLMContextLimit(c.model),
 	}
 }
@@ -20,28 +20,57 @@ func NewManager() *Manager {
 }
 
 func (m *Manager) RegisterClient(name string, config Config) error {
+	client, err := m.NewClient(config)
+	if err != nil {
+		return err
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	m.clients[name] = client
+	return nil
+}
+
+// NewClient creates a client for the config without registering it, used for clients with per-organization credentials.
+// The requests of the client are recorded in the metrics.
+func (m *Manager) NewClient(config Config) (Client, error) {
 	var client Client
 	var err error
 
 	switch config.Provider {
 	case "openai":
 		client, err = NewOpenAIClient(config)
+	case "azure-openai":
+		client, err = NewAzureOpenAIClient(config)
 	case "gemini":
 		client, err = NewGeminiClient(config)
+	case "ollama":
+		client, err = NewOllamaClient(config)
+	case "claude":
+		client, err = NewClaudeClient(config)
+	case "bedrock":
+		client, err = NewBedrockClient(config)
+	case "openai-compatible":
+		client, err = NewOpenAICompatibleClient(config)
 	// Add other providers here (Gemini, etc.)
 	default:
-		return fmt.Errorf("unsupported LLM provider: %s", config.Provider)
+		return nil, fmt.Errorf("unsupported LLM provider: %s", config.Provider)
 	}
 
 	if err != nil {
-		return fmt.Errorf("failed to create LLM client: %v", err)
+		return nil, fmt.Errorf("failed to create LLM client: %v", err)
 	}
 
+	return instrumentClient(client), nil
+}
+
+// AddClient registers a client created outside of the manager, e.g. a failover client over several providers
+func (m *Manager) AddClient(name string, client Client) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	m.clients[name] = client
-	return nil
 }
 
 func (m *Manager) GetClient(name string) (Client, error) {
@@ -0,0 +1,186 @@
+package llm
+
+import (
+	"context"
+	"neobase-ai/internal/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Reasons of the retried LLM requests
+const (
+	RetryFailover  = "failover"   // the provider failed, the next provider of the failover chain was called
+	RetryRepair    = "repair"     // the response didn't match the response schema, the LLM was asked to fix it
+	RetryQueryPlan = "query_plan" // a query failed to plan, the LLM was asked to correct it
+)
+
+// latencyBuckets are the upper bounds of the request duration buckets, the last bucket holds the slower requests
+var latencyBuckets = []time.Duration{
+	time.Second,
+	2 * time.Second,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+	time.Minute,
+}
+
+// ModelMetrics are the requests made to a model since the server started, for capacity planning
+type ModelMetrics struct {
+	Provider         string
+	Model            string
+	Requests         int64
+	Failures         int64
+	TotalDuration    time.Duration
+	MaxDuration      time.Duration
+	Latency          []LatencyBucket
+	PromptTokens     int64
+	CompletionTokens int64
+	Retries          map[string]int64 // by reason, see the Retry constants
+	Errors           map[string]int64 // by class, see the ErrorClass constants
+}
+
+// LatencyBucket counts the requests which took up to UpTo, 0 for the requests slower than the last bucket
+type LatencyBucket struct {
+	UpTo  time.Duration
+	Count int64
+}
+
+type metricsKey struct {
+	provider string
+	model    string
+}
+
+type metricsRegistry struct {
+	since  time.Time
+	models map[metricsKey]*ModelMetrics
+	mu     sync.Mutex
+}
+
+// metrics holds the metrics of every client of the process, the clients of the organizations included
+var metrics = &metricsRegistry{
+	since:  time.Now(),
+	models: make(map[metricsKey]*ModelMetrics),
+}
+
+// GetMetrics returns the metrics of the models called since the server started, sorted by provider & model
+func GetMetrics() (since time.Time, snapshot []ModelMetrics) {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+
+	snapshot = make([]ModelMetrics, 0, len(metrics.models))
+	for _, model := range metrics.models {
+		copied := *model
+		copied.Latency = append([]LatencyBucket(nil), model.Latency...)
+		copied.Retries = make(map[string]int64, len(model.Retries))
+		for reason, count := range model.Retries {
+			copied.Retries[reason] = count
+		}
+		copied.Errors = make(map[string]int64, len(model.Errors))
+		for class, count := range model.Errors {
+			copied.Errors[class] = count
+		}
+		snapshot = append(snapshot, copied)
+	}
+	sort.Slice(snapshot, func(i, j int) bool {
+		if snapshot[i].Provider != snapshot[j].Provider {
+			return snapshot[i].Provider < snapshot[j].Provider
+		}
+		return snapshot[i].Model < snapshot[j].Model
+	})
+	return metrics.since, snapshot
+}
+
+// RecordRetry counts a request retried on the model, e.g. to repair an invalid response
+func RecordRetry(info ModelInfo, reason string) {
+	metrics.update(info, func(model *ModelMetrics) {
+		model.Retries[reason]++
+	})
+}
+
+func (r *metricsRegistry) update(info ModelInfo, apply func(model *ModelMetrics)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := metricsKey{provider: info.Provider, model: info.Name}
+	model, exists := r.models[key]
+	if !exists {
+		model = &ModelMetrics{
+			Provider: info.Provider,
+			Model:    info.Name,
+			Latency:  make([]LatencyBucket, len(latencyBuckets)+1),
+			Retries:  make(map[string]int64),
+			Errors:   make(map[string]int64),
+		}
+		for i, upTo := range latencyBuckets {
+			model.Latency[i].UpTo = upTo
+		}
+		r.models[key] = model
+	}
+	apply(model)
+}
+
+func (r *metricsRegistry) recordRequest(ctx context.Context, info ModelInfo, duration time.Duration, err error) {
+	r.update(info, func(model *ModelMetrics) {
+		model.Requests++
+		model.TotalDuration += duration
+		model.MaxDuration = max(model.MaxDuration, duration)
+
+		bucket := len(latencyBuckets)
+		for i, upTo := range latencyBuckets {
+			if duration <= upTo {
+				bucket = i
+				break
+			}
+		}
+		model.Latency[bucket].Count++
+
+		if err != nil {
+			model.Failures++
+			model.Errors[errorClass(ctx, err)]++
+		}
+	})
+}
+
+func (r *metricsRegistry) recordTokens(info ModelInfo, promptTokens, completionTokens int) {
+	r.update(info, func(model *ModelMetrics) {
+		model.PromptTokens += int64(promptTokens)
+		model.CompletionTokens += int64(completionTokens)
+	})
+}
+
+// instrumentedClient records the duration & the errors of the requests of a client in the metrics.
+// A request may make several calls to the provider, e.g. to run tools, it is timed as a whole.
+type instrumentedClient struct {
+	Client
+}
+
+func (c *instrumentedClient) GenerateResponse(ctx context.Context, messages []*models.LLMMessage, dbType string) (string, error) {
+	start := time.Now()
+	response, err := c.Client.GenerateResponse(ctx, messages, dbType)
+	metrics.recordRequest(ctx, c.GetModelInfo(), time.Since(start), err)
+	return response, err
+}
+
+// instrumentedStreamingClient keeps the streaming of the clients able to, the others must not look like they stream
+type instrumentedStreamingClient struct {
+	instrumentedClient
+	streamingClient StreamingClient
+}
+
+func (c *instrumentedStreamingClient) GenerateResponseStream(ctx context.Context, messages []*models.LLMMessage, dbType string, onChunk func(chunk string)) (string, error) {
+	start := time.Now()
+	response, err := c.streamingClient.GenerateResponseStream(ctx, messages, dbType, onChunk)
+	metrics.recordRequest(ctx, c.GetModelInfo(), time.Since(start), err)
+	return response, err
+}
+
+func instrumentClient(client Client) Client {
+	if streamingClient, ok := client.(StreamingClient); ok {
+		return &instrumentedStreamingClient{
+			instrumentedClient: instrumentedClient{Client: client},
+			streamingClient:    streamingClient,
+		}
+	}
+	return &instrumentedClient{Client: client}
+}
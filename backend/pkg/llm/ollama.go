@@ -0,0 +1,292 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"neobase-ai/internal/constants"
+	"neobase-ai/internal/models"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OllamaClient talks to a self-hosted Ollama server, no API key is needed
+type OllamaClient struct {
+	httpClient          *http.Client
+	baseURL             string
+	model               string
+	maxCompletionTokens int
+	temperature         float64
+	DBConfigs           []LLMDBConfig
+}
+
+type ollamaMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ollamaChatRequest struct {
+	Model    string                 `json:"model"`
+	Messages []ollamaMessage        `json:"messages"`
+	Stream   bool                   `json:"stream"`
+	Format   json.RawMessage        `json:"format,omitempty"`
+	Options  map[string]interface{} `json:"options,omitempty"`
+}
+
+type ollamaChatResponse struct {
+	Model   string        `json:"model"`
+	Message ollamaMessage `json:"message"`
+	Done    bool          `json:"done"`
+	Error   string        `json:"error,omitempty"`
+	// Token counts, sent with the last response of a stream
+	PromptEvalCount int `json:"prompt_eval_count,omitempty"`
+	EvalCount       int `json:"eval_count,omitempty"`
+}
+
+func NewOllamaClient(config Config) (*OllamaClient, error) {
+	baseURL := strings.TrimRight(config.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = constants.OllamaBaseURL
+	}
+	model := config.Model
+	if model == "" {
+		model = constants.OllamaModel
+	}
+
+	return &OllamaClient{
+		// Local models can take minutes to answer, the request context cancels slower responses
+		httpClient:          &http.Client{Timeout: 10 * time.Minute},
+		baseURL:             baseURL,
+		model:               model,
+		maxCompletionTokens: config.MaxCompletionTokens,
+		temperature:         config.Temperature,
+		DBConfigs:           config.DBConfigs,
+	}, nil
+}
+
+func (c *OllamaClient) GenerateResponse(ctx context.Context, messages []*models.LLMMessage, dbType string) (string, error) {
+	// Check if the context is cancelled
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+
+	req := c.buildRequest(ctx, messages, dbType, false)
+	httpResp, err := c.postChat(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read Ollama response: %v", err)
+	}
+
+	var resp ollamaChatResponse
+	unmarshalErr := json.Unmarshal(respBody, &resp)
+	if httpResp.StatusCode != http.StatusOK {
+		return "", &StatusError{Provider: "Ollama", StatusCode: httpResp.StatusCode, Message: resp.Error}
+	}
+	if unmarshalErr != nil {
+		return "", fmt.Errorf("invalid Ollama response: %v", unmarshalErr)
+	}
+	if resp.Error != "" {
+		return "", fmt.Errorf("Ollama API error: %s", resp.Error)
+	}
+	recordUsage(ctx, c.GetModelInfo(), resp.PromptEvalCount, resp.EvalCount)
+	if resp.Message.Content == "" {
+		return "", fmt.Errorf("no response from Ollama")
+	}
+
+	log.Printf("OLLAMA -> GenerateResponse -> resp: %v", resp)
+	return ollamaResponseText(resp.Message.Content)
+}
+
+// GenerateResponseStream streams the chat, each line of the stream holds the next delta of the response
+func (c *OllamaClient) GenerateResponseStream(ctx context.Context, messages []*models.LLMMessage, dbType string, onChunk func(chunk string)) (string, error) {
+	// Check if the context is cancelled
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+
+	req := c.buildRequest(ctx, messages, dbType, true)
+	httpResp, err := c.postChat(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		var resp ollamaChatResponse
+		respBody, _ := io.ReadAll(httpResp.Body)
+		_ = json.Unmarshal(respBody, &resp)
+		return "", &StatusError{Provider: "Ollama", StatusCode: httpResp.StatusCode, Message: resp.Error}
+	}
+
+	streamer := newAssistantMessageStreamer(onChunk)
+	scanner := bufio.NewScanner(httpResp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var resp ollamaChatResponse
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+			return "", fmt.Errorf("invalid Ollama response: %v", err)
+		}
+		if resp.Error != "" {
+			return "", fmt.Errorf("Ollama API error: %s", resp.Error)
+		}
+		streamer.Write(resp.Message.Content)
+		if resp.Done {
+			recordUsage(ctx, c.GetModelInfo(), resp.PromptEvalCount, resp.EvalCount)
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read Ollama response: %v", err)
+	}
+	if streamer.Response() == "" {
+		return "", fmt.Errorf("no response from Ollama")
+	}
+
+	log.Printf("OLLAMA -> GenerateResponseStream -> response: %s", streamer.Response())
+	return ollamaResponseText(streamer.Response())
+}
+
+// buildRequest converts the messages to a chat request answering with the JSON schema of the database type
+func (c *OllamaClient) buildRequest(ctx context.Context, messages []*models.LLMMessage, dbType string, stream bool) ollamaChatRequest {
+	systemPrompt := ""
+	responseSchema := ""
+
+	for _, dbConfig := range c.DBConfigs {
+		if dbConfig.DBType == dbType {
+			systemPrompt = dbConfig.SystemPrompt
+			responseSchema = dbConfig.Schema.(string)
+			break
+		}
+	}
+	systemPrompt = systemPromptFor(ctx, "ollama", dbType, systemPrompt)
+
+	// Add system message with database-specific prompt only
+	ollamaMessages := make([]ollamaMessage, 0, len(messages)+1)
+	ollamaMessages = append(ollamaMessages, ollamaMessage{
+		Role:    "system",
+		Content: systemPrompt,
+	})
+
+	for _, msg := range messages {
+		content := ""
+
+		// Handle different message types
+		switch msg.Role {
+		case "user":
+			if userMsg, ok := msg.Content["user_message"].(string); ok {
+				content = userMsg
+			}
+		case "assistant":
+			if assistantMsg, ok := msg.Content["assistant_response"].(map[string]interface{}); ok {
+				content = formatAssistantResponse(assistantMsg)
+			}
+		case "system":
+			if schemaUpdate, ok := msg.Content["schema_update"].(string); ok {
+				content = fmt.Sprintf("Database schema update:\n%s", schemaUpdate)
+			}
+			if liveActivity, ok := msg.Content["live_activity"].(string); ok {
+				content = fmt.Sprintf("Current database activity, use it to explain what is happening on the database right now (reference the actual statements, sessions & locks):\n%s", liveActivity)
+			}
+			if serverFeatures, ok := msg.Content["server_features"].(string); ok {
+				content = fmt.Sprintf("Database server capabilities, generated queries must only use syntax this version supports:\n%s", serverFeatures)
+			}
+			if relevantTables, ok := msg.Content["relevant_tables"].(string); ok {
+				content = fmt.Sprintf("Tables the previous queries of this chat used the most, most relevant first. Prefer them when the request doesn't name its tables, the rest of the schema remains available:\n%s", relevantTables)
+			}
+			if summary, ok := msg.Content["conversation_summary"].(string); ok {
+				content = fmt.Sprintf("Summary of the earlier messages of this chat, they were summarized to fit the context:\n%s", summary)
+			}
+			if queryExamples, ok := msg.Content["query_examples"].(string); ok {
+				content = fmt.Sprintf("Examples of questions & the queries answering them on this database, written by the user. Follow their conventions (tables, joins, filters) when they match the request:\n%s", queryExamples)
+			}
+		}
+
+		if content != "" {
+			ollamaMessages = append(ollamaMessages, ollamaMessage{
+				Role:    mapRole(msg.Role),
+				Content: content,
+			})
+		}
+	}
+
+	// Ollama constrains the output to the JSON schema given as format, the prompts & schemas are the OpenAI ones
+	// The chat may override the sampling
+	sampling := samplingFor(ctx, c.temperature, c.maxCompletionTokens, 2)
+	options := map[string]interface{}{
+		"temperature": sampling.temperature,
+	}
+	if sampling.topP != nil {
+		options["top_p"] = *sampling.topP
+	}
+	if sampling.maxTokens > 0 {
+		options["num_predict"] = sampling.maxTokens
+	}
+	req := ollamaChatRequest{
+		Model:    c.model,
+		Messages: ollamaMessages,
+		Stream:   stream,
+		Format:   json.RawMessage(`"json"`),
+		Options:  options,
+	}
+	if responseSchema != "" {
+		req.Format = json.RawMessage(responseSchema)
+	}
+	return req
+}
+
+// postChat sends a chat request to the Ollama server
+func (c *OllamaClient) postChat(ctx context.Context, req ollamaChatRequest) (*http.Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Ollama request: %v", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/chat", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Ollama request: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	// Call Ollama API
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		log.Printf("OLLAMA -> postChat -> err: %v", err)
+		return nil, fmt.Errorf("Ollama API error: %w", err)
+	}
+	return httpResp, nil
+}
+
+// ollamaResponseText validates the JSON response, local models may wrap it in a code block
+func ollamaResponseText(content string) (string, error) {
+	responseText := strings.TrimSpace(content)
+	responseText = strings.TrimPrefix(responseText, "```json")
+	responseText = strings.TrimPrefix(responseText, "```")
+	responseText = strings.TrimSuffix(responseText, "```")
+
+	// Validate response against schema
+	var llmResponse constants.LLMResponse
+	if err := json.Unmarshal([]byte(responseText), &llmResponse); err != nil {
+		return "", &InvalidResponseError{Response: responseText, Err: err}
+	}
+
+	return responseText, nil
+}
+
+func (c *OllamaClient) GetModelInfo() ModelInfo {
+	return ModelInfo{
+		Name:                c.model,
+		Provider:            "ollama",
+		MaxCompletionTokens: c.maxCompletionTokens,
+		ContextLimit:        constants.GetLLMContextLimit(c.model),
+	}
+}
@@ -3,20 +3,29 @@ package llm
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"neobase-ai/internal/constants"
 	"neobase-ai/internal/models"
+	"strings"
 
 	"github.com/sashabaranov/go-openai"
 )
 
 type OpenAIClient struct {
 	client              *openai.Client
+	provider            string
 	model               string
 	maxCompletionTokens int
 	temperature         float64
 	DBConfigs           []LLMDBConfig
+
+	// Servers implementing the OpenAI API, see NewOpenAICompatibleClient
+	responseFormat  string // ResponseFormatJSONSchema when empty
+	disableTools    bool   // the server can't call tools, the LLM answers without them
+	legacyMaxTokens bool   // the token limit is sent as max_tokens, max_completion_tokens is ignored by most servers
 }
 
 func NewOpenAIClient(config Config) (*OpenAIClient, error) {
@@ -32,6 +41,7 @@ func NewOpenAIClient(config Config) (*OpenAIClient, error) {
 
 	return &OpenAIClient{
 		client:              client,
+		provider:            "openai",
 		model:               model,
 		maxCompletionTokens: config.MaxCompletionTokens,
 		temperature:         config.Temperature,
@@ -39,12 +49,158 @@ func NewOpenAIClient(config Config) (*OpenAIClient, error) {
 	}, nil
 }
 
+// NewAzureOpenAIClient creates a client of an Azure OpenAI deployment, the model of the config is the name of the deployment
+func NewAzureOpenAIClient(config Config) (*OpenAIClient, error) {
+	if config.APIKey == "" {
+		return nil, fmt.Errorf("azure OpenAI API key is required")
+	}
+	if config.BaseURL == "" {
+		return nil, fmt.Errorf("azure OpenAI endpoint is required")
+	}
+	if config.Model == "" {
+		return nil, fmt.Errorf("azure OpenAI deployment name is required")
+	}
+
+	// Requests are sent to the deployment whatever the model, structured outputs need an API version from 2024-08-01-preview
+	deployment := config.Model
+	clientConfig := openai.DefaultAzureConfig(config.APIKey, config.BaseURL)
+	if config.APIVersion != "" {
+		clientConfig.APIVersion = config.APIVersion
+	}
+	clientConfig.AzureModelMapperFunc = func(model string) string {
+		return deployment
+	}
+
+	return &OpenAIClient{
+		client:              openai.NewClientWithConfig(clientConfig),
+		provider:            "azure-openai",
+		model:               deployment,
+		maxCompletionTokens: config.MaxCompletionTokens,
+		temperature:         config.Temperature,
+		DBConfigs:           config.DBConfigs,
+	}, nil
+}
+
+// NewOpenAICompatibleClient creates a client of a server implementing the OpenAI chat completions API, e.g. vLLM, LM Studio, Together or Groq.
+// The API key is optional as local servers usually have none.
+func NewOpenAICompatibleClient(config Config) (*OpenAIClient, error) {
+	if config.BaseURL == "" {
+		return nil, fmt.Errorf("OpenAI-compatible base URL is required")
+	}
+	if config.Model == "" {
+		return nil, fmt.Errorf("OpenAI-compatible model is required")
+	}
+	switch config.ResponseFormat {
+	case "", ResponseFormatJSONSchema, ResponseFormatJSONObject:
+	default:
+		return nil, fmt.Errorf("unsupported OpenAI-compatible response format: %s", config.ResponseFormat)
+	}
+
+	clientConfig := openai.DefaultConfig(config.APIKey)
+	clientConfig.BaseURL = strings.TrimRight(config.BaseURL, "/")
+
+	return &OpenAIClient{
+		client:              openai.NewClientWithConfig(clientConfig),
+		provider:            "openai-compatible",
+		model:               config.Model,
+		maxCompletionTokens: config.MaxCompletionTokens,
+		temperature:         config.Temperature,
+		DBConfigs:           config.DBConfigs,
+		responseFormat:      config.ResponseFormat,
+		disableTools:        config.DisableTools,
+		legacyMaxTokens:     true,
+	}, nil
+}
+
 func (c *OpenAIClient) GenerateResponse(ctx context.Context, messages []*models.LLMMessage, dbType string) (string, error) {
 	// Check if the context is cancelled
 	if ctx.Err() != nil {
 		return "", ctx.Err()
 	}
 
+	req := c.buildRequest(ctx, messages, dbType)
+
+	// Call OpenAI API
+	resp, err := c.createCompletion(ctx, req)
+	if err != nil {
+		log.Printf("GenerateResponse -> err: %v", err)
+		return "", fmt.Errorf("OpenAI API error: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no response from OpenAI")
+	}
+
+	log.Printf("OPENAI -> GenerateResponse -> resp: %v", resp)
+	// Validate response against schema
+	var llmResponse constants.LLMResponse
+	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &llmResponse); err != nil {
+		return "", &InvalidResponseError{Response: resp.Choices[0].Message.Content, Err: err}
+	}
+
+	return resp.Choices[0].Message.Content, nil
+}
+
+// createCompletion lets the model call the tools of the context until it answers, the completion answering is returned.
+// Once the rounds of calls are used up, the model has to answer without tools.
+func (c *OpenAIClient) createCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	executor, maxRounds, ok := c.enabledTools(ctx)
+	if !ok {
+		resp, err := c.client.CreateChatCompletion(ctx, req)
+		if err == nil {
+			recordUsage(ctx, c.GetModelInfo(), resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+		}
+		return resp, err
+	}
+
+	req.Messages[0].Content += toolsPrompt
+	for _, tool := range executor.Tools() {
+		req.Tools = append(req.Tools, openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		})
+	}
+
+	for round := 1; ; round++ {
+		resp, err := c.client.CreateChatCompletion(ctx, req)
+		if err != nil {
+			return resp, err
+		}
+		recordUsage(ctx, c.GetModelInfo(), resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+		if len(resp.Choices) == 0 || len(resp.Choices[0].Message.ToolCalls) == 0 {
+			return resp, nil
+		}
+
+		// The results of the calls follow the message calling them, the model then calls more tools or answers
+		message := resp.Choices[0].Message
+		req.Messages = append(req.Messages, message)
+		for _, call := range message.ToolCalls {
+			req.Messages = append(req.Messages, openai.ChatCompletionMessage{
+				Role:       openai.ChatMessageRoleTool,
+				ToolCallID: call.ID,
+				Content:    runTool(ctx, executor, call.Function.Name, call.Function.Arguments),
+			})
+		}
+		if round >= maxRounds {
+			req.ToolChoice = "none"
+		}
+	}
+}
+
+// enabledTools returns the tools of the context, none when the server can't call them
+func (c *OpenAIClient) enabledTools(ctx context.Context) (ToolExecutor, int, bool) {
+	if c.disableTools {
+		return nil, 0, false
+	}
+	return toolsFor(ctx)
+}
+
+// buildRequest converts the messages to a completion request answering with the JSON schema of the database type
+func (c *OpenAIClient) buildRequest(ctx context.Context, messages []*models.LLMMessage, dbType string) openai.ChatCompletionRequest {
 	// Convert messages to OpenAI format
 	openAIMessages := make([]openai.ChatCompletionMessage, 0, len(messages))
 
@@ -58,6 +214,7 @@ func (c *OpenAIClient) GenerateResponse(ctx context.Context, messages []*models.
 			break
 		}
 	}
+	systemPrompt = systemPromptFor(ctx, c.provider, dbType, systemPrompt)
 
 	// Add system message with database-specific prompt only
 	openAIMessages = append(openAIMessages, openai.ChatCompletionMessage{
@@ -82,6 +239,21 @@ func (c *OpenAIClient) GenerateResponse(ctx context.Context, messages []*models.
 			if schemaUpdate, ok := msg.Content["schema_update"].(string); ok {
 				content = fmt.Sprintf("Database schema update:\n%s", schemaUpdate)
 			}
+			if liveActivity, ok := msg.Content["live_activity"].(string); ok {
+				content = fmt.Sprintf("Current database activity, use it to explain what is happening on the database right now (reference the actual statements, sessions & locks):\n%s", liveActivity)
+			}
+			if serverFeatures, ok := msg.Content["server_features"].(string); ok {
+				content = fmt.Sprintf("Database server capabilities, generated queries must only use syntax this version supports:\n%s", serverFeatures)
+			}
+			if relevantTables, ok := msg.Content["relevant_tables"].(string); ok {
+				content = fmt.Sprintf("Tables the previous queries of this chat used the most, most relevant first. Prefer them when the request doesn't name its tables, the rest of the schema remains available:\n%s", relevantTables)
+			}
+			if summary, ok := msg.Content["conversation_summary"].(string); ok {
+				content = fmt.Sprintf("Summary of the earlier messages of this chat, they were summarized to fit the context:\n%s", summary)
+			}
+			if queryExamples, ok := msg.Content["query_examples"].(string); ok {
+				content = fmt.Sprintf("Examples of questions & the queries answering them on this database, written by the user. Follow their conventions (tables, joins, filters) when they match the request:\n%s", queryExamples)
+			}
 		}
 
 		if content != "" {
@@ -92,12 +264,15 @@ func (c *OpenAIClient) GenerateResponse(ctx context.Context, messages []*models.
 		}
 	}
 
+	// The chat may override the sampling, OpenAI accepts temperatures up to 2
+	sampling := samplingFor(ctx, c.temperature, c.maxCompletionTokens, 2)
+
 	// Create completion request with JSON schema
 	req := openai.ChatCompletionRequest{
 		Model:               c.model,
 		Messages:            openAIMessages,
-		MaxCompletionTokens: c.maxCompletionTokens,
-		Temperature:         float32(c.temperature),
+		MaxCompletionTokens: sampling.maxTokens,
+		Temperature:         float32(sampling.temperature),
 		ResponseFormat: &openai.ChatCompletionResponseFormat{
 			Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
 			JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
@@ -108,37 +283,85 @@ func (c *OpenAIClient) GenerateResponse(ctx context.Context, messages []*models.
 			},
 		},
 	}
+	if c.responseFormat == ResponseFormatJSONObject {
+		// The server only guarantees valid JSON, the schema is given in the prompt instead
+		req.ResponseFormat = &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
+		req.Messages[0].Content += "\n\nRespond with a JSON object matching this JSON schema:\n" + responseSchema
+	}
+	if c.legacyMaxTokens {
+		req.MaxTokens = req.MaxCompletionTokens
+		req.MaxCompletionTokens = 0
+	}
+	if sampling.topP != nil {
+		req.TopP = float32(*sampling.topP)
+	}
+	return req
+}
 
+// GenerateResponseStream streams the completion, the JSON response is validated once complete as by GenerateResponse
+func (c *OpenAIClient) GenerateResponseStream(ctx context.Context, messages []*models.LLMMessage, dbType string, onChunk func(chunk string)) (string, error) {
 	// Check if the context is cancelled
 	if ctx.Err() != nil {
 		return "", ctx.Err()
 	}
 
-	// Call OpenAI API
-	resp, err := c.client.CreateChatCompletion(ctx, req)
+	// The rounds of tool calls are not streamed, the assistant message is sent at once with the response
+	if _, _, ok := c.enabledTools(ctx); ok {
+		response, err := c.GenerateResponse(ctx, messages, dbType)
+		if err == nil {
+			streamAssistantMessage(response, onChunk)
+		}
+		return response, err
+	}
+
+	req := c.buildRequest(ctx, messages, dbType)
+	// The usage is sent in a last chunk without choices
+	req.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	stream, err := c.client.CreateChatCompletionStream(ctx, req)
 	if err != nil {
-		log.Printf("GenerateResponse -> err: %v", err)
-		return "", fmt.Errorf("OpenAI API error: %v", err)
+		log.Printf("GenerateResponseStream -> err: %v", err)
+		return "", fmt.Errorf("OpenAI API error: %w", err)
 	}
+	defer stream.Close()
 
-	if len(resp.Choices) == 0 {
+	streamer := newAssistantMessageStreamer(onChunk)
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			log.Printf("GenerateResponseStream -> err: %v", err)
+			return "", fmt.Errorf("OpenAI API error: %w", err)
+		}
+		if len(resp.Choices) > 0 {
+			streamer.Write(resp.Choices[0].Delta.Content)
+		}
+		if resp.Usage != nil {
+			recordUsage(ctx, c.GetModelInfo(), resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+		}
+	}
+
+	response := streamer.Response()
+	if response == "" {
 		return "", fmt.Errorf("no response from OpenAI")
 	}
 
-	log.Printf("OPENAI -> GenerateResponse -> resp: %v", resp)
+	log.Printf("OPENAI -> GenerateResponseStream -> response: %s", response)
 	// Validate response against schema
 	var llmResponse constants.LLMResponse
-	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &llmResponse); err != nil {
-		return "", fmt.Errorf("invalid response format: %v", err)
+	if err := json.Unmarshal([]byte(response), &llmResponse); err != nil {
+		return "", &InvalidResponseError{Response: response, Err: err}
 	}
 
-	return resp.Choices[0].Message.Content, nil
+	return response, nil
 }
 
 func (c *OpenAIClient) GetModelInfo() ModelInfo {
 	return ModelInfo{
 		Name:                c.model,
-		Provider:            "openai",
+		Provider:            c.provider,
 		MaxCompletionTokens: c.maxCompletionTokens,
+		ContextLimit:        constants.GetLLMContextLimit(c.model),
 	}
 }
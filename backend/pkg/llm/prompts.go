@@ -0,0 +1,29 @@
+package llm
+
+import "context"
+
+// PromptOverrides replaces the built-in system prompts, e.g. with the prompt templates edited by the operators
+type PromptOverrides interface {
+	// SystemPrompt returns the system prompt of a provider & a database type, false to keep the built-in prompt
+	SystemPrompt(provider, dbType string) (string, bool)
+}
+
+type promptOverridesContextKey struct{}
+
+// WithPromptOverrides returns a context in which the clients use the system prompts of the overrides
+func WithPromptOverrides(ctx context.Context, overrides PromptOverrides) context.Context {
+	return context.WithValue(ctx, promptOverridesContextKey{}, overrides)
+}
+
+// systemPromptFor returns the system prompt overriding the built-in one in the context, the built-in one otherwise.
+// The provider is the one of the client, a failover client calls each provider with its own prompt.
+func systemPromptFor(ctx context.Context, provider, dbType, builtInPrompt string) string {
+	overrides, ok := ctx.Value(promptOverridesContextKey{}).(PromptOverrides)
+	if !ok || overrides == nil {
+		return builtInPrompt
+	}
+	if prompt, ok := overrides.SystemPrompt(provider, dbType); ok {
+		return prompt
+	}
+	return builtInPrompt
+}
@@ -0,0 +1,47 @@
+package llm
+
+import "context"
+
+// Sampling overrides the sampling configured for the clients, e.g. a temperature of 0 for deterministic queries.
+// Nil fields keep the configuration of the client.
+type Sampling struct {
+	Temperature *float64
+	TopP        *float64
+	MaxTokens   *int
+}
+
+type samplingContextKey struct{}
+
+// WithSampling returns a context in which the clients use the sampling overrides
+func WithSampling(ctx context.Context, sampling Sampling) context.Context {
+	return context.WithValue(ctx, samplingContextKey{}, sampling)
+}
+
+// samplingParams are the sampling parameters of a call
+type samplingParams struct {
+	temperature float64
+	topP        *float64 // nil keeps the default of the provider
+	maxTokens   int
+}
+
+// samplingFor returns the sampling parameters of a call, the overrides of the context replace the configuration of the client.
+// The temperature is capped at the maximum the provider accepts.
+func samplingFor(ctx context.Context, temperature float64, maxTokens int, maxTemperature float64) samplingParams {
+	params := samplingParams{temperature: temperature, maxTokens: maxTokens}
+	sampling, ok := ctx.Value(samplingContextKey{}).(Sampling)
+	if !ok {
+		return params
+	}
+
+	if sampling.Temperature != nil {
+		params.temperature = min(max(*sampling.Temperature, 0), maxTemperature)
+	}
+	if sampling.TopP != nil {
+		topP := min(max(*sampling.TopP, 0), 1)
+		params.topP = &topP
+	}
+	if sampling.MaxTokens != nil && *sampling.MaxTokens > 0 {
+		params.maxTokens = *sampling.MaxTokens
+	}
+	return params
+}
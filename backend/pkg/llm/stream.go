@@ -0,0 +1,123 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"neobase-ai/internal/models"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// StreamingClient is implemented by the clients able to stream their response while it is generated
+type StreamingClient interface {
+	Client
+	// GenerateResponseStream returns the same response as GenerateResponse, onChunk receives the assistant message as it is generated
+	GenerateResponseStream(ctx context.Context, messages []*models.LLMMessage, dbType string, onChunk func(chunk string)) (string, error)
+}
+
+// assistantMessageKeyRegex matches the start of the assistantMessage value in the JSON response
+var assistantMessageKeyRegex = regexp.MustCompile(`"assistantMessage"\s*:\s*"`)
+
+// highSurrogateEscapeRegex matches a trailing \u escape of a high surrogate, its low surrogate follows in the next delta
+var highSurrogateEscapeRegex = regexp.MustCompile(`\\u[dD][89abAB][0-9a-fA-F]{2}$`)
+
+// assistantMessageStreamer assembles the JSON response of a stream, the assistant message is passed to onChunk as it is generated.
+// The other fields of the response (queries, action buttons) are only used once the response is complete.
+type assistantMessageStreamer struct {
+	response   strings.Builder
+	valueStart int // Index of the assistantMessage value in the response, -1 until it's generated
+	sent       int // Length of the assistant message already passed to onChunk
+	onChunk    func(chunk string)
+}
+
+func newAssistantMessageStreamer(onChunk func(chunk string)) *assistantMessageStreamer {
+	return &assistantMessageStreamer{
+		valueStart: -1,
+		onChunk:    onChunk,
+	}
+}
+
+// Write appends a delta of the JSON response, the new text of the assistant message is passed to onChunk
+func (s *assistantMessageStreamer) Write(delta string) {
+	s.response.WriteString(delta)
+	if s.onChunk == nil {
+		return
+	}
+
+	response := s.response.String()
+	if s.valueStart == -1 {
+		location := assistantMessageKeyRegex.FindStringIndex(response)
+		if location == nil {
+			return
+		}
+		s.valueStart = location[1]
+	}
+
+	message, ok := partialJSONString(response[s.valueStart:])
+	if ok && len(message) > s.sent {
+		s.onChunk(message[s.sent:])
+		s.sent = len(message)
+	}
+}
+
+// Response returns the JSON response assembled so far
+func (s *assistantMessageStreamer) Response() string {
+	return s.response.String()
+}
+
+// partialJSONString decodes a JSON string value which may not be complete yet, the opening quote is already consumed.
+// An escape sequence or a character cut by the stream is left for the next delta.
+func partialJSONString(value string) (string, bool) {
+	end := len(value)
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' {
+			i++
+			continue
+		}
+		if value[i] == '"' {
+			end = i
+			break
+		}
+	}
+	raw := value[:end]
+
+	// Drop a trailing escape sequence the stream has not completed, e.g. \ or \u00
+	if lastEscape := strings.LastIndex(raw, "\\"); lastEscape != -1 {
+		backslashes := 0
+		for i := lastEscape; i >= 0 && raw[i] == '\\'; i-- {
+			backslashes++
+		}
+		if backslashes%2 == 1 {
+			escape := raw[lastEscape:]
+			if len(escape) == 1 || (escape[1] == 'u' && len(escape) < 6) {
+				raw = raw[:lastEscape]
+			}
+		}
+	}
+
+	if location := highSurrogateEscapeRegex.FindStringIndex(raw); location != nil {
+		raw = raw[:location[0]]
+	}
+
+	// Drop a trailing multi-byte character the stream has not completed
+	for i := 1; i <= utf8.UTFMax && i <= len(raw); i++ {
+		if utf8.RuneStart(raw[len(raw)-i]) {
+			if !utf8.FullRuneInString(raw[len(raw)-i:]) {
+				raw = raw[:len(raw)-i]
+			}
+			break
+		}
+	}
+
+	var decoded string
+	if err := json.Unmarshal([]byte(`"`+raw+`"`), &decoded); err != nil {
+		return "", false
+	}
+	return decoded, true
+}
+
+// streamAssistantMessage passes the assistant message of a complete response to onChunk at once, for the responses generated without streaming
+func streamAssistantMessage(response string, onChunk func(chunk string)) {
+	newAssistantMessageStreamer(onChunk).Write(response)
+}
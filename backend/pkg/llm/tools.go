@@ -0,0 +1,70 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Characters of a tool result sent back to the model, longer results are truncated
+const maxToolResultLength = 8000
+
+// toolsPrompt is appended to the system prompt when the model can call tools
+const toolsPrompt = "\n\nBefore answering, you can call tools to ground your queries in the actual database: read the schema of the tables you are unsure about, explain a query to check it is valid & uses the indexes, or run a read-only query to look at the data (e.g. the distinct values of a column). Only call them when they help, the response must still follow the response schema."
+
+// Tool is a function the model can call before answering, e.g. to read the schema of a table
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  json.RawMessage // JSON schema of the arguments
+}
+
+// ToolExecutor runs the tools called by the model, the results are sent back to it
+type ToolExecutor interface {
+	Tools() []Tool
+	ExecuteTool(ctx context.Context, name string, arguments json.RawMessage) (string, error)
+}
+
+type toolsContextKey struct{}
+
+type toolCalling struct {
+	executor  ToolExecutor
+	maxRounds int
+}
+
+// WithTools returns a context in which the clients supporting tool calling (OpenAI, Azure OpenAI & Claude) let the model
+// call the tools of the executor, up to maxRounds rounds of calls before it must answer. The other clients answer in one shot.
+func WithTools(ctx context.Context, executor ToolExecutor, maxRounds int) context.Context {
+	if executor == nil || maxRounds <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, toolsContextKey{}, toolCalling{executor: executor, maxRounds: maxRounds})
+}
+
+// toolsFor returns the tools the model can call in a context, false when it must answer in one shot
+func toolsFor(ctx context.Context) (ToolExecutor, int, bool) {
+	calling, ok := ctx.Value(toolsContextKey{}).(toolCalling)
+	if !ok || len(calling.executor.Tools()) == 0 {
+		return nil, 0, false
+	}
+	return calling.executor, calling.maxRounds, true
+}
+
+// runTool executes a call of the model, an error is sent back as the result so the model can correct its call
+func runTool(ctx context.Context, executor ToolExecutor, name, arguments string) string {
+	if strings.TrimSpace(arguments) == "" {
+		arguments = "{}"
+	}
+	log.Printf("LLM -> runTool -> %s(%s)", name, arguments)
+
+	result, err := executor.ExecuteTool(ctx, name, json.RawMessage(arguments))
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	if len(result) > maxToolResultLength {
+		result = result[:maxToolResultLength] + "\n... (truncated)"
+	}
+	return result
+}
@@ -31,12 +31,26 @@ type ModelInfo struct {
 type Config struct {
 	Provider            string
 	Model               string
-	APIKey              string
+	APIKey              string // Access key ID for Bedrock
+	SecretKey           string // Secret access key for Bedrock
+	SessionToken        string // Session token of temporary AWS credentials
+	Region              string // AWS region for Bedrock
+	BaseURL             string // Server of self-hosted providers, e.g. Ollama, or a proxy of the Claude API
+	APIVersion          string // API version of Azure OpenAI
+	SafetyThreshold     string // Harm block threshold of Gemini: none, only_high, medium_and_above or low_and_above
+	ResponseFormat      string // Response format of OpenAI-compatible servers: json_schema or json_object
+	DisableTools        bool   // The OpenAI-compatible server can't call tools
 	MaxCompletionTokens int
 	Temperature         float64
 	DBConfigs           []LLMDBConfig
 }
 
+// Response formats of the OpenAI-compatible servers, json_object for the servers without structured outputs
+const (
+	ResponseFormatJSONSchema = "json_schema"
+	ResponseFormatJSONObject = "json_object"
+)
+
 type LLMDBConfig struct {
 	DBType       string
 	Schema       interface{}
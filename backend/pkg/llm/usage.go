@@ -0,0 +1,41 @@
+package llm
+
+import (
+	"context"
+	"sync"
+)
+
+// Usage is the number of tokens the calls of a request used, reported by the provider which answered
+type Usage struct {
+	Provider         string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	mu               sync.Mutex
+}
+
+type usageContextKey struct{}
+
+// WithUsage returns a context in which the clients add the tokens of their calls to the usage
+func WithUsage(ctx context.Context, usage *Usage) context.Context {
+	return context.WithValue(ctx, usageContextKey{}, usage)
+}
+
+// recordUsage adds the tokens of a call to the metrics & to the usage of the context, if any
+func recordUsage(ctx context.Context, info ModelInfo, promptTokens, completionTokens int) {
+	metrics.recordTokens(info, promptTokens, completionTokens)
+
+	usage, ok := ctx.Value(usageContextKey{}).(*Usage)
+	if !ok || usage == nil {
+		return
+	}
+
+	usage.mu.Lock()
+	defer usage.mu.Unlock()
+
+	// A failover may call several providers, the one which answered last is kept
+	usage.Provider = info.Provider
+	usage.Model = info.Name
+	usage.PromptTokens += promptTokens
+	usage.CompletionTokens += completionTokens
+}
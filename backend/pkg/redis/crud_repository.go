@@ -20,6 +20,7 @@ type IRedisRepositories interface {
 	Hset(key string, data string, expireAt time.Time, ctx context.Context) error
 	Get(key string, ctx context.Context) (string, error)
 	Del(key string, ctx context.Context) error
+	DelByPattern(pattern string, ctx context.Context) error
 	GetAllByField(ctx context.Context, modelType interface{}, filterFunc func(interface{}) bool) ([]interface{}, error)
 	TTL(key string, ctx context.Context) (time.Duration, error)
 	StartPipeline(ctx context.Context) *Pipeline
@@ -76,6 +77,30 @@ func (r *RedisRepositories) Del(key string, ctx context.Context) error {
 	return nil
 }
 
+// DelByPattern deletes the keys matching a glob pattern, e.g. prefix:*
+func (r *RedisRepositories) DelByPattern(pattern string, ctx context.Context) error {
+	var cursor uint64
+	for {
+		keys, nextCursor, err := r.Client.Scan(ctx, cursor, pattern, 100).Result()
+		if err != nil {
+			log.Printf("Error scanning Redis keys: %v", err)
+			return err
+		}
+		if len(keys) > 0 {
+			if err := r.Client.Del(ctx, keys...).Err(); err != nil {
+				log.Printf("Error deleting Redis keys: %v", err)
+				return err
+			}
+		}
+		if nextCursor == 0 {
+			break
+		}
+		cursor = nextCursor
+	}
+	log.Printf("Successfully deleted Redis keys matching: %s", pattern)
+	return nil
+}
+
 // GetAllByField fetches all records and filters them using a custom filter function
 func (r *RedisRepositories) GetAllByField(ctx context.Context, modelType interface{}, filterFunc func(interface{}) bool) ([]interface{}, error) {
 	var results []interface{}
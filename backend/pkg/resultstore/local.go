@@ -0,0 +1,110 @@
+package resultstore
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalDownloadPath is the route of the server downloading the files of a local store, followed by the key of the file
+const LocalDownloadPath = "/api/results/"
+
+// LocalStore keeps the files in a directory, the server sends them to the holders of a link signed with its key
+type LocalStore struct {
+	dir        string
+	publicURL  string
+	signingKey []byte
+}
+
+func NewLocalStore(dir, publicURL, signingKey string) (*LocalStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("the directory of the result files is required")
+	}
+	if signingKey == "" {
+		return nil, fmt.Errorf("the signing key of the result links is required")
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create the directory of the result files: %v", err)
+	}
+	return &LocalStore{dir: dir, publicURL: strings.TrimSuffix(publicURL, "/"), signingKey: []byte(signingKey)}, nil
+}
+
+func (s *LocalStore) Put(ctx context.Context, key string, file *os.File, contentType string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+		return err
+	}
+
+	// The file is written aside & renamed so that a link never reads a partial file
+	partial := target + ".partial"
+	out, err := os.OpenFile(partial, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o640)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, file); err != nil {
+		out.Close()
+		os.Remove(partial)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(partial)
+		return err
+	}
+	return os.Rename(partial, target)
+}
+
+func (s *LocalStore) SignedURL(key string, ttl time.Duration) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", s.signature(key, expires))
+	return s.publicURL + LocalDownloadPath + key + "?" + query.Encode(), nil
+}
+
+// Open returns the path of the file of a signed link, ErrInvalidLink or ErrLinkExpired when the link can't be used
+func (s *LocalStore) Open(key, expires, signature string) (string, error) {
+	if !hmac.Equal([]byte(signature), []byte(s.signature(key, expires))) {
+		return "", ErrInvalidLink
+	}
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return "", ErrInvalidLink
+	}
+	if time.Now().Unix() > expiresAt {
+		return "", ErrLinkExpired
+	}
+	return s.path(key)
+}
+
+// path returns the file of a key, the keys are relative slash separated paths that can't leave the directory
+func (s *LocalStore) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." {
+		return "", fmt.Errorf("invalid key %s", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+func (s *LocalStore) signature(key, expires string) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
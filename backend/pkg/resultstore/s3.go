@@ -0,0 +1,104 @@
+package resultstore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"neobase-ai/pkg/sigv4"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// S3Store keeps the files in an S3 bucket or a bucket of an S3-compatible service, the requests are signed with AWS Signature
+// Version 4 & the links are presigned URLs
+type S3Store struct {
+	endpoint    *url.URL // nil for AWS, whose buckets are addressed by host
+	bucket      string
+	region      string
+	credentials sigv4.Credentials
+	httpClient  *http.Client
+}
+
+func NewS3Store(endpoint, bucket, region, accessKeyID, secretAccessKey, sessionToken string) (*S3Store, error) {
+	if bucket == "" {
+		return nil, fmt.Errorf("the bucket of the result files is required")
+	}
+	if region == "" {
+		return nil, fmt.Errorf("the region of the bucket is required")
+	}
+	if accessKeyID == "" || secretAccessKey == "" {
+		return nil, fmt.Errorf("the access key of the bucket is required")
+	}
+
+	store := &S3Store{
+		bucket:      bucket,
+		region:      region,
+		credentials: sigv4.Credentials{AccessKeyID: accessKeyID, SecretAccessKey: secretAccessKey, SessionToken: sessionToken},
+		httpClient:  &http.Client{Timeout: 30 * time.Minute},
+	}
+	if endpoint != "" {
+		parsed, err := url.Parse(endpoint)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return nil, fmt.Errorf("invalid endpoint %s", endpoint)
+		}
+		store.endpoint = parsed
+	}
+	return store, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, file *os.File, contentType string) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key).String(), file)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = info.Size()
+	req.Header.Set("Content-Type", contentType)
+	// The upload is streamed from its file, its body isn't signed
+	req.Header.Set("X-Amz-Content-Sha256", sigv4.UnsignedPayload)
+	sigv4.SignRequest(req, sigv4.UnsignedPayload, s.credentials, s.region, "s3", time.Now())
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("upload of %s failed with status %d: %s", key, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func (s *S3Store) SignedURL(key string, ttl time.Duration) (string, error) {
+	if ttl > MaxLinkTTL {
+		ttl = MaxLinkTTL
+	}
+	objectURL := s.objectURL(key)
+	// The file is saved rather than shown by the browser
+	objectURL.RawQuery = url.Values{"response-content-disposition": {fmt.Sprintf("attachment; filename=%q", path.Base(key))}}.Encode()
+
+	signed := sigv4.PresignURL(http.MethodGet, objectURL, sigv4.UnsignedPayload, s.credentials, s.region, "s3", ttl, time.Now())
+	return signed.String(), nil
+}
+
+// objectURL returns the URL of an object, by the host of the bucket on AWS & by its path on the other services
+func (s *S3Store) objectURL(key string) *url.URL {
+	if s.endpoint == nil {
+		return &url.URL{Scheme: "https", Host: fmt.Sprintf("%s.s3.%s.amazonaws.com", s.bucket, s.region), Path: "/" + key}
+	}
+	objectURL := *s.endpoint
+	objectURL.Path = strings.TrimSuffix(objectURL.Path, "/") + "/" + s.bucket + "/" + key
+	return &objectURL
+}
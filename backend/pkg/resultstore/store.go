@@ -0,0 +1,82 @@
+package resultstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Storages of the result files
+const (
+	StorageLocal = "local" // A directory of the server, the files are downloaded from the server with a signed link
+	StorageS3    = "s3"    // An S3 bucket or an S3-compatible one, e.g. MinIO
+	StorageGCS   = "gcs"   // A GCS bucket through its S3-compatible API, with HMAC keys
+)
+
+// MaxLinkTTL is the longest a signed link stays valid, the limit of the S3 presigned URLs
+const MaxLinkTTL = 7 * 24 * time.Hour
+
+var (
+	// ErrInvalidLink is returned for the local links whose signature doesn't match their file & expiry
+	ErrInvalidLink = errors.New("invalid link")
+	// ErrLinkExpired is returned for the local links past their expiry
+	ErrLinkExpired = errors.New("link expired")
+)
+
+// Store keeps the files of the results too large to be stored with their query, the files are downloaded with signed links
+type Store interface {
+	// Put uploads the content of file as the object key, file is read from its start
+	Put(ctx context.Context, key string, file *os.File, contentType string) error
+	// SignedURL returns a link downloading the object key without credentials until it expires
+	SignedURL(key string, ttl time.Duration) (string, error)
+}
+
+// Config is where the result files are stored
+type Config struct {
+	Storage string // local, s3 or gcs
+
+	// Local storage, the links point to the download route of the server at PublicURL & are signed with SigningKey
+	LocalDir   string
+	PublicURL  string
+	SigningKey string
+
+	// S3 & GCS storage, Endpoint is set for the S3-compatible services & defaults to the one of GCS
+	Bucket          string
+	Region          string
+	Endpoint        string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// New returns the store of the configuration, nil without a storage
+func New(config Config) (Store, error) {
+	var store Store
+	var err error
+	switch strings.ToLower(config.Storage) {
+	case "":
+		return nil, nil
+	case StorageLocal:
+		store, err = NewLocalStore(config.LocalDir, config.PublicURL, config.SigningKey)
+	case StorageS3:
+		store, err = NewS3Store(config.Endpoint, config.Bucket, config.Region, config.AccessKeyID, config.SecretAccessKey, config.SessionToken)
+	case StorageGCS:
+		endpoint, region := config.Endpoint, config.Region
+		if endpoint == "" {
+			endpoint = "https://storage.googleapis.com"
+		}
+		if region == "" {
+			region = "auto"
+		}
+		store, err = NewS3Store(endpoint, config.Bucket, region, config.AccessKeyID, config.SecretAccessKey, "")
+	default:
+		return nil, fmt.Errorf("unknown storage %s, expected local, s3 or gcs", config.Storage)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return store, nil
+}
@@ -0,0 +1,192 @@
+// Package sigv4 signs requests to AWS & S3-compatible services with AWS Signature Version 4, in the Authorization header or
+// in the query of a presigned URL.
+package sigv4
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	algorithm  = "AWS4-HMAC-SHA256"
+	timeFormat = "20060102T150405Z"
+
+	// UnsignedPayload is the payload hash of the requests whose body isn't signed, e.g. streamed uploads & S3 presigned URLs
+	UnsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// Credentials are the access key of an IAM user or role signing the requests
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // Temporary credentials only
+}
+
+// SignRequest signs a request in its Authorization header. Every header set so far is signed with the host, payloadHash is
+// the hex SHA-256 of the body (see PayloadHash) or UnsignedPayload.
+func SignRequest(req *http.Request, payloadHash string, credentials Credentials, region, service string, now time.Time) {
+	amzDate := now.UTC().Format(timeFormat)
+	scope := credentialScope(amzDate, region, service)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = canonicalHeaderValue(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	query, _ := url.ParseQuery(req.URL.RawQuery)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL, service),
+		CanonicalQuery(query),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		algorithm, credentials.AccessKeyID, scope, signedHeaders, signature(credentials, amzDate, region, service, canonicalRequest)))
+}
+
+// PresignURL returns the URL signed in its query, valid for expires. Only the host is signed, the parameters of the URL are kept
+// & signed with the X-Amz-* ones.
+func PresignURL(method string, target *url.URL, payloadHash string, credentials Credentials, region, service string, expires time.Duration, now time.Time) *url.URL {
+	amzDate := now.UTC().Format(timeFormat)
+	scope := credentialScope(amzDate, region, service)
+
+	query := target.Query()
+	query.Set("X-Amz-Algorithm", algorithm)
+	query.Set("X-Amz-Credential", credentials.AccessKeyID+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+	if credentials.SessionToken != "" {
+		query.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+
+	canonicalQuery := CanonicalQuery(query)
+	canonicalRequest := strings.Join([]string{
+		method,
+		canonicalURI(target, service),
+		canonicalQuery,
+		"host:" + target.Host + "\n",
+		"host",
+		payloadHash,
+	}, "\n")
+
+	signed := *target
+	signed.RawQuery = canonicalQuery + "&X-Amz-Signature=" + signature(credentials, amzDate, region, service, canonicalRequest)
+	return &signed
+}
+
+// PayloadHash returns the hex SHA-256 of a body
+func PayloadHash(body []byte) string {
+	hash := sha256.Sum256(body)
+	return hex.EncodeToString(hash[:])
+}
+
+// CanonicalQuery encodes the parameters sorted by name then value, every character but the unreserved ones encoded
+func CanonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	params := []string{}
+	for _, name := range names {
+		values := append([]string{}, query[name]...)
+		sort.Strings(values)
+		for _, value := range values {
+			params = append(params, URIEncode(name, true)+"="+URIEncode(value, true))
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// URIEncode encodes every character but the unreserved ones, slashes are kept unless encodeSlash is set
+func URIEncode(value string, encodeSlash bool) string {
+	var encoded strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9'), c == '-', c == '_', c == '.', c == '~':
+			encoded.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			encoded.WriteByte(c)
+		default:
+			fmt.Fprintf(&encoded, "%%%02X", c)
+		}
+	}
+	return encoded.String()
+}
+
+// canonicalURI encodes the path of a URL, S3 encodes it once & the other services encode the escaped path again
+func canonicalURI(target *url.URL, service string) string {
+	if service == "s3" {
+		path := target.Path
+		if path == "" {
+			path = "/"
+		}
+		return URIEncode(path, false)
+	}
+	return URIEncode(target.EscapedPath(), false)
+}
+
+// canonicalHeaderValue trims a header value & collapses its runs of spaces
+func canonicalHeaderValue(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
+
+func credentialScope(amzDate, region, service string) string {
+	return fmt.Sprintf("%s/%s/%s/aws4_request", amzDate[:8], region, service)
+}
+
+// signature signs a canonical request with the key derived from the secret for the date, region & service
+func signature(credentials Credentials, amzDate, region, service, canonicalRequest string) string {
+	stringToSign := strings.Join([]string{
+		algorithm,
+		amzDate,
+		credentialScope(amzDate, region, service),
+		PayloadHash([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+credentials.SecretAccessKey), amzDate[:8])
+	signingKey = hmacSHA256(signingKey, region)
+	signingKey = hmacSHA256(signingKey, service)
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,310 @@
+// Package xlsx writes Office Open XML spreadsheets as a stream, the rows are written to the output as they are added so
+// the size of a workbook isn't bound by the memory. Only typed values & bold headers are supported.
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Limits of Excel
+const (
+	MaxRows            = 1048576
+	MaxColumns         = 16384
+	maxCellLength      = 32767
+	maxSheetNameLength = 31
+	maxExactInteger    = 999999999999999 // Excel keeps 15 significant digits, larger integers are written as text
+)
+
+// Styles of the cells, indexes of the cellXfs of styles.xml
+const (
+	styleDefault  = 0
+	styleHeader   = 1
+	styleDateTime = 2
+	styleDate     = 3
+)
+
+var (
+	ErrTooManyRows    = errors.New("the sheet is limited to 1048576 rows")
+	ErrTooManyColumns = errors.New("the sheet is limited to 16384 columns")
+	ErrNoSheet        = errors.New("no sheet was added")
+)
+
+// excelEpoch is the day 0 of the serial dates, before the 1900 leap year bug of Excel
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+var sheetNameReplacer = strings.NewReplacer("[", "(", "]", ")", ":", "-", "*", "-", "?", "", "/", "-", `\`, "-")
+
+// Writer writes a workbook sheet by sheet, a sheet can't be changed once the next one is added
+type Writer struct {
+	zip    *zip.Writer
+	sheets []string
+	sheet  *bufio.Writer // nil until the first sheet is added
+	rows   int           // rows of the current sheet
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{zip: zip.NewWriter(w)}
+}
+
+// AddSheet ends the current sheet & starts a new one, the rows written next are added to it.
+// The characters Excel doesn't allow in the name are replaced & it is cut to 31 characters.
+func (w *Writer) AddSheet(name string) error {
+	name = sheetNameReplacer.Replace(strings.TrimSpace(name))
+	if utf8.RuneCountInString(name) > maxSheetNameLength {
+		name = string([]rune(name)[:maxSheetNameLength])
+	}
+	if name == "" {
+		name = fmt.Sprintf("Sheet%d", len(w.sheets)+1)
+	}
+	for _, existing := range w.sheets {
+		if strings.EqualFold(existing, name) {
+			return fmt.Errorf("sheet %s already exists", name)
+		}
+	}
+
+	if err := w.endSheet(); err != nil {
+		return err
+	}
+	w.sheets = append(w.sheets, name)
+	entry, err := w.zip.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", len(w.sheets)))
+	if err != nil {
+		return err
+	}
+	w.sheet = bufio.NewWriter(entry)
+	w.rows = 0
+	_, err = w.sheet.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return err
+}
+
+// WriteHeader adds a row of bold names to the current sheet
+func (w *Writer) WriteHeader(names []string) error {
+	values := make([]interface{}, len(names))
+	for i, name := range names {
+		values[i] = name
+	}
+	return w.writeRow(values, styleHeader)
+}
+
+// WriteRow adds a row to the current sheet. Numbers, booleans & times are typed cells, nil is an empty cell & the other
+// values are written as text.
+func (w *Writer) WriteRow(values []interface{}) error {
+	return w.writeRow(values, styleDefault)
+}
+
+// Flush sends the rows written so far to the output
+func (w *Writer) Flush() error {
+	if w.sheet == nil {
+		return nil
+	}
+	return w.sheet.Flush()
+}
+
+// Close ends the current sheet & writes the parts describing the workbook, it doesn't close the output
+func (w *Writer) Close() error {
+	if len(w.sheets) == 0 {
+		return ErrNoSheet
+	}
+	if err := w.endSheet(); err != nil {
+		return err
+	}
+
+	var contentTypes, workbook, workbookRels strings.Builder
+	contentTypes.WriteString(xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	workbook.WriteString(xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	workbookRels.WriteString(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i, name := range w.sheets {
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escape(name), i+1, i+1)
+		fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	contentTypes.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets></workbook>`)
+	fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`, len(w.sheets)+1)
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", contentTypes.String()},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"xl/workbook.xml", workbook.String()},
+		{"xl/_rels/workbook.xml.rels", workbookRels.String()},
+		{"xl/styles.xml", stylesXML},
+	}
+	for _, part := range parts {
+		entry, err := w.zip.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(entry, part.content); err != nil {
+			return err
+		}
+	}
+	return w.zip.Close()
+}
+
+func (w *Writer) endSheet() error {
+	if w.sheet == nil {
+		return nil
+	}
+	if _, err := w.sheet.WriteString(`</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	err := w.sheet.Flush()
+	w.sheet = nil
+	return err
+}
+
+func (w *Writer) writeRow(values []interface{}, style int) error {
+	if w.sheet == nil {
+		return ErrNoSheet
+	}
+	if w.rows >= MaxRows {
+		return ErrTooManyRows
+	}
+	if len(values) > MaxColumns {
+		return ErrTooManyColumns
+	}
+	w.rows++
+
+	var row strings.Builder
+	fmt.Fprintf(&row, `<row r="%d">`, w.rows)
+	for i, value := range values {
+		writeCell(&row, columnName(i)+strconv.Itoa(w.rows), value, style)
+	}
+	row.WriteString(`</row>`)
+	_, err := w.sheet.WriteString(row.String())
+	return err
+}
+
+func writeCell(row *strings.Builder, ref string, value interface{}, style int) {
+	switch v := value.(type) {
+	case nil:
+		return
+	case bool:
+		b := 0
+		if v {
+			b = 1
+		}
+		fmt.Fprintf(row, `<c r="%s" s="%d" t="b"><v>%d</v></c>`, ref, style, b)
+		return
+	case int:
+		value = int64(v)
+	case int8:
+		value = int64(v)
+	case int16:
+		value = int64(v)
+	case int32:
+		value = int64(v)
+	case uint8:
+		value = int64(v)
+	case uint16:
+		value = int64(v)
+	case uint32:
+		value = int64(v)
+	case uint:
+		if uint64(v) <= maxExactInteger {
+			value = int64(v)
+		}
+	case uint64:
+		if v <= maxExactInteger {
+			value = int64(v)
+		}
+	case float32:
+		value = float64(v)
+	}
+
+	switch v := value.(type) {
+	case int64:
+		if v >= -maxExactInteger && v <= maxExactInteger {
+			fmt.Fprintf(row, `<c r="%s" s="%d"><v>%d</v></c>`, ref, style, v)
+			return
+		}
+	case float64:
+		if !math.IsNaN(v) && !math.IsInf(v, 0) {
+			fmt.Fprintf(row, `<c r="%s" s="%d"><v>%s</v></c>`, ref, style, strconv.FormatFloat(v, 'g', -1, 64))
+			return
+		}
+	case time.Time:
+		if serial, dateOnly, ok := serialDate(v); ok {
+			if style == styleDefault {
+				style = styleDateTime
+				if dateOnly {
+					style = styleDate
+				}
+			}
+			fmt.Fprintf(row, `<c r="%s" s="%d"><v>%s</v></c>`, ref, style, strconv.FormatFloat(serial, 'f', -1, 64))
+			return
+		}
+		value = v.Format(time.RFC3339Nano)
+	}
+
+	text, ok := value.(string)
+	if !ok {
+		text = fmt.Sprint(value)
+	}
+	if utf8.RuneCountInString(text) > maxCellLength {
+		text = string([]rune(text)[:maxCellLength])
+	}
+	fmt.Fprintf(row, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, style, escape(text))
+}
+
+// serialDate returns the days since the Excel epoch of the wall clock of a time, the dates before 1900-03-01 are written as text
+func serialDate(t time.Time) (float64, bool, bool) {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	if wall.Before(time.Date(1900, 3, 1, 0, 0, 0, 0, time.UTC)) || wall.Year() > 9999 {
+		return 0, false, false
+	}
+	seconds := wall.Unix() - excelEpoch.Unix()
+	serial := float64(seconds)/86400 + float64(wall.Nanosecond())/(86400*1e9)
+	dateOnly := seconds%86400 == 0 && wall.Nanosecond() == 0
+	return serial, dateOnly, true
+}
+
+// columnName returns the letters of a column index, e.g. 0 is A & 27 is AB
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// escape escapes the XML special characters, the characters XML doesn't allow are replaced by U+FFFD
+func escape(text string) string {
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(text))
+	return escaped.String()
+}
+
+const stylesXML = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="2"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm:ss"/><numFmt numFmtId="165" formatCode="yyyy-mm-dd"/></numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="4">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
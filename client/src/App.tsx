@@ -1,11 +1,11 @@
 import axios from 'axios';
 import { EventSourcePolyfill } from 'event-source-polyfill';
 import { Boxes } from 'lucide-react';
-import { useCallback, useEffect, useState } from 'react';
+import { useCallback, useEffect, useRef, useState } from 'react';
 import toast, { Toaster } from 'react-hot-toast';
 import AuthForm from './components/auth/AuthForm';
 import ChatWindow from './components/chat/ChatWindow';
-import { Message, QueryResult, LoadingStep } from './components/chat/types';
+import { ClarificationAnswer, Message, QueryResult, LoadingStep } from './components/chat/types';
 import StarUsButton from './components/common/StarUsButton';
 import SuccessBanner from './components/common/SuccessBanner';
 import Sidebar from './components/dashboard/Sidebar';
@@ -41,6 +41,8 @@ function AppContent() {
   const { streamId, setStreamId, generateStreamId } = useStream();
   const [isMessageSending, setIsMessageSending] = useState(false);
   const [temporaryMessage, setTemporaryMessage] = useState<Message | null>(null);
+  // Set once the assistant message was streamed by ai-response-chunk events, the final response is then not typed again
+  const streamedContentRef = useRef(false);
   const { user, setUser } = useUser();
   const [refreshSchemaController, setRefreshSchemaController] = useState<AbortController | null>(null);
   const [isSSEReconnecting, setIsSSEReconnecting] = useState(false);
@@ -768,7 +770,7 @@ function AppContent() {
     console.log('new stream id', streamId);
   };
 
-  const handleSendMessage = async (content: string) => {
+  const handleSendMessage = async (content: string, clarification?: ClarificationAnswer) => {
     if (!selectedConnection?.id || !streamId || isMessageSending) return;
 
     try {
@@ -789,7 +791,15 @@ function AppContent() {
 
       // Wait for 100 ms for the eventSource to be open
       await new Promise(resolve => setTimeout(resolve, 100));
-      const response = await chatService.sendMessage(selectedConnection.id, 'temp', streamId, content);
+      // An answer to a clarification is sent to its message, the clarification options are removed once answered
+      const response = clarification
+        ? await chatService.answerClarification(selectedConnection.id, clarification.messageId, clarification.buttonId, streamId)
+        : await chatService.sendMessage(selectedConnection.id, 'temp', streamId, content);
+      if (clarification && response.success) {
+        setMessages(prev => prev.map(msg => msg.id === clarification.messageId
+          ? { ...msg, action_buttons: msg.action_buttons?.filter(b => b.action !== 'clarify') }
+          : msg));
+      }
 
       // Update the chat updated_at field of the selected connection
       if (selectedConnection) {
@@ -897,9 +907,21 @@ function AppContent() {
             }
             break;
 
+          case 'ai-response-chunk':
+            // Append the chunk to the temporary message, the loading steps are hidden once it has content
+            streamedContentRef.current = true;
+            setMessages(prev => prev.map(msg =>
+              msg.is_streaming && msg.id === 'temp'
+                ? { ...msg, content: msg.content + response.data }
+                : msg
+            ));
+            break;
+
           case 'ai-response':
             if (response.data) {
               console.log('ai-response -> response.data', response.data);
+              const wasStreamed = streamedContentRef.current;
+              streamedContentRef.current = false;
 
               // Check if this is a response to an edited message
               const isEditedResponse = response.data.user_message_id && 
@@ -930,7 +952,7 @@ function AppContent() {
                       return {
                         ...msg,
                         id: msg.id, // Keep the original ID
-                        content: '', // Reset content to empty for animation
+                        content: wasStreamed ? response.data.content : '', // Reset content to empty for animation unless it was streamed
                         action_buttons: response.data.action_buttons, // Update action buttons from response
                         queries: response.data.queries?.map((q: QueryResult) => ({...q, query: ''})) || [], // Initialize queries with empty strings
                         is_loading: false,
@@ -946,7 +968,9 @@ function AppContent() {
                 });
                 
                 // Animate content
-                await animateTyping(response.data.content, existingMessage.id);
+                if (!wasStreamed) {
+                  await animateTyping(response.data.content, existingMessage.id);
+                }
                 
                 // Animate queries
                 if (response.data.queries && response.data.queries.length > 0) {
@@ -974,7 +998,7 @@ function AppContent() {
                 const baseMessage: Message = {
                   id: response.data.id,
                   type: 'assistant' as const,
-                  content: '',
+                  content: wasStreamed ? response.data.content : '',
                   action_buttons: response.data.action_buttons,
                   queries: response.data.queries?.map((q: QueryResult) => ({...q, query: ''})) || [],
                   is_loading: false,
@@ -993,7 +1017,9 @@ function AppContent() {
                 });
 
                 // Animate content
-                await animateTyping(response.data.content, response.data.id);
+                if (!wasStreamed) {
+                  await animateTyping(response.data.content, response.data.id);
+                }
                 
                 // Animate queries
                 if (response.data.queries && response.data.queries.length > 0) {
@@ -1021,6 +1047,7 @@ function AppContent() {
             break;
 
           case 'ai-response-error':
+            streamedContentRef.current = false;
             // Show error message instead of temporary message
             setMessages(prev => {
               const withoutTemp = prev.filter(msg => !msg.is_streaming);
@@ -1039,7 +1066,18 @@ function AppContent() {
 
             break;
 
+          case 'query-result-file':
+            // The whole result of an executed query was written to a file, its download link is added to the query
+            if (response.data) {
+              setMessages(prev => prev.map(msg => msg.id === response.data.message_id ? {
+                ...msg,
+                queries: msg.queries?.map(q => q.id === response.data.query_id ? { ...q, result_file: response.data.result_file } : q)
+              } : msg));
+            }
+            break;
+
           case 'response-cancelled':
+            streamedContentRef.current = false;
             // Remove temporary streaming message
             setMessages(prev => {
               return prev.filter(msg => !(msg.is_streaming && msg.id === 'temp'));
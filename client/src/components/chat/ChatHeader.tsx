@@ -1,4 +1,4 @@
-import { Eraser, ListRestart, Loader, Pencil, PlugZap, RefreshCw } from 'lucide-react';
+import { Eraser, ListRestart, Loader, Lock, Pencil, PlugZap, RefreshCw } from 'lucide-react';
 import { useCallback, useMemo } from 'react';
 import { Chat } from '../../types/chat';
 import analyticsService from '../../services/analyticsService';
@@ -13,8 +13,12 @@ interface ChatHeaderProps {
     onShowCloseConfirm: () => void;
     onReconnect: () => void;
     setShowRefreshSchema: (show: boolean) => void;
+    onStartTransaction?: () => void;
 }
 
+// Databases a transaction can be kept open on across messages
+const TRANSACTION_DATABASES = ['postgresql', 'yugabytedb', 'mysql', 'mariadb'];
+
 export default function ChatHeader({
     chat,
     isConnecting = true,
@@ -24,6 +28,7 @@ export default function ChatHeader({
     onShowCloseConfirm,
     onReconnect,
     setShowRefreshSchema,
+    onStartTransaction,
 }: ChatHeaderProps) {
     const connectionStatus = useMemo(() => {
         if (isConnecting) {
@@ -144,6 +149,21 @@ export default function ChatHeader({
                     </div>
                 </div>
 
+                {isConnected && onStartTransaction && TRANSACTION_DATABASES.includes(chat.connection.type) && (
+                    <div className="relative group hidden md:block">
+                        <button
+                            onClick={onStartTransaction}
+                            className="p-2 hover:bg-neo-gray rounded-lg transition-colors neo-border text-gray-800"
+                            aria-label="Start transaction"
+                        >
+                            <Lock className="w-5 h-5" />
+                        </button>
+                        <div className="absolute invisible opacity-0 group-hover:visible group-hover:opacity-100 transition-opacity duration-200 bottom-[-35px] left-1/2 transform -translate-x-1/2 bg-black text-white text-xs py-1 px-2 rounded whitespace-nowrap z-50 before:content-[''] before:absolute before:top-[-5px] before:left-1/2 before:transform before:-translate-x-1/2 before:border-[5px] before:border-transparent before:border-b-black">
+                            Start transaction
+                        </div>
+                    </div>
+                )}
+
                 {isConnected ? (
                     <div className="relative group hidden md:block">
                         <button
@@ -12,14 +12,14 @@ import ConnectionModal from '../modals/ConnectionModal';
 import ChatHeader from './ChatHeader';
 import MessageInput from './MessageInput';
 import MessageTile from './MessageTile';
-import { Message } from './types';
+import { ClarificationAnswer, Message } from './types';
 import { ChatSettings } from '../../types/chat';
 interface ChatWindowProps {
   chat: Chat;
   isExpanded: boolean;
   messages: Message[];
   setMessages: React.Dispatch<React.SetStateAction<Message[]>>;
-  onSendMessage: (message: string) => Promise<void>;
+  onSendMessage: (message: string, clarification?: ClarificationAnswer) => Promise<void>;
   onEditMessage: (id: string, content: string) => void;
   onClearChat: () => void;
   onCloseConnection: () => void;
@@ -581,7 +581,8 @@ export default function ChatWindow({
                       ...q,
                       query: showEditQueryConfirm.query!,
                       is_edited: true,
-                      original_query: q.query
+                      original_query: q.query,
+                      affected_rows_preview: response.data?.data?.affected_rows_preview
                     }
                     : q
                 )
@@ -722,6 +723,31 @@ export default function ChatWindow({
       onSendMessage(fixRollbackErrorContent);
     }
 
+  // Commits or rolls back the open transaction the queries of the message ran in
+  const handleEndTransactionAction = async (commit: boolean) => {
+    try {
+      const ended = await chatService.endTransaction(chat.id, commit);
+      const undone = new Set(ended.reason === 'committed' ? [] : ended.queries.map(q => q.query_id));
+      setMessages(prev => prev.map(msg => ({
+        ...msg,
+        queries: msg.queries?.map(q => undone.has(q.id) ? { ...q, is_rolled_back: true } : q),
+        action_buttons: msg.action_buttons?.filter(b => b.action !== "commit_transaction" && b.action !== "rollback_transaction")
+      })));
+      toast.success(ended.reason === 'committed' ? 'Transaction committed' : 'Transaction rolled back');
+    } catch (error: any) {
+      toast.error(error.message);
+    }
+  };
+
+  const handleStartTransaction = async () => {
+    try {
+      await chatService.startTransaction(chat.id, streamId || generateStreamId());
+      toast.success('Transaction started, the next queries run in it until committed or rolled back');
+    } catch (error: any) {
+      toast.error(error.message);
+    }
+  };
+
   const handleConfirmClearChat = useCallback(async () => {
     // Track chat cleared event
     if (chat?.id) {
@@ -788,6 +814,7 @@ export default function ChatWindow({
         onShowCloseConfirm={() => setShowCloseConfirm(true)}
         onReconnect={handleReconnect}
         setShowRefreshSchema={() => setShowRefreshSchema(true)}
+        onStartTransaction={handleStartTransaction}
       />
 
       <div
@@ -873,15 +900,20 @@ export default function ChatWindow({
                   isFirstMessage={index === 0}
                   onQueryUpdate={handleQueryUpdate}
                   onEditQuery={handleEditQuery}
-                  buttonCallback={(action) => {
+                  buttonCallback={(action, button) => {
                     if (action === "refresh_schema") {
                       setShowRefreshSchema(true);
+                    } else if (action === "clarify" && button) {
+                      // Answer the clarification with the picked option, the AI resumes with the answer
+                      onSendMessage(button.label, { messageId: message.id, buttonId: button.id });
                     } else if (action === "fix_error") {
                       // Handle fix_error action
                       handleFixErrorAction(message);
                     } else if (action === "fix_rollback_error") {
                       // Handle fix_rollback_error action
                       handleFixRollbackErrorAction(message);
+                    } else if (action === "commit_transaction" || action === "rollback_transaction") {
+                      handleEndTransactionAction(action === "commit_transaction");
                     } else {
                       console.log(`Action not implemented: ${action}`);
                       toast.error(`There is no available action for this button: ${action}`);
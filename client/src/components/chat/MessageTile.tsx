@@ -7,7 +7,7 @@ import chatService from '../../services/chatService';
 import ConfirmationModal from '../modals/ConfirmationModal';
 import RollbackConfirmationModal from '../modals/RollbackConfirmationModal';
 import LoadingSteps from './LoadingSteps';
-import { Message, QueryResult } from './types';
+import { ActionButton, Message, QueryResult, ResultColumn } from './types';
 import MarkdownRenderer from './MarkdownRenderer';
 import { formatActionAt } from '../../utils/message';
 
@@ -47,7 +47,7 @@ interface MessageTileProps {
     isFirstMessage?: boolean;
     onQueryUpdate: (callback: () => void) => void;
     onEditQuery: (id: string, queryId: string, query: string) => void;
-    buttonCallback?: (action: string) => void;
+    buttonCallback?: (action: string, button?: ActionButton) => void;
 }
 
 const toastStyle = {
@@ -101,6 +101,8 @@ export default function MessageTile({
     const [viewMode, setViewMode] = useState<'table' | 'json'>('table');
     const [showCriticalConfirm, setShowCriticalConfirm] = useState(false);
     const [queryToExecute, setQueryToExecute] = useState<string | null>(null);
+    const [showExecuteAllConfirm, setShowExecuteAllConfirm] = useState(false);
+    const [isExecutingAll, setIsExecutingAll] = useState(false);
     const [rollbackState, setRollbackState] = useState<{
         show: boolean;
         queryId: string | null;
@@ -220,26 +222,8 @@ export default function MessageTile({
                     // For initial data, always show first page (25 records)
                     const pageData = resultArray.slice(0, 25);
 
-                    // Cache both pages from initial 50 records if available
-                    if (!pageDataCacheRef.current[query.id]) {
-                        pageDataCacheRef.current[query.id] = {};
-                    }
-
-                    if (resultArray.length > 0) {
-                        // Cache first page (1-25)
-                        pageDataCacheRef.current[query.id][1] = {
-                            data: resultArray.slice(0, 25),
-                            totalRecords
-                        };
-
-                        // Cache second page (26-50) if it exists
-                        if (resultArray.length > 25) {
-                            pageDataCacheRef.current[query.id][2] = {
-                                data: resultArray.slice(25, 50),
-                                totalRecords
-                            };
-                        }
-                    }
+                    // Cache the pages of the initial records, their number depends on the rows shown of the connection
+                    cachePages(query.id, resultArray, 1, 0, totalRecords);
 
                     initialStates[query.id] = {
                         data: pageData,
@@ -314,21 +298,10 @@ export default function MessageTile({
                 const totalRecords = response.data.total_records_count;
 
                 // Slice the data into pages of 25
-                const pageData = sliceIntoPages(fullData, DEFAULT_PAGE_SIZE, 1);
-
-                // Cache both pages from the API response
-                const basePage = Math.floor((1 - 1) / 2) * 2 + 1;
-                const firstHalf = sliceIntoPages(fullData, DEFAULT_PAGE_SIZE, 1);
-                const secondHalf = sliceIntoPages(fullData, DEFAULT_PAGE_SIZE, 2);
+                const pageData = fullData.slice(0, DEFAULT_PAGE_SIZE);
 
-                pageDataCacheRef.current[queryId][basePage] = {
-                    data: firstHalf,
-                    totalRecords
-                };
-                pageDataCacheRef.current[queryId][basePage + 1] = {
-                    data: secondHalf,
-                    totalRecords
-                };
+                // Cache the pages of the API response
+                cachePages(queryId, fullData, 1, 0, totalRecords || fullData.length);
                 onQueryUpdate(() => {
                     if (!response.data.execution_result) {
                         console.log('response.data.execution_result is empty', response.data.execution_result);
@@ -344,6 +317,9 @@ export default function MessageTile({
                                 Object.keys(response.data.execution_result).length === 0) 
                                 ? null 
                                 : response.data.execution_result,
+                            result_columns: response.data.columns,
+                            // The file of the previous execution is replaced when the new one is written
+                            result_file: undefined,
                             execution_time: response.data.execution_time,
                             action_at: response.data.action_at,
                             error: response.data.error,
@@ -394,6 +370,81 @@ export default function MessageTile({
         }
     };
 
+    // The queries of the message not executed yet, run together by the Execute All button
+    const pendingQueries = (message.queries || []).filter(q => !q.is_executed || q.is_rolled_back);
+
+    const handleExecuteAllQueries = () => {
+        if (pendingQueries.some(q => q.is_critical)) {
+            setShowExecuteAllConfirm(true);
+            return;
+        }
+        executeAllQueries();
+    };
+
+    const executeAllQueries = async () => {
+        const queryIds = pendingQueries.map(q => q.id);
+        const controller = new AbortController();
+        queryIds.forEach(queryId => {
+            abortControllerRef.current[queryId] = controller;
+        });
+        setIsExecutingAll(true);
+        onQueryUpdate(() => {
+            setQueryStates(prev => ({
+                ...prev,
+                ...Object.fromEntries(queryIds.map(queryId => [queryId, { isExecuting: true, isExample: false }]))
+            }));
+        });
+
+        try {
+            await checkSSEConnection();
+            const response = await chatService.executeAllQueries(chatId, message.id, streamId || '', controller);
+            if (response?.success) {
+                const executions = Object.fromEntries(response.data.queries.map(execution => [execution.query_id, execution]));
+                onQueryUpdate(() => {
+                    setMessage({
+                        ...message,
+                        // The rolled back & skipped queries are left as they were, to be executed again
+                        queries: message.queries?.map(q => executions[q.id]?.is_executed ? {
+                            ...q,
+                            is_executed: true,
+                            is_rolled_back: false,
+                            execution_result: executions[q.id].execution_result ?? null,
+                            result_columns: executions[q.id].columns,
+                            execution_time: executions[q.id].execution_time,
+                            action_at: executions[q.id].action_at,
+                            error: executions[q.id].error,
+                        } : q),
+                        action_buttons: response.data.action_buttons || []
+                    });
+                });
+
+                if (response.data.success) {
+                    toast('All queries executed!', {
+                        ...toastStyle,
+                        icon: '✅',
+                    });
+                } else {
+                    toast.error('A query failed, none of the queries were applied', toastStyle);
+                }
+            }
+        } catch (error: any) {
+            if (error.name !== 'AbortError') {
+                toast.error("Queries execution failed: " + error.message);
+            }
+        } finally {
+            setIsExecutingAll(false);
+            onQueryUpdate(() => {
+                setQueryStates(prev => ({
+                    ...prev,
+                    ...Object.fromEntries(queryIds.map(queryId => [queryId, { isExecuting: false, isExample: false }]))
+                }));
+            });
+            queryIds.forEach(queryId => {
+                delete abortControllerRef.current[queryId];
+            });
+        }
+    };
+
     const handleRollback = async (queryId: string) => {
         const queryIndex = message.queries?.findIndex(q => q.id === queryId) ?? -1;
         if (queryIndex === -1) return;
@@ -831,12 +882,20 @@ export default function MessageTile({
         return <span>{String(value)}</span>;
     };
 
-    const renderTableView = (data: any[]) => {
+    const renderTableView = (data: any[], resultColumns?: ResultColumn[]) => {
         if (!data || data.length === 0) {
             return <div className="text-gray-500">No data to display</div>;
         }
 
-        const columns = Object.keys(data[0]);
+        // The columns keep the order of the result when it was described, the fields of the rows come after
+        const columns = Array.from(new Set([
+            ...(resultColumns || []).map(column => column.name),
+            ...Object.keys(data[0]),
+        ]));
+        const columnTypes = Object.fromEntries((resultColumns || []).map(column => [
+            column.name,
+            `${column.database_type}${column.nullable ? ', nullable' : ''}`,
+        ]));
         
         // Detect date columns
         const dateColumnList = columns.filter(column => {
@@ -855,7 +914,7 @@ export default function MessageTile({
                     <thead>
                         <tr>
                             {columns.map(column => (
-                                <th key={column} className="py-2 px-4 bg-gray-800 border-b border-gray-700 text-gray-300 font-mono">
+                                <th key={column} title={columnTypes[column]} className="py-2 px-4 bg-gray-800 border-b border-gray-700 text-gray-300 font-mono">
                                     <div className="flex items-center">
                                         <span>{column}</span>
                                         {dateColumnList.includes(column) && (
@@ -935,7 +994,7 @@ export default function MessageTile({
 
                         {viewMode === 'table' ? (
                             currentPageData.length > 0 ? (
-                                renderTableView(currentPageData)
+                                renderTableView(currentPageData, query.result_columns)
                             ) : (
                                 <div className="text-gray-500">No data to display</div>
                             )
@@ -1003,9 +1062,18 @@ export default function MessageTile({
         );
     };
 
-    const sliceIntoPages = (data: any[], pageSize: number, currentPage: number): any[] => {
-        const startIndex = (currentPage % 2 === 1) ? 0 : pageSize;
-        return data.slice(startIndex, startIndex + pageSize);
+    // Caches the rows read from offset as the pages starting at firstPage, a partial page only when it ends the result
+    const cachePages = (queryId: string, rows: any[], firstPage: number, offset: number, totalRecords: number) => {
+        if (!pageDataCacheRef.current[queryId]) {
+            pageDataCacheRef.current[queryId] = {};
+        }
+        for (let start = 0; start < rows.length; start += DEFAULT_PAGE_SIZE) {
+            const data = rows.slice(start, start + DEFAULT_PAGE_SIZE);
+            if (data.length < DEFAULT_PAGE_SIZE && offset + rows.length < totalRecords) {
+                break;
+            }
+            pageDataCacheRef.current[queryId][firstPage + start / DEFAULT_PAGE_SIZE] = { data, totalRecords };
+        }
     };
 
     const handlePageChange = useCallback(async (queryId: string, page: number) => {
@@ -1026,10 +1094,12 @@ export default function MessageTile({
         }));
 
         try {
-            // Wrap state updates in preserveScroll
-            if (newOffset < 50 && query.execution_result) {
-                const resultArray = parseResults(query.execution_result);
-                const totalRecords = query.pagination?.total_records_count || resultArray.length;
+            // Wrap state updates in preserveScroll, the pages within the initial records are sliced from them
+            const initialRows = parseResults(query.execution_result);
+            const initialTotal = query.pagination?.total_records_count || initialRows.length;
+            if (query.execution_result && (newOffset + state.pageSize <= initialRows.length || initialRows.length >= initialTotal)) {
+                const resultArray = initialRows;
+                const totalRecords = initialTotal;
 
                 // Calculate the slice for current page
                 const startIndex = (page - 1) * state.pageSize;
@@ -1076,13 +1146,20 @@ export default function MessageTile({
                 return;
             }
 
-            // For remote pagination - fetch new pages
-            const apiPage = Math.ceil(page / 2); // Convert UI page to API page (each API call gets 50 records)
+            // For remote pagination - fetch a page of the server's page size starting at this page, it may hold the next ones too
+
+            // The next page is read after the key of the last row seen when the query has a keyset column, deep offsets are slow
+            const keysetColumn = query.pagination?.keyset_column;
+            const lastRow = newOffset <= initialRows.length
+                ? initialRows[newOffset - 1]
+                : pageDataCacheRef.current[queryId][page - 1]?.data?.[state.pageSize - 1];
+
             const response = await axios.post(`${import.meta.env.VITE_API_URL}/chats/${chatId}/queries/results`, {
                 message_id: message.id,
                 query_id: queryId,
                 stream_id: streamId,
-                offset: (apiPage - 1) * 50 // Adjust offset to fetch 50 records at a time
+                offset: newOffset,
+                after: keysetColumn && lastRow ? lastRow[keysetColumn] : undefined
             });
 
             // Get the results array from the response
@@ -1091,21 +1168,10 @@ export default function MessageTile({
             const totalRecords = responseData.total_records_count;
 
             // Slice the data into pages of 25
-            const pageData = sliceIntoPages(fullData, state.pageSize, page % 2);
-
-            // Cache both pages from the API response
-            const basePage = Math.floor((page - 1) / 2) * 2 + 1;
-            const firstHalf = sliceIntoPages(fullData, state.pageSize, 1);
-            const secondHalf = sliceIntoPages(fullData, state.pageSize, 2);
-
-            pageDataCacheRef.current[queryId][basePage] = {
-                data: firstHalf,
-                totalRecords
-            };
-            pageDataCacheRef.current[queryId][basePage + 1] = {
-                data: secondHalf,
-                totalRecords
-            };
+            const pageData = fullData.slice(0, state.pageSize);
+
+            // Cache the pages of the API response
+            cachePages(queryId, fullData, page, newOffset, totalRecords || newOffset + fullData.length);
 
             // Wrap the final state update
             onQueryUpdate(() => {
@@ -1422,6 +1488,20 @@ export default function MessageTile({
                             </code>
                         </pre>
                     )}
+                    {query.affected_rows_preview && !query.is_executed && !query.is_rolled_back && !queryState.isExecuting && (
+                        <div className="px-4 pb-4 border-t border-gray-700 pt-4">
+                            <span className="flex items-center gap-2 text-yellow-300">
+                                <AlertCircle className="w-4 h-4" />
+                                This will affect {query.affected_rows_preview.count} {query.affected_rows_preview.count === 1 ? 'row' : 'rows'}
+                                {query.affected_rows_preview.sample.length > 0 ? ', sample:' : ''}
+                            </span>
+                            {query.affected_rows_preview.sample.length > 0 && (
+                                <pre className="mt-2 text-xs text-gray-300 overflow-x-auto whitespace-pre-wrap break-words">
+                                    {JSON.stringify(query.affected_rows_preview.sample, null, 2)}
+                                </pre>
+                            )}
+                        </div>
+                    )}
                     {(query.execution_result || query.example_result || query.error || queryState.isExecuting) && (
                         <div className="border-t border-gray-700 mt-2 w-full">
                             {queryState.isExecuting ? (
@@ -1559,6 +1639,19 @@ export default function MessageTile({
                                                                 >
                                                                     Export as JSON
                                                                 </button>
+                                                                {query.result_file && (
+                                                                    <button 
+                                                                        onClick={(e) => {
+                                                                            e.preventDefault();
+                                                                            e.stopPropagation();
+                                                                            handleDownloadResultFile(query.id);
+                                                                            setOpenDownloadMenu(null);
+                                                                        }}
+                                                                        className="block w-full text-left px-4 py-2 text-sm text-white hover:bg-gray-700 border-t border-gray-700"
+                                                                    >
+                                                                        Download full result ({query.result_file.rows} rows, JSON Lines)
+                                                                    </button>
+                                                                )}
                                                             </div>
                                                         </div>
                                                     )}
@@ -1752,6 +1845,26 @@ export default function MessageTile({
         return uniqueSentences.join(' ');
     };
 
+    // Downloads the file the whole result was written to, with the link of the execution until it expires
+    const handleDownloadResultFile = async (queryId: string) => {
+        const query = message.queries?.find(q => q.id === queryId);
+        if (!query?.result_file) return;
+
+        try {
+            let url = query.result_file.url;
+            const expiresAt = query.result_file.expires_at ? new Date(query.result_file.expires_at).getTime() : 0;
+            if (!url || expiresAt <= Date.now()) {
+                const response = await chatService.getQueryResultFile(chatId, message.id, queryId);
+                url = response.data.url;
+            }
+            if (url) {
+                window.open(url, '_blank');
+            }
+        } catch (error: any) {
+            toast.error(error.message, toastStyle);
+        }
+    };
+
     // Add this function to handle data export
     const handleExportData = (queryId: string, format: 'csv' | 'xlsx' | 'json') => {
         const query = message.queries?.find(q => q.id === queryId);
@@ -2060,6 +2173,20 @@ export default function MessageTile({
                                             })}
                                         </div>
                                     )}
+
+                                    {!message.is_streaming && pendingQueries.length > 1 && (
+                                        <div className="flex flex-wrap gap-3 mt-4">
+                                            <button
+                                                onClick={handleExecuteAllQueries}
+                                                disabled={isExecutingAll}
+                                                className="neo-button flex items-center gap-2"
+                                                title="Execute the queries in order in one transaction, a failing query rolls back the ones before it"
+                                            >
+                                                {isExecutingAll ? <Loader className="w-4 h-4 animate-spin" /> : <Play className="w-4 h-4" />}
+                                                Execute All ({pendingQueries.length})
+                                            </button>
+                                        </div>
+                                    )}
                                     
                                     {message.action_buttons && message.action_buttons.length > 0 && (
                                         <div className="flex flex-wrap gap-3 mt-4">
@@ -2068,7 +2195,7 @@ export default function MessageTile({
                                                     key={button.id}
                                                     onClick={() => {
                                                         if (buttonCallback) {
-                                                            buttonCallback(button.action);
+                                                            buttonCallback(button.action, button);
                                                         } else {
                                                             console.log(`Action button clicked: ${button.action}`);
                                                         }
@@ -2102,6 +2229,18 @@ export default function MessageTile({
                 />
             )}
 
+            {showExecuteAllConfirm && (
+                <ConfirmationModal
+                    title="Critical Queries"
+                    message="Some of these queries may affect important data. Are you sure you want to execute them all?"
+                    onConfirm={async () => {
+                        setShowExecuteAllConfirm(false);
+                        executeAllQueries();
+                    }}
+                    onCancel={() => setShowExecuteAllConfirm(false)}
+                />
+            )}
+
             {showCriticalConfirm && (
                 <ConfirmationModal
                     title="Critical Query"
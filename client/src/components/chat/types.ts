@@ -4,12 +4,15 @@ export interface QueryResult {
     pagination?: {
         total_records_count?: number;
         paginated_query?: string;
+        keyset_column?: string;
     };
     description: string;
     execution_time?: number | null;
     example_execution_time?: number | null;
     example_result?: any[] | null;
     execution_result?: any[] | null;
+    result_columns?: ResultColumn[];
+    result_file?: ResultFile;
     is_executed?: boolean;
     is_rolled_back?: boolean;
     error?: {
@@ -18,6 +21,10 @@ export interface QueryResult {
         details?: string;
     };
     is_critical?: boolean;
+    affected_rows_preview?: {
+        count: number;
+        sample: any[];
+    };
     can_rollback?: boolean;
     rollback_query?: string;
     rollback_dependent_query?: string;
@@ -26,6 +33,23 @@ export interface QueryResult {
     action_at?: string;
 }
 
+// The file the whole result was written to when too large for the chat, the execution result is its preview.
+// The url is only set right after the execution, a new link is got from the result-file route.
+export interface ResultFile {
+    url?: string;
+    expires_at?: string;
+    format: string;
+    rows: number;
+    size_bytes: number;
+}
+
+// A column of the rows of an execution result, in the order of the result
+export interface ResultColumn {
+    name: string;
+    database_type: string;
+    nullable?: boolean;
+}
+
 export interface ActionButton {
     id: string;
     label: string;
@@ -33,6 +57,12 @@ export interface ActionButton {
     isPrimary: boolean;
 }
 
+// The option picked to answer the clarification question of an assistant message
+export interface ClarificationAnswer {
+    messageId: string;
+    buttonId: string;
+}
+
 export interface Message {
     id: string;
     type: 'user' | 'assistant';
@@ -1,5 +1,5 @@
 import { Chat, Connection, TablesResponse, ChatSettings } from '../types/chat';
-import { ExecuteQueryResponse, MessagesResponse, SendMessageResponse } from '../types/messages';
+import { ChartRequest, ChartResponse, ExecuteAllQueriesResponse, ExecuteQueryResponse, MessagesResponse, ResultFileResponse, SendMessageResponse } from '../types/messages';
 import axios from './axiosConfig';
 
 const API_URL = import.meta.env.VITE_API_URL;
@@ -9,6 +9,19 @@ interface CreateChatResponse {
     data: Chat;
 }
 
+// How the open transaction of a chat ended & the queries it committed or undid
+export interface TransactionEnd {
+    reason: 'committed' | 'rolled_back' | 'expired' | 'disconnected' | 'failed';
+    error?: string;
+    queries: {
+        message_id: string;
+        query_id: string;
+        query: string;
+        query_type: string;
+        executed_at: string;
+    }[];
+}
+
 const chatService = {
     // Add a cache for tables
     tablesCache: {} as Record<string, {tables: any[], timestamp: number}>,
@@ -173,6 +186,28 @@ const chatService = {
             throw new Error(error.response?.data?.error || 'Failed to send message');
         }
     },
+    async answerClarification(chatId: string, messageId: string, buttonId: string, streamId: string): Promise<SendMessageResponse> {
+        try {
+            const response = await axios.post<SendMessageResponse>(
+                `${API_URL}/chats/${chatId}/messages/${messageId}/clarify`,
+                {
+                    stream_id: streamId,
+                    button_id: buttonId
+                },
+                {
+                    withCredentials: true,
+                    headers: {
+                        'Content-Type': 'application/json',
+                        'Authorization': `Bearer ${localStorage.getItem('token')}`
+                    }
+                }
+            );
+            return response.data
+        } catch (error: any) {
+            console.error('Answer clarification error:', error);
+            throw new Error(error.response?.data?.error || 'Failed to answer clarification');
+        }
+    },
     async cancelStream(chatId: string, streamId: string): Promise<void> {
         try {
             await axios.post(
@@ -220,6 +255,33 @@ const chatService = {
         }
     },
 
+    async executeAllQueries(chatId: string, messageId: string, streamId: string, controller: AbortController): Promise<ExecuteAllQueriesResponse | undefined> {
+        try {
+            const response = await axios.post<ExecuteAllQueriesResponse>(
+                `${API_URL}/chats/${chatId}/queries/execute-all`,
+                {
+                    message_id: messageId,
+                    stream_id: streamId
+                },
+                {
+                    signal: controller.signal,
+                    withCredentials: true,
+                    headers: {
+                        'Content-Type': 'application/json',
+                        'Authorization': `Bearer ${localStorage.getItem('token')}`
+                    }
+                }
+            );
+            return response.data;
+        } catch (error: any) {
+            if (error.name === 'CanceledError' || error.name === 'AbortError') {
+                return undefined;
+            }
+            console.error('Execute all queries error:', error);
+            throw new Error(error.response?.data?.error || 'Failed to execute the queries');
+        }
+    },
+
     async rollbackQuery(chatId: string, messageId: string, queryId: string, streamId: string, controller: AbortController): Promise<ExecuteQueryResponse | undefined> {
         try {
             const response = await axios.post<ExecuteQueryResponse>(`${API_URL}/chats/${chatId}/queries/rollback`, {
@@ -246,6 +308,44 @@ const chatService = {
         }
     },
 
+    async startTransaction(chatId: string, streamId: string): Promise<void> {
+        try {
+            await axios.post(`${API_URL}/chats/${chatId}/transaction`, {
+                stream_id: streamId
+            },
+                {
+                    withCredentials: true,
+                    headers: {
+                        'Content-Type': 'application/json',
+                        'Authorization': `Bearer ${localStorage.getItem('token')}`
+                    }
+                }
+            );
+        } catch (error: any) {
+            console.error('Start transaction error:', error);
+            throw new Error(error.response?.data?.error || 'Failed to start transaction');
+        }
+    },
+
+    async endTransaction(chatId: string, commit: boolean): Promise<TransactionEnd> {
+        try {
+            const response = await axios.post<{ success: boolean; data: TransactionEnd }>(
+                `${API_URL}/chats/${chatId}/transaction/${commit ? 'commit' : 'rollback'}`,
+                {},
+                {
+                    withCredentials: true,
+                    headers: {
+                        'Authorization': `Bearer ${localStorage.getItem('token')}`
+                    }
+                }
+            );
+            return response.data.data;
+        } catch (error: any) {
+            console.error('End transaction error:', error);
+            throw new Error(error.response?.data?.error || `Failed to ${commit ? 'commit' : 'rollback'} transaction`);
+        }
+    },
+
     async refreshSchema(chatId: string, controller: AbortController): Promise<boolean> {
         try {
             const response = await axios.post(`${API_URL}/chats/${chatId}/refresh-schema`, {
@@ -291,6 +391,42 @@ const chatService = {
         }
     },
 
+    async getChartSeries(chatId: string, messageId: string, queryId: string, chart: ChartRequest): Promise<ChartResponse> {
+        try {
+            const response = await axios.post<ChartResponse>(
+                `${API_URL}/chats/${chatId}/queries/chart`,
+                {
+                    message_id: messageId,
+                    query_id: queryId,
+                    ...chart
+                },
+                {
+                    withCredentials: true,
+                }
+            );
+            return response.data;
+        } catch (error: any) {
+            console.error('Chart query results error:', error);
+            throw new Error(error.response?.data?.error || 'Failed to chart the results');
+        }
+    },
+
+    async getQueryResultFile(chatId: string, messageId: string, queryId: string): Promise<ResultFileResponse> {
+        try {
+            const response = await axios.get<ResultFileResponse>(
+                `${API_URL}/chats/${chatId}/queries/result-file`,
+                {
+                    params: { message_id: messageId, query_id: queryId },
+                    withCredentials: true,
+                }
+            );
+            return response.data;
+        } catch (error: any) {
+            console.error('Get query result file error:', error);
+            throw new Error(error.response?.data?.error || 'Failed to get the result file');
+        }
+    },
+
     async updateSelectedCollections(chatId: string, selectedCollections: string): Promise<Chat> {
         try {
             const response = await axios.patch<CreateChatResponse>(
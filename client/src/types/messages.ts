@@ -1,4 +1,4 @@
-import { ActionButton, Message } from "../components/chat/types";
+import { ActionButton, Message, ResultColumn, ResultFile } from "../components/chat/types";
 
 
 // Update MessagesResponse to use BackendMessage instead of Message
@@ -37,11 +37,14 @@ export interface BackendMessage {
         };
         example_result: any[];
         execution_result: any[];
+        result_columns?: ResultColumn[];
+        result_file?: ResultFile;
         action_at?: string;
         query_type: string;
         pagination?: {
             total_records_count?: number;
             paginated_query?: string;
+            keyset_column?: string;
         };
         tables: string;
         rollback_query?: string;
@@ -88,6 +91,8 @@ export interface ExecuteQueryResponse {
         query_id: string;
         execution_time?: number;
         execution_result?: any[];
+        columns?: ResultColumn[];
+        result_file_pending?: boolean; // The whole result is being written to a file, its link comes in a query-result-file event
         total_records_count: number;
         is_rolled_back: boolean;
         is_executed: boolean;
@@ -99,4 +104,65 @@ export interface ExecuteQueryResponse {
         action_buttons?: ActionButton[];
         action_at?: string;
     };
+}
+
+// The queries of a message executed together in one transaction, the first failing query rolls back the ones before it
+export interface ResultFileResponse {
+    success: boolean;
+    data: ResultFile;
+}
+
+export interface ExecuteAllQueriesResponse {
+    success: boolean;
+    data: {
+        chat_id: string;
+        message_id: string;
+        success: boolean;
+        queries: {
+            query_id: string;
+            status: 'succeeded' | 'failed' | 'rolled_back' | 'skipped';
+            is_executed: boolean;
+            execution_time?: number;
+            execution_result?: any;
+            columns?: ResultColumn[];
+            error?: {
+                code: string;
+                message: string;
+                details?: string;
+            };
+            action_at?: string;
+        }[];
+        action_buttons?: ActionButton[];
+    };
+}
+
+// How the result of a query is grouped & aggregated into chart series, server-side
+export interface ChartRequest {
+    x_column: string;
+    y_column?: string;
+    series_column?: string;
+    aggregate?: 'count' | 'sum' | 'avg' | 'min' | 'max';
+    time_bucket?: 'minute' | 'hour' | 'day' | 'week' | 'month' | 'year';
+    max_points?: number;
+    use_primary?: boolean;
+}
+
+export interface ChartResponse {
+    success: boolean;
+    data: {
+        chat_id: string;
+        message_id: string;
+        query_id: string;
+        x_column: string;
+        y_column?: string;
+        aggregate: string;
+        time_bucket?: string;
+        series: {
+            name: string;
+            points: { x: string | null; y: number }[];
+        }[];
+        rows: number;
+        truncated: boolean;
+        on_read_replica: boolean;
+    };
 }
\ No newline at end of file
@@ -1,6 +1,6 @@
 export interface StreamResponse {
-    event: 'ai-response' | 'ai-response-step' | 'ai-response-error' | 'db-connected' |
+    event: 'ai-response' | 'ai-response-step' | 'ai-response-chunk' | 'ai-response-error' | 'db-connected' |
     'db-disconnected' | 'sse-connected' | 'response-cancelled' | 'query-results' |
-    'rollback-executed' | 'query-execution-failed' | 'rollback-query-failed';
+    'rollback-executed' | 'query-execution-failed' | 'rollback-query-failed' | 'query-result-file';
     data?: any;
 } 
\ No newline at end of file